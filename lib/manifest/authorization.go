@@ -0,0 +1,21 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// AuthorizationInfo declares the JWT claims that callers must have to invoke the given functions,
+// or to read the given fields of the GraphQL types, which are named as "Type.field".
+// Each requirement is either a claim name, which must be present, or "claim=value", which must match.
+// All of the requirements must be met.
+type AuthorizationInfo struct {
+	Name      string   `json:"-"`
+	Functions []string `json:"functions,omitempty"`
+	Fields    []string `json:"fields,omitempty"`
+	Requires  []string `json:"requires"`
+}
@@ -0,0 +1,18 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// BudgetInfo limits the estimated monthly spend on model invocations, in US dollars.
+// When the soft limit is reached, a warning is logged.  When the hard limit is reached, further model invocations
+// are rejected until the next calendar month (UTC).  Limits that are zero or omitted are not enforced.
+type BudgetInfo struct {
+	SoftLimit float64 `json:"softLimit,omitempty"`
+	HardLimit float64 `json:"hardLimit,omitempty"`
+}
@@ -9,21 +9,87 @@
 
 package manifest
 
+// CollectionInfo configures a collection.  The ttl is the number of seconds items are kept after they are upserted,
+// unless an item is upserted with its own ttl.  Zero means that items are kept until they are removed.  Host is a
+// postgresql host of the manifest that stores the items and vectors of the collection, instead of the runtime's
+// database, so that the policies of that database, such as where it is hosted and how it is backed up, apply to them.
 type CollectionInfo struct {
 	SearchMethods map[string]SearchMethodInfo `json:"searchMethods"`
+	Ttl           int                         `json:"ttl,omitempty"`
+	Host          string                      `json:"host,omitempty"`
 }
 
+// The distance metrics a search method can compare vectors with.  Embedding models are trained for a metric,
+// which is usually cosine.
+const (
+	DistanceCosine    = "cosine"
+	DistanceDot       = "dot"
+	DistanceEuclidean = "euclidean"
+)
+
+// SearchMethodInfo configures how the texts of a collection are embedded and searched.  Distance is one of the
+// distance metrics, cosine when empty.  When Normalize is set, vectors are scaled to unit length when they are
+// inserted or searched, which makes the dot product equivalent to cosine similarity.  Field names a metadata field
+// whose value is embedded instead of the item's text, so that a collection can have a vector for each field of its
+// items, such as a title and a body.  Items without the field, or whose field isn't a string, embed their text.
 type SearchMethodInfo struct {
-	Embedder string    `json:"embedder"`
-	Index    IndexInfo `json:"index"`
+	Embedder     string           `json:"embedder"`
+	Field        string           `json:"field,omitempty"`
+	Index        IndexInfo        `json:"index"`
+	Distance     string           `json:"distance,omitempty"`
+	Normalize    bool             `json:"normalize,omitempty"`
+	Quantization QuantizationInfo `json:"quantization,omitempty"`
+}
+
+// The ways vectors can be quantized.  Int8 quantization stores a byte per dimension, using 4x less memory,
+// and product quantization stores a byte per subvector, using 16x less memory with the default subvectors.
+const (
+	QuantizationInt8    = "int8"
+	QuantizationProduct = "pq"
+)
+
+// QuantizationInfo compresses the vectors of a search method in memory, when its type is set.  Searches rank the
+// compressed vectors, and then re-rank the best of them against the full-precision vectors in the database.
+// Rerank is the number of candidates re-ranked for each result, 4 by default.  Subvectors is the number of
+// subvectors of product quantization, a quarter of the dimensions by default.
+type QuantizationInfo struct {
+	Type       string `json:"type,omitempty"`
+	Subvectors int    `json:"subvectors,omitempty"`
+	Rerank     int    `json:"rerank,omitempty"`
 }
 
+// GetDistance returns the distance metric of the search method, cosine if none is set.
+func (s SearchMethodInfo) GetDistance() string {
+	if s.Distance == "" {
+		return DistanceCosine
+	}
+	return s.Distance
+}
+
+// The index types that keep vectors in an external vector database, instead of in the runtime's memory.
+const (
+	IndexTypePgvector = "pgvector"
+	IndexTypeQdrant   = "qdrant"
+	IndexTypePinecone = "pinecone"
+)
+
+// IndexInfo configures the index of a search method.  For the external index types, Host is the manifest host
+// of the vector database, which is a postgresql host for pgvector and an http host for Qdrant and Pinecone.
+// Name is the pgvector table, Qdrant collection, or Pinecone namespace prefix that the vectors are stored in,
+// which is derived from the names of the collection and search method when empty.
 type IndexInfo struct {
 	Type    string      `json:"type"`
 	Options OptionsInfo `json:"options"`
+	Host    string      `json:"host,omitempty"`
+	Name    string      `json:"name,omitempty"`
 }
 
+// OptionsInfo tunes an HNSW index.  M is the maximum number of neighbors of each vector, and efConstruction and
+// efSearch are the number of candidates considered when adding vectors and when searching.  Higher values improve
+// recall at the expense of memory and speed.  Zero values use the runtime's defaults.
 type OptionsInfo struct {
+	M              int `json:"m,omitempty"`
 	EfConstruction int `json:"efConstruction"`
+	EfSearch       int `json:"efSearch,omitempty"`
 	MaxLevels      int `json:"maxLevels"`
 }
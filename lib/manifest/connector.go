@@ -0,0 +1,30 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// ConnectorInfo declares a GraphQL field that is served by calling an HTTP endpoint
+// and mapping the response, without requiring any function code.
+type ConnectorInfo struct {
+	Name        string                        `json:"-"`
+	Description string                        `json:"description,omitempty"`
+	Host        string                        `json:"host"`
+	Method      string                        `json:"method,omitempty"`
+	Path        string                        `json:"path,omitempty"`
+	Body        string                        `json:"body,omitempty"`
+	Arguments   map[string]string             `json:"arguments,omitempty"`
+	ReturnType  string                        `json:"returnType,omitempty"`
+	Select      string                        `json:"select,omitempty"`
+	Fields      map[string]ConnectorFieldInfo `json:"fields,omitempty"`
+}
+
+type ConnectorFieldInfo struct {
+	Type   string `json:"type"`
+	Select string `json:"select"`
+}
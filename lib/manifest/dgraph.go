@@ -13,6 +13,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"net"
 )
 
 const (
@@ -24,6 +25,10 @@ type DgraphHostInfo struct {
 	Type       string `json:"type"`
 	GrpcTarget string `json:"grpcTarget"`
 	Key        string `json:"key"`
+
+	// TLS sets whether connections use TLS.  If it isn't set, TLS is used unless the
+	// gRPC target is a loopback address, such as localhost or 127.0.0.1.
+	TLS *bool `json:"tls,omitempty"`
 }
 
 func (p DgraphHostInfo) HostName() string {
@@ -34,6 +39,27 @@ func (DgraphHostInfo) HostType() string {
 	return HostTypeDgraph
 }
 
+// UseTLS reports whether connections to the host use TLS.  Connections with a key always do,
+// since the key must not be sent in plain text.
+func (h DgraphHostInfo) UseTLS() bool {
+	if h.Key != "" {
+		return true
+	}
+	if h.TLS != nil {
+		return *h.TLS
+	}
+
+	host, _, err := net.SplitHostPort(h.GrpcTarget)
+	if err != nil {
+		host = h.GrpcTarget
+	}
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
 func (h DgraphHostInfo) GetVariables() []string {
 	return extractVariables(h.Key)
 }
@@ -0,0 +1,27 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// GraphQLInfo configures the GraphQL endpoint of the runtime.
+type GraphQLInfo struct {
+	// Introspection can be set to false to disable introspection outside of the development environment,
+	// except for requests from admins.  Introspection is always enabled in development.
+	Introspection *bool `json:"introspection,omitempty"`
+
+	// TrustedDocuments names a JSON file, stored alongside the plugin, of the only operations that may be executed
+	// outside of the development environment, except by admins.  Clients can send the ID of an operation
+	// instead of its document.
+	TrustedDocuments string `json:"trustedDocuments,omitempty"`
+}
+
+// IntrospectionEnabled returns whether introspection is enabled outside of the development environment.
+func (g *GraphQLInfo) IntrospectionEnabled() bool {
+	return g == nil || g.Introspection == nil || *g.Introspection
+}
@@ -0,0 +1,21 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// GuardInfo declares a condition that is checked before the given functions are invoked.
+// The condition is a CEL expression over the function's arguments and the caller's JWT claims.
+// When it evaluates to false, the invocation is rejected, or rerouted to another function.
+type GuardInfo struct {
+	Name      string   `json:"-"`
+	Functions []string `json:"functions"`
+	Condition string   `json:"condition"`
+	Message   string   `json:"message,omitempty"`
+	Reroute   string   `json:"reroute,omitempty"`
+}
@@ -31,6 +31,27 @@ type HTTPHostInfo struct {
 	BaseURL         string            `json:"baseURL"`
 	Headers         map[string]string `json:"headers"`
 	QueryParameters map[string]string `json:"queryParameters"`
+	RateLimit       *RateLimitInfo    `json:"rateLimit,omitempty"`
+	Cache           *HttpCacheInfo    `json:"cache,omitempty"`
+	Auth            *HttpAuthInfo     `json:"auth,omitempty"`
+}
+
+type RateLimitInfo struct {
+	RequestsPerSecond float64 `json:"rps,omitempty"`
+	Burst             int     `json:"burst,omitempty"`
+	Concurrency       int     `json:"concurrency,omitempty"`
+}
+
+const (
+	HttpCacheStoreMemory = "memory"
+	HttpCacheStoreRedis  = "redis"
+)
+
+// HttpCacheInfo enables caching of responses from the host, according to their Cache-Control headers.
+type HttpCacheInfo struct {
+	Store    string `json:"store,omitempty"`
+	RedisUrl string `json:"redisUrl,omitempty"`
+	MaxTtl   int    `json:"maxTtl,omitempty"`
 }
 
 func (h HTTPHostInfo) HostName() string {
@@ -41,6 +62,22 @@ func (h HTTPHostInfo) HostType() string {
 	return HostTypeHTTP
 }
 
+const (
+	HttpAuthGoogleServiceAccount = "google-service-account"
+	HttpAuthAzureAD              = "azure-ad"
+)
+
+// HttpAuthInfo configures the host to authenticate requests with OAuth 2.0 access tokens,
+// which the runtime obtains and refreshes using the given credentials.
+// For Azure AD, the credentials are the client secret of the application with the given tenant and client ids.
+type HttpAuthInfo struct {
+	Type        string   `json:"type"`
+	Credentials string   `json:"credentials"`
+	Scopes      []string `json:"scopes,omitempty"`
+	TenantId    string   `json:"tenantId,omitempty"`
+	ClientId    string   `json:"clientId,omitempty"`
+}
+
 func (h HTTPHostInfo) GetVariables() []string {
 	cap := 2 * (len(h.Headers) + len(h.QueryParameters))
 	set := make(map[string]bool, cap)
@@ -66,6 +103,26 @@ func (h HTTPHostInfo) GetVariables() []string {
 		}
 	}
 
+	if h.Cache != nil {
+		for _, v := range extractVariables(h.Cache.RedisUrl) {
+			if _, ok := set[v]; !ok {
+				set[v] = true
+				results = append(results, v)
+			}
+		}
+	}
+
+	if h.Auth != nil {
+		for _, s := range []string{h.Auth.Credentials, h.Auth.TenantId, h.Auth.ClientId} {
+			for _, v := range extractVariables(s) {
+				if _, ok := set[v]; !ok {
+					set[v] = true
+					results = append(results, v)
+				}
+			}
+		}
+	}
+
 	return results
 }
 
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Environment placeholders can be used in any string value of the manifest:
+//
+//	${NAME}            is replaced by the value of the NAME environment variable, which must be set
+//	${NAME:-default}   is replaced by the value of NAME, or by the default if NAME is unset or empty
+//	$${                is an escaped, literal ${
+//
+// They are distinct from the {{NAME}} placeholders of host secrets, which are left as they are.
+var envPlaceholderRegex = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+var envNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// InterpolateEnv replaces the environment placeholders in the string values of the manifest content,
+// using the lookup function to get the values of environment variables (typically os.LookupEnv).
+// It returns an error if a placeholder is malformed, or names a variable that isn't set and has no default.
+func InterpolateEnv(content []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	return interpolateEnv(content, lookup, true)
+}
+
+func interpolateEnv(content []byte, lookup func(string) (string, bool), strict bool) ([]byte, error) {
+	data, err := standardizeJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to standardize manifest: %w", err)
+	}
+
+	// Skip the round trip when there's nothing to replace.
+	if !bytes.Contains(data, []byte("${")) {
+		return data, nil
+	}
+
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize manifest: %w", err)
+	}
+
+	in := &interpolator{lookup: lookup, strict: strict, missing: make(map[string]bool)}
+	v, err = in.interpolate(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(in.missing) > 0 {
+		names := make([]string, 0, len(in.missing))
+		for name := range in.missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("the manifest uses environment variables that are not set: %s", strings.Join(names, ", "))
+	}
+
+	return json.Marshal(v)
+}
+
+type interpolator struct {
+	lookup  func(string) (string, bool)
+	strict  bool
+	missing map[string]bool
+}
+
+func (in *interpolator) interpolate(v any) (any, error) {
+	switch t := v.(type) {
+	case string:
+		return in.interpolateString(t)
+	case map[string]any:
+		for key, val := range t {
+			r, err := in.interpolate(val)
+			if err != nil {
+				return nil, err
+			}
+			t[key] = r
+		}
+	case []any:
+		for i, val := range t {
+			r, err := in.interpolate(val)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = r
+		}
+	}
+	return v, nil
+}
+
+func (in *interpolator) interpolateString(s string) (string, error) {
+	var err error
+	result := envPlaceholderRegex.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+
+		expr := match[2 : len(match)-1]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if !envNameRegex.MatchString(name) {
+			if err == nil {
+				err = fmt.Errorf("invalid environment placeholder %s in the manifest", match)
+			}
+			return match
+		}
+
+		if val, ok := in.lookup(name); ok && (val != "" || !hasDefault) {
+			return val
+		}
+		if hasDefault {
+			return def
+		}
+
+		// Placeholders that can't be resolved are errors when loading the manifest,
+		// but are left as they are when only validating it.
+		if in.strict {
+			in.missing[name] = true
+		}
+		return match
+	})
+	return result, err
+}
@@ -0,0 +1,19 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// InputLimitsInfo declares the largest function arguments that the runtime will accept.
+// Arguments that exceed a limit are rejected before they are written to the function's memory.
+// Limits that are zero or omitted use the runtime's defaults.
+type InputLimitsInfo struct {
+	MaxListItems  int `json:"maxListItems,omitempty"`
+	MaxMapEntries int `json:"maxMapEntries,omitempty"`
+	MaxDepth      int `json:"maxDepth,omitempty"`
+}
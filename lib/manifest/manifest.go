@@ -13,6 +13,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/tailscale/hujson"
@@ -36,10 +37,21 @@ type HostInfo interface {
 }
 
 type Manifest struct {
-	Version     int                       `json:"-"`
-	Models      map[string]ModelInfo      `json:"models"`
-	Hosts       map[string]HostInfo       `json:"hosts"`
-	Collections map[string]CollectionInfo `json:"collections"`
+	Version       int                            `json:"-"`
+	Models        map[string]ModelInfo           `json:"models"`
+	Hosts         map[string]HostInfo            `json:"hosts"`
+	Collections   map[string]CollectionInfo      `json:"collections"`
+	Variables     map[string]string              `json:"variables"`
+	Connectors    map[string]ConnectorInfo       `json:"connectors"`
+	Guards        map[string]GuardInfo           `json:"guards"`
+	Authorization map[string]AuthorizationInfo   `json:"authorization"`
+	RateLimits    map[string]CallerRateLimitInfo `json:"rateLimits"`
+	Plugins       map[string]PluginInfo          `json:"plugins"`
+	Transforms    map[string]TransformInfo       `json:"transforms"`
+	Prompts       map[string]PromptInfo          `json:"prompts"`
+	InputLimits   *InputLimitsInfo               `json:"inputLimits"`
+	Budget        *BudgetInfo                    `json:"budget"`
+	GraphQL       *GraphQLInfo                   `json:"graphql"`
 }
 
 func (m *Manifest) IsCurrentVersion() bool {
@@ -69,9 +81,12 @@ func ValidateManifest(content []byte) error {
 		return err
 	}
 
-	content, err = standardizeJSON(content)
+	// Environment placeholders are replaced where they can be, so that values such as URLs can be validated.
+	// Any that can't be are left as they are, since the environment the manifest is validated in isn't
+	// necessarily the one it will be loaded in.
+	content, err = interpolateEnv(content, os.LookupEnv, false)
 	if err != nil {
-		return fmt.Errorf("failed to standardize manifest: %w", err)
+		return err
 	}
 
 	var v interface{}
@@ -103,9 +118,20 @@ func ReadManifest(content []byte) (*Manifest, error) {
 
 func parseManifestJson(data []byte, manifest *Manifest) error {
 	var m struct {
-		Models      map[string]ModelInfo       `json:"models"`
-		Hosts       map[string]json.RawMessage `json:"hosts"`
-		Collections map[string]CollectionInfo  `json:"collections"`
+		Models        map[string]ModelInfo           `json:"models"`
+		Hosts         map[string]json.RawMessage     `json:"hosts"`
+		Collections   map[string]CollectionInfo      `json:"collections"`
+		Variables     map[string]string              `json:"variables"`
+		Connectors    map[string]ConnectorInfo       `json:"connectors"`
+		Guards        map[string]GuardInfo           `json:"guards"`
+		Authorization map[string]AuthorizationInfo   `json:"authorization"`
+		RateLimits    map[string]CallerRateLimitInfo `json:"rateLimits"`
+		Plugins       map[string]PluginInfo          `json:"plugins"`
+		Transforms    map[string]TransformInfo       `json:"transforms"`
+		Prompts       map[string]PromptInfo          `json:"prompts"`
+		InputLimits   *InputLimitsInfo               `json:"inputLimits"`
+		Budget        *BudgetInfo                    `json:"budget"`
+		GraphQL       *GraphQLInfo                   `json:"graphql"`
 	}
 	if err := json.Unmarshal(data, &m); err != nil {
 		return fmt.Errorf("failed to parse manifest: %w", err)
@@ -148,12 +174,66 @@ func parseManifestJson(data []byte, manifest *Manifest) error {
 			}
 			h.Name = name
 			manifest.Hosts[name] = h
+		case HostTypeNats:
+			var h NatsHostInfo
+			if err := json.Unmarshal(rawHost, &h); err != nil {
+				return fmt.Errorf("failed to parse manifest: %w", err)
+			}
+			h.Name = name
+			manifest.Hosts[name] = h
 		default:
 			return fmt.Errorf("unknown host type: [%s]", hostType.String())
 		}
 	}
 
 	manifest.Collections = m.Collections
+	manifest.Variables = m.Variables
+
+	manifest.Connectors = m.Connectors
+	for key, connector := range manifest.Connectors {
+		connector.Name = key
+		manifest.Connectors[key] = connector
+	}
+
+	manifest.Guards = m.Guards
+	for key, guard := range manifest.Guards {
+		guard.Name = key
+		manifest.Guards[key] = guard
+	}
+
+	manifest.Authorization = m.Authorization
+	for key, rule := range manifest.Authorization {
+		rule.Name = key
+		manifest.Authorization[key] = rule
+	}
+
+	manifest.RateLimits = m.RateLimits
+	for key, rule := range manifest.RateLimits {
+		rule.Name = key
+		manifest.RateLimits[key] = rule
+	}
+
+	manifest.Plugins = m.Plugins
+	for key, plugin := range manifest.Plugins {
+		plugin.Name = key
+		manifest.Plugins[key] = plugin
+	}
+
+	manifest.Transforms = m.Transforms
+	for key, transform := range manifest.Transforms {
+		transform.Name = key
+		manifest.Transforms[key] = transform
+	}
+
+	manifest.Prompts = m.Prompts
+	for key, prompt := range manifest.Prompts {
+		prompt.Name = key
+		manifest.Prompts[key] = prompt
+	}
+
+	manifest.InputLimits = m.InputLimits
+	manifest.Budget = m.Budget
+	manifest.GraphQL = m.GraphQL
 
 	return nil
 }
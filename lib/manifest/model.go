@@ -16,12 +16,68 @@ import (
 )
 
 type ModelInfo struct {
-	Name        string `json:"-"`
-	SourceModel string `json:"sourceModel"`
-	Provider    string `json:"provider"`
-	Host        string `json:"host"`
-	Path        string `json:"path"`
-	Dedicated   bool   `json:"dedicated"`
+	Name        string            `json:"-"`
+	SourceModel string            `json:"sourceModel"`
+	Provider    string            `json:"provider"`
+	Host        string            `json:"host"`
+	Path        string            `json:"path"`
+	Dedicated   bool              `json:"dedicated"`
+	Deployment  string            `json:"deployment"`
+	ApiVersion  string            `json:"apiVersion"`
+	Cache       *ModelCacheInfo   `json:"cache,omitempty"`
+	Retry       *ModelRetryInfo   `json:"retry,omitempty"`
+	Fallbacks   []string          `json:"fallbacks,omitempty"`
+	Pricing     *ModelPricingInfo `json:"pricing,omitempty"`
+	Moderation  *ModerationInfo   `json:"moderation,omitempty"`
+}
+
+const (
+	ModerationApplyInput  = "input"
+	ModerationApplyOutput = "output"
+	ModerationApplyBoth   = "both"
+
+	ModerationActionBlock = "block"
+	ModerationActionFlag  = "flag"
+)
+
+// ModerationInfo is a policy that checks the model's prompts, completions, or both.  A text violates the policy if
+// the moderation model flags it, if it contains a term of the deny list, or if it matches one of the regular expressions.
+// Violations either block the invocation, which is the default, or are flagged in the response and allowed.
+type ModerationInfo struct {
+	Model    string   `json:"model,omitempty"`
+	DenyList []string `json:"denyList,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	Apply    string   `json:"apply,omitempty"`
+	Action   string   `json:"action,omitempty"`
+}
+
+// ModelPricingInfo is the price of the model's tokens, in US dollars per million tokens.  It overrides the runtime's
+// built-in prices, and is used to estimate the spend of the model's invocations.
+type ModelPricingInfo struct {
+	InputPerMillion  float64 `json:"inputPerMillion"`
+	OutputPerMillion float64 `json:"outputPerMillion,omitempty"`
+}
+
+// ModelRetryInfo configures retries of model requests that fail with a transient error, such as a 429 or 5xx status.
+// MaxAttempts is the total number of attempts, including the first.  MaxDelay is the longest number of seconds
+// to wait before retrying.  If the provider asks for a longer delay, the request fails over to the next fallback model.
+type ModelRetryInfo struct {
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	MaxDelay    int `json:"maxDelay,omitempty"`
+}
+
+// ModelCacheInfo enables caching of the model's responses, so that identical requests don't invoke the model again.
+// The ttl is the number of seconds a response is kept.  Zero means that responses are kept until they are evicted.
+type ModelCacheInfo struct {
+	Ttl      int                `json:"ttl,omitempty"`
+	Semantic *SemanticCacheInfo `json:"semantic,omitempty"`
+}
+
+// SemanticCacheInfo enables reuse of the response to a previous request whose prompt is similar enough to the new one,
+// as measured by the cosine similarity of the prompts' embeddings from the given embedding model.
+type SemanticCacheInfo struct {
+	EmbeddingModel string  `json:"embeddingModel"`
+	Threshold      float64 `json:"threshold,omitempty"`
 }
 
 func (m ModelInfo) Hash() string {
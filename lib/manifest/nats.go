@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	HostTypeNats string = "nats"
+)
+
+type NatsHostInfo struct {
+	Name          string                 `json:"-"`
+	Type          string                 `json:"type"`
+	Url           string                 `json:"url"`
+	Token         string                 `json:"token,omitempty"`
+	Username      string                 `json:"username,omitempty"`
+	Password      string                 `json:"password,omitempty"`
+	Subscriptions []NatsSubscriptionInfo `json:"subscriptions,omitempty"`
+}
+
+type NatsSubscriptionInfo struct {
+	Subject    string `json:"subject"`
+	Function   string `json:"function"`
+	QueueGroup string `json:"queueGroup,omitempty"`
+}
+
+func (p NatsHostInfo) HostName() string {
+	return p.Name
+}
+
+func (NatsHostInfo) HostType() string {
+	return HostTypeNats
+}
+
+func (h NatsHostInfo) GetVariables() []string {
+	fields := []string{h.Url, h.Token, h.Username, h.Password}
+	set := make(map[string]bool, len(fields))
+	results := make([]string, 0, len(fields))
+
+	for _, s := range fields {
+		for _, v := range extractVariables(s) {
+			if _, ok := set[v]; !ok {
+				set[v] = true
+				results = append(results, v)
+			}
+		}
+	}
+
+	return results
+}
+
+func (h NatsHostInfo) Hash() string {
+	// Concatenate the attributes into a single string
+	data := fmt.Sprintf("%v|%v|%v|%v", h.Name, h.Type, h.Url, h.Subscriptions)
+
+	// Compute the SHA-256 hash
+	hash := sha256.Sum256([]byte(data))
+
+	// Convert the hash to a hexadecimal string
+	hashStr := hex.EncodeToString(hash[:])
+
+	return hashStr
+}
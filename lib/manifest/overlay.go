@@ -0,0 +1,82 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OverlayFileName returns the name of the overlay file for an environment, such as "hypermode.stage.json"
+// for the manifest file "hypermode.json" and the environment "stage".
+func OverlayFileName(manifestFileName, environment string) string {
+	base := strings.TrimSuffix(manifestFileName, ".json")
+	return base + "." + environment + ".json"
+}
+
+// MergeOverlay applies an overlay to the manifest content, as a JSON merge patch (RFC 7386).
+// Objects in the overlay are merged into the corresponding objects of the manifest, null values remove
+// the corresponding entries, and any other values replace them.
+func MergeOverlay(content, overlay []byte) ([]byte, error) {
+	base, err := decodeJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	patch, err := decodeJSON(overlay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest overlay: %w", err)
+	}
+
+	if _, ok := patch.(map[string]any); !ok {
+		return nil, fmt.Errorf("the manifest overlay must be a JSON object")
+	}
+
+	return json.Marshal(mergePatch(base, patch))
+}
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any, len(p))
+	}
+
+	for key, val := range p {
+		if val == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], val)
+		}
+	}
+
+	return t
+}
+
+func decodeJSON(content []byte) (any, error) {
+	data, err := standardizeJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,22 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// PluginInfo configures a single plugin, when several plugins are hosted together, such as for different apps or teams.
+// Its variables are available only to the plugin's functions, and take precedence over the manifest's variables.
+// When host functions are listed, the plugin's functions may only call those host functions.  Names may contain
+// "*" wildcards, such as "http*".  Likewise, the plugin's functions may only call the listed functions of other
+// plugins, named as plugin.function, such as "users.getUser" or "billing.*".
+type PluginInfo struct {
+	Name          string            `json:"-"`
+	Variables     map[string]string `json:"variables,omitempty"`
+	HostFunctions []string          `json:"hostFunctions,omitempty"`
+	Calls         []string          `json:"calls,omitempty"`
+}
@@ -0,0 +1,18 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// PromptInfo declares a named prompt template, which functions render with their own variables.
+// The template is given either inline, or as the name of a ".prompt" file stored alongside the plugin.
+type PromptInfo struct {
+	Name     string `json:"-"`
+	Template string `json:"template,omitempty"`
+	File     string `json:"file,omitempty"`
+}
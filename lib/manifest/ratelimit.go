@@ -0,0 +1,27 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// CallerRateLimitInfo limits how often each caller may call the runtime's API, or invoke the given functions.
+// Callers are identified by their API key, the subject of their JWT, or their IP address, in that order.
+// An API key only identifies a caller if it is one of the keys in the MODUS_API_KEYS secret.
+// Without functions, the rule limits the caller's requests to the API.  Otherwise, it limits the caller's
+// invocations of each of the functions separately.  Use "*" to apply the rule to every function.
+//
+// A rule can limit the sustained rate of calls, with a burst allowance, and the total number of calls
+// in a period, such as a daily quota.  Limits that are zero or omitted are not enforced.
+type CallerRateLimitInfo struct {
+	Name              string   `json:"-"`
+	Functions         []string `json:"functions,omitempty"`
+	RequestsPerSecond float64  `json:"rps,omitempty"`
+	Burst             int      `json:"burst,omitempty"`
+	Quota             int      `json:"quota,omitempty"`
+	QuotaPeriod       string   `json:"quotaPeriod,omitempty"`
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest_test
+
+import (
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+)
+
+func lookupIn(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestInterpolateEnv(t *testing.T) {
+	content := []byte(`{
+		// comments are allowed
+		"models": {
+			"text-generator": {
+				"sourceModel": "${MODEL_NAME:-gpt-4o}",
+				"host": "${MODEL_HOST}",
+				"connection": "${EMPTY:-fallback}",
+			}
+		},
+		"connections": {
+			"my-api": {
+				"type": "http",
+				"baseUrl": "https://${API_HOST}/v1/",
+				"headers": {"Authorization": "Bearer {{API_TOKEN}}", "X-Literal": "$${NOT_A_VAR}"}
+			}
+		},
+		"inputLimits": {"maxBytes": 1048576}
+	}`)
+
+	env := map[string]string{
+		"MODEL_HOST": "openai",
+		"API_HOST":   "api.example.com",
+		"EMPTY":      "",
+	}
+
+	result, err := manifest.InterpolateEnv(content, lookupIn(env))
+	if err != nil {
+		t.Fatalf("Error interpolating manifest: %s", err)
+	}
+
+	expected := `{"connections":{"my-api":{"baseUrl":"https://api.example.com/v1/","headers":{"Authorization":"Bearer {{API_TOKEN}}","X-Literal":"${NOT_A_VAR}"},"type":"http"}},` +
+		`"inputLimits":{"maxBytes":1048576},` +
+		`"models":{"text-generator":{"connection":"fallback","host":"openai","sourceModel":"gpt-4o"}}}`
+	if string(result) != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestInterpolateEnv_Errors(t *testing.T) {
+	content := []byte(`{"models": {"m": {"host": "${HOST_B}", "sourceModel": "${HOST_A}"}}}`)
+	_, err := manifest.InterpolateEnv(content, lookupIn(nil))
+	if err == nil || err.Error() != "the manifest uses environment variables that are not set: HOST_A, HOST_B" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	content = []byte(`{"models": {"m": {"host": "${not valid}"}}}`)
+	_, err = manifest.InterpolateEnv(content, lookupIn(nil))
+	if err == nil || err.Error() != "invalid environment placeholder ${not valid} in the manifest" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestMergeOverlay(t *testing.T) {
+	content := []byte(`{
+		"models": {
+			"a": {"sourceModel": "small", "host": "hypermode"},
+			"b": {"sourceModel": "other", "host": "hypermode"}
+		},
+		"connections": {"my-api": {"type": "http", "baseUrl": "http://localhost:8080/"}}
+	}`)
+
+	overlay := []byte(`{
+		"models": {
+			"a": {"sourceModel": "large"},
+			"b": null
+		},
+		"connections": {"my-api": {"baseUrl": "https://${API_HOST}/"}},
+	}`)
+
+	result, err := manifest.MergeOverlay(content, overlay)
+	if err != nil {
+		t.Fatalf("Error merging overlay: %s", err)
+	}
+
+	expected := `{"connections":{"my-api":{"baseUrl":"https://${API_HOST}/","type":"http"}},"models":{"a":{"host":"hypermode","sourceModel":"large"}}}`
+	if string(result) != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	if _, err := manifest.MergeOverlay(content, []byte(`[]`)); err == nil {
+		t.Error("Expected an error for an overlay that isn't an object")
+	}
+
+	if name := manifest.OverlayFileName("hypermode.json", "stage"); name != "hypermode.stage.json" {
+		t.Errorf("Unexpected overlay file name: %s", name)
+	}
+}
@@ -22,6 +22,7 @@ var validManifest []byte
 
 func TestReadManifest(t *testing.T) {
 	// This should match the content of valid_hypermode.json
+	disabled := false
 	expectedManifest := &manifest.Manifest{
 		Version: 2,
 		Models: map[string]manifest.ModelInfo{
@@ -41,6 +42,8 @@ func TestReadManifest(t *testing.T) {
 				Name:        "model-3",
 				SourceModel: "source-model-3",
 				Host:        "my-model-host",
+				Cache:       &manifest.ModelCacheInfo{Ttl: 600},
+				Pricing:     &manifest.ModelPricingInfo{InputPerMillion: 0.5, OutputPerMillion: 1.5},
 			},
 			"model-4": {
 				Name:        "model-4",
@@ -49,6 +52,57 @@ func TestReadManifest(t *testing.T) {
 				Host:        "hypermode",
 				Dedicated:   true,
 			},
+			"model-5": {
+				Name:        "model-5",
+				SourceModel: "claude-3-5-sonnet-20240620",
+				Provider:    "anthropic",
+				Host:        "another-model-host",
+				Retry:       &manifest.ModelRetryInfo{MaxAttempts: 3, MaxDelay: 10},
+				Fallbacks:   []string{"model-8"},
+			},
+			"model-6": {
+				Name:        "model-6",
+				SourceModel: "gemini-1.5-flash",
+				Provider:    "gemini",
+				Host:        "vertex-ai",
+				Path:        "gemini-1.5-flash:generateContent",
+			},
+			"model-7": {
+				Name:        "model-7",
+				SourceModel: "meta.llama3-1-8b-instruct-v1:0",
+				Host:        "aws-bedrock",
+			},
+			"model-8": {
+				Name:        "model-8",
+				SourceModel: "gpt-4o",
+				Provider:    "azure-openai",
+				Host:        "azure-openai",
+				Deployment:  "my-gpt-4o",
+				ApiVersion:  "2024-06-01",
+				Moderation: &manifest.ModerationInfo{
+					Model:    "model-3",
+					DenyList: []string{"internal only"},
+					Patterns: []string{`\b\d{3}-\d{2}-\d{4}\b`},
+					Apply:    manifest.ModerationApplyOutput,
+					Action:   manifest.ModerationActionFlag,
+				},
+			},
+			"model-9": {
+				Name:        "model-9",
+				SourceModel: "llama3.2",
+				Provider:    "openai-compatible",
+				Host:        "ollama",
+				Cache: &manifest.ModelCacheInfo{
+					Semantic: &manifest.SemanticCacheInfo{EmbeddingModel: "model-3", Threshold: 0.9},
+				},
+			},
+			"model-10": {
+				Name:        "model-10",
+				SourceModel: "stable-diffusion-xl-1024-v1-0",
+				Provider:    "stability",
+				Host:        "my-model-host",
+				Path:        "v1/generation/stable-diffusion-xl-1024-v1-0/text-to-image",
+			},
 		},
 		Hosts: map[string]manifest.HostInfo{
 			"my-model-host": manifest.HTTPHostInfo{
@@ -67,6 +121,31 @@ func TestReadManifest(t *testing.T) {
 					"X-API-Key": "{{API_KEY}}",
 				},
 			},
+			"vertex-ai": manifest.HTTPHostInfo{
+				Name:    "vertex-ai",
+				Type:    manifest.HostTypeHTTP,
+				BaseURL: "https://us-central1-aiplatform.googleapis.com/v1/projects/my-project/locations/us-central1/publishers/google/models/",
+				Auth: &manifest.HttpAuthInfo{
+					Type:        manifest.HttpAuthGoogleServiceAccount,
+					Credentials: "{{GOOGLE_SERVICE_ACCOUNT_KEY}}",
+				},
+			},
+			"azure-openai": manifest.HTTPHostInfo{
+				Name:    "azure-openai",
+				Type:    manifest.HostTypeHTTP,
+				BaseURL: "https://my-resource.openai.azure.com/",
+				Auth: &manifest.HttpAuthInfo{
+					Type:        manifest.HttpAuthAzureAD,
+					TenantId:    "{{AZURE_TENANT_ID}}",
+					ClientId:    "{{AZURE_CLIENT_ID}}",
+					Credentials: "{{AZURE_CLIENT_SECRET}}",
+				},
+			},
+			"ollama": manifest.HTTPHostInfo{
+				Name:    "ollama",
+				Type:    manifest.HostTypeHTTP,
+				BaseURL: "http://localhost:11434/",
+			},
 			"my-graphql-api": manifest.HTTPHostInfo{
 				Name:     "my-graphql-api",
 				Type:     manifest.HostTypeHTTP,
@@ -82,6 +161,11 @@ func TestReadManifest(t *testing.T) {
 				QueryParameters: map[string]string{
 					"api_token": "{{API_TOKEN}}",
 				},
+				RateLimit: &manifest.RateLimitInfo{
+					RequestsPerSecond: 5,
+					Burst:             10,
+					Concurrency:       2,
+				},
 			},
 			"another-rest-api": manifest.HTTPHostInfo{
 				Name:    "another-rest-api",
@@ -90,6 +174,11 @@ func TestReadManifest(t *testing.T) {
 				Headers: map[string]string{
 					"Authorization": "Basic {{base64(USERNAME:PASSWORD)}}",
 				},
+				Cache: &manifest.HttpCacheInfo{
+					Store:    manifest.HttpCacheStoreRedis,
+					RedisUrl: "redis://:{{REDIS_PASSWORD}}@localhost:6379/0",
+					MaxTtl:   300,
+				},
 			},
 			"api-with-type": manifest.HTTPHostInfo{
 				Name:    "api-with-type",
@@ -113,26 +202,178 @@ func TestReadManifest(t *testing.T) {
 				GrpcTarget: "localhost:9080",
 				Key:        "",
 			},
+			"compose-dgraph": manifest.DgraphHostInfo{
+				Name:       "compose-dgraph",
+				Type:       "dgraph",
+				GrpcTarget: "dgraph:9080",
+				TLS:        &disabled,
+			},
+			"my-nats": manifest.NatsHostInfo{
+				Name:  "my-nats",
+				Type:  "nats",
+				Url:   "nats://localhost:4222",
+				Token: "{{NATS_TOKEN}}",
+				Subscriptions: []manifest.NatsSubscriptionInfo{
+					{
+						Subject:    "orders.created",
+						Function:   "handleOrderCreated",
+						QueueGroup: "order-workers",
+					},
+					{
+						Subject:  "audit.>",
+						Function: "recordAuditEvent",
+					},
+				},
+			},
 		},
 		Collections: map[string]manifest.CollectionInfo{
 			"collection1": {
+				Ttl:  86400,
+				Host: "neon",
 				SearchMethods: map[string]manifest.SearchMethodInfo{
 					"searchMethod1": {
 						Embedder: "embedder1",
+						Quantization: manifest.QuantizationInfo{
+							Type:   manifest.QuantizationInt8,
+							Rerank: 8,
+						},
 					},
 					"searchMethod2": {
-						Embedder: "embedder1",
+						Embedder:  "embedder1",
+						Distance:  manifest.DistanceDot,
+						Normalize: true,
 						Index: manifest.IndexInfo{
 							Type: "hnsw",
 							Options: manifest.OptionsInfo{
+								M:              16,
 								EfConstruction: 100,
+								EfSearch:       50,
 								MaxLevels:      3,
 							},
 						},
 					},
+					"searchMethod3": {
+						Embedder: "embedder1",
+						Field:    "title",
+						Index: manifest.IndexInfo{
+							Type: manifest.IndexTypePgvector,
+							Host: "neon",
+							Name: "collection1_vectors",
+						},
+					},
 				},
 			},
 		},
+		Connectors: map[string]manifest.ConnectorInfo{
+			"stockPrice": {
+				Name:        "stockPrice",
+				Description: "Gets the latest price for a stock symbol.",
+				Host:        "my-rest-api",
+				Path:        "quote?symbol={{symbol}}",
+				Arguments: map[string]string{
+					"symbol": "String!",
+				},
+				ReturnType: "Float",
+				Select:     "quote.price",
+			},
+			"userProfile": {
+				Name:   "userProfile",
+				Host:   "my-rest-api",
+				Method: "POST",
+				Path:   "users/lookup",
+				Body:   `{"id": {{id}}}`,
+				Arguments: map[string]string{
+					"id": "Int!",
+				},
+				Fields: map[string]manifest.ConnectorFieldInfo{
+					"name": {Type: "String!", Select: "profile.displayName"},
+					"tags": {Type: "[String!]", Select: "profile.tags"},
+				},
+			},
+		},
+		Variables: map[string]string{
+			"FEATURE_FLAG":  "enabled",
+			"support.email": "support@example.com",
+		},
+		Guards: map[string]manifest.GuardInfo{
+			"adminOnly": {
+				Name:      "adminOnly",
+				Functions: []string{"deleteUser"},
+				Condition: "has(claims.role) && claims.role == 'admin'",
+				Message:   "Only administrators can delete users.",
+			},
+			"smallQueries": {
+				Name:      "smallQueries",
+				Functions: []string{"search"},
+				Condition: "args.limit <= 100",
+				Reroute:   "searchLimited",
+			},
+		},
+		Authorization: map[string]manifest.AuthorizationInfo{
+			"admins": {
+				Name:      "admins",
+				Functions: []string{"deleteUser"},
+				Fields:    []string{"User.email"},
+				Requires:  []string{"role=admin"},
+			},
+		},
+		RateLimits: map[string]manifest.CallerRateLimitInfo{
+			"perCaller": {
+				Name:              "perCaller",
+				RequestsPerSecond: 20,
+				Burst:             40,
+			},
+			"generation": {
+				Name:        "generation",
+				Functions:   []string{"generateText"},
+				Quota:       1000,
+				QuotaPeriod: "24h",
+			},
+		},
+		Plugins: map[string]manifest.PluginInfo{
+			"billing": {
+				Name:          "billing",
+				Variables:     map[string]string{"currency": "EUR"},
+				HostFunctions: []string{"log", "http*"},
+				Calls:         []string{"users.getUser"},
+			},
+		},
+		Transforms: map[string]manifest.TransformInfo{
+			"activeUsers": {
+				Name:      "activeUsers",
+				Functions: []string{"getUsers"},
+				Query:     "map(select(.active))",
+			},
+			"mobileSummary": {
+				Name:      "mobileSummary",
+				Functions: []string{"getProduct"},
+				Query:     ".description |= .[:100]",
+				Clients:   []string{"mobile-app"},
+			},
+		},
+		Prompts: map[string]manifest.PromptInfo{
+			"summarize": {
+				Name:     "summarize",
+				Template: "Summarize the following text in {{language}}:\n\n{{text}}",
+			},
+			"classify": {
+				Name: "classify",
+				File: "classify.prompt",
+			},
+		},
+		InputLimits: &manifest.InputLimitsInfo{
+			MaxListItems:  5000,
+			MaxMapEntries: 1000,
+			MaxDepth:      16,
+		},
+		Budget: &manifest.BudgetInfo{
+			SoftLimit: 80,
+			HardLimit: 100,
+		},
+		GraphQL: &manifest.GraphQLInfo{
+			Introspection:    &disabled,
+			TrustedDocuments: "trusted-documents.json",
+		},
 	}
 
 	actualManifest, err := manifest.ReadManifest(validManifest)
@@ -266,16 +507,41 @@ func TestDgraphLocalHostInfo_Hash(t *testing.T) {
 	}
 }
 
+func TestDgraphHostInfo_UseTLS(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		host     manifest.DgraphHostInfo
+		expected bool
+	}{
+		{manifest.DgraphHostInfo{GrpcTarget: "localhost:9080"}, false},
+		{manifest.DgraphHostInfo{GrpcTarget: "127.0.0.1:9080"}, false},
+		{manifest.DgraphHostInfo{GrpcTarget: "[::1]:9080"}, false},
+		{manifest.DgraphHostInfo{GrpcTarget: "dgraph.example.com:443"}, true},
+		{manifest.DgraphHostInfo{GrpcTarget: "dgraph:9080", TLS: &disabled}, false},
+		{manifest.DgraphHostInfo{GrpcTarget: "localhost:9080", TLS: &enabled}, true},
+		{manifest.DgraphHostInfo{GrpcTarget: "dgraph:9080", TLS: &disabled, Key: "{{DGRAPH_KEY}}"}, true},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.host.UseTLS(); actual != tt.expected {
+			t.Errorf("Expected UseTLS of %s to be %t, but got: %t", tt.host.GrpcTarget, tt.expected, actual)
+		}
+	}
+}
+
 func TestGetHostVariablesFromManifest(t *testing.T) {
 	// This should match the host variables that are present in valid_hypermode.json
 	expectedVars := map[string][]string{
 		"my-model-host":      {"API_KEY"},
 		"another-model-host": {"API_KEY"},
+		"vertex-ai":          {"GOOGLE_SERVICE_ACCOUNT_KEY"},
+		"azure-openai":       {"AZURE_CLIENT_SECRET", "AZURE_TENANT_ID", "AZURE_CLIENT_ID"},
 		"my-graphql-api":     {"AUTH_TOKEN"},
 		"my-rest-api":        {"API_TOKEN"},
-		"another-rest-api":   {"USERNAME", "PASSWORD"},
+		"another-rest-api":   {"USERNAME", "PASSWORD", "REDIS_PASSWORD"},
 		"neon":               {"POSTGRESQL_USERNAME", "POSTGRESQL_PASSWORD"},
 		"my-dgraph-cloud":    {"DGRAPH_KEY"},
+		"my-nats":            {"NATS_TOKEN"},
 	}
 
 	m, err := manifest.ReadManifest(validManifest)
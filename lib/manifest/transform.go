@@ -0,0 +1,20 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifest
+
+// TransformInfo declares a jq query that reshapes the output of the given functions before it is returned
+// by the REST and event stream endpoints.  GraphQL responses are shaped by the schema, so they aren't transformed.
+// If clients are listed, the transform only applies to requests that identify as one of them.
+type TransformInfo struct {
+	Name      string   `json:"-"`
+	Functions []string `json:"functions"`
+	Query     string   `json:"query"`
+	Clients   []string `json:"clients,omitempty"`
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package appconfig
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+)
+
+// Keys with this prefix are reserved for deployment metadata provided by the runtime.
+const reservedPrefix = "modus."
+
+// GetConfigValue returns the value of a non-secret configuration item, or nil if it is not defined.
+// Values come from the "variables" section of the manifest, except for the following reserved keys:
+//
+//	modus.environment     - the environment name (ex: "prod", "dev")
+//	modus.namespace       - the deployment namespace
+//	modus.region          - the deployment region, if known
+//	modus.runtime.version - the runtime version number
+//	modus.plugin.name     - the name of the calling plugin
+//	modus.plugin.version  - the version of the calling plugin, if known
+//	modus.plugin.buildId  - the build id of the calling plugin
+func GetConfigValue(ctx context.Context, key string) *string {
+	var value string
+	var ok bool
+	if strings.HasPrefix(key, reservedPrefix) {
+		value, ok = getDeploymentValue(ctx, key)
+	} else {
+		value, ok = getVariable(ctx, key)
+	}
+
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// GetConfigKeys returns the keys of all configuration items available to the calling function.
+func GetConfigKeys(ctx context.Context) []string {
+	vars := manifestdata.GetManifest().Variables
+	pluginVars := getPluginVariables(ctx)
+	keys := make([]string, 0, len(vars)+len(pluginVars)+len(deploymentKeys))
+	for k := range vars {
+		if _, ok := pluginVars[k]; !ok && !strings.HasPrefix(k, reservedPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range pluginVars {
+		if !strings.HasPrefix(k, reservedPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range deploymentKeys {
+		if _, ok := getDeploymentValue(ctx, k); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+var deploymentKeys = []string{
+	"modus.environment",
+	"modus.namespace",
+	"modus.region",
+	"modus.runtime.version",
+	"modus.plugin.name",
+	"modus.plugin.version",
+	"modus.plugin.buildId",
+}
+
+// getVariable returns the value of a variable of the manifest, giving precedence to the variables
+// that the manifest sets for the calling plugin.
+func getVariable(ctx context.Context, key string) (string, bool) {
+	if value, ok := getPluginVariables(ctx)[key]; ok {
+		return value, true
+	}
+	value, ok := manifestdata.GetManifest().Variables[key]
+	return value, ok
+}
+
+// getPluginVariables returns the variables that the manifest sets for the calling plugin, if any.
+func getPluginVariables(ctx context.Context) map[string]string {
+	if plugin, ok := plugins.GetPluginFromContext(ctx); ok {
+		return manifestdata.GetManifest().Plugins[plugin.Name()].Variables
+	}
+	return nil
+}
+
+func getDeploymentValue(ctx context.Context, key string) (string, bool) {
+	var value string
+	switch key {
+	case "modus.environment":
+		value = config.GetEnvironmentName()
+	case "modus.namespace":
+		value = config.GetNamespace()
+	case "modus.region":
+		value = config.GetRegion()
+	case "modus.runtime.version":
+		value = config.GetVersionNumber()
+	case "modus.plugin.name", "modus.plugin.version", "modus.plugin.buildId":
+		plugin, ok := plugins.GetPluginFromContext(ctx)
+		if !ok {
+			return "", false
+		}
+		switch key {
+		case "modus.plugin.name":
+			value = plugin.Name()
+		case "modus.plugin.version":
+			value = plugin.Version()
+		case "modus.plugin.buildId":
+			value = plugin.BuildId()
+		}
+	default:
+		return "", false
+	}
+
+	return value, value != ""
+}
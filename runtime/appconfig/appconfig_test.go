@@ -0,0 +1,90 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package appconfig_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/appconfig"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetConfigValue(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{
+		Variables: map[string]string{
+			"GREETING":    "hello",
+			"modus.other": "ignored",
+		},
+	})
+
+	ctx := context.Background()
+
+	v := appconfig.GetConfigValue(ctx, "GREETING")
+	if assert.NotNil(t, v) {
+		assert.Equal(t, "hello", *v)
+	}
+
+	assert.Nil(t, appconfig.GetConfigValue(ctx, "MISSING"))
+
+	// reserved keys can't be overridden by the manifest
+	assert.Nil(t, appconfig.GetConfigValue(ctx, "modus.other"))
+
+	// no plugin in context
+	assert.Nil(t, appconfig.GetConfigValue(ctx, "modus.plugin.name"))
+
+	t.Setenv("MODUS_REGION", "us-west-2")
+	v = appconfig.GetConfigValue(ctx, "modus.region")
+	if assert.NotNil(t, v) {
+		assert.Equal(t, "us-west-2", *v)
+	}
+
+	keys := appconfig.GetConfigKeys(ctx)
+	assert.Contains(t, keys, "GREETING")
+	assert.Contains(t, keys, "modus.region")
+	assert.NotContains(t, keys, "modus.other")
+}
+
+func Test_GetConfigValue_PluginVariables(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{
+		Variables: map[string]string{
+			"GREETING": "hello",
+			"CURRENCY": "USD",
+		},
+		Plugins: map[string]manifest.PluginInfo{
+			"billing": {Variables: map[string]string{"CURRENCY": "EUR", "TAX_RATE": "0.2"}},
+		},
+	})
+
+	plugin := &plugins.Plugin{Metadata: &metadata.Metadata{Plugin: "billing@1.0.0"}}
+	ctx := context.WithValue(context.Background(), utils.PluginContextKey, plugin)
+
+	// The plugin's variables take precedence, and are only available to the plugin.
+	v := appconfig.GetConfigValue(ctx, "CURRENCY")
+	if assert.NotNil(t, v) {
+		assert.Equal(t, "EUR", *v)
+	}
+	v = appconfig.GetConfigValue(ctx, "GREETING")
+	if assert.NotNil(t, v) {
+		assert.Equal(t, "hello", *v)
+	}
+	assert.Nil(t, appconfig.GetConfigValue(context.Background(), "TAX_RATE"))
+
+	keys := slices.DeleteFunc(appconfig.GetConfigKeys(ctx), func(k string) bool { return strings.HasPrefix(k, "modus.") })
+	assert.ElementsMatch(t, []string{"GREETING", "CURRENCY", "TAX_RATE"}, keys)
+}
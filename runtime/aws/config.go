@@ -12,6 +12,7 @@ package aws
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	hmConfig "github.com/hypermodeinc/modus/runtime/config"
 	"github.com/hypermodeinc/modus/runtime/logger"
@@ -23,11 +24,33 @@ import (
 )
 
 var awsConfig aws.Config
+var awsConfigLoaded bool
+var awsConfigMutex sync.Mutex
 
 func GetAwsConfig() aws.Config {
 	return awsConfig
 }
 
+// LoadAwsConfig returns the AWS configuration.  If AWS wasn't used when the runtime started,
+// the configuration is loaded from the default credential chain on first use.
+func LoadAwsConfig(ctx context.Context) (aws.Config, error) {
+	awsConfigMutex.Lock()
+	defer awsConfigMutex.Unlock()
+
+	if awsConfigLoaded {
+		return awsConfig, nil
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("error loading AWS configuration: %w", err)
+	}
+
+	awsConfig = cfg
+	awsConfigLoaded = true
+	return awsConfig, nil
+}
+
 func Initialize(ctx context.Context) {
 	if !(hmConfig.UseAwsStorage || hmConfig.UseAwsSecrets) {
 		return
@@ -55,6 +78,7 @@ func initialize(ctx context.Context) error {
 	}
 
 	awsConfig = cfg
+	awsConfigLoaded = true
 
 	logger.Info(ctx).
 		Str("region", awsConfig.Region).
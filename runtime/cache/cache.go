@@ -0,0 +1,143 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a thread-safe, size-bounded, in-memory cache with optional per-entry expiration.
+// When the cache is full, the least recently used entries are evicted first.
+type Cache[V any] struct {
+	mu      sync.Mutex
+	maxSize int64
+	size    int64
+	sizeOf  func(V) int64
+	items   map[string]*list.Element
+	lru     *list.List
+}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	size    int64
+	expires time.Time
+}
+
+// New creates a new cache that holds up to maxSize units, as measured by the sizeOf function.
+// If sizeOf is nil, each entry counts as one unit, and maxSize is the maximum number of entries.
+func New[V any](maxSize int64, sizeOf func(V) int64) *Cache[V] {
+	if sizeOf == nil {
+		sizeOf = func(V) int64 { return 1 }
+	}
+	return &Cache[V]{
+		maxSize: maxSize,
+		sizeOf:  sizeOf,
+		items:   make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Get returns the value for the key, if it is present and not expired.
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := el.Value.(*entry[V])
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		c.removeElement(el)
+		return zero, false
+	}
+
+	c.lru.MoveToFront(el)
+	return e.value, true
+}
+
+// Set adds or replaces the value for the key.  A ttl of zero means the entry doesn't expire,
+// though it may still be evicted when the cache is full.
+// It returns false if the value is too large to fit in the cache.
+func (c *Cache[V]) Set(key string, value V, ttl time.Duration) bool {
+	size := c.sizeOf(value)
+	if size > c.maxSize {
+		return false
+	}
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+
+	e := &entry[V]{key, value, size, expires}
+	c.items[key] = c.lru.PushFront(e)
+	c.size += size
+
+	for c.size > c.maxSize {
+		c.removeElement(c.lru.Back())
+	}
+
+	return true
+}
+
+// Delete removes the key from the cache, and returns true if it was present.
+func (c *Cache[V]) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+		return true
+	}
+	return false
+}
+
+// Clear removes all entries from the cache.
+func (c *Cache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.items)
+	c.lru.Init()
+	c.size = 0
+}
+
+// Len returns the number of entries in the cache, including any that have expired but not yet been removed.
+func (c *Cache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// Size returns the total size of the entries in the cache, as measured by the sizeOf function.
+func (c *Cache[V]) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *Cache[V]) removeElement(el *list.Element) {
+	e := c.lru.Remove(el).(*entry[V])
+	delete(c.items, e.key)
+	c.size -= e.size
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Cache_GetSet(t *testing.T) {
+	c := cache.New[string](10, nil)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	c.Set("a", "1", 0)
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", v)
+
+	c.Set("a", "2", 0)
+	v, _ = c.Get("a")
+	assert.Equal(t, "2", v)
+	assert.Equal(t, 1, c.Len())
+
+	assert.True(t, c.Delete("a"))
+	assert.False(t, c.Delete("a"))
+	assert.Equal(t, 0, c.Len())
+}
+
+func Test_Cache_Expiration(t *testing.T) {
+	c := cache.New[string](10, nil)
+
+	c.Set("a", "1", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func Test_Cache_Eviction(t *testing.T) {
+	c := cache.New(10, func(s string) int64 { return int64(len(s)) })
+
+	c.Set("a", "aaaa", 0)
+	c.Set("b", "bbbb", 0)
+
+	// touch "a" so that "b" is the least recently used
+	c.Get("a")
+
+	c.Set("c", "cccc", 0)
+
+	_, ok := c.Get("b")
+	assert.False(t, ok)
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, int64(8), c.Size())
+
+	assert.False(t, c.Set("d", "ddddddddddd", 0))
+}
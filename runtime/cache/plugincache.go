@@ -0,0 +1,88 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+)
+
+// Each plugin gets its own cache, so that plugins can't read or evict each other's values.
+// The cache is keyed by plugin name, so it survives reloading a new version of the same plugin.
+var pluginCaches = make(map[string]*Cache[string])
+var pluginCachesMutex sync.Mutex
+
+func getPluginCache(ctx context.Context) (*Cache[string], error) {
+	plugin, ok := plugins.GetPluginFromContext(ctx)
+	if !ok {
+		return nil, errors.New("plugin not found in context")
+	}
+
+	name := plugin.Name()
+
+	pluginCachesMutex.Lock()
+	defer pluginCachesMutex.Unlock()
+
+	c, ok := pluginCaches[name]
+	if !ok {
+		maxSize := int64(config.PluginCacheSize) * 1024 * 1024
+		c = New(maxSize, func(s string) int64 { return int64(len(s)) })
+		pluginCaches[name] = c
+	}
+
+	return c, nil
+}
+
+// GetCachedValue returns the value stored in the plugin's cache for the given key, or nil if not found.
+func GetCachedValue(ctx context.Context, key string) (*string, error) {
+	c, err := getPluginCache(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if value, ok := c.Get(key); ok {
+		return &value, nil
+	}
+	return nil, nil
+}
+
+// SetCachedValue stores a value in the plugin's cache for the given key.
+// The value expires after the given number of milliseconds, or never expires if the ttl is zero.
+func SetCachedValue(ctx context.Context, key, value string, ttlMs int64) error {
+	if ttlMs < 0 {
+		return errors.New("ttl must not be negative")
+	}
+
+	c, err := getPluginCache(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !c.Set(key, value, time.Duration(ttlMs)*time.Millisecond) {
+		return errors.New("value is too large to store in the cache")
+	}
+	return nil
+}
+
+// DeleteCachedValue removes the value stored in the plugin's cache for the given key,
+// and returns true if a value was removed.
+func DeleteCachedValue(ctx context.Context, key string) (bool, error) {
+	c, err := getPluginCache(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return c.Delete(key), nil
+}
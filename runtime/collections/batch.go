@@ -0,0 +1,349 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/collections/index"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// UpsertBatchToCollection upserts many items at once, for ingesting data.  Unlike UpsertToCollection, each item
+// can fail on its own, such as when its text is empty or its embedding fails, without failing the rest of the batch.
+// Embeddings are computed in batches, and only the items that were embedded by every search method are written,
+// in a single transaction.  The result lists the keys of the items that were upserted, and the failures of those that weren't.
+func UpsertBatchToCollection(ctx context.Context, collectionName, namespace string, keys, texts []string, labels [][]string, metadata []string) (*CollectionBatchMutationResult, error) {
+	return UpsertBatchToCollectionWithTtl(ctx, collectionName, namespace, keys, texts, labels, metadata, nil)
+}
+
+// UpsertBatchToCollectionWithTtl is UpsertBatchToCollection, with the number of seconds each item is kept.
+// A ttl of zero uses the ttl of the collection, if it has one.
+func UpsertBatchToCollectionWithTtl(ctx context.Context, collectionName, namespace string, keys, texts []string, labels [][]string, metadata []string, ttls []int64) (*CollectionBatchMutationResult, error) {
+
+	collectionData := manifestdata.GetManifest().Collections[collectionName]
+
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) != 0 && len(keys) != len(texts) {
+		return nil, fmt.Errorf("mismatch in number of keys and texts: %d != %d", len(keys), len(texts))
+	}
+	if len(labels) != 0 && len(labels) != len(texts) {
+		return nil, fmt.Errorf("mismatch in number of labels and texts: %d != %d", len(labels), len(texts))
+	}
+	if len(metadata) != 0 && len(metadata) != len(texts) {
+		return nil, fmt.Errorf("mismatch in number of metadata and texts: %d != %d", len(metadata), len(texts))
+	}
+	ttls, err = resolveTtls(collectionName, ttls, len(texts))
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace == "" {
+		namespace = in_mem.DefaultNamespace
+	}
+
+	collNs, err := col.findOrCreateNamespace(namespace, in_mem.NewCollectionNamespace(collectionName, namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	batch := newUpsertBatch(keys, texts, labels, metadata)
+
+	// compute embeddings for each search method, leaving out the items that fail
+	vectors := make(map[*interfaces.VectorIndexWrapper][][]float32, len(collectionData.SearchMethods))
+	for searchMethodName, searchMethod := range collectionData.SearchMethods {
+		vectorIndex, err := collNs.GetVectorIndex(ctx, searchMethodName)
+		if err == index.ErrVectorIndexNotFound {
+			vectorIndex, err = createIndexObject(collNs.GetCollectionName(), collNs.GetNamespace(), searchMethod, searchMethodName)
+			if err != nil {
+				return nil, err
+			}
+			err = collNs.SetVectorIndex(ctx, searchMethodName, vectorIndex)
+			if err != nil {
+				return nil, err
+			}
+		} else if err != nil {
+			return nil, err
+		}
+
+		embedder := activeEmbedder(collectionName, searchMethodName, searchMethod.Embedder)
+		if err := validateEmbedder(ctx, embedder); err != nil {
+			return nil, err
+		}
+
+		vecs, errs := embedInBatches(batch.pendingTexts(searchMethod.Field), func(texts []string) ([][]float32, error) {
+			return embedTexts(ctx, embedder, texts)
+		})
+		vectors[vectorIndex] = batch.applyEmbeddings(searchMethodName, vecs, errs)
+	}
+
+	pending := batch.pending()
+	if len(pending) == 0 {
+		return NewCollectionBatchMutationResult(collectionName, "upsert", nil, batch.failures), nil
+	}
+
+	// write the texts of all the remaining items in one pass
+	pendingKeys, pendingTexts, pendingLabels, pendingMetadata := batch.pendingItems()
+	if err := collNs.InsertTexts(ctx, pendingKeys, pendingTexts, pendingLabels, pendingMetadata); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(pendingKeys))
+	for i, key := range pendingKeys {
+		id, err := collNs.GetExternalId(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	journalTexts(ctx, collNs, ids, pendingKeys, pendingTexts, pendingLabels, pendingMetadata)
+
+	if ttls != nil {
+		pendingTtls := make([]int64, 0, len(pending))
+		for _, i := range pending {
+			pendingTtls = append(pendingTtls, ttls[i])
+		}
+		if err := setExpiry(ctx, ids, pendingTtls); err != nil {
+			return nil, err
+		}
+	}
+
+	// then their vectors, one pass for each search method
+	for vectorIndex, vecs := range vectors {
+		pendingVecs := make([][]float32, 0, len(pending))
+		for _, i := range pending {
+			pendingVecs = append(pendingVecs, vecs[i])
+		}
+		if err := vectorIndex.InsertVectors(ctx, ids, pendingVecs); err != nil {
+			return nil, err
+		}
+		journalVectors(ctx, collNs, vectorIndex, ids, nil, pendingKeys, pendingVecs)
+	}
+
+	return NewCollectionBatchMutationResult(collectionName, "upsert", pendingKeys, batch.failures), nil
+}
+
+// DeleteBatchFromCollection deletes many items at once, in a single transaction.
+// Keys that aren't in the collection are reported as failures.
+func DeleteBatchFromCollection(ctx context.Context, collectionName, namespace string, keys []string) (*CollectionBatchMutationResult, error) {
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace == "" {
+		namespace = in_mem.DefaultNamespace
+	}
+
+	collNs, err := col.findNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// the vectors are deleted along with their texts
+	deleted, err := db.DeleteCollectionTextsByKeys(ctx, collectionName, namespace, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var deletedKeys []string
+	var failures []*CollectionMutationFailure
+	for _, key := range keys {
+		if !slices.Contains(deleted, key) {
+			failures = append(failures, &CollectionMutationFailure{Key: key, Error: "key not found"})
+			continue
+		}
+		if slices.Contains(deletedKeys, key) {
+			continue
+		}
+
+		for _, vectorIndex := range collNs.GetVectorIndexMap() {
+			if err := vectorIndex.DeleteVectorFromMemory(ctx, key); err != nil {
+				return nil, err
+			}
+		}
+		if err := collNs.DeleteTextFromMemory(ctx, key); err != nil {
+			return nil, err
+		}
+		journalDelete(ctx, collNs, key)
+		deletedKeys = append(deletedKeys, key)
+	}
+
+	return NewCollectionBatchMutationResult(collectionName, "delete", deletedKeys, failures), nil
+}
+
+// upsertBatch tracks the items of a batch upsert, and which of them have failed.
+type upsertBatch struct {
+	keys     []string
+	texts    []string
+	labels   [][]string
+	metadata []map[string]any
+	failed   []bool
+	failures []*CollectionMutationFailure
+}
+
+func newUpsertBatch(keys, texts []string, labels [][]string, metadata []string) *upsertBatch {
+	b := &upsertBatch{
+		keys:     make([]string, len(texts)),
+		texts:    texts,
+		labels:   labels,
+		metadata: make([]map[string]any, len(texts)),
+		failed:   make([]bool, len(texts)),
+	}
+
+	seen := make(map[string]bool, len(texts))
+	for i := range texts {
+		if len(keys) != 0 && keys[i] != "" {
+			b.keys[i] = keys[i]
+		} else {
+			b.keys[i] = utils.GenerateUUIDv7()
+		}
+
+		switch {
+		case seen[b.keys[i]]:
+			b.fail(i, "duplicate key in batch")
+		case texts[i] == "":
+			b.fail(i, "text is empty")
+		case len(metadata) != 0:
+			m, err := parseMetadataItem(metadata[i])
+			if err != nil {
+				b.fail(i, "invalid metadata: "+err.Error())
+			} else {
+				b.metadata[i] = m
+			}
+		}
+		seen[b.keys[i]] = true
+	}
+	return b
+}
+
+func (b *upsertBatch) fail(i int, msg string) {
+	if b.failed[i] {
+		return
+	}
+	b.failed[i] = true
+	b.failures = append(b.failures, &CollectionMutationFailure{Key: b.keys[i], Error: msg})
+}
+
+// pending returns the indexes of the items that haven't failed.
+func (b *upsertBatch) pending() []int {
+	pending := make([]int, 0, len(b.texts))
+	for i, failed := range b.failed {
+		if !failed {
+			pending = append(pending, i)
+		}
+	}
+	return pending
+}
+
+// pendingTexts returns the texts that a search method with the field embeds for the pending items.
+func (b *upsertBatch) pendingTexts(field string) []string {
+	pending := b.pending()
+	texts := make([]string, len(pending))
+	for j, i := range pending {
+		texts[j] = fieldText(field, b.texts[i], b.metadata[i])
+	}
+	return texts
+}
+
+func (b *upsertBatch) pendingItems() (keys, texts []string, labels [][]string, metadata []map[string]any) {
+	pending := b.pending()
+	keys = make([]string, len(pending))
+	texts = make([]string, len(pending))
+	if len(b.labels) != 0 {
+		labels = make([][]string, len(pending))
+	}
+	hasMetadata := false
+	for _, i := range pending {
+		if b.metadata[i] != nil {
+			hasMetadata = true
+			break
+		}
+	}
+	if hasMetadata {
+		metadata = make([]map[string]any, len(pending))
+	}
+
+	for j, i := range pending {
+		keys[j] = b.keys[i]
+		texts[j] = b.texts[i]
+		if labels != nil {
+			labels[j] = b.labels[i]
+		}
+		if metadata != nil {
+			metadata[j] = b.metadata[i]
+		}
+	}
+	return keys, texts, labels, metadata
+}
+
+// applyEmbeddings takes the embeddings of the pending items, failing those that couldn't be embedded,
+// and returns the embeddings indexed by item.
+func (b *upsertBatch) applyEmbeddings(searchMethod string, vecs [][]float32, errs []error) [][]float32 {
+	byItem := make([][]float32, len(b.texts))
+	for j, i := range b.pending() {
+		if errs[j] != nil {
+			b.fail(i, fmt.Sprintf("search method %s: %v", searchMethod, errs[j]))
+			continue
+		}
+		byItem[i] = vecs[j]
+	}
+	return byItem
+}
+
+// embedInBatches computes the embeddings of the texts in batches.  When a batch fails, its texts are embedded
+// one at a time, so that only the texts that can't be embedded fail.  The errors are indexed by text.
+func embedInBatches(texts []string, embed func([]string) ([][]float32, error)) ([][]float32, []error) {
+	vecs := make([][]float32, len(texts))
+	errs := make([]error, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+
+		batchVecs, err := embed(texts[start:end])
+		if err == nil {
+			copy(vecs[start:end], batchVecs)
+			continue
+		}
+
+		if end-start == 1 {
+			errs[start] = err
+			continue
+		}
+		for i := start; i < end; i++ {
+			vec, err := embed(texts[i : i+1])
+			if err != nil {
+				errs[i] = err
+			} else {
+				vecs[i] = vec[0]
+			}
+		}
+	}
+	return vecs, errs
+}
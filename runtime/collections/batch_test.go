@@ -0,0 +1,105 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedInBatches(t *testing.T) {
+	texts := make([]string, batchSize*2+3)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+	texts[batchSize+1] = "bad text"
+
+	calls := 0
+	embed := func(texts []string) ([][]float32, error) {
+		calls++
+		vecs := make([][]float32, len(texts))
+		for i, text := range texts {
+			if strings.HasPrefix(text, "bad") {
+				return nil, errors.New("cannot embed")
+			}
+			vecs[i] = []float32{float32(len(text))}
+		}
+		return vecs, nil
+	}
+
+	vecs, errs := embedInBatches(texts, embed)
+	require.Len(t, vecs, len(texts))
+	require.Len(t, errs, len(texts))
+
+	// three batches, and the failed one again one text at a time
+	assert.Equal(t, 3+batchSize, calls)
+
+	for i := range texts {
+		if i == batchSize+1 {
+			assert.Error(t, errs[i])
+			assert.Nil(t, vecs[i])
+		} else {
+			assert.NoError(t, errs[i], i)
+			assert.Equal(t, []float32{float32(len(texts[i]))}, vecs[i], i)
+		}
+	}
+}
+
+func TestUpsertBatch(t *testing.T) {
+	keys := []string{"a", "b", "", "a", "e"}
+	texts := []string{"apple", "", "cherry", "again", "elderberry"}
+	labels := [][]string{{"1"}, {"2"}, {"3"}, {"4"}, {"5"}}
+	metadata := []string{`{"n":1}`, "", "", "", "{bad"}
+
+	b := newUpsertBatch(keys, texts, labels, metadata)
+	assert.NotEmpty(t, b.keys[2], "a key is generated for an item without one")
+	assert.Equal(t, []int{0, 2}, b.pending())
+
+	failures := map[string]string{}
+	for _, f := range b.failures {
+		failures[f.Key] = f.Error
+	}
+	assert.Equal(t, "text is empty", failures["b"])
+	assert.Equal(t, "duplicate key in batch", failures["a"])
+	assert.Contains(t, failures["e"], "invalid metadata")
+
+	// the second pending item fails to embed
+	vecs := b.applyEmbeddings("search", [][]float32{{1}, nil}, []error{nil, errors.New("boom")})
+	assert.Equal(t, []float32{1}, vecs[0])
+	assert.Equal(t, []int{0}, b.pending())
+	assert.Equal(t, "search method search: boom", b.failures[len(b.failures)-1].Error)
+
+	pendingKeys, pendingTexts, pendingLabels, pendingMetadata := b.pendingItems()
+	assert.Equal(t, []string{"a"}, pendingKeys)
+	assert.Equal(t, []string{"apple"}, pendingTexts)
+	assert.Equal(t, [][]string{{"1"}}, pendingLabels)
+	assert.Equal(t, []map[string]any{{"n": float64(1)}}, pendingMetadata)
+}
+
+func TestNewCollectionBatchMutationResult(t *testing.T) {
+	result := NewCollectionBatchMutationResult("docs", "upsert", []string{"a"}, nil)
+	assert.Equal(t, "success", result.Status)
+	assert.Empty(t, result.Error)
+	assert.NotNil(t, result.Failures)
+
+	failures := []*CollectionMutationFailure{{Key: "b", Error: "text is empty"}}
+	result = NewCollectionBatchMutationResult("docs", "upsert", []string{"a"}, failures)
+	assert.Equal(t, "partial", result.Status)
+	assert.Equal(t, "1 of 2 items failed", result.Error)
+
+	result = NewCollectionBatchMutationResult("docs", "upsert", nil, failures)
+	assert.Equal(t, "error", result.Status)
+	assert.NotNil(t, result.Keys)
+}
@@ -11,11 +11,17 @@ package collections
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
 
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
 	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
 )
 
+// AllNamespaces is the wildcard that searches every namespace of a collection.
+const AllNamespaces = "*"
+
 type collection struct {
 	collectionNamespaceMap map[string]interfaces.CollectionNamespace
 	mu                     sync.RWMutex
@@ -42,6 +48,49 @@ func (c *collection) findNamespace(namespace string) (interfaces.CollectionNames
 	return ns, nil
 }
 
+// resolveNamespaces returns the namespaces to search, in order, without duplicates.  The wildcard expands to
+// every namespace of the collection, and no namespaces means the default namespace.
+// A namespace that is named explicitly must exist.
+func (c *collection) resolveNamespaces(namespaces []string) ([]interfaces.CollectionNamespace, error) {
+	if len(namespaces) == 0 {
+		namespaces = []string{in_mem.DefaultNamespace}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		if namespace != AllNamespaces {
+			names = append(names, namespace)
+			continue
+		}
+
+		all := make([]string, 0, len(c.collectionNamespaceMap))
+		for name := range c.collectionNamespaceMap {
+			all = append(all, name)
+		}
+		sort.Strings(all)
+		names = append(names, all...)
+	}
+
+	result := make([]interfaces.CollectionNamespace, 0, len(names))
+	seen := make([]string, 0, len(names))
+	for _, name := range names {
+		if slices.Contains(seen, name) {
+			continue
+		}
+		seen = append(seen, name)
+
+		ns, found := c.collectionNamespaceMap[name]
+		if !found {
+			return nil, fmt.Errorf("%w: %s", errNamespaceNotFound, name)
+		}
+		result = append(result, ns)
+	}
+	return result, nil
+}
+
 func (c *collection) findOrCreateNamespace(namespace string, index interfaces.CollectionNamespace) (interfaces.CollectionNamespace, error) {
 	c.mu.RLock()
 	ns, found := c.collectionNamespaceMap[namespace]
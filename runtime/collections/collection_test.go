@@ -0,0 +1,59 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveNamespaces(t *testing.T) {
+	col := newCollection()
+	for _, ns := range []string{in_mem.DefaultNamespace, "tenant-b", "tenant-a"} {
+		_, err := col.createCollectionNamespace(ns, in_mem.NewCollectionNamespace("docs", ns))
+		require.NoError(t, err)
+	}
+
+	names := func(namespaces []interfaces.CollectionNamespace) []string {
+		result := make([]string, len(namespaces))
+		for i, ns := range namespaces {
+			result[i] = ns.GetNamespace()
+		}
+		return result
+	}
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		want       []string
+	}{
+		{"Default", nil, []string{in_mem.DefaultNamespace}},
+		{"Explicit", []string{"tenant-b", "tenant-a"}, []string{"tenant-b", "tenant-a"}},
+		{"Duplicates", []string{"tenant-a", "tenant-a"}, []string{"tenant-a"}},
+		{"Wildcard", []string{AllNamespaces}, []string{in_mem.DefaultNamespace, "tenant-a", "tenant-b"}},
+		{"WildcardWithExplicit", []string{"tenant-b", AllNamespaces}, []string{"tenant-b", in_mem.DefaultNamespace, "tenant-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespaces, err := col.resolveNamespaces(tt.namespaces)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, names(namespaces))
+		})
+	}
+
+	_, err := col.resolveNamespaces([]string{"tenant-a", "missing"})
+	assert.ErrorIs(t, err, errNamespaceNotFound)
+	assert.ErrorContains(t, err, "missing")
+}
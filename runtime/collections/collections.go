@@ -11,10 +11,12 @@ package collections
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
@@ -22,7 +24,9 @@ import (
 	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
 	collection_utils "github.com/hypermodeinc/modus/runtime/collections/utils"
 	"github.com/hypermodeinc/modus/runtime/functions"
+	"github.com/hypermodeinc/modus/runtime/logger"
 	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/standby"
 	"github.com/hypermodeinc/modus/runtime/utils"
 	"github.com/hypermodeinc/modus/runtime/wasmhost"
 )
@@ -32,28 +36,73 @@ var errInvalidEmbedderSignature = errors.New("invalid embedder function signatur
 func Initialize(ctx context.Context) {
 	globalNamespaceManager = newCollectionFactory()
 	manifestdata.RegisterManifestLoadedCallback(cleanAndProcessManifest)
+
+	// Restore collections from local storage once, before they are first synced with the database.
+	var restoreOnce sync.Once
 	functions.RegisterFunctionsLoadedCallback(func(ctx context.Context) {
+		restoreOnce.Do(func() {
+			restoreCollections(ctx)
+		})
 		globalNamespaceManager.readFromPostgres(ctx)
 	})
 
+	// When a standby is promoted, load any remaining changes and embed any texts the failed runtime didn't get to.
+	standby.RegisterPromotedCallback(func(ctx context.Context) {
+		globalNamespaceManager.requestSync()
+	})
+
 	go globalNamespaceManager.worker(ctx)
 }
 
 func Shutdown(ctx context.Context) {
+	stopReembedding()
+
 	close(globalNamespaceManager.quit)
 	<-globalNamespaceManager.done
+
+	if store := globalCollectionStore; store != nil {
+		close(store.quit)
+		<-store.done
+	}
+}
+
+func restoreCollections(ctx context.Context) {
+	dir := getCollectionsPath()
+	if dir == "" || standby.IsStandby() {
+		return
+	}
+
+	store, err := newCollectionStore(dir)
+	if err == nil {
+		err = store.restore(ctx, globalNamespaceManager)
+	}
+	if err != nil {
+		logger.Err(ctx, err).Str("path", dir).Msg("Failed to restore collections from local storage.  Collections will not be persisted locally.")
+		return
+	}
+
+	globalCollectionStore = store
+	go store.worker(ctx, globalNamespaceManager)
 }
 
 func UpsertToCollection(ctx context.Context, collectionName, namespace string, keys, texts []string, labels [][]string) (*CollectionMutationResult, error) {
+	return UpsertToCollectionWithMetadata(ctx, collectionName, namespace, keys, texts, labels, nil)
+}
 
-	// Get the collectionName data from the manifest
-	collectionData := manifestdata.GetManifest().Collections[collectionName]
+// UpsertToCollectionWithMetadata upserts texts along with metadata that can be used to filter searches.
+// The metadata for each text is a JSON object, or an empty string if the text has no metadata.
+func UpsertToCollectionWithMetadata(ctx context.Context, collectionName, namespace string, keys, texts []string, labels [][]string, metadata []string) (*CollectionMutationResult, error) {
 
 	col, err := globalNamespaceManager.findCollection(collectionName)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
 	if namespace == "" {
 		namespace = in_mem.DefaultNamespace
 	}
@@ -76,68 +125,103 @@ func UpsertToCollection(ctx context.Context, collectionName, namespace string, k
 		return nil, fmt.Errorf("mismatch in number of labels and texts: %d != %d", len(labels), len(texts))
 	}
 
-	err = collNs.InsertTexts(ctx, keys, texts, labels)
+	if len(metadata) != 0 && len(metadata) != len(texts) {
+		return nil, fmt.Errorf("mismatch in number of metadata and texts: %d != %d", len(metadata), len(texts))
+	}
+
+	metadataArr, err := parseMetadata(metadata)
 	if err != nil {
 		return nil, err
 	}
 
-	// compute embeddings for each search method, and insert into vector index
+	ttls, err := resolveTtls(collectionName, nil, len(texts))
+	if err != nil {
+		return nil, err
+	}
+
+	err = collNs.InsertTexts(ctx, keys, texts, labels, metadataArr)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(keys))
+	for i, key := range keys {
+		id, err := collNs.GetExternalId(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	journalTexts(ctx, collNs, ids, keys, texts, labels, metadataArr)
+
+	if err := setExpiry(ctx, ids, ttls); err != nil {
+		return nil, err
+	}
+
+	if err := embedIntoSearchMethods(ctx, collNs, ids, keys, texts); err != nil {
+		return nil, err
+	}
+
+	return NewCollectionMutationResult(collectionName, "upsert", "success", keys, ""), nil
+}
+
+// embedIntoSearchMethods computes the embeddings of the texts for each search method of the collection,
+// and inserts them into the vector indexes of the namespace.
+func embedIntoSearchMethods(ctx context.Context, collNs interfaces.CollectionNamespace, ids []int64, keys, texts []string) error {
+	// Get the collection data from the manifest
+	collectionName := collNs.GetCollectionName()
+	collectionData := manifestdata.GetManifest().Collections[collectionName]
+
 	for searchMethodName, searchMethod := range collectionData.SearchMethods {
 		vectorIndex, err := collNs.GetVectorIndex(ctx, searchMethodName)
 		if err == index.ErrVectorIndexNotFound {
-			vectorIndex, err = createIndexObject(searchMethod, searchMethodName)
+			vectorIndex, err = createIndexObject(collNs.GetCollectionName(), collNs.GetNamespace(), searchMethod, searchMethodName)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			err = collNs.SetVectorIndex(ctx, searchMethodName, vectorIndex)
 			if err != nil {
-				return nil, err
+				return err
 			}
 		} else if err != nil {
-			return nil, err
+			return err
 		}
 
-		embedder := searchMethod.Embedder
+		embedder := activeEmbedder(collectionName, searchMethodName, searchMethod.Embedder)
 		if err := validateEmbedder(ctx, embedder); err != nil {
-			return nil, err
+			return err
+		}
+
+		textsToEmbed, err := fieldTexts(ctx, collNs, searchMethodName, keys, texts)
+		if err != nil {
+			return err
 		}
 
 		callCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 		defer cancel()
-		executionInfo, err := wasmhost.CallFunction(callCtx, embedder, texts)
+		executionInfo, err := wasmhost.CallFunction(callCtx, embedder, textsToEmbed)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		result := executionInfo.Result()
 
 		textVecs, err := collection_utils.ConvertToFloat32_2DArray(result)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if len(textVecs) != len(texts) {
-			return nil, fmt.Errorf("mismatch in number of embeddings generated by embedder %s", embedder)
-		}
-
-		ids := make([]int64, len(keys))
-		for i := range textVecs {
-			key := keys[i]
-
-			id, err := collNs.GetExternalId(ctx, key)
-			if err != nil {
-				return nil, err
-			}
-			ids[i] = id
+			return fmt.Errorf("mismatch in number of embeddings generated by embedder %s", embedder)
 		}
 
 		err = vectorIndex.InsertVectors(ctx, ids, textVecs)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		journalVectors(ctx, collNs, vectorIndex, ids, nil, keys, textVecs)
 	}
-
-	return NewCollectionMutationResult(collectionName, "upsert", "success", keys, ""), nil
+	return nil
 }
 
 func DeleteFromCollection(ctx context.Context, collectionName, namespace, key string) (*CollectionMutationResult, error) {
@@ -146,6 +230,11 @@ func DeleteFromCollection(ctx context.Context, collectionName, namespace, key st
 		return nil, err
 	}
 
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
 	if namespace == "" {
 		namespace = in_mem.DefaultNamespace
 	}
@@ -169,6 +258,7 @@ func DeleteFromCollection(ctx context.Context, collectionName, namespace, key st
 	if err != nil {
 		return nil, err
 	}
+	journalDelete(ctx, collNs, key)
 
 	keys := []string{key}
 
@@ -176,55 +266,47 @@ func DeleteFromCollection(ctx context.Context, collectionName, namespace, key st
 }
 
 func SearchCollection(ctx context.Context, collectionName string, namespaces []string, searchMethod, text string, limit int32, returnText bool) (*CollectionSearchResult, error) {
+	return SearchCollectionWithFilter(ctx, collectionName, namespaces, searchMethod, text, limit, returnText, "")
+}
+
+// SearchCollectionWithFilter searches the collection, only returning items whose metadata matches the filter expression.
+func SearchCollectionWithFilter(ctx context.Context, collectionName string, namespaces []string, searchMethod, text string, limit int32, returnText bool, filter string) (*CollectionSearchResult, error) {
 
 	col, err := globalNamespaceManager.findCollection(collectionName)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(namespaces) == 0 {
-		namespaces = []string{in_mem.DefaultNamespace}
-	}
-
-	embedder, err := getEmbedder(ctx, collectionName, searchMethod)
+	ctx, err = collectionContext(ctx, collectionName)
 	if err != nil {
 		return nil, err
 	}
 
-	texts := []string{text}
-
-	callCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
-	defer cancel()
-	executionInfo, err := wasmhost.CallFunction(callCtx, embedder, texts)
+	metadataFilter, err := parseMetadataFilter(filter)
 	if err != nil {
 		return nil, err
 	}
 
-	result := executionInfo.Result()
-
-	textVecs, err := collection_utils.ConvertToFloat32_2DArray(result)
+	collNamespaces, err := col.resolveNamespaces(namespaces)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(textVecs) == 0 {
-		return nil, fmt.Errorf("no embeddings generated by embedder %s", embedder)
+	textVec, err := embedText(ctx, collectionName, searchMethod, text)
+	if err != nil {
+		return nil, err
 	}
 
 	// merge all objects
-	mergedObjects := make([]*CollectionSearchResultObject, 0, len(namespaces)*int(limit))
-	for _, ns := range namespaces {
-		collNs, err := col.findNamespace(ns)
-		if err != nil {
-			return nil, err
-		}
+	mergedObjects := make([]*CollectionSearchResultObject, 0, len(collNamespaces)*int(limit))
+	for _, collNs := range collNamespaces {
 
 		vectorIndex, err := collNs.GetVectorIndex(ctx, searchMethod)
 		if err != nil {
 			return nil, err
 		}
 
-		objects, err := vectorIndex.Search(ctx, textVecs[0], int(limit), nil)
+		objects, err := vectorIndex.Search(ctx, textVec, int(limit), searchFilterFor(ctx, metadataFilter, collNs))
 		if err != nil {
 			return nil, err
 		}
@@ -238,7 +320,7 @@ func SearchCollection(ctx context.Context, collectionName string, namespaces []s
 			if err != nil {
 				return nil, err
 			}
-			mergedObjects = append(mergedObjects, NewCollectionSearchResultObject(ns, object.GetIndex(), text, labels, object.GetValue(), 1-object.GetValue()))
+			mergedObjects = append(mergedObjects, NewCollectionSearchResultObject(collNs.GetNamespace(), object.GetIndex(), text, labels, object.GetValue(), collection_utils.ScoreForDistance(vectorIndex.GetDistance(), object.GetValue())))
 		}
 	}
 
@@ -255,30 +337,42 @@ func SearchCollection(ctx context.Context, collectionName string, namespaces []s
 }
 
 func SearchCollectionByVector(ctx context.Context, collectionName string, namespaces []string, searchMethod string, vector []float32, limit int32, returnText bool) (*CollectionSearchResult, error) {
+	return SearchCollectionByVectorWithFilter(ctx, collectionName, namespaces, searchMethod, vector, limit, returnText, "")
+}
+
+// SearchCollectionByVectorWithFilter searches the collection by vector, only returning items whose metadata matches the filter expression.
+func SearchCollectionByVectorWithFilter(ctx context.Context, collectionName string, namespaces []string, searchMethod string, vector []float32, limit int32, returnText bool, filter string) (*CollectionSearchResult, error) {
 
 	col, err := globalNamespaceManager.findCollection(collectionName)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(namespaces) == 0 {
-		namespaces = []string{in_mem.DefaultNamespace}
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataFilter, err := parseMetadataFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	collNamespaces, err := col.resolveNamespaces(namespaces)
+	if err != nil {
+		return nil, err
 	}
 
 	// merge all objects
-	mergedObjects := make([]*CollectionSearchResultObject, 0, len(namespaces)*int(limit))
-	for _, ns := range namespaces {
-		collNs, err := col.findNamespace(ns)
-		if err != nil {
-			return nil, err
-		}
+	mergedObjects := make([]*CollectionSearchResultObject, 0, len(collNamespaces)*int(limit))
+	for _, collNs := range collNamespaces {
 
 		vectorIndex, err := collNs.GetVectorIndex(ctx, searchMethod)
 		if err != nil {
 			return nil, err
 		}
 
-		objects, err := vectorIndex.Search(ctx, vector, int(limit), nil)
+		objects, err := vectorIndex.Search(ctx, vector, int(limit), searchFilterFor(ctx, metadataFilter, collNs))
 		if err != nil {
 			return nil, err
 		}
@@ -292,7 +386,7 @@ func SearchCollectionByVector(ctx context.Context, collectionName string, namesp
 			if err != nil {
 				return nil, err
 			}
-			mergedObjects = append(mergedObjects, NewCollectionSearchResultObject(ns, object.GetIndex(), text, labels, object.GetValue(), 1-object.GetValue()))
+			mergedObjects = append(mergedObjects, NewCollectionSearchResultObject(collNs.GetNamespace(), object.GetIndex(), text, labels, object.GetValue(), collection_utils.ScoreForDistance(vectorIndex.GetDistance(), object.GetValue())))
 		}
 	}
 
@@ -315,6 +409,11 @@ func NnClassify(ctx context.Context, collectionName, namespace, searchMethod, te
 		return nil, err
 	}
 
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
 	if namespace == "" {
 		namespace = in_mem.DefaultNamespace
 	}
@@ -398,7 +497,7 @@ func NnClassify(ctx context.Context, collectionName, namespace, searchMethod, te
 				totalLabels++
 			}
 
-			res.Cluster = append(res.Cluster, NewCollectionClassificationResultObject(nn.GetIndex(), labels, nn.GetValue(), 1-nn.GetValue()))
+			res.Cluster = append(res.Cluster, NewCollectionClassificationResultObject(nn.GetIndex(), labels, nn.GetValue(), collection_utils.ScoreForDistance(vectorIndex.GetDistance(), nn.GetValue())))
 		}
 	}
 
@@ -466,6 +565,33 @@ func GetLabels(ctx context.Context, collectionName, namespace, key string) ([]st
 	return labels, nil
 }
 
+// GetMetadata returns the metadata for the key as a JSON object.
+func GetMetadata(ctx context.Context, collectionName, namespace, key string) (string, error) {
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return "", err
+	}
+
+	collNs, err := col.findNamespace(namespace)
+	if err != nil {
+		return "", err
+	}
+
+	metadata, err := collNs.GetMetadata(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if metadata == nil {
+		return "{}", nil
+	}
+
+	bytes, err := utils.JsonSerialize(metadata)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
 func ComputeDistance(ctx context.Context, collectionName, namespace, searchMethod, id1, id2 string) (*CollectionSearchResultObject, error) {
 
 	col, err := globalNamespaceManager.findCollection(collectionName)
@@ -505,12 +631,12 @@ func ComputeDistance(ctx context.Context, collectionName, namespace, searchMetho
 		return nil, fmt.Errorf("vector for id %s not found", id2)
 	}
 
-	distance, err := collection_utils.CosineDistance(vec1, vec2)
+	distance, err := collection_utils.Distance(vectorIndex.GetDistance(), vec1, vec2)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewCollectionSearchResultObject(namespace, "", "", []string{}, distance, 1-distance), nil
+	return NewCollectionSearchResultObject(namespace, "", "", []string{}, distance, collection_utils.ScoreForDistance(vectorIndex.GetDistance(), distance)), nil
 }
 
 func RecomputeSearchMethod(ctx context.Context, collectionName, namespace, searchMethod string) (*SearchMethodMutationResult, error) {
@@ -520,6 +646,11 @@ func RecomputeSearchMethod(ctx context.Context, collectionName, namespace, searc
 		return nil, err
 	}
 
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
 	if namespace == "" {
 		namespace = in_mem.DefaultNamespace
 	}
@@ -604,6 +735,74 @@ func GetNamespacesFromCollection(ctx context.Context, collectionName string) ([]
 	return namespaces, nil
 }
 
+// parseMetadata parses the JSON metadata for each text, returning nil if none of the texts have metadata.
+func parseMetadata(metadata []string) ([]map[string]any, error) {
+	var result []map[string]any
+	for i, m := range metadata {
+		if m == "" {
+			continue
+		}
+		if result == nil {
+			result = make([]map[string]any, len(metadata))
+		}
+		var err error
+		if result[i], err = parseMetadataItem(m); err != nil {
+			return nil, fmt.Errorf("invalid metadata at index %d: %w", i, err)
+		}
+	}
+	return result, nil
+}
+
+func parseMetadataItem(metadata string) (map[string]any, error) {
+	if metadata == "" {
+		return nil, nil
+	}
+	// numbers are decoded as float64, the same as metadata read from the database
+	var result map[string]any
+	if err := json.Unmarshal([]byte(metadata), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// embedText computes the embedding of the text with the embedder of the search method.
+func embedText(ctx context.Context, collectionName, searchMethod, text string) ([]float32, error) {
+	embedder, err := getEmbedder(ctx, collectionName, searchMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	textVecs, err := embedTexts(ctx, embedder, []string{text})
+	if err != nil {
+		return nil, err
+	}
+
+	return textVecs[0], nil
+}
+
+// embedTexts computes the embeddings of the texts with the embedder, returning an embedding for each text.
+func embedTexts(ctx context.Context, embedder string, texts []string) ([][]float32, error) {
+	callCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	executionInfo, err := wasmhost.CallFunction(callCtx, embedder, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := executionInfo.Result()
+
+	textVecs, err := collection_utils.ConvertToFloat32_2DArray(result)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(textVecs) != len(texts) {
+		return nil, fmt.Errorf("mismatch in number of embeddings generated by embedder %s", embedder)
+	}
+
+	return textVecs, nil
+}
+
 func getEmbedder(ctx context.Context, collectionName string, searchMethod string) (string, error) {
 	manifestColl, ok := manifestdata.GetManifest().Collections[collectionName]
 	if !ok {
@@ -615,7 +814,7 @@ func getEmbedder(ctx context.Context, collectionName string, searchMethod string
 		return "", fmt.Errorf("search method %s not found in collection %s", searchMethod, collectionName)
 	}
 
-	embedder := manifestSearchMethod.Embedder
+	embedder := activeEmbedder(collectionName, searchMethod, manifestSearchMethod.Embedder)
 	if embedder == "" {
 		return "", fmt.Errorf("embedder not found in search method %s of collection %s", searchMethod, collectionName)
 	}
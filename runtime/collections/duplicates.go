@@ -0,0 +1,159 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	collection_utils "github.com/hypermodeinc/modus/runtime/collections/utils"
+)
+
+// nearDuplicateCandidates is the number of nearest neighbors of each item that are compared to the threshold.
+// Items with more near-duplicates than this still end up in the same cluster, through their neighbors.
+const nearDuplicateCandidates = 10
+
+// FindNearDuplicates groups the items of a namespace whose vectors in the search method are at least as similar as
+// the threshold, such as 0.95 for the cosine similarity.  Items are linked to their nearest neighbors above the
+// threshold, and the linked items form clusters, so the items of a cluster are near-duplicates of each other directly
+// or through other items of the cluster.  Only clusters of two or more items are returned, largest first.
+// Every item is searched for, so this takes as long as a search of each item.
+func FindNearDuplicates(ctx context.Context, collectionName, namespace, searchMethod string, threshold float64) (*CollectionDuplicatesResult, error) {
+	if math.IsNaN(threshold) {
+		return nil, fmt.Errorf("threshold must be a number")
+	}
+
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace == "" {
+		namespace = in_mem.DefaultNamespace
+	}
+
+	collNs, err := col.findNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	vectorIndex, err := collNs.GetVectorIndex(ctx, searchMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := collNs.GetIdMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := slices.Sorted(maps.Keys(ids))
+
+	clusters := newDuplicateClusters()
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		vec, err := vectorIndex.GetVector(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if vec == nil {
+			// the text hasn't been embedded yet
+			continue
+		}
+
+		neighbors, err := vectorIndex.Search(ctx, vec, nearDuplicateCandidates+1, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range neighbors {
+			if n.GetIndex() == key {
+				continue
+			}
+			score := collection_utils.ScoreForDistance(vectorIndex.GetDistance(), n.GetValue())
+			if score >= threshold {
+				clusters.link(key, n.GetIndex(), score)
+			}
+		}
+	}
+
+	return NewCollectionDuplicatesResult(collectionName, namespace, searchMethod, clusters.result()), nil
+}
+
+// duplicateClusters is a union-find of the linked items, which tracks the lowest score of the links of each cluster.
+type duplicateClusters struct {
+	parent map[string]string
+	score  map[string]float64
+}
+
+func newDuplicateClusters() *duplicateClusters {
+	return &duplicateClusters{
+		parent: map[string]string{},
+		score:  map[string]float64{},
+	}
+}
+
+func (c *duplicateClusters) find(key string) string {
+	parent, ok := c.parent[key]
+	if !ok {
+		c.parent[key] = key
+		c.score[key] = math.Inf(1)
+		return key
+	}
+	if parent == key {
+		return key
+	}
+	root := c.find(parent)
+	c.parent[key] = root
+	return root
+}
+
+func (c *duplicateClusters) link(a, b string, score float64) {
+	rootA, rootB := c.find(a), c.find(b)
+	if rootA == rootB {
+		return
+	}
+	c.parent[rootB] = rootA
+	c.score[rootA] = min(c.score[rootA], c.score[rootB], score)
+	delete(c.score, rootB)
+}
+
+func (c *duplicateClusters) result() []*CollectionDuplicateCluster {
+	byRoot := map[string]*CollectionDuplicateCluster{}
+	for _, key := range slices.Sorted(maps.Keys(c.parent)) {
+		root := c.find(key)
+		cluster, ok := byRoot[root]
+		if !ok {
+			cluster = &CollectionDuplicateCluster{Score: c.score[root]}
+			byRoot[root] = cluster
+		}
+		cluster.Keys = append(cluster.Keys, key)
+	}
+
+	clusters := slices.Collect(maps.Values(byRoot))
+	slices.SortFunc(clusters, func(a, b *CollectionDuplicateCluster) int {
+		if len(a.Keys) != len(b.Keys) {
+			return len(b.Keys) - len(a.Keys)
+		}
+		return cmp.Compare(a.Keys[0], b.Keys[0])
+	})
+	return clusters
+}
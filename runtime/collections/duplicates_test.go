@@ -0,0 +1,78 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindNearDuplicates(t *testing.T) {
+	ctx := context.Background()
+
+	cf := newCollectionFactory()
+	col, err := cf.createCollection("docs", newCollection())
+	require.NoError(t, err)
+	collNs, err := col.createCollectionNamespace("", in_mem.NewCollectionNamespace("docs", ""))
+	require.NoError(t, err)
+	vi, err := createIndexObject("docs", "", manifest.SearchMethodInfo{}, "search")
+	require.NoError(t, err)
+	require.NoError(t, collNs.SetVectorIndex(ctx, "search", vi))
+
+	// a, b and c form a chain of near-duplicates, d and e are near-duplicates, f is unique,
+	// and g hasn't been embedded yet
+	vecs := map[string][]float32{
+		"a": {1, 0, 0},
+		"b": {1, 0.2, 0},
+		"c": {1, 0.4, 0},
+		"d": {0, 1, 0},
+		"e": {0, 1, 0.1},
+		"f": {0, 0, 1},
+	}
+	var id int64
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		id++
+		require.NoError(t, collNs.InsertTextToMemory(ctx, id, key, "text "+key, nil, nil))
+		if vec, ok := vecs[key]; ok {
+			require.NoError(t, vi.InsertVectorToMemory(ctx, id, id, key, vec))
+		}
+	}
+
+	previous := globalNamespaceManager
+	globalNamespaceManager = cf
+	defer func() { globalNamespaceManager = previous }()
+
+	result, err := FindNearDuplicates(ctx, "docs", "", "search", 0.98)
+	require.NoError(t, err)
+	require.Len(t, result.Clusters, 2)
+	assert.Equal(t, []string{"a", "b", "c"}, result.Clusters[0].Keys)
+	assert.Equal(t, []string{"d", "e"}, result.Clusters[1].Keys)
+
+	// the score of a cluster is its weakest link, between a and b, rather than the similarity of a and c
+	assert.InDelta(t, 1/math.Sqrt(1.04), result.Clusters[0].Score, 1e-6)
+	assert.Less(t, 1/math.Sqrt(1.16), result.Clusters[0].Score)
+
+	result, err = FindNearDuplicates(ctx, "docs", "", "search", 0.999)
+	require.NoError(t, err)
+	assert.Empty(t, result.Clusters)
+
+	_, err = FindNearDuplicates(ctx, "docs", "missing", "search", 0.9)
+	assert.ErrorIs(t, err, errNamespaceNotFound)
+
+	_, err = FindNearDuplicates(ctx, "docs", "", "search", math.NaN())
+	assert.Error(t, err)
+}
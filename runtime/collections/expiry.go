@@ -0,0 +1,136 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+)
+
+// Items expire when the ttl they were upserted with has passed, or the ttl of their collection if they have none.
+// The expiry is kept in the database, which is the source of truth.  The worker of the collection factory deletes
+// the expired items from the database, and then evicts them from memory, so they are gone within a sync interval.
+// Texts that have expired are not loaded from the database, so they don't come back after a restart.
+
+// expiredTextsBatchSize is the most expired texts deleted from the database at once.
+const expiredTextsBatchSize = 1000
+
+// The database operations of expiry, which tests replace.
+var (
+	setTextsExpiry     = db.SetCollectionTextsExpiry
+	deleteExpiredTexts = db.DeleteExpiredCollectionTexts
+)
+
+// resolveTtls returns the ttl of each item, in seconds, using the ttl of the collection for items that have none.
+// It returns nil if none of the items expire.
+func resolveTtls(collectionName string, ttls []int64, count int) ([]int64, error) {
+	if len(ttls) != 0 && len(ttls) != count {
+		return nil, fmt.Errorf("mismatch in number of ttls and texts: %d != %d", len(ttls), count)
+	}
+
+	collectionTtl := int64(manifestdata.GetManifest().Collections[collectionName].Ttl)
+	if len(ttls) == 0 && collectionTtl <= 0 {
+		return nil, nil
+	}
+
+	resolved := make([]int64, count)
+	for i := range resolved {
+		switch {
+		case len(ttls) != 0 && ttls[i] < 0:
+			return nil, fmt.Errorf("ttl must not be negative: %d", ttls[i])
+		case len(ttls) != 0 && ttls[i] > 0:
+			resolved[i] = ttls[i]
+		default:
+			resolved[i] = collectionTtl
+		}
+	}
+	return resolved, nil
+}
+
+// setExpiry sets the texts to expire after their ttls.  It does nothing if ttls is nil.
+func setExpiry(ctx context.Context, textIds []int64, ttls []int64) error {
+	if ttls == nil {
+		return nil
+	}
+	return setTextsExpiry(ctx, textIds, ttls)
+}
+
+// evictExpired deletes the expired texts from the database, and from the hosts that store collections,
+// and removes them from memory.
+func (cf *collectionFactory) evictExpired(ctx context.Context) {
+	if len(manifestdata.GetManifest().Collections) == 0 {
+		return
+	}
+
+	for _, host := range append([]string{""}, collectionHosts()...) {
+		hostCtx, err := hostContext(ctx, host)
+		if err != nil {
+			logger.Err(ctx, err).Str("host", host).Msg("Failed to delete expired texts from collections.")
+			continue
+		}
+		cf.evictExpiredFromHost(hostCtx)
+	}
+}
+
+// evictExpiredFromHost deletes the expired texts from the database of the context, and removes them from memory.
+func (cf *collectionFactory) evictExpiredFromHost(ctx context.Context) {
+	for {
+		expired, err := deleteExpiredTexts(ctx, expiredTextsBatchSize)
+		if err != nil {
+			logger.Err(ctx, err).Msg("Failed to delete expired texts from collections.")
+			return
+		}
+
+		for _, t := range expired {
+			if err := cf.evictText(ctx, t); err != nil {
+				logger.Err(ctx, err).
+					Str("collection_name", t.Collection).
+					Str("namespace", t.Namespace).
+					Str("key", t.Key).
+					Msg("Failed to evict expired text from collection.")
+			}
+		}
+
+		if len(expired) < expiredTextsBatchSize {
+			return
+		}
+	}
+}
+
+// evictText removes an expired text, and its vectors, from memory.  The text is left alone if it was upserted again
+// after it was deleted, in which case the text in memory is a new one, with a different id.
+func (cf *collectionFactory) evictText(ctx context.Context, t db.ExpiredCollectionText) error {
+	col, err := cf.findCollection(t.Collection)
+	if err != nil {
+		return nil
+	}
+	collNs, err := col.findNamespace(t.Namespace)
+	if err != nil {
+		return nil
+	}
+	if id, err := collNs.GetExternalId(ctx, t.Key); err != nil || id != t.Id {
+		return nil
+	}
+
+	for _, vectorIndex := range collNs.GetVectorIndexMap() {
+		if err := vectorIndex.DeleteVectorFromMemory(ctx, t.Key); err != nil {
+			return err
+		}
+	}
+	if err := collNs.DeleteTextFromMemory(ctx, t.Key); err != nil {
+		return err
+	}
+	journalDelete(ctx, collNs, t.Key)
+	return nil
+}
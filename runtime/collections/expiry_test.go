@@ -0,0 +1,100 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTtls(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{
+		Collections: map[string]manifest.CollectionInfo{
+			"sessions": {Ttl: 60},
+			"docs":     {},
+		},
+	})
+
+	ttls, err := resolveTtls("docs", nil, 2)
+	require.NoError(t, err)
+	assert.Nil(t, ttls, "nothing expires without a ttl")
+
+	ttls, err = resolveTtls("docs", []int64{10, 0}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 0}, ttls)
+
+	ttls, err = resolveTtls("sessions", nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{60, 60}, ttls)
+
+	ttls, err = resolveTtls("sessions", []int64{10, 0}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 60}, ttls, "items without a ttl use the ttl of the collection")
+
+	_, err = resolveTtls("docs", []int64{-1, 0}, 2)
+	assert.Error(t, err)
+
+	_, err = resolveTtls("docs", []int64{10}, 2)
+	assert.Error(t, err)
+}
+
+func TestEvictExpired(t *testing.T) {
+	ctx := context.Background()
+	manifestdata.SetManifest(&manifest.Manifest{
+		Collections: map[string]manifest.CollectionInfo{
+			"sessions": {Ttl: 60},
+		},
+	})
+
+	cf := newCollectionFactory()
+	col, err := cf.createCollection("sessions", newCollection())
+	require.NoError(t, err)
+	collNs, err := col.createCollectionNamespace("", in_mem.NewCollectionNamespace("sessions", ""))
+	require.NoError(t, err)
+	vi, err := createIndexObject("sessions", "", manifest.SearchMethodInfo{Embedder: "embed"}, "search")
+	require.NoError(t, err)
+	require.NoError(t, collNs.SetVectorIndex(ctx, "search", vi))
+
+	for id, key := range map[int64]string{1: "a", 2: "b", 4: "c"} {
+		require.NoError(t, collNs.InsertTextToMemory(ctx, id, key, "text "+key, nil, nil))
+		require.NoError(t, vi.InsertVectorToMemory(ctx, id, id, key, []float32{1, float32(id)}))
+	}
+
+	deleteExpiredTexts = func(ctx context.Context, limit int) ([]db.ExpiredCollectionText, error) {
+		return []db.ExpiredCollectionText{
+			{Id: 1, Collection: "sessions", Key: "a"},
+			// c was upserted again, as text 4, after text 3 expired
+			{Id: 3, Collection: "sessions", Key: "c"},
+			{Id: 5, Collection: "removed", Key: "d"},
+		}, nil
+	}
+	defer func() { deleteExpiredTexts = db.DeleteExpiredCollectionTexts }()
+
+	cf.evictExpired(ctx)
+
+	texts, err := collNs.GetTextMap(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"b": "text b", "c": "text c"}, texts)
+
+	vec, err := vi.GetVector(ctx, "a")
+	require.NoError(t, err)
+	assert.Nil(t, vec)
+	vec, err = vi.GetVector(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1, 4}, vec)
+}
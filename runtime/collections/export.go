@@ -0,0 +1,518 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem/hnsw"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+/*
+
+DESIGN NOTES:
+
+- An archive of a collection holds the items of each of its namespaces, with their vectors for each search method,
+  so that it can be imported into another runtime without computing any embeddings.  It is a gzipped gob stream of
+  a header, followed by one record per namespace.
+- Items and vectors are read from the database, which has the full-precision vectors even when an index is quantized.
+- HNSW graphs are slow to build, so they are archived too.  They are snapshotted before the items are read,
+  so any item written meanwhile is in the archive, and is added to the graph when it is imported.
+- Importing writes the items and vectors to the database like an upsert.  When a namespace is empty beforehand,
+  and its index matches the archived graph, the graph is restored rather than rebuilt.  Vectors are only imported
+  into search methods that have the same embedder as they had when exported.  Otherwise, the texts are embedded again.
+
+*/
+
+const (
+	collectionArchiveVersion = 1
+	importBatchSize          = 1000
+)
+
+type collectionArchiveHeader struct {
+	Version    int
+	Collection string
+	CreatedAt  time.Time
+
+	// Embedders are the embedders of each search method, which the vectors were computed with.
+	Embedders map[string]string
+}
+
+type namespaceArchive struct {
+	Namespace string
+	Keys      []string
+	Texts     []string
+	Labels    [][]string
+	Metadata  []string
+
+	// Vectors are those of each search method, in the same order as the keys.  An item without a vector has an empty one.
+	Vectors map[string][][]float32
+	Indexes []vectorIndexSnapshot
+}
+
+// ExportCollection writes an archive of every namespace of the collection to object storage.
+func ExportCollection(ctx context.Context, collectionName, uri string) (*CollectionSnapshotResult, error) {
+	loc, err := parseObjectUri(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := db.GetUniqueNamespaces(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(namespaces)
+
+	embedders := make(map[string]string)
+	for searchMethodName, searchMethod := range manifestdata.GetManifest().Collections[collectionName].SearchMethods {
+		embedders[searchMethodName] = searchMethod.Embedder
+	}
+
+	start := time.Now()
+	f, err := os.CreateTemp("", "collection-*.archive")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	header := collectionArchiveHeader{
+		Version:    collectionArchiveVersion,
+		Collection: collectionName,
+		CreatedAt:  time.Now().UTC(),
+		Embedders:  embedders,
+	}
+	count, err := writeCollectionArchive(f, header, namespaces, func(namespace string) (*namespaceArchive, error) {
+		return readNamespaceArchive(ctx, col, collectionName, namespace, embedders)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write archive of collection %s: %w", collectionName, err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := loc.put(ctx, f); err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx).
+		Str("collection_name", collectionName).
+		Str("uri", uri).
+		Int("namespaces", len(namespaces)).
+		Int("items", count).
+		Dur("duration_ms", time.Since(start)).
+		Msg("Exported collection.")
+
+	return NewCollectionSnapshotResult(collectionName, "export", uri, namespaces, count), nil
+}
+
+// ImportCollection upserts the items of every namespace in the archive into the collection,
+// which doesn't need to have the same name as the collection that was exported.
+func ImportCollection(ctx context.Context, collectionName, uri string) (*CollectionSnapshotResult, error) {
+	loc, err := parseObjectUri(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := globalNamespaceManager.findCollection(collectionName); err != nil {
+		return nil, err
+	}
+
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	body, err := loc.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var namespaces []string
+	count := 0
+	err = readCollectionArchive(body, func(header *collectionArchiveHeader, ns *namespaceArchive) error {
+		if err := importNamespace(ctx, collectionName, header.Embedders, ns); err != nil {
+			return fmt.Errorf("failed to import namespace %q: %w", ns.Namespace, err)
+		}
+		namespaces = append(namespaces, ns.Namespace)
+		count += len(ns.Keys)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import collection %s: %w", collectionName, err)
+	}
+
+	logger.Info(ctx).
+		Str("collection_name", collectionName).
+		Str("uri", uri).
+		Int("namespaces", len(namespaces)).
+		Int("items", count).
+		Dur("duration_ms", time.Since(start)).
+		Msg("Imported collection.")
+
+	return NewCollectionSnapshotResult(collectionName, "import", uri, namespaces, count), nil
+}
+
+// writeCollectionArchive writes the header, followed by each namespace as it is read.  It returns the number of items written.
+func writeCollectionArchive(w io.Writer, header collectionArchiveHeader, namespaces []string, read func(namespace string) (*namespaceArchive, error)) (int, error) {
+	zw := gzip.NewWriter(w)
+	enc := gob.NewEncoder(zw)
+	if err := enc.Encode(header); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, namespace := range namespaces {
+		ns, err := read(namespace)
+		if err != nil {
+			return count, err
+		}
+		if err := enc.Encode(ns); err != nil {
+			return count, err
+		}
+		count += len(ns.Keys)
+	}
+	return count, zw.Close()
+}
+
+// readCollectionArchive calls fn with each namespace in the archive, one at a time.
+func readCollectionArchive(r io.Reader, fn func(header *collectionArchiveHeader, ns *namespaceArchive) error) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("not a collection archive: %w", err)
+	}
+	defer zr.Close()
+
+	dec := gob.NewDecoder(zr)
+	var header collectionArchiveHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("not a collection archive: %w", err)
+	}
+	if header.Version != collectionArchiveVersion {
+		return fmt.Errorf("unsupported collection archive version %d", header.Version)
+	}
+
+	for {
+		var ns namespaceArchive
+		if err := dec.Decode(&ns); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := ns.validate(); err != nil {
+			return err
+		}
+		if err := fn(&header, &ns); err != nil {
+			return err
+		}
+	}
+}
+
+func (ns *namespaceArchive) validate() error {
+	if len(ns.Texts) != len(ns.Keys) ||
+		(len(ns.Labels) != 0 && len(ns.Labels) != len(ns.Keys)) ||
+		(len(ns.Metadata) != 0 && len(ns.Metadata) != len(ns.Keys)) {
+		return fmt.Errorf("namespace %q of the archive is damaged", ns.Namespace)
+	}
+	for searchMethod, vecs := range ns.Vectors {
+		if len(vecs) != len(ns.Keys) {
+			return fmt.Errorf("vectors of search method %s in namespace %q of the archive are damaged", searchMethod, ns.Namespace)
+		}
+	}
+	return nil
+}
+
+// readNamespaceArchive reads the items of the namespace and their vectors from the database,
+// after taking snapshots of its HNSW graphs.
+func readNamespaceArchive(ctx context.Context, col *collection, collectionName, namespace string, embedders map[string]string) (*namespaceArchive, error) {
+	ns := &namespaceArchive{
+		Namespace: namespace,
+		Vectors:   make(map[string][][]float32, len(embedders)),
+	}
+
+	if collNs, err := col.findNamespace(namespace); err == nil {
+		for searchMethod, vi := range collNs.GetVectorIndexMap() {
+			sn, ok := vi.VectorIndex.(interfaces.Snapshotter)
+			if !ok || vi.Type != hnsw.HnswVectorIndexType {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := sn.WriteSnapshot(&buf); err != nil {
+				return nil, err
+			}
+			ns.Indexes = append(ns.Indexes, vectorIndexSnapshot{
+				SearchMethod: searchMethod,
+				Type:         vi.Type,
+				Distance:     vi.GetDistance(),
+				Normalize:    vi.GetNormalize(),
+				Quantization: vi.GetQuantization(),
+				Data:         buf.Bytes(),
+			})
+		}
+	}
+
+	textIds, keys, texts, labels, metadata, err := db.QueryCollectionTextsFromCheckpoint(ctx, collectionName, namespace, 0)
+	if err != nil {
+		return nil, err
+	}
+	ns.Keys = keys
+	ns.Texts = texts
+	ns.Labels = labels
+	ns.Metadata = make([]string, len(metadata))
+	for i, m := range metadata {
+		if m == nil {
+			continue
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		ns.Metadata[i] = string(b)
+	}
+
+	positions := make(map[int64]int, len(textIds))
+	for i, id := range textIds {
+		positions[id] = i
+	}
+	for searchMethod := range embedders {
+		vecTextIds, _, _, vectors, err := db.QueryCollectionVectorsFromCheckpoint(ctx, collectionName, searchMethod, namespace, 0)
+		if err != nil {
+			return nil, err
+		}
+		vecs := make([][]float32, len(keys))
+		for i, id := range vecTextIds {
+			if p, ok := positions[id]; ok {
+				vecs[p] = vectors[i]
+			}
+		}
+		ns.Vectors[searchMethod] = vecs
+	}
+
+	return ns, nil
+}
+
+// importNamespace upserts the items of the namespace, with the vectors of each search method that has the same embedder.
+func importNamespace(ctx context.Context, collectionName string, embedders map[string]string, ns *namespaceArchive) error {
+	if len(ns.Keys) == 0 {
+		return nil
+	}
+
+	collNs, err := getOrCreateNamespace(ctx, globalNamespaceManager, collectionName, ns.Namespace)
+	if err != nil {
+		return err
+	}
+	size, err := collNs.Len(ctx)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := parseMetadata(ns.Metadata)
+	if err != nil {
+		return err
+	}
+
+	// decide how to fill each vector index
+	vectorIndexes := collNs.GetVectorIndexMap()
+	usable := make(map[string]bool, len(vectorIndexes))
+	restorable := make(map[string]*vectorIndexSnapshot)
+	for searchMethod, vi := range vectorIndexes {
+		vecs, ok := ns.Vectors[searchMethod]
+		usable[searchMethod] = ok && embedders[searchMethod] == vi.GetEmbedderName()
+		if !usable[searchMethod] || size != 0 || slices.ContainsFunc(vecs, func(v []float32) bool { return len(v) == 0 }) {
+			continue
+		}
+		for i, ix := range ns.Indexes {
+			if _, ok := vi.VectorIndex.(interfaces.CheckpointSetter); ok && ix.SearchMethod == searchMethod && ix.matches(vi) {
+				restorable[searchMethod] = &ns.Indexes[i]
+			}
+		}
+	}
+
+	textIds := make([]int64, 0, len(ns.Keys))
+	vectorIds := make(map[string][]int64, len(restorable))
+	for start := 0; start < len(ns.Keys); start += importBatchSize {
+		end := min(start+importBatchSize, len(ns.Keys))
+		keys := ns.Keys[start:end]
+		texts := ns.Texts[start:end]
+		var labels [][]string
+		if len(ns.Labels) != 0 {
+			labels = ns.Labels[start:end]
+		}
+		var batchMetadata []map[string]any
+		if len(metadata) != 0 {
+			batchMetadata = metadata[start:end]
+		}
+
+		if err := collNs.InsertTexts(ctx, keys, texts, labels, batchMetadata); err != nil {
+			return err
+		}
+		ids := make([]int64, len(keys))
+		for i, key := range keys {
+			if ids[i], err = collNs.GetExternalId(ctx, key); err != nil {
+				return err
+			}
+		}
+		textIds = append(textIds, ids...)
+		journalTexts(ctx, collNs, ids, keys, texts, labels, batchMetadata)
+
+		for searchMethod, vi := range vectorIndexes {
+			if !usable[searchMethod] {
+				if err := processTexts(ctx, collNs, vi, keys, texts); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// a graph that is restored is filled from the snapshot, so the vectors are only written to the database
+			vecs := ns.Vectors[searchMethod][start:end]
+			if restorable[searchMethod] != nil {
+				written, _, err := db.WriteCollectionVectors(ctx, searchMethod, ids, vecs)
+				if err != nil {
+					return err
+				}
+				vectorIds[searchMethod] = append(vectorIds[searchMethod], written...)
+				continue
+			}
+
+			var vecIds []int64
+			var vecKeys, missingKeys, missingTexts []string
+			var present [][]float32
+			for i, vec := range vecs {
+				if len(vec) == 0 {
+					missingKeys = append(missingKeys, keys[i])
+					missingTexts = append(missingTexts, texts[i])
+					continue
+				}
+				vecIds = append(vecIds, ids[i])
+				vecKeys = append(vecKeys, keys[i])
+				present = append(present, vec)
+			}
+			if len(present) > 0 {
+				if err := vi.InsertVectors(ctx, vecIds, present); err != nil {
+					return err
+				}
+				journalVectors(ctx, collNs, vi, vecIds, nil, vecKeys, present)
+			}
+			if err := processTexts(ctx, collNs, vi, missingKeys, missingTexts); err != nil {
+				return err
+			}
+		}
+	}
+
+	for searchMethod, ix := range restorable {
+		restoreArchivedIndex(ctx, collNs, vectorIndexes[searchMethod], ix, textIds, vectorIds[searchMethod], ns.Keys, ns.Vectors[searchMethod])
+	}
+	return nil
+}
+
+// restoreArchivedIndex restores the archived graph, and adds any item that isn't in it.  If the graph can't be restored,
+// the vectors are inserted instead.  The vectors are not journaled, since the graph is restored from the database
+// if the runtime stops before its next snapshot.
+func restoreArchivedIndex(ctx context.Context, collNs interfaces.CollectionNamespace, vi *interfaces.VectorIndexWrapper, ix *vectorIndexSnapshot, textIds, vectorIds []int64, keys []string, vecs [][]float32) {
+	err := vi.VectorIndex.(interfaces.Snapshotter).ReadSnapshot(bytes.NewReader(ix.Data))
+	if err == nil {
+		for i, key := range keys {
+			if vec, _ := vi.GetVector(ctx, key); vec != nil {
+				continue
+			}
+			if err = vi.InsertVectorToMemory(ctx, textIds[i], vectorIds[i], key, vecs[i]); err != nil {
+				break
+			}
+		}
+		vi.VectorIndex.(interfaces.CheckpointSetter).SetCheckpoints(slices.Max(vectorIds), slices.Max(textIds))
+	}
+
+	if err != nil {
+		logger.Warn(ctx).Err(err).
+			Str("collection_name", collNs.GetCollectionName()).
+			Str("search_method", ix.SearchMethod).
+			Msg("Failed to restore the archived vector index.  Inserting its vectors instead.")
+		if err := batchInsertVectorsToMemory(ctx, vi, textIds, vectorIds, keys, vecs); err != nil {
+			logger.Err(ctx, err).Str("collection_name", collNs.GetCollectionName()).Str("search_method", ix.SearchMethod).Msg("Failed to insert the archived vectors.")
+		}
+	}
+
+	if store := globalCollectionStore; store != nil {
+		store.markDirty()
+		store.requestSnapshot()
+	}
+}
+
+// ExportHandler exports a collection to object storage (POST with "collection" and "uri" query parameters).
+func ExportHandler(w http.ResponseWriter, r *http.Request) {
+	handleSnapshotRequest(w, r, "export", ExportCollection)
+}
+
+// ImportHandler imports a collection from object storage (POST with "collection" and "uri" query parameters).
+func ImportHandler(w http.ResponseWriter, r *http.Request) {
+	handleSnapshotRequest(w, r, "import", ImportCollection)
+}
+
+func handleSnapshotRequest(w http.ResponseWriter, r *http.Request, operation string, fn func(ctx context.Context, collectionName, uri string) (*CollectionSnapshotResult, error)) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	collectionName := query.Get("collection")
+	uri := query.Get("uri")
+	if collectionName == "" || uri == "" {
+		http.Error(w, "A collection and a uri are required.", http.StatusBadRequest)
+		return
+	}
+	if _, err := parseObjectUri(uri); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := fn(ctx, collectionName, uri)
+	if errors.Is(err, errCollectionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		logger.Err(ctx, err).Str("collection_name", collectionName).Str("uri", uri).Msgf("Failed to %s collection.", operation)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJsonResponse(w, result)
+}
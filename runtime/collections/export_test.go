@@ -0,0 +1,128 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem/hnsw"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseObjectUri(t *testing.T) {
+	tests := []struct {
+		uri     string
+		want    *objectLocation
+		wantErr string
+	}{
+		{"s3://bucket/backups/docs.archive", &objectLocation{scheme: "s3", bucket: "bucket", key: "backups/docs.archive"}, ""},
+		{"gs://bucket/docs.archive", &objectLocation{scheme: "gs", bucket: "bucket", key: "docs.archive"}, ""},
+		{"s3:///docs.archive", nil, "a bucket is required"},
+		{"s3://bucket/backups/", nil, "an object key is required"},
+		{"file:///tmp/docs.archive", nil, "only be used"},
+		{"https://example.com/docs.archive", nil, "unsupported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, err := parseObjectUri(tt.uri)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCollectionArchive(t *testing.T) {
+	ctx := context.Background()
+
+	// the source graph has "a" and "b", but "c" was written after its snapshot was taken
+	source := hnsw.NewHnswVectorIndex("approx", "embed", manifest.OptionsInfo{}, "", false)
+	require.NoError(t, source.InsertVectorsToMemory(ctx, []int64{101, 102}, []int64{201, 202}, []string{"a", "b"}, [][]float32{{1, 0}, {0, 1}}))
+	var graph bytes.Buffer
+	require.NoError(t, source.WriteSnapshot(&graph))
+
+	namespaces := map[string]*namespaceArchive{
+		"": {
+			Keys:     []string{"a", "b", "c"},
+			Texts:    []string{"apple", "banana", "cherry"},
+			Metadata: []string{`{"color":"red"}`, "", ""},
+			Vectors:  map[string][][]float32{"approx": {{1, 0}, {0, 1}, {1, 1}}, "exact": {{1, 0}, nil, {1, 1}}},
+			Indexes:  []vectorIndexSnapshot{{SearchMethod: "approx", Type: hnsw.HnswVectorIndexType, Data: graph.Bytes()}},
+		},
+		"other": {Namespace: "other", Keys: []string{"d"}, Texts: []string{"date"}},
+	}
+
+	var buf bytes.Buffer
+	header := collectionArchiveHeader{Version: collectionArchiveVersion, Collection: "docs", Embedders: map[string]string{"approx": "embed"}}
+	count, err := writeCollectionArchive(&buf, header, []string{"", "other"}, func(namespace string) (*namespaceArchive, error) {
+		return namespaces[namespace], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	var read []*namespaceArchive
+	require.NoError(t, readCollectionArchive(bytes.NewReader(buf.Bytes()), func(h *collectionArchiveHeader, ns *namespaceArchive) error {
+		assert.Equal(t, "docs", h.Collection)
+		assert.Equal(t, "embed", h.Embedders["approx"])
+		read = append(read, ns)
+		return nil
+	}))
+	require.Len(t, read, 2)
+	assert.Equal(t, namespaces[""].Keys, read[0].Keys)
+	assert.Equal(t, namespaces[""].Metadata, read[0].Metadata)
+	assert.Empty(t, read[0].Vectors["exact"][1], "a missing vector stays empty")
+	assert.Equal(t, "other", read[1].Namespace)
+
+	// the graph is restored with the ids of this database, and the item that wasn't in it is added
+	target := &interfaces.VectorIndexWrapper{
+		Type:        hnsw.HnswVectorIndexType,
+		VectorIndex: hnsw.NewHnswVectorIndex("approx", "embed", manifest.OptionsInfo{}, "", false),
+	}
+	require.True(t, read[0].Indexes[0].matches(target))
+	restoreArchivedIndex(ctx, in_mem.NewCollectionNamespace("docs", ""), target, &read[0].Indexes[0], []int64{1, 2, 3}, []int64{11, 12, 13}, read[0].Keys, read[0].Vectors["approx"])
+
+	for _, key := range []string{"a", "b", "c"} {
+		vec, err := target.GetVector(ctx, key)
+		require.NoError(t, err)
+		assert.NotNil(t, vec, key)
+	}
+	checkpoint, err := target.GetCheckpointId(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), checkpoint)
+	lastTextId, err := target.GetLastIndexedTextId(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), lastTextId)
+}
+
+func TestCollectionArchiveDamaged(t *testing.T) {
+	var buf bytes.Buffer
+	header := collectionArchiveHeader{Version: collectionArchiveVersion, Collection: "docs"}
+	_, err := writeCollectionArchive(&buf, header, []string{""}, func(namespace string) (*namespaceArchive, error) {
+		return &namespaceArchive{Keys: []string{"a", "b"}, Texts: []string{"apple", "banana"}, Vectors: map[string][][]float32{"exact": {{1}}}}, nil
+	})
+	require.NoError(t, err)
+
+	err = readCollectionArchive(&buf, func(*collectionArchiveHeader, *namespaceArchive) error { return nil })
+	assert.ErrorContains(t, err, "damaged")
+
+	err = readCollectionArchive(bytes.NewReader([]byte("not gzip")), func(*collectionArchiveHeader, *namespaceArchive) error { return nil })
+	assert.ErrorContains(t, err, "not a collection archive")
+}
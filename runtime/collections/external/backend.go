@@ -0,0 +1,130 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/hosts"
+	"github.com/hypermodeinc/modus/runtime/httpclient"
+)
+
+// A backend stores the vectors of a search method in an external vector database.  The vectors of every namespace
+// of the collection are kept in the same table, collection, or index, and are scoped to their namespace.
+type backend interface {
+	// upsert adds the vectors with the keys, replacing any vectors that already have the keys
+	upsert(ctx context.Context, namespace string, keys []string, vecs [][]float32) error
+
+	// search returns up to k of the vectors closest to the query, along with their keys, closest first
+	search(ctx context.Context, namespace string, query []float32, k int) ([]candidate, error)
+
+	// delete removes the vectors with the keys, ignoring keys that don't exist
+	delete(ctx context.Context, namespace string, keys []string) error
+
+	// fetch returns the vector with the key, or nil if there isn't one
+	fetch(ctx context.Context, namespace string, key string) ([]float32, error)
+}
+
+type candidate struct {
+	key    string
+	vector []float32
+}
+
+// newBackend creates the backend for the index type of the manifest.
+func newBackend(indexType, host, name, distance string) (backend, error) {
+	switch indexType {
+	case manifest.IndexTypePgvector:
+		return &pgvectorBackend{host: host, table: name, distance: distance}, nil
+	case manifest.IndexTypeQdrant:
+		return &qdrantBackend{host: host, collection: name, distance: distance}, nil
+	case manifest.IndexTypePinecone:
+		return &pineconeBackend{host: host, prefix: name}, nil
+	default:
+		return nil, fmt.Errorf("unknown external index type: %s", indexType)
+	}
+}
+
+// defaultName derives the name of the table, collection, or namespace prefix of a search method from the names
+// of its collection and search method, using only the characters that every backend allows.
+func defaultName(collectionName, searchMethodName string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '_'
+		}
+	}, collectionName+"_"+searchMethodName)
+}
+
+// errNotFound is returned by doJson when the server responds with 404 Not Found.
+var errNotFound = errors.New("not found")
+
+// doJson sends a JSON request to a path of the http host, and decodes the JSON response into result, if it isn't nil.
+// The host's headers and secrets are applied, so the host carries the URL and API key of the vector database.
+func doJson(ctx context.Context, hostName, method, path string, body, result any) error {
+	host, err := hosts.GetHttpHost(hostName)
+	if err != nil {
+		return err
+	}
+	base := host.BaseURL
+	if base == "" {
+		base = host.Endpoint
+	}
+	if base == "" {
+		return fmt.Errorf("host %s does not have a base url or endpoint", hostName)
+	}
+
+	request := &httpclient.HttpRequest{
+		Url:     strings.TrimSuffix(base, "/") + path,
+		Method:  method,
+		Headers: &httpclient.HttpHeaders{Data: map[string]*httpclient.HttpHeader{}},
+	}
+	if body != nil {
+		request.Body, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		request.Headers.Data["content-type"] = &httpclient.HttpHeader{
+			Name:   "Content-Type",
+			Values: []string{"application/json"},
+		}
+	}
+
+	response, err := httpclient.HttpFetchFromHost(ctx, host, request)
+	if err != nil {
+		return err
+	}
+	if response.Status == http.StatusNotFound {
+		return errNotFound
+	}
+	if response.Error != nil {
+		if response.Status == 0 {
+			return fmt.Errorf("request to host %s failed: %s", hostName, response.Error.Message)
+		}
+		return fmt.Errorf("request to host %s failed with status %d %s: %s", hostName, response.Status, response.StatusText, response.Body)
+	}
+
+	if result == nil || len(response.Body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(response.Body, result); err != nil {
+		return fmt.Errorf("invalid response from host %s: %w", hostName, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/sqlclient"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgvectorBackend stores vectors in a table of a PostgreSQL host, using the pgvector extension.  The table and its
+// HNSW index are created when vectors are first inserted, if they don't exist.  The connection pool is shared with
+// the database host functions, and is looked up for each operation, since it is replaced when the manifest changes.
+type pgvectorBackend struct {
+	host     string
+	table    string
+	distance string
+	mu       sync.Mutex
+	ready    bool
+}
+
+// pgUndefinedTable is the PostgreSQL error code for a table that doesn't exist.
+const pgUndefinedTable = "42P01"
+
+// operators returns the pgvector distance operator and index operator class of the distance metric.
+func (b *pgvectorBackend) operators() (string, string) {
+	switch b.distance {
+	case manifest.DistanceDot:
+		return "<#>", "vector_ip_ops"
+	case manifest.DistanceEuclidean:
+		return "<->", "vector_l2_ops"
+	default:
+		return "<=>", "vector_cosine_ops"
+	}
+}
+
+func (b *pgvectorBackend) tableName() string {
+	return pgx.Identifier{b.table}.Sanitize()
+}
+
+// formatVector formats the vector as pgvector text, such as [1,2,3].
+func formatVector(vec []float32) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, f := range vec {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+func (b *pgvectorBackend) ensure(ctx context.Context, dimensions int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ready {
+		return nil
+	}
+
+	pool, err := sqlclient.GetPostgresPool(ctx, b.host)
+	if err != nil {
+		return err
+	}
+
+	_, opClass := b.operators()
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS vector",
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (namespace TEXT NOT NULL, key TEXT NOT NULL, vector vector(%d) NOT NULL, PRIMARY KEY (namespace, key))", b.tableName(), dimensions),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING hnsw (vector %s)", pgx.Identifier{b.table + "_vector_idx"}.Sanitize(), b.tableName(), opClass),
+	}
+	for _, stmt := range statements {
+		if _, err := pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create pgvector table %s: %w", b.table, err)
+		}
+	}
+
+	b.ready = true
+	return nil
+}
+
+func (b *pgvectorBackend) upsert(ctx context.Context, namespace string, keys []string, vecs [][]float32) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := b.ensure(ctx, len(vecs[0])); err != nil {
+		return err
+	}
+	pool, err := sqlclient.GetPostgresPool(ctx, b.host)
+	if err != nil {
+		return err
+	}
+
+	values := make([]string, len(vecs))
+	for i, vec := range vecs {
+		values[i] = formatVector(vec)
+	}
+	stmt := fmt.Sprintf(`INSERT INTO %s (namespace, key, vector)
+		SELECT $1, k, v::vector FROM unnest($2::text[], $3::text[]) AS u(k, v)
+		ON CONFLICT (namespace, key) DO UPDATE SET vector = excluded.vector`, b.tableName())
+	_, err = pool.Exec(ctx, stmt, namespace, keys, values)
+	return err
+}
+
+func (b *pgvectorBackend) search(ctx context.Context, namespace string, query []float32, k int) ([]candidate, error) {
+	pool, err := sqlclient.GetPostgresPool(ctx, b.host)
+	if err != nil {
+		return nil, err
+	}
+
+	op, _ := b.operators()
+	stmt := fmt.Sprintf("SELECT key, vector::real[] FROM %s WHERE namespace = $1 ORDER BY vector %s $2::vector LIMIT $3", b.tableName(), op)
+	rows, err := pool.Query(ctx, stmt, namespace, formatVector(query), k)
+	if isUndefinedTable(err) {
+		// nothing has been inserted yet
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.key, &c.vector); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); isUndefinedTable(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+func (b *pgvectorBackend) delete(ctx context.Context, namespace string, keys []string) error {
+	pool, err := sqlclient.GetPostgresPool(ctx, b.host)
+	if err != nil {
+		return err
+	}
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE namespace = $1 AND key = ANY($2)", b.tableName())
+	if _, err := pool.Exec(ctx, stmt, namespace, keys); err != nil && !isUndefinedTable(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *pgvectorBackend) fetch(ctx context.Context, namespace string, key string) ([]float32, error) {
+	pool, err := sqlclient.GetPostgresPool(ctx, b.host)
+	if err != nil {
+		return nil, err
+	}
+	var vec []float32
+	stmt := fmt.Sprintf("SELECT vector::real[] FROM %s WHERE namespace = $1 AND key = $2", b.tableName())
+	err = pool.QueryRow(ctx, stmt, namespace, key).Scan(&vec)
+	if errors.Is(err, pgx.ErrNoRows) || isUndefinedTable(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return vec, nil
+}
+
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable
+}
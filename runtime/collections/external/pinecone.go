@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package external
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// pineconeBackend stores vectors in a Pinecone index, using its data plane API at the URL of the host.
+// Each namespace of the collection is stored in its own Pinecone namespace, named <prefix>:<namespace>.
+// The index must already exist, with the dimensions and metric of the search method.
+type pineconeBackend struct {
+	host   string
+	prefix string
+}
+
+// pineconeMaxTopK is the most results a Pinecone query can return.
+const pineconeMaxTopK = 10000
+
+type pineconeVector struct {
+	Id     string    `json:"id"`
+	Values []float32 `json:"values"`
+}
+
+func (b *pineconeBackend) namespace(namespace string) string {
+	return b.prefix + ":" + namespace
+}
+
+func (b *pineconeBackend) upsert(ctx context.Context, namespace string, keys []string, vecs [][]float32) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	vectors := make([]pineconeVector, len(keys))
+	for i, key := range keys {
+		vectors[i] = pineconeVector{Id: key, Values: vecs[i]}
+	}
+	return doJson(ctx, b.host, http.MethodPost, "/vectors/upsert", map[string]any{
+		"vectors":   vectors,
+		"namespace": b.namespace(namespace),
+	}, nil)
+}
+
+func (b *pineconeBackend) search(ctx context.Context, namespace string, query []float32, k int) ([]candidate, error) {
+	var response struct {
+		Matches []pineconeVector `json:"matches"`
+	}
+	err := doJson(ctx, b.host, http.MethodPost, "/query", map[string]any{
+		"namespace":     b.namespace(namespace),
+		"vector":        query,
+		"topK":          min(k, pineconeMaxTopK),
+		"includeValues": true,
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]candidate, len(response.Matches))
+	for i, m := range response.Matches {
+		candidates[i] = candidate{key: m.Id, vector: m.Values}
+	}
+	return candidates, nil
+}
+
+func (b *pineconeBackend) delete(ctx context.Context, namespace string, keys []string) error {
+	return doJson(ctx, b.host, http.MethodPost, "/vectors/delete", map[string]any{
+		"ids":       keys,
+		"namespace": b.namespace(namespace),
+	}, nil)
+}
+
+func (b *pineconeBackend) fetch(ctx context.Context, namespace string, key string) ([]float32, error) {
+	var response struct {
+		Vectors map[string]pineconeVector `json:"vectors"`
+	}
+	query := url.Values{"ids": {key}, "namespace": {b.namespace(namespace)}}
+	err := doJson(ctx, b.host, http.MethodGet, "/vectors/fetch?"+query.Encode(), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := response.Vectors[key]; ok {
+		return v.Values, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package external
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"github.com/google/uuid"
+)
+
+// qdrantBackend stores vectors in a Qdrant collection, using its REST API.  The collection is created when vectors are
+// first inserted, if it doesn't exist.  Qdrant requires ids to be integers or UUIDs, so each point has a UUID derived
+// from its namespace and key, and the namespace and key are stored in its payload.
+type qdrantBackend struct {
+	host       string
+	collection string
+	distance   string
+	mu         sync.Mutex
+	ready      bool
+}
+
+type qdrantPoint struct {
+	Id      string         `json:"id"`
+	Vector  []float32      `json:"vector,omitempty"`
+	Payload map[string]any `json:"payload,omitempty"`
+}
+
+type qdrantPointsResponse struct {
+	Result []qdrantPoint `json:"result"`
+}
+
+func (b *qdrantBackend) path(suffix string) string {
+	return "/collections/" + url.PathEscape(b.collection) + suffix
+}
+
+func qdrantPointId(namespace, key string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(namespace+"\x00"+key)).String()
+}
+
+func qdrantNamespaceFilter(namespace string) map[string]any {
+	return map[string]any{
+		"must": []map[string]any{
+			{"key": "namespace", "match": map[string]any{"value": namespace}},
+		},
+	}
+}
+
+// ensure creates the collection for vectors of the dimensions, along with an index of the namespaces of its points,
+// unless it already exists.
+func (b *qdrantBackend) ensure(ctx context.Context, dimensions int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ready {
+		return nil
+	}
+
+	err := doJson(ctx, b.host, http.MethodGet, b.path(""), nil, nil)
+	if errors.Is(err, errNotFound) {
+		distance := "Cosine"
+		switch b.distance {
+		case manifest.DistanceDot:
+			distance = "Dot"
+		case manifest.DistanceEuclidean:
+			distance = "Euclid"
+		}
+		err = doJson(ctx, b.host, http.MethodPut, b.path(""), map[string]any{
+			"vectors": map[string]any{"size": dimensions, "distance": distance},
+		}, nil)
+		if err != nil {
+			return err
+		}
+		err = doJson(ctx, b.host, http.MethodPut, b.path("/index?wait=true"), map[string]any{
+			"field_name":   "namespace",
+			"field_schema": "keyword",
+		}, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	b.ready = true
+	return nil
+}
+
+func (b *qdrantBackend) upsert(ctx context.Context, namespace string, keys []string, vecs [][]float32) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := b.ensure(ctx, len(vecs[0])); err != nil {
+		return err
+	}
+
+	points := make([]qdrantPoint, len(keys))
+	for i, key := range keys {
+		points[i] = qdrantPoint{
+			Id:      qdrantPointId(namespace, key),
+			Vector:  vecs[i],
+			Payload: map[string]any{"namespace": namespace, "key": key},
+		}
+	}
+	return doJson(ctx, b.host, http.MethodPut, b.path("/points?wait=true"), map[string]any{"points": points}, nil)
+}
+
+func (b *qdrantBackend) search(ctx context.Context, namespace string, query []float32, k int) ([]candidate, error) {
+	var response qdrantPointsResponse
+	err := doJson(ctx, b.host, http.MethodPost, b.path("/points/search"), map[string]any{
+		"vector":       query,
+		"limit":        k,
+		"filter":       qdrantNamespaceFilter(namespace),
+		"with_payload": true,
+		"with_vector":  true,
+	}, &response)
+	if errors.Is(err, errNotFound) {
+		// nothing has been inserted yet
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]candidate, 0, len(response.Result))
+	for _, p := range response.Result {
+		key, _ := p.Payload["key"].(string)
+		candidates = append(candidates, candidate{key: key, vector: p.Vector})
+	}
+	return candidates, nil
+}
+
+func (b *qdrantBackend) delete(ctx context.Context, namespace string, keys []string) error {
+	ids := make([]string, len(keys))
+	for i, key := range keys {
+		ids[i] = qdrantPointId(namespace, key)
+	}
+	err := doJson(ctx, b.host, http.MethodPost, b.path("/points/delete?wait=true"), map[string]any{"points": ids}, nil)
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (b *qdrantBackend) fetch(ctx context.Context, namespace string, key string) ([]float32, error) {
+	var response qdrantPointsResponse
+	err := doJson(ctx, b.host, http.MethodPost, b.path("/points"), map[string]any{
+		"ids":         []string{qdrantPointId(namespace, key)},
+		"with_vector": true,
+	}, &response)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(response.Result) == 0 {
+		return nil, nil
+	}
+	return response.Result[0].Vector, nil
+}
@@ -0,0 +1,326 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package external
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/index"
+	"github.com/hypermodeinc/modus/runtime/collections/utils"
+	"github.com/hypermodeinc/modus/runtime/db"
+)
+
+const (
+	PgvectorVectorIndexType = "PgvectorVectorIndex"
+	QdrantVectorIndexType   = "QdrantVectorIndex"
+	PineconeVectorIndexType = "PineconeVectorIndex"
+
+	// upsertBatchSize is the most vectors sent to the backend in one request.
+	upsertBatchSize = 100
+
+	// maxCandidates is the most candidates requested from the backend when the filter discards many of them.
+	maxCandidates = 10000
+)
+
+// VectorIndexType returns the type of the vector index created for the external index type in the manifest,
+// or an empty string if the index type isn't external.
+func VectorIndexType(manifestType string) string {
+	switch manifestType {
+	case manifest.IndexTypePgvector:
+		return PgvectorVectorIndexType
+	case manifest.IndexTypeQdrant:
+		return QdrantVectorIndexType
+	case manifest.IndexTypePinecone:
+		return PineconeVectorIndexType
+	default:
+		return ""
+	}
+}
+
+// ExternalVectorIndex keeps the vectors of a search method, in one namespace of a collection, in an external vector
+// database.  Vectors are still written to the collection_vectors table, which remains the source of truth that the
+// external database is synced from, so the checkpoints of the index work the same as those of the in-memory indexes.
+// The backend finds the nearest candidates, and their distances are computed here with the distance metric of the
+// search method, so scores are the same as those of the in-memory indexes.
+type ExternalVectorIndex struct {
+	mu                sync.RWMutex
+	searchMethodName  string
+	embedderName      string
+	namespace         string
+	distance          string
+	normalize         bool
+	info              manifest.IndexInfo
+	backend           backend
+	lastInsertedID    int64
+	lastIndexedTextID int64
+}
+
+// NewExternalVectorIndex creates an index of the search method, for the namespace of the collection, that keeps its
+// vectors in the vector database of the search method's index.
+func NewExternalVectorIndex(collectionName, namespace, searchMethodName string, searchMethod manifest.SearchMethodInfo) (*ExternalVectorIndex, error) {
+	info := searchMethod.Index
+	if info.Host == "" {
+		return nil, fmt.Errorf("a host is required for %s indexes", info.Type)
+	}
+	if searchMethod.Quantization.Type != "" {
+		return nil, fmt.Errorf("quantization is not supported by %s indexes", info.Type)
+	}
+
+	name := cmp.Or(info.Name, defaultName(collectionName, searchMethodName))
+	b, err := newBackend(info.Type, info.Host, name, searchMethod.GetDistance())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExternalVectorIndex{
+		searchMethodName: searchMethodName,
+		embedderName:     searchMethod.Embedder,
+		namespace:        namespace,
+		distance:         searchMethod.GetDistance(),
+		normalize:        searchMethod.Normalize,
+		info:             info,
+		backend:          b,
+	}, nil
+}
+
+// GetIndexInfo returns the index configuration the index was created with.
+func (ix *ExternalVectorIndex) GetIndexInfo() manifest.IndexInfo {
+	return ix.info
+}
+
+func (ix *ExternalVectorIndex) GetDistance() string {
+	return ix.distance
+}
+
+func (ix *ExternalVectorIndex) GetNormalize() bool {
+	return ix.normalize
+}
+
+// GetQuantization returns no quantization, since the vector database is responsible for how its vectors are stored.
+func (ix *ExternalVectorIndex) GetQuantization() manifest.QuantizationInfo {
+	return manifest.QuantizationInfo{}
+}
+
+func (ix *ExternalVectorIndex) GetSearchMethodName() string {
+	return ix.searchMethodName
+}
+
+func (ix *ExternalVectorIndex) SetEmbedderName(embedderName string) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.embedderName = embedderName
+	return nil
+}
+
+func (ix *ExternalVectorIndex) GetEmbedderName() string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.embedderName
+}
+
+func (ix *ExternalVectorIndex) Search(ctx context.Context, query []float32, maxResults int, filter index.SearchFilter) (utils.MaxTupleHeap, error) {
+	if maxResults <= 0 {
+		maxResults = 1
+	}
+	query = utils.PrepareVector(query, ix.normalize)
+
+	// The backend doesn't know about the filter, so ask for more candidates until enough of them pass it,
+	// or until the backend has no more of them.
+	var results utils.MaxTupleHeap
+	for k := maxResults; ; k = min(k*4, maxCandidates) {
+		candidates, err := ix.backend.search(ctx, ix.namespace, query, k)
+		if err != nil {
+			return nil, err
+		}
+
+		results = results[:0]
+		for _, c := range candidates {
+			if filter != nil && !filter(query, c.vector, c.key) {
+				continue
+			}
+			distance, err := utils.Distance(ix.distance, query, c.vector)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, utils.InitHeapElement(distance, c.key, false))
+		}
+		slices.SortStableFunc(results, func(a, b utils.MaxHeapElement) int {
+			return cmp.Compare(a.GetValue(), b.GetValue())
+		})
+		if len(results) > maxResults {
+			results = results[:maxResults]
+		}
+
+		if len(results) == maxResults || len(candidates) < k || k == maxCandidates {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func (ix *ExternalVectorIndex) SearchWithKey(ctx context.Context, queryKey string, maxResults int, filter index.SearchFilter) (utils.MaxTupleHeap, error) {
+	query, err := ix.GetVector(ctx, queryKey)
+	if err != nil || query == nil {
+		return nil, err
+	}
+	return ix.Search(ctx, query, maxResults, filter)
+}
+
+func (ix *ExternalVectorIndex) InsertVectors(ctx context.Context, textIds []int64, vecs [][]float32) error {
+	if len(textIds) != len(vecs) {
+		return fmt.Errorf("textIds and vecs must have the same length")
+	}
+	vectorIds, keys, err := db.WriteCollectionVectors(ctx, ix.searchMethodName, textIds, vecs)
+	if err != nil {
+		return err
+	}
+
+	return ix.InsertVectorsToMemory(ctx, textIds, vectorIds, keys, vecs)
+}
+
+func (ix *ExternalVectorIndex) InsertVector(ctx context.Context, textId int64, vec []float32) error {
+	vectorId, key, err := db.WriteCollectionVector(ctx, ix.searchMethodName, textId, vec)
+	if err != nil {
+		return err
+	}
+
+	return ix.InsertVectorToMemory(ctx, textId, vectorId, key, vec)
+}
+
+// InsertVectorsToMemory upserts the vectors into the vector database.  Upserts replace vectors with the same keys,
+// so vectors that are synced again after a restart don't create duplicates.
+func (ix *ExternalVectorIndex) InsertVectorsToMemory(ctx context.Context, textIds []int64, vectorIds []int64, keys []string, vecs [][]float32) error {
+	if len(vectorIds) == 0 {
+		return nil
+	}
+	if len(keys) != len(vecs) {
+		return errors.New("keys and vecs must have the same length")
+	}
+	if ix.normalize {
+		normalized := make([][]float32, len(vecs))
+		for i, vec := range vecs {
+			normalized[i] = utils.PrepareVector(vec, true)
+		}
+		vecs = normalized
+	}
+
+	for i := 0; i < len(keys); i += upsertBatchSize {
+		end := min(i+upsertBatchSize, len(keys))
+		if err := ix.backend.upsert(ctx, ix.namespace, keys[i:end], vecs[i:end]); err != nil {
+			return err
+		}
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.lastInsertedID = max(ix.lastInsertedID, slices.Max(vectorIds))
+	ix.lastIndexedTextID = max(ix.lastIndexedTextID, slices.Max(textIds))
+	return nil
+}
+
+func (ix *ExternalVectorIndex) InsertVectorToMemory(ctx context.Context, textId, vectorId int64, key string, vec []float32) error {
+	return ix.InsertVectorsToMemory(ctx, []int64{textId}, []int64{vectorId}, []string{key}, [][]float32{vec})
+}
+
+func (ix *ExternalVectorIndex) DeleteVector(ctx context.Context, textId int64, key string) error {
+	if err := db.DeleteCollectionVector(ctx, ix.searchMethodName, textId); err != nil {
+		return err
+	}
+	return ix.backend.delete(ctx, ix.namespace, []string{key})
+}
+
+func (ix *ExternalVectorIndex) DeleteVectorFromMemory(ctx context.Context, key string) error {
+	return ix.backend.delete(ctx, ix.namespace, []string{key})
+}
+
+func (ix *ExternalVectorIndex) GetVector(ctx context.Context, key string) ([]float32, error) {
+	return ix.backend.fetch(ctx, ix.namespace, key)
+}
+
+func (ix *ExternalVectorIndex) GetCheckpointId(ctx context.Context) (int64, error) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.lastInsertedID, nil
+}
+
+func (ix *ExternalVectorIndex) GetLastIndexedTextId(ctx context.Context) (int64, error) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.lastIndexedTextID, nil
+}
+
+// WriteSnapshot writes the checkpoints of the index, and where its vectors are stored.  The vectors themselves
+// are in the vector database, so a restored index only needs to sync the vectors written after the checkpoints.
+func (ix *ExternalVectorIndex) WriteSnapshot(w io.Writer) error {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if err := binary.Write(w, binary.LittleEndian, [2]int64{ix.lastInsertedID, ix.lastIndexedTextID}); err != nil {
+		return err
+	}
+	return writeString(w, ix.info.Host+"\x00"+ix.info.Name)
+}
+
+// ReadSnapshot restores the checkpoints of the index from a snapshot.  A snapshot of an index that stored its vectors
+// somewhere else is ignored, so that all of the vectors are synced to the new location.
+func (ix *ExternalVectorIndex) ReadSnapshot(r io.Reader) error {
+	var checkpoints [2]int64
+	if err := binary.Read(r, binary.LittleEndian, &checkpoints); err != nil {
+		return err
+	}
+	location, err := readString(r)
+	if err != nil {
+		return err
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if location != ix.info.Host+"\x00"+ix.info.Name {
+		return nil
+	}
+	ix.lastInsertedID = checkpoints[0]
+	ix.lastIndexedTextID = checkpoints[1]
+	return nil
+}
+
+func (ix *ExternalVectorIndex) SetCheckpoints(lastInsertedId, lastIndexedTextId int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.lastInsertedID = lastInsertedId
+	ix.lastIndexedTextID = lastIndexedTextId
+}
+
+func writeString(w io.Writer, s string) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func readString(r io.Reader) (string, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
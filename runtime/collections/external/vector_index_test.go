@@ -0,0 +1,313 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package external
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/utils"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	secrets.Initialize(context.Background())
+	os.Exit(m.Run())
+}
+
+// fakeVectorDB keeps vectors by namespace and id, and ranks them by cosine distance, like a vector database would.
+type fakeVectorDB struct {
+	mu        sync.Mutex
+	created   bool
+	vectors   map[string]map[string][]float32
+	apiKeys   []string
+	dimension int
+}
+
+func (db *fakeVectorDB) upsert(namespace, id string, vec []float32) {
+	if db.vectors[namespace] == nil {
+		db.vectors[namespace] = map[string][]float32{}
+	}
+	db.vectors[namespace][id] = vec
+}
+
+func (db *fakeVectorDB) nearest(namespace string, query []float32, k int) []string {
+	ids := make([]string, 0, len(db.vectors[namespace]))
+	for id := range db.vectors[namespace] {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		distA, _ := utils.Distance(manifest.DistanceCosine, query, db.vectors[namespace][a])
+		distB, _ := utils.Distance(manifest.DistanceCosine, query, db.vectors[namespace][b])
+		return cmp.Compare(distA, distB)
+	})
+	return ids[:min(k, len(ids))]
+}
+
+func newFakeQdrant(t *testing.T) (*fakeVectorDB, string) {
+	fake := &fakeVectorDB{vectors: map[string]map[string][]float32{}}
+	keys := map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.apiKeys = append(fake.apiKeys, r.Header.Get("Api-Key"))
+
+		var body struct {
+			Vectors struct {
+				Size int `json:"size"`
+			} `json:"vectors"`
+			Points []json.RawMessage `json:"points"`
+			Ids    []string          `json:"ids"`
+			Vector []float32         `json:"vector"`
+			Limit  int               `json:"limit"`
+			Filter struct {
+				Must []struct {
+					Match struct {
+						Value string `json:"value"`
+					} `json:"match"`
+				} `json:"must"`
+			} `json:"filter"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		path := strings.TrimPrefix(r.URL.Path, "/collections/docs_search")
+		if path != r.URL.Path && !fake.created && !(r.Method == http.MethodPut && path == "") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var result []qdrantPoint
+		switch r.Method + " " + path {
+		case "GET ":
+		case "PUT ":
+			fake.created = true
+			fake.dimension = body.Vectors.Size
+		case "PUT /index":
+		case "PUT /points":
+			for _, raw := range body.Points {
+				var p qdrantPoint
+				require.NoError(t, json.Unmarshal(raw, &p))
+				ns := p.Payload["namespace"].(string)
+				keys[p.Id] = p.Payload["key"].(string)
+				fake.upsert(ns, p.Id, p.Vector)
+			}
+		case "POST /points/search":
+			ns := body.Filter.Must[0].Match.Value
+			for _, id := range fake.nearest(ns, body.Vector, body.Limit) {
+				result = append(result, qdrantPoint{Id: id, Vector: fake.vectors[ns][id], Payload: map[string]any{"key": keys[id]}})
+			}
+		case "POST /points/delete":
+			for _, raw := range body.Points {
+				var id string
+				require.NoError(t, json.Unmarshal(raw, &id))
+				for _, vecs := range fake.vectors {
+					delete(vecs, id)
+				}
+			}
+		case "POST /points":
+			for _, vecs := range fake.vectors {
+				if vec, ok := vecs[body.Ids[0]]; ok {
+					result = append(result, qdrantPoint{Id: body.Ids[0], Vector: vec})
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	t.Cleanup(server.Close)
+	return fake, server.URL
+}
+
+func newFakePinecone(t *testing.T) (*fakeVectorDB, string) {
+	fake := &fakeVectorDB{vectors: map[string]map[string][]float32{}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.apiKeys = append(fake.apiKeys, r.Header.Get("Api-Key"))
+
+		var body struct {
+			Namespace string           `json:"namespace"`
+			Vectors   []pineconeVector `json:"vectors"`
+			Ids       []string         `json:"ids"`
+			Vector    []float32        `json:"vector"`
+			TopK      int              `json:"topK"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		switch r.URL.Path {
+		case "/vectors/upsert":
+			for _, v := range body.Vectors {
+				fake.upsert(body.Namespace, v.Id, v.Values)
+			}
+			_, _ = w.Write([]byte(`{}`))
+		case "/query":
+			matches := []pineconeVector{}
+			for _, id := range fake.nearest(body.Namespace, body.Vector, body.TopK) {
+				matches = append(matches, pineconeVector{Id: id, Values: fake.vectors[body.Namespace][id]})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"matches": matches})
+		case "/vectors/delete":
+			for _, id := range body.Ids {
+				delete(fake.vectors[body.Namespace], id)
+			}
+			_, _ = w.Write([]byte(`{}`))
+		case "/vectors/fetch":
+			ns, id := r.URL.Query().Get("namespace"), r.URL.Query().Get("ids")
+			vectors := map[string]pineconeVector{}
+			if vec, ok := fake.vectors[ns][id]; ok {
+				vectors[id] = pineconeVector{Id: id, Values: vec}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"vectors": vectors})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return fake, server.URL
+}
+
+func setHost(url string) {
+	manifestdata.SetManifest(&manifest.Manifest{
+		Hosts: map[string]manifest.HostInfo{
+			"vectors": manifest.HTTPHostInfo{
+				Name:    "vectors",
+				BaseURL: url,
+				Headers: map[string]string{"Api-Key": "secret"},
+			},
+		},
+	})
+}
+
+func TestExternalVectorIndex(t *testing.T) {
+	for _, indexType := range []string{manifest.IndexTypeQdrant, manifest.IndexTypePinecone} {
+		t.Run(indexType, func(t *testing.T) {
+			ctx := context.Background()
+			var fake *fakeVectorDB
+			var url string
+			if indexType == manifest.IndexTypeQdrant {
+				fake, url = newFakeQdrant(t)
+			} else {
+				fake, url = newFakePinecone(t)
+			}
+			setHost(url)
+
+			searchMethod := manifest.SearchMethodInfo{
+				Embedder: "embed",
+				Index:    manifest.IndexInfo{Type: indexType, Host: "vectors"},
+			}
+			ix, err := NewExternalVectorIndex("Docs", "tenant-1", "search", searchMethod)
+			require.NoError(t, err)
+			other, err := NewExternalVectorIndex("Docs", "tenant-2", "search", searchMethod)
+			require.NoError(t, err)
+
+			// searching before anything is inserted finds nothing
+			results, err := ix.Search(ctx, []float32{1, 0}, 2, nil)
+			require.NoError(t, err)
+			assert.Empty(t, results)
+
+			require.NoError(t, ix.InsertVectorsToMemory(ctx, []int64{1, 2, 3}, []int64{11, 12, 13}, []string{"a", "b", "c"}, [][]float32{{1, 0}, {1, 1}, {0, 1}}))
+			require.NoError(t, other.InsertVectorToMemory(ctx, 4, 14, "d", []float32{1, 0}))
+			if indexType == manifest.IndexTypeQdrant {
+				assert.Equal(t, 2, fake.dimension)
+			}
+
+			results, err = ix.Search(ctx, []float32{1, 0}, 2, nil)
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.Equal(t, "a", results[0].GetIndex())
+			assert.InDelta(t, 0, results[0].GetValue(), 1e-6)
+			assert.Equal(t, "b", results[1].GetIndex())
+
+			// the filter discards the closest vectors, so more candidates are requested
+			results, err = ix.Search(ctx, []float32{1, 0}, 1, func(query, vec []float32, key string) bool { return key == "c" })
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, "c", results[0].GetIndex())
+
+			vec, err := ix.GetVector(ctx, "c")
+			require.NoError(t, err)
+			assert.Equal(t, []float32{0, 1}, vec)
+			vec, err = ix.GetVector(ctx, "d")
+			require.NoError(t, err)
+			assert.Nil(t, vec, "vectors of other namespaces are not visible")
+
+			require.NoError(t, ix.DeleteVectorFromMemory(ctx, "a"))
+			results, err = ix.SearchWithKey(ctx, "b", 3, nil)
+			require.NoError(t, err)
+			assert.Len(t, results, 2)
+
+			checkpoint, err := ix.GetCheckpointId(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(13), checkpoint)
+			assert.NotContains(t, fake.apiKeys, "", "the headers of the host are sent with each request")
+		})
+	}
+}
+
+func TestExternalVectorIndexValidation(t *testing.T) {
+	_, err := NewExternalVectorIndex("docs", "", "search", manifest.SearchMethodInfo{Index: manifest.IndexInfo{Type: manifest.IndexTypeQdrant}})
+	assert.ErrorContains(t, err, "host is required")
+
+	_, err = NewExternalVectorIndex("docs", "", "search", manifest.SearchMethodInfo{
+		Index:        manifest.IndexInfo{Type: manifest.IndexTypePgvector, Host: "neon"},
+		Quantization: manifest.QuantizationInfo{Type: manifest.QuantizationInt8},
+	})
+	assert.ErrorContains(t, err, "quantization is not supported")
+}
+
+func TestExternalVectorIndexSnapshot(t *testing.T) {
+	searchMethod := manifest.SearchMethodInfo{Index: manifest.IndexInfo{Type: manifest.IndexTypePgvector, Host: "neon"}}
+	source, err := NewExternalVectorIndex("docs", "", "search", searchMethod)
+	require.NoError(t, err)
+	source.SetCheckpoints(13, 3)
+
+	var buf bytes.Buffer
+	require.NoError(t, source.WriteSnapshot(&buf))
+
+	target, err := NewExternalVectorIndex("docs", "", "search", searchMethod)
+	require.NoError(t, err)
+	require.NoError(t, target.ReadSnapshot(bytes.NewReader(buf.Bytes())))
+	checkpoint, _ := target.GetCheckpointId(context.Background())
+	assert.Equal(t, int64(13), checkpoint)
+
+	// an index stored in another table syncs all of its vectors again
+	searchMethod.Index.Name = "other"
+	moved, err := NewExternalVectorIndex("docs", "", "search", searchMethod)
+	require.NoError(t, err)
+	require.NoError(t, moved.ReadSnapshot(bytes.NewReader(buf.Bytes())))
+	checkpoint, _ = moved.GetCheckpointId(context.Background())
+	assert.Equal(t, int64(0), checkpoint)
+}
+
+func TestDefaultName(t *testing.T) {
+	assert.Equal(t, "my_docs_semantic_search", defaultName("My-Docs", "semantic.search"))
+}
+
+func TestFormatVector(t *testing.T) {
+	assert.Equal(t, "[1,-0.5,0.25]", formatVector([]float32{1, -0.5, 0.25}))
+}
@@ -19,10 +19,14 @@ import (
 	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
 	"github.com/hypermodeinc/modus/runtime/db"
 	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/standby"
 )
 
 const collectionFactoryWriteInterval = 1
 
+// standbySyncInterval is how often a standby runtime syncs collections, so that little is left to load when it is promoted.
+const standbySyncInterval = 10 * time.Second
+
 var (
 	globalNamespaceManager *collectionFactory
 	errCollectionNotFound  = fmt.Errorf("collection not found")
@@ -34,6 +38,7 @@ type collectionFactory struct {
 	mu            sync.RWMutex
 	quit          chan struct{}
 	done          chan struct{}
+	syncNow       chan struct{}
 }
 
 func newCollectionFactory() *collectionFactory {
@@ -43,8 +48,9 @@ func newCollectionFactory() *collectionFactory {
 				collectionNamespaceMap: map[string]interfaces.CollectionNamespace{},
 			},
 		},
-		quit: make(chan struct{}),
-		done: make(chan struct{}),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+		syncNow: make(chan struct{}, 1),
 	}
 }
 
@@ -82,11 +88,18 @@ func (cf *collectionFactory) removeCollection(name string) error {
 	return nil
 }
 
-func (cf *collectionFactory) readFromPostgres(ctx context.Context) bool {
+func (cf *collectionFactory) readFromPostgres(baseCtx context.Context) bool {
 	resetTimerFaster := false
-	var err error
 	for _, namespaceCollectionFactory := range cf.collectionMap {
 		for _, col := range namespaceCollectionFactory.collectionNamespaceMap {
+			ctx, err := collectionContext(baseCtx, col.GetCollectionName())
+			if err != nil {
+				logger.Err(baseCtx, err).
+					Str("collection_name", col.GetCollectionName()).
+					Msg("Failed to connect to the database of collection.")
+				continue
+			}
+
 			resetTimerFaster, err = loadTextsIntoCollection(ctx, col)
 			if err != nil {
 				logger.Err(ctx, err).
@@ -105,7 +118,11 @@ func (cf *collectionFactory) readFromPostgres(ctx context.Context) bool {
 					break
 				}
 
-				// catch up on any texts that weren't embedded
+				// catch up on any texts that weren't embedded, unless this is a standby,
+				// in which case the active runtime is responsible for embedding them
+				if standby.IsStandby() {
+					continue
+				}
 				err := syncTextsWithVectorIndex(ctx, col, vectorIndex)
 				if err != nil {
 					logger.Err(ctx, err).
@@ -123,22 +140,45 @@ func (cf *collectionFactory) readFromPostgres(ctx context.Context) bool {
 
 func (cf *collectionFactory) worker(ctx context.Context) {
 	defer close(cf.done)
-	timer := time.NewTimer(collectionFactoryWriteInterval * time.Minute)
+	interval := collectionFactoryWriteInterval * time.Minute
+	if standby.IsStandby() {
+		interval = standbySyncInterval
+	}
+	timer := time.NewTimer(interval)
 
 	defer timer.Stop()
 	for {
 		select {
 		case <-timer.C:
-			// read from postgres all collections & searchMethod after lastInsertedID
-			resetTimerFaster := cf.readFromPostgres(ctx)
-			if resetTimerFaster {
-				timer.Reset(10 * time.Second)
-			} else {
-				timer.Reset(collectionFactoryWriteInterval * time.Minute)
-			}
+		case <-cf.syncNow:
+			timer.Stop()
 		case <-cf.quit:
 			return
 		}
+
+		// the active runtime deletes expired items, before reading the changes from postgres
+		if !standby.IsStandby() {
+			cf.evictExpired(ctx)
+		}
+
+		// read from postgres all collections & searchMethod after lastInsertedID
+		resetTimerFaster := cf.readFromPostgres(ctx)
+		updateCollectionMetrics(ctx)
+		if resetTimerFaster {
+			timer.Reset(10 * time.Second)
+		} else if standby.IsStandby() {
+			timer.Reset(standbySyncInterval)
+		} else {
+			timer.Reset(collectionFactoryWriteInterval * time.Minute)
+		}
+	}
+}
+
+// requestSync asks the worker to sync collections from the database as soon as possible.
+func (cf *collectionFactory) requestSync() {
+	select {
+	case cf.syncNow <- struct{}{}:
+	default:
 	}
 }
 
@@ -150,7 +190,7 @@ func loadTextsIntoCollection(ctx context.Context, col interfaces.CollectionNames
 	}
 
 	// Query all texts from checkpoint
-	textIds, keys, texts, labels, err := db.QueryCollectionTextsFromCheckpoint(ctx, col.GetCollectionName(), col.GetNamespace(), textCheckpointId)
+	textIds, keys, texts, labels, metadata, err := db.QueryCollectionTextsFromCheckpoint(ctx, col.GetCollectionName(), col.GetNamespace(), textCheckpointId)
 	if err != nil {
 		return false, err
 	}
@@ -163,10 +203,11 @@ func loadTextsIntoCollection(ctx context.Context, col interfaces.CollectionNames
 	}
 
 	// Insert all texts into collection
-	err = col.InsertTextsToMemory(ctx, textIds, keys, texts, labels)
+	err = col.InsertTextsToMemory(ctx, textIds, keys, texts, labels, metadata)
 	if err != nil {
 		return false, err
 	}
+	journalTexts(ctx, col, textIds, keys, texts, labels, metadata)
 
 	return false, nil
 }
@@ -192,6 +233,7 @@ func loadVectorsIntoVectorIndex(ctx context.Context, vectorIndex interfaces.Vect
 	if err != nil {
 		return err
 	}
+	journalVectors(ctx, col, vectorIndex, textIds, vectorIds, keys, vectors)
 
 	return nil
 }
@@ -202,7 +244,7 @@ func syncTextsWithVectorIndex(ctx context.Context, col interfaces.CollectionName
 	if err != nil {
 		return err
 	}
-	textIds, keys, texts, _, err := db.QueryCollectionTextsFromCheckpoint(ctx, col.GetCollectionName(), col.GetNamespace(), lastIndexedTextId)
+	textIds, keys, texts, _, _, err := db.QueryCollectionTextsFromCheckpoint(ctx, col.GetCollectionName(), col.GetNamespace(), lastIndexedTextId)
 	if err != nil {
 		return err
 	}
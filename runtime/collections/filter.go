@@ -0,0 +1,494 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hypermodeinc/modus/runtime/collections/index"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+)
+
+// A metadataFilter is a parsed filter expression, which is matched against the metadata of collection items.
+//
+// Filter expressions compare metadata fields to literal values, and can be combined with AND, OR, NOT and parentheses:
+//
+//	tenant = 'acme' AND lang IN ('en', 'fr') AND (year >= 2020 OR pinned = true)
+//
+// Supported operators are =, !=, <, <=, >, >=, IN and NOT IN.  Values are strings in single or double quotes,
+// numbers, true, false or null.  Nested fields are addressed with dots, such as author.name.  When a field holds
+// an array, a comparison matches if any of its elements match.  A comparison against a field that isn't present
+// never matches, except for "= null".
+type metadataFilter interface {
+	matches(metadata map[string]any) bool
+}
+
+// parseMetadataFilter parses a filter expression.  An empty expression returns a nil filter.
+func parseMetadataFilter(expr string) (metadataFilter, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %s in filter at position %d", p.peek().text, p.peek().pos)
+	}
+	return f, nil
+}
+
+// searchFilterFor returns a search filter that matches the metadata of items in the namespace,
+// or nil if there is nothing to filter.
+func searchFilterFor(ctx context.Context, f metadataFilter, collNs interfaces.CollectionNamespace) index.SearchFilter {
+	if f == nil {
+		return nil
+	}
+	return func(_, _ []float32, key string) bool {
+		metadata, err := collNs.GetMetadata(ctx, key)
+		if err != nil {
+			return false
+		}
+		return f.matches(metadata)
+	}
+}
+
+type andFilter []metadataFilter
+
+func (f andFilter) matches(metadata map[string]any) bool {
+	for _, sub := range f {
+		if !sub.matches(metadata) {
+			return false
+		}
+	}
+	return true
+}
+
+type orFilter []metadataFilter
+
+func (f orFilter) matches(metadata map[string]any) bool {
+	for _, sub := range f {
+		if sub.matches(metadata) {
+			return true
+		}
+	}
+	return false
+}
+
+type notFilter struct {
+	filter metadataFilter
+}
+
+func (f notFilter) matches(metadata map[string]any) bool {
+	return !f.filter.matches(metadata)
+}
+
+type comparisonFilter struct {
+	field  []string
+	op     string
+	values []any
+}
+
+func (f *comparisonFilter) matches(metadata map[string]any) bool {
+	value, found := lookupField(metadata, f.field)
+	switch f.op {
+	case "IN":
+		return found && anyElement(value, func(v any) bool {
+			for _, want := range f.values {
+				if c, ok := compareValues(v, want); ok && c == 0 {
+					return true
+				}
+			}
+			return false
+		})
+	case "NOT IN":
+		return found && !(&comparisonFilter{field: f.field, op: "IN", values: f.values}).matches(metadata)
+	case "!=":
+		if f.values[0] == nil {
+			return found && value != nil
+		}
+		return found && !(&comparisonFilter{field: f.field, op: "=", values: f.values}).matches(metadata)
+	}
+
+	want := f.values[0]
+	if want == nil && f.op == "=" {
+		return !found || value == nil
+	}
+	if !found {
+		return false
+	}
+	return anyElement(value, func(v any) bool {
+		c, ok := compareValues(v, want)
+		if !ok {
+			return false
+		}
+		switch f.op {
+		case "=":
+			return c == 0
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		case ">=":
+			return c >= 0
+		}
+		return false
+	})
+}
+
+func lookupField(metadata map[string]any, field []string) (any, bool) {
+	var value any = metadata
+	for _, name := range field {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		value, ok = m[name]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// anyElement calls fn for each element if the value is an array, or for the value itself otherwise.
+func anyElement(value any, fn func(any) bool) bool {
+	if arr, ok := value.([]any); ok {
+		for _, v := range arr {
+			if fn(v) {
+				return true
+			}
+		}
+		return false
+	}
+	return fn(value)
+}
+
+// compareValues compares two values of the same kind, returning false if they can't be compared.
+func compareValues(a, b any) (int, bool) {
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			switch {
+			case a == b:
+				return 0, true
+			case !a:
+				return -1, true
+			default:
+				return 1, true
+			}
+		}
+	default:
+		af, ok1 := toFloat64(a)
+		bf, ok2 := toFloat64(b)
+		if ok1 && ok2 {
+			switch {
+			case af < bf:
+				return -1, true
+			case af > bf:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func toFloat64(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+type filterTokenKind int
+
+const (
+	tokenIdent filterTokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenPunct
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, filterToken{tokenPunct, string(r), i})
+			i++
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			start := i
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			op := string(runes[start:i])
+			switch op {
+			case "==":
+				op = "="
+			case "!":
+				return nil, fmt.Errorf("unexpected ! in filter at position %d", start)
+			}
+			tokens = append(tokens, filterToken{tokenOperator, op, start})
+		case r == '\'' || r == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string in filter at position %d", start)
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == r {
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, filterToken{tokenString, sb.String(), start})
+		case r == '-' || r == '.' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])) {
+				i++
+			}
+			tokens = append(tokens, filterToken{tokenNumber, string(runes[start:i]), start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '.' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, filterToken{tokenIdent, string(runes[start:i]), start})
+		default:
+			return nil, fmt.Errorf("unexpected %q in filter at position %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.done() {
+		return filterToken{kind: tokenPunct, text: "end of filter", pos: -1}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// keyword reports whether the next token is the given keyword, and consumes it if so.
+func (p *filterParser) keyword(kw string) bool {
+	t := p.peek()
+	if t.kind == tokenIdent && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(punct string) error {
+	t := p.next()
+	if t.kind != tokenPunct || t.text != punct {
+		return fmt.Errorf("expected %s in filter but found %s", punct, t.text)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (metadataFilter, error) {
+	var filters orFilter
+	for {
+		f, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+		if !p.keyword("OR") {
+			break
+		}
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return filters, nil
+}
+
+func (p *filterParser) parseAnd() (metadataFilter, error) {
+	var filters andFilter
+	for {
+		f, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+		if !p.keyword("AND") {
+			break
+		}
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return filters, nil
+}
+
+func (p *filterParser) parseNot() (metadataFilter, error) {
+	if p.keyword("NOT") {
+		f, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{f}, nil
+	}
+
+	if t := p.peek(); t.kind == tokenPunct && t.text == "(" {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (metadataFilter, error) {
+	field := p.next()
+	if field.kind != tokenIdent {
+		return nil, fmt.Errorf("expected a field name in filter but found %s", field.text)
+	}
+	f := &comparisonFilter{field: strings.Split(field.text, ".")}
+
+	switch {
+	case p.keyword("IN"):
+		f.op = "IN"
+	case p.keyword("NOT"):
+		if !p.keyword("IN") {
+			return nil, fmt.Errorf("expected IN after NOT in filter for field %s", field.text)
+		}
+		f.op = "NOT IN"
+	default:
+		op := p.next()
+		if op.kind != tokenOperator {
+			return nil, fmt.Errorf("expected an operator after field %s in filter but found %s", field.text, op.text)
+		}
+		f.op = op.text
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if value == nil && f.op != "=" && f.op != "!=" {
+			return nil, fmt.Errorf("null can only be compared with = or != in filter for field %s", field.text)
+		}
+		f.values = []any{value}
+		return f, nil
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		f.values = append(f.values, value)
+		if t := p.peek(); t.kind == tokenPunct && t.text == "," {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseValue() (any, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s in filter at position %d", t.text, t.pos)
+		}
+		return n, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a value in filter but found %s", t.text)
+}
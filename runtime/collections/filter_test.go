@@ -0,0 +1,162 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataFilter(t *testing.T) {
+	metadata := map[string]any{
+		"tenant": "acme",
+		"lang":   "en",
+		"year":   float64(2021),
+		"pinned": true,
+		"tags":   []any{"news", "tech"},
+		"author": map[string]any{"name": "Ada"},
+		"empty":  nil,
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"tenant = 'acme'", true},
+		{"tenant == \"acme\"", true},
+		{"tenant = 'other'", false},
+		{"tenant != 'other'", true},
+		{"tenant = 'acme' AND lang = 'en'", true},
+		{"tenant = 'acme' and lang = 'fr'", false},
+		{"lang = 'fr' OR year > 2020", true},
+		{"year >= 2021 AND year < 2022", true},
+		{"year <= 2020", false},
+		{"year = 2021.0", true},
+		{"year > -1e3", true},
+		{"lang IN ('en', 'fr')", true},
+		{"lang IN ('de')", false},
+		{"lang NOT IN ('de', 'fr')", true},
+		{"NOT lang = 'en'", false},
+		{"NOT (lang = 'fr' OR pinned = false)", true},
+		{"pinned = true", true},
+		{"tags = 'tech'", true},
+		{"tags IN ('sports', 'news')", true},
+		{"tags = 'sports'", false},
+		{"author.name = 'Ada'", true},
+		{"author.age > 30", false},
+		{"missing = 'x'", false},
+		{"missing != 'x'", false},
+		{"missing NOT IN ('x')", false},
+		{"missing = null", true},
+		{"empty = null", true},
+		{"empty != null", false},
+		{"tenant != null", true},
+		{"year = '2021'", false},
+		{"lang > 'de'", true},
+		{"(tenant = 'acme' AND (lang = 'en' OR lang = 'fr')) AND year > 2000", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := parseMetadataFilter(tt.filter)
+			require.NoError(t, err)
+			require.NotNil(t, f)
+			assert.Equal(t, tt.want, f.matches(metadata))
+		})
+	}
+
+	t.Run("NoMetadata", func(t *testing.T) {
+		f, err := parseMetadataFilter("tenant = 'acme'")
+		require.NoError(t, err)
+		assert.False(t, f.matches(nil))
+	})
+}
+
+func TestParseMetadataFilterErrors(t *testing.T) {
+	f, err := parseMetadataFilter("  ")
+	require.NoError(t, err)
+	assert.Nil(t, f)
+
+	for _, filter := range []string{
+		"tenant",
+		"tenant =",
+		"tenant = 'acme",
+		"tenant = acme",
+		"tenant ! 'acme'",
+		"tenant = 'acme' AND",
+		"(tenant = 'acme'",
+		"tenant = 'acme')",
+		"lang IN 'en'",
+		"lang IN ()",
+		"lang NOT 'en'",
+		"year > null",
+		"year > 1.2.3",
+		"'acme' = tenant",
+		"tenant = 'acme' lang = 'en'",
+		"tenant ~ 'acme'",
+	} {
+		_, err := parseMetadataFilter(filter)
+		assert.Error(t, err, filter)
+	}
+}
+
+func TestSearchWithMetadataFilter(t *testing.T) {
+	ctx := context.Background()
+
+	for _, indexType := range []string{interfaces.SequentialManifestType, interfaces.HnswManifestType} {
+		t.Run(indexType, func(t *testing.T) {
+			collNs := in_mem.NewCollectionNamespace("docs", "")
+			vi, err := createIndexObject("docs", "", manifest.SearchMethodInfo{Index: manifest.IndexInfo{Type: indexType}}, "search")
+			require.NoError(t, err)
+			require.NoError(t, collNs.SetVectorIndex(ctx, "search", vi))
+
+			keys := []string{"a", "b", "c", "d"}
+			ids := []int64{1, 2, 3, 4}
+			metadata := []map[string]any{
+				{"tenant": "x", "lang": "en"},
+				{"tenant": "x", "lang": "fr"},
+				{"tenant": "y", "lang": "en"},
+				nil,
+			}
+			vecs := [][]float32{{1, 0}, {0.9, 0.1}, {0.95, 0.05}, {0.99, 0.01}}
+			require.NoError(t, collNs.InsertTextsToMemory(ctx, ids, keys, keys, nil, metadata))
+			require.NoError(t, vi.InsertVectorsToMemory(ctx, ids, ids, keys, vecs))
+
+			f, err := parseMetadataFilter("tenant = 'x' AND lang = 'en'")
+			require.NoError(t, err)
+			results, err := vi.Search(ctx, []float32{1, 0}, 2, searchFilterFor(ctx, f, collNs))
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, "a", results[0].GetIndex())
+
+			f, err = parseMetadataFilter("lang IN ('en', 'fr')")
+			require.NoError(t, err)
+			results, err = vi.Search(ctx, []float32{1, 0}, 3, searchFilterFor(ctx, f, collNs))
+			require.NoError(t, err)
+			require.Len(t, results, 3)
+			for _, r := range results {
+				assert.NotEqual(t, "d", r.GetIndex())
+			}
+
+			// updating an item without metadata removes its old metadata
+			require.NoError(t, collNs.InsertTextsToMemory(ctx, []int64{5}, []string{"a"}, []string{"a"}, nil, nil))
+			metadataA, err := collNs.GetMetadata(ctx, "a")
+			require.NoError(t, err)
+			assert.Nil(t, metadataA)
+		})
+	}
+}
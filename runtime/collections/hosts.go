@@ -0,0 +1,66 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/sqlclient"
+)
+
+// A collection with a host in the manifest stores its items and vectors in that PostgreSQL host, instead of the
+// runtime's database.  The operations of a collection that read or write the database run with the context of the
+// collection, which carries the connection pool of its host.  The pool is looked up for each operation, since it is
+// replaced when the manifest changes.  Moving a collection to another host doesn't copy its items, which can be done
+// by exporting the collection before the move, and importing it after.
+
+// The database operations of hosts, which tests replace.
+var (
+	getHostPool             = sqlclient.GetPostgresPool
+	prepareCollectionTables = db.PrepareCollectionTables
+)
+
+// collectionContext returns the context for the database operations of the collection.
+func collectionContext(ctx context.Context, collectionName string) (context.Context, error) {
+	return hostContext(ctx, manifestdata.GetManifest().Collections[collectionName].Host)
+}
+
+// hostContext returns the context for the database operations of the collections stored in the host,
+// or in the runtime's database when the host is empty.
+func hostContext(ctx context.Context, host string) (context.Context, error) {
+	if host == "" {
+		return db.WithCollectionPool(ctx, nil), nil
+	}
+
+	pool, err := getHostPool(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database of host %s: %w", host, err)
+	}
+	if err := prepareCollectionTables(ctx, pool); err != nil {
+		return nil, fmt.Errorf("failed to prepare the database of host %s: %w", host, err)
+	}
+	return db.WithCollectionPool(ctx, pool), nil
+}
+
+// collectionHosts returns the hosts that store collections of the manifest, in order.
+func collectionHosts() []string {
+	var hosts []string
+	for _, info := range manifestdata.GetManifest().Collections {
+		if info.Host != "" && !slices.Contains(hosts, info.Host) {
+			hosts = append(hosts, info.Host)
+		}
+	}
+	slices.Sort(hosts)
+	return hosts
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/sqlclient"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupHostsTest(t *testing.T) (prepared *[]*pgxpool.Pool) {
+	manifestdata.SetManifest(&manifest.Manifest{
+		Collections: map[string]manifest.CollectionInfo{
+			"local":  {},
+			"remote": {Host: "neon"},
+			"other":  {Host: "aurora"},
+			"more":   {Host: "neon"},
+		},
+	})
+
+	// the pool doesn't connect until it is used
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost/db")
+	require.NoError(t, err)
+
+	prepared = &[]*pgxpool.Pool{}
+	getHostPool = func(ctx context.Context, host string) (*pgxpool.Pool, error) {
+		if host != "neon" {
+			return nil, errors.New("host not found")
+		}
+		return pool, nil
+	}
+	prepareCollectionTables = func(ctx context.Context, p *pgxpool.Pool) error {
+		*prepared = append(*prepared, p)
+		return nil
+	}
+
+	t.Cleanup(func() {
+		pool.Close()
+		getHostPool = sqlclient.GetPostgresPool
+		prepareCollectionTables = db.PrepareCollectionTables
+	})
+	return prepared
+}
+
+func TestCollectionContext(t *testing.T) {
+	ctx := context.Background()
+	prepared := setupHostsTest(t)
+
+	_, err := collectionContext(ctx, "local")
+	require.NoError(t, err)
+	assert.Empty(t, *prepared, "a collection without a host uses the runtime's database")
+
+	_, err = collectionContext(ctx, "remote")
+	require.NoError(t, err)
+	assert.Len(t, *prepared, 1, "the tables are prepared in the host's database")
+
+	_, err = collectionContext(ctx, "other")
+	assert.ErrorContains(t, err, "host aurora")
+}
+
+func TestCollectionHosts(t *testing.T) {
+	setupHostsTest(t)
+	assert.Equal(t, []string{"aurora", "neon"}, collectionHosts())
+}
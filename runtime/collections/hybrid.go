@@ -0,0 +1,232 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	collection_utils "github.com/hypermodeinc/modus/runtime/collections/utils"
+)
+
+const (
+	// FusionRRF combines the vector and keyword results with reciprocal rank fusion, which only uses the rank of
+	// each result, so the scores of the two searches don't need to be comparable.
+	FusionRRF = "rrf"
+
+	// FusionWeighted combines the similarity scores of the vector results with the BM25 scores of the keyword
+	// results, normalized to the best keyword result, weighted by the vector weight.  The similarity is the cosine
+	// similarity for the cosine distance, so search methods using other distances may need a different weight.
+	FusionWeighted = "weighted"
+)
+
+// rrfK dampens the contribution of the top ranks in reciprocal rank fusion.  60 is the value from the original paper.
+const rrfK = 60
+
+// minHybridCandidates is the minimum number of results taken from each search before they are fused,
+// so that results ranked lower by one search but higher by the other can still make it into the final results.
+const minHybridCandidates = 40
+
+type hybridCandidate struct {
+	key           string
+	distance      float64
+	hasDistance   bool
+	vectorRank    int
+	keywordRank   int
+	keywordScore  float64
+	combinedScore float64
+}
+
+// HybridSearchCollection searches the collection with both the vector index of the search method and the keyword index,
+// and fuses the results.  The fusion is either "rrf" (the default) or "weighted", in which case the vector weight, from 0 to 1,
+// sets how much the vector similarity counts against the keyword score.  The score of each result is its fused score.
+func HybridSearchCollection(ctx context.Context, collectionName string, namespaces []string, searchMethod, text string, limit int32, returnText bool, fusion string, vectorWeight float64, filter string) (*CollectionSearchResult, error) {
+
+	switch fusion {
+	case "":
+		fusion = FusionRRF
+	case FusionRRF:
+	case FusionWeighted:
+		if vectorWeight < 0 || vectorWeight > 1 {
+			return nil, fmt.Errorf("vector weight must be between 0 and 1, got %v", vectorWeight)
+		}
+	default:
+		return nil, fmt.Errorf("unknown fusion method %s, expected %s or %s", fusion, FusionRRF, FusionWeighted)
+	}
+
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataFilter, err := parseMetadataFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	collNamespaces, err := col.resolveNamespaces(namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	textVec, err := embedText(ctx, collectionName, searchMethod, text)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := max(int(limit)*4, minHybridCandidates)
+
+	// merge all objects
+	mergedObjects := make([]*CollectionSearchResultObject, 0, len(collNamespaces)*int(limit))
+	for _, collNs := range collNamespaces {
+		vectorIndex, err := collNs.GetVectorIndex(ctx, searchMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		searchFilter := searchFilterFor(ctx, metadataFilter, collNs)
+		vectorResults, err := vectorIndex.Search(ctx, textVec, candidates, searchFilter)
+		if err != nil {
+			return nil, err
+		}
+		keywordResults, err := collNs.SearchKeywords(ctx, text, candidates, searchFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		fused, err := fuseResults(ctx, vectorIndex, textVec, vectorResults, keywordResults, fusion, vectorWeight)
+		if err != nil {
+			return nil, err
+		}
+		if len(fused) > int(limit) {
+			fused = fused[:int(limit)]
+		}
+
+		for _, c := range fused {
+			object, err := newHybridSearchResultObject(ctx, collNs, c)
+			if err != nil {
+				return nil, err
+			}
+			mergedObjects = append(mergedObjects, object)
+		}
+	}
+
+	// sort by score
+	sort.SliceStable(mergedObjects, func(i, j int) bool {
+		return mergedObjects[i].Score > mergedObjects[j].Score
+	})
+
+	if len(mergedObjects) > int(limit) {
+		mergedObjects = mergedObjects[:int(limit)]
+	}
+
+	return NewCollectionSearchResult(collectionName, searchMethod, "success", mergedObjects, ""), nil
+}
+
+// fuseResults combines the ranked vector and keyword results, returning the candidates ordered by their combined score.
+func fuseResults(ctx context.Context, vectorIndex interfaces.VectorIndex, query []float32, vectorResults, keywordResults collection_utils.MaxTupleHeap, fusion string, vectorWeight float64) ([]*hybridCandidate, error) {
+	byKey := make(map[string]*hybridCandidate, len(vectorResults)+len(keywordResults))
+	ordered := make([]*hybridCandidate, 0, len(vectorResults)+len(keywordResults))
+	get := func(key string) *hybridCandidate {
+		c, ok := byKey[key]
+		if !ok {
+			c = &hybridCandidate{key: key}
+			byKey[key] = c
+			ordered = append(ordered, c)
+		}
+		return c
+	}
+
+	for i, r := range vectorResults {
+		c := get(r.GetIndex())
+		c.vectorRank = i + 1
+		c.distance = r.GetValue()
+		c.hasDistance = true
+	}
+
+	maxKeywordScore := 0.0
+	for i, r := range keywordResults {
+		c := get(r.GetIndex())
+		c.keywordRank = i + 1
+		c.keywordScore = r.GetValue()
+		maxKeywordScore = max(maxKeywordScore, r.GetValue())
+	}
+
+	// keyword matches that weren't among the nearest vectors still get their actual distance,
+	// so that every result reports a distance, and the weighted fusion can use it
+	query = collection_utils.PrepareVector(query, vectorIndex.GetNormalize())
+	for _, c := range ordered {
+		if c.hasDistance {
+			continue
+		}
+		vec, err := vectorIndex.GetVector(ctx, c.key)
+		if err != nil || vec == nil {
+			// the text hasn't been embedded yet
+			continue
+		}
+		distance, err := collection_utils.Distance(vectorIndex.GetDistance(), query, vec)
+		if err != nil {
+			return nil, err
+		}
+		c.distance = distance
+		c.hasDistance = true
+	}
+
+	for _, c := range ordered {
+		switch fusion {
+		case FusionWeighted:
+			var similarity, keywordScore float64
+			if c.hasDistance {
+				similarity = collection_utils.ScoreForDistance(vectorIndex.GetDistance(), c.distance)
+			}
+			if maxKeywordScore > 0 {
+				keywordScore = c.keywordScore / maxKeywordScore
+			}
+			c.combinedScore = vectorWeight*similarity + (1-vectorWeight)*keywordScore
+		default:
+			if c.vectorRank > 0 {
+				c.combinedScore += 1 / float64(rrfK+c.vectorRank)
+			}
+			if c.keywordRank > 0 {
+				c.combinedScore += 1 / float64(rrfK+c.keywordRank)
+			}
+		}
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].combinedScore > ordered[j].combinedScore
+	})
+	return ordered, nil
+}
+
+func newHybridSearchResultObject(ctx context.Context, collNs interfaces.CollectionNamespace, c *hybridCandidate) (*CollectionSearchResultObject, error) {
+	text, err := collNs.GetText(ctx, c.key)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := collNs.GetLabels(ctx, c.key)
+	if err != nil {
+		return nil, err
+	}
+
+	// a text that hasn't been embedded yet is treated as unrelated to the query
+	distance := 1.0
+	if c.hasDistance {
+		distance = c.distance
+	}
+	return NewCollectionSearchResultObject(collNs.GetNamespace(), c.key, text, labels, distance, c.combinedScore), nil
+}
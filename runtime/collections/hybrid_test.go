@@ -0,0 +1,95 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuseResults(t *testing.T) {
+	ctx := context.Background()
+
+	collNs := in_mem.NewCollectionNamespace("docs", "")
+	vi, err := createIndexObject("docs", "", manifest.SearchMethodInfo{}, "search")
+	require.NoError(t, err)
+	require.NoError(t, collNs.SetVectorIndex(ctx, "search", vi))
+
+	keys := []string{"a", "b", "c", "d"}
+	ids := []int64{1, 2, 3, 4}
+	texts := []string{
+		"reset a password",
+		"recovering access to your account",
+		"error code E1234 when resetting",
+		"unrelated text about cooking",
+	}
+	vecs := [][]float32{{1, 0}, {0.95, 0.3}, {0.2, 1}, {0, 1}}
+	require.NoError(t, collNs.InsertTextsToMemory(ctx, ids, keys, texts, nil, nil))
+	require.NoError(t, vi.InsertVectorsToMemory(ctx, ids, ids, keys, vecs))
+
+	query := []float32{1, 0.1}
+	vectorResults, err := vi.Search(ctx, query, 2, nil)
+	require.NoError(t, err)
+	keywordResults, err := collNs.SearchKeywords(ctx, "E1234 password", 2, nil)
+	require.NoError(t, err)
+
+	resultKeys := func(candidates []*hybridCandidate) []string {
+		out := make([]string, len(candidates))
+		for i, c := range candidates {
+			out[i] = c.key
+		}
+		return out
+	}
+
+	t.Run("RRF", func(t *testing.T) {
+		fused, err := fuseResults(ctx, vi, query, vectorResults, keywordResults, FusionRRF, 0)
+		require.NoError(t, err)
+
+		// a is first in both, c is only found by keyword, and every result has a distance
+		assert.Equal(t, []string{"a", "b", "c"}, resultKeys(fused)[:3])
+		assert.InDelta(t, 2.0/61, fused[0].combinedScore, 1e-9)
+		for _, c := range fused {
+			assert.True(t, c.hasDistance, c.key)
+		}
+		assert.Greater(t, fused[2].distance, fused[0].distance)
+	})
+
+	t.Run("Weighted", func(t *testing.T) {
+		fused, err := fuseResults(ctx, vi, query, vectorResults, keywordResults, FusionWeighted, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "a", fused[0].key)
+		assert.InDelta(t, 1, fused[0].combinedScore, 1e-9)
+
+		// keyword-only ranking puts the exact term match above the semantically similar text
+		assert.Less(t, slices.Index(resultKeys(fused), "c"), slices.Index(resultKeys(fused), "b"))
+
+		// vector-only ranking is by similarity
+		fused, err = fuseResults(ctx, vi, query, vectorResults, keywordResults, FusionWeighted, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, resultKeys(fused))
+	})
+}
+
+func TestHybridSearchCollectionValidatesFusion(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := HybridSearchCollection(ctx, "docs", nil, "search", "text", 10, false, "bogus", 0, "")
+	assert.ErrorContains(t, err, "unknown fusion method")
+
+	_, err = HybridSearchCollection(ctx, "docs", nil, "search", "text", 10, false, FusionWeighted, 1.5, "")
+	assert.ErrorContains(t, err, "vector weight")
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hnsw
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem/sequential"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/collections/utils"
+)
+
+// Embeddings of real text are clustered by topic, rather than spread uniformly, which is what the graph relies on.
+func clusteredVectors(rng *rand.Rand, n, dims int) ([]string, [][]float32) {
+	const clusters = 50
+	_, centers := randomVectors(rand.New(rand.NewSource(0)), clusters, dims)
+
+	keys := make([]string, n)
+	vecs := make([][]float32, n)
+	for i := range n {
+		keys[i] = fmt.Sprintf("key%d", i)
+		center := centers[rng.Intn(clusters)]
+		vec := make([]float32, dims)
+		for j := range vec {
+			vec[j] = center[j] + float32(rng.NormFloat64())*0.02
+		}
+		vecs[i], _ = utils.Normalize(vec)
+	}
+	return keys, vecs
+}
+
+// Compare searches of the HNSW index with the sequential index, which scans every vector.
+// Run with: go test -run '^$' -bench . ./collections/in_mem/hnsw/
+func BenchmarkVectorIndexSearch(b *testing.B) {
+	const dims = 384
+	for _, size := range []int{1_000, 10_000, 100_000} {
+		if testing.Short() && size > 10_000 {
+			continue
+		}
+
+		rng := rand.New(rand.NewSource(1))
+		keys, vecs := clusteredVectors(rng, size, dims)
+		ids := make([]int64, size)
+		for i := range ids {
+			ids[i] = int64(i + 1)
+		}
+		_, queries := clusteredVectors(rng, 100, dims)
+
+		indexes := map[string]interfaces.VectorIndex{
+			"sequential": sequential.NewSequentialVectorIndex("searchMethod", "embedder", "", false),
+			"hnsw":       NewHnswVectorIndex("searchMethod", "embedder", manifest.OptionsInfo{}, "", false),
+		}
+		for _, name := range []string{"sequential", "hnsw"} {
+			index := indexes[name]
+			if err := index.InsertVectorsToMemory(context.Background(), ids, ids, keys, vecs); err != nil {
+				b.Fatal(err)
+			}
+
+			b.Run(fmt.Sprintf("%s/%d", name, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := index.Search(context.Background(), queries[i%len(queries)], 10, nil); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkHnswVectorIndexInsert(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	keys, vecs := clusteredVectors(rng, b.N, 384)
+	index := NewHnswVectorIndex("searchMethod", "embedder", manifest.OptionsInfo{}, "", false)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := int64(i + 1)
+		if err := index.InsertVectorToMemory(context.Background(), id, id, keys[i], vecs[i]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
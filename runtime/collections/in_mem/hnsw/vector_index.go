@@ -10,12 +10,16 @@
 package hnsw
 
 import (
-	"container/heap"
+	"bufio"
+	"cmp"
 	"context"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"slices"
 	"sync"
 
+	"github.com/hypermodeinc/modus/lib/manifest"
 	"github.com/hypermodeinc/modus/runtime/collections/index"
 	"github.com/hypermodeinc/modus/runtime/collections/utils"
 	"github.com/hypermodeinc/modus/runtime/db"
@@ -24,25 +28,75 @@ import (
 
 const (
 	HnswVectorIndexType = "HnswVectorIndex"
+
+	defaultMaxLevels = 5
 )
 
 type HnswVectorIndex struct {
 	mu                sync.RWMutex
 	searchMethodName  string
 	embedderName      string
+	distance          string
+	normalize         bool
 	lastInsertedID    int64
 	lastIndexedTextID int64
 	HnswIndex         *hnsw.Graph[string]
 }
 
-func NewHnswVectorIndex(searchMethod, embedder string) *HnswVectorIndex {
+// NewHnswVectorIndex creates an HNSW index, tuned by the options of the search method in the manifest.
+// Options that are not set use the defaults of the graph.  The graph compares vectors with the distance metric,
+// and the vectors are normalized if normalize is set.
+func NewHnswVectorIndex(searchMethod, embedder string, options manifest.OptionsInfo, distance string, normalize bool) *HnswVectorIndex {
+	g := hnsw.NewGraph[string]()
+	g.Distance = graphDistance(distance)
+	if options.M > 0 {
+		g.M = options.M
+	}
+	if options.EfConstruction > 0 {
+		g.EfConstruction = options.EfConstruction
+	}
+	if options.EfSearch > 0 {
+		g.EfSearch = options.EfSearch
+	}
+	g.MaxLevels = defaultMaxLevels
+	if options.MaxLevels > 0 {
+		g.MaxLevels = options.MaxLevels
+	}
+
 	return &HnswVectorIndex{
 		searchMethodName: searchMethod,
 		embedderName:     embedder,
-		HnswIndex:        hnsw.NewGraph[string](),
+		distance:         distance,
+		normalize:        normalize,
+		HnswIndex:        g,
+	}
+}
+
+// graphDistance returns the distance function of the graph for the distance metric of the search method.
+func graphDistance(distance string) hnsw.DistanceFunc {
+	switch distance {
+	case manifest.DistanceDot:
+		return hnsw.DotProductDistance
+	case manifest.DistanceEuclidean:
+		return hnsw.EuclideanDistance
+	default:
+		return hnsw.CosineDistance
 	}
 }
 
+func (ims *HnswVectorIndex) GetDistance() string {
+	return ims.distance
+}
+
+func (ims *HnswVectorIndex) GetNormalize() bool {
+	return ims.normalize
+}
+
+// GetQuantization returns no quantization, since the graph keeps its vectors in full precision.
+func (ims *HnswVectorIndex) GetQuantization() manifest.QuantizationInfo {
+	return manifest.QuantizationInfo{}
+}
+
 func (ims *HnswVectorIndex) GetSearchMethodName() string {
 	return ims.searchMethodName
 }
@@ -65,57 +119,61 @@ func (ims *HnswVectorIndex) GetVectorNodesMap() map[string][]float32 {
 }
 
 func (ims *HnswVectorIndex) Search(ctx context.Context, query []float32, maxResults int, filter index.SearchFilter) (utils.MaxTupleHeap, error) {
-	// calculate cosine similarity and return top maxResults results
 	ims.mu.RLock()
 	defer ims.mu.RUnlock()
 	if ims.HnswIndex == nil {
 		return nil, fmt.Errorf("vector index is not initialized")
 	}
-	neighbors, err := ims.HnswIndex.Search(query, maxResults)
-	if err != nil {
-		return nil, err
-	}
-	keys, distances := make([]string, len(neighbors)), make([]float64, len(neighbors))
-	for i, neighbor := range neighbors {
-		keys[i] = string(neighbor.Key)
-		distances[i] = float64(neighbor.Distance)
+	if maxResults <= 0 {
+		maxResults = 1
 	}
-	var results utils.MaxTupleHeap
-	heap.Init(&results)
-
-	for i, key := range keys {
-		heap.Push(&results, utils.InitHeapElement(distances[i], key, false))
+	query = utils.PrepareVector(query, ims.normalize)
+	size := ims.HnswIndex.Len()
+	if size == 0 {
+		return utils.MaxTupleHeap{}, nil
 	}
 
-	// Return top maxResults results
+	// The graph keeps only as many candidates as it is asked for, so ask for at least efSearch of them
+	// and keep the best.  The graph doesn't know about the filter, so ask for more neighbors until enough
+	// of them pass it, or until the whole graph has been searched.
 	var finalResults utils.MaxTupleHeap
-	for results.Len() > 0 {
-		finalResults = append(finalResults, heap.Pop(&results).(utils.MaxHeapElement))
-	}
-	// Reverse the finalResults to get the highest similarity first
-	for i, j := 0, len(finalResults)-1; i < j; i, j = i+1, j-1 {
-		finalResults[i], finalResults[j] = finalResults[j], finalResults[i]
+	for k := max(maxResults, ims.HnswIndex.EfSearch); ; k *= 4 {
+		neighbors, err := ims.HnswIndex.Search(query, min(k, size))
+		if err != nil {
+			return nil, err
+		}
+		slices.SortStableFunc(neighbors, func(a, b hnsw.SearchResultNode[string]) int {
+			return cmp.Compare(a.Distance, b.Distance)
+		})
+
+		finalResults = finalResults[:0]
+		for _, neighbor := range neighbors {
+			if filter != nil && !filter(query, neighbor.Value, neighbor.Key) {
+				continue
+			}
+			finalResults = append(finalResults, utils.InitHeapElement(float64(neighbor.Distance), neighbor.Key, false))
+			if len(finalResults) == maxResults {
+				break
+			}
+		}
+
+		if len(finalResults) == maxResults || k >= size {
+			break
+		}
 	}
 
 	return finalResults, nil
 }
 
 func (ims *HnswVectorIndex) SearchWithKey(ctx context.Context, queryKey string, maxResults int, filter index.SearchFilter) (utils.MaxTupleHeap, error) {
-	ims.mu.RLock()
 	query, found := ims.HnswIndex.Lookup(queryKey)
 	if !found {
-		return nil, fmt.Errorf("key not found")
-	}
-	ims.mu.RUnlock()
-	if query == nil {
 		return nil, nil
 	}
 	return ims.Search(ctx, query, maxResults, filter)
 }
 
 func (ims *HnswVectorIndex) InsertVectors(ctx context.Context, textIds []int64, vecs [][]float32) error {
-	ims.mu.Lock()
-	defer ims.mu.Unlock()
 	if len(textIds) != len(vecs) {
 		return fmt.Errorf("textIds and vecs must have the same length")
 	}
@@ -140,6 +198,18 @@ func (ims *HnswVectorIndex) InsertVector(ctx context.Context, textId int64, vec
 }
 
 func (ims *HnswVectorIndex) InsertVectorsToMemory(ctx context.Context, textIds []int64, vectorIds []int64, keys []string, vecs [][]float32) error {
+	if len(vectorIds) == 0 {
+		return nil
+	}
+	ims.mu.Lock()
+	defer ims.mu.Unlock()
+	if ims.normalize {
+		normalized := make([][]float32, len(vecs))
+		for i, vec := range vecs {
+			normalized[i] = utils.PrepareVector(vec, true)
+		}
+		vecs = normalized
+	}
 	nodes, err := hnsw.MakeNodes(keys, vecs)
 	if err != nil {
 		return err
@@ -156,7 +226,7 @@ func (ims *HnswVectorIndex) InsertVectorsToMemory(ctx context.Context, textIds [
 func (ims *HnswVectorIndex) InsertVectorToMemory(ctx context.Context, textId, vectorId int64, key string, vec []float32) error {
 	ims.mu.Lock()
 	defer ims.mu.Unlock()
-	err := ims.HnswIndex.Add(hnsw.MakeNode(key, vec))
+	err := ims.HnswIndex.Add(hnsw.MakeNode(key, utils.PrepareVector(vec, ims.normalize)))
 	if err != nil {
 		return err
 	}
@@ -176,6 +246,13 @@ func (ims *HnswVectorIndex) DeleteVector(ctx context.Context, textId int64, key
 	return nil
 }
 
+func (ims *HnswVectorIndex) DeleteVectorFromMemory(ctx context.Context, key string) error {
+	ims.mu.Lock()
+	defer ims.mu.Unlock()
+	ims.HnswIndex.Delete(key)
+	return nil
+}
+
 func (ims *HnswVectorIndex) GetVector(ctx context.Context, key string) ([]float32, error) {
 	ims.mu.RLock()
 	defer ims.mu.RUnlock()
@@ -194,3 +271,71 @@ func (ims *HnswVectorIndex) GetLastIndexedTextId(ctx context.Context) (int64, er
 	defer ims.mu.RUnlock()
 	return ims.lastIndexedTextID, nil
 }
+
+// WriteSnapshot writes the checkpoints of the index, followed by its graph.
+// Restoring the graph is much faster than inserting its vectors again.
+func (ims *HnswVectorIndex) WriteSnapshot(w io.Writer) error {
+	ims.mu.RLock()
+	defer ims.mu.RUnlock()
+	if err := binary.Write(w, binary.LittleEndian, [2]int64{ims.lastInsertedID, ims.lastIndexedTextID}); err != nil {
+		return err
+	}
+	return ims.HnswIndex.Export(w)
+}
+
+// ReadSnapshot restores the index from a snapshot.  The graph keeps the options and distance it was created with,
+// rather than those of the snapshot, so that changes to the manifest are applied.
+func (ims *HnswVectorIndex) ReadSnapshot(r io.Reader) error {
+	br, ok := r.(interface {
+		io.Reader
+		io.ByteReader
+	})
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	var checkpoints [2]int64
+	if err := binary.Read(br, binary.LittleEndian, &checkpoints); err != nil {
+		return err
+	}
+
+	ims.mu.Lock()
+	defer ims.mu.Unlock()
+	g := hnsw.NewGraph[string]()
+	if err := g.Import(br); err != nil {
+		return err
+	}
+	g.M = ims.HnswIndex.M
+	g.Ml = ims.HnswIndex.Ml
+	g.EfSearch = ims.HnswIndex.EfSearch
+	g.EfConstruction = ims.HnswIndex.EfConstruction
+	g.MaxLevels = ims.HnswIndex.MaxLevels
+	g.Distance = ims.HnswIndex.Distance
+
+	ims.HnswIndex = g
+	ims.lastInsertedID = checkpoints[0]
+	ims.lastIndexedTextID = checkpoints[1]
+	return nil
+}
+
+// GetStats counts 4 bytes for each dimension of the vectors, and a key and pointer for each of the up to M neighbors
+// of a node in the base layer of the graph.  The upper layers hold a small fraction of the nodes, so they are ignored.
+func (ims *HnswVectorIndex) GetStats() index.VectorIndexStats {
+	ims.mu.RLock()
+	defer ims.mu.RUnlock()
+
+	nodes := ims.HnswIndex.Len()
+	dims := ims.HnswIndex.Dims()
+	return index.VectorIndexStats{
+		Vectors:     nodes,
+		Dimensions:  dims,
+		MemoryBytes: int64(nodes) * int64(4*dims+24*ims.HnswIndex.M),
+	}
+}
+
+func (ims *HnswVectorIndex) SetCheckpoints(lastInsertedId, lastIndexedTextId int64) {
+	ims.mu.Lock()
+	defer ims.mu.Unlock()
+	ims.lastInsertedID = lastInsertedId
+	ims.lastIndexedTextID = lastIndexedTextId
+}
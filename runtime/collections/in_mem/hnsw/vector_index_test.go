@@ -12,9 +12,12 @@ package hnsw
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"sync"
 	"testing"
 
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem/sequential"
 	"github.com/hypermodeinc/modus/runtime/collections/utils"
 )
 
@@ -44,7 +47,7 @@ func TestMultipleSequentialVectorIndexes(t *testing.T) {
 			defer wg.Done()
 
 			// Create a new HnswVectorIndex
-			index := NewHnswVectorIndex("searchMethod"+fmt.Sprint(i), "embedder"+fmt.Sprint(i))
+			index := NewHnswVectorIndex("searchMethod"+fmt.Sprint(i), "embedder"+fmt.Sprint(i), manifest.OptionsInfo{}, "", false)
 
 			// Generate unique data for this index
 			textIds := make([]int64, len(baseTextIds))
@@ -115,3 +118,129 @@ func TestMultipleSequentialVectorIndexes(t *testing.T) {
 	// Wait for all goroutines to finish
 	wg.Wait()
 }
+
+func TestHnswVectorIndexOptions(t *testing.T) {
+	index := NewHnswVectorIndex("searchMethod", "embedder", manifest.OptionsInfo{M: 8, EfConstruction: 32, EfSearch: 64, MaxLevels: 3}, "", false)
+	if index.HnswIndex.M != 8 || index.HnswIndex.EfConstruction != 32 || index.HnswIndex.EfSearch != 64 || index.HnswIndex.MaxLevels != 3 {
+		t.Errorf("Expected options to be applied to the graph, got %+v", index.HnswIndex)
+	}
+
+	index = NewHnswVectorIndex("searchMethod", "embedder", manifest.OptionsInfo{}, "", false)
+	if index.HnswIndex.M != 20 || index.HnswIndex.MaxLevels != defaultMaxLevels {
+		t.Errorf("Expected default options, got %+v", index.HnswIndex)
+	}
+}
+
+func TestHnswVectorIndexSearch(t *testing.T) {
+	ctx := context.Background()
+	index := NewHnswVectorIndex("searchMethod", "embedder", manifest.OptionsInfo{}, "", false)
+
+	// an empty index has no results
+	objs, err := index.Search(ctx, []float32{1, 0}, 3, nil)
+	if err != nil {
+		t.Fatalf("Failed to search empty index: %v", err)
+	}
+	if len(objs) != 0 {
+		t.Errorf("Expected no results, got %v", objs)
+	}
+
+	// a missing key has no results, and doesn't hold the lock
+	objs, err = index.SearchWithKey(ctx, "missing", 3, nil)
+	if err != nil || objs != nil {
+		t.Errorf("Expected no results for a missing key, got %v, %v", objs, err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	keys, vecs := randomVectors(rng, 500, 16)
+	ids := make([]int64, len(keys))
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	if err := index.InsertVectorsToMemory(ctx, ids, ids, keys, vecs); err != nil {
+		t.Fatalf("Failed to insert vectors: %v", err)
+	}
+
+	// results are ordered by distance
+	objs, err = index.Search(ctx, vecs[0], 10, nil)
+	if err != nil {
+		t.Fatalf("Failed to search index: %v", err)
+	}
+	if len(objs) != 10 || objs[0].GetIndex() != keys[0] {
+		t.Fatalf("Expected 10 results starting with %s, got %v", keys[0], objs)
+	}
+	for i := 1; i < len(objs); i++ {
+		if objs[i].GetValue() < objs[i-1].GetValue() {
+			t.Errorf("Expected results ordered by distance, got %v", objs)
+		}
+	}
+
+	// the filter is applied, and enough results are still returned
+	odd := func(query, vec []float32, key string) bool { return key[len(key)-1]%2 == 1 }
+	objs, err = index.Search(ctx, vecs[0], 10, odd)
+	if err != nil {
+		t.Fatalf("Failed to search index: %v", err)
+	}
+	if len(objs) != 10 {
+		t.Errorf("Expected 10 filtered results, got %d", len(objs))
+	}
+	for _, obj := range objs {
+		if !odd(nil, nil, obj.GetIndex()) {
+			t.Errorf("Expected only filtered results, got %s", obj.GetIndex())
+		}
+	}
+}
+
+func TestHnswVectorIndexRecall(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(2))
+	keys, vecs := randomVectors(rng, 2000, 32)
+	ids := make([]int64, len(keys))
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+
+	hnswIndex := NewHnswVectorIndex("searchMethod", "embedder", manifest.OptionsInfo{}, "", false)
+	seqIndex := sequential.NewSequentialVectorIndex("searchMethod", "embedder", "", false)
+	if err := hnswIndex.InsertVectorsToMemory(ctx, ids, ids, keys, vecs); err != nil {
+		t.Fatalf("Failed to insert vectors: %v", err)
+	}
+	if err := seqIndex.InsertVectorsToMemory(ctx, ids, ids, keys, vecs); err != nil {
+		t.Fatalf("Failed to insert vectors: %v", err)
+	}
+
+	const k = 10
+	found, total := 0, 0
+	for range 50 {
+		_, queries := randomVectors(rng, 1, 32)
+		expected, _ := seqIndex.Search(ctx, queries[0], k, nil)
+		actual, _ := hnswIndex.Search(ctx, queries[0], k, nil)
+		want := make(map[string]bool, k)
+		for _, e := range expected {
+			want[e.GetIndex()] = true
+		}
+		for _, a := range actual {
+			if want[a.GetIndex()] {
+				found++
+			}
+		}
+		total += k
+	}
+
+	if recall := float64(found) / float64(total); recall < 0.9 {
+		t.Errorf("Expected recall of at least 0.9, got %.2f", recall)
+	}
+}
+
+func randomVectors(rng *rand.Rand, n, dims int) ([]string, [][]float32) {
+	keys := make([]string, n)
+	vecs := make([][]float32, n)
+	for i := range n {
+		keys[i] = fmt.Sprintf("key%d", i)
+		vec := make([]float32, dims)
+		for j := range vec {
+			vec[j] = rng.Float32()*2 - 1
+		}
+		vecs[i], _ = utils.Normalize(vec)
+	}
+	return keys, vecs
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package keyword
+
+import (
+	"container/heap"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/hypermodeinc/modus/runtime/collections/utils"
+)
+
+// BM25 parameters, using the common defaults.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// KeywordIndex is an inverted index of the terms in texts, which ranks texts for a query with BM25.
+type KeywordIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[string]int // term: key: term frequency
+	docTerms map[string][]string       // key: unique terms, to remove a text from the postings
+	docLens  map[string]int            // key: number of terms
+	totalLen int
+}
+
+func NewKeywordIndex() *KeywordIndex {
+	return &KeywordIndex{
+		postings: map[string]map[string]int{},
+		docTerms: map[string][]string{},
+		docLens:  map[string]int{},
+	}
+}
+
+// Tokenize splits text into lowercase terms of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Insert adds the text for the key, replacing any text previously added for it.
+func (ki *KeywordIndex) Insert(key, text string) {
+	terms := Tokenize(text)
+	freqs := make(map[string]int, len(terms))
+	for _, term := range terms {
+		freqs[term]++
+	}
+
+	ki.mu.Lock()
+	defer ki.mu.Unlock()
+	ki.delete(key)
+
+	unique := make([]string, 0, len(freqs))
+	for term, freq := range freqs {
+		keys, ok := ki.postings[term]
+		if !ok {
+			keys = map[string]int{}
+			ki.postings[term] = keys
+		}
+		keys[key] = freq
+		unique = append(unique, term)
+	}
+	ki.docTerms[key] = unique
+	ki.docLens[key] = len(terms)
+	ki.totalLen += len(terms)
+}
+
+// Delete removes the text for the key.
+func (ki *KeywordIndex) Delete(key string) {
+	ki.mu.Lock()
+	defer ki.mu.Unlock()
+	ki.delete(key)
+}
+
+func (ki *KeywordIndex) delete(key string) {
+	terms, ok := ki.docTerms[key]
+	if !ok {
+		return
+	}
+	for _, term := range terms {
+		keys := ki.postings[term]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(ki.postings, term)
+		}
+	}
+	ki.totalLen -= ki.docLens[key]
+	delete(ki.docTerms, key)
+	delete(ki.docLens, key)
+}
+
+// Len returns the number of texts in the index.
+func (ki *KeywordIndex) Len() int {
+	ki.mu.RLock()
+	defer ki.mu.RUnlock()
+	return len(ki.docLens)
+}
+
+// Search returns the keys of the texts that best match the query, with their BM25 scores, highest first.
+// Texts that don't contain any of the query terms, or that the filter rejects, are not returned.
+func (ki *KeywordIndex) Search(query string, maxResults int, filter func(key string) bool) utils.MaxTupleHeap {
+	terms := Tokenize(query)
+	if maxResults <= 0 || len(terms) == 0 {
+		return nil
+	}
+
+	ki.mu.RLock()
+	defer ki.mu.RUnlock()
+
+	n := float64(len(ki.docLens))
+	if n == 0 {
+		return nil
+	}
+	avgLen := float64(ki.totalLen) / n
+
+	scores := map[string]float64{}
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		keys := ki.postings[term]
+		if len(keys) == 0 {
+			continue
+		}
+		df := float64(len(keys))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for key, freq := range keys {
+			tf := float64(freq)
+			norm := 1 - b + b*float64(ki.docLens[key])/avgLen
+			scores[key] += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+
+	// keep the best results in a min-heap, by negating the scores of the max-heap
+	var results utils.MaxTupleHeap
+	for key, score := range scores {
+		if filter != nil && !filter(key) {
+			continue
+		}
+		if results.Len() < maxResults {
+			heap.Push(&results, utils.InitHeapElement(-score, key, false))
+		} else if -score < results[0].GetValue() {
+			heap.Pop(&results)
+			heap.Push(&results, utils.InitHeapElement(-score, key, false))
+		}
+	}
+
+	finalResults := make(utils.MaxTupleHeap, results.Len())
+	for i := len(finalResults) - 1; i >= 0; i-- {
+		e := heap.Pop(&results).(utils.MaxHeapElement)
+		finalResults[i] = utils.InitHeapElement(-e.GetValue(), e.GetIndex(), false)
+	}
+	return finalResults
+}
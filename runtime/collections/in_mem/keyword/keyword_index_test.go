@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package keyword
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"hello", "world", "go1", "23", "café"}, Tokenize("Hello, World! go1.23 -- Café"))
+	assert.Empty(t, Tokenize(" ,.! "))
+}
+
+func TestKeywordIndexSearch(t *testing.T) {
+	ki := NewKeywordIndex()
+	ki.Insert("a", "the quick brown fox")
+	ki.Insert("b", "the lazy dog sleeps all day")
+	ki.Insert("c", "a quick quick dog")
+	ki.Insert("d", "error code E1234 in the parser")
+	require.Equal(t, 4, ki.Len())
+
+	results := ki.Search("quick dog", 10, nil)
+	require.Len(t, results, 3)
+	// c has both terms, and quick twice
+	assert.Equal(t, "c", results[0].GetIndex())
+	assert.Greater(t, results[0].GetValue(), results[1].GetValue())
+	assert.Greater(t, results[1].GetValue(), results[2].GetValue())
+
+	// exact terms that an embedding would likely miss
+	results = ki.Search("E1234", 10, nil)
+	require.Len(t, results, 1)
+	assert.Equal(t, "d", results[0].GetIndex())
+
+	// common terms count less than rare ones
+	results = ki.Search("the fox", 10, nil)
+	require.Len(t, results, 3)
+	assert.Equal(t, "a", results[0].GetIndex())
+
+	results = ki.Search("quick dog", 1, nil)
+	require.Len(t, results, 1)
+	assert.Equal(t, "c", results[0].GetIndex())
+
+	results = ki.Search("quick dog", 10, func(key string) bool { return key != "c" })
+	require.Len(t, results, 2)
+	assert.ElementsMatch(t, []string{"a", "b"}, []string{results[0].GetIndex(), results[1].GetIndex()})
+
+	assert.Empty(t, ki.Search("unicorn", 10, nil))
+	assert.Empty(t, ki.Search("", 10, nil))
+}
+
+func TestKeywordIndexUpdateAndDelete(t *testing.T) {
+	ki := NewKeywordIndex()
+	ki.Insert("a", "red apple")
+	ki.Insert("b", "green apple")
+
+	// replacing a text removes its old terms
+	ki.Insert("a", "yellow banana")
+	assert.Empty(t, ki.Search("red", 10, nil))
+	results := ki.Search("banana", 10, nil)
+	require.Len(t, results, 1)
+	assert.Equal(t, "a", results[0].GetIndex())
+
+	ki.Delete("b")
+	ki.Delete("missing")
+	assert.Empty(t, ki.Search("apple", 10, nil))
+	assert.Equal(t, 1, ki.Len())
+	assert.Equal(t, 2, ki.totalLen)
+	assert.NotContains(t, ki.postings, "apple")
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sequential
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/index"
+	"github.com/hypermodeinc/modus/runtime/collections/quantization"
+	"github.com/hypermodeinc/modus/runtime/collections/utils"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/logger"
+)
+
+// queryFullVectors fetches the full-precision vectors of the texts, to re-rank the candidates of a quantized search.
+var queryFullVectors = db.QueryCollectionVectorsByTextIds
+
+// NewQuantizedSequentialVectorIndex creates a sequential index that keeps its vectors quantized in memory.
+// Searches rank the quantized vectors, and then re-rank the best of them with the full-precision vectors
+// in the database.  Vectors are kept in full precision until the quantizer has been trained on enough of them.
+func NewQuantizedSequentialVectorIndex(searchMethod, embedder, distance string, normalize bool, info manifest.QuantizationInfo) (*SequentialVectorIndex, error) {
+	quantizer, err := quantization.New(info)
+	if err != nil {
+		return nil, err
+	}
+
+	ims := NewSequentialVectorIndex(searchMethod, embedder, distance, normalize)
+	ims.quantization = info
+	ims.quantizer = quantizer
+	ims.codes = make(map[string][]byte)
+	ims.textIds = make(map[string]int64)
+	return ims, nil
+}
+
+func (ims *SequentialVectorIndex) GetQuantization() manifest.QuantizationInfo {
+	return ims.quantization
+}
+
+// storeVector keeps the vector for the key, quantized if the index is quantized.  The lock must be held.
+func (ims *SequentialVectorIndex) storeVector(key string, textId int64, vec []float32) error {
+	vec = utils.PrepareVector(vec, ims.normalize)
+	if ims.quantizer == nil {
+		ims.VectorMap[key] = vec
+		return nil
+	}
+
+	ims.textIds[key] = textId
+	if !ims.quantizer.Trained() {
+		ims.VectorMap[key] = vec
+		delete(ims.codes, key)
+		if len(ims.VectorMap) >= ims.quantizer.TrainingSize() {
+			return ims.trainQuantizer()
+		}
+		return nil
+	}
+
+	code, err := ims.quantizer.Encode(vec)
+	if err != nil {
+		return err
+	}
+	ims.codes[key] = code
+	delete(ims.VectorMap, key)
+	return nil
+}
+
+// trainQuantizer trains the quantizer on the full-precision vectors, and then quantizes them.  The lock must be held.
+func (ims *SequentialVectorIndex) trainQuantizer() error {
+	sample := make([][]float32, 0, len(ims.VectorMap))
+	for _, vec := range ims.VectorMap {
+		sample = append(sample, vec)
+	}
+	if err := ims.quantizer.Train(sample); err != nil {
+		return err
+	}
+
+	for key, vec := range ims.VectorMap {
+		code, err := ims.quantizer.Encode(vec)
+		if err != nil {
+			return err
+		}
+		ims.codes[key] = code
+	}
+	ims.VectorMap = make(map[string][]float32)
+	return nil
+}
+
+func (ims *SequentialVectorIndex) deleteVector(key string) {
+	delete(ims.VectorMap, key)
+	if ims.quantizer != nil {
+		delete(ims.codes, key)
+		delete(ims.textIds, key)
+	}
+}
+
+// searchQuantized ranks the quantized vectors, and re-ranks the best candidates with their full-precision vectors.
+func (ims *SequentialVectorIndex) searchQuantized(ctx context.Context, query []float32, maxResults int, filter index.SearchFilter) (utils.MaxTupleHeap, error) {
+	if maxResults <= 0 {
+		maxResults = 1
+	}
+	rerank := ims.quantization.Rerank
+	if rerank <= 0 {
+		rerank = quantization.DefaultRerank
+	}
+	query = utils.PrepareVector(query, ims.normalize)
+	queryNormSq, _ := utils.DotProduct(query, query)
+
+	ims.mu.RLock()
+	var candidates utils.MaxTupleHeap
+	push := func(distance float64, key string) {
+		if candidates.Len() < maxResults*rerank {
+			heap.Push(&candidates, utils.InitHeapElement(distance, key, false))
+		} else if utils.IsBetterScoreForDistance(distance, candidates[0].GetValue()) {
+			heap.Pop(&candidates)
+			heap.Push(&candidates, utils.InitHeapElement(distance, key, false))
+		}
+	}
+
+	// vectors that haven't been quantized yet are compared exactly
+	for key, vector := range ims.VectorMap {
+		if filter != nil && !filter(query, vector, key) {
+			continue
+		}
+		distance, err := utils.Distance(ims.distance, query, vector)
+		if err != nil {
+			ims.mu.RUnlock()
+			return nil, err
+		}
+		push(distance, key)
+	}
+
+	score := ims.quantizer.Scorer(query)
+	for key, code := range ims.codes {
+		if filter != nil && !filter(query, ims.quantizer.Decode(code), key) {
+			continue
+		}
+		dot, normSq := score(code)
+		push(quantization.Distance(ims.distance, dot, normSq, float64(queryNormSq)), key)
+	}
+
+	results := make(utils.MaxTupleHeap, 0, candidates.Len())
+	var rerankIds []int64
+	for candidates.Len() > 0 {
+		c := heap.Pop(&candidates).(utils.MaxHeapElement)
+		results = append(results, c)
+		if _, ok := ims.codes[c.GetIndex()]; ok {
+			rerankIds = append(rerankIds, ims.textIds[c.GetIndex()])
+		}
+	}
+	textIds := make(map[string]int64, len(results))
+	for _, r := range results {
+		textIds[r.GetIndex()] = ims.textIds[r.GetIndex()]
+	}
+	ims.mu.RUnlock()
+
+	// the database is queried without holding the lock
+	if len(rerankIds) > 0 {
+		vectors, err := queryFullVectors(ctx, ims.searchMethodName, rerankIds)
+		if err != nil {
+			logger.Warn(ctx).Err(err).Str("search_method", ims.searchMethodName).Msg("Failed to re-rank quantized vectors, using their approximate distances.")
+		}
+		for i, r := range results {
+			vec, ok := vectors[textIds[r.GetIndex()]]
+			if !ok {
+				continue
+			}
+			distance, err := utils.Distance(ims.distance, query, utils.PrepareVector(vec, ims.normalize))
+			if err != nil {
+				return nil, err
+			}
+			results[i] = utils.InitHeapElement(distance, r.GetIndex(), false)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return utils.IsBetterScoreForDistance(results[i].GetValue(), results[j].GetValue())
+	})
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results, nil
+}
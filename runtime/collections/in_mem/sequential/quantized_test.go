@@ -0,0 +1,179 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package sequential
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/quantization"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	secrets.Initialize(context.Background())
+	os.Exit(m.Run())
+}
+
+// insertRandomVectors inserts the same random vectors into each index, and returns them by text id.
+func insertRandomVectors(t *testing.T, indexes []*SequentialVectorIndex, n, dims int) map[int64][]float32 {
+	rng := rand.New(rand.NewSource(7))
+	ids := make([]int64, n)
+	keys := make([]string, n)
+	vecs := make([][]float32, n)
+	byId := make(map[int64][]float32, n)
+	for i := range vecs {
+		ids[i] = int64(i + 1)
+		keys[i] = fmt.Sprint("key", i)
+		vecs[i] = make([]float32, dims)
+		for j := range vecs[i] {
+			vecs[i][j] = rng.Float32()*2 - 1
+		}
+		byId[ids[i]] = vecs[i]
+	}
+	for _, ims := range indexes {
+		require.NoError(t, ims.InsertVectorsToMemory(context.Background(), ids, ids, keys, vecs))
+	}
+	return byId
+}
+
+func TestQuantizedSearchRecall(t *testing.T) {
+	ctx := context.Background()
+	query := []float32{0.5, -0.2, 0.1, 0.9, -0.4, 0.3, 0.0, -0.7, 0.2, 0.6, -0.1, 0.8, 0.4, -0.5, 0.3, 0.1}
+
+	tests := []struct {
+		quantization string
+		rerank       bool
+		minRecall    int
+	}{
+		{manifest.QuantizationInt8, false, 8},
+		{manifest.QuantizationInt8, true, 10},
+		{manifest.QuantizationProduct, false, 4},
+		{manifest.QuantizationProduct, true, 8},
+	}
+
+	for _, tt := range tests {
+		name := tt.quantization
+		if tt.rerank {
+			name += "/rerank"
+		}
+		t.Run(name, func(t *testing.T) {
+			quantizationType := tt.quantization
+			exact := NewSequentialVectorIndex("search", "embedder", manifest.DistanceCosine, false)
+			quantized, err := NewQuantizedSequentialVectorIndex("search", "embedder", manifest.DistanceCosine, false, manifest.QuantizationInfo{Type: quantizationType, Rerank: 8})
+			require.NoError(t, err)
+			if quantizationType == manifest.QuantizationProduct {
+				quantized.quantizer = quantization.NewProductQuantizer(0, 100)
+			}
+			vectors := insertRandomVectors(t, []*SequentialVectorIndex{exact, quantized}, 500, len(query))
+			assert.Empty(t, quantized.VectorMap)
+
+			// there is no database in tests, so re-ranking either falls back to the approximate distances,
+			// or gets the full-precision vectors from memory
+			if tt.rerank {
+				queryFullVectors = func(ctx context.Context, searchMethod string, textIds []int64) (map[int64][]float32, error) {
+					result := make(map[int64][]float32, len(textIds))
+					for _, id := range textIds {
+						result[id] = vectors[id]
+					}
+					return result, nil
+				}
+				defer func() { queryFullVectors = db.QueryCollectionVectorsByTextIds }()
+			}
+			assert.Len(t, quantized.codes, 500)
+
+			want, err := exact.Search(ctx, query, 10, nil)
+			require.NoError(t, err)
+			got, err := quantized.Search(ctx, query, 10, nil)
+			require.NoError(t, err)
+			require.Len(t, got, 10)
+
+			wantKeys := map[string]bool{}
+			for _, r := range want {
+				wantKeys[r.GetIndex()] = true
+			}
+			found := 0
+			for i, r := range got {
+				if wantKeys[r.GetIndex()] {
+					found++
+				}
+				if i > 0 {
+					assert.LessOrEqual(t, got[i-1].GetValue(), r.GetValue())
+				}
+			}
+			assert.GreaterOrEqual(t, found, tt.minRecall, "recall of quantized search is too low")
+			if tt.rerank {
+				assert.InDelta(t, want[0].GetValue(), got[0].GetValue(), 1e-6)
+			}
+		})
+	}
+}
+
+func TestProductQuantizationTraining(t *testing.T) {
+	ctx := context.Background()
+	ims, err := NewQuantizedSequentialVectorIndex("search", "embedder", manifest.DistanceEuclidean, false, manifest.QuantizationInfo{Type: manifest.QuantizationProduct})
+	require.NoError(t, err)
+	ims.quantizer = quantization.NewProductQuantizer(2, 10)
+
+	// vectors are kept in full precision until there are enough of them to train on
+	insertRandomVectors(t, []*SequentialVectorIndex{ims}, 9, 8)
+	assert.Len(t, ims.VectorMap, 9)
+	assert.Empty(t, ims.codes)
+
+	require.NoError(t, ims.InsertVectorToMemory(ctx, 10, 10, "key9", []float32{1, 2, 3, 4, 5, 6, 7, 8}))
+	assert.Empty(t, ims.VectorMap)
+	assert.Len(t, ims.codes, 10)
+	assert.Len(t, ims.codes["key9"], 2)
+
+	vec, err := ims.GetVector(ctx, "key9")
+	require.NoError(t, err)
+	assert.Len(t, vec, 8)
+
+	results, err := ims.Search(ctx, []float32{1, 2, 3, 4, 5, 6, 7, 8}, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "key9", results[0].GetIndex())
+
+	require.NoError(t, ims.DeleteVectorFromMemory(ctx, "key9"))
+	assert.Len(t, ims.codes, 9)
+	assert.NotContains(t, ims.textIds, "key9")
+}
+
+func TestQuantizedSnapshot(t *testing.T) {
+	ctx := context.Background()
+	info := manifest.QuantizationInfo{Type: manifest.QuantizationInt8}
+	ims, err := NewQuantizedSequentialVectorIndex("search", "embedder", manifest.DistanceCosine, false, info)
+	require.NoError(t, err)
+	insertRandomVectors(t, []*SequentialVectorIndex{ims}, 20, 4)
+
+	var buf bytes.Buffer
+	require.NoError(t, ims.WriteSnapshot(&buf))
+
+	restored, err := NewQuantizedSequentialVectorIndex("search", "embedder", manifest.DistanceCosine, false, info)
+	require.NoError(t, err)
+	require.NoError(t, restored.ReadSnapshot(&buf))
+	assert.Equal(t, ims.codes, restored.codes)
+	assert.Equal(t, ims.textIds, restored.textIds)
+
+	want, err := ims.GetVector(ctx, "key3")
+	require.NoError(t, err)
+	got, err := restored.GetVector(ctx, "key3")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
@@ -12,10 +12,14 @@ package sequential
 import (
 	"container/heap"
 	"context"
+	"encoding/gob"
 	"fmt"
+	"io"
 	"sync"
 
+	"github.com/hypermodeinc/modus/lib/manifest"
 	"github.com/hypermodeinc/modus/runtime/collections/index"
+	"github.com/hypermodeinc/modus/runtime/collections/quantization"
 	"github.com/hypermodeinc/modus/runtime/collections/utils"
 	"github.com/hypermodeinc/modus/runtime/db"
 )
@@ -28,15 +32,27 @@ type SequentialVectorIndex struct {
 	mu                sync.RWMutex
 	searchMethodName  string
 	embedderName      string
+	distance          string
+	normalize         bool
 	lastInsertedID    int64
 	lastIndexedTextID int64
 	VectorMap         map[string][]float32 // key: vector
+
+	// quantized indexes keep the vectors as codes, with the ids of their texts for re-ranking
+	quantization manifest.QuantizationInfo
+	quantizer    quantization.Quantizer
+	codes        map[string][]byte
+	textIds      map[string]int64
 }
 
-func NewSequentialVectorIndex(searchMethod, embedder string) *SequentialVectorIndex {
+// NewSequentialVectorIndex creates a sequential index, which compares the query to every vector with the distance metric,
+// and normalizes the vectors if normalize is set.
+func NewSequentialVectorIndex(searchMethod, embedder, distance string, normalize bool) *SequentialVectorIndex {
 	return &SequentialVectorIndex{
 		searchMethodName: searchMethod,
 		embedderName:     embedder,
+		distance:         distance,
+		normalize:        normalize,
 		VectorMap:        make(map[string][]float32),
 	}
 }
@@ -58,6 +74,14 @@ func (ims *SequentialVectorIndex) GetEmbedderName() string {
 	return ims.embedderName
 }
 
+func (ims *SequentialVectorIndex) GetDistance() string {
+	return ims.distance
+}
+
+func (ims *SequentialVectorIndex) GetNormalize() bool {
+	return ims.normalize
+}
+
 func (ims *SequentialVectorIndex) GetVectorNodesMap() map[string][]float32 {
 	ims.mu.RLock()
 	defer ims.mu.RUnlock()
@@ -65,19 +89,24 @@ func (ims *SequentialVectorIndex) GetVectorNodesMap() map[string][]float32 {
 }
 
 func (ims *SequentialVectorIndex) Search(ctx context.Context, query []float32, maxResults int, filter index.SearchFilter) (utils.MaxTupleHeap, error) {
-	// calculate cosine similarity and return top maxResults results
+	if ims.quantizer != nil {
+		return ims.searchQuantized(ctx, query, maxResults, filter)
+	}
+
+	// calculate the distance to each vector and return top maxResults results
 	ims.mu.RLock()
 	defer ims.mu.RUnlock()
 	if maxResults <= 0 {
 		maxResults = 1
 	}
+	query = utils.PrepareVector(query, ims.normalize)
 	var results utils.MaxTupleHeap
 	heap.Init(&results)
 	for key, vector := range ims.VectorMap {
 		if filter != nil && !filter(query, vector, key) {
 			continue
 		}
-		similarity, err := utils.CosineDistance(query, vector)
+		similarity, err := utils.Distance(ims.distance, query, vector)
 		if err != nil {
 			return nil, err
 		}
@@ -139,7 +168,9 @@ func (ims *SequentialVectorIndex) InsertVectorsToMemory(ctx context.Context, tex
 	ims.mu.Lock()
 	defer ims.mu.Unlock()
 	for i, key := range keys {
-		ims.VectorMap[key] = vecs[i]
+		if err := ims.storeVector(key, textIds[i], vecs[i]); err != nil {
+			return err
+		}
 		ims.lastInsertedID = vectorIds[i]
 		ims.lastIndexedTextID = textIds[i]
 	}
@@ -149,7 +180,9 @@ func (ims *SequentialVectorIndex) InsertVectorsToMemory(ctx context.Context, tex
 func (ims *SequentialVectorIndex) InsertVectorToMemory(ctx context.Context, textId, vectorId int64, key string, vec []float32) error {
 	ims.mu.Lock()
 	defer ims.mu.Unlock()
-	ims.VectorMap[key] = vec
+	if err := ims.storeVector(key, textId, vec); err != nil {
+		return err
+	}
 	ims.lastInsertedID = vectorId
 	ims.lastIndexedTextID = textId
 	return nil
@@ -162,13 +195,23 @@ func (ims *SequentialVectorIndex) DeleteVector(ctx context.Context, textId int64
 	if err != nil {
 		return err
 	}
-	delete(ims.VectorMap, key)
+	ims.deleteVector(key)
+	return nil
+}
+
+func (ims *SequentialVectorIndex) DeleteVectorFromMemory(ctx context.Context, key string) error {
+	ims.mu.Lock()
+	defer ims.mu.Unlock()
+	ims.deleteVector(key)
 	return nil
 }
 
 func (ims *SequentialVectorIndex) GetVector(ctx context.Context, key string) ([]float32, error) {
 	ims.mu.RLock()
 	defer ims.mu.RUnlock()
+	if code, ok := ims.codes[key]; ok {
+		return ims.quantizer.Decode(code), nil
+	}
 	return ims.VectorMap[key], nil
 }
 
@@ -183,3 +226,80 @@ func (ims *SequentialVectorIndex) GetLastIndexedTextId(ctx context.Context) (int
 	defer ims.mu.RUnlock()
 	return ims.lastIndexedTextID, nil
 }
+
+func (ims *SequentialVectorIndex) SetCheckpoints(lastInsertedId, lastIndexedTextId int64) {
+	ims.mu.Lock()
+	defer ims.mu.Unlock()
+	ims.lastInsertedID = lastInsertedId
+	ims.lastIndexedTextID = lastIndexedTextId
+}
+
+// GetStats counts 4 bytes for each dimension of the vectors, or the size of their codes when they are quantized.
+func (ims *SequentialVectorIndex) GetStats() index.VectorIndexStats {
+	ims.mu.RLock()
+	defer ims.mu.RUnlock()
+
+	stats := index.VectorIndexStats{Vectors: len(ims.VectorMap) + len(ims.codes)}
+	for key, vec := range ims.VectorMap {
+		stats.Dimensions = len(vec)
+		stats.MemoryBytes += int64(len(key) + 4*len(vec))
+	}
+	for key, code := range ims.codes {
+		if stats.Dimensions == 0 {
+			stats.Dimensions = len(ims.quantizer.Decode(code))
+		}
+		// the id of the text is kept with each code
+		stats.MemoryBytes += int64(len(key) + len(code) + 8)
+	}
+	return stats
+}
+
+type sequentialSnapshot struct {
+	LastInsertedID    int64
+	LastIndexedTextID int64
+	VectorMap         map[string][]float32
+	Quantizer         quantization.Quantizer
+	Codes             map[string][]byte
+	TextIds           map[string]int64
+}
+
+func (ims *SequentialVectorIndex) WriteSnapshot(w io.Writer) error {
+	ims.mu.RLock()
+	defer ims.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(sequentialSnapshot{
+		LastInsertedID:    ims.lastInsertedID,
+		LastIndexedTextID: ims.lastIndexedTextID,
+		VectorMap:         ims.VectorMap,
+		Quantizer:         ims.quantizer,
+		Codes:             ims.codes,
+		TextIds:           ims.textIds,
+	})
+}
+
+func (ims *SequentialVectorIndex) ReadSnapshot(r io.Reader) error {
+	var snapshot sequentialSnapshot
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+
+	ims.mu.Lock()
+	defer ims.mu.Unlock()
+	ims.lastInsertedID = snapshot.LastInsertedID
+	ims.lastIndexedTextID = snapshot.LastIndexedTextID
+	ims.VectorMap = snapshot.VectorMap
+	if ims.VectorMap == nil {
+		ims.VectorMap = make(map[string][]float32)
+	}
+	if ims.quantizer != nil && snapshot.Quantizer != nil {
+		ims.quantizer = snapshot.Quantizer
+		ims.codes = snapshot.Codes
+		ims.textIds = snapshot.TextIds
+		if ims.codes == nil {
+			ims.codes = make(map[string][]byte)
+		}
+		if ims.textIds == nil {
+			ims.textIds = make(map[string]int64)
+		}
+	}
+	return nil
+}
@@ -11,16 +11,28 @@ package in_mem
 
 import (
 	"context"
+	"encoding/gob"
 	"fmt"
+	"io"
+	"maps"
 	"sync"
+	"time"
 
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem/keyword"
 	"github.com/hypermodeinc/modus/runtime/collections/index"
 	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/collections/utils"
 	"github.com/hypermodeinc/modus/runtime/db"
 )
 
 const DefaultNamespace = ""
 
+func init() {
+	// metadata values decoded from JSON can hold arrays and nested objects, which gob needs to know about
+	gob.Register([]any{})
+	gob.Register(map[string]any{})
+}
+
 type InMemCollectionNamespace struct {
 	mu             sync.RWMutex
 	collectionName string
@@ -28,8 +40,11 @@ type InMemCollectionNamespace struct {
 	lastInsertedID int64
 	TextMap        map[string]string // key: text
 	LabelsMap      map[string][]string
+	MetadataMap    map[string]map[string]any
 	IdMap          map[string]int64                          // key: postgres id
 	VectorIndexMap map[string]*interfaces.VectorIndexWrapper // searchMethod: vectorIndex
+	keywordIndex   *keyword.KeywordIndex
+	lastMutation   time.Time
 }
 
 func NewCollectionNamespace(name, namespace string) *InMemCollectionNamespace {
@@ -38,8 +53,10 @@ func NewCollectionNamespace(name, namespace string) *InMemCollectionNamespace {
 		namespace:      namespace,
 		TextMap:        map[string]string{},
 		LabelsMap:      map[string][]string{},
+		MetadataMap:    map[string]map[string]any{},
 		IdMap:          map[string]int64{},
 		VectorIndexMap: map[string]*interfaces.VectorIndexWrapper{},
+		keywordIndex:   keyword.NewKeywordIndex(),
 	}
 }
 
@@ -87,7 +104,17 @@ func (ti *InMemCollectionNamespace) DeleteVectorIndex(ctx context.Context, searc
 	return nil
 }
 
-func (ti *InMemCollectionNamespace) InsertTexts(ctx context.Context, keys []string, texts []string, labelsArr [][]string) error {
+func (ti *InMemCollectionNamespace) ReplaceVectorIndex(ctx context.Context, searchMethod string, vectorIndex *interfaces.VectorIndexWrapper) error {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	if _, ok := ti.VectorIndexMap[searchMethod]; !ok {
+		return index.ErrVectorIndexNotFound
+	}
+	ti.VectorIndexMap[searchMethod] = vectorIndex
+	return nil
+}
+
+func (ti *InMemCollectionNamespace) InsertTexts(ctx context.Context, keys []string, texts []string, labelsArr [][]string, metadataArr []map[string]any) error {
 	if len(keys) != len(texts) {
 		return fmt.Errorf("keys and texts must have the same length")
 	}
@@ -96,28 +123,35 @@ func (ti *InMemCollectionNamespace) InsertTexts(ctx context.Context, keys []stri
 		return fmt.Errorf("labels must have the same length as keys or be empty")
 	}
 
-	ids, err := db.WriteCollectionTexts(ctx, ti.collectionName, ti.namespace, keys, texts, labelsArr)
+	if len(metadataArr) != 0 && len(metadataArr) != len(keys) {
+		return fmt.Errorf("metadata must have the same length as keys or be empty")
+	}
+
+	ids, err := db.WriteCollectionTexts(ctx, ti.collectionName, ti.namespace, keys, texts, labelsArr, metadataArr)
 	if err != nil {
 		return err
 	}
 
-	return ti.InsertTextsToMemory(ctx, ids, keys, texts, labelsArr)
+	return ti.InsertTextsToMemory(ctx, ids, keys, texts, labelsArr, metadataArr)
 }
 
-func (ti *InMemCollectionNamespace) InsertText(ctx context.Context, key string, text string, labels []string) error {
-	id, err := db.WriteCollectionText(ctx, ti.collectionName, ti.namespace, key, text, labels)
+func (ti *InMemCollectionNamespace) InsertText(ctx context.Context, key string, text string, labels []string, metadata map[string]any) error {
+	id, err := db.WriteCollectionText(ctx, ti.collectionName, ti.namespace, key, text, labels, metadata)
 	if err != nil {
 		return err
 	}
 
-	return ti.InsertTextToMemory(ctx, id, key, text, labels)
+	return ti.InsertTextToMemory(ctx, id, key, text, labels, metadata)
 }
 
-func (ti *InMemCollectionNamespace) InsertTextsToMemory(ctx context.Context, ids []int64, keys []string, texts []string, labelsArr [][]string) error {
+func (ti *InMemCollectionNamespace) InsertTextsToMemory(ctx context.Context, ids []int64, keys []string, texts []string, labelsArr [][]string, metadataArr []map[string]any) error {
 
 	if len(labelsArr) != 0 && len(labelsArr) != len(keys) {
 		return fmt.Errorf("labels must have the same length as keys or be empty")
 	}
+	if len(metadataArr) != 0 && len(metadataArr) != len(keys) {
+		return fmt.Errorf("metadata must have the same length as keys or be empty")
+	}
 	if len(ids) != len(keys) || len(ids) != len(texts) {
 		return fmt.Errorf("ids, keys and texts must have the same length")
 	}
@@ -126,24 +160,38 @@ func (ti *InMemCollectionNamespace) InsertTextsToMemory(ctx context.Context, ids
 	defer ti.mu.Unlock()
 	for i, key := range keys {
 		ti.TextMap[key] = texts[i]
+		ti.keywordIndex.Insert(key, texts[i])
 		if len(labelsArr) != 0 {
 			ti.LabelsMap[key] = labelsArr[i]
 		}
+		if len(metadataArr) != 0 && len(metadataArr[i]) != 0 {
+			ti.MetadataMap[key] = metadataArr[i]
+		} else {
+			delete(ti.MetadataMap, key)
+		}
 		ti.IdMap[key] = ids[i]
 		ti.lastInsertedID = ids[i]
 	}
+	ti.lastMutation = time.Now()
 	return nil
 }
 
-func (ti *InMemCollectionNamespace) InsertTextToMemory(ctx context.Context, id int64, key string, text string, labels []string) error {
+func (ti *InMemCollectionNamespace) InsertTextToMemory(ctx context.Context, id int64, key string, text string, labels []string, metadata map[string]any) error {
 	ti.mu.Lock()
 	defer ti.mu.Unlock()
 	ti.TextMap[key] = text
+	ti.keywordIndex.Insert(key, text)
 	if len(labels) != 0 {
 		ti.LabelsMap[key] = labels
 	}
+	if len(metadata) != 0 {
+		ti.MetadataMap[key] = metadata
+	} else {
+		delete(ti.MetadataMap, key)
+	}
 	ti.IdMap[key] = id
 	ti.lastInsertedID = id
+	ti.lastMutation = time.Now()
 	return nil
 }
 
@@ -155,6 +203,20 @@ func (ti *InMemCollectionNamespace) DeleteText(ctx context.Context, key string)
 		return err
 	}
 	delete(ti.TextMap, key)
+	ti.keywordIndex.Delete(key)
+	ti.lastMutation = time.Now()
+	return nil
+}
+
+func (ti *InMemCollectionNamespace) DeleteTextFromMemory(ctx context.Context, key string) error {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	delete(ti.TextMap, key)
+	delete(ti.LabelsMap, key)
+	delete(ti.MetadataMap, key)
+	delete(ti.IdMap, key)
+	ti.keywordIndex.Delete(key)
+	ti.lastMutation = time.Now()
 	return nil
 }
 
@@ -176,6 +238,23 @@ func (ti *InMemCollectionNamespace) GetLabels(ctx context.Context, key string) (
 	return ti.LabelsMap[key], nil
 }
 
+func (ti *InMemCollectionNamespace) GetMetadata(ctx context.Context, key string) (map[string]any, error) {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+	return ti.MetadataMap[key], nil
+}
+
+// SearchKeywords ranks the texts that contain the terms of the query with BM25, highest score first.
+func (ti *InMemCollectionNamespace) SearchKeywords(ctx context.Context, query string, maxResults int, filter index.SearchFilter) (utils.MaxTupleHeap, error) {
+	var keyFilter func(string) bool
+	if filter != nil {
+		keyFilter = func(key string) bool {
+			return filter(nil, nil, key)
+		}
+	}
+	return ti.keywordIndex.Search(query, maxResults, keyFilter), nil
+}
+
 func (ti *InMemCollectionNamespace) GetLabelsMap(ctx context.Context) (map[string][]string, error) {
 	ti.mu.RLock()
 	defer ti.mu.RUnlock()
@@ -194,8 +273,125 @@ func (ti *InMemCollectionNamespace) GetExternalId(ctx context.Context, key strin
 	return ti.IdMap[key], nil
 }
 
+func (ti *InMemCollectionNamespace) GetIdMap(ctx context.Context) (map[string]int64, error) {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+	return maps.Clone(ti.IdMap), nil
+}
+
+// GetStats estimates the memory used by the texts from their sizes.  The last mutation is when the texts in memory
+// last changed, which includes texts synced from the database that were upserted by other instances.
+func (ti *InMemCollectionNamespace) GetStats(ctx context.Context) (index.NamespaceStats, error) {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+
+	// each item is also held by the id map, which is a string header and an int64
+	const itemOverhead = 24
+
+	var size int64
+	for key, text := range ti.TextMap {
+		size += int64(2*len(key)+len(text)) + itemOverhead
+	}
+	for _, labels := range ti.LabelsMap {
+		for _, label := range labels {
+			size += int64(len(label))
+		}
+	}
+	for _, metadata := range ti.MetadataMap {
+		size += metadataSize(metadata)
+	}
+
+	return index.NamespaceStats{
+		Items:        len(ti.TextMap),
+		MemoryBytes:  size,
+		LastMutation: ti.lastMutation,
+	}, nil
+}
+
+// metadataSize estimates the size of metadata, counting the length of strings, and 8 bytes for any other value.
+func metadataSize(metadata map[string]any) int64 {
+	var size int64
+	for key, value := range metadata {
+		size += int64(len(key)) + valueSize(value)
+	}
+	return size
+}
+
+func valueSize(value any) int64 {
+	switch v := value.(type) {
+	case string:
+		return int64(len(v))
+	case []any:
+		var size int64
+		for _, item := range v {
+			size += valueSize(item)
+		}
+		return size
+	case map[string]any:
+		return metadataSize(v)
+	default:
+		return 8
+	}
+}
+
 func (ti *InMemCollectionNamespace) GetCheckpointId(ctx context.Context) (int64, error) {
 	ti.mu.RLock()
 	defer ti.mu.RUnlock()
 	return ti.lastInsertedID, nil
 }
+
+type namespaceSnapshot struct {
+	LastInsertedID int64
+	TextMap        map[string]string
+	LabelsMap      map[string][]string
+	MetadataMap    map[string]map[string]any
+	IdMap          map[string]int64
+}
+
+// WriteSnapshot writes the texts of the namespace, but not its vector indexes, which are written separately.
+func (ti *InMemCollectionNamespace) WriteSnapshot(w io.Writer) error {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(namespaceSnapshot{
+		LastInsertedID: ti.lastInsertedID,
+		TextMap:        ti.TextMap,
+		LabelsMap:      ti.LabelsMap,
+		MetadataMap:    ti.MetadataMap,
+		IdMap:          ti.IdMap,
+	})
+}
+
+func (ti *InMemCollectionNamespace) ReadSnapshot(r io.Reader) error {
+	var snapshot namespaceSnapshot
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	ti.lastInsertedID = snapshot.LastInsertedID
+	ti.TextMap = snapshot.TextMap
+	ti.LabelsMap = snapshot.LabelsMap
+	ti.MetadataMap = snapshot.MetadataMap
+	ti.IdMap = snapshot.IdMap
+	ti.lastMutation = time.Now()
+	if ti.TextMap == nil {
+		ti.TextMap = map[string]string{}
+	}
+	if ti.LabelsMap == nil {
+		ti.LabelsMap = map[string][]string{}
+	}
+	if ti.MetadataMap == nil {
+		ti.MetadataMap = map[string]map[string]any{}
+	}
+	if ti.IdMap == nil {
+		ti.IdMap = map[string]int64{}
+	}
+
+	// the keyword index isn't in the snapshot, since it's quick to rebuild from the texts
+	ti.keywordIndex = keyword.NewKeywordIndex()
+	for key, text := range ti.TextMap {
+		ti.keywordIndex.Insert(key, text)
+	}
+	return nil
+}
@@ -46,7 +46,7 @@ func TestMultipleInMemCollections(t *testing.T) {
 			col := NewCollectionNamespace("collection"+fmt.Sprint(i), "")
 
 			// Insert the texts into the collection
-			err := col.InsertTextsToMemory(ctx, ids, keys, texts, labels)
+			err := col.InsertTextsToMemory(ctx, ids, keys, texts, labels, nil)
 			if err != nil {
 				t.Errorf("Failed to insert texts into collection: %v", err)
 			}
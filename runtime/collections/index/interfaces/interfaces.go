@@ -13,7 +13,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 
+	"github.com/hypermodeinc/modus/lib/manifest"
 	"github.com/hypermodeinc/modus/runtime/collections/in_mem/sequential"
 	"github.com/hypermodeinc/modus/runtime/collections/index"
 	"github.com/hypermodeinc/modus/runtime/collections/utils"
@@ -91,25 +93,37 @@ type CollectionNamespace interface {
 	// DeleteVectorIndex deletes the VectorIndex for a given searchMethod
 	DeleteVectorIndex(ctx context.Context, searchMethod string) error
 
+	// ReplaceVectorIndex replaces the VectorIndex for a given searchMethod, so that searches use either the old or the new index
+	ReplaceVectorIndex(ctx context.Context, searchMethod string, index *VectorIndexWrapper) error
+
 	// InsertTexts will add texts and keys into the existing VectorIndex
-	InsertTexts(ctx context.Context, keys []string, texts []string, labelsArr [][]string) error
+	InsertTexts(ctx context.Context, keys []string, texts []string, labelsArr [][]string, metadataArr []map[string]any) error
 
 	// InsertText will add a text and key into the existing VectorIndex
-	InsertText(ctx context.Context, key string, text string, labels []string) error
+	InsertText(ctx context.Context, key string, text string, labels []string, metadata map[string]any) error
 
-	InsertTextsToMemory(ctx context.Context, ids []int64, keys []string, texts []string, labelsArr [][]string) error
+	InsertTextsToMemory(ctx context.Context, ids []int64, keys []string, texts []string, labelsArr [][]string, metadataArr []map[string]any) error
 
-	InsertTextToMemory(ctx context.Context, id int64, key string, text string, labels []string) error
+	InsertTextToMemory(ctx context.Context, id int64, key string, text string, labels []string, metadata map[string]any) error
 
 	// DeleteText will remove a text and key from the existing VectorIndex
 	DeleteText(ctx context.Context, key string) error
 
+	DeleteTextFromMemory(ctx context.Context, key string) error
+
 	// GetText will return the text for a given key
 	GetText(ctx context.Context, key string) (string, error)
 
 	// GetLabel will return the label for a given key
 	GetLabels(ctx context.Context, key string) ([]string, error)
 
+	// GetMetadata will return the metadata for a given key
+	GetMetadata(ctx context.Context, key string) (map[string]any, error)
+
+	// SearchKeywords will find the keys of the texts that best match the terms of the query,
+	// scored with BM25, limiting to the specified maximum number of results after filtering.
+	SearchKeywords(ctx context.Context, query string, maxResults int, filter index.SearchFilter) (utils.MaxTupleHeap, error)
+
 	// GetTextMap returns the map of key to text
 	GetTextMap(ctx context.Context) (map[string]string, error)
 
@@ -122,6 +136,12 @@ type CollectionNamespace interface {
 	// GetExternalId returns the external id for a given key
 	GetExternalId(ctx context.Context, key string) (int64, error)
 
+	// GetIdMap returns a copy of the map of key to external id
+	GetIdMap(ctx context.Context) (map[string]int64, error)
+
+	// GetStats returns the number of texts, their estimated memory usage, and when they last changed
+	GetStats(ctx context.Context) (index.NamespaceStats, error)
+
 	GetCheckpointId(ctx context.Context) (int64, error)
 }
 
@@ -133,6 +153,15 @@ type VectorIndex interface {
 
 	GetEmbedderName() string
 
+	// GetDistance returns the distance metric the vectors are compared with, where empty means cosine
+	GetDistance() string
+
+	// GetNormalize returns whether the vectors are normalized when they are inserted or searched
+	GetNormalize() bool
+
+	// GetQuantization returns how the vectors are quantized in memory, where an empty type means they aren't
+	GetQuantization() manifest.QuantizationInfo
+
 	// Search will find the keys for a given set of vectors based on the
 	// input query, limiting to the specified maximum number of results.
 	// The filter parameter indicates that we might discard certain parameters
@@ -171,6 +200,8 @@ type VectorIndex interface {
 	// key does not exist, it should throw an error to not delete non-existent keys
 	DeleteVector(ctx context.Context, textId int64, key string) error
 
+	DeleteVectorFromMemory(ctx context.Context, key string) error
+
 	// GetVector will return the vector for a given key
 	GetVector(ctx context.Context, key string) ([]float32, error)
 
@@ -178,3 +209,22 @@ type VectorIndex interface {
 
 	GetLastIndexedTextId(ctx context.Context) (int64, error)
 }
+
+// A Snapshotter can write its in-memory state to a snapshot, and restore it from one,
+// which is faster than inserting everything again.
+type Snapshotter interface {
+	WriteSnapshot(w io.Writer) error
+	ReadSnapshot(r io.Reader) error
+}
+
+// A CheckpointSetter can have its checkpoints set, such as when it was restored from a snapshot
+// taken with another database, whose ids don't match those of this one.
+type CheckpointSetter interface {
+	SetCheckpoints(lastInsertedId, lastIndexedTextId int64)
+}
+
+// A StatsReporter can report how many vectors it holds in memory, and roughly how much memory they use.
+// Indexes that keep their vectors elsewhere, such as in an external database, don't report stats.
+type StatsReporter interface {
+	GetStats() index.VectorIndexStats
+}
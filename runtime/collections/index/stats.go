@@ -0,0 +1,28 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package index
+
+import "time"
+
+// VectorIndexStats describes the vectors an index holds in memory.
+// MemoryBytes is an estimate, which doesn't include the overhead of the maps the vectors are kept in.
+type VectorIndexStats struct {
+	Vectors     int
+	Dimensions  int
+	MemoryBytes int64
+}
+
+// NamespaceStats describes the texts a collection namespace holds in memory.
+// MemoryBytes is an estimate of the size of the keys, texts, labels and metadata.
+type NamespaceStats struct {
+	Items        int
+	MemoryBytes  int64
+	LastMutation time.Time
+}
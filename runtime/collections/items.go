@@ -0,0 +1,224 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// The orders that items can be read in.  Items are sorted by insertion time in the order they were last upserted,
+// since upserting an item replaces it.
+const (
+	SortByKey       = "key"
+	SortByInsertion = "insertion"
+)
+
+const (
+	defaultItemsPageSize = 100
+	maxItemsPageSize     = 1000
+)
+
+var (
+	errInvalidCursor    = errors.New("invalid cursor")
+	errInvalidSortOrder = errors.New("invalid sort order")
+)
+
+// itemsCursor is the position after the last item of a page.  It holds the sort key of that item, rather than an
+// offset, so that pages don't skip or repeat items when items are upserted or removed between reads.
+type itemsCursor struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Key        string `json:"k"`
+	Id         int64  `json:"i,omitempty"`
+}
+
+func (c *itemsCursor) encode() string {
+	bytes, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+func decodeItemsCursor(cursor string) (*itemsCursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var c itemsCursor
+	if err := json.Unmarshal(bytes, &c); err != nil {
+		return nil, errInvalidCursor
+	}
+	return &c, nil
+}
+
+// GetItemsFromCollection reads a page of the items of a namespace, sorted by key or by insertion time.  The cursor
+// is empty for the first page, and is the cursor of the previous page's result for the next ones.  The result has
+// an empty cursor when there are no more items.  The limit is 100 when zero, and at most 1000.
+func GetItemsFromCollection(ctx context.Context, collectionName, namespace, sortBy string, descending bool, cursor string, limit int32) (*CollectionItemsResult, error) {
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace == "" {
+		namespace = in_mem.DefaultNamespace
+	}
+
+	collNs, err := col.findNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if sortBy == "" {
+		sortBy = SortByKey
+	} else if sortBy != SortByKey && sortBy != SortByInsertion {
+		return nil, fmt.Errorf("%w: %s", errInvalidSortOrder, sortBy)
+	}
+
+	var after *itemsCursor
+	if cursor != "" {
+		after, err = decodeItemsCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if after.SortBy != sortBy || after.Descending != descending {
+			return nil, fmt.Errorf("%w: the cursor is for a different sort order", errInvalidCursor)
+		}
+	}
+
+	pageSize := int(limit)
+	if pageSize <= 0 {
+		pageSize = defaultItemsPageSize
+	}
+	pageSize = min(pageSize, maxItemsPageSize)
+
+	ids, err := collNs.GetIdMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	compare := func(aKey string, aId int64, bKey string, bId int64) int {
+		var c int
+		if sortBy == SortByInsertion {
+			c = cmp.Compare(aId, bId)
+		} else {
+			c = cmp.Compare(aKey, bKey)
+		}
+		if descending {
+			return -c
+		}
+		return c
+	}
+
+	keys := make([]string, 0, len(ids))
+	for key, id := range ids {
+		if after == nil || compare(key, id, after.Key, after.Id) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return compare(a, ids[a], b, ids[b])
+	})
+
+	hasMore := len(keys) > pageSize
+	if hasMore {
+		keys = keys[:pageSize]
+	}
+
+	items := make([]*CollectionItemObject, 0, len(keys))
+	for _, key := range keys {
+		text, err := collNs.GetText(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		labels, err := collNs.GetLabels(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := collNs.GetMetadata(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		item, err := NewCollectionItemObject(key, text, labels, metadata)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	next := ""
+	if hasMore {
+		last := keys[len(keys)-1]
+		next = (&itemsCursor{SortBy: sortBy, Descending: descending, Key: last, Id: ids[last]}).encode()
+	}
+
+	return NewCollectionItemsResult(collectionName, namespace, items, next), nil
+}
+
+// ItemsHandler reads a page of the items of a collection (GET with "collection", and optional "namespace", "sort",
+// "order", "cursor" and "limit" query parameters).  The sort is "key" or "insertion", and the order is "asc" or "desc".
+func ItemsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	collectionName := query.Get("collection")
+	if collectionName == "" {
+		http.Error(w, "A collection is required.", http.StatusBadRequest)
+		return
+	}
+
+	var descending bool
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		descending = true
+	default:
+		http.Error(w, "The order must be asc or desc.", http.StatusBadRequest)
+		return
+	}
+
+	var limit int64
+	if s := query.Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.ParseInt(s, 10, 32)
+		if err != nil || limit < 0 {
+			http.Error(w, "The limit must be a positive number.", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := GetItemsFromCollection(ctx, collectionName, query.Get("namespace"), query.Get("sort"), descending, query.Get("cursor"), int32(limit))
+	switch {
+	case errors.Is(err, errCollectionNotFound), errors.Is(err, errNamespaceNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errInvalidCursor), errors.Is(err, errInvalidSortOrder):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		logger.Err(ctx, err).Str("collection_name", collectionName).Msg("Failed to read items of collection.")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		utils.WriteJsonResponse(w, result)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupItemsTest(t *testing.T) {
+	ctx := context.Background()
+
+	cf := newCollectionFactory()
+	col, err := cf.createCollection("docs", newCollection())
+	require.NoError(t, err)
+	collNs, err := col.createCollectionNamespace("", in_mem.NewCollectionNamespace("docs", ""))
+	require.NoError(t, err)
+
+	// inserted in a different order than their keys
+	require.NoError(t, collNs.InsertTextToMemory(ctx, 1, "c", "cherry", []string{"fruit"}, nil))
+	require.NoError(t, collNs.InsertTextToMemory(ctx, 2, "a", "apple", nil, map[string]any{"color": "red"}))
+	require.NoError(t, collNs.InsertTextToMemory(ctx, 3, "e", "elderberry", nil, nil))
+	require.NoError(t, collNs.InsertTextToMemory(ctx, 4, "b", "banana", nil, nil))
+	require.NoError(t, collNs.InsertTextToMemory(ctx, 5, "d", "date", nil, nil))
+
+	previous := globalNamespaceManager
+	globalNamespaceManager = cf
+	t.Cleanup(func() { globalNamespaceManager = previous })
+}
+
+// readAllItems reads every page of items, returning the keys of each page.
+func readAllItems(t *testing.T, sortBy string, descending bool, limit int32) [][]string {
+	var pages [][]string
+	cursor := ""
+	for {
+		result, err := GetItemsFromCollection(context.Background(), "docs", "", sortBy, descending, cursor, limit)
+		require.NoError(t, err)
+		keys := []string{}
+		for _, item := range result.Items {
+			keys = append(keys, item.Key)
+		}
+		pages = append(pages, keys)
+		if result.Cursor == "" {
+			return pages
+		}
+		cursor = result.Cursor
+	}
+}
+
+func TestGetItemsFromCollection(t *testing.T) {
+	setupItemsTest(t)
+
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, readAllItems(t, SortByKey, false, 2))
+	assert.Equal(t, [][]string{{"e", "d"}, {"c", "b"}, {"a"}}, readAllItems(t, SortByKey, true, 2))
+	assert.Equal(t, [][]string{{"c", "a", "e"}, {"b", "d"}}, readAllItems(t, SortByInsertion, false, 3))
+	assert.Equal(t, [][]string{{"d", "b", "e", "a", "c"}}, readAllItems(t, SortByInsertion, true, 0))
+
+	result, err := GetItemsFromCollection(context.Background(), "docs", "", "", false, "", 2)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, &CollectionItemObject{Key: "a", Text: "apple", Labels: []string{}, Metadata: `{"color":"red"}`}, result.Items[0])
+}
+
+func TestGetItemsFromCollectionWithChanges(t *testing.T) {
+	ctx := context.Background()
+	setupItemsTest(t)
+
+	first, err := GetItemsFromCollection(ctx, "docs", "", SortByKey, false, "", 2)
+	require.NoError(t, err)
+
+	// the next page starts after the last key of the previous one, regardless of the items removed or added before it
+	col, _ := globalNamespaceManager.findCollection("docs")
+	collNs, _ := col.findNamespace("")
+	require.NoError(t, collNs.DeleteTextFromMemory(ctx, "a"))
+	require.NoError(t, collNs.InsertTextToMemory(ctx, 6, "aa", "apricot", nil, nil))
+	require.NoError(t, collNs.InsertTextToMemory(ctx, 7, "ca", "cantaloupe", nil, nil))
+
+	next, err := GetItemsFromCollection(ctx, "docs", "", SortByKey, false, first.Cursor, 2)
+	require.NoError(t, err)
+	keys := []string{}
+	for _, item := range next.Items {
+		keys = append(keys, item.Key)
+	}
+	assert.Equal(t, []string{"c", "ca"}, keys)
+}
+
+func TestGetItemsFromCollectionErrors(t *testing.T) {
+	ctx := context.Background()
+	setupItemsTest(t)
+
+	_, err := GetItemsFromCollection(ctx, "docs", "", "size", false, "", 0)
+	assert.ErrorIs(t, err, errInvalidSortOrder)
+
+	_, err = GetItemsFromCollection(ctx, "docs", "", SortByKey, false, "not a cursor", 0)
+	assert.ErrorIs(t, err, errInvalidCursor)
+
+	result, err := GetItemsFromCollection(ctx, "docs", "", SortByKey, false, "", 1)
+	require.NoError(t, err)
+	_, err = GetItemsFromCollection(ctx, "docs", "", SortByInsertion, false, result.Cursor, 1)
+	assert.ErrorIs(t, err, errInvalidCursor, "a cursor can't be used with another sort order")
+
+	_, err = GetItemsFromCollection(ctx, "docs", "missing", SortByKey, false, "", 1)
+	assert.ErrorIs(t, err, errNamespaceNotFound)
+}
+
+func TestItemsHandler(t *testing.T) {
+	setupItemsTest(t)
+
+	w := httptest.NewRecorder()
+	ItemsHandler(w, httptest.NewRequest(http.MethodGet, "/admin/collections/items?collection=docs&sort=insertion&order=desc&limit=2", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var result CollectionItemsResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "d", result.Items[0].Key)
+	assert.NotEmpty(t, result.Cursor)
+
+	for url, status := range map[string]int{
+		"/admin/collections/items":                           http.StatusBadRequest,
+		"/admin/collections/items?collection=docs&order=up":  http.StatusBadRequest,
+		"/admin/collections/items?collection=docs&sort=size": http.StatusBadRequest,
+		"/admin/collections/items?collection=docs&cursor=x":  http.StatusBadRequest,
+		"/admin/collections/items?collection=missing":        http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		ItemsHandler(w, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, status, w.Code, url)
+	}
+}
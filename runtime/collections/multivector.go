@@ -0,0 +1,226 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/collections/index"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	collection_utils "github.com/hypermodeinc/modus/runtime/collections/utils"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+)
+
+// A search method with a field embeds that field of the metadata of each item, instead of the item's text, so that a
+// collection can have several vectors for each item, one for each search method.  Changing the field of a search
+// method doesn't re-embed the items that were already embedded, which is done by recomputing the search method.
+
+// fieldText returns the text that a search method with the field embeds for an item.  It is the value of the field in
+// the metadata of the item, or the text of the item if there is no field, or the item has no string value for it.
+func fieldText(field, text string, metadata map[string]any) string {
+	if field == "" {
+		return text
+	}
+	if value, ok := metadata[field].(string); ok && value != "" {
+		return value
+	}
+	return text
+}
+
+// searchMethodField returns the field that the search method of the collection embeds, or an empty string for the text.
+func searchMethodField(collectionName, searchMethodName string) string {
+	return manifestdata.GetManifest().Collections[collectionName].SearchMethods[searchMethodName].Field
+}
+
+// fieldTexts returns the texts that the search method of the collection embeds for the items of the namespace.
+func fieldTexts(ctx context.Context, collNs interfaces.CollectionNamespace, searchMethodName string, keys, texts []string) ([]string, error) {
+	field := searchMethodField(collNs.GetCollectionName(), searchMethodName)
+	if field == "" {
+		return texts, nil
+	}
+
+	result := make([]string, len(texts))
+	for i, key := range keys {
+		metadata, err := collNs.GetMetadata(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = fieldText(field, texts[i], metadata)
+	}
+	return result, nil
+}
+
+type weightedCandidate struct {
+	key           string
+	distance      float64
+	combinedScore float64
+}
+
+// WeightedSearchCollection searches the collection with several search methods, such as one for the title and one for
+// the body of the items, and combines their results by weight.  The score of each result is the weighted sum of its
+// similarity in each search method, and its distance is the weighted sum of its distances.  An item that hasn't been
+// embedded by a search method yet counts as unrelated to the query in that search method.  The weights don't have to
+// add up to 1, since they are scaled so that they do.  The search method of the result lists the search methods.
+func WeightedSearchCollection(ctx context.Context, collectionName string, namespaces []string, searchMethods []string, weights []float64, text string, limit int32, returnText bool, filter string) (*CollectionSearchResult, error) {
+
+	if len(searchMethods) == 0 {
+		return nil, fmt.Errorf("at least one search method is required")
+	}
+	if len(weights) != len(searchMethods) {
+		return nil, fmt.Errorf("mismatch in number of weights and search methods: %d != %d", len(weights), len(searchMethods))
+	}
+	var totalWeight float64
+	for _, weight := range weights {
+		if weight < 0 {
+			return nil, fmt.Errorf("weights must not be negative, got %v", weight)
+		}
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return nil, fmt.Errorf("at least one weight must be greater than 0")
+	}
+
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataFilter, err := parseMetadataFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	collNamespaces, err := col.resolveNamespaces(namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	// search methods that share an embedder only embed the query once
+	queryVecs := make([][]float32, len(searchMethods))
+	byEmbedder := make(map[string][]float32, len(searchMethods))
+	for i, searchMethod := range searchMethods {
+		embedder, err := getEmbedder(ctx, collectionName, searchMethod)
+		if err != nil {
+			return nil, err
+		}
+		if vec, ok := byEmbedder[embedder]; ok {
+			queryVecs[i] = vec
+			continue
+		}
+		vecs, err := embedTexts(ctx, embedder, []string{text})
+		if err != nil {
+			return nil, err
+		}
+		byEmbedder[embedder] = vecs[0]
+		queryVecs[i] = vecs[0]
+	}
+
+	candidates := max(int(limit)*4, minHybridCandidates)
+
+	// merge all objects
+	mergedObjects := make([]*CollectionSearchResultObject, 0, len(collNamespaces)*int(limit))
+	for _, collNs := range collNamespaces {
+		vectorIndexes := make([]interfaces.VectorIndex, len(searchMethods))
+		for i, searchMethod := range searchMethods {
+			vectorIndex, err := collNs.GetVectorIndex(ctx, searchMethod)
+			if err != nil {
+				return nil, err
+			}
+			vectorIndexes[i] = vectorIndex
+		}
+
+		combined, err := combineWeightedResults(ctx, vectorIndexes, queryVecs, weights, totalWeight, candidates, searchFilterFor(ctx, metadataFilter, collNs))
+		if err != nil {
+			return nil, err
+		}
+		if len(combined) > int(limit) {
+			combined = combined[:int(limit)]
+		}
+
+		for _, c := range combined {
+			text, err := collNs.GetText(ctx, c.key)
+			if err != nil {
+				return nil, err
+			}
+			labels, err := collNs.GetLabels(ctx, c.key)
+			if err != nil {
+				return nil, err
+			}
+			mergedObjects = append(mergedObjects, NewCollectionSearchResultObject(collNs.GetNamespace(), c.key, text, labels, c.distance, c.combinedScore))
+		}
+	}
+
+	// sort by score
+	sort.SliceStable(mergedObjects, func(i, j int) bool {
+		return mergedObjects[i].Score > mergedObjects[j].Score
+	})
+
+	if len(mergedObjects) > int(limit) {
+		mergedObjects = mergedObjects[:int(limit)]
+	}
+
+	return NewCollectionSearchResult(collectionName, strings.Join(searchMethods, ","), "success", mergedObjects, ""), nil
+}
+
+// combineWeightedResults searches each vector index for candidates, and returns them ordered by their combined score.
+// Candidates that weren't among the nearest vectors of a search method get their actual distance in it.
+func combineWeightedResults(ctx context.Context, vectorIndexes []interfaces.VectorIndex, queryVecs [][]float32, weights []float64, totalWeight float64, candidates int, filter index.SearchFilter) ([]*weightedCandidate, error) {
+	distances := make([]map[string]float64, len(vectorIndexes))
+	ordered := []*weightedCandidate{}
+	seen := map[string]bool{}
+	for i, vectorIndex := range vectorIndexes {
+		results, err := vectorIndex.Search(ctx, queryVecs[i], candidates, filter)
+		if err != nil {
+			return nil, err
+		}
+		distances[i] = make(map[string]float64, len(results))
+		for _, r := range results {
+			distances[i][r.GetIndex()] = r.GetValue()
+			if !seen[r.GetIndex()] {
+				seen[r.GetIndex()] = true
+				ordered = append(ordered, &weightedCandidate{key: r.GetIndex()})
+			}
+		}
+	}
+
+	for i, vectorIndex := range vectorIndexes {
+		query := collection_utils.PrepareVector(queryVecs[i], vectorIndex.GetNormalize())
+		for _, c := range ordered {
+			distance, ok := distances[i][c.key]
+			if !ok {
+				vec, err := vectorIndex.GetVector(ctx, c.key)
+				if err != nil || vec == nil {
+					// the text hasn't been embedded yet
+					c.distance += weights[i] / totalWeight
+					continue
+				}
+				distance, err = collection_utils.Distance(vectorIndex.GetDistance(), query, vec)
+				if err != nil {
+					return nil, err
+				}
+			}
+			c.distance += weights[i] / totalWeight * distance
+			c.combinedScore += weights[i] / totalWeight * collection_utils.ScoreForDistance(vectorIndex.GetDistance(), distance)
+		}
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].combinedScore > ordered[j].combinedScore
+	})
+	return ordered, nil
+}
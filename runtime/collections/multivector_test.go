@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldTexts(t *testing.T) {
+	ctx := context.Background()
+	manifestdata.SetManifest(&manifest.Manifest{
+		Collections: map[string]manifest.CollectionInfo{
+			"docs": {SearchMethods: map[string]manifest.SearchMethodInfo{
+				"body":  {Embedder: "embed"},
+				"title": {Embedder: "embed", Field: "title"},
+			}},
+		},
+	})
+
+	collNs := in_mem.NewCollectionNamespace("docs", "")
+	require.NoError(t, collNs.InsertTextsToMemory(ctx, []int64{1, 2, 3}, []string{"a", "b", "c"},
+		[]string{"body a", "body b", "body c"}, nil,
+		[]map[string]any{{"title": "title a"}, {"title": 2}, nil}))
+
+	texts, err := fieldTexts(ctx, collNs, "body", []string{"a", "b", "c"}, []string{"body a", "body b", "body c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"body a", "body b", "body c"}, texts)
+
+	// items without a string value for the field embed their text
+	texts, err = fieldTexts(ctx, collNs, "title", []string{"a", "b", "c"}, []string{"body a", "body b", "body c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"title a", "body b", "body c"}, texts)
+
+	batch := newUpsertBatch([]string{"a", "b"}, []string{"body a", "body b"}, nil, []string{`{"title":"title a"}`, ""})
+	assert.Equal(t, []string{"title a", "body b"}, batch.pendingTexts("title"))
+	assert.Equal(t, []string{"body a", "body b"}, batch.pendingTexts(""))
+}
+
+func TestCombineWeightedResults(t *testing.T) {
+	ctx := context.Background()
+
+	newIndex := func(name string, vecs map[string][]float32) interfaces.VectorIndex {
+		vi, err := createIndexObject("docs", "", manifest.SearchMethodInfo{}, name)
+		require.NoError(t, err)
+		var id int64
+		for key, vec := range vecs {
+			id++
+			require.NoError(t, vi.InsertVectorToMemory(ctx, id, id, key, vec))
+		}
+		return vi
+	}
+
+	// a has the best title, b has the best body, and c hasn't had its body embedded yet
+	title := newIndex("title", map[string][]float32{"a": {1, 0}, "b": {0, 1}, "c": {0.6, 0.8}})
+	body := newIndex("body", map[string][]float32{"a": {0, 1}, "b": {1, 0}})
+	indexes := []interfaces.VectorIndex{title, body}
+	queryVecs := [][]float32{{1, 0}, {1, 0}}
+
+	keys := func(candidates []*weightedCandidate) []string {
+		out := make([]string, len(candidates))
+		for i, c := range candidates {
+			out[i] = c.key
+		}
+		return out
+	}
+
+	combined, err := combineWeightedResults(ctx, indexes, queryVecs, []float64{3, 1}, 4, 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "b"}, keys(combined))
+	assert.InDelta(t, 0.75, combined[0].combinedScore, 1e-6)
+	assert.InDelta(t, 0.25, combined[0].distance, 1e-6)
+	assert.InDelta(t, 0.75*0.6, combined[1].combinedScore, 1e-6, "c counts as unrelated in the body")
+
+	combined, err = combineWeightedResults(ctx, indexes, queryVecs, []float64{1, 3}, 4, 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a", "c"}, keys(combined))
+
+	// with a single search method, the results are those of the search method
+	combined, err = combineWeightedResults(ctx, indexes, queryVecs, []float64{0, 1}, 1, 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "b", combined[0].key)
+	assert.InDelta(t, 1, combined[0].combinedScore, 1e-6)
+}
+
+func TestWeightedSearchCollectionValidatesWeights(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := WeightedSearchCollection(ctx, "docs", nil, nil, nil, "text", 10, false, "")
+	assert.ErrorContains(t, err, "search method is required")
+
+	_, err = WeightedSearchCollection(ctx, "docs", nil, []string{"title", "body"}, []float64{1}, "text", 10, false, "")
+	assert.ErrorContains(t, err, "mismatch in number of weights")
+
+	_, err = WeightedSearchCollection(ctx, "docs", nil, []string{"title", "body"}, []float64{1, -1}, "text", 10, false, "")
+	assert.ErrorContains(t, err, "must not be negative")
+
+	_, err = WeightedSearchCollection(ctx, "docs", nil, []string{"title", "body"}, []float64{0, 0}, "text", 10, false, "")
+	assert.ErrorContains(t, err, "greater than 0")
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	hyp_aws "github.com/hypermodeinc/modus/runtime/aws"
+	"github.com/hypermodeinc/modus/runtime/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Collection archives are exported to and imported from object storage, at a URI such as s3://bucket/path/file.
+// Google Cloud Storage (gs://bucket/path/file) is accessed with its S3-compatible API, using the HMAC key in the
+// MODUS_GCS_ACCESS_KEY_ID and MODUS_GCS_SECRET_ACCESS_KEY environment variables.  In development,
+// a local file (file:///path/file) can be used as well.
+
+const gcsEndpoint = "https://storage.googleapis.com"
+
+type objectLocation struct {
+	scheme string
+	bucket string
+	key    string
+}
+
+func parseObjectUri(uri string) (*objectLocation, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object storage URI: %w", err)
+	}
+
+	switch u.Scheme {
+	case "s3", "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("a bucket is required in object storage URI %s", uri)
+		}
+		key := strings.TrimPrefix(u.Path, "/")
+		if key == "" || strings.HasSuffix(key, "/") {
+			return nil, fmt.Errorf("an object key is required in object storage URI %s", uri)
+		}
+		return &objectLocation{scheme: u.Scheme, bucket: u.Host, key: key}, nil
+	case "file":
+		if !config.IsDevEnvironment() {
+			return nil, errors.New("local files can only be used for collection archives in development")
+		}
+		if u.Path == "" || strings.HasSuffix(u.Path, "/") {
+			return nil, fmt.Errorf("a file path is required in URI %s", uri)
+		}
+		return &objectLocation{scheme: u.Scheme, key: filepath.FromSlash(u.Path)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported object storage URI %s, expected s3://, gs:// or file://", uri)
+	}
+}
+
+func (loc *objectLocation) s3Client(ctx context.Context) (*s3.Client, error) {
+	if loc.scheme == "gs" {
+		keyId := os.Getenv("MODUS_GCS_ACCESS_KEY_ID")
+		secret := os.Getenv("MODUS_GCS_SECRET_ACCESS_KEY")
+		if keyId == "" || secret == "" {
+			return nil, errors.New("MODUS_GCS_ACCESS_KEY_ID and MODUS_GCS_SECRET_ACCESS_KEY are required to use Google Cloud Storage")
+		}
+		return s3.New(s3.Options{
+			BaseEndpoint: aws.String(gcsEndpoint),
+			Region:       "auto",
+			Credentials:  credentials.NewStaticCredentialsProvider(keyId, secret, ""),
+			UsePathStyle: true,
+		}), nil
+	}
+
+	cfg, err := hyp_aws.LoadAwsConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s3.NewFromConfig(cfg), nil
+}
+
+// put uploads the contents of the file to the location.
+func (loc *objectLocation) put(ctx context.Context, f *os.File) error {
+	if loc.scheme == "file" {
+		if err := os.MkdirAll(filepath.Dir(loc.key), 0755); err != nil {
+			return err
+		}
+		tmp := loc.key + ".tmp"
+		out, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp)
+		_, err = io.Copy(out, f)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		return os.Rename(tmp, loc.key)
+	}
+
+	client, err := loc.s3Client(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: &loc.bucket,
+		Key:    &loc.key,
+		Body:   f,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s to bucket %s: %w", loc.key, loc.bucket, err)
+	}
+	return nil
+}
+
+// get returns the contents of the object at the location, which the caller must close.
+func (loc *objectLocation) get(ctx context.Context) (io.ReadCloser, error) {
+	if loc.scheme == "file" {
+		return os.Open(loc.key)
+	}
+
+	client, err := loc.s3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &loc.bucket,
+		Key:    &loc.key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s from bucket %s: %w", loc.key, loc.bucket, err)
+	}
+	return obj.Body, nil
+}
@@ -0,0 +1,744 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/collections/index"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+)
+
+/*
+
+DESIGN NOTES:
+
+- Collections are stored in the database, and held in memory for searching.  Rather than loading everything from the
+  database and rebuilding every vector index on startup, the in-memory state is also persisted to a local directory,
+  as a snapshot plus a write-ahead log of the changes made since the snapshot.
+- On startup, the snapshot is restored and the log is replayed before the first sync with the database, which then
+  only reads the texts and vectors inserted after the restored checkpoints.
+- The log is split into numbered segments.  A snapshot starts a new segment before it reads the collections, so every
+  change in the earlier segments is included in it, and changes made while it is written are kept in the new segment.
+  The snapshot records the first segment to replay after it, and the earlier segments are removed once it is written.
+- Each log record is checksummed and synced to disk.  A record torn by a crash fails its checksum, and replay stops
+  there.  Snapshots are written to a temporary file and renamed into place, so they are either complete or absent.
+- Replaying a record is idempotent, since the same change may be in both the snapshot and the log.
+- Anything that can't be restored is loaded from the database as before, so persistence never loses data that the
+  database has.  It is disabled for a standby runtime, which syncs from the database continuously instead.
+
+*/
+
+const (
+	snapshotFileName  = "collections.snapshot"
+	walFileExtension  = ".wal"
+	snapshotVersion   = 1
+	snapshotInterval  = 5 * time.Minute
+	maxWalSegmentSize = 64 << 20
+)
+
+const (
+	walOpTexts   = "texts"
+	walOpVectors = "vectors"
+	walOpDelete  = "delete"
+)
+
+var globalCollectionStore *collectionStore
+
+// walRecord is a change to the in-memory state of a collection namespace.
+type walRecord struct {
+	Op           string           `json:"op"`
+	Collection   string           `json:"collection"`
+	Namespace    string           `json:"namespace"`
+	SearchMethod string           `json:"searchMethod,omitempty"`
+	TextIds      []int64          `json:"textIds,omitempty"`
+	VectorIds    []int64          `json:"vectorIds,omitempty"`
+	Keys         []string         `json:"keys"`
+	Texts        []string         `json:"texts,omitempty"`
+	Labels       [][]string       `json:"labels,omitempty"`
+	Metadata     []map[string]any `json:"metadata,omitempty"`
+	Vectors      [][]float32      `json:"vectors,omitempty"`
+}
+
+type snapshotHeader struct {
+	Version   int
+	Segment   int64
+	CreatedAt time.Time
+}
+
+type namespaceSnapshot struct {
+	Collection string
+	Namespace  string
+	Texts      []byte
+	Indexes    []vectorIndexSnapshot
+}
+
+type vectorIndexSnapshot struct {
+	SearchMethod string
+	Type         string
+	Embedder     string
+	Distance     string
+	Normalize    bool
+	Quantization manifest.QuantizationInfo
+	Data         []byte
+}
+
+// matches reports whether the snapshot was taken of an index like the vector index, so that it can be restored into it.
+// Snapshots taken before distance metrics could be chosen are of cosine indexes.
+func (ix *vectorIndexSnapshot) matches(vi *interfaces.VectorIndexWrapper) bool {
+	return vi.Type == ix.Type &&
+		cmp.Or(vi.GetDistance(), manifest.DistanceCosine) == cmp.Or(ix.Distance, manifest.DistanceCosine) &&
+		vi.GetNormalize() == ix.Normalize &&
+		vi.GetQuantization() == ix.Quantization
+}
+
+type collectionStore struct {
+	dir         string
+	mu          sync.Mutex
+	wal         *os.File
+	segment     int64
+	walSize     int64
+	dirty       bool
+	snapshotNow chan struct{}
+	quit        chan struct{}
+	done        chan struct{}
+}
+
+func getCollectionsPath() string {
+	if config.CollectionsPath != "" {
+		return config.CollectionsPath
+	}
+	if config.UseAwsStorage || config.StoragePath == "" {
+		return ""
+	}
+	return filepath.Join(config.StoragePath, "collections")
+}
+
+func newCollectionStore(dir string) (*collectionStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create collections directory: %w", err)
+	}
+	return &collectionStore{
+		dir:         dir,
+		snapshotNow: make(chan struct{}, 1),
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// restore loads the snapshot and replays the log into the collections, then starts a new log segment.
+// Collections and search methods that are no longer in the manifest are skipped.
+func (s *collectionStore) restore(ctx context.Context, cf *collectionFactory) error {
+	start := time.Now()
+
+	segments, err := s.listSegments()
+	if err != nil {
+		return err
+	}
+
+	// Without the snapshot, the log is incomplete, so everything is loaded from the database instead.
+	segment, namespaces, err := s.readSnapshot()
+	if err != nil {
+		logger.Warn(ctx).Err(err).Msg("Failed to read the collections snapshot.  Collections will be loaded from the database.")
+		segment, namespaces = math.MaxInt64, nil
+	}
+
+	s.applySnapshot(ctx, cf, namespaces)
+
+	records := 0
+	for _, seg := range segments {
+		if seg < segment {
+			continue
+		}
+		n, err := s.replaySegment(ctx, cf, seg)
+		records += n
+		if err != nil {
+			logger.Warn(ctx).Err(err).Int64("segment", seg).Msg("Stopped replaying the collections log at a damaged record.")
+			break
+		}
+	}
+
+	var next int64
+	if len(segments) > 0 {
+		next = segments[len(segments)-1] + 1
+	}
+	if segment != math.MaxInt64 {
+		next = max(next, segment)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.openSegment(next); err != nil {
+		return err
+	}
+	s.dirty = records > 0 || segment == math.MaxInt64
+
+	logger.Info(ctx).
+		Int("namespaces", len(namespaces)).
+		Int("records", records).
+		Dur("duration_ms", time.Since(start)).
+		Msg("Restored collections from local storage.")
+	return nil
+}
+
+// applySnapshot restores each namespace and vector index in the snapshot.  Any that fail are left empty,
+// and are loaded from the database instead.
+func (s *collectionStore) applySnapshot(ctx context.Context, cf *collectionFactory, namespaces []namespaceSnapshot) {
+	for _, ns := range namespaces {
+		collNs, err := getOrCreateNamespace(ctx, cf, ns.Collection, ns.Namespace)
+		if errors.Is(err, errCollectionNotFound) {
+			continue
+		} else if err != nil {
+			logger.Warn(ctx).Err(err).Str("collection_name", ns.Collection).Msg("Failed to restore collection namespace.")
+			continue
+		}
+
+		if sn, ok := collNs.(interfaces.Snapshotter); ok {
+			if err := sn.ReadSnapshot(bytes.NewReader(ns.Texts)); err != nil {
+				logger.Warn(ctx).Err(err).Str("collection_name", ns.Collection).Msg("Failed to restore collection texts.")
+				continue
+			}
+		}
+
+		for _, ix := range ns.Indexes {
+			vi, err := collNs.GetVectorIndex(ctx, ix.SearchMethod)
+			if errors.Is(err, index.ErrVectorIndexNotFound) || (err == nil && !ix.matches(vi)) {
+				// the search method was removed or changed, so its vectors are loaded from the database instead
+				continue
+			} else if err != nil {
+				logger.Warn(ctx).Err(err).Str("collection_name", ns.Collection).Str("search_method", ix.SearchMethod).Msg("Failed to restore vector index.")
+				continue
+			}
+
+			if sn, ok := vi.VectorIndex.(interfaces.Snapshotter); ok {
+				if err := sn.ReadSnapshot(bytes.NewReader(ix.Data)); err != nil {
+					logger.Warn(ctx).Err(err).Str("collection_name", ns.Collection).Str("search_method", ix.SearchMethod).Msg("Failed to restore vector index.")
+					continue
+				}
+			}
+
+			// the restored vectors were computed with another embedder, so they are re-embedded in the background
+			if ix.Embedder != "" && ix.Embedder != vi.GetEmbedderName() {
+				if searchMethod, ok := manifestdata.GetManifest().Collections[ns.Collection].SearchMethods[ix.SearchMethod]; ok {
+					_ = vi.SetEmbedderName(ix.Embedder)
+					reembedIfNeeded(ctx, ns.Collection, ix.SearchMethod, searchMethod, vi)
+				}
+			}
+		}
+	}
+}
+
+func (s *collectionStore) readSnapshot() (int64, []namespaceSnapshot, error) {
+	f, err := os.Open(filepath.Join(s.dir, snapshotFileName))
+	if os.IsNotExist(err) {
+		return 0, nil, nil
+	} else if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return 0, nil, err
+	}
+	if header.Version != snapshotVersion {
+		return 0, nil, fmt.Errorf("unsupported collections snapshot version %d", header.Version)
+	}
+
+	var namespaces []namespaceSnapshot
+	for {
+		var ns namespaceSnapshot
+		if err := dec.Decode(&ns); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, nil, err
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return header.Segment, namespaces, nil
+}
+
+// snapshot writes the state of every collection, and removes the log segments that it includes.
+func (s *collectionStore) snapshot(ctx context.Context, cf *collectionFactory) error {
+	start := time.Now()
+
+	// start a new segment, so that changes made while the snapshot is written are kept
+	s.mu.Lock()
+	segment := s.segment + 1
+	if err := s.openSegment(segment); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	path := filepath.Join(s.dir, snapshotFileName)
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	w := bufio.NewWriter(f)
+	count, err := writeSnapshot(ctx, w, cf, segment)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		s.markDirty()
+		return fmt.Errorf("failed to write collections snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		s.markDirty()
+		return fmt.Errorf("failed to replace collections snapshot: %w", err)
+	}
+	syncDir(s.dir)
+
+	segments, err := s.listSegments()
+	if err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		if seg < segment {
+			if err := os.Remove(s.segmentPath(seg)); err != nil {
+				logger.Warn(ctx).Err(err).Int64("segment", seg).Msg("Failed to remove collections log segment.")
+			}
+		}
+	}
+
+	logger.Info(ctx).
+		Int("namespaces", count).
+		Dur("duration_ms", time.Since(start)).
+		Msg("Wrote collections snapshot.")
+	return nil
+}
+
+func writeSnapshot(ctx context.Context, w io.Writer, cf *collectionFactory, segment int64) (int, error) {
+	enc := gob.NewEncoder(w)
+	if err := enc.Encode(snapshotHeader{Version: snapshotVersion, Segment: segment, CreatedAt: time.Now().UTC()}); err != nil {
+		return 0, err
+	}
+
+	cf.mu.RLock()
+	names := make([]string, 0, len(cf.collectionMap))
+	for name := range cf.collectionMap {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	cf.mu.RUnlock()
+	slices.Sort(names)
+
+	count := 0
+	for _, name := range names {
+		col, err := cf.findCollection(name)
+		if err != nil {
+			continue
+		}
+
+		col.mu.RLock()
+		namespaces := make([]interfaces.CollectionNamespace, 0, len(col.collectionNamespaceMap))
+		for _, ns := range col.collectionNamespaceMap {
+			namespaces = append(namespaces, ns)
+		}
+		col.mu.RUnlock()
+
+		for _, collNs := range namespaces {
+			sn, ok := collNs.(interfaces.Snapshotter)
+			if !ok {
+				continue
+			}
+
+			var buf bytes.Buffer
+			if err := sn.WriteSnapshot(&buf); err != nil {
+				return count, err
+			}
+			ns := namespaceSnapshot{
+				Collection: name,
+				Namespace:  collNs.GetNamespace(),
+				Texts:      buf.Bytes(),
+			}
+
+			for searchMethod, vi := range collNs.GetVectorIndexMap() {
+				sn, ok := vi.VectorIndex.(interfaces.Snapshotter)
+				if !ok {
+					continue
+				}
+				var buf bytes.Buffer
+				if err := sn.WriteSnapshot(&buf); err != nil {
+					return count, err
+				}
+				ns.Indexes = append(ns.Indexes, vectorIndexSnapshot{
+					SearchMethod: searchMethod,
+					Type:         vi.Type,
+					Embedder:     vi.GetEmbedderName(),
+					Distance:     vi.GetDistance(),
+					Normalize:    vi.GetNormalize(),
+					Quantization: vi.GetQuantization(),
+					Data:         buf.Bytes(),
+				})
+			}
+
+			if err := enc.Encode(ns); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// append writes the record to the current log segment, and syncs it to disk.
+func (s *collectionStore) append(rec *walRecord) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	frame := make([]byte, 8+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	copy(frame[8:], payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		// nothing has been restored yet, so there is nothing to add to
+		return nil
+	}
+
+	if _, err := s.wal.Write(frame); err != nil {
+		return err
+	}
+	if err := s.wal.Sync(); err != nil {
+		return err
+	}
+
+	s.dirty = true
+	s.walSize += int64(len(frame))
+	if s.walSize > maxWalSegmentSize {
+		s.requestSnapshot()
+	}
+	return nil
+}
+
+func (s *collectionStore) replaySegment(ctx context.Context, cf *collectionFactory, segment int64) (int, error) {
+	f, err := os.Open(s.segmentPath(segment))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	count := 0
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return count, fmt.Errorf("truncated record header: %w", err)
+		}
+
+		payload := make([]byte, binary.LittleEndian.Uint32(header[0:4]))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return count, fmt.Errorf("truncated record: %w", err)
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+			return count, errors.New("record checksum mismatch")
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return count, err
+		}
+		if err := applyWalRecord(ctx, cf, &rec); err != nil {
+			logger.Warn(ctx).Err(err).
+				Str("collection_name", rec.Collection).
+				Str("op", rec.Op).
+				Msg("Failed to replay collections log record.")
+		}
+		count++
+	}
+}
+
+func applyWalRecord(ctx context.Context, cf *collectionFactory, rec *walRecord) error {
+	collNs, err := getOrCreateNamespace(ctx, cf, rec.Collection, rec.Namespace)
+	if errors.Is(err, errCollectionNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	switch rec.Op {
+	case walOpTexts:
+		return collNs.InsertTextsToMemory(ctx, rec.TextIds, rec.Keys, rec.Texts, rec.Labels, rec.Metadata)
+	case walOpVectors:
+		vi, err := collNs.GetVectorIndex(ctx, rec.SearchMethod)
+		if errors.Is(err, index.ErrVectorIndexNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return batchInsertVectorsToMemory(ctx, vi, rec.TextIds, rec.VectorIds, rec.Keys, rec.Vectors)
+	case walOpDelete:
+		for _, key := range rec.Keys {
+			for _, vi := range collNs.GetVectorIndexMap() {
+				if err := vi.DeleteVectorFromMemory(ctx, key); err != nil {
+					return err
+				}
+			}
+			if err := collNs.DeleteTextFromMemory(ctx, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown collections log operation %q", rec.Op)
+	}
+}
+
+// openSegment closes the current log segment, if any, and opens the given one for appending.
+// The caller must hold the lock.
+func (s *collectionStore) openSegment(segment int64) error {
+	if s.wal != nil {
+		if err := s.wal.Close(); err != nil {
+			return err
+		}
+		s.wal = nil
+	}
+
+	f, err := os.OpenFile(s.segmentPath(segment), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open collections log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	syncDir(s.dir)
+
+	s.wal = f
+	s.segment = segment
+	s.walSize = info.Size()
+	return nil
+}
+
+func (s *collectionStore) segmentPath(segment int64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%016d%s", segment, walFileExtension))
+}
+
+func (s *collectionStore) listSegments() ([]int64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []int64
+	for _, e := range entries {
+		name, found := strings.CutSuffix(e.Name(), walFileExtension)
+		if !found || e.IsDir() {
+			continue
+		}
+		if seg, err := strconv.ParseInt(name, 10, 64); err == nil {
+			segments = append(segments, seg)
+		}
+	}
+	slices.Sort(segments)
+	return segments, nil
+}
+
+func (s *collectionStore) markDirty() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirty = true
+}
+
+func (s *collectionStore) isDirty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dirty
+}
+
+func (s *collectionStore) requestSnapshot() {
+	select {
+	case s.snapshotNow <- struct{}{}:
+	default:
+	}
+}
+
+// worker writes a snapshot periodically when there are changes, or sooner when the log grows large,
+// and a final one on shutdown.
+func (s *collectionStore) worker(ctx context.Context, cf *collectionFactory) {
+	defer close(s.done)
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !s.isDirty() {
+				continue
+			}
+		case <-s.snapshotNow:
+		case <-s.quit:
+			if s.isDirty() {
+				if err := s.snapshot(ctx, cf); err != nil {
+					logger.Err(ctx, err).Msg("Failed to write collections snapshot on shutdown.")
+				}
+			}
+			s.mu.Lock()
+			if s.wal != nil {
+				s.wal.Close()
+				s.wal = nil
+			}
+			s.mu.Unlock()
+			return
+		}
+
+		if err := s.snapshot(ctx, cf); err != nil {
+			logger.Err(ctx, err).Msg("Failed to write collections snapshot.")
+		}
+	}
+}
+
+// getOrCreateNamespace returns the namespace of the collection, creating it with the vector indexes of the
+// search methods in the manifest if needed.
+func getOrCreateNamespace(ctx context.Context, cf *collectionFactory, collectionName, namespace string) (interfaces.CollectionNamespace, error) {
+	col, err := cf.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	collNs, err := col.findOrCreateNamespace(namespace, in_mem.NewCollectionNamespace(collectionName, namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	collectionInfo := manifestdata.GetManifest().Collections[collectionName]
+	for searchMethodName, searchMethod := range collectionInfo.SearchMethods {
+		if _, err := collNs.GetVectorIndex(ctx, searchMethodName); errors.Is(err, index.ErrVectorIndexNotFound) {
+			if err := setIndex(ctx, collNs, searchMethod, searchMethodName); err != nil && !errors.Is(err, index.ErrVectorIndexAlreadyExists) {
+				return nil, err
+			}
+		}
+	}
+	return collNs, nil
+}
+
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
+
+func journal(ctx context.Context, rec *walRecord) {
+	if globalCollectionStore == nil {
+		return
+	}
+	if err := globalCollectionStore.append(rec); err != nil {
+		logger.Err(ctx, err).
+			Str("collection_name", rec.Collection).
+			Str("op", rec.Op).
+			Msg("Failed to write to the collections log.")
+	}
+}
+
+// journalTexts records texts inserted into memory, so they are restored after a restart.
+func journalTexts(ctx context.Context, col interfaces.CollectionNamespace, textIds []int64, keys, texts []string, labels [][]string, metadata []map[string]any) {
+	if len(keys) == 0 {
+		return
+	}
+	journal(ctx, &walRecord{
+		Op:         walOpTexts,
+		Collection: col.GetCollectionName(),
+		Namespace:  col.GetNamespace(),
+		TextIds:    textIds,
+		Keys:       keys,
+		Texts:      texts,
+		Labels:     labels,
+		Metadata:   metadata,
+	})
+}
+
+// journalVectors records vectors inserted into memory, so they are restored after a restart.
+// When the vector ids aren't known, the checkpoint of the index is used for all of them,
+// since only the last one is needed to restore it.
+func journalVectors(ctx context.Context, col interfaces.CollectionNamespace, vectorIndex interfaces.VectorIndex, textIds, vectorIds []int64, keys []string, vecs [][]float32) {
+	if globalCollectionStore == nil || len(keys) == 0 {
+		return
+	}
+
+	if vectorIds == nil {
+		checkpoint, err := vectorIndex.GetCheckpointId(ctx)
+		if err != nil {
+			logger.Err(ctx, err).Str("collection_name", col.GetCollectionName()).Msg("Failed to get vector index checkpoint.")
+			return
+		}
+		vectorIds = make([]int64, len(keys))
+		for i := range vectorIds {
+			vectorIds[i] = checkpoint
+		}
+	}
+
+	journal(ctx, &walRecord{
+		Op:           walOpVectors,
+		Collection:   col.GetCollectionName(),
+		Namespace:    col.GetNamespace(),
+		SearchMethod: vectorIndex.GetSearchMethodName(),
+		TextIds:      textIds,
+		VectorIds:    vectorIds,
+		Keys:         keys,
+		Vectors:      vecs,
+	})
+}
+
+// journalDelete records a key deleted from the namespace and its vector indexes.
+func journalDelete(ctx context.Context, col interfaces.CollectionNamespace, key string) {
+	journal(ctx, &walRecord{
+		Op:         walOpDelete,
+		Collection: col.GetCollectionName(),
+		Namespace:  col.GetNamespace(),
+		Keys:       []string{key},
+	})
+}
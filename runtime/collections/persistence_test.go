@@ -0,0 +1,148 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionStoreRestore(t *testing.T) {
+	ctx := context.Background()
+
+	md := manifestdata.GetManifest()
+	if md.Collections == nil {
+		md.Collections = map[string]manifest.CollectionInfo{}
+	}
+	md.Collections["docs"] = manifest.CollectionInfo{
+		SearchMethods: map[string]manifest.SearchMethodInfo{
+			"exact":  {Embedder: "embed", Index: manifest.IndexInfo{Type: interfaces.SequentialManifestType}},
+			"approx": {Embedder: "embed", Index: manifest.IndexInfo{Type: interfaces.HnswManifestType}},
+		},
+	}
+	defer delete(md.Collections, "docs")
+	defer func() { globalCollectionStore = nil }()
+
+	newFactory := func() *collectionFactory {
+		cf := newCollectionFactory()
+		_, err := cf.createCollection("docs", newCollection())
+		require.NoError(t, err)
+		return cf
+	}
+
+	insert := func(collNs interfaces.CollectionNamespace, id int64, key, text string, vec []float32) {
+		ids, keys := []int64{id}, []string{key}
+		metadata := []map[string]any{{"fruit": text, "tags": []any{"x", float64(id)}}}
+		require.NoError(t, collNs.InsertTextsToMemory(ctx, ids, keys, []string{text}, [][]string{{"label"}}, metadata))
+		journalTexts(ctx, collNs, ids, keys, []string{text}, [][]string{{"label"}}, metadata)
+		for _, vi := range collNs.GetVectorIndexMap() {
+			require.NoError(t, vi.InsertVectorsToMemory(ctx, ids, ids, keys, [][]float32{vec}))
+			journalVectors(ctx, collNs, vi, ids, ids, keys, [][]float32{vec})
+		}
+	}
+
+	dir := t.TempDir()
+	cf := newFactory()
+	store, err := newCollectionStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.restore(ctx, cf))
+	globalCollectionStore = store
+
+	collNs, err := getOrCreateNamespace(ctx, cf, "docs", "")
+	require.NoError(t, err)
+	insert(collNs, 1, "a", "apple", []float32{1, 0, 0})
+	insert(collNs, 2, "b", "banana", []float32{0, 1, 0})
+
+	// the snapshot includes the first changes, and removes the log segment they were in
+	require.NoError(t, store.snapshot(ctx, cf))
+	segments, err := store.listSegments()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, segments)
+
+	// later changes are only in the log
+	insert(collNs, 3, "c", "cherry", []float32{0, 0, 1})
+	for _, vi := range collNs.GetVectorIndexMap() {
+		require.NoError(t, vi.DeleteVectorFromMemory(ctx, "a"))
+	}
+	require.NoError(t, collNs.DeleteTextFromMemory(ctx, "a"))
+	journalDelete(ctx, collNs, "a")
+
+	// simulate a crash in the middle of writing a record
+	f, err := os.OpenFile(store.segmentPath(1), os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0x40, 0, 0, 0, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, store.wal.Close())
+	store.wal = nil
+
+	// restore into a fresh runtime
+	cf = newFactory()
+	store, err = newCollectionStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.restore(ctx, cf))
+	defer store.wal.Close()
+	assert.Equal(t, int64(2), store.segment)
+
+	collNs, err = getOrCreateNamespace(ctx, cf, "docs", "")
+	require.NoError(t, err)
+
+	texts, err := collNs.GetTextMap(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"b": "banana", "c": "cherry"}, texts)
+
+	labels, err := collNs.GetLabels(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"label"}, labels)
+
+	// metadata is restored from both the snapshot and the log
+	for key, text := range map[string]string{"b": "banana", "c": "cherry"} {
+		metadata, err := collNs.GetMetadata(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, text, metadata["fruit"], key)
+	}
+
+	checkpoint, err := collNs.GetCheckpointId(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), checkpoint)
+
+	for name, vi := range collNs.GetVectorIndexMap() {
+		checkpoint, err := vi.GetCheckpointId(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), checkpoint, name)
+
+		results, err := vi.Search(ctx, []float32{0, 0.1, 1}, 3, nil)
+		require.NoError(t, err)
+		require.Len(t, results, 2, name)
+		assert.Equal(t, "c", results[0].GetIndex(), name)
+		assert.Equal(t, "b", results[1].GetIndex(), name)
+	}
+}
+
+func TestCollectionStoreSkipsUnknownCollections(t *testing.T) {
+	ctx := context.Background()
+
+	cf := newCollectionFactory()
+	store, err := newCollectionStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, store.restore(ctx, cf))
+	defer store.wal.Close()
+
+	err = applyWalRecord(ctx, cf, &walRecord{Op: walOpTexts, Collection: "missing", TextIds: []int64{1}, Keys: []string{"a"}, Texts: []string{"x"}})
+	assert.NoError(t, err)
+}
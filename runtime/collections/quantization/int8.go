@@ -0,0 +1,84 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package quantization
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// int8HeaderSize is the size of the scale and squared norm at the start of each int8 code.
+const int8HeaderSize = 8
+
+// Int8Quantizer scales each vector so that its largest component is 127, and rounds its components to bytes.
+// The scale is kept with each code, so it needs no training.
+type Int8Quantizer struct{}
+
+func NewInt8Quantizer() *Int8Quantizer {
+	return &Int8Quantizer{}
+}
+
+func (q *Int8Quantizer) TrainingSize() int {
+	return 0
+}
+
+func (q *Int8Quantizer) Trained() bool {
+	return true
+}
+
+func (q *Int8Quantizer) Train(sample [][]float32) error {
+	return nil
+}
+
+// Encode returns the scale and squared norm of the decoded vector, followed by a byte for each component.
+func (q *Int8Quantizer) Encode(vec []float32) ([]byte, error) {
+	var maxAbs float32
+	for _, v := range vec {
+		maxAbs = max(maxAbs, float32(math.Abs(float64(v))))
+	}
+	scale := maxAbs / 127
+
+	code := make([]byte, int8HeaderSize+len(vec))
+	var normSq float32
+	for i, v := range vec {
+		var c int8
+		if scale > 0 {
+			c = int8(math.Round(float64(v / scale)))
+		}
+		code[int8HeaderSize+i] = byte(c)
+		d := float32(c) * scale
+		normSq += d * d
+	}
+	binary.LittleEndian.PutUint32(code[0:], math.Float32bits(scale))
+	binary.LittleEndian.PutUint32(code[4:], math.Float32bits(normSq))
+	return code, nil
+}
+
+func (q *Int8Quantizer) Decode(code []byte) []float32 {
+	scale := math.Float32frombits(binary.LittleEndian.Uint32(code[0:]))
+	vec := make([]float32, len(code)-int8HeaderSize)
+	for i := range vec {
+		vec[i] = float32(int8(code[int8HeaderSize+i])) * scale
+	}
+	return vec
+}
+
+func (q *Int8Quantizer) Scorer(query []float32) func(code []byte) (float64, float64) {
+	return func(code []byte) (float64, float64) {
+		scale := math.Float32frombits(binary.LittleEndian.Uint32(code[0:]))
+		normSq := math.Float32frombits(binary.LittleEndian.Uint32(code[4:]))
+		components := code[int8HeaderSize:]
+		var dot float32
+		for i, c := range components[:min(len(components), len(query))] {
+			dot += query[i] * float32(int8(c))
+		}
+		return float64(dot * scale), float64(normSq)
+	}
+}
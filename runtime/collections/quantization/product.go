@@ -0,0 +1,237 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package quantization
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/viterin/vek/vek32"
+)
+
+const (
+	// defaultTrainingSize is the number of vectors product quantization is trained on.
+	// Until then, the vectors are kept in full precision.
+	defaultTrainingSize = 1000
+
+	// maxCentroids is the number of centroids of each subvector, so that a centroid fits in a byte.
+	maxCentroids = 256
+
+	kmeansIterations = 25
+)
+
+// ProductQuantizer splits vectors into subvectors, and replaces each subvector by the nearest of the centroids
+// learned for it with k-means, so that each subvector is stored in a byte.
+type ProductQuantizer struct {
+	Subvectors int
+	Size       int
+
+	// Bounds are the offsets where each subvector starts, followed by the number of dimensions.
+	Bounds          []int
+	Centroids       [][][]float32
+	CentroidNormsSq [][]float64
+}
+
+// NewProductQuantizer creates a product quantizer that is trained on the first trainingSize vectors.
+// If subvectors is zero, each subvector has four dimensions.
+func NewProductQuantizer(subvectors, trainingSize int) *ProductQuantizer {
+	return &ProductQuantizer{
+		Subvectors: subvectors,
+		Size:       trainingSize,
+	}
+}
+
+func (q *ProductQuantizer) TrainingSize() int {
+	return q.Size
+}
+
+func (q *ProductQuantizer) Trained() bool {
+	return q.Centroids != nil
+}
+
+func (q *ProductQuantizer) Train(sample [][]float32) error {
+	if len(sample) == 0 {
+		return errors.New("can not train product quantization without vectors")
+	}
+	dims := len(sample[0])
+	for _, vec := range sample {
+		if len(vec) != dims {
+			return errors.New("can not train product quantization on vectors of different lengths")
+		}
+	}
+
+	m := q.Subvectors
+	if m == 0 {
+		m = max(dims/4, 1)
+	}
+	m = min(m, dims)
+
+	bounds := make([]int, m+1)
+	for i := range bounds {
+		bounds[i] = i * dims / m
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	k := min(maxCentroids, len(sample))
+	centroids := make([][][]float32, m)
+	norms := make([][]float64, m)
+	for i := range m {
+		points := make([][]float32, len(sample))
+		for j, vec := range sample {
+			points[j] = vec[bounds[i]:bounds[i+1]]
+		}
+		centroids[i] = kmeans(points, k, rng)
+		norms[i] = make([]float64, len(centroids[i]))
+		for j, c := range centroids[i] {
+			norms[i][j] = float64(vek32.Dot(c, c))
+		}
+	}
+
+	q.Bounds = bounds
+	q.Centroids = centroids
+	q.CentroidNormsSq = norms
+	return nil
+}
+
+// Encode returns the index of the nearest centroid of each subvector.
+func (q *ProductQuantizer) Encode(vec []float32) ([]byte, error) {
+	if !q.Trained() {
+		return nil, errors.New("product quantization is not trained")
+	}
+	if len(vec) != q.Bounds[len(q.Bounds)-1] {
+		return nil, fmt.Errorf("expected a vector of %d dimensions, got %d", q.Bounds[len(q.Bounds)-1], len(vec))
+	}
+
+	code := make([]byte, len(q.Centroids))
+	for i, centroids := range q.Centroids {
+		code[i] = byte(nearest(centroids, vec[q.Bounds[i]:q.Bounds[i+1]]))
+	}
+	return code, nil
+}
+
+func (q *ProductQuantizer) Decode(code []byte) []float32 {
+	vec := make([]float32, 0, q.Bounds[len(q.Bounds)-1])
+	for i, c := range code {
+		vec = append(vec, q.Centroids[i][c]...)
+	}
+	return vec
+}
+
+// Scorer computes the dot product of each subvector of the query with each centroid once,
+// so that scoring a code only sums a value for each subvector.
+func (q *ProductQuantizer) Scorer(query []float32) func(code []byte) (float64, float64) {
+	dots := make([][]float64, len(q.Centroids))
+	for i, centroids := range q.Centroids {
+		dots[i] = make([]float64, len(centroids))
+		if q.Bounds[i+1] > len(query) {
+			continue
+		}
+		sub := query[q.Bounds[i]:q.Bounds[i+1]]
+		for j, c := range centroids {
+			dots[i][j] = float64(vek32.Dot(sub, c))
+		}
+	}
+
+	return func(code []byte) (float64, float64) {
+		var dot, normSq float64
+		for i, c := range code {
+			dot += dots[i][c]
+			normSq += q.CentroidNormsSq[i][c]
+		}
+		return dot, normSq
+	}
+}
+
+// kmeans clusters the points into k centroids, starting from centroids chosen with k-means++.
+func kmeans(points [][]float32, k int, rng *rand.Rand) [][]float32 {
+	centroids := make([][]float32, 0, k)
+	centroids = append(centroids, clone(points[rng.Intn(len(points))]))
+
+	dists := make([]float64, len(points))
+	for len(centroids) < k {
+		var total float64
+		for i, p := range points {
+			dists[i] = squaredDistance(p, centroids[nearest(centroids, p)])
+			total += dists[i]
+		}
+		if total == 0 {
+			// there are fewer distinct points than centroids
+			break
+		}
+		target := rng.Float64() * total
+		next := len(points) - 1
+		for i, d := range dists {
+			target -= d
+			if target <= 0 {
+				next = i
+				break
+			}
+		}
+		centroids = append(centroids, clone(points[next]))
+	}
+
+	assignments := make([]int, len(points))
+	for iter := 0; iter < kmeansIterations; iter++ {
+		changed := false
+		for i, p := range points {
+			if c := nearest(centroids, p); c != assignments[i] {
+				assignments[i] = c
+				changed = true
+			}
+		}
+		if iter > 0 && !changed {
+			break
+		}
+
+		sums := make([][]float32, len(centroids))
+		counts := make([]int, len(centroids))
+		for i := range sums {
+			sums[i] = make([]float32, len(points[0]))
+		}
+		for i, p := range points {
+			vek32.Add_Inplace(sums[assignments[i]], p)
+			counts[assignments[i]]++
+		}
+		for i := range centroids {
+			if counts[i] == 0 {
+				// reseed an empty cluster with a random point
+				centroids[i] = clone(points[rng.Intn(len(points))])
+				continue
+			}
+			centroids[i] = vek32.DivNumber(sums[i], float32(counts[i]))
+		}
+	}
+	return centroids
+}
+
+func nearest(centroids [][]float32, p []float32) int {
+	best, bestDist := 0, math.Inf(1)
+	for i, c := range centroids {
+		if d := squaredDistance(p, c); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+func squaredDistance(a, b []float32) float64 {
+	var sum float32
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return float64(sum)
+}
+
+func clone(v []float32) []float32 {
+	return append([]float32(nil), v...)
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package quantization
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+)
+
+// DefaultRerank is the number of candidates re-ranked with full-precision vectors for each result, when not set.
+const DefaultRerank = 4
+
+func init() {
+	gob.Register(&Int8Quantizer{})
+	gob.Register(&ProductQuantizer{})
+}
+
+// A Quantizer compresses vectors into codes that use less memory, and computes distances from a query to the codes,
+// without decompressing them.
+type Quantizer interface {
+	// TrainingSize returns the number of vectors needed to train the quantizer, or zero if it doesn't need training.
+	TrainingSize() int
+
+	// Trained returns whether the quantizer can encode vectors.
+	Trained() bool
+
+	// Train fits the quantizer to a sample of vectors.
+	Train(sample [][]float32) error
+
+	// Encode compresses a vector into a code.
+	Encode(vec []float32) ([]byte, error)
+
+	// Decode returns an approximation of the vector a code was encoded from.
+	Decode(code []byte) []float32
+
+	// Scorer returns a function that computes the dot product of the query with the vector a code was encoded from,
+	// and the squared norm of that vector, from which any of the distance metrics can be computed.
+	Scorer(query []float32) func(code []byte) (dot, normSq float64)
+}
+
+// New creates the quantizer for the quantization of a search method, or returns nil if its vectors aren't quantized.
+func New(info manifest.QuantizationInfo) (Quantizer, error) {
+	switch info.Type {
+	case "":
+		return nil, nil
+	case manifest.QuantizationInt8:
+		return NewInt8Quantizer(), nil
+	case manifest.QuantizationProduct:
+		if info.Subvectors < 0 {
+			return nil, fmt.Errorf("subvectors must not be negative, got %d", info.Subvectors)
+		}
+		return NewProductQuantizer(info.Subvectors, defaultTrainingSize), nil
+	default:
+		return nil, fmt.Errorf("unknown quantization %s, expected %s or %s", info.Type, manifest.QuantizationInt8, manifest.QuantizationProduct)
+	}
+}
+
+// Distance computes the distance between the query and a quantized vector with the distance metric, from the
+// dot product and squared norms of the scorer.  It matches the distance of the full-precision vectors
+// computed by utils.Distance, up to the error of the quantization.
+func Distance(metric string, dot, normSq, queryNormSq float64) float64 {
+	switch metric {
+	case manifest.DistanceDot:
+		return -dot
+	case manifest.DistanceEuclidean:
+		return math.Sqrt(max(queryNormSq-2*dot+normSq, 0))
+	default:
+		if normSq == 0 || queryNormSq == 0 {
+			return 1
+		}
+		return 1 - dot/math.Sqrt(normSq*queryNormSq)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package quantization
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomVectors(n, dims int) [][]float32 {
+	rng := rand.New(rand.NewSource(42))
+	vecs := make([][]float32, n)
+	for i := range vecs {
+		vecs[i] = make([]float32, dims)
+		for j := range vecs[i] {
+			vecs[i][j] = rng.Float32()*2 - 1
+		}
+	}
+	return vecs
+}
+
+func TestNew(t *testing.T) {
+	q, err := New(manifest.QuantizationInfo{})
+	require.NoError(t, err)
+	assert.Nil(t, q)
+
+	q, err = New(manifest.QuantizationInfo{Type: manifest.QuantizationInt8})
+	require.NoError(t, err)
+	assert.IsType(t, &Int8Quantizer{}, q)
+
+	q, err = New(manifest.QuantizationInfo{Type: manifest.QuantizationProduct, Subvectors: 8})
+	require.NoError(t, err)
+	assert.Equal(t, defaultTrainingSize, q.TrainingSize())
+
+	_, err = New(manifest.QuantizationInfo{Type: "binary"})
+	assert.ErrorContains(t, err, "unknown quantization")
+}
+
+func TestQuantizedDistances(t *testing.T) {
+	vecs := randomVectors(300, 32)
+	query := randomVectors(1, 32)[0]
+
+	pq := NewProductQuantizer(0, len(vecs))
+	require.False(t, pq.Trained())
+	require.NoError(t, pq.Train(vecs))
+
+	tests := []struct {
+		name      string
+		quantizer Quantizer
+		tolerance float64
+	}{
+		{"int8", NewInt8Quantizer(), 0.05},
+		{"pq", pq, 0.6},
+	}
+
+	for _, tt := range tests {
+		for _, metric := range []string{manifest.DistanceCosine, manifest.DistanceDot, manifest.DistanceEuclidean} {
+			t.Run(tt.name+"/"+metric, func(t *testing.T) {
+				score := tt.quantizer.Scorer(query)
+				queryNormSq, _ := utils.DotProduct(query, query)
+
+				var totalErr float64
+				for _, vec := range vecs {
+					code, err := tt.quantizer.Encode(vec)
+					require.NoError(t, err)
+
+					dot, normSq := score(code)
+					got := Distance(metric, dot, normSq, float64(queryNormSq))
+					want, err := utils.Distance(metric, query, vec)
+					require.NoError(t, err)
+					totalErr += abs(got - want)
+
+					// the distance from the code is the distance to its decoded vector
+					decoded, err := utils.Distance(metric, query, tt.quantizer.Decode(code))
+					require.NoError(t, err)
+					assert.InDelta(t, decoded, got, 1e-3)
+				}
+				assert.Less(t, totalErr/float64(len(vecs)), tt.tolerance)
+			})
+		}
+	}
+}
+
+func TestProductQuantizerMemory(t *testing.T) {
+	vecs := randomVectors(50, 64)
+	q := NewProductQuantizer(0, len(vecs))
+	require.NoError(t, q.Train(vecs))
+
+	code, err := q.Encode(vecs[0])
+	require.NoError(t, err)
+	assert.Len(t, code, 16)
+
+	_, err = q.Encode(vecs[0][:10])
+	assert.ErrorContains(t, err, "dimensions")
+
+	int8Code, err := NewInt8Quantizer().Encode(vecs[0])
+	require.NoError(t, err)
+	assert.Len(t, int8Code, 64+int8HeaderSize)
+}
+
+func TestQuantizerGob(t *testing.T) {
+	vecs := randomVectors(20, 8)
+	var q Quantizer = NewProductQuantizer(2, len(vecs))
+	require.NoError(t, q.Train(vecs))
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&q))
+
+	var restored Quantizer
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&restored))
+	assert.Equal(t, q, restored)
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,452 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/external"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/standby"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+/*
+
+DESIGN NOTES:
+
+- When the embedder of a search method changes, the existing index keeps serving searches and upserts with the
+  previous embedder, while a background job embeds every text of the collection again with the new embedder.
+- The job builds a new index for each namespace, and stages its vectors in the database under a separate search
+  method name.  It repeats until it has caught up with the texts written meanwhile.
+- When every namespace has been built, the staged vectors replace the old ones in a single transaction, and the new
+  indexes replace the old ones in memory.  Texts written after the job caught up are embedded by the next sync,
+  since the new indexes' checkpoints are at the last text the job embedded.
+- External indexes share their vector database with the old index, so their vectors are uploaded at the switch.
+- If the embedder changes again, or back, the job is cancelled, and a new one is started if needed.  A job that
+  doesn't finish is started again from the beginning, including after a restart from local storage.
+- Standbys don't re-embed, since the active runtime writes the new vectors, which the standby syncs.
+
+*/
+
+const (
+	ReembedStatusRunning   = "running"
+	ReembedStatusCompleted = "completed"
+	ReembedStatusFailed    = "failed"
+	ReembedStatusCancelled = "cancelled"
+
+	// stagedSearchMethodSuffix is added to the name of a search method, for the vectors staged while it is re-embedded.
+	stagedSearchMethodSuffix = "#reembed"
+)
+
+// The database operations of re-embedding, which tests replace.
+var (
+	queryTextsToReembed = db.QueryCollectionTextsFromCheckpoint
+	writeStagedVectors  = db.WriteCollectionVectors
+	deleteStagedVectors = db.DeleteCollectionVectors
+	swapStagedVectors   = db.SwapCollectionVectors
+	embedTextsToReembed = embedTexts
+)
+
+// ReembedStatus reports the progress of re-embedding the texts of a search method with a new embedder.
+type ReembedStatus struct {
+	Collection       string     `json:"collection"`
+	SearchMethod     string     `json:"searchMethod"`
+	Embedder         string     `json:"embedder"`
+	PreviousEmbedder string     `json:"previousEmbedder"`
+	Status           string     `json:"status"`
+	Total            int        `json:"total"`
+	Processed        int        `json:"processed"`
+	Error            string     `json:"error,omitempty"`
+	StartedAt        time.Time  `json:"startedAt"`
+	FinishedAt       *time.Time `json:"finishedAt,omitempty"`
+}
+
+type reembedJob struct {
+	mu           sync.Mutex
+	status       ReembedStatus
+	searchMethod manifest.SearchMethodInfo
+	cancel       context.CancelFunc
+	done         chan struct{}
+}
+
+type reembedKey struct {
+	collection   string
+	searchMethod string
+}
+
+// reembedJobs holds the latest job of each search method, guarding the switch to the new indexes.
+var reembedJobs = struct {
+	sync.Mutex
+	jobs map[reembedKey]*reembedJob
+}{jobs: map[reembedKey]*reembedJob{}}
+
+// stagedIndex is a new index built for a namespace, with the keys and the last text it embedded.
+type stagedIndex struct {
+	vi         *interfaces.VectorIndexWrapper
+	keys       []string
+	lastTextId int64
+}
+
+// reembedIfNeeded starts re-embedding the search method when the embedder of its index differs from the manifest,
+// unless it is already in progress.  A job for an embedder that is no longer in the manifest is cancelled.
+func reembedIfNeeded(ctx context.Context, collectionName, searchMethodName string, searchMethod manifest.SearchMethodInfo, vi *interfaces.VectorIndexWrapper) {
+	current := vi.GetEmbedderName()
+	if standby.IsStandby() {
+		if current != searchMethod.Embedder {
+			_ = vi.SetEmbedderName(searchMethod.Embedder)
+		}
+		return
+	}
+
+	reembedJobs.Lock()
+	defer reembedJobs.Unlock()
+
+	key := reembedKey{collectionName, searchMethodName}
+	if job := reembedJobs.jobs[key]; job != nil && job.running() {
+		if current != searchMethod.Embedder && job.searchMethod.Embedder == searchMethod.Embedder {
+			return
+		}
+		job.cancel()
+	}
+	if current == searchMethod.Embedder {
+		return
+	}
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	job := &reembedJob{
+		status: ReembedStatus{
+			Collection:       collectionName,
+			SearchMethod:     searchMethodName,
+			Embedder:         searchMethod.Embedder,
+			PreviousEmbedder: current,
+			Status:           ReembedStatusRunning,
+			StartedAt:        time.Now().UTC(),
+		},
+		searchMethod: searchMethod,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
+	reembedJobs.jobs[key] = job
+
+	logger.Info(ctx).
+		Str("collection_name", collectionName).
+		Str("search_method", searchMethodName).
+		Str("embedder", searchMethod.Embedder).
+		Msg("Re-embedding collection with a new embedder.")
+	go job.run(jobCtx)
+}
+
+// activeEmbedder returns the embedder that searches of the search method should use, which is the previous
+// embedder while the search method is being re-embedded.
+func activeEmbedder(collectionName, searchMethodName, embedder string) string {
+	reembedJobs.Lock()
+	defer reembedJobs.Unlock()
+	if job := reembedJobs.jobs[reembedKey{collectionName, searchMethodName}]; job != nil && job.running() {
+		return job.status.PreviousEmbedder
+	}
+	return embedder
+}
+
+// stopReembedding cancels any jobs in progress, and waits for them to stop.
+func stopReembedding() {
+	reembedJobs.Lock()
+	jobs := make([]*reembedJob, 0, len(reembedJobs.jobs))
+	for _, job := range reembedJobs.jobs {
+		job.cancel()
+		jobs = append(jobs, job)
+	}
+	reembedJobs.Unlock()
+
+	for _, job := range jobs {
+		<-job.done
+	}
+}
+
+// getReembedStatuses returns the status of the latest job of each search method, optionally of one collection.
+func getReembedStatuses(collectionName string) []ReembedStatus {
+	reembedJobs.Lock()
+	defer reembedJobs.Unlock()
+
+	statuses := make([]ReembedStatus, 0, len(reembedJobs.jobs))
+	for key, job := range reembedJobs.jobs {
+		if collectionName != "" && key.collection != collectionName {
+			continue
+		}
+		job.mu.Lock()
+		statuses = append(statuses, job.status)
+		job.mu.Unlock()
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Collection != statuses[j].Collection {
+			return statuses[i].Collection < statuses[j].Collection
+		}
+		return statuses[i].SearchMethod < statuses[j].SearchMethod
+	})
+	return statuses
+}
+
+func (j *reembedJob) running() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status.Status == ReembedStatusRunning
+}
+
+func (j *reembedJob) progress(total, processed int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Total += total
+	j.status.Processed += processed
+}
+
+func (j *reembedJob) run(ctx context.Context) {
+	defer close(j.done)
+	defer j.cancel()
+
+	err := j.reembed(ctx)
+
+	j.mu.Lock()
+	now := time.Now().UTC()
+	j.status.FinishedAt = &now
+	switch {
+	case err == nil:
+		j.status.Status = ReembedStatusCompleted
+	case errors.Is(err, context.Canceled):
+		j.status.Status = ReembedStatusCancelled
+	default:
+		j.status.Status = ReembedStatusFailed
+		j.status.Error = err.Error()
+	}
+	status := j.status
+	j.mu.Unlock()
+
+	if err != nil {
+		j.cleanup()
+	}
+
+	l := logger.Info(ctx)
+	if status.Status == ReembedStatusFailed {
+		l = logger.Err(ctx, err)
+	}
+	l.Str("collection_name", status.Collection).
+		Str("search_method", status.SearchMethod).
+		Str("embedder", status.Embedder).
+		Int("processed", status.Processed).
+		Msgf("Re-embedding collection %s.", status.Status)
+}
+
+func (j *reembedJob) reembed(ctx context.Context) error {
+	col, err := globalNamespaceManager.findCollection(j.status.Collection)
+	if err != nil {
+		return err
+	}
+
+	ctx, err = collectionContext(ctx, j.status.Collection)
+	if err != nil {
+		return err
+	}
+
+	// build a new index for each namespace, including any that were created meanwhile
+	staged := map[interfaces.CollectionNamespace]*stagedIndex{}
+	for {
+		namespaces, err := col.resolveNamespaces([]string{AllNamespaces})
+		if err != nil {
+			return err
+		}
+		namespaces = slices.DeleteFunc(namespaces, func(collNs interfaces.CollectionNamespace) bool {
+			return staged[collNs] != nil
+		})
+		if len(namespaces) == 0 {
+			break
+		}
+		for _, collNs := range namespaces {
+			s, err := j.stage(ctx, collNs)
+			if err != nil {
+				return err
+			}
+			staged[collNs] = s
+		}
+	}
+
+	return j.swap(ctx, col, staged)
+}
+
+// stage embeds the texts of the namespace with the new embedder, until it has caught up with the texts written meanwhile.
+func (j *reembedJob) stage(ctx context.Context, collNs interfaces.CollectionNamespace) (*stagedIndex, error) {
+	collectionName, namespace := collNs.GetCollectionName(), collNs.GetNamespace()
+	stagedName := j.status.SearchMethod + stagedSearchMethodSuffix
+
+	// vectors staged by a job that didn't finish are embedded again
+	if err := deleteStagedVectors(ctx, collectionName, stagedName, namespace); err != nil {
+		return nil, err
+	}
+
+	vi, err := createIndexObject(collectionName, namespace, j.searchMethod, j.status.SearchMethod)
+	if err != nil {
+		return nil, err
+	}
+	inMemory := external.VectorIndexType(j.searchMethod.Index.Type) == ""
+
+	s := &stagedIndex{vi: vi}
+	for {
+		textIds, keys, texts, _, metadata, err := queryTextsToReembed(ctx, collectionName, namespace, s.lastTextId)
+		if err != nil {
+			return nil, err
+		}
+		if len(textIds) == 0 {
+			return s, nil
+		}
+		j.progress(len(textIds), 0)
+
+		if j.searchMethod.Field != "" && len(metadata) != 0 {
+			for i := range texts {
+				texts[i] = fieldText(j.searchMethod.Field, texts[i], metadata[i])
+			}
+		}
+
+		for i := 0; i < len(textIds); i += batchSize {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			end := min(i+batchSize, len(textIds))
+
+			vecs, err := embedTextsToReembed(ctx, j.status.Embedder, texts[i:end])
+			if err != nil {
+				return nil, err
+			}
+			vectorIds, _, err := writeStagedVectors(ctx, stagedName, textIds[i:end], vecs)
+			if err != nil {
+				return nil, err
+			}
+			if inMemory {
+				if err := vi.InsertVectorsToMemory(ctx, textIds[i:end], vectorIds, keys[i:end], vecs); err != nil {
+					return nil, err
+				}
+			}
+			j.progress(0, end-i)
+		}
+
+		s.keys = append(s.keys, keys...)
+		s.lastTextId = max(s.lastTextId, slices.Max(textIds))
+	}
+}
+
+// swap replaces the old vectors with the staged ones in the database, and the old indexes with the new ones in memory.
+func (j *reembedJob) swap(ctx context.Context, col *collection, staged map[interfaces.CollectionNamespace]*stagedIndex) error {
+	reembedJobs.Lock()
+	defer reembedJobs.Unlock()
+
+	// the job may have been cancelled while waiting for the lock
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	lastVectorId, err := swapStagedVectors(ctx, j.status.Collection, j.status.SearchMethod, j.status.SearchMethod+stagedSearchMethodSuffix)
+	if err != nil {
+		return err
+	}
+
+	namespaces, err := col.resolveNamespaces([]string{AllNamespaces})
+	if err != nil {
+		return err
+	}
+	for _, collNs := range namespaces {
+		s := staged[collNs]
+		if s == nil {
+			// the namespace was created after the job caught up, so the next sync embeds its texts
+			vi, err := createIndexObject(collNs.GetCollectionName(), collNs.GetNamespace(), j.searchMethod, j.status.SearchMethod)
+			if err != nil {
+				return err
+			}
+			s = &stagedIndex{vi: vi}
+		} else if external.VectorIndexType(j.searchMethod.Index.Type) != "" {
+			if err := loadVectorsIntoVectorIndex(ctx, s.vi, collNs); err != nil {
+				return err
+			}
+		}
+
+		// remove the texts that were deleted while the job was running
+		if len(s.keys) > 0 {
+			textMap, err := collNs.GetTextMap(ctx)
+			if err != nil {
+				return err
+			}
+			for _, key := range s.keys {
+				if _, ok := textMap[key]; !ok {
+					if err := s.vi.DeleteVectorFromMemory(ctx, key); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		if cs, ok := s.vi.VectorIndex.(interfaces.CheckpointSetter); ok && s.lastTextId > 0 {
+			cs.SetCheckpoints(lastVectorId, s.lastTextId)
+		}
+		if err := collNs.ReplaceVectorIndex(ctx, j.status.SearchMethod, s.vi); err != nil {
+			logger.Warn(ctx).Err(err).
+				Str("collection_name", j.status.Collection).
+				Str("namespace", collNs.GetNamespace()).
+				Str("search_method", j.status.SearchMethod).
+				Msg("Failed to replace the vector index with the re-embedded one.")
+		}
+	}
+
+	if store := globalCollectionStore; store != nil {
+		store.markDirty()
+		store.requestSnapshot()
+	}
+	globalNamespaceManager.requestSync()
+	return nil
+}
+
+// cleanup deletes the vectors staged by a job that didn't finish.
+func (j *reembedJob) cleanup() {
+	col, err := globalNamespaceManager.findCollection(j.status.Collection)
+	if err != nil {
+		return
+	}
+	ctx, err := collectionContext(context.Background(), j.status.Collection)
+	if err != nil {
+		return
+	}
+	namespaces, err := col.resolveNamespaces([]string{AllNamespaces})
+	if err != nil {
+		return
+	}
+	for _, collNs := range namespaces {
+		if err := deleteStagedVectors(ctx, j.status.Collection, j.status.SearchMethod+stagedSearchMethodSuffix, collNs.GetNamespace()); err != nil {
+			logger.Warn(ctx).Err(err).
+				Str("collection_name", j.status.Collection).
+				Str("search_method", j.status.SearchMethod).
+				Msg("Failed to delete the vectors staged for re-embedding.")
+			return
+		}
+	}
+}
+
+// ReembedHandler reports the progress of re-embedding collections (GET, with an optional "collection" query parameter).
+func ReembedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.WriteJsonResponse(w, getReembedStatuses(r.URL.Query().Get("collection")))
+}
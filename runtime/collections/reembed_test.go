@@ -0,0 +1,205 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupReembedTest creates a collection with two namespaces, whose texts were embedded with the "old" embedder,
+// and replaces the database and embedder calls of re-embedding.  The embedder waits for the release channel.
+func setupReembedTest(t *testing.T) (map[string]interfaces.CollectionNamespace, chan struct{}) {
+	ctx := context.Background()
+
+	texts := map[string]map[int64][2]string{
+		"":      {1: {"a", "apple"}, 2: {"b", "banana"}},
+		"other": {3: {"c", "cherry"}},
+	}
+
+	cf := newCollectionFactory()
+	col, err := cf.createCollection("docs", newCollection())
+	require.NoError(t, err)
+	namespaces := map[string]interfaces.CollectionNamespace{}
+	for namespace, items := range texts {
+		collNs, err := col.createCollectionNamespace(namespace, in_mem.NewCollectionNamespace("docs", namespace))
+		require.NoError(t, err)
+		vi, err := createIndexObject("docs", namespace, manifest.SearchMethodInfo{Embedder: "old"}, "search")
+		require.NoError(t, err)
+		require.NoError(t, collNs.SetVectorIndex(ctx, "search", vi))
+		for id, item := range items {
+			require.NoError(t, collNs.InsertTextToMemory(ctx, id, item[0], item[1], nil, nil))
+			require.NoError(t, vi.InsertVectorToMemory(ctx, id, id, item[0], []float32{0, 1}))
+		}
+		namespaces[namespace] = collNs
+	}
+
+	release := make(chan struct{})
+	var vectorId int64
+	queryTextsToReembed = func(ctx context.Context, collection, namespace string, textCheckpointId int64) ([]int64, []string, []string, [][]string, []map[string]any, error) {
+		var ids []int64
+		var keys, values []string
+		for id, item := range texts[namespace] {
+			if id > textCheckpointId {
+				ids = append(ids, id)
+				keys = append(keys, item[0])
+				values = append(values, item[1])
+			}
+		}
+		return ids, keys, values, nil, nil, nil
+	}
+	embedTextsToReembed = func(ctx context.Context, embedder string, texts []string) ([][]float32, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		vecs := make([][]float32, len(texts))
+		for i, text := range texts {
+			vecs[i] = []float32{float32(len(text)), 0}
+		}
+		return vecs, nil
+	}
+	writeStagedVectors = func(ctx context.Context, searchMethodName string, textIds []int64, vecs [][]float32) ([]int64, []string, error) {
+		assert.Equal(t, "search"+stagedSearchMethodSuffix, searchMethodName)
+		ids := make([]int64, len(textIds))
+		for i := range ids {
+			vectorId++
+			ids[i] = 10 + vectorId
+		}
+		return ids, nil, nil
+	}
+	deleteStagedVectors = func(ctx context.Context, collectionName, searchMethodName, namespace string) error {
+		return nil
+	}
+	swapStagedVectors = func(ctx context.Context, collectionName, searchMethodName, stagedSearchMethodName string) (int64, error) {
+		return 100, nil
+	}
+
+	previous := globalNamespaceManager
+	globalNamespaceManager = cf
+	t.Cleanup(func() {
+		globalNamespaceManager = previous
+		queryTextsToReembed = db.QueryCollectionTextsFromCheckpoint
+		writeStagedVectors = db.WriteCollectionVectors
+		deleteStagedVectors = db.DeleteCollectionVectors
+		swapStagedVectors = db.SwapCollectionVectors
+		embedTextsToReembed = embedTexts
+		stopReembedding()
+		clear(reembedJobs.jobs)
+	})
+
+	return namespaces, release
+}
+
+func TestReembed(t *testing.T) {
+	ctx := context.Background()
+	namespaces, release := setupReembedTest(t)
+
+	vi, err := namespaces[""].GetVectorIndex(ctx, "search")
+	require.NoError(t, err)
+	reembedIfNeeded(ctx, "docs", "search", manifest.SearchMethodInfo{Embedder: "new"}, vi)
+	job := reembedJobs.jobs[reembedKey{"docs", "search"}]
+	require.NotNil(t, job)
+
+	// starting it again while it is running does nothing
+	reembedIfNeeded(ctx, "docs", "search", manifest.SearchMethodInfo{Embedder: "new"}, vi)
+	assert.Same(t, job, reembedJobs.jobs[reembedKey{"docs", "search"}])
+
+	// searches use the previous embedder until the job is done
+	assert.Equal(t, "old", activeEmbedder("docs", "search", "new"))
+	statuses := getReembedStatuses("docs")
+	require.Len(t, statuses, 1)
+	assert.Equal(t, ReembedStatusRunning, statuses[0].Status)
+	assert.Equal(t, "old", statuses[0].PreviousEmbedder)
+
+	close(release)
+	<-job.done
+
+	assert.Equal(t, "new", activeEmbedder("docs", "search", "new"))
+	status := getReembedStatuses("")[0]
+	assert.Equal(t, ReembedStatusCompleted, status.Status)
+	assert.Equal(t, 3, status.Total)
+	assert.Equal(t, 3, status.Processed)
+	assert.NotNil(t, status.FinishedAt)
+
+	for namespace, collNs := range namespaces {
+		vi, err := collNs.GetVectorIndex(ctx, "search")
+		require.NoError(t, err)
+		assert.Equal(t, "new", vi.GetEmbedderName(), namespace)
+		checkpoint, err := vi.GetCheckpointId(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), checkpoint, namespace)
+	}
+	vi, err = namespaces["other"].GetVectorIndex(ctx, "search")
+	require.NoError(t, err)
+	vec, err := vi.GetVector(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{6, 0}, vec)
+}
+
+func TestReembedCancelled(t *testing.T) {
+	ctx := context.Background()
+	namespaces, _ := setupReembedTest(t)
+
+	vi, err := namespaces[""].GetVectorIndex(ctx, "search")
+	require.NoError(t, err)
+	reembedIfNeeded(ctx, "docs", "search", manifest.SearchMethodInfo{Embedder: "new"}, vi)
+	job := reembedJobs.jobs[reembedKey{"docs", "search"}]
+
+	// the embedder was changed back, so the job is cancelled, and the index isn't replaced
+	reembedIfNeeded(ctx, "docs", "search", manifest.SearchMethodInfo{Embedder: "old"}, vi)
+	<-job.done
+	assert.Equal(t, ReembedStatusCancelled, getReembedStatuses("docs")[0].Status)
+
+	current, err := namespaces[""].GetVectorIndex(ctx, "search")
+	require.NoError(t, err)
+	assert.Same(t, vi, current)
+	assert.Equal(t, "old", activeEmbedder("docs", "search", "old"))
+}
+
+func TestReembedHandler(t *testing.T) {
+	ctx := context.Background()
+	namespaces, release := setupReembedTest(t)
+
+	vi, err := namespaces[""].GetVectorIndex(ctx, "search")
+	require.NoError(t, err)
+	reembedIfNeeded(ctx, "docs", "search", manifest.SearchMethodInfo{Embedder: "new"}, vi)
+	close(release)
+	<-reembedJobs.jobs[reembedKey{"docs", "search"}].done
+
+	w := httptest.NewRecorder()
+	ReembedHandler(w, httptest.NewRequest(http.MethodGet, "/admin/collections/reembed?collection=docs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var statuses []ReembedStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "new", statuses[0].Embedder)
+	assert.Equal(t, ReembedStatusCompleted, statuses[0].Status)
+
+	w = httptest.NewRecorder()
+	ReembedHandler(w, httptest.NewRequest(http.MethodGet, "/admin/collections/reembed?collection=other", nil))
+	assert.JSONEq(t, "[]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	ReembedHandler(w, httptest.NewRequest(http.MethodPost, "/admin/collections/reembed", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
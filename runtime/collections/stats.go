@@ -0,0 +1,237 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/metrics"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// CollectionStats describes the items of a collection held in memory by this runtime, to monitor its growth.
+// Memory usage is an estimate from the sizes of the items and vectors.
+type CollectionStats struct {
+	Collection   string           `json:"collection"`
+	Ttl          int              `json:"ttl,omitempty"`
+	Items        int              `json:"items"`
+	MemoryBytes  int64            `json:"memoryBytes"`
+	LastMutation *time.Time       `json:"lastMutation,omitempty"`
+	Namespaces   []NamespaceStats `json:"namespaces"`
+}
+
+type NamespaceStats struct {
+	Namespace     string              `json:"namespace"`
+	Items         int                 `json:"items"`
+	MemoryBytes   int64               `json:"memoryBytes"`
+	LastMutation  *time.Time          `json:"lastMutation,omitempty"`
+	SearchMethods []SearchMethodStats `json:"searchMethods"`
+}
+
+// SearchMethodStats describes the index of a search method.  Indexes that keep their vectors in an external
+// vector database have no vector count, dimensions or memory usage.  Pending is the number of items that have
+// yet to be embedded into an in-memory index, which should stay near zero.
+type SearchMethodStats struct {
+	SearchMethod string                     `json:"searchMethod"`
+	Embedder     string                     `json:"embedder"`
+	IndexType    string                     `json:"indexType"`
+	Options      *manifest.OptionsInfo      `json:"options,omitempty"`
+	Distance     string                     `json:"distance"`
+	Normalize    bool                       `json:"normalize,omitempty"`
+	Quantization *manifest.QuantizationInfo `json:"quantization,omitempty"`
+	Vectors      *int                       `json:"vectors,omitempty"`
+	Dimensions   int                        `json:"dimensions,omitempty"`
+	MemoryBytes  int64                      `json:"memoryBytes"`
+	Pending      int                        `json:"pending"`
+	CheckpointId int64                      `json:"checkpointId"`
+}
+
+// getCollectionStats returns the stats of the collection, or of every collection if the name is empty.
+func getCollectionStats(ctx context.Context, collectionName string) ([]CollectionStats, error) {
+	cf := globalNamespaceManager
+
+	var names []string
+	if collectionName != "" {
+		if _, err := cf.findCollection(collectionName); err != nil {
+			return nil, err
+		}
+		names = []string{collectionName}
+	} else {
+		cf.mu.RLock()
+		for name := range cf.collectionMap {
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+		cf.mu.RUnlock()
+		slices.Sort(names)
+	}
+
+	stats := make([]CollectionStats, 0, len(names))
+	for _, name := range names {
+		col, err := cf.findCollection(name)
+		if err != nil {
+			continue
+		}
+		s, err := col.getStats(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, *s)
+	}
+	return stats, nil
+}
+
+func (c *collection) getStats(ctx context.Context, collectionName string) (*CollectionStats, error) {
+	info := manifestdata.GetManifest().Collections[collectionName]
+	stats := &CollectionStats{
+		Collection: collectionName,
+		Ttl:        info.Ttl,
+		Namespaces: []NamespaceStats{},
+	}
+
+	c.mu.RLock()
+	namespaces := maps.Clone(c.collectionNamespaceMap)
+	c.mu.RUnlock()
+
+	for _, namespace := range slices.Sorted(maps.Keys(namespaces)) {
+		ns, err := getNamespaceStats(ctx, namespaces[namespace], info)
+		if err != nil {
+			return nil, err
+		}
+		stats.Items += ns.Items
+		stats.MemoryBytes += ns.MemoryBytes
+		if ns.LastMutation != nil && (stats.LastMutation == nil || ns.LastMutation.After(*stats.LastMutation)) {
+			stats.LastMutation = ns.LastMutation
+		}
+		stats.Namespaces = append(stats.Namespaces, *ns)
+	}
+	return stats, nil
+}
+
+func getNamespaceStats(ctx context.Context, collNs interfaces.CollectionNamespace, info manifest.CollectionInfo) (*NamespaceStats, error) {
+	nsStats, err := collNs.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &NamespaceStats{
+		Namespace:     collNs.GetNamespace(),
+		Items:         nsStats.Items,
+		MemoryBytes:   nsStats.MemoryBytes,
+		SearchMethods: []SearchMethodStats{},
+	}
+	if !nsStats.LastMutation.IsZero() {
+		stats.LastMutation = &nsStats.LastMutation
+	}
+
+	vectorIndexes := collNs.GetVectorIndexMap()
+	for _, name := range slices.Sorted(maps.Keys(vectorIndexes)) {
+		vectorIndex := vectorIndexes[name]
+		checkpointId, err := vectorIndex.GetCheckpointId(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		sm := SearchMethodStats{
+			SearchMethod: name,
+			Embedder:     vectorIndex.GetEmbedderName(),
+			IndexType:    vectorIndex.Type,
+			Distance:     vectorIndex.GetDistance(),
+			Normalize:    vectorIndex.GetNormalize(),
+			CheckpointId: checkpointId,
+		}
+		if quantization := vectorIndex.GetQuantization(); quantization.Type != "" {
+			sm.Quantization = &quantization
+		}
+		if searchMethod, ok := info.SearchMethods[name]; ok {
+			sm.IndexType = searchMethod.Index.Type
+			if sm.IndexType == "" {
+				sm.IndexType = interfaces.SequentialManifestType
+			}
+			if sm.IndexType == interfaces.HnswManifestType {
+				sm.Options = &searchMethod.Index.Options
+			}
+		}
+		if reporter, ok := vectorIndex.VectorIndex.(interfaces.StatsReporter); ok {
+			viStats := reporter.GetStats()
+			sm.Vectors = &viStats.Vectors
+			sm.Dimensions = viStats.Dimensions
+			sm.MemoryBytes = viStats.MemoryBytes
+			sm.Pending = max(stats.Items-viStats.Vectors, 0)
+			stats.MemoryBytes += viStats.MemoryBytes
+		}
+		stats.SearchMethods = append(stats.SearchMethods, sm)
+	}
+	return stats, nil
+}
+
+// updateCollectionMetrics sets the collection gauges from the stats of every collection.  The gauges are reset first,
+// so that collections and namespaces that were removed stop being reported.
+func updateCollectionMetrics(ctx context.Context) {
+	stats, err := getCollectionStats(ctx, "")
+	if err != nil {
+		logger.Err(ctx, err).Msg("Failed to get collection stats for metrics.")
+		return
+	}
+
+	metrics.CollectionItemsNum.Reset()
+	metrics.CollectionVectorsNum.Reset()
+	metrics.CollectionMemoryBytes.Reset()
+	metrics.CollectionLastMutationSeconds.Reset()
+
+	for _, col := range stats {
+		for _, ns := range col.Namespaces {
+			metrics.CollectionItemsNum.WithLabelValues(col.Collection, ns.Namespace).Set(float64(ns.Items))
+			metrics.CollectionMemoryBytes.WithLabelValues(col.Collection, ns.Namespace).Set(float64(ns.MemoryBytes))
+			if ns.LastMutation != nil {
+				metrics.CollectionLastMutationSeconds.WithLabelValues(col.Collection, ns.Namespace).Set(float64(ns.LastMutation.Unix()))
+			}
+			for _, sm := range ns.SearchMethods {
+				if sm.Vectors != nil {
+					metrics.CollectionVectorsNum.
+						WithLabelValues(col.Collection, ns.Namespace, sm.SearchMethod).
+						Set(float64(*sm.Vectors))
+				}
+			}
+		}
+	}
+}
+
+// StatsHandler returns the stats of the collections (GET with an optional "collection" query parameter).
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	collectionName := r.URL.Query().Get("collection")
+	stats, err := getCollectionStats(ctx, collectionName)
+	switch {
+	case errors.Is(err, errCollectionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		logger.Err(ctx, err).Str("collection_name", collectionName).Msg("Failed to get collection stats.")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		utils.WriteJsonResponse(w, stats)
+	}
+}
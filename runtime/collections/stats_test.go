@@ -0,0 +1,139 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStatsTest(t *testing.T) {
+	ctx := context.Background()
+
+	searchMethods := map[string]manifest.SearchMethodInfo{
+		"exact": {Embedder: "embed"},
+		"fast":  {Embedder: "embed", Index: manifest.IndexInfo{Type: "hnsw", Options: manifest.OptionsInfo{M: 8}}},
+	}
+	manifestdata.SetManifest(&manifest.Manifest{
+		Collections: map[string]manifest.CollectionInfo{
+			"docs": {SearchMethods: searchMethods, Ttl: 3600},
+		},
+	})
+
+	cf := newCollectionFactory()
+	col, err := cf.createCollection("docs", newCollection())
+	require.NoError(t, err)
+
+	for namespace, keys := range map[string][]string{"": {"a", "b"}, "other": {"c"}} {
+		collNs, err := col.createCollectionNamespace(namespace, in_mem.NewCollectionNamespace("docs", namespace))
+		require.NoError(t, err)
+		for name, searchMethod := range searchMethods {
+			vi, err := createIndexObject("docs", namespace, searchMethod, name)
+			require.NoError(t, err)
+			require.NoError(t, collNs.SetVectorIndex(ctx, name, vi))
+		}
+		for i, key := range keys {
+			id := int64(i + 1)
+			require.NoError(t, collNs.InsertTextToMemory(ctx, id, key, "text", []string{"label"}, nil))
+			vi, err := collNs.GetVectorIndex(ctx, "exact")
+			require.NoError(t, err)
+			require.NoError(t, vi.InsertVectorToMemory(ctx, id, id, key, []float32{1, 2, 3}))
+		}
+	}
+
+	previous := globalNamespaceManager
+	globalNamespaceManager = cf
+	t.Cleanup(func() { globalNamespaceManager = previous })
+}
+
+func TestGetCollectionStats(t *testing.T) {
+	setupStatsTest(t)
+
+	stats, err := getCollectionStats(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+
+	col := stats[0]
+	assert.Equal(t, "docs", col.Collection)
+	assert.Equal(t, 3600, col.Ttl)
+	assert.Equal(t, 3, col.Items)
+	assert.NotNil(t, col.LastMutation)
+	require.Len(t, col.Namespaces, 2)
+
+	ns := col.Namespaces[0]
+	assert.Equal(t, "", ns.Namespace)
+	assert.Equal(t, 2, ns.Items)
+	assert.Positive(t, ns.MemoryBytes)
+	assert.Equal(t, col.MemoryBytes, ns.MemoryBytes+col.Namespaces[1].MemoryBytes)
+	require.Len(t, ns.SearchMethods, 2)
+
+	exact := ns.SearchMethods[0]
+	assert.Equal(t, "exact", exact.SearchMethod)
+	assert.Equal(t, "sequential", exact.IndexType)
+	assert.Nil(t, exact.Options)
+	assert.Equal(t, 2, *exact.Vectors)
+	assert.Equal(t, 3, exact.Dimensions)
+	assert.Equal(t, int64(2*(1+12)), exact.MemoryBytes)
+	assert.Equal(t, 0, exact.Pending)
+
+	fast := ns.SearchMethods[1]
+	assert.Equal(t, "hnsw", fast.IndexType)
+	assert.Equal(t, 8, fast.Options.M)
+	assert.Equal(t, 0, *fast.Vectors)
+	assert.Equal(t, 2, fast.Pending, "the items haven't been embedded into the hnsw index")
+
+	_, err = getCollectionStats(context.Background(), "missing")
+	assert.ErrorIs(t, err, errCollectionNotFound)
+}
+
+func TestUpdateCollectionMetrics(t *testing.T) {
+	setupStatsTest(t)
+
+	metrics.CollectionItemsNum.WithLabelValues("removed", "").Set(10)
+	updateCollectionMetrics(context.Background())
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.CollectionItemsNum.WithLabelValues("docs", "")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.CollectionItemsNum.WithLabelValues("docs", "other")))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.CollectionItemsNum), "removed collections are no longer reported")
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.CollectionVectorsNum.WithLabelValues("docs", "other", "exact")))
+	assert.Positive(t, testutil.ToFloat64(metrics.CollectionLastMutationSeconds.WithLabelValues("docs", "")))
+}
+
+func TestStatsHandler(t *testing.T) {
+	setupStatsTest(t)
+
+	w := httptest.NewRecorder()
+	StatsHandler(w, httptest.NewRequest(http.MethodGet, "/admin/collections/stats?collection=docs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats []CollectionStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	require.Len(t, stats, 1)
+	assert.Equal(t, 3, stats[0].Items)
+
+	w = httptest.NewRecorder()
+	StatsHandler(w, httptest.NewRequest(http.MethodGet, "/admin/collections/stats?collection=missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	StatsHandler(w, httptest.NewRequest(http.MethodPost, "/admin/collections/stats", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
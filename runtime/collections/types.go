@@ -9,6 +9,12 @@
 
 package collections
 
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
 func NewCollectionMutationResult(collection, operation, status string, keys []string, err string) *CollectionMutationResult {
 	if keys == nil {
 		keys = []string{}
@@ -30,6 +36,81 @@ type CollectionMutationResult struct {
 	Error      string
 }
 
+func NewCollectionVersionedMutationResult(collection, operation, status, key string, version int64, err string) *CollectionVersionedMutationResult {
+	return &CollectionVersionedMutationResult{
+		Collection: collection,
+		Operation:  operation,
+		Status:     status,
+		Key:        key,
+		Version:    version,
+		Error:      err,
+	}
+}
+
+// CollectionVersionedMutationResult is the result of a mutation of an item that expected it to have a version.
+// The version is the new version of the item on success, or its current version when the status is "conflict".
+// A version of 0 means that the item doesn't exist.
+type CollectionVersionedMutationResult struct {
+	Collection string
+	Operation  string
+	Status     string
+	Key        string
+	Version    int64
+	Error      string
+}
+
+// CollectionItemVersion is the current version of an item, which is 0 if the item doesn't exist.
+type CollectionItemVersion struct {
+	Key     string
+	Version int64
+}
+
+func NewCollectionBatchMutationResult(collection, operation string, keys []string, failures []*CollectionMutationFailure) *CollectionBatchMutationResult {
+	if keys == nil {
+		keys = []string{}
+	}
+	if failures == nil {
+		failures = []*CollectionMutationFailure{}
+	}
+
+	status := "success"
+	var err string
+	if len(failures) > 0 {
+		if len(keys) == 0 {
+			status = "error"
+			err = "all items failed"
+		} else {
+			status = "partial"
+			err = fmt.Sprintf("%d of %d items failed", len(failures), len(failures)+len(keys))
+		}
+	}
+
+	return &CollectionBatchMutationResult{
+		Collection: collection,
+		Operation:  operation,
+		Status:     status,
+		Keys:       keys,
+		Failures:   failures,
+		Error:      err,
+	}
+}
+
+// CollectionBatchMutationResult is the result of a batch mutation, where each item can fail independently.
+// The keys are those of the items that succeeded, and the failures are those that didn't.
+type CollectionBatchMutationResult struct {
+	Collection string
+	Operation  string
+	Status     string
+	Keys       []string
+	Failures   []*CollectionMutationFailure
+	Error      string
+}
+
+type CollectionMutationFailure struct {
+	Key   string
+	Error string
+}
+
 func NewSearchMethodMutationResult(collection, searchMethod, operation, status, err string) *SearchMethodMutationResult {
 	return &SearchMethodMutationResult{
 		Collection:   collection,
@@ -48,6 +129,113 @@ type SearchMethodMutationResult struct {
 	Error        string
 }
 
+func NewCollectionSnapshotResult(collection, operation, uri string, namespaces []string, count int) *CollectionSnapshotResult {
+	if namespaces == nil {
+		namespaces = []string{}
+	}
+	return &CollectionSnapshotResult{
+		Collection: collection,
+		Operation:  operation,
+		Status:     "success",
+		Uri:        uri,
+		Namespaces: namespaces,
+		Count:      int32(count),
+	}
+}
+
+// CollectionSnapshotResult is the result of exporting a collection to object storage, or importing it from there.
+// The count is the number of items in the namespaces that were exported or imported.
+type CollectionSnapshotResult struct {
+	Collection string
+	Operation  string
+	Status     string
+	Uri        string
+	Namespaces []string
+	Count      int32
+	Error      string
+}
+
+func NewCollectionItemsResult(collection, namespace string, items []*CollectionItemObject, cursor string) *CollectionItemsResult {
+	if items == nil {
+		items = []*CollectionItemObject{}
+	}
+	return &CollectionItemsResult{
+		Collection: collection,
+		Namespace:  namespace,
+		Status:     "success",
+		Items:      items,
+		Cursor:     cursor,
+	}
+}
+
+// CollectionItemsResult is a page of the items of a collection.  The cursor reads the next page,
+// and is empty when there are no more items.
+type CollectionItemsResult struct {
+	Collection string
+	Namespace  string
+	Status     string
+	Items      []*CollectionItemObject
+	Cursor     string
+	Error      string
+}
+
+func NewCollectionItemObject(key, text string, labels []string, metadata map[string]any) (*CollectionItemObject, error) {
+	if labels == nil {
+		labels = []string{}
+	}
+	item := &CollectionItemObject{
+		Key:    key,
+		Text:   text,
+		Labels: labels,
+	}
+	if metadata != nil {
+		bytes, err := utils.JsonSerialize(metadata)
+		if err != nil {
+			return nil, err
+		}
+		item.Metadata = string(bytes)
+	}
+	return item, nil
+}
+
+// CollectionItemObject is an item of a collection.  The metadata is a JSON object, or empty if the item has none.
+type CollectionItemObject struct {
+	Key      string
+	Text     string
+	Labels   []string
+	Metadata string
+}
+
+func NewCollectionDuplicatesResult(collection, namespace, searchMethod string, clusters []*CollectionDuplicateCluster) *CollectionDuplicatesResult {
+	if clusters == nil {
+		clusters = []*CollectionDuplicateCluster{}
+	}
+	return &CollectionDuplicatesResult{
+		Collection:   collection,
+		Namespace:    namespace,
+		SearchMethod: searchMethod,
+		Status:       "success",
+		Clusters:     clusters,
+	}
+}
+
+// CollectionDuplicatesResult holds the clusters of near-duplicate items of a namespace.
+type CollectionDuplicatesResult struct {
+	Collection   string
+	Namespace    string
+	SearchMethod string
+	Status       string
+	Clusters     []*CollectionDuplicateCluster
+	Error        string
+}
+
+// CollectionDuplicateCluster is a group of items that are near-duplicates of each other, directly or through other
+// items of the group.  The score is the lowest similarity of the pairs of items that link the group.
+type CollectionDuplicateCluster struct {
+	Keys  []string
+	Score float64
+}
+
 func NewCollectionSearchResult(collection, searchMethod, status string, objects []*CollectionSearchResultObject, err string) *CollectionSearchResult {
 	if objects == nil {
 		objects = []*CollectionSearchResultObject{}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"github.com/viterin/vek/vek32"
+)
+
+// ValidateDistance returns an error if the distance metric isn't supported.  An empty metric is cosine.
+func ValidateDistance(metric string) error {
+	switch metric {
+	case "", manifest.DistanceCosine, manifest.DistanceDot, manifest.DistanceEuclidean:
+		return nil
+	default:
+		return fmt.Errorf("unknown distance metric %s, expected %s, %s or %s", metric, manifest.DistanceCosine, manifest.DistanceDot, manifest.DistanceEuclidean)
+	}
+}
+
+// Distance computes the distance between two vectors with the distance metric, where smaller distances are closer.
+// The cosine distance ranges from 0 to 2, the euclidean distance is never negative, and the dot product distance
+// is the negated dot product.  An empty metric is cosine.
+func Distance(metric string, a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, errors.New("can not compute distance between vectors of different lengths")
+	}
+
+	switch metric {
+	case "", manifest.DistanceCosine:
+		return float64(1 - vek32.CosineSimilarity(a, b)), nil
+	case manifest.DistanceDot:
+		return float64(-vek32.Dot(a, b)), nil
+	case manifest.DistanceEuclidean:
+		return float64(vek32.Distance(a, b)), nil
+	default:
+		return 0, ValidateDistance(metric)
+	}
+}
+
+// ScoreForDistance converts a distance computed with the distance metric into a similarity score,
+// where higher scores are more similar.  The score is the cosine similarity, the dot product,
+// or 1 / (1 + distance) for the euclidean distance.
+func ScoreForDistance(metric string, distance float64) float64 {
+	switch metric {
+	case manifest.DistanceDot:
+		return -distance
+	case manifest.DistanceEuclidean:
+		return 1 / (1 + distance)
+	default:
+		return 1 - distance
+	}
+}
+
+// PrepareVector returns the vector scaled to unit length when normalize is set, or the vector itself otherwise.
+// A vector of zeros can't be normalized, so it is returned as is.
+func PrepareVector(v []float32, normalize bool) []float32 {
+	if !normalize {
+		return v
+	}
+	n, err := Normalize(v)
+	if err != nil {
+		return v
+	}
+	return n
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+)
+
+func TestDistance(t *testing.T) {
+	a := []float32{3, 4}
+	b := []float32{6, 8}
+
+	tests := []struct {
+		metric   string
+		distance float64
+		score    float64
+	}{
+		{"", 0, 1},
+		{manifest.DistanceCosine, 0, 1},
+		{manifest.DistanceDot, -50, 50},
+		{manifest.DistanceEuclidean, 5, 1.0 / 6},
+	}
+
+	for _, tt := range tests {
+		d, err := Distance(tt.metric, a, b)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.metric, err)
+		}
+		if math.Abs(d-tt.distance) > 1e-6 {
+			t.Errorf("%s: expected distance %v, got %v", tt.metric, tt.distance, d)
+		}
+		if score := ScoreForDistance(tt.metric, d); math.Abs(score-tt.score) > 1e-6 {
+			t.Errorf("%s: expected score %v, got %v", tt.metric, tt.score, score)
+		}
+	}
+
+	if _, err := Distance("manhattan", a, b); err == nil {
+		t.Error("Expected an error for an unknown metric")
+	}
+	if _, err := Distance(manifest.DistanceDot, a, []float32{1}); err == nil {
+		t.Error("Expected an error for vectors of different lengths")
+	}
+}
+
+func TestPrepareVector(t *testing.T) {
+	v := []float32{3, 4}
+	if got := PrepareVector(v, false); &got[0] != &v[0] {
+		t.Error("Expected the vector to be returned as is when not normalizing")
+	}
+
+	got := PrepareVector(v, true)
+	if math.Abs(float64(got[0])-0.6) > 1e-6 || math.Abs(float64(got[1])-0.8) > 1e-6 {
+		t.Errorf("Expected a unit vector, got %v", got)
+	}
+	if v[0] != 3 || v[1] != 4 {
+		t.Errorf("Expected the original vector to be unchanged, got %v", v)
+	}
+
+	zeros := []float32{0, 0}
+	if got := PrepareVector(zeros, true); got[0] != 0 || got[1] != 0 {
+		t.Errorf("Expected a vector of zeros to be unchanged, got %v", got)
+	}
+}
@@ -10,6 +10,7 @@
 package collections
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -17,7 +18,9 @@ import (
 	"time"
 
 	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/external"
 	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem/hnsw"
 	"github.com/hypermodeinc/modus/runtime/collections/in_mem/sequential"
 	"github.com/hypermodeinc/modus/runtime/collections/index"
 	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
@@ -58,21 +61,39 @@ func cleanAndProcessManifest(ctx context.Context) error {
 	return nil
 }
 
-func createIndexObject(searchMethod manifest.SearchMethodInfo, searchMethodName string) (*interfaces.VectorIndexWrapper, error) {
+// createIndexObject creates the vector index of the search method, for the namespace of the collection.
+func createIndexObject(collectionName, namespace string, searchMethod manifest.SearchMethodInfo, searchMethodName string) (*interfaces.VectorIndexWrapper, error) {
+	if err := utils.ValidateDistance(searchMethod.Distance); err != nil {
+		return nil, err
+	}
+
+	distance := searchMethod.GetDistance()
 	vectorIndex := &interfaces.VectorIndexWrapper{}
 	switch searchMethod.Index.Type {
-	case interfaces.SequentialManifestType:
+	case interfaces.SequentialManifestType, "":
 		vectorIndex.Type = sequential.SequentialVectorIndexType
-		vectorIndex.VectorIndex = sequential.NewSequentialVectorIndex(searchMethodName, searchMethod.Embedder)
+		if searchMethod.Quantization.Type == "" {
+			vectorIndex.VectorIndex = sequential.NewSequentialVectorIndex(searchMethodName, searchMethod.Embedder, distance, searchMethod.Normalize)
+			break
+		}
+		vi, err := sequential.NewQuantizedSequentialVectorIndex(searchMethodName, searchMethod.Embedder, distance, searchMethod.Normalize, searchMethod.Quantization)
+		if err != nil {
+			return nil, err
+		}
+		vectorIndex.VectorIndex = vi
 	case interfaces.HnswManifestType:
-		vectorIndex.Type = sequential.SequentialVectorIndexType
-		vectorIndex.VectorIndex = sequential.NewSequentialVectorIndex(searchMethodName, searchMethod.Embedder)
-		// // TODO: hnsw currently broken, auto-sync is not working, it keeps embedding forever, even though it has correctly indexed. for now, set it to sequential. fix in future PR
-		// vectorIndex.Type = hnsw.HnswVectorIndexType
-		// vectorIndex.VectorIndex = hnsw.NewHnswVectorIndex(collectionName, searchMethodName, searchMethod.Embedder)
-	case "":
-		vectorIndex.Type = sequential.SequentialVectorIndexType
-		vectorIndex.VectorIndex = sequential.NewSequentialVectorIndex(searchMethodName, searchMethod.Embedder)
+		if searchMethod.Quantization.Type != "" {
+			return nil, fmt.Errorf("quantization is only supported by %s indexes", interfaces.SequentialManifestType)
+		}
+		vectorIndex.Type = hnsw.HnswVectorIndexType
+		vectorIndex.VectorIndex = hnsw.NewHnswVectorIndex(searchMethodName, searchMethod.Embedder, searchMethod.Index.Options, distance, searchMethod.Normalize)
+	case manifest.IndexTypePgvector, manifest.IndexTypeQdrant, manifest.IndexTypePinecone:
+		vi, err := external.NewExternalVectorIndex(collectionName, namespace, searchMethodName, searchMethod)
+		if err != nil {
+			return nil, err
+		}
+		vectorIndex.Type = external.VectorIndexType(searchMethod.Index.Type)
+		vectorIndex.VectorIndex = vi
 	default:
 		return nil, fmt.Errorf("Unknown index type: %s", searchMethod.Index.Type)
 	}
@@ -80,6 +101,34 @@ func createIndexObject(searchMethod manifest.SearchMethodInfo, searchMethodName
 	return vectorIndex, nil
 }
 
+// vectorIndexMatches reports whether the vector index was created for the index type, distance metric, normalization,
+// and quantization of the search method in the manifest, and for the same vector database if it is external.
+// An index that doesn't match is rebuilt from the vectors in the database.
+func vectorIndexMatches(vi *interfaces.VectorIndexWrapper, searchMethod manifest.SearchMethodInfo) bool {
+	if ext, ok := vi.VectorIndex.(*external.ExternalVectorIndex); ok {
+		info := ext.GetIndexInfo()
+		if info.Host != searchMethod.Index.Host || info.Name != searchMethod.Index.Name {
+			return false
+		}
+	}
+	return vi.Type == getVectorIndexType(searchMethod.Index.Type) &&
+		cmp.Or(vi.GetDistance(), manifest.DistanceCosine) == searchMethod.GetDistance() &&
+		vi.GetNormalize() == searchMethod.Normalize &&
+		vi.GetQuantization() == searchMethod.Quantization
+}
+
+// getVectorIndexType returns the type of the vector index created for the index type in the manifest.
+func getVectorIndexType(manifestType string) string {
+	switch manifestType {
+	case interfaces.HnswManifestType:
+		return hnsw.HnswVectorIndexType
+	case manifest.IndexTypePgvector, manifest.IndexTypeQdrant, manifest.IndexTypePinecone:
+		return external.VectorIndexType(manifestType)
+	default:
+		return sequential.SequentialVectorIndexType
+	}
+}
+
 func deleteIndexesNotInManifest(ctx context.Context, man *manifest.Manifest) {
 	for collectionName := range globalNamespaceManager.getNamespaceCollectionFactoryMap() {
 		if _, ok := man.Collections[collectionName]; !ok {
@@ -140,7 +189,11 @@ func processManifestCollections(ctx context.Context, man *manifest.Manifest) {
 			// forces all users to use in-memory index for now
 			// TODO implement other types of indexes based on manifest info
 			// fetch all tenants and create a collection for each tenant
-			namespaces, err := db.GetUniqueNamespaces(ctx, collectionName)
+			var namespaces []string
+			colCtx, err := collectionContext(ctx, collectionName)
+			if err == nil {
+				namespaces, err = db.GetUniqueNamespaces(colCtx, collectionName)
+			}
 			if err != nil {
 				logger.Err(ctx, err).
 					Str("collection_name", collectionName).
@@ -178,7 +231,7 @@ func processManifestCollections(ctx context.Context, man *manifest.Manifest) {
 								Msg("Failed to set vector index.")
 						}
 					}
-				} else if vi != nil && vi.Type != searchMethod.Index.Type {
+				} else if vi != nil && !vectorIndexMatches(vi, searchMethod) {
 					if err := collNs.DeleteVectorIndex(ctx, searchMethodName); err != nil {
 						logger.Err(ctx, err).
 							Str("index_name", searchMethodName).
@@ -189,18 +242,14 @@ func processManifestCollections(ctx context.Context, man *manifest.Manifest) {
 							logger.Err(ctx, err).
 								Str("index_name", searchMethodName).
 								Msg("Failed to set vector index.")
+						} else if rebuilt, err := collNs.GetVectorIndex(ctx, searchMethodName); err == nil && vi.GetEmbedderName() != searchMethod.Embedder {
+							// the rebuilt index is loaded with the vectors of the previous embedder, until it is re-embedded
+							_ = rebuilt.SetEmbedderName(vi.GetEmbedderName())
+							reembedIfNeeded(ctx, collectionName, searchMethodName, searchMethod, rebuilt)
 						}
 					}
-				} else if vi.GetEmbedderName() != searchMethod.Embedder {
-					//TODO: figure out what to actually do if the embedder is different, for now just updating the name
-					// what if the user changes the internals? -> they want us to reindex, model might have diff dimensions
-					// but what if they just changed the name? -> they want us to just update the name. but we cant know that
-					// imo we should just update the name and let the user reindex if they want to
-					if err := vi.SetEmbedderName(searchMethod.Embedder); err != nil {
-						logger.Err(ctx, err).
-							Str("index_name", searchMethodName).
-							Msg("Failed to update vector index.")
-					}
+				} else if vi != nil {
+					reembedIfNeeded(ctx, collectionName, searchMethodName, searchMethod, vi)
 				}
 			}
 		}
@@ -211,6 +260,10 @@ func processTexts(ctx context.Context, col interfaces.CollectionNamespace, vecto
 	if len(keys) != len(texts) {
 		return fmt.Errorf("mismatch in keys and texts")
 	}
+	texts, err := fieldTexts(ctx, col, vectorIndex.GetSearchMethodName(), keys, texts)
+	if err != nil {
+		return err
+	}
 	for i := 0; i < len(keys); i += batchSize {
 		end := i + batchSize
 		if end > len(keys) {
@@ -251,6 +304,7 @@ func processTexts(ctx context.Context, col interfaces.CollectionNamespace, vecto
 		if err != nil {
 			return err
 		}
+		journalVectors(ctx, col, vectorIndex, textIds, nil, keysBatch, textVecs)
 	}
 	return nil
 }
@@ -273,7 +327,7 @@ func processTextMap(ctx context.Context, col interfaces.CollectionNamespace, vec
 }
 
 func setIndex(ctx context.Context, collNs interfaces.CollectionNamespace, searchMethod manifest.SearchMethodInfo, searchMethodName string) error {
-	vectorIndex, err := createIndexObject(searchMethod, searchMethodName)
+	vectorIndex, err := createIndexObject(collNs.GetCollectionName(), collNs.GetNamespace(), searchMethod, searchMethodName)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/index/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistanceMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	keys := []string{"large", "near", "aligned"}
+	ids := []int64{1, 2, 3}
+	vecs := [][]float32{{10, 10}, {1, 0.1}, {0.5, 0}}
+	query := []float32{1, 0}
+
+	tests := []struct {
+		distance  string
+		normalize bool
+		want      []string
+	}{
+		{"", false, []string{"aligned", "near", "large"}},
+		{manifest.DistanceCosine, false, []string{"aligned", "near", "large"}},
+		{manifest.DistanceDot, false, []string{"large", "near", "aligned"}},
+		{manifest.DistanceDot, true, []string{"aligned", "near", "large"}},
+		{manifest.DistanceEuclidean, false, []string{"near", "aligned", "large"}},
+	}
+
+	for _, indexType := range []string{interfaces.SequentialManifestType, interfaces.HnswManifestType} {
+		for _, tt := range tests {
+			name := indexType + "/" + tt.distance
+			if tt.normalize {
+				name += "/normalized"
+			}
+			t.Run(name, func(t *testing.T) {
+				searchMethod := manifest.SearchMethodInfo{
+					Index:     manifest.IndexInfo{Type: indexType},
+					Distance:  tt.distance,
+					Normalize: tt.normalize,
+				}
+				vi, err := createIndexObject("docs", "", searchMethod, "search")
+				require.NoError(t, err)
+				assert.True(t, vectorIndexMatches(vi, searchMethod))
+				require.NoError(t, vi.InsertVectorsToMemory(ctx, ids, ids, keys, vecs))
+
+				results, err := vi.Search(ctx, query, 3, nil)
+				require.NoError(t, err)
+				got := make([]string, len(results))
+				for i, r := range results {
+					got[i] = r.GetIndex()
+				}
+				assert.Equal(t, tt.want, got)
+			})
+		}
+	}
+}
+
+func TestVectorIndexMatches(t *testing.T) {
+	vi, err := createIndexObject("docs", "", manifest.SearchMethodInfo{}, "search")
+	require.NoError(t, err)
+
+	assert.True(t, vectorIndexMatches(vi, manifest.SearchMethodInfo{Distance: manifest.DistanceCosine}))
+	assert.False(t, vectorIndexMatches(vi, manifest.SearchMethodInfo{Distance: manifest.DistanceDot}))
+	assert.False(t, vectorIndexMatches(vi, manifest.SearchMethodInfo{Normalize: true}))
+	assert.False(t, vectorIndexMatches(vi, manifest.SearchMethodInfo{Index: manifest.IndexInfo{Type: interfaces.HnswManifestType}}))
+
+	_, err = createIndexObject("docs", "", manifest.SearchMethodInfo{Distance: "manhattan"}, "search")
+	assert.ErrorContains(t, err, "unknown distance metric")
+}
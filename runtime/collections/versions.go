@@ -0,0 +1,167 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/db"
+)
+
+// Each item has a version, which starts at 1 and is incremented by every upsert of the item.  The version is kept in
+// the database, which checks it and writes the item in the same transaction, so that a writer that read an item can
+// update or delete it only if no other writer changed it in the meantime.  A writer that loses the race gets a
+// result with a "conflict" status and the current version of the item, and can read the item again and retry.
+
+// The database operations of versioning, which tests replace.
+var (
+	writeTextIfVersion  = db.WriteCollectionTextIfVersion
+	deleteTextIfVersion = db.DeleteCollectionTextIfVersion
+	getTextVersion      = db.GetCollectionTextVersion
+)
+
+// UpsertToCollectionIfVersion upserts an item only if its current version is the expected version, or if it doesn't
+// exist when the expected version is 0.  The result has the new version of the item.
+func UpsertToCollectionIfVersion(ctx context.Context, collectionName, namespace, key, text string, labels []string, metadata string, expectedVersion int64) (*CollectionVersionedMutationResult, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	if expectedVersion < 0 {
+		return nil, fmt.Errorf("expected version must not be negative: %d", expectedVersion)
+	}
+
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace == "" {
+		namespace = in_mem.DefaultNamespace
+	}
+
+	collNs, err := col.findOrCreateNamespace(namespace, in_mem.NewCollectionNamespace(collectionName, namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	metadataMap, err := parseMetadataItem(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	ttls, err := resolveTtls(collectionName, nil, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	id, version, err := writeTextIfVersion(ctx, collectionName, namespace, key, text, labels, metadataMap, expectedVersion)
+	var conflict *db.VersionConflictError
+	if errors.As(err, &conflict) {
+		return NewCollectionVersionedMutationResult(collectionName, "upsert", "conflict", key, conflict.Current, conflict.Error()), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := collNs.InsertTextToMemory(ctx, id, key, text, labels, metadataMap); err != nil {
+		return nil, err
+	}
+
+	ids := []int64{id}
+	keys := []string{key}
+	texts := []string{text}
+	journalTexts(ctx, collNs, ids, keys, texts, [][]string{labels}, []map[string]any{metadataMap})
+
+	if err := setExpiry(ctx, ids, ttls); err != nil {
+		return nil, err
+	}
+
+	if err := embedIntoSearchMethods(ctx, collNs, ids, keys, texts); err != nil {
+		return nil, err
+	}
+
+	return NewCollectionVersionedMutationResult(collectionName, "upsert", "success", key, version, ""), nil
+}
+
+// DeleteFromCollectionIfVersion deletes an item only if its current version is the expected version.
+func DeleteFromCollectionIfVersion(ctx context.Context, collectionName, namespace, key string, expectedVersion int64) (*CollectionVersionedMutationResult, error) {
+	if expectedVersion < 0 {
+		return nil, fmt.Errorf("expected version must not be negative: %d", expectedVersion)
+	}
+
+	col, err := globalNamespaceManager.findCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace == "" {
+		namespace = in_mem.DefaultNamespace
+	}
+
+	collNs, err := col.findNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// the vectors are deleted along with the text
+	err = deleteTextIfVersion(ctx, collectionName, namespace, key, expectedVersion)
+	var conflict *db.VersionConflictError
+	if errors.As(err, &conflict) {
+		return NewCollectionVersionedMutationResult(collectionName, "delete", "conflict", key, conflict.Current, conflict.Error()), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, vectorIndex := range collNs.GetVectorIndexMap() {
+		if err := vectorIndex.DeleteVectorFromMemory(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	if err := collNs.DeleteTextFromMemory(ctx, key); err != nil {
+		return nil, err
+	}
+	journalDelete(ctx, collNs, key)
+
+	return NewCollectionVersionedMutationResult(collectionName, "delete", "success", key, 0, ""), nil
+}
+
+// GetVersionFromCollection returns the current version of an item, which is 0 if it doesn't exist.
+func GetVersionFromCollection(ctx context.Context, collectionName, namespace, key string) (*CollectionItemVersion, error) {
+	if _, err := globalNamespaceManager.findCollection(collectionName); err != nil {
+		return nil, err
+	}
+
+	ctx, err := collectionContext(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace == "" {
+		namespace = in_mem.DefaultNamespace
+	}
+
+	version, err := getTextVersion(ctx, collectionName, namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	return &CollectionItemVersion{Key: key, Version: version}, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package collections
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/collections/in_mem"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupVersionsTest replaces the database with a map of the versions of the items, checked the way the database does.
+func setupVersionsTest(t *testing.T) (collNs *in_mem.InMemCollectionNamespace, versions map[string]int64) {
+	manifestdata.SetManifest(&manifest.Manifest{
+		Collections: map[string]manifest.CollectionInfo{"docs": {}},
+	})
+
+	cf := newCollectionFactory()
+	col, err := cf.createCollection("docs", newCollection())
+	require.NoError(t, err)
+	collNs = in_mem.NewCollectionNamespace("docs", in_mem.DefaultNamespace)
+	_, err = col.createCollectionNamespace(in_mem.DefaultNamespace, collNs)
+	require.NoError(t, err)
+
+	versions = map[string]int64{}
+	var lastId int64
+	check := func(key string, expected int64) error {
+		if expected != db.AnyVersion && expected != versions[key] {
+			return &db.VersionConflictError{Key: key, Expected: expected, Current: versions[key]}
+		}
+		return nil
+	}
+	writeTextIfVersion = func(ctx context.Context, collectionName, namespace, key, text string, labels []string, metadata map[string]any, expectedVersion int64) (int64, int64, error) {
+		if err := check(key, expectedVersion); err != nil {
+			return 0, 0, err
+		}
+		lastId++
+		versions[key]++
+		return lastId, versions[key], nil
+	}
+	deleteTextIfVersion = func(ctx context.Context, collectionName, namespace, key string, expectedVersion int64) error {
+		if err := check(key, expectedVersion); err != nil {
+			return err
+		}
+		delete(versions, key)
+		return nil
+	}
+	getTextVersion = func(ctx context.Context, collectionName, namespace, key string) (int64, error) {
+		return versions[key], nil
+	}
+
+	previous := globalNamespaceManager
+	globalNamespaceManager = cf
+	t.Cleanup(func() {
+		globalNamespaceManager = previous
+		writeTextIfVersion = db.WriteCollectionTextIfVersion
+		deleteTextIfVersion = db.DeleteCollectionTextIfVersion
+		getTextVersion = db.GetCollectionTextVersion
+	})
+	return collNs, versions
+}
+
+func TestUpsertToCollectionIfVersion(t *testing.T) {
+	ctx := context.Background()
+	collNs, _ := setupVersionsTest(t)
+
+	result, err := UpsertToCollectionIfVersion(ctx, "docs", "", "a", "first", nil, `{"n":1}`, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "success", result.Status)
+	assert.Equal(t, int64(1), result.Version)
+
+	// creating the item again conflicts, since it already exists
+	result, err = UpsertToCollectionIfVersion(ctx, "docs", "", "a", "again", nil, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "conflict", result.Status)
+	assert.Equal(t, int64(1), result.Version)
+	assert.Contains(t, result.Error, "expected no item")
+
+	result, err = UpsertToCollectionIfVersion(ctx, "docs", "", "a", "second", nil, "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "success", result.Status)
+	assert.Equal(t, int64(2), result.Version)
+
+	// a writer that read version 1 doesn't overwrite the second text
+	result, err = UpsertToCollectionIfVersion(ctx, "docs", "", "a", "stale", nil, "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "conflict", result.Status)
+	assert.Equal(t, int64(2), result.Version)
+
+	text, err := collNs.GetText(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "second", text)
+
+	version, err := GetVersionFromCollection(ctx, "docs", "", "a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version.Version)
+
+	_, err = UpsertToCollectionIfVersion(ctx, "docs", "", "", "text", nil, "", 0)
+	assert.Error(t, err)
+	_, err = UpsertToCollectionIfVersion(ctx, "docs", "", "a", "text", nil, "", -1)
+	assert.Error(t, err)
+	_, err = UpsertToCollectionIfVersion(ctx, "missing", "", "a", "text", nil, "", 0)
+	assert.ErrorIs(t, err, errCollectionNotFound)
+}
+
+func TestDeleteFromCollectionIfVersion(t *testing.T) {
+	ctx := context.Background()
+	collNs, versions := setupVersionsTest(t)
+
+	_, err := UpsertToCollectionIfVersion(ctx, "docs", "", "a", "first", nil, "", 0)
+	require.NoError(t, err)
+	_, err = UpsertToCollectionIfVersion(ctx, "docs", "", "a", "second", nil, "", 1)
+	require.NoError(t, err)
+
+	result, err := DeleteFromCollectionIfVersion(ctx, "docs", "", "a", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "conflict", result.Status)
+	assert.Equal(t, int64(2), result.Version)
+	text, err := collNs.GetText(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "second", text, "the item isn't deleted on a conflict")
+
+	result, err = DeleteFromCollectionIfVersion(ctx, "docs", "", "a", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "success", result.Status)
+	assert.Equal(t, int64(0), result.Version)
+	assert.NotContains(t, versions, "a")
+
+	ids, err := collNs.GetIdMap(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, ids, "a")
+
+	result, err = DeleteFromCollectionIfVersion(ctx, "docs", "", "a", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "conflict", result.Status)
+	assert.Contains(t, result.Error, "doesn't exist")
+}
@@ -25,8 +25,37 @@ var UseAwsSecrets bool
 var UseAwsStorage bool
 var S3Bucket string
 var S3Path string
+var S3EventQueue string
+var OciReference string
 var RefreshInterval time.Duration
+var DrainTimeout time.Duration
+var HookTimeout time.Duration
+var InitFailurePolicy string
 var UseJsonLogging bool
+var PluginCacheSize int
+var PluginHistorySize int
+var PluginSigningKeys string
+var WarmFunctions string
+var StandbyOf string
+var FailoverThreshold int
+var SmokeFunctions string
+var ModelFixturesPath string
+var ModelFixtureMode string
+var CollectionsPath string
+var MaxQueryDepth int
+var MaxQueryAliases int
+var MaxQueryCost int
+var RequestConcurrency int
+var MaxBatchSize int
+var BatchConcurrency int
+var EnableRestApi bool
+var CorsOrigins string
+var CorsHeaders string
+var MaxRequestSize int
+var ReadTimeout time.Duration
+var WriteTimeout time.Duration
+var EnableCompression bool
+var StrictSchema bool
 
 func parseCommandLineFlags() {
 	flag.IntVar(&Port, "port", 8686, "The HTTP port to listen on.")
@@ -36,8 +65,37 @@ func parseCommandLineFlags() {
 	flag.BoolVar(&UseAwsStorage, "useAwsStorage", false, "Use AWS S3 for storage instead of the local filesystem.")
 	flag.StringVar(&S3Bucket, "s3bucket", "", "The S3 bucket to use, if using AWS storage.")
 	flag.StringVar(&S3Path, "s3path", "", "The path within the S3 bucket to use, if using AWS storage.")
+	flag.StringVar(&S3EventQueue, "s3eventQueue", "", "The URL of an SQS queue that receives the event notifications of the S3 bucket, directly or through EventBridge or SNS.  If set, changes to files are picked up as they are notified, as well as on the refresh interval.")
+	flag.StringVar(&OciReference, "ociReference", "", "A reference to an OCI artifact to use for storage instead of S3 or the local filesystem, such as ghcr.io/my-org/my-app:v1.  Each layer is a file, named by its title annotation, as pushed by oras.  A tag is checked for changes on the refresh interval, and a digest (@sha256:...) pins the exact content.  Credentials are read from MODUS_OCI_USERNAME and MODUS_OCI_PASSWORD, or from the Docker configuration.")
 	flag.DurationVar(&RefreshInterval, "refresh", time.Second*5, "The refresh interval to reload any changes.")
+	flag.DurationVar(&DrainTimeout, "drainTimeout", time.Second*20, "The maximum time to wait for function calls in progress to complete, when the runtime shuts down or a plugin is replaced or unloaded.")
+	flag.DurationVar(&HookTimeout, "hookTimeout", time.Second*10, "The maximum time for the modus_init or modus_shutdown lifecycle hook of a plugin to run.")
+	flag.StringVar(&InitFailurePolicy, "initFailure", "reject", "Either \"reject\", which refuses to load a plugin whose modus_init hook fails or times out, keeping the version it would have replaced, or \"ignore\", which loads it regardless.")
 	flag.BoolVar(&UseJsonLogging, "jsonlogs", false, "Use JSON format for logging.")
+	flag.IntVar(&PluginCacheSize, "cachesize", 64, "The maximum size, in megabytes, of the in-memory cache available to each plugin.")
+	flag.IntVar(&PluginHistorySize, "pluginHistory", 3, "The number of previous versions of each plugin that are kept compiled, so that they can be rolled back to instantly.")
+	flag.StringVar(&PluginSigningKeys, "pluginSigningKeys", "", "A comma-separated list of paths to PEM-encoded Ed25519 or ECDSA public keys, such as cosign keys.  If set, a plugin is only loaded if its .wasm.sig signature file, stored next to it, was made by one of the keys.")
+	flag.StringVar(&WarmFunctions, "warmFunctions", "", "A comma-separated list of functions to prepare when a plugin is loaded, rather than on their first call, so that they respond quickly from the start.  \"*\" prepares every function.")
+	flag.StringVar(&StandbyOf, "standbyOf", "", "The URL of an active runtime.  If set, this runtime runs as its warm standby.")
+	flag.StringVar(&SmokeFunctions, "smoke", "", "A comma-separated list of functions without parameters to run each time the plugin is reloaded, in development.")
+	flag.StringVar(&ModelFixturesPath, "modelFixtures", "", "The path to a directory of recorded model responses.  If set, model invocations are recorded to and replayed from it.")
+	flag.StringVar(&ModelFixtureMode, "modelFixtureMode", "auto", "Either \"record\", \"replay\" or \"auto\", which replays recorded model responses and records any that are missing.")
+	flag.StringVar(&CollectionsPath, "collectionsPath", "", "The path to a directory where collections are persisted, so they reload quickly after a restart.  Defaults to a \"collections\" directory within the local storage path, when not using AWS storage.")
+	flag.IntVar(&MaxQueryDepth, "maxQueryDepth", 15, "The maximum depth of the fields of a GraphQL query.  Zero disables the limit.")
+	flag.IntVar(&MaxQueryAliases, "maxQueryAliases", 30, "The maximum number of aliased fields in a GraphQL query.  Zero disables the limit.")
+	flag.IntVar(&MaxQueryCost, "maxQueryCost", 5000, "The maximum estimated cost of a GraphQL query, which counts each field once for every item of the lists that enclose it.  Zero disables the limit.")
+	flag.IntVar(&RequestConcurrency, "requestConcurrency", 8, "The maximum number of functions that a single GraphQL request invokes concurrently.")
+	flag.IntVar(&MaxBatchSize, "maxBatchSize", 20, "The maximum number of GraphQL operations in a batched request.  Zero disables batching.")
+	flag.IntVar(&BatchConcurrency, "batchConcurrency", 4, "The maximum number of operations of a batched GraphQL request that are executed concurrently.")
+	flag.BoolVar(&EnableRestApi, "restApi", false, "Also serve each function as a REST endpoint, at POST /api/v1/{function}, with JSON arguments and results, and each streaming function as server-sent events, at /api/v1/stream/{function}.  When trusted documents are required, only the functions that they use are served.")
+	flag.StringVar(&CorsOrigins, "corsOrigins", "*", "A comma-separated list of the origins that browsers may call the runtime from.  Origins may contain a \"*\" wildcard.")
+	flag.StringVar(&CorsHeaders, "corsHeaders", "Authorization,Content-Type,X-Api-Key,X-Modus-Client,X-Modus-Canary", "A comma-separated list of the headers that browsers may send in cross-origin requests.")
+	flag.IntVar(&MaxRequestSize, "maxRequestSize", 0, "The maximum size, in megabytes, of the body of an HTTP request.  Zero disables the limit.")
+	flag.DurationVar(&ReadTimeout, "readTimeout", 0, "The maximum time to read an HTTP request, including its body.  Zero disables the timeout.")
+	flag.DurationVar(&WriteTimeout, "writeTimeout", 0, "The maximum time to write an HTTP response, from the end of reading the request.  Zero disables the timeout.  Note that it also ends streamed responses.")
+	flag.BoolVar(&EnableCompression, "compress", false, "Compress HTTP responses with gzip, for clients that accept it.")
+	flag.BoolVar(&StrictSchema, "strictSchema", false, "Refuse to load a plugin, or a manifest change, whose GraphQL schema has breaking changes from the schema being served, such as removed fields or changed types.")
+	flag.IntVar(&FailoverThreshold, "failoverThreshold", 3, "The number of consecutive failed health checks of the active runtime before a standby promotes itself.")
 
 	var showVersion bool
 	const versionUsage = "Show the Runtime version number and exit."
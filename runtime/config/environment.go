@@ -51,6 +51,15 @@ func IsDevEnvironment() bool {
 	return environment == "dev" || environment == "development"
 }
 
+// GetRegion returns the deployment region, from the MODUS_REGION or AWS_REGION environment variables.
+// It returns an empty string if the region is not known.
+func GetRegion() string {
+	if region := os.Getenv("MODUS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_REGION")
+}
+
 func GetNamespace() string {
 	return namespace
 }
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/hosts"
+	"github.com/hypermodeinc/modus/runtime/httpclient"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+var placeholderRegex = regexp.MustCompile(`{{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*}}`)
+
+// GetConnector returns the connector with the given name from the manifest, if there is one.
+func GetConnector(name string) (*manifest.ConnectorInfo, bool) {
+	c, ok := manifestdata.GetManifest().Connectors[name]
+	if !ok {
+		return nil, false
+	}
+	return &c, true
+}
+
+// Invoke calls the connector's HTTP endpoint with the given arguments,
+// and maps the JSON response to the connector's result.
+func Invoke(ctx context.Context, connector *manifest.ConnectorInfo, args map[string]any) (any, error) {
+	host, err := hosts.GetHttpHost(connector.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	reqUrl, err := buildUrl(host, connector.Path, args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url for connector %s: %w", connector.Name, err)
+	}
+
+	method := connector.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	request := &httpclient.HttpRequest{
+		Url:     reqUrl,
+		Method:  method,
+		Headers: &httpclient.HttpHeaders{Data: map[string]*httpclient.HttpHeader{}},
+	}
+
+	if connector.Body != "" {
+		body, err := expandBody(connector.Body, args)
+		if err != nil {
+			return nil, err
+		}
+		request.Body = []byte(body)
+		request.Headers.Data["content-type"] = &httpclient.HttpHeader{
+			Name:   "Content-Type",
+			Values: []string{"application/json"},
+		}
+	}
+
+	response, err := httpclient.HttpFetchFromHost(ctx, host, request)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		if response.Status == 0 {
+			return nil, fmt.Errorf("request for connector %s failed: %s", connector.Name, response.Error.Message)
+		}
+		return nil, fmt.Errorf("request for connector %s failed with status %d %s", connector.Name, response.Status, response.StatusText)
+	}
+
+	return mapResponse(connector, response.Body)
+}
+
+func buildUrl(host *manifest.HTTPHostInfo, path string, args map[string]any) (string, error) {
+	base := host.BaseURL
+	if base == "" {
+		base = host.Endpoint
+	}
+	if base == "" {
+		return "", fmt.Errorf("host %s does not have a base url or endpoint", host.Name)
+	}
+
+	// Escape arguments differently, depending on whether they're in the path or the query string.
+	pathPart, queryPart, hasQuery := strings.Cut(path, "?")
+	pathPart = expandTemplate(pathPart, args, escapePathArg)
+	if hasQuery {
+		pathPart += "?" + expandTemplate(queryPart, args, url.QueryEscape)
+	}
+
+	if pathPart == "" {
+		return base, nil
+	}
+
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(baseUrl.Path, "/") {
+		baseUrl.Path += "/"
+	}
+
+	u, err := baseUrl.Parse(strings.TrimPrefix(pathPart, "/"))
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// escapePathArg escapes an argument that is used in the path.  Arguments of "." or ".." are percent-encoded,
+// so that they aren't resolved as dot segments, which would let them reach paths outside of the base url.
+func escapePathArg(s string) string {
+	if s == "." || s == ".." {
+		return strings.ReplaceAll(s, ".", "%2E")
+	}
+	return url.PathEscape(s)
+}
+
+func expandTemplate(template string, args map[string]any, escape func(string) string) string {
+	return placeholderRegex.ReplaceAllStringFunc(template, func(match string) string {
+		name := placeholderRegex.FindStringSubmatch(match)[1]
+		v, ok := args[name]
+		if !ok || v == nil {
+			return ""
+		}
+		return escape(fmt.Sprint(v))
+	})
+}
+
+func expandBody(template string, args map[string]any) (string, error) {
+	var expandErr error
+	result := placeholderRegex.ReplaceAllStringFunc(template, func(match string) string {
+		name := placeholderRegex.FindStringSubmatch(match)[1]
+		b, err := utils.JsonSerialize(args[name])
+		if err != nil {
+			expandErr = err
+			return ""
+		}
+		return string(b)
+	})
+	return result, expandErr
+}
+
+func mapResponse(connector *manifest.ConnectorInfo, body []byte) (any, error) {
+	var data gjson.Result
+	if connector.Select == "" {
+		data = gjson.ParseBytes(body)
+	} else {
+		data = gjson.GetBytes(body, connector.Select)
+	}
+
+	if len(connector.Fields) > 0 {
+		if !data.Exists() || data.Type == gjson.Null {
+			return nil, nil
+		}
+		return mapFields(connector, data), nil
+	}
+
+	if !data.Exists() {
+		return nil, nil
+	}
+
+	// With no declared return type, the result is a string.
+	if connector.ReturnType == "" {
+		if connector.Select == "" {
+			return string(body), nil
+		}
+		return data.String(), nil
+	}
+
+	return data.Value(), nil
+}
+
+func mapFields(connector *manifest.ConnectorInfo, data gjson.Result) map[string]any {
+	result := make(map[string]any, len(connector.Fields))
+	for name, field := range connector.Fields {
+		result[name] = data.Get(field.Select).Value()
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package connectors_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/connectors"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/quote", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"quote":{"symbol":%q,"price":12.5}}`, r.URL.Query().Get("symbol"))
+	})
+	mux.HandleFunc("/v1/users/lookup", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, `{"request":%s,"profile":{"displayName":"Sam","tags":["a","b"]}}`, body)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	secrets.Initialize(context.Background())
+	manifestdata.SetManifest(&manifest.Manifest{
+		Hosts: map[string]manifest.HostInfo{
+			"api": manifest.HTTPHostInfo{Name: "api", BaseURL: server.URL + "/v1/"},
+		},
+		Connectors: map[string]manifest.ConnectorInfo{
+			"stockPrice": {
+				Name:       "stockPrice",
+				Host:       "api",
+				Path:       "quote?symbol={{symbol}}",
+				Arguments:  map[string]string{"symbol": "String!"},
+				ReturnType: "Float",
+				Select:     "quote.price",
+			},
+			"stockSymbol": {
+				Name:      "stockSymbol",
+				Host:      "api",
+				Path:      "quote?symbol={{symbol}}",
+				Arguments: map[string]string{"symbol": "String!"},
+				Select:    "quote.symbol",
+			},
+			"userProfile": {
+				Name:      "userProfile",
+				Host:      "api",
+				Method:    "POST",
+				Path:      "users/lookup",
+				Body:      `{"id": {{id}}}`,
+				Arguments: map[string]string{"id": "Int!"},
+				Fields: map[string]manifest.ConnectorFieldInfo{
+					"name": {Type: "String!", Select: "profile.displayName"},
+					"tags": {Type: "[String!]", Select: "profile.tags"},
+					"id":   {Type: "Int", Select: "request.id"},
+				},
+			},
+		},
+	})
+}
+
+func Test_Invoke(t *testing.T) {
+	setup(t)
+	ctx := context.Background()
+
+	c, ok := connectors.GetConnector("stockPrice")
+	require.True(t, ok)
+	result, err := connectors.Invoke(ctx, c, map[string]any{"symbol": "A&B"})
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, result)
+
+	c, _ = connectors.GetConnector("stockSymbol")
+	result, err = connectors.Invoke(ctx, c, map[string]any{"symbol": "A&B"})
+	require.NoError(t, err)
+	assert.Equal(t, "A&B", result)
+
+	c, _ = connectors.GetConnector("userProfile")
+	result, err = connectors.Invoke(ctx, c, map[string]any{"id": 42})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name": "Sam",
+		"tags": []any{"a", "b"},
+		"id":   float64(42),
+	}, result)
+
+	_, ok = connectors.GetConnector("missing")
+	assert.False(t, ok)
+}
+
+func Test_Invoke_DotSegments(t *testing.T) {
+	var requestUri string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestUri = r.RequestURI
+		fmt.Fprint(w, `{"id":"x"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	secrets.Initialize(context.Background())
+	manifestdata.SetManifest(&manifest.Manifest{
+		Hosts: map[string]manifest.HostInfo{
+			"api": manifest.HTTPHostInfo{Name: "api", BaseURL: server.URL + "/v1/"},
+		},
+		Connectors: map[string]manifest.ConnectorInfo{
+			"item": {
+				Name:      "item",
+				Host:      "api",
+				Path:      "items/{{id}}",
+				Arguments: map[string]string{"id": "String!"},
+				Select:    "id",
+			},
+		},
+	})
+
+	// Dot segments in arguments must not reach paths outside of the base url.
+	c, _ := connectors.GetConnector("item")
+	tests := map[string]string{
+		"..":    "/v1/items/%2E%2E",
+		".":     "/v1/items/%2E",
+		"../x":  "/v1/items/..%2Fx",
+		"a..b":  "/v1/items/a..b",
+		"plain": "/v1/items/plain",
+	}
+	for id, expected := range tests {
+		_, err := connectors.Invoke(context.Background(), c, map[string]any{"id": id})
+		require.NoError(t, err)
+		assert.Equal(t, expected, requestUri, "id: %s", id)
+	}
+}
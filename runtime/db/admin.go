@@ -0,0 +1,99 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package db
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/google/uuid"
+)
+
+const defaultInferencesLimit = 100
+const maxInferencesLimit = 1000
+
+type inferencesResponse struct {
+	Inferences []*InferenceRecord `json:"inferences"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
+
+// InferencesHandler lists the inference history, newest first (GET).  The results can be filtered by the "model",
+// "function", "plugin" and "pluginVersion" query parameters, and by a time range with the "since" and "until"
+// parameters, in RFC 3339 format.  Pass the "nextCursor" of a response as the "cursor" parameter to get the next page.
+func InferencesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	q := &InferenceQuery{
+		Model:         params.Get("model"),
+		Function:      params.Get("function"),
+		Plugin:        params.Get("plugin"),
+		PluginVersion: params.Get("pluginVersion"),
+		Cursor:        params.Get("cursor"),
+		Limit:         defaultInferencesLimit,
+	}
+
+	if s := params.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit.", http.StatusBadRequest)
+			return
+		}
+		q.Limit = min(n, maxInferencesLimit)
+	}
+
+	for name, t := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if s := params.Get(name); s != "" {
+			v, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				http.Error(w, "Invalid "+name+" time.", http.StatusBadRequest)
+				return
+			}
+			*t = v
+		}
+	}
+
+	if q.Cursor != "" {
+		if _, err := uuid.Parse(q.Cursor); err != nil {
+			http.Error(w, "Invalid cursor.", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Fetch one more record than requested, to know if there is another page.
+	limit := q.Limit
+	q.Limit++
+	records, err := QueryInferences(ctx, q)
+	if err != nil {
+		logger.Err(ctx, err).Msg("Failed to retrieve inference history.")
+		http.Error(w, "Failed to retrieve inference history.", http.StatusInternalServerError)
+		return
+	}
+
+	resp := inferencesResponse{Inferences: records}
+	if len(records) > limit {
+		resp.Inferences = records[:limit]
+		resp.NextCursor = records[limit-1].Id
+	}
+	if resp.Inferences == nil {
+		resp.Inferences = []*InferenceRecord{}
+	}
+
+	utils.WriteJsonResponse(w, resp)
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// A collection can be stored in a PostgreSQL host of the manifest, instead of the runtime's database.  The collections
+// package puts the pool of the host in the context of the collection's operations, and the functions of the collection
+// tables run their transactions on it.  The tables are created in the host's database when it is first used, with the
+// schema of the runtime's tables, except that vectors are stored with the pgvector extension, so they can be queried
+// there too.  Only the functions of the collection tables use the pool, so functions called with the same context,
+// such as embedders, still write jobs and inference history to the runtime's database.
+
+type collectionPoolKey struct{}
+
+// WithCollectionPool returns a context in which the functions of the collection tables use the pool, such as the pool
+// of the PostgreSQL host that stores a collection.  A nil pool uses the runtime's database.
+func WithCollectionPool(ctx context.Context, pool *pgxpool.Pool) context.Context {
+	return context.WithValue(ctx, collectionPoolKey{}, pool)
+}
+
+// withCollectionTx is WithTx for the collection tables, in the database of the context.
+func withCollectionTx(ctx context.Context, fn func(pgx.Tx) error) error {
+	span, ctx := utils.NewSentrySpanForCallingFunc(ctx)
+	defer span.Finish()
+
+	var tx pgx.Tx
+	var err error
+	if pool, _ := ctx.Value(collectionPoolKey{}).(*pgxpool.Pool); pool != nil {
+		tx, err = pool.Begin(ctx)
+	} else {
+		tx, err = GetTx(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	return runTx(ctx, tx, fn)
+}
+
+// collectionTablesSchema creates the collection tables, as they are after the migrations of the runtime's database.
+var collectionTablesSchema = []string{
+	"CREATE EXTENSION IF NOT EXISTS vector",
+	fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id BIGSERIAL PRIMARY KEY,
+		collection TEXT NOT NULL,
+		namespace TEXT NOT NULL DEFAULT '',
+		key TEXT NOT NULL,
+		text TEXT NOT NULL,
+		labels TEXT[],
+		metadata JSONB,
+		version BIGINT NOT NULL DEFAULT 1,
+		expires_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`, collectionTextsTable),
+	fmt.Sprintf("CREATE INDEX IF NOT EXISTS collection_texts_collection_key_idx ON %s (collection, key)", collectionTextsTable),
+	fmt.Sprintf("CREATE INDEX IF NOT EXISTS collection_texts_expires_at_idx ON %s (expires_at) WHERE expires_at IS NOT NULL", collectionTextsTable),
+	fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id BIGSERIAL PRIMARY KEY,
+		text_id BIGINT REFERENCES %s(id) ON DELETE CASCADE,
+		search_method TEXT NOT NULL,
+		vector vector NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`, collectionVectorsTable, collectionTextsTable),
+	fmt.Sprintf("CREATE INDEX IF NOT EXISTS collection_vectors_search_method_text_id_idx ON %s (search_method, text_id)", collectionVectorsTable),
+}
+
+// preparedCollectionPools holds the pools whose database has the collection tables.
+var preparedCollectionPools sync.Map
+
+// PrepareCollectionTables creates the collection tables in the database of the pool, if they don't exist.
+// It only does so once for each pool.
+func PrepareCollectionTables(ctx context.Context, pool *pgxpool.Pool) error {
+	if _, ok := preparedCollectionPools.Load(pool); ok {
+		return nil
+	}
+
+	for _, stmt := range collectionTablesSchema {
+		if _, err := pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create the collection tables: %w", err)
+		}
+	}
+
+	preparedCollectionPools.Store(pool, struct{}{})
+	return nil
+}
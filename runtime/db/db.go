@@ -56,13 +56,15 @@ type runtimePostgresWriter struct {
 }
 
 type inferenceHistory struct {
-	model    *manifest.ModelInfo
-	input    any
-	output   any
-	start    time.Time
-	end      time.Time
-	pluginId *string
-	function *string
+	model         *manifest.ModelInfo
+	input         any
+	output        any
+	start         time.Time
+	end           time.Time
+	pluginId      *string
+	pluginName    string
+	pluginVersion string
+	function      *string
 }
 
 func (w *runtimePostgresWriter) GetPool(ctx context.Context) (*pgxpool.Pool, error) {
@@ -119,7 +121,7 @@ func (w *runtimePostgresWriter) worker(ctx context.Context) {
 			batch[batchIndex] = data
 			batchIndex++
 			if batchIndex == batchSize {
-				WriteInferenceHistoryToDB(ctx, batch[:batchSize])
+				writeInferenceHistory(ctx, batch[:batchSize])
 				batchIndex = 0
 
 				// we need to drain the timer channel to prevent the timer from firing
@@ -129,11 +131,11 @@ func (w *runtimePostgresWriter) worker(ctx context.Context) {
 				timer.Reset(inferenceRefresherInterval)
 			}
 		case <-timer.C:
-			WriteInferenceHistoryToDB(ctx, batch[:batchIndex])
+			writeInferenceHistory(ctx, batch[:batchIndex])
 			batchIndex = 0
 			timer.Reset(inferenceRefresherInterval)
 		case <-w.quit:
-			WriteInferenceHistoryToDB(ctx, batch[:batchIndex])
+			writeInferenceHistory(ctx, batch[:batchIndex])
 			close(w.done)
 			return
 		}
@@ -266,8 +268,10 @@ func getPluginId(ctx context.Context, tx pgx.Tx, buildId string) (string, error)
 
 func WriteInferenceHistory(ctx context.Context, model *manifest.ModelInfo, input, output any, start, end time.Time) {
 	var pluginId *string
+	var pluginName, pluginVersion string
 	if plugin, ok := plugins.GetPluginFromContext(ctx); ok {
 		pluginId = &plugin.Id
+		pluginName, pluginVersion = plugin.Metadata.NameAndVersion()
 	}
 
 	var function *string
@@ -276,19 +280,21 @@ func WriteInferenceHistory(ctx context.Context, model *manifest.ModelInfo, input
 	}
 
 	globalRuntimePostgresWriter.Write(inferenceHistory{
-		model:    model,
-		input:    input,
-		output:   output,
-		start:    start,
-		end:      end,
-		pluginId: pluginId,
-		function: function,
+		model:         model,
+		input:         input,
+		output:        output,
+		start:         start,
+		end:           end,
+		pluginId:      pluginId,
+		pluginName:    pluginName,
+		pluginVersion: pluginVersion,
+		function:      function,
 	})
 }
 
 func GetUniqueNamespaces(ctx context.Context, collectionName string) ([]string, error) {
 	var namespaces []string
-	err := WithTx(ctx, func(tx pgx.Tx) error {
+	err := withCollectionTx(ctx, func(tx pgx.Tx) error {
 		query := fmt.Sprintf("SELECT DISTINCT namespace FROM %s WHERE collection = $1", collectionTextsTable)
 		rows, err := tx.Query(ctx, query, collectionName)
 		if err != nil {
@@ -316,28 +322,115 @@ func GetUniqueNamespaces(ctx context.Context, collectionName string) ([]string,
 	return namespaces, nil
 }
 
-func WriteCollectionTexts(ctx context.Context, collectionName, namespace string, keys, texts []string, labelsArr [][]string) ([]int64, error) {
+// AnyVersion is the expected version of a write that doesn't check the version of the item.
+const AnyVersion int64 = -1
+
+// VersionConflictError is returned by a write that expected an item to have a version other than its current one.
+// A version of 0 means that the item doesn't exist.
+type VersionConflictError struct {
+	Key      string
+	Expected int64
+	Current  int64
+}
+
+func (e *VersionConflictError) Error() string {
+	switch {
+	case e.Expected == 0:
+		return fmt.Sprintf("version conflict for key %s: expected no item, but it has version %d", e.Key, e.Current)
+	case e.Current == 0:
+		return fmt.Sprintf("version conflict for key %s: expected version %d, but the item doesn't exist", e.Key, e.Expected)
+	default:
+		return fmt.Sprintf("version conflict for key %s: expected version %d, but the current version is %d", e.Key, e.Expected, e.Current)
+	}
+}
+
+func checkVersion(key string, expected, current int64) error {
+	if expected != AnyVersion && expected != current {
+		return &VersionConflictError{Key: key, Expected: expected, Current: current}
+	}
+	return nil
+}
+
+// lockCollectionKeys locks the keys of the namespace until the transaction ends, so that concurrent writes of
+// the same item take turns instead of overwriting each other.  The keys are locked in order, to avoid deadlocks.
+func lockCollectionKeys(ctx context.Context, tx pgx.Tx, collectionName, namespace string, keys []string) error {
+	query := `SELECT pg_advisory_xact_lock(hashtextextended($1 || chr(0) || $2 || chr(0) || k, 0))
+		FROM (SELECT DISTINCT unnest($3::text[]) AS k ORDER BY k) AS keys`
+	_, err := tx.Exec(ctx, query, collectionName, namespace, keys)
+	return err
+}
+
+// deleteCollectionTextVersions deletes the rows of the keys, returning the version of each key that existed.
+func deleteCollectionTextVersions(ctx context.Context, tx pgx.Tx, collectionName, namespace string, keys []string) (map[string]int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE collection = $1 AND namespace = $2 AND key = ANY($3) RETURNING key, version", collectionTextsTable)
+	rows, err := tx.Query(ctx, query, collectionName, namespace, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[string]int64, len(keys))
+	for rows.Next() {
+		var key string
+		var version int64
+		if err := rows.Scan(&key, &version); err != nil {
+			return nil, err
+		}
+		versions[key] = max(versions[key], version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetCollectionTextVersion returns the version of the item, or 0 if it doesn't exist.
+// Each write of an item increments its version, starting from 1.
+func GetCollectionTextVersion(ctx context.Context, collectionName, namespace, key string) (version int64, err error) {
+	err = withCollectionTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s WHERE collection = $1 AND namespace = $2 AND key = $3", collectionTextsTable)
+		return tx.QueryRow(ctx, query, collectionName, namespace, key).Scan(&version)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func WriteCollectionTexts(ctx context.Context, collectionName, namespace string, keys, texts []string, labelsArr [][]string, metadataArr []map[string]any) ([]int64, error) {
 	if len(labelsArr) != 0 && len(keys) != len(labelsArr) {
 		return nil, errors.New("if labels is not empty, it must have the same length as keys")
 	}
 
+	if len(metadataArr) != 0 && len(keys) != len(metadataArr) {
+		return nil, errors.New("if metadata is not empty, it must have the same length as keys")
+	}
+
 	if len(keys) != len(texts) {
 		return nil, errors.New("keys and texts must have the same length")
 	}
 
 	ids := make([]int64, len(keys))
-	err := WithTx(ctx, func(tx pgx.Tx) error {
-		// Delete any existing rows that match the collectionName and keys
-		deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE collection = $1 AND namespace = $2 AND key = ANY($3)", collectionTextsTable)
-		_, err := tx.Exec(ctx, deleteQuery, collectionName, namespace, keys)
+	err := withCollectionTx(ctx, func(tx pgx.Tx) error {
+		if err := lockCollectionKeys(ctx, tx, collectionName, namespace, keys); err != nil {
+			return err
+		}
+
+		// Delete any existing rows that match the collectionName and keys, and increment their versions
+		versions, err := deleteCollectionTextVersions(ctx, tx, collectionName, namespace, keys)
 		if err != nil {
 			return err
 		}
+		nextVersions := make([]int64, len(keys))
+		for i, key := range keys {
+			nextVersions[i] = versions[key] + 1
+		}
 
 		// Insert the new rows
-		if len(labelsArr) == 0 {
-			query := fmt.Sprintf("INSERT INTO %s (collection, namespace, key, text) VALUES ($1, $2, unnest($3::text[]), unnest($4::text[])) RETURNING id", collectionTextsTable)
-			rows, err := tx.Query(ctx, query, collectionName, namespace, keys, texts)
+		if len(labelsArr) == 0 && len(metadataArr) == 0 {
+			query := fmt.Sprintf("INSERT INTO %s (collection, namespace, key, text, version) VALUES ($1, $2, unnest($3::text[]), unnest($4::text[]), unnest($5::bigint[])) RETURNING id", collectionTextsTable)
+			rows, err := tx.Query(ctx, query, collectionName, namespace, keys, texts, nextVersions)
 			if err != nil {
 				return err
 			}
@@ -355,8 +448,16 @@ func WriteCollectionTexts(ctx context.Context, collectionName, namespace string,
 			}
 		} else {
 			for i := range keys {
-				query := fmt.Sprintf("INSERT INTO %s (collection, namespace, key, text, labels) VALUES ($1, $2, $3, $4, $5) RETURNING id", collectionTextsTable)
-				err := tx.QueryRow(ctx, query, collectionName, namespace, keys[i], texts[i], labelsArr[i]).Scan(&ids[i])
+				var labels []string
+				if len(labelsArr) != 0 {
+					labels = labelsArr[i]
+				}
+				var metadata map[string]any
+				if len(metadataArr) != 0 {
+					metadata = metadataArr[i]
+				}
+				query := fmt.Sprintf("INSERT INTO %s (collection, namespace, key, text, labels, metadata, version) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id", collectionTextsTable)
+				err := tx.QueryRow(ctx, query, collectionName, namespace, keys[i], texts[i], labels, metadata, nextVersions[i]).Scan(&ids[i])
 				if err != nil {
 					return err
 				}
@@ -371,32 +472,62 @@ func WriteCollectionTexts(ctx context.Context, collectionName, namespace string,
 	return ids, nil
 }
 
-func WriteCollectionText(ctx context.Context, collectionName, namespace, key, text string, labels []string) (id int64, err error) {
-	err = WithTx(ctx, func(tx pgx.Tx) error {
-		// Delete any existing rows that match the collectionName and key
-		deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE collection = $1 AND namespace = $2 AND key = $3", collectionTextsTable)
-		_, err := tx.Exec(ctx, deleteQuery, collectionName, namespace, key)
+func WriteCollectionText(ctx context.Context, collectionName, namespace, key, text string, labels []string, metadata map[string]any) (id int64, err error) {
+	id, _, err = WriteCollectionTextIfVersion(ctx, collectionName, namespace, key, text, labels, metadata, AnyVersion)
+	return id, err
+}
+
+// WriteCollectionTextIfVersion writes the text only if the item has the expected version, returning the id and the
+// version of the new row, or a VersionConflictError.  An expected version of 0 only writes the text of a new item.
+func WriteCollectionTextIfVersion(ctx context.Context, collectionName, namespace, key, text string, labels []string, metadata map[string]any, expectedVersion int64) (id, version int64, err error) {
+	err = withCollectionTx(ctx, func(tx pgx.Tx) error {
+		if err := lockCollectionKeys(ctx, tx, collectionName, namespace, []string{key}); err != nil {
+			return err
+		}
+
+		// Delete any existing rows that match the collectionName and key, which is rolled back on a conflict
+		versions, err := deleteCollectionTextVersions(ctx, tx, collectionName, namespace, []string{key})
 		if err != nil {
 			return err
 		}
+		if err := checkVersion(key, expectedVersion, versions[key]); err != nil {
+			return err
+		}
+		version = versions[key] + 1
 
 		// Insert the new row
 		if len(labels) == 0 {
 			labels = nil
 		}
-		query := fmt.Sprintf("INSERT INTO %s (collection, namespace, key, text, labels) VALUES ($1, $2, $3, $4, unnest($5::text[])) RETURNING id", collectionTextsTable)
-		row := tx.QueryRow(ctx, query, collectionName, namespace, key, text, labels)
+		query := fmt.Sprintf("INSERT INTO %s (collection, namespace, key, text, labels, metadata, version) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id", collectionTextsTable)
+		row := tx.QueryRow(ctx, query, collectionName, namespace, key, text, labels, metadata, version)
 		return row.Scan(&id)
 	})
 
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
-	return id, nil
+	return id, version, nil
+}
+
+// SetCollectionTextsExpiry sets the texts to expire the given number of seconds from now.
+// Texts with a ttl of zero don't expire.
+func SetCollectionTextsExpiry(ctx context.Context, textIds []int64, ttls []int64) error {
+	if len(textIds) != len(ttls) {
+		return errors.New("textIds and ttls must have the same length")
+	}
+
+	return withCollectionTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf(`UPDATE %s AS t SET expires_at = now() + make_interval(secs => e.ttl)
+			FROM unnest($1::bigint[], $2::bigint[]) AS e(id, ttl)
+			WHERE t.id = e.id AND e.ttl > 0`, collectionTextsTable)
+		_, err := tx.Exec(ctx, query, textIds, ttls)
+		return err
+	})
 }
 
 func DeleteCollectionTexts(ctx context.Context, collectionName string) error {
-	return WithTx(ctx, func(tx pgx.Tx) error {
+	return withCollectionTx(ctx, func(tx pgx.Tx) error {
 		query := fmt.Sprintf("DELETE FROM %s WHERE collection = $1", collectionTextsTable)
 		_, err := tx.Exec(ctx, query, collectionName)
 		if err != nil {
@@ -406,17 +537,95 @@ func DeleteCollectionTexts(ctx context.Context, collectionName string) error {
 	})
 }
 
+// DeleteCollectionTextsByKeys deletes the texts for the keys, and their vectors, returning the keys that were found.
+func DeleteCollectionTextsByKeys(ctx context.Context, collectionName, namespace string, keys []string) ([]string, error) {
+	var deleted []string
+	err := withCollectionTx(ctx, func(tx pgx.Tx) error {
+		if err := lockCollectionKeys(ctx, tx, collectionName, namespace, keys); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf("DELETE FROM %s WHERE collection = $1 AND namespace = $2 AND key = ANY($3) RETURNING key", collectionTextsTable)
+		rows, err := tx.Query(ctx, query, collectionName, namespace, keys)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				return err
+			}
+			deleted = append(deleted, key)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
 func DeleteCollectionText(ctx context.Context, collectionName, namespace, key string) error {
-	return WithTx(ctx, func(tx pgx.Tx) error {
-		query := fmt.Sprintf("DELETE FROM %s WHERE collection = $1 AND namespace = $2 AND key = $3", collectionTextsTable)
-		_, err := tx.Exec(ctx, query, collectionName, namespace, key)
+	return DeleteCollectionTextIfVersion(ctx, collectionName, namespace, key, AnyVersion)
+}
+
+// DeleteCollectionTextIfVersion deletes the text, and its vectors, only if the item has the expected version.
+// Otherwise it returns a VersionConflictError.
+func DeleteCollectionTextIfVersion(ctx context.Context, collectionName, namespace, key string, expectedVersion int64) error {
+	return withCollectionTx(ctx, func(tx pgx.Tx) error {
+		if err := lockCollectionKeys(ctx, tx, collectionName, namespace, []string{key}); err != nil {
+			return err
+		}
+
+		// the delete is rolled back on a conflict
+		versions, err := deleteCollectionTextVersions(ctx, tx, collectionName, namespace, []string{key})
 		if err != nil {
 			return err
 		}
-		return nil
+		return checkVersion(key, expectedVersion, versions[key])
 	})
 }
 
+// ExpiredCollectionText identifies a text that was deleted because it expired.
+type ExpiredCollectionText struct {
+	Id         int64
+	Collection string
+	Namespace  string
+	Key        string
+}
+
+// DeleteExpiredCollectionTexts deletes up to limit texts that have expired, and their vectors, returning the deleted texts.
+func DeleteExpiredCollectionTexts(ctx context.Context, limit int) ([]ExpiredCollectionText, error) {
+	var expired []ExpiredCollectionText
+	err := withCollectionTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf(`DELETE FROM %[1]s WHERE id IN (
+			SELECT id FROM %[1]s WHERE expires_at <= now() LIMIT $1
+		) RETURNING id, collection, namespace, key`, collectionTextsTable)
+		rows, err := tx.Query(ctx, query, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var t ExpiredCollectionText
+			if err := rows.Scan(&t.Id, &t.Collection, &t.Namespace, &t.Key); err != nil {
+				return err
+			}
+			expired = append(expired, t)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
 func WriteCollectionVectors(ctx context.Context, searchMethodName string, textIds []int64, vectors [][]float32) ([]int64, []string, error) {
 	if len(textIds) != len(vectors) {
 		return nil, nil, errors.New("textIds and vectors must have the same length")
@@ -424,7 +633,7 @@ func WriteCollectionVectors(ctx context.Context, searchMethodName string, textId
 
 	vectorIds := make([]int64, len(textIds))
 	keys := make([]string, len(textIds))
-	err := WithTx(ctx, func(tx pgx.Tx) error {
+	err := withCollectionTx(ctx, func(tx pgx.Tx) error {
 		// Delete any existing rows that match the searchMethodName and textIds
 		deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE search_method = $1 AND text_id = ANY($2)", collectionVectorsTable)
 		_, err := tx.Exec(ctx, deleteQuery, searchMethodName, textIds)
@@ -444,7 +653,7 @@ func WriteCollectionVectors(ctx context.Context, searchMethodName string, textId
 			vectorIds[i] = id
 		}
 
-		query = "SELECT key FROM collection_texts WHERE id = ANY($1)"
+		query = fmt.Sprintf("SELECT key FROM %s WHERE id = ANY($1)", collectionTextsTable)
 		rows, err := tx.Query(ctx, query, textIds)
 		if err != nil {
 			return err
@@ -472,7 +681,7 @@ func WriteCollectionVectors(ctx context.Context, searchMethodName string, textId
 }
 
 func WriteCollectionVector(ctx context.Context, searchMethodName string, textId int64, vector []float32) (vectorId int64, key string, err error) {
-	err = WithTx(ctx, func(tx pgx.Tx) error {
+	err = withCollectionTx(ctx, func(tx pgx.Tx) error {
 		// Delete any existing rows that match the searchMethodName and textId
 		deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE search_method = $1 AND text_id = $2", collectionVectorsTable)
 		_, err := tx.Exec(ctx, deleteQuery, searchMethodName, textId)
@@ -481,14 +690,14 @@ func WriteCollectionVector(ctx context.Context, searchMethodName string, textId
 		}
 
 		// Insert the new row
-		query := fmt.Sprintf("INSERT INTO %s (search_method, text_id, vector) VALUES ($1, $2, $3) RETURNING id", collectionVectorsTable)
+		query := fmt.Sprintf("INSERT INTO %s (search_method, text_id, vector) VALUES ($1, $2, $3::real[]) RETURNING id", collectionVectorsTable)
 		row := tx.QueryRow(ctx, query, searchMethodName, textId, vector)
 		err = row.Scan(&vectorId)
 		if err != nil {
 			return err
 		}
 
-		query = "SELECT key FROM collection_texts WHERE id = $1"
+		query = fmt.Sprintf("SELECT key FROM %s WHERE id = $1", collectionTextsTable)
 		row = tx.QueryRow(ctx, query, textId)
 		return row.Scan(&key)
 
@@ -501,7 +710,7 @@ func WriteCollectionVector(ctx context.Context, searchMethodName string, textId
 }
 
 func DeleteCollectionVectors(ctx context.Context, collectionName, searchMethodName, namespace string) error {
-	return WithTx(ctx, func(tx pgx.Tx) error {
+	return withCollectionTx(ctx, func(tx pgx.Tx) error {
 		query := fmt.Sprintf(`
 		DELETE FROM %s cv 
 		USING %s ct 
@@ -518,8 +727,47 @@ func DeleteCollectionVectors(ctx context.Context, collectionName, searchMethodNa
 	})
 }
 
+// SwapCollectionVectors replaces the vectors of the search method in the collection with the vectors staged under
+// another search method name, in a single transaction.  The staged vectors are inserted as new rows, so that their ids
+// are after the checkpoints of any index of the old vectors, and the largest of the new ids is returned.
+func SwapCollectionVectors(ctx context.Context, collectionName, searchMethodName, stagedSearchMethodName string) (lastVectorId int64, err error) {
+	err = withCollectionTx(ctx, func(tx pgx.Tx) error {
+		deleteQuery := fmt.Sprintf(`
+		DELETE FROM %s cv
+		USING %s ct
+		WHERE ct.id = cv.text_id
+		AND ct.collection = $1
+		AND cv.search_method = $2`,
+			collectionVectorsTable, collectionTextsTable)
+		if _, err := tx.Exec(ctx, deleteQuery, collectionName, searchMethodName); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(`
+		WITH inserted AS (
+			INSERT INTO %s (search_method, text_id, vector)
+			SELECT $2, cv.text_id, cv.vector
+			FROM %s cv
+			JOIN %s ct ON ct.id = cv.text_id
+			WHERE ct.collection = $1
+			AND cv.search_method = $3
+			ORDER BY cv.id
+			RETURNING id
+		)
+		SELECT COALESCE(MAX(id), 0) FROM inserted`,
+			collectionVectorsTable, collectionVectorsTable, collectionTextsTable)
+		if err := tx.QueryRow(ctx, query, collectionName, searchMethodName, stagedSearchMethodName).Scan(&lastVectorId); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, deleteQuery, collectionName, stagedSearchMethodName)
+		return err
+	})
+	return lastVectorId, err
+}
+
 func DeleteCollectionVector(ctx context.Context, searchMethodName string, textId int64) error {
-	return WithTx(ctx, func(tx pgx.Tx) error {
+	return withCollectionTx(ctx, func(tx pgx.Tx) error {
 		query := fmt.Sprintf("DELETE FROM %s WHERE search_method = $1 AND text_id = $2", collectionVectorsTable)
 		_, err := tx.Exec(ctx, query, searchMethodName, textId)
 		if err != nil {
@@ -529,13 +777,14 @@ func DeleteCollectionVector(ctx context.Context, searchMethodName string, textId
 	})
 }
 
-func QueryCollectionTextsFromCheckpoint(ctx context.Context, collection, namespace string, textCheckpointId int64) ([]int64, []string, []string, [][]string, error) {
+func QueryCollectionTextsFromCheckpoint(ctx context.Context, collection, namespace string, textCheckpointId int64) ([]int64, []string, []string, [][]string, []map[string]any, error) {
 	var textIds []int64
 	var keys []string
 	var texts []string
 	var labelsArr [][]string
-	err := WithTx(ctx, func(tx pgx.Tx) error {
-		query := fmt.Sprintf("SELECT id, key, text, labels FROM %s WHERE id > $1 AND collection = $2 AND namespace = $3", collectionTextsTable)
+	var metadataArr []map[string]any
+	err := withCollectionTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf("SELECT id, key, text, labels, metadata FROM %s WHERE id > $1 AND collection = $2 AND namespace = $3 AND (expires_at IS NULL OR expires_at > now())", collectionTextsTable)
 		rows, err := tx.Query(ctx, query, textCheckpointId, collection, namespace)
 		if err != nil {
 			return err
@@ -547,13 +796,15 @@ func QueryCollectionTextsFromCheckpoint(ctx context.Context, collection, namespa
 			var key string
 			var text string
 			var labels []string
-			if err := rows.Scan(&id, &key, &text, &labels); err != nil {
+			var metadata map[string]any
+			if err := rows.Scan(&id, &key, &text, &labels, &metadata); err != nil {
 				return err
 			}
 			textIds = append(textIds, id)
 			keys = append(keys, key)
 			texts = append(texts, text)
 			labelsArr = append(labelsArr, labels)
+			metadataArr = append(metadataArr, metadata)
 
 		}
 
@@ -564,10 +815,10 @@ func QueryCollectionTextsFromCheckpoint(ctx context.Context, collection, namespa
 	})
 
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, nil, nil, nil, nil, err
 	}
 
-	return textIds, keys, texts, labelsArr, nil
+	return textIds, keys, texts, labelsArr, metadataArr, nil
 }
 
 func QueryCollectionVectorsFromCheckpoint(ctx context.Context, collectionName, searchMethodName, namespace string, vecCheckpointId int64) ([]int64, []int64, []string, [][]float32, error) {
@@ -575,8 +826,8 @@ func QueryCollectionVectorsFromCheckpoint(ctx context.Context, collectionName, s
 	var vectorIds []int64
 	var keys []string
 	var vectors [][]float32
-	err := WithTx(ctx, func(tx pgx.Tx) error {
-		query := fmt.Sprintf(`SELECT ct.id, cv.id, ct.key, cv.vector 
+	err := withCollectionTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf(`SELECT ct.id, cv.id, ct.key, cv.vector::real[]
                   FROM %s cv 
                   JOIN %s ct ON cv.text_id = ct.id 
                   WHERE cv.id > $1 AND ct.collection = $2 AND cv.search_method = $3 AND ct.namespace = $4`, collectionVectorsTable, collectionTextsTable)
@@ -613,49 +864,50 @@ func QueryCollectionVectorsFromCheckpoint(ctx context.Context, collectionName, s
 	return textIds, vectorIds, keys, vectors, nil
 }
 
-func WriteInferenceHistoryToDB(ctx context.Context, batch []inferenceHistory) {
-	if len(batch) == 0 {
-		return
-	}
-
-	err := WithTx(ctx, func(tx pgx.Tx) error {
-		b := &pgx.Batch{}
-		for _, data := range batch {
-			input, output, err := data.getJson()
-			if err != nil {
-				return err
-			}
-			query := fmt.Sprintf(`INSERT INTO %s
-(id, model_hash, input, output, started_at, duration_ms, plugin_id, function)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-`, inferencesTable)
-			args := []any{
-				utils.GenerateUUIDv7(),
-				data.model.Hash(),
-				input,
-				output,
-				data.start,
-				data.end.Sub(data.start).Milliseconds(),
-				data.pluginId,
-				data.function,
-			}
-			b.Queue(query, args...)
+// QueryCollectionVectorsByTextIds returns the vectors of the search method for the texts, by text id.
+func QueryCollectionVectorsByTextIds(ctx context.Context, searchMethodName string, textIds []int64) (map[int64][]float32, error) {
+	vectors := make(map[int64][]float32, len(textIds))
+	err := withCollectionTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf("SELECT text_id, vector::real[] FROM %s WHERE search_method = $1 AND text_id = ANY($2)", collectionVectorsTable)
+		rows, err := tx.Query(ctx, query, searchMethodName, textIds)
+		if err != nil {
+			return err
 		}
+		defer rows.Close()
 
-		br := tx.SendBatch(ctx, b)
-		defer br.Close()
-
-		for range batch {
-			_, err := br.Exec()
-			if err != nil {
+		for rows.Next() {
+			var textId int64
+			var vector []float32
+			if err := rows.Scan(&textId, &vector); err != nil {
 				return err
 			}
+			vectors[textId] = vector
 		}
-
-		return nil
+		return rows.Err()
 	})
 
 	if err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+func writeInferenceHistory(ctx context.Context, batch []inferenceHistory) {
+	if len(batch) == 0 {
+		return
+	}
+
+	records := make([]*InferenceRecord, 0, len(batch))
+	for _, data := range batch {
+		r, err := data.toRecord()
+		if err != nil {
+			logger.Err(ctx, err).Str("model", data.model.Name).Msg("Inference history record is invalid.")
+			continue
+		}
+		records = append(records, r)
+	}
+
+	if err := getInferenceStore(ctx).WriteInferences(ctx, records); err != nil {
 		logDbWarningOrError(ctx, err, "Inference history not written to database.")
 	}
 }
@@ -686,13 +938,18 @@ func WithTx(ctx context.Context, fn func(pgx.Tx) error) error {
 	if err != nil {
 		return err
 	}
+	return runTx(ctx, tx, fn)
+}
+
+// runTx calls the function with the transaction, which is committed if the function succeeds, or rolled back if it fails.
+func runTx(ctx context.Context, tx pgx.Tx, fn func(pgx.Tx) error) error {
 	defer func() {
 		if err := tx.Rollback(ctx); err != nil {
 			return
 		}
 	}()
 
-	err = fn(tx)
+	err := fn(tx)
 	if err != nil {
 		return err
 	}
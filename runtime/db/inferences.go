@@ -0,0 +1,283 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxMemoryInferences is the number of inference records kept when no database is configured.
+const maxMemoryInferences = 1000
+
+// InferenceRecord is a model invocation made by a function, as stored in the inference history.
+type InferenceRecord struct {
+	Id            string          `json:"id"`
+	Model         string          `json:"model,omitempty"`
+	ModelHash     string          `json:"modelHash"`
+	Input         json.RawMessage `json:"input"`
+	Output        json.RawMessage `json:"output"`
+	InputTokens   int             `json:"inputTokens"`
+	OutputTokens  int             `json:"outputTokens"`
+	StartedAt     time.Time       `json:"startedAt"`
+	DurationMs    int64           `json:"durationMs"`
+	Function      string          `json:"function,omitempty"`
+	Plugin        string          `json:"plugin,omitempty"`
+	PluginVersion string          `json:"pluginVersion,omitempty"`
+
+	pluginId *string
+}
+
+// InferenceQuery filters the inference history.  Records are returned newest first.
+// The cursor is the id of the last record of the previous page, if any.
+type InferenceQuery struct {
+	Model         string
+	Function      string
+	Plugin        string
+	PluginVersion string
+	Since         time.Time
+	Until         time.Time
+	Cursor        string
+	Limit         int
+}
+
+// InferenceStore persists the inference history.  By default, the history is stored in the Modus database,
+// or in memory when no database is configured.  A different store can be set with SetInferenceStore.
+type InferenceStore interface {
+	WriteInferences(ctx context.Context, records []*InferenceRecord) error
+	QueryInferences(ctx context.Context, q *InferenceQuery) ([]*InferenceRecord, error)
+}
+
+var inferenceStore InferenceStore
+var inferenceStoreMutex sync.RWMutex
+
+// SetInferenceStore replaces the store used for the inference history.
+func SetInferenceStore(store InferenceStore) {
+	inferenceStoreMutex.Lock()
+	defer inferenceStoreMutex.Unlock()
+	inferenceStore = store
+}
+
+func getInferenceStore(ctx context.Context) InferenceStore {
+	inferenceStoreMutex.RLock()
+	store := inferenceStore
+	inferenceStoreMutex.RUnlock()
+	if store != nil {
+		return store
+	}
+
+	inferenceStoreMutex.Lock()
+	defer inferenceStoreMutex.Unlock()
+	if inferenceStore == nil {
+		if IsDbConfigured(ctx) {
+			inferenceStore = postgresInferenceStore{}
+		} else {
+			inferenceStore = newMemoryInferenceStore()
+		}
+	}
+	return inferenceStore
+}
+
+// QueryInferences returns the records of the inference history that match the query.
+func QueryInferences(ctx context.Context, q *InferenceQuery) ([]*InferenceRecord, error) {
+	return getInferenceStore(ctx).QueryInferences(ctx, q)
+}
+
+func (h *inferenceHistory) toRecord() (*InferenceRecord, error) {
+	input, output, err := h.getJson()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &InferenceRecord{
+		Id:            utils.GenerateUUIDv7(),
+		Model:         h.model.Name,
+		ModelHash:     h.model.Hash(),
+		Input:         input,
+		Output:        output,
+		StartedAt:     h.start,
+		DurationMs:    h.end.Sub(h.start).Milliseconds(),
+		Plugin:        h.pluginName,
+		PluginVersion: h.pluginVersion,
+		pluginId:      h.pluginId,
+	}
+	r.InputTokens, r.OutputTokens, _ = utils.GetTokenUsage(string(output))
+	if h.function != nil {
+		r.Function = *h.function
+	}
+	return r, nil
+}
+
+// postgresInferenceStore keeps the inference history in the Modus database.
+type postgresInferenceStore struct{}
+
+func (postgresInferenceStore) WriteInferences(ctx context.Context, records []*InferenceRecord) error {
+	return WithTx(ctx, func(tx pgx.Tx) error {
+		b := &pgx.Batch{}
+		query := fmt.Sprintf(`INSERT INTO %s
+(id, model_hash, model_name, input, output, input_tokens, output_tokens, started_at, duration_ms, plugin_id, function)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+`, inferencesTable)
+		for _, r := range records {
+			b.Queue(query,
+				r.Id,
+				r.ModelHash,
+				r.Model,
+				[]byte(r.Input),
+				[]byte(r.Output),
+				r.InputTokens,
+				r.OutputTokens,
+				r.StartedAt,
+				r.DurationMs,
+				r.pluginId,
+				utils.NilIfEmpty(r.Function),
+			)
+		}
+
+		br := tx.SendBatch(ctx, b)
+		defer br.Close()
+
+		for range records {
+			if _, err := br.Exec(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (postgresInferenceStore) QueryInferences(ctx context.Context, q *InferenceQuery) ([]*InferenceRecord, error) {
+	var conditions []string
+	var args []any
+	where := func(cond string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if q.Model != "" {
+		where("i.model_name = $%d", q.Model)
+	}
+	if q.Function != "" {
+		where("i.function = $%d", q.Function)
+	}
+	if q.Plugin != "" {
+		where("p.name = $%d", q.Plugin)
+	}
+	if q.PluginVersion != "" {
+		where("p.version = $%d", q.PluginVersion)
+	}
+	if !q.Since.IsZero() {
+		where("i.started_at >= $%d", q.Since)
+	}
+	if !q.Until.IsZero() {
+		where("i.started_at < $%d", q.Until)
+	}
+	if q.Cursor != "" {
+		where("i.id < $%d", q.Cursor)
+	}
+
+	query := fmt.Sprintf(`SELECT i.id, i.model_name, i.model_hash, i.input, i.output, i.input_tokens, i.output_tokens,
+i.started_at, i.duration_ms, i.function, p.name, p.version
+FROM %s i LEFT JOIN %s p ON p.id = i.plugin_id`, inferencesTable, pluginsTable)
+	if len(conditions) > 0 {
+		query += "\nWHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, q.Limit)
+	query += fmt.Sprintf("\nORDER BY i.id DESC LIMIT $%d", len(args))
+
+	var records []*InferenceRecord
+	err := WithTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var r InferenceRecord
+			var model, function, plugin, version *string
+			var inputTokens, outputTokens *int32
+			if err := rows.Scan(&r.Id, &model, &r.ModelHash, &r.Input, &r.Output, &inputTokens, &outputTokens,
+				&r.StartedAt, &r.DurationMs, &function, &plugin, &version); err != nil {
+				return err
+			}
+			r.Model, r.Function, r.Plugin, r.PluginVersion = deref(model), deref(function), deref(plugin), deref(version)
+			if inputTokens != nil {
+				r.InputTokens = int(*inputTokens)
+			}
+			if outputTokens != nil {
+				r.OutputTokens = int(*outputTokens)
+			}
+			records = append(records, &r)
+		}
+		return rows.Err()
+	})
+	return records, err
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// memoryInferenceStore keeps the most recent inference history in memory, such as during local development.
+// Records held in memory are lost when the runtime stops.
+type memoryInferenceStore struct {
+	mu      sync.RWMutex
+	records []*InferenceRecord
+}
+
+func newMemoryInferenceStore() *memoryInferenceStore {
+	return &memoryInferenceStore{}
+}
+
+func (s *memoryInferenceStore) WriteInferences(ctx context.Context, records []*InferenceRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, records...)
+	if n := len(s.records) - maxMemoryInferences; n > 0 {
+		clear(s.records[:n])
+		s.records = s.records[n:]
+	}
+	return nil
+}
+
+func (s *memoryInferenceStore) QueryInferences(ctx context.Context, q *InferenceQuery) ([]*InferenceRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []*InferenceRecord
+	for i := len(s.records) - 1; i >= 0 && len(results) < q.Limit; i-- {
+		r := s.records[i]
+		switch {
+		case q.Model != "" && r.Model != q.Model,
+			q.Function != "" && r.Function != q.Function,
+			q.Plugin != "" && r.Plugin != q.Plugin,
+			q.PluginVersion != "" && r.PluginVersion != q.PluginVersion,
+			!q.Since.IsZero() && r.StartedAt.Before(q.Since),
+			!q.Until.IsZero() && !r.StartedAt.Before(q.Until),
+			q.Cursor != "" && r.Id >= q.Cursor:
+			continue
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
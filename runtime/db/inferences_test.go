@@ -0,0 +1,129 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMemoryInferenceStore(t *testing.T) *memoryInferenceStore {
+	store := newMemoryInferenceStore()
+	SetInferenceStore(store)
+	t.Cleanup(func() { SetInferenceStore(nil) })
+	return store
+}
+
+func TestInferenceHistoryRecord(t *testing.T) {
+	function := "summarize"
+	start := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	h := inferenceHistory{
+		model:         &manifest.ModelInfo{Name: "text-generator", Host: "openai"},
+		input:         `{"messages":[{"role":"user","content":"Hello"}]}`,
+		output:        map[string]any{"usage": map[string]int{"prompt_tokens": 12, "completion_tokens": 3}},
+		start:         start,
+		end:           start.Add(250 * time.Millisecond),
+		pluginName:    "my-plugin",
+		pluginVersion: "1.2.0",
+		function:      &function,
+	}
+
+	r, err := h.toRecord()
+	require.NoError(t, err)
+	assert.NotEmpty(t, r.Id)
+	assert.Equal(t, "text-generator", r.Model)
+	assert.Equal(t, 12, r.InputTokens)
+	assert.Equal(t, 3, r.OutputTokens)
+	assert.Equal(t, int64(250), r.DurationMs)
+	assert.Equal(t, "summarize", r.Function)
+	assert.Equal(t, "my-plugin", r.Plugin)
+	assert.Equal(t, "1.2.0", r.PluginVersion)
+	assert.JSONEq(t, `{"messages":[{"role":"user","content":"Hello"}]}`, string(r.Input))
+}
+
+func TestInferencesHandler(t *testing.T) {
+	store := setupMemoryInferenceStore(t)
+
+	start := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	var batch []inferenceHistory
+	for i := range 5 {
+		function := "summarize"
+		if i%2 == 1 {
+			function = "classify"
+		}
+		batch = append(batch, inferenceHistory{
+			model:    &manifest.ModelInfo{Name: "text-generator"},
+			input:    `{}`,
+			output:   `{}`,
+			start:    start.Add(time.Duration(i) * time.Minute),
+			end:      start.Add(time.Duration(i) * time.Minute),
+			function: &function,
+		})
+	}
+	writeInferenceHistory(context.Background(), batch)
+	require.Len(t, store.records, 5)
+
+	get := func(query string) inferencesResponse {
+		req := httptest.NewRequest(http.MethodGet, "/admin/inferences?"+query, nil)
+		w := httptest.NewRecorder()
+		InferencesHandler(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp inferencesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	page1 := get("function=summarize&limit=2")
+	require.Len(t, page1.Inferences, 2)
+	assert.Equal(t, start.Add(4*time.Minute), page1.Inferences[0].StartedAt)
+	assert.Equal(t, start.Add(2*time.Minute), page1.Inferences[1].StartedAt)
+	assert.Equal(t, page1.Inferences[1].Id, page1.NextCursor)
+
+	page2 := get("function=summarize&limit=2&cursor=" + page1.NextCursor)
+	require.Len(t, page2.Inferences, 1)
+	assert.Equal(t, start, page2.Inferences[0].StartedAt)
+	assert.Empty(t, page2.NextCursor)
+
+	ranged := get("since=" + start.Add(time.Minute).Format(time.RFC3339) + "&until=" + start.Add(3*time.Minute).Format(time.RFC3339))
+	assert.Len(t, ranged.Inferences, 2)
+
+	none := get("model=other")
+	assert.NotNil(t, none.Inferences)
+	assert.Empty(t, none.Inferences)
+}
+
+func TestInferencesHandlerInvalidParameters(t *testing.T) {
+	setupMemoryInferenceStore(t)
+
+	for _, query := range []string{"limit=0", "since=yesterday", "cursor=abc"} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/inferences?"+query, nil)
+		w := httptest.NewRecorder()
+		InferencesHandler(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestMemoryInferenceStoreLimit(t *testing.T) {
+	store := newMemoryInferenceStore()
+	for range maxMemoryInferences + 10 {
+		require.NoError(t, store.WriteInferences(context.Background(), []*InferenceRecord{{Id: "x"}}))
+	}
+	assert.Len(t, store.records, maxMemoryInferences)
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const jobsTable = "jobs"
+
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDead    = "dead"
+)
+
+type Job struct {
+	Id          string    `json:"id"`
+	Function    string    `json:"function"`
+	Payload     string    `json:"payload"`
+	Status      string    `json:"status"`
+	Attempts    int32     `json:"attempts"`
+	MaxAttempts int32     `json:"maxAttempts"`
+	LastError   string    `json:"lastError,omitempty"`
+	RunAt       time.Time `json:"runAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func IsDbConfigured(ctx context.Context) bool {
+	_, err := globalRuntimePostgresWriter.GetPool(ctx)
+	return !errors.Is(err, errDbNotConfigured)
+}
+
+func InsertJob(ctx context.Context, job *Job) error {
+	return WithTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf("INSERT INTO %s (id, function, payload, status, max_attempts, run_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at", jobsTable)
+		return tx.QueryRow(ctx, query, job.Id, job.Function, job.Payload, JobStatusPending, job.MaxAttempts, job.RunAt).Scan(&job.CreatedAt)
+	})
+}
+
+// ClaimJobs marks up to limit jobs that are due as running, and returns them.
+// A running job whose lease has expired (for example, because the runtime that claimed it crashed)
+// is due again, which gives at-least-once execution.
+func ClaimJobs(ctx context.Context, limit int, lease time.Duration) ([]Job, error) {
+	var jobs []Job
+	err := WithTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf(`UPDATE %[1]s SET status = $1, attempts = attempts + 1, run_at = NOW() + make_interval(secs => $2), updated_at = NOW()
+WHERE id IN (
+	SELECT id FROM %[1]s
+	WHERE status IN ($3, $1) AND run_at <= NOW()
+	ORDER BY run_at
+	LIMIT $4
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, function, payload, status, attempts, max_attempts, COALESCE(last_error, ''), run_at, created_at`, jobsTable)
+		rows, err := tx.Query(ctx, query, JobStatusRunning, lease.Seconds(), JobStatusPending, limit)
+		if err != nil {
+			return err
+		}
+		jobs, err = scanJobs(rows)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func DeleteJob(ctx context.Context, id string) error {
+	return WithTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", jobsTable)
+		_, err := tx.Exec(ctx, query, id)
+		return err
+	})
+}
+
+// UpdateFailedJob records a failed attempt, and either schedules the job to run again at runAt,
+// or moves it to the dead-letter list.
+func UpdateFailedJob(ctx context.Context, id, lastError string, runAt time.Time, dead bool) error {
+	status := JobStatusPending
+	if dead {
+		status = JobStatusDead
+	}
+
+	return WithTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET status = $1, last_error = $2, run_at = $3, updated_at = NOW() WHERE id = $4", jobsTable)
+		_, err := tx.Exec(ctx, query, status, lastError, runAt, id)
+		return err
+	})
+}
+
+func GetDeadJobs(ctx context.Context, limit int) ([]Job, error) {
+	var jobs []Job
+	err := WithTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf(`SELECT id, function, payload, status, attempts, max_attempts, COALESCE(last_error, ''), run_at, created_at
+FROM %s WHERE status = $1 ORDER BY updated_at DESC LIMIT $2`, jobsTable)
+		rows, err := tx.Query(ctx, query, JobStatusDead, limit)
+		if err != nil {
+			return err
+		}
+		jobs, err = scanJobs(rows)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// RetryDeadJob moves a job from the dead-letter list back to the queue, with a fresh set of attempts.
+func RetryDeadJob(ctx context.Context, id string) (bool, error) {
+	var found bool
+	err := WithTx(ctx, func(tx pgx.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET status = $1, attempts = 0, run_at = NOW(), updated_at = NOW() WHERE id = $2 AND status = $3", jobsTable)
+		tag, err := tx.Exec(ctx, query, JobStatusPending, id, JobStatusDead)
+		if err != nil {
+			return err
+		}
+		found = tag.RowsAffected() > 0
+		return nil
+	})
+	return found, err
+}
+
+func scanJobs(rows pgx.Rows) ([]Job, error) {
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var j Job
+		if err := rows.Scan(&j.Id, &j.Function, &j.Payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.LastError, &j.RunAt, &j.CreatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
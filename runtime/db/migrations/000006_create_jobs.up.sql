@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "jobs" (
+    "id" UUID PRIMARY KEY,
+    "function" TEXT NOT NULL,
+    "payload" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "max_attempts" INTEGER NOT NULL,
+    "last_error" TEXT,
+    "run_at" TIMESTAMP(3) WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP(3) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP(3) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS jobs_status_run_at_idx ON jobs (status, run_at);
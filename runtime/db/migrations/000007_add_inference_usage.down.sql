@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS inferences_started_at_idx;
+DROP INDEX IF EXISTS inferences_model_name_idx;
+
+ALTER TABLE IF EXISTS "inferences"
+DROP COLUMN IF EXISTS "output_tokens",
+DROP COLUMN IF EXISTS "input_tokens",
+DROP COLUMN IF EXISTS "model_name";
@@ -0,0 +1,7 @@
+ALTER TABLE IF EXISTS "inferences"
+ADD COLUMN "model_name" TEXT,
+ADD COLUMN "input_tokens" INTEGER,
+ADD COLUMN "output_tokens" INTEGER;
+
+CREATE INDEX IF NOT EXISTS inferences_model_name_idx ON inferences (model_name);
+CREATE INDEX IF NOT EXISTS inferences_started_at_idx ON inferences (started_at);
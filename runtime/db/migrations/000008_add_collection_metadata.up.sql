@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE collection_texts ADD COLUMN metadata JSONB;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS collection_texts_expires_at_idx;
+
+ALTER TABLE collection_texts DROP COLUMN expires_at;
+
+COMMIT;
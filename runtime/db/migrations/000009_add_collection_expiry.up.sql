@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE collection_texts ADD COLUMN expires_at TIMESTAMPTZ;
+
+CREATE INDEX collection_texts_expires_at_idx ON collection_texts (expires_at) WHERE expires_at IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE collection_texts ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+COMMIT;
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package devloop runs designated smoke functions each time the plugin is reloaded in development,
+// so that developers can see the effect of their changes as soon as they rebuild.
+package devloop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/functions"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+)
+
+// smokeTimeout is how long each smoke function may run.
+const smokeTimeout = 30 * time.Second
+
+// maxResultLength is the maximum length of a result shown on the console.
+const maxResultLength = 200
+
+func Initialize(ctx context.Context) {
+	if !config.IsDevEnvironment() {
+		return
+	}
+
+	names := parseFunctionNames(config.SmokeFunctions)
+	if len(names) == 0 {
+		return
+	}
+
+	functions.RegisterFunctionsLoadedCallback(func(ctx context.Context) {
+		// Run in the background, so that loading isn't held up by the functions.
+		go runSmokeFunctions(ctx, names)
+	})
+}
+
+func parseFunctionNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func runSmokeFunctions(ctx context.Context, names []string) {
+	results := make([]string, len(names))
+	for i, name := range names {
+		results[i] = runSmokeFunction(ctx, name)
+	}
+
+	if config.UseJsonLogging {
+		logger.Info(ctx).Strs("results", results).Bool("user_visible", true).Msg("Ran smoke functions.")
+	} else {
+		fmt.Printf("\nSmoke functions:\n  %s\n\n", strings.Join(results, "\n  "))
+	}
+}
+
+func runSmokeFunction(ctx context.Context, name string) string {
+	ctx, cancel := context.WithTimeout(ctx, smokeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	info, err := wasmhost.CallFunction(ctx, name)
+	duration := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		return formatResult(name, duration, nil, err)
+	}
+
+	return formatResult(name, duration, info.Result(), nil)
+}
+
+func formatResult(name string, duration time.Duration, result any, err error) string {
+	if err != nil {
+		return fmt.Sprintf("✗ %s (%s): %v", name, duration, err)
+	}
+
+	if result == nil {
+		return fmt.Sprintf("✓ %s (%s)", name, duration)
+	}
+
+	s := fmt.Sprint(result)
+	if bytes, err := utils.JsonSerialize(result); err == nil {
+		s = string(bytes)
+	}
+	if len(s) > maxResultLength {
+		s = s[:maxResultLength] + "…"
+	}
+
+	return fmt.Sprintf("✓ %s (%s): %s", name, duration, s)
+}
@@ -16,8 +16,9 @@ import (
 )
 
 func Initialize() {
+	// Only the connections of hosts that changed or were removed are closed when the manifest is reloaded.
 	manifestdata.RegisterManifestLoadedCallback(func(ctx context.Context) error {
-		ShutdownConns()
+		shutdownConns(manifestdata.GetManifestDiff(ctx).IsHostStale)
 		return nil
 	})
 }
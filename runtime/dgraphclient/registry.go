@@ -13,7 +13,6 @@ import (
 	"context"
 	"crypto/x509"
 	"fmt"
-	"strings"
 	"sync"
 
 	"github.com/hypermodeinc/modus/lib/manifest"
@@ -56,12 +55,18 @@ func newDgraphRegistry() *dgraphRegistry {
 }
 
 func ShutdownConns() {
+	shutdownConns(func(string) bool { return true })
+}
+
+func shutdownConns(stale func(hostName string) bool) {
 	dgr.Lock()
 	defer dgr.Unlock()
-	for _, ds := range dgr.dgraphConnectorCache {
-		ds.conn.Close()
+	for name, ds := range dgr.dgraphConnectorCache {
+		if stale(name) {
+			ds.conn.Close()
+			delete(dgr.dgraphConnectorCache, name)
+		}
 	}
-	clear(dgr.dgraphConnectorCache)
 }
 
 func (dr *dgraphRegistry) getDgraphConnector(ctx context.Context, dgName string) (*dgraphConnector, error) {
@@ -110,7 +115,7 @@ func (dr *dgraphRegistry) getDgraphConnector(ctx context.Context, dgName string)
 				grpc.WithTransportCredentials(creds),
 				grpc.WithPerRPCCredentials(&authCreds{hostKey}),
 			}
-		} else if strings.Split(host.GrpcTarget, ":")[0] != "localhost" {
+		} else if host.UseTLS() {
 			pool, err := x509.SystemCertPool()
 			if err != nil {
 				return nil, err
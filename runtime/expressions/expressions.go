@@ -0,0 +1,147 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package expressions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/cache"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The optional function libraries that can be enabled for an expression.
+// See https://pkg.go.dev/github.com/google/cel-go/ext for the functions each provides.
+var libraries = map[string]cel.EnvOption{
+	"strings":  ext.Strings(),
+	"math":     ext.Math(),
+	"encoders": ext.Encoders(),
+	"lists":    ext.Lists(),
+	"sets":     ext.Sets(),
+}
+
+// costLimit bounds the work an expression may do, so that a bad expression can't tie up the host.
+const costLimit = 1_000_000
+
+const maxCachedPrograms = 1000
+
+var programCache = cache.New[cel.Program](maxCachedPrograms, nil)
+
+// Evaluate evaluates a CEL expression against JSON data, which the expression can access as `data`.
+// The names of any optional function libraries to enable are given in libs.
+// The result is returned as JSON.
+func Evaluate(ctx context.Context, expression, dataJson string, libs []string) (string, error) {
+	prg, err := getProgram(expression, libs)
+	if err != nil {
+		return "", err
+	}
+
+	var data any
+	if dataJson != "" {
+		dec := json.NewDecoder(strings.NewReader(dataJson))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err != nil {
+			return "", fmt.Errorf("invalid JSON data: %w", err)
+		}
+		data = convertNumbers(data)
+	}
+
+	out, _, err := prg.ContextEval(ctx, map[string]any{"data": data})
+	if err != nil {
+		return "", err
+	}
+
+	v, err := out.ConvertToNative(reflect.TypeFor[*structpb.Value]())
+	if err != nil {
+		return "", fmt.Errorf("expression result cannot be converted to JSON: %w", err)
+	}
+
+	result, err := protojson.Marshal(v.(*structpb.Value))
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+func getProgram(expression string, libs []string) (cel.Program, error) {
+	libs = slices.Clone(libs)
+	slices.Sort(libs)
+	libs = slices.Compact(libs)
+
+	key := strings.Join(libs, ",") + "|" + expression
+	if prg, ok := programCache.Get(key); ok {
+		return prg, nil
+	}
+
+	opts := []cel.EnvOption{
+		cel.Variable("data", cel.DynType),
+		cel.CrossTypeNumericComparisons(true),
+	}
+	for _, name := range libs {
+		lib, ok := libraries[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown expression library: %s", name)
+		}
+		opts = append(opts, lib)
+	}
+
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, iss := env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+
+	prg, err := env.Program(ast,
+		cel.CostLimit(costLimit),
+		cel.InterruptCheckFrequency(100),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	programCache.Set(key, prg, 0)
+	return prg, nil
+}
+
+// convertNumbers converts JSON numbers to int64 when they are whole numbers, and float64 otherwise.
+// CEL doesn't mix integer and floating point arithmetic, so this lets expressions such as
+// `data.count + 1` work as expected.
+func convertNumbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		for k, val := range t {
+			t[k] = convertNumbers(val)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = convertNumbers(val)
+		}
+	}
+	return v
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package expressions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testData = `{"user":{"name":"Alice","age":42,"roles":["admin","editor"]},"limit":10}`
+
+func Test_Evaluate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		expression string
+		libs       []string
+		expected   string
+	}{
+		{"data.user.age >= 18", nil, "true"},
+		{"'admin' in data.user.roles", nil, "true"},
+		{"data.limit * 2", nil, "20"},
+		{"data.user.name + '!'", nil, `"Alice!"`},
+		{"{'name': data.user.name, 'count': size(data.user.roles)}", nil, `{"count":2,"name":"Alice"}`},
+		{"data.user.roles.map(r, r.size())", nil, "[5,6]"},
+		{"data.user.name.upperAscii()", []string{"strings"}, `"ALICE"`},
+		{"math.greatest(data.limit, 3)", []string{"math"}, "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			result, err := Evaluate(ctx, tt.expression, testData, tt.libs)
+			require.Nil(t, err)
+			assert.JSONEq(t, tt.expected, result)
+		})
+	}
+}
+
+func Test_Evaluate_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := Evaluate(ctx, "data.user.", testData, nil)
+	assert.NotNil(t, err, "syntax error")
+
+	_, err = Evaluate(ctx, "data.user.name.upperAscii()", testData, nil)
+	assert.NotNil(t, err, "library not enabled")
+
+	_, err = Evaluate(ctx, "true", testData, []string{"bogus"})
+	assert.NotNil(t, err, "unknown library")
+
+	_, err = Evaluate(ctx, "data.missing.value", testData, nil)
+	assert.NotNil(t, err, "missing key")
+
+	_, err = Evaluate(ctx, "true", "{not json", nil)
+	assert.NotNil(t, err, "invalid data")
+}
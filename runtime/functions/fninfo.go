@@ -10,6 +10,8 @@
 package functions
 
 import (
+	"context"
+
 	"github.com/hypermodeinc/modus/runtime/langsupport"
 	"github.com/hypermodeinc/modus/runtime/plugins"
 	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
@@ -20,7 +22,7 @@ type FunctionInfo interface {
 	IsImport() bool
 	Plugin() *plugins.Plugin
 	Metadata() *metadata.Function
-	ExecutionPlan() langsupport.ExecutionPlan
+	ExecutionPlan(ctx context.Context) (langsupport.ExecutionPlan, error)
 }
 
 func NewFunctionInfo(fnName string, plugin *plugins.Plugin, isImport bool) (FunctionInfo, bool) {
@@ -33,12 +35,11 @@ func NewFunctionInfo(fnName string, plugin *plugins.Plugin, isImport bool) (Func
 	}
 
 	fnMeta := fnMap[fnName]
-	plan := plugin.ExecutionPlans[fnName]
-	if fnMeta == nil || plan == nil {
+	if fnMeta == nil {
 		return nil, false
 	}
 
-	info := &functionInfo{fnName, isImport, plugin, fnMeta, plan}
+	info := &functionInfo{fnName, isImport, plugin, fnMeta}
 	return info, true
 }
 
@@ -47,11 +48,13 @@ type functionInfo struct {
 	isImport bool
 	plugin   *plugins.Plugin
 	fnMeta   *metadata.Function
-	plan     langsupport.ExecutionPlan
 }
 
-func (f *functionInfo) Name() string                             { return f.fnName }
-func (f *functionInfo) IsImport() bool                           { return f.isImport }
-func (f *functionInfo) Plugin() *plugins.Plugin                  { return f.plugin }
-func (f *functionInfo) Metadata() *metadata.Function             { return f.fnMeta }
-func (f *functionInfo) ExecutionPlan() langsupport.ExecutionPlan { return f.plan }
+func (f *functionInfo) Name() string                 { return f.fnName }
+func (f *functionInfo) IsImport() bool               { return f.isImport }
+func (f *functionInfo) Plugin() *plugins.Plugin      { return f.plugin }
+func (f *functionInfo) Metadata() *metadata.Function { return f.fnMeta }
+
+func (f *functionInfo) ExecutionPlan(ctx context.Context) (langsupport.ExecutionPlan, error) {
+	return f.plugin.GetExecutionPlan(ctx, f.fnName)
+}
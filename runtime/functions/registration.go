@@ -63,11 +63,23 @@ func (fr *functionRegistry) RegisterExports(ctx context.Context, plugin *plugins
 	fnExports := plugin.Module.ExportedFunctions()
 	names := make([]string, 0, len(fnExports))
 	for fnName := range fnExports {
+		if plugins.IsHook(fnName) {
+			continue
+		}
+
 		info, ok := NewFunctionInfo(fnName, plugin, false)
 		if ok {
 			fr.functions[fnName] = info
 			names = append(names, fnName)
 
+			// The function can also be called by its name qualified with the plugin's namespace,
+			// which is how it is named in the schema when another plugin exports a function of the same name.
+			if ns := plugin.Metadata.Namespace(); ns != "" {
+				qualifiedName := ns + "_" + fnName
+				fr.functions[qualifiedName] = info
+				names = append(names, qualifiedName)
+			}
+
 			logger.Info(ctx).
 				Str("function", fnName).
 				Str("plugin", plugin.Name()).
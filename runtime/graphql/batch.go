@@ -0,0 +1,141 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+// readBatchRequest reads the body of a POST request, and returns its operations if it is a JSON array of
+// GraphQL requests, as sent by batching clients such as Apollo's batch link.  Otherwise, the body is restored,
+// so that the request can be handled as a single operation.
+func readBatchRequest(r *http.Request) ([]json.RawMessage, bool, error) {
+	if r.Method != http.MethodPost || r.Body == nil {
+		return nil, false, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !isJsonArray(body) {
+		return nil, false, nil
+	}
+
+	var operations []json.RawMessage
+	if err := json.Unmarshal(body, &operations); err != nil {
+		return nil, false, err
+	}
+	return operations, true, nil
+}
+
+func isJsonArray(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '['
+}
+
+// handleBatchRequest executes the operations of a batched request, a few at a time, and writes their responses
+// as a JSON array in the same order.  Each operation is handled as if it were sent alone, with the context
+// and headers of the batched request, except that its response is never streamed or served as Not Modified.
+func handleBatchRequest(w http.ResponseWriter, r *http.Request, operations []json.RawMessage) {
+	if config.MaxBatchSize <= 0 {
+		http.Error(w, "Batched GraphQL requests are not enabled.", http.StatusBadRequest)
+		return
+	}
+	if len(operations) == 0 || len(operations) > config.MaxBatchSize {
+		http.Error(w, fmt.Sprintf("A batched GraphQL request must contain between 1 and %d operations.", config.MaxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	responses := make([]json.RawMessage, len(operations))
+	sem := make(chan struct{}, max(config.BatchConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, operation := range operations {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			responses[i] = executeBatchedOperation(r, operation)
+		}()
+	}
+	wg.Wait()
+
+	response, err := json.Marshal(responses)
+	if err != nil {
+		http.Error(w, "Failed to write the batched GraphQL response.", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJsonContentHeader(w)
+	_, _ = w.Write(response)
+}
+
+// executeBatchedOperation handles one operation of a batched request, and returns its response.
+// A failure that isn't reported as a GraphQL response is converted to one, so the batch is never cut short.
+func executeBatchedOperation(r *http.Request, operation json.RawMessage) json.RawMessage {
+	if !gjson.ParseBytes(operation).IsObject() {
+		return batchErrorResponse("Failed to parse GraphQL request.")
+	}
+
+	or := r.Clone(r.Context())
+	or.Body = io.NopCloser(bytes.NewReader(operation))
+	or.ContentLength = int64(len(operation))
+	or.Header.Set("Accept", "application/json")
+	or.Header.Del("If-None-Match")
+
+	bw := &bufferedResponseWriter{header: make(http.Header), status: http.StatusOK}
+	handleGraphQLRequest(bw, or)
+
+	response := bytes.TrimSpace(bw.body.Bytes())
+	if bw.status != http.StatusOK || !gjson.ValidBytes(response) {
+		return batchErrorResponse(string(response))
+	}
+	return response
+}
+
+func batchErrorResponse(msg string) json.RawMessage {
+	msg = strings.TrimSpace(msg)
+	response, _ := json.Marshal(map[string]any{"errors": []map[string]string{{"message": msg}}})
+	return response
+}
+
+// bufferedResponseWriter holds the response of an operation of a batched request, until the batch is complete.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadBatchRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(` [{"query":"{a}"},{"query":"{b}"}]`))
+	operations, ok, err := readBatchRequest(r)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, operations, 2)
+	assert.JSONEq(t, `{"query":"{b}"}`, string(operations[1]))
+
+	// A single operation is left for the usual handling, with its body intact.
+	body := `{"query":"{a}"}`
+	r = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	_, ok, err = readBatchRequest(r)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	b, _ := io.ReadAll(r.Body)
+	assert.Equal(t, body, string(b))
+
+	r = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`[{"query":"{a}"}`))
+	_, _, err = readBatchRequest(r)
+	assert.Error(t, err)
+}
+
+func Test_HandleBatchRequest(t *testing.T) {
+	defer func(size int) { config.MaxBatchSize = size }(config.MaxBatchSize)
+	config.MaxBatchSize = 3
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`[{"query":"{a}"},"bad",{"query":"{b}"}]`))
+	w := httptest.NewRecorder()
+	GraphQLRequestHandler.ServeHTTP(w, r)
+
+	// Without an active schema, each operation gets its own error, in order.
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[
+		{"errors":[{"message":"There is no active GraphQL schema.  Please load a Modus plugin, or declare connectors in the manifest."}]},
+		{"errors":[{"message":"Failed to parse GraphQL request."}]},
+		{"errors":[{"message":"There is no active GraphQL schema.  Please load a Modus plugin, or declare connectors in the manifest."}]}
+	]`, w.Body.String())
+}
+
+func Test_HandleBatchRequest_TooLarge(t *testing.T) {
+	defer func(size int) { config.MaxBatchSize = size }(config.MaxBatchSize)
+	config.MaxBatchSize = 3
+
+	operations := strings.Repeat(`{"query":"{a}"},`, config.MaxBatchSize+1)
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("["+strings.TrimSuffix(operations, ",")+"]"))
+	w := httptest.NewRecorder()
+	GraphQLRequestHandler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/middleware"
+
+	"github.com/tidwall/gjson"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+// allFunctions is used in an authorization rule's function list to apply it to every function.
+const allFunctions = "*"
+
+// requirement is a JWT claim that the caller must have, and optionally the value it must have.
+type requirement struct {
+	claim    string
+	value    string
+	hasValue bool
+}
+
+type authorizationRules struct {
+	functions map[string][]requirement
+	fields    map[string][]requirement
+}
+
+var rules authorizationRules
+var rulesMutex sync.RWMutex
+
+// LoadAuthorizationRules reads the authorization rules of the manifest.
+func LoadAuthorizationRules(ctx context.Context) error {
+	loadAuthorizationRules(manifestdata.GetManifest().Authorization)
+	return nil
+}
+
+func loadAuthorizationRules(infos map[string]manifest.AuthorizationInfo) {
+	r := authorizationRules{
+		functions: make(map[string][]requirement),
+		fields:    make(map[string][]requirement),
+	}
+	for _, info := range infos {
+		reqs := parseRequirements(info.Requires)
+		for _, fn := range info.Functions {
+			r.functions[fn] = append(r.functions[fn], reqs...)
+		}
+		for _, field := range info.Fields {
+			r.fields[field] = append(r.fields[field], reqs...)
+		}
+	}
+
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	rules = r
+}
+
+// parseRequirements parses requirements of the form "claim" or "claim=value".
+func parseRequirements(items []string) []requirement {
+	reqs := make([]requirement, 0, len(items))
+	for _, item := range items {
+		claim, value, hasValue := strings.Cut(strings.TrimSpace(item), "=")
+		if claim = strings.TrimSpace(claim); claim != "" {
+			reqs = append(reqs, requirement{claim, strings.TrimSpace(value), hasValue})
+		}
+	}
+	return reqs
+}
+
+// meetsRequirements reports whether the JWT claims meet all of the requirements.
+func meetsRequirements(claims string, reqs []requirement) bool {
+	if len(reqs) == 0 {
+		return true
+	}
+	if claims == "" {
+		return false
+	}
+
+	for _, req := range reqs {
+		v := gjson.Get(claims, gjson.Escape(req.claim))
+		if !v.Exists() {
+			return false
+		}
+		if !req.hasValue {
+			continue
+		}
+
+		if v.IsArray() {
+			found := false
+			for _, item := range v.Array() {
+				if item.String() == req.value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		} else if v.String() != req.value {
+			return false
+		}
+	}
+	return true
+}
+
+// checkAuthorization enforces the manifest's authorization rules for the function.
+// It applies to connectors as well as functions of plugins.
+func checkAuthorization(ctx context.Context, fnName string) error {
+	rulesMutex.RLock()
+	reqs := append(slices.Clone(rules.functions[allFunctions]), rules.functions[fnName]...)
+	rulesMutex.RUnlock()
+
+	if !meetsRequirements(middleware.GetJWTClaims(ctx), reqs) {
+		return errAccessDenied
+	}
+	return nil
+}
+
+// checkRateLimit enforces the manifest's rate limits for the function, for the caller of the request.
+// When the caller exceeded a limit, the error tells them how many seconds to wait before calling it again.
+func checkRateLimit(ctx context.Context, fnName string, ci *callInfo) []resolve.GraphQLError {
+	retryAfter, ok := middleware.CheckFunctionRateLimit(ctx, fnName)
+	if ok {
+		return nil
+	}
+	return []resolve.GraphQLError{{
+		Message: "rate limit exceeded",
+		Path:    []any{ci.Function.AliasOrName()},
+		Extensions: map[string]interface{}{
+			"level":      "error",
+			"code":       "RATE_LIMITED",
+			"category":   "TOO_MANY_REQUESTS",
+			"retryAfter": middleware.RetryAfterSeconds(retryAfter),
+		},
+	}}
+}
+
+// authorizeFields enforces the manifest's authorization rules for the fields selected from the function's result.
+// Fields the caller may not read are marked as denied, so that they are null in the response, and an error is
+// returned for each of them.
+func authorizeFields(ctx context.Context, tf *fieldInfo) []resolve.GraphQLError {
+	rulesMutex.RLock()
+	fieldRules := rules.fields
+	rulesMutex.RUnlock()
+
+	if len(fieldRules) == 0 {
+		return nil
+	}
+
+	claims := middleware.GetJWTClaims(ctx)
+	return authorizeFieldsAt(claims, fieldRules, tf, []any{tf.AliasOrName()})
+}
+
+func authorizeFieldsAt(claims string, fieldRules map[string][]requirement, tf *fieldInfo, path []any) []resolve.GraphQLError {
+	var gqlErrors []resolve.GraphQLError
+	for i := range tf.Fields {
+		f := &tf.Fields[i]
+		fieldPath := append(path[:len(path):len(path)], f.AliasOrName())
+
+		if reqs, ok := fieldRules[tf.TypeName+"."+f.Name]; ok && !meetsRequirements(claims, reqs) {
+			f.denied = true
+			gqlErrors = append(gqlErrors, resolve.GraphQLError{
+				Message: errAccessDenied.Error(),
+				Path:    fieldPath,
+				Extensions: map[string]interface{}{
+					"level": "error",
+				},
+			})
+			continue
+		}
+
+		gqlErrors = append(gqlErrors, authorizeFieldsAt(claims, fieldRules, f, fieldPath)...)
+	}
+	return gqlErrors
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MeetsRequirements(t *testing.T) {
+	claims := `{"sub":"alice","role":"admin","scopes":["read","write"]}`
+
+	cases := []struct {
+		requires []string
+		expected bool
+	}{
+		{nil, true},
+		{[]string{"sub"}, true},
+		{[]string{"role=admin"}, true},
+		{[]string{"role=user"}, false},
+		{[]string{"scopes=write"}, true},
+		{[]string{"scopes=delete"}, false},
+		{[]string{"role=admin", "scopes=read"}, true},
+		{[]string{"role=admin", "tenant"}, false},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, meetsRequirements(claims, parseRequirements(tc.requires)), "%v", tc.requires)
+	}
+
+	assert.False(t, meetsRequirements("", parseRequirements([]string{"sub"})))
+}
+
+func Test_CheckAuthorization(t *testing.T) {
+	loadAuthorizationRules(map[string]manifest.AuthorizationInfo{
+		"admins": {Functions: []string{"deleteUser"}, Requires: []string{"role=admin"}},
+	})
+	t.Cleanup(func() { loadAuthorizationRules(nil) })
+
+	ctx := context.Background()
+	assert.Equal(t, errAccessDenied, checkAuthorization(ctx, "deleteUser"))
+	assert.Nil(t, checkAuthorization(ctx, "getUser"))
+}
+
+func Test_AuthorizeFields(t *testing.T) {
+	loadAuthorizationRules(map[string]manifest.AuthorizationInfo{
+		"admins": {Fields: []string{"User.email"}, Requires: []string{"role=admin"}},
+	})
+	t.Cleanup(func() { loadAuthorizationRules(nil) })
+
+	tf := &fieldInfo{
+		Name:     "getUser",
+		TypeName: "User",
+		Fields: []fieldInfo{
+			{Name: "name", TypeName: "String"},
+			{Name: "email", Alias: "contact", TypeName: "String"},
+		},
+	}
+
+	gqlErrors := authorizeFields(context.Background(), tf)
+	require.Len(t, gqlErrors, 1)
+	assert.Equal(t, "access denied", gqlErrors[0].Message)
+	assert.Equal(t, []any{"getUser", "contact"}, gqlErrors[0].Path)
+
+	result, err := transformValue([]byte(`{"name":"Alice","email":"alice@example.com"}`), tf)
+	require.Nil(t, err)
+	assert.Equal(t, `{"name":"Alice","contact":null}`, string(result))
+
+	// an administrator can read the field
+	tf.Fields[1].denied = false
+	gqlErrors = authorizeFieldsAt(`{"role":"admin"}`, rules.fields, tf, []any{"getUser"})
+	assert.Empty(t, gqlErrors)
+	assert.False(t, tf.Fields[1].denied)
+}
+
+func Test_CheckAuthDirective_Requires(t *testing.T) {
+	fn := metadata.NewFunction("deleteUser").
+		WithDirective("auth", map[string]string{"requires": "role=admin, scopes=write"})
+
+	// without claims, the requirements can't be met
+	assert.Equal(t, errAccessDenied, checkAuthDirective(context.Background(), fn))
+}
@@ -10,10 +10,25 @@
 package datasource
 
 import (
+	"github.com/hypermodeinc/modus/runtime/graphql/schemagen"
 	"github.com/hypermodeinc/modus/runtime/wasmhost"
 )
 
 type HypDSConfig struct {
-	WasmHost wasmhost.WasmHost
-	MapTypes []string
+	WasmHost   wasmhost.WasmHost
+	MapTypes   []string
+	Federation *Federation
+
+	// Namespace is set when the schema is that of a single plugin, whose functions are then called by their names
+	// qualified with the plugin's namespace, so that a function of another plugin of the same name is never called.
+	Namespace string
+}
+
+// Federation is the configuration of a federation subgraph, which is set when functions resolve entities.
+type Federation struct {
+	// SDL is the schema of the subgraph, returned by the _service field.
+	SDL string
+
+	// Entities are the functions that resolve entities, by type name.
+	Entities map[string][]*schemagen.EntityResolver
 }
@@ -0,0 +1,47 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"github.com/hypermodeinc/modus/runtime/graphql/validation"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+// checkConstraints validates the arguments against the constraints of the function's parameters,
+// before the function is invoked.  Each violation is returned as a GraphQL error, which identifies the
+// argument and the constraint in its extensions, so that clients can show the error next to the input.
+func checkConstraints(fn *metadata.Function, ci *callInfo) ([]resolve.GraphQLError, error) {
+	constraints, err := validation.GetConstraints(fn)
+	if err != nil || len(constraints) == 0 {
+		return nil, err
+	}
+
+	violations := validation.Validate(constraints, ci.Parameters)
+	if len(violations) == 0 {
+		return nil, nil
+	}
+
+	gqlErrors := make([]resolve.GraphQLError, len(violations))
+	for i, v := range violations {
+		gqlErrors[i] = resolve.GraphQLError{
+			Message: v.Message,
+			Path:    []any{ci.Function.AliasOrName()},
+			Extensions: map[string]interface{}{
+				"level":      "error",
+				"code":       "BAD_USER_INPUT",
+				"argument":   v.Argument,
+				"constraint": v.Constraint,
+			},
+		}
+	}
+	return gqlErrors, nil
+}
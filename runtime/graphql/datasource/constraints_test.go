@@ -0,0 +1,44 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CheckConstraints(t *testing.T) {
+	fn := metadata.NewFunction("setAge").
+		WithParameter("age", "int32").
+		WithDirective("validate", map[string]string{"param": "age", "min": "0"})
+
+	ci := &callInfo{
+		Function:   fieldInfo{Name: "setAge", Alias: "update"},
+		Parameters: map[string]any{"age": json.Number("-5")},
+	}
+
+	gqlErrors, err := checkConstraints(fn, ci)
+	require.Nil(t, err)
+	require.Len(t, gqlErrors, 1)
+	assert.Equal(t, "age must be at least 0", gqlErrors[0].Message)
+	assert.Equal(t, []any{"update"}, gqlErrors[0].Path)
+	assert.Equal(t, "BAD_USER_INPUT", gqlErrors[0].Extensions["code"])
+	assert.Equal(t, "age", gqlErrors[0].Extensions["argument"])
+	assert.Equal(t, "min", gqlErrors[0].Extensions["constraint"])
+
+	ci.Parameters["age"] = json.Number("5")
+	gqlErrors, err = checkConstraints(fn, ci)
+	require.Nil(t, err)
+	assert.Empty(t, gqlErrors)
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/cache"
+	"github.com/hypermodeinc/modus/runtime/functions"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/middleware"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// The directives that function authors can place on their functions.
+// They are read from the plugin metadata, and interpreted here when the function is resolved.
+const (
+	// @auth requires the request to carry a JWT.
+	// With the "claim" argument, the claim must be present, and with "value",
+	// it must equal the value (or contain it, for an array claim).
+	// The "requires" argument lists more such requirements, separated by commas, as "claim" or "claim=value".
+	authDirective = "auth"
+
+	// @cache caches the function's results for the duration given by the "ttl" argument,
+	// which is a number of seconds or a duration such as "5m". The default is one minute.
+	// Results are cached separately for each combination of arguments and JWT claims.
+	cacheDirective = "cache"
+
+	// @mask replaces the values of the string fields named in the comma-separated "fields" argument,
+	// anywhere in the function's result.
+	maskDirective = "mask"
+
+	// @paginate returns the function's list result as a connection, which clients page through
+	// with the "first" and "after" arguments.  The "limit" and "offset" arguments name parameters of the function,
+	// which are set from the client's arguments, so that the function can return just the requested page.
+	paginateDirective = "paginate"
+)
+
+const defaultCacheTTL = 1 * time.Minute
+const maxCachedResults = 10_000
+const maskedValue = "****"
+
+var errAccessDenied = errors.New("access denied")
+
+var resultCache = cache.New[any](maxCachedResults, nil)
+
+func checkAuthDirective(ctx context.Context, fn *metadata.Function) error {
+	d := fn.GetDirective(authDirective)
+	if d == nil {
+		return nil
+	}
+
+	claims := middleware.GetJWTClaims(ctx)
+	if claims == "" {
+		return errAccessDenied
+	}
+
+	var reqs []requirement
+	if claim := d.Args["claim"]; claim != "" {
+		value, hasValue := d.Args["value"]
+		reqs = append(reqs, requirement{claim, value, hasValue})
+	}
+	if requires := d.Args["requires"]; requires != "" {
+		reqs = append(reqs, parseRequirements(strings.Split(requires, ","))...)
+	}
+
+	if !meetsRequirements(claims, reqs) {
+		return errAccessDenied
+	}
+	return nil
+}
+
+// getCacheKey returns the key under which the function's result is cached,
+// or an empty string if the function's results are not cached.
+func getCacheKey(ctx context.Context, fnInfo functions.FunctionInfo, parameters map[string]any) (string, time.Duration) {
+	ttl := CacheTTL(ctx, fnInfo.Metadata())
+	if ttl <= 0 {
+		return "", 0
+	}
+
+	params, err := utils.JsonSerialize(parameters)
+	if err != nil {
+		return "", 0
+	}
+
+	// The plugin id changes when the plugin is reloaded, so results from a previous version are never used.
+	key := fnInfo.Plugin().Id + "|" + fnInfo.Name() + "|" + string(params) + "|" + middleware.GetJWTClaims(ctx)
+	return key, ttl
+}
+
+// CacheTTL returns how long the results of a function may be cached, as set by its @cache directive,
+// or zero if they are not cached.
+func CacheTTL(ctx context.Context, fn *metadata.Function) time.Duration {
+	d := fn.GetDirective(cacheDirective)
+	if d == nil {
+		return 0
+	}
+
+	ttl := defaultCacheTTL
+	if s := d.Args["ttl"]; s != "" {
+		if n, err := strconv.Atoi(s); err == nil {
+			ttl = time.Duration(n) * time.Second
+		} else if dur, err := time.ParseDuration(s); err == nil {
+			ttl = dur
+		} else {
+			logger.Warn(ctx).
+				Str("function", fn.Name).
+				Str("ttl", s).
+				Msg("Invalid ttl in @cache directive. Using the default.")
+		}
+	}
+	return max(ttl, 0)
+}
+
+func applyMaskDirective(fn *metadata.Function, result any) (any, error) {
+	d := fn.GetDirective(maskDirective)
+	if d == nil || result == nil {
+		return result, nil
+	}
+
+	fields := make(map[string]bool)
+	for _, f := range strings.Split(d.Args["fields"], ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields[f] = true
+		}
+	}
+	if len(fields) == 0 {
+		return result, nil
+	}
+
+	// Round-trip through JSON, so that any result type (including structs) can be masked by field name.
+	data, err := utils.JsonSerialize(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	if err := utils.JsonDeserialize(data, &v); err != nil {
+		return nil, err
+	}
+
+	return maskFields(v, fields), nil
+}
+
+func maskFields(v any, fields map[string]bool) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if _, ok := val.(string); ok && fields[k] {
+				t[k] = maskedValue
+			} else {
+				t[k] = maskFields(val, fields)
+			}
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = maskFields(val, fields)
+		}
+	}
+	return v
+}
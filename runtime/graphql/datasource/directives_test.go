@@ -0,0 +1,67 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPerson struct {
+	Name    string       `json:"name"`
+	Email   string       `json:"email"`
+	Age     int          `json:"age"`
+	Friends []testPerson `json:"friends"`
+}
+
+func Test_ApplyMaskDirective(t *testing.T) {
+	fn := metadata.NewFunction("getPerson").
+		WithDirective("mask", map[string]string{"fields": "email, age"})
+
+	result, err := applyMaskDirective(fn, &testPerson{
+		Name:    "Alice",
+		Email:   "alice@example.com",
+		Age:     42,
+		Friends: []testPerson{{Name: "Bob", Email: "bob@example.com"}},
+	})
+	require.Nil(t, err)
+
+	m := result.(map[string]any)
+	assert.Equal(t, "Alice", m["name"])
+	assert.Equal(t, maskedValue, m["email"])
+
+	// only string values are masked
+	assert.NotEqual(t, maskedValue, m["age"])
+
+	friend := m["friends"].([]any)[0].(map[string]any)
+	assert.Equal(t, "Bob", friend["name"])
+	assert.Equal(t, maskedValue, friend["email"])
+}
+
+func Test_ApplyMaskDirective_NoDirective(t *testing.T) {
+	fn := metadata.NewFunction("getPerson")
+	p := &testPerson{Name: "Alice", Email: "alice@example.com"}
+
+	result, err := applyMaskDirective(fn, p)
+	require.Nil(t, err)
+	assert.Same(t, p, result)
+}
+
+func Test_CheckAuthDirective_NoClaims(t *testing.T) {
+	fn := metadata.NewFunction("getSecret").WithDirective("auth", nil)
+	assert.Equal(t, errAccessDenied, checkAuthDirective(context.Background(), fn))
+
+	fn = metadata.NewFunction("getPublic")
+	assert.Nil(t, checkAuthDirective(context.Background(), fn))
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/graphql/schemagen"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+// callFederationField resolves the _service and _entities fields of a federation subgraph.
+// It returns false when the field is not one of them.
+func (ds *ModusDataSource) callFederationField(ctx context.Context, ci *callInfo) (bool, any, []resolve.GraphQLError, error) {
+	if ds.Federation == nil {
+		return false, nil, nil, nil
+	}
+
+	switch ci.Function.Name {
+	case schemagen.ServiceField:
+		return true, map[string]any{"sdl": ds.Federation.SDL}, nil, nil
+	case schemagen.EntitiesField:
+		result, gqlErrors, err := ds.resolveEntities(ctx, ci)
+		return true, result, gqlErrors, err
+	}
+	return false, nil, nil, nil
+}
+
+// resolveEntities calls the entity resolver of each representation, which is an object with the __typename
+// and key fields of an entity.  Entities that cannot be resolved are null, with an error at their position.
+func (ds *ModusDataSource) resolveEntities(ctx context.Context, ci *callInfo) ([]any, []resolve.GraphQLError, error) {
+	representations, ok := ci.Parameters["representations"].([]any)
+	if !ok {
+		return nil, nil, errors.New("the representations argument must be a list of objects")
+	}
+
+	fieldName := ci.Function.AliasOrName()
+	results := make([]any, len(representations))
+	var gqlErrors []resolve.GraphQLError
+	for i, r := range representations {
+		result, errs, err := ds.resolveEntity(ctx, r)
+		for _, e := range errs {
+			e.Path = []any{fieldName, i}
+			gqlErrors = append(gqlErrors, e)
+		}
+		if err != nil {
+			gqlErrors = append(gqlErrors, resolve.GraphQLError{
+				Message:    err.Error(),
+				Path:       []any{fieldName, i},
+				Extensions: map[string]any{"level": "error"},
+			})
+		}
+		results[i] = result
+	}
+
+	return results, gqlErrors, nil
+}
+
+func (ds *ModusDataSource) resolveEntity(ctx context.Context, representation any) (any, []resolve.GraphQLError, error) {
+	rep, ok := representation.(map[string]any)
+	if !ok {
+		return nil, nil, errors.New("the representation of an entity must be an object")
+	}
+
+	typeName, _ := rep["__typename"].(string)
+	resolver := findEntityResolver(ds.Federation.Entities[typeName], rep)
+	if resolver == nil {
+		return nil, nil, fmt.Errorf("no function resolves entities of type %s with the given key", typeName)
+	}
+
+	parameters := make(map[string]any, len(resolver.Parameters))
+	for i, p := range resolver.Parameters {
+		parameters[p] = rep[resolver.KeyFields[i]]
+	}
+
+	result, gqlErrors, err := ds.callFunction(ctx, &callInfo{
+		Function:   fieldInfo{Name: resolver.Function},
+		Parameters: parameters,
+	})
+	if err != nil || result == nil {
+		return nil, gqlErrors, err
+	}
+
+	// The result is an object, which must also have its type name, so the entity can be told apart
+	// from those of other types.
+	var entity map[string]any
+	if b, err := utils.JsonSerialize(result); err != nil {
+		return nil, gqlErrors, err
+	} else if err := utils.JsonDeserialize(b, &entity); err != nil {
+		return nil, gqlErrors, err
+	}
+	entity["__typename"] = typeName
+
+	return entity, gqlErrors, nil
+}
+
+// findEntityResolver returns the first resolver whose key fields are all in the representation.
+func findEntityResolver(resolvers []*schemagen.EntityResolver, rep map[string]any) *schemagen.EntityResolver {
+	for _, r := range resolvers {
+		found := true
+		for _, k := range r.KeyFields {
+			if _, ok := rep[k]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			return r
+		}
+	}
+	return nil
+}
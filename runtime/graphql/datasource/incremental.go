@@ -0,0 +1,162 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// IncrementalDirectives declares the directives that clients use to receive parts of a query's result
+// as they become available.  @defer delays the fields of a fragment, and @stream delays the items of a list
+// after the first initialCount items.  When the client can't receive incremental payloads,
+// the directives are ignored, and the whole result is returned at once.
+const IncrementalDirectives = `directive @defer(if: Boolean! = true, label: String) on FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+directive @stream(if: Boolean! = true, label: String, initialCount: Int! = 0) on FIELD`
+
+// streamChunkSize is the size of the serialized items that are sent together, for a streamed list.
+const streamChunkSize = 32 * 1024
+
+type itemStreamContextKey struct{}
+
+// StreamedField is a root field whose list result the client asked to receive incrementally, with @stream.
+type StreamedField struct {
+	InitialCount int
+	Label        string
+}
+
+// StreamedItems are items of a streamed field that follow its initial items, starting at the Start index.
+// If the items could not be serialized, Err is set instead, and no further items of the field are sent.
+type StreamedItems struct {
+	Field string
+	Label string
+	Start int
+	Items json.RawMessage
+	Err   error
+}
+
+// ItemStream carries the items of the streamed fields of a request, which are serialized after the initial result
+// has been resolved, a chunk at a time.
+type ItemStream struct {
+	fields map[string]StreamedField
+	items  chan StreamedItems
+	wg     sync.WaitGroup
+}
+
+// WithItemStream returns a context for a request, whose fields of the given response keys are streamed.
+func WithItemStream(ctx context.Context, fields map[string]StreamedField) (context.Context, *ItemStream) {
+	s := &ItemStream{fields: fields, items: make(chan StreamedItems)}
+	return context.WithValue(ctx, itemStreamContextKey{}, s), s
+}
+
+// Items returns the channel that the streamed items are sent to.
+func (s *ItemStream) Items() <-chan StreamedItems {
+	return s.items
+}
+
+// Close closes the channel of items once all of them have been sent.  It must be called after all of the
+// operations of the request have been executed, since any of them may resolve a streamed field.
+func (s *ItemStream) Close() {
+	go func() {
+		s.wg.Wait()
+		close(s.items)
+	}()
+}
+
+// streamedList is the remainder of a streamed field's list, after its initial items.
+type streamedList struct {
+	field StreamedField
+	items reflect.Value
+}
+
+// holdBackStreamedItems returns the initial items of a function's list result, when the client asked to
+// stream the field.  The remaining items are returned separately, to be sent once the initial result is written.
+func holdBackStreamedItems(ctx context.Context, ci *callInfo, result any) (any, *streamedList) {
+	s, ok := ctx.Value(itemStreamContextKey{}).(*ItemStream)
+	if !ok || result == nil {
+		return result, nil
+	}
+	field, ok := s.fields[ci.Function.AliasOrName()]
+	if !ok {
+		return result, nil
+	}
+
+	items := reflect.ValueOf(result)
+	if (items.Kind() != reflect.Slice && items.Kind() != reflect.Array) || items.Len() <= field.InitialCount {
+		return result, nil
+	}
+
+	initial := make([]any, field.InitialCount)
+	for i := range initial {
+		initial[i] = items.Index(i).Interface()
+	}
+	return initial, &streamedList{field, items}
+}
+
+// send serializes the remaining items of a streamed field in the background, and sends them to the item stream
+// in chunks.  The fields of the items are transformed the same way as those of the initial items.
+func (l *streamedList) send(ctx context.Context, ci *callInfo) {
+	s, ok := ctx.Value(itemStreamContextKey{}).(*ItemStream)
+	if !ok {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		fieldName := ci.Function.AliasOrName()
+		emit := func(items StreamedItems) bool {
+			items.Field = fieldName
+			items.Label = l.field.Label
+			select {
+			case s.items <- items:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var buf bytes.Buffer
+		start := l.field.InitialCount
+		for i := start; i < l.items.Len(); i++ {
+			data, err := utils.JsonSerialize(l.items.Index(i).Interface())
+			if err == nil {
+				data, err = transformValue(data, &ci.Function)
+			}
+			if err != nil {
+				emit(StreamedItems{Start: i, Err: err})
+				return
+			}
+
+			if buf.Len() == 0 {
+				buf.WriteByte('[')
+			} else {
+				buf.WriteByte(',')
+			}
+			buf.Write(data)
+
+			if buf.Len() >= streamChunkSize || i == l.items.Len()-1 {
+				buf.WriteByte(']')
+				if !emit(StreamedItems{Start: start, Items: bytes.Clone(buf.Bytes())}) {
+					return
+				}
+				buf.Reset()
+				start = i + 1
+			}
+		}
+	}()
+}
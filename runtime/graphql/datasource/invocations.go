@@ -0,0 +1,81 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"context"
+	"sync"
+)
+
+type invocationBudgetContextKey struct{}
+
+// invocationBudget bounds the functions that a request invokes concurrently.  The engine resolves the root fields
+// of an operation concurrently, but the root fields of a mutation must take effect in order, so they take turns.
+type invocationBudget struct {
+	slots chan struct{}
+
+	mu    sync.Mutex
+	turns map[int]chan struct{}
+}
+
+// WithInvocationBudget returns a context for a request, whose functions are invoked at most limit at a time.
+func WithInvocationBudget(ctx context.Context, limit int) context.Context {
+	b := &invocationBudget{turns: make(map[int]chan struct{})}
+	if limit > 0 {
+		b.slots = make(chan struct{}, limit)
+	}
+	return context.WithValue(ctx, invocationBudgetContextKey{}, b)
+}
+
+// acquireInvocation waits until the request may invoke another function.  The returned function must be called
+// when the invocation completes.
+func acquireInvocation(ctx context.Context) (release func(), err error) {
+	b, ok := ctx.Value(invocationBudgetContextKey{}).(*invocationBudget)
+	if !ok || b.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return func() { <-b.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// awaitTurn waits until the root fields of a mutation before the given position have been resolved.
+// Positions start at one.  The returned function must be called when the field has been resolved.
+func awaitTurn(ctx context.Context, position int) (done func(), err error) {
+	b, ok := ctx.Value(invocationBudgetContextKey{}).(*invocationBudget)
+	if !ok || position <= 0 {
+		return func() {}, nil
+	}
+
+	if position > 1 {
+		select {
+		case <-b.turn(position):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return func() { close(b.turn(position + 1)) }, nil
+}
+
+// turn returns a channel that is closed when the field at the given position may be resolved.
+func (b *invocationBudget) turn(position int) chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch, ok := b.turns[position]
+	if !ok {
+		ch = make(chan struct{})
+		b.turns[position] = ch
+	}
+	return ch
+}
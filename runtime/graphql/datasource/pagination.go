@@ -0,0 +1,155 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+const cursorPrefix = "cursor:"
+
+// pageRequest is the page of a paginated function's result that the client requested.
+type pageRequest struct {
+	// offset is the position of the first item of the page, in the whole list.
+	offset int
+
+	// first is the number of items in the page, or -1 for all of the remaining items.
+	first int
+
+	// start is the index of the first item of the page in the function's result,
+	// which is zero when the function has already skipped to the offset.
+	start int
+
+	// complete is true when the function returns the whole list, so the total count is known.
+	complete bool
+}
+
+// preparePagination replaces the pagination arguments of the client with the parameters of the function
+// that the @paginate directive names, and records the page to take from the function's result.
+func preparePagination(fn *metadata.Function, ci *callInfo) error {
+	d := fn.GetDirective(paginateDirective)
+	if d == nil {
+		return nil
+	}
+
+	if ci.Parameters == nil {
+		ci.Parameters = make(map[string]any)
+	}
+	parameters := ci.Parameters
+	page := &pageRequest{first: -1}
+
+	if v := parameters["first"]; v != nil {
+		first, err := utils.Cast[int](v)
+		if err != nil || first < 0 {
+			return errors.New("the first argument must be a non-negative integer")
+		}
+		page.first = first
+	}
+
+	if v := parameters["after"]; v != nil {
+		cursor, _ := v.(string)
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return err
+		}
+		page.offset = after + 1
+	}
+
+	delete(parameters, "first")
+	delete(parameters, "after")
+
+	limitParam, offsetParam := d.Args["limit"], d.Args["offset"]
+	if offsetParam != "" {
+		parameters[offsetParam] = page.offset
+	} else {
+		page.start = page.offset
+	}
+	if limitParam != "" && page.first >= 0 {
+		// One more item than requested tells whether there is a next page.
+		parameters[limitParam] = page.start + page.first + 1
+	}
+	page.complete = limitParam == "" && offsetParam == ""
+
+	ci.page = page
+	return nil
+}
+
+// connection builds the connection for the page of items from the function's result.
+func (p *pageRequest) connection(result any) (any, error) {
+	items := reflect.ValueOf(result)
+	switch {
+	case result == nil:
+		items = reflect.ValueOf([]any{})
+	case items.Kind() != reflect.Slice && items.Kind() != reflect.Array:
+		return nil, fmt.Errorf("expected a list result to paginate, but got %T", result)
+	}
+
+	count := items.Len()
+	start := min(p.start, count)
+	end := count
+	if p.first >= 0 {
+		end = min(start+p.first, count)
+	}
+
+	edges := make([]map[string]any, 0, end-start)
+	for i := start; i < end; i++ {
+		edges = append(edges, map[string]any{
+			"node":   items.Index(i).Interface(),
+			"cursor": encodeCursor(p.offset + i - start),
+		})
+	}
+
+	pageInfo := map[string]any{
+		"hasNextPage":     end < count,
+		"hasPreviousPage": p.offset > 0,
+		"startCursor":     nil,
+		"endCursor":       nil,
+	}
+	if len(edges) > 0 {
+		pageInfo["startCursor"] = edges[0]["cursor"]
+		pageInfo["endCursor"] = edges[len(edges)-1]["cursor"]
+	}
+
+	var totalCount any
+	if p.complete {
+		totalCount = count
+	}
+
+	return map[string]any{
+		"edges":      edges,
+		"pageInfo":   pageInfo,
+		"totalCount": totalCount,
+	}, nil
+}
+
+// Cursors are opaque to clients, but are just the position of the item in the list.
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	b, err := base64.StdEncoding.DecodeString(cursor)
+	if err == nil {
+		if s, ok := strings.CutPrefix(string(b), cursorPrefix); ok {
+			if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+				return n, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor: %s", cursor)
+}
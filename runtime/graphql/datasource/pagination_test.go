@@ -0,0 +1,96 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Pagination(t *testing.T) {
+	fn := metadata.NewFunction("listTags").WithDirective("paginate", nil)
+	tags := []string{"a", "b", "c", "d", "e"}
+
+	// first page
+	ci := &callInfo{Parameters: map[string]any{"first": json.Number("2")}}
+	require.Nil(t, preparePagination(fn, ci))
+	assert.Empty(t, ci.Parameters)
+
+	result, err := ci.page.connection(tags)
+	require.Nil(t, err)
+	assertConnection(t, result, `{
+		"edges":[{"cursor":"Y3Vyc29yOjA=","node":"a"},{"cursor":"Y3Vyc29yOjE=","node":"b"}],
+		"pageInfo":{"endCursor":"Y3Vyc29yOjE=","hasNextPage":true,"hasPreviousPage":false,"startCursor":"Y3Vyc29yOjA="},
+		"totalCount":5
+	}`)
+
+	// last page, after the cursor of the second item
+	ci = &callInfo{Parameters: map[string]any{"first": json.Number("3"), "after": "Y3Vyc29yOjE="}}
+	require.Nil(t, preparePagination(fn, ci))
+
+	result, err = ci.page.connection(tags)
+	require.Nil(t, err)
+	assertConnection(t, result, `{
+		"edges":[{"cursor":"Y3Vyc29yOjI=","node":"c"},{"cursor":"Y3Vyc29yOjM=","node":"d"},{"cursor":"Y3Vyc29yOjQ=","node":"e"}],
+		"pageInfo":{"endCursor":"Y3Vyc29yOjQ=","hasNextPage":false,"hasPreviousPage":true,"startCursor":"Y3Vyc29yOjI="},
+		"totalCount":5
+	}`)
+
+	// past the end
+	ci = &callInfo{Parameters: map[string]any{"after": encodeCursor(10)}}
+	require.Nil(t, preparePagination(fn, ci))
+
+	result, err = ci.page.connection(tags)
+	require.Nil(t, err)
+	assertConnection(t, result, `{
+		"edges":[],
+		"pageInfo":{"endCursor":null,"hasNextPage":false,"hasPreviousPage":true,"startCursor":null},
+		"totalCount":5
+	}`)
+}
+
+func Test_Pagination_MappedParameters(t *testing.T) {
+	fn := metadata.NewFunction("searchUsers").
+		WithDirective("paginate", map[string]string{"limit": "limit", "offset": "offset"})
+
+	ci := &callInfo{Parameters: map[string]any{"query": "a", "first": json.Number("2"), "after": encodeCursor(3)}}
+	require.Nil(t, preparePagination(fn, ci))
+	assert.Equal(t, map[string]any{"query": "a", "limit": 3, "offset": 4}, ci.Parameters)
+
+	// the function returns one more item than requested, from the offset
+	result, err := ci.page.connection([]string{"e", "f", "g"})
+	require.Nil(t, err)
+	assertConnection(t, result, `{
+		"edges":[{"cursor":"Y3Vyc29yOjQ=","node":"e"},{"cursor":"Y3Vyc29yOjU=","node":"f"}],
+		"pageInfo":{"endCursor":"Y3Vyc29yOjU=","hasNextPage":true,"hasPreviousPage":true,"startCursor":"Y3Vyc29yOjQ="},
+		"totalCount":null
+	}`)
+}
+
+func Test_Pagination_InvalidArguments(t *testing.T) {
+	fn := metadata.NewFunction("listTags").WithDirective("paginate", nil)
+
+	err := preparePagination(fn, &callInfo{Parameters: map[string]any{"after": "bogus"}})
+	assert.ErrorContains(t, err, "invalid cursor")
+
+	err = preparePagination(fn, &callInfo{Parameters: map[string]any{"first": json.Number("-1")}})
+	assert.ErrorContains(t, err, "non-negative")
+}
+
+func assertConnection(t *testing.T, result any, expected string) {
+	actual, err := utils.JsonSerialize(result)
+	require.Nil(t, err)
+	assert.JSONEq(t, expected, string(actual))
+}
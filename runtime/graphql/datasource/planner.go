@@ -11,9 +11,11 @@ package datasource
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 
 	"github.com/hypermodeinc/modus/runtime/logger"
 	"github.com/hypermodeinc/modus/runtime/utils"
@@ -24,14 +26,17 @@ import (
 )
 
 type HypDSPlanner struct {
-	ctx       context.Context
-	config    HypDSConfig
-	visitor   *plan.Visitor
-	variables resolve.Variables
-	fields    map[int]fieldInfo
-	template  struct {
-		function *fieldInfo
-		data     []byte
+	ctx            context.Context
+	config         HypDSConfig
+	visitor        *plan.Visitor
+	variables      resolve.Variables
+	fields         map[int]fieldInfo
+	typeConditions map[int]string // the types of the inline fragments that enclose fields
+	template       struct {
+		function  *fieldInfo
+		data      []byte
+		position  int
+		typeNames map[string]string
 	}
 }
 
@@ -42,7 +47,9 @@ type fieldInfo struct {
 	TypeName  string      `json:"type,omitempty"`
 	Fields    []fieldInfo `json:"fields,omitempty"`
 	IsMapType bool        `json:"isMapType,omitempty"`
+	OnType    string      `json:"on,omitempty"`
 	fieldRefs []int       `json:"-"`
+	denied    bool        `json:"-"`
 }
 
 func (t *fieldInfo) AliasOrName() string {
@@ -88,6 +95,7 @@ func (p *HypDSPlanner) Register(visitor *plan.Visitor, configuration plan.DataSo
 
 func (p *HypDSPlanner) EnterDocument(operation, definition *ast.Document) {
 	p.fields = make(map[int]fieldInfo, len(operation.Fields))
+	p.typeConditions = make(map[int]string)
 }
 
 func (p *HypDSPlanner) EnterField(ref int) {
@@ -99,8 +107,20 @@ func (p *HypDSPlanner) EnterField(ref int) {
 	// If the field is enclosed by a root node, then it represents the function we want to call.
 	if p.enclosingTypeIsRootNode() {
 
+		// The __typename field of the root type can share the planner of a function's field.
+		// It is answered along with the function's result, rather than by calling a function.
+		if f.Name == "__typename" {
+			if p.template.typeNames == nil {
+				p.template.typeNames = make(map[string]string)
+			}
+			definition := p.visitor.Definition
+			p.template.typeNames[f.AliasOrName()] = definition.NodeNameString(p.visitor.Walker.EnclosingTypeDefinition)
+			return
+		}
+
 		// Save the field for the function.
 		p.template.function = f
+		p.template.position = p.mutationPosition(ref)
 
 		// Also capture the input data for the function.
 		err := p.captureInputData(ref)
@@ -113,7 +133,9 @@ func (p *HypDSPlanner) EnterField(ref int) {
 
 func (p *HypDSPlanner) LeaveDocument(operation, definition *ast.Document) {
 	// Stitch the captured fields together to form a tree.
-	p.stitchFields(p.template.function)
+	if p.template.function != nil {
+		p.stitchFields(p.template.function)
+	}
 }
 
 func (p *HypDSPlanner) stitchFields(f *fieldInfo) {
@@ -124,19 +146,18 @@ func (p *HypDSPlanner) stitchFields(f *fieldInfo) {
 	f.Fields = make([]fieldInfo, len(f.fieldRefs))
 	for i, ref := range f.fieldRefs {
 		field := p.fields[ref]
+		field.OnType = p.typeConditions[ref]
 		p.stitchFields(&field)
 		f.Fields[i] = field
 	}
 }
 
 func (p *HypDSPlanner) enclosingTypeIsRootNode() bool {
-	enclosingTypeDef := p.visitor.Walker.EnclosingTypeDefinition
-	for _, node := range p.visitor.Operation.RootNodes {
-		if node.Ref == enclosingTypeDef.Ref {
-			return true
-		}
-	}
-	return false
+	definition := p.visitor.Definition
+	name := definition.NodeNameBytes(p.visitor.Walker.EnclosingTypeDefinition)
+	return bytes.Equal(name, definition.Index.QueryTypeName) ||
+		bytes.Equal(name, definition.Index.MutationTypeName) ||
+		bytes.Equal(name, definition.Index.SubscriptionTypeName)
 }
 
 func (p *HypDSPlanner) captureField(ref int) *fieldInfo {
@@ -159,13 +180,40 @@ func (p *HypDSPlanner) captureField(ref int) *fieldInfo {
 	if operation.FieldHasSelections(ref) {
 		ssRef, ok := operation.FieldSelectionSet(ref)
 		if ok {
-			f.fieldRefs = operation.SelectionSetFieldSelections(ssRef)
+			f.fieldRefs = p.captureSelections(ssRef, "")
 		}
 	}
 
 	return f
 }
 
+// captureSelections returns the fields of a selection set, including those of inline fragments,
+// which are only included in the result for objects of the fragment's type.
+func (p *HypDSPlanner) captureSelections(ssRef int, onType string) []int {
+	operation := p.visitor.Operation
+
+	var refs []int
+	for _, sel := range operation.SelectionSets[ssRef].SelectionRefs {
+		selection := operation.Selections[sel]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			refs = append(refs, selection.Ref)
+			if onType != "" {
+				p.typeConditions[selection.Ref] = onType
+			}
+		case ast.SelectionKindInlineFragment:
+			fragmentType := onType
+			if operation.InlineFragmentHasTypeCondition(selection.Ref) {
+				fragmentType = operation.InlineFragmentTypeConditionNameString(selection.Ref)
+			}
+			if fragmentSS, ok := operation.InlineFragmentSelectionSet(selection.Ref); ok {
+				refs = append(refs, p.captureSelections(fragmentSS, fragmentType)...)
+			}
+		}
+	}
+	return refs
+}
+
 func (p *HypDSPlanner) captureInputData(fieldRef int) error {
 	operation := p.visitor.Operation
 	variables := resolve.NewVariables()
@@ -208,22 +256,13 @@ func (p *HypDSPlanner) captureInputData(fieldRef int) error {
 }
 
 func (p *HypDSPlanner) ConfigureFetch() resolve.FetchConfiguration {
-	fnJson, err := utils.JsonSerialize(p.template.function)
-	if err != nil {
-		logger.Error(p.ctx).Err(err).Msg("Error serializing json while configuring graphql fetch.")
-		return resolve.FetchConfiguration{}
-	}
-
-	// Note: we have to build the rest of the template manually, because the data field may
-	// contain placeholders for variables, such as $$0$$ which are not valid in JSON.
-	// They are replaced with the actual values by the time Load is called.
-	inputTemplate := fmt.Sprintf(`{"fn":%s,"data":%s}`, fnJson, p.template.data)
-
 	return resolve.FetchConfiguration{
-		Input:     inputTemplate,
+		Input:     p.inputTemplate(),
 		Variables: p.variables,
 		DataSource: &ModusDataSource{
-			WasmHost: p.config.WasmHost,
+			WasmHost:   p.config.WasmHost,
+			Federation: p.config.Federation,
+			Namespace:  p.config.Namespace,
 		},
 		PostProcessing: resolve.PostProcessingConfiguration{
 			SelectResponseDataPath:   []string{"data"},
@@ -233,5 +272,70 @@ func (p *HypDSPlanner) ConfigureFetch() resolve.FetchConfiguration {
 }
 
 func (p *HypDSPlanner) ConfigureSubscription() plan.SubscriptionConfiguration {
-	panic("subscription not implemented")
+	return plan.SubscriptionConfiguration{
+		Input:     p.inputTemplate(),
+		Variables: p.variables,
+		DataSource: &ModusSubscriptionSource{
+			ModusDataSource{WasmHost: p.config.WasmHost, Federation: p.config.Federation, Namespace: p.config.Namespace},
+		},
+		PostProcessing: resolve.PostProcessingConfiguration{
+			SelectResponseDataPath:   []string{"data"},
+			SelectResponseErrorsPath: []string{"errors"},
+		},
+	}
+}
+
+func (p *HypDSPlanner) inputTemplate() string {
+	fnJson, err := utils.JsonSerialize(p.template.function)
+	if err != nil {
+		logger.Error(p.ctx).Err(err).Msg("Error serializing json while configuring graphql fetch.")
+		return ""
+	}
+
+	// Note: we have to build the rest of the template manually, because the data field may
+	// contain placeholders for variables, such as $$0$$ which are not valid in JSON.
+	// They are replaced with the actual values by the time Load is called.
+	var buf strings.Builder
+	fmt.Fprintf(&buf, `{"fn":%s,"data":%s`, fnJson, cmp.Or(string(p.template.data), "{}"))
+	if p.template.position > 0 {
+		fmt.Fprintf(&buf, `,"position":%d`, p.template.position)
+	}
+	if len(p.template.typeNames) > 0 {
+		typeNamesJson, err := utils.JsonSerialize(p.template.typeNames)
+		if err != nil {
+			logger.Error(p.ctx).Err(err).Msg("Error serializing json while configuring graphql fetch.")
+			return ""
+		}
+		fmt.Fprintf(&buf, `,"typeNames":%s`, typeNamesJson)
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// mutationPosition returns the position of a root field of a mutation, starting at one,
+// or zero if the operation is not a mutation.
+func (p *HypDSPlanner) mutationPosition(ref int) int {
+	operation := p.visitor.Operation
+	ancestors := p.visitor.Walker.Ancestors
+	if len(ancestors) == 0 || ancestors[0].Kind != ast.NodeKindOperationDefinition {
+		return 0
+	}
+
+	op := operation.OperationDefinitions[ancestors[0].Ref]
+	if op.OperationType != ast.OperationTypeMutation {
+		return 0
+	}
+	// Fields that are not resolved by a function, such as __typename, don't take a turn.
+	position := 0
+	for _, sel := range operation.SelectionSetFieldSelections(op.SelectionSet) {
+		fieldRef := operation.Selections[sel].Ref
+		if strings.HasPrefix(operation.FieldNameString(fieldRef), "__") {
+			continue
+		}
+		position++
+		if fieldRef == ref {
+			return position
+		}
+	}
+	return 0
 }
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/functions"
+	"github.com/hypermodeinc/modus/runtime/langsupport"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+
+	"github.com/buger/jsonparser"
+)
+
+// The custom scalars of the generated schema, which need coercion between their GraphQL and guest representations.
+// DateTime values are RFC 3339 strings in both directions, so they need nothing more than the type handlers provide.
+const (
+	bigIntScalar = "BigInt"
+	bytesScalar  = "Bytes"
+)
+
+// coerceInputs converts the arguments of a function call from their GraphQL representation to the values that
+// the type handlers expect.  BigInt strings are parsed by the handlers, but Bytes arguments are base64 strings,
+// which would otherwise be taken as the raw bytes of the string.
+func coerceInputs(fnInfo functions.FunctionInfo, parameters map[string]any) error {
+	params := fnInfo.Metadata().Parameters
+	if len(params) == 0 || len(parameters) == 0 {
+		return nil
+	}
+
+	plugin := fnInfo.Plugin()
+	lti := plugin.Language.TypeInfo()
+	for _, p := range params {
+		val, ok := parameters[p.Name]
+		if !ok {
+			continue
+		}
+		v, err := coerceInput(val, p.Type, lti, plugin.Metadata)
+		if err != nil {
+			return fmt.Errorf("invalid value for argument %s: %w", p.Name, err)
+		}
+		parameters[p.Name] = v
+	}
+	return nil
+}
+
+func coerceInput(val any, typ string, lti langsupport.LanguageTypeInfo, md *metadata.Metadata) (any, error) {
+	if val == nil {
+		return nil, nil
+	}
+
+	for lti.IsNullableType(typ) {
+		t := lti.GetUnderlyingType(typ)
+		if t == typ {
+			break
+		}
+		typ = t
+	}
+
+	switch {
+	case lti.IsByteSequenceType(typ):
+		if s, ok := val.(string); ok {
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, fmt.Errorf("expected a base64 encoded string")
+			}
+			return b, nil
+		}
+
+	case lti.IsListType(typ):
+		if items, ok := val.([]any); ok {
+			elemType := lti.GetListSubtype(typ)
+			for i, item := range items {
+				v, err := coerceInput(item, elemType, lti, md)
+				if err != nil {
+					return nil, err
+				}
+				items[i] = v
+			}
+		}
+
+	case lti.IsObjectType(typ) && !lti.IsMapType(typ):
+		obj, ok := val.(map[string]any)
+		if !ok {
+			break
+		}
+		def, err := md.GetTypeDefinition(typ)
+		if err != nil {
+			break
+		}
+		for _, f := range def.Fields {
+			if fv, ok := obj[f.Name]; ok {
+				v, err := coerceInput(fv, f.Type, lti, md)
+				if err != nil {
+					return nil, err
+				}
+				obj[f.Name] = v
+			}
+		}
+	}
+
+	return val, nil
+}
+
+// transformScalar converts a leaf value of the function's result to its GraphQL representation.
+func transformScalar(data []byte, tf *fieldInfo) ([]byte, error) {
+	switch tf.TypeName {
+	case bigIntScalar:
+		// 64-bit integers are strings, as they can exceed the precision of numbers in JSON clients.
+		if data[0] == '[' {
+			return transformArray(data, tf)
+		}
+		if data[0] != '"' {
+			return []byte(`"` + string(data) + `"`), nil
+		}
+
+	case bytesScalar:
+		// Byte slices are already base64 strings, but fixed-size byte arrays are serialized as arrays of numbers.
+		if data[0] != '[' {
+			break
+		}
+		var b []byte
+		var isBytes = true
+		_, err := jsonparser.ArrayEach(data, func(val []byte, dt jsonparser.ValueType, _ int, _ error) {
+			if dt != jsonparser.Number {
+				isBytes = false
+				return
+			}
+			n, err := jsonparser.ParseInt(val)
+			if err != nil || n < 0 || n > 255 {
+				isBytes = false
+				return
+			}
+			b = append(b, byte(n))
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !isBytes {
+			return transformArray(data, tf)
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('"')
+		buf.WriteString(base64.StdEncoding.EncodeToString(b))
+		buf.WriteByte('"')
+		return buf.Bytes(), nil
+	}
+
+	return data, nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/languages"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TransformValue_Scalars(t *testing.T) {
+	cases := []struct {
+		typeName string
+		data     string
+		expected string
+	}{
+		{"BigInt", `9223372036854775807`, `"9223372036854775807"`},
+		{"BigInt", `[1,null,18446744073709551615]`, `["1",null,"18446744073709551615"]`},
+		{"Bytes", `"AQID"`, `"AQID"`},
+		{"Bytes", `[1,2,3]`, `"AQID"`},
+		{"Bytes", `["AQID","BAU="]`, `["AQID","BAU="]`},
+		{"DateTime", `"2024-01-02T03:04:05Z"`, `"2024-01-02T03:04:05Z"`},
+		{"Int", `42`, `42`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.typeName+" "+tc.data, func(t *testing.T) {
+			result, err := transformValue([]byte(tc.data), &fieldInfo{Name: "f", TypeName: tc.typeName})
+			require.Nil(t, err)
+			assert.Equal(t, tc.expected, string(result))
+		})
+	}
+}
+
+func Test_TransformValue_ScalarFields(t *testing.T) {
+	tf := &fieldInfo{
+		Name:     "account",
+		TypeName: "Account",
+		Fields: []fieldInfo{
+			{Name: "id", TypeName: "BigInt"},
+			{Name: "avatar", TypeName: "Bytes"},
+		},
+	}
+
+	result, err := transformValue([]byte(`{"id":12345678901234,"avatar":"AQID"}`), tf)
+	require.Nil(t, err)
+	assert.Equal(t, `{"id":"12345678901234","avatar":"AQID"}`, string(result))
+}
+
+func Test_CoerceInput(t *testing.T) {
+	lti := languages.GoLang().TypeInfo()
+	md := metadata.NewPluginMetadata()
+	md.Types["testdata.File"] = &metadata.TypeDefinition{
+		Name: "testdata.File",
+		Fields: []*metadata.Field{
+			{Name: "name", Type: "string"},
+			{Name: "content", Type: "[]byte"},
+		},
+	}
+
+	v, err := coerceInput("AQID", "[]byte", lti, md)
+	require.Nil(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, v)
+
+	v, err = coerceInput([]any{"AQID", nil}, "[]*[]byte", lti, md)
+	require.Nil(t, err)
+	assert.Equal(t, []any{[]byte{1, 2, 3}, nil}, v)
+
+	v, err = coerceInput(map[string]any{"name": "a.bin", "content": "AQID"}, "*testdata.File", lti, md)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]any{"name": "a.bin", "content": []byte{1, 2, 3}}, v)
+
+	// BigInt strings are left for the type handlers to parse
+	v, err = coerceInput("18446744073709551615", "uint64", lti, md)
+	require.Nil(t, err)
+	assert.Equal(t, "18446744073709551615", v)
+
+	_, err = coerceInput("not base64!", "[]byte", lti, md)
+	assert.ErrorContains(t, err, "base64")
+}
@@ -15,7 +15,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"sync"
 
+	"github.com/hypermodeinc/modus/runtime/connectors"
+	"github.com/hypermodeinc/modus/runtime/guards"
 	"github.com/hypermodeinc/modus/runtime/logger"
 	"github.com/hypermodeinc/modus/runtime/utils"
 	"github.com/hypermodeinc/modus/runtime/wasmhost"
@@ -30,10 +34,32 @@ const DataSourceName = "ModusDataSource"
 type callInfo struct {
 	Function   fieldInfo      `json:"fn"`
 	Parameters map[string]any `json:"data"`
+
+	// Position is the position of a root field of a mutation, starting at one, which orders their execution.
+	Position int `json:"position,omitempty"`
+
+	// TypeNames are the __typename fields of the root type that share the fetch of the function, by alias.
+	TypeNames map[string]string `json:"typeNames,omitempty"`
+
+	page *pageRequest
 }
 
+// outputMutex guards the function output map of a request, whose root fields are resolved concurrently.
+var outputMutex sync.Mutex
+
 type ModusDataSource struct {
-	WasmHost wasmhost.WasmHost
+	WasmHost   wasmhost.WasmHost
+	Federation *Federation
+	Namespace  string
+}
+
+// qualifiedName returns the name that a function is registered by, which is qualified with the namespace
+// of its plugin when the schema is that of a single plugin.
+func (ds *ModusDataSource) qualifiedName(fnName string) string {
+	if ds.Namespace == "" {
+		return fnName
+	}
+	return ds.Namespace + "_" + fnName
 }
 
 func (ds *ModusDataSource) Load(ctx context.Context, input []byte, out *bytes.Buffer) error {
@@ -45,16 +71,50 @@ func (ds *ModusDataSource) Load(ctx context.Context, input []byte, out *bytes.Bu
 		return fmt.Errorf("error parsing input: %w", err)
 	}
 
+	// A fetch of only the __typename field of the root type doesn't call a function.
+	if ci.Function.Name == "" {
+		data, err := utils.JsonSerialize(ci.TypeNames)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, `{"data":%s}`, data)
+		return nil
+	}
+
+	// The root fields of a mutation are resolved in order.
+	done, err := awaitTurn(ctx, ci.Position)
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	// Load the data
 	result, gqlErrors, err := ds.callFunction(ctx, &ci)
 
+	// Take the requested page of a paginated function's result.
+	if err == nil && ci.page != nil {
+		result, err = ci.page.connection(result)
+	}
+
+	// Hold back the items of a list that the client asked to stream, after the initial items.
+	var streamed *streamedList
+	if err == nil {
+		result, streamed = holdBackStreamedItems(ctx, &ci, result)
+	}
+
 	// Write the response
 	err = writeGraphQLResponse(ctx, out, result, gqlErrors, err, &ci)
 	if err != nil {
 		logger.Error(ctx).Err(err).Msg("Error creating GraphQL response.")
+		return err
 	}
 
-	return err
+	// Send the held back items, once the fields that the caller may read have been determined.
+	if streamed != nil {
+		streamed.send(ctx, &ci)
+	}
+
+	return nil
 }
 
 func (*ModusDataSource) LoadWithFiles(ctx context.Context, input []byte, files []httpclient.File, out *bytes.Buffer) (err error) {
@@ -64,14 +124,66 @@ func (*ModusDataSource) LoadWithFiles(ctx context.Context, input []byte, files [
 
 func (ds *ModusDataSource) callFunction(ctx context.Context, callInfo *callInfo) (any, []resolve.GraphQLError, error) {
 
+	// The fields of a federation subgraph are resolved by the runtime, rather than by a function.
+	if ok, result, gqlErrors, err := ds.callFederationField(ctx, callInfo); ok {
+		return result, gqlErrors, err
+	}
+
+	// Check the manifest guards before doing anything else, as they may reject or reroute the invocation.
+	fnName, err := guards.Check(ctx, callInfo.Function.Name, callInfo.Parameters)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkAuthorization(ctx, fnName); err != nil {
+		return nil, nil, err
+	}
+	if gqlErrors := checkRateLimit(ctx, fnName, callInfo); len(gqlErrors) > 0 {
+		return nil, gqlErrors, nil
+	}
+
 	// Get the function info
-	fnInfo, err := ds.WasmHost.GetFunctionInfo(callInfo.Function.Name)
+	fnInfo, err := ds.WasmHost.GetFunctionInfo(ds.qualifiedName(fnName))
 	if err != nil {
+		// If there's no function, the field may be served by a connector instead.
+		if connector, ok := connectors.GetConnector(fnName); ok {
+			result, err := connectors.Invoke(ctx, connector, callInfo.Parameters)
+			return result, nil, err
+		}
+		return nil, nil, err
+	}
+
+	// Apply the directives that take effect before the function is called.
+	fnMeta := fnInfo.Metadata()
+	if err := checkAuthDirective(ctx, fnMeta); err != nil {
+		return nil, nil, err
+	}
+
+	if gqlErrors, err := checkConstraints(fnMeta, callInfo); len(gqlErrors) > 0 || err != nil {
+		return nil, gqlErrors, err
+	}
+
+	if err := preparePagination(fnMeta, callInfo); err != nil {
+		return nil, nil, err
+	}
+
+	cacheKey, cacheTTL := getCacheKey(ctx, fnInfo, callInfo.Parameters)
+	if cacheKey != "" {
+		if result, ok := resultCache.Get(cacheKey); ok {
+			return result, nil, nil
+		}
+	}
+
+	if err := coerceInputs(fnInfo, callInfo.Parameters); err != nil {
 		return nil, nil, err
 	}
 
-	// Call the function
+	// Call the function, within the request's budget of concurrent invocations
+	release, err := acquireInvocation(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 	execInfo, err := ds.WasmHost.CallFunction(ctx, fnInfo, callInfo.Parameters)
+	release()
 	if err != nil {
 		// The full error message has already been logged.  Return a generic error to the caller, which will be included in the response.
 		return nil, nil, errors.New("error calling function")
@@ -79,18 +191,18 @@ func (ds *ModusDataSource) callFunction(ctx context.Context, callInfo *callInfo)
 
 	// Store the execution info into the function output map.
 	outputMap := ctx.Value(utils.FunctionOutputContextKey).(map[string]wasmhost.ExecutionInfo)
+	outputMutex.Lock()
 	outputMap[callInfo.Function.AliasOrName()] = execInfo
+	outputMutex.Unlock()
 
-	// Transform messages (and error lines in the output buffers) to GraphQL errors.
-	messages := append(execInfo.Messages(), utils.TransformConsoleOutput(execInfo.Buffers())...)
-	gqlErrors := transformErrors(messages, callInfo)
+	// Transform error messages, including error lines of the console output, to GraphQL errors.
+	gqlErrors := transformErrors(execInfo.Messages(), callInfo)
 
 	// Get the result.
 	result := execInfo.Result()
 
 	// If we have multiple results, unpack them into a map that matches the schema generated type.
-	if results, ok := result.([]any); ok && len(fnInfo.ExecutionPlan().ResultHandlers()) > 1 {
-		fnMeta := fnInfo.Metadata()
+	if results, ok := result.([]any); ok && len(fnMeta.Results) > 1 {
 		m := make(map[string]any, len(results))
 		for i, r := range results {
 			name := fnMeta.Results[i].Name
@@ -102,7 +214,17 @@ func (ds *ModusDataSource) callFunction(ctx context.Context, callInfo *callInfo)
 		result = m
 	}
 
-	return result, gqlErrors, err
+	// Apply the directives that take effect on the result.
+	result, err = applyMaskDirective(fnMeta, result)
+	if err != nil {
+		return nil, gqlErrors, err
+	}
+
+	if cacheKey != "" && len(gqlErrors) == 0 {
+		resultCache.Set(cacheKey, result, cacheTTL)
+	}
+
+	return result, gqlErrors, nil
 }
 
 func writeGraphQLResponse(ctx context.Context, out *bytes.Buffer, result any, gqlErrors []resolve.GraphQLError, fnErr error, ci *callInfo) error {
@@ -120,6 +242,11 @@ func writeGraphQLResponse(ctx context.Context, out *bytes.Buffer, result any, gq
 		})
 	}
 
+	// Leave out the fields of the result that the caller is not authorized to read
+	if result != nil {
+		gqlErrors = append(gqlErrors, authorizeFields(ctx, &ci.Function)...)
+	}
+
 	// If there are GraphQL errors, serialize them as json
 	var jsonErrors []byte
 	if len(gqlErrors) > 0 {
@@ -160,7 +287,11 @@ func writeGraphQLResponse(ctx context.Context, out *bytes.Buffer, result any, gq
 	out.Grow(len(jsonData) + len(jsonErrors) + len(fieldName) + 26)
 	out.WriteByte('{')
 	if len(jsonData) > 0 {
-		out.WriteString(`"data":{"`)
+		out.WriteString(`"data":{`)
+		for alias, typeName := range ci.TypeNames {
+			fmt.Fprintf(out, `"%s":"%s",`, alias, typeName)
+		}
+		out.WriteByte('"')
 		out.WriteString(fieldName)
 		out.WriteString(`":`)
 		out.Write(jsonData)
@@ -181,9 +312,12 @@ func writeGraphQLResponse(ctx context.Context, out *bytes.Buffer, result any, gq
 var nullWord = []byte("null")
 
 func transformValue(data []byte, tf *fieldInfo) (result []byte, err error) {
-	if len(tf.Fields) == 0 || len(data) == 0 || bytes.Equal(data, nullWord) {
+	if len(data) == 0 || bytes.Equal(data, nullWord) {
 		return data, nil
 	}
+	if len(tf.Fields) == 0 {
+		return transformScalar(data, tf)
+	}
 
 	switch data[0] {
 	case '{':
@@ -204,10 +338,14 @@ func transformArray(data []byte, tf *fieldInfo) ([]byte, error) {
 	buf.WriteByte('[')
 
 	var loopErr error
-	_, err := jsonparser.ArrayEach(data, func(val []byte, _ jsonparser.ValueType, _ int, _ error) {
+	_, err := jsonparser.ArrayEach(data, func(val []byte, dataType jsonparser.ValueType, _ int, _ error) {
 		if loopErr != nil {
 			return
 		}
+		if dataType == jsonparser.String {
+			// String values are missing their outer quotes, the same as in transformObject.
+			val = []byte(`"` + string(val) + `"`)
+		}
 		val, err := transformValue(val, tf)
 		if err != nil {
 			loopErr = err
@@ -232,10 +370,28 @@ func transformArray(data []byte, tf *fieldInfo) ([]byte, error) {
 func transformObject(data []byte, tf *fieldInfo) ([]byte, error) {
 	buf := bytes.Buffer{}
 	buf.WriteByte('{')
-	for i, f := range tf.Fields {
+
+	// The type name in the data is that of an object in a union, such as an entity of a federation subgraph.
+	// The engine needs it to select the fields of the object's type, so it is written even when not requested.
+	typeName := tf.TypeName
+	if v, err := jsonparser.GetString(data, "__typename"); err == nil {
+		typeName = v
+		if !slices.ContainsFunc(tf.Fields, func(f fieldInfo) bool { return f.Name == "__typename" && f.Alias == "" }) {
+			buf.WriteString(`"__typename":"` + typeName + `"`)
+		}
+	}
+
+	for _, f := range tf.Fields {
+		if f.OnType != "" && f.OnType != typeName {
+			// The field is in a fragment for a different type.
+			continue
+		}
+
 		var val []byte
 		if f.Name == "__typename" {
-			val = []byte(`"` + tf.TypeName + `"`)
+			val = []byte(`"` + typeName + `"`)
+		} else if f.denied {
+			val = nullWord
 		} else {
 			v, dataType, _, err := jsonparser.Get(data, f.Name)
 			if err != nil {
@@ -251,7 +407,7 @@ func transformObject(data []byte, tf *fieldInfo) ([]byte, error) {
 				return nil, err
 			}
 		}
-		if i > 0 {
+		if buf.Len() > 1 {
 			buf.WriteByte(',')
 		}
 		buf.WriteByte('"')
@@ -393,12 +549,15 @@ func transformErrors(messages []utils.LogMessage, ci *callInfo) []resolve.GraphQ
 		// Only include errors.  Other messages will be captured later and
 		// passed back as logs in the extensions section of the response.
 		if msg.IsError() {
+			extensions := make(map[string]any, len(msg.Extensions)+1)
+			extensions["level"] = msg.Level
+			for k, v := range msg.Extensions {
+				extensions[k] = v
+			}
 			errors = append(errors, resolve.GraphQLError{
-				Message: msg.Message,
-				Path:    []any{ci.Function.AliasOrName()},
-				Extensions: map[string]interface{}{
-					"level": msg.Level,
-				},
+				Message:    msg.Message,
+				Path:       []any{ci.Function.AliasOrName()},
+				Extensions: extensions,
 			})
 		}
 	}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/engine/resolve"
+)
+
+// ModusSubscriptionSource resolves a subscription to a streaming function.  The function is called once, and each chunk
+// of output that it streams is sent to the client as a value of the subscription's field.  The function's result
+// is then sent as the last value, and the subscription completes.
+type ModusSubscriptionSource struct {
+	ModusDataSource
+}
+
+type flushesContextKey struct{}
+
+// WithSubscriptionFlushes returns a context for executing subscriptions whose response writer calls the returned
+// function each time it flushes an update.  The engine resolves updates concurrently, so the source waits for each
+// update to be flushed before sending the next one, which keeps the streamed output in order.
+func WithSubscriptionFlushes(ctx context.Context) (context.Context, func()) {
+	flushed := make(chan struct{}, 1)
+	return context.WithValue(ctx, flushesContextKey{}, flushed), func() {
+		select {
+		case flushed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// subscriptionIds makes each subscription unique, so that the engine doesn't share a function call between clients
+// that subscribe with the same arguments.  Each of them would otherwise miss the output streamed before they joined.
+var subscriptionIds atomic.Uint64
+
+func (ds *ModusSubscriptionSource) UniqueRequestID(ctx *resolve.Context, input []byte, xxh *xxhash.Digest) error {
+	if _, err := xxh.Write(input); err != nil {
+		return err
+	}
+	_, err := xxh.WriteString(strconv.FormatUint(subscriptionIds.Add(1), 10))
+	return err
+}
+
+func (ds *ModusSubscriptionSource) Start(ctx *resolve.Context, input []byte, updater resolve.SubscriptionUpdater) error {
+	var ci callInfo
+	if err := utils.JsonDeserialize(input, &ci); err != nil {
+		return fmt.Errorf("error parsing input: %w", err)
+	}
+
+	go ds.run(ctx.Context(), input, &ci, updater)
+	return nil
+}
+
+func (ds *ModusSubscriptionSource) run(ctx context.Context, input []byte, ci *callInfo, updater resolve.SubscriptionUpdater) {
+	defer updater.Done()
+
+	flushed, _ := ctx.Value(flushesContextKey{}).(chan struct{})
+	update := func(data []byte) {
+		updater.Update(data)
+		if flushed != nil {
+			select {
+			case <-flushed:
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	// Functions called by the subscription record their output here, rather than in the output of the request.
+	ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, make(map[string]wasmhost.ExecutionInfo))
+	ctx = context.WithValue(ctx, utils.StreamWriterContextKey, utils.StreamWriter(func(_, data string) {
+		// Chunks of a function that returns a string are strings, even if they happen to be valid JSON, such as a number.
+		var chunk any = data
+		if ci.Function.TypeName != "String" && json.Valid([]byte(data)) {
+			chunk = json.RawMessage(data)
+		}
+
+		var buf bytes.Buffer
+		if err := writeGraphQLResponse(ctx, &buf, chunk, nil, nil, ci); err != nil {
+			logger.Error(ctx).Err(err).Msg("Error creating GraphQL response for streamed output.")
+			return
+		}
+		update(buf.Bytes())
+	}))
+
+	// The function's errors are included in the response, so there is nothing else to do with them here.
+	var buf bytes.Buffer
+	_ = ds.Load(ctx, input, &buf)
+	if ctx.Err() == nil {
+		update(buf.Bytes())
+	}
+}
@@ -10,8 +10,11 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"sync"
+	"time"
 
 	"context"
 	"strings"
@@ -20,6 +23,7 @@ import (
 	"github.com/hypermodeinc/modus/runtime/graphql/datasource"
 	"github.com/hypermodeinc/modus/runtime/graphql/schemagen"
 	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/metrics"
 	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
 	"github.com/hypermodeinc/modus/runtime/utils"
 	"github.com/hypermodeinc/modus/runtime/wasmhost"
@@ -31,8 +35,24 @@ import (
 )
 
 var instance *engine.ExecutionEngine
+var instanceSchema *gql.Schema
+var instanceSDL *schemaDocuments
+var instanceCacheTTLs map[string]time.Duration
+var instancePlugins map[string]*pluginInstance
 var mutex sync.RWMutex
 
+// schemaDocuments are the schema of the current engine, and the schemas of the individual plugins it is composed of.
+type schemaDocuments struct {
+	schema  string
+	plugins map[string]*schemagen.GraphQLSchema
+}
+
+// pluginInstance is an engine that serves the functions of a single plugin, with the plugin's own schema.
+type pluginInstance struct {
+	engine *engine.ExecutionEngine
+	schema *gql.Schema
+}
+
 // GetEngine provides thread-safe access to the current GraphQL execution engine.
 func GetEngine() *engine.ExecutionEngine {
 	mutex.RLock()
@@ -40,17 +60,73 @@ func GetEngine() *engine.ExecutionEngine {
 	return instance
 }
 
-func setEngine(engine *engine.ExecutionEngine) {
+// GetSchema provides thread-safe access to the schema of the current GraphQL execution engine.
+func GetSchema() *gql.Schema {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return instanceSchema
+}
+
+// GetSchemaDocument returns the schema of the current GraphQL execution engine, or of one of the plugins
+// it is composed of if a plugin name is given.  It returns false if there is no such schema.
+func GetSchemaDocument(pluginName string) (string, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if instanceSDL == nil {
+		return "", false
+	}
+	if pluginName == "" {
+		return instanceSDL.schema, true
+	}
+	sdl, ok := instanceSDL.plugins[pluginName]
+	if !ok {
+		return "", false
+	}
+	return sdl.Schema, true
+}
+
+// GetPluginEngine provides thread-safe access to the engine that serves the functions of a single plugin,
+// with the names that the plugin gives them, and its schema.  It returns false if there is no such plugin.
+func GetPluginEngine(pluginName string) (*engine.ExecutionEngine, *gql.Schema, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	pi, ok := instancePlugins[pluginName]
+	if !ok {
+		return nil, nil, false
+	}
+	return pi.engine, pi.schema, true
+}
+
+// GetCacheTTL returns how long the result of a root field of the query type may be cached,
+// or false if the field doesn't call a function whose results are cached.
+func GetCacheTTL(fieldName string) (time.Duration, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	ttl, ok := instanceCacheTTLs[fieldName]
+	return ttl, ok
+}
+
+func setEngine(engine *engine.ExecutionEngine, schema *gql.Schema, sdl *schemaDocuments, plugins map[string]*pluginInstance, cacheTTLs map[string]time.Duration) {
 	mutex.Lock()
 	defer mutex.Unlock()
 	instance = engine
+	instanceSchema = schema
+	instanceSDL = sdl
+	instancePlugins = plugins
+	instanceCacheTTLs = cacheTTLs
 }
 
-func Activate(ctx context.Context, md *metadata.Metadata) error {
+// Activate generates the schema of the functions of the given plugins, and starts a new engine to execute it.
+func Activate(ctx context.Context, mds []*metadata.Metadata) error {
 	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
 
-	schema, cfg, err := generateSchema(ctx, md)
+	schema, cfg, sdl, err := generateSchema(ctx, mds)
+	if err != nil {
+		return err
+	}
+
+	changes, err := checkSchemaChanges(ctx, sdl.schema)
 	if err != nil {
 		return err
 	}
@@ -65,17 +141,91 @@ func Activate(ctx context.Context, md *metadata.Metadata) error {
 		return err
 	}
 
-	setEngine(engine)
+	plugins, err := makePluginEngines(ctx, mds, sdl, &pluginInstance{engine, schema})
+	if err != nil {
+		return err
+	}
+
+	setEngine(engine, schema, sdl, plugins, getCacheTTLs(ctx, schema, cfg.WasmHost))
+	setLastSchema(sdl.schema, changes)
 	return nil
 }
 
-func generateSchema(ctx context.Context, md *metadata.Metadata) (*gql.Schema, *datasource.HypDSConfig, error) {
+// makePluginEngines makes an engine for each plugin, so that each plugin can also be served on its own,
+// independently of the other plugins that are loaded.  When there is only one plugin, the composed engine serves it.
+func makePluginEngines(ctx context.Context, mds []*metadata.Metadata, sdl *schemaDocuments, composed *pluginInstance) (map[string]*pluginInstance, error) {
 	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
 
-	generated, err := schemagen.GetGraphQLSchema(ctx, md)
+	plugins := make(map[string]*pluginInstance, len(mds))
+	if len(mds) == 1 {
+		plugins[mds[0].Name()] = composed
+		return plugins, nil
+	}
+
+	for _, md := range mds {
+		generated := sdl.plugins[md.Name()]
+		schema, err := gql.NewSchemaFromString(generated.Schema + "\n\n" + datasource.IncrementalDirectives)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", md.Name(), err)
+		}
+
+		cfg := &datasource.HypDSConfig{
+			WasmHost:  wasmhost.GetWasmHost(ctx),
+			MapTypes:  generated.MapTypes,
+			Namespace: md.Namespace(),
+		}
+		if len(generated.Entities) > 0 {
+			cfg.Federation = &datasource.Federation{
+				SDL:      generated.SubgraphSDL,
+				Entities: generated.Entities,
+			}
+		}
+
+		datasourceConfig, err := getDatasourceConfig(ctx, schema, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", md.Name(), err)
+		}
+
+		engine, err := makeEngine(ctx, schema, datasourceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", md.Name(), err)
+		}
+
+		plugins[md.Name()] = &pluginInstance{engine, schema}
+	}
+	return plugins, nil
+}
+
+// getCacheTTLs returns the cache durations of the root fields of the query type that call functions
+// with the @cache directive.
+func getCacheTTLs(ctx context.Context, schema *gql.Schema, host wasmhost.WasmHost) map[string]time.Duration {
+	ttls := make(map[string]time.Duration)
+	if host == nil {
+		return ttls
+	}
+	for _, name := range getAllRootFields(ctx, schema, schema.QueryTypeName()) {
+		if info, err := host.GetFunctionInfo(name); err == nil {
+			if ttl := datasource.CacheTTL(ctx, info.Metadata()); ttl > 0 {
+				ttls[name] = ttl
+			}
+		}
+	}
+	return ttls
+}
+
+func generateSchema(ctx context.Context, mds []*metadata.Metadata) (*gql.Schema, *datasource.HypDSConfig, *schemaDocuments, error) {
+	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
+	defer span.Finish()
+
+	composed, err := schemagen.ComposeGraphQLSchema(ctx, mds)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+	generated := composed.GraphQLSchema
+
+	for _, conflict := range composed.Conflicts {
+		logger.Warn(ctx).Bool("user_visible", true).Msg(conflict)
 	}
 
 	if utils.DebugModeEnabled() {
@@ -86,40 +236,133 @@ func generateSchema(ctx context.Context, md *metadata.Metadata) (*gql.Schema, *d
 		}
 	}
 
-	schema, err := gql.NewSchemaFromString(generated.Schema)
+	// The directives of incremental delivery are handled by the runtime, rather than by functions.
+	schema, err := gql.NewSchemaFromString(generated.Schema + "\n\n" + datasource.IncrementalDirectives)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	cfg := &datasource.HypDSConfig{
 		WasmHost: wasmhost.GetWasmHost(ctx),
 		MapTypes: generated.MapTypes,
 	}
+	if len(generated.Entities) > 0 {
+		cfg.Federation = &datasource.Federation{
+			SDL:      generated.SubgraphSDL,
+			Entities: generated.Entities,
+		}
+	}
 
-	return schema, cfg, nil
+	return schema, cfg, &schemaDocuments{schema: generated.Schema, plugins: composed.Plugins}, nil
+}
+
+// ErrBreakingSchemaChanges is returned when a new schema is refused, because it has breaking changes
+// from the schema being served, and strict schema mode is enabled.
+var ErrBreakingSchemaChanges = errors.New("the GraphQL schema has breaking changes")
+
+var lastSchema string
+var lastSchemaChanges []schemagen.SchemaChange
+var lastSchemaMutex sync.RWMutex
+
+// GetSchemaChanges returns the changes of the schema being served from the one that was served before it.
+func GetSchemaChanges() []schemagen.SchemaChange {
+	lastSchemaMutex.RLock()
+	defer lastSchemaMutex.RUnlock()
+	return lastSchemaChanges
+}
+
+func setLastSchema(schema string, changes []schemagen.SchemaChange) {
+	lastSchemaMutex.Lock()
+	defer lastSchemaMutex.Unlock()
+	if schema != lastSchema {
+		lastSchema = schema
+		lastSchemaChanges = changes
+	}
+}
+
+// checkSchemaChanges compares a new schema with the schema being served.  In development, all of the changes are shown,
+// so that developers can see the effect of their changes to their functions without reading the whole schema.
+// Breaking changes are always logged, and in strict schema mode, they refuse the new schema.
+func checkSchemaChanges(ctx context.Context, schema string) ([]schemagen.SchemaChange, error) {
+	lastSchemaMutex.RLock()
+	previous := lastSchema
+	lastSchemaMutex.RUnlock()
+
+	if previous == "" || previous == schema {
+		return nil, nil
+	}
+
+	changes := schemagen.DiffSchemaChanges(previous, schema)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	var breaking []string
+	for _, c := range changes {
+		if c.Breaking {
+			breaking = append(breaking, c.Description)
+		}
+	}
+
+	if config.IsDevEnvironment() {
+		if config.UseJsonLogging {
+			logger.Info(ctx).Any("changes", changes).Bool("user_visible", true).Msg("GraphQL schema changed.")
+		} else {
+			lines := make([]string, len(changes))
+			for i, c := range changes {
+				lines[i] = c.Description
+				if c.Breaking {
+					lines[i] += "  (breaking)"
+				}
+			}
+			fmt.Printf("\nGraphQL schema changes:\n  %s\n\n", strings.Join(lines, "\n  "))
+		}
+	}
+
+	if len(breaking) == 0 {
+		return changes, nil
+	}
+
+	metrics.BreakingSchemaChangesNum.Add(float64(len(breaking)))
+
+	if config.StrictSchema {
+		logger.Error(ctx).Strs("changes", breaking).Bool("user_visible", true).Msg("The GraphQL schema has breaking changes.  It will not be served, because strict schema mode is enabled.")
+		return nil, fmt.Errorf("%w: %s", ErrBreakingSchemaChanges, strings.Join(breaking, "; "))
+	}
+
+	logger.Warn(ctx).Strs("changes", breaking).Bool("user_visible", true).Msg("The GraphQL schema has breaking changes.  Clients that use the changed fields may fail.")
+	return changes, nil
 }
 
 func getDatasourceConfig(ctx context.Context, schema *gql.Schema, cfg *datasource.HypDSConfig) (plan.DataSourceConfiguration[datasource.HypDSConfig], error) {
 	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
 
-	queryTypeName := schema.QueryTypeName()
-	queryFieldNames := getAllQueryFields(ctx, schema)
-	rootNodes := []plan.TypeField{
-		{
-			TypeName:   queryTypeName,
-			FieldNames: queryFieldNames,
-		},
-	}
-
-	var childNodes []plan.TypeField
-	for _, f := range queryFieldNames {
-		fields := schema.GetAllNestedFieldChildrenFromTypeField(queryTypeName, f, gql.NewSkipReservedNamesFunc())
-		for _, field := range fields {
-			childNodes = append(childNodes, plan.TypeField{
-				TypeName:   field.TypeName,
-				FieldNames: field.FieldNames,
-			})
+	// Streaming functions are fields of the subscription type, as well as the query or mutation type.
+	rootTypeNames := []string{schema.QueryTypeName()}
+	if schema.HasMutationType() {
+		rootTypeNames = append(rootTypeNames, schema.MutationTypeName())
+	}
+	if schema.HasSubscriptionType() {
+		rootTypeNames = append(rootTypeNames, schema.SubscriptionTypeName())
+	}
+
+	var rootNodes, childNodes []plan.TypeField
+	for _, typeName := range rootTypeNames {
+		fieldNames := getAllRootFields(ctx, schema, typeName)
+		rootNodes = append(rootNodes, plan.TypeField{
+			TypeName:   typeName,
+			FieldNames: fieldNames,
+		})
+
+		for _, f := range fieldNames {
+			fields := schema.GetAllNestedFieldChildrenFromTypeField(typeName, f, gql.NewSkipReservedNamesFunc())
+			for _, field := range fields {
+				childNodes = append(childNodes, plan.TypeField{
+					TypeName:   field.TypeName,
+					FieldNames: field.FieldNames,
+				})
+			}
 		}
 	}
 
@@ -131,6 +374,11 @@ func getDatasourceConfig(ctx context.Context, schema *gql.Schema, cfg *datasourc
 	)
 }
 
+// errorExtensionFields are the fields of the extensions of errors that are returned to the caller,
+// including the code, category and details of errors reported by functions.
+// The engine removes any others.
+var errorExtensionFields = []string{"level", "code", "category", "details", "argument", "constraint", "retryAfter"}
+
 func makeEngine(ctx context.Context, schema *gql.Schema, datasourceConfig plan.DataSourceConfiguration[datasource.HypDSConfig]) (*engine.ExecutionEngine, error) {
 	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
@@ -142,23 +390,24 @@ func makeEngine(ctx context.Context, schema *gql.Schema, datasourceConfig plan.D
 		MaxConcurrency:               1024,
 		PropagateSubgraphErrors:      true,
 		SubgraphErrorPropagationMode: resolve.SubgraphErrorPropagationModePassThrough,
+		AllowedErrorExtensionFields:  errorExtensionFields,
+		AsyncErrorWriter:             &asyncErrorWriter{},
 	}
 
 	adapter := newLoggerAdapter(ctx)
 	return engine.NewExecutionEngine(ctx, adapter, engineConfig, resolverOptions)
 }
 
-func getAllQueryFields(ctx context.Context, s *gql.Schema) []string {
+func getAllRootFields(ctx context.Context, s *gql.Schema, rootTypeName string) []string {
 	span, _ := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
 
 	doc := s.Document()
-	queryTypeName := s.QueryTypeName()
 
 	fields := make([]string, 0)
 	for _, objectType := range doc.ObjectTypeDefinitions {
 		typeName := doc.Input.ByteSliceString(objectType.Name)
-		if typeName == queryTypeName {
+		if typeName == rootTypeName {
 			for _, fieldRef := range objectType.FieldsDefinition.Refs {
 				field := doc.FieldDefinitions[fieldRef]
 				fieldName := doc.Input.ByteSliceString(field.Name)
@@ -172,3 +421,14 @@ func getAllQueryFields(ctx context.Context, s *gql.Schema) []string {
 
 	return fields
 }
+
+// asyncErrorWriter writes the errors that occur while resolving a subscription, after its response has started.
+type asyncErrorWriter struct{}
+
+func (w *asyncErrorWriter) WriteError(ctx *resolve.Context, err error, res *resolve.GraphQLResponse, out io.Writer) {
+	msg, _ := utils.JsonSerialize(err.Error())
+	_, _ = fmt.Fprintf(out, `{"errors":[{"message":%s}]}`, msg)
+	if sw, ok := out.(resolve.SubscriptionResponseWriter); ok {
+		_ = sw.Flush()
+	}
+}
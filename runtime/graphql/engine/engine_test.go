@@ -0,0 +1,572 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/functions"
+	"github.com/hypermodeinc/modus/runtime/graphql/datasource"
+	"github.com/hypermodeinc/modus/runtime/graphql/schemagen"
+	"github.com/hypermodeinc/modus/runtime/langsupport"
+	"github.com/hypermodeinc/modus/runtime/languages"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+// storyHost is a wasm host with a single function, which streams a number of tokens before returning its result.
+type storyHost struct {
+	wasmhost.WasmHost
+	tokens int
+}
+
+type storyFunction struct {
+	functions.FunctionInfo
+}
+
+func (storyFunction) Metadata() *metadata.Function {
+	return &metadata.Function{Name: "tellStory"}
+}
+
+func (storyFunction) ExecutionPlan(context.Context) (langsupport.ExecutionPlan, error) {
+	return storyPlan{}, nil
+}
+
+type storyPlan struct {
+	langsupport.ExecutionPlan
+}
+
+func (storyPlan) ResultHandlers() []langsupport.TypeHandler {
+	return nil
+}
+
+type storyResult struct {
+	wasmhost.ExecutionInfo
+	result string
+}
+
+func (storyResult) Messages() []utils.LogMessage { return nil }
+func (r storyResult) Result() any                { return r.result }
+
+func (h storyHost) GetFunctionInfo(fnName string) (functions.FunctionInfo, error) {
+	if fnName != "tellStory" {
+		return nil, fmt.Errorf("function %s not found", fnName)
+	}
+	return storyFunction{}, nil
+}
+
+func (h storyHost) CallFunction(ctx context.Context, fnInfo functions.FunctionInfo, parameters map[string]any) (wasmhost.ExecutionInfo, error) {
+	w, _ := ctx.Value(utils.StreamWriterContextKey).(utils.StreamWriter)
+	var story strings.Builder
+	for i := range h.tokens {
+		token := fmt.Sprintf("%d ", i)
+		if w != nil {
+			w("tellStory", token)
+		}
+		story.WriteString(token)
+	}
+	return storyResult{result: story.String()}, nil
+}
+
+// subscriptionWriter records the updates of a subscription, and ends its execution when it completes.
+type subscriptionWriter struct {
+	buf      bytes.Buffer
+	updates  []string
+	flushed  func()
+	complete func()
+}
+
+func (w *subscriptionWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *subscriptionWriter) Flush() error {
+	w.updates = append(w.updates, w.buf.String())
+	w.buf.Reset()
+	w.flushed()
+	return nil
+}
+
+func (w *subscriptionWriter) Complete() {
+	w.complete()
+}
+
+func Test_Subscription_StreamsInOrder(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{})
+	ctx := context.Background()
+
+	schema, err := gql.NewSchemaFromString(`
+type Query {
+  tellStory(topic: String!): String!
+}
+
+type Subscription {
+  tellStory(topic: String!): String!
+}`)
+	require.NoError(t, err)
+
+	const tokens = 50
+	dsConfig, err := getDatasourceConfig(ctx, schema, &datasource.HypDSConfig{WasmHost: storyHost{tokens: tokens}})
+	require.NoError(t, err)
+	engine, err := makeEngine(ctx, schema, dsConfig)
+	require.NoError(t, err)
+
+	ctx, flushed := datasource.WithSubscriptionFlushes(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	w := &subscriptionWriter{flushed: flushed, complete: cancel}
+	req := gql.Request{Query: `subscription { tellStory(topic: "dragons") }`}
+	require.NoError(t, engine.Execute(ctx, &req, w))
+	require.ErrorIs(t, ctx.Err(), context.Canceled, "the subscription completes when the function returns")
+
+	require.Len(t, w.updates, tokens+1)
+	var story strings.Builder
+	for i := range tokens {
+		token := fmt.Sprintf("%d ", i)
+		assert.Equal(t, fmt.Sprintf(`{"data":{"tellStory":"%s"}}`, token), w.updates[i])
+		story.WriteString(token)
+	}
+	assert.Equal(t, fmt.Sprintf(`{"data":{"tellStory":"%s"}}`, story.String()), w.updates[tokens])
+}
+
+func Test_Mutation(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{})
+	ctx := context.Background()
+
+	schema, err := gql.NewSchemaFromString(`
+type Query {
+  sayHello: String!
+}
+
+type Mutation {
+  tellStory(topic: String!): String!
+}`)
+	require.NoError(t, err)
+
+	dsConfig, err := getDatasourceConfig(ctx, schema, &datasource.HypDSConfig{WasmHost: storyHost{tokens: 3}})
+	require.NoError(t, err)
+	engine, err := makeEngine(ctx, schema, dsConfig)
+	require.NoError(t, err)
+
+	ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, map[string]wasmhost.ExecutionInfo{})
+	w := gql.NewEngineResultWriter()
+	req := gql.Request{Query: `mutation { tellStory(topic: "dragons") }`}
+	require.NoError(t, engine.Execute(ctx, &req, &w))
+	assert.Equal(t, `{"data":{"tellStory":"0 1 2 "}}`, w.String())
+}
+
+// userHost is a wasm host with a single function, which resolves users by their id.
+type userHost struct {
+	wasmhost.WasmHost
+}
+
+type userFunction struct {
+	functions.FunctionInfo
+}
+
+func (userFunction) Metadata() *metadata.Function {
+	return metadata.NewFunction("getUser").WithParameter("id", "string").WithResult("*main.User")
+}
+
+func (userFunction) ExecutionPlan(context.Context) (langsupport.ExecutionPlan, error) {
+	return storyPlan{}, nil
+}
+
+func (userFunction) Plugin() *plugins.Plugin {
+	return &plugins.Plugin{Language: languages.GoLang(), Metadata: metadata.NewPluginMetadata()}
+}
+
+type userResult struct {
+	wasmhost.ExecutionInfo
+	result any
+}
+
+func (userResult) Messages() []utils.LogMessage { return nil }
+func (r userResult) Result() any                { return r.result }
+
+func (h userHost) GetFunctionInfo(fnName string) (functions.FunctionInfo, error) {
+	if fnName != "getUser" {
+		return nil, fmt.Errorf("function %s not found", fnName)
+	}
+	return userFunction{}, nil
+}
+
+func (h userHost) CallFunction(ctx context.Context, fnInfo functions.FunctionInfo, parameters map[string]any) (wasmhost.ExecutionInfo, error) {
+	id := parameters["id"].(string)
+	if id == "0" {
+		return userResult{}, nil
+	}
+	return userResult{result: map[string]any{"id": id, "name": "User " + id}}, nil
+}
+
+func Test_Federation(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{})
+	ctx := context.Background()
+
+	schema, err := gql.NewSchemaFromString(`
+type Query {
+  _entities(representations: [_Any!]!): [_Entity]!
+  _service: _Service!
+  getUser(id: String!): User
+}
+
+scalar _Any
+scalar _FieldSet
+
+type _Service {
+  sdl: String
+}
+
+type User @key(fields: "id") {
+  id: String!
+  name: String!
+}
+
+union _Entity = User
+
+directive @key(fields: _FieldSet!) repeatable on OBJECT | INTERFACE`)
+	require.NoError(t, err)
+
+	dsConfig, err := getDatasourceConfig(ctx, schema, &datasource.HypDSConfig{
+		WasmHost: userHost{},
+		Federation: &datasource.Federation{
+			SDL: "type User @key(fields: \"id\") { id: String! name: String! }",
+			Entities: map[string][]*schemagen.EntityResolver{
+				"User": {{Function: "getUser", KeyFields: []string{"id"}, Parameters: []string{"id"}}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	engine, err := makeEngine(ctx, schema, dsConfig)
+	require.NoError(t, err)
+
+	execute := func(query, variables string) string {
+		ctx := context.WithValue(ctx, utils.FunctionOutputContextKey, map[string]wasmhost.ExecutionInfo{})
+		w := gql.NewEngineResultWriter()
+		req := gql.Request{Query: query, Variables: []byte(variables)}
+		require.NoError(t, engine.Execute(ctx, &req, &w))
+		return w.String()
+	}
+
+	result := execute(`{ _service { sdl } }`, "")
+	assert.Equal(t, `{"data":{"_service":{"sdl":"type User @key(fields: \"id\") { id: String! name: String! }"}}}`, result)
+
+	result = execute(`query ($representations: [_Any!]!) {
+  _entities(representations: $representations) { __typename ... on User { id name } }
+}`, `{"representations":[{"__typename":"User","id":"1"},{"__typename":"User","id":"2"}]}`)
+	assert.Equal(t, `{"data":{"_entities":[{"__typename":"User","id":"1","name":"User 1"},{"__typename":"User","id":"2","name":"User 2"}]}}`, result)
+
+	result = execute(`query ($representations: [_Any!]!) {
+  _entities(representations: $representations) { ... on User { name } }
+}`, `{"representations":[{"__typename":"User","id":"0"},{"__typename":"User","id":"3"}]}`)
+	assert.Equal(t, `{"data":{"_entities":[null,{"name":"User 3"}]}}`, result)
+}
+
+// recordingHost is a wasm host like storyHost, which records the order of its invocations,
+// and the largest number of invocations that were running at the same time.
+type recordingHost struct {
+	storyHost
+	mu      *sync.Mutex
+	topics  *[]string
+	running *int
+	peak    *int
+}
+
+func (h recordingHost) CallFunction(ctx context.Context, fnInfo functions.FunctionInfo, parameters map[string]any) (wasmhost.ExecutionInfo, error) {
+	h.mu.Lock()
+	*h.running++
+	*h.peak = max(*h.peak, *h.running)
+	h.mu.Unlock()
+
+	// Later topics finish sooner, so that invocations that are not ordered complete out of order.
+	topic := parameters["topic"].(string)
+	n, _ := strconv.Atoi(topic)
+	time.Sleep(time.Duration(10-n) * 10 * time.Millisecond)
+
+	h.mu.Lock()
+	*h.running--
+	*h.topics = append(*h.topics, topic)
+	h.mu.Unlock()
+	return storyResult{result: topic}, nil
+}
+
+func Test_RootFields_Concurrency(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{})
+	ctx := context.Background()
+
+	schema, err := gql.NewSchemaFromString(`
+type Query {
+  tellStory(topic: String!): String!
+}
+
+type Mutation {
+  tellStory(topic: String!): String!
+}`)
+	require.NoError(t, err)
+
+	host := recordingHost{mu: &sync.Mutex{}, topics: &[]string{}, running: new(int), peak: new(int)}
+	dsConfig, err := getDatasourceConfig(ctx, schema, &datasource.HypDSConfig{WasmHost: host})
+	require.NoError(t, err)
+	engine, err := makeEngine(ctx, schema, dsConfig)
+	require.NoError(t, err)
+
+	execute := func(query string) string {
+		ctx := context.WithValue(ctx, utils.FunctionOutputContextKey, map[string]wasmhost.ExecutionInfo{})
+		ctx = datasource.WithInvocationBudget(ctx, 2)
+		w := gql.NewEngineResultWriter()
+		req := gql.Request{Query: query}
+		require.NoError(t, engine.Execute(ctx, &req, &w))
+		return w.String()
+	}
+
+	// The fields of a query are resolved concurrently, within the budget.
+	result := execute(`{ a: tellStory(topic: "1") b: tellStory(topic: "2") c: tellStory(topic: "3") d: tellStory(topic: "4") }`)
+	assert.Equal(t, `{"data":{"a":"1","b":"2","c":"3","d":"4"}}`, result)
+	assert.Equal(t, 2, *host.peak)
+
+	// The fields of a mutation are resolved in order, one at a time.
+	*host.topics, *host.peak = nil, 0
+	result = execute(`mutation { a: tellStory(topic: "1") __typename b: tellStory(topic: "2") c: tellStory(topic: "3") }`)
+	assert.Equal(t, `{"data":{"a":"1","__typename":"Mutation","b":"2","c":"3"}}`, result)
+	assert.Equal(t, []string{"1", "2", "3"}, *host.topics)
+	assert.Equal(t, 1, *host.peak)
+	// The __typename of the root type doesn't call a function.
+	*host.topics = nil
+	result = execute(`{ __typename }`)
+	assert.Equal(t, `{"data":{"__typename":"Query"}}`, result)
+	assert.Empty(t, *host.topics)
+}
+
+// errorHost is a wasm host like userHost, whose function reports an error for users that don't exist.
+type errorHost struct {
+	userHost
+}
+
+type errorResult struct {
+	userResult
+	messages []utils.LogMessage
+}
+
+func (r errorResult) Messages() []utils.LogMessage { return r.messages }
+
+func (h errorHost) CallFunction(ctx context.Context, fnInfo functions.FunctionInfo, parameters map[string]any) (wasmhost.ExecutionInfo, error) {
+	id := parameters["id"].(string)
+	if id != "404" {
+		return h.userHost.CallFunction(ctx, fnInfo, parameters)
+	}
+	return errorResult{messages: []utils.LogMessage{{
+		Level:   "error",
+		Message: "User not found.",
+		Extensions: map[string]any{
+			"code":     "USER_NOT_FOUND",
+			"category": "NOT_FOUND",
+			"details":  map[string]any{"id": id},
+		},
+	}}}, nil
+}
+
+func Test_StructuredErrors(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{})
+	ctx := context.Background()
+
+	schema, err := gql.NewSchemaFromString(`
+type Query {
+  getUser(id: String!): User
+}
+
+type User {
+  id: String!
+  name: String!
+}`)
+	require.NoError(t, err)
+
+	dsConfig, err := getDatasourceConfig(ctx, schema, &datasource.HypDSConfig{WasmHost: errorHost{}})
+	require.NoError(t, err)
+	engine, err := makeEngine(ctx, schema, dsConfig)
+	require.NoError(t, err)
+
+	ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, map[string]wasmhost.ExecutionInfo{})
+	w := gql.NewEngineResultWriter()
+	req := gql.Request{Query: `{ a: getUser(id: "1") { id } b: getUser(id: "404") { id } }`}
+	require.NoError(t, engine.Execute(ctx, &req, &w))
+
+	// The error is reported with its extensions, and the other fields are still returned.
+	assert.JSONEq(t, `{
+  "errors": [{
+    "message": "User not found.",
+    "path": ["b"],
+    "extensions": {"level": "error", "code": "USER_NOT_FOUND", "category": "NOT_FOUND", "details": {"id": "404"}}
+  }],
+  "data": {"a": {"id": "1"}, "b": null}
+}`, w.String())
+}
+
+// listHost is a wasm host with a single function, which returns a list of the given number of users.
+type listHost struct {
+	wasmhost.WasmHost
+	count int
+}
+
+func (h listHost) GetFunctionInfo(fnName string) (functions.FunctionInfo, error) {
+	if fnName != "listUsers" {
+		return nil, fmt.Errorf("function %s not found", fnName)
+	}
+	return storyFunction{}, nil
+}
+
+func (h listHost) CallFunction(ctx context.Context, fnInfo functions.FunctionInfo, parameters map[string]any) (wasmhost.ExecutionInfo, error) {
+	users := make([]any, h.count)
+	for i := range users {
+		users[i] = map[string]any{"id": strconv.Itoa(i), "name": "user " + strconv.Itoa(i)}
+	}
+	return userResult{result: users}, nil
+}
+
+func Test_StreamedList(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{})
+	ctx := context.Background()
+
+	schema, err := gql.NewSchemaFromString(`
+type Query {
+  listUsers: [User!]!
+}
+
+type User {
+  id: String!
+  name: String!
+}
+
+` + datasource.IncrementalDirectives)
+	require.NoError(t, err)
+
+	dsConfig, err := getDatasourceConfig(ctx, schema, &datasource.HypDSConfig{WasmHost: listHost{count: 5000}})
+	require.NoError(t, err)
+	engine, err := makeEngine(ctx, schema, dsConfig)
+	require.NoError(t, err)
+
+	ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, map[string]wasmhost.ExecutionInfo{})
+	ctx, stream := datasource.WithItemStream(ctx, map[string]datasource.StreamedField{"users": {InitialCount: 2, Label: "all"}})
+
+	// The items after the initial ones are sent while the stream is read.
+	var chunks []datasource.StreamedItems
+	done := make(chan struct{})
+	go func() {
+		for items := range stream.Items() {
+			chunks = append(chunks, items)
+		}
+		close(done)
+	}()
+
+	w := gql.NewEngineResultWriter()
+	req := gql.Request{Query: `{ users: listUsers @stream(initialCount: 2, label: "all") { userId: id } }`}
+	require.NoError(t, engine.Execute(ctx, &req, &w))
+	stream.Close()
+	<-done
+
+	// The initial result has only the initial items.
+	assert.Equal(t, `{"data":{"users":[{"userId":"0"},{"userId":"1"}]}}`, w.String())
+
+	// The remaining items are sent in order, in more than one chunk, with the fields that were selected.
+	require.Greater(t, len(chunks), 1)
+	next := 2
+	for _, chunk := range chunks {
+		require.NoError(t, chunk.Err)
+		assert.Equal(t, "users", chunk.Field)
+		assert.Equal(t, "all", chunk.Label)
+		assert.Equal(t, next, chunk.Start)
+
+		var items []map[string]string
+		require.NoError(t, utils.JsonDeserialize(chunk.Items, &items))
+		for i, item := range items {
+			assert.Equal(t, map[string]string{"userId": strconv.Itoa(next + i)}, item)
+		}
+		next += len(items)
+	}
+	assert.Equal(t, 5000, next)
+}
+
+func Test_CheckSchemaChanges_Strict(t *testing.T) {
+	ctx := context.Background()
+	defer func(strict bool) { config.StrictSchema = strict }(config.StrictSchema)
+	defer setLastSchema("", nil)
+
+	setLastSchema("type Query {\n  a: Int\n  b: Int\n}\n", nil)
+
+	// Additions are accepted in strict mode.
+	config.StrictSchema = true
+	changes, err := checkSchemaChanges(ctx, "type Query {\n  a: Int\n  b: Int\n  c: Int\n}\n")
+	require.NoError(t, err)
+	assert.Equal(t, []schemagen.SchemaChange{{Description: "+ Query.c: Int"}}, changes)
+
+	// Removals are refused in strict mode, and only reported otherwise.
+	_, err = checkSchemaChanges(ctx, "type Query {\n  a: Int\n}\n")
+	assert.ErrorIs(t, err, ErrBreakingSchemaChanges)
+
+	config.StrictSchema = false
+	changes, err = checkSchemaChanges(ctx, "type Query {\n  a: Int\n}\n")
+	require.NoError(t, err)
+	assert.Equal(t, []schemagen.SchemaChange{{Description: "- Query.b: Int", Breaking: true}}, changes)
+}
+
+// namespacedHost has the same function as userHost, which it only finds by its name qualified with its plugin's namespace.
+type namespacedHost struct {
+	userHost
+}
+
+func (h namespacedHost) GetFunctionInfo(fnName string) (functions.FunctionInfo, error) {
+	if fnName != "users_getUser" {
+		return nil, fmt.Errorf("function %s not found", fnName)
+	}
+	return userFunction{}, nil
+}
+
+func Test_PluginEngine_QualifiesFunctions(t *testing.T) {
+	manifestdata.SetManifest(&manifest.Manifest{})
+	ctx := context.Background()
+
+	schema, err := gql.NewSchemaFromString(`
+type Query {
+  getUser(id: String!): User
+}
+
+type User {
+  id: String!
+  name: String!
+}`)
+	require.NoError(t, err)
+
+	dsConfig, err := getDatasourceConfig(ctx, schema, &datasource.HypDSConfig{WasmHost: namespacedHost{}, Namespace: "users"})
+	require.NoError(t, err)
+	engine, err := makeEngine(ctx, schema, dsConfig)
+	require.NoError(t, err)
+
+	ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, map[string]wasmhost.ExecutionInfo{})
+	w := gql.NewEngineResultWriter()
+	req := gql.Request{Query: `{ getUser(id: "1") { name } }`}
+	require.NoError(t, engine.Execute(ctx, &req, &w))
+	assert.Equal(t, `{"data":{"getUser":{"name":"User 1"}}}`, w.String())
+}
@@ -0,0 +1,218 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/graphql/datasource"
+	"github.com/hypermodeinc/modus/runtime/graphql/engine"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/outputtransforms"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+)
+
+// StreamPathPrefix is the path under which each streaming function is served as a stream of server-sent events,
+// such as /api/v1/stream/generateStory.
+const StreamPathPrefix = RestPathPrefix + "stream/"
+
+var StreamRequestHandler = http.HandlerFunc(handleStreamRequest)
+
+// handleStreamRequest calls a streaming function, and sends each chunk of its output as a "message" event as soon
+// as it is produced, so that plain clients such as curl, or a browser's EventSource, can consume it.
+// The function's result is then sent as a "complete" event, or its errors as an "error" event, and the stream ends.
+//
+// The arguments are the query parameters of a GET request, which is all that an EventSource can send,
+// or the JSON object of the body of a POST request.
+func handleStreamRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	fnName := strings.TrimPrefix(r.URL.Path, StreamPathPrefix)
+	if fnName == "" || strings.HasPrefix(fnName, "_") || strings.Contains(fnName, "/") {
+		writeRestError(w, http.StatusNotFound, "Function not found.")
+		return
+	}
+
+	if !isTrustedFunction(r.Header, fnName) {
+		writeRestError(w, http.StatusForbidden, untrustedFunctionMessage)
+		return
+	}
+
+	schema := engine.GetSchema()
+	eng := engine.GetEngine()
+	if eng == nil || schema == nil {
+		writeRestError(w, http.StatusServiceUnavailable, "There is no active GraphQL schema.  Please load a Modus plugin, or declare connectors in the manifest.")
+		return
+	}
+
+	// Only the functions that stream their output are fields of the Subscription type.
+	doc := schema.Document()
+	if _, ok := findRootField(doc, "Subscription", fnName); !ok {
+		writeRestError(w, http.StatusNotFound, "Streaming function not found.")
+		return
+	}
+	operationType, field, ok := findFunctionField(schema, fnName)
+	if !ok {
+		writeRestError(w, http.StatusNotFound, "Streaming function not found.")
+		return
+	}
+
+	var variables []byte
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		variables, err = queryArguments(doc, field, r.URL.Query())
+		if err != nil {
+			writeRestError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeRestError(w, http.StatusBadRequest, "Failed to read the request body.")
+			return
+		}
+		if len(strings.TrimSpace(string(body))) == 0 {
+			body = []byte("{}")
+		} else if !gjson.ValidBytes(body) || !gjson.ParseBytes(body).IsObject() {
+			writeRestError(w, http.StatusBadRequest, "The request body must be a JSON object of the function's arguments.")
+			return
+		}
+		variables = body
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query, err := buildRestQuery(doc, operationType, field, variables)
+	if err != nil {
+		writeRestError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	gqlRequest := gql.Request{Query: query, Variables: variables}
+
+	// Identify the client, so that client-specific output transforms can be applied.
+	if client := r.Header.Get("X-Modus-Client"); client != "" {
+		ctx = context.WithValue(ctx, utils.ClientNameContextKey, client)
+	}
+	if canary := r.Header.Get("X-Modus-Canary"); canary != "" {
+		ctx = context.WithValue(ctx, utils.CanaryContextKey, canary)
+	}
+
+	output := make(map[string]wasmhost.ExecutionInfo)
+	ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, output)
+	ctx = datasource.WithInvocationBudget(ctx, config.RequestConcurrency)
+
+	es := &eventStream{w: w}
+	ctx = context.WithValue(ctx, utils.StreamWriterContextKey, utils.StreamWriter(es.writeChunk))
+
+	resultWriter := gql.NewEngineResultWriter()
+	if err := eng.Execute(ctx, &gqlRequest, &resultWriter); err != nil {
+		requestErrors := graphqlerrors.RequestErrorsFromError(err)
+		if len(requestErrors) == 0 {
+			logger.Err(ctx, err).Msg("Failed to execute streaming function.")
+			requestErrors = graphqlerrors.RequestErrors{{Message: "Failed to execute the function."}}
+		}
+		data, _ := utils.JsonSerialize(map[string]any{"errors": requestErrors})
+		es.writeEvent("error", data)
+		return
+	}
+
+	response := resultWriter.Bytes()
+	if errs := gjson.GetBytes(response, "errors"); errs.Exists() {
+		es.writeEvent("error", []byte(fmt.Sprintf(`{"errors":%s}`, errs.Raw)))
+		return
+	}
+
+	// Reshape the output with any transforms declared in the manifest.
+	data, err := outputtransforms.Apply(ctx, fnName, []byte(gjson.GetBytes(response, "data."+fnName).Raw))
+	if err != nil {
+		logger.Err(ctx, err).Bool("user_visible", true).Msg("Failed to transform the function's output.")
+		es.writeEvent("error", []byte(`{"errors":[{"message":"Failed to transform the function's output."}]}`))
+		return
+	}
+	es.writeEvent("complete", data)
+}
+
+// queryArguments converts the query parameters of a request to the JSON object of a function's arguments.
+// The values of string and ID arguments are used as they are.  Other values are parsed as JSON,
+// so that numbers, booleans, lists and objects can be given, and are otherwise used as strings.
+func queryArguments(doc *ast.Document, field int, params url.Values) ([]byte, error) {
+	defs := doc.FieldDefinitionArgumentsDefinitions(field)
+
+	var unknown []string
+	for name := range params {
+		if !slices.ContainsFunc(defs, func(arg int) bool { return doc.InputValueDefinitionNameString(arg) == name }) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return nil, fmt.Errorf("function %s has no argument named %s", doc.FieldDefinitionNameString(field), strings.Join(unknown, ", "))
+	}
+
+	args := []byte("{}")
+	for _, arg := range defs {
+		name := doc.InputValueDefinitionNameString(arg)
+		if !params.Has(name) {
+			continue
+		}
+		value := params.Get(name)
+		typeName := doc.ResolveTypeNameString(doc.InputValueDefinitionType(arg))
+		if typeName != "String" && typeName != "ID" && gjson.Valid(value) {
+			args, _ = sjson.SetRawBytes(args, name, []byte(value))
+		} else {
+			args, _ = sjson.SetBytes(args, name, value)
+		}
+	}
+	return args, nil
+}
+
+// eventStream sends the output of a streaming function as server-sent events.
+// Chunks of output are sent as they are, so that a client can read a stream of model tokens directly.
+type eventStream struct {
+	w       http.ResponseWriter
+	mu      sync.Mutex
+	started bool
+}
+
+func (s *eventStream) writeChunk(_, data string) {
+	s.writeEvent("", []byte(data))
+}
+
+func (s *eventStream) writeEvent(event string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		h := s.w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+	writeServerSentEvent(s.w, event, data)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+func Test_QueryArguments(t *testing.T) {
+	schema, err := gql.NewSchemaFromString(restTestSchema)
+	require.NoError(t, err)
+	doc := schema.Document()
+	field, ok := findRootField(doc, "Query", "getUser")
+	require.True(t, ok)
+
+	// String arguments are used as they are, even if they look like JSON.
+	args, err := queryArguments(doc, field, url.Values{"id": {"123"}, "verbose": {"true"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"123","verbose":true}`, string(args))
+
+	args, err = queryArguments(doc, field, url.Values{"id": {"a b"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"a b"}`, string(args))
+
+	_, err = queryArguments(doc, field, url.Values{"id": {"1"}, "name": {"x"}})
+	assert.EqualError(t, err, "function getUser has no argument named name")
+}
+
+func Test_EventStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	es := &eventStream{w: w}
+	es.writeChunk("generateStory", "Once upon")
+	es.writeChunk("generateStory", " a time\nthere was")
+	es.writeEvent("complete", []byte(`"Once upon a time\nthere was"`))
+
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "data: Once upon\n\n"+
+		"data:  a time\ndata: there was\n\n"+
+		"event: complete\ndata: \"Once upon a time\\nthere was\"\n\n", w.Body.String())
+}
@@ -10,18 +10,26 @@
 package graphql
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/graphql/datasource"
 	"github.com/hypermodeinc/modus/runtime/graphql/engine"
 	"github.com/hypermodeinc/modus/runtime/logger"
 	"github.com/hypermodeinc/modus/runtime/manifestdata"
 	"github.com/hypermodeinc/modus/runtime/pluginmanager"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
 	"github.com/hypermodeinc/modus/runtime/utils"
 	"github.com/hypermodeinc/modus/runtime/wasmhost"
 
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 	eng "github.com/wundergraph/graphql-go-tools/execution/engine"
 	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
@@ -32,34 +40,99 @@ import (
 
 var GraphQLRequestHandler = http.HandlerFunc(handleGraphQLRequest)
 
+// GraphQLPluginPathPrefix is the path under which each plugin is also served on its own, such as /graphql/my-plugin,
+// so that the plugins of several apps or teams can be hosted together, each with its own endpoint and schema.
+const GraphQLPluginPathPrefix = "/graphql/"
+
+// getEngine returns the engine that serves the request path, and its schema.  The functions of all of the plugins
+// are served at /graphql, and those of a single plugin at /graphql/{plugin}.  It returns false if the path
+// names a plugin that isn't loaded.
+func getEngine(path string) (*eng.ExecutionEngine, *gql.Schema, bool) {
+	pluginName, ok := strings.CutPrefix(path, GraphQLPluginPathPrefix)
+	if !ok || pluginName == "" {
+		return engine.GetEngine(), engine.GetSchema(), true
+	}
+	return engine.GetPluginEngine(pluginName)
+}
+
 func Initialize() {
-	// The GraphQL engine's Activate function should be called when a plugin is loaded.
-	pluginmanager.RegisterPluginLoadedCallback(engine.Activate)
-
-	// It should also be called when the manifest changes, since the manifest can affect function filtering.
-	manifestdata.RegisterManifestLoadedCallback(func(ctx context.Context) error {
-		plugins := pluginmanager.GetRegisteredPlugins()
-		if len(plugins) == 0 {
-			// No plugins are loaded, so there's nothing to do.
-			// This is expected during startup, because the manifest loads before the plugins.
-			return nil
+	// The GraphQL engine should be activated when a plugin is loaded.
+	// A plugin whose schema is refused is rejected, so that the plugin it replaces keeps being served.
+	pluginmanager.RegisterPluginLoadedCallback(func(ctx context.Context, _ *metadata.Metadata) error {
+		err := activateEngine(ctx)
+		if errors.Is(err, engine.ErrBreakingSchemaChanges) {
+			return fmt.Errorf("%w: %w", pluginmanager.ErrPluginRejected, err)
 		}
+		return err
+	})
 
-		if len(plugins) > 1 {
-			// TODO: We should support multiple plugins in the future.
-			logger.Warn(ctx).Msg("Multiple plugins loaded.  Only the first plugin will be used.")
-		}
+	// It should also be activated when the manifest changes, since the manifest can affect function filtering.
+	manifestdata.RegisterManifestLoadedCallback(activateEngine)
 
-		return engine.Activate(ctx, plugins[0].Metadata)
-	})
+	// The authorization rules of the manifest are enforced when functions are resolved.
+	manifestdata.RegisterManifestLoadedCallback(datasource.LoadAuthorizationRules)
+
+	// The trusted documents are read from the file that the manifest names, if any.
+	manifestdata.RegisterManifestLoadedCallback(loadTrustedDocuments)
+}
+
+// activateEngine activates the GraphQL engine with the functions of all of the loaded plugins,
+// composed into a single schema.
+func activateEngine(ctx context.Context) error {
+	plugins := pluginmanager.GetRegisteredPlugins()
+
+	// No plugins are loaded, but connectors from the manifest can still be served.
+	// Otherwise, there's nothing to do.  This is expected during startup, because the manifest loads before the plugins.
+	if len(plugins) == 0 && len(manifestdata.GetManifest().Connectors) == 0 {
+		return nil
+	}
+
+	mds := make([]*metadata.Metadata, len(plugins))
+	for i, p := range plugins {
+		mds[i] = p.Metadata
+	}
+	if err := engine.Activate(ctx, mds); err != nil {
+		return err
+	}
+
+	// Cached responses may not match the functions of the new schema.
+	responseCache.Clear()
+	return nil
 }
 
 func handleGraphQLRequest(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	// Subscriptions, and other operations, can also be sent over a WebSocket connection.
+	if isWebSocketUpgrade(r) {
+		handleGraphQLWebSocket(w, r)
+		return
+	}
+
+	// Several operations can be sent together, as a JSON array, and their responses are returned together.
+	if operations, ok, err := readBatchRequest(r); err != nil {
+		http.Error(w, "Failed to parse GraphQL request.", http.StatusBadRequest)
+		return
+	} else if ok {
+		handleBatchRequest(w, r, operations)
+		return
+	}
+
+	// If the client accepts an event stream, results are sent as server-sent events.
+	var sw *sseResponseWriter
+	if wantsEventStream(r) {
+		sw = newSseResponseWriter(w)
+		defer sw.complete()
+		w = sw
+	}
+
 	// Read the incoming GraphQL request
 	var gqlRequest gql.Request
-	err := gql.UnmarshalHttpRequest(r, &gqlRequest)
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = gql.UnmarshalRequest(bytes.NewReader(body), &gqlRequest)
+		gqlRequest.SetHeader(r.Header)
+	}
 	if err != nil {
 		// NOTE: we intentionally don't log this, to avoid a bad actor spamming the logs
 		// TODO: we should capture metrics here though
@@ -68,10 +141,14 @@ func handleGraphQLRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the active GraphQL engine, if there is one.
-	engine := engine.GetEngine()
+	// Get the active GraphQL engine and its schema, if there is one.
+	engine, schema, found := getEngine(r.URL.Path)
+	if !found {
+		http.Error(w, "Plugin not found.", http.StatusNotFound)
+		return
+	}
 	if engine == nil {
-		msg := "There is no active GraphQL schema.  Please load a Modus plugin."
+		msg := "There is no active GraphQL schema.  Please load a Modus plugin, or declare connectors in the manifest."
 		logger.Warn(ctx).Msg(msg)
 		utils.WriteJsonContentHeader(w)
 		if ok, _ := gqlRequest.IsIntrospectionQuery(); ok {
@@ -82,10 +159,47 @@ func handleGraphQLRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Run only trusted documents, when the manifest requires them.
+	if errs := resolveTrustedDocument(r.Header, &gqlRequest, body); len(errs) > 0 {
+		utils.WriteJsonContentHeader(w)
+		_, _ = errs.WriteResponse(w)
+		return
+	}
+
+	// Subscriptions stream their results, which requires a WebSocket connection or an event stream.
+	opType, _ := gqlRequest.OperationType()
+	if opType == gql.OperationTypeSubscription && sw == nil {
+		utils.WriteJsonContentHeader(w)
+		_, _ = w.Write([]byte(`{"errors":[{"message":"Subscriptions require a WebSocket connection, using the graphql-transport-ws protocol, or a request that accepts text/event-stream."}]}`))
+		return
+	}
+
+	// Reject introspection when it is disabled.
+	if errs := checkIntrospection(r.Header, &gqlRequest); len(errs) > 0 {
+		utils.WriteJsonContentHeader(w)
+		_, _ = errs.WriteResponse(w)
+		return
+	}
+
+	// Reject queries that exceed the limits, before any function is invoked.
+	if errs := checkQueryLimits(schema, &gqlRequest); len(errs) > 0 {
+		utils.WriteJsonContentHeader(w)
+		_, _ = errs.WriteResponse(w)
+		return
+	}
+
+	// Route the request to the canary version of a plugin, or away from it, rather than by the canary's percentage.
+	if canary := r.Header.Get("X-Modus-Canary"); canary != "" {
+		ctx = context.WithValue(ctx, utils.CanaryContextKey, canary)
+	}
+
 	// Create the output map
 	output := make(map[string]wasmhost.ExecutionInfo)
 	ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, output)
 
+	// Bound the functions that the request invokes concurrently
+	ctx = datasource.WithInvocationBudget(ctx, config.RequestConcurrency)
+
 	// Set tracing options
 	var options = []eng.ExecutionOptions{}
 	if utils.TraceModeEnabled() {
@@ -95,6 +209,28 @@ func handleGraphQLRequest(w http.ResponseWriter, r *http.Request) {
 		options = append(options, eng.WithRequestTraceOptions(traceOpts))
 	}
 
+	// Each update of a subscription is sent to the client as soon as it is available.
+	if opType == gql.OperationTypeSubscription {
+		executeSubscription(ctx, sw, engine, &gqlRequest, options)
+		return
+	}
+
+	// Deliver the deferred and streamed parts of a query after its initial result, if the client can receive them.
+	if pw := newPartWriter(w, r); pw != nil {
+		if ir, ok := planIncrementalDelivery(&gqlRequest); ok {
+			executeIncremental(ctx, pw, engine, ir, gqlRequest.Variables, options)
+			return
+		}
+	}
+
+	// Serve the response from the cache, if every root field calls a function whose results are cached.
+	// The cache is keyed by the names of the composed schema, so only requests of that schema use it.
+	cq, cacheable := getCacheableQuery(ctx, &gqlRequest)
+	cacheable = cacheable && !strings.HasPrefix(r.URL.Path, GraphQLPluginPathPrefix)
+	if cacheable && serveCachedResponse(w, r, cq) {
+		return
+	}
+
 	// Execute the GraphQL query
 	resultWriter := gql.NewEngineResultWriter()
 	err = engine.Execute(ctx, &gqlRequest, &resultWriter, options...)
@@ -125,6 +261,13 @@ func handleGraphQLRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := resultWriter.Bytes()
+	if cacheable && cacheResponse(w, r, cq, bytes.Clone(response)) {
+		return
+	}
+
+	// Tell the client when to retry the functions that were rate limited.
+	setRetryAfter(w, response)
+
 	response, err = addOutputToResponse(response, output)
 	if err != nil {
 		msg := "Failed to add function output to response."
@@ -137,6 +280,18 @@ func handleGraphQLRequest(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(response)
 }
 
+// setRetryAfter sets the Retry-After header of a response, if any of its errors report how long the caller
+// must wait before calling a function again.  The longest wait is used.
+func setRetryAfter(w http.ResponseWriter, response []byte) {
+	retryAfter := int64(0)
+	for _, v := range gjson.GetBytes(response, "errors.#.extensions.retryAfter").Array() {
+		retryAfter = max(retryAfter, v.Int())
+	}
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	}
+}
+
 func addOutputToResponse(response []byte, output map[string]wasmhost.ExecutionInfo) ([]byte, error) {
 
 	// NOTE: JSON serialization should be as efficient as possible, as it is called on every GraphQL response.
@@ -156,7 +311,24 @@ func addOutputToResponse(response []byte, output map[string]wasmhost.ExecutionIn
 			invocations = b
 		}
 
-		logMessages := utils.TransformConsoleOutput(item.Buffers())
+		if usage := item.ModelUsage(); len(usage) > 0 {
+			if b, err := sjson.SetBytesOptions(invocations, key+".modelUsage", usage, jsonOptions); err != nil {
+				return nil, err
+			} else {
+				invocations = b
+			}
+		}
+
+		if flags := item.ModerationFlags(); len(flags) > 0 {
+			if b, err := sjson.SetBytesOptions(invocations, key+".moderation", flags, jsonOptions); err != nil {
+				return nil, err
+			} else {
+				invocations = b
+			}
+		}
+
+		// The console output and the logged messages, in the order they were written.
+		logMessages := item.Messages()
 		if len(logMessages) == 0 {
 			continue
 		}
@@ -179,6 +351,13 @@ func addOutputToResponse(response []byte, output map[string]wasmhost.ExecutionIn
 				} else {
 					invocations = b
 				}
+				if len(logMessage.Fields) > 0 {
+					if b, err := sjson.SetBytesOptions(invocations, path+".fields", logMessage.Fields, jsonOptions); err != nil {
+						return nil, err
+					} else {
+						invocations = b
+					}
+				}
 				i++
 			}
 		}
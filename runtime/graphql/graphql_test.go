@@ -0,0 +1,49 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testExecutionInfo struct {
+	wasmhost.ExecutionInfo
+	buffers utils.OutputBuffers
+}
+
+func (e testExecutionInfo) ExecutionId() string                     { return "exec-1" }
+func (e testExecutionInfo) Messages() []utils.LogMessage            { return e.buffers.Messages() }
+func (e testExecutionInfo) ModelUsage() []utils.ModelUsage          { return nil }
+func (e testExecutionInfo) ModerationFlags() []utils.ModerationFlag { return nil }
+
+func Test_AddOutputToResponse(t *testing.T) {
+	buffers := utils.NewOutputBuffers()
+	_, _ = buffers.StdOut().Write([]byte("Info: printed\n"))
+	buffers.Log(utils.LogMessage{Level: "warning", Message: "Low stock.", Fields: map[string]string{"sku": "a-1"}})
+	buffers.Log(utils.LogMessage{Level: "error", Message: "Failed."})
+	_, _ = buffers.StdOut().Write([]byte("done\n"))
+
+	output := map[string]wasmhost.ExecutionInfo{"getStock": testExecutionInfo{buffers: buffers}}
+	response, err := addOutputToResponse([]byte(`{"data":{"getStock":2}}`), output)
+	require.NoError(t, err)
+
+	// Console output and logged messages are in the order they were written.  Errors are returned as GraphQL errors instead.
+	assert.JSONEq(t, `{"data":{"getStock":2},"extensions":{"invocations":{"getStock":{"executionId":"exec-1","logs":[
+		{"level":"info","message":"printed"},
+		{"level":"warning","message":"Low stock.","fields":{"sku":"a-1"}},
+		{"message":"done"}
+	]}}}}`, string(response))
+}
@@ -0,0 +1,406 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/graphql/datasource"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	eng "github.com/wundergraph/graphql-go-tools/execution/engine"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+)
+
+// acceptsMultipart reports whether the client can receive a response in several parts, as multipart/mixed.
+func acceptsMultipart(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "multipart/mixed")
+}
+
+// incrementalRequest is a query whose result is delivered in several payloads, because the client deferred
+// some of its root fields with @defer, or asked for the items of a list to be streamed with @stream.
+//
+// Each root field calls a function, so deferred fragments of the root type are executed as operations of their own,
+// after the initial operation.  Fragments that are deferred within the result of a function are resolved along with
+// the rest of the result, which the incremental delivery protocol allows.  Likewise, only the list results of
+// root fields are streamed.
+type incrementalRequest struct {
+	// initial is the operation of the root fields that are not deferred, or empty if all of them are.
+	initial string
+
+	// operationName selects the operation, when the document has more than one.
+	operationName string
+
+	deferred []deferredFragment
+	streams  map[string]datasource.StreamedField
+}
+
+type deferredFragment struct {
+	label string
+	query string
+}
+
+// planIncrementalDelivery splits a query into the operations of its initial result and of its deferred fragments.
+// It returns false if nothing is deferred or streamed, or if the request isn't a query, in which case it is
+// executed as usual.
+func planIncrementalDelivery(req *gql.Request) (*incrementalRequest, bool) {
+	doc, report := astparser.ParseGraphqlDocumentString(req.Query)
+	if report.HasErrors() {
+		return nil, false
+	}
+
+	opRef := slices.IndexFunc(doc.OperationDefinitions, func(op ast.OperationDefinition) bool {
+		return req.OperationName == "" || doc.Input.ByteSliceString(op.Name) == req.OperationName
+	})
+	if opRef < 0 {
+		return nil, false
+	}
+	op := doc.OperationDefinitions[opRef]
+	if op.OperationType != ast.OperationTypeQuery || !op.HasSelections {
+		return nil, false
+	}
+
+	ir := &incrementalRequest{operationName: req.OperationName, streams: make(map[string]datasource.StreamedField)}
+	var initial, deferred []int
+	for _, sel := range doc.SelectionSets[op.SelectionSet].SelectionRefs {
+		selection := doc.Selections[sel]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			if d, ok := activeDirective(&doc, doc.Fields[selection.Ref].Directives.Refs, "stream", req.Variables); ok {
+				ir.streams[doc.FieldAliasOrNameString(selection.Ref)] = datasource.StreamedField{
+					InitialCount: max(int(directiveArgument(&doc, d, "initialCount", req.Variables).Int()), 0),
+					Label:        directiveArgument(&doc, d, "label", req.Variables).String(),
+				}
+			}
+		case ast.SelectionKindInlineFragment:
+			if d, ok := activeDirective(&doc, doc.InlineFragments[selection.Ref].Directives.Refs, "defer", req.Variables); ok {
+				deferred = append(deferred, sel)
+				ir.deferred = append(ir.deferred, deferredFragment{label: directiveArgument(&doc, d, "label", req.Variables).String()})
+				continue
+			}
+		case ast.SelectionKindFragmentSpread:
+			if d, ok := activeDirective(&doc, doc.FragmentSpreads[selection.Ref].Directives.Refs, "defer", req.Variables); ok {
+				deferred = append(deferred, sel)
+				ir.deferred = append(ir.deferred, deferredFragment{label: directiveArgument(&doc, d, "label", req.Variables).String()})
+				continue
+			}
+		}
+		initial = append(initial, sel)
+	}
+	if len(deferred) == 0 && len(ir.streams) == 0 {
+		return nil, false
+	}
+
+	var err error
+	if len(initial) > 0 {
+		if ir.initial, err = printOperation(&doc, opRef, initial); err != nil {
+			return nil, false
+		}
+	}
+	for i, sel := range deferred {
+		if ir.deferred[i].query, err = printOperation(&doc, opRef, []int{sel}); err != nil {
+			return nil, false
+		}
+	}
+
+	return ir, true
+}
+
+// activeDirective returns the directive of the given name, unless its "if" argument is false.
+func activeDirective(doc *ast.Document, refs []int, name string, variables []byte) (int, bool) {
+	i := slices.IndexFunc(refs, func(ref int) bool { return doc.DirectiveNameString(ref) == name })
+	if i < 0 {
+		return -1, false
+	}
+	if v := directiveArgument(doc, refs[i], "if", variables); v.Exists() && !v.Bool() {
+		return -1, false
+	}
+	return refs[i], true
+}
+
+// directiveArgument returns the value of an argument of a directive, from the query or from its variables.
+func directiveArgument(doc *ast.Document, ref int, name string, variables []byte) gjson.Result {
+	value, ok := doc.DirectiveArgumentValueByName(ref, []byte(name))
+	if !ok {
+		return gjson.Result{}
+	}
+	if value.Kind == ast.ValueKindVariable {
+		return gjson.GetBytes(variables, doc.VariableValueNameString(value.Ref))
+	}
+	b, err := doc.ValueToJSON(value)
+	if err != nil {
+		return gjson.Result{}
+	}
+	return gjson.ParseBytes(b)
+}
+
+// printOperation prints an operation with only the given selections of its root type,
+// and only the variables that they use.
+func printOperation(doc *ast.Document, opRef int, selections []int) (string, error) {
+	op := &doc.OperationDefinitions[opRef]
+	ss := &doc.SelectionSets[op.SelectionSet]
+	savedSelections, savedVariables := ss.SelectionRefs, op.VariableDefinitions.Refs
+	defer func() {
+		ss.SelectionRefs, op.VariableDefinitions.Refs = savedSelections, savedVariables
+		op.HasVariableDefinitions = len(savedVariables) > 0
+	}()
+
+	ss.SelectionRefs = selections
+	used := make(map[string]bool)
+	collectVariables(doc, op.SelectionSet, used, nil)
+	op.VariableDefinitions.Refs = slices.DeleteFunc(slices.Clone(savedVariables), func(ref int) bool {
+		return !used[doc.VariableDefinitionNameString(ref)]
+	})
+	op.HasVariableDefinitions = len(op.VariableDefinitions.Refs) > 0
+
+	return astprinter.PrintString(doc)
+}
+
+// collectVariables adds the names of the variables used by a selection set, and by the fragments it spreads.
+func collectVariables(doc *ast.Document, ssRef int, used map[string]bool, fragments []string) {
+	for _, sel := range doc.SelectionSets[ssRef].SelectionRefs {
+		selection := doc.Selections[sel]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			for _, arg := range doc.FieldArguments(selection.Ref) {
+				collectValueVariables(doc, doc.ArgumentValue(arg), used)
+			}
+			collectDirectiveVariables(doc, doc.Fields[selection.Ref].Directives.Refs, used)
+			if ss, ok := doc.FieldSelectionSet(selection.Ref); ok {
+				collectVariables(doc, ss, used, fragments)
+			}
+		case ast.SelectionKindInlineFragment:
+			collectDirectiveVariables(doc, doc.InlineFragments[selection.Ref].Directives.Refs, used)
+			if ss, ok := doc.InlineFragmentSelectionSet(selection.Ref); ok {
+				collectVariables(doc, ss, used, fragments)
+			}
+		case ast.SelectionKindFragmentSpread:
+			collectDirectiveVariables(doc, doc.FragmentSpreads[selection.Ref].Directives.Refs, used)
+			name := doc.FragmentSpreadNameString(selection.Ref)
+			ref, ok := doc.FragmentDefinitionRef([]byte(name))
+			if !ok || slices.Contains(fragments, name) {
+				continue
+			}
+			if fragment := doc.FragmentDefinitions[ref]; fragment.HasSelections {
+				collectVariables(doc, fragment.SelectionSet, used, append(fragments, name))
+			}
+		}
+	}
+}
+
+func collectDirectiveVariables(doc *ast.Document, directives []int, used map[string]bool) {
+	for _, d := range directives {
+		for _, arg := range doc.Directives[d].Arguments.Refs {
+			collectValueVariables(doc, doc.ArgumentValue(arg), used)
+		}
+	}
+}
+
+func collectValueVariables(doc *ast.Document, value ast.Value, used map[string]bool) {
+	switch value.Kind {
+	case ast.ValueKindVariable:
+		used[doc.VariableValueNameString(value.Ref)] = true
+	case ast.ValueKindList:
+		for _, ref := range doc.ListValues[value.Ref].Refs {
+			collectValueVariables(doc, doc.Value(ref), used)
+		}
+	case ast.ValueKindObject:
+		for _, ref := range doc.ObjectValues[value.Ref].Refs {
+			collectValueVariables(doc, doc.ObjectFieldValue(ref), used)
+		}
+	}
+}
+
+// partWriter writes the payloads of an incremental response.
+type partWriter interface {
+	writePart(payload []byte)
+	close()
+}
+
+func newPartWriter(w http.ResponseWriter, r *http.Request) partWriter {
+	switch {
+	case wantsEventStream(r):
+		// The response writer is already an sseResponseWriter, which sends each payload as a "next" event.
+		return eventPartWriter{w}
+	case acceptsMultipart(r):
+		return &multipartWriter{ResponseWriter: w}
+	}
+	return nil
+}
+
+type eventPartWriter struct {
+	http.ResponseWriter
+}
+
+func (w eventPartWriter) writePart(payload []byte) {
+	_, _ = w.Write(payload)
+}
+
+func (eventPartWriter) close() {}
+
+// multipartWriter writes each payload as a part of a multipart/mixed response, as incremental delivery over HTTP
+// is implemented by GraphQL clients.
+type multipartWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *multipartWriter) writePart(payload []byte) {
+	if !w.started {
+		w.Header().Set("Content-Type", `multipart/mixed; boundary="-"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		w.started = true
+	}
+	fmt.Fprintf(w, "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n%s", payload)
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *multipartWriter) close() {
+	if w.started {
+		_, _ = w.Write([]byte("\r\n-----\r\n"))
+	}
+}
+
+// executeIncremental executes the operations of an incremental request, and writes the initial result,
+// followed by the results of the deferred fragments and the items of the streamed lists, as they become available.
+func executeIncremental(ctx context.Context, pw partWriter, engine *eng.ExecutionEngine, ir *incrementalRequest, variables []byte, options []eng.ExecutionOptions) {
+	defer pw.close()
+
+	ctx, stream := datasource.WithItemStream(ctx, ir.streams)
+
+	// The initial payload.
+	initial := []byte(`{"data":{}}`)
+	if ir.initial != "" {
+		var ok bool
+		if initial, ok = executePart(ctx, engine, ir.initial, ir.operationName, variables, options); !ok {
+			pw.writePart(initial)
+			stream.Close()
+			return
+		}
+	}
+	initial, _ = sjson.SetBytes(initial, "hasNext", true)
+	pw.writePart(initial)
+
+	// The deferred fragments are executed one after another, while the streamed items are sent.
+	deferred := make(chan []byte)
+	go func() {
+		defer close(deferred)
+		defer stream.Close()
+		for _, d := range ir.deferred {
+			response, _ := executePart(ctx, engine, d.query, ir.operationName, variables, options)
+			select {
+			case deferred <- deferredPayload(d.label, response):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	items := stream.Items()
+	for deferred != nil || items != nil {
+		select {
+		case payload, ok := <-deferred:
+			if !ok {
+				deferred = nil
+				continue
+			}
+			pw.writePart(payload)
+		case streamed, ok := <-items:
+			if !ok {
+				items = nil
+				continue
+			}
+			pw.writePart(streamedItemsPayload(streamed))
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	pw.writePart([]byte(`{"hasNext":false}`))
+}
+
+// executePart executes one of the operations of an incremental request.  It returns false, along with the errors,
+// if the operation could not be executed.
+func executePart(ctx context.Context, engine *eng.ExecutionEngine, query, operationName string, variables []byte, options []eng.ExecutionOptions) ([]byte, bool) {
+	output := make(map[string]wasmhost.ExecutionInfo)
+	ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, output)
+
+	req := gql.Request{Query: query, OperationName: operationName, Variables: variables}
+	resultWriter := gql.NewEngineResultWriter()
+	if err := engine.Execute(ctx, &req, &resultWriter, options...); err != nil {
+		requestErrors := graphqlerrors.RequestErrorsFromError(err)
+		if len(requestErrors) == 0 {
+			logger.Err(ctx, err).Msg("Failed to execute GraphQL query.")
+			return []byte(`{"errors":[{"message":"Failed to execute GraphQL query."}]}`), false
+		}
+		var buf bytes.Buffer
+		_, _ = requestErrors.WriteResponse(&buf)
+		return buf.Bytes(), false
+	}
+
+	response, err := addOutputToResponse(resultWriter.Bytes(), output)
+	if err != nil {
+		logger.Err(ctx, err).Msg("Failed to add function output to response.")
+		return resultWriter.Bytes(), true
+	}
+	return response, true
+}
+
+// deferredPayload is the payload of the result of a deferred fragment of the root type.
+func deferredPayload(label string, response []byte) []byte {
+	result := gjson.ParseBytes(response)
+	payload := []byte(`{"incremental":[{"data":null,"path":[]}],"hasNext":true}`)
+	if data := result.Get("data"); data.Exists() {
+		payload, _ = sjson.SetRawBytes(payload, "incremental.0.data", []byte(data.Raw))
+	}
+	if errs := result.Get("errors"); errs.Exists() {
+		payload, _ = sjson.SetRawBytes(payload, "incremental.0.errors", []byte(errs.Raw))
+	}
+	if label != "" {
+		payload, _ = sjson.SetBytes(payload, "incremental.0.label", label)
+	}
+	if extensions := result.Get("extensions"); extensions.Exists() {
+		payload, _ = sjson.SetRawBytes(payload, "extensions", []byte(extensions.Raw))
+	}
+	return payload
+}
+
+// streamedItemsPayload is the payload of items of a streamed list.
+func streamedItemsPayload(s datasource.StreamedItems) []byte {
+	path := []any{s.Field, s.Start}
+	payload := []byte(`{"incremental":[{}],"hasNext":true}`)
+	payload, _ = sjson.SetBytes(payload, "incremental.0.path", path)
+	if s.Err != nil {
+		payload, _ = sjson.SetRawBytes(payload, "incremental.0.items", []byte("null"))
+		payload, _ = sjson.SetBytes(payload, "incremental.0.errors", []map[string]any{{"message": s.Err.Error(), "path": path}})
+	} else {
+		payload, _ = sjson.SetRawBytes(payload, "incremental.0.items", s.Items)
+	}
+	if s.Label != "" {
+		payload, _ = sjson.SetBytes(payload, "incremental.0.label", s.Label)
+	}
+	return payload
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/graphql/datasource"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+func Test_PlanIncrementalDelivery(t *testing.T) {
+	req := &gql.Request{
+		Query: `query Q($id: String!, $count: Int!, $slow: Boolean!) {
+  listPets @stream(initialCount: $count, label: "pets")
+  getUser(id: $id) { id }
+  ... @defer(label: "manager", if: $slow) { manager: getUser(id: "2") { ...Names } }
+  ... @defer(if: false) { other: getUser(id: "3") { id } }
+}
+fragment Names on User { id }`,
+		OperationName: "Q",
+		Variables:     []byte(`{"id":"1","count":2,"slow":true}`),
+	}
+
+	ir, ok := planIncrementalDelivery(req)
+	require.True(t, ok)
+
+	assert.Equal(t, map[string]datasource.StreamedField{"listPets": {InitialCount: 2, Label: "pets"}}, ir.streams)
+	assert.Equal(t, "Q", ir.operationName)
+
+	// The initial operation leaves out the deferred fragment, and the variable that only it uses.
+	assert.Equal(t, `query Q($id: String!, $count: Int!){listPets @stream(initialCount: $count, label: "pets") getUser(id: $id){id} ... @defer(if: false){other: getUser(id: "3"){id}}} fragment Names on User {id}`, ir.initial)
+
+	require.Len(t, ir.deferred, 1)
+	assert.Equal(t, "manager", ir.deferred[0].label)
+	assert.Equal(t, `query Q($slow: Boolean!){... @defer(label: "manager", if: $slow){manager: getUser(id: "2"){...Names}}} fragment Names on User {id}`, ir.deferred[0].query)
+}
+
+func Test_PlanIncrementalDelivery_NotIncremental(t *testing.T) {
+	queries := []string{
+		`{ getUser(id: "1") { id } }`,
+		`{ getUser(id: "1") { ... @defer { id } } }`,
+		`mutation { ... @defer { deleteUser(id: "1") } }`,
+		`{ listPets @stream(if: false) }`,
+	}
+	for _, query := range queries {
+		_, ok := planIncrementalDelivery(&gql.Request{Query: query})
+		assert.False(t, ok, query)
+	}
+}
+
+func Test_IncrementalPayloads(t *testing.T) {
+	payload := deferredPayload("manager", []byte(`{"data":{"manager":{"id":"2"}},"errors":[{"message":"oops"}]}`))
+	assert.JSONEq(t, `{"incremental":[{"data":{"manager":{"id":"2"}},"errors":[{"message":"oops"}],"path":[],"label":"manager"}],"hasNext":true}`, string(payload))
+
+	payload = streamedItemsPayload(datasource.StreamedItems{Field: "listPets", Label: "pets", Start: 2, Items: json.RawMessage(`["c","d"]`)})
+	assert.JSONEq(t, `{"incremental":[{"items":["c","d"],"path":["listPets",2],"label":"pets"}],"hasNext":true}`, string(payload))
+
+	payload = streamedItemsPayload(datasource.StreamedItems{Field: "listPets", Start: 4, Err: errors.New("bad item")})
+	assert.JSONEq(t, `{"incremental":[{"items":null,"path":["listPets",4],"errors":[{"message":"bad item","path":["listPets",4]}]}],"hasNext":true}`, string(payload))
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/middleware"
+
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+)
+
+const introspectionDisabledMessage = "GraphQL introspection is disabled."
+
+// checkIntrospection returns an error if the request introspects the schema, when introspection is disabled.
+// Introspection is always enabled in development, and for requests from admins.
+func checkIntrospection(header http.Header, req *gql.Request) graphqlerrors.RequestErrors {
+	if config.IsDevEnvironment() || middleware.IsAdminRequest(header) {
+		return nil
+	}
+	if md := manifestdata.GetManifest(); md == nil || md.GraphQL.IntrospectionEnabled() {
+		return nil
+	}
+	if !usesIntrospection(req) {
+		return nil
+	}
+	return graphqlerrors.RequestErrors{{Message: introspectionDisabledMessage}}
+}
+
+// usesIntrospection returns whether any operation of the request selects the __schema or __type fields,
+// which can only be selected on the query type.  The __typename field is not considered introspection.
+func usesIntrospection(req *gql.Request) bool {
+	operation, report := astparser.ParseGraphqlDocumentString(req.Query)
+	if report.HasErrors() {
+		// The engine reports the errors, without executing the query.
+		return false
+	}
+
+	for _, op := range operation.OperationDefinitions {
+		if op.OperationType == ast.OperationTypeQuery && op.HasSelections &&
+			selectsIntrospection(&operation, op.SelectionSet, nil) {
+			return true
+		}
+	}
+	return false
+}
+
+func selectsIntrospection(operation *ast.Document, ssRef int, fragments []string) bool {
+	for _, sel := range operation.SelectionSets[ssRef].SelectionRefs {
+		selection := operation.Selections[sel]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			switch operation.FieldNameString(selection.Ref) {
+			case "__schema", "__type":
+				return true
+			}
+		case ast.SelectionKindInlineFragment:
+			if ss, ok := operation.InlineFragmentSelectionSet(selection.Ref); ok && selectsIntrospection(operation, ss, fragments) {
+				return true
+			}
+		case ast.SelectionKindFragmentSpread:
+			name := operation.FragmentSpreadNameString(selection.Ref)
+			ref, ok := operation.FragmentDefinitionRef([]byte(name))
+			if !ok || slices.Contains(fragments, name) {
+				continue
+			}
+			if fragment := operation.FragmentDefinitions[ref]; fragment.HasSelections &&
+				selectsIntrospection(operation, fragment.SelectionSet, append(fragments, name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
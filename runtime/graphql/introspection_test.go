@@ -0,0 +1,55 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+func Test_UsesIntrospection(t *testing.T) {
+	tests := map[string]bool{
+		`{ __schema { types { name } } }`:                                                true,
+		`{ __type(name: "User") { name } }`:                                              true,
+		`{ ...schema } fragment schema on Query { ... { __schema { types { name } } } }`: true,
+		`{ getUser(id: "1") { __typename id } }`:                                         false,
+		`mutation { addUser(name: "a") { id } }`:                                         false,
+	}
+
+	for query, expected := range tests {
+		assert.Equal(t, expected, usesIntrospection(&gql.Request{Query: query}), query)
+	}
+}
+
+func Test_CheckIntrospection(t *testing.T) {
+	t.Setenv("MODUS_ADMIN_TOKEN", "secret")
+	disabled := false
+	manifestdata.SetManifest(&manifest.Manifest{GraphQL: &manifest.GraphQLInfo{Introspection: &disabled}})
+	defer manifestdata.SetManifest(&manifest.Manifest{})
+
+	req := &gql.Request{Query: `{ __schema { queryType { name } } }`}
+	errs := checkIntrospection(http.Header{}, req)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "GraphQL introspection is disabled.", errs[0].Message)
+	}
+
+	assert.Empty(t, checkIntrospection(http.Header{"X-Modus-Admin-Token": {"secret"}}, req))
+	assert.Len(t, checkIntrospection(http.Header{"X-Modus-Admin-Token": {"wrong"}}, req), 1)
+	assert.Empty(t, checkIntrospection(http.Header{}, &gql.Request{Query: `{ getUser(id: "1") { __typename } }`}))
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+	assert.Empty(t, checkIntrospection(http.Header{}, req))
+}
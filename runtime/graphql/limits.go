@@ -0,0 +1,216 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+
+	"github.com/tidwall/gjson"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+)
+
+// defaultListSize is the number of items that a list field is expected to return, when the query doesn't say.
+const defaultListSize = 10
+
+// maxCost caps the cost of a query while it is estimated, so that deeply nested lists can't overflow it.
+const maxCost = 1 << 30
+
+// queryStats are the measures of a query that are limited before it is executed.
+type queryStats struct {
+	depth   int
+	aliases int
+	cost    int
+}
+
+// checkQueryLimits estimates the depth, number of aliases and cost of a query, and returns an error for each
+// limit that it exceeds, so that a single query can't fan out into a large number of function invocations.
+// Queries that can't be parsed are left for the engine to report.
+func checkQueryLimits(schema *gql.Schema, req *gql.Request) graphqlerrors.RequestErrors {
+	if schema == nil || (config.MaxQueryDepth <= 0 && config.MaxQueryAliases <= 0 && config.MaxQueryCost <= 0) {
+		return nil
+	}
+
+	stats, ok := analyzeQuery(schema.Document(), req)
+	if !ok {
+		return nil
+	}
+
+	var errs graphqlerrors.RequestErrors
+	if config.MaxQueryDepth > 0 && stats.depth > config.MaxQueryDepth {
+		errs = append(errs, graphqlerrors.RequestError{
+			Message: fmt.Sprintf("The query has a depth of %d, which exceeds the maximum depth of %d.", stats.depth, config.MaxQueryDepth),
+		})
+	}
+	if config.MaxQueryAliases > 0 && stats.aliases > config.MaxQueryAliases {
+		errs = append(errs, graphqlerrors.RequestError{
+			Message: fmt.Sprintf("The query has %d aliases, which exceeds the maximum of %d.", stats.aliases, config.MaxQueryAliases),
+		})
+	}
+	if config.MaxQueryCost > 0 && stats.cost > config.MaxQueryCost {
+		errs = append(errs, graphqlerrors.RequestError{
+			Message: fmt.Sprintf("The query has an estimated cost of %d, which exceeds the maximum cost of %d.", stats.cost, config.MaxQueryCost),
+		})
+	}
+	return errs
+}
+
+type queryAnalyzer struct {
+	operation  *ast.Document
+	definition *ast.Document
+	variables  []byte
+	stats      queryStats
+
+	// fragments are the names of the fragments being analyzed, which guards against fragments that spread themselves.
+	fragments []string
+}
+
+// analyzeQuery measures the operation of the request that is to be executed.
+func analyzeQuery(definition *ast.Document, req *gql.Request) (queryStats, bool) {
+	operation, report := astparser.ParseGraphqlDocumentString(req.Query)
+	if report.HasErrors() {
+		return queryStats{}, false
+	}
+
+	a := &queryAnalyzer{operation: &operation, definition: definition, variables: req.Variables}
+	for ref, op := range operation.OperationDefinitions {
+		if req.OperationName != "" && operation.OperationDefinitionNameString(ref) != req.OperationName {
+			continue
+		}
+
+		var rootTypeName ast.ByteSlice
+		switch op.OperationType {
+		case ast.OperationTypeQuery:
+			rootTypeName = definition.Index.QueryTypeName
+		case ast.OperationTypeMutation:
+			rootTypeName = definition.Index.MutationTypeName
+		case ast.OperationTypeSubscription:
+			rootTypeName = definition.Index.SubscriptionTypeName
+		}
+
+		if op.HasSelections {
+			a.stats.cost = a.selectionSet(op.SelectionSet, string(rootTypeName), 1, 1, 0)
+		}
+		return a.stats, true
+	}
+
+	return queryStats{}, false
+}
+
+// selectionSet returns the cost of the fields of a selection set, each of which costs the number of times
+// that it is expected to be resolved, which is the product of the sizes of the lists that enclose it.
+// The page size is the "first" argument of the enclosing field, such as a connection of a paginated function,
+// which is the size of the lists within it.
+func (a *queryAnalyzer) selectionSet(ssRef int, typeName string, depth, multiplier, pageSize int) int {
+	operation := a.operation
+	cost := 0
+	for _, sel := range operation.SelectionSets[ssRef].SelectionRefs {
+		selection := operation.Selections[sel]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			cost += a.field(selection.Ref, typeName, depth, multiplier, pageSize)
+		case ast.SelectionKindInlineFragment:
+			fragmentType := typeName
+			if operation.InlineFragmentHasTypeCondition(selection.Ref) {
+				fragmentType = operation.InlineFragmentTypeConditionNameString(selection.Ref)
+			}
+			if ss, ok := operation.InlineFragmentSelectionSet(selection.Ref); ok {
+				cost += a.selectionSet(ss, fragmentType, depth, multiplier, pageSize)
+			}
+		case ast.SelectionKindFragmentSpread:
+			name := operation.FragmentSpreadNameString(selection.Ref)
+			ref, ok := operation.FragmentDefinitionRef([]byte(name))
+			if !ok || slices.Contains(a.fragments, name) {
+				continue
+			}
+			if fragment := operation.FragmentDefinitions[ref]; fragment.HasSelections {
+				a.fragments = append(a.fragments, name)
+				cost += a.selectionSet(fragment.SelectionSet, string(operation.FragmentDefinitionTypeName(ref)), depth, multiplier, pageSize)
+				a.fragments = a.fragments[:len(a.fragments)-1]
+			}
+		}
+		cost = min(cost, maxCost)
+	}
+	return cost
+}
+
+func (a *queryAnalyzer) field(ref int, typeName string, depth, multiplier, pageSize int) int {
+	operation := a.operation
+	definition := a.definition
+
+	// Introspection is answered by the engine, without invoking any functions.
+	name := operation.FieldNameString(ref)
+	if strings.HasPrefix(name, "__") {
+		return 0
+	}
+
+	a.stats.depth = max(a.stats.depth, depth)
+	if operation.FieldAliasIsDefined(ref) {
+		a.stats.aliases++
+	}
+
+	ss, ok := operation.FieldSelectionSet(ref)
+	if !ok {
+		return multiplier
+	}
+
+	// The fields of objects are resolved once for each item of the lists that enclose them.
+	first := a.firstArgument(ref)
+	listSize := cmp.Or(first, pageSize, defaultListSize)
+	fieldTypeName := ""
+	listWraps := 0
+	if node, ok := definition.Index.FirstNodeByNameStr(typeName); ok {
+		if def, ok := definition.NodeFieldDefinitionByName(node, []byte(name)); ok {
+			typeRef := definition.FieldDefinitionType(def)
+			fieldTypeName = definition.ResolveTypeNameString(typeRef)
+			listWraps = definition.TypeNumberOfListWraps(typeRef)
+		}
+	}
+
+	childMultiplier := multiplier
+	for range listWraps {
+		childMultiplier = min(childMultiplier*listSize, maxCost)
+	}
+	if listWraps > 0 {
+		// The page size is taken by this list, rather than the lists within it.
+		first = 0
+	}
+
+	return min(multiplier+a.selectionSet(ss, fieldTypeName, depth+1, childMultiplier, first), maxCost)
+}
+
+// firstArgument returns the value of the "first" argument of a field, as used by paginated functions,
+// or zero when it has none.
+func (a *queryAnalyzer) firstArgument(fieldRef int) int {
+	operation := a.operation
+	arg, ok := operation.FieldArgument(fieldRef, []byte("first"))
+	if !ok {
+		return 0
+	}
+
+	value := operation.ArgumentValue(arg)
+	switch value.Kind {
+	case ast.ValueKindInteger:
+		return max(int(operation.IntValueAsInt(value.Ref)), 1)
+	case ast.ValueKindVariable:
+		v := gjson.GetBytes(a.variables, operation.VariableValueNameString(value.Ref))
+		if v.Type == gjson.Number {
+			return max(int(v.Int()), 1)
+		}
+	}
+	return 0
+}
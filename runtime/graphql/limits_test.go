@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+const limitsTestSchema = `
+type Query {
+  getUser(id: String!): User
+  listUsers: [User!]!
+  searchUsers(first: Int, after: String): UserConnection!
+}
+
+type User {
+  id: String!
+  name: String!
+  friends: [User!]!
+}
+
+type UserConnection {
+  edges: [UserEdge!]!
+}
+
+type UserEdge {
+  node: User!
+  cursor: String!
+}`
+
+func Test_AnalyzeQuery(t *testing.T) {
+	schema, err := gql.NewSchemaFromString(limitsTestSchema)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		query     string
+		variables string
+		expected  queryStats
+	}{
+		{
+			name:     "single field",
+			query:    `{ getUser(id: "1") { id name } }`,
+			expected: queryStats{depth: 2, cost: 3},
+		},
+		{
+			name:     "aliases",
+			query:    `{ a: getUser(id: "1") { id } b: getUser(id: "2") { id } }`,
+			expected: queryStats{depth: 2, aliases: 2, cost: 4},
+		},
+		{
+			name:     "nested lists",
+			query:    `{ listUsers { id friends { id } } }`,
+			expected: queryStats{depth: 3, cost: 1 + 10*2 + 100},
+		},
+		{
+			name:     "page size",
+			query:    `{ searchUsers(first: 3) { edges { cursor node { id } } } }`,
+			expected: queryStats{depth: 4, cost: 1 + 1 + 3*3},
+		},
+		{
+			name:      "page size from variables",
+			query:     `query ($first: Int) { searchUsers(first: $first) { edges { node { id } } } }`,
+			variables: `{"first": 5}`,
+			expected:  queryStats{depth: 4, cost: 1 + 1 + 5*2},
+		},
+		{
+			name:     "fragments",
+			query:    `{ listUsers { ...userFields } } fragment userFields on User { id ... on User { name } }`,
+			expected: queryStats{depth: 2, cost: 1 + 10*2},
+		},
+		{
+			name:     "introspection",
+			query:    `{ __schema { types { name fields { name type { name } } } } }`,
+			expected: queryStats{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &gql.Request{Query: tt.query, Variables: []byte(tt.variables)}
+			stats, ok := analyzeQuery(schema.Document(), req)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, stats)
+		})
+	}
+}
+
+func Test_CheckQueryLimits(t *testing.T) {
+	schema, err := gql.NewSchemaFromString(limitsTestSchema)
+	require.NoError(t, err)
+
+	defer func(depth, aliases, cost int) {
+		config.MaxQueryDepth, config.MaxQueryAliases, config.MaxQueryCost = depth, aliases, cost
+	}(config.MaxQueryDepth, config.MaxQueryAliases, config.MaxQueryCost)
+	config.MaxQueryDepth, config.MaxQueryAliases, config.MaxQueryCost = 3, 1, 200
+
+	errs := checkQueryLimits(schema, &gql.Request{Query: `{ listUsers { id friends { id } } }`})
+	assert.Empty(t, errs)
+
+	errs = checkQueryLimits(schema, &gql.Request{Query: `{ a: listUsers { friends { friends { id } } } b: getUser(id: "1") { id } }`})
+	require.Len(t, errs, 3)
+	assert.Equal(t, "The query has a depth of 4, which exceeds the maximum depth of 3.", errs[0].Message)
+	assert.Equal(t, "The query has 2 aliases, which exceeds the maximum of 1.", errs[1].Message)
+	assert.Equal(t, "The query has an estimated cost of 1113, which exceeds the maximum cost of 200.", errs[2].Message)
+}
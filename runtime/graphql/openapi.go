@@ -0,0 +1,327 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/graphql/engine"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+)
+
+// OpenAPIPath is the well-known path of the OpenAPI document that describes the REST endpoints of the functions.
+const OpenAPIPath = "/openapi.json"
+
+// OpenAPIHandler returns an OpenAPI 3 document of the REST endpoints of the functions, which is generated from the
+// same GraphQL schema that the endpoints are resolved with.
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	schema := engine.GetSchema()
+	if schema == nil {
+		http.Error(w, "No schema is loaded", http.StatusServiceUnavailable)
+		return
+	}
+
+	utils.WriteJsonResponse(w, generateOpenAPI(schema))
+}
+
+// openAPIGenerator converts the root fields of a GraphQL schema to the operations of an OpenAPI document,
+// and the types they use to its component schemas.
+type openAPIGenerator struct {
+	doc        *ast.Document
+	components map[string]any
+}
+
+func generateOpenAPI(schema *gql.Schema) map[string]any {
+	g := &openAPIGenerator{
+		doc: schema.Document(),
+		components: map[string]any{
+			"Errors": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"errors": map[string]any{
+						"type": "array",
+						"items": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"message":    map[string]any{"type": "string"},
+								"path":       map[string]any{"type": "array", "items": map[string]any{}},
+								"extensions": map[string]any{"type": "object", "additionalProperties": true},
+							},
+							"required": []string{"message"},
+						},
+					},
+				},
+				"required": []string{"errors"},
+			},
+		},
+	}
+
+	paths := make(map[string]any)
+	rootTypeNames := []string{schema.QueryTypeName()}
+	if schema.HasMutationType() {
+		rootTypeNames = append(rootTypeNames, schema.MutationTypeName())
+	}
+	for _, typeName := range rootTypeNames {
+		node, ok := g.doc.Index.FirstNodeByNameStr(typeName)
+		if !ok {
+			continue
+		}
+		for _, field := range g.doc.NodeFieldDefinitions(node) {
+			name := g.doc.FieldDefinitionNameString(field)
+			if strings.HasPrefix(name, "_") {
+				continue
+			}
+			if _, found := paths[RestPathPrefix+name]; !found {
+				paths[RestPathPrefix+name] = map[string]any{"post": g.operation(field)}
+			}
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Modus API",
+			"version": config.GetVersionNumber(),
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": g.components},
+	}
+}
+
+func (g *openAPIGenerator) operation(field int) map[string]any {
+	doc := g.doc
+	name := doc.FieldDefinitionNameString(field)
+
+	errorResponse := map[string]any{
+		"description": "The function failed, or its arguments are invalid.",
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Errors"}},
+		},
+	}
+
+	op := map[string]any{
+		"operationId": name,
+		"responses": map[string]any{
+			"200": map[string]any{
+				"description": "The result of the function.",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": g.typeSchema(doc.FieldDefinitionType(field))},
+				},
+			},
+			"default": errorResponse,
+		},
+	}
+	if description := doc.FieldDefinitionDescriptionString(field); description != "" {
+		op["description"] = description
+	}
+	if _, deprecated := doc.FieldDefinitionDirectiveByName(field, []byte("deprecated")); deprecated {
+		op["deprecated"] = true
+	}
+
+	if args := doc.FieldDefinitionArgumentsDefinitions(field); len(args) > 0 {
+		body := g.inputValuesSchema(args)
+		op["requestBody"] = map[string]any{
+			"required": body["required"] != nil,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": body},
+			},
+		}
+	}
+
+	return op
+}
+
+// inputValuesSchema returns the schema of an object with the given arguments or input fields as its properties.
+func (g *openAPIGenerator) inputValuesSchema(refs []int) map[string]any {
+	doc := g.doc
+	properties := make(map[string]any, len(refs))
+	var required []string
+	for _, ref := range refs {
+		name := doc.InputValueDefinitionNameString(ref)
+		typeRef := doc.InputValueDefinitionType(ref)
+		property := g.typeSchema(typeRef)
+		if description := doc.InputValueDefinitionDescriptionString(ref); description != "" {
+			property = withDescription(property, description)
+		}
+		properties[name] = property
+		if doc.TypeIsNonNull(typeRef) && !doc.InputValueDefinitionHasDefaultValue(ref) {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// typeSchema returns the schema of a GraphQL type.  Named types other than scalars refer to component schemas.
+func (g *openAPIGenerator) typeSchema(typeRef int) map[string]any {
+	doc := g.doc
+	t := doc.Types[typeRef]
+	switch t.TypeKind {
+	case ast.TypeKindNonNull:
+		s := g.typeSchema(t.OfType)
+		delete(s, "nullable")
+		if allOf, ok := s["allOf"]; ok && len(s) == 1 {
+			return allOf.([]any)[0].(map[string]any)
+		}
+		return s
+	case ast.TypeKindList:
+		return map[string]any{"type": "array", "items": g.typeSchema(t.OfType), "nullable": true}
+	}
+
+	name := doc.TypeNameString(typeRef)
+	if s, ok := scalarSchema(name); ok {
+		s["nullable"] = true
+		return s
+	}
+
+	g.addComponent(name)
+	return map[string]any{"allOf": []any{map[string]any{"$ref": "#/components/schemas/" + name}}, "nullable": true}
+}
+
+func scalarSchema(name string) (map[string]any, bool) {
+	switch name {
+	case "String", "ID":
+		return map[string]any{"type": "string"}, true
+	case "Int":
+		return map[string]any{"type": "integer", "format": "int32"}, true
+	case "UInt":
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0}, true
+	case "Float":
+		return map[string]any{"type": "number", "format": "double"}, true
+	case "Boolean":
+		return map[string]any{"type": "boolean"}, true
+	case "BigInt":
+		return map[string]any{"type": "string", "format": "int64"}, true
+	case "DateTime":
+		return map[string]any{"type": "string", "format": "date-time"}, true
+	case "Bytes":
+		return map[string]any{"type": "string", "format": "byte"}, true
+	}
+	return nil, false
+}
+
+// addComponent adds the schema of a named type, and of the types it uses, to the components of the document.
+func (g *openAPIGenerator) addComponent(name string) {
+	if _, found := g.components[name]; found {
+		return
+	}
+
+	doc := g.doc
+	node, ok := doc.Index.FirstNodeByNameStr(name)
+	if !ok {
+		g.components[name] = map[string]any{}
+		return
+	}
+
+	// Mark the type as added before its fields, which may refer to it.
+	s := map[string]any{}
+	g.components[name] = s
+
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition:
+		properties := make(map[string]any)
+		var required []string
+		for _, field := range doc.NodeFieldDefinitions(node) {
+			fieldName := doc.FieldDefinitionNameString(field)
+			if strings.HasPrefix(fieldName, "__") || requiresArguments(doc, field) {
+				continue
+			}
+			typeRef := doc.FieldDefinitionType(field)
+			property := g.typeSchema(typeRef)
+			if description := doc.FieldDefinitionDescriptionString(field); description != "" {
+				property = withDescription(property, description)
+			}
+			if _, deprecated := doc.FieldDefinitionDirectiveByName(field, []byte("deprecated")); deprecated {
+				property["deprecated"] = true
+			}
+			properties[fieldName] = property
+
+			// Fields of object types may be left out of a result, when they would repeat a type that encloses them.
+			if doc.TypeIsNonNull(typeRef) && !g.isObjectType(doc.ResolveTypeNameString(typeRef)) {
+				required = append(required, fieldName)
+			}
+		}
+		s["type"] = "object"
+		s["properties"] = properties
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		if node.Kind == ast.NodeKindObjectTypeDefinition {
+			if description := doc.ObjectTypeDescriptionNameString(node.Ref); description != "" {
+				s["description"] = description
+			}
+		}
+
+	case ast.NodeKindInputObjectTypeDefinition:
+		for k, v := range g.inputValuesSchema(doc.NodeInputFieldDefinitions(node)) {
+			s[k] = v
+		}
+		if description := doc.InputObjectTypeDefinitionDescriptionString(node.Ref); description != "" {
+			s["description"] = description
+		}
+
+	case ast.NodeKindUnionTypeDefinition:
+		members, _ := doc.UnionTypeDefinitionMemberTypeNames(node.Ref)
+		oneOf := make([]any, len(members))
+		for i, member := range members {
+			g.addComponent(member)
+			oneOf[i] = map[string]any{"$ref": "#/components/schemas/" + member}
+		}
+		s["oneOf"] = oneOf
+		s["discriminator"] = map[string]any{"propertyName": "__typename"}
+
+	case ast.NodeKindEnumTypeDefinition:
+		values := make([]string, 0, len(doc.EnumTypeDefinitions[node.Ref].EnumValuesDefinition.Refs))
+		for _, ref := range doc.EnumTypeDefinitions[node.Ref].EnumValuesDefinition.Refs {
+			values = append(values, doc.EnumValueDefinitionNameString(ref))
+		}
+		s["type"] = "string"
+		s["enum"] = values
+
+	default:
+		// Other custom scalars can have any value.
+	}
+}
+
+func (g *openAPIGenerator) isObjectType(name string) bool {
+	node, ok := g.doc.Index.FirstNodeByNameStr(name)
+	if !ok {
+		return false
+	}
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition, ast.NodeKindUnionTypeDefinition:
+		return true
+	}
+	return false
+}
+
+// withDescription adds a description to a schema.  A reference can't have a description of its own,
+// so it is wrapped first.
+func withDescription(s map[string]any, description string) map[string]any {
+	if _, isRef := s["$ref"]; isRef {
+		s = map[string]any{"allOf": []any{s}}
+	}
+	s["description"] = description
+	return s
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+const openAPITestSchema = `
+type Query {
+  getUser(id: String!, verbose: Boolean = false): User
+  listPets: [Pet!]!
+  "Finds users by role."
+  findUsers(role: Role!, limit: Int = 10): [User!]! @deprecated(reason: "Use searchUsers.")
+  createdAt(input: NewUser!): DateTime!
+}
+
+type Mutation {
+  deleteUser(id: String!): Boolean!
+}
+
+type User {
+  id: String!
+  friends(first: Int!): [User!]!
+  manager: User
+  address: Address
+}
+
+type Address {
+  city: String!
+}
+
+type Dog {
+  name: String!
+}
+
+type Cat {
+  lives: Int!
+}
+
+union Pet = Dog | Cat
+
+enum Role {
+  ADMIN
+  MEMBER
+}
+
+input NewUser {
+  name: String!
+  role: Role
+}
+
+scalar DateTime`
+
+func Test_GenerateOpenAPI(t *testing.T) {
+	schema, err := gql.NewSchemaFromString(openAPITestSchema)
+	require.NoError(t, err)
+
+	b, err := utils.JsonSerialize(generateOpenAPI(schema))
+	require.NoError(t, err)
+	doc := gjson.ParseBytes(b)
+
+	assert.Equal(t, "3.0.3", doc.Get("openapi").String())
+
+	getUser := doc.Get(`paths./api/v1/getUser.post`)
+	assert.Equal(t, "getUser", getUser.Get("operationId").String())
+	assert.Equal(t, `["id"]`, getUser.Get("requestBody.content.application/json.schema.required").Raw)
+	assert.Equal(t, "string", getUser.Get("requestBody.content.application/json.schema.properties.id.type").String())
+	assert.True(t, getUser.Get("requestBody.content.application/json.schema.properties.verbose.nullable").Bool())
+	assert.Equal(t, `{"allOf":[{"$ref":"#/components/schemas/User"}],"nullable":true}`, getUser.Get(`responses.200.content.application/json.schema`).Raw)
+	assert.Equal(t, "#/components/schemas/Errors", getUser.Get(`responses.default.content.application/json.schema.$ref`).String())
+
+	listPets := doc.Get(`paths./api/v1/listPets.post`)
+	assert.False(t, listPets.Get("requestBody").Exists())
+	assert.Equal(t, `{"items":{"$ref":"#/components/schemas/Pet"},"type":"array"}`, listPets.Get(`responses.200.content.application/json.schema`).Raw)
+
+	assert.True(t, doc.Get(`paths./api/v1/deleteUser.post`).Exists())
+
+	findUsers := doc.Get(`paths./api/v1/findUsers.post`)
+	assert.Equal(t, "Finds users by role.", findUsers.Get("description").String())
+	assert.True(t, findUsers.Get("deprecated").Bool())
+	assert.Equal(t, `["role"]`, findUsers.Get("requestBody.content.application/json.schema.required").Raw)
+
+	createdAt := doc.Get(`paths./api/v1/createdAt.post`)
+	assert.Equal(t, "date-time", createdAt.Get(`responses.200.content.application/json.schema.format`).String())
+
+	schemas := doc.Get("components.schemas")
+
+	// Fields that require arguments are left out, and only scalar fields are required.
+	user := schemas.Get("User")
+	assert.Equal(t, `["id"]`, user.Get("required").Raw)
+	assert.False(t, user.Get("properties.friends").Exists())
+	assert.True(t, user.Get("properties.manager").Exists())
+	assert.Equal(t, "#/components/schemas/Address", user.Get("properties.address.allOf.0.$ref").String())
+	assert.True(t, schemas.Get("Address").Exists())
+
+	assert.Equal(t, `[{"$ref":"#/components/schemas/Dog"},{"$ref":"#/components/schemas/Cat"}]`, schemas.Get("Pet.oneOf").Raw)
+	assert.Equal(t, "int32", schemas.Get("Cat.properties.lives.format").String())
+	assert.Equal(t, `["ADMIN","MEMBER"]`, schemas.Get("Role.enum").Raw)
+	assert.Equal(t, `["name"]`, schemas.Get("NewUser.required").Raw)
+	assert.False(t, schemas.Get("DateTime").Exists())
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/cache"
+	"github.com/hypermodeinc/modus/runtime/graphql/engine"
+	"github.com/hypermodeinc/modus/runtime/metrics"
+	"github.com/hypermodeinc/modus/runtime/middleware"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astparser"
+)
+
+// maxResponseCacheSize is the total size, in bytes, of the responses that are cached.
+const maxResponseCacheSize = 64 * 1024 * 1024
+
+// cachedResponse is the response to a query, without the extensions that describe its execution.
+type cachedResponse struct {
+	response []byte
+	etag     string
+	expires  time.Time
+}
+
+var responseCache = cache.New(maxResponseCacheSize, func(r *cachedResponse) int64 { return int64(len(r.response)) })
+
+// cacheableQuery is a query whose response may be cached, because each of its root fields calls a function
+// with the @cache directive.  The response is cached for the shortest of the functions' durations.
+type cacheableQuery struct {
+	key string
+	ttl time.Duration
+}
+
+// getCacheableQuery returns the cache key and duration of a query, or false if its response may not be cached.
+// Responses are cached separately for each operation, variables, JWT claims and client,
+// since any of them can change the response.
+func getCacheableQuery(ctx context.Context, req *gql.Request) (*cacheableQuery, bool) {
+	if utils.TraceModeEnabled() {
+		return nil, false
+	}
+
+	doc, report := astparser.ParseGraphqlDocumentString(req.Query)
+	if report.HasErrors() {
+		return nil, false
+	}
+
+	opRef := slices.IndexFunc(doc.OperationDefinitions, func(op ast.OperationDefinition) bool {
+		return req.OperationName == "" || doc.Input.ByteSliceString(op.Name) == req.OperationName
+	})
+	if opRef < 0 {
+		return nil, false
+	}
+	op := doc.OperationDefinitions[opRef]
+	if op.OperationType != ast.OperationTypeQuery || !op.HasSelections {
+		return nil, false
+	}
+
+	var ttl time.Duration
+	if !rootFieldsCacheable(&doc, op.SelectionSet, &ttl, nil) || ttl <= 0 {
+		return nil, false
+	}
+
+	// Requests routed explicitly to or away from a canary get responses of the version they asked for.
+	canary, _ := ctx.Value(utils.CanaryContextKey).(string)
+	h := sha256.New()
+	for _, s := range []string{req.Query, req.OperationName, string(req.Variables), middleware.GetJWTClaims(ctx), canary} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	return &cacheableQuery{key: hex.EncodeToString(h.Sum(nil)), ttl: ttl}, true
+}
+
+// rootFieldsCacheable reports whether every root field of a selection set calls a function whose results are cached,
+// and lowers the duration to the shortest of theirs.
+func rootFieldsCacheable(doc *ast.Document, ssRef int, ttl *time.Duration, fragments []string) bool {
+	for _, sel := range doc.SelectionSets[ssRef].SelectionRefs {
+		selection := doc.Selections[sel]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			name := doc.FieldNameString(selection.Ref)
+			if name == "__typename" {
+				continue
+			}
+			fieldTTL, ok := engine.GetCacheTTL(name)
+			if !ok {
+				return false
+			}
+			if *ttl == 0 || fieldTTL < *ttl {
+				*ttl = fieldTTL
+			}
+		case ast.SelectionKindInlineFragment:
+			if ss, ok := doc.InlineFragmentSelectionSet(selection.Ref); ok && !rootFieldsCacheable(doc, ss, ttl, fragments) {
+				return false
+			}
+		case ast.SelectionKindFragmentSpread:
+			name := doc.FragmentSpreadNameString(selection.Ref)
+			ref, ok := doc.FragmentDefinitionRef([]byte(name))
+			if !ok || slices.Contains(fragments, name) {
+				return false
+			}
+			if fragment := doc.FragmentDefinitions[ref]; fragment.HasSelections && !rootFieldsCacheable(doc, fragment.SelectionSet, ttl, append(fragments, name)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// serveCachedResponse writes the cached response of a query, if there is one.
+// If the client already has the response, as identified by its ETag, only the status is written.
+func serveCachedResponse(w http.ResponseWriter, r *http.Request, q *cacheableQuery) bool {
+	cached, ok := responseCache.Get(q.key)
+	if !ok {
+		metrics.GraphQLResponseCacheNum.WithLabelValues("miss").Inc()
+		return false
+	}
+	metrics.GraphQLResponseCacheNum.WithLabelValues("hit").Inc()
+
+	if writeCacheHeaders(w, r, cached) {
+		return true
+	}
+	utils.WriteJsonContentHeader(w)
+	_, _ = w.Write(cached.response)
+	return true
+}
+
+// cacheResponse caches the response of a query, unless it has errors.  It writes the cache headers of the response,
+// and returns true if the client already has the response, in which case only the status has been written.
+func cacheResponse(w http.ResponseWriter, r *http.Request, q *cacheableQuery, response []byte) bool {
+	if gjson.GetBytes(response, "errors").Exists() {
+		return false
+	}
+
+	sum := sha256.Sum256(response)
+	cached := &cachedResponse{
+		response: response,
+		etag:     `W/"` + hex.EncodeToString(sum[:16]) + `"`,
+		expires:  time.Now().Add(q.ttl),
+	}
+	responseCache.Set(q.key, cached, q.ttl)
+
+	return writeCacheHeaders(w, r, cached)
+}
+
+// writeCacheHeaders writes the ETag and Cache-Control headers of a cached response.  If the request's If-None-Match
+// header matches the ETag, it also writes the Not Modified status, and returns true.
+func writeCacheHeaders(w http.ResponseWriter, r *http.Request, cached *cachedResponse) bool {
+	maxAge := max(int(time.Until(cached.expires).Seconds()), 0)
+	w.Header().Set("ETag", cached.etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+
+	if etagMatches(r.Header.Get("If-None-Match"), cached.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatches compares the ETags of an If-None-Match header with an ETag, using weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+func Test_ResponseCache(t *testing.T) {
+	responseCache.Clear()
+	q := &cacheableQuery{key: "test", ttl: time.Minute}
+	response := []byte(`{"data":{"getUser":{"id":"1"}}}`)
+
+	// A miss executes the query, whose response is then cached.
+	r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	w := httptest.NewRecorder()
+	require.False(t, serveCachedResponse(w, r, q))
+	require.False(t, cacheResponse(w, r, q, response))
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "private, max-age=59", w.Header().Get("Cache-Control"))
+
+	// A hit is served from the cache.
+	w = httptest.NewRecorder()
+	require.True(t, serveCachedResponse(w, r, q))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, string(response), w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	// A client that already has the response gets only the status.
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	require.True(t, serveCachedResponse(w, r, q))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// Responses with errors are not cached.
+	q = &cacheableQuery{key: "errors", ttl: time.Minute}
+	w = httptest.NewRecorder()
+	assert.False(t, cacheResponse(w, r, q, []byte(`{"errors":[{"message":"oops"}],"data":{"getUser":null}}`)))
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.False(t, serveCachedResponse(httptest.NewRecorder(), r, q))
+}
+
+func Test_GetCacheableQuery_NotCached(t *testing.T) {
+	// Without functions whose results are cached, no query is cacheable.
+	for _, query := range []string{
+		`{ getUser(id: "1") { id } }`,
+		`mutation { deleteUser(id: "1") }`,
+		`{ __schema { types { name } } }`,
+	} {
+		_, ok := getCacheableQuery(context.Background(), &gql.Request{Query: query})
+		assert.False(t, ok, query)
+	}
+}
+
+func Test_EtagMatches(t *testing.T) {
+	etag := `W/"abc"`
+	assert.True(t, etagMatches(`W/"abc"`, etag))
+	assert.True(t, etagMatches(`"abc"`, etag))
+	assert.True(t, etagMatches(`"xyz", W/"abc"`, etag))
+	assert.True(t, etagMatches(`*`, etag))
+	assert.False(t, etagMatches(`"xyz"`, etag))
+	assert.False(t, etagMatches(``, etag))
+}
@@ -0,0 +1,311 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/graphql/datasource"
+	"github.com/hypermodeinc/modus/runtime/graphql/engine"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/outputtransforms"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	"github.com/tidwall/gjson"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+)
+
+// RestPathPrefix is the path under which each function is served as a REST endpoint, such as /api/v1/getUser.
+const RestPathPrefix = "/api/v1/"
+
+var RestRequestHandler = http.HandlerFunc(handleRestRequest)
+
+// handleRestRequest calls a function with the arguments in the JSON object of the request body,
+// and returns its result as JSON.  The call is made through the GraphQL engine, as a query or mutation
+// that selects every field of the result, so that it is authorized and resolved as it would be from GraphQL.
+func handleRestRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fnName := strings.TrimPrefix(r.URL.Path, RestPathPrefix)
+	if fnName == "" || strings.HasPrefix(fnName, "_") || strings.Contains(fnName, "/") {
+		writeRestError(w, http.StatusNotFound, "Function not found.")
+		return
+	}
+
+	if !isTrustedFunction(r.Header, fnName) {
+		writeRestError(w, http.StatusForbidden, untrustedFunctionMessage)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeRestError(w, http.StatusBadRequest, "Failed to read the request body.")
+		return
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		body = []byte("{}")
+	} else if !gjson.ValidBytes(body) || !gjson.ParseBytes(body).IsObject() {
+		writeRestError(w, http.StatusBadRequest, "The request body must be a JSON object of the function's arguments.")
+		return
+	}
+
+	schema := engine.GetSchema()
+	eng := engine.GetEngine()
+	if eng == nil || schema == nil {
+		writeRestError(w, http.StatusServiceUnavailable, "There is no active GraphQL schema.  Please load a Modus plugin, or declare connectors in the manifest.")
+		return
+	}
+
+	operationType, field, ok := findFunctionField(schema, fnName)
+	if !ok {
+		writeRestError(w, http.StatusNotFound, "Function not found.")
+		return
+	}
+
+	query, err := buildRestQuery(schema.Document(), operationType, field, body)
+	if err != nil {
+		writeRestError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	gqlRequest := gql.Request{Query: query, Variables: body}
+
+	// Identify the client, so that client-specific output transforms can be applied.
+	if client := r.Header.Get("X-Modus-Client"); client != "" {
+		ctx = context.WithValue(ctx, utils.ClientNameContextKey, client)
+	}
+	if canary := r.Header.Get("X-Modus-Canary"); canary != "" {
+		ctx = context.WithValue(ctx, utils.CanaryContextKey, canary)
+	}
+
+	output := make(map[string]wasmhost.ExecutionInfo)
+	ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, output)
+	ctx = datasource.WithInvocationBudget(ctx, config.RequestConcurrency)
+
+	resultWriter := gql.NewEngineResultWriter()
+	if err := eng.Execute(ctx, &gqlRequest, &resultWriter); err != nil {
+		requestErrors := graphqlerrors.RequestErrorsFromError(err)
+		if len(requestErrors) == 0 {
+			logger.Err(ctx, err).Msg("Failed to execute REST request.")
+			writeRestError(w, http.StatusInternalServerError, "Failed to execute the function.")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = requestErrors.WriteResponse(w)
+		return
+	}
+
+	response := resultWriter.Bytes()
+	if errs := gjson.GetBytes(response, "errors"); errs.Exists() {
+		setRetryAfter(w, response)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(restErrorStatus(errs))
+		fmt.Fprintf(w, `{"errors":%s}`, errs.Raw)
+		return
+	}
+
+	// Reshape the output with any transforms declared in the manifest.
+	data, err := outputtransforms.Apply(ctx, fnName, []byte(gjson.GetBytes(response, "data."+fnName).Raw))
+	if err != nil {
+		logger.Err(ctx, err).Bool("user_visible", true).Msg("Failed to transform the function's output.")
+		writeRestError(w, http.StatusInternalServerError, "Failed to transform the function's output.")
+		return
+	}
+
+	if info, ok := output[fnName]; ok {
+		w.Header().Set("X-Modus-Execution-Id", info.ExecutionId())
+	}
+	utils.WriteJsonContentHeader(w)
+	_, _ = w.Write(data)
+}
+
+func writeRestError(w http.ResponseWriter, status int, msg string) {
+	b, _ := utils.JsonSerialize(msg)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"errors":[{"message":%s}]}`, b)
+}
+
+// restErrorStatuses are the HTTP status codes of the error categories that functions report.
+var restErrorStatuses = map[string]int{
+	"BAD_REQUEST":       http.StatusBadRequest,
+	"UNAUTHENTICATED":   http.StatusUnauthorized,
+	"FORBIDDEN":         http.StatusForbidden,
+	"NOT_FOUND":         http.StatusNotFound,
+	"CONFLICT":          http.StatusConflict,
+	"TOO_MANY_REQUESTS": http.StatusTooManyRequests,
+	"INTERNAL":          http.StatusInternalServerError,
+	"UNAVAILABLE":       http.StatusServiceUnavailable,
+}
+
+// restErrorStatus returns the HTTP status code for the errors of a response, from the category of the first error.
+// Invalid arguments are a bad request, and any other errors are internal server errors.
+func restErrorStatus(errs gjson.Result) int {
+	first := errs.Get("0.extensions")
+	if status, ok := restErrorStatuses[first.Get("category").String()]; ok {
+		return status
+	}
+	if first.Get("code").String() == "BAD_USER_INPUT" {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// findFunctionField returns the root field of a function, and the type of operation that calls it.
+func findFunctionField(schema *gql.Schema, fnName string) (string, int, bool) {
+	doc := schema.Document()
+	if field, ok := findRootField(doc, schema.QueryTypeName(), fnName); ok {
+		return "query", field, true
+	}
+	if schema.HasMutationType() {
+		if field, ok := findRootField(doc, schema.MutationTypeName(), fnName); ok {
+			return "mutation", field, true
+		}
+	}
+	return "", -1, false
+}
+
+func findRootField(doc *ast.Document, typeName, fieldName string) (int, bool) {
+	node, ok := doc.Index.FirstNodeByNameStr(typeName)
+	if !ok {
+		return -1, false
+	}
+	for _, ref := range doc.NodeFieldDefinitions(node) {
+		if doc.FieldDefinitionNameString(ref) == fieldName {
+			return ref, true
+		}
+	}
+	return -1, false
+}
+
+// buildRestQuery builds the GraphQL operation that calls a function, passing each of its arguments as a variable.
+func buildRestQuery(doc *ast.Document, operationType string, field int, variables []byte) (string, error) {
+	fnName := doc.FieldDefinitionNameString(field)
+	args := doc.FieldDefinitionArgumentsDefinitions(field)
+
+	var unknown []string
+	gjson.ParseBytes(variables).ForEach(func(key, _ gjson.Result) bool {
+		if !slices.ContainsFunc(args, func(arg int) bool { return doc.InputValueDefinitionNameString(arg) == key.String() }) {
+			unknown = append(unknown, key.String())
+		}
+		return true
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("function %s has no argument named %s", fnName, strings.Join(unknown, ", "))
+	}
+
+	var b strings.Builder
+	b.WriteString(operationType)
+	if len(args) > 0 {
+		b.WriteByte('(')
+		for i, arg := range args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			typ, err := doc.PrintTypeBytes(doc.InputValueDefinitionType(arg), nil)
+			if err != nil {
+				return "", err
+			}
+			b.WriteByte('$')
+			b.WriteString(doc.InputValueDefinitionNameString(arg))
+			b.WriteString(": ")
+			b.Write(typ)
+		}
+		b.WriteByte(')')
+	}
+
+	b.WriteString(" { ")
+	b.WriteString(fnName)
+	if len(args) > 0 {
+		b.WriteByte('(')
+		for i, arg := range args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			name := doc.InputValueDefinitionNameString(arg)
+			b.WriteString(name)
+			b.WriteString(": $")
+			b.WriteString(name)
+		}
+		b.WriteByte(')')
+	}
+	writeFullSelection(&b, doc, doc.ResolveTypeNameString(doc.FieldDefinitionType(field)), nil)
+	b.WriteString(" }")
+
+	return b.String(), nil
+}
+
+// writeFullSelection writes the selection of all of the fields of a type, and of the types of those fields,
+// leaving out fields that would repeat a type that encloses them, and fields that require arguments.
+func writeFullSelection(b *strings.Builder, doc *ast.Document, typeName string, enclosing []string) {
+	node, ok := doc.Index.FirstNodeByNameStr(typeName)
+	if !ok {
+		return
+	}
+
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition:
+		enclosing = append(enclosing, typeName)
+		b.WriteString(" {")
+		written := false
+		for _, ref := range doc.NodeFieldDefinitions(node) {
+			name := doc.FieldDefinitionNameString(ref)
+			fieldType := doc.ResolveTypeNameString(doc.FieldDefinitionType(ref))
+			if strings.HasPrefix(name, "__") || slices.Contains(enclosing, fieldType) || requiresArguments(doc, ref) {
+				continue
+			}
+			b.WriteByte(' ')
+			b.WriteString(name)
+			writeFullSelection(b, doc, fieldType, enclosing)
+			written = true
+		}
+		if !written {
+			b.WriteString(" __typename")
+		}
+		b.WriteString(" }")
+
+	case ast.NodeKindUnionTypeDefinition:
+		members, _ := doc.UnionTypeDefinitionMemberTypeNames(node.Ref)
+		b.WriteString(" { __typename")
+		for _, member := range members {
+			if slices.Contains(enclosing, member) {
+				continue
+			}
+			b.WriteString(" ... on ")
+			b.WriteString(member)
+			writeFullSelection(b, doc, member, enclosing)
+		}
+		b.WriteString(" }")
+	}
+}
+
+func requiresArguments(doc *ast.Document, fieldRef int) bool {
+	for _, arg := range doc.FieldDefinitionArgumentsDefinitions(fieldRef) {
+		if doc.TypeIsNonNull(doc.InputValueDefinitionType(arg)) && !doc.InputValueDefinitionHasDefaultValue(arg) {
+			return true
+		}
+	}
+	return false
+}
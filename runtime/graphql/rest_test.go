@@ -0,0 +1,129 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+const restTestSchema = `
+type Query {
+  getUser(id: String!, verbose: Boolean = false): User
+  listPets: [Pet!]!
+}
+
+type Mutation {
+  deleteUser(id: String!): Boolean!
+}
+
+type User {
+  id: String!
+  friends(first: Int!): [User!]!
+  manager: User
+  address: Address
+}
+
+type Address {
+  city: String!
+}
+
+type Dog {
+  name: String!
+}
+
+type Cat {
+  lives: Int!
+}
+
+union Pet = Dog | Cat`
+
+func Test_BuildRestQuery(t *testing.T) {
+	schema, err := gql.NewSchemaFromString(restTestSchema)
+	require.NoError(t, err)
+
+	tests := []struct {
+		fnName    string
+		variables string
+		expected  string
+	}{
+		{
+			fnName:    "getUser",
+			variables: `{"id": "1"}`,
+			expected:  `query($id: String!, $verbose: Boolean) { getUser(id: $id, verbose: $verbose) { id address { city } } }`,
+		},
+		{
+			fnName:   "listPets",
+			expected: `query { listPets { __typename ... on Dog { name } ... on Cat { lives } } }`,
+		},
+		{
+			fnName:    "deleteUser",
+			variables: `{"id": "1"}`,
+			expected:  `mutation($id: String!) { deleteUser(id: $id) }`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fnName, func(t *testing.T) {
+			operationType, field, ok := findFunctionField(schema, tt.fnName)
+			require.True(t, ok)
+
+			query, err := buildRestQuery(schema.Document(), operationType, field, []byte(tt.variables))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, query)
+
+			req := gql.Request{Query: query}
+			result, err := req.ValidateForSchema(schema)
+			require.NoError(t, err)
+			assert.True(t, result.Valid, result.Errors)
+		})
+	}
+
+	_, _, ok := findFunctionField(schema, "missing")
+	assert.False(t, ok)
+
+	operationType, field, _ := findFunctionField(schema, "getUser")
+	_, err = buildRestQuery(schema.Document(), operationType, field, []byte(`{"id": "1", "name": "x"}`))
+	assert.EqualError(t, err, "function getUser has no argument named name")
+}
+
+func Test_RestErrorStatus(t *testing.T) {
+	tests := map[string]int{
+		`[{"message": "a", "extensions": {"category": "NOT_FOUND"}}]`:   http.StatusNotFound,
+		`[{"message": "a", "extensions": {"category": "FORBIDDEN"}}]`:   http.StatusForbidden,
+		`[{"message": "a", "extensions": {"code": "BAD_USER_INPUT"}}]`:  http.StatusBadRequest,
+		`[{"message": "a", "extensions": {"level": "error"}}]`:          http.StatusInternalServerError,
+		`[{"message": "a"}, {"extensions": {"category": "NOT_FOUND"}}]`: http.StatusInternalServerError,
+	}
+	for errs, expected := range tests {
+		assert.Equal(t, expected, restErrorStatus(gjson.Parse(errs)), errs)
+	}
+}
+
+func Test_SetRetryAfter(t *testing.T) {
+	response := []byte(`{"data":null,"errors":[
+		{"message":"rate limit exceeded","path":["a"],"extensions":{"category":"TOO_MANY_REQUESTS","retryAfter":3}},
+		{"message":"rate limit exceeded","path":["b"],"extensions":{"category":"TOO_MANY_REQUESTS","retryAfter":60}}
+	]}`)
+	w := httptest.NewRecorder()
+	setRetryAfter(w, response)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusTooManyRequests, restErrorStatus(gjson.GetBytes(response, "errors")))
+
+	w = httptest.NewRecorder()
+	setRetryAfter(w, []byte(`{"errors":[{"message":"oops"}]}`))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"net/http"
+
+	"github.com/hypermodeinc/modus/runtime/graphql/engine"
+	"github.com/hypermodeinc/modus/runtime/graphql/schemagen"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// SchemaHandler returns the GraphQL schema that is being served, which is composed of the functions of all plugins.
+// The schema of a single plugin, as it would be on its own, is returned when the plugin query parameter names it.
+func SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	plugin := r.URL.Query().Get("plugin")
+	sdl, ok := engine.GetSchemaDocument(plugin)
+	if !ok {
+		if plugin != "" {
+			http.Error(w, "Plugin not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "No schema is loaded", http.StatusServiceUnavailable)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/graphql; charset=utf-8")
+	_, _ = w.Write([]byte(sdl))
+}
+
+// SchemaChangesHandler returns the changes of the schema that is being served from the schema that was served before it,
+// and whether each change is breaking, so that deployments can be checked for changes that may fail their clients.
+func SchemaChangesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	changes := engine.GetSchemaChanges()
+	if changes == nil {
+		changes = []schemagen.SchemaChange{}
+	}
+	utils.WriteJsonResponse(w, changes)
+}
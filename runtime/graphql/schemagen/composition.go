@@ -0,0 +1,226 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// ComposedGraphQLSchema is a schema of the functions of several plugins, along with the schema of each plugin on its own.
+type ComposedGraphQLSchema struct {
+	*GraphQLSchema
+
+	// Plugins are the schemas of the individual plugins, by plugin name.  Their functions keep their own names.
+	Plugins map[string]*GraphQLSchema
+
+	// Conflicts describe the functions and types that were renamed, because more than one plugin defines them.
+	Conflicts []string
+}
+
+// ComposeGraphQLSchema generates a single schema for the functions of all of the plugins.
+//
+// Functions keep their names, unless more than one plugin exports a function of the same name.  Each of those
+// is then qualified with the namespace of its plugin, such as myPlugin_getUser, which is also a name that the
+// function is registered by.  Likewise, types of the same name that are defined differently by more than one plugin
+// are scoped to each plugin, such as MyPluginUser.  Types that are defined the same way are shared.
+// The plugins are composed in the order given, so the schema is the same each time they are composed.
+func ComposeGraphQLSchema(ctx context.Context, mds []*metadata.Metadata) (*ComposedGraphQLSchema, error) {
+	span, _ := utils.NewSentrySpanForCurrentFunc(ctx)
+	defer span.Finish()
+
+	result := &ComposedGraphQLSchema{Plugins: make(map[string]*GraphQLSchema, len(mds))}
+	schemas := make([]*pluginSchema, 0, len(mds))
+	for _, md := range mds {
+		ps, err := transformMetadata(md)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", md.Name(), err)
+		}
+		result.Plugins[md.Name()] = buildSchema(ps, false)
+
+		// Only the types that the plugin's functions use can conflict with those of other plugins.
+		if len(mds) > 1 {
+			ps.inputTypeDefs = usedTypes(ps.inputTypeDefs, ps.functions, true)
+			ps.resultTypeDefs = usedTypes(ps.resultTypeDefs, ps.functions, false)
+		}
+		schemas = append(schemas, ps)
+	}
+
+	result.Conflicts = append(result.Conflicts, scopeConflictingTypes(schemas, true)...)
+	result.Conflicts = append(result.Conflicts, scopeConflictingTypes(schemas, false)...)
+	result.Conflicts = append(result.Conflicts, qualifyConflictingFunctions(schemas)...)
+
+	composed := newPluginSchema("")
+	for _, ps := range schemas {
+		composed.functions = append(composed.functions, ps.functions...)
+		for name, t := range ps.inputTypeDefs {
+			composed.inputTypeDefs[name] = t
+		}
+		for name, t := range ps.resultTypeDefs {
+			composed.resultTypeDefs[name] = t
+		}
+	}
+	slices.SortStableFunc(composed.functions, func(a, b *FunctionSignature) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	result.GraphQLSchema = buildSchema(composed, true)
+	return result, nil
+}
+
+func usedTypes(typeDefs map[string]*TypeDefinition, functions []*FunctionSignature, forInput bool) map[string]*TypeDefinition {
+	used := filterTypes(utils.MapValues(typeDefs), functions, forInput)
+	result := make(map[string]*TypeDefinition, len(used))
+	for _, t := range used {
+		result[t.Name] = t
+	}
+	return result
+}
+
+// qualifyConflictingFunctions qualifies the names of functions that more than one plugin exports,
+// with the namespace of each plugin.
+func qualifyConflictingFunctions(schemas []*pluginSchema) []string {
+	owners := make(map[string][]*pluginSchema)
+	for _, ps := range schemas {
+		for _, f := range ps.functions {
+			owners[f.Name] = append(owners[f.Name], ps)
+		}
+	}
+
+	var conflicts []string
+	for _, name := range utils.MapKeys(owners) {
+		if len(owners[name]) < 2 {
+			continue
+		}
+
+		qualified := make([]string, 0, len(owners[name]))
+		for _, ps := range owners[name] {
+			for i, f := range ps.functions {
+				if f.Name != name {
+					continue
+				}
+				if ps.namespace == "" {
+					// The function can't be qualified, so it is left out.
+					ps.functions = slices.Delete(ps.functions, i, i+1)
+					break
+				}
+
+				f.Name = ps.namespace + "_" + name
+				if f.EntityResolver != nil {
+					f.EntityResolver.Function = f.Name
+				}
+				qualified = append(qualified, f.Name)
+				break
+			}
+		}
+		conflicts = append(conflicts, fmt.Sprintf("Function %s is exported by more than one plugin, so it is named %s.", name, strings.Join(qualified, ", ")))
+	}
+
+	slices.Sort(conflicts)
+	return conflicts
+}
+
+// maxRenamePasses bounds the passes over the types of the plugins, in case plugins have the same namespace.
+const maxRenamePasses = 10
+
+// scopeConflictingTypes renames the types that more than one plugin defines differently, by prefixing them with
+// the namespace of each plugin.  Renaming a type can change the definitions of the types that refer to it,
+// so this is repeated until no conflicts remain.
+func scopeConflictingTypes(schemas []*pluginSchema, forInput bool) []string {
+	typeDefs := func(ps *pluginSchema) map[string]*TypeDefinition {
+		if forInput {
+			return ps.inputTypeDefs
+		}
+		return ps.resultTypeDefs
+	}
+
+	var conflicts []string
+	for range maxRenamePasses {
+		owners := make(map[string][]*pluginSchema)
+		for _, ps := range schemas {
+			for name := range typeDefs(ps) {
+				owners[name] = append(owners[name], ps)
+			}
+		}
+
+		renamed := false
+		for _, name := range utils.MapKeys(owners) {
+			defs := owners[name]
+			if len(defs) < 2 || !slices.ContainsFunc(defs[1:], func(ps *pluginSchema) bool {
+				return !sameTypeDefinition(typeDefs(defs[0])[name], typeDefs(ps)[name])
+			}) {
+				continue
+			}
+
+			scoped := make([]string, 0, len(defs))
+			for _, ps := range defs {
+				if ps.namespace == "" {
+					continue
+				}
+				newName := strings.ToUpper(ps.namespace[:1]) + ps.namespace[1:] + name
+				ps.renameType(typeDefs(ps), name, newName)
+				scoped = append(scoped, newName)
+				renamed = true
+			}
+			conflicts = append(conflicts, fmt.Sprintf("Type %s is defined differently by more than one plugin, so it is named %s.", name, strings.Join(scoped, ", ")))
+		}
+
+		if !renamed {
+			break
+		}
+	}
+
+	slices.Sort(conflicts)
+	return conflicts
+}
+
+func sameTypeDefinition(a, b *TypeDefinition) bool {
+	return a.IsMapType == b.IsMapType && slices.EqualFunc(a.Fields, b.Fields, func(x, y *NameTypePair) bool {
+		return x.Name == y.Name && x.Type == y.Type
+	})
+}
+
+// renameType renames one of the plugin's types, and the references to it in its other types and functions.
+func (ps *pluginSchema) renameType(typeDefs map[string]*TypeDefinition, oldName, newName string) {
+	t := typeDefs[oldName]
+	delete(typeDefs, oldName)
+	t.Name = newName
+	typeDefs[newName] = t
+
+	for _, t := range typeDefs {
+		for _, f := range t.Fields {
+			f.Type = renameTypeReference(f.Type, oldName, newName)
+		}
+	}
+	for _, f := range ps.functions {
+		f.ReturnType = renameTypeReference(f.ReturnType, oldName, newName)
+		for _, p := range f.Parameters {
+			p.Type = renameTypeReference(p.Type, oldName, newName)
+		}
+		if f.EntityType == oldName {
+			f.EntityType = newName
+		}
+	}
+}
+
+// renameTypeReference renames the named type of a type reference, such as User in [User!]!.
+func renameTypeReference(typ, oldName, newName string) string {
+	name := strings.Trim(typ, "[]!")
+	if name != oldName {
+		return typ
+	}
+	i := strings.Index(typ, name)
+	return typ[:i] + newName + typ[i+len(name):]
+}
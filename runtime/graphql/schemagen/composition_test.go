@@ -0,0 +1,172 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+
+	"github.com/stretchr/testify/require"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+func Test_ComposeGraphQLSchema(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	users := metadata.NewPluginMetadata()
+	users.Plugin = "user-service@1.0.0"
+	users.SDK = "modus-sdk-go"
+	users.FnExports.AddFunction("getUser").
+		WithParameter("id", "string").
+		WithResult("*users.User")
+	users.FnExports.AddFunction("sayHello").
+		WithResult("string")
+	users.Types.AddType("*users.User").
+		WithId(3)
+	users.Types.AddType("users.User").
+		WithId(4).
+		WithField("id", "string").
+		WithField("name", "string").
+		WithField("address", "*users.Address")
+	users.Types.AddType("*users.Address").
+		WithId(5)
+	users.Types.AddType("users.Address").
+		WithId(6).
+		WithField("city", "string")
+
+	orders := metadata.NewPluginMetadata()
+	orders.Plugin = "orders"
+	orders.SDK = "modus-sdk-go"
+	orders.FnExports.AddFunction("getCustomer").
+		WithParameter("id", "string").
+		WithResult("*orders.User")
+	orders.FnExports.AddFunction("sayHello").
+		WithResult("string")
+	orders.Types.AddType("*orders.User").
+		WithId(3)
+	orders.Types.AddType("orders.User").
+		WithId(4).
+		WithField("id", "string").
+		WithField("address", "*orders.Address").
+		WithField("createdAt", "time.Time")
+	orders.Types.AddType("*orders.Address").
+		WithId(5)
+	orders.Types.AddType("orders.Address").
+		WithId(6).
+		WithField("city", "string")
+
+	result, err := ComposeGraphQLSchema(context.Background(), []*metadata.Metadata{orders, users})
+	require.Nil(t, err)
+
+	// Types that are defined the same way are shared, while the others are scoped to their plugins.
+	// Functions of the same name are qualified with the namespace of their plugins.
+	expectedSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  getCustomer(id: String!): OrdersUser
+  getUser(id: String!): UserServiceUser
+  orders_sayHello: String!
+  userService_sayHello: String!
+}
+
+scalar DateTime
+
+type Address {
+  city: String!
+}
+
+type OrdersUser {
+  id: String!
+  address: Address
+  createdAt: DateTime!
+}
+
+type UserServiceUser {
+  id: String!
+  name: String!
+  address: Address
+}
+`[1:]
+
+	require.Equal(t, expectedSchema, result.Schema)
+	require.Equal(t, []string{
+		"Type User is defined differently by more than one plugin, so it is named OrdersUser, UserServiceUser.",
+		"Function sayHello is exported by more than one plugin, so it is named orders_sayHello, userService_sayHello.",
+	}, result.Conflicts)
+
+	_, err = gql.NewSchemaFromString(result.Schema)
+	require.Nil(t, err)
+
+	// Each plugin's own schema is unchanged.
+	expectedOrdersSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  getCustomer(id: String!): User
+  sayHello: String!
+}
+
+scalar DateTime
+
+type Address {
+  city: String!
+}
+
+type User {
+  id: String!
+  address: Address
+  createdAt: DateTime!
+}
+`[1:]
+
+	require.Len(t, result.Plugins, 2)
+	require.Equal(t, expectedOrdersSchema, result.Plugins["orders"].Schema)
+}
+
+func Test_ComposeGraphQLSchema_SinglePlugin(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.Plugin = "example"
+	md.SDK = "modus-sdk-go"
+	md.FnExports.AddFunction("sayHello").
+		WithParameter("name", "string").
+		WithResult("string")
+
+	expected, err := GetGraphQLSchema(context.Background(), md)
+	require.Nil(t, err)
+
+	result, err := ComposeGraphQLSchema(context.Background(), []*metadata.Metadata{md})
+	require.Nil(t, err)
+	require.Equal(t, expected.Schema, result.Schema)
+	require.Equal(t, expected.Schema, result.Plugins["example"].Schema)
+	require.Empty(t, result.Conflicts)
+}
+
+func Test_Namespace(t *testing.T) {
+	tests := map[string]string{
+		"example":          "example",
+		"user-service@1.0": "userService",
+		"My_Plugin":        "myPlugin",
+		"2fa-service":      "faService",
+		"":                 "",
+	}
+	for name, expected := range tests {
+		md := &metadata.Metadata{Plugin: name}
+		require.Equal(t, expected, md.Namespace(), name)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// addConnectors adds the connectors declared in the manifest as query fields, or as mutation fields
+// for connectors whose HTTP method changes data on the host, such as POST.
+// Functions take precedence over connectors that have the same name.
+func addConnectors(functions []*FunctionSignature, resultTypeDefs map[string]*TypeDefinition) []*FunctionSignature {
+	connectors := manifestdata.GetManifest().Connectors
+	if len(connectors) == 0 {
+		return functions
+	}
+
+	fnNames := make(map[string]bool, len(functions))
+	for _, f := range functions {
+		fnNames[f.Name] = true
+	}
+
+	names := utils.MapKeys(connectors)
+	sort.Strings(names)
+	for _, name := range names {
+		if fnNames[name] {
+			continue
+		}
+
+		c := connectors[name]
+
+		argNames := utils.MapKeys(c.Arguments)
+		sort.Strings(argNames)
+		params := make([]*ParameterSignature, len(argNames))
+		for i, argName := range argNames {
+			params[i] = &ParameterSignature{
+				Name: argName,
+				Type: c.Arguments[argName],
+			}
+		}
+
+		var returnType string
+		if len(c.Fields) > 0 {
+			fieldNames := utils.MapKeys(c.Fields)
+			sort.Strings(fieldNames)
+			fields := make([]*NameTypePair, len(fieldNames))
+			for i, fieldName := range fieldNames {
+				fields[i] = &NameTypePair{
+					Name: fieldName,
+					Type: c.Fields[fieldName].Type,
+				}
+			}
+
+			typeName := strings.ToUpper(name[:1]) + name[1:]
+			if _, ok := resultTypeDefs[typeName]; ok {
+				typeName += "Result"
+			}
+			returnType = newType(typeName, fields, resultTypeDefs)
+		} else if c.ReturnType != "" {
+			returnType = c.ReturnType
+		} else {
+			returnType = "String"
+		}
+
+		functions = append(functions, &FunctionSignature{
+			Name:        name,
+			Parameters:  params,
+			ReturnType:  returnType,
+			Description: c.Description,
+			Mutation:    isMutationMethod(c.Method),
+		})
+	}
+
+	return functions
+}
+
+// isMutationMethod reports whether requests with the HTTP method are not safe to repeat or cache.
+// The default method is GET.
+func isMutationMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
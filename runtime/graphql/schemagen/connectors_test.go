@@ -0,0 +1,91 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetGraphQLSchema_Connectors(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{
+		Connectors: map[string]manifest.ConnectorInfo{
+			"stockPrice": {
+				Description: "Gets a stock price.",
+				Host:        "api",
+				Arguments:   map[string]string{"symbol": "String!"},
+				ReturnType:  "Float",
+				Select:      "quote.price",
+			},
+			"userProfile": {
+				Host:      "api",
+				Arguments: map[string]string{"id": "Int!"},
+				Fields: map[string]manifest.ConnectorFieldInfo{
+					"name": {Type: "String!", Select: "profile.displayName"},
+					"tags": {Type: "[String!]", Select: "profile.tags"},
+				},
+			},
+			"sayHello": {
+				Host: "api",
+			},
+			"placeOrder": {
+				Host:      "api",
+				Method:    "POST",
+				Arguments: map[string]string{"item": "String!"},
+			},
+		},
+	})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+	md.FnExports.AddFunction("sayHello").
+		WithParameter("name", "string").
+		WithResult("string")
+
+	result, err := GetGraphQLSchema(context.Background(), md)
+
+	// Note: the sayHello function takes precedence over the connector of the same name.
+	expectedSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  sayHello(name: String!): String!
+  """
+  Gets a stock price.
+  """
+  stockPrice(symbol: String!): Float
+  userProfile(id: Int!): UserProfile
+}
+
+type Mutation {
+  placeOrder(item: String!): String
+}
+
+type UserProfile {
+  name: String!
+  tags: [String!]
+}
+`[1:]
+
+	require.Nil(t, err)
+	require.Equal(t, expectedSchema, result.Schema)
+
+	// connectors can be served without any plugin loaded
+	result, err = GetGraphQLSchema(context.Background(), nil)
+	require.Nil(t, err)
+	require.Contains(t, result.Schema, "  sayHello: String\n")
+}
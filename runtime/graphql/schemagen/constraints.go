@@ -0,0 +1,48 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"slices"
+
+	"github.com/hypermodeinc/modus/runtime/graphql/validation"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+)
+
+// The constraints of arguments are declared with the @constraint directive, for tools that read the schema.
+// Introspection doesn't include directives, so they are also described in the argument's description.
+const constraintDirectiveDefinition = "directive @constraint(min: Float, max: Float, minLength: Int, maxLength: Int, pattern: String, required: Boolean) on ARGUMENT_DEFINITION"
+
+func addConstraints(f *metadata.Function, params []*ParameterSignature) error {
+	constraints, err := validation.GetConstraints(f)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range constraints {
+		i := slices.IndexFunc(params, func(p *ParameterSignature) bool { return p.Name == c.Param })
+		if i < 0 {
+			continue
+		}
+
+		p := params[i]
+		p.Constraint = c.SchemaArguments()
+		if p.Description == "" {
+			p.Description = c.Description()
+		} else {
+			p.Description += " " + c.Description()
+		}
+	}
+	return nil
+}
+
+func hasConstraints(f *FunctionSignature) bool {
+	return slices.ContainsFunc(f.Parameters, func(p *ParameterSignature) bool { return p.Constraint != "" })
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// Deprecation is the deprecation notice of a function or field, which is written as the @deprecated directive.
+type Deprecation struct {
+	Reason string
+}
+
+// parseDeprecation separates the deprecation notice from the rest of the documentation of a function or field.
+// A notice is either a paragraph that starts with "Deprecated:", as is the convention for Go doc comments,
+// or a paragraph that starts with a "@deprecated" tag, as in JSDoc comments.  The rest of the paragraph is the reason.
+func parseDeprecation(docs string) (string, *Deprecation) {
+	if !strings.Contains(docs, "Deprecated:") && !strings.Contains(docs, "@deprecated") {
+		return docs, nil
+	}
+
+	var deprecation *Deprecation
+	paragraphs := strings.Split(docs, "\n\n")
+	description := make([]string, 0, len(paragraphs))
+	for _, p := range paragraphs {
+		if deprecation == nil {
+			reason, found := strings.CutPrefix(p, "Deprecated:")
+			if !found {
+				reason, found = strings.CutPrefix(p, "@deprecated")
+			}
+			if found {
+				deprecation = &Deprecation{Reason: strings.Join(strings.Fields(reason), " ")}
+				continue
+			}
+		}
+		description = append(description, p)
+	}
+
+	return strings.TrimSpace(strings.Join(description, "\n\n")), deprecation
+}
+
+func writeDeprecation(buf *bytes.Buffer, d *Deprecation) {
+	if d == nil {
+		return
+	}
+
+	buf.WriteString(" @deprecated")
+	if d.Reason != "" {
+		if s, err := utils.JsonSerialize(d.Reason); err == nil {
+			buf.WriteString("(reason: ")
+			buf.Write(s)
+			buf.WriteByte(')')
+		}
+	}
+}
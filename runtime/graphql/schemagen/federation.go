@@ -0,0 +1,135 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+)
+
+// entityDirective marks a function that resolves an entity of an Apollo Federation supergraph, such as:
+//
+//	//modus:entity
+//	func GetUser(id string) (*User, error)
+//
+//	//modus:entity key=email
+//	func GetUserByEmail(address string) (*User, error)
+//
+// The function's result type becomes an entity, whose key is the function's parameters, which must be fields
+// of the type.  The "key" argument can list the key fields instead, separated by commas, in the order of the
+// parameters.  When there are entities, the schema is a federation subgraph, which can join a supergraph.
+const entityDirective = "entity"
+
+// EntityResolver is a function that resolves entities of a type from their key fields,
+// which are passed to the parameters of the same position.
+type EntityResolver struct {
+	Function   string
+	KeyFields  []string
+	Parameters []string
+}
+
+// The fields, types and directives that a federation subgraph adds to its schema.
+// See https://www.apollographql.com/docs/federation/subgraph-spec
+const (
+	EntitiesField = "_entities"
+	ServiceField  = "_service"
+)
+
+const federationDefinitions = `
+union _Entity = %s
+
+directive @key(fields: _FieldSet!) repeatable on OBJECT | INTERFACE`
+
+// getEntityResolver returns the entity type of a function marked as an entity resolver,
+// and the resolver that the runtime uses to resolve entities of the type.
+func getEntityResolver(f *metadata.Function, params []*ParameterSignature, returnType string, typeDefs map[string]*TypeDefinition) (string, *EntityResolver, error) {
+	typeName := strings.TrimSuffix(returnType, "!")
+	t, ok := typeDefs[typeName]
+	if !ok || len(t.Fields) == 0 || t.IsMapType {
+		return "", nil, fmt.Errorf("only functions that return an object can resolve entities")
+	}
+
+	if len(params) == 0 {
+		return "", nil, fmt.Errorf("a function that resolves entities must have parameters for the key fields")
+	}
+
+	paramNames := make([]string, len(params))
+	for i, p := range params {
+		paramNames[i] = p.Name
+	}
+
+	var keyFields []string
+	if key := f.GetDirective(entityDirective).Args["key"]; key != "" {
+		for _, k := range strings.Split(key, ",") {
+			keyFields = append(keyFields, strings.TrimSpace(k))
+		}
+		if len(keyFields) != len(params) {
+			return "", nil, fmt.Errorf("the key of the entity must have a field for each parameter")
+		}
+	} else {
+		keyFields = paramNames
+	}
+
+	for _, k := range keyFields {
+		if !slices.ContainsFunc(t.Fields, func(f *NameTypePair) bool { return f.Name == k }) {
+			return "", nil, fmt.Errorf("key field %s is not a field of type %s", k, typeName)
+		}
+	}
+
+	return typeName, &EntityResolver{Function: f.Name, KeyFields: keyFields, Parameters: paramNames}, nil
+}
+
+// addKeys adds the keys of the entity resolvers to the entity types.
+func addKeys(entities map[string][]*EntityResolver, resultTypeDefs []*TypeDefinition) {
+	for _, t := range resultTypeDefs {
+		for _, r := range entities[t.Name] {
+			key := strings.Join(r.KeyFields, " ")
+			if !slices.Contains(t.Keys, key) {
+				t.Keys = append(t.Keys, key)
+			}
+		}
+	}
+}
+
+// writeFederatedSchema writes the schema of a federation subgraph, which adds the _entities and _service fields
+// to the Query type, along with the types and directives they use.
+func writeFederatedSchema(buf *bytes.Buffer, functions []*FunctionSignature, scalarTypes []string, inputTypeDefs, resultTypeDefs []*TypeDefinition, entities map[string][]*EntityResolver) {
+	functions = append(slices.Clone(functions),
+		&FunctionSignature{
+			Name:       EntitiesField,
+			Parameters: []*ParameterSignature{{Name: "representations", Type: "[_Any!]!"}},
+			ReturnType: "[_Entity]!",
+		},
+		&FunctionSignature{
+			Name:       ServiceField,
+			ReturnType: "_Service!",
+		},
+	)
+	scalarTypes = append(slices.Clone(scalarTypes), "_Any", "_FieldSet")
+	resultTypeDefs = append(slices.Clone(resultTypeDefs), &TypeDefinition{
+		Name:   "_Service",
+		Fields: []*NameTypePair{{Name: "sdl", Type: "String"}},
+	})
+
+	writeSchema(buf, functions, scalarTypes, inputTypeDefs, resultTypeDefs)
+
+	typeNames := make([]string, 0, len(entities))
+	for name := range entities {
+		typeNames = append(typeNames, name)
+	}
+	slices.Sort(typeNames)
+
+	fmt.Fprintf(buf, federationDefinitions, strings.Join(typeNames, " | "))
+	buf.WriteByte('\n')
+}
@@ -9,7 +9,10 @@
 
 package schemagen
 
-import "github.com/hypermodeinc/modus/runtime/manifestdata"
+import (
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+)
 
 func getFnFilter() func(*FunctionSignature) bool {
 	embedders := make(map[string]bool)
@@ -20,6 +23,6 @@ func getFnFilter() func(*FunctionSignature) bool {
 	}
 
 	return func(f *FunctionSignature) bool {
-		return !embedders[f.Name]
+		return !embedders[f.Name] && !plugins.IsHook(f.Name)
 	}
 }
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+)
+
+// paginateDirective marks a function that returns a list, whose result is then a Relay-style connection.
+// Clients page through the list with the "first" and "after" arguments, and the runtime builds the edges,
+// cursors and page info.  The "limit" and "offset" arguments of the directive name parameters of the function,
+// which are then set by the runtime instead of the client, so that the function only needs to return one page.
+const paginateDirective = "paginate"
+
+// The arguments that clients use to page through a connection.
+const (
+	firstArgument = "first"
+	afterArgument = "after"
+)
+
+// paginate converts the signature of a function to return a connection of the items of the list it returns.
+func paginate(d *metadata.Directive, params []*ParameterSignature, returnType string, typeDefs map[string]*TypeDefinition) ([]*ParameterSignature, string, error) {
+	listType := strings.TrimSuffix(returnType, "!")
+	if !strings.HasPrefix(listType, "[") {
+		return nil, "", errors.New("only functions that return a list can be paginated")
+	}
+
+	nodeType := listType[1 : len(listType)-1]
+	if strings.HasPrefix(nodeType, "[") {
+		return nil, "", errors.New("functions that return a list of lists can't be paginated")
+	}
+
+	// The parameters set by the runtime are not arguments of the field.
+	mapped := []string{d.Args["limit"], d.Args["offset"]}
+	results := make([]*ParameterSignature, 0, len(params)+2)
+	for _, p := range params {
+		switch {
+		case p.Name != "" && slices.Contains(mapped, p.Name):
+			continue
+		case p.Name == firstArgument || p.Name == afterArgument:
+			return nil, "", fmt.Errorf("parameter %s conflicts with the pagination arguments", p.Name)
+		}
+		results = append(results, p)
+	}
+	for _, name := range mapped {
+		if name != "" && !slices.ContainsFunc(params, func(p *ParameterSignature) bool { return p.Name == name }) {
+			return nil, "", fmt.Errorf("parameter %s named by the @%s directive does not exist", name, paginateDirective)
+		}
+	}
+
+	results = append(results,
+		&ParameterSignature{Name: firstArgument, Type: "Int", Description: "The number of items to return."},
+		&ParameterSignature{Name: afterArgument, Type: "String", Description: "The cursor of the item to return items after."},
+	)
+
+	// The connection type is named for the items, such as UserConnection, or NullableUserConnection.
+	name := getBaseType(nodeType)
+	if !strings.HasSuffix(nodeType, "!") {
+		name = "Nullable" + name
+	}
+
+	pageInfo := newType("PageInfo", []*NameTypePair{
+		{Name: "hasNextPage", Type: "Boolean!"},
+		{Name: "hasPreviousPage", Type: "Boolean!"},
+		{Name: "startCursor", Type: "String"},
+		{Name: "endCursor", Type: "String"},
+	}, typeDefs)
+
+	edge := newType(name+"Edge", []*NameTypePair{
+		{Name: "node", Type: nodeType},
+		{Name: "cursor", Type: "String!"},
+	}, typeDefs)
+
+	connection := newType(name+"Connection", []*NameTypePair{
+		{Name: "edges", Type: "[" + edge + "!]!"},
+		{Name: "pageInfo", Type: pageInfo + "!"},
+		{Name: "totalCount", Type: "Int", Description: "The total number of items, when it is known."},
+	}, typeDefs)
+
+	return results, connection + "!", nil
+}
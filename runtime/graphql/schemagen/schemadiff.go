@@ -0,0 +1,281 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SchemaChange is a change between two generated schemas.  A breaking change can fail operations of clients that
+// were written for the old schema, such as a removed field, an argument that became required,
+// or a field whose type changed.
+type SchemaChange struct {
+	Description string `json:"change"`
+	Breaking    bool   `json:"breaking"`
+}
+
+// DiffSchemas compares two generated schemas, and returns a concise description of each change.
+// Fields are identified by their type and name, such as "Query.getPerson", and other definitions by their declaration.
+// Added items start with "+", removed items with "-", and changed fields with "~".
+func DiffSchemas(oldSchema, newSchema string) []string {
+	changes := DiffSchemaChanges(oldSchema, newSchema)
+	descriptions := make([]string, len(changes))
+	for i, c := range changes {
+		descriptions[i] = c.Description
+	}
+	return descriptions
+}
+
+// DiffSchemaChanges compares two generated schemas, as DiffSchemas does, and also reports which changes are breaking.
+func DiffSchemaChanges(oldSchema, newSchema string) []SchemaChange {
+	oldItems := schemaItems(oldSchema)
+	newItems := schemaItems(newSchema)
+
+	keys := make([]string, 0, len(oldItems)+len(newItems))
+	for k := range oldItems {
+		keys = append(keys, k)
+	}
+	for k := range newItems {
+		if _, ok := oldItems[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	var changes []SchemaChange
+	for _, k := range keys {
+		o, inOld := oldItems[k]
+		n, inNew := newItems[k]
+		switch {
+		case !inOld:
+			changes = append(changes, SchemaChange{"+ " + n, isBreakingAddition(newItems, k, n)})
+		case !inNew:
+			changes = append(changes, SchemaChange{"- " + o, true})
+		case o != n:
+			changes = append(changes, SchemaChange{fmt.Sprintf("~ %s (was %s)", n, o), isBreakingChange(newItems, k, o, n)})
+		}
+	}
+
+	return changes
+}
+
+// isBreakingAddition reports whether adding an item breaks clients, which is the case for a required field
+// of an input type.  Clients that don't know about the field can't provide it.
+func isBreakingAddition(items map[string]string, key, item string) bool {
+	typeName, _, isField := strings.Cut(key, ".")
+	if !isField || !strings.HasPrefix(items[typeName], "input ") {
+		return false
+	}
+	f := parseFieldItem(item)
+	return strings.HasSuffix(f.typ, "!") && !f.hasDefault
+}
+
+// isBreakingChange reports whether a change to an item breaks clients.  The type of an output field may become
+// stricter, since clients still receive the values they expect, and the type of an input field or argument may become
+// more lenient, since clients can still provide the values they did.  Arguments may be added if they are optional.
+// Changes to descriptions, defaults and deprecations are not breaking.
+func isBreakingChange(items map[string]string, key, oldItem, newItem string) bool {
+	typeName, _, isField := strings.Cut(key, ".")
+	if !isField {
+		return true
+	}
+
+	kind, _, _ := strings.Cut(items[typeName], " ")
+	if kind == "enum" {
+		return false
+	}
+
+	o := parseFieldItem(oldItem)
+	n := parseFieldItem(newItem)
+	if kind == "input" {
+		if !isStricterOrEqual(n.typ, o.typ) || (strings.HasSuffix(n.typ, "!") && !n.hasDefault && o.hasDefault) {
+			return true
+		}
+	} else if !isStricterOrEqual(o.typ, n.typ) {
+		return true
+	}
+
+	for name, oldArg := range o.args {
+		newArg, ok := n.args[name]
+		if !ok || !isStricterOrEqual(newArg.typ, oldArg.typ) {
+			return true
+		}
+	}
+	for name, newArg := range n.args {
+		if _, ok := o.args[name]; !ok && strings.HasSuffix(newArg.typ, "!") && !newArg.hasDefault {
+			return true
+		}
+	}
+	return false
+}
+
+// isStricterOrEqual reports whether type reference b is the same as type reference a, except that it may be non-null
+// where a is nullable.  For example, "[Int]!" is stricter than "[Int]", but "[Int]" is not stricter than "[Int!]".
+func isStricterOrEqual(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j < len(b) && b[j] == '!':
+			if i < len(a) && a[i] == '!' {
+				i++
+			}
+			j++
+		case i < len(a) && a[i] == '!':
+			return false
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type fieldItem struct {
+	typ        string
+	hasDefault bool
+	args       map[string]fieldItem
+}
+
+// parseFieldItem parses an item of a field, such as `Query.getPerson(name: String!, "the age" age: Int = 0): Person`,
+// or of an argument, such as `name: String!`.  Descriptions and directives are ignored.
+func parseFieldItem(item string) fieldItem {
+	f := fieldItem{args: make(map[string]fieldItem)}
+
+	rest := item
+	if i := strings.IndexAny(item, "(:"); i >= 0 && item[i] == '(' {
+		end := closingParen(item, i)
+		for _, arg := range splitTopLevel(item[i+1 : end]) {
+			arg = skipDescription(strings.TrimSpace(arg))
+			name, def, _ := strings.Cut(arg, ":")
+			f.args[strings.TrimSpace(name)] = parseFieldItem(":" + def)
+		}
+		rest = item[end+1:]
+	}
+
+	_, def, _ := strings.Cut(rest, ":")
+	def = strings.TrimSpace(def)
+	f.typ, def, _ = strings.Cut(def, " ")
+	f.hasDefault = strings.HasPrefix(strings.TrimSpace(def), "=")
+	return f
+}
+
+// closingParen returns the index of the parenthesis that closes the one at the given index,
+// skipping over any in strings.
+func closingParen(s string, open int) int {
+	depth := 0
+	inString := false
+	for i := open; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// splitTopLevel splits a list of arguments at the commas that aren't within strings, lists or objects.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth := 0
+	inString := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// skipDescription removes the inline description that an argument may start with.
+func skipDescription(arg string) string {
+	if !strings.HasPrefix(arg, `"`) {
+		return arg
+	}
+	for i := 1; i < len(arg); i++ {
+		switch arg[i] {
+		case '\\':
+			i++
+		case '"':
+			return strings.TrimSpace(arg[i+1:])
+		}
+	}
+	return arg
+}
+
+// schemaItems returns the fields and single-line definitions of a generated schema, keyed by name.
+// Descriptions of types and fields, and comments, are ignored.
+func schemaItems(schema string) map[string]string {
+	items := make(map[string]string)
+
+	var typeName string
+	inDescription := false
+	for _, line := range strings.Split(schema, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, `"""`):
+			// a single-line description opens and closes on the same line
+			if line == `"""` || !strings.HasSuffix(line, `"""`) {
+				inDescription = !inDescription
+			}
+			continue
+		case inDescription:
+			continue
+		case strings.HasSuffix(line, "{"):
+			decl := strings.Fields(strings.TrimSuffix(line, "{"))
+			if len(decl) > 1 {
+				typeName = decl[1]
+				items[typeName] = strings.Join(decl[:2], " ")
+			}
+		case line == "}":
+			typeName = ""
+		case typeName != "":
+			name := line
+			if i := strings.IndexAny(line, "(: "); i > 0 {
+				name = line[:i]
+			}
+			items[typeName+"."+name] = typeName + "." + line
+		default:
+			items[line] = line
+		}
+	}
+
+	return items
+}
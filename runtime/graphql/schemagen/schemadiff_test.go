@@ -0,0 +1,154 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package schemagen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffSchemas(t *testing.T) {
+	oldSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  """
+  Gets a person by name.
+  """
+  getPerson(name: String!): Person
+  sayHello(name: String!): String!
+}
+
+scalar Void
+
+type Person {
+  name: String!
+  age: Int
+}
+`
+
+	newSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  """
+  Gets a person by their name.
+  """
+  getPerson(name: String!): Person
+  sayHello(name: String!, greeting: String): String!
+  listPeople: [Person!]
+}
+
+scalar Timestamp
+
+type Person {
+  name: String!
+  age: Int!
+}
+
+enum Color {
+  RED
+}
+`
+
+	changes := DiffSchemas(oldSchema, newSchema)
+	assert.Equal(t, []string{
+		"+ enum Color",
+		"+ Color.RED",
+		"~ Person.age: Int! (was Person.age: Int)",
+		"+ Query.listPeople: [Person!]",
+		"~ Query.sayHello(name: String!, greeting: String): String! (was Query.sayHello(name: String!): String!)",
+		"+ scalar Timestamp",
+		"- scalar Void",
+	}, changes)
+
+	assert.Empty(t, DiffSchemas(newSchema, newSchema))
+}
+
+func Test_DiffSchemaChanges_Breaking(t *testing.T) {
+	oldSchema := `
+type Query {
+  getPerson(name: String!, "the age" age: Int): Person
+  listPeople(limit: Int!): [Person]
+  findPeople(query: String): [Person!]!
+  removed: String
+}
+
+input PersonInput {
+  name: String!
+  age: Int
+  nickname: String = ""
+}
+
+type Person {
+  name: String
+  age: Int!
+}
+
+enum Color {
+  RED
+  GREEN
+}
+`
+
+	newSchema := `
+type Query {
+  getPerson(name: String!, "the age, in years" age: Int, "a new, optional argument" verbose: Boolean = false): Person!
+  listPeople(limit: Int, offset: Int!): [Person]
+  findPeople(query: String!): [Person]!
+}
+
+input PersonInput {
+  name: String
+  age: Int!
+  nickname: String!
+  email: String!
+  phone: String = ""
+}
+
+type Person {
+  name: String!
+  age: Int
+}
+
+enum Color {
+  RED @deprecated(reason: "Use GREEN.")
+  BLUE
+}
+`
+
+	assert.Equal(t, []SchemaChange{
+		{"+ Color.BLUE", false},
+		{"- Color.GREEN", true},
+		{`~ Color.RED @deprecated(reason: "Use GREEN.") (was Color.RED)`, false},
+		{"~ Person.age: Int (was Person.age: Int!)", true},
+		{"~ Person.name: String! (was Person.name: String)", false},
+		{"~ PersonInput.age: Int! (was PersonInput.age: Int)", true},
+		{"+ PersonInput.email: String!", true},
+		{"~ PersonInput.name: String (was PersonInput.name: String!)", false},
+		{`~ PersonInput.nickname: String! (was PersonInput.nickname: String = "")`, true},
+		{`+ PersonInput.phone: String = ""`, false},
+		{"~ Query.findPeople(query: String!): [Person]! (was Query.findPeople(query: String): [Person!]!)", true},
+		{`~ Query.getPerson(name: String!, "the age, in years" age: Int, "a new, optional argument" verbose: Boolean = false): Person! (was Query.getPerson(name: String!, "the age" age: Int): Person)`, false},
+		{"~ Query.listPeople(limit: Int, offset: Int!): [Person] (was Query.listPeople(limit: Int!): [Person])", true},
+		{"- Query.removed: String", true},
+	}, DiffSchemaChanges(oldSchema, newSchema))
+}
+
+func Test_IsStricterOrEqual(t *testing.T) {
+	assert.True(t, isStricterOrEqual("Int", "Int"))
+	assert.True(t, isStricterOrEqual("Int", "Int!"))
+	assert.True(t, isStricterOrEqual("[Int]", "[Int!]!"))
+	assert.False(t, isStricterOrEqual("Int!", "Int"))
+	assert.False(t, isStricterOrEqual("[Int!]", "[Int]!"))
+	assert.False(t, isStricterOrEqual("Int", "String"))
+	assert.False(t, isStricterOrEqual("Int", "[Int]"))
+}
@@ -14,6 +14,7 @@ import (
 	"cmp"
 	"context"
 	"fmt"
+	"maps"
 	"slices"
 	"sort"
 	"strings"
@@ -27,36 +28,101 @@ import (
 type GraphQLSchema struct {
 	Schema   string
 	MapTypes []string
+
+	// SubgraphSDL is the schema without the additions of a federation subgraph, which is returned by the
+	// _service field.  It is only set when there are entities.
+	SubgraphSDL string
+
+	// Entities are the functions that resolve entities, by type name.
+	Entities map[string][]*EntityResolver
 }
 
 func GetGraphQLSchema(ctx context.Context, md *metadata.Metadata) (*GraphQLSchema, error) {
 	span, _ := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
 
+	// The metadata may be nil, when the schema only contains connectors from the manifest.
+	ps := newPluginSchema("")
+	if md != nil {
+		var err error
+		if ps, err = transformMetadata(md); err != nil {
+			return nil, err
+		}
+	}
+
+	return buildSchema(ps, true), nil
+}
+
+// pluginSchema holds the functions and types of a plugin, as they are written in the schema.
+type pluginSchema struct {
+	namespace      string
+	functions      []*FunctionSignature
+	inputTypeDefs  map[string]*TypeDefinition
+	resultTypeDefs map[string]*TypeDefinition
+}
+
+func newPluginSchema(namespace string) *pluginSchema {
+	return &pluginSchema{
+		namespace:      namespace,
+		inputTypeDefs:  make(map[string]*TypeDefinition),
+		resultTypeDefs: make(map[string]*TypeDefinition),
+	}
+}
+
+func transformMetadata(md *metadata.Metadata) (*pluginSchema, error) {
 	lang, err := languages.GetLanguageForSDK(md.SDK)
 	if err != nil {
 		return nil, err
 	}
 
+	ps := newPluginSchema(md.Namespace())
 	lti := lang.TypeInfo()
-	inputTypeDefs, errors := transformTypes(md.Types, lti, true)
-	resultTypeDefs, errs := transformTypes(md.Types, lti, false)
+	var errors, errs []*TransformError
+	ps.inputTypeDefs, errors = transformTypes(md.Types, lti, true)
+	ps.resultTypeDefs, errs = transformTypes(md.Types, lti, false)
 	errors = append(errors, errs...)
-	functions, errs := transformFunctions(md.FnExports, inputTypeDefs, resultTypeDefs, lti)
+	ps.functions, errs = transformFunctions(md.FnExports, ps.inputTypeDefs, ps.resultTypeDefs, lti)
 	errors = append(errors, errs...)
 
 	if len(errors) > 0 {
 		return nil, fmt.Errorf("failed to generate schema: %+v", errors)
 	}
 
-	functions = filterFunctions(functions)
+	ps.functions = filterFunctions(ps.functions)
+	return ps, nil
+}
+
+// buildSchema writes the schema of the functions and types, along with the connectors of the manifest if requested.
+func buildSchema(ps *pluginSchema, withConnectors bool) *GraphQLSchema {
+	functions := ps.functions
+	inputTypeDefs := maps.Clone(ps.inputTypeDefs)
+	resultTypeDefs := maps.Clone(ps.resultTypeDefs)
+
+	if withConnectors {
+		functions = addConnectors(functions, resultTypeDefs)
+	}
 	scalarTypes := extractCustomScalarTypes(inputTypeDefs, resultTypeDefs)
 	inputTypes := filterTypes(utils.MapValues(inputTypeDefs), functions, true)
 	resultTypes := filterTypes(utils.MapValues(resultTypeDefs), functions, false)
 
+	entities := make(map[string][]*EntityResolver)
+	for _, f := range functions {
+		if f.EntityResolver != nil {
+			entities[f.EntityType] = append(entities[f.EntityType], f.EntityResolver)
+		}
+	}
+	addKeys(entities, resultTypes)
+
 	buf := bytes.Buffer{}
 	writeSchema(&buf, functions, scalarTypes, inputTypes, resultTypes)
 
+	var sdl string
+	if len(entities) > 0 {
+		sdl = buf.String()
+		buf.Reset()
+		writeFederatedSchema(&buf, functions, scalarTypes, inputTypes, resultTypes, entities)
+	}
+
 	mapTypes := make([]string, 0, len(resultTypeDefs))
 	for _, t := range resultTypeDefs {
 		if t.IsMapType {
@@ -65,9 +131,11 @@ func GetGraphQLSchema(ctx context.Context, md *metadata.Metadata) (*GraphQLSchem
 	}
 
 	return &GraphQLSchema{
-		Schema:   buf.String(),
-		MapTypes: mapTypes,
-	}, nil
+		Schema:      buf.String(),
+		MapTypes:    mapTypes,
+		SubgraphSDL: sdl,
+		Entities:    entities,
+	}
 }
 
 type TransformError struct {
@@ -112,34 +180,57 @@ func transformTypes(types metadata.TypeMap, lti langsupport.LanguageTypeInfo, fo
 		}
 
 		typeDefs[name] = &TypeDefinition{
-			Name:   name,
-			Fields: fields,
+			Name:        name,
+			Fields:      fields,
+			Description: t.Docs,
 		}
 	}
 	return typeDefs, errors
 }
 
+// mutationDirective marks a function that has side effects, which is then a field of the Mutation type
+// instead of the Query type.  Clients don't cache the results of mutations, and run them one at a time.
+const mutationDirective = "mutation"
+
+// streamingDirective marks a function that streams its output, such as model tokens or progress events.
+// Such a function is also a field of the Subscription type, so that clients can receive its output as it is produced.
+const streamingDirective = "streaming"
+
 type FunctionSignature struct {
-	Name       string
-	Parameters []*ParameterSignature
-	ReturnType string
+	Name        string
+	Parameters  []*ParameterSignature
+	ReturnType  string
+	Description string
+	Deprecation *Deprecation
+	Mutation    bool
+	Streaming   bool
+
+	EntityType     string
+	EntityResolver *EntityResolver
 }
 
 type TypeDefinition struct {
-	Name      string
-	Fields    []*NameTypePair
-	IsMapType bool
+	Name        string
+	Fields      []*NameTypePair
+	IsMapType   bool
+	Description string
+	Keys        []string
 }
 
 type NameTypePair struct {
-	Name string
-	Type string
+	Name        string
+	Type        string
+	Default     *any
+	Description string
+	Deprecation *Deprecation
 }
 
 type ParameterSignature struct {
-	Name    string
-	Type    string
-	Default *any
+	Name        string
+	Type        string
+	Default     *any
+	Description string
+	Constraint  string
 }
 
 func transformFunctions(functions metadata.FunctionMap, inputTypeDefs, resultTypeDefs map[string]*TypeDefinition, lti langsupport.LanguageTypeInfo) ([]*FunctionSignature, []*TransformError) {
@@ -164,10 +255,36 @@ func transformFunctions(functions metadata.FunctionMap, inputTypeDefs, resultTyp
 			continue
 		}
 
+		if err := addConstraints(f, params); err != nil {
+			errors = append(errors, &TransformError{f, err})
+			continue
+		}
+
+		if d := f.GetDirective(paginateDirective); d != nil {
+			params, returnType, err = paginate(d, params, returnType, resultTypeDefs)
+			if err != nil {
+				errors = append(errors, &TransformError{f, err})
+				continue
+			}
+		}
+
+		description, deprecation := parseDeprecation(f.Docs)
 		output[i] = &FunctionSignature{
-			Name:       f.Name,
-			Parameters: params,
-			ReturnType: returnType,
+			Name:        f.Name,
+			Parameters:  params,
+			ReturnType:  returnType,
+			Description: description,
+			Deprecation: deprecation,
+			Mutation:    f.GetDirective(mutationDirective) != nil,
+			Streaming:   f.GetDirective(streamingDirective) != nil,
+		}
+
+		if f.GetDirective(entityDirective) != nil {
+			output[i].EntityType, output[i].EntityResolver, err = getEntityResolver(f, params, returnType, resultTypeDefs)
+			if err != nil {
+				errors = append(errors, &TransformError{f, err})
+				continue
+			}
 		}
 
 		i++
@@ -285,33 +402,34 @@ func writeSchema(buf *bytes.Buffer, functions []*FunctionSignature, scalarTypes
 	// write query functions
 	buf.WriteString("type Query {\n")
 	for _, f := range functions {
-		buf.WriteString("  ")
-		buf.WriteString(f.Name)
-		if len(f.Parameters) > 0 {
-			buf.WriteByte('(')
-			for i, p := range f.Parameters {
-				if i > 0 {
-					buf.WriteString(", ")
-				}
-				buf.WriteString(p.Name)
-				buf.WriteString(": ")
-				buf.WriteString(p.Type)
-				if p.Default != nil {
-					val, err := utils.JsonSerialize(*p.Default)
-					if err == nil {
-						buf.WriteString(" = ")
-						buf.Write(val)
-					}
-				}
-			}
-			buf.WriteByte(')')
+		if !f.Mutation {
+			writeFunctionField(buf, f)
 		}
-		buf.WriteString(": ")
-		buf.WriteString(f.ReturnType)
-		buf.WriteByte('\n')
 	}
 	buf.WriteByte('}')
 
+	// write mutation functions
+	if slices.ContainsFunc(functions, func(f *FunctionSignature) bool { return f.Mutation }) {
+		buf.WriteString("\n\ntype Mutation {\n")
+		for _, f := range functions {
+			if f.Mutation {
+				writeFunctionField(buf, f)
+			}
+		}
+		buf.WriteByte('}')
+	}
+
+	// write subscriptions for streaming functions
+	if slices.ContainsFunc(functions, func(f *FunctionSignature) bool { return f.Streaming }) {
+		buf.WriteString("\n\ntype Subscription {\n")
+		for _, f := range functions {
+			if f.Streaming {
+				writeFunctionField(buf, f)
+			}
+		}
+		buf.WriteByte('}')
+	}
+
 	// write scalars
 	for i, scalar := range scalarTypes {
 		if i == 0 {
@@ -323,17 +441,32 @@ func writeSchema(buf *bytes.Buffer, functions []*FunctionSignature, scalarTypes
 		buf.WriteString(scalar)
 	}
 
+	// write the directive of argument constraints
+	if slices.ContainsFunc(functions, hasConstraints) {
+		buf.WriteString("\n\n")
+		buf.WriteString(constraintDirectiveDefinition)
+	}
+
 	// write input types
+	inputTypesByName := make(map[string]*TypeDefinition, len(inputTypeDefs))
+	for _, t := range inputTypeDefs {
+		inputTypesByName[t.Name] = t
+	}
 	for _, t := range inputTypeDefs {
 		buf.WriteString("\n\n")
+		writeDescription(buf, t.Description, "")
 		buf.WriteString("input ")
 		buf.WriteString(t.Name)
 		buf.WriteString(" {\n")
 		for _, f := range t.Fields {
+			writeDescription(buf, f.Description, "  ")
 			buf.WriteString("  ")
 			buf.WriteString(f.Name)
 			buf.WriteString(": ")
 			buf.WriteString(f.Type)
+			if !t.IsMapType {
+				writeInputFieldDefault(buf, f, inputTypesByName)
+			}
 			buf.WriteByte('\n')
 		}
 		buf.WriteByte('}')
@@ -342,14 +475,22 @@ func writeSchema(buf *bytes.Buffer, functions []*FunctionSignature, scalarTypes
 	// write result types
 	for _, t := range resultTypeDefs {
 		buf.WriteString("\n\n")
+		writeDescription(buf, t.Description, "")
 		buf.WriteString("type ")
 		buf.WriteString(t.Name)
+		for _, key := range t.Keys {
+			buf.WriteString(` @key(fields: "`)
+			buf.WriteString(key)
+			buf.WriteString(`")`)
+		}
 		buf.WriteString(" {\n")
 		for _, f := range t.Fields {
+			writeDescription(buf, f.Description, "  ")
 			buf.WriteString("  ")
 			buf.WriteString(f.Name)
 			buf.WriteString(": ")
 			buf.WriteString(f.Type)
+			writeDeprecation(buf, f.Deprecation)
 			buf.WriteByte('\n')
 		}
 		buf.WriteByte('}')
@@ -358,6 +499,137 @@ func writeSchema(buf *bytes.Buffer, functions []*FunctionSignature, scalarTypes
 	buf.WriteByte('\n')
 }
 
+func writeFunctionField(buf *bytes.Buffer, f *FunctionSignature) {
+	writeDescription(buf, f.Description, "  ")
+	buf.WriteString("  ")
+	buf.WriteString(f.Name)
+	if len(f.Parameters) > 0 {
+		buf.WriteByte('(')
+		for i, p := range f.Parameters {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if p.Description != "" {
+				writeInlineDescription(buf, p.Description)
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(p.Name)
+			buf.WriteString(": ")
+			buf.WriteString(p.Type)
+			if p.Default != nil {
+				val, err := utils.JsonSerialize(*p.Default)
+				if err == nil {
+					buf.WriteString(" = ")
+					buf.Write(val)
+				}
+			}
+			if p.Constraint != "" {
+				buf.WriteString(" @constraint(")
+				buf.WriteString(p.Constraint)
+				buf.WriteByte(')')
+			}
+		}
+		buf.WriteByte(')')
+	}
+	buf.WriteString(": ")
+	buf.WriteString(f.ReturnType)
+	writeDeprecation(buf, f.Deprecation)
+	buf.WriteByte('\n')
+}
+
+// writeInputFieldDefault writes the default value of an input field, so that clients can omit the field
+// when passing a partial object.  The default comes from the metadata if present, such as the literal initializer
+// of an AssemblyScript class field, and otherwise is the empty value of the field's type, which matches the value
+// the runtime uses for omitted fields.
+func writeInputFieldDefault(buf *bytes.Buffer, f *NameTypePair, inputTypes map[string]*TypeDefinition) {
+	if val, ok := getInputFieldDefault(f, inputTypes, nil); ok {
+		buf.WriteString(" = ")
+		buf.WriteString(val)
+	}
+}
+
+func getInputFieldDefault(f *NameTypePair, inputTypes map[string]*TypeDefinition, visiting map[string]bool) (string, bool) {
+	if f.Default != nil {
+		v, err := utils.JsonSerialize(*f.Default)
+		if err != nil {
+			return "", false
+		}
+		return string(v), true
+	}
+
+	if lit, ok := emptyValueLiterals[f.Type]; ok {
+		return lit, true
+	}
+
+	if !strings.HasSuffix(f.Type, "!") {
+		// nullable fields are already optional
+		return "", false
+	}
+
+	if strings.HasPrefix(f.Type, "[") {
+		return "[]", true
+	}
+
+	// An input object can be omitted when all of its own fields can be, in which case the runtime fills them.
+	if t, ok := inputTypes[strings.TrimSuffix(f.Type, "!")]; ok && !t.IsMapType && !visiting[t.Name] {
+		if visiting == nil {
+			visiting = make(map[string]bool)
+		}
+		visiting[t.Name] = true
+		defer delete(visiting, t.Name)
+
+		for _, field := range t.Fields {
+			if !strings.HasSuffix(field.Type, "!") {
+				continue
+			}
+			if _, ok := getInputFieldDefault(field, inputTypes, visiting); !ok {
+				return "", false
+			}
+		}
+		return "{}", true
+	}
+
+	// other types are required
+	return "", false
+}
+
+var emptyValueLiterals = map[string]string{
+	"String!":  `""`,
+	"Boolean!": "false",
+	"Int!":     "0",
+	"Float!":   "0",
+	"UInt!":    "0",
+	"BigInt!":  `"0"`,
+	"Bytes!":   `""`,
+}
+
+func writeDescription(buf *bytes.Buffer, description, indent string) {
+	if description == "" {
+		return
+	}
+
+	// Use a block string, so that multi-line descriptions are preserved.
+	buf.WriteString(indent)
+	buf.WriteString("\"\"\"\n")
+	for _, line := range strings.Split(description, "\n") {
+		if line != "" {
+			buf.WriteString(indent)
+			buf.WriteString(strings.ReplaceAll(line, `"""`, `\"""`))
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(indent)
+	buf.WriteString("\"\"\"\n")
+}
+
+func writeInlineDescription(buf *bytes.Buffer, description string) {
+	// Argument descriptions are written inline, so use a regular string and keep it on one line.
+	s, err := utils.JsonSerialize(strings.Join(strings.Fields(description), " "))
+	if err == nil {
+		buf.Write(s)
+	}
+}
+
 func convertParameters(parameters []*metadata.Parameter, lti langsupport.LanguageTypeInfo, typeDefs map[string]*TypeDefinition) ([]*ParameterSignature, error) {
 	if len(parameters) == 0 {
 		return nil, nil
@@ -372,9 +644,10 @@ func convertParameters(parameters []*metadata.Parameter, lti langsupport.Languag
 		}
 
 		output[i] = &ParameterSignature{
-			Name:    p.Name,
-			Type:    t,
-			Default: p.Default,
+			Name:        p.Name,
+			Type:        t,
+			Default:     p.Default,
+			Description: p.Docs,
 		}
 	}
 	return output, nil
@@ -455,8 +728,14 @@ func convertFields(fields []*metadata.Field, lti langsupport.LanguageTypeInfo, t
 			return nil, err
 		}
 		results[i] = &NameTypePair{
-			Name: f.Name,
-			Type: t,
+			Name:        f.Name,
+			Type:        t,
+			Description: f.Docs,
+		}
+		if forInput {
+			results[i].Default = f.Default
+		} else {
+			results[i].Description, results[i].Deprecation = parseDeprecation(f.Docs)
 		}
 	}
 	return results, nil
@@ -494,10 +773,8 @@ func convertType(typ string, lti langsupport.LanguageTypeInfo, typeDefs map[stri
 	}
 
 	if lti.IsByteSequenceType(typ) {
-		// Note: If the bytes represent valid UTF-8 strings, Go will serialize them as actual strings.
-		// Otherwise, the data will be base64 encoded.
-		// TODO: We may want to ensure that the results are _always_ base64 encoded.
-		return "String" + n, nil
+		// Bytes are base64 encoded strings, in both directions.
+		return newScalar("Bytes", typeDefs) + n, nil
 	}
 
 	if lti.IsBooleanType(typ) {
@@ -518,11 +795,8 @@ func convertType(typ string, lti langsupport.LanguageTypeInfo, typeDefs map[stri
 
 		switch size {
 		case 8:
-			if signed {
-				return newScalar("Int64", typeDefs) + n, nil
-			} else {
-				return newScalar("UInt64", typeDefs) + n, nil
-			}
+			// 64-bit integers are encoded as strings, as they can exceed the precision of numbers in JSON clients.
+			return newScalar("BigInt", typeDefs) + n, nil
 		case 4:
 			if !signed {
 				return newScalar("UInt", typeDefs) + n, nil
@@ -533,7 +807,7 @@ func convertType(typ string, lti langsupport.LanguageTypeInfo, typeDefs map[stri
 	}
 
 	if lti.IsTimestampType(typ) {
-		return newScalar("Timestamp", typeDefs) + n, nil
+		return newScalar("DateTime", typeDefs) + n, nil
 	}
 
 	// check for array types
@@ -601,7 +875,7 @@ func convertType(typ string, lti langsupport.LanguageTypeInfo, typeDefs map[stri
 			typeName += "Input"
 		}
 
-		newMapType(typeName, []*NameTypePair{{"key", kt}, {"value", vt}}, typeDefs)
+		newMapType(typeName, []*NameTypePair{{Name: "key", Type: kt}, {Name: "value", Type: vt}}, typeDefs)
 
 		// The map is represented as a list of the pair type.
 		// The list might be nullable, but the pair type within the list is always non-nullable.
@@ -161,7 +161,7 @@ func Test_GetGraphQLSchema_AssemblyScript(t *testing.T) {
 		WithField("location", "assembly/test/Coordinates")
 
 	md.Types.AddType("assembly/test/Coordinates").
-		WithField("lat", "f64").
+		WithField("lat", "f64", 51.5).
 		WithField("lon", "f64")
 
 	// This should be excluded from the final schema
@@ -179,7 +179,7 @@ func Test_GetGraphQLSchema_AssemblyScript(t *testing.T) {
 type Query {
   add(a: Int!, b: Int!): Int!
   addPerson(person: PersonInput!): Void
-  currentTime: Timestamp!
+  currentTime: DateTime!
   doNothing: Void
   getPeople: [Person!]!
   getPerson: Person!
@@ -195,44 +195,44 @@ type Query {
   transform(items: [StringStringPairInput!]!): [StringStringPair!]!
 }
 
-scalar Timestamp
+scalar DateTime
 scalar Void
 
 input AddressInput {
-  street: String!
-  city: String!
-  state: String!
-  country: String!
-  postalCode: String!
-  location: CoordinatesInput!
+  street: String! = ""
+  city: String! = ""
+  state: String! = ""
+  country: String! = ""
+  postalCode: String! = ""
+  location: CoordinatesInput! = {}
 }
 
 input CoordinatesInput {
-  lat: Float!
-  lon: Float!
+  lat: Float! = 51.5
+  lon: Float! = 0
 }
 
 input Obj1Input {
-  id: Int!
-  name: String!
+  id: Int! = 0
+  name: String! = ""
 }
 
 input Obj2Input {
-  name: String!
+  name: String! = ""
 }
 
 input Obj3Input {
-  name: String!
+  name: String! = ""
 }
 
 input Obj4Input {
-  name: String!
+  name: String! = ""
 }
 
 input PersonInput {
-  name: String!
-  age: Int!
-  addresses: [AddressInput!]!
+  name: String! = ""
+  age: Int! = 0
+  addresses: [AddressInput!]! = []
 }
 
 input StringStringPairInput {
@@ -336,14 +336,16 @@ func Test_ConvertType_AssemblyScript(t *testing.T) {
 		{"~lib/array/Array<~lib/string/String|null>", true, "[String]!", nil, nil},
 
 		// Custom scalar types
-		{"~lib/date/Date", false, "Timestamp!", nil, []*TypeDefinition{{Name: "Timestamp"}}},
-		{"~lib/date/Date", true, "Timestamp!", nil, []*TypeDefinition{{Name: "Timestamp"}}},
-		{"i64", false, "Int64!", nil, []*TypeDefinition{{Name: "Int64"}}},
-		{"i64", true, "Int64!", nil, []*TypeDefinition{{Name: "Int64"}}},
+		{"~lib/date/Date", false, "DateTime!", nil, []*TypeDefinition{{Name: "DateTime"}}},
+		{"~lib/date/Date", true, "DateTime!", nil, []*TypeDefinition{{Name: "DateTime"}}},
+		{"i64", false, "BigInt!", nil, []*TypeDefinition{{Name: "BigInt"}}},
+		{"i64", true, "BigInt!", nil, []*TypeDefinition{{Name: "BigInt"}}},
 		{"u32", false, "UInt!", nil, []*TypeDefinition{{Name: "UInt"}}},
 		{"u32", true, "UInt!", nil, []*TypeDefinition{{Name: "UInt"}}},
-		{"u64", false, "UInt64!", nil, []*TypeDefinition{{Name: "UInt64"}}},
-		{"u64", true, "UInt64!", nil, []*TypeDefinition{{Name: "UInt64"}}},
+		{"u64", false, "BigInt!", nil, []*TypeDefinition{{Name: "BigInt"}}},
+		{"u64", true, "BigInt!", nil, []*TypeDefinition{{Name: "BigInt"}}},
+		{"~lib/arraybuffer/ArrayBuffer", false, "Bytes!", nil, []*TypeDefinition{{Name: "Bytes"}}},
+		{"~lib/arraybuffer/ArrayBuffer", true, "Bytes!", nil, []*TypeDefinition{{Name: "Bytes"}}},
 
 		// Custom types
 		{"assembly/test/User", false, "User!",
@@ -358,9 +360,9 @@ func Test_ConvertType_AssemblyScript(t *testing.T) {
 			[]*TypeDefinition{{
 				Name: "User",
 				Fields: []*NameTypePair{
-					{"firstName", "String!"},
-					{"lastName", "String!"},
-					{"age", "Int!"},
+					{Name: "firstName", Type: "String!"},
+					{Name: "lastName", Type: "String!"},
+					{Name: "age", Type: "Int!"},
 				},
 			}}},
 		{"assembly/test/User", true, "UserInput!",
@@ -375,9 +377,9 @@ func Test_ConvertType_AssemblyScript(t *testing.T) {
 			[]*TypeDefinition{{
 				Name: "UserInput",
 				Fields: []*NameTypePair{
-					{"firstName", "String!"},
-					{"lastName", "String!"},
-					{"age", "Int!"},
+					{Name: "firstName", Type: "String!"},
+					{Name: "lastName", Type: "String!"},
+					{Name: "age", Type: "Int!"},
 				},
 			}}},
 
@@ -396,48 +398,48 @@ func Test_ConvertType_AssemblyScript(t *testing.T) {
 		{"~lib/map/Map<~lib/string/String,~lib/string/String>", false, "[StringStringPair!]!", nil, []*TypeDefinition{{
 			Name: "StringStringPair",
 			Fields: []*NameTypePair{
-				{"key", "String!"},
-				{"value", "String!"},
+				{Name: "key", Type: "String!"},
+				{Name: "value", Type: "String!"},
 			},
 			IsMapType: true,
 		}}},
 		{"~lib/map/Map<~lib/string/String,~lib/string/String>", true, "[StringStringPairInput!]!", nil, []*TypeDefinition{{
 			Name: "StringStringPairInput",
 			Fields: []*NameTypePair{
-				{"key", "String!"},
-				{"value", "String!"},
+				{Name: "key", Type: "String!"},
+				{Name: "value", Type: "String!"},
 			},
 			IsMapType: true,
 		}}},
 		{"~lib/map/Map<~lib/string/String,~lib/string/String|null>", false, "[StringNullableStringPair!]!", nil, []*TypeDefinition{{
 			Name: "StringNullableStringPair",
 			Fields: []*NameTypePair{
-				{"key", "String!"},
-				{"value", "String"},
+				{Name: "key", Type: "String!"},
+				{Name: "value", Type: "String"},
 			},
 			IsMapType: true,
 		}}},
 		{"~lib/map/Map<~lib/string/String,~lib/string/String|null>", true, "[StringNullableStringPairInput!]!", nil, []*TypeDefinition{{
 			Name: "StringNullableStringPairInput",
 			Fields: []*NameTypePair{
-				{"key", "String!"},
-				{"value", "String"},
+				{Name: "key", Type: "String!"},
+				{Name: "value", Type: "String"},
 			},
 			IsMapType: true,
 		}}},
 		{"~lib/map/Map<i32,~lib/string/String>", false, "[IntStringPair!]!", nil, []*TypeDefinition{{
 			Name: "IntStringPair",
 			Fields: []*NameTypePair{
-				{"key", "Int!"},
-				{"value", "String!"},
+				{Name: "key", Type: "Int!"},
+				{Name: "value", Type: "String!"},
 			},
 			IsMapType: true,
 		}}},
 		{"~lib/map/Map<i32,~lib/string/String>", true, "[IntStringPairInput!]!", nil, []*TypeDefinition{{
 			Name: "IntStringPairInput",
 			Fields: []*NameTypePair{
-				{"key", "Int!"},
-				{"value", "String!"},
+				{Name: "key", Type: "Int!"},
+				{Name: "value", Type: "String!"},
 			},
 			IsMapType: true,
 		}}},
@@ -445,16 +447,16 @@ func Test_ConvertType_AssemblyScript(t *testing.T) {
 			{
 				Name: "StringStringFloatPairListPair",
 				Fields: []*NameTypePair{
-					{"key", "String!"},
-					{"value", "[StringFloatPair!]!"},
+					{Name: "key", Type: "String!"},
+					{Name: "value", Type: "[StringFloatPair!]!"},
 				},
 				IsMapType: true,
 			},
 			{
 				Name: "StringFloatPair",
 				Fields: []*NameTypePair{
-					{"key", "String!"},
-					{"value", "Float!"},
+					{Name: "key", Type: "String!"},
+					{Name: "value", Type: "Float!"},
 				},
 				IsMapType: true,
 			},
@@ -463,16 +465,16 @@ func Test_ConvertType_AssemblyScript(t *testing.T) {
 			{
 				Name: "StringStringFloatPairListPairInput",
 				Fields: []*NameTypePair{
-					{"key", "String!"},
-					{"value", "[StringFloatPairInput!]!"},
+					{Name: "key", Type: "String!"},
+					{Name: "value", Type: "[StringFloatPairInput!]!"},
 				},
 				IsMapType: true,
 			},
 			{
 				Name: "StringFloatPairInput",
 				Fields: []*NameTypePair{
-					{"key", "String!"},
-					{"value", "Float!"},
+					{Name: "key", Type: "String!"},
+					{Name: "value", Type: "Float!"},
 				},
 				IsMapType: true,
 			},
@@ -21,6 +21,7 @@ import (
 	"github.com/hypermodeinc/modus/runtime/utils"
 
 	"github.com/stretchr/testify/require"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
 )
 
 func Test_GetGraphQLSchema_Go(t *testing.T) {
@@ -207,7 +208,7 @@ func Test_GetGraphQLSchema_Go(t *testing.T) {
 type Query {
   add(a: Int!, b: Int!): Int!
   addPerson(person: PersonInput!): Void
-  currentTime: Timestamp!
+  currentTime: DateTime!
   doNothing: Void
   getPeople: [Person!]
   getPerson: Person!
@@ -226,43 +227,43 @@ type Query {
   transform(items: [StringStringPairInput!]): [StringStringPair!]
 }
 
-scalar Timestamp
+scalar DateTime
 scalar Void
 
 input AddressInput {
-  street: String!
-  city: String!
-  state: String!
-  country: String!
-  postalCode: String!
-  location: CoordinatesInput!
+  street: String! = ""
+  city: String! = ""
+  state: String! = ""
+  country: String! = ""
+  postalCode: String! = ""
+  location: CoordinatesInput! = {}
 }
 
 input CoordinatesInput {
-  lat: Float!
-  lon: Float!
+  lat: Float! = 0
+  lon: Float! = 0
 }
 
 input Obj1Input {
-  id: Int!
-  name: String!
+  id: Int! = 0
+  name: String! = ""
 }
 
 input Obj2Input {
-  name: String!
+  name: String! = ""
 }
 
 input Obj3Input {
-  name: String!
+  name: String! = ""
 }
 
 input Obj4Input {
-  name: String!
+  name: String! = ""
 }
 
 input PersonInput {
-  name: String!
-  age: Int!
+  name: String! = ""
+  age: Int! = 0
   addresses: [AddressInput!]
 }
 
@@ -333,6 +334,10 @@ type StringStringPair {
 
 	require.Nil(t, err)
 	require.Equal(t, expectedSchema, result.Schema)
+
+	// the defaults of input fields, including those of nested objects, must be valid
+	_, err = gql.NewSchemaFromString(result.Schema)
+	require.Nil(t, err)
 }
 
 func Test_ConvertType_Go(t *testing.T) {
@@ -375,14 +380,16 @@ func Test_ConvertType_Go(t *testing.T) {
 		{"[]*string", true, "[String]", nil, nil},
 
 		// Custom scalar types
-		{"time.Time", false, "Timestamp!", nil, []*TypeDefinition{{Name: "Timestamp"}}},
-		{"time.Time", true, "Timestamp!", nil, []*TypeDefinition{{Name: "Timestamp"}}},
-		{"int64", false, "Int64!", nil, []*TypeDefinition{{Name: "Int64"}}},
-		{"int64", true, "Int64!", nil, []*TypeDefinition{{Name: "Int64"}}},
+		{"time.Time", false, "DateTime!", nil, []*TypeDefinition{{Name: "DateTime"}}},
+		{"time.Time", true, "DateTime!", nil, []*TypeDefinition{{Name: "DateTime"}}},
+		{"int64", false, "BigInt!", nil, []*TypeDefinition{{Name: "BigInt"}}},
+		{"int64", true, "BigInt!", nil, []*TypeDefinition{{Name: "BigInt"}}},
 		{"uint32", false, "UInt!", nil, []*TypeDefinition{{Name: "UInt"}}},
 		{"uint32", true, "UInt!", nil, []*TypeDefinition{{Name: "UInt"}}},
-		{"uint64", false, "UInt64!", nil, []*TypeDefinition{{Name: "UInt64"}}},
-		{"uint64", true, "UInt64!", nil, []*TypeDefinition{{Name: "UInt64"}}},
+		{"uint64", false, "BigInt!", nil, []*TypeDefinition{{Name: "BigInt"}}},
+		{"uint64", true, "BigInt!", nil, []*TypeDefinition{{Name: "BigInt"}}},
+		{"[]byte", false, "Bytes", nil, []*TypeDefinition{{Name: "Bytes"}}},
+		{"[]byte", true, "Bytes", nil, []*TypeDefinition{{Name: "Bytes"}}},
 
 		// Custom types
 		{"testdata.User", false, "User!",
@@ -397,9 +404,9 @@ func Test_ConvertType_Go(t *testing.T) {
 			[]*TypeDefinition{{
 				Name: "User",
 				Fields: []*NameTypePair{
-					{"firstName", "String!"},
-					{"lastName", "String!"},
-					{"age", "Int!"},
+					{Name: "firstName", Type: "String!"},
+					{Name: "lastName", Type: "String!"},
+					{Name: "age", Type: "Int!"},
 				},
 			}}},
 		{"testdata.User", true, "UserInput!",
@@ -414,9 +421,9 @@ func Test_ConvertType_Go(t *testing.T) {
 			[]*TypeDefinition{{
 				Name: "UserInput",
 				Fields: []*NameTypePair{
-					{"firstName", "String!"},
-					{"lastName", "String!"},
-					{"age", "Int!"},
+					{Name: "firstName", Type: "String!"},
+					{Name: "lastName", Type: "String!"},
+					{Name: "age", Type: "Int!"},
 				},
 			}}},
 
@@ -439,48 +446,48 @@ func Test_ConvertType_Go(t *testing.T) {
 		{"map[string]string", false, "[StringStringPair!]", nil, []*TypeDefinition{{
 			Name: "StringStringPair",
 			Fields: []*NameTypePair{
-				{"key", "String!"},
-				{"value", "String!"},
+				{Name: "key", Type: "String!"},
+				{Name: "value", Type: "String!"},
 			},
 			IsMapType: true,
 		}}},
 		{"map[string]string", true, "[StringStringPairInput!]", nil, []*TypeDefinition{{
 			Name: "StringStringPairInput",
 			Fields: []*NameTypePair{
-				{"key", "String!"},
-				{"value", "String!"},
+				{Name: "key", Type: "String!"},
+				{Name: "value", Type: "String!"},
 			},
 			IsMapType: true,
 		}}},
 		{"map[string]*string", false, "[StringNullableStringPair!]", nil, []*TypeDefinition{{
 			Name: "StringNullableStringPair",
 			Fields: []*NameTypePair{
-				{"key", "String!"},
-				{"value", "String"},
+				{Name: "key", Type: "String!"},
+				{Name: "value", Type: "String"},
 			},
 			IsMapType: true,
 		}}},
 		{"map[string]*string", true, "[StringNullableStringPairInput!]", nil, []*TypeDefinition{{
 			Name: "StringNullableStringPairInput",
 			Fields: []*NameTypePair{
-				{"key", "String!"},
-				{"value", "String"},
+				{Name: "key", Type: "String!"},
+				{Name: "value", Type: "String"},
 			},
 			IsMapType: true,
 		}}},
 		{"map[int32]string", false, "[IntStringPair!]", nil, []*TypeDefinition{{
 			Name: "IntStringPair",
 			Fields: []*NameTypePair{
-				{"key", "Int!"},
-				{"value", "String!"},
+				{Name: "key", Type: "Int!"},
+				{Name: "value", Type: "String!"},
 			},
 			IsMapType: true,
 		}}},
 		{"map[int32]string", true, "[IntStringPairInput!]", nil, []*TypeDefinition{{
 			Name: "IntStringPairInput",
 			Fields: []*NameTypePair{
-				{"key", "Int!"},
-				{"value", "String!"},
+				{Name: "key", Type: "Int!"},
+				{Name: "value", Type: "String!"},
 			},
 			IsMapType: true,
 		}}},
@@ -488,16 +495,16 @@ func Test_ConvertType_Go(t *testing.T) {
 			{
 				Name: "StringNullableStringFloatPairListPair",
 				Fields: []*NameTypePair{
-					{"key", "String!"},
-					{"value", "[StringFloatPair!]"},
+					{Name: "key", Type: "String!"},
+					{Name: "value", Type: "[StringFloatPair!]"},
 				},
 				IsMapType: true,
 			},
 			{
 				Name: "StringFloatPair",
 				Fields: []*NameTypePair{
-					{"key", "String!"},
-					{"value", "Float!"},
+					{Name: "key", Type: "String!"},
+					{Name: "value", Type: "Float!"},
 				},
 				IsMapType: true,
 			},
@@ -506,16 +513,16 @@ func Test_ConvertType_Go(t *testing.T) {
 			{
 				Name: "StringNullableStringFloatPairListPairInput",
 				Fields: []*NameTypePair{
-					{"key", "String!"},
-					{"value", "[StringFloatPairInput!]"},
+					{Name: "key", Type: "String!"},
+					{Name: "value", Type: "[StringFloatPairInput!]"},
 				},
 				IsMapType: true,
 			},
 			{
 				Name: "StringFloatPairInput",
 				Fields: []*NameTypePair{
-					{"key", "String!"},
-					{"value", "Float!"},
+					{Name: "key", Type: "String!"},
+					{Name: "value", Type: "Float!"},
 				},
 				IsMapType: true,
 			},
@@ -550,3 +557,461 @@ func Test_ConvertType_Go(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetGraphQLSchema_Go_Descriptions(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+
+	md.FnExports.AddFunction("getPerson").
+		WithParameter("name", "string").
+		WithResult("*testdata.Person").
+		WithDocs("Gets a person by name.\nReturns nil if not found.")
+
+	md.FnExports["getPerson"].Parameters[0].Docs = "The name of the person."
+
+	md.Types.AddType("*testdata.Person").
+		WithId(3)
+	md.Types.AddType("testdata.Person").
+		WithId(4).
+		WithField("name", "string").
+		WithField("age", "int32").
+		WithDocs("A person.").
+		WithFieldDocs("age", "The person's age, in years.")
+
+	result, err := GetGraphQLSchema(context.Background(), md)
+
+	expectedSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  """
+  Gets a person by name.
+  Returns nil if not found.
+  """
+  getPerson("The name of the person." name: String!): Person
+}
+
+"""
+A person.
+"""
+type Person {
+  name: String!
+  """
+  The person's age, in years.
+  """
+  age: Int!
+}
+`[1:]
+
+	require.Nil(t, err)
+	require.Equal(t, expectedSchema, result.Schema)
+}
+
+func Test_GetGraphQLSchema_Go_Deprecations(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+
+	md.FnExports.AddFunction("getPerson").
+		WithParameter("name", "string").
+		WithResult("*testdata.Person").
+		WithDocs("Gets a person by name.\n\nDeprecated: Use findPerson instead,\nwhich also matches nicknames.")
+	md.FnExports.AddFunction("findPerson").
+		WithParameter("name", "string").
+		WithResult("*testdata.Person").
+		WithDocs("Finds a person by name or nickname.")
+
+	md.Types.AddType("*testdata.Person").
+		WithId(3)
+	md.Types.AddType("testdata.Person").
+		WithId(4).
+		WithField("name", "string").
+		WithField("age", "int32").
+		WithFieldDocs("age", "@deprecated")
+
+	result, err := GetGraphQLSchema(context.Background(), md)
+
+	expectedSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  """
+  Finds a person by name or nickname.
+  """
+  findPerson(name: String!): Person
+  """
+  Gets a person by name.
+  """
+  getPerson(name: String!): Person @deprecated(reason: "Use findPerson instead, which also matches nicknames.")
+}
+
+type Person {
+  name: String!
+  age: Int! @deprecated
+}
+`[1:]
+
+	require.Nil(t, err)
+	require.Equal(t, expectedSchema, result.Schema)
+
+	_, err = gql.NewSchemaFromString(result.Schema)
+	require.Nil(t, err)
+}
+
+func Test_GetGraphQLSchema_Go_Streaming(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+
+	md.FnExports.AddFunction("sayHello").
+		WithParameter("name", "string").
+		WithResult("string")
+
+	md.FnExports.AddFunction("tellStory").
+		WithParameter("topic", "string").
+		WithResult("string").
+		WithDocs("Tells a story, one token at a time.")
+
+	md.FnExports["tellStory"].Directives = []*metadata.Directive{{Name: "streaming"}}
+
+	result, err := GetGraphQLSchema(context.Background(), md)
+
+	expectedSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  sayHello(name: String!): String!
+  """
+  Tells a story, one token at a time.
+  """
+  tellStory(topic: String!): String!
+}
+
+type Subscription {
+  """
+  Tells a story, one token at a time.
+  """
+  tellStory(topic: String!): String!
+}
+`[1:]
+
+	require.Nil(t, err)
+	require.Equal(t, expectedSchema, result.Schema)
+}
+
+func Test_GetGraphQLSchema_Go_Mutations(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+
+	md.FnExports.AddFunction("getCount").
+		WithResult("int32")
+
+	md.FnExports.AddFunction("increment").
+		WithParameter("by", "int32").
+		WithResult("int32")
+
+	md.FnExports["increment"].Directives = []*metadata.Directive{{Name: "mutation"}}
+
+	result, err := GetGraphQLSchema(context.Background(), md)
+
+	expectedSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  getCount: Int!
+}
+
+type Mutation {
+  increment(by: Int!): Int!
+}
+`[1:]
+
+	require.Nil(t, err)
+	require.Equal(t, expectedSchema, result.Schema)
+}
+
+func Test_GetGraphQLSchema_Go_Pagination(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+
+	md.FnExports.AddFunction("listTags").
+		WithResult("[]string")
+
+	md.FnExports.AddFunction("searchUsers").
+		WithParameter("query", "string").
+		WithParameter("limit", "int").
+		WithParameter("offset", "int").
+		WithResult("[]*testdata.User")
+
+	md.FnExports["listTags"].Directives = []*metadata.Directive{{Name: "paginate"}}
+	md.FnExports["searchUsers"].Directives = []*metadata.Directive{{
+		Name: "paginate",
+		Args: map[string]string{"limit": "limit", "offset": "offset"},
+	}}
+
+	md.Types.AddType("[]string")
+	md.Types.AddType("[]*testdata.User")
+	md.Types.AddType("*testdata.User")
+	md.Types.AddType("testdata.User").
+		WithField("name", "string")
+
+	result, err := GetGraphQLSchema(context.Background(), md)
+
+	expectedSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  listTags("The number of items to return." first: Int, "The cursor of the item to return items after." after: String): StringConnection!
+  searchUsers(query: String!, "The number of items to return." first: Int, "The cursor of the item to return items after." after: String): NullableUserConnection!
+}
+
+type NullableUserConnection {
+  edges: [NullableUserEdge!]!
+  pageInfo: PageInfo!
+  """
+  The total number of items, when it is known.
+  """
+  totalCount: Int
+}
+
+type NullableUserEdge {
+  node: User
+  cursor: String!
+}
+
+type PageInfo {
+  hasNextPage: Boolean!
+  hasPreviousPage: Boolean!
+  startCursor: String
+  endCursor: String
+}
+
+type StringConnection {
+  edges: [StringEdge!]!
+  pageInfo: PageInfo!
+  """
+  The total number of items, when it is known.
+  """
+  totalCount: Int
+}
+
+type StringEdge {
+  node: String!
+  cursor: String!
+}
+
+type User {
+  name: String!
+}
+`[1:]
+
+	require.Nil(t, err)
+	require.Equal(t, expectedSchema, result.Schema)
+}
+
+func Test_GetGraphQLSchema_Go_PaginationRequiresList(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+
+	md.FnExports.AddFunction("getCount").
+		WithResult("int32")
+	md.FnExports["getCount"].Directives = []*metadata.Directive{{Name: "paginate"}}
+
+	_, err := GetGraphQLSchema(context.Background(), md)
+	require.ErrorContains(t, err, "only functions that return a list can be paginated")
+}
+
+func Test_GetGraphQLSchema_Go_Constraints(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+
+	md.FnExports.AddFunction("addUser").
+		WithParameter("name", "string").
+		WithParameter("age", "int32").
+		WithResult("string").
+		WithDirective("validate", map[string]string{"param": "name", "required": "", "maxLength": "100", "pattern": "^[A-Z]"}).
+		WithDirective("validate", map[string]string{"param": "age", "min": "0", "max": "150"})
+
+	md.FnExports["addUser"].Parameters[1].Docs = "The age of the user."
+
+	result, err := GetGraphQLSchema(context.Background(), md)
+
+	expectedSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  addUser("Required. Length must be at most 100. Must match /^[A-Z]/." name: String! @constraint(maxLength: 100, pattern: "^[A-Z]", required: true), "The age of the user. Must be between 0 and 150." age: Int! @constraint(min: 0, max: 150)): String!
+}
+
+directive @constraint(min: Float, max: Float, minLength: Int, maxLength: Int, pattern: String, required: Boolean) on ARGUMENT_DEFINITION
+`[1:]
+
+	require.Nil(t, err)
+	require.Equal(t, expectedSchema, result.Schema)
+
+	_, err = gql.NewSchemaFromString(result.Schema)
+	require.Nil(t, err)
+}
+
+func Test_GetGraphQLSchema_Go_Federation(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+
+	md.FnExports.AddFunction("getUser").
+		WithParameter("id", "string").
+		WithResult("*testdata.User").
+		WithDirective("entity", nil)
+
+	md.FnExports.AddFunction("getUserByEmail").
+		WithParameter("address", "string").
+		WithResult("*testdata.User").
+		WithDirective("entity", map[string]string{"key": "email"})
+
+	md.Types.AddType("*testdata.User")
+	md.Types.AddType("testdata.User").
+		WithField("id", "string").
+		WithField("email", "string").
+		WithField("name", "string")
+
+	result, err := GetGraphQLSchema(context.Background(), md)
+
+	expectedSDL := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  getUser(id: String!): User
+  getUserByEmail(address: String!): User
+}
+
+type User @key(fields: "id") @key(fields: "email") {
+  id: String!
+  email: String!
+  name: String!
+}
+`[1:]
+
+	expectedSchema := `
+# Modus GraphQL Schema (auto-generated)
+
+type Query {
+  _entities(representations: [_Any!]!): [_Entity]!
+  _service: _Service!
+  getUser(id: String!): User
+  getUserByEmail(address: String!): User
+}
+
+scalar _Any
+scalar _FieldSet
+
+type _Service {
+  sdl: String
+}
+
+type User @key(fields: "id") @key(fields: "email") {
+  id: String!
+  email: String!
+  name: String!
+}
+
+union _Entity = User
+
+directive @key(fields: _FieldSet!) repeatable on OBJECT | INTERFACE
+`[1:]
+
+	require.Nil(t, err)
+	require.Equal(t, expectedSDL, result.SubgraphSDL)
+	require.Equal(t, expectedSchema, result.Schema)
+	require.Equal(t, map[string][]*EntityResolver{
+		"User": {
+			{Function: "getUser", KeyFields: []string{"id"}, Parameters: []string{"id"}},
+			{Function: "getUserByEmail", KeyFields: []string{"email"}, Parameters: []string{"address"}},
+		},
+	}, result.Entities)
+
+	_, err = gql.NewSchemaFromString(result.Schema)
+	require.Nil(t, err)
+}
+
+func Test_GetGraphQLSchema_Go_FederationInvalidKey(t *testing.T) {
+
+	manifestdata.SetManifest(&manifest.Manifest{})
+
+	md := metadata.NewPluginMetadata()
+	md.SDK = "modus-sdk-go"
+
+	md.FnExports.AddFunction("getUser").
+		WithParameter("userId", "string").
+		WithResult("*testdata.User").
+		WithDirective("entity", nil)
+
+	md.Types.AddType("*testdata.User")
+	md.Types.AddType("testdata.User").
+		WithField("id", "string")
+
+	_, err := GetGraphQLSchema(context.Background(), md)
+	require.ErrorContains(t, err, "key field userId is not a field of type User")
+}
+
+func Test_GetInputFieldDefault(t *testing.T) {
+	var scale any = 2.5
+	inputTypes := map[string]*TypeDefinition{
+		"CoordinatesInput": {Name: "CoordinatesInput", Fields: []*NameTypePair{
+			{Name: "lat", Type: "Float!"},
+			{Name: "label", Type: "String"},
+		}},
+		"RouteInput": {Name: "RouteInput", Fields: []*NameTypePair{
+			{Name: "origin", Type: "CoordinatesInput!"},
+			{Name: "stops", Type: "[CoordinatesInput!]!"},
+		}},
+		"KeyedInput": {Name: "KeyedInput", Fields: []*NameTypePair{
+			{Name: "key", Type: "ID!"},
+		}},
+		"NodeInput": {Name: "NodeInput", Fields: []*NameTypePair{
+			{Name: "next", Type: "NodeInput!"},
+		}},
+	}
+
+	tests := []struct {
+		field    *NameTypePair
+		expected string
+		ok       bool
+	}{
+		{&NameTypePair{Type: "Int!"}, "0", true},
+		{&NameTypePair{Type: "Int"}, "", false},
+		{&NameTypePair{Type: "[Int!]!"}, "[]", true},
+		{&NameTypePair{Type: "Float!", Default: &scale}, "2.5", true},
+		{&NameTypePair{Type: "CoordinatesInput!"}, "{}", true},
+		{&NameTypePair{Type: "CoordinatesInput"}, "", false},
+		{&NameTypePair{Type: "RouteInput!"}, "{}", true},
+		{&NameTypePair{Type: "KeyedInput!"}, "", false},
+		{&NameTypePair{Type: "NodeInput!"}, "", false},
+	}
+
+	for _, tt := range tests {
+		val, ok := getInputFieldDefault(tt.field, inputTypes, nil)
+		require.Equal(t, tt.ok, ok, tt.field.Type)
+		require.Equal(t, tt.expected, val, tt.field.Type)
+	}
+}
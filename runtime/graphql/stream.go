@@ -0,0 +1,180 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/runtime/graphql/datasource"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	eng "github.com/wundergraph/graphql-go-tools/execution/engine"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+)
+
+// wantsEventStream reports whether the client has asked for the response as a stream of server-sent events.
+func wantsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// sseResponseWriter delivers a GraphQL response as server-sent events, following the
+// "distinct connections" mode of the GraphQL over SSE protocol.  Each result is sent as a "next" event,
+// and the response ends with a "complete" event.  A query has a single result, while a subscription to a
+// streaming function has a result for each chunk of output that the function streams, such as model tokens.
+//
+// Errors that occur before anything has been sent are passed through as regular HTTP responses.
+type sseResponseWriter struct {
+	http.ResponseWriter
+	mu          sync.Mutex
+	started     bool
+	passthrough bool
+}
+
+func newSseResponseWriter(w http.ResponseWriter) *sseResponseWriter {
+	return &sseResponseWriter{ResponseWriter: w}
+}
+
+func (w *sseResponseWriter) WriteHeader(statusCode int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.started && statusCode != http.StatusOK {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+func (w *sseResponseWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+
+	data := b
+	if !json.Valid(b) {
+		msg := strings.TrimSpace(string(b))
+		data, _ = utils.JsonSerialize(map[string]any{"errors": []map[string]string{{"message": msg}}})
+	}
+
+	w.writeEvent("next", data)
+	return len(b), nil
+}
+
+func (w *sseResponseWriter) complete() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.passthrough {
+		w.writeEvent("complete", nil)
+	}
+}
+
+func (w *sseResponseWriter) writeEvent(event string, data []byte) {
+	if !w.started {
+		h := w.ResponseWriter.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		w.ResponseWriter.WriteHeader(http.StatusOK)
+		w.started = true
+	}
+
+	writeServerSentEvent(w.ResponseWriter, event, data)
+}
+
+// executeSubscription runs a subscription, and sends each of its results to the client as a "next" event.
+func executeSubscription(ctx context.Context, w *sseResponseWriter, engine *eng.ExecutionEngine, req *gql.Request, options []eng.ExecutionOptions) {
+	ctx, flushed := datasource.WithSubscriptionFlushes(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sw := &sseSubscriptionWriter{sse: w, flushed: flushed, complete: cancel}
+	err := engine.Execute(ctx, req, sw, options...)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	if report, ok := err.(operationreport.Report); ok && len(report.InternalErrors) > 0 {
+		// Log internal errors, but don't return them to the client
+		msg := "Failed to execute GraphQL operation."
+		logger.Err(ctx, err).Msg(msg)
+		http.Error(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJsonContentHeader(w)
+	_, _ = graphqlerrors.RequestErrorsFromError(err).WriteResponse(w)
+}
+
+// sseSubscriptionWriter collects each update of a subscription, and sends it when the engine flushes it.
+// The engine completes the writer when the subscription's function returns, which ends the execution.
+type sseSubscriptionWriter struct {
+	sse      *sseResponseWriter
+	flushed  func()
+	complete func()
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *sseSubscriptionWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *sseSubscriptionWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer w.flushed()
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := w.sse.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *sseSubscriptionWriter) Complete() {
+	w.complete()
+}
+
+// writeServerSentEvent writes an event, and flushes it to the client.  Each line of the data is written
+// as a separate data field, which the client joins back together.  Events without a name are "message" events.
+func writeServerSentEvent(w http.ResponseWriter, event string, data []byte) {
+	var buf bytes.Buffer
+	if event != "" {
+		buf.WriteString("event: ")
+		buf.WriteString(event)
+		buf.WriteByte('\n')
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	_, _ = w.Write(buf.Bytes())
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SseResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newSseResponseWriter(rec)
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"data":{"chat":"hi"}}`))
+	w.complete()
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Result().Header.Get("Content-Type"))
+	assert.Equal(t, ""+
+		"event: next\ndata: {\"data\":{\"chat\":\"hi\"}}\n\n"+
+		"event: complete\ndata: \n\n",
+		rec.Body.String())
+}
+
+func Test_SseSubscriptionWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newSseResponseWriter(rec)
+
+	flushes := 0
+	completed := false
+	sw := &sseSubscriptionWriter{sse: w, flushed: func() { flushes++ }, complete: func() { completed = true }}
+
+	// Each update that the engine flushes is a result of the subscription.
+	_, _ = sw.Write([]byte(`{"data":{"chat":"Once"}}`))
+	require.NoError(t, sw.Flush())
+	_, _ = sw.Write([]byte(`{"data":{"chat":" upon"}}`))
+	require.NoError(t, sw.Flush())
+	require.NoError(t, sw.Flush())
+	sw.Complete()
+	w.complete()
+
+	assert.Equal(t, 3, flushes)
+	assert.True(t, completed)
+	assert.Equal(t, "text/event-stream", rec.Result().Header.Get("Content-Type"))
+	assert.Equal(t, ""+
+		"event: next\ndata: {\"data\":{\"chat\":\"Once\"}}\n\n"+
+		"event: next\ndata: {\"data\":{\"chat\":\" upon\"}}\n\n"+
+		"event: complete\ndata: \n\n",
+		rec.Body.String())
+}
+
+func Test_GraphQL_EventStream(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"subscription { tellStory }"}`))
+	req.Header.Set("Accept", "text/event-stream")
+	rec := httptest.NewRecorder()
+	handleGraphQLRequest(rec, req)
+
+	// Without a schema, the errors are the result of the operation.
+	assert.Equal(t, "text/event-stream", rec.Result().Header.Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "event: next\ndata: {\"errors\":"))
+	assert.True(t, strings.HasSuffix(rec.Body.String(), "event: complete\ndata: \n\n"))
+}
+
+func Test_SseResponseWriter_ErrorBeforeStreaming(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newSseResponseWriter(rec)
+
+	http.Error(w, "Failed to parse GraphQL request.", http.StatusBadRequest)
+	w.complete()
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "Failed to parse GraphQL request.\n", rec.Body.String())
+}
+
+func Test_SseResponseWriter_ErrorAfterStreaming(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newSseResponseWriter(rec)
+
+	_, _ = w.Write([]byte(`{"data":{"chat":"a"}}`))
+	http.Error(w, "Failed to execute GraphQL operation.", http.StatusInternalServerError)
+	w.complete()
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "event: next\ndata: {\"errors\":[{\"message\":\"Failed to execute GraphQL operation.\"}]}\n\n")
+}
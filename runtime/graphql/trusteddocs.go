@@ -0,0 +1,185 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/middleware"
+	"github.com/hypermodeinc/modus/runtime/storage"
+
+	"github.com/tidwall/gjson"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+)
+
+const untrustedDocumentMessage = "Only trusted documents may be executed."
+const untrustedFunctionMessage = "Only the functions that trusted documents use may be called."
+
+// trustedDocuments maps the IDs of the operations that may be executed to their documents.
+// It is nil when any operation may be executed.
+var trustedDocuments map[string]string
+var trustedDocumentsMutex sync.RWMutex
+
+// trustedFunctions is the set of functions that the trusted documents call, as the root fields of their operations.
+var trustedFunctions map[string]bool
+
+// loadTrustedDocuments reads the trusted documents file that the manifest names, if any.
+// If the file can't be read, no operation is trusted, rather than every operation.
+func loadTrustedDocuments(ctx context.Context) error {
+	var docs map[string]string
+	if md := manifestdata.GetManifest(); md != nil && md.GraphQL != nil && md.GraphQL.TrustedDocuments != "" {
+		name := md.GraphQL.TrustedDocuments
+		data, err := storage.GetFileContents(ctx, name)
+		if err == nil {
+			docs, err = parseTrustedDocuments(data)
+		}
+		if err != nil {
+			logger.Err(ctx, err).Str("filename", name).Msg("Failed to load trusted documents.  No operations will be allowed.")
+			docs = make(map[string]string)
+		} else {
+			logger.Info(ctx).Str("filename", name).Int("count", len(docs)).Msg("Loaded trusted documents.")
+		}
+	}
+
+	setTrustedDocuments(docs)
+	return nil
+}
+
+func setTrustedDocuments(docs map[string]string) {
+	var functions map[string]bool
+	if docs != nil {
+		functions = make(map[string]bool)
+		for _, query := range docs {
+			addRootFields(query, functions)
+		}
+	}
+
+	trustedDocumentsMutex.Lock()
+	defer trustedDocumentsMutex.Unlock()
+	trustedDocuments = docs
+	trustedFunctions = functions
+}
+
+// addRootFields adds the names of the root fields of the operations of the document to the set.
+func addRootFields(query string, fields map[string]bool) {
+	doc, report := astparser.ParseGraphqlDocumentString(query)
+	if report.HasErrors() {
+		return
+	}
+	for _, op := range doc.OperationDefinitions {
+		if !op.HasSelections {
+			continue
+		}
+		for _, ref := range doc.SelectionSets[op.SelectionSet].SelectionRefs {
+			if sel := doc.Selections[ref]; sel.Kind == ast.SelectionKindField {
+				fields[doc.FieldNameString(sel.Ref)] = true
+			}
+		}
+	}
+}
+
+// parseTrustedDocuments reads a JSON object that maps document IDs to documents, as generated by most GraphQL
+// clients, or an Apollo persisted query manifest, which lists the operations with their IDs and bodies.
+func parseTrustedDocuments(data []byte) (map[string]string, error) {
+	if !gjson.ValidBytes(data) {
+		return nil, fmt.Errorf("the trusted documents file is not valid JSON")
+	}
+
+	doc := gjson.ParseBytes(data)
+	if !doc.IsObject() {
+		return nil, fmt.Errorf("the trusted documents file must be a JSON object")
+	}
+
+	docs := make(map[string]string)
+	if operations := doc.Get("operations"); operations.IsArray() {
+		for _, op := range operations.Array() {
+			docs[normalizeDocumentID(op.Get("id").String())] = op.Get("body").String()
+		}
+		return docs, nil
+	}
+
+	for id, query := range doc.Map() {
+		docs[normalizeDocumentID(id)] = query.String()
+	}
+	return docs, nil
+}
+
+// normalizeDocumentID removes the "sha256:" prefix that document IDs may have.
+func normalizeDocumentID(id string) string {
+	return strings.ToLower(strings.TrimPrefix(id, "sha256:"))
+}
+
+// hashDocument returns the ID of a document, which is the hex-encoded SHA-256 hash of its text.
+func hashDocument(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// documentID returns the ID of the document that a request refers to, either as its documentId,
+// or as the hash of an Apollo persisted query.
+func documentID(payload []byte) string {
+	if id := gjson.GetBytes(payload, "documentId").String(); id != "" {
+		return normalizeDocumentID(id)
+	}
+	return normalizeDocumentID(gjson.GetBytes(payload, "extensions.persistedQuery.sha256Hash").String())
+}
+
+// resolveTrustedDocument sets the query of a request that refers to a trusted document by its ID.
+// When trusted documents are required, it returns an error for any other query, except in the development
+// environment, and for requests from admins.
+func resolveTrustedDocument(header http.Header, req *gql.Request, payload []byte) graphqlerrors.RequestErrors {
+	trustedDocumentsMutex.RLock()
+	docs := trustedDocuments
+	trustedDocumentsMutex.RUnlock()
+
+	if id := documentID(payload); id != "" {
+		query, ok := docs[id]
+		if !ok {
+			return graphqlerrors.RequestErrors{{Message: fmt.Sprintf("Unknown document ID %s.", id)}}
+		}
+		req.Query = query
+		return nil
+	}
+
+	if docs == nil || config.IsDevEnvironment() || middleware.IsAdminRequest(header) {
+		return nil
+	}
+
+	if _, ok := docs[hashDocument(req.Query)]; ok {
+		return nil
+	}
+	return graphqlerrors.RequestErrors{{Message: untrustedDocumentMessage}}
+}
+
+// isTrustedFunction reports whether a function may be called directly, through the REST or event stream endpoints,
+// which execute documents of their own.  When trusted documents are required, only the functions that the trusted
+// documents use may be called, except in the development environment, and by admins.
+func isTrustedFunction(header http.Header, fnName string) bool {
+	trustedDocumentsMutex.RLock()
+	docs, functions := trustedDocuments, trustedFunctions
+	trustedDocumentsMutex.RUnlock()
+
+	if docs == nil || config.IsDevEnvironment() || middleware.IsAdminRequest(header) {
+		return true
+	}
+	return functions[fnName]
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+)
+
+const trustedQuery = `{ getUser(id: "1") { id } }`
+
+func Test_ParseTrustedDocuments(t *testing.T) {
+	docs, err := parseTrustedDocuments([]byte(`{"sha256:ABC": "{ a }", "def": "{ b }"}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"abc": "{ a }", "def": "{ b }"}, docs)
+
+	docs, err = parseTrustedDocuments([]byte(`{
+		"format": "apollo-persisted-query-manifest",
+		"version": 1,
+		"operations": [{"id": "abc", "name": "A", "type": "query", "body": "query A { a }"}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"abc": "query A { a }"}, docs)
+
+	_, err = parseTrustedDocuments([]byte(`["{ a }"]`))
+	assert.Error(t, err)
+}
+
+func Test_ResolveTrustedDocument(t *testing.T) {
+	t.Setenv("MODUS_ADMIN_TOKEN", "secret")
+	id := hashDocument(trustedQuery)
+	trustedDocuments = map[string]string{id: trustedQuery}
+	defer func() { trustedDocuments = nil }()
+
+	// A trusted document can be sent by its ID, or in full.
+	req := &gql.Request{}
+	assert.Empty(t, resolveTrustedDocument(http.Header{}, req, []byte(`{"documentId":"sha256:`+id+`"}`)))
+	assert.Equal(t, trustedQuery, req.Query)
+
+	req = &gql.Request{}
+	assert.Empty(t, resolveTrustedDocument(http.Header{}, req, []byte(`{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"`+id+`"}}}`)))
+	assert.Equal(t, trustedQuery, req.Query)
+
+	assert.Empty(t, resolveTrustedDocument(http.Header{}, &gql.Request{Query: trustedQuery}, nil))
+
+	// Other documents are rejected, except for admins.
+	errs := resolveTrustedDocument(http.Header{}, &gql.Request{Query: `{ getUser(id: "2") { id } }`}, nil)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, untrustedDocumentMessage, errs[0].Message)
+	}
+	assert.Empty(t, resolveTrustedDocument(http.Header{"X-Modus-Admin-Token": {"secret"}}, &gql.Request{Query: `{ getUser(id: "2") { id } }`}, nil))
+	assert.Len(t, resolveTrustedDocument(http.Header{}, &gql.Request{}, []byte(`{"documentId":"unknown"}`)), 1)
+
+	// Without trusted documents, any document may be executed.
+	trustedDocuments = nil
+	assert.Empty(t, resolveTrustedDocument(http.Header{}, &gql.Request{Query: `{ getUser(id: "2") { id } }`}, nil))
+}
+
+func Test_TrustedDocuments_RestAndEventStream(t *testing.T) {
+	t.Setenv("MODUS_ADMIN_TOKEN", "secret")
+	setTrustedDocuments(map[string]string{hashDocument(trustedQuery): trustedQuery})
+	defer setTrustedDocuments(nil)
+
+	assert.True(t, isTrustedFunction(http.Header{}, "getUser"))
+	assert.False(t, isTrustedFunction(http.Header{}, "deleteUser"))
+	assert.True(t, isTrustedFunction(http.Header{"X-Modus-Admin-Token": {"secret"}}, "deleteUser"))
+
+	// functions that no trusted document uses can't be called as REST endpoints, or streamed
+	w := httptest.NewRecorder()
+	handleRestRequest(w, httptest.NewRequest(http.MethodPost, RestPathPrefix+"deleteUser", strings.NewReader(`{"id":"1"}`)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), untrustedFunctionMessage)
+
+	w = httptest.NewRecorder()
+	handleStreamRequest(w, httptest.NewRequest(http.MethodGet, StreamPathPrefix+"deleteUser", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// a trusted function gets as far as the engine, which isn't running in this test
+	w = httptest.NewRecorder()
+	handleRestRequest(w, httptest.NewRequest(http.MethodPost, RestPathPrefix+"getUser", strings.NewReader(`{"id":"1"}`)))
+	assert.NotEqual(t, http.StatusForbidden, w.Code)
+
+	// without trusted documents, any function may be called
+	setTrustedDocuments(nil)
+	assert.True(t, isTrustedFunction(http.Header{}, "deleteUser"))
+}
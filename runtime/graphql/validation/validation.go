@@ -0,0 +1,287 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package validation provides the constraints that function authors place on the arguments of their functions,
+// which are declared in the GraphQL schema, and checked before the functions are invoked.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// The "validate" directive constrains one parameter of a function, named by its "param" argument, such as:
+//
+//	//modus:validate param=age min=0 max=150
+//	//modus:validate param=name required minLength=1 maxLength=100 pattern=^[A-Za-z]
+//
+// The "min" and "max" arguments limit numbers, "minLength" and "maxLength" limit the length of strings and lists,
+// "pattern" is a regular expression that strings must match, and "required" rejects null and empty values.
+const Directive = "validate"
+
+type Constraint struct {
+	Param     string
+	Min       *float64
+	Max       *float64
+	MinLength *int
+	MaxLength *int
+	Pattern   *regexp.Regexp
+	Required  bool
+}
+
+// Violation describes an argument that does not meet a constraint.
+type Violation struct {
+	Argument   string `json:"argument"`
+	Constraint string `json:"constraint"`
+	Message    string `json:"message"`
+}
+
+// patterns holds the compiled regular expressions, so that they aren't compiled for every function call.
+var patterns sync.Map
+
+// GetConstraints returns the constraints of the function's parameters, in the order they are declared.
+func GetConstraints(fn *metadata.Function) ([]*Constraint, error) {
+	var constraints []*Constraint
+	for _, d := range fn.GetDirectives(Directive) {
+		c, err := parseConstraint(d)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(fn.Parameters, func(p *metadata.Parameter) bool { return p.Name == c.Param }) {
+			return nil, fmt.Errorf("parameter %s of the @%s directive does not exist", c.Param, Directive)
+		}
+		if slices.ContainsFunc(constraints, func(other *Constraint) bool { return other.Param == c.Param }) {
+			return nil, fmt.Errorf("parameter %s has more than one @%s directive", c.Param, Directive)
+		}
+		constraints = append(constraints, c)
+	}
+
+	return constraints, nil
+}
+
+func parseConstraint(d *metadata.Directive) (*Constraint, error) {
+	c := &Constraint{Param: d.Args["param"]}
+	if c.Param == "" {
+		return nil, fmt.Errorf("the @%s directive requires a param argument", Directive)
+	}
+
+	for key, value := range d.Args {
+		var err error
+		switch key {
+		case "param":
+			continue
+		case "min":
+			c.Min, err = parseNumber(value)
+		case "max":
+			c.Max, err = parseNumber(value)
+		case "minLength":
+			c.MinLength, err = parseLength(value)
+		case "maxLength":
+			c.MaxLength, err = parseLength(value)
+		case "pattern":
+			c.Pattern, err = compilePattern(value)
+		case "required":
+			c.Required = value == "" || value == "true"
+		default:
+			err = fmt.Errorf("unknown argument")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s argument of the @%s directive for parameter %s: %w", key, Directive, c.Param, err)
+		}
+	}
+
+	return c, nil
+}
+
+func compilePattern(s string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(s); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(s, re)
+	return re, nil
+}
+
+func parseNumber(s string) (*float64, error) {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func parseLength(s string) (*int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("must not be negative")
+	}
+	return &n, nil
+}
+
+// SchemaArguments returns the arguments of the constraint, as they are written in the GraphQL schema.
+func (c *Constraint) SchemaArguments() string {
+	var args []string
+	if c.Min != nil {
+		args = append(args, "min: "+formatNumber(*c.Min))
+	}
+	if c.Max != nil {
+		args = append(args, "max: "+formatNumber(*c.Max))
+	}
+	if c.MinLength != nil {
+		args = append(args, "minLength: "+strconv.Itoa(*c.MinLength))
+	}
+	if c.MaxLength != nil {
+		args = append(args, "maxLength: "+strconv.Itoa(*c.MaxLength))
+	}
+	if c.Pattern != nil {
+		s, _ := utils.JsonSerialize(c.Pattern.String())
+		args = append(args, "pattern: "+string(s))
+	}
+	if c.Required {
+		args = append(args, "required: true")
+	}
+	return strings.Join(args, ", ")
+}
+
+// Description describes the constraint, for the description of the argument in the schema.
+func (c *Constraint) Description() string {
+	var sentences []string
+	if c.Required {
+		sentences = append(sentences, "Required.")
+	}
+	if s := describeRange(c.Min, c.Max, formatNumber); s != "" {
+		sentences = append(sentences, "Must be "+s+".")
+	}
+	if s := describeRange(c.MinLength, c.MaxLength, strconv.Itoa); s != "" {
+		sentences = append(sentences, "Length must be "+s+".")
+	}
+	if c.Pattern != nil {
+		sentences = append(sentences, "Must match /"+c.Pattern.String()+"/.")
+	}
+	return strings.Join(sentences, " ")
+}
+
+func describeRange[T any](min, max *T, format func(T) string) string {
+	switch {
+	case min != nil && max != nil:
+		return "between " + format(*min) + " and " + format(*max)
+	case min != nil:
+		return "at least " + format(*min)
+	case max != nil:
+		return "at most " + format(*max)
+	}
+	return ""
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// Validate checks the arguments of a function call against the constraints,
+// and returns a violation for each constraint that is not met.
+func Validate(constraints []*Constraint, parameters map[string]any) []*Violation {
+	var violations []*Violation
+	for _, c := range constraints {
+		violations = append(violations, c.validate(parameters[c.Param])...)
+	}
+	return violations
+}
+
+func (c *Constraint) validate(value any) []*Violation {
+	var violations []*Violation
+	violate := func(constraint, format string, a ...any) {
+		violations = append(violations, &Violation{
+			Argument:   c.Param,
+			Constraint: constraint,
+			Message:    fmt.Sprintf("%s "+format, append([]any{c.Param}, a...)...),
+		})
+	}
+
+	if value == nil {
+		if c.Required {
+			violate("required", "is required")
+		}
+		return violations
+	}
+
+	if c.Min != nil || c.Max != nil {
+		if n, ok := toNumber(value); ok {
+			if c.Min != nil && n < *c.Min {
+				violate("min", "must be at least %s", formatNumber(*c.Min))
+			}
+			if c.Max != nil && n > *c.Max {
+				violate("max", "must be at most %s", formatNumber(*c.Max))
+			}
+		}
+	}
+
+	if length, ok := lengthOf(value); ok {
+		if c.Required && length == 0 {
+			violate("required", "must not be empty")
+		}
+		if c.MinLength != nil && length < *c.MinLength {
+			violate("minLength", "must have a length of at least %d", *c.MinLength)
+		}
+		if c.MaxLength != nil && length > *c.MaxLength {
+			violate("maxLength", "must have a length of at most %d", *c.MaxLength)
+		}
+	}
+
+	if s, ok := value.(string); ok && c.Pattern != nil && !c.Pattern.MatchString(s) {
+		violate("pattern", "must match the pattern /%s/", c.Pattern.String())
+	}
+
+	return violations
+}
+
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case string:
+		// BigInt arguments are strings, but strings that are not numbers are not constrained.
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	case bool:
+		return 0, false
+	}
+
+	n, err := utils.Cast[float64](value)
+	return n, err == nil
+}
+
+func lengthOf(value any) (int, bool) {
+	if s, ok := value.(string); ok {
+		return utf8.RuneCountInString(s), true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return v.Len(), true
+	}
+	return 0, false
+}
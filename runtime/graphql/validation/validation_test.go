@@ -0,0 +1,89 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFunction() *metadata.Function {
+	return metadata.NewFunction("addUser").
+		WithParameter("name", "string").
+		WithParameter("age", "int32").
+		WithParameter("tags", "[]string").
+		WithDirective("validate", map[string]string{"param": "name", "required": "", "maxLength": "5", "pattern": "^[A-Z]"}).
+		WithDirective("validate", map[string]string{"param": "age", "min": "0", "max": "150"}).
+		WithDirective("validate", map[string]string{"param": "tags", "minLength": "1"})
+}
+
+func Test_Validate(t *testing.T) {
+	constraints, err := GetConstraints(testFunction())
+	require.Nil(t, err)
+	require.Len(t, constraints, 3)
+
+	violations := Validate(constraints, map[string]any{
+		"name": "Alice",
+		"age":  json.Number("42"),
+		"tags": []any{"a"},
+	})
+	assert.Empty(t, violations)
+
+	violations = Validate(constraints, map[string]any{
+		"name": "alice smith",
+		"age":  json.Number("-1"),
+		"tags": []any{},
+	})
+	assert.Equal(t, []*Violation{
+		{Argument: "name", Constraint: "maxLength", Message: "name must have a length of at most 5"},
+		{Argument: "name", Constraint: "pattern", Message: "name must match the pattern /^[A-Z]/"},
+		{Argument: "age", Constraint: "min", Message: "age must be at least 0"},
+		{Argument: "tags", Constraint: "minLength", Message: "tags must have a length of at least 1"},
+	}, violations)
+
+	violations = Validate(constraints, map[string]any{"age": json.Number("151")})
+	assert.Equal(t, []*Violation{
+		{Argument: "name", Constraint: "required", Message: "name is required"},
+		{Argument: "age", Constraint: "max", Message: "age must be at most 150"},
+	}, violations)
+}
+
+func Test_GetConstraints_Invalid(t *testing.T) {
+	fn := metadata.NewFunction("f").
+		WithParameter("x", "int32").
+		WithDirective("validate", map[string]string{"param": "x", "min": "zero"})
+	_, err := GetConstraints(fn)
+	assert.ErrorContains(t, err, "invalid min argument")
+
+	fn = metadata.NewFunction("f").
+		WithDirective("validate", map[string]string{"param": "y", "min": "0"})
+	_, err = GetConstraints(fn)
+	assert.ErrorContains(t, err, "parameter y of the @validate directive does not exist")
+
+	fn = metadata.NewFunction("f").
+		WithParameter("x", "int32").
+		WithDirective("validate", map[string]string{"param": "x", "size": "1"})
+	_, err = GetConstraints(fn)
+	assert.ErrorContains(t, err, "unknown argument")
+}
+
+func Test_Constraint_Schema(t *testing.T) {
+	constraints, err := GetConstraints(testFunction())
+	require.Nil(t, err)
+
+	assert.Equal(t, `maxLength: 5, pattern: "^[A-Z]", required: true`, constraints[0].SchemaArguments())
+	assert.Equal(t, "Required. Length must be at most 5. Must match /^[A-Z]/.", constraints[0].Description())
+	assert.Equal(t, "min: 0, max: 150", constraints[1].SchemaArguments())
+	assert.Equal(t, "Must be between 0 and 150.", constraints[1].Description())
+}
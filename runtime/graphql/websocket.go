@@ -0,0 +1,349 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/graphql/datasource"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	gql "github.com/wundergraph/graphql-go-tools/execution/graphql"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/graphqlerrors"
+	"github.com/wundergraph/graphql-go-tools/v2/pkg/operationreport"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// The WebSocket transport follows the GraphQL over WebSocket protocol of the graphql-ws library.
+// See https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+//
+// Each operation is started by a "subscribe" message.  A subscription to a streaming function receives a "next"
+// message for each chunk of output the function streams, and for its result.  Queries are also accepted,
+// and receive a single "next" message with their result.  Either way, a "complete" message ends the operation.
+const graphqlWsProtocol = "graphql-transport-ws"
+
+// connectionInitTimeout is how long a client has to send its "connection_init" message after connecting.
+const connectionInitTimeout = 10 * time.Second
+
+// The close codes of the protocol.
+const (
+	wsCloseInvalidMessage         websocket.StatusCode = 4400
+	wsCloseUnauthorized           websocket.StatusCode = 4401
+	wsCloseSubprotocolNotAccepted websocket.StatusCode = 4406
+	wsCloseInitTimeout            websocket.StatusCode = 4408
+	wsCloseSubscriberExists       websocket.StatusCode = 4409
+	wsCloseTooManyInitRequests    websocket.StatusCode = 4429
+)
+
+type wsMessage struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// isWebSocketUpgrade reports whether the client is opening a WebSocket connection.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+type wsConnection struct {
+	conn   *websocket.Conn
+	header http.Header
+	path   string
+
+	mu            sync.Mutex
+	initialized   bool
+	subscriptions map[string]context.CancelFunc
+}
+
+func handleGraphQLWebSocket(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Requests are authorized by bearer tokens rather than cookies, so connections are accepted from any origin,
+	// the same as other GraphQL requests.
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols:       []string{graphqlWsProtocol},
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		// Accept has already written an error response.
+		return
+	}
+	defer conn.CloseNow()
+
+	if conn.Subprotocol() != graphqlWsProtocol {
+		_ = conn.Close(wsCloseSubprotocolNotAccepted, "Subprotocol not acceptable")
+		return
+	}
+
+	c := &wsConnection{
+		conn:          conn,
+		header:        r.Header,
+		path:          r.URL.Path,
+		subscriptions: make(map[string]context.CancelFunc),
+	}
+	defer c.cancelAll()
+
+	initTimer := time.AfterFunc(connectionInitTimeout, func() {
+		if !c.isInitialized() {
+			_ = conn.Close(wsCloseInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	for {
+		var msg wsMessage
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			if !c.initialize() {
+				_ = conn.Close(wsCloseTooManyInitRequests, "Too many initialisation requests")
+				return
+			}
+			c.send(ctx, &wsMessage{Type: "connection_ack"})
+
+		case "ping":
+			c.send(ctx, &wsMessage{Type: "pong"})
+
+		case "pong":
+			// nothing to do
+
+		case "subscribe":
+			if !c.isInitialized() {
+				_ = conn.Close(wsCloseUnauthorized, "Unauthorized")
+				return
+			}
+
+			var req gql.Request
+			if msg.Id == "" || utils.JsonDeserialize(msg.Payload, &req) != nil {
+				_ = conn.Close(wsCloseInvalidMessage, "Invalid subscribe message")
+				return
+			}
+			req.SetHeader(c.header)
+			if errs := resolveTrustedDocument(c.header, &req, msg.Payload); len(errs) > 0 {
+				c.sendError(ctx, msg.Id, errs)
+				continue
+			}
+
+			opCtx, ok := c.subscribe(ctx, msg.Id)
+			if !ok {
+				_ = conn.Close(wsCloseSubscriberExists, fmt.Sprintf("Subscriber for %s already exists", msg.Id))
+				return
+			}
+			go c.execute(opCtx, msg.Id, &req)
+
+		case "complete":
+			c.unsubscribe(msg.Id)
+
+		default:
+			_ = conn.Close(wsCloseInvalidMessage, "Invalid message type")
+			return
+		}
+	}
+}
+
+func (c *wsConnection) initialize() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.initialized {
+		return false
+	}
+	c.initialized = true
+	return true
+}
+
+func (c *wsConnection) isInitialized() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.initialized
+}
+
+func (c *wsConnection) subscribe(ctx context.Context, id string) (context.Context, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, found := c.subscriptions[id]; found {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.subscriptions[id] = cancel
+	return ctx, true
+}
+
+// unsubscribe stops the operation, and reports whether it was still running.
+func (c *wsConnection) unsubscribe(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cancel, found := c.subscriptions[id]
+	if found {
+		cancel()
+		delete(c.subscriptions, id)
+	}
+	return found
+}
+
+func (c *wsConnection) cancelAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, cancel := range c.subscriptions {
+		cancel()
+		delete(c.subscriptions, id)
+	}
+}
+
+func (c *wsConnection) send(ctx context.Context, msg *wsMessage) {
+	// Errors mean that the connection is closed, which ends the read loop.
+	_ = wsjson.Write(ctx, c.conn, msg)
+}
+
+func (c *wsConnection) sendError(ctx context.Context, id string, errs any) {
+	payload, err := utils.JsonSerialize(errs)
+	if err != nil {
+		return
+	}
+	c.send(ctx, &wsMessage{Id: id, Type: "error", Payload: payload})
+}
+
+// execute runs an operation, and sends its results to the client.  The operation ends with a "complete" message,
+// unless the client completed it first, or it failed with an "error" message.
+func (c *wsConnection) execute(ctx context.Context, id string, req *gql.Request) {
+	// Messages are still sent after the operation's context is cancelled, when the operation completes.
+	connCtx := context.WithoutCancel(ctx)
+	if !c.run(ctx, id, req) {
+		c.unsubscribe(id)
+		return
+	}
+	if c.unsubscribe(id) {
+		c.send(connCtx, &wsMessage{Id: id, Type: "complete"})
+	}
+}
+
+func (c *wsConnection) run(ctx context.Context, id string, req *gql.Request) bool {
+	connCtx := context.WithoutCancel(ctx)
+
+	engine, schema, found := getEngine(c.path)
+	if !found {
+		c.sendError(connCtx, id, []map[string]string{{"message": "Plugin not found."}})
+		return false
+	}
+	if engine == nil {
+		msg := "There is no active GraphQL schema.  Please load a Modus plugin, or declare connectors in the manifest."
+		c.sendError(connCtx, id, []map[string]string{{"message": msg}})
+		return false
+	}
+
+	if errs := checkIntrospection(c.header, req); len(errs) > 0 {
+		c.sendError(connCtx, id, errs)
+		return false
+	}
+
+	if errs := checkQueryLimits(schema, req); len(errs) > 0 {
+		c.sendError(connCtx, id, errs)
+		return false
+	}
+
+	if canary := c.header.Get("X-Modus-Canary"); canary != "" {
+		ctx = context.WithValue(ctx, utils.CanaryContextKey, canary)
+	}
+
+	opType, err := req.OperationType()
+	if err != nil {
+		c.sendError(connCtx, id, graphqlerrors.RequestErrorsFromError(err))
+		return false
+	}
+
+	if opType == gql.OperationTypeSubscription {
+		ctx, flushed := datasource.WithSubscriptionFlushes(ctx)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		w := &wsSubscriptionWriter{conn: c, ctx: connCtx, id: id, flushed: flushed, complete: cancel}
+		err = engine.Execute(ctx, req, w)
+	} else {
+		output := make(map[string]wasmhost.ExecutionInfo)
+		ctx = context.WithValue(ctx, utils.FunctionOutputContextKey, output)
+		ctx = datasource.WithInvocationBudget(ctx, config.RequestConcurrency)
+
+		resultWriter := gql.NewEngineResultWriter()
+		err = engine.Execute(ctx, req, &resultWriter)
+		if err == nil {
+			response, err := addOutputToResponse(resultWriter.Bytes(), output)
+			if err != nil {
+				logger.Err(ctx, err).Msg("Failed to add function output to response.")
+				response = resultWriter.Bytes()
+			}
+			c.send(connCtx, &wsMessage{Id: id, Type: "next", Payload: response})
+		}
+	}
+
+	if err != nil && ctx.Err() == nil {
+		if report, ok := err.(operationreport.Report); ok && len(report.InternalErrors) > 0 {
+			// Log internal errors, but don't return them to the client
+			msg := "Failed to execute GraphQL operation."
+			logger.Err(ctx, err).Msg(msg)
+			c.sendError(connCtx, id, []map[string]string{{"message": msg}})
+		} else {
+			c.sendError(connCtx, id, graphqlerrors.RequestErrorsFromError(err))
+		}
+		return false
+	}
+
+	return true
+}
+
+// wsSubscriptionWriter sends each update of a subscription to the client as a "next" message.
+// The engine completes the writer when the subscription's function returns, which ends the execution.
+type wsSubscriptionWriter struct {
+	conn     *wsConnection
+	ctx      context.Context
+	id       string
+	flushed  func()
+	complete func()
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *wsSubscriptionWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *wsSubscriptionWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer w.flushed()
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	payload := bytes.Clone(w.buf.Bytes())
+	w.buf.Reset()
+	return wsjson.Write(w.ctx, w.conn.conn, &wsMessage{Id: w.id, Type: "next", Payload: payload})
+}
+
+func (w *wsSubscriptionWriter) Complete() {
+	w.complete()
+}
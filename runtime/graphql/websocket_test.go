@@ -0,0 +1,98 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func dialGraphQLWebSocket(t *testing.T, subprotocols ...string) (context.Context, *websocket.Conn) {
+	server := httptest.NewServer(GraphQLRequestHandler)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{Subprotocols: subprotocols})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.CloseNow() })
+	return ctx, conn
+}
+
+func readWsMessage(t *testing.T, ctx context.Context, conn *websocket.Conn) *wsMessage {
+	var msg wsMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	return &msg
+}
+
+func Test_WebSocket_Handshake(t *testing.T) {
+	ctx, conn := dialGraphQLWebSocket(t, graphqlWsProtocol)
+	assert.Equal(t, graphqlWsProtocol, conn.Subprotocol())
+
+	require.NoError(t, wsjson.Write(ctx, conn, wsMessage{Type: "connection_init"}))
+	assert.Equal(t, "connection_ack", readWsMessage(t, ctx, conn).Type)
+
+	require.NoError(t, wsjson.Write(ctx, conn, wsMessage{Type: "ping"}))
+	assert.Equal(t, "pong", readWsMessage(t, ctx, conn).Type)
+
+	// a second init closes the connection
+	require.NoError(t, wsjson.Write(ctx, conn, wsMessage{Type: "connection_init"}))
+	_, _, err := conn.Read(ctx)
+	assert.Equal(t, wsCloseTooManyInitRequests, websocket.CloseStatus(err))
+}
+
+func Test_WebSocket_SubscribeBeforeInit(t *testing.T) {
+	ctx, conn := dialGraphQLWebSocket(t, graphqlWsProtocol)
+
+	msg := wsMessage{Id: "1", Type: "subscribe", Payload: []byte(`{"query":"subscription { tellStory }"}`)}
+	require.NoError(t, wsjson.Write(ctx, conn, msg))
+	_, _, err := conn.Read(ctx)
+	assert.Equal(t, wsCloseUnauthorized, websocket.CloseStatus(err))
+}
+
+func Test_WebSocket_SubscribeWithoutSchema(t *testing.T) {
+	ctx, conn := dialGraphQLWebSocket(t, graphqlWsProtocol)
+
+	require.NoError(t, wsjson.Write(ctx, conn, wsMessage{Type: "connection_init"}))
+	assert.Equal(t, "connection_ack", readWsMessage(t, ctx, conn).Type)
+
+	msg := wsMessage{Id: "1", Type: "subscribe", Payload: []byte(`{"query":"subscription { tellStory }"}`)}
+	require.NoError(t, wsjson.Write(ctx, conn, msg))
+
+	reply := readWsMessage(t, ctx, conn)
+	assert.Equal(t, "error", reply.Type)
+	assert.Equal(t, "1", reply.Id)
+	assert.Contains(t, string(reply.Payload), "There is no active GraphQL schema.")
+}
+
+func Test_WebSocket_InvalidMessage(t *testing.T) {
+	ctx, conn := dialGraphQLWebSocket(t, graphqlWsProtocol)
+
+	require.NoError(t, wsjson.Write(ctx, conn, wsMessage{Type: "bogus"}))
+	_, _, err := conn.Read(ctx)
+	assert.Equal(t, wsCloseInvalidMessage, websocket.CloseStatus(err))
+}
+
+func Test_WebSocket_UnsupportedSubprotocol(t *testing.T) {
+	ctx, conn := dialGraphQLWebSocket(t)
+
+	_, _, err := conn.Read(ctx)
+	assert.Equal(t, wsCloseSubprotocolNotAccepted, websocket.CloseStatus(err))
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package guards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/middleware"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/google/cel-go/cel"
+)
+
+// allFunctions is used in a guard's function list to apply it to every function.
+const allFunctions = "*"
+
+type guard struct {
+	info    manifest.GuardInfo
+	program cel.Program
+	err     error
+}
+
+var guardsByFunction map[string][]*guard
+var guardsMutex sync.RWMutex
+
+var celEnv = func() *cel.Env {
+	env, err := cel.NewEnv(
+		cel.Variable("function", cel.StringType),
+		cel.Variable("args", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("claims", cel.MapType(cel.StringType, cel.DynType)),
+		cel.CrossTypeNumericComparisons(true),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return env
+}()
+
+func Initialize() {
+	manifestdata.RegisterManifestLoadedCallback(func(ctx context.Context) error {
+		loadGuards(ctx, manifestdata.GetManifest().Guards)
+		return nil
+	})
+}
+
+func loadGuards(ctx context.Context, infos map[string]manifest.GuardInfo) {
+	// Evaluate guards in a consistent order.
+	names := make([]string, 0, len(infos))
+	for name := range infos {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	byFunction := make(map[string][]*guard)
+	for _, name := range names {
+		g := &guard{info: infos[name]}
+		g.program, g.err = compile(g.info.Condition)
+		if g.err != nil {
+			// An invalid guard rejects every invocation, rather than letting them all through.
+			logger.Error(ctx).Err(g.err).
+				Str("guard", name).
+				Bool("user_visible", true).
+				Msg("Invalid guard condition. Invocations of the guarded functions will be rejected.")
+		}
+
+		for _, fn := range g.info.Functions {
+			byFunction[fn] = append(byFunction[fn], g)
+		}
+	}
+
+	guardsMutex.Lock()
+	defer guardsMutex.Unlock()
+	guardsByFunction = byFunction
+}
+
+func compile(condition string) (cel.Program, error) {
+	ast, iss := celEnv.Compile(condition)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("condition must evaluate to a boolean, not %s", ast.OutputType())
+	}
+	return celEnv.Program(ast)
+}
+
+// Check evaluates the guards that apply to the function, before it is invoked.
+// It returns the name of the function that should be invoked instead, which is the original function
+// unless a guard reroutes it, or an error if a guard rejects the invocation.
+func Check(ctx context.Context, fnName string, args map[string]any) (string, error) {
+	guardsMutex.RLock()
+	guards := append(slices.Clone(guardsByFunction[allFunctions]), guardsByFunction[fnName]...)
+	guardsMutex.RUnlock()
+
+	if len(guards) == 0 {
+		return fnName, nil
+	}
+
+	vars, err := getVariables(ctx, fnName, args)
+	if err != nil {
+		return "", err
+	}
+
+	for _, g := range guards {
+		if ok := g.evaluate(ctx, vars); ok {
+			continue
+		}
+
+		if g.info.Reroute != "" {
+			logger.Debug(ctx).
+				Str("guard", g.info.Name).
+				Str("function", fnName).
+				Str("reroute", g.info.Reroute).
+				Msg("Guard condition not met. Rerouting invocation.")
+			return g.info.Reroute, nil
+		}
+
+		if g.info.Message != "" {
+			return "", fmt.Errorf("%s", g.info.Message)
+		}
+		return "", fmt.Errorf("invocation of %s was rejected by guard %s", fnName, g.info.Name)
+	}
+
+	return fnName, nil
+}
+
+func (g *guard) evaluate(ctx context.Context, vars map[string]any) bool {
+	if g.err != nil {
+		return false
+	}
+
+	out, _, err := g.program.Eval(vars)
+	if err != nil {
+		// Errors such as a missing argument or claim mean the condition isn't met.
+		logger.Debug(ctx).Err(err).
+			Str("guard", g.info.Name).
+			Msg("Guard condition could not be evaluated.")
+		return false
+	}
+
+	result, ok := out.Value().(bool)
+	return ok && result
+}
+
+func getVariables(ctx context.Context, fnName string, args map[string]any) (map[string]any, error) {
+	// Normalize the arguments to plain JSON types, which CEL understands.
+	argsJson, err := utils.JsonSerialize(args)
+	if err != nil {
+		return nil, err
+	}
+	var argsMap map[string]any
+	if err := json.Unmarshal(argsJson, &argsMap); err != nil {
+		return nil, err
+	}
+	if argsMap == nil {
+		argsMap = map[string]any{}
+	}
+
+	claimsMap := map[string]any{}
+	if claims := middleware.GetJWTClaims(ctx); claims != "" {
+		if err := json.Unmarshal([]byte(claims), &claimsMap); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]any{
+		"function": fnName,
+		"args":     argsMap,
+		"claims":   claimsMap,
+	}, nil
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package guards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Check(t *testing.T) {
+	ctx := context.Background()
+	loadGuards(ctx, map[string]manifest.GuardInfo{
+		"adminOnly": {
+			Name:      "adminOnly",
+			Functions: []string{"deleteUser"},
+			Condition: "has(claims.role) && claims.role == 'admin'",
+			Message:   "Only administrators can delete users.",
+		},
+		"smallQueries": {
+			Name:      "smallQueries",
+			Functions: []string{"search"},
+			Condition: "args.limit <= 100",
+			Reroute:   "searchLimited",
+		},
+		"noEmptyNames": {
+			Name:      "noEmptyNames",
+			Functions: []string{"*"},
+			Condition: "!has(args.name) || args.name != ''",
+		},
+	})
+
+	fn, err := Check(ctx, "getUser", map[string]any{"id": 1})
+	require.Nil(t, err)
+	assert.Equal(t, "getUser", fn)
+
+	// no claims are present, so the admin guard rejects the invocation
+	_, err = Check(ctx, "deleteUser", map[string]any{"id": 1})
+	assert.EqualError(t, err, "Only administrators can delete users.")
+
+	fn, err = Check(ctx, "search", map[string]any{"limit": 10})
+	require.Nil(t, err)
+	assert.Equal(t, "search", fn)
+
+	fn, err = Check(ctx, "search", map[string]any{"limit": 1000})
+	require.Nil(t, err)
+	assert.Equal(t, "searchLimited", fn)
+
+	// a missing argument means the condition is not met
+	fn, err = Check(ctx, "search", map[string]any{})
+	require.Nil(t, err)
+	assert.Equal(t, "searchLimited", fn)
+
+	// the wildcard guard applies to every function
+	_, err = Check(ctx, "getUser", map[string]any{"name": ""})
+	assert.EqualError(t, err, "invocation of getUser was rejected by guard noEmptyNames")
+}
+
+func Test_Check_InvalidCondition(t *testing.T) {
+	ctx := context.Background()
+	loadGuards(ctx, map[string]manifest.GuardInfo{
+		"broken": {
+			Name:      "broken",
+			Functions: []string{"getUser"},
+			Condition: "args.id +",
+		},
+		"notBoolean": {
+			Name:      "notBoolean",
+			Functions: []string{"getOrder"},
+			Condition: "'yes'",
+		},
+	})
+
+	_, err := Check(ctx, "getUser", map[string]any{"id": 1})
+	assert.NotNil(t, err)
+
+	_, err = Check(ctx, "getOrder", map[string]any{"id": 1})
+	assert.NotNil(t, err)
+}
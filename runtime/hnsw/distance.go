@@ -47,9 +47,19 @@ func EuclideanDistance(a, b []float32) (float32, error) {
 	return math32.Sqrt(sum), nil
 }
 
+// DotProductDistance computes the negated dot product of two vectors, so that vectors with a larger
+// dot product are closer.  Unlike the other distances, it can be negative.
+func DotProductDistance(a, b []float32) (float32, error) {
+	if len(a) != len(b) {
+		return 0, ErrDifferentVectorLengths
+	}
+	return -vek32.Dot(a, b), nil
+}
+
 var distanceFuncs = map[string]DistanceFunc{
 	"euclidean": EuclideanDistance,
 	"cosine":    CosineDistance,
+	"dot":       DotProductDistance,
 }
 
 func distanceFuncToName(fn DistanceFunc) (string, bool) {
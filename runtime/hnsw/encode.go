@@ -166,12 +166,19 @@ func (h *Graph[K]) Export(w io.Writer) error {
 			return fmt.Errorf("encode number of nodes: %w", err)
 		}
 		for _, node := range layer.nodes {
-			_, err = multiBinaryWrite(w, node.Key, node.Value, len(node.neighbors))
+			neighbors := make([]K, 0, len(node.neighbors))
+			for key, neighbor := range node.neighbors {
+				if !neighbor.node.deleted {
+					neighbors = append(neighbors, key)
+				}
+			}
+
+			_, err = multiBinaryWrite(w, node.Key, node.Value, len(neighbors))
 			if err != nil {
 				return fmt.Errorf("encode node data: %w", err)
 			}
 
-			for neighbor := range node.neighbors {
+			for _, neighbor := range neighbors {
 				_, err = binaryWrite(w, neighbor)
 				if err != nil {
 					return fmt.Errorf("encode neighbor %v: %w", neighbor, err)
@@ -55,6 +55,10 @@ type layerNode[K cmp.Ordered] struct {
 	// It is a map and not a slice to allow for efficient deletes, esp.
 	// when M is high.
 	neighbors map[K]*layerNeighborNode[K]
+
+	// deleted is set when the node is removed from its layer.  Links to the node from nodes that
+	// it doesn't link back to can't be found when it is removed, so they are skipped instead.
+	deleted bool
 }
 
 type layerNeighborNode[K cmp.Ordered] struct {
@@ -95,7 +99,7 @@ func (n *layerNode[K]) addNeighbor(newNeighborNode *layerNeighborNode[K], m int,
 	delete(n.neighbors, worstNeighbor.node.Key)
 	// Delete backlink from the worst neighbor.
 	delete(worstNeighbor.node.neighbors, n.Key)
-	err := worstNeighbor.node.replenish(m)
+	err := worstNeighbor.node.replenish(m, dist)
 	if err != nil {
 		return err
 	}
@@ -144,10 +148,13 @@ func (n *layerNode[K]) search(
 	visited[n.Key] = true
 
 	for candidates.Len() > 0 {
-		var (
-			current  = candidates.Pop().node
-			improved = false
-		)
+		// Stop when the closest remaining candidate is farther than every result,
+		// since none of its neighbors are likely to improve the result set.
+		closest := candidates.Pop()
+		if result.Len() >= k && closest.distance > result.Max().distance {
+			break
+		}
+		current := closest.node
 
 		// We iterate the map in a sorted, deterministic fashion for
 		// tests.
@@ -155,7 +162,7 @@ func (n *layerNode[K]) search(
 		slices.Sort(neighborKeys)
 		for _, neighborID := range neighborKeys {
 			neighbor := current.neighbors[neighborID]
-			if visited[neighborID] {
+			if visited[neighborID] || neighbor.node.deleted {
 				continue
 			}
 			visited[neighborID] = true
@@ -165,7 +172,6 @@ func (n *layerNode[K]) search(
 				return nil, err
 			}
 
-			improved = improved || neighborDist < result.Min().distance
 			if result.Len() < k {
 				result.Push(layerNeighborNode[K]{node: neighbor.node, distance: neighborDist})
 			} else if neighborDist < result.Max().distance {
@@ -179,32 +185,32 @@ func (n *layerNode[K]) search(
 				candidates.PopLast()
 			}
 		}
-
-		// Termination condition: no improvement in distance and at least
-		// kMin candidates in the result set.
-		if !improved && result.Len() >= k {
-			break
-		}
 	}
 
 	return result.Slice(), nil
 }
 
-func (n *layerNode[K]) replenish(m int) error {
+func (n *layerNode[K]) replenish(m int, dist DistanceFunc) error {
 	if len(n.neighbors) >= m {
 		return nil
 	}
 
+	for key, neighbor := range n.neighbors {
+		if neighbor.node.deleted {
+			delete(n.neighbors, key)
+		}
+	}
+
 	// Priority queue to find the best candidates efficiently.
 	candidates := heap.Heap[layerNeighborNode[K]]{}
 	candidates.Init(make([]layerNeighborNode[K], 0, len(n.neighbors)*m))
 
 	for _, neighbor := range n.neighbors {
 		for _, candidate := range neighbor.node.neighbors {
-			if _, exists := n.neighbors[candidate.node.Key]; exists || candidate.node == n {
+			if _, exists := n.neighbors[candidate.node.Key]; exists || candidate.node == n || candidate.node.deleted {
 				continue
 			}
-			neighborDist, err := CosineDistance(n.Value, candidate.node.Value)
+			neighborDist, err := dist(n.Value, candidate.node.Value)
 			if err != nil {
 				return err
 			}
@@ -215,7 +221,7 @@ func (n *layerNode[K]) replenish(m int) error {
 	// Add the best candidates up to m.
 	for len(n.neighbors) < m && candidates.Len() > 0 {
 		bestCandidate := candidates.Pop()
-		err := n.addNeighbor(&bestCandidate, m, CosineDistance)
+		err := n.addNeighbor(&bestCandidate, m, dist)
 		if err != nil {
 			return err
 		}
@@ -226,10 +232,14 @@ func (n *layerNode[K]) replenish(m int) error {
 
 // isolates remove the node from the graph by removing all connections
 // to neighbors.
-func (n *layerNode[K]) isolate(m int) error {
+func (n *layerNode[K]) isolate(m int, dist DistanceFunc) error {
+	// Unlink the node from all of its neighbors before replenishing any of them,
+	// so that it isn't found again through another neighbor.
 	for _, neighbor := range n.neighbors {
 		delete(neighbor.node.neighbors, n.Key)
-		err := neighbor.node.replenish(m)
+	}
+	for _, neighbor := range n.neighbors {
+		err := neighbor.node.replenish(m, dist)
 		if err != nil {
 			return err
 		}
@@ -299,6 +309,10 @@ type Graph[K cmp.Ordered] struct {
 	// expense of memory.
 	EfConstruction int
 
+	// MaxLevels limits the number of layers in the graph, when greater than 0.
+	// Otherwise, the number of layers grows with the size of the base layer.
+	MaxLevels int
+
 	// layers is a slice of layers in the graph.
 	layers []*layer[K]
 }
@@ -354,6 +368,9 @@ func (h *Graph[K]) randomLevel() (int, error) {
 			return 0, err
 		}
 	}
+	if h.MaxLevels > 0 {
+		max = min(max, h.MaxLevels-1)
+	}
 
 	for level := 0; level < max; level++ {
 		if h.Rng == nil {
@@ -470,7 +487,8 @@ func (g *Graph[K]) Add(nodes ...Node[K]) error {
 			if insertLevel >= i {
 				if node, ok := layer.nodes[key]; ok {
 					delete(layer.nodes, key)
-					err := node.isolate(g.M)
+					node.deleted = true
+					err := node.isolate(g.M, g.Distance)
 					if err != nil {
 						return err
 					}
@@ -604,13 +622,19 @@ func (h *Graph[K]) DeleteWithLock(key K) bool {
 			continue
 		}
 		delete(layer.nodes, key)
-		err := node.isolate(h.M)
+		node.deleted = true
+		err := node.isolate(h.M, h.Distance)
 		if err != nil {
 			return false
 		}
 		deleted = true
 	}
 
+	// Remove the top layers that are left empty, since a search enters the graph from the top layer.
+	for len(h.layers) > 0 && len(h.layers[len(h.layers)-1].nodes) == 0 {
+		h.layers = h.layers[:len(h.layers)-1]
+	}
+
 	return deleted
 }
 
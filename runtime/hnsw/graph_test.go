@@ -142,8 +142,8 @@ func TestGraph_AddSearch(t *testing.T) {
 		[]SearchResultNode[int]{
 			{Node: Node[int]{Key: 64, Value: Vector{64}}, Distance: 0.5},
 			{Node: Node[int]{Key: 65, Value: Vector{65}}, Distance: 0.5},
-			{Node: Node[int]{Key: 62, Value: Vector{62}}, Distance: 2.5},
 			{Node: Node[int]{Key: 63, Value: Vector{63}}, Distance: 1.5},
+			{Node: Node[int]{Key: 66, Value: Vector{66}}, Distance: 1.5},
 		},
 		nearest,
 	)
@@ -184,6 +184,17 @@ func TestGraph_AddDelete(t *testing.T) {
 		ok := g.Delete(-1)
 		require.False(t, ok)
 	})
+
+	t.Run("SearchAfterDeletingTopLayer", func(t *testing.T) {
+		top := g.layers[len(g.layers)-1]
+		for key := range top.nodes {
+			require.True(t, g.Delete(key))
+		}
+
+		nearest, err := g.Search([]float32{64.5}, 2)
+		require.NoError(t, err)
+		require.Len(t, nearest, 2)
+	})
 }
 
 func Benchmark_HNSW(b *testing.B) {
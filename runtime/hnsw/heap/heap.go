@@ -80,8 +80,10 @@ func (h *Heap[T]) Pop() T {
 	return heap.Pop(&h.inner).(T)
 }
 
+// PopLast removes and returns the maximum element (according to Less) from the heap.
+// The complexity is O(n) where n = h.Len().
 func (h *Heap[T]) PopLast() T {
-	return h.Remove(h.Len() - 1)
+	return h.Remove(h.maxIndex())
 }
 
 // Remove removes and returns the element at index i from the heap.
@@ -96,8 +98,24 @@ func (h *Heap[T]) Min() T {
 }
 
 // Max returns the maximum element in the heap.
+// The complexity is O(n) where n = h.Len().
 func (h *Heap[T]) Max() T {
-	return h.inner.data[h.inner.Len()-1]
+	return h.inner.data[h.maxIndex()]
+}
+
+// maxIndex returns the index of the maximum element, which is one of the leaves of the heap.
+func (h *Heap[T]) maxIndex() int {
+	n := h.inner.Len()
+	if n == 0 {
+		return -1
+	}
+	idx := n / 2
+	for i := idx + 1; i < n; i++ {
+		if h.inner.Less(idx, i) {
+			idx = i
+		}
+	}
+	return idx
 }
 
 func (h *Heap[T]) Slice() []T {
@@ -42,3 +42,23 @@ func TestHeap(t *testing.T) {
 		t.Errorf("Heap did not return sorted elements: %+v", inOrder)
 	}
 }
+
+func TestHeapMax(t *testing.T) {
+	h := Heap[Int]{}
+
+	for i := 0; i < 20; i++ {
+		h.Push(Int(rand.Int() % 100))
+	}
+
+	var inReverseOrder []Int
+	for h.Len() > 0 {
+		max := h.Max()
+		require.Equal(t, max, h.PopLast())
+		inReverseOrder = append(inReverseOrder, max)
+	}
+
+	slices.Reverse(inReverseOrder)
+	if !slices.IsSorted(inReverseOrder) {
+		t.Errorf("Heap did not return reverse sorted elements: %+v", inReverseOrder)
+	}
+}
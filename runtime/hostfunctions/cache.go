@@ -0,0 +1,36 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/cache"
+)
+
+func init() {
+	registerHostFunction("hypermode", "cacheGet", cache.GetCachedValue,
+		withErrorMessage("Error getting value from cache."),
+		withMessageDetail(func(key string) string {
+			return fmt.Sprintf("Key: %s", key)
+		}))
+
+	registerHostFunction("hypermode", "cacheSet", cache.SetCachedValue,
+		withErrorMessage("Error setting value in cache."),
+		withMessageDetail(func(key string) string {
+			return fmt.Sprintf("Key: %s", key)
+		}))
+
+	registerHostFunction("hypermode", "cacheDelete", cache.DeleteCachedValue,
+		withErrorMessage("Error deleting value from cache."),
+		withMessageDetail(func(key string) string {
+			return fmt.Sprintf("Key: %s", key)
+		}))
+}
@@ -24,6 +24,13 @@ func init() {
 			return fmt.Sprintf("Collection: %s, Namespace: %s, Method: %s", collectionName, namespace, searchMethod)
 		}))
 
+	registerHostFunction("hypermode", "deleteBatchFromCollection", collections.DeleteBatchFromCollection,
+		withCancelledMessage("Cancelled deleting batch from collection."),
+		withErrorMessage("Error deleting batch from collection."),
+		withMessageDetail(func(collectionName, namespace string, keys []string) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, Count: %d", collectionName, namespace, len(keys))
+		}))
+
 	registerHostFunction("hypermode", "deleteFromCollection", collections.DeleteFromCollection,
 		withCancelledMessage("Cancelled deleting from collection."),
 		withErrorMessage("Error deleting from collection."),
@@ -31,6 +38,20 @@ func init() {
 			return fmt.Sprintf("Collection: %s, Namespace: %s, Key: %s", collectionName, namespace, key)
 		}))
 
+	registerHostFunction("hypermode", "deleteFromCollectionIfVersion", collections.DeleteFromCollectionIfVersion,
+		withCancelledMessage("Cancelled deleting from collection."),
+		withErrorMessage("Error deleting from collection."),
+		withMessageDetail(func(collectionName, namespace, key string, expectedVersion int64) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, Key: %s, Version: %d", collectionName, namespace, key, expectedVersion)
+		}))
+
+	registerHostFunction("hypermode", "exportCollection", collections.ExportCollection,
+		withCancelledMessage("Cancelled exporting collection."),
+		withErrorMessage("Error exporting collection."),
+		withMessageDetail(func(collectionName, uri string) string {
+			return fmt.Sprintf("Collection: %s, URI: %s", collectionName, uri)
+		}))
+
 	registerHostFunction("hypermode", "getNamespacesFromCollection", collections.GetNamespacesFromCollection,
 		withCancelledMessage("Cancelled getting namespaces from collection."),
 		withErrorMessage("Error getting namespaces from collection."),
@@ -52,6 +73,13 @@ func init() {
 			return fmt.Sprintf("Collection: %s, Namespace: %s", collectionName, namespace)
 		}))
 
+	registerHostFunction("hypermode", "getVersionFromCollection", collections.GetVersionFromCollection,
+		withCancelledMessage("Cancelled getting version from collection."),
+		withErrorMessage("Error getting version from collection."),
+		withMessageDetail(func(collectionName, namespace, key string) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, Key: %s", collectionName, namespace, key)
+		}))
+
 	registerHostFunction("hypermode", "getVector", collections.GetVector,
 		withCancelledMessage("Cancelled getting vector from collection."),
 		withErrorMessage("Error getting vector from collection."),
@@ -66,6 +94,41 @@ func init() {
 			return fmt.Sprintf("Collection: %s, Namespace: %s, ID: %s", collectionName, namespace, id)
 		}))
 
+	registerHostFunction("hypermode", "getItemsFromCollection", collections.GetItemsFromCollection,
+		withCancelledMessage("Cancelled getting items from collection."),
+		withErrorMessage("Error getting items from collection."),
+		withMessageDetail(func(collectionName, namespace, sortBy string) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, Sort: %s", collectionName, namespace, sortBy)
+		}))
+
+	registerHostFunction("hypermode", "getMetadata", collections.GetMetadata,
+		withCancelledMessage("Cancelled getting metadata from collection."),
+		withErrorMessage("Error getting metadata from collection."),
+		withMessageDetail(func(collectionName, namespace, id string) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, ID: %s", collectionName, namespace, id)
+		}))
+
+	registerHostFunction("hypermode", "hybridSearchCollection", collections.HybridSearchCollection,
+		withCancelledMessage("Cancelled hybrid searching collection."),
+		withErrorMessage("Error hybrid searching collection."),
+		withMessageDetail(func(collectionName string, namespaces []string, searchMethod, text string, limit int32, returnText bool, fusion string) string {
+			return fmt.Sprintf("Collection: %s, Namespaces: %v, Method: %s, Fusion: %s", collectionName, namespaces, searchMethod, fusion)
+		}))
+
+	registerHostFunction("hypermode", "weightedSearchCollection", collections.WeightedSearchCollection,
+		withCancelledMessage("Cancelled weighted searching collection."),
+		withErrorMessage("Error weighted searching collection."),
+		withMessageDetail(func(collectionName string, namespaces []string, searchMethods []string, weights []float64) string {
+			return fmt.Sprintf("Collection: %s, Namespaces: %v, Methods: %v, Weights: %v", collectionName, namespaces, searchMethods, weights)
+		}))
+
+	registerHostFunction("hypermode", "importCollection", collections.ImportCollection,
+		withCancelledMessage("Cancelled importing collection."),
+		withErrorMessage("Error importing collection."),
+		withMessageDetail(func(collectionName, uri string) string {
+			return fmt.Sprintf("Collection: %s, URI: %s", collectionName, uri)
+		}))
+
 	registerHostFunction("hypermode", "nnClassifyCollection", collections.NnClassify,
 		withCancelledMessage("Cancelled classification."),
 		withErrorMessage("Error during classification."),
@@ -73,6 +136,13 @@ func init() {
 			return fmt.Sprintf("Collection: %s, Namespace: %s, Method: %s", collectionName, namespace, searchMethod)
 		}))
 
+	registerHostFunction("hypermode", "findNearDuplicates", collections.FindNearDuplicates,
+		withCancelledMessage("Cancelled finding near-duplicates in collection."),
+		withErrorMessage("Error finding near-duplicates in collection."),
+		withMessageDetail(func(collectionName, namespace, searchMethod string, threshold float64) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, Method: %s, Threshold: %v", collectionName, namespace, searchMethod, threshold)
+		}))
+
 	registerHostFunction("hypermode", "recomputeSearchMethod", collections.RecomputeSearchMethod,
 		withStartingMessage("Starting recomputing search method for collection."),
 		withCompletedMessage("Completed recomputing search method for collection."),
@@ -96,10 +166,52 @@ func init() {
 			return fmt.Sprintf("Collection: %s, Namespaces: %v, Method: %s", collectionName, namespaces, searchMethod)
 		}))
 
+	registerHostFunction("hypermode", "searchCollectionWithFilter", collections.SearchCollectionWithFilter,
+		withCancelledMessage("Cancelled searching collection."),
+		withErrorMessage("Error searching collection."),
+		withMessageDetail(func(collectionName string, namespaces []string, searchMethod, text string, limit int32, returnText bool, filter string) string {
+			return fmt.Sprintf("Collection: %s, Namespaces: %v, Method: %s, Filter: %s", collectionName, namespaces, searchMethod, filter)
+		}))
+
+	registerHostFunction("hypermode", "searchCollectionByVectorWithFilter", collections.SearchCollectionByVectorWithFilter,
+		withCancelledMessage("Cancelled searching collection by vector."),
+		withErrorMessage("Error searching collection by vector."),
+		withMessageDetail(func(collectionName string, namespaces []string, searchMethod string, vector []float32, limit int32, returnText bool, filter string) string {
+			return fmt.Sprintf("Collection: %s, Namespaces: %v, Method: %s, Filter: %s", collectionName, namespaces, searchMethod, filter)
+		}))
+
+	registerHostFunction("hypermode", "upsertBatchToCollection", collections.UpsertBatchToCollection,
+		withCancelledMessage("Cancelled collection batch upsert."),
+		withErrorMessage("Error batch upserting to collection."),
+		withMessageDetail(func(collectionName, namespace string, keys, texts []string) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, Count: %d", collectionName, namespace, len(texts))
+		}))
+
+	registerHostFunction("hypermode", "upsertBatchToCollectionWithTtl", collections.UpsertBatchToCollectionWithTtl,
+		withCancelledMessage("Cancelled collection batch upsert."),
+		withErrorMessage("Error batch upserting to collection."),
+		withMessageDetail(func(collectionName, namespace string, keys, texts []string) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, Count: %d", collectionName, namespace, len(texts))
+		}))
+
 	registerHostFunction("hypermode", "upsertToCollection", collections.UpsertToCollection,
 		withCancelledMessage("Cancelled collection upsert."),
 		withErrorMessage("Error upserting to collection."),
 		withMessageDetail(func(collectionName, namespace string, keys []string) string {
 			return fmt.Sprintf("Collection: %s, Namespace: %s, Keys: %v", collectionName, namespace, keys)
 		}))
+
+	registerHostFunction("hypermode", "upsertToCollectionWithMetadata", collections.UpsertToCollectionWithMetadata,
+		withCancelledMessage("Cancelled collection upsert."),
+		withErrorMessage("Error upserting to collection."),
+		withMessageDetail(func(collectionName, namespace string, keys []string) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, Keys: %v", collectionName, namespace, keys)
+		}))
+
+	registerHostFunction("hypermode", "upsertToCollectionIfVersion", collections.UpsertToCollectionIfVersion,
+		withCancelledMessage("Cancelled collection upsert."),
+		withErrorMessage("Error upserting to collection."),
+		withMessageDetail(func(collectionName, namespace, key, text string, labels []string, metadata string, expectedVersion int64) string {
+			return fmt.Sprintf("Collection: %s, Namespace: %s, Key: %s, Version: %d", collectionName, namespace, key, expectedVersion)
+		}))
 }
@@ -0,0 +1,17 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import "github.com/hypermodeinc/modus/runtime/appconfig"
+
+func init() {
+	registerHostFunction("hypermode", "getConfigValue", appconfig.GetConfigValue)
+	registerHostFunction("hypermode", "getConfigKeys", appconfig.GetConfigKeys)
+}
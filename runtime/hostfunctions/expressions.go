@@ -0,0 +1,27 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/expressions"
+)
+
+func init() {
+	registerHostFunction("hypermode", "evaluateExpression", expressions.Evaluate,
+		withStartingMessage("Evaluating expression."),
+		withCompletedMessage("Completed evaluating expression."),
+		withCancelledMessage("Cancelled evaluating expression."),
+		withErrorMessage("Error evaluating expression."),
+		withMessageDetail(func(expression string) string {
+			return fmt.Sprintf("Expression: %s", expression)
+		}))
+}
@@ -24,4 +24,13 @@ func init() {
 		withMessageDetail(func(request *httpclient.HttpRequest) string {
 			return fmt.Sprintf("%s %s", request.Method, request.Url)
 		}))
+
+	registerHostFunction("hypermode", "httpFetchAll", httpclient.HttpFetchAll,
+		withStartingMessage("Starting paginated HTTP requests."),
+		withCompletedMessage("Completed paginated HTTP requests."),
+		withCancelledMessage("Cancelled paginated HTTP requests."),
+		withErrorMessage("Error making paginated HTTP requests."),
+		withMessageDetail(func(request *httpclient.HttpRequest) string {
+			return fmt.Sprintf("%s %s", request.Method, request.Url)
+		}))
 }
@@ -0,0 +1,30 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/jobqueue"
+)
+
+func init() {
+	registerHostFunction("hypermode", "enqueueJob", jobqueue.Enqueue,
+		withStartingMessage("Enqueuing job."),
+		withCompletedMessage("Completed enqueuing job."),
+		withCancelledMessage("Cancelled enqueuing job."),
+		withErrorMessage("Error enqueuing job."),
+		withMessageDetail(func(req *jobqueue.JobRequest) string {
+			if req == nil {
+				return ""
+			}
+			return fmt.Sprintf("Function: %s", req.FunctionName)
+		}))
+}
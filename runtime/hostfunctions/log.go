@@ -16,31 +16,93 @@ import (
 
 	"github.com/hypermodeinc/modus/runtime/logger"
 	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/rs/zerolog"
 )
 
 func init() {
 	registerHostFunction("hypermode", "log", LogFunctionMessage)
+	registerHostFunction("hypermode", "writeLog", WriteFunctionLog)
+	registerHostFunction("hypermode", "reportError", ReportFunctionError)
 }
 
 func LogFunctionMessage(ctx context.Context, level, message string) {
+	WriteFunctionLog(ctx, level, message, nil)
+}
+
+// WriteFunctionLog logs a message from a function, along with structured fields.
+// The fields are included as attributes in the runtime's log output,
+// and are returned to the caller in the GraphQL response extensions.
+func WriteFunctionLog(ctx context.Context, level, message string, fields map[string]string) {
 
 	// store messages in the context, so we can return them to the caller
-	messages := ctx.Value(utils.FunctionMessagesContextKey).(*[]utils.LogMessage)
-	*messages = append(*messages, utils.LogMessage{
+	buffers := ctx.Value(utils.FunctionMessagesContextKey).(utils.OutputBuffers)
+	buffers.Log(utils.LogMessage{
 		Level:   level,
 		Message: message,
+		Fields:  fields,
 	})
 
 	// If debugging, write debug messages to stderr instead of the logger
 	if level == "debug" && utils.DebugModeEnabled() {
-		fmt.Fprintln(os.Stderr, message)
+		if len(fields) > 0 {
+			fmt.Fprintln(os.Stderr, message, fields)
+		} else {
+			fmt.Fprintln(os.Stderr, message)
+		}
 		return
 	}
 
 	// write to the logger
-	logger.Get(ctx).
+	l := logger.Get(ctx).
 		WithLevel(logger.ParseLevel(level)).
 		Str("text", message).
+		Bool("user_visible", true)
+
+	if len(fields) > 0 {
+		l = l.Dict("fields", zerolog.Dict().Fields(toAnyMap(fields)))
+	}
+
+	l.Msg("Message logged from function.")
+}
+
+// ReportFunctionError logs an error that a function returned, along with the code, category and details
+// that describe it to the caller.  The details are a JSON object, or empty if there are none.
+func ReportFunctionError(ctx context.Context, message, code, category, details string) {
+	extensions := make(map[string]any, 3)
+	if code != "" {
+		extensions["code"] = code
+	}
+	if category != "" {
+		extensions["category"] = category
+	}
+	if details != "" {
+		var d map[string]any
+		if err := utils.JsonDeserialize([]byte(details), &d); err != nil {
+			logger.Warn(ctx).Err(err).Msg("Error parsing the details of an error reported by a function.")
+		} else if d != nil {
+			extensions["details"] = d
+		}
+	}
+
+	buffers := ctx.Value(utils.FunctionMessagesContextKey).(utils.OutputBuffers)
+	buffers.Log(utils.LogMessage{
+		Level:      "error",
+		Message:    message,
+		Extensions: extensions,
+	})
+
+	logger.Error(ctx).
+		Str("text", message).
+		Fields(extensions).
 		Bool("user_visible", true).
-		Msg("Message logged from function.")
+		Msg("Error reported by function.")
+}
+
+func toAnyMap(m map[string]string) map[string]any {
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
 }
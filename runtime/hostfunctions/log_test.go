@@ -0,0 +1,69 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	prev := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = prev })
+	return &buf
+}
+
+func Test_WriteFunctionLog(t *testing.T) {
+	logs := captureLogs(t)
+	buffers := utils.NewOutputBuffers()
+	ctx := context.WithValue(context.Background(), utils.FunctionMessagesContextKey, buffers)
+
+	LogFunctionMessage(ctx, "info", "Starting.")
+	_, _ = buffers.StdOut().Write([]byte("printed\n"))
+	WriteFunctionLog(ctx, "warning", "Low stock.", map[string]string{"sku": "a-1", "count": "2"})
+
+	// the messages are returned to the caller in the order they were written
+	assert.Equal(t, []utils.LogMessage{
+		{Level: "info", Message: "Starting."},
+		{Message: "printed"},
+		{Level: "warning", Message: "Low stock.", Fields: map[string]string{"sku": "a-1", "count": "2"}},
+	}, buffers.Messages())
+
+	// and are written to the runtime's log, with the fields as structured attributes
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"level":"info","text":"Starting.","user_visible":true,"message":"Message logged from function."}`, lines[0])
+	assert.JSONEq(t, `{"level":"warn","text":"Low stock.","user_visible":true,"fields":{"sku":"a-1","count":"2"},"message":"Message logged from function."}`, lines[1])
+}
+
+func Test_ReportFunctionError(t *testing.T) {
+	logs := captureLogs(t)
+	buffers := utils.NewOutputBuffers()
+	ctx := context.WithValue(context.Background(), utils.FunctionMessagesContextKey, buffers)
+
+	ReportFunctionError(ctx, "Not found.", "NOT_FOUND", "user", `{"id":"42"}`)
+
+	messages := buffers.Messages()
+	require.Len(t, messages, 1)
+	assert.True(t, messages[0].IsError())
+	assert.Equal(t, map[string]any{"code": "NOT_FOUND", "category": "user", "details": map[string]any{"id": "42"}}, messages[0].Extensions)
+	assert.Contains(t, logs.String(), `"code":"NOT_FOUND"`)
+}
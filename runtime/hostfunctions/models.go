@@ -31,4 +31,73 @@ func init() {
 		withMessageDetail(func(modelName string) string {
 			return fmt.Sprintf("Model: %s", modelName)
 		}))
+
+	registerHostFunction("hypermode", "invokeModelWithTools", models.InvokeModelWithTools,
+		withStartingMessage("Invoking model with tools."),
+		withCompletedMessage("Completed model invocation with tools."),
+		withCancelledMessage("Cancelled model invocation with tools."),
+		withErrorMessage("Error invoking model with tools."),
+		withMessageDetail(func(modelName string) string {
+			return fmt.Sprintf("Model: %s", modelName)
+		}))
+
+	registerHostFunction("hypermode", "computeEmbeddings", models.ComputeEmbeddings,
+		withStartingMessage("Computing embeddings."),
+		withCompletedMessage("Completed computing embeddings."),
+		withCancelledMessage("Cancelled computing embeddings."),
+		withErrorMessage("Error computing embeddings."),
+		withMessageDetail(func(modelName string, texts []string) string {
+			return fmt.Sprintf("Model: %s, Texts: %d", modelName, len(texts))
+		}))
+
+	registerHostFunction("hypermode", "generateImages", models.GenerateImages,
+		withStartingMessage("Generating images."),
+		withCompletedMessage("Completed generating images."),
+		withCancelledMessage("Cancelled generating images."),
+		withErrorMessage("Error generating images."),
+		withMessageDetail(func(modelName string) string {
+			return fmt.Sprintf("Model: %s", modelName)
+		}))
+
+	registerHostFunction("hypermode", "rerankDocuments", models.RerankDocuments,
+		withStartingMessage("Reranking documents."),
+		withCompletedMessage("Completed reranking documents."),
+		withCancelledMessage("Cancelled reranking documents."),
+		withErrorMessage("Error reranking documents."),
+		withMessageDetail(func(modelName string) string {
+			return fmt.Sprintf("Model: %s", modelName)
+		}))
+
+	registerHostFunction("hypermode", "transcribeAudio", models.TranscribeAudio,
+		withStartingMessage("Transcribing audio."),
+		withCompletedMessage("Completed transcribing audio."),
+		withCancelledMessage("Cancelled transcribing audio."),
+		withErrorMessage("Error transcribing audio."),
+		withMessageDetail(func(modelName string) string {
+			return fmt.Sprintf("Model: %s", modelName)
+		}))
+
+	registerHostFunction("hypermode", "synthesizeSpeech", models.SynthesizeSpeech,
+		withStartingMessage("Synthesizing speech."),
+		withCompletedMessage("Completed synthesizing speech."),
+		withCancelledMessage("Cancelled synthesizing speech."),
+		withErrorMessage("Error synthesizing speech."),
+		withMessageDetail(func(modelName string) string {
+			return fmt.Sprintf("Model: %s", modelName)
+		}))
+
+	registerHostFunction("hypermode", "startModelStream", models.StartModelStream,
+		withStartingMessage("Starting model stream."),
+		withCompletedMessage("Started model stream."),
+		withCancelledMessage("Cancelled starting model stream."),
+		withErrorMessage("Error starting model stream."),
+		withMessageDetail(func(modelName string) string {
+			return fmt.Sprintf("Model: %s", modelName)
+		}))
+
+	registerHostFunction("hypermode", "readModelStream", models.ReadModelStream,
+		withErrorMessage("Error reading model stream."))
+
+	registerHostFunction("hypermode", "closeModelStream", models.CloseModelStream,
+		withErrorMessage("Error closing model stream."))
 }
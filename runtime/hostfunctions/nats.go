@@ -0,0 +1,36 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/natsclient"
+)
+
+func init() {
+	registerHostFunction("hypermode", "natsPublish", natsclient.Publish,
+		withStartingMessage("Publishing NATS message."),
+		withCompletedMessage("Completed publishing NATS message."),
+		withCancelledMessage("Cancelled publishing NATS message."),
+		withErrorMessage("Error publishing NATS message."),
+		withMessageDetail(func(hostName, subject string) string {
+			return fmt.Sprintf("Host: %s Subject: %s", hostName, subject)
+		}))
+
+	registerHostFunction("hypermode", "natsRequest", natsclient.Request,
+		withStartingMessage("Sending NATS request."),
+		withCompletedMessage("Completed NATS request."),
+		withCancelledMessage("Cancelled NATS request."),
+		withErrorMessage("Error sending NATS request."),
+		withMessageDetail(func(hostName, subject string) string {
+			return fmt.Sprintf("Host: %s Subject: %s", hostName, subject)
+		}))
+}
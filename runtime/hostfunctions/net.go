@@ -0,0 +1,36 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/netdiag"
+)
+
+func init() {
+	registerHostFunction("hypermode", "netLookup", netdiag.Lookup,
+		withStartingMessage("Resolving host name."),
+		withCompletedMessage("Completed resolving host name."),
+		withCancelledMessage("Cancelled resolving host name."),
+		withErrorMessage("Error resolving host name."),
+		withMessageDetail(func(host string) string {
+			return fmt.Sprintf("Host: %s", host)
+		}))
+
+	registerHostFunction("hypermode", "netProbe", netdiag.Probe,
+		withStartingMessage("Probing connection."),
+		withCompletedMessage("Completed probing connection."),
+		withCancelledMessage("Cancelled probing connection."),
+		withErrorMessage("Error probing connection."),
+		withMessageDetail(func(hostName string) string {
+			return fmt.Sprintf("Host: %s", hostName)
+		}))
+}
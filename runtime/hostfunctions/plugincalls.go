@@ -0,0 +1,27 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/plugincalls"
+)
+
+func init() {
+	registerHostFunction("hypermode", "callFunction", plugincalls.CallFunction,
+		withStartingMessage("Calling function of another plugin."),
+		withCompletedMessage("Completed call to function of another plugin."),
+		withCancelledMessage("Cancelled call to function of another plugin."),
+		withErrorMessage("Error calling function of another plugin."),
+		withMessageDetail(func(pluginName, fnName string) string {
+			return fmt.Sprintf("Function: %s.%s", pluginName, fnName)
+		}))
+}
@@ -0,0 +1,24 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/prompts"
+)
+
+func init() {
+	registerHostFunction("hypermode", "renderPrompt", prompts.Render,
+		withErrorMessage("Error rendering prompt."),
+		withMessageDetail(func(name string) string {
+			return fmt.Sprintf("Prompt: %s", name)
+		}))
+}
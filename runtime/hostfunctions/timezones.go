@@ -0,0 +1,41 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/timezones"
+)
+
+func init() {
+	registerHostFunction("hypermode", "getTimeZones", timezones.GetTimeZones)
+
+	registerHostFunction("hypermode", "getTimeZoneInfo", timezones.GetTimeZoneInfo,
+		withErrorMessage("Error getting time zone info."),
+		withMessageDetail(func(tz string) string {
+			return fmt.Sprintf("Time zone: %s", tz)
+		}))
+
+	registerHostFunction("hypermode", "getTimeZoneTransitions", timezones.GetTimeZoneTransitions,
+		withErrorMessage("Error getting time zone transitions."),
+		withMessageDetail(func(tz string) string {
+			return fmt.Sprintf("Time zone: %s", tz)
+		}))
+
+	registerHostFunction("hypermode", "convertTimeZone", timezones.ConvertTime,
+		withErrorMessage("Error converting time between time zones."),
+		withMessageDetail(func(input, fromTz, toTz string) string {
+			return fmt.Sprintf("From: %s, To: %s", fromTz, toTz)
+		}))
+
+	registerHostFunction("hypermode", "formatTimeInZone", timezones.FormatTime,
+		withErrorMessage("Error formatting time in time zone."))
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hostfunctions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/transforms"
+)
+
+func init() {
+	registerHostFunction("hypermode", "flattenData", transforms.Flatten,
+		withErrorMessage("Error flattening data."))
+
+	registerHostFunction("hypermode", "groupData", transforms.GroupBy,
+		withErrorMessage("Error grouping data."),
+		withMessageDetail(func(dataJson, key string) string {
+			return fmt.Sprintf("Key: %s", key)
+		}))
+
+	registerHostFunction("hypermode", "projectData", transforms.Project,
+		withErrorMessage("Error projecting data."),
+		withMessageDetail(func(dataJson string, fields []string) string {
+			return fmt.Sprintf("Fields: %s", strings.Join(fields, ", "))
+		}))
+
+	registerHostFunction("hypermode", "joinData", transforms.Join,
+		withErrorMessage("Error joining data."),
+		withMessageDetail(func(leftJson, rightJson, leftKey, rightKey, kind string) string {
+			return fmt.Sprintf("Left key: %s, Right key: %s, Kind: %s", leftKey, rightKey, kind)
+		}))
+}
@@ -89,5 +89,11 @@ func PostToHostEndpoint[TResult any](ctx context.Context, host *manifest.HTTPHos
 		return secrets.ApplyHostSecretsToHttpRequest(ctx, host, req)
 	}
 
+	release, err := AcquireRateLimit(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	return utils.PostHttp[TResult](ctx, host.Endpoint, payload, bs)
 }
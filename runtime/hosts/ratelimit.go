@@ -0,0 +1,95 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hosts
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"golang.org/x/time/rate"
+)
+
+type hostLimiter struct {
+	info   manifest.RateLimitInfo
+	tokens *rate.Limiter
+	slots  chan struct{}
+}
+
+var limiters = make(map[string]*hostLimiter)
+var limitersMutex sync.Mutex
+
+// AcquireRateLimit waits until the host's rate limit allows another request.
+// The limit is shared by all callers in the runtime, regardless of which plugin or function makes the request.
+// The returned function must be called when the request has completed.
+func AcquireRateLimit(ctx context.Context, host *manifest.HTTPHostInfo) (release func(), err error) {
+	l := getLimiter(host)
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release = func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if l.tokens != nil {
+		if err := l.tokens.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+func getLimiter(host *manifest.HTTPHostInfo) *hostLimiter {
+	limitersMutex.Lock()
+	defer limitersMutex.Unlock()
+
+	if host.RateLimit == nil {
+		delete(limiters, host.Name)
+		return nil
+	}
+
+	// Reuse the existing limiter, unless the host's limits changed when the manifest was reloaded.
+	if l, ok := limiters[host.Name]; ok && l.info == *host.RateLimit {
+		return l
+	}
+
+	l := newHostLimiter(*host.RateLimit)
+	limiters[host.Name] = l
+	return l
+}
+
+func newHostLimiter(info manifest.RateLimitInfo) *hostLimiter {
+	l := &hostLimiter{info: info}
+
+	if info.RequestsPerSecond > 0 {
+		burst := max(info.Burst, 1)
+		l.tokens = rate.NewLimiter(rate.Limit(info.RequestsPerSecond), burst)
+	}
+
+	if info.Concurrency > 0 {
+		l.slots = make(chan struct{}, info.Concurrency)
+	}
+
+	return l
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package hosts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AcquireRateLimit_NoLimit(t *testing.T) {
+	host := &manifest.HTTPHostInfo{Name: "unlimited"}
+
+	for range 100 {
+		release, err := AcquireRateLimit(context.Background(), host)
+		require.Nil(t, err)
+		release()
+	}
+}
+
+func Test_AcquireRateLimit_Concurrency(t *testing.T) {
+	host := &manifest.HTTPHostInfo{
+		Name:      "concurrency",
+		RateLimit: &manifest.RateLimitInfo{Concurrency: 2},
+	}
+
+	release1, err := AcquireRateLimit(context.Background(), host)
+	require.Nil(t, err)
+	release2, err := AcquireRateLimit(context.Background(), host)
+	require.Nil(t, err)
+
+	// a third request must wait for one of the first two to complete
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = AcquireRateLimit(ctx, host)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release1()
+	release3, err := AcquireRateLimit(context.Background(), host)
+	require.Nil(t, err)
+
+	release2()
+	release3()
+}
+
+func Test_AcquireRateLimit_RequestsPerSecond(t *testing.T) {
+	host := &manifest.HTTPHostInfo{
+		Name:      "rps",
+		RateLimit: &manifest.RateLimitInfo{RequestsPerSecond: 1, Burst: 2},
+	}
+
+	// the burst is allowed immediately
+	for range 2 {
+		release, err := AcquireRateLimit(context.Background(), host)
+		require.Nil(t, err)
+		release()
+	}
+
+	// the next request would have to wait about a second
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := AcquireRateLimit(ctx, host)
+	assert.NotNil(t, err)
+
+	// changing the limits replaces the limiter
+	host.RateLimit = &manifest.RateLimitInfo{RequestsPerSecond: 1, Burst: 3}
+	release, err := AcquireRateLimit(context.Background(), host)
+	require.Nil(t, err)
+	release()
+}
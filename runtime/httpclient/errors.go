@@ -0,0 +1,148 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error codes returned to the guest in HttpError.Code
+const (
+	ErrorCodeBadRequest       = "bad_request"
+	ErrorCodeUnauthorized     = "unauthorized"
+	ErrorCodeForbidden        = "forbidden"
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodeConflict         = "conflict"
+	ErrorCodeRateLimited      = "rate_limited"
+	ErrorCodeClientError      = "client_error"
+	ErrorCodeServerError      = "server_error"
+	ErrorCodeUnavailable      = "unavailable"
+	ErrorCodeTimeout          = "timeout"
+	ErrorCodeDnsFailure       = "dns_failure"
+	ErrorCodeConnectionFailed = "connection_failed"
+	ErrorCodeCancelled        = "cancelled"
+)
+
+// maxBodySnippetLength is the maximum number of bytes of the response body included in an HttpError.
+const maxBodySnippetLength = 1024
+
+// Headers from an error response that are useful to the guest when deciding how to handle the error.
+var errorHeaderNames = []string{
+	"content-type",
+	"retry-after",
+	"www-authenticate",
+	"x-request-id",
+}
+
+func newStatusError(resp *HttpResponse) *HttpError {
+	code, retryable := classifyStatus(resp.Status)
+
+	headers := make(map[string]*HttpHeader)
+	if resp.Headers != nil {
+		for name, header := range resp.Headers.Data {
+			if isErrorHeader(name) {
+				headers[name] = header
+			}
+		}
+	}
+
+	return &HttpError{
+		Code:        code,
+		Message:     resp.StatusText,
+		Status:      resp.Status,
+		Headers:     &HttpHeaders{Data: headers},
+		BodySnippet: getBodySnippet(resp.Body),
+		Retryable:   retryable,
+	}
+}
+
+func newTransportError(err error) *HttpError {
+	code := ErrorCodeConnectionFailed
+	retryable := true
+
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		code, retryable = ErrorCodeCancelled, false
+	case errors.Is(err, context.DeadlineExceeded):
+		code = ErrorCodeTimeout
+	case errors.As(err, &dnsErr):
+		code, retryable = ErrorCodeDnsFailure, dnsErr.IsTemporary || dnsErr.IsTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		code = ErrorCodeTimeout
+	}
+
+	return &HttpError{
+		Code:      code,
+		Message:   err.Error(),
+		Retryable: retryable,
+	}
+}
+
+func classifyStatus(status uint16) (code string, retryable bool) {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrorCodeBadRequest, false
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized, false
+	case http.StatusForbidden:
+		return ErrorCodeForbidden, false
+	case http.StatusNotFound:
+		return ErrorCodeNotFound, false
+	case http.StatusConflict:
+		return ErrorCodeConflict, false
+	case http.StatusRequestTimeout:
+		return ErrorCodeTimeout, true
+	case http.StatusTooEarly:
+		return ErrorCodeClientError, true
+	case http.StatusTooManyRequests:
+		return ErrorCodeRateLimited, true
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return ErrorCodeUnavailable, true
+	case http.StatusGatewayTimeout:
+		return ErrorCodeTimeout, true
+	}
+
+	if status >= 500 {
+		return ErrorCodeServerError, true
+	}
+	return ErrorCodeClientError, false
+}
+
+func isErrorHeader(name string) bool {
+	if strings.HasPrefix(name, "x-ratelimit-") {
+		return true
+	}
+	for _, n := range errorHeaderNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+func getBodySnippet(body []byte) string {
+	if len(body) <= maxBodySnippetLength {
+		return strings.ToValidUTF8(string(body), "")
+	}
+
+	// don't cut a multi-byte character in half
+	n := maxBodySnippetLength
+	for n > 0 && !utf8.RuneStart(body[n]) {
+		n--
+	}
+	return strings.ToValidUTF8(string(body[:n]), "") + "..."
+}
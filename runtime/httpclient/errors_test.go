@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewStatusError(t *testing.T) {
+	resp := &HttpResponse{
+		Status:     429,
+		StatusText: "Too Many Requests",
+		Headers: &HttpHeaders{Data: map[string]*HttpHeader{
+			"retry-after":           {Name: "Retry-After", Values: []string{"30"}},
+			"x-ratelimit-remaining": {Name: "X-RateLimit-Remaining", Values: []string{"0"}},
+			"set-cookie":            {Name: "Set-Cookie", Values: []string{"secret"}},
+		}},
+		Body: []byte(strings.Repeat("x", 2000)),
+	}
+
+	e := newStatusError(resp)
+	assert.Equal(t, ErrorCodeRateLimited, e.Code)
+	assert.Equal(t, uint16(429), e.Status)
+	assert.True(t, e.Retryable)
+	assert.Contains(t, e.Headers.Data, "retry-after")
+	assert.Contains(t, e.Headers.Data, "x-ratelimit-remaining")
+	assert.NotContains(t, e.Headers.Data, "set-cookie")
+	assert.Equal(t, maxBodySnippetLength+3, len(e.BodySnippet))
+}
+
+func Test_ClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status    uint16
+		code      string
+		retryable bool
+	}{
+		{400, ErrorCodeBadRequest, false},
+		{401, ErrorCodeUnauthorized, false},
+		{404, ErrorCodeNotFound, false},
+		{418, ErrorCodeClientError, false},
+		{500, ErrorCodeServerError, true},
+		{503, ErrorCodeUnavailable, true},
+		{504, ErrorCodeTimeout, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.status), func(t *testing.T) {
+			code, retryable := classifyStatus(tt.status)
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.retryable, retryable)
+		})
+	}
+}
+
+func Test_NewTransportError(t *testing.T) {
+	e := newTransportError(fmt.Errorf("request failed: %w", context.DeadlineExceeded))
+	assert.Equal(t, ErrorCodeTimeout, e.Code)
+	assert.True(t, e.Retryable)
+
+	e = newTransportError(fmt.Errorf("request failed: %w", context.Canceled))
+	assert.Equal(t, ErrorCodeCancelled, e.Code)
+	assert.False(t, e.Retryable)
+
+	e = newTransportError(&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true})
+	assert.Equal(t, ErrorCodeDnsFailure, e.Code)
+	assert.False(t, e.Retryable)
+}
@@ -16,6 +16,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/hypermodeinc/modus/lib/manifest"
 	"github.com/hypermodeinc/modus/runtime/hosts"
 	"github.com/hypermodeinc/modus/runtime/secrets"
 	"github.com/hypermodeinc/modus/runtime/utils"
@@ -27,6 +28,12 @@ func HttpFetch(ctx context.Context, request *HttpRequest) (*HttpResponse, error)
 		return nil, err
 	}
 
+	return HttpFetchFromHost(ctx, host, request)
+}
+
+// HttpFetchFromHost makes an HTTP request using the configuration and secrets of the given host.
+// The caller is responsible for ensuring the request URL belongs to the host.
+func HttpFetchFromHost(ctx context.Context, host *manifest.HTTPHostInfo, request *HttpRequest) (*HttpResponse, error) {
 	body := bytes.NewBuffer(request.Body)
 	req, err := http.NewRequestWithContext(ctx, request.Method, request.Url, body)
 	if err != nil {
@@ -43,13 +50,31 @@ func HttpFetch(ctx context.Context, request *HttpRequest) (*HttpResponse, error)
 		return nil, err
 	}
 
-	resp, err := utils.HttpClient().Do(req)
+	if host.Cache != nil {
+		return doCachedRequest(ctx, host, req)
+	}
+
+	return doRequest(ctx, host, req)
+}
+
+func doRequest(ctx context.Context, host *manifest.HTTPHostInfo, req *http.Request) (*HttpResponse, error) {
+	release, err := hosts.AcquireRateLimit(ctx, host)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
+
+	resp, err := utils.HttpClient().Do(req)
+	if err != nil {
+		// Pass transport errors back to the caller as a structured error, rather than failing the host function.
+		return &HttpResponse{
+			Headers: &HttpHeaders{Data: map[string]*HttpHeader{}},
+			Error:   newTransportError(err),
+		}, nil
+	}
 	defer resp.Body.Close()
 
-	// Don't check status code here, just pass it back to the caller.
+	// Don't fail on the status code here, just pass it back to the caller.
 
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -72,5 +97,9 @@ func HttpFetch(ctx context.Context, request *HttpRequest) (*HttpResponse, error)
 		Body:       content,
 	}
 
+	if resp.StatusCode >= 400 {
+		response.Error = newStatusError(response)
+	}
+
 	return response, nil
 }
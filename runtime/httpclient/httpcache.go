@@ -0,0 +1,309 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/logger"
+)
+
+// cacheStatusHeader is added to responses from hosts that have caching enabled,
+// to indicate whether the response came from the cache.
+const cacheStatusHeader = "X-Modus-Cache"
+
+const (
+	cacheStatusHit         = "HIT"
+	cacheStatusMiss        = "MISS"
+	cacheStatusRevalidated = "REVALIDATED"
+)
+
+// staleRetention is how long a response that can be revalidated is kept after it is no longer fresh.
+const staleRetention = 1 * time.Hour
+
+// Status codes whose responses may be cached, per RFC 9110 section 15.1.
+var cacheableStatusCodes = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+type cachedResponse struct {
+	Status     uint16              `json:"status"`
+	StatusText string              `json:"statusText"`
+	Headers    map[string][]string `json:"headers"`
+	Body       []byte              `json:"body"`
+	FreshUntil time.Time           `json:"freshUntil"`
+	Vary       map[string]string   `json:"vary,omitempty"`
+}
+
+func doCachedRequest(ctx context.Context, host *manifest.HTTPHostInfo, req *http.Request) (*HttpResponse, error) {
+	// Requests that change state invalidate any cached response for the same URL.
+	if req.Method != http.MethodGet {
+		resp, err := doRequest(ctx, host, req)
+		if err == nil && resp.Status >= 200 && resp.Status < 400 && req.Method != http.MethodHead {
+			if store, err := getResponseStore(ctx, host); err == nil {
+				store.delete(ctx, getCacheKey(host, req))
+			}
+		}
+		return resp, err
+	}
+
+	reqCC := parseCacheControl(req.Header.Values("Cache-Control"))
+	if _, ok := reqCC["no-store"]; ok {
+		return doRequest(ctx, host, req)
+	}
+
+	store, err := getResponseStore(ctx, host)
+	if err != nil {
+		logger.Warn(ctx).Err(err).Str("host", host.Name).Msg("HTTP cache is unavailable.")
+		return doRequest(ctx, host, req)
+	}
+
+	key := getCacheKey(host, req)
+	entry, found := store.get(ctx, key)
+	if found && !entry.matchesVary(req) {
+		found = false
+	}
+
+	if found {
+		_, noCache := reqCC["no-cache"]
+		if !noCache && time.Now().Before(entry.FreshUntil) {
+			return entry.toHttpResponse(cacheStatusHit), nil
+		}
+
+		// Revalidate the stale response with the origin, if possible.
+		etag := http.Header(entry.Headers).Get("ETag")
+		lastModified := http.Header(entry.Headers).Get("Last-Modified")
+		if etag == "" && lastModified == "" {
+			found = false
+		} else {
+			req = req.Clone(ctx)
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				req.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	resp, err := doRequest(ctx, host, req)
+	if err != nil || resp.Status == 0 {
+		return resp, err
+	}
+
+	if found && resp.Status == http.StatusNotModified {
+		// Update the stored headers with those from the 304 response, and serve the stored body.
+		h := http.Header(entry.Headers)
+		for _, header := range resp.Headers.Data {
+			if !strings.EqualFold(header.Name, "Content-Length") {
+				h[http.CanonicalHeaderKey(header.Name)] = header.Values
+			}
+		}
+		if !isSharedCacheable(req, h) {
+			store.delete(ctx, key)
+		} else if ttl, ok := getFreshness(h, time.Now(), host.Cache.MaxTtl); ok {
+			entry.FreshUntil = time.Now().Add(ttl)
+			store.set(ctx, key, entry, ttl+staleRetention)
+		}
+		return entry.toHttpResponse(cacheStatusRevalidated), nil
+	}
+
+	if cacheableStatusCodes[int(resp.Status)] {
+		h := resp.getHttpHeader()
+		if ttl, ok := getFreshness(h, time.Now(), host.Cache.MaxTtl); ok && isSharedCacheable(req, h) {
+			entry := &cachedResponse{
+				Status:     resp.Status,
+				StatusText: resp.StatusText,
+				Headers:    h,
+				Body:       resp.Body,
+				FreshUntil: time.Now().Add(ttl),
+				Vary:       getVaryValues(h, req),
+			}
+
+			storeTtl := ttl
+			if h.Get("ETag") != "" || h.Get("Last-Modified") != "" {
+				storeTtl += staleRetention
+			}
+			store.set(ctx, key, entry, storeTtl)
+		}
+	}
+
+	resp.setHeader(cacheStatusHeader, cacheStatusMiss)
+	return resp, nil
+}
+
+// getCacheKey returns the key for cached responses to the request.
+// It is hashed, because the URL may contain secrets from the host's query parameters.
+func getCacheKey(host *manifest.HTTPHostInfo, req *http.Request) string {
+	hash := sha256.Sum256([]byte(host.Name + "|" + req.URL.String()))
+	return "modus:httpcache:" + hex.EncodeToString(hash[:])
+}
+
+// isSharedCacheable reports whether the response to the request may be stored in the cache, which is shared by
+// all callers, and across runtimes with the Redis store.  Per RFC 9111 sections 3.5 and 5.2.2.7, responses marked
+// private are never stored, nor are responses to requests with an Authorization header, unless the response
+// explicitly allows it.
+func isSharedCacheable(req *http.Request, h http.Header) bool {
+	cc := parseCacheControl(h.Values("Cache-Control"))
+	if _, ok := cc["private"]; ok {
+		return false
+	}
+
+	if req.Header.Get("Authorization") == "" {
+		return true
+	}
+	for _, directive := range []string{"public", "s-maxage", "must-revalidate"} {
+		if _, ok := cc[directive]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getFreshness returns how long a response with the given headers is fresh,
+// and whether it may be stored at all.
+func getFreshness(h http.Header, now time.Time, maxTtl int) (time.Duration, bool) {
+	cc := parseCacheControl(h.Values("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return 0, false
+	}
+	if strings.TrimSpace(h.Get("Vary")) == "*" {
+		return 0, false
+	}
+
+	var ttl time.Duration
+	if _, ok := cc["no-cache"]; ok {
+		ttl = 0
+	} else if v, ok := cc["s-maxage"]; ok {
+		ttl = parseSeconds(v)
+	} else if v, ok := cc["max-age"]; ok {
+		ttl = parseSeconds(v)
+	} else if expires := h.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			date := now
+			if d, err := http.ParseTime(h.Get("Date")); err == nil {
+				date = d
+			}
+			ttl = t.Sub(date)
+		}
+	}
+
+	if age := h.Get("Age"); age != "" {
+		ttl -= parseSeconds(age)
+	}
+
+	if maxTtl > 0 {
+		ttl = min(ttl, time.Duration(maxTtl)*time.Second)
+	}
+
+	if ttl > 0 {
+		return ttl, true
+	}
+
+	// A response that isn't fresh may still be stored, if it can be revalidated.
+	canRevalidate := h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+	return 0, canRevalidate
+}
+
+func parseCacheControl(values []string) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			k, v, _ := strings.Cut(part, "=")
+			directives[strings.ToLower(strings.TrimSpace(k))] = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return directives
+}
+
+func parseSeconds(s string) time.Duration {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+func getVaryValues(h http.Header, req *http.Request) map[string]string {
+	var values map[string]string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]string)
+			}
+			values[name] = strings.Join(req.Header.Values(name), ",")
+		}
+	}
+	return values
+}
+
+func (r *cachedResponse) matchesVary(req *http.Request) bool {
+	for name, value := range r.Vary {
+		if strings.Join(req.Header.Values(name), ",") != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *cachedResponse) toHttpResponse(cacheStatus string) *HttpResponse {
+	headers := make(map[string]*HttpHeader, len(r.Headers)+1)
+	for name, values := range r.Headers {
+		headers[strings.ToLower(name)] = &HttpHeader{
+			Name:   name,
+			Values: values,
+		}
+	}
+
+	resp := &HttpResponse{
+		Status:     r.Status,
+		StatusText: r.StatusText,
+		Headers:    &HttpHeaders{Data: headers},
+		Body:       r.Body,
+	}
+	resp.setHeader(cacheStatusHeader, cacheStatus)
+
+	if resp.Status >= 400 {
+		resp.Error = newStatusError(resp)
+	}
+
+	return resp
+}
+
+func (r *HttpResponse) getHttpHeader() http.Header {
+	h := make(http.Header, len(r.Headers.Data))
+	for _, header := range r.Headers.Data {
+		h[http.CanonicalHeaderKey(header.Name)] = header.Values
+	}
+	return h
+}
+
+func (r *HttpResponse) setHeader(name, value string) {
+	r.Headers.Data[strings.ToLower(name)] = &HttpHeader{
+		Name:   name,
+		Values: []string{value},
+	}
+}
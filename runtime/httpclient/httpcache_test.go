@@ -0,0 +1,231 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCachingServer(t *testing.T) (*manifest.HTTPHostInfo, *atomic.Int32) {
+	var hits atomic.Int32
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/fresh", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, "fresh %d", hits.Load())
+	})
+
+	mux.HandleFunc("/etag", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, "etag body")
+	})
+
+	mux.HandleFunc("/vary", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprintf(w, "hello in %s", r.Header.Get("Accept-Language"))
+	})
+
+	mux.HandleFunc("/nostore", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "no-store, max-age=60")
+		fmt.Fprint(w, "secret")
+	})
+
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "private, max-age=60")
+		fmt.Fprintf(w, "private %d", hits.Load())
+	})
+
+	mux.HandleFunc("/public", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		fmt.Fprintf(w, "public %d", hits.Load())
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	t.Cleanup(memoryStore.cache.Clear)
+
+	secrets.Initialize(context.Background())
+
+	host := &manifest.HTTPHostInfo{
+		Name:    "cached",
+		Type:    manifest.HostTypeHTTP,
+		BaseURL: server.URL + "/",
+		Cache:   &manifest.HttpCacheInfo{Store: manifest.HttpCacheStoreMemory},
+	}
+	return host, &hits
+}
+
+func fetchFromCachedHost(t *testing.T, host *manifest.HTTPHostInfo, path string, headers map[string]string) *HttpResponse {
+	req := &HttpRequest{
+		Url:     host.BaseURL + path,
+		Method:  http.MethodGet,
+		Headers: &HttpHeaders{Data: map[string]*HttpHeader{}},
+	}
+	for name, value := range headers {
+		req.Headers.Data[name] = &HttpHeader{Name: name, Values: []string{value}}
+	}
+
+	resp, err := HttpFetchFromHost(context.Background(), host, req)
+	require.Nil(t, err)
+	require.Nil(t, resp.Error)
+	return resp
+}
+
+func getCacheStatus(resp *HttpResponse) string {
+	if h, ok := resp.Headers.Data["x-modus-cache"]; ok {
+		return h.Values[0]
+	}
+	return ""
+}
+
+func Test_HttpCache_Fresh(t *testing.T) {
+	host, hits := setupCachingServer(t)
+
+	resp := fetchFromCachedHost(t, host, "fresh", nil)
+	assert.Equal(t, "fresh 1", string(resp.Body))
+	assert.Equal(t, cacheStatusMiss, getCacheStatus(resp))
+
+	resp = fetchFromCachedHost(t, host, "fresh", nil)
+	assert.Equal(t, "fresh 1", string(resp.Body))
+	assert.Equal(t, cacheStatusHit, getCacheStatus(resp))
+	assert.Equal(t, int32(1), hits.Load())
+
+	// the request can ask to bypass the cache
+	resp = fetchFromCachedHost(t, host, "fresh", map[string]string{"Cache-Control": "no-store"})
+	assert.Equal(t, "fresh 2", string(resp.Body))
+}
+
+func Test_HttpCache_Revalidate(t *testing.T) {
+	host, hits := setupCachingServer(t)
+
+	resp := fetchFromCachedHost(t, host, "etag", nil)
+	assert.Equal(t, "etag body", string(resp.Body))
+	assert.Equal(t, cacheStatusMiss, getCacheStatus(resp))
+
+	resp = fetchFromCachedHost(t, host, "etag", nil)
+	assert.Equal(t, uint16(200), resp.Status)
+	assert.Equal(t, "etag body", string(resp.Body))
+	assert.Equal(t, cacheStatusRevalidated, getCacheStatus(resp))
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func Test_HttpCache_Vary(t *testing.T) {
+	host, hits := setupCachingServer(t)
+
+	resp := fetchFromCachedHost(t, host, "vary", map[string]string{"Accept-Language": "en"})
+	assert.Equal(t, "hello in en", string(resp.Body))
+
+	resp = fetchFromCachedHost(t, host, "vary", map[string]string{"Accept-Language": "fr"})
+	assert.Equal(t, "hello in fr", string(resp.Body))
+	assert.Equal(t, cacheStatusMiss, getCacheStatus(resp))
+	assert.Equal(t, int32(2), hits.Load())
+
+	resp = fetchFromCachedHost(t, host, "vary", map[string]string{"Accept-Language": "fr"})
+	assert.Equal(t, "hello in fr", string(resp.Body))
+	assert.Equal(t, cacheStatusHit, getCacheStatus(resp))
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func Test_HttpCache_NoStore(t *testing.T) {
+	host, hits := setupCachingServer(t)
+
+	fetchFromCachedHost(t, host, "nostore", nil)
+	resp := fetchFromCachedHost(t, host, "nostore", nil)
+	assert.Equal(t, cacheStatusMiss, getCacheStatus(resp))
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func Test_HttpCache_Private(t *testing.T) {
+	host, hits := setupCachingServer(t)
+
+	fetchFromCachedHost(t, host, "private", nil)
+	resp := fetchFromCachedHost(t, host, "private", nil)
+	assert.Equal(t, "private 2", string(resp.Body))
+	assert.Equal(t, cacheStatusMiss, getCacheStatus(resp))
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func Test_HttpCache_Authorization(t *testing.T) {
+	host, hits := setupCachingServer(t)
+
+	// responses to authorized requests are not stored, unless the response allows it
+	fetchFromCachedHost(t, host, "fresh", map[string]string{"Authorization": "Bearer alice"})
+	resp := fetchFromCachedHost(t, host, "fresh", map[string]string{"Authorization": "Bearer bob"})
+	assert.Equal(t, "fresh 2", string(resp.Body))
+	assert.Equal(t, cacheStatusMiss, getCacheStatus(resp))
+	assert.Equal(t, int32(2), hits.Load())
+
+	resp = fetchFromCachedHost(t, host, "fresh", nil)
+	assert.Equal(t, "fresh 3", string(resp.Body))
+	assert.Equal(t, cacheStatusMiss, getCacheStatus(resp))
+
+	fetchFromCachedHost(t, host, "public", map[string]string{"Authorization": "Bearer alice"})
+	resp = fetchFromCachedHost(t, host, "public", map[string]string{"Authorization": "Bearer bob"})
+	assert.Equal(t, "public 4", string(resp.Body))
+	assert.Equal(t, cacheStatusHit, getCacheStatus(resp))
+	assert.Equal(t, int32(4), hits.Load())
+}
+
+func Test_GetFreshness(t *testing.T) {
+	now := time.Now()
+
+	ttl, ok := getFreshness(http.Header{"Cache-Control": {"public, max-age=120"}}, now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 120*time.Second, ttl)
+
+	ttl, ok = getFreshness(http.Header{"Cache-Control": {"max-age=120, s-maxage=30"}}, now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, ttl)
+
+	ttl, ok = getFreshness(http.Header{"Cache-Control": {"max-age=120"}, "Age": {"100"}}, now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, ttl)
+
+	ttl, ok = getFreshness(http.Header{"Cache-Control": {"max-age=120"}}, now, 10)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, ttl)
+
+	ttl, ok = getFreshness(http.Header{
+		"Date":    {now.UTC().Format(http.TimeFormat)},
+		"Expires": {now.Add(time.Hour).UTC().Format(http.TimeFormat)},
+	}, now, 0)
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Second))
+
+	_, ok = getFreshness(http.Header{}, now, 0)
+	assert.False(t, ok)
+
+	_, ok = getFreshness(http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}}, now, 0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/cache"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxMemoryCacheSize is the total size of the responses kept in memory, for all hosts.
+const maxMemoryCacheSize = 64 * 1024 * 1024
+
+type responseStore interface {
+	get(ctx context.Context, key string) (*cachedResponse, bool)
+	set(ctx context.Context, key string, r *cachedResponse, ttl time.Duration)
+	delete(ctx context.Context, key string)
+}
+
+var memoryStore = &memoryResponseStore{
+	cache: cache.New(maxMemoryCacheSize, func(r *cachedResponse) int64 {
+		size := int64(len(r.Body))
+		for name, values := range r.Headers {
+			size += int64(len(name))
+			for _, v := range values {
+				size += int64(len(v))
+			}
+		}
+		return size
+	}),
+}
+
+var redisClients = make(map[string]*redis.Client)
+var redisClientsMutex sync.Mutex
+
+func Initialize() {
+	// Close Redis connections when the manifest is reloaded, as hosts may have changed.
+	manifestdata.RegisterManifestLoadedCallback(func(ctx context.Context) error {
+		ShutdownCacheConns()
+		return nil
+	})
+}
+
+func getResponseStore(ctx context.Context, host *manifest.HTTPHostInfo) (responseStore, error) {
+	switch host.Cache.Store {
+	case manifest.HttpCacheStoreMemory, "":
+		return memoryStore, nil
+	case manifest.HttpCacheStoreRedis:
+		if host.Cache.RedisUrl == "" {
+			return nil, errors.New("a redis url is required for the redis cache store")
+		}
+		redisUrl, err := secrets.ApplyHostSecretsToString(ctx, host, host.Cache.RedisUrl)
+		if err != nil {
+			return nil, err
+		}
+		return getRedisStore(redisUrl)
+	default:
+		return nil, errors.New("unknown cache store: " + host.Cache.Store)
+	}
+}
+
+type memoryResponseStore struct {
+	cache *cache.Cache[*cachedResponse]
+}
+
+func (s *memoryResponseStore) get(ctx context.Context, key string) (*cachedResponse, bool) {
+	r, ok := s.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	// Return a copy, so that updates during revalidation don't race with other readers.
+	c := *r
+	c.Headers = maps.Clone(r.Headers)
+	return &c, true
+}
+
+func (s *memoryResponseStore) set(ctx context.Context, key string, r *cachedResponse, ttl time.Duration) {
+	s.cache.Set(key, r, ttl)
+}
+
+func (s *memoryResponseStore) delete(ctx context.Context, key string) {
+	s.cache.Delete(key)
+}
+
+type redisResponseStore struct {
+	client *redis.Client
+}
+
+func getRedisStore(redisUrl string) (*redisResponseStore, error) {
+	redisClientsMutex.Lock()
+	defer redisClientsMutex.Unlock()
+
+	if client, ok := redisClients[redisUrl]; ok {
+		return &redisResponseStore{client}, nil
+	}
+
+	opts, err := redis.ParseURL(redisUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(opts)
+	redisClients[redisUrl] = client
+	return &redisResponseStore{client}, nil
+}
+
+func ShutdownCacheConns() {
+	redisClientsMutex.Lock()
+	defer redisClientsMutex.Unlock()
+
+	for _, client := range redisClients {
+		_ = client.Close()
+	}
+	clear(redisClients)
+}
+
+func (s *redisResponseStore) get(ctx context.Context, key string) (*cachedResponse, bool) {
+	data, err := s.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Warn(ctx).Err(err).Msg("Failed to read from the HTTP cache.")
+		}
+		return nil, false
+	}
+
+	var r cachedResponse
+	if err := utils.JsonDeserialize(data, &r); err != nil {
+		logger.Warn(ctx).Err(err).Msg("Failed to read from the HTTP cache.")
+		return nil, false
+	}
+	return &r, true
+}
+
+func (s *redisResponseStore) set(ctx context.Context, key string, r *cachedResponse, ttl time.Duration) {
+	data, err := utils.JsonSerialize(r)
+	if err != nil {
+		logger.Warn(ctx).Err(err).Msg("Failed to write to the HTTP cache.")
+		return
+	}
+
+	if err := s.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		logger.Warn(ctx).Err(err).Msg("Failed to write to the HTTP cache.")
+	}
+}
+
+func (s *redisResponseStore) delete(ctx context.Context, key string) {
+	if err := s.client.Del(ctx, key).Err(); err != nil {
+		logger.Warn(ctx).Err(err).Msg("Failed to delete from the HTTP cache.")
+	}
+}
@@ -0,0 +1,242 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// Pagination strategies supported by HttpFetchAll
+const (
+	PaginationCursor = "cursor"
+	PaginationPage   = "page"
+	PaginationLink   = "link"
+)
+
+const defaultMaxPages = 100
+const maxMaxPages = 1000
+const maxPagedBodySize = 64 * 1024 * 1024
+
+type PaginationOptions struct {
+	// Strategy is one of "cursor", "page", or "link".
+	Strategy string
+
+	// ItemsPath is the path to the array of items in each response body (ex: "data.items").
+	// If empty, the response body itself must be an array.
+	ItemsPath string
+
+	// CursorParam is the query parameter used to send the cursor (cursor strategy only).
+	CursorParam string
+
+	// CursorPath is the path to the next cursor in each response body (cursor strategy only).
+	CursorPath string
+
+	// PageParam is the query parameter used to send the page number (page strategy only).
+	PageParam string
+
+	// StartPage is the number of the first page (page strategy only). Defaults to 1.
+	StartPage int32
+
+	// PageSizeParam and PageSize optionally set the number of items requested per page.
+	PageSizeParam string
+	PageSize      int32
+
+	// MaxPages and MaxItems limit the total number of pages requested, and items returned.
+	MaxPages int32
+	MaxItems int32
+}
+
+type HttpPagedResponse struct {
+	// Items is a JSON array containing the items from all of the pages.
+	Items []byte
+
+	// Pages is the number of pages that were fetched.
+	Pages int32
+
+	// Truncated is true if more pages may be available, but a limit was reached.
+	Truncated bool
+
+	// Error is set if a page request failed.  Items fetched before the failure are still returned.
+	Error *HttpError
+}
+
+// HttpFetchAll fetches all pages of a paginated REST API, starting from the given request,
+// and returns the items from all pages concatenated into a single JSON array.
+func HttpFetchAll(ctx context.Context, request *HttpRequest, options *PaginationOptions) (*HttpPagedResponse, error) {
+	if err := validatePaginationOptions(options); err != nil {
+		return nil, err
+	}
+
+	maxPages := int(options.MaxPages)
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	} else if maxPages > maxMaxPages {
+		maxPages = maxMaxPages
+	}
+
+	page := int(options.StartPage)
+	if page == 0 {
+		page = 1
+	}
+
+	pageUrl, err := url.Parse(request.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if options.PageSizeParam != "" && options.PageSize > 0 {
+		setQueryParam(pageUrl, options.PageSizeParam, strconv.Itoa(int(options.PageSize)))
+	}
+	if options.Strategy == PaginationPage {
+		setQueryParam(pageUrl, options.PageParam, strconv.Itoa(page))
+	}
+
+	result := &HttpPagedResponse{}
+	items := bytes.NewBufferString("[")
+	numItems := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if int(result.Pages) >= maxPages {
+			result.Truncated = true
+			break
+		}
+
+		req := *request
+		req.Url = pageUrl.String()
+		resp, err := HttpFetch(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		result.Pages++
+
+		if resp.Error != nil {
+			result.Error = resp.Error
+			break
+		}
+
+		var pageItems []gjson.Result
+		if options.ItemsPath == "" {
+			pageItems = gjson.ParseBytes(resp.Body).Array()
+		} else {
+			pageItems = gjson.GetBytes(resp.Body, options.ItemsPath).Array()
+		}
+
+		limitReached := false
+		for _, item := range pageItems {
+			if options.MaxItems > 0 && numItems >= int(options.MaxItems) {
+				limitReached = true
+				break
+			}
+			if items.Len()+len(item.Raw) > maxPagedBodySize {
+				limitReached = true
+				break
+			}
+			if numItems > 0 {
+				items.WriteByte(',')
+			}
+			items.WriteString(item.Raw)
+			numItems++
+		}
+		if limitReached {
+			result.Truncated = true
+			break
+		}
+
+		// determine the next page, if any
+		var next string
+		switch options.Strategy {
+		case PaginationCursor:
+			cursor := gjson.GetBytes(resp.Body, options.CursorPath)
+			if cursor.Exists() && cursor.Type != gjson.Null && cursor.String() != "" {
+				setQueryParam(pageUrl, options.CursorParam, cursor.String())
+				next = pageUrl.String()
+			}
+		case PaginationPage:
+			if len(pageItems) > 0 && (options.PageSize <= 0 || len(pageItems) >= int(options.PageSize)) {
+				page++
+				setQueryParam(pageUrl, options.PageParam, strconv.Itoa(page))
+				next = pageUrl.String()
+			}
+		case PaginationLink:
+			if h, ok := resp.Headers.Data["link"]; ok {
+				if link := getNextLink(h.Values); link != "" {
+					if u, err := pageUrl.Parse(link); err == nil {
+						next = u.String()
+					}
+				}
+			}
+		}
+
+		if next == "" {
+			break
+		}
+
+		pageUrl, err = url.Parse(next)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next page url: %w", err)
+		}
+	}
+
+	items.WriteByte(']')
+	result.Items = items.Bytes()
+	return result, nil
+}
+
+func validatePaginationOptions(options *PaginationOptions) error {
+	if options == nil {
+		return fmt.Errorf("pagination options are required")
+	}
+
+	switch options.Strategy {
+	case PaginationCursor:
+		if options.CursorParam == "" || options.CursorPath == "" {
+			return fmt.Errorf("cursor pagination requires both a cursor parameter and a cursor path")
+		}
+	case PaginationPage:
+		if options.PageParam == "" {
+			return fmt.Errorf("page pagination requires a page parameter")
+		}
+	case PaginationLink:
+	default:
+		return fmt.Errorf("unsupported pagination strategy: %q", options.Strategy)
+	}
+
+	return nil
+}
+
+func setQueryParam(u *url.URL, name, value string) {
+	q := u.Query()
+	q.Set(name, value)
+	u.RawQuery = q.Encode()
+}
+
+// linkNextRegex matches a link whose relation types include "next" as a whole token, such as rel="next"
+// or rel="prev next", but not rel="next-page".
+var linkNextRegex = regexp.MustCompile(`<([^>]*)>\s*;[^,]*\brel="?(?:[^",;]*\s)?next(?:[\s";,]|$)`)
+
+// getNextLink returns the URL of the "next" relation from RFC 8288 Link header values.
+func getNextLink(values []string) string {
+	for _, v := range values {
+		if m := linkNextRegex.FindStringSubmatch(v); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
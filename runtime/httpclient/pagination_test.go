@@ -0,0 +1,147 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPagingServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	// cursor pagination: three pages, cursor in the body
+	mux.HandleFunc("/cursor", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("after") {
+		case "":
+			fmt.Fprint(w, `{"data":[1,2],"next":"a"}`)
+		case "a":
+			fmt.Fprint(w, `{"data":[3,4],"next":"b"}`)
+		default:
+			fmt.Fprint(w, `{"data":[5],"next":null}`)
+		}
+	})
+
+	// page pagination: pages of two items, until empty
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		switch page {
+		case 1:
+			fmt.Fprint(w, `["a","b"]`)
+		case 2:
+			fmt.Fprint(w, `["c"]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+
+	// link header pagination
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("p") == "" {
+			w.Header().Set("Link", `</link?p=2>; rel="next", </link?p=9>; rel="last"`)
+			fmt.Fprint(w, `[{"id":1}]`)
+		} else {
+			fmt.Fprint(w, `[{"id":2}]`)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	secrets.Initialize(context.Background())
+	manifestdata.SetManifest(&manifest.Manifest{
+		Hosts: map[string]manifest.HostInfo{
+			"test": manifest.HTTPHostInfo{Name: "test", BaseURL: server.URL + "/"},
+		},
+	})
+
+	return server
+}
+
+func Test_HttpFetchAll_Cursor(t *testing.T) {
+	server := setupPagingServer(t)
+
+	req := &HttpRequest{Url: server.URL + "/cursor", Method: "GET"}
+	opts := &PaginationOptions{Strategy: PaginationCursor, ItemsPath: "data", CursorParam: "after", CursorPath: "next"}
+
+	result, err := HttpFetchAll(context.Background(), req, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "[1,2,3,4,5]", string(result.Items))
+	assert.Equal(t, int32(3), result.Pages)
+	assert.False(t, result.Truncated)
+}
+
+func Test_HttpFetchAll_Page(t *testing.T) {
+	server := setupPagingServer(t)
+
+	req := &HttpRequest{Url: server.URL + "/page", Method: "GET"}
+	opts := &PaginationOptions{Strategy: PaginationPage, PageParam: "page", PageSizeParam: "size", PageSize: 2}
+
+	result, err := HttpFetchAll(context.Background(), req, opts)
+	require.NoError(t, err)
+	assert.Equal(t, `["a","b","c"]`, string(result.Items))
+	assert.Equal(t, int32(2), result.Pages)
+}
+
+func Test_HttpFetchAll_Link(t *testing.T) {
+	server := setupPagingServer(t)
+
+	req := &HttpRequest{Url: server.URL + "/link", Method: "GET"}
+	opts := &PaginationOptions{Strategy: PaginationLink}
+
+	result, err := HttpFetchAll(context.Background(), req, opts)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id":1},{"id":2}]`, string(result.Items))
+}
+
+func Test_HttpFetchAll_Limits(t *testing.T) {
+	server := setupPagingServer(t)
+
+	req := &HttpRequest{Url: server.URL + "/cursor", Method: "GET"}
+	opts := &PaginationOptions{Strategy: PaginationCursor, ItemsPath: "data", CursorParam: "after", CursorPath: "next", MaxItems: 3}
+
+	result, err := HttpFetchAll(context.Background(), req, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "[1,2,3]", string(result.Items))
+	assert.True(t, result.Truncated)
+
+	opts.MaxItems = 0
+	opts.MaxPages = 1
+	result, err = HttpFetchAll(context.Background(), req, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "[1,2]", string(result.Items))
+	assert.True(t, result.Truncated)
+}
+
+func Test_GetNextLink(t *testing.T) {
+	assert.Equal(t, "https://x/2", getNextLink([]string{`<https://x/2>; rel="next"`}))
+	assert.Equal(t, "/b", getNextLink([]string{`</a>; rel="prev", </b>; rel="next"`}))
+	assert.Equal(t, "/b", getNextLink([]string{`</b>; rel=next`}))
+	assert.Equal(t, "", getNextLink([]string{`</a>; rel="last"`}))
+
+	// "next" must be a whole relation type
+	assert.Equal(t, "/b", getNextLink([]string{`</b>; rel="prev next"`}))
+	assert.Equal(t, "/b", getNextLink([]string{`</b>; rel=next; title="Next"`}))
+	assert.Equal(t, "/c", getNextLink([]string{`</b>; rel=next-page, </c>; rel=next, </d>; rel=last`}))
+	assert.Equal(t, "", getNextLink([]string{`</b>; rel="next-page"`}))
+	assert.Equal(t, "", getNextLink([]string{`</b>; rel="nextpage"`}))
+	assert.Equal(t, "", getNextLink([]string{`</b>; rel="prenext"`}))
+}
@@ -21,6 +21,19 @@ type HttpResponse struct {
 	StatusText string
 	Headers    *HttpHeaders
 	Body       []byte
+	Error      *HttpError
+}
+
+// HttpError describes a failed request, so that the guest can decide how to handle it
+// without having to parse error strings.  It is set on the response when the request
+// could not be completed, or when the server responded with an error status code.
+type HttpError struct {
+	Code        string
+	Message     string
+	Status      uint16
+	Headers     *HttpHeaders
+	BodySnippet string
+	Retryable   bool
 }
 
 type HttpHeaders struct {
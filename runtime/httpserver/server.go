@@ -17,22 +17,30 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
-	"time"
 
+	"github.com/hypermodeinc/modus/runtime/collections"
 	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/db"
 	"github.com/hypermodeinc/modus/runtime/graphql"
+	"github.com/hypermodeinc/modus/runtime/jobqueue"
+	"github.com/hypermodeinc/modus/runtime/lifecycle"
 	"github.com/hypermodeinc/modus/runtime/logger"
 	"github.com/hypermodeinc/modus/runtime/metrics"
 	"github.com/hypermodeinc/modus/runtime/middleware"
+	"github.com/hypermodeinc/modus/runtime/models"
+	"github.com/hypermodeinc/modus/runtime/netdiag"
+	"github.com/hypermodeinc/modus/runtime/pluginmanager"
+	"github.com/hypermodeinc/modus/runtime/standby"
+	"github.com/hypermodeinc/modus/runtime/storage"
 	"github.com/hypermodeinc/modus/runtime/utils"
 
+	"github.com/klauspost/compress/gzhttp"
 	"github.com/rs/cors"
 )
 
-// shutdownTimeout is the time to wait for the server to shutdown gracefully.
-const shutdownTimeout = 5 * time.Second
-
 func Start(ctx context.Context, local bool) {
 
 	logger.Info(ctx).
@@ -61,7 +69,12 @@ func startHttpServer(ctx context.Context, addresses ...string) {
 	mux := GetHandlerMux()
 	servers := make([]*http.Server, len(addresses))
 	for i, addr := range addresses {
-		servers[i] = &http.Server{Handler: mux, Addr: addr}
+		servers[i] = &http.Server{
+			Handler:      mux,
+			Addr:         addr,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+		}
 	}
 
 	// Start a goroutine for each server.
@@ -82,6 +95,8 @@ func startHttpServer(ctx context.Context, addresses ...string) {
 	select {
 	case <-ctx.Done():
 		logger.Info(ctx).Msg("Context canceled.  Stopping HTTP server...")
+	case <-lifecycle.ShutdownRequested():
+		logger.Info(ctx).Msg("Shutdown requested.  Stopping HTTP server...")
 	case sig := <-sigChan:
 		switch sig {
 		case syscall.SIGINT:
@@ -92,14 +107,21 @@ func startHttpServer(ctx context.Context, addresses ...string) {
 		}
 	}
 
-	// Shutdown all servers gracefully.
+	// Shutdown all servers gracefully.  They stop accepting new requests at once, and requests in progress,
+	// such as long-running function calls, have until the drain timeout to complete.
+	shutdownCtx, shutdownRelease := context.WithTimeout(ctx, config.DrainTimeout)
+	defer shutdownRelease()
+	var wg sync.WaitGroup
 	for _, server := range servers {
-		shutdownCtx, shutdownRelease := context.WithTimeout(ctx, shutdownTimeout)
-		defer shutdownRelease()
-		if err := server.Shutdown(shutdownCtx); err != nil {
-			logger.Fatal(ctx).Err(err).Msg("HTTP server shutdown error.")
-		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				logger.Warn(ctx).Err(err).Str("address", server.Addr).Msg("HTTP server did not shut down gracefully.")
+			}
+		}()
 	}
+	wg.Wait()
 
 	// Wait for the servers to shutdown completely.
 	for range servers {
@@ -113,7 +135,18 @@ func GetHandlerMux() http.Handler {
 	mux := http.NewServeMux()
 
 	// Register our main endpoints with instrumentation.
-	mux.Handle("/graphql", metrics.InstrumentHandler(middleware.HandleJWT(graphql.GraphQLRequestHandler), "graphql"))
+	mux.Handle("/graphql", metrics.InstrumentHandler(standby.HandleRequireActive(middleware.HandleJWT(middleware.HandleRateLimit(graphql.GraphQLRequestHandler))), "graphql"))
+	// Each plugin is also served on its own, with its own schema, so that one runtime can host several apps.
+	mux.Handle(graphql.GraphQLPluginPathPrefix, metrics.InstrumentHandler(standby.HandleRequireActive(middleware.HandleJWT(middleware.HandleRateLimit(graphql.GraphQLRequestHandler))), "graphql"))
+
+	// The REST facade serves the same functions, with the same authorization, to clients that can't use GraphQL.
+	if config.EnableRestApi {
+		mux.Handle(graphql.RestPathPrefix, metrics.InstrumentHandler(standby.HandleRequireActive(middleware.HandleJWT(middleware.HandleRateLimit(graphql.RestRequestHandler))), "rest"))
+		// Streaming functions are also served as server-sent events, for clients such as curl or a browser's EventSource.
+		mux.Handle(graphql.StreamPathPrefix, metrics.InstrumentHandler(standby.HandleRequireActive(middleware.HandleJWT(middleware.HandleRateLimit(graphql.StreamRequestHandler))), "stream"))
+		// The OpenAPI document describes those endpoints, for client generators and API gateways.
+		mux.Handle(graphql.OpenAPIPath, metrics.InstrumentHandler(http.HandlerFunc(graphql.OpenAPIHandler), "openapi"))
+	}
 
 	// Register metrics endpoint which uses the Prometheus scraping protocol.
 	// We do not instrument it with the InstrumentHandler so that any scraper (eg. OTel)
@@ -123,15 +156,76 @@ func GetHandlerMux() http.Handler {
 	// Also register the health endpoint, un-instrumented.
 	mux.HandleFunc("/health", healthHandler)
 
+	// The readiness endpoint reports whether this runtime should receive requests, for use by load balancers and readiness probes.
+	mux.HandleFunc("/ready", lifecycle.ReadyHandler)
+
+	// Register the admin endpoints, which require admin authorization outside of development.
+	mux.Handle("/admin/collections/export", middleware.HandleAdminAuth(http.HandlerFunc(collections.ExportHandler)))
+	mux.Handle("/admin/collections/import", middleware.HandleAdminAuth(http.HandlerFunc(collections.ImportHandler)))
+	mux.Handle("/admin/collections/reembed", middleware.HandleAdminAuth(http.HandlerFunc(collections.ReembedHandler)))
+	mux.Handle("/admin/collections/items", middleware.HandleAdminAuth(http.HandlerFunc(collections.ItemsHandler)))
+	mux.Handle("/admin/collections/stats", middleware.HandleAdminAuth(http.HandlerFunc(collections.StatsHandler)))
+	mux.Handle("/admin/costs", middleware.HandleAdminAuth(http.HandlerFunc(models.CostsHandler)))
+	mux.Handle("/admin/inferences", middleware.HandleAdminAuth(http.HandlerFunc(db.InferencesHandler)))
+	mux.Handle("/admin/jobs/dead", middleware.HandleAdminAuth(http.HandlerFunc(jobqueue.DeadJobsHandler)))
+	mux.Handle("/admin/plugins", middleware.HandleAdminAuth(http.HandlerFunc(pluginmanager.PluginsHandler)))
+	mux.Handle("/admin/plugins/versions", middleware.HandleAdminAuth(http.HandlerFunc(pluginmanager.VersionsHandler)))
+	mux.Handle("/admin/plugins/rollback", middleware.HandleAdminAuth(http.HandlerFunc(pluginmanager.RollbackHandler)))
+	mux.Handle("/admin/plugins/unpin", middleware.HandleAdminAuth(http.HandlerFunc(pluginmanager.UnpinHandler)))
+	mux.Handle("/admin/plugins/canary", middleware.HandleAdminAuth(http.HandlerFunc(pluginmanager.CanaryHandler)))
+	mux.Handle("/admin/plugins/canary/stop", middleware.HandleAdminAuth(http.HandlerFunc(pluginmanager.CanaryStopHandler)))
+	mux.Handle("/admin/probe", middleware.HandleAdminAuth(http.HandlerFunc(netdiag.ProbeHandler)))
+	mux.Handle("/admin/schema", middleware.HandleAdminAuth(http.HandlerFunc(graphql.SchemaHandler)))
+	mux.Handle("/admin/schema/changes", middleware.HandleAdminAuth(http.HandlerFunc(graphql.SchemaChangesHandler)))
+	mux.Handle("/admin/refresh", middleware.HandleAdminAuth(http.HandlerFunc(storage.RefreshHandler)))
+	mux.Handle("/admin/promote", middleware.HandleAdminAuth(http.HandlerFunc(standby.PromoteHandler)))
+	mux.Handle("/admin/drain", middleware.HandleAdminAuth(http.HandlerFunc(lifecycle.DrainHandler)))
+	mux.Handle("/admin/quitquitquit", middleware.HandleAdminAuth(http.HandlerFunc(lifecycle.QuitHandler)))
+
 	// Restrict the HTTP methods for all above handlers to GET and POST.
 	handler := restrictHttpMethods(mux)
 
+	// Limit the size of request bodies.
+	if config.MaxRequestSize > 0 {
+		handler = limitRequestSize(handler, int64(config.MaxRequestSize)<<20)
+	}
+
+	// Compress responses for clients that accept it.  Streamed responses are compressed as they are flushed.
+	if config.EnableCompression {
+		handler = gzhttp.GzipHandler(handler)
+	}
+
 	// Add CORS support to all endpoints.
+	return handleCors(handler, config.CorsOrigins, config.CorsHeaders)
+}
+
+func handleCors(next http.Handler, origins, headers string) http.Handler {
 	c := cors.New(cors.Options{
-		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		AllowedOrigins: splitList(origins),
+		AllowedHeaders: splitList(headers),
+	})
+	return c.Handler(next)
+}
+
+func limitRequestSize(next http.Handler, maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
 	})
+}
 
-	return c.Handler(handler)
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 func restrictHttpMethods(next http.Handler) http.Handler {
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package httpserver
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SplitList(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, splitList("a,b,c"))
+	assert.Equal(t, []string{"a", "b"}, splitList(" a , ,b, "))
+	assert.Empty(t, splitList(""))
+	assert.Empty(t, splitList(" , "))
+}
+
+// echoBody writes the request body back, or 413 if the body is larger than allowed.
+var echoBody = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if maxErr := (*http.MaxBytesError)(nil); errors.As(err, &maxErr) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, _ = w.Write(body)
+})
+
+func Test_LimitRequestSize(t *testing.T) {
+	handler := limitRequestSize(echoBody, 10)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("0123456789")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	// a declared length over the limit is refused before the handler runs
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("0123456789a")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// a body of unknown length fails when it is read past the limit
+	r := httptest.NewRequest(http.MethodPost, "/graphql", io.MultiReader(strings.NewReader("0123456789a")))
+	r.ContentLength = -1
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func Test_HandleCors(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := handleCors(ok, "https://*.example.com", "Authorization,Content-Type,X-Api-Key,X-Modus-Client,X-Modus-Canary")
+
+	preflight := func(origin, headers string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodOptions, "/graphql", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		r.Header.Set("Access-Control-Request-Headers", headers)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := preflight("https://app.example.com", "authorization,content-type,x-api-key,x-modus-canary,x-modus-client")
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "authorization,content-type,x-api-key,x-modus-canary,x-modus-client", w.Header().Get("Access-Control-Allow-Headers"))
+
+	w = preflight("https://app.example.com", "x-other")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Headers"))
+
+	w = preflight("https://other.com", "content-type")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
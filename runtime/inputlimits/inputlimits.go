@@ -0,0 +1,158 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package inputlimits
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+)
+
+const (
+	defaultMaxListItems  = 100_000
+	defaultMaxMapEntries = 10_000
+	defaultMaxDepth      = 32
+)
+
+type limits struct {
+	maxListItems  int
+	maxMapEntries int
+	maxDepth      int
+}
+
+var current = newLimits(nil)
+var mu sync.RWMutex
+
+func Initialize() {
+	manifestdata.RegisterManifestLoadedCallback(func(ctx context.Context) error {
+		setLimits(manifestdata.GetManifest().InputLimits)
+		return nil
+	})
+}
+
+func setLimits(info *manifest.InputLimitsInfo) {
+	l := newLimits(info)
+	mu.Lock()
+	defer mu.Unlock()
+	current = l
+}
+
+func newLimits(info *manifest.InputLimitsInfo) limits {
+	l := limits{
+		maxListItems:  defaultMaxListItems,
+		maxMapEntries: defaultMaxMapEntries,
+		maxDepth:      defaultMaxDepth,
+	}
+	if info != nil {
+		if info.MaxListItems > 0 {
+			l.maxListItems = info.MaxListItems
+		}
+		if info.MaxMapEntries > 0 {
+			l.maxMapEntries = info.MaxMapEntries
+		}
+		if info.MaxDepth > 0 {
+			l.maxDepth = info.MaxDepth
+		}
+	}
+	return l
+}
+
+// Check verifies that the function's arguments are within the configured limits, so that
+// excessively large or deeply nested input is rejected before it is written to the function's memory.
+// The error identifies the location of the first value that exceeds a limit, such as "items[3].tags".
+func Check(parameters map[string]any) error {
+	mu.RLock()
+	l := current
+	mu.RUnlock()
+
+	// check in a consistent order, so that the same input always reports the same error
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := l.check(reflect.ValueOf(parameters[name]), name, 0); err != nil {
+			return fmt.Errorf("function parameter '%s' is too large: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (l limits) check(rv reflect.Value, path string, depth int) error {
+	for rv.Kind() == reflect.Interface || rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		// byte sequences are copied to memory as a single buffer, so only their total size matters
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		if err := l.checkDepth(path, depth); err != nil {
+			return err
+		}
+		if n := rv.Len(); n > l.maxListItems {
+			return fmt.Errorf("list at %s has %d items, which exceeds the limit of %d", path, n, l.maxListItems)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := l.check(rv.Index(i), fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if err := l.checkDepth(path, depth); err != nil {
+			return err
+		}
+		if n := rv.Len(); n > l.maxMapEntries {
+			return fmt.Errorf("map at %s has %d entries, which exceeds the limit of %d", path, n, l.maxMapEntries)
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			if err := l.check(iter.Value(), fmt.Sprintf("%s.%v", path, iter.Key()), depth+1); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Struct:
+		if err := l.checkDepth(path, depth); err != nil {
+			return err
+		}
+		rt := rv.Type()
+		for i := 0; i < rv.NumField(); i++ {
+			if !rt.Field(i).IsExported() {
+				continue
+			}
+			if err := l.check(rv.Field(i), path+"."+rt.Field(i).Name, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l limits) checkDepth(path string, depth int) error {
+	if depth >= l.maxDepth {
+		return fmt.Errorf("value at %s is nested more than %d levels deep", path, l.maxDepth)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package inputlimits
+
+import (
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name string
+	Tags []string
+}
+
+func Test_Check(t *testing.T) {
+	setLimits(&manifest.InputLimitsInfo{MaxListItems: 3, MaxMapEntries: 2, MaxDepth: 3})
+	defer setLimits(nil)
+
+	// within limits
+	require.NoError(t, Check(map[string]any{
+		"items":  []any{map[string]any{"name": "a", "tags": []any{"x", "y", "z"}}},
+		"data":   make([]byte, 1000),
+		"struct": testItem{Name: "a", Tags: []string{"x"}},
+		"nil":    nil,
+	}))
+
+	err := Check(map[string]any{"items": []any{1, 2, 3, 4}})
+	assert.EqualError(t, err, "function parameter 'items' is too large: list at items has 4 items, which exceeds the limit of 3")
+
+	err = Check(map[string]any{"items": []any{map[string]any{"tags": []any{"a", "b", "c", "d"}}}})
+	assert.EqualError(t, err, "function parameter 'items' is too large: list at items[0].tags has 4 items, which exceeds the limit of 3")
+
+	err = Check(map[string]any{"m": map[string]string{"a": "1", "b": "2", "c": "3"}})
+	assert.EqualError(t, err, "function parameter 'm' is too large: map at m has 3 entries, which exceeds the limit of 2")
+
+	err = Check(map[string]any{"item": &testItem{Tags: []string{"a", "b", "c", "d"}}})
+	assert.EqualError(t, err, "function parameter 'item' is too large: list at item.Tags has 4 items, which exceeds the limit of 3")
+
+	err = Check(map[string]any{"deep": []any{[]any{[]any{[]any{1}}}}})
+	assert.EqualError(t, err, "function parameter 'deep' is too large: value at deep[0][0][0] is nested more than 3 levels deep")
+}
+
+func Test_DefaultLimits(t *testing.T) {
+	setLimits(nil)
+	assert.Equal(t, limits{defaultMaxListItems, defaultMaxMapEntries, defaultMaxDepth}, current)
+
+	setLimits(&manifest.InputLimitsInfo{MaxDepth: 5})
+	defer setLimits(nil)
+	assert.Equal(t, limits{defaultMaxListItems, defaultMaxMapEntries, 5}, current)
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package jobqueue
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+const defaultDeadJobsLimit = 100
+const maxDeadJobsLimit = 1000
+
+// DeadJobsHandler lists the jobs in the dead-letter list (GET),
+// or moves a dead job back to the queue for another set of attempts (POST with an "id" query parameter).
+func DeadJobsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if store == nil {
+		http.Error(w, "The job queue has not been initialized.", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		limit := defaultDeadJobsLimit
+		if s := r.URL.Query().Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				http.Error(w, "Invalid limit.", http.StatusBadRequest)
+				return
+			}
+			limit = min(n, maxDeadJobsLimit)
+		}
+
+		jobs, err := store.deadJobs(ctx, limit)
+		if err != nil {
+			logger.Err(ctx, err).Msg("Failed to retrieve dead jobs.")
+			http.Error(w, "Failed to retrieve dead jobs.", http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteJsonResponse(w, jobs)
+
+	case http.MethodPost:
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "A job id is required.", http.StatusBadRequest)
+			return
+		}
+
+		found, err := store.retryDead(ctx, id)
+		if err != nil {
+			logger.Err(ctx, err).Str("job_id", id).Msg("Failed to retry dead job.")
+			http.Error(w, "Failed to retry dead job.", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "Dead job not found.", http.StatusNotFound)
+			return
+		}
+
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+
+		utils.WriteJsonResponse(w, map[string]string{"id": id, "status": "pending"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+)
+
+const defaultMaxAttempts = 5
+const maxMaxAttempts = 100
+
+// JobRequest is the job passed by the guest when enqueuing.
+type JobRequest struct {
+	FunctionName string
+	Payload      string
+	MaxAttempts  int32
+	DelayMs      int64
+}
+
+var store jobStore
+
+var wake = make(chan struct{}, 1)
+var stop context.CancelFunc
+var stopped sync.WaitGroup
+
+func Initialize(ctx context.Context) {
+	if db.IsDbConfigured(ctx) {
+		store = dbStore{}
+	} else {
+		store = newMemoryStore()
+		logger.Warn(ctx).Msg("Database has not been configured. Queued jobs will be kept in memory, and will not survive a restart.")
+	}
+
+	ctx, stop = context.WithCancel(ctx)
+	stopped.Add(1)
+	go func() {
+		defer stopped.Done()
+		runWorker(ctx, store)
+	}()
+}
+
+func Shutdown(ctx context.Context) {
+	if stop != nil {
+		stop()
+		stopped.Wait()
+	}
+}
+
+// Enqueue adds a job to the queue, and returns the job's ID.
+// The named function will be invoked with the job's payload until it succeeds,
+// or until the maximum number of attempts is reached.
+func Enqueue(ctx context.Context, req *JobRequest) (string, error) {
+	if store == nil {
+		return "", errors.New("the job queue has not been initialized")
+	}
+	if req == nil || req.FunctionName == "" {
+		return "", errors.New("a function name is required")
+	}
+
+	info, err := wasmhost.GetWasmHost(ctx).GetFunctionInfo(req.FunctionName)
+	if err != nil {
+		return "", err
+	}
+	if n := len(info.Metadata().Parameters); n > 1 {
+		return "", fmt.Errorf("function %s cannot be used as a job handler, because it has %d parameters", req.FunctionName, n)
+	}
+
+	maxAttempts := req.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	} else if maxAttempts > maxMaxAttempts {
+		maxAttempts = maxMaxAttempts
+	}
+
+	job := &db.Job{
+		Id:          utils.GenerateUUIDv7(),
+		Function:    req.FunctionName,
+		Payload:     req.Payload,
+		MaxAttempts: maxAttempts,
+		RunAt:       time.Now().Add(time.Duration(req.DelayMs) * time.Millisecond).UTC(),
+	}
+
+	if err := store.insert(ctx, job); err != nil {
+		return "", err
+	}
+
+	if req.DelayMs <= 0 {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return job.Id, nil
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetBackoff(t *testing.T) {
+	assert.Equal(t, 1*time.Second, getBackoff(1))
+	assert.Equal(t, 2*time.Second, getBackoff(2))
+	assert.Equal(t, 4*time.Second, getBackoff(3))
+	assert.Equal(t, 256*time.Second, getBackoff(9))
+	assert.Equal(t, maxBackoff, getBackoff(10))
+	assert.Equal(t, maxBackoff, getBackoff(1000))
+}
+
+func Test_MemoryStore_Claim(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryStore()
+
+	now := time.Now()
+	require.Nil(t, s.insert(ctx, &db.Job{Id: "a", Function: "f", MaxAttempts: 3, RunAt: now.Add(-2 * time.Second)}))
+	require.Nil(t, s.insert(ctx, &db.Job{Id: "b", Function: "f", MaxAttempts: 3, RunAt: now.Add(-1 * time.Second)}))
+	require.Nil(t, s.insert(ctx, &db.Job{Id: "c", Function: "f", MaxAttempts: 3, RunAt: now.Add(time.Hour)}))
+
+	jobs, err := s.claim(ctx, 1, time.Minute)
+	require.Nil(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "a", jobs[0].Id)
+	assert.Equal(t, int32(1), jobs[0].Attempts)
+	assert.Equal(t, db.JobStatusRunning, jobs[0].Status)
+
+	// "a" is leased, and "c" is not yet due
+	jobs, err = s.claim(ctx, 10, time.Minute)
+	require.Nil(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "b", jobs[0].Id)
+
+	// an expired lease makes the job available again
+	s.jobs["a"].RunAt = now.Add(-time.Second)
+	jobs, err = s.claim(ctx, 10, time.Minute)
+	require.Nil(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "a", jobs[0].Id)
+	assert.Equal(t, int32(2), jobs[0].Attempts)
+}
+
+func Test_ProcessJob_RetriesThenDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryStore()
+
+	calls := 0
+	invokeFunction = func(ctx context.Context, job *db.Job) error {
+		calls++
+		assert.Equal(t, "payload", job.Payload)
+		return errors.New("boom")
+	}
+
+	require.Nil(t, s.insert(ctx, &db.Job{Id: "a", Function: "f", Payload: "payload", MaxAttempts: 2, RunAt: time.Now()}))
+
+	jobs, err := s.claim(ctx, 10, time.Minute)
+	require.Nil(t, err)
+	require.Len(t, jobs, 1)
+	processJob(ctx, s, &jobs[0])
+
+	assert.Equal(t, db.JobStatusPending, s.jobs["a"].Status)
+	assert.Equal(t, "boom", s.jobs["a"].LastError)
+	assert.True(t, s.jobs["a"].RunAt.After(time.Now()))
+
+	s.jobs["a"].RunAt = time.Now()
+	jobs, err = s.claim(ctx, 10, time.Minute)
+	require.Nil(t, err)
+	require.Len(t, jobs, 1)
+	processJob(ctx, s, &jobs[0])
+
+	assert.Equal(t, 2, calls)
+	dead, err := s.deadJobs(ctx, 10)
+	require.Nil(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "a", dead[0].Id)
+	assert.Equal(t, int32(2), dead[0].Attempts)
+
+	// retrying a dead job puts it back in the queue
+	found, err := s.retryDead(ctx, "a")
+	require.Nil(t, err)
+	assert.True(t, found)
+
+	invokeFunction = func(ctx context.Context, job *db.Job) error {
+		return nil
+	}
+
+	jobs, err = s.claim(ctx, 10, time.Minute)
+	require.Nil(t, err)
+	require.Len(t, jobs, 1)
+	processJob(ctx, s, &jobs[0])
+	assert.Empty(t, s.jobs)
+}
+
+// cancelAwareStore fails like the database store does when its context is cancelled.
+type cancelAwareStore struct {
+	*memoryStore
+}
+
+func (s cancelAwareStore) remove(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.memoryStore.remove(ctx, id)
+}
+
+func (s cancelAwareStore) fail(ctx context.Context, id, lastError string, runAt time.Time, dead bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.memoryStore.fail(ctx, id, lastError, runAt, dead)
+}
+
+func Test_ProcessJob_RecordsOutcomeAfterShutdown(t *testing.T) {
+	s := cancelAwareStore{newMemoryStore()}
+
+	// the worker is stopped while the job is running
+	runJob := func(id string, result error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		invokeFunction = func(callCtx context.Context, job *db.Job) error {
+			cancel()
+			assert.Nil(t, callCtx.Err())
+			return result
+		}
+
+		require.Nil(t, s.insert(ctx, &db.Job{Id: id, Function: "f", MaxAttempts: 3, RunAt: time.Now()}))
+		jobs, err := s.claim(ctx, 10, time.Minute)
+		require.Nil(t, err)
+		require.Len(t, jobs, 1)
+		processJob(ctx, s, &jobs[0])
+	}
+
+	runJob("a", nil)
+	assert.Empty(t, s.jobs)
+
+	runJob("b", errors.New("boom"))
+	assert.Equal(t, db.JobStatusPending, s.jobs["b"].Status)
+	assert.Equal(t, "boom", s.jobs["b"].LastError)
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package jobqueue
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/db"
+)
+
+type jobStore interface {
+	insert(ctx context.Context, job *db.Job) error
+	claim(ctx context.Context, limit int, lease time.Duration) ([]db.Job, error)
+	remove(ctx context.Context, id string) error
+	fail(ctx context.Context, id, lastError string, runAt time.Time, dead bool) error
+	deadJobs(ctx context.Context, limit int) ([]db.Job, error)
+	retryDead(ctx context.Context, id string) (bool, error)
+}
+
+// dbStore keeps jobs in the Modus database, so they survive restarts and are shared by all runtime instances.
+type dbStore struct{}
+
+func (dbStore) insert(ctx context.Context, job *db.Job) error {
+	return db.InsertJob(ctx, job)
+}
+
+func (dbStore) claim(ctx context.Context, limit int, lease time.Duration) ([]db.Job, error) {
+	return db.ClaimJobs(ctx, limit, lease)
+}
+
+func (dbStore) remove(ctx context.Context, id string) error {
+	return db.DeleteJob(ctx, id)
+}
+
+func (dbStore) fail(ctx context.Context, id, lastError string, runAt time.Time, dead bool) error {
+	return db.UpdateFailedJob(ctx, id, lastError, runAt, dead)
+}
+
+func (dbStore) deadJobs(ctx context.Context, limit int) ([]db.Job, error) {
+	return db.GetDeadJobs(ctx, limit)
+}
+
+func (dbStore) retryDead(ctx context.Context, id string) (bool, error) {
+	return db.RetryDeadJob(ctx, id)
+}
+
+// memoryStore is used when no database is configured, such as during local development.
+// Jobs held in memory are lost when the runtime stops.
+type memoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*db.Job
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		jobs: make(map[string]*db.Job),
+	}
+}
+
+func (s *memoryStore) insert(ctx context.Context, job *db.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.Status = db.JobStatusPending
+	job.CreatedAt = time.Now().UTC()
+	j := *job
+	s.jobs[job.Id] = &j
+	return nil
+}
+
+func (s *memoryStore) claim(ctx context.Context, limit int, lease time.Duration) ([]db.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	due := make([]*db.Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		if j.Status != db.JobStatusDead && !j.RunAt.After(now) {
+			due = append(due, j)
+		}
+	}
+
+	slices.SortFunc(due, func(a, b *db.Job) int {
+		return a.RunAt.Compare(b.RunAt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	results := make([]db.Job, len(due))
+	for i, j := range due {
+		j.Status = db.JobStatusRunning
+		j.Attempts++
+		j.RunAt = now.Add(lease)
+		results[i] = *j
+	}
+	return results, nil
+}
+
+func (s *memoryStore) remove(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *memoryStore) fail(ctx context.Context, id, lastError string, runAt time.Time, dead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j, ok := s.jobs[id]; ok {
+		j.LastError = lastError
+		j.RunAt = runAt
+		if dead {
+			j.Status = db.JobStatusDead
+		} else {
+			j.Status = db.JobStatusPending
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) deadJobs(ctx context.Context, limit int) ([]db.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]db.Job, 0)
+	for _, j := range s.jobs {
+		if j.Status == db.JobStatusDead {
+			results = append(results, *j)
+		}
+	}
+
+	slices.SortFunc(results, func(a, b db.Job) int {
+		return b.RunAt.Compare(a.RunAt)
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (s *memoryStore) retryDead(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok || j.Status != db.JobStatusDead {
+		return false, nil
+	}
+
+	j.Status = db.JobStatusPending
+	j.Attempts = 0
+	j.RunAt = time.Now()
+	return true, nil
+}
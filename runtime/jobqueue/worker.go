@@ -0,0 +1,151 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package jobqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/standby"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+)
+
+const pollInterval = 1 * time.Second
+const maxConcurrentJobs = 4
+
+// jobLease is how long a claimed job is reserved for the worker that claimed it.
+// It is also the maximum time a job may run. If the job does not complete by then,
+// it is considered failed and becomes available to be claimed again.
+const jobLease = 5 * time.Minute
+
+// bookkeepingTimeout limits how long recording the outcome of a job may take.
+const bookkeepingTimeout = 10 * time.Second
+
+const initialBackoff = 1 * time.Second
+const maxBackoff = 5 * time.Minute
+
+// invokeFunction calls the job's handler function. It is a variable so it can be replaced in tests.
+var invokeFunction = func(ctx context.Context, job *db.Job) error {
+	host := wasmhost.GetWasmHost(ctx)
+	info, err := host.GetFunctionInfo(job.Function)
+	if err != nil {
+		return err
+	}
+
+	var params []any
+	if len(info.Metadata().Parameters) > 0 {
+		params = []any{job.Payload}
+	}
+
+	_, err = host.CallFunctionByName(ctx, job.Function, params...)
+	return err
+}
+
+func runWorker(ctx context.Context, store jobStore) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	sem := make(chan struct{}, maxConcurrentJobs)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+
+		// A standby leaves the queued jobs to the active runtime.
+		if standby.IsStandby() {
+			continue
+		}
+
+		available := maxConcurrentJobs - len(sem)
+		if available == 0 {
+			continue
+		}
+
+		jobs, err := store.claim(ctx, available, jobLease)
+		if err != nil {
+			logger.Err(ctx, err).Msg("Failed to claim queued jobs.")
+			continue
+		}
+
+		for _, job := range jobs {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				processJob(ctx, store, &job)
+			}()
+		}
+	}
+}
+
+func processJob(ctx context.Context, store jobStore, job *db.Job) {
+	// The job keeps running through shutdown, so that it isn't needlessly retried.
+	callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobLease)
+	defer cancel()
+
+	err := invokeFunction(callCtx, job)
+
+	// The outcome is recorded even if the worker is stopping, or a completed job would be run again.
+	ctx, cancelBookkeeping := context.WithTimeout(context.WithoutCancel(ctx), bookkeepingTimeout)
+	defer cancelBookkeeping()
+
+	if err == nil {
+		if err := store.remove(ctx, job.Id); err != nil {
+			logger.Err(ctx, err).Str("job_id", job.Id).Msg("Failed to remove completed job.")
+		}
+		return
+	}
+
+	dead := job.Attempts >= job.MaxAttempts
+	runAt := time.Now().Add(getBackoff(job.Attempts)).UTC()
+
+	if dead {
+		logger.Err(ctx, err).
+			Str("job_id", job.Id).
+			Str("function", job.Function).
+			Int32("attempts", job.Attempts).
+			Msg("Job failed on its last attempt, and has been moved to the dead-letter list.")
+	} else {
+		logger.Warn(ctx).Err(err).
+			Str("job_id", job.Id).
+			Str("function", job.Function).
+			Int32("attempts", job.Attempts).
+			Time("retry_at", runAt).
+			Msg("Job failed, and will be retried.")
+	}
+
+	if err := store.fail(ctx, job.Id, err.Error(), runAt, dead); err != nil {
+		logger.Err(ctx, err).Str("job_id", job.Id).Msg("Failed to record failed job.")
+	}
+}
+
+// getBackoff returns the delay before retrying a job that has failed the given number of attempts.
+// The delay doubles with each attempt, up to a maximum.
+func getBackoff(attempts int32) time.Duration {
+	if attempts < 1 {
+		return initialBackoff
+	}
+	if attempts > 20 {
+		return maxBackoff
+	}
+	return min(initialBackoff<<(attempts-1), maxBackoff)
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package langsupport
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// GetInputFieldValue returns the value of a field from an object given as a map, such as a partial JSON object
+// received as a function argument.  If the field is missing, the field's default value from the metadata is used,
+// or an empty value of the field's type if the metadata doesn't declare one.  The second result reports whether
+// the field was defaulted.
+func GetInputFieldValue(m map[string]any, field *metadata.Field, ti TypeInfo) (any, bool) {
+	if val, found := m[field.Name]; found {
+		return val, false
+	}
+
+	if field.Default != nil {
+		return *field.Default, true
+	}
+
+	return EmptyValue(ti), true
+}
+
+// EmptyValue returns the value used in place of an omitted value of the given type.
+// This is nil for nullable types, and otherwise the zero value of the type, except that maps and slices
+// are empty rather than nil, so that non-nullable objects and lists can be written to memory.
+func EmptyValue(ti TypeInfo) any {
+	if ti.IsNullable() {
+		return nil
+	}
+
+	rt := ti.ReflectedType()
+	switch rt.Kind() {
+	case reflect.Map:
+		return reflect.MakeMap(rt).Interface()
+	case reflect.Slice:
+		return reflect.MakeSlice(rt, 0, 0).Interface()
+	}
+
+	return ti.ZeroValue()
+}
+
+// LogDefaultedFields reports the fields of an input object that were filled with default values,
+// which is helpful when debugging functions that are called with partial objects.
+func LogDefaultedFields(ctx context.Context, typeName string, fields []string) {
+	if len(fields) == 0 || !utils.DebugModeEnabled() {
+		return
+	}
+
+	logger.Debug(ctx).
+		Str("type", typeName).
+		Strs("fields", fields).
+		Msg("Input object is missing fields. Using default values.")
+}
@@ -89,12 +89,18 @@ func (h *classHandler) Write(ctx context.Context, wa langsupport.WasmAdapter, of
 
 	cln := utils.NewCleanerN(len(h.fieldHandlers))
 
+	fieldTypes := h.typeInfo.ObjectFieldTypes()
 	fieldOffsets := h.typeInfo.ObjectFieldOffsets()
+	var defaulted []string
 	for i, field := range h.typeDef.Fields {
 		var fieldObj any
 		if mapObj != nil {
-			// case sensitive when reading from map
-			fieldObj = mapObj[field.Name]
+			// case sensitive when reading from map, with defaults for any missing fields
+			var isDefault bool
+			fieldObj, isDefault = langsupport.GetInputFieldValue(mapObj, field, fieldTypes[i])
+			if isDefault {
+				defaulted = append(defaulted, field.Name)
+			}
 		} else {
 			// case insensitive when reading from struct
 			fieldObj = rvObj.FieldByNameFunc(func(s string) bool { return strings.EqualFold(s, field.Name) }).Interface()
@@ -109,6 +115,7 @@ func (h *classHandler) Write(ctx context.Context, wa langsupport.WasmAdapter, of
 		}
 	}
 
+	langsupport.LogDefaultedFields(ctx, h.typeInfo.Name(), defaulted)
 	return cln, nil
 }
 
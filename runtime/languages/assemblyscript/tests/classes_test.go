@@ -100,6 +100,11 @@ var testClass4AsMap_withNull = map[string]any{
 	"c": nil,
 }
 
+var testClass4AsMap_partial = map[string]any{
+	"a": true,
+	"b": 123,
+}
+
 var testClass5AsMap = map[string]any{
 	"a": true,
 	"b": testClass3AsMap,
@@ -155,6 +160,13 @@ func TestClassInput4_withNull(t *testing.T) {
 	}
 }
 
+func TestClassInput4_partial(t *testing.T) {
+	fnName := "testClassInput4_withNull"
+	if _, err := fixture.CallFunction(t, fnName, testClass4AsMap_partial); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestClassInput5(t *testing.T) {
 	fnName := "testClassInput5"
 	if _, err := fixture.CallFunction(t, fnName, testClass5); err != nil {
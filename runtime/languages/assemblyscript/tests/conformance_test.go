@@ -0,0 +1,40 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package assemblyscript_test
+
+import "testing"
+
+func TestConformance(t *testing.T) {
+	fixture.RunConformanceSuite(t, map[string]string{
+		"bool/input/true":             "testBoolInput_true",
+		"bool/input/false":            "testBoolInput_false",
+		"bool/output/true":            "testBoolOutput_true",
+		"bool/output/false":           "testBoolOutput_false",
+		"i64/input/min":               "testI64Input_min",
+		"i64/input/max":               "testI64Input_max",
+		"i64/output/min":              "testI64Output_min",
+		"i64/output/max":              "testI64Output_max",
+		"u64/input/min":               "testU64Input_min",
+		"u64/input/max":               "testU64Input_max",
+		"u64/output/min":              "testU64Output_min",
+		"u64/output/max":              "testU64Output_max",
+		"string/input":                "testStringInput",
+		"string/output":               "testStringOutput",
+		"string/nullable/input/null":  "testNullStringInput_null",
+		"string/nullable/output/null": "testNullStringOutput_null",
+		"map/input":                   "testMapInput_string_string",
+		"map/output":                  "testMapOutput_string_string",
+		"map/iterate":                 "testIterateMap_string_string",
+		"map/lookup":                  "testMapLookup_string_string",
+		"object/input/null_field":     "testClassInput4_withNull",
+		"object/input/missing_field":  "testClassInput4_withNull",
+		"object/output/null_field":    "testClassOutput4_map_withNull",
+	})
+}
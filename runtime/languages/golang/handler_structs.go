@@ -102,14 +102,20 @@ func (h *structHandler) Write(ctx context.Context, wa langsupport.WasmAdapter, o
 	}
 
 	numFields := len(h.typeDef.Fields)
+	fieldTypes := h.typeInfo.ObjectFieldTypes()
 	fieldOffsets := h.typeInfo.ObjectFieldOffsets()
 	cleaner := utils.NewCleanerN(numFields)
 
+	var defaulted []string
 	for i, field := range h.typeDef.Fields {
 		var fieldObj any
 		if mapObj != nil {
-			// case sensitive when reading from map
-			fieldObj = mapObj[field.Name]
+			// case sensitive when reading from map, with defaults for any missing fields
+			var isDefault bool
+			fieldObj, isDefault = langsupport.GetInputFieldValue(mapObj, field, fieldTypes[i])
+			if isDefault {
+				defaulted = append(defaulted, field.Name)
+			}
 		} else {
 			// case insensitive when reading from struct
 			fieldObj = rvObj.FieldByNameFunc(func(s string) bool { return strings.EqualFold(s, field.Name) }).Interface()
@@ -124,6 +130,7 @@ func (h *structHandler) Write(ctx context.Context, wa langsupport.WasmAdapter, o
 		}
 	}
 
+	langsupport.LogDefaultedFields(ctx, h.typeInfo.Name(), defaulted)
 	return cleaner, nil
 }
 
@@ -157,14 +164,20 @@ func (h *structHandler) Encode(ctx context.Context, wa langsupport.WasmAdapter,
 	}
 
 	numFields := len(h.typeDef.Fields)
+	fieldTypes := h.typeInfo.ObjectFieldTypes()
 	results := make([]uint64, 0, numFields*2)
 	cleaner := utils.NewCleanerN(numFields)
 
+	var defaulted []string
 	for i, field := range h.typeDef.Fields {
 		var fieldObj any
 		if mapObj != nil {
-			// case sensitive when reading from map
-			fieldObj = mapObj[field.Name]
+			// case sensitive when reading from map, with defaults for any missing fields
+			var isDefault bool
+			fieldObj, isDefault = langsupport.GetInputFieldValue(mapObj, field, fieldTypes[i])
+			if isDefault {
+				defaulted = append(defaulted, field.Name)
+			}
 		} else {
 			// case insensitive when reading from struct
 			fieldObj = rvObj.FieldByNameFunc(func(s string) bool { return strings.EqualFold(s, field.Name) }).Interface()
@@ -179,6 +192,7 @@ func (h *structHandler) Encode(ctx context.Context, wa langsupport.WasmAdapter,
 		results = append(results, vals...)
 	}
 
+	langsupport.LogDefaultedFields(ctx, h.typeInfo.Name(), defaulted)
 	return results, cleaner, nil
 }
 
@@ -513,3 +513,32 @@ func getInnerHandlers(handler langsupport.TypeHandler) []langsupport.TypeHandler
 	}
 	return results
 }
+
+func TestEmptyValue(t *testing.T) {
+	tests := []struct {
+		typ      string
+		expected any
+	}{
+		{"int", 0},
+		{"string", ""},
+		{"*string", nil},
+		{"[]string", nil}, // slices and maps are nullable in Go
+		{"map[string]string", nil},
+		{"testdata.TestStruct2", TestStruct2{}},
+	}
+
+	planner := fixture.NewPlanner()
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			handler, err := planner.GetHandler(fixture.Context, tt.typ)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result := langsupport.EmptyValue(handler.TypeInfo())
+			if !reflect.DeepEqual(tt.expected, result) {
+				t.Errorf("expected %#v, got %#v", tt.expected, result)
+			}
+		})
+	}
+}
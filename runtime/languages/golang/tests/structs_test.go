@@ -113,6 +113,11 @@ var testStruct4AsMap_withNil = map[string]any{
 	"c": nil,
 }
 
+var testStruct4AsMap_partial = map[string]any{
+	"a": true,
+	"b": 123,
+}
+
 var testStruct5AsMap = map[string]any{
 	"a": "abc",
 	"b": "def",
@@ -186,6 +191,13 @@ func TestStructInput4_withNil(t *testing.T) {
 	}
 }
 
+func TestStructInput4_partial(t *testing.T) {
+	fnName := "testStructInput4_withNil"
+	if _, err := fixture.CallFunction(t, fnName, testStruct4AsMap_partial); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestStructPtrInput1(t *testing.T) {
 	fnName := "testStructPtrInput1"
 	if _, err := fixture.CallFunction(t, fnName, testStruct1); err != nil {
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package lifecycle
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// defaultDrainTimeout is how long the drain endpoint waits by default, which is within Kubernetes' default grace period.
+const defaultDrainTimeout = 25 * time.Second
+
+// ReadyHandler reports whether the runtime is ready to serve requests, for use as a readiness probe.
+// The runtime isn't ready until its functions are loaded, nor while it is a standby or is draining.
+func ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	status := Status()
+	utils.WriteJsonContentHeader(w)
+	if status == "ready" {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write([]byte(`{"status":"` + status + `"}`))
+}
+
+// DrainHandler stops the runtime from being ready, and responds once function executions in progress have completed.
+// It is intended to be used as a Kubernetes preStop hook, which uses GET requests.
+// The optional "timeout" query parameter is a duration, such as "10s".
+func DrainHandler(w http.ResponseWriter, r *http.Request) {
+	timeout := defaultDrainTimeout
+	if s := r.URL.Query().Get("timeout"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			http.Error(w, "Invalid timeout.", http.StatusBadRequest)
+			return
+		}
+		timeout = d
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	remaining := Drain(ctx)
+	utils.WriteJsonResponse(w, map[string]any{"status": "draining", "inFlight": remaining})
+}
+
+// QuitHandler shuts down the runtime gracefully (POST), in the style of Envoy's /quitquitquit endpoint.
+func QuitHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.WriteJsonResponse(w, map[string]string{"status": "shutting down"})
+	RequestShutdown(r.Context())
+}
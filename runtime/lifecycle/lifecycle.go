@@ -0,0 +1,99 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package lifecycle tracks whether the runtime is ready to serve requests,
+// and supports draining and shutting down the runtime on request, as used by container orchestrators such as Kubernetes.
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/functions"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/standby"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+)
+
+// drainPollInterval is how often draining checks whether function executions have completed.
+const drainPollInterval = 100 * time.Millisecond
+
+var warm atomic.Bool
+var draining atomic.Bool
+
+var shutdownChan = make(chan struct{})
+var shutdownOnce sync.Once
+
+func Initialize() {
+	functions.RegisterFunctionsLoadedCallback(func(ctx context.Context) {
+		if !warm.Swap(true) {
+			logger.Info(ctx).Msg("Functions loaded.  The runtime is ready to serve requests.")
+		}
+	})
+}
+
+// Status returns the readiness status of the runtime, which is one of
+// "warming", "standby", "draining" or "ready".
+func Status() string {
+	switch {
+	case !warm.Load():
+		return "warming"
+	case standby.IsStandby():
+		return "standby"
+	case draining.Load():
+		return "draining"
+	default:
+		return "ready"
+	}
+}
+
+// IsReady reports whether the runtime should receive new requests.
+func IsReady() bool {
+	return Status() == "ready"
+}
+
+// Drain marks the runtime as not ready, so that it stops receiving new requests,
+// then waits until function executions in progress have completed, or until the context is done.
+// It returns the number of function executions still in progress.
+func Drain(ctx context.Context) int64 {
+	if !draining.Swap(true) {
+		logger.Info(ctx).Msg("Draining the runtime.")
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		n := wasmhost.InFlightExecutions()
+		if n == 0 {
+			return 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return n
+		case <-ticker.C:
+		}
+	}
+}
+
+// RequestShutdown asks the HTTP server to shut down gracefully, as if it had received a terminate signal.
+func RequestShutdown(ctx context.Context) {
+	shutdownOnce.Do(func() {
+		logger.Info(ctx).Msg("Shutdown requested.")
+		close(shutdownChan)
+	})
+}
+
+// ShutdownRequested returns a channel that is closed when a shutdown has been requested.
+func ShutdownRequested() <-chan struct{} {
+	return shutdownChan
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package lifecycle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReadinessAndDrain(t *testing.T) {
+	defer func() {
+		warm.Store(false)
+		draining.Store(false)
+	}()
+
+	ready := func() (int, string) {
+		rec := httptest.NewRecorder()
+		ReadyHandler(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	// not ready until functions are loaded
+	code, body := ready()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.JSONEq(t, `{"status":"warming"}`, body)
+
+	warm.Store(true)
+	code, body = ready()
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"status":"ready"}`, body)
+
+	// draining completes immediately when nothing is running, and the runtime stays unready
+	rec := httptest.NewRecorder()
+	DrainHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/drain?timeout=1s", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"draining","inFlight":0}`, rec.Body.String())
+
+	code, body = ready()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.JSONEq(t, `{"status":"draining"}`, body)
+
+	rec = httptest.NewRecorder()
+	DrainHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/drain?timeout=soon", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func Test_QuitHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	QuitHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/quitquitquit", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	select {
+	case <-ShutdownRequested():
+		t.Fatal("shutdown was requested")
+	default:
+	}
+
+	rec = httptest.NewRecorder()
+	QuitHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/quitquitquit", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	select {
+	case <-ShutdownRequested():
+	default:
+		t.Fatal("shutdown was not requested")
+	}
+}
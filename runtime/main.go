@@ -8,6 +8,7 @@
  */
 
 //go:generate go run ./tools/generate_version
+//go:generate go run ./tools/generate_zonenames
 
 package main
 
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifestdata
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+)
+
+// SectionDiff lists the names of the entries of a manifest section that were added, changed, or removed.
+type SectionDiff struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+func (d SectionDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// ManifestDiff describes the changes from the previously loaded manifest to the current one.
+type ManifestDiff struct {
+	Sections map[string]SectionDiff
+
+	// Settings lists the names of the top-level settings that changed, such as "inputLimits".
+	Settings []string
+}
+
+type manifestDiffContextKey struct{}
+
+// GetManifestDiff returns the changes of the manifest that was just loaded, from the context passed to the
+// manifest loaded callbacks.  It returns nil if the changes aren't known, in which case callbacks should
+// assume that everything changed.
+func GetManifestDiff(ctx context.Context) *ManifestDiff {
+	d, _ := ctx.Value(manifestDiffContextKey{}).(*ManifestDiff)
+	return d
+}
+
+func (d *ManifestDiff) IsEmpty() bool {
+	return len(d.Sections) == 0 && len(d.Settings) == 0
+}
+
+// IsHostStale reports whether connections to a host must be re-established, because the host changed or was removed.
+// Hosts that were added have no connections yet, and those that didn't change can keep theirs.
+func (d *ManifestDiff) IsHostStale(name string) bool {
+	if d == nil {
+		return true
+	}
+	hosts := d.Sections["hosts"]
+	return slices.Contains(hosts.Changed, name) || slices.Contains(hosts.Removed, name)
+}
+
+// HostsChanged reports whether any host was added, changed, or removed.
+func (d *ManifestDiff) HostsChanged() bool {
+	if d == nil {
+		return true
+	}
+	_, ok := d.Sections["hosts"]
+	return ok
+}
+
+// String summarizes the changes, such as "hosts: +a ~b -c; models: -d; settings: inputLimits".
+func (d *ManifestDiff) String() string {
+	names := make([]string, 0, len(d.Sections))
+	for name := range d.Sections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var parts []string
+	for _, name := range names {
+		s := d.Sections[name]
+		var entries []string
+		for _, n := range s.Added {
+			entries = append(entries, "+"+n)
+		}
+		for _, n := range s.Changed {
+			entries = append(entries, "~"+n)
+		}
+		for _, n := range s.Removed {
+			entries = append(entries, "-"+n)
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", name, strings.Join(entries, " ")))
+	}
+	if len(d.Settings) > 0 {
+		parts = append(parts, "settings: "+strings.Join(d.Settings, " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func diffManifests(prev, next *manifest.Manifest) *ManifestDiff {
+	d := &ManifestDiff{Sections: make(map[string]SectionDiff)}
+
+	addSection := func(name string, s SectionDiff) {
+		if !s.IsEmpty() {
+			d.Sections[name] = s
+		}
+	}
+	addSection("models", diffSection(prev.Models, next.Models))
+	addSection("hosts", diffSection(prev.Hosts, next.Hosts))
+	addSection("collections", diffSection(prev.Collections, next.Collections))
+	addSection("variables", diffSection(prev.Variables, next.Variables))
+	addSection("connectors", diffSection(prev.Connectors, next.Connectors))
+	addSection("guards", diffSection(prev.Guards, next.Guards))
+	addSection("authorization", diffSection(prev.Authorization, next.Authorization))
+	addSection("rateLimits", diffSection(prev.RateLimits, next.RateLimits))
+	addSection("plugins", diffSection(prev.Plugins, next.Plugins))
+	addSection("transforms", diffSection(prev.Transforms, next.Transforms))
+	addSection("prompts", diffSection(prev.Prompts, next.Prompts))
+
+	if !reflect.DeepEqual(prev.InputLimits, next.InputLimits) {
+		d.Settings = append(d.Settings, "inputLimits")
+	}
+	if !reflect.DeepEqual(prev.Budget, next.Budget) {
+		d.Settings = append(d.Settings, "budget")
+	}
+	if !reflect.DeepEqual(prev.GraphQL, next.GraphQL) {
+		d.Settings = append(d.Settings, "graphql")
+	}
+
+	return d
+}
+
+func diffSection[T any](prev, next map[string]T) SectionDiff {
+	var d SectionDiff
+	for name, n := range next {
+		if p, ok := prev[name]; !ok {
+			d.Added = append(d.Added, name)
+		} else if !reflect.DeepEqual(p, n) {
+			d.Changed = append(d.Changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	slices.Sort(d.Added)
+	slices.Sort(d.Changed)
+	slices.Sort(d.Removed)
+	return d
+}
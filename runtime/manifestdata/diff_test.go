@@ -0,0 +1,58 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package manifestdata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffManifests(t *testing.T) {
+	prev := &manifest.Manifest{
+		Models: map[string]manifest.ModelInfo{
+			"a": {Name: "a", SourceModel: "small"},
+			"b": {Name: "b", SourceModel: "other"},
+		},
+		Hosts: map[string]manifest.HostInfo{
+			"db":  manifest.PostgresqlHostInfo{Name: "db", ConnStr: "postgresql://localhost/a"},
+			"api": manifest.HTTPHostInfo{Name: "api", Endpoint: "https://example.com/"},
+		},
+	}
+	next := &manifest.Manifest{
+		Models: map[string]manifest.ModelInfo{
+			"a": {Name: "a", SourceModel: "small"},
+		},
+		Hosts: map[string]manifest.HostInfo{
+			"db":    manifest.PostgresqlHostInfo{Name: "db", ConnStr: "postgresql://localhost/b"},
+			"api":   manifest.HTTPHostInfo{Name: "api", Endpoint: "https://example.com/"},
+			"graph": manifest.DgraphHostInfo{Name: "graph", GrpcTarget: "localhost:9080"},
+		},
+		InputLimits: &manifest.InputLimitsInfo{},
+	}
+
+	diff := diffManifests(prev, next)
+	assert.Equal(t, "hosts: +graph ~db; models: -b; settings: inputLimits", diff.String())
+	assert.True(t, diff.HostsChanged())
+	assert.True(t, diff.IsHostStale("db"))
+	assert.False(t, diff.IsHostStale("api"))
+	assert.False(t, diff.IsHostStale("graph"))
+
+	assert.True(t, diffManifests(next, next).IsEmpty())
+
+	// without a diff, callbacks must assume that everything changed
+	unknown := GetManifestDiff(context.Background())
+	assert.Nil(t, unknown)
+	assert.True(t, unknown.IsHostStale("api"))
+	assert.True(t, unknown.HostsChanged())
+}
@@ -11,9 +11,13 @@ package manifestdata
 
 import (
 	"context"
+	"os"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/config"
 	"github.com/hypermodeinc/modus/runtime/logger"
 	"github.com/hypermodeinc/modus/runtime/storage"
 	"github.com/hypermodeinc/modus/runtime/utils"
@@ -23,6 +27,7 @@ const manifestFileName = "hypermode.json"
 
 var mu sync.RWMutex
 var man = &manifest.Manifest{}
+var loaded atomic.Bool
 
 func GetManifest() *manifest.Manifest {
 	mu.RLock()
@@ -36,9 +41,15 @@ func SetManifest(m *manifest.Manifest) {
 	man = m
 }
 
+// getOverlayFileName returns the name of the optional manifest overlay file for the current environment,
+// such as "hypermode.stage.json", whose contents are merged over the manifest file.
+func getOverlayFileName() string {
+	return manifest.OverlayFileName(manifestFileName, config.GetEnvironmentName())
+}
+
 func MonitorManifestFile(ctx context.Context) {
 	loadFile := func(file storage.FileInfo) error {
-		if file.Name != manifestFileName {
+		if file.Name != manifestFileName && file.Name != getOverlayFileName() {
 			return nil
 		}
 		err := loadManifest(ctx)
@@ -46,6 +57,11 @@ func MonitorManifestFile(ctx context.Context) {
 			logger.Info(ctx).
 				Str("filename", file.Name).
 				Msg("Loaded manifest file.")
+		} else if loaded.Load() {
+			logger.Err(ctx, err).
+				Str("filename", file.Name).
+				Bool("user_visible", true).
+				Msg("Failed to load manifest file.  The last valid manifest remains active.")
 		} else {
 			logger.Err(ctx, err).
 				Str("filename", file.Name).
@@ -56,10 +72,18 @@ func MonitorManifestFile(ctx context.Context) {
 	}
 
 	// NOTE: Removing the manifest file entirely is not currently supported.
+	// Removing the overlay file reloads the manifest without it.
+	removeFile := func(file storage.FileInfo) error {
+		if file.Name != getOverlayFileName() {
+			return nil
+		}
+		return loadFile(file)
+	}
 
 	sm := storage.NewStorageMonitor(".json")
 	sm.Added = loadFile
 	sm.Modified = loadFile
+	sm.Removed = removeFile
 	sm.Start(ctx)
 }
 
@@ -72,6 +96,21 @@ func loadManifest(ctx context.Context) error {
 		return err
 	}
 
+	bytes, err = applyOverlay(ctx, bytes)
+	if err != nil {
+		return err
+	}
+
+	bytes, err = manifest.InterpolateEnv(bytes, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	// An invalid manifest is rejected, and the last valid one remains active.
+	if err := manifest.ValidateManifest(bytes); err != nil {
+		return err
+	}
+
 	m, err := manifest.ReadManifest(bytes)
 	if err != nil {
 		return err
@@ -84,11 +123,58 @@ func loadManifest(ctx context.Context) error {
 			Msg("The manifest file is in a deprecated format.  Please update it to the current format.")
 	}
 
+	diff := diffManifests(GetManifest(), m)
+	if loaded.Load() {
+		if diff.IsEmpty() {
+			logger.Info(ctx).
+				Str("filename", manifestFileName).
+				Msg("The manifest has not changed.")
+			return nil
+		}
+		logger.Info(ctx).
+			Str("filename", manifestFileName).
+			Str("changes", diff.String()).
+			Bool("user_visible", true).
+			Msg("Applying manifest changes.")
+	}
+
 	// Only update the Manifest global when we have successfully read the manifest.
 	SetManifest(m)
+	loaded.Store(true)
 
-	// Trigger the manifest loaded event.
+	// Trigger the manifest loaded event, letting the callbacks apply only what changed.
+	ctx = context.WithValue(ctx, manifestDiffContextKey{}, diff)
 	err = triggerManifestLoaded(ctx)
 
 	return err
 }
+
+// applyOverlay merges the overlay file for the current environment over the manifest, if there is one.
+func applyOverlay(ctx context.Context, content []byte) ([]byte, error) {
+	overlayFileName := getOverlayFileName()
+
+	files, err := storage.ListFiles(ctx, ".json")
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(files, func(f storage.FileInfo) bool { return f.Name == overlayFileName }) {
+		return content, nil
+	}
+
+	overlay, err := storage.GetFileContents(ctx, overlayFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err = manifest.MergeOverlay(content, overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx).
+		Str("filename", overlayFileName).
+		Str("environment", config.GetEnvironmentName()).
+		Msg("Applied manifest overlay file.")
+
+	return content, nil
+}
@@ -73,6 +73,14 @@ var (
 			Help: "Number of function executions",
 		},
 	)
+	// FunctionExecutionsInFlightNum is a gauge of function executions in progress, suitable for autoscaling.
+	// # of series = 1
+	FunctionExecutionsInFlightNum = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "runtime_function_executions_in_flight_num",
+			Help: "A gauge of function executions currently in progress",
+		},
+	)
 	// FunctionExecutionDurationMilliseconds is a histogram of latencies for wasm function executions of user plugins.
 	// # of series = # of functions x 49
 	FunctionExecutionDurationMilliseconds = prometheus.NewHistogramVec(
@@ -105,6 +113,154 @@ var (
 			Help: "Number of dropped inference requests",
 		},
 	)
+
+	// ModelInvocationsNum is a counter of model invocations made by functions, by outcome.
+	// # of series = # of models x # of functions x 2
+	ModelInvocationsNum = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runtime_model_invocations_num",
+			Help: "Number of model invocations",
+		},
+		[]string{"model", "host", "function_name", "status"},
+	)
+	// ModelTokensNum is a counter of tokens consumed by model invocations, as reported by the model's provider.
+	// # of series = # of models x # of functions x 2
+	ModelTokensNum = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runtime_model_tokens_num",
+			Help: "Number of input and output tokens of model invocations",
+		},
+		[]string{"model", "host", "function_name", "type"},
+	)
+	// ModelInvocationDurationMilliseconds is a histogram of latencies of model providers.
+	// # of series = # of models x # of functions x 21
+	ModelInvocationDurationMilliseconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "runtime_model_invocation_duration_milliseconds",
+			Help: "A histogram of latencies for model invocations",
+			Buckets: []float64{
+				10, 25, 50, 100, 200, 300, 400, 500, 750, 1000, 1500,
+				2000, 3000, 4000, 5000, 7500, 10000, 15000, 20000, 40000, 60000,
+			},
+		},
+		[]string{"model", "host", "function_name"},
+	)
+	// ModelCostDollars is a counter of the estimated spend on model invocations, from the models' token prices.
+	// # of series = # of models x # of plugins
+	ModelCostDollars = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runtime_model_cost_dollars",
+			Help: "Estimated cost of model invocations, in US dollars",
+		},
+		[]string{"model", "plugin", "environment"},
+	)
+	// ModelModerationViolationsNum is a counter of the model inputs and outputs that violated a moderation policy.
+	// # of series = # of models with moderation x 2 stages x 2 actions
+	ModelModerationViolationsNum = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runtime_model_moderation_violations_num",
+			Help: "Number of model inputs and outputs that violated a moderation policy",
+		},
+		[]string{"model", "stage", "action"},
+	)
+
+	// GraphQLResponseCacheNum is a counter of the lookups of cacheable queries in the response cache, by result,
+	// from which the hit rate is computed.
+	// # of series = 2
+	GraphQLResponseCacheNum = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runtime_graphql_response_cache_num",
+			Help: "Number of cacheable GraphQL queries that were served from the response cache (hit) or executed (miss)",
+		},
+		[]string{"result"},
+	)
+
+	// RateLimitedRequestsNum is a counter of the requests and function invocations that were rejected,
+	// by the rate limit rule of the manifest that rejected them.
+	// # of series = # of rate limit rules
+	RateLimitedRequestsNum = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runtime_rate_limited_requests_num",
+			Help: "Number of requests and function invocations rejected by rate limits, by rule",
+		},
+		[]string{"rule"},
+	)
+
+	// BreakingSchemaChangesNum is a counter of the breaking changes found between a generated GraphQL schema
+	// and the schema that was served before it, whether or not the new schema was refused.
+	BreakingSchemaChangesNum = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "runtime_breaking_schema_changes_num",
+			Help: "Number of breaking changes found in newly generated GraphQL schemas",
+		},
+	)
+
+	// PluginVersionExecutionsNum is a counter of the function executions of each version of a plugin that has a canary,
+	// by outcome, so that the error rates of the versions can be compared.
+	PluginVersionExecutionsNum = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runtime_plugin_version_executions_num",
+			Help: "Number of function executions of each version of a plugin with a canary",
+		},
+		[]string{"plugin", "build_id", "status"},
+	)
+
+	// PluginVersionExecutionDurationMilliseconds is a histogram of the function latencies of each version of a plugin
+	// that has a canary.
+	PluginVersionExecutionDurationMilliseconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "runtime_plugin_version_execution_duration_milliseconds",
+			Help:    "A histogram of latencies for function executions of each version of a plugin with a canary",
+			Buckets: []float64{10, 20, 50, 100, 200, 300, 500, 750, 1000, 2000, 5000, 10000, 30000, 60000},
+		},
+		[]string{"plugin", "build_id"},
+	)
+
+	// UnverifiedPluginsNum is a counter of the plugins that were refused because they were unsigned,
+	// or their signature didn't match any of the trusted keys.
+	UnverifiedPluginsNum = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "runtime_unverified_plugins_num",
+			Help: "Number of plugins refused for a missing or invalid signature",
+		},
+	)
+
+	// CollectionItemsNum is a gauge of the items held in memory by each collection namespace.
+	// # of series = # of collection namespaces
+	CollectionItemsNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runtime_collection_items_num",
+			Help: "Number of items in each collection namespace",
+		},
+		[]string{"collection", "namespace"},
+	)
+	// CollectionVectorsNum is a gauge of the vectors held in memory by each search method of a collection namespace.
+	// # of series = # of collection namespaces x # of in-memory search methods
+	CollectionVectorsNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runtime_collection_vectors_num",
+			Help: "Number of vectors in each in-memory search method of a collection namespace",
+		},
+		[]string{"collection", "namespace", "search_method"},
+	)
+	// CollectionMemoryBytes is a gauge of the estimated memory used by the items and vectors of each collection namespace.
+	// # of series = # of collection namespaces
+	CollectionMemoryBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runtime_collection_memory_bytes",
+			Help: "Estimated memory used by the items and vectors of each collection namespace",
+		},
+		[]string{"collection", "namespace"},
+	)
+	// CollectionLastMutationSeconds is a gauge of the unix time at which the items of each collection namespace last changed.
+	// # of series = # of collection namespaces
+	CollectionLastMutationSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runtime_collection_last_mutation_seconds",
+			Help: "Unix time at which the items of each collection namespace last changed",
+		},
+		[]string{"collection", "namespace"},
+	)
 )
 
 func init() {
@@ -114,9 +270,25 @@ func init() {
 		httpRequestsDurationSeconds,
 		httpResponseSizeBytes,
 		FunctionExecutionsNum,
+		FunctionExecutionsInFlightNum,
 		FunctionExecutionDurationMilliseconds,
 		FunctionExecutionDurationMillisecondsSummary,
 		DroppedInferencesNum,
+		ModelInvocationsNum,
+		ModelTokensNum,
+		ModelInvocationDurationMilliseconds,
+		ModelCostDollars,
+		ModelModerationViolationsNum,
+		GraphQLResponseCacheNum,
+		RateLimitedRequestsNum,
+		BreakingSchemaChangesNum,
+		UnverifiedPluginsNum,
+		PluginVersionExecutionsNum,
+		PluginVersionExecutionDurationMilliseconds,
+		CollectionItemsNum,
+		CollectionVectorsNum,
+		CollectionMemoryBytes,
+		CollectionLastMutationSeconds,
 	)
 }
 
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+)
+
+// HandleAdminAuth protects the runtime's administrative endpoints.
+// In development, they are open. Otherwise, the request must carry the token
+// set in the MODUS_ADMIN_TOKEN environment variable as a bearer token.
+// If no token is set, the endpoints are disabled outside of development.
+func HandleAdminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adminToken := os.Getenv("MODUS_ADMIN_TOKEN")
+		if adminToken == "" {
+			if config.IsDevEnvironment() {
+				next.ServeHTTP(w, r)
+			} else {
+				http.Error(w, "Not Found", http.StatusNotFound)
+			}
+			return
+		}
+
+		tokenStr, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(tokenStr), []byte(adminToken)) != 1 {
+			logger.Warn(r.Context()).Str("path", r.URL.Path).Msg("Unauthorized request to admin endpoint.")
+			http.Error(w, "Access Denied", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AdminTokenHeader carries the admin token on requests to endpoints that use the Authorization header
+// for other purposes, such as the GraphQL endpoint, to unlock features that are otherwise reserved for admins.
+const AdminTokenHeader = "X-Modus-Admin-Token"
+
+// IsAdminRequest returns whether the request headers carry the token set in the MODUS_ADMIN_TOKEN
+// environment variable, in the X-Modus-Admin-Token header.
+func IsAdminRequest(header http.Header) bool {
+	adminToken := os.Getenv("MODUS_ADMIN_TOKEN")
+	token := header.Get(AdminTokenHeader)
+	return adminToken != "" && token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
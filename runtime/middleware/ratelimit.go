@@ -0,0 +1,289 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/cache"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/metrics"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/time/rate"
+)
+
+// ApiKeyHeader carries the API key that identifies a caller, for rate limiting.
+const ApiKeyHeader = "X-Api-Key"
+
+// apiKeysSecretName names the secret that holds the valid API keys, separated by commas.
+// API keys only identify callers when they are valid, since a caller could otherwise
+// escape their limits by sending a different key with each request.
+const apiKeysSecretName = "MODUS_API_KEYS"
+
+// maxRateLimitedCallers is the number of callers whose usage is tracked by each rule.
+// The usage of the callers that were least recently seen is discarded first.
+const maxRateLimitedCallers = 100_000
+
+const defaultQuotaPeriod = 24 * time.Hour
+
+type callerKeyContextKey struct{}
+
+// rateLimitRule tracks the usage of a rate limit rule of the manifest, by each caller and function.
+type rateLimitRule struct {
+	name        string
+	rps         float64
+	burst       int
+	quota       int
+	quotaPeriod time.Duration
+	usage       *cache.Cache[*callerUsage]
+	mu          sync.Mutex
+}
+
+type callerUsage struct {
+	tokens     *rate.Limiter
+	quotaStart time.Time
+	quotaUsed  int
+	mu         sync.Mutex
+}
+
+var rateLimits struct {
+	requests  []*rateLimitRule
+	functions map[string][]*rateLimitRule
+}
+var rateLimitsMutex sync.RWMutex
+
+const allFunctions = "*"
+
+// InitializeRateLimits loads the rate limit rules of the manifest whenever it changes.
+// The usage of each caller is reset when the rules are reloaded.
+func InitializeRateLimits() {
+	manifestdata.RegisterManifestLoadedCallback(func(ctx context.Context) error {
+		loadRateLimits(ctx, manifestdata.GetManifest().RateLimits)
+		return nil
+	})
+}
+
+func loadRateLimits(ctx context.Context, infos map[string]manifest.CallerRateLimitInfo) {
+	var requests []*rateLimitRule
+	functions := make(map[string][]*rateLimitRule)
+	for _, info := range infos {
+		rule := newRateLimitRule(ctx, info)
+		if rule == nil {
+			continue
+		}
+		if len(info.Functions) == 0 {
+			requests = append(requests, rule)
+		}
+		for _, fn := range info.Functions {
+			functions[fn] = append(functions[fn], rule)
+		}
+	}
+
+	rateLimitsMutex.Lock()
+	defer rateLimitsMutex.Unlock()
+	rateLimits.requests = requests
+	rateLimits.functions = functions
+}
+
+func newRateLimitRule(ctx context.Context, info manifest.CallerRateLimitInfo) *rateLimitRule {
+	if info.RequestsPerSecond <= 0 && info.Quota <= 0 {
+		return nil
+	}
+
+	rule := &rateLimitRule{
+		name:        info.Name,
+		rps:         info.RequestsPerSecond,
+		burst:       max(info.Burst, 1),
+		quota:       info.Quota,
+		quotaPeriod: defaultQuotaPeriod,
+		usage:       cache.New[*callerUsage](maxRateLimitedCallers, nil),
+	}
+
+	if info.QuotaPeriod != "" {
+		period, err := time.ParseDuration(info.QuotaPeriod)
+		if err != nil || period <= 0 {
+			logger.Warn(ctx).Str("rule", info.Name).Str("quotaPeriod", info.QuotaPeriod).Msg("Invalid quota period of rate limit rule.  Using the default of 24h.")
+		} else {
+			rule.quotaPeriod = period
+		}
+	}
+
+	return rule
+}
+
+// allow counts a call by the caller, and returns true if the rule allows it.
+// Otherwise, it returns how long the caller should wait before calling again.
+func (rule *rateLimitRule) allow(key string) (time.Duration, bool) {
+	u := rule.callerUsage(key)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+	if rule.quota > 0 {
+		if now.Sub(u.quotaStart) >= rule.quotaPeriod {
+			u.quotaStart = now
+			u.quotaUsed = 0
+		}
+		if u.quotaUsed >= rule.quota {
+			return u.quotaStart.Add(rule.quotaPeriod).Sub(now), false
+		}
+	}
+
+	if u.tokens != nil {
+		r := u.tokens.ReserveN(now, 1)
+		if delay := r.DelayFrom(now); delay > 0 {
+			r.CancelAt(now)
+			return delay, false
+		}
+	}
+
+	u.quotaUsed++
+	return 0, true
+}
+
+func (rule *rateLimitRule) callerUsage(key string) *callerUsage {
+	rule.mu.Lock()
+	defer rule.mu.Unlock()
+
+	if u, ok := rule.usage.Get(key); ok {
+		return u
+	}
+
+	u := &callerUsage{}
+	if rule.rps > 0 {
+		u.tokens = rate.NewLimiter(rate.Limit(rule.rps), rule.burst)
+	}
+	rule.usage.Set(key, u, 0)
+	return u
+}
+
+// HandleRateLimit enforces the rate limit rules of the manifest that apply to all requests to the API,
+// for the caller of the request.  Requests that exceed a limit are rejected with a 429 status, and a Retry-After
+// header.  It also identifies the caller to the rules that apply to functions, so it must follow HandleJWT.
+func HandleRateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		key := callerKey(ctx, r)
+
+		rateLimitsMutex.RLock()
+		rules := rateLimits.requests
+		rateLimitsMutex.RUnlock()
+
+		if retryAfter, rule, ok := allowAll(rules, key); !ok {
+			metrics.RateLimitedRequestsNum.WithLabelValues(rule).Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(RetryAfterSeconds(retryAfter)))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+
+		ctx = context.WithValue(ctx, callerKeyContextKey{}, key)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// CheckFunctionRateLimit enforces the rate limit rules of the manifest that apply to the function,
+// for the caller of the request.  If the caller exceeded a limit, it returns how long they should wait.
+func CheckFunctionRateLimit(ctx context.Context, fnName string) (time.Duration, bool) {
+	key, ok := ctx.Value(callerKeyContextKey{}).(string)
+	if !ok {
+		return 0, true
+	}
+
+	rateLimitsMutex.RLock()
+	rules := slices.Concat(rateLimits.functions[allFunctions], rateLimits.functions[fnName])
+	rateLimitsMutex.RUnlock()
+
+	retryAfter, rule, ok := allowAll(rules, fnName+"\x00"+key)
+	if !ok {
+		metrics.RateLimitedRequestsNum.WithLabelValues(rule).Inc()
+	}
+	return retryAfter, ok
+}
+
+// allowAll counts a call against each of the rules, and returns the name of the first rule that rejects it.
+func allowAll(rules []*rateLimitRule, key string) (time.Duration, string, bool) {
+	for _, rule := range rules {
+		if retryAfter, ok := rule.allow(key); !ok {
+			return retryAfter, rule.name, false
+		}
+	}
+	return 0, "", true
+}
+
+// callerKey identifies the caller of a request, by their valid API key, the subject of their JWT,
+// or their IP address, in that order.  API keys are hashed, so they aren't held in memory.
+func callerKey(ctx context.Context, r *http.Request) string {
+	if apiKey := r.Header.Get(ApiKeyHeader); apiKey != "" {
+		if hash := hashApiKey(apiKey); isValidApiKey(hash) {
+			return "key:" + hash
+		}
+	}
+	if sub := gjson.Get(GetJWTClaims(ctx), "sub").String(); sub != "" {
+		return "sub:" + sub
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return "ip:" + host
+	}
+	return "ip:" + r.RemoteAddr
+}
+
+func hashApiKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:16])
+}
+
+var apiKeys struct {
+	secret string
+	hashes map[string]bool
+	mu     sync.Mutex
+}
+
+// isValidApiKey reports whether the hash is that of one of the API keys in the secret.
+// The hashes are recomputed when the secret changes.
+func isValidApiKey(hash string) bool {
+	secret, err := secrets.GetSecretValue(apiKeysSecretName)
+	if err != nil {
+		return false
+	}
+
+	apiKeys.mu.Lock()
+	defer apiKeys.mu.Unlock()
+
+	if apiKeys.hashes == nil || apiKeys.secret != secret {
+		apiKeys.hashes = make(map[string]bool)
+		for _, key := range strings.Split(secret, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				apiKeys.hashes[hashApiKey(key)] = true
+			}
+		}
+		apiKeys.secret = secret
+	}
+
+	return apiKeys.hashes[hash]
+}
+
+// RetryAfterSeconds converts a wait to the whole number of seconds of a Retry-After header.
+func RetryAfterSeconds(d time.Duration) int {
+	return max(int(math.Ceil(d.Seconds())), 1)
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func doRateLimitedRequest(handler http.Handler, apiKey string) int {
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if apiKey != "" {
+		req.Header.Set(ApiKeyHeader, apiKey)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimit_ApiKeys(t *testing.T) {
+	t.Setenv(apiKeysSecretName, "key-a, key-b")
+	secrets.Initialize(context.Background())
+
+	loadRateLimits(context.Background(), map[string]manifest.CallerRateLimitInfo{
+		"per-caller": {Name: "per-caller", Quota: 2},
+	})
+	t.Cleanup(func() { loadRateLimits(context.Background(), nil) })
+
+	handler := HandleRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// a caller that rotates unknown keys is identified by their IP address
+	assert.Equal(t, http.StatusOK, doRateLimitedRequest(handler, "random-1"))
+	assert.Equal(t, http.StatusOK, doRateLimitedRequest(handler, "random-2"))
+	for i := 3; i <= 5; i++ {
+		assert.Equal(t, http.StatusTooManyRequests, doRateLimitedRequest(handler, fmt.Sprintf("random-%d", i)))
+	}
+
+	// valid keys identify callers, regardless of their IP address
+	assert.Equal(t, http.StatusOK, doRateLimitedRequest(handler, "key-a"))
+	assert.Equal(t, http.StatusOK, doRateLimitedRequest(handler, "key-a"))
+	assert.Equal(t, http.StatusTooManyRequests, doRateLimitedRequest(handler, "key-a"))
+	assert.Equal(t, http.StatusOK, doRateLimitedRequest(handler, "key-b"))
+}
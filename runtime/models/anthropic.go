@@ -0,0 +1,187 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// See https://docs.anthropic.com/en/api/messages
+const (
+	anthropicApiVersion       = "2023-06-01"
+	anthropicDefaultMaxTokens = 4096
+)
+
+// anthropicProvider adapts requests to the Anthropic Messages API.
+// The host should have the https://api.anthropic.com/v1/messages endpoint, and provide the API key with an "x-api-key" header.
+type anthropicProvider struct {
+	defaultProvider
+}
+
+func (anthropicProvider) prepareInput(model *manifest.ModelInfo, input string) (string, error) {
+	if !gjson.Valid(input) {
+		return "", fmt.Errorf("model input is not valid JSON")
+	}
+
+	// The model and max_tokens fields are required by the API, but can be inferred.
+	var err error
+	if !gjson.Get(input, "model").Exists() && model.SourceModel != "" {
+		if input, err = sjson.Set(input, "model", model.SourceModel); err != nil {
+			return "", err
+		}
+	}
+	if !gjson.Get(input, "max_tokens").Exists() {
+		if input, err = sjson.Set(input, "max_tokens", anthropicDefaultMaxTokens); err != nil {
+			return "", err
+		}
+	}
+
+	return input, nil
+}
+
+func (anthropicProvider) prepareRequest(ctx context.Context, model *manifest.ModelInfo, req *http.Request) error {
+	if req.Header.Get("anthropic-version") == "" {
+		req.Header.Set("anthropic-version", anthropicApiVersion)
+	}
+	return nil
+}
+
+// encodeChatRequest converts the request to the Anthropic Messages API.
+// System messages become the system prompt, tool calls become "tool_use" blocks, and tool results become
+// "tool_result" blocks in a user message.  Consecutive messages with the same role are combined, as the API requires.
+// See https://docs.anthropic.com/en/docs/build-with-claude/tool-use
+func (anthropicProvider) encodeChatRequest(model *manifest.ModelInfo, req *ChatRequest) (string, error) {
+	var system []string
+	var messages []map[string]any
+	addBlocks := func(role string, blocks ...map[string]any) {
+		if n := len(messages); n > 0 && messages[n-1]["role"] == role {
+			messages[n-1]["content"] = append(messages[n-1]["content"].([]map[string]any), blocks...)
+			return
+		}
+		messages = append(messages, map[string]any{"role": role, "content": blocks})
+	}
+
+	for _, m := range req.Messages {
+		switch m.Role {
+		case "system":
+			system = append(system, m.Content)
+		case "user":
+			addBlocks("user", map[string]any{"type": "text", "text": m.Content})
+		case "assistant":
+			var blocks []map[string]any
+			if m.Content != "" {
+				blocks = append(blocks, map[string]any{"type": "text", "text": m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				args := json.RawMessage(tc.Arguments)
+				if len(args) == 0 {
+					args = json.RawMessage("{}")
+				} else if !json.Valid(args) {
+					return "", fmt.Errorf("arguments of tool call %s are not valid JSON", tc.Id)
+				}
+				blocks = append(blocks, map[string]any{"type": "tool_use", "id": tc.Id, "name": tc.Name, "input": args})
+			}
+			addBlocks("assistant", blocks...)
+		case "tool":
+			addBlocks("user", map[string]any{"type": "tool_result", "tool_use_id": m.ToolCallId, "content": m.Content})
+		}
+	}
+
+	// Anthropic models don't have a structured output mode, so they are instructed to respond with JSON.
+	if req.ResponseFormat != nil {
+		system = append(system, req.ResponseFormat.instructions())
+	}
+
+	input := map[string]any{"messages": messages}
+	if len(system) > 0 {
+		input["system"] = strings.Join(system, "\n\n")
+	}
+
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]any, len(req.Tools))
+		for i, t := range req.Tools {
+			tool := map[string]any{"name": t.Name, "input_schema": t.toolParameters()}
+			if t.Description != "" {
+				tool["description"] = t.Description
+			}
+			tools[i] = tool
+		}
+		input["tools"] = tools
+
+		switch req.ToolChoice {
+		case "":
+		case toolChoiceAuto, toolChoiceNone:
+			input["tool_choice"] = map[string]any{"type": req.ToolChoice}
+		case toolChoiceRequired:
+			input["tool_choice"] = map[string]any{"type": "any"}
+		default:
+			input["tool_choice"] = map[string]any{"type": "tool", "name": req.ToolChoice}
+		}
+	}
+
+	if req.MaxTokens > 0 {
+		input["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		input["temperature"] = *req.Temperature
+	}
+
+	data, err := utils.JsonSerialize(input)
+	return string(data), err
+}
+
+func (anthropicProvider) decodeChatResponse(output string) (*ChatResponse, error) {
+	resp := &ChatResponse{}
+
+	var sb strings.Builder
+	for _, block := range gjson.Get(output, "content").Array() {
+		switch block.Get("type").String() {
+		case "text":
+			sb.WriteString(block.Get("text").String())
+		case "tool_use":
+			resp.ToolCalls = append(resp.ToolCalls, &ToolCall{
+				Id:        block.Get("id").String(),
+				Name:      block.Get("name").String(),
+				Arguments: block.Get("input").Raw,
+			})
+		}
+	}
+	resp.Content = sb.String()
+
+	switch reason := gjson.Get(output, "stop_reason").String(); reason {
+	case "end_turn", "stop_sequence":
+		resp.FinishReason = "stop"
+	case "max_tokens":
+		resp.FinishReason = "length"
+	case "tool_use":
+		resp.FinishReason = "tool_calls"
+	default:
+		resp.FinishReason = reason
+	}
+
+	if usage := gjson.Get(output, "usage"); usage.Exists() {
+		resp.Usage = &ChatUsage{
+			InputTokens:  int(usage.Get("input_tokens").Int()),
+			OutputTokens: int(usage.Get("output_tokens").Int()),
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicPrepareInput(t *testing.T) {
+	model := &manifest.ModelInfo{SourceModel: "claude-3-5-sonnet-20240620", Provider: "anthropic"}
+	p := getModelProvider(model)
+
+	input, err := p.prepareInput(model, `{"messages":[{"role":"user","content":"hi"}]}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"messages":[{"role":"user","content":"hi"}],"model":"claude-3-5-sonnet-20240620","max_tokens":4096}`, input)
+
+	// fields given by the caller are preserved
+	input, err = p.prepareInput(model, `{"model":"claude-3-haiku-20240307","max_tokens":100,"messages":[]}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model":"claude-3-haiku-20240307","max_tokens":100,"messages":[]}`, input)
+
+	_, err = p.prepareInput(model, `not json`)
+	assert.Error(t, err)
+}
+
+func TestInvokeAnthropicModel(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, anthropicApiVersion, r.Header.Get("anthropic-version"))
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"model":"claude-3-5-sonnet-20240620","max_tokens":4096,"system":"be brief","messages":[{"role":"user","content":"hi"}]}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"type":"message","role":"assistant","content":[{"type":"text","text":"hello"}]}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["anthropic"] = manifest.HTTPHostInfo{
+		Name:     "anthropic",
+		Endpoint: tsrv.URL,
+		Headers:  map[string]string{"x-api-key": "test-key"},
+	}
+	md.Models["claude"] = manifest.ModelInfo{
+		Name:        "claude",
+		SourceModel: "claude-3-5-sonnet-20240620",
+		Provider:    "anthropic",
+		Host:        "anthropic",
+	}
+	defer func() {
+		delete(md.Hosts, "anthropic")
+		delete(md.Models, "claude")
+	}()
+
+	output, err := InvokeModel(context.Background(), "claude", `{"system":"be brief","messages":[{"role":"user","content":"hi"}]}`)
+	require.NoError(t, err)
+	assert.Contains(t, output, `"text":"hello"`)
+}
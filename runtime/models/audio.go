@@ -0,0 +1,364 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/hosts"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+// TranscriptionRequest is a request to transcribe audio to text.  The audio is base64 encoded in JSON.
+type TranscriptionRequest struct {
+	Audio       []byte   `json:"audio"`
+	FileName    string   `json:"fileName,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Prompt      string   `json:"prompt,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+type TranscriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// SpeechRequest is a request to synthesize speech from text.  If an output URL is given, the audio is uploaded
+// to it with a PUT request as it is received from the model, rather than being returned to the function.
+// The URL must belong to a host defined in the manifest, such as a presigned object storage URL.
+type SpeechRequest struct {
+	Text      string   `json:"text"`
+	Voice     string   `json:"voice,omitempty"`
+	Format    string   `json:"format,omitempty"`
+	Speed     *float64 `json:"speed,omitempty"`
+	OutputUrl string   `json:"outputUrl,omitempty"`
+}
+
+type SpeechResponse struct {
+	Audio    []byte `json:"audio,omitempty"`
+	Url      string `json:"url,omitempty"`
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+}
+
+const defaultSpeechVoice = "alloy"
+
+var audioMimeTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"opus": "audio/ogg",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"wav":  "audio/wav",
+	"pcm":  "audio/pcm",
+}
+
+// TranscribeAudio sends audio to a transcription model, such as Whisper, and returns the text as JSON.
+// The audio is uploaded as multipart form data, following the OpenAI audio API.
+// See https://platform.openai.com/docs/api-reference/audio/createTranscription
+func TranscribeAudio(ctx context.Context, modelName string, request string) (string, error) {
+	model, err := GetModel(modelName)
+	if err != nil {
+		return "", err
+	}
+
+	var req TranscriptionRequest
+	if err := utils.JsonDeserialize([]byte(request), &req); err != nil {
+		return "", fmt.Errorf("invalid transcription request: %w", err)
+	}
+	if len(req.Audio) == 0 {
+		return "", errors.New("invalid transcription request: audio is required")
+	}
+
+	return invokeModelWithFallbacks(ctx, model, func(ctx context.Context, m *manifest.ModelInfo) (string, error) {
+		if err := checkAudioSupport(m); err != nil {
+			return "", err
+		}
+
+		body, contentType, err := encodeTranscriptionRequest(m, &req)
+		if err != nil {
+			return "", err
+		}
+
+		startTime := utils.GetTime()
+		resp, release, err := sendModelRequest(ctx, m, body, contentType)
+		if err != nil {
+			recordModelInvocation(ctx, m, nil, utils.GetTime().Sub(startTime), err)
+			return "", err
+		}
+		defer release()
+		defer resp.Body.Close()
+
+		output, err := io.ReadAll(resp.Body)
+		endTime := utils.GetTime()
+		recordModelInvocation(ctx, m, nil, endTime.Sub(startTime), err)
+		if err != nil {
+			return "", err
+		}
+
+		text := gjson.GetBytes(output, "text")
+		if !text.Exists() {
+			return "", errors.New("model output has no text")
+		}
+
+		result, err := utils.JsonSerialize(&TranscriptionResponse{Text: text.String()})
+		if err != nil {
+			return "", err
+		}
+
+		input := map[string]any{"fileName": req.FileName, "size": len(req.Audio)}
+		db.WriteInferenceHistory(ctx, m, input, string(result), startTime, endTime)
+
+		return string(result), nil
+	})
+}
+
+// SynthesizeSpeech sends text to a text-to-speech model, and returns the audio or the URL it was uploaded to, as JSON.
+// See https://platform.openai.com/docs/api-reference/audio/createSpeech
+func SynthesizeSpeech(ctx context.Context, modelName string, request string) (string, error) {
+	model, err := GetModel(modelName)
+	if err != nil {
+		return "", err
+	}
+
+	var req SpeechRequest
+	if err := utils.JsonDeserialize([]byte(request), &req); err != nil {
+		return "", fmt.Errorf("invalid speech request: %w", err)
+	}
+	if req.Text == "" {
+		return "", errors.New("invalid speech request: text is required")
+	}
+	if req.Format != "" && audioMimeTypes[req.Format] == "" {
+		return "", fmt.Errorf("invalid speech request: unknown audio format: %s", req.Format)
+	}
+
+	var outputHost *manifest.HTTPHostInfo
+	if req.OutputUrl != "" {
+		if outputHost, err = hosts.GetHttpHostForUrl(req.OutputUrl); err != nil {
+			return "", fmt.Errorf("invalid speech request: %w", err)
+		}
+	}
+
+	return invokeModelWithFallbacks(ctx, model, func(ctx context.Context, m *manifest.ModelInfo) (string, error) {
+		if err := checkAudioSupport(m); err != nil {
+			return "", err
+		}
+
+		input := map[string]any{"input": req.Text, "voice": req.Voice}
+		if req.Voice == "" {
+			input["voice"] = defaultSpeechVoice
+		}
+		if m.SourceModel != "" {
+			input["model"] = m.SourceModel
+		}
+		if req.Format != "" {
+			input["response_format"] = req.Format
+		}
+		if req.Speed != nil {
+			input["speed"] = *req.Speed
+		}
+		body, err := utils.JsonSerialize(input)
+		if err != nil {
+			return "", err
+		}
+
+		startTime := utils.GetTime()
+		resp, release, err := sendModelRequest(ctx, m, bytes.NewReader(body), "application/json")
+		if err != nil {
+			recordModelInvocation(ctx, m, nil, utils.GetTime().Sub(startTime), err)
+			return "", err
+		}
+		defer release()
+		defer resp.Body.Close()
+
+		result := &SpeechResponse{MimeType: getAudioMimeType(resp, req.Format)}
+		if outputHost != nil {
+			result.Size, err = uploadAudio(ctx, outputHost, req.OutputUrl, result.MimeType, resp)
+			result.Url = req.OutputUrl
+		} else {
+			result.Audio, err = io.ReadAll(resp.Body)
+			result.Size = int64(len(result.Audio))
+		}
+		endTime := utils.GetTime()
+		recordModelInvocation(ctx, m, nil, endTime.Sub(startTime), err)
+		if err != nil {
+			return "", err
+		}
+
+		output := map[string]any{"mimeType": result.MimeType, "size": result.Size, "url": result.Url}
+		db.WriteInferenceHistory(ctx, m, string(body), output, startTime, endTime)
+
+		data, err := utils.JsonSerialize(result)
+		return string(data), err
+	})
+}
+
+// checkAudioSupport returns an error unless the model follows the OpenAI audio API.
+func checkAudioSupport(model *manifest.ModelInfo) error {
+	if model.Host != bedrockHost && model.Host != hosts.HypermodeHost {
+		switch getModelProvider(model).(type) {
+		case defaultProvider, azureOpenAIProvider, openAICompatibleProvider:
+			return nil
+		}
+	}
+	return fmt.Errorf("audio is not supported for model %s", model.Name)
+}
+
+func encodeTranscriptionRequest(model *manifest.ModelInfo, req *TranscriptionRequest) (io.Reader, string, error) {
+	fileName := req.FileName
+	if fileName == "" {
+		fileName = "audio"
+	}
+
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+
+	fw, err := w.CreateFormFile("file", fileName)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := fw.Write(req.Audio); err != nil {
+		return nil, "", err
+	}
+
+	fields := map[string]string{
+		"model":           model.SourceModel,
+		"language":        req.Language,
+		"prompt":          req.Prompt,
+		"response_format": "json",
+	}
+	if req.Temperature != nil {
+		fields["temperature"] = strconv.FormatFloat(*req.Temperature, 'f', -1, 64)
+	}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := w.WriteField(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf, w.FormDataContentType(), nil
+}
+
+// sendModelRequest sends the body to the model's endpoint, and returns the response without reading it,
+// so that large responses such as audio can be streamed.  The caller must close the body, and then call release.
+func sendModelRequest(ctx context.Context, model *manifest.ModelInfo, body io.Reader, contentType string) (*http.Response, func(), error) {
+	endpoint, host, err := getModelEndpointAndHost(model)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	release, err := hosts.AcquireRateLimit(ctx, host)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("error creating request: %w", err)
+	}
+	if err := prepareModelRequest(ctx, model, host, req); err != nil {
+		release()
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := utils.HttpClient().Do(req)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		defer release()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, nil, &utils.HttpStatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header,
+			Body:       body,
+		}
+	}
+
+	return resp, release, nil
+}
+
+func getAudioMimeType(resp *http.Response, format string) string {
+	if ct := resp.Header.Get("Content-Type"); ct != "" && ct != "application/octet-stream" {
+		return ct
+	}
+	if format == "" {
+		format = "mp3"
+	}
+	return audioMimeTypes[format]
+}
+
+// uploadAudio streams the audio from the model's response to the output URL.  Object storage requires the length
+// of the upload, so if the model doesn't give it, the audio is first written to a temporary file.
+func uploadAudio(ctx context.Context, host *manifest.HTTPHostInfo, url, mimeType string, resp *http.Response) (int64, error) {
+	var body io.Reader = resp.Body
+	size := resp.ContentLength
+
+	if size < 0 {
+		f, err := os.CreateTemp("", "modus-audio-*")
+		if err != nil {
+			return 0, err
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		if size, err = io.Copy(f, resp.Body); err != nil {
+			return 0, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		body = f
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", mimeType)
+	if err := secrets.ApplyHostSecretsToHttpRequest(ctx, host, req); err != nil {
+		return 0, err
+	}
+
+	uploadResp, err := utils.HttpClient().Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error uploading audio: %w", err)
+	}
+	defer uploadResp.Body.Close()
+
+	if uploadResp.StatusCode < 200 || uploadResp.StatusCode >= 300 {
+		return 0, fmt.Errorf("error uploading audio: %s", uploadResp.Status)
+	}
+	return size, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+var testAudioBytes = []byte("ID3\x04\x00\x00\x00\x00\x00\x00test audio")
+
+func setupAudioModel(t *testing.T, handler http.Handler, path string) {
+	tsrv := httptest.NewServer(handler)
+	t.Cleanup(tsrv.Close)
+
+	md := manifestdata.GetManifest()
+	md.Hosts["audio"] = manifest.HTTPHostInfo{Name: "audio", BaseURL: tsrv.URL + "/"}
+	md.Models["audio-model"] = manifest.ModelInfo{
+		Name:        "audio-model",
+		Provider:    "openai-compatible",
+		Host:        "audio",
+		SourceModel: "whisper-1",
+		Path:        path,
+	}
+	t.Cleanup(func() {
+		delete(md.Hosts, "audio")
+		delete(md.Models, "audio-model")
+	})
+}
+
+func TestTranscribeAudio(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audio/transcriptions", r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "whisper-1", r.FormValue("model"))
+		assert.Equal(t, "en", r.FormValue("language"))
+		assert.Equal(t, "json", r.FormValue("response_format"))
+
+		f, fh, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer f.Close()
+		assert.Equal(t, "speech.mp3", fh.Filename)
+		data, _ := io.ReadAll(f)
+		assert.Equal(t, testAudioBytes, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"text":"Hello world."}`))
+	})
+	setupAudioModel(t, handler, "v1/audio/transcriptions")
+
+	b64 := base64.StdEncoding.EncodeToString(testAudioBytes)
+	output, err := TranscribeAudio(context.Background(), "audio-model", `{"audio":"`+b64+`","fileName":"speech.mp3","language":"en"}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text":"Hello world."}`, output)
+}
+
+func TestTranscribeAudioRequiresAudio(t *testing.T) {
+	setupAudioModel(t, http.NotFoundHandler(), "v1/audio/transcriptions")
+
+	_, err := TranscribeAudio(context.Background(), "audio-model", `{"fileName":"speech.mp3"}`)
+	assert.Error(t, err)
+}
+
+func TestSynthesizeSpeech(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audio/speech", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"model":"whisper-1","input":"Hello world.","voice":"alloy","response_format":"mp3"}`, string(body))
+
+		w.Header().Set("Content-Type", "audio/mpeg")
+		_, _ = w.Write(testAudioBytes)
+	})
+	setupAudioModel(t, handler, "v1/audio/speech")
+
+	output, err := SynthesizeSpeech(context.Background(), "audio-model", `{"text":"Hello world.","format":"mp3"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "audio/mpeg", gjson.Get(output, "mimeType").String())
+	assert.Equal(t, int64(len(testAudioBytes)), gjson.Get(output, "size").Int())
+
+	audio, err := base64.StdEncoding.DecodeString(gjson.Get(output, "audio").String())
+	require.NoError(t, err)
+	assert.Equal(t, testAudioBytes, audio)
+}
+
+func TestSynthesizeSpeechToOutputUrl(t *testing.T) {
+	modelHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// flushing before writing forces a chunked response, with an unknown length
+		w.Header().Set("Content-Type", "audio/wav")
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(testAudioBytes)
+	})
+	setupAudioModel(t, modelHandler, "v1/audio/speech")
+
+	var uploaded []byte
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "audio/wav", r.Header.Get("Content-Type"))
+		assert.Equal(t, int64(len(testAudioBytes)), r.ContentLength)
+		assert.Equal(t, "Bearer storage-token", r.Header.Get("Authorization"))
+		uploaded, _ = io.ReadAll(r.Body)
+	}))
+	defer storage.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["storage"] = manifest.HTTPHostInfo{
+		Name:    "storage",
+		BaseURL: storage.URL + "/",
+		Headers: map[string]string{"Authorization": "Bearer storage-token"},
+	}
+	defer delete(md.Hosts, "storage")
+
+	url := storage.URL + "/bucket/speech.wav"
+	output, err := SynthesizeSpeech(context.Background(), "audio-model", `{"text":"Hello world.","outputUrl":"`+url+`"}`)
+	require.NoError(t, err)
+	assert.Equal(t, url, gjson.Get(output, "url").String())
+	assert.False(t, gjson.Get(output, "audio").Exists())
+	assert.Equal(t, testAudioBytes, uploaded)
+}
+
+func TestSynthesizeSpeechRejectsUnknownOutputHost(t *testing.T) {
+	setupAudioModel(t, http.NotFoundHandler(), "v1/audio/speech")
+
+	_, err := SynthesizeSpeech(context.Background(), "audio-model", `{"text":"Hello.","outputUrl":"https://example.com/speech.mp3"}`)
+	assert.Error(t, err)
+}
+
+func TestCheckAudioSupport(t *testing.T) {
+	assert.NoError(t, checkAudioSupport(&manifest.ModelInfo{Host: "openai"}))
+	assert.NoError(t, checkAudioSupport(&manifest.ModelInfo{Host: "azure", Provider: "azure-openai"}))
+	assert.Error(t, checkAudioSupport(&manifest.ModelInfo{Host: "anthropic", Provider: "anthropic"}))
+	assert.Error(t, checkAudioSupport(&manifest.ModelInfo{Host: bedrockHost}))
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+)
+
+// azureOpenAIDefaultApiVersion is the Azure OpenAI API version used when the model doesn't specify one.
+const azureOpenAIDefaultApiVersion = "2024-06-01"
+
+// azureOpenAIProvider routes requests to a deployment of an Azure OpenAI resource.
+// See https://learn.microsoft.com/azure/ai-services/openai/reference
+//
+// The host's base URL is the resource endpoint, such as "https://my-resource.openai.azure.com/".
+// The host authenticates with either an "api-key" header, or with Azure AD credentials.
+// Requests and responses otherwise follow the OpenAI API, so the input is sent as-is.
+type azureOpenAIProvider struct {
+	defaultProvider
+}
+
+// getEndpoint composes the URL of the model's deployment, such as
+// "https://my-resource.openai.azure.com/openai/deployments/my-gpt-4o/chat/completions?api-version=2024-06-01".
+// A host with an endpoint instead of a base URL is used as-is, except that the API version is added if missing.
+func (azureOpenAIProvider) getEndpoint(model *manifest.ModelInfo, host *manifest.HTTPHostInfo) (string, error) {
+	if host.BaseURL != "" && host.Endpoint != "" {
+		return "", fmt.Errorf("specify either base URL or endpoint for a host, not both")
+	}
+
+	endpoint := host.Endpoint
+	if host.BaseURL != "" {
+		deployment := model.Deployment
+		if deployment == "" {
+			deployment = model.SourceModel
+		}
+		if deployment == "" {
+			return "", fmt.Errorf("a deployment name or source model is required for Azure OpenAI models")
+		}
+
+		operation := strings.Trim(model.Path, "/")
+		if operation == "" {
+			operation = "chat/completions"
+		}
+
+		endpoint = fmt.Sprintf("%s/openai/deployments/%s/%s", strings.TrimRight(host.BaseURL, "/"), url.PathEscape(deployment), operation)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid Azure OpenAI endpoint: %w", err)
+	}
+
+	q := u.Query()
+	if model.ApiVersion != "" {
+		q.Set("api-version", model.ApiVersion)
+	} else if !q.Has("api-version") {
+		q.Set("api-version", azureOpenAIDefaultApiVersion)
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
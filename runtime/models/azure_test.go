@@ -0,0 +1,94 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureOpenAIEndpoint(t *testing.T) {
+	host := &manifest.HTTPHostInfo{Name: "azure", BaseURL: "https://my-resource.openai.azure.com/"}
+	p := getModelProvider(&manifest.ModelInfo{Provider: "azure-openai"})
+
+	tests := []struct {
+		model    manifest.ModelInfo
+		expected string
+	}{
+		{
+			manifest.ModelInfo{SourceModel: "gpt-4o"},
+			"https://my-resource.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-06-01",
+		},
+		{
+			manifest.ModelInfo{SourceModel: "text-embedding-3-small", Deployment: "embeddings", Path: "embeddings", ApiVersion: "2024-10-21"},
+			"https://my-resource.openai.azure.com/openai/deployments/embeddings/embeddings?api-version=2024-10-21",
+		},
+	}
+
+	for _, tt := range tests {
+		endpoint, err := p.getEndpoint(&tt.model, host)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, endpoint)
+	}
+
+	// a full endpoint is used as-is, keeping its API version
+	host = &manifest.HTTPHostInfo{Name: "azure", Endpoint: "https://example.com/openai/deployments/x/chat/completions?api-version=2023-05-15"}
+	endpoint, err := p.getEndpoint(&manifest.ModelInfo{SourceModel: "gpt-4o"}, host)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/openai/deployments/x/chat/completions?api-version=2023-05-15", endpoint)
+}
+
+func TestInvokeAzureOpenAIModel(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/my-gpt-4o/chat/completions", r.URL.Path)
+		assert.Equal(t, "2024-06-01", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "test-key", r.Header.Get("api-key"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hello"}}]}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["azure"] = manifest.HTTPHostInfo{
+		Name:    "azure",
+		BaseURL: tsrv.URL,
+		Headers: map[string]string{"api-key": "test-key"},
+	}
+	md.Models["azure-gpt"] = manifest.ModelInfo{
+		Name:        "azure-gpt",
+		SourceModel: "gpt-4o",
+		Provider:    "azure-openai",
+		Host:        "azure",
+		Deployment:  "my-gpt-4o",
+	}
+	defer func() {
+		delete(md.Hosts, "azure")
+		delete(md.Models, "azure-gpt")
+	}()
+
+	output, err := InvokeModel(context.Background(), "azure-gpt", `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+	require.NoError(t, err)
+	assert.Contains(t, output, `"content":"hello"`)
+}
@@ -12,6 +12,7 @@ package models
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/hypermodeinc/modus/lib/manifest"
 	hyp_aws "github.com/hypermodeinc/modus/runtime/aws"
@@ -20,33 +21,144 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
+// bedrockHost is the name of the host used for models on AWS Bedrock.
+// Requests are signed with credentials from the default AWS credential chain.
+const bedrockHost = "aws-bedrock"
+
+// bedrockAnthropicVersion is the Anthropic API version required by Anthropic models on Bedrock.
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// getBedrockModelId returns the Bedrock model id, such as "anthropic.claude-3-5-sonnet-20240620-v1:0".
+// The model's source model can be the full id, or the provider can be given separately.
+func getBedrockModelId(model *manifest.ModelInfo) string {
+	if model.Provider == "" {
+		return model.SourceModel
+	}
+	return fmt.Sprintf("%s.%s", model.Provider, model.SourceModel)
+}
+
+// getBedrockModelFamily returns the provider part of the model id, such as "anthropic", "amazon" or "meta".
+// The region prefix of cross-region inference profiles, such as "us.", is ignored.
+func getBedrockModelFamily(modelId string) string {
+	parts := strings.Split(modelId, ".")
+	if len(parts) > 2 && len(parts[0]) <= 4 {
+		return parts[1]
+	}
+	return parts[0]
+}
+
+// prepareBedrockInput adapts the input to the request body expected by Bedrock for the model's family.
+// Amazon Titan and Meta Llama models accept their native request bodies as-is.
+func prepareBedrockInput(model *manifest.ModelInfo, input string) (string, error) {
+	if !gjson.Valid(input) {
+		return "", fmt.Errorf("model input is not valid JSON")
+	}
+
+	if getBedrockModelFamily(getBedrockModelId(model)) != "anthropic" {
+		return input, nil
+	}
+
+	// Anthropic models on Bedrock take the model from the request path, and the API version in the body.
+	var err error
+	if gjson.Get(input, "model").Exists() {
+		if input, err = sjson.Delete(input, "model"); err != nil {
+			return "", err
+		}
+	}
+	if !gjson.Get(input, "anthropic_version").Exists() {
+		if input, err = sjson.Set(input, "anthropic_version", bedrockAnthropicVersion); err != nil {
+			return "", err
+		}
+	}
+	if !gjson.Get(input, "max_tokens").Exists() {
+		if input, err = sjson.Set(input, "max_tokens", anthropicDefaultMaxTokens); err != nil {
+			return "", err
+		}
+	}
+
+	return input, nil
+}
+
+func getBedrockClient(ctx context.Context) (*bedrockruntime.Client, error) {
+	cfg, err := hyp_aws.LoadAwsConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bedrockruntime.NewFromConfig(cfg), nil
+}
+
 func invokeAwsBedrockModel(ctx context.Context, model *manifest.ModelInfo, input string) (output string, err error) {
 
 	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
 
-	// NOTE: Bedrock support is experimental, and not advertised to users.
-	// It currently uses the same AWS credentials as the Runtime.
-	// In the future, we will support user-provided credentials for Bedrock.
-
-	cfg := hyp_aws.GetAwsConfig() // TODO, connect to AWS using the user-provided credentials
-	client := bedrockruntime.NewFromConfig(cfg)
+	input, err = prepareBedrockInput(model, input)
+	if err != nil {
+		return "", err
+	}
 
-	modelId := fmt.Sprintf("%s.%s", model.Provider, model.SourceModel)
+	client, err := getBedrockClient(ctx)
+	if err != nil {
+		return "", err
+	}
 
 	startTime := utils.GetTime()
 	result, err := client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
-		ModelId:     &modelId,
+		ModelId:     aws.String(getBedrockModelId(model)),
 		ContentType: aws.String("application/json"),
+		Accept:      aws.String("application/json"),
 		Body:        []byte(input),
 	})
 	endTime := utils.GetTime()
 
+	if err != nil {
+		recordModelInvocation(ctx, model, nil, endTime.Sub(startTime), err)
+		return "", err
+	}
+
 	output = string(result.Body)
+	recordModelInvocation(ctx, model, output, endTime.Sub(startTime), nil)
 
 	db.WriteInferenceHistory(ctx, model, input, output, startTime, endTime)
 
-	return output, err
+	return output, nil
+}
+
+// startBedrockStream invokes the model with a response stream.
+// Each chunk of the stream is a JSON object, in the model's native streaming format.
+func startBedrockStream(ctx context.Context, model *manifest.ModelInfo, input string) (streamReader, error) {
+	client, err := getBedrockClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
+		ModelId:     aws.String(getBedrockModelId(model)),
+		ContentType: aws.String("application/json"),
+		Accept:      aws.String("application/json"),
+		Body:        []byte(input),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stream := result.GetStream()
+	read := func(handler func(data string) error) error {
+		defer stream.Close()
+		for event := range stream.Events() {
+			if chunk, ok := event.(*types.ResponseStreamMemberChunk); ok {
+				if err := handler(string(chunk.Value.Bytes)); err != nil {
+					return ignoreEOF(err)
+				}
+			}
+		}
+		return stream.Err()
+	}
+
+	return read, nil
 }
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBedrockPrepareInput(t *testing.T) {
+	claude := &manifest.ModelInfo{SourceModel: "claude-3-5-sonnet-20240620-v1:0", Provider: "anthropic", Host: bedrockHost}
+	assert.Equal(t, "anthropic.claude-3-5-sonnet-20240620-v1:0", getBedrockModelId(claude))
+
+	input, err := prepareBedrockInput(claude, `{"model":"claude","messages":[]}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"anthropic_version":"bedrock-2023-05-31","max_tokens":4096,"messages":[]}`, input)
+
+	// cross-region inference profiles are recognized
+	profile := &manifest.ModelInfo{SourceModel: "us.anthropic.claude-3-5-sonnet-20240620-v1:0", Host: bedrockHost}
+	assert.Equal(t, "anthropic", getBedrockModelFamily(getBedrockModelId(profile)))
+
+	llama := &manifest.ModelInfo{SourceModel: "meta.llama3-1-8b-instruct-v1:0", Host: bedrockHost}
+	input, err = prepareBedrockInput(llama, `{"prompt":"hi"}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"prompt":"hi"}`, input)
+
+	_, err = prepareBedrockInput(llama, `not json`)
+	assert.Error(t, err)
+}
+
+func TestInvokeBedrockModel(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=test-key/")
+
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/model/amazon.titan-text-express-v1/invoke":
+			assert.JSONEq(t, `{"inputText":"hi"}`, string(body))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"results":[{"outputText":"hello"}]}`))
+
+		case "/model/amazon.titan-text-express-v1/invoke-with-response-stream":
+			w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+			enc := eventstream.NewEncoder()
+			for _, text := range []string{"hel", "lo"} {
+				chunk := fmt.Sprintf(`{"outputText":"%s"}`, text)
+				payload := fmt.Sprintf(`{"bytes":"%s"}`, base64.StdEncoding.EncodeToString([]byte(chunk)))
+				err := enc.Encode(w, eventstream.Message{
+					Headers: eventstream.Headers{
+						{Name: ":message-type", Value: eventstream.StringValue("event")},
+						{Name: ":event-type", Value: eventstream.StringValue("chunk")},
+						{Name: ":content-type", Value: eventstream.StringValue("application/json")},
+					},
+					Payload: []byte(payload),
+				})
+				assert.NoError(t, err)
+			}
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", tsrv.URL)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+
+	md := manifestdata.GetManifest()
+	md.Models["titan"] = manifest.ModelInfo{
+		Name:        "titan",
+		SourceModel: "titan-text-express-v1",
+		Provider:    "amazon",
+		Host:        bedrockHost,
+	}
+	defer delete(md.Models, "titan")
+
+	ctx := context.Background()
+	output, err := InvokeModel(ctx, "titan", `{"inputText":"hi"}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"results":[{"outputText":"hello"}]}`, output)
+
+	id, err := StartModelStream(ctx, "titan", `{"inputText":"hi"}`)
+	require.NoError(t, err)
+
+	var chunks []string
+	for {
+		chunk, err := ReadModelStream(ctx, id)
+		require.NoError(t, err)
+		if chunk.Done {
+			break
+		}
+		chunks = append(chunks, chunk.Data)
+	}
+	assert.Equal(t, `{"outputText":"hel"}|{"outputText":"lo"}`, strings.Join(chunks, "|"))
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/cache"
+	"github.com/hypermodeinc/modus/runtime/logger"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxResponseCacheSize is the total size of the model responses kept in memory, for all models.
+const maxResponseCacheSize = 64 * 1024 * 1024
+
+// maxSemanticCacheEntries is the number of prompt embeddings kept for each model with a semantic cache.
+const maxSemanticCacheEntries = 1000
+
+const defaultSemanticThreshold = 0.95
+
+var responseCache = cache.New(maxResponseCacheSize, func(s string) int64 { return int64(len(s)) })
+
+var semanticCaches = make(map[string]*semanticCache)
+var semanticCachesMutex sync.Mutex
+
+type semanticCache struct {
+	mu      sync.Mutex
+	entries []*semanticCacheEntry
+}
+
+type semanticCacheEntry struct {
+	scope     string
+	embedding []float32
+	output    string
+	expires   time.Time
+}
+
+// invokeModelWithCache returns a cached response for the input if the model has caching enabled and one is available.
+// Otherwise, it invokes the model and caches the response.  The model is invoked through the recorded fixtures, if any.
+func invokeModelWithCache(ctx context.Context, model *manifest.ModelInfo, input string, invoke func(string) (string, error)) (string, error) {
+	invokeDirect := invoke
+	invoke = func(input string) (string, error) {
+		return invokeModelWithFixtures(ctx, model, input, invokeDirect)
+	}
+
+	if model.Cache == nil {
+		return invoke(input)
+	}
+
+	ttl := time.Duration(model.Cache.Ttl) * time.Second
+	key := getResponseCacheKey(model, input)
+	if output, ok := responseCache.Get(key); ok {
+		recordUninvokedModelInvocation(ctx, model, modelStatusCached)
+		return output, nil
+	}
+
+	var sc *semanticCache
+	var scope string
+	var embedding []float32
+	if model.Cache.Semantic != nil {
+		prompt, s, ok := getSemanticPrompt(input)
+		if ok {
+			if e, err := getPromptEmbedding(ctx, model.Cache.Semantic.EmbeddingModel, prompt); err != nil {
+				logger.Warn(ctx).Err(err).Str("model", model.Name).Msg("Semantic cache is unavailable.")
+			} else {
+				sc, scope, embedding = getSemanticCache(model), s, e
+				threshold := model.Cache.Semantic.Threshold
+				if threshold == 0 {
+					threshold = defaultSemanticThreshold
+				}
+				if output, ok := sc.find(scope, embedding, threshold); ok {
+					recordUninvokedModelInvocation(ctx, model, modelStatusCached)
+					return output, nil
+				}
+			}
+		}
+	}
+
+	output, err := invoke(input)
+	if err != nil {
+		return "", err
+	}
+
+	responseCache.Set(key, output, ttl)
+	if sc != nil {
+		sc.add(scope, embedding, output, ttl)
+	}
+
+	return output, nil
+}
+
+// getResponseCacheKey returns the key of a response in the cache.  It includes the hash of the model's definition,
+// so that responses aren't reused after the model is changed in the manifest.
+func getResponseCacheKey(model *manifest.ModelInfo, input string) string {
+	hash := sha256.Sum256([]byte(input))
+	return model.Hash() + ":" + hex.EncodeToString(hash[:])
+}
+
+// getSemanticPrompt extracts the text of the prompt from the model input, for the known request formats.
+// The scope is a hash of the rest of the input, such as the model parameters, which must match exactly
+// for a cached response to be reused.
+func getSemanticPrompt(input string) (prompt, scope string, ok bool) {
+	if !gjson.Valid(input) {
+		return "", "", false
+	}
+
+	var sb strings.Builder
+	if system := gjson.Get(input, "system"); system.Type == gjson.String {
+		sb.WriteString("system: ")
+		sb.WriteString(system.String())
+		sb.WriteString("\n")
+	}
+
+	if messages := gjson.Get(input, "messages"); messages.IsArray() {
+		for _, m := range messages.Array() {
+			sb.WriteString(m.Get("role").String())
+			sb.WriteString(": ")
+			content := m.Get("content")
+			if content.IsArray() {
+				for _, part := range content.Get("#.text").Array() {
+					sb.WriteString(part.String())
+				}
+			} else {
+				sb.WriteString(content.String())
+			}
+			sb.WriteString("\n")
+		}
+	} else if p := gjson.Get(input, "prompt"); p.Type == gjson.String {
+		sb.WriteString(p.String())
+	}
+
+	prompt = sb.String()
+	if prompt == "" {
+		return "", "", false
+	}
+
+	rest := input
+	for _, path := range []string{"system", "messages", "prompt"} {
+		if r, err := sjson.Delete(rest, path); err == nil {
+			rest = r
+		}
+	}
+	hash := sha256.Sum256([]byte(rest))
+
+	return prompt, hex.EncodeToString(hash[:]), true
+}
+
+// getPromptEmbedding computes the embedding of the prompt with the semantic cache's embedding model.
+func getPromptEmbedding(ctx context.Context, modelName, prompt string) ([]float32, error) {
+	vectors, err := ComputeEmbeddings(ctx, modelName, []string{prompt})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func getSemanticCache(model *manifest.ModelInfo) *semanticCache {
+	semanticCachesMutex.Lock()
+	defer semanticCachesMutex.Unlock()
+
+	key := model.Hash()
+	sc, ok := semanticCaches[key]
+	if !ok {
+		sc = &semanticCache{}
+		semanticCaches[key] = sc
+	}
+	return sc
+}
+
+// find returns the output of the most similar prompt in the same scope, if its similarity meets the threshold.
+func (c *semanticCache) find(scope string, embedding []float32, threshold float64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	best := -1.0
+	var output string
+	live := c.entries[:0]
+	for _, e := range c.entries {
+		if !e.expires.IsZero() && now.After(e.expires) {
+			continue
+		}
+		live = append(live, e)
+
+		if e.scope != scope {
+			continue
+		}
+		if sim := cosineSimilarity(e.embedding, embedding); sim >= threshold && sim > best {
+			best, output = sim, e.output
+		}
+	}
+	clear(c.entries[len(live):])
+	c.entries = live
+
+	return output, best >= 0
+}
+
+func (c *semanticCache) add(scope string, embedding []float32, output string, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Evict the oldest entry when the cache is full.
+	if len(c.entries) >= maxSemanticCacheEntries {
+		c.entries[0] = nil
+		c.entries = c.entries[1:]
+	}
+	c.entries = append(c.entries, &semanticCacheEntry{scope, embedding, output, expires})
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSemanticPrompt(t *testing.T) {
+	prompt, scope1, ok := getSemanticPrompt(`{"model":"x","temperature":0,"messages":[{"role":"user","content":"hello"}]}`)
+	require.True(t, ok)
+	assert.Equal(t, "user: hello\n", prompt)
+
+	prompt, scope2, ok := getSemanticPrompt(`{"model":"x","temperature":0,"system":"be brief","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`)
+	require.True(t, ok)
+	assert.Equal(t, "system: be brief\nuser: hi\n", prompt)
+	assert.Equal(t, scope1, scope2)
+
+	_, scope3, ok := getSemanticPrompt(`{"model":"x","temperature":1,"messages":[{"role":"user","content":"hello"}]}`)
+	require.True(t, ok)
+	assert.NotEqual(t, scope1, scope3)
+
+	_, _, ok = getSemanticPrompt(`{"inputs":"hello"}`)
+	assert.False(t, ok)
+}
+
+func TestInvokeModelWithCache(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hello"}}]}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["cached-host"] = manifest.HTTPHostInfo{Name: "cached-host", Endpoint: tsrv.URL}
+	md.Models["cached-model"] = manifest.ModelInfo{
+		Name:        "cached-model",
+		SourceModel: "gpt-4o",
+		Host:        "cached-host",
+		Cache:       &manifest.ModelCacheInfo{Ttl: 60},
+	}
+	defer func() {
+		delete(md.Hosts, "cached-host")
+		delete(md.Models, "cached-model")
+	}()
+
+	var usage []utils.ModelUsage
+	ctx := context.WithValue(context.Background(), utils.ModelUsageContextKey, &usage)
+
+	for range 2 {
+		output, err := InvokeModel(ctx, "cached-model", `{"messages":[{"role":"user","content":"hi"}]}`)
+		require.NoError(t, err)
+		assert.Contains(t, output, `"content":"hello"`)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err := InvokeModel(ctx, "cached-model", `{"messages":[{"role":"user","content":"bye"}]}`)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	require.Len(t, usage, 3)
+	assert.Equal(t, "success", usage[0].Status)
+	assert.Equal(t, "cached", usage[1].Status)
+	assert.Equal(t, "success", usage[2].Status)
+}
+
+func TestInvokeModelWithSemanticCache(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			// Prompts about the weather are similar to each other, and different from anything else.
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), "weather") {
+				_, _ = w.Write([]byte(`{"data":[{"embedding":[0.9,0.1]}]}`))
+			} else {
+				_, _ = w.Write([]byte(`{"data":[{"embedding":[0.1,0.9]}]}`))
+			}
+			return
+		}
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"sunny"}}]}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["semantic-host"] = manifest.HTTPHostInfo{Name: "semantic-host", BaseURL: tsrv.URL + "/"}
+	md.Models["semantic-embedder"] = manifest.ModelInfo{
+		Name:        "semantic-embedder",
+		SourceModel: "text-embedding-3-small",
+		Host:        "semantic-host",
+		Path:        "embeddings",
+	}
+	md.Models["semantic-model"] = manifest.ModelInfo{
+		Name:        "semantic-model",
+		SourceModel: "gpt-4o",
+		Host:        "semantic-host",
+		Path:        "chat",
+		Cache: &manifest.ModelCacheInfo{
+			Semantic: &manifest.SemanticCacheInfo{EmbeddingModel: "semantic-embedder", Threshold: 0.99},
+		},
+	}
+	defer func() {
+		delete(md.Hosts, "semantic-host")
+		delete(md.Models, "semantic-embedder")
+		delete(md.Models, "semantic-model")
+	}()
+
+	ctx := context.Background()
+	prompts := []string{"What is the weather in Paris?", "what's the weather in paris", "Tell me a joke"}
+	for _, p := range prompts {
+		_, err := InvokeModel(ctx, "semantic-model", `{"messages":[{"role":"user","content":"`+p+`"}]}`)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/metrics"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+/*
+
+DESIGN NOTES:
+
+- The spend of each model invocation is estimated from the tokens reported by the provider, and the model's token prices.
+- Prices come from the model's "pricing" in the manifest, or else from the built-in table below, which is matched
+  by the longest prefix of the source model, so that dated versions such as "gpt-4o-2024-08-06" are priced as "gpt-4o".
+  Invocations of models without a known price, or whose provider doesn't report tokens, are not counted.
+- Spend is accumulated per calendar month (UTC), in the memory of each runtime instance, so it starts from zero
+  when the runtime restarts.  The metrics counter can be used to aggregate spend across instances and restarts.
+- The manifest's budget is checked before each model invocation.  Reaching the soft limit logs a warning once a month.
+  Reaching the hard limit rejects further invocations until the next month.
+
+*/
+
+// builtinModelPrices are the list prices of well-known models, in US dollars per million input and output tokens.
+var builtinModelPrices = map[string]manifest.ModelPricingInfo{
+	"gpt-4o":                 {InputPerMillion: 2.5, OutputPerMillion: 10},
+	"gpt-4o-mini":            {InputPerMillion: 0.15, OutputPerMillion: 0.6},
+	"gpt-4-turbo":            {InputPerMillion: 10, OutputPerMillion: 30},
+	"gpt-3.5-turbo":          {InputPerMillion: 0.5, OutputPerMillion: 1.5},
+	"o1-preview":             {InputPerMillion: 15, OutputPerMillion: 60},
+	"o1-mini":                {InputPerMillion: 3, OutputPerMillion: 12},
+	"text-embedding-3-small": {InputPerMillion: 0.02},
+	"text-embedding-3-large": {InputPerMillion: 0.13},
+	"text-embedding-ada-002": {InputPerMillion: 0.1},
+	"claude-3-5-sonnet":      {InputPerMillion: 3, OutputPerMillion: 15},
+	"claude-3-5-haiku":       {InputPerMillion: 0.8, OutputPerMillion: 4},
+	"claude-3-opus":          {InputPerMillion: 15, OutputPerMillion: 75},
+	"claude-3-haiku":         {InputPerMillion: 0.25, OutputPerMillion: 1.25},
+	"gemini-1.5-pro":         {InputPerMillion: 1.25, OutputPerMillion: 5},
+	"gemini-1.5-flash":       {InputPerMillion: 0.075, OutputPerMillion: 0.3},
+}
+
+type spendTracker struct {
+	mu       sync.Mutex
+	month    string
+	total    float64
+	byPlugin map[string]float64
+	byModel  map[string]float64
+	warned   bool
+}
+
+var spend = &spendTracker{}
+
+// getModelPricing returns the token prices of the model, if they are known.
+func getModelPricing(model *manifest.ModelInfo) (manifest.ModelPricingInfo, bool) {
+	if model.Pricing != nil {
+		return *model.Pricing, true
+	}
+
+	var best string
+	for prefix := range builtinModelPrices {
+		if strings.HasPrefix(model.SourceModel, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return manifest.ModelPricingInfo{}, false
+	}
+	return builtinModelPrices[best], true
+}
+
+// estimateModelCost returns the estimated cost of an invocation, in US dollars.
+func estimateModelCost(model *manifest.ModelInfo, inputTokens, outputTokens int) (float64, bool) {
+	pricing, ok := getModelPricing(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(inputTokens)*pricing.InputPerMillion + float64(outputTokens)*pricing.OutputPerMillion) / 1_000_000, true
+}
+
+// recordModelCost adds the estimated cost of an invocation to the month's spend, and warns when the soft limit is reached.
+func recordModelCost(ctx context.Context, model *manifest.ModelInfo, inputTokens, outputTokens int) {
+	cost, ok := estimateModelCost(model, inputTokens, outputTokens)
+	if !ok || cost == 0 {
+		return
+	}
+
+	var pluginName string
+	if plugin, ok := plugins.GetPluginFromContext(ctx); ok {
+		pluginName = plugin.Metadata.Name()
+	}
+	metrics.ModelCostDollars.WithLabelValues(model.Name, pluginName, config.GetEnvironmentName()).Add(cost)
+
+	total, warn := spend.add(utils.GetTime(), pluginName, model.Name, cost)
+	if warn {
+		budget := manifestdata.GetManifest().Budget
+		logger.Warn(ctx).
+			Float64("spend", total).
+			Float64("soft_limit", budget.SoftLimit).
+			Msg("The monthly model budget's soft limit has been reached.")
+	}
+}
+
+// checkModelBudget returns an error if the month's spend has reached the hard limit of the manifest's budget.
+func checkModelBudget() error {
+	budget := manifestdata.GetManifest().Budget
+	if budget == nil || budget.HardLimit <= 0 {
+		return nil
+	}
+
+	if total := spend.current(utils.GetTime()); total >= budget.HardLimit {
+		return fmt.Errorf("the monthly model budget of $%.2f has been reached", budget.HardLimit)
+	}
+	return nil
+}
+
+// add returns the month's total spend, and whether the soft limit was reached by this invocation.
+func (s *spendTracker) add(now time.Time, pluginName, modelName string, cost float64) (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollover(now)
+	s.total += cost
+	s.byPlugin[pluginName] += cost
+	s.byModel[modelName] += cost
+
+	budget := manifestdata.GetManifest().Budget
+	if budget != nil && budget.SoftLimit > 0 && s.total >= budget.SoftLimit && !s.warned {
+		s.warned = true
+		return s.total, true
+	}
+	return s.total, false
+}
+
+func (s *spendTracker) current(now time.Time) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollover(now)
+	return s.total
+}
+
+// rollover starts a new month's spend when the month changes.  The caller must hold the lock.
+func (s *spendTracker) rollover(now time.Time) {
+	month := now.UTC().Format("2006-01")
+	if s.month != month {
+		s.month = month
+		s.total = 0
+		s.byPlugin = make(map[string]float64)
+		s.byModel = make(map[string]float64)
+		s.warned = false
+	}
+}
+
+type costsResponse struct {
+	Month       string             `json:"month"`
+	Environment string             `json:"environment"`
+	Total       float64            `json:"total"`
+	SoftLimit   float64            `json:"softLimit,omitempty"`
+	HardLimit   float64            `json:"hardLimit,omitempty"`
+	Plugins     map[string]float64 `json:"plugins"`
+	Models      map[string]float64 `json:"models"`
+}
+
+// CostsHandler returns the estimated spend on model invocations for the current month, in US dollars,
+// by plugin and by model, along with the manifest's budget (GET).
+func CostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	spend.mu.Lock()
+	spend.rollover(utils.GetTime())
+	resp := costsResponse{
+		Month:       spend.month,
+		Environment: config.GetEnvironmentName(),
+		Total:       spend.total,
+		Plugins:     make(map[string]float64, len(spend.byPlugin)),
+		Models:      make(map[string]float64, len(spend.byModel)),
+	}
+	for k, v := range spend.byPlugin {
+		resp.Plugins[k] = v
+	}
+	for k, v := range spend.byModel {
+		resp.Models[k] = v
+	}
+	spend.mu.Unlock()
+
+	if budget := manifestdata.GetManifest().Budget; budget != nil {
+		resp.SoftLimit = budget.SoftLimit
+		resp.HardLimit = budget.HardLimit
+	}
+
+	utils.WriteJsonResponse(w, resp)
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetSpend(t *testing.T, budget *manifest.BudgetInfo) {
+	md := manifestdata.GetManifest()
+	prev := md.Budget
+	md.Budget = budget
+	spend = &spendTracker{}
+	t.Cleanup(func() {
+		md.Budget = prev
+		spend = &spendTracker{}
+	})
+}
+
+func TestGetModelPricing(t *testing.T) {
+	pricing, ok := getModelPricing(&manifest.ModelInfo{SourceModel: "gpt-4o-mini-2024-07-18"})
+	require.True(t, ok)
+	assert.Equal(t, 0.15, pricing.InputPerMillion)
+
+	pricing, ok = getModelPricing(&manifest.ModelInfo{SourceModel: "gpt-4o-2024-08-06"})
+	require.True(t, ok)
+	assert.Equal(t, 2.5, pricing.InputPerMillion)
+
+	custom := &manifest.ModelPricingInfo{InputPerMillion: 1, OutputPerMillion: 2}
+	pricing, ok = getModelPricing(&manifest.ModelInfo{SourceModel: "gpt-4o", Pricing: custom})
+	require.True(t, ok)
+	assert.Equal(t, *custom, pricing)
+
+	_, ok = getModelPricing(&manifest.ModelInfo{SourceModel: "my-own-model"})
+	assert.False(t, ok)
+}
+
+func TestEstimateModelCost(t *testing.T) {
+	model := &manifest.ModelInfo{Pricing: &manifest.ModelPricingInfo{InputPerMillion: 3, OutputPerMillion: 15}}
+	cost, ok := estimateModelCost(model, 1_000_000, 200_000)
+	require.True(t, ok)
+	assert.InDelta(t, 6.0, cost, 1e-9)
+}
+
+func TestModelBudget(t *testing.T) {
+	resetSpend(t, &manifest.BudgetInfo{SoftLimit: 1, HardLimit: 2})
+
+	model := &manifest.ModelInfo{Name: "priced", Pricing: &manifest.ModelPricingInfo{InputPerMillion: 1_000_000}}
+	ctx := context.Background()
+
+	recordModelCost(ctx, model, 1, 0)
+	assert.True(t, spend.warned, "the soft limit should have been reached")
+	assert.NoError(t, checkModelBudget())
+
+	recordModelCost(ctx, model, 1, 0)
+	assert.ErrorContains(t, checkModelBudget(), "monthly model budget")
+
+	_, err := invokeModelWithFallbacks(ctx, model, func(context.Context, *manifest.ModelInfo) (string, error) {
+		t.Fatal("the model should not be invoked once the budget is spent")
+		return "", nil
+	})
+	assert.Error(t, err)
+}
+
+func TestSpendRollsOverMonthly(t *testing.T) {
+	resetSpend(t, nil)
+
+	october := time.Date(2024, 10, 31, 23, 0, 0, 0, time.UTC)
+	total, _ := spend.add(october, "my-plugin", "priced", 5)
+	assert.Equal(t, 5.0, total)
+
+	assert.Equal(t, 0.0, spend.current(october.Add(2*time.Hour)))
+}
+
+func TestCostsHandler(t *testing.T) {
+	resetSpend(t, &manifest.BudgetInfo{HardLimit: 100})
+
+	spend.add(time.Now(), "my-plugin", "priced", 1.5)
+	spend.add(time.Now(), "my-plugin", "other", 0.5)
+
+	w := httptest.NewRecorder()
+	CostsHandler(w, httptest.NewRequest(http.MethodGet, "/admin/costs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp costsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2.0, resp.Total)
+	assert.Equal(t, 100.0, resp.HardLimit)
+	assert.Equal(t, map[string]float64{"my-plugin": 2}, resp.Plugins)
+	assert.Equal(t, map[string]float64{"priced": 1.5, "other": 0.5}, resp.Models)
+}
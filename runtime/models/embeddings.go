@@ -0,0 +1,233 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/hosts"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxEmbeddingBatchSize is the number of texts sent in each request, which is within the limits of all supported providers.
+const maxEmbeddingBatchSize = 96
+
+// maxEmbeddingConcurrency is the number of requests for batches of texts that are in flight at once, per call.
+const maxEmbeddingConcurrency = 4
+
+// ComputeEmbeddings returns an embedding vector for each of the texts, in the same order.
+// The texts are sent to the model in batches, and batches are requested concurrently,
+// so that a function can embed many texts with one call rather than one model invocation per text.
+func ComputeEmbeddings(ctx context.Context, modelName string, texts []string) ([][]float32, error) {
+	model, err := GetModel(modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	batchSize := maxEmbeddingBatchSize
+	if model.Host == bedrockHost && getBedrockModelFamily(getBedrockModelId(model)) != "cohere" {
+		// Other embedding models on Bedrock accept one text per request.
+		batchSize = 1
+	}
+
+	results := make([][]float32, len(texts))
+	errs := make([]error, 0)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxEmbeddingConcurrency)
+
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		batch := texts[start:end]
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			vectors, err := embedBatch(ctx, model, batch)
+			if err == nil && len(vectors) != len(batch) {
+				err = fmt.Errorf("model returned %d embeddings for %d texts", len(vectors), len(batch))
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			copy(results[start:end], vectors)
+		}()
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return results, nil
+}
+
+func embedBatch(ctx context.Context, model *manifest.ModelInfo, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	_, err := invokeModelWithFallbacks(ctx, model, func(ctx context.Context, m *manifest.ModelInfo) (string, error) {
+		input, err := encodeEmbeddingRequest(m, texts)
+		if err != nil {
+			return "", err
+		}
+
+		output, err := invokeModel(ctx, m, input)
+		if err != nil {
+			return "", err
+		}
+
+		vectors, err = decodeEmbeddingResponse(m, output)
+		return output, err
+	})
+	return vectors, err
+}
+
+func encodeEmbeddingRequest(model *manifest.ModelInfo, texts []string) (string, error) {
+	var input any
+	switch {
+	case model.Host == hosts.HypermodeHost:
+		input = map[string]any{"instances": texts}
+	case model.Host == bedrockHost:
+		if getBedrockModelFamily(getBedrockModelId(model)) == "cohere" {
+			input = map[string]any{"texts": texts, "input_type": "search_document"}
+		} else {
+			input = map[string]any{"inputText": texts[0]}
+		}
+	default:
+		return getModelProvider(model).encodeEmbeddingRequest(model, texts)
+	}
+
+	data, err := utils.JsonSerialize(input)
+	return string(data), err
+}
+
+func decodeEmbeddingResponse(model *manifest.ModelInfo, output string) ([][]float32, error) {
+	switch {
+	case model.Host == hosts.HypermodeHost:
+		return parseVectors(gjson.Get(output, "predictions").Array())
+	case model.Host == bedrockHost:
+		if embeddings := gjson.Get(output, "embeddings"); embeddings.Exists() {
+			return parseVectors(embeddings.Array())
+		}
+		return parseVectors([]gjson.Result{gjson.Get(output, "embedding")})
+	default:
+		return getModelProvider(model).decodeEmbeddingResponse(output)
+	}
+}
+
+// encodeEmbeddingRequest uses the OpenAI embeddings API, which most providers follow.
+// See https://platform.openai.com/docs/api-reference/embeddings/create
+func (defaultProvider) encodeEmbeddingRequest(model *manifest.ModelInfo, texts []string) (string, error) {
+	input := map[string]any{"input": texts}
+	if model.SourceModel != "" {
+		input["model"] = model.SourceModel
+	}
+
+	data, err := utils.JsonSerialize(input)
+	return string(data), err
+}
+
+func (defaultProvider) decodeEmbeddingResponse(output string) ([][]float32, error) {
+	data := gjson.Get(output, "data").Array()
+	vectors := make([]gjson.Result, len(data))
+	for i, d := range data {
+		// The embeddings may be returned in any order, so use the index of each.
+		idx := i
+		if index := d.Get("index"); index.Exists() {
+			idx = int(index.Int())
+		}
+		if idx < 0 || idx >= len(data) {
+			return nil, fmt.Errorf("model output has an invalid embedding index: %d", idx)
+		}
+		vectors[idx] = d.Get("embedding")
+	}
+	return parseVectors(vectors)
+}
+
+// encodeEmbeddingRequest uses the batchEmbedContents method of Google AI Studio, or the predict method of Vertex AI,
+// according to the method in the model's path.
+func (geminiProvider) encodeEmbeddingRequest(model *manifest.ModelInfo, texts []string) (string, error) {
+	var input any
+	switch {
+	case strings.HasSuffix(model.Path, ":batchEmbedContents"):
+		requests := make([]map[string]any, len(texts))
+		for i, text := range texts {
+			requests[i] = map[string]any{
+				"model":   "models/" + model.SourceModel,
+				"content": map[string]any{"parts": []map[string]any{{"text": text}}},
+			}
+		}
+		input = map[string]any{"requests": requests}
+	case strings.HasSuffix(model.Path, ":predict"):
+		instances := make([]map[string]any, len(texts))
+		for i, text := range texts {
+			instances[i] = map[string]any{"content": text}
+		}
+		input = map[string]any{"instances": instances}
+	default:
+		return "", fmt.Errorf("embeddings require the batchEmbedContents or predict method for Gemini models")
+	}
+
+	data, err := utils.JsonSerialize(input)
+	return string(data), err
+}
+
+func (geminiProvider) decodeEmbeddingResponse(output string) ([][]float32, error) {
+	if embeddings := gjson.Get(output, "embeddings"); embeddings.Exists() {
+		return parseVectors(embeddings.Get("#.values").Array())
+	}
+	return parseVectors(gjson.Get(output, "predictions.#.embeddings.values").Array())
+}
+
+func (anthropicProvider) encodeEmbeddingRequest(model *manifest.ModelInfo, texts []string) (string, error) {
+	return "", fmt.Errorf("embeddings are not supported for Anthropic models")
+}
+
+func (anthropicProvider) decodeEmbeddingResponse(output string) ([][]float32, error) {
+	return nil, fmt.Errorf("embeddings are not supported for Anthropic models")
+}
+
+func parseVectors(values []gjson.Result) ([][]float32, error) {
+	vectors := make([][]float32, len(values))
+	for i, v := range values {
+		if !v.IsArray() {
+			return nil, fmt.Errorf("model output is missing embedding %d", i)
+		}
+		nums := v.Array()
+		vector := make([]float32, len(nums))
+		for j, n := range nums {
+			vector[j] = float32(n.Float())
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
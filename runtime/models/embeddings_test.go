@@ -0,0 +1,123 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestComputeEmbeddings(t *testing.T) {
+	var requests atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "text-embedding-3-small", gjson.GetBytes(body, "model").String())
+
+		// Return the embeddings in reverse order, with each vector holding the text's number.
+		inputs := gjson.GetBytes(body, "input").Array()
+		assert.LessOrEqual(t, len(inputs), maxEmbeddingBatchSize)
+		data := "["
+		for i := len(inputs) - 1; i >= 0; i-- {
+			var n int
+			_, _ = fmt.Sscanf(inputs[i].String(), "text %d", &n)
+			data += fmt.Sprintf(`{"index":%d,"embedding":[%d,0.5]}`, i, n)
+			if i > 0 {
+				data += ","
+			}
+		}
+		data += "]"
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":` + data + `}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["embeddings-host"] = manifest.HTTPHostInfo{Name: "embeddings-host", Endpoint: tsrv.URL}
+	md.Models["embedder"] = manifest.ModelInfo{
+		Name:        "embedder",
+		SourceModel: "text-embedding-3-small",
+		Host:        "embeddings-host",
+	}
+	defer func() {
+		delete(md.Hosts, "embeddings-host")
+		delete(md.Models, "embedder")
+	}()
+
+	texts := make([]string, 250)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+
+	vectors, err := ComputeEmbeddings(context.Background(), "embedder", texts)
+	require.NoError(t, err)
+	require.Len(t, vectors, len(texts))
+	for i, v := range vectors {
+		assert.Equal(t, []float32{float32(i), 0.5}, v)
+	}
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestEncodeEmbeddingRequest(t *testing.T) {
+	tests := []struct {
+		model    *manifest.ModelInfo
+		expected string
+	}{
+		{&manifest.ModelInfo{Host: "hypermode"}, `{"instances":["a","b"]}`},
+		{&manifest.ModelInfo{Host: bedrockHost, SourceModel: "amazon.titan-embed-text-v2:0"}, `{"inputText":"a"}`},
+		{&manifest.ModelInfo{Host: bedrockHost, SourceModel: "cohere.embed-english-v3"}, `{"texts":["a","b"],"input_type":"search_document"}`},
+		{&manifest.ModelInfo{Host: "vertex", Provider: "gemini", Path: "text-embedding-004:predict"}, `{"instances":[{"content":"a"},{"content":"b"}]}`},
+		{&manifest.ModelInfo{Host: "ai-studio", Provider: "gemini", SourceModel: "text-embedding-004", Path: "text-embedding-004:batchEmbedContents"},
+			`{"requests":[{"model":"models/text-embedding-004","content":{"parts":[{"text":"a"}]}},{"model":"models/text-embedding-004","content":{"parts":[{"text":"b"}]}}]}`},
+	}
+
+	for _, tt := range tests {
+		input, err := encodeEmbeddingRequest(tt.model, []string{"a", "b"})
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.expected, input)
+	}
+
+	_, err := encodeEmbeddingRequest(&manifest.ModelInfo{Host: "anthropic", Provider: "anthropic"}, []string{"a"})
+	assert.Error(t, err)
+}
+
+func TestDecodeEmbeddingResponse(t *testing.T) {
+	tests := []struct {
+		model  *manifest.ModelInfo
+		output string
+	}{
+		{&manifest.ModelInfo{Host: "hypermode"}, `{"predictions":[[1,2]]}`},
+		{&manifest.ModelInfo{Host: bedrockHost, SourceModel: "amazon.titan-embed-text-v2:0"}, `{"embedding":[1,2],"inputTextTokenCount":1}`},
+		{&manifest.ModelInfo{Host: "vertex", Provider: "gemini"}, `{"predictions":[{"embeddings":{"values":[1,2]}}]}`},
+		{&manifest.ModelInfo{Host: "ai-studio", Provider: "gemini"}, `{"embeddings":[{"values":[1,2]}]}`},
+		{&manifest.ModelInfo{Host: "openai"}, `{"data":[{"index":0,"embedding":[1,2]}]}`},
+	}
+
+	for _, tt := range tests {
+		vectors, err := decodeEmbeddingResponse(tt.model, tt.output)
+		require.NoError(t, err)
+		assert.Equal(t, [][]float32{{1, 2}}, vectors, tt.output)
+	}
+}
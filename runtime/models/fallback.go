@@ -0,0 +1,186 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultRetryMaxDelay = 30 * time.Second
+	initialRetryBackoff  = 500 * time.Millisecond
+)
+
+type contextKey string
+
+// fallbackForContextKey holds the name of the requested model, while one of its fallback models is invoked.
+const fallbackForContextKey contextKey = "fallback_for"
+
+// invokeModelWithFallbacks invokes the model, retrying transient failures as configured for the model.
+// If the model still fails with a transient error, each of its fallback models is tried in order.
+// The invoke function is called with the model to use for each attempt.  No attempt is made once the monthly budget is spent.
+func invokeModelWithFallbacks(ctx context.Context, model *manifest.ModelInfo, invoke func(context.Context, *manifest.ModelInfo) (string, error)) (string, error) {
+	if err := checkModelBudget(); err != nil {
+		return "", err
+	}
+
+	output, err := invokeModelWithRetry(ctx, model, invoke)
+	if err == nil {
+		return output, nil
+	}
+
+	for _, name := range model.Fallbacks {
+		if retryable, _ := getRetryInfo(err); !retryable || ctx.Err() != nil {
+			return "", err
+		}
+
+		fallback, e := GetModel(name)
+		if e != nil {
+			logger.Warn(ctx).Err(e).Str("model", model.Name).Msg("Fallback model is not available.")
+			continue
+		}
+
+		logger.Warn(ctx).Err(err).
+			Str("model", model.Name).
+			Str("fallback", fallback.Name).
+			Msg("Model request failed.  Trying the fallback model.")
+
+		fctx := context.WithValue(ctx, fallbackForContextKey, model.Name)
+		output, err = invokeModelWithRetry(fctx, fallback, invoke)
+		if err == nil {
+			logger.Info(ctx).
+				Str("model", model.Name).
+				Str("fallback", fallback.Name).
+				Msg("Model request was served by the fallback model.")
+			return output, nil
+		}
+	}
+
+	return "", err
+}
+
+func invokeModelWithRetry(ctx context.Context, model *manifest.ModelInfo, invoke func(context.Context, *manifest.ModelInfo) (string, error)) (string, error) {
+	maxAttempts, maxDelay := 1, defaultRetryMaxDelay
+	if model.Retry != nil {
+		if model.Retry.MaxAttempts > 0 {
+			maxAttempts = model.Retry.MaxAttempts
+		}
+		if model.Retry.MaxDelay > 0 {
+			maxDelay = time.Duration(model.Retry.MaxDelay) * time.Second
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		output, err := invoke(ctx, model)
+		if err == nil || attempt >= maxAttempts {
+			return output, err
+		}
+
+		retryable, delay := getRetryInfo(err)
+		if !retryable {
+			return "", err
+		}
+
+		if delay == 0 {
+			// exponential backoff with jitter, when the provider doesn't say how long to wait
+			backoff := initialRetryBackoff << (attempt - 1)
+			delay = min(backoff/2+rand.N(backoff/2), maxDelay)
+		} else if delay > maxDelay {
+			// The provider asked us to wait longer than allowed, so give up and let the caller fail over.
+			return "", err
+		}
+
+		logger.Debug(ctx).Err(err).
+			Str("model", model.Name).
+			Int("attempt", attempt).
+			Dur("delay", delay).
+			Msg("Retrying model request.")
+
+		select {
+		case <-ctx.Done():
+			return "", err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// getRetryInfo reports whether the error from a model request is transient, such as a rate limit or server error,
+// and the delay advertised by the provider before retrying, if any.
+func getRetryInfo(err error) (retryable bool, delay time.Duration) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0
+	}
+
+	var statusErr *utils.HttpStatusError
+	if errors.As(err, &statusErr) {
+		return isRetryableStatus(statusErr.StatusCode), getRetryDelay(statusErr.Header)
+	}
+
+	// errors from the AWS SDK, for models on Bedrock
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) {
+		return isRetryableStatus(respErr.HTTPStatusCode()), 0
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true, 0
+	}
+
+	return false, 0
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+// getRetryDelay returns the delay advertised in the response headers.  Some providers, such as OpenAI,
+// give a more precise delay in milliseconds in addition to the standard Retry-After header.
+func getRetryDelay(header http.Header) time.Duration {
+	if ms, err := strconv.Atoi(header.Get("Retry-After-Ms")); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+
+	retryAfter := header.Get("Retry-After")
+	if retryAfter == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(retryAfter); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(retryAfter); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// setInputModel replaces the model named in the input, if any, when the input is sent to a fallback model.
+func setInputModel(input string, model *manifest.ModelInfo) string {
+	if model.SourceModel == "" || !gjson.Get(input, "model").Exists() {
+		return input
+	}
+	if s, err := sjson.Set(input, "model", model.SourceModel); err == nil {
+		return s
+	}
+	return input
+}
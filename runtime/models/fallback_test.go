@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRetryInfo(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+		delay     time.Duration
+	}{
+		{&utils.HttpStatusError{StatusCode: 429, Header: http.Header{"Retry-After": {"2"}}}, true, 2 * time.Second},
+		{&utils.HttpStatusError{StatusCode: 429, Header: http.Header{"Retry-After-Ms": {"150"}, "Retry-After": {"1"}}}, true, 150 * time.Millisecond},
+		{&utils.HttpStatusError{StatusCode: 503, Header: http.Header{}}, true, 0},
+		{&utils.HttpStatusError{StatusCode: 400, Header: http.Header{}}, false, 0},
+		{context.Canceled, false, 0},
+		{errors.New("model output is not valid JSON"), false, 0},
+	}
+
+	for _, tt := range tests {
+		retryable, delay := getRetryInfo(tt.err)
+		assert.Equal(t, tt.retryable, retryable, tt.err.Error())
+		assert.Equal(t, tt.delay, delay, tt.err.Error())
+	}
+}
+
+func TestInvokeModelWithRetry(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After-Ms", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hello"}}]}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["retry-host"] = manifest.HTTPHostInfo{Name: "retry-host", Endpoint: tsrv.URL}
+	md.Models["retry-model"] = manifest.ModelInfo{
+		Name:  "retry-model",
+		Host:  "retry-host",
+		Retry: &manifest.ModelRetryInfo{MaxAttempts: 3},
+	}
+	defer func() {
+		delete(md.Hosts, "retry-host")
+		delete(md.Models, "retry-model")
+	}()
+
+	output, err := InvokeModel(context.Background(), "retry-model", `{"messages":[{"role":"user","content":"hi"}]}`)
+	require.NoError(t, err)
+	assert.Contains(t, output, `"content":"hello"`)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestInvokeModelWithFallbacks(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"model":"llama3.2","messages":[{"role":"user","content":"hi"}]}`, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hello"}}]}`))
+	}))
+	defer secondary.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["primary-host"] = manifest.HTTPHostInfo{Name: "primary-host", Endpoint: primary.URL}
+	md.Hosts["secondary-host"] = manifest.HTTPHostInfo{Name: "secondary-host", Endpoint: secondary.URL}
+	md.Models["primary-model"] = manifest.ModelInfo{
+		Name:        "primary-model",
+		SourceModel: "gpt-4o",
+		Host:        "primary-host",
+		Fallbacks:   []string{"missing-model", "secondary-model"},
+	}
+	md.Models["secondary-model"] = manifest.ModelInfo{
+		Name:        "secondary-model",
+		SourceModel: "llama3.2",
+		Host:        "secondary-host",
+	}
+	defer func() {
+		delete(md.Hosts, "primary-host")
+		delete(md.Hosts, "secondary-host")
+		delete(md.Models, "primary-model")
+		delete(md.Models, "secondary-model")
+	}()
+
+	var usage []utils.ModelUsage
+	ctx := context.WithValue(context.Background(), utils.ModelUsageContextKey, &usage)
+
+	output, err := InvokeModel(ctx, "primary-model", `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+	require.NoError(t, err)
+	assert.Contains(t, output, `"content":"hello"`)
+
+	require.Len(t, usage, 2)
+	assert.Equal(t, "primary-model", usage[0].Model)
+	assert.Equal(t, "error", usage[0].Status)
+	assert.Equal(t, "secondary-model", usage[1].Model)
+	assert.Equal(t, "primary-model", usage[1].FallbackFor)
+	assert.Equal(t, "success", usage[1].Status)
+}
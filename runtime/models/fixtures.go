@@ -0,0 +1,172 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+/*
+
+DESIGN NOTES:
+
+- When the runtime is started with a model fixtures directory, model invocations are served from recorded responses,
+  so that a plugin's tests run deterministically, offline, and without the cost of calling the models.
+- Each response is recorded in its own file, at "<model name>/<hash of the input>.json" within the directory.
+  The file contains the model name, input and output, so that fixtures can be reviewed and committed with the tests.
+  JSON values are indented in the file, and replayed in compact form, which is equivalent for the code that reads them.
+- In "auto" mode, a recorded response is replayed if there is one, and otherwise the model is invoked and its response
+  is recorded.  In "record" mode, the model is always invoked and its response recorded.  In "replay" mode,
+  the model is never invoked, and an invocation without a recorded response fails.
+- Fixtures apply to every invocation whose input and output are text, including chat, embeddings and images.
+  Streamed responses and audio are not recorded.
+
+*/
+
+const (
+	fixtureModeAuto   = "auto"
+	fixtureModeRecord = "record"
+	fixtureModeReplay = "replay"
+)
+
+type modelFixture struct {
+	Model  string          `json:"model"`
+	Input  json.RawMessage `json:"input"`
+	Output json.RawMessage `json:"output"`
+}
+
+// invokeModelWithFixtures replays the recorded response to the input, or invokes the model and records its response,
+// depending on the fixture mode.  It invokes the model directly when no fixtures directory is configured.
+func invokeModelWithFixtures(ctx context.Context, model *manifest.ModelInfo, input string, invoke func(string) (string, error)) (string, error) {
+	if config.ModelFixturesPath == "" {
+		return invoke(input)
+	}
+
+	mode := config.ModelFixtureMode
+	switch mode {
+	case "", fixtureModeAuto, fixtureModeRecord, fixtureModeReplay:
+	default:
+		return "", fmt.Errorf("unknown model fixture mode: %s", mode)
+	}
+
+	path := getFixturePath(model, input)
+	if mode != fixtureModeRecord {
+		output, err := readFixture(path)
+		if err == nil {
+			recordUninvokedModelInvocation(ctx, model, modelStatusReplayed)
+			return output, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to read recorded response for model %s from %s: %w", model.Name, path, err)
+		}
+		if mode == fixtureModeReplay {
+			return "", fmt.Errorf("no recorded response for model %s in %s: %w", model.Name, path, err)
+		}
+	}
+
+	output, err := invoke(input)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeFixture(path, model, input, output); err != nil {
+		logger.Warn(ctx).Err(err).Str("model", model.Name).Str("path", path).Msg("Failed to record model response.")
+	}
+	return output, nil
+}
+
+func getFixturePath(model *manifest.ModelInfo, input string) string {
+	hash := sha256.Sum256([]byte(input))
+	return filepath.Join(config.ModelFixturesPath, model.Name, hex.EncodeToString(hash[:])+".json")
+}
+
+func readFixture(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var f modelFixture
+	if err := utils.JsonDeserialize(data, &f); err != nil {
+		return "", fmt.Errorf("invalid fixture: %w", err)
+	}
+	return fromFixtureValue(f.Output), nil
+}
+
+// writeFixture writes to a temporary file first, so that a fixture being replayed is never read half-written.
+func writeFixture(path string, model *manifest.ModelInfo, input, output string) error {
+	f := modelFixture{
+		Model:  model.Name,
+		Input:  toFixtureValue(input),
+		Output: toFixtureValue(output),
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".fixture-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// toFixtureValue keeps JSON objects and arrays as they are, so that fixtures are readable, and stores anything else as a string.
+func toFixtureValue(s string) json.RawMessage {
+	if r := gjson.Parse(s); (r.IsObject() || r.IsArray()) && gjson.Valid(s) {
+		return json.RawMessage(s)
+	}
+	data, _ := json.Marshal(s)
+	return data
+}
+
+func fromFixtureValue(v json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil {
+		return string(v)
+	}
+	return buf.String()
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setFixtureConfig(t *testing.T, mode string) {
+	path, prevMode := config.ModelFixturesPath, config.ModelFixtureMode
+	config.ModelFixturesPath, config.ModelFixtureMode = t.TempDir(), mode
+	t.Cleanup(func() {
+		config.ModelFixturesPath, config.ModelFixtureMode = path, prevMode
+	})
+}
+
+func TestModelFixturesAutoMode(t *testing.T) {
+	setFixtureConfig(t, fixtureModeAuto)
+
+	model := &manifest.ModelInfo{Name: "fixture-model"}
+	input := `{"messages":[{"role":"user","content":"Hello"}]}`
+	calls := 0
+	invoke := func(string) (string, error) {
+		calls++
+		return `{"choices":[{"message":{"content":"Hi"}}]}`, nil
+	}
+
+	output, err := invokeModelWithFixtures(context.Background(), model, input, invoke)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	replayed, err := invokeModelWithFixtures(context.Background(), model, input, invoke)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "the recorded response should be replayed")
+	assert.JSONEq(t, output, replayed)
+
+	data, err := os.ReadFile(getFixturePath(model, input))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model":"fixture-model","input":`+input+`,"output":`+output+`}`, string(data))
+}
+
+func TestModelFixturesReplayMode(t *testing.T) {
+	setFixtureConfig(t, fixtureModeReplay)
+
+	model := &manifest.ModelInfo{Name: "fixture-model"}
+	invoke := func(string) (string, error) {
+		t.Fatal("the model should not be invoked in replay mode")
+		return "", nil
+	}
+
+	_, err := invokeModelWithFixtures(context.Background(), model, `{"prompt":"Hello"}`, invoke)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, writeFixture(getFixturePath(model, "plain text"), model, "plain text", "not json"))
+	output, err := invokeModelWithFixtures(context.Background(), model, "plain text", invoke)
+	require.NoError(t, err)
+	assert.Equal(t, "not json", output)
+}
+
+func TestModelFixturesRecordMode(t *testing.T) {
+	setFixtureConfig(t, fixtureModeRecord)
+
+	model := &manifest.ModelInfo{Name: "fixture-model"}
+	input := `{"prompt":"Hello"}`
+	require.NoError(t, writeFixture(getFixturePath(model, input), model, input, `{"text":"old"}`))
+
+	output, err := invokeModelWithFixtures(context.Background(), model, input, func(string) (string, error) {
+		return `{"text":"new"}`, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"text":"new"}`, output)
+
+	recorded, err := readFixture(getFixturePath(model, input))
+	require.NoError(t, err)
+	assert.Equal(t, `{"text":"new"}`, recorded)
+}
+
+func TestModelFixturesDoNotRecordErrors(t *testing.T) {
+	setFixtureConfig(t, fixtureModeAuto)
+
+	model := &manifest.ModelInfo{Name: "fixture-model"}
+	_, err := invokeModelWithFixtures(context.Background(), model, "input", func(string) (string, error) {
+		return "", errors.New("model unavailable")
+	})
+	assert.Error(t, err)
+
+	_, err = os.Stat(getFixturePath(model, "input"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
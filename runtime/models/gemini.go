@@ -0,0 +1,66 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"github.com/tidwall/gjson"
+)
+
+// geminiProvider adapts requests to the Gemini API, as served by Google AI Studio or Vertex AI.
+// See https://ai.google.dev/api and https://cloud.google.com/vertex-ai/generative-ai/docs/model-reference/inference
+//
+// The model's endpoint includes the method, such as "gemini-1.5-flash:generateContent" or "text-embedding-004:embedContent".
+// For Google AI Studio, the host should provide the API key with an "x-goog-api-key" header.
+// For Vertex AI, the host should authenticate with a Google service account.
+type geminiProvider struct {
+	defaultProvider
+}
+
+func (geminiProvider) prepareInput(model *manifest.ModelInfo, input string) (string, error) {
+	if !gjson.Valid(input) {
+		return "", fmt.Errorf("model input is not valid JSON")
+	}
+	return input, nil
+}
+
+// prepareStream changes the generateContent method to streamGenerateContent, which streams server-sent events
+// when the "alt=sse" query parameter is given.  The input is unchanged.
+func (geminiProvider) prepareStream(endpoint, input string) (string, string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", err
+	}
+
+	base, found := strings.CutSuffix(u.Path, ":generateContent")
+	if !found {
+		return "", "", fmt.Errorf("streaming is only supported for the generateContent method")
+	}
+	u.Path = base + ":streamGenerateContent"
+
+	q := u.Query()
+	q.Set("alt", "sse")
+	u.RawQuery = q.Encode()
+
+	return u.String(), input, nil
+}
+
+func (geminiProvider) encodeChatRequest(model *manifest.ModelInfo, req *ChatRequest) (string, error) {
+	return "", fmt.Errorf("tool calling is not yet supported for Gemini models")
+}
+
+func (geminiProvider) decodeChatResponse(output string) (*ChatResponse, error) {
+	return nil, fmt.Errorf("tool calling is not yet supported for Gemini models")
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeminiPrepareStream(t *testing.T) {
+	model := &manifest.ModelInfo{SourceModel: "gemini-1.5-flash", Provider: "gemini"}
+	p := getModelProvider(model)
+
+	input := `{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`
+
+	endpoint, payload, err := p.prepareStream("https://example.com/v1/models/gemini-1.5-flash:generateContent", input)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/v1/models/gemini-1.5-flash:streamGenerateContent?alt=sse", endpoint)
+	assert.Equal(t, input, payload)
+
+	// existing query parameters are preserved
+	endpoint, _, err = p.prepareStream("https://example.com/v1/models/gemini-1.5-flash:generateContent?key=abc", input)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/v1/models/gemini-1.5-flash:streamGenerateContent?alt=sse&key=abc", endpoint)
+
+	_, _, err = p.prepareStream("https://example.com/v1/models/text-embedding-004:embedContent", input)
+	assert.Error(t, err)
+
+	_, err = p.prepareInput(model, `not json`)
+	assert.Error(t, err)
+}
+
+func TestDefaultPrepareStream(t *testing.T) {
+	p := getModelProvider(&manifest.ModelInfo{})
+
+	endpoint, payload, err := p.prepareStream("https://example.com/v1/chat/completions", `{"model":"gpt-4o"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/v1/chat/completions", endpoint)
+	assert.JSONEq(t, `{"model":"gpt-4o","stream":true}`, payload)
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// huggingFaceProvider sends requests to the Hugging Face serverless Inference API,
+// or to a dedicated Inference Endpoint or self-hosted Text Generation Inference (TGI) server.
+// See https://huggingface.co/docs/api-inference and https://huggingface.co/docs/text-generation-inference
+//
+// For the serverless API, the host's base URL is "https://api-inference.huggingface.co/", and the request is
+// routed to the model's source model id, such as "models/mistralai/Mistral-7B-Instruct-v0.3".
+// For a TGI server, the host's base URL is the address of the server, and the model's path selects the operation,
+// such as "generate" for text generation or "v1/chat/completions" for the OpenAI-compatible Messages API.
+// The host should provide the access token with an "Authorization" header, when required.
+//
+// Inputs with "inputs" use the text-generation or feature-extraction tasks, and are sent as-is.
+// Inputs with "messages" use the Messages API, and the model field is filled in when omitted.
+type huggingFaceProvider struct {
+	defaultProvider
+}
+
+func (huggingFaceProvider) prepareInput(model *manifest.ModelInfo, input string) (string, error) {
+	if !gjson.Valid(input) {
+		return "", fmt.Errorf("model input is not valid JSON")
+	}
+
+	if gjson.Get(input, "messages").Exists() && !gjson.Get(input, "model").Exists() && model.SourceModel != "" {
+		return sjson.Set(input, "model", model.SourceModel)
+	}
+
+	return input, nil
+}
+
+// prepareRequest asks the serverless API to wait for the model to load, rather than failing while it is cold.
+func (huggingFaceProvider) prepareRequest(ctx context.Context, model *manifest.ModelInfo, req *http.Request) error {
+	req.Header.Set("x-wait-for-model", "true")
+	return nil
+}
+
+// prepareStream changes the TGI generate operation to generate_stream, which streams server-sent events.
+// Otherwise, streaming is requested in the input, as supported by the serverless API and the Messages API.
+func (p huggingFaceProvider) prepareStream(endpoint, input string) (string, string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", err
+	}
+
+	if base, found := strings.CutSuffix(u.Path, "/generate"); found {
+		u.Path = base + "/generate_stream"
+		return u.String(), input, nil
+	}
+
+	return p.defaultProvider.prepareStream(endpoint, input)
+}
+
+// getEndpoint composes the URL of the model on the host.  The model's path is appended to the host's base URL when set.
+// Otherwise, the source model id is used to route the request, as with the serverless API.
+// A host with an endpoint instead of a base URL, such as a dedicated Inference Endpoint, is used as-is.
+func (huggingFaceProvider) getEndpoint(model *manifest.ModelInfo, host *manifest.HTTPHostInfo) (string, error) {
+	if host.BaseURL != "" && host.Endpoint != "" {
+		return "", fmt.Errorf("specify either base URL or endpoint for a host, not both")
+	}
+
+	if host.BaseURL == "" {
+		if model.Path != "" {
+			return "", fmt.Errorf("model path is defined but host has no base URL")
+		}
+		return host.Endpoint, nil
+	}
+
+	baseUrl := strings.TrimRight(host.BaseURL, "/")
+	if model.Path != "" {
+		return fmt.Sprintf("%s/%s", baseUrl, strings.TrimLeft(model.Path, "/")), nil
+	}
+	if model.SourceModel == "" {
+		return "", fmt.Errorf("model path or source model must be defined for Hugging Face models")
+	}
+	return fmt.Sprintf("%s/models/%s", baseUrl, strings.Trim(model.SourceModel, "/")), nil
+}
+
+func (huggingFaceProvider) encodeEmbeddingRequest(model *manifest.ModelInfo, texts []string) (string, error) {
+	data, err := utils.JsonSerialize(map[string]any{"inputs": texts})
+	return string(data), err
+}
+
+// decodeEmbeddingResponse reads the output of the feature-extraction task, which is a vector per text for
+// sentence embedding models.  Models that return a vector per token are mean-pooled to a vector per text.
+func (huggingFaceProvider) decodeEmbeddingResponse(output string) ([][]float32, error) {
+	results := gjson.Parse(output).Array()
+	vectors := make([][]float32, len(results))
+	for i, r := range results {
+		values := r.Array()
+		if len(values) == 0 || !values[0].IsArray() {
+			v, err := parseVectors([]gjson.Result{r})
+			if err != nil {
+				return nil, fmt.Errorf("model output is missing embedding %d", i)
+			}
+			vectors[i] = v[0]
+			continue
+		}
+
+		tokens, err := parseVectors(values)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = meanPool(tokens)
+	}
+	return vectors, nil
+}
+
+// encodeRerankRequest uses the rerank operation of a Text Embeddings Inference (TEI) server, whose model path is "rerank".
+// See https://huggingface.github.io/text-embeddings-inference
+func (huggingFaceProvider) encodeRerankRequest(model *manifest.ModelInfo, req *RerankRequest) (string, error) {
+	data, err := utils.JsonSerialize(map[string]any{"query": req.Query, "texts": req.Documents})
+	return string(data), err
+}
+
+func (huggingFaceProvider) decodeRerankResponse(output string) ([]*RerankResult, error) {
+	return parseRerankResults(gjson.Parse(output).Array(), "score")
+}
+
+func (huggingFaceProvider) encodeImageRequest(model *manifest.ModelInfo, req *ImageRequest) (string, error) {
+	return "", fmt.Errorf("image generation is not supported for Hugging Face models")
+}
+
+func (huggingFaceProvider) decodeImageResponse(output string) (*ImageResponse, error) {
+	return nil, fmt.Errorf("image generation is not supported for Hugging Face models")
+}
+
+func meanPool(vectors [][]float32) []float32 {
+	if len(vectors) == 0 {
+		return nil
+	}
+	result := make([]float32, len(vectors[0]))
+	for _, v := range vectors {
+		for j := range min(len(v), len(result)) {
+			result[j] += v[j]
+		}
+	}
+	for j := range result {
+		result[j] /= float32(len(vectors))
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/hosts"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHuggingFaceEndpoint(t *testing.T) {
+	p := getModelProvider(&manifest.ModelInfo{Provider: "hugging-face"})
+
+	tests := []struct {
+		baseUrl  string
+		path     string
+		expected string
+	}{
+		{"https://api-inference.huggingface.co/", "", "https://api-inference.huggingface.co/models/BAAI/bge-small-en-v1.5"},
+		{"http://localhost:8080/", "generate", "http://localhost:8080/generate"},
+		{"http://localhost:8080", "v1/chat/completions", "http://localhost:8080/v1/chat/completions"},
+	}
+
+	for _, tt := range tests {
+		host := &manifest.HTTPHostInfo{Name: "hf", BaseURL: tt.baseUrl}
+		endpoint, err := p.getEndpoint(&manifest.ModelInfo{SourceModel: "BAAI/bge-small-en-v1.5", Path: tt.path}, host)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, endpoint)
+	}
+
+	endpoint, err := p.getEndpoint(&manifest.ModelInfo{}, &manifest.HTTPHostInfo{Endpoint: "https://xyz.endpoints.huggingface.cloud"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://xyz.endpoints.huggingface.cloud", endpoint)
+}
+
+func TestHuggingFaceProviderOnlyForExternalHosts(t *testing.T) {
+	p := getModelProvider(&manifest.ModelInfo{Provider: "hugging-face", Host: hosts.HypermodeHost})
+	assert.IsType(t, defaultProvider{}, p)
+}
+
+func TestHuggingFacePrepareStream(t *testing.T) {
+	p := huggingFaceProvider{}
+
+	endpoint, input, err := p.prepareStream("http://localhost:8080/generate", `{"inputs":"hi"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/generate_stream", endpoint)
+	assert.JSONEq(t, `{"inputs":"hi"}`, input)
+
+	endpoint, input, err = p.prepareStream("http://localhost:8080/v1/chat/completions", `{"messages":[]}`)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/v1/chat/completions", endpoint)
+	assert.JSONEq(t, `{"messages":[],"stream":true}`, input)
+}
+
+func TestHuggingFaceEmbeddings(t *testing.T) {
+	p := huggingFaceProvider{}
+
+	input, err := p.encodeEmbeddingRequest(&manifest.ModelInfo{}, []string{"a", "b"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"inputs":["a","b"]}`, input)
+
+	// sentence embeddings
+	vectors, err := p.decodeEmbeddingResponse(`[[0.1,0.2],[0.3,0.4]]`)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, vectors)
+
+	// token embeddings are mean-pooled
+	vectors, err = p.decodeEmbeddingResponse(`[[[1,2],[3,4]],[[5,6]]]`)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{2, 3}, {5, 6}}, vectors)
+}
+
+func TestInvokeHuggingFaceModel(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models/mistralai/Mistral-7B-Instruct-v0.3", r.URL.Path)
+		assert.Equal(t, "true", r.Header.Get("x-wait-for-model"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"inputs":"Hello","parameters":{"max_new_tokens":20}}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"generated_text":"Hello world"}]`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["hf"] = manifest.HTTPHostInfo{
+		Name:    "hf",
+		BaseURL: tsrv.URL + "/",
+	}
+	md.Models["mistral"] = manifest.ModelInfo{
+		Name:        "mistral",
+		SourceModel: "mistralai/Mistral-7B-Instruct-v0.3",
+		Provider:    "hugging-face",
+		Host:        "hf",
+	}
+	defer func() {
+		delete(md.Hosts, "hf")
+		delete(md.Models, "mistral")
+	}()
+
+	output, err := InvokeModel(context.Background(), "mistral", `{"inputs":"Hello","parameters":{"max_new_tokens":20}}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"generated_text":"Hello world"}]`, output)
+}
@@ -0,0 +1,311 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+const maxImageCount = 10
+
+// ImageRequest is a provider-neutral request to generate images from a text prompt.
+// The size is given as "WIDTHxHEIGHT", such as "1024x1024".  The response type is either "data", to return the
+// bytes of each image, or "url", to return a link to the image when the provider stores the images itself.
+type ImageRequest struct {
+	Prompt         string `json:"prompt"`
+	NegativePrompt string `json:"negativePrompt,omitempty"`
+	Count          int    `json:"count,omitempty"`
+	Size           string `json:"size,omitempty"`
+	Format         string `json:"format,omitempty"`
+	ResponseType   string `json:"responseType,omitempty"`
+}
+
+// ImageResponse contains the generated images.  Each image has either a URL or the image's bytes,
+// which are base64 encoded in JSON.
+type ImageResponse struct {
+	Images []*GeneratedImage `json:"images"`
+}
+
+type GeneratedImage struct {
+	Url           string `json:"url,omitempty"`
+	Data          []byte `json:"data,omitempty"`
+	MimeType      string `json:"mimeType,omitempty"`
+	RevisedPrompt string `json:"revisedPrompt,omitempty"`
+}
+
+const (
+	imageResponseData = "data"
+	imageResponseUrl  = "url"
+)
+
+// GenerateImages sends a provider-neutral image generation request to the model, and returns the generated images,
+// both as JSON.  The request is encoded as the model's provider expects.
+func GenerateImages(ctx context.Context, modelName string, request string) (string, error) {
+	model, err := GetModel(modelName)
+	if err != nil {
+		return "", err
+	}
+
+	var req ImageRequest
+	if err := utils.JsonDeserialize([]byte(request), &req); err != nil {
+		return "", fmt.Errorf("invalid image request: %w", err)
+	}
+	if err := req.validate(); err != nil {
+		return "", fmt.Errorf("invalid image request: %w", err)
+	}
+
+	var resp *ImageResponse
+	_, err = invokeModelWithFallbacks(ctx, model, func(ctx context.Context, m *manifest.ModelInfo) (string, error) {
+		input, err := encodeImageRequest(m, &req)
+		if err != nil {
+			return "", err
+		}
+
+		output, err := invokeModel(ctx, m, input)
+		if err != nil {
+			return "", err
+		}
+
+		resp, err = decodeImageResponse(m, output)
+		return output, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, img := range resp.Images {
+		if img.MimeType == "" && len(img.Data) > 0 {
+			img.MimeType = http.DetectContentType(img.Data)
+		}
+	}
+
+	data, err := utils.JsonSerialize(resp)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (r *ImageRequest) validate() error {
+	if r.Prompt == "" {
+		return errors.New("a prompt is required")
+	}
+	if r.Count < 0 || r.Count > maxImageCount {
+		return fmt.Errorf("the number of images must be between 1 and %d", maxImageCount)
+	}
+	if r.Size != "" {
+		if _, _, err := r.dimensions(); err != nil {
+			return err
+		}
+	}
+	switch r.ResponseType {
+	case "", imageResponseData, imageResponseUrl:
+	default:
+		return fmt.Errorf("unknown response type: %s", r.ResponseType)
+	}
+	return nil
+}
+
+func (r *ImageRequest) count() int {
+	if r.Count == 0 {
+		return 1
+	}
+	return r.Count
+}
+
+func (r *ImageRequest) dimensions() (width, height int, err error) {
+	w, h, found := strings.Cut(strings.ToLower(r.Size), "x")
+	if found {
+		width, err1 := strconv.Atoi(w)
+		height, err2 := strconv.Atoi(h)
+		if err1 == nil && err2 == nil && width > 0 && height > 0 {
+			return width, height, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid image size: %s", r.Size)
+}
+
+func encodeImageRequest(model *manifest.ModelInfo, req *ImageRequest) (string, error) {
+	if model.Host == bedrockHost {
+		var input map[string]any
+		switch getBedrockModelFamily(getBedrockModelId(model)) {
+		case "amazon":
+			input = encodeTitanImageRequest(req)
+		case "stability":
+			input = encodeStabilityImageRequest(req)
+		default:
+			return "", fmt.Errorf("image generation is not supported for Bedrock model %s", model.SourceModel)
+		}
+		data, err := utils.JsonSerialize(input)
+		return string(data), err
+	}
+
+	return getModelProvider(model).encodeImageRequest(model, req)
+}
+
+func decodeImageResponse(model *manifest.ModelInfo, output string) (*ImageResponse, error) {
+	if model.Host == bedrockHost {
+		if images := gjson.Get(output, "images"); images.Exists() {
+			return decodeBase64Images(images.Array(), "")
+		}
+		return stabilityProvider{}.decodeImageResponse(output)
+	}
+
+	return getModelProvider(model).decodeImageResponse(output)
+}
+
+// encodeImageRequest uses the OpenAI image generation API.
+// See https://platform.openai.com/docs/api-reference/images/create
+func (defaultProvider) encodeImageRequest(model *manifest.ModelInfo, req *ImageRequest) (string, error) {
+	input := map[string]any{
+		"prompt": req.Prompt,
+		"n":      req.count(),
+	}
+	if model.SourceModel != "" {
+		input["model"] = model.SourceModel
+	}
+	if req.Size != "" {
+		input["size"] = req.Size
+	}
+	if req.Format != "" {
+		input["output_format"] = req.Format
+	}
+	if req.ResponseType == imageResponseUrl {
+		input["response_format"] = "url"
+	} else {
+		input["response_format"] = "b64_json"
+	}
+
+	data, err := utils.JsonSerialize(input)
+	return string(data), err
+}
+
+func (defaultProvider) decodeImageResponse(output string) (*ImageResponse, error) {
+	data := gjson.Get(output, "data").Array()
+	resp := &ImageResponse{Images: make([]*GeneratedImage, 0, len(data))}
+	for _, d := range data {
+		img := &GeneratedImage{
+			Url:           d.Get("url").String(),
+			RevisedPrompt: d.Get("revised_prompt").String(),
+		}
+		if b64 := d.Get("b64_json"); b64.Exists() {
+			bytes, err := base64.StdEncoding.DecodeString(b64.String())
+			if err != nil {
+				return nil, fmt.Errorf("model output has an invalid image: %w", err)
+			}
+			img.Data = bytes
+		}
+		resp.Images = append(resp.Images, img)
+	}
+
+	if len(resp.Images) == 0 {
+		return nil, errors.New("model output has no images")
+	}
+	return resp, nil
+}
+
+// encodeImageRequest uses the Imagen predict method on Vertex AI, such as "imagen-3.0-generate-001:predict".
+func (geminiProvider) encodeImageRequest(model *manifest.ModelInfo, req *ImageRequest) (string, error) {
+	parameters := map[string]any{"sampleCount": req.count()}
+	if req.NegativePrompt != "" {
+		parameters["negativePrompt"] = req.NegativePrompt
+	}
+	if req.Size != "" {
+		w, h, _ := req.dimensions()
+		parameters["aspectRatio"] = aspectRatio(w, h)
+	}
+	if req.Format != "" {
+		parameters["outputOptions"] = map[string]any{"mimeType": "image/" + req.Format}
+	}
+
+	input := map[string]any{
+		"instances":  []map[string]any{{"prompt": req.Prompt}},
+		"parameters": parameters,
+	}
+	data, err := utils.JsonSerialize(input)
+	return string(data), err
+}
+
+func (geminiProvider) decodeImageResponse(output string) (*ImageResponse, error) {
+	predictions := gjson.Get(output, "predictions").Array()
+	resp := &ImageResponse{Images: make([]*GeneratedImage, 0, len(predictions))}
+	for _, p := range predictions {
+		images, err := decodeBase64Images([]gjson.Result{p.Get("bytesBase64Encoded")}, p.Get("mimeType").String())
+		if err != nil {
+			return nil, err
+		}
+		resp.Images = append(resp.Images, images.Images...)
+	}
+	return resp, nil
+}
+
+func (anthropicProvider) encodeImageRequest(model *manifest.ModelInfo, req *ImageRequest) (string, error) {
+	return "", fmt.Errorf("image generation is not supported for Anthropic models")
+}
+
+func (anthropicProvider) decodeImageResponse(output string) (*ImageResponse, error) {
+	return nil, fmt.Errorf("image generation is not supported for Anthropic models")
+}
+
+// encodeTitanImageRequest uses the request format of Amazon Titan Image Generator models on Bedrock.
+func encodeTitanImageRequest(req *ImageRequest) map[string]any {
+	params := map[string]any{"text": req.Prompt}
+	if req.NegativePrompt != "" {
+		params["negativeText"] = req.NegativePrompt
+	}
+
+	config := map[string]any{"numberOfImages": req.count()}
+	if req.Size != "" {
+		w, h, _ := req.dimensions()
+		config["width"], config["height"] = w, h
+	}
+
+	return map[string]any{
+		"taskType":              "TEXT_IMAGE",
+		"textToImageParams":     params,
+		"imageGenerationConfig": config,
+	}
+}
+
+func decodeBase64Images(values []gjson.Result, mimeType string) (*ImageResponse, error) {
+	resp := &ImageResponse{Images: make([]*GeneratedImage, 0, len(values))}
+	for _, v := range values {
+		bytes, err := base64.StdEncoding.DecodeString(v.String())
+		if err != nil || len(bytes) == 0 {
+			return nil, errors.New("model output has an invalid image")
+		}
+		resp.Images = append(resp.Images, &GeneratedImage{Data: bytes, MimeType: mimeType})
+	}
+
+	if len(resp.Images) == 0 {
+		return nil, errors.New("model output has no images")
+	}
+	return resp, nil
+}
+
+// aspectRatio returns the reduced ratio of the dimensions, such as "16:9".
+func aspectRatio(width, height int) string {
+	a, b := width, height
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return fmt.Sprintf("%d:%d", width/a, height/a)
+}
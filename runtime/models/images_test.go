@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+// the first bytes of a PNG file, which are enough to detect the content type
+var testPngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestEncodeImageRequest(t *testing.T) {
+	req := &ImageRequest{Prompt: "a red fox", NegativePrompt: "blurry", Count: 2, Size: "1792x1024"}
+
+	tests := []struct {
+		model    *manifest.ModelInfo
+		expected string
+	}{
+		{&manifest.ModelInfo{Host: "openai", SourceModel: "dall-e-3"},
+			`{"model":"dall-e-3","prompt":"a red fox","n":2,"size":"1792x1024","response_format":"b64_json"}`},
+		{&manifest.ModelInfo{Host: "stability", Provider: "stability"},
+			`{"text_prompts":[{"text":"a red fox","weight":1},{"text":"blurry","weight":-1}],"samples":2,"width":1792,"height":1024}`},
+		{&manifest.ModelInfo{Host: bedrockHost, SourceModel: "amazon.titan-image-generator-v1"},
+			`{"taskType":"TEXT_IMAGE","textToImageParams":{"text":"a red fox","negativeText":"blurry"},"imageGenerationConfig":{"numberOfImages":2,"width":1792,"height":1024}}`},
+		{&manifest.ModelInfo{Host: "vertex", Provider: "gemini"},
+			`{"instances":[{"prompt":"a red fox"}],"parameters":{"sampleCount":2,"negativePrompt":"blurry","aspectRatio":"7:4"}}`},
+	}
+
+	for _, tt := range tests {
+		input, err := encodeImageRequest(tt.model, req)
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.expected, input)
+	}
+}
+
+func TestImageRequestValidate(t *testing.T) {
+	assert.Error(t, (&ImageRequest{}).validate())
+	assert.Error(t, (&ImageRequest{Prompt: "x", Size: "large"}).validate())
+	assert.Error(t, (&ImageRequest{Prompt: "x", Count: 11}).validate())
+	assert.Error(t, (&ImageRequest{Prompt: "x", ResponseType: "file"}).validate())
+	assert.NoError(t, (&ImageRequest{Prompt: "x", Size: "512x512", ResponseType: "url"}).validate())
+}
+
+func TestGenerateImages(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString(testPngBytes)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "a red fox", gjson.GetBytes(body, "text_prompts.0.text").String())
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"artifacts":[{"base64":"` + b64 + `","finishReason":"SUCCESS"},{"base64":"","finishReason":"CONTENT_FILTERED"}]}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["stability"] = manifest.HTTPHostInfo{Name: "stability", BaseURL: tsrv.URL + "/"}
+	md.Models["sdxl"] = manifest.ModelInfo{
+		Name:     "sdxl",
+		Provider: "stability",
+		Host:     "stability",
+		Path:     "v1/generation/stable-diffusion-xl-1024-v1-0/text-to-image",
+	}
+	defer func() {
+		delete(md.Hosts, "stability")
+		delete(md.Models, "sdxl")
+	}()
+
+	output, err := GenerateImages(context.Background(), "sdxl", `{"prompt":"a red fox"}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"images":[{"data":"`+b64+`","mimeType":"image/png"}]}`, output)
+}
@@ -55,12 +55,30 @@ func InvokeModel(ctx context.Context, modelName string, input string) (string, e
 		return "", err
 	}
 
-	// TODO: use the provider pattern instead of branching
-	if model.Host == "aws-bedrock" {
-		return invokeAwsBedrockModel(ctx, model, input)
-	}
+	return invokeModelWithFallbacks(ctx, model, func(ctx context.Context, m *manifest.ModelInfo) (string, error) {
+		if m != model {
+			return invokeModel(ctx, m, setInputModel(input, m))
+		}
+		return invokeModel(ctx, m, input)
+	})
+}
+
+func invokeModel(ctx context.Context, model *manifest.ModelInfo, input string) (string, error) {
+	return invokeModelWithModeration(ctx, model, input, func(input string) (string, error) {
+		return invokeModelWithCache(ctx, model, input, func(input string) (string, error) {
+			// TODO: use the provider pattern instead of branching
+			if model.Host == bedrockHost {
+				return invokeAwsBedrockModel(ctx, model, input)
+			}
+
+			input, err := getModelProvider(model).prepareInput(model, input)
+			if err != nil {
+				return "", err
+			}
 
-	return PostToModelEndpoint[string](ctx, model, input)
+			return PostToModelEndpoint[string](ctx, model, input)
+		})
+	})
 }
 
 func PostToModelEndpoint[TResult any](ctx context.Context, model *manifest.ModelInfo, payload any) (TResult, error) {
@@ -74,27 +92,44 @@ func PostToModelEndpoint[TResult any](ctx context.Context, model *manifest.Model
 	}
 
 	bs := func(ctx context.Context, req *http.Request) error {
-		req.Header.Set("Content-Type", "application/json")
-		if host.Name != hosts.HypermodeHost {
-			return secrets.ApplyHostSecretsToHttpRequest(ctx, host, req)
-		}
-		if config.IsDevEnvironment() {
-			return secrets.ApplyAuthToLocalModelRequest(ctx, host, req)
-		}
-		return nil
+		return prepareModelRequest(ctx, model, host, req)
+	}
+
+	release, err := hosts.AcquireRateLimit(ctx, host)
+	if err != nil {
+		var empty TResult
+		return empty, err
 	}
+	defer release()
 
+	startTime := utils.GetTime()
 	res, err := utils.PostHttp[TResult](ctx, endpoint, payload, bs)
 	if err != nil {
+		recordModelInvocation(ctx, model, nil, utils.GetTime().Sub(startTime), err)
 		var empty TResult
 		return empty, err
 	}
 
+	recordModelInvocation(ctx, model, res.Data, res.Duration(), nil)
 	db.WriteInferenceHistory(ctx, model, payload, res.Data, res.StartTime, res.EndTime)
 
 	return res.Data, nil
 }
 
+func prepareModelRequest(ctx context.Context, model *manifest.ModelInfo, host *manifest.HTTPHostInfo, req *http.Request) error {
+	req.Header.Set("Content-Type", "application/json")
+	if host.Name != hosts.HypermodeHost {
+		if err := secrets.ApplyHostSecretsToHttpRequest(ctx, host, req); err != nil {
+			return err
+		}
+		return getModelProvider(model).prepareRequest(ctx, model, req)
+	}
+	if config.IsDevEnvironment() {
+		return secrets.ApplyAuthToLocalModelRequest(ctx, host, req)
+	}
+	return nil
+}
+
 func getModelEndpointAndHost(model *manifest.ModelInfo) (string, *manifest.HTTPHostInfo, error) {
 
 	host, err := hosts.GetHttpHost(model.Host)
@@ -116,23 +151,8 @@ func getModelEndpointAndHost(model *manifest.ModelInfo) (string, *manifest.HTTPH
 		return endpoint, host, nil
 	}
 
-	if host.BaseURL != "" && host.Endpoint != "" {
-		return "", host, fmt.Errorf("specify either base URL or endpoint for a host, not both")
-	}
-
-	if host.BaseURL != "" {
-		if model.Path == "" {
-			return "", host, fmt.Errorf("model path is not defined")
-		}
-		endpoint := fmt.Sprintf("%s/%s", strings.TrimRight(host.BaseURL, "/"), strings.TrimLeft(model.Path, "/"))
-		return endpoint, host, nil
-	}
-
-	if model.Path != "" {
-		return "", host, fmt.Errorf("model path is defined but host has no base URL")
-	}
-
-	return host.Endpoint, host, nil
+	endpoint, err := getModelProvider(model).getEndpoint(model, host)
+	return endpoint, host, err
 }
 
 func isValidLocalHypermodeModel(modelName string) bool {
@@ -0,0 +1,291 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/metrics"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+/*
+
+DESIGN NOTES:
+
+- A model's moderation policy checks the text of its input before the model is invoked, and the text of its output
+  after.  The text is every string value in the JSON, except for fields that name things rather than hold content,
+  such as "model" or "role", so that the policy works the same way for every provider.
+- The moderation model is invoked with the OpenAI moderations API, which is also followed by other providers.
+  It is invoked without moderation of its own, and its invocations are counted like any other.
+- Deny list terms are matched without regard to case.  Patterns are Go regular expressions, compiled once each.
+- Blocked violations fail the invocation with an error.  Flagged violations are allowed.  Both are returned to
+  the caller in the response extensions, counted in the metrics, and logged for audit, without the offending text.
+- If the moderation model fails, the invocation fails when the policy blocks violations, and is allowed with
+  a warning when the policy only flags them.
+- Streamed responses are only checked on input, since the output is passed to the function as it arrives.
+
+*/
+
+const (
+	moderationStageInput  = "input"
+	moderationStageOutput = "output"
+)
+
+// nonContentFields are JSON fields whose string values identify things, rather than hold text to moderate.
+var nonContentFields = map[string]bool{
+	"model":         true,
+	"role":          true,
+	"type":          true,
+	"id":            true,
+	"object":        true,
+	"finish_reason": true,
+	"stop_reason":   true,
+	"finishReason":  true,
+	"mime_type":     true,
+	"mimeType":      true,
+	"tool_call_id":  true,
+	"url":           true,
+	"b64_json":      true,
+}
+
+var moderationPatterns sync.Map // map[string]*regexp.Regexp
+
+// ModerationError is returned when a moderation policy blocks a model's input or output.
+type ModerationError struct {
+	Model   string
+	Stage   string
+	Reasons []string
+}
+
+func (e *ModerationError) Error() string {
+	return fmt.Sprintf("the %s of model %s was blocked by its moderation policy: %s", e.Stage, e.Model, strings.Join(e.Reasons, ", "))
+}
+
+// invokeModelWithModeration checks the input and output of the invocation with the model's moderation policy, if any.
+func invokeModelWithModeration(ctx context.Context, model *manifest.ModelInfo, input string, invoke func(string) (string, error)) (string, error) {
+	policy := model.Moderation
+	if policy == nil {
+		return invoke(input)
+	}
+
+	if appliesTo(policy, moderationStageInput) {
+		if err := moderate(ctx, model, moderationStageInput, input); err != nil {
+			return "", err
+		}
+	}
+
+	output, err := invoke(input)
+	if err != nil {
+		return "", err
+	}
+
+	if appliesTo(policy, moderationStageOutput) {
+		if err := moderate(ctx, model, moderationStageOutput, output); err != nil {
+			return "", err
+		}
+	}
+
+	return output, nil
+}
+
+// moderateModelInput checks only the input of the invocation with the model's moderation policy, if any.
+func moderateModelInput(ctx context.Context, model *manifest.ModelInfo, input string) error {
+	if model.Moderation == nil || !appliesTo(model.Moderation, moderationStageInput) {
+		return nil
+	}
+	return moderate(ctx, model, moderationStageInput, input)
+}
+
+func appliesTo(policy *manifest.ModerationInfo, stage string) bool {
+	switch policy.Apply {
+	case "", manifest.ModerationApplyBoth:
+		return true
+	default:
+		return policy.Apply == stage
+	}
+}
+
+// moderate checks the text of the JSON with the model's moderation policy, and handles any violation.
+func moderate(ctx context.Context, model *manifest.ModelInfo, stage, data string) error {
+	policy := model.Moderation
+	block := policy.Action != manifest.ModerationActionFlag
+
+	reasons, err := checkModeration(ctx, policy, extractModerationText(data))
+	if err != nil {
+		if block {
+			return fmt.Errorf("failed to moderate the %s of model %s: %w", stage, model.Name, err)
+		}
+		logger.Warn(ctx).Err(err).Str("model", model.Name).Str("stage", stage).Msg("Failed to moderate model text.")
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	action := "flagged"
+	if block {
+		action = "blocked"
+	}
+
+	metrics.ModelModerationViolationsNum.WithLabelValues(model.Name, stage, action).Inc()
+	utils.AddModerationFlag(ctx, utils.ModerationFlag{
+		Model:   model.Name,
+		Stage:   stage,
+		Action:  action,
+		Reasons: reasons,
+	})
+	logger.Warn(ctx).
+		Str("model", model.Name).
+		Str("stage", stage).
+		Str("action", action).
+		Strs("reasons", reasons).
+		Msg("Model text violated the moderation policy.")
+
+	if block {
+		return &ModerationError{Model: model.Name, Stage: stage, Reasons: reasons}
+	}
+	return nil
+}
+
+// checkModeration returns the reasons the text violates the policy, if any.
+func checkModeration(ctx context.Context, policy *manifest.ModerationInfo, text string) ([]string, error) {
+	var reasons []string
+	if text == "" {
+		return reasons, nil
+	}
+
+	lower := strings.ToLower(text)
+	for _, term := range policy.DenyList {
+		if term != "" && strings.Contains(lower, strings.ToLower(term)) {
+			reasons = append(reasons, "deny list: "+term)
+		}
+	}
+
+	for _, pattern := range policy.Patterns {
+		re, err := getModerationPattern(pattern)
+		if err != nil {
+			return reasons, err
+		}
+		if re.MatchString(text) {
+			reasons = append(reasons, "pattern: "+pattern)
+		}
+	}
+
+	if policy.Model != "" {
+		categories, err := invokeModerationModel(ctx, policy.Model, text)
+		if err != nil {
+			return reasons, err
+		}
+		reasons = append(reasons, categories...)
+	}
+
+	return reasons, nil
+}
+
+func getModerationPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := moderationPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid moderation pattern %q: %w", pattern, err)
+	}
+	moderationPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// invokeModerationModel returns the categories that the moderation model flags the text for.
+// See https://platform.openai.com/docs/api-reference/moderations
+func invokeModerationModel(ctx context.Context, modelName, text string) ([]string, error) {
+	model, err := GetModel(modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	input := map[string]any{"input": text}
+	if model.SourceModel != "" {
+		input["model"] = model.SourceModel
+	}
+	data, err := utils.JsonSerialize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := invokeModelWithCache(ctx, model, string(data), func(input string) (string, error) {
+		return PostToModelEndpoint[string](ctx, model, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := gjson.Get(output, "results")
+	if !results.IsArray() {
+		return nil, fmt.Errorf("moderation model output is missing results")
+	}
+
+	var categories []string
+	for _, r := range results.Array() {
+		if !r.Get("flagged").Bool() {
+			continue
+		}
+		found := false
+		r.Get("categories").ForEach(func(key, value gjson.Result) bool {
+			if value.Bool() {
+				categories = append(categories, "category: "+key.String())
+				found = true
+			}
+			return true
+		})
+		if !found {
+			categories = append(categories, "category: flagged")
+		}
+	}
+	return categories, nil
+}
+
+// extractModerationText returns the content of the JSON, which is every string value other than those that name things.
+// Text that isn't valid JSON is moderated as-is.
+func extractModerationText(data string) string {
+	if !gjson.Valid(data) {
+		return data
+	}
+
+	var sb strings.Builder
+	var walk func(key string, value gjson.Result)
+	walk = func(key string, value gjson.Result) {
+		switch {
+		case value.IsObject() || value.IsArray():
+			value.ForEach(func(k, v gjson.Result) bool {
+				if k.Type == gjson.String {
+					walk(k.String(), v)
+				} else {
+					walk(key, v)
+				}
+				return true
+			})
+		case value.Type == gjson.String && !nonContentFields[key]:
+			if sb.Len() > 0 {
+				sb.WriteByte('\n')
+			}
+			sb.WriteString(value.String())
+		}
+	}
+	walk("", gjson.Parse(data))
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestExtractModerationText(t *testing.T) {
+	input := `{"model":"gpt-4o","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":[{"type":"text","text":"Hello"}]}]}`
+	assert.Equal(t, "Be brief.\nHello", extractModerationText(input))
+
+	output := `{"id":"x","choices":[{"message":{"role":"assistant","content":"Hi there"},"finish_reason":"stop"}]}`
+	assert.Equal(t, "Hi there", extractModerationText(output))
+
+	assert.Equal(t, "plain text", extractModerationText("plain text"))
+}
+
+func TestCheckModeration(t *testing.T) {
+	policy := &manifest.ModerationInfo{
+		DenyList: []string{"Internal Only"},
+		Patterns: []string{`\b\d{3}-\d{2}-\d{4}\b`},
+	}
+
+	reasons, err := checkModeration(context.Background(), policy, "This is internal only.  SSN 123-45-6789.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deny list: Internal Only", `pattern: \b\d{3}-\d{2}-\d{4}\b`}, reasons)
+
+	reasons, err = checkModeration(context.Background(), policy, "Nothing to see here.")
+	require.NoError(t, err)
+	assert.Empty(t, reasons)
+
+	_, err = checkModeration(context.Background(), &manifest.ModerationInfo{Patterns: []string{"("}}, "text")
+	assert.Error(t, err)
+}
+
+func TestInvokeModelWithModeration(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/moderations":
+			flagged := gjson.GetBytes(body, "input").String() == "I will hurt you"
+			if flagged {
+				_, _ = w.Write([]byte(`{"results":[{"flagged":true,"categories":{"violence":true,"hate":false}}]}`))
+			} else {
+				_, _ = w.Write([]byte(`{"results":[{"flagged":false,"categories":{"violence":false}}]}`))
+			}
+		case "/v1/chat/completions":
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"The code is ABC-123."}}]}`))
+		}
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["moderated"] = manifest.HTTPHostInfo{
+		Name:    "moderated",
+		BaseURL: tsrv.URL + "/",
+	}
+	md.Models["moderator"] = manifest.ModelInfo{
+		Name:        "moderator",
+		SourceModel: "omni-moderation-latest",
+		Host:        "moderated",
+		Path:        "v1/moderations",
+	}
+	md.Models["guarded"] = manifest.ModelInfo{
+		Name:        "guarded",
+		SourceModel: "gpt-4o",
+		Host:        "moderated",
+		Path:        "v1/chat/completions",
+		Moderation: &manifest.ModerationInfo{
+			Model:    "moderator",
+			Patterns: []string{`[A-Z]{3}-\d{3}`},
+			Apply:    manifest.ModerationApplyBoth,
+		},
+	}
+	defer func() {
+		delete(md.Hosts, "moderated")
+		delete(md.Models, "moderator")
+		delete(md.Models, "guarded")
+	}()
+
+	newContext := func() (context.Context, *[]utils.ModerationFlag) {
+		flags := &[]utils.ModerationFlag{}
+		return context.WithValue(context.Background(), utils.ModerationFlagsContextKey, flags), flags
+	}
+
+	// the input is blocked by the moderation model
+	ctx, flags := newContext()
+	_, err := InvokeModel(ctx, "guarded", `{"messages":[{"role":"user","content":"I will hurt you"}]}`)
+	var modErr *ModerationError
+	require.ErrorAs(t, err, &modErr)
+	assert.Equal(t, moderationStageInput, modErr.Stage)
+	assert.Equal(t, []utils.ModerationFlag{{Model: "guarded", Stage: "input", Action: "blocked", Reasons: []string{"category: violence"}}}, *flags)
+
+	// the output is blocked by the pattern
+	ctx, flags = newContext()
+	_, err = InvokeModel(ctx, "guarded", `{"messages":[{"role":"user","content":"What is the code?"}]}`)
+	require.ErrorAs(t, err, &modErr)
+	assert.Equal(t, moderationStageOutput, modErr.Stage)
+	require.Len(t, *flags, 1)
+
+	// the output is flagged, but allowed
+	md.Models["guarded"].Moderation.Action = manifest.ModerationActionFlag
+	ctx, flags = newContext()
+	output, err := InvokeModel(ctx, "guarded", `{"messages":[{"role":"user","content":"What is the code?"}]}`)
+	require.NoError(t, err)
+	assert.Contains(t, output, "ABC-123")
+	assert.Equal(t, []utils.ModerationFlag{{Model: "guarded", Stage: "output", Action: "flagged", Reasons: []string{`pattern: [A-Z]{3}-\d{3}`}}}, *flags)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// openAICompatibleProvider sends requests to a self-hosted server that implements the OpenAI API,
+// such as Ollama, vLLM, or the llama.cpp server.
+//
+// The host's base URL is the address of the server, such as "http://localhost:11434/" for Ollama,
+// or "http://localhost:8000/v1/" for vLLM.  The model's path defaults to the chat completions operation.
+// These servers usually don't require an API key, so the host doesn't need any headers or secrets.
+type openAICompatibleProvider struct {
+	defaultProvider
+}
+
+func (openAICompatibleProvider) prepareInput(model *manifest.ModelInfo, input string) (string, error) {
+	if !gjson.Valid(input) {
+		return "", fmt.Errorf("model input is not valid JSON")
+	}
+
+	// The server may host several models, so the model field is required, but can be inferred.
+	if !gjson.Get(input, "model").Exists() && model.SourceModel != "" {
+		return sjson.Set(input, "model", model.SourceModel)
+	}
+
+	return input, nil
+}
+
+// getEndpoint composes the URL of the operation on the server, such as "http://localhost:11434/v1/chat/completions".
+// The "/v1" prefix is added to the model's path, unless the host's base URL already includes it.
+// A host with an endpoint instead of a base URL is used as-is.
+func (openAICompatibleProvider) getEndpoint(model *manifest.ModelInfo, host *manifest.HTTPHostInfo) (string, error) {
+	if host.BaseURL != "" && host.Endpoint != "" {
+		return "", fmt.Errorf("specify either base URL or endpoint for a host, not both")
+	}
+
+	if host.BaseURL == "" {
+		if model.Path != "" {
+			return "", fmt.Errorf("model path is defined but host has no base URL")
+		}
+		return host.Endpoint, nil
+	}
+
+	baseUrl := strings.TrimRight(host.BaseURL, "/")
+	path := strings.Trim(model.Path, "/")
+	if path == "" {
+		path = "chat/completions"
+	}
+	if !strings.HasSuffix(baseUrl, "/v1") && !strings.HasPrefix(path, "v1/") {
+		path = "v1/" + path
+	}
+
+	return fmt.Sprintf("%s/%s", baseUrl, path), nil
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAICompatibleEndpoint(t *testing.T) {
+	p := getModelProvider(&manifest.ModelInfo{Provider: "openai-compatible"})
+
+	tests := []struct {
+		baseUrl  string
+		path     string
+		expected string
+	}{
+		{"http://localhost:11434/", "", "http://localhost:11434/v1/chat/completions"},
+		{"http://localhost:11434/", "embeddings", "http://localhost:11434/v1/embeddings"},
+		{"http://localhost:8000/v1/", "", "http://localhost:8000/v1/chat/completions"},
+		{"http://localhost:8080/", "v1/completions", "http://localhost:8080/v1/completions"},
+	}
+
+	for _, tt := range tests {
+		host := &manifest.HTTPHostInfo{Name: "local", BaseURL: tt.baseUrl}
+		endpoint, err := p.getEndpoint(&manifest.ModelInfo{SourceModel: "llama3.2", Path: tt.path}, host)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, endpoint)
+	}
+}
+
+func TestInvokeOpenAICompatibleModel(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"model":"llama3.2","messages":[{"role":"user","content":"hi"}]}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hello"}}]}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["ollama"] = manifest.HTTPHostInfo{
+		Name:    "ollama",
+		BaseURL: tsrv.URL + "/",
+	}
+	md.Models["local-llama"] = manifest.ModelInfo{
+		Name:        "local-llama",
+		SourceModel: "llama3.2",
+		Provider:    "openai-compatible",
+		Host:        "ollama",
+	}
+	defer func() {
+		delete(md.Hosts, "ollama")
+		delete(md.Models, "local-llama")
+	}()
+
+	// the model name is filled in when the input omits it
+	output, err := InvokeModel(context.Background(), "local-llama", `{"messages":[{"role":"user","content":"hi"}]}`)
+	require.NoError(t, err)
+	assert.Contains(t, output, `"content":"hello"`)
+}
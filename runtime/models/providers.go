@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/hosts"
+
+	"github.com/tidwall/sjson"
+)
+
+// A modelProvider adapts model invocations to the API of a particular model provider.
+type modelProvider interface {
+
+	// prepareInput adjusts the input before it is sent to the model, such as by filling in required fields.
+	prepareInput(model *manifest.ModelInfo, input string) (string, error)
+
+	// prepareRequest adds any provider-specific headers to the request.
+	prepareRequest(ctx context.Context, model *manifest.ModelInfo, req *http.Request) error
+
+	// prepareStream returns the endpoint and input to use to request a streaming response.
+	prepareStream(endpoint, input string) (string, string, error)
+
+	// getEndpoint returns the URL of the model's endpoint on an external host.
+	getEndpoint(model *manifest.ModelInfo, host *manifest.HTTPHostInfo) (string, error)
+
+	// encodeChatRequest converts a provider-neutral chat request, which may declare tools, to the provider's input.
+	encodeChatRequest(model *manifest.ModelInfo, req *ChatRequest) (string, error)
+
+	// decodeChatResponse converts the provider's output to a provider-neutral chat response, including any tool calls.
+	decodeChatResponse(output string) (*ChatResponse, error)
+
+	// encodeEmbeddingRequest returns the provider's input to compute embeddings for a batch of texts.
+	encodeEmbeddingRequest(model *manifest.ModelInfo, texts []string) (string, error)
+
+	// decodeEmbeddingResponse returns the embeddings from the provider's output, in the order of the texts.
+	decodeEmbeddingResponse(output string) ([][]float32, error)
+
+	// encodeImageRequest converts a provider-neutral image generation request to the provider's input.
+	encodeImageRequest(model *manifest.ModelInfo, req *ImageRequest) (string, error)
+
+	// decodeImageResponse returns the generated images from the provider's output.
+	decodeImageResponse(output string) (*ImageResponse, error)
+
+	// encodeRerankRequest converts a provider-neutral rerank request to the provider's input.
+	encodeRerankRequest(model *manifest.ModelInfo, req *RerankRequest) (string, error)
+
+	// decodeRerankResponse returns the relevance score of each document from the provider's output, in any order.
+	decodeRerankResponse(output string) ([]*RerankResult, error)
+}
+
+// providers contains the model providers that need special handling, keyed by the provider name used in the manifest.
+// Models from other providers are invoked by sending the input to the host as-is.
+var providers = map[string]modelProvider{
+	"anthropic":         anthropicProvider{},
+	"azure-openai":      azureOpenAIProvider{},
+	"gemini":            geminiProvider{},
+	"hugging-face":      huggingFaceProvider{},
+	"openai-compatible": openAICompatibleProvider{},
+	"stability":         stabilityProvider{},
+	"voyage":            voyageProvider{},
+}
+
+func getModelProvider(model *manifest.ModelInfo) modelProvider {
+	// Models hosted on Hypermode name their source provider, but are invoked the same way regardless of it.
+	if model.Host == hosts.HypermodeHost {
+		return defaultProvider{}
+	}
+	if p, ok := providers[strings.ToLower(model.Provider)]; ok {
+		return p
+	}
+	return defaultProvider{}
+}
+
+// defaultProvider handles models that use the OpenAI API conventions, and is the basis of other providers.
+type defaultProvider struct{}
+
+func (defaultProvider) prepareInput(model *manifest.ModelInfo, input string) (string, error) {
+	return input, nil
+}
+
+func (defaultProvider) prepareRequest(ctx context.Context, model *manifest.ModelInfo, req *http.Request) error {
+	return nil
+}
+
+func (defaultProvider) prepareStream(endpoint, input string) (string, string, error) {
+	input, err := sjson.Set(input, "stream", true)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid model input: %w", err)
+	}
+	return endpoint, input, nil
+}
+
+func (defaultProvider) getEndpoint(model *manifest.ModelInfo, host *manifest.HTTPHostInfo) (string, error) {
+	if host.BaseURL != "" && host.Endpoint != "" {
+		return "", fmt.Errorf("specify either base URL or endpoint for a host, not both")
+	}
+
+	if host.BaseURL != "" {
+		if model.Path == "" {
+			return "", fmt.Errorf("model path is not defined")
+		}
+		endpoint := fmt.Sprintf("%s/%s", strings.TrimRight(host.BaseURL, "/"), strings.TrimLeft(model.Path, "/"))
+		return endpoint, nil
+	}
+
+	if model.Path != "" {
+		return "", fmt.Errorf("model path is defined but host has no base URL")
+	}
+
+	return host.Endpoint, nil
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/hosts"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+const maxRerankDocuments = 1000
+
+// RerankRequest is a provider-neutral request to score candidate documents by their relevance to a query.
+// When TopN is set, only that many of the most relevant documents are returned.
+type RerankRequest struct {
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"topN,omitempty"`
+}
+
+// RerankResponse contains the scored documents, ordered from most to least relevant.
+type RerankResponse struct {
+	Results []*RerankResult `json:"results"`
+}
+
+// RerankResult is the relevance score of a document.  The index is the position of the document in the request.
+type RerankResult struct {
+	Index    int     `json:"index"`
+	Score    float64 `json:"score"`
+	Document string  `json:"document"`
+}
+
+// RerankDocuments sends a provider-neutral rerank request to the model, and returns the scored documents,
+// both as JSON.  The request is encoded as the model's provider expects.
+func RerankDocuments(ctx context.Context, modelName string, request string) (string, error) {
+	model, err := GetModel(modelName)
+	if err != nil {
+		return "", err
+	}
+
+	var req RerankRequest
+	if err := utils.JsonDeserialize([]byte(request), &req); err != nil {
+		return "", fmt.Errorf("invalid rerank request: %w", err)
+	}
+	if err := req.validate(); err != nil {
+		return "", fmt.Errorf("invalid rerank request: %w", err)
+	}
+
+	resp := &RerankResponse{Results: []*RerankResult{}}
+	if len(req.Documents) > 0 {
+		_, err = invokeModelWithFallbacks(ctx, model, func(ctx context.Context, m *manifest.ModelInfo) (string, error) {
+			input, err := encodeRerankRequest(m, &req)
+			if err != nil {
+				return "", err
+			}
+
+			output, err := invokeModel(ctx, m, input)
+			if err != nil {
+				return "", err
+			}
+
+			resp, err = decodeRerankResponse(m, output, &req)
+			return output, err
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	data, err := utils.JsonSerialize(resp)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (r *RerankRequest) validate() error {
+	if r.Query == "" {
+		return errors.New("a query is required")
+	}
+	if len(r.Documents) > maxRerankDocuments {
+		return fmt.Errorf("the number of documents must be at most %d", maxRerankDocuments)
+	}
+	if r.TopN < 0 {
+		return errors.New("topN must not be negative")
+	}
+	return nil
+}
+
+func encodeRerankRequest(model *manifest.ModelInfo, req *RerankRequest) (string, error) {
+	switch {
+	case model.Host == hosts.HypermodeHost:
+		return "", fmt.Errorf("reranking is not supported for models hosted on Hypermode")
+	case model.Host == bedrockHost:
+		if getBedrockModelFamily(getBedrockModelId(model)) != "cohere" {
+			return "", fmt.Errorf("reranking is not supported for Bedrock model %s", model.SourceModel)
+		}
+		input := map[string]any{"query": req.Query, "documents": req.Documents, "api_version": 2}
+		if req.TopN > 0 {
+			input["top_n"] = req.TopN
+		}
+		data, err := utils.JsonSerialize(input)
+		return string(data), err
+	}
+
+	return getModelProvider(model).encodeRerankRequest(model, req)
+}
+
+// decodeRerankResponse reads the provider's scores, then attaches the documents, sorts the results by score,
+// and keeps the top results, since not all providers do so.
+func decodeRerankResponse(model *manifest.ModelInfo, output string, req *RerankRequest) (*RerankResponse, error) {
+	var results []*RerankResult
+	var err error
+	if model.Host == bedrockHost {
+		results, err = defaultProvider{}.decodeRerankResponse(output)
+	} else {
+		results, err = getModelProvider(model).decodeRerankResponse(output)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		if r.Index < 0 || r.Index >= len(req.Documents) {
+			return nil, fmt.Errorf("model output has an invalid document index: %d", r.Index)
+		}
+		r.Document = req.Documents[r.Index]
+	}
+
+	slices.SortStableFunc(results, func(a, b *RerankResult) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		default:
+			return 0
+		}
+	})
+	if req.TopN > 0 && len(results) > req.TopN {
+		results = results[:req.TopN]
+	}
+
+	return &RerankResponse{Results: results}, nil
+}
+
+func parseRerankResults(values []gjson.Result, scoreField string) ([]*RerankResult, error) {
+	results := make([]*RerankResult, len(values))
+	for i, v := range values {
+		index, score := v.Get("index"), v.Get(scoreField)
+		if !index.Exists() || !score.Exists() {
+			return nil, fmt.Errorf("model output is missing the score of result %d", i)
+		}
+		results[i] = &RerankResult{Index: int(index.Int()), Score: score.Float()}
+	}
+	return results, nil
+}
+
+// encodeRerankRequest uses the Cohere rerank API, which is also followed by Jina AI and by OpenAI-compatible servers
+// such as vLLM.  See https://docs.cohere.com/reference/rerank
+func (defaultProvider) encodeRerankRequest(model *manifest.ModelInfo, req *RerankRequest) (string, error) {
+	input := map[string]any{"query": req.Query, "documents": req.Documents}
+	if model.SourceModel != "" {
+		input["model"] = model.SourceModel
+	}
+	if req.TopN > 0 {
+		input["top_n"] = req.TopN
+	}
+
+	data, err := utils.JsonSerialize(input)
+	return string(data), err
+}
+
+// decodeRerankResponse reads the "results" of the Cohere rerank API, or the "data" of the Voyage AI rerank API.
+func (defaultProvider) decodeRerankResponse(output string) ([]*RerankResult, error) {
+	if results := gjson.Get(output, "results"); results.Exists() {
+		return parseRerankResults(results.Array(), "relevance_score")
+	}
+	return parseRerankResults(gjson.Get(output, "data").Array(), "relevance_score")
+}
+
+func (geminiProvider) encodeRerankRequest(model *manifest.ModelInfo, req *RerankRequest) (string, error) {
+	return "", fmt.Errorf("reranking is not supported for Gemini models")
+}
+
+func (geminiProvider) decodeRerankResponse(output string) ([]*RerankResult, error) {
+	return nil, fmt.Errorf("reranking is not supported for Gemini models")
+}
+
+func (anthropicProvider) encodeRerankRequest(model *manifest.ModelInfo, req *RerankRequest) (string, error) {
+	return "", fmt.Errorf("reranking is not supported for Anthropic models")
+}
+
+func (anthropicProvider) decodeRerankResponse(output string) ([]*RerankResult, error) {
+	return nil, fmt.Errorf("reranking is not supported for Anthropic models")
+}
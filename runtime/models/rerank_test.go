@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeRerankRequest(t *testing.T) {
+	req := &RerankRequest{Query: "capital of France", Documents: []string{"Berlin", "Paris"}, TopN: 1}
+
+	tests := []struct {
+		model    *manifest.ModelInfo
+		expected string
+	}{
+		{&manifest.ModelInfo{Host: "cohere", SourceModel: "rerank-v3.5"},
+			`{"model":"rerank-v3.5","query":"capital of France","documents":["Berlin","Paris"],"top_n":1}`},
+		{&manifest.ModelInfo{Host: "voyage", Provider: "voyage", SourceModel: "rerank-2"},
+			`{"model":"rerank-2","query":"capital of France","documents":["Berlin","Paris"],"top_k":1}`},
+		{&manifest.ModelInfo{Host: "tei", Provider: "hugging-face"},
+			`{"query":"capital of France","texts":["Berlin","Paris"]}`},
+		{&manifest.ModelInfo{Host: bedrockHost, SourceModel: "cohere.rerank-v3-5:0"},
+			`{"query":"capital of France","documents":["Berlin","Paris"],"top_n":1,"api_version":2}`},
+	}
+
+	for _, tt := range tests {
+		input, err := encodeRerankRequest(tt.model, req)
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.expected, input)
+	}
+
+	_, err := encodeRerankRequest(&manifest.ModelInfo{Host: "anthropic", Provider: "anthropic"}, req)
+	assert.Error(t, err)
+}
+
+func TestDecodeRerankResponse(t *testing.T) {
+	req := &RerankRequest{Query: "q", Documents: []string{"a", "b", "c"}, TopN: 2}
+
+	tests := []struct {
+		model  *manifest.ModelInfo
+		output string
+	}{
+		{&manifest.ModelInfo{Host: "cohere"}, `{"results":[{"index":1,"relevance_score":0.9},{"index":2,"relevance_score":0.5},{"index":0,"relevance_score":0.1}]}`},
+		{&manifest.ModelInfo{Host: "voyage", Provider: "voyage"}, `{"data":[{"index":2,"relevance_score":0.5},{"index":1,"relevance_score":0.9}]}`},
+		{&manifest.ModelInfo{Host: "tei", Provider: "hugging-face"}, `[{"index":0,"score":0.1},{"index":2,"score":0.5},{"index":1,"score":0.9}]`},
+	}
+
+	for _, tt := range tests {
+		resp, err := decodeRerankResponse(tt.model, tt.output, req)
+		require.NoError(t, err)
+		assert.Equal(t, []*RerankResult{{Index: 1, Score: 0.9, Document: "b"}, {Index: 2, Score: 0.5, Document: "c"}}, resp.Results)
+	}
+
+	_, err := decodeRerankResponse(&manifest.ModelInfo{Host: "cohere"}, `{"results":[{"index":5,"relevance_score":1}]}`, req)
+	assert.Error(t, err)
+}
+
+func TestRerankRequestValidate(t *testing.T) {
+	assert.Error(t, (&RerankRequest{}).validate())
+	assert.Error(t, (&RerankRequest{Query: "q", TopN: -1}).validate())
+	assert.Error(t, (&RerankRequest{Query: "q", Documents: make([]string, maxRerankDocuments+1)}).validate())
+	assert.NoError(t, (&RerankRequest{Query: "q", Documents: []string{"a"}}).validate())
+}
+
+func TestRerankDocuments(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/rerank", r.URL.Path)
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"model":"rerank-v3.5","query":"q","documents":["a","b"]}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results":[{"index":1,"relevance_score":0.8},{"index":0,"relevance_score":0.2}]}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["cohere"] = manifest.HTTPHostInfo{
+		Name:    "cohere",
+		BaseURL: tsrv.URL + "/",
+	}
+	md.Models["reranker"] = manifest.ModelInfo{
+		Name:        "reranker",
+		SourceModel: "rerank-v3.5",
+		Host:        "cohere",
+		Path:        "v2/rerank",
+	}
+	defer func() {
+		delete(md.Hosts, "cohere")
+		delete(md.Models, "reranker")
+	}()
+
+	output, err := RerankDocuments(context.Background(), "reranker", `{"query":"q","documents":["a","b"]}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"results":[{"index":1,"score":0.8,"document":"b"},{"index":0,"score":0.2,"document":"a"}]}`, output)
+
+	output, err = RerankDocuments(context.Background(), "reranker", `{"query":"q","documents":[]}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"results":[]}`, output)
+}
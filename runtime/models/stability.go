@@ -0,0 +1,106 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+// stabilityProvider sends requests to the Stability AI REST API, which generates images.
+// See https://platform.stability.ai/docs/api-reference
+//
+// The host's base URL is "https://api.stability.ai/", and the model's path is the text-to-image operation
+// of an engine, such as "v1/generation/stable-diffusion-xl-1024-v1-0/text-to-image".
+// The host should provide the API key with an "Authorization" header.
+type stabilityProvider struct {
+	defaultProvider
+}
+
+func (stabilityProvider) prepareRequest(ctx context.Context, model *manifest.ModelInfo, req *http.Request) error {
+	// The API returns raw image bytes unless JSON is requested.
+	req.Header.Set("Accept", "application/json")
+	return nil
+}
+
+func (stabilityProvider) prepareStream(endpoint, input string) (string, string, error) {
+	return "", "", fmt.Errorf("streaming is not supported for Stability AI models")
+}
+
+func (stabilityProvider) encodeChatRequest(model *manifest.ModelInfo, req *ChatRequest) (string, error) {
+	return "", fmt.Errorf("chat is not supported for Stability AI models")
+}
+
+func (stabilityProvider) decodeChatResponse(output string) (*ChatResponse, error) {
+	return nil, fmt.Errorf("chat is not supported for Stability AI models")
+}
+
+func (stabilityProvider) encodeEmbeddingRequest(model *manifest.ModelInfo, texts []string) (string, error) {
+	return "", fmt.Errorf("embeddings are not supported for Stability AI models")
+}
+
+func (stabilityProvider) decodeEmbeddingResponse(output string) ([][]float32, error) {
+	return nil, fmt.Errorf("embeddings are not supported for Stability AI models")
+}
+
+func (stabilityProvider) encodeRerankRequest(model *manifest.ModelInfo, req *RerankRequest) (string, error) {
+	return "", fmt.Errorf("reranking is not supported for Stability AI models")
+}
+
+func (stabilityProvider) decodeRerankResponse(output string) ([]*RerankResult, error) {
+	return nil, fmt.Errorf("reranking is not supported for Stability AI models")
+}
+
+func (stabilityProvider) encodeImageRequest(model *manifest.ModelInfo, req *ImageRequest) (string, error) {
+	data, err := utils.JsonSerialize(encodeStabilityImageRequest(req))
+	return string(data), err
+}
+
+// decodeImageResponse reads the artifacts of the response.  Artifacts that were filtered by the content
+// moderation of the API are omitted.
+func (stabilityProvider) decodeImageResponse(output string) (*ImageResponse, error) {
+	var values []gjson.Result
+	for _, a := range gjson.Get(output, "artifacts").Array() {
+		if a.Get("finishReason").String() != "CONTENT_FILTERED" {
+			values = append(values, a.Get("base64"))
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, errors.New("model output has no images")
+	}
+	return decodeBase64Images(values, "")
+}
+
+// encodeStabilityImageRequest uses the text-to-image request format of Stable Diffusion models,
+// which is the same on Stability AI and on Bedrock.
+func encodeStabilityImageRequest(req *ImageRequest) map[string]any {
+	prompts := []map[string]any{{"text": req.Prompt, "weight": 1}}
+	if req.NegativePrompt != "" {
+		prompts = append(prompts, map[string]any{"text": req.NegativePrompt, "weight": -1})
+	}
+
+	input := map[string]any{
+		"text_prompts": prompts,
+		"samples":      req.count(),
+	}
+	if req.Size != "" {
+		w, h, _ := req.dimensions()
+		input["width"], input["height"] = w, h
+	}
+	return input
+}
@@ -0,0 +1,307 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/hosts"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/rs/xid"
+)
+
+// streamIdleTimeout is how long a stream waits for the function to read the next chunk before it is abandoned.
+const streamIdleTimeout = 30 * time.Second
+
+const maxStreamEventSize = 1024 * 1024
+
+type ModelStreamChunk struct {
+	Data string
+	Done bool
+}
+
+type modelStream struct {
+	executionId string
+	chunks      chan string
+	err         error // only read after chunks is closed
+	cancel      context.CancelFunc
+}
+
+var streams sync.Map
+
+// StartModelStream invokes a model with streaming enabled, and returns the id of the stream.
+// The model's response is expected to be a stream of server-sent events, as used by OpenAI-compatible APIs,
+// or a Bedrock response stream.  Chunks are read from the stream with ReadModelStream.
+func StartModelStream(ctx context.Context, modelName string, input string) (string, error) {
+	model, err := GetModel(modelName)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkModelBudget(); err != nil {
+		return "", err
+	}
+
+	if err := moderateModelInput(ctx, model, input); err != nil {
+		return "", err
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	var read streamReader
+	var release func()
+	var startTime time.Time
+	if model.Host == bedrockHost {
+		input, err = prepareBedrockInput(model, input)
+		if err == nil {
+			release = func() {}
+			startTime = utils.GetTime()
+			read, err = startBedrockStream(streamCtx, model, input)
+		}
+	} else {
+		input, read, release, startTime, err = startHttpStream(streamCtx, model, input)
+	}
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	id := xid.New().String()
+	s := &modelStream{
+		chunks: make(chan string),
+		cancel: cancel,
+	}
+	if executionId, ok := ctx.Value(utils.ExecutionIdContextKey).(string); ok {
+		s.executionId = executionId
+	}
+	streams.Store(id, s)
+
+	go func() {
+		defer release()
+		defer cancel()
+		defer close(s.chunks)
+
+		var output []string
+		s.err = read(func(data string) error {
+			if data == "[DONE]" {
+				return io.EOF
+			}
+			output = append(output, data)
+
+			timer := time.NewTimer(streamIdleTimeout)
+			defer timer.Stop()
+			select {
+			case s.chunks <- data:
+				return nil
+			case <-timer.C:
+				return fmt.Errorf("model stream was not read for %s", streamIdleTimeout)
+			case <-streamCtx.Done():
+				return streamCtx.Err()
+			}
+		})
+
+		db.WriteInferenceHistory(ctx, model, input, output, startTime, utils.GetTime())
+
+		// Remove the stream if the function doesn't read it to the end.
+		time.AfterFunc(streamIdleTimeout, func() {
+			streams.CompareAndDelete(id, s)
+		})
+	}()
+
+	return id, nil
+}
+
+// A streamReader calls the handler with the data of each chunk of a model's response, until the response ends,
+// or the handler returns an error.  It releases any resources used by the response before returning.
+type streamReader func(handler func(data string) error) error
+
+// startHttpStream sends a streaming request to the model's HTTP host.
+// It returns the prepared input, a reader for the response, and a function to release the host's rate limit.
+func startHttpStream(ctx context.Context, model *manifest.ModelInfo, input string) (string, streamReader, func(), time.Time, error) {
+	endpoint, host, err := getModelEndpointAndHost(model)
+	if err != nil {
+		return "", nil, nil, time.Time{}, err
+	}
+
+	provider := getModelProvider(model)
+	input, err = provider.prepareInput(model, input)
+	if err != nil {
+		return "", nil, nil, time.Time{}, err
+	}
+
+	endpoint, payload, err := provider.prepareStream(endpoint, input)
+	if err != nil {
+		return "", nil, nil, time.Time{}, err
+	}
+
+	release, err := hosts.AcquireRateLimit(ctx, host)
+	if err != nil {
+		return "", nil, nil, time.Time{}, err
+	}
+
+	res, startTime, err := sendStreamRequest(ctx, model, host, endpoint, payload)
+	if err != nil {
+		release()
+		return "", nil, nil, time.Time{}, err
+	}
+
+	read := func(handler func(data string) error) error {
+		defer res.Body.Close()
+		return readServerSentEvents(res.Body, handler)
+	}
+
+	return input, read, release, startTime, nil
+}
+
+// ReadModelStream waits for the next chunk of a model stream.
+// When the stream has ended, the chunk is marked as done and has no data.
+// If the client requested a streaming response, the chunk is also sent to the client.
+func ReadModelStream(ctx context.Context, streamId string) (*ModelStreamChunk, error) {
+	s, err := getModelStream(ctx, streamId)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case data, ok := <-s.chunks:
+		if !ok {
+			streams.Delete(streamId)
+			if s.err != nil {
+				return nil, s.err
+			}
+			return &ModelStreamChunk{Done: true}, nil
+		}
+
+		if w, ok := ctx.Value(utils.StreamWriterContextKey).(utils.StreamWriter); ok {
+			fnName, _ := ctx.Value(utils.FunctionNameContextKey).(string)
+			w(fnName, data)
+		}
+
+		return &ModelStreamChunk{Data: data}, nil
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// CloseModelStream stops a model stream before it has been fully read.
+func CloseModelStream(ctx context.Context, streamId string) error {
+	s, err := getModelStream(ctx, streamId)
+	if err != nil {
+		return err
+	}
+
+	s.cancel()
+	streams.Delete(streamId)
+	return nil
+}
+
+func getModelStream(ctx context.Context, streamId string) (*modelStream, error) {
+	v, ok := streams.Load(streamId)
+	if !ok {
+		return nil, fmt.Errorf("model stream %s was not found", streamId)
+	}
+
+	// A stream can only be used by the function execution that started it.
+	s := v.(*modelStream)
+	if executionId, _ := ctx.Value(utils.ExecutionIdContextKey).(string); executionId != s.executionId {
+		return nil, fmt.Errorf("model stream %s was not found", streamId)
+	}
+
+	return s, nil
+}
+
+func sendStreamRequest(ctx context.Context, model *manifest.ModelInfo, host *manifest.HTTPHostInfo, endpoint, payload string) (*http.Response, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(payload))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error creating request: %w", err)
+	}
+
+	if err := prepareModelRequest(ctx, model, host, req); err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	startTime := utils.GetTime()
+	res, err := utils.HttpClient().Do(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		if len(body) == 0 {
+			return nil, time.Time{}, fmt.Errorf("HTTP error: %s", res.Status)
+		}
+		return nil, time.Time{}, fmt.Errorf("HTTP error: %s\n%s", res.Status, body)
+	}
+
+	return res, startTime, nil
+}
+
+// readServerSentEvents calls the handler with the data of each event in the stream.
+// Reading stops without error at the end of the stream, or when the handler returns io.EOF.
+func readServerSentEvents(r io.Reader, handler func(data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamEventSize)
+
+	var data bytes.Buffer
+	dispatch := func() error {
+		if data.Len() == 0 {
+			return nil
+		}
+		s := data.String()
+		data.Reset()
+		return handler(s)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if err := dispatch(); err != nil {
+				return ignoreEOF(err)
+			}
+			continue
+		}
+
+		// Only the data field is used.  Comments and other fields are ignored.
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return ignoreEOF(dispatch())
+}
+
+func ignoreEOF(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
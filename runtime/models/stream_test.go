@@ -0,0 +1,120 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadServerSentEvents(t *testing.T) {
+	stream := ": comment\n" +
+		"data: {\"a\":1}\n\n" +
+		"event: message\n" +
+		"data: line1\n" +
+		"data: line2\n\n" +
+		"data: [DONE]\n\n" +
+		"data: ignored\n\n"
+
+	var events []string
+	err := readServerSentEvents(strings.NewReader(stream), func(data string) error {
+		if data == "[DONE]" {
+			return io.EOF
+		}
+		events = append(events, data)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"a":1}`, "line1\nline2"}, events)
+}
+
+func TestModelStream(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"prompt":"hi","stream":true}`, string(body))
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := range 3 {
+			fmt.Fprintf(w, "data: {\"token\":%d}\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	h := manifestdata.GetManifest().Hosts[testHostName].(manifest.HTTPHostInfo)
+	h.Endpoint = tsrv.URL
+	manifestdata.GetManifest().Hosts[testHostName] = h
+
+	var written []string
+	ctx := context.WithValue(context.Background(), utils.ExecutionIdContextKey, "exec1")
+	ctx = context.WithValue(ctx, utils.FunctionNameContextKey, "chat")
+	ctx = context.WithValue(ctx, utils.StreamWriterContextKey, utils.StreamWriter(func(fnName, data string) {
+		written = append(written, fnName+":"+data)
+	}))
+
+	id, err := StartModelStream(ctx, testModelName, `{"prompt":"hi"}`)
+	require.NoError(t, err)
+
+	// another execution cannot read the stream
+	otherCtx := context.WithValue(context.Background(), utils.ExecutionIdContextKey, "exec2")
+	_, err = ReadModelStream(otherCtx, id)
+	assert.Error(t, err)
+
+	var chunks []string
+	for {
+		chunk, err := ReadModelStream(ctx, id)
+		require.NoError(t, err)
+		if chunk.Done {
+			break
+		}
+		chunks = append(chunks, chunk.Data)
+	}
+
+	expected := []string{`{"token":0}`, `{"token":1}`, `{"token":2}`}
+	assert.Equal(t, expected, chunks)
+	assert.Equal(t, []string{`chat:{"token":0}`, `chat:{"token":1}`, `chat:{"token":2}`}, written)
+
+	// the stream is removed once it has been read to the end
+	_, err = ReadModelStream(ctx, id)
+	assert.Error(t, err)
+}
+
+func TestModelStreamHttpError(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad input", http.StatusBadRequest)
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	h := manifestdata.GetManifest().Hosts[testHostName].(manifest.HTTPHostInfo)
+	h.Endpoint = tsrv.URL
+	manifestdata.GetManifest().Hosts[testHostName] = h
+
+	_, err := StartModelStream(context.Background(), testModelName, `{}`)
+	assert.ErrorContains(t, err, "bad input")
+}
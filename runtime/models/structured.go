@@ -0,0 +1,129 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// maxResponseFormatRetries limits how many times the model is asked to correct a response that doesn't match the schema.
+const maxResponseFormatRetries = 5
+
+// ResponseFormat constrains the model's response to a JSON value that matches the schema.
+// Models whose provider supports structured output are asked for it directly.  Others are instructed to respond
+// with JSON matching the schema.  In both cases, the response is validated against the schema, and the model is
+// asked to correct an invalid response up to MaxRetries times.
+type ResponseFormat struct {
+	Name       string          `json:"name,omitempty"`
+	Schema     json.RawMessage `json:"schema"`
+	MaxRetries int             `json:"maxRetries,omitempty"`
+}
+
+func (f *ResponseFormat) name() string {
+	if f.Name == "" {
+		return "response"
+	}
+	return f.Name
+}
+
+func (f *ResponseFormat) compile() (*jsonschema.Schema, error) {
+	if len(f.Schema) == 0 {
+		return nil, errors.New("a response schema is required")
+	}
+	if f.MaxRetries < 0 || f.MaxRetries > maxResponseFormatRetries {
+		return nil, fmt.Errorf("the maximum number of retries must be between 0 and %d", maxResponseFormatRetries)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(f.name()+".json", bytes.NewReader(f.Schema)); err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+	schema, err := c.Compile(f.name() + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+	return schema, nil
+}
+
+// instructions returns a system prompt that asks for a response matching the schema,
+// for models whose provider doesn't support structured output natively.
+func (f *ResponseFormat) instructions() string {
+	return "Respond only with a JSON value that matches the following JSON schema, without any other text.\n\n" + string(f.Schema)
+}
+
+// validateStructuredOutput checks the model's response against the schema, and returns the JSON value,
+// without any markdown code fence that the model may have put around it.
+func validateStructuredOutput(schema *jsonschema.Schema, content string) (string, error) {
+	content = trimCodeFence(content)
+
+	var v any
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return "", errors.New("the response is not a valid JSON value")
+	}
+
+	if err := schema.Validate(v); err != nil {
+		var ve *jsonschema.ValidationError
+		if errors.As(err, &ve) {
+			return "", errors.New(formatValidationError(ve))
+		}
+		return "", err
+	}
+
+	return content, nil
+}
+
+func formatValidationError(ve *jsonschema.ValidationError) string {
+	causes := ve.BasicOutput().Errors
+	msgs := make([]string, 0, len(causes))
+	for _, c := range causes {
+		if c.Error == "" || strings.HasPrefix(c.Error, "doesn't validate with") {
+			continue
+		}
+		loc := c.InstanceLocation
+		if loc == "" {
+			loc = "/"
+		}
+		msgs = append(msgs, fmt.Sprintf("at %s: %s", loc, c.Error))
+	}
+	if len(msgs) == 0 {
+		return ve.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func trimCodeFence(content string) string {
+	s := strings.TrimSpace(content)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:] // skip the language, such as "json"
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
+
+// correctionMessages returns the messages that ask the model to correct its invalid response.
+func correctionMessages(content string, err error) []*ChatMessage {
+	return []*ChatMessage{
+		{Role: "assistant", Content: content},
+		{Role: "user", Content: fmt.Sprintf("Your response does not match the required JSON schema: %s\n\nRespond again with only the corrected JSON value.", err)},
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+var testResponseFormat = &ResponseFormat{
+	Name:   "city",
+	Schema: json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"},"population":{"type":"integer"}},"required":["name","population"]}`),
+}
+
+func TestValidateStructuredOutput(t *testing.T) {
+	schema, err := testResponseFormat.compile()
+	require.NoError(t, err)
+
+	content, err := validateStructuredOutput(schema, "```json\n{\"name\":\"Paris\",\"population\":2100000}\n```")
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Paris","population":2100000}`, content)
+
+	_, err = validateStructuredOutput(schema, `{"name":"Paris"}`)
+	assert.ErrorContains(t, err, "missing properties: 'population'")
+
+	_, err = validateStructuredOutput(schema, `{"name":"Paris","population":"many"}`)
+	assert.ErrorContains(t, err, "at /population")
+
+	_, err = validateStructuredOutput(schema, `Paris has about 2 million people.`)
+	assert.ErrorContains(t, err, "not a valid JSON value")
+
+	_, err = (&ResponseFormat{Schema: json.RawMessage(`{"type":"nope"}`)}).compile()
+	assert.Error(t, err)
+}
+
+func TestInvokeModelWithResponseFormat(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "city", gjson.GetBytes(body, "response_format.json_schema.name").String())
+
+		content := `{"name":"Paris"}`
+		if calls.Add(1) > 1 {
+			// the correction request includes the invalid response and the validation error
+			messages := gjson.GetBytes(body, "messages").Array()
+			require.Len(t, messages, 3)
+			assert.Equal(t, `{"name":"Paris"}`, messages[1].Get("content").String())
+			assert.Contains(t, messages[2].Get("content").String(), "population")
+			content = `{"name":"Paris","population":2100000}`
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":` + string(mustJson(content)) + `},"finish_reason":"stop"}]}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["structured-host"] = manifest.HTTPHostInfo{Name: "structured-host", Endpoint: tsrv.URL}
+	md.Models["structured-model"] = manifest.ModelInfo{Name: "structured-model", SourceModel: "gpt-4o", Host: "structured-host"}
+	defer func() {
+		delete(md.Hosts, "structured-host")
+		delete(md.Models, "structured-model")
+	}()
+
+	req := ChatRequest{
+		Messages:       []*ChatMessage{{Role: "user", Content: "Describe Paris."}},
+		ResponseFormat: &ResponseFormat{Name: testResponseFormat.Name, Schema: testResponseFormat.Schema},
+	}
+
+	// without retries, the invalid response is an error
+	_, err := InvokeModelWithTools(context.Background(), "structured-model", string(mustJson(req)))
+	assert.ErrorContains(t, err, "does not match the response schema")
+
+	calls.Store(0)
+	req.ResponseFormat.MaxRetries = 1
+	output, err := InvokeModelWithTools(context.Background(), "structured-model", string(mustJson(req)))
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Paris","population":2100000}`, gjson.Get(output, "content").String())
+}
+
+func TestAnthropicEncodeResponseFormat(t *testing.T) {
+	p := getModelProvider(&manifest.ModelInfo{Provider: "anthropic"})
+	input, err := p.encodeChatRequest(&manifest.ModelInfo{}, &ChatRequest{
+		Messages:       []*ChatMessage{{Role: "user", Content: "Describe Paris."}},
+		ResponseFormat: testResponseFormat,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, gjson.Get(input, "system").String(), `"required":["name","population"]`)
+}
+
+func mustJson(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,325 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/tidwall/gjson"
+)
+
+// ChatRequest is a provider-neutral chat request, in which the caller may declare tools that the model can call.
+// The runtime converts it to the API of the model's provider, so that functions can use tools the same way with any model.
+type ChatRequest struct {
+	Messages    []*ChatMessage `json:"messages"`
+	Tools       []*Tool        `json:"tools,omitempty"`
+	ToolChoice  string         `json:"toolChoice,omitempty"`
+	MaxTokens   int            `json:"maxTokens,omitempty"`
+	Temperature *float64       `json:"temperature,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"responseFormat,omitempty"`
+}
+
+// ChatMessage is a message in a chat request.  The role is one of "system", "user", "assistant" or "tool".
+// Assistant messages may include the tool calls that the model made, and tool messages give the result of a tool call.
+type ChatMessage struct {
+	Role       string      `json:"role"`
+	Content    string      `json:"content,omitempty"`
+	ToolCalls  []*ToolCall `json:"toolCalls,omitempty"`
+	ToolCallId string      `json:"toolCallId,omitempty"`
+}
+
+// Tool declares a tool that the model may call.  The parameters are described by a JSON schema.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolCall is a call to a tool made by the model.  The arguments are a JSON object generated by the model.
+type ToolCall struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ChatResponse is the provider-neutral response to a chat request.
+// The finish reason is one of "stop", "length" or "tool_calls", or another reason given by the provider.
+type ChatResponse struct {
+	Content      string      `json:"content"`
+	ToolCalls    []*ToolCall `json:"toolCalls,omitempty"`
+	FinishReason string      `json:"finishReason"`
+	Usage        *ChatUsage  `json:"usage,omitempty"`
+}
+
+type ChatUsage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+// Tool choices that have the same meaning for all providers.  Any other value is the name of a tool that the model must call.
+const (
+	toolChoiceAuto     = "auto"
+	toolChoiceNone     = "none"
+	toolChoiceRequired = "required"
+)
+
+// InvokeModelWithTools sends a provider-neutral chat request to the model, and returns the provider-neutral response,
+// both as JSON.  Tool declarations and tool calls are encoded as the model's provider expects.
+func InvokeModelWithTools(ctx context.Context, modelName string, request string) (string, error) {
+	model, err := GetModel(modelName)
+	if err != nil {
+		return "", err
+	}
+
+	if model.Host == bedrockHost {
+		return "", fmt.Errorf("tool calling is not supported for AWS Bedrock models")
+	}
+
+	var req ChatRequest
+	if err := utils.JsonDeserialize([]byte(request), &req); err != nil {
+		return "", fmt.Errorf("invalid chat request: %w", err)
+	}
+	if err := req.validate(); err != nil {
+		return "", fmt.Errorf("invalid chat request: %w", err)
+	}
+
+	if req.ResponseFormat == nil {
+		resp, err := invokeChat(ctx, model, &req)
+		if err != nil {
+			return "", err
+		}
+		return serializeChatResponse(resp)
+	}
+
+	schema, err := req.ResponseFormat.compile()
+	if err != nil {
+		return "", fmt.Errorf("invalid chat request: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := invokeChat(ctx, model, &req)
+		if err != nil {
+			return "", err
+		}
+
+		// The model may call tools before giving its final response, which is what must match the schema.
+		if len(resp.ToolCalls) > 0 {
+			return serializeChatResponse(resp)
+		}
+
+		content, err := validateStructuredOutput(schema, resp.Content)
+		if err == nil {
+			resp.Content = content
+			return serializeChatResponse(resp)
+		}
+
+		if attempt >= req.ResponseFormat.MaxRetries {
+			return "", fmt.Errorf("model output does not match the response schema: %w", err)
+		}
+		req.Messages = append(req.Messages, correctionMessages(resp.Content, err)...)
+	}
+}
+
+// invokeChat encodes the request for the model's provider, and decodes the response.
+// The request is encoded for each model that is tried, since fallback models may have different providers.
+func invokeChat(ctx context.Context, model *manifest.ModelInfo, req *ChatRequest) (*ChatResponse, error) {
+	var resp *ChatResponse
+	_, err := invokeModelWithFallbacks(ctx, model, func(ctx context.Context, m *manifest.ModelInfo) (string, error) {
+		if m.Host == bedrockHost {
+			return "", fmt.Errorf("tool calling is not supported for AWS Bedrock models")
+		}
+
+		provider := getModelProvider(m)
+		input, err := provider.encodeChatRequest(m, req)
+		if err != nil {
+			return "", err
+		}
+
+		input, err = provider.prepareInput(m, input)
+		if err != nil {
+			return "", err
+		}
+
+		output, err := invokeModelWithModeration(ctx, m, input, func(input string) (string, error) {
+			return invokeModelWithCache(ctx, m, input, func(input string) (string, error) {
+				return PostToModelEndpoint[string](ctx, m, input)
+			})
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if !gjson.Valid(output) {
+			return "", fmt.Errorf("model output is not valid JSON")
+		}
+
+		resp, err = provider.decodeChatResponse(output)
+		return output, err
+	})
+	return resp, err
+}
+
+func serializeChatResponse(resp *ChatResponse) (string, error) {
+	data, err := utils.JsonSerialize(resp)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (r *ChatRequest) validate() error {
+	if len(r.Messages) == 0 {
+		return errors.New("at least one message is required")
+	}
+
+	for _, t := range r.Tools {
+		if t.Name == "" {
+			return errors.New("tools must have a name")
+		}
+	}
+
+	for _, m := range r.Messages {
+		switch m.Role {
+		case "system", "user", "assistant":
+		case "tool":
+			if m.ToolCallId == "" {
+				return errors.New("tool messages must have a tool call id")
+			}
+		default:
+			return fmt.Errorf("unknown message role: %s", m.Role)
+		}
+	}
+
+	return nil
+}
+
+// toolParameters returns the tool's parameter schema, or a schema of an empty object if the tool has no parameters.
+func (t *Tool) toolParameters() json.RawMessage {
+	if len(t.Parameters) == 0 {
+		return json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+	return t.Parameters
+}
+
+// encodeChatRequest converts the request to the OpenAI chat completions API, which most providers follow.
+// See https://platform.openai.com/docs/api-reference/chat/create
+func (defaultProvider) encodeChatRequest(model *manifest.ModelInfo, req *ChatRequest) (string, error) {
+	messages := make([]map[string]any, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		msg := map[string]any{"role": m.Role}
+		switch m.Role {
+		case "assistant":
+			if m.Content != "" || len(m.ToolCalls) == 0 {
+				msg["content"] = m.Content
+			}
+			if len(m.ToolCalls) > 0 {
+				calls := make([]map[string]any, len(m.ToolCalls))
+				for i, tc := range m.ToolCalls {
+					calls[i] = map[string]any{
+						"id":       tc.Id,
+						"type":     "function",
+						"function": map[string]any{"name": tc.Name, "arguments": tc.Arguments},
+					}
+				}
+				msg["tool_calls"] = calls
+			}
+		case "tool":
+			msg["tool_call_id"] = m.ToolCallId
+			msg["content"] = m.Content
+		default:
+			msg["content"] = m.Content
+		}
+		messages = append(messages, msg)
+	}
+
+	input := map[string]any{"messages": messages}
+	if model.SourceModel != "" {
+		input["model"] = model.SourceModel
+	}
+
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]any, len(req.Tools))
+		for i, t := range req.Tools {
+			fn := map[string]any{"name": t.Name, "parameters": t.toolParameters()}
+			if t.Description != "" {
+				fn["description"] = t.Description
+			}
+			tools[i] = map[string]any{"type": "function", "function": fn}
+		}
+		input["tools"] = tools
+
+		switch req.ToolChoice {
+		case "":
+		case toolChoiceAuto, toolChoiceNone, toolChoiceRequired:
+			input["tool_choice"] = req.ToolChoice
+		default:
+			input["tool_choice"] = map[string]any{"type": "function", "function": map[string]any{"name": req.ToolChoice}}
+		}
+	}
+
+	if f := req.ResponseFormat; f != nil {
+		input["response_format"] = map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   f.name(),
+				"schema": f.Schema,
+			},
+		}
+	}
+
+	if req.MaxTokens > 0 {
+		input["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		input["temperature"] = *req.Temperature
+	}
+
+	data, err := utils.JsonSerialize(input)
+	return string(data), err
+}
+
+func (defaultProvider) decodeChatResponse(output string) (*ChatResponse, error) {
+	choice := gjson.Get(output, "choices.0")
+	if !choice.Exists() {
+		return nil, fmt.Errorf("model output has no choices")
+	}
+
+	resp := &ChatResponse{
+		Content:      choice.Get("message.content").String(),
+		FinishReason: choice.Get("finish_reason").String(),
+	}
+
+	for _, tc := range choice.Get("message.tool_calls").Array() {
+		resp.ToolCalls = append(resp.ToolCalls, &ToolCall{
+			Id:        tc.Get("id").String(),
+			Name:      tc.Get("function.name").String(),
+			Arguments: tc.Get("function.arguments").String(),
+		})
+	}
+	if resp.FinishReason == "function_call" {
+		resp.FinishReason = "tool_calls"
+	}
+
+	if usage := gjson.Get(output, "usage"); usage.Exists() {
+		resp.Usage = &ChatUsage{
+			InputTokens:  int(usage.Get("prompt_tokens").Int()),
+			OutputTokens: int(usage.Get("completion_tokens").Int()),
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testChatRequest = &ChatRequest{
+	Messages: []*ChatMessage{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "What's the weather in Paris?"},
+		{Role: "assistant", ToolCalls: []*ToolCall{{Id: "call_1", Name: "getWeather", Arguments: `{"city":"Paris"}`}}},
+		{Role: "tool", ToolCallId: "call_1", Content: "sunny"},
+	},
+	Tools: []*Tool{
+		{Name: "getWeather", Description: "Gets the weather", Parameters: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`)},
+	},
+	ToolChoice: "required",
+}
+
+func TestOpenAIEncodeChatRequest(t *testing.T) {
+	p := getModelProvider(&manifest.ModelInfo{})
+	input, err := p.encodeChatRequest(&manifest.ModelInfo{SourceModel: "gpt-4o"}, testChatRequest)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"model": "gpt-4o",
+		"messages": [
+			{"role": "system", "content": "be brief"},
+			{"role": "user", "content": "What's the weather in Paris?"},
+			{"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "getWeather", "arguments": "{\"city\":\"Paris\"}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "sunny"}
+		],
+		"tools": [{"type": "function", "function": {"name": "getWeather", "description": "Gets the weather", "parameters": {"type":"object","properties":{"city":{"type":"string"}}}}}],
+		"tool_choice": "required"
+	}`, input)
+}
+
+func TestOpenAIDecodeChatResponse(t *testing.T) {
+	p := getModelProvider(&manifest.ModelInfo{})
+	resp, err := p.decodeChatResponse(`{
+		"choices": [{"message": {"role": "assistant", "content": null, "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "getWeather", "arguments": "{\"city\":\"Paris\"}"}}]}, "finish_reason": "tool_calls"}],
+		"usage": {"prompt_tokens": 20, "completion_tokens": 5}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, &ChatResponse{
+		ToolCalls:    []*ToolCall{{Id: "call_1", Name: "getWeather", Arguments: `{"city":"Paris"}`}},
+		FinishReason: "tool_calls",
+		Usage:        &ChatUsage{InputTokens: 20, OutputTokens: 5},
+	}, resp)
+}
+
+func TestAnthropicEncodeChatRequest(t *testing.T) {
+	p := getModelProvider(&manifest.ModelInfo{Provider: "anthropic"})
+	input, err := p.encodeChatRequest(&manifest.ModelInfo{}, testChatRequest)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"system": "be brief",
+		"messages": [
+			{"role": "user", "content": [{"type": "text", "text": "What's the weather in Paris?"}]},
+			{"role": "assistant", "content": [{"type": "tool_use", "id": "call_1", "name": "getWeather", "input": {"city": "Paris"}}]},
+			{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "call_1", "content": "sunny"}]}
+		],
+		"tools": [{"name": "getWeather", "description": "Gets the weather", "input_schema": {"type":"object","properties":{"city":{"type":"string"}}}}],
+		"tool_choice": {"type": "any"}
+	}`, input)
+}
+
+func TestAnthropicDecodeChatResponse(t *testing.T) {
+	p := getModelProvider(&manifest.ModelInfo{Provider: "anthropic"})
+	resp, err := p.decodeChatResponse(`{
+		"content": [{"type": "text", "text": "Let me check."}, {"type": "tool_use", "id": "toolu_1", "name": "getWeather", "input": {"city": "Paris"}}],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 30, "output_tokens": 10}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, &ChatResponse{
+		Content:      "Let me check.",
+		ToolCalls:    []*ToolCall{{Id: "toolu_1", Name: "getWeather", Arguments: `{"city": "Paris"}`}},
+		FinishReason: "tool_calls",
+		Usage:        &ChatUsage{InputTokens: 30, OutputTokens: 10},
+	}, resp)
+}
+
+func TestInvokeModelWithTools(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"model":"claude-3-5-sonnet-20240620","max_tokens":4096,"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}],"tools":[{"name":"ping","input_schema":{"type":"object","properties":{}}}]}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"tool_use","id":"toolu_1","name":"ping","input":{}}],"stop_reason":"tool_use"}`))
+	})
+
+	tsrv := httptest.NewServer(handler)
+	defer tsrv.Close()
+
+	md := manifestdata.GetManifest()
+	md.Hosts["anthropic"] = manifest.HTTPHostInfo{Name: "anthropic", Endpoint: tsrv.URL}
+	md.Models["claude"] = manifest.ModelInfo{
+		Name:        "claude",
+		SourceModel: "claude-3-5-sonnet-20240620",
+		Provider:    "anthropic",
+		Host:        "anthropic",
+	}
+	defer func() {
+		delete(md.Hosts, "anthropic")
+		delete(md.Models, "claude")
+	}()
+
+	output, err := InvokeModelWithTools(context.Background(), "claude", `{"messages":[{"role":"user","content":"hi"}],"tools":[{"name":"ping"}]}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"content":"","toolCalls":[{"id":"toolu_1","name":"ping","arguments":"{}"}],"finishReason":"tool_calls"}`, output)
+
+	_, err = InvokeModelWithTools(context.Background(), "claude", `{"messages":[{"role":"tool","content":"x"}]}`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/metrics"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+const (
+	modelStatusSuccess  = "success"
+	modelStatusError    = "error"
+	modelStatusCached   = "cached"
+	modelStatusReplayed = "replayed"
+)
+
+// recordModelInvocation updates the model metrics, and adds the usage to the function execution in the context,
+// so that it can be returned to the caller.  The output is only inspected for token counts if the invocation succeeded.
+func recordModelInvocation(ctx context.Context, model *manifest.ModelInfo, output any, duration time.Duration, err error) {
+	fnName, _ := ctx.Value(utils.FunctionNameContextKey).(string)
+
+	fallbackFor, _ := ctx.Value(fallbackForContextKey).(string)
+
+	usage := utils.ModelUsage{
+		Model:       model.Name,
+		FallbackFor: fallbackFor,
+		DurationMs:  duration.Milliseconds(),
+		Status:      modelStatusSuccess,
+	}
+
+	if err != nil {
+		usage.Status = modelStatusError
+	} else if s, ok := output.(string); ok {
+		usage.InputTokens, usage.OutputTokens, _ = utils.GetTokenUsage(s)
+	} else if data, e := utils.JsonSerialize(output); e == nil {
+		usage.InputTokens, usage.OutputTokens, _ = utils.GetTokenUsage(string(data))
+	}
+
+	metrics.ModelInvocationsNum.WithLabelValues(model.Name, model.Host, fnName, usage.Status).Inc()
+	metrics.ModelInvocationDurationMilliseconds.WithLabelValues(model.Name, model.Host, fnName).Observe(float64(usage.DurationMs))
+	if usage.InputTokens > 0 {
+		metrics.ModelTokensNum.WithLabelValues(model.Name, model.Host, fnName, "input").Add(float64(usage.InputTokens))
+	}
+	if usage.OutputTokens > 0 {
+		metrics.ModelTokensNum.WithLabelValues(model.Name, model.Host, fnName, "output").Add(float64(usage.OutputTokens))
+	}
+	if usage.Status == modelStatusSuccess {
+		recordModelCost(ctx, model, usage.InputTokens, usage.OutputTokens)
+	}
+
+	utils.AddModelUsage(ctx, usage)
+}
+
+// recordUninvokedModelInvocation counts a model invocation that was answered without invoking the model,
+// such as from the cache or from a recorded fixture.  The status says how it was answered.
+func recordUninvokedModelInvocation(ctx context.Context, model *manifest.ModelInfo, status string) {
+	fnName, _ := ctx.Value(utils.FunctionNameContextKey).(string)
+	metrics.ModelInvocationsNum.WithLabelValues(model.Name, model.Host, fnName, status).Inc()
+	fallbackFor, _ := ctx.Value(fallbackForContextKey).(string)
+	utils.AddModelUsage(ctx, utils.ModelUsage{Model: model.Name, FallbackFor: fallbackFor, Status: status})
+}
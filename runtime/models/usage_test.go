@@ -0,0 +1,44 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/metrics"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordModelInvocation(t *testing.T) {
+	var usage []utils.ModelUsage
+	ctx := context.WithValue(context.Background(), utils.ModelUsageContextKey, &usage)
+	ctx = context.WithValue(ctx, utils.FunctionNameContextKey, "summarize")
+
+	model := &manifest.ModelInfo{Name: "usage-test", Host: "openai"}
+	recordModelInvocation(ctx, model, `{"usage":{"prompt_tokens":20,"completion_tokens":5}}`, 150*time.Millisecond, nil)
+	recordModelInvocation(ctx, model, nil, 20*time.Millisecond, errors.New("boom"))
+
+	assert.Equal(t, []utils.ModelUsage{
+		{Model: "usage-test", InputTokens: 20, OutputTokens: 5, DurationMs: 150, Status: "success"},
+		{Model: "usage-test", DurationMs: 20, Status: "error"},
+	}, usage)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ModelInvocationsNum.WithLabelValues("usage-test", "openai", "summarize", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ModelInvocationsNum.WithLabelValues("usage-test", "openai", "summarize", "error")))
+	assert.Equal(t, 20.0, testutil.ToFloat64(metrics.ModelTokensNum.WithLabelValues("usage-test", "openai", "summarize", "input")))
+	assert.Equal(t, 5.0, testutil.ToFloat64(metrics.ModelTokensNum.WithLabelValues("usage-test", "openai", "summarize", "output")))
+}
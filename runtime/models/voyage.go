@@ -0,0 +1,38 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// voyageProvider sends requests to the Voyage AI API, which provides embedding and reranking models.
+// See https://docs.voyageai.com/reference
+//
+// The host's base URL is "https://api.voyageai.com/", and the model's path is the operation,
+// such as "v1/embeddings" or "v1/rerank".  The host should provide the API key with an "Authorization" header.
+// Embeddings follow the OpenAI conventions, but reranking differs from the Cohere conventions.
+type voyageProvider struct {
+	defaultProvider
+}
+
+func (voyageProvider) encodeRerankRequest(model *manifest.ModelInfo, req *RerankRequest) (string, error) {
+	input := map[string]any{"query": req.Query, "documents": req.Documents}
+	if model.SourceModel != "" {
+		input["model"] = model.SourceModel
+	}
+	if req.TopN > 0 {
+		input["top_k"] = req.TopN
+	}
+
+	data, err := utils.JsonSerialize(input)
+	return string(data), err
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package natsclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+)
+
+func Initialize(ctx context.Context) {
+	manifestdata.RegisterManifestLoadedCallback(func(loadCtx context.Context) error {
+		// Subscriptions are only restarted when hosts changed, and only the connections of hosts that changed
+		// or were removed are closed.
+		diff := manifestdata.GetManifestDiff(loadCtx)
+		if !diff.HostsChanged() {
+			return nil
+		}
+
+		// Subscriptions are started from the original context rather than the one passed to the callback,
+		// because messages can arrive long after the manifest loading has completed.
+		shutdownConns(diff.IsHostStale)
+		nr.startSubscriptions(ctx)
+		return nil
+	})
+}
+
+// Publish publishes a message to the subject.  It returns "success" rather than nothing,
+// so that the guest can tell whether the message was published.
+func Publish(ctx context.Context, hostName, subject, data string) (string, error) {
+	conn, err := nr.getConnection(ctx, hostName)
+	if err != nil {
+		return "", err
+	}
+
+	if err := conn.Publish(subject, []byte(data)); err != nil {
+		return "", err
+	}
+
+	return "success", nil
+}
+
+func Request(ctx context.Context, hostName, subject, data string, timeoutMs int64) (string, error) {
+	conn, err := nr.getConnection(ctx, hostName)
+	if err != nil {
+		return "", err
+	}
+
+	timeout := defaultRequestTimeout
+	if timeoutMs > 0 {
+		timeout = time.Duration(timeoutMs) * time.Millisecond
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	msg, err := conn.RequestWithContext(ctx, subject, []byte(data))
+	if err != nil {
+		return "", err
+	}
+
+	return string(msg.Data), nil
+}
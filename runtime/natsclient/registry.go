@@ -0,0 +1,130 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package natsclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/nats-io/nats.go"
+)
+
+const defaultRequestTimeout = 10 * time.Second
+
+var nr = newNatsRegistry()
+
+type natsRegistry struct {
+	sync.RWMutex
+	connCache     map[string]*nats.Conn
+	subscriptions []*nats.Subscription
+}
+
+func newNatsRegistry() *natsRegistry {
+	return &natsRegistry{
+		connCache: make(map[string]*nats.Conn),
+	}
+}
+
+func ShutdownConns() {
+	shutdownConns(func(string) bool { return true })
+}
+
+// shutdownConns drains all the subscriptions, and closes the connections of the hosts that are stale.
+func shutdownConns(stale func(hostName string) bool) {
+	nr.Lock()
+	defer nr.Unlock()
+	for _, sub := range nr.subscriptions {
+		_ = sub.Drain()
+	}
+	nr.subscriptions = nil
+	for name, conn := range nr.connCache {
+		if stale(name) {
+			conn.Close()
+			delete(nr.connCache, name)
+		}
+	}
+}
+
+func (nr *natsRegistry) getConnection(ctx context.Context, hostName string) (*nats.Conn, error) {
+	nr.RLock()
+	conn, ok := nr.connCache[hostName]
+	nr.RUnlock()
+	if ok {
+		return conn, nil
+	}
+
+	nr.Lock()
+	defer nr.Unlock()
+	return nr.getConnectionLocked(ctx, hostName)
+}
+
+func (nr *natsRegistry) getConnectionLocked(ctx context.Context, hostName string) (*nats.Conn, error) {
+	if conn, ok := nr.connCache[hostName]; ok {
+		return conn, nil
+	}
+
+	info, ok := manifestdata.GetManifest().Hosts[hostName]
+	if !ok {
+		return nil, fmt.Errorf("nats host %s not found", hostName)
+	}
+	if info.HostType() != manifest.HostTypeNats {
+		return nil, fmt.Errorf("host %s is not a nats host", hostName)
+	}
+
+	host := info.(manifest.NatsHostInfo)
+	if host.Url == "" {
+		return nil, fmt.Errorf("nats host %s has empty url", hostName)
+	}
+
+	url, err := secrets.ApplyHostSecretsToString(ctx, info, host.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []nats.Option{
+		nats.Name("modus"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	}
+
+	if host.Token != "" {
+		token, err := secrets.ApplyHostSecretsToString(ctx, info, host.Token)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, nats.Token(token))
+	}
+
+	if host.Username != "" || host.Password != "" {
+		username, err := secrets.ApplyHostSecretsToString(ctx, info, host.Username)
+		if err != nil {
+			return nil, err
+		}
+		password, err := secrets.ApplyHostSecretsToString(ctx, info, host.Password)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, nats.UserInfo(username, password))
+	}
+
+	conn, err := nats.Connect(url, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	nr.connCache[hostName] = conn
+	return conn, nil
+}
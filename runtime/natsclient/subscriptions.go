@@ -0,0 +1,139 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package natsclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	"github.com/nats-io/nats.go"
+)
+
+func (nr *natsRegistry) startSubscriptions(ctx context.Context) {
+	nr.Lock()
+	defer nr.Unlock()
+
+	for name, info := range manifestdata.GetManifest().Hosts {
+		host, ok := info.(manifest.NatsHostInfo)
+		if !ok || len(host.Subscriptions) == 0 {
+			continue
+		}
+
+		conn, err := nr.getConnectionLocked(ctx, name)
+		if err != nil {
+			logger.Err(ctx, err).
+				Str("host", name).
+				Msg("Failed to connect to NATS host.")
+			continue
+		}
+
+		for _, s := range host.Subscriptions {
+			handler := newMessageHandler(ctx, name, s)
+
+			var sub *nats.Subscription
+			if s.QueueGroup != "" {
+				sub, err = conn.QueueSubscribe(s.Subject, s.QueueGroup, handler)
+			} else {
+				sub, err = conn.Subscribe(s.Subject, handler)
+			}
+			if err != nil {
+				logger.Err(ctx, err).
+					Str("host", name).
+					Str("subject", s.Subject).
+					Msg("Failed to subscribe to NATS subject.")
+				continue
+			}
+
+			nr.subscriptions = append(nr.subscriptions, sub)
+
+			logger.Info(ctx).
+				Str("host", name).
+				Str("subject", s.Subject).
+				Str("queue_group", s.QueueGroup).
+				Str("function", s.Function).
+				Msg("Subscribed to NATS subject.")
+		}
+	}
+}
+
+func newMessageHandler(ctx context.Context, hostName string, s manifest.NatsSubscriptionInfo) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		host := wasmhost.GetWasmHost(ctx)
+		info, err := host.GetFunctionInfo(s.Function)
+		if err != nil {
+			logger.Err(ctx, err).
+				Str("host", hostName).
+				Str("subject", msg.Subject).
+				Msg("No function available to handle NATS message.")
+			return
+		}
+
+		params, err := getMessageParameters(len(info.Metadata().Parameters), msg)
+		if err != nil {
+			logger.Err(ctx, err).
+				Str("host", hostName).
+				Str("subject", msg.Subject).
+				Str("function", s.Function).
+				Msg("Function cannot be used to handle NATS messages.")
+			return
+		}
+
+		execInfo, err := host.CallFunctionByName(ctx, s.Function, params...)
+		if err != nil {
+			logger.Err(ctx, err).
+				Str("host", hostName).
+				Str("subject", msg.Subject).
+				Str("function", s.Function).
+				Msg("Error handling NATS message.")
+			return
+		}
+
+		// If the sender expects a reply, respond with the function's result.
+		if msg.Reply != "" {
+			var data []byte
+			switch r := execInfo.Result().(type) {
+			case nil:
+			case string:
+				data = []byte(r)
+			case []byte:
+				data = r
+			default:
+				data = []byte(fmt.Sprint(r))
+			}
+			if err := msg.Respond(data); err != nil {
+				logger.Err(ctx, err).
+					Str("host", hostName).
+					Str("subject", msg.Subject).
+					Msg("Failed to reply to NATS message.")
+			}
+		}
+	}
+}
+
+// getMessageParameters maps a message onto the parameters of the handler function.
+// A function with one parameter receives the message data.
+// A function with two parameters receives the subject and the message data.
+func getMessageParameters(numParams int, msg *nats.Msg) ([]any, error) {
+	switch numParams {
+	case 0:
+		return nil, nil
+	case 1:
+		return []any{string(msg.Data)}, nil
+	case 2:
+		return []any{msg.Subject, string(msg.Data)}, nil
+	default:
+		return nil, fmt.Errorf("expected at most 2 parameters, but the function has %d", numParams)
+	}
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package natsclient
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetMessageParameters(t *testing.T) {
+	msg := &nats.Msg{Subject: "orders.created", Data: []byte(`{"id":1}`)}
+
+	params, err := getMessageParameters(0, msg)
+	require.Nil(t, err)
+	assert.Empty(t, params)
+
+	params, err = getMessageParameters(1, msg)
+	require.Nil(t, err)
+	assert.Equal(t, []any{`{"id":1}`}, params)
+
+	params, err = getMessageParameters(2, msg)
+	require.Nil(t, err)
+	assert.Equal(t, []any{"orders.created", `{"id":1}`}, params)
+
+	_, err = getMessageParameters(3, msg)
+	assert.NotNil(t, err)
+}
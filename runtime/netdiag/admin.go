@@ -0,0 +1,51 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package netdiag
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// ProbeHandler probes the host named by the "host" query parameter, or all hosts in the manifest if none is given.
+// With a "lookup" query parameter instead, it resolves the given DNS name.
+func ProbeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	if name := query.Get("lookup"); name != "" {
+		result, err := Lookup(ctx, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.WriteJsonResponse(w, result)
+		return
+	}
+
+	if name := query.Get("host"); name != "" {
+		result, err := Probe(ctx, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		utils.WriteJsonResponse(w, result)
+		return
+	}
+
+	results := ProbeAll(ctx)
+	slices.SortFunc(results, func(a, b *ProbeResult) int {
+		return strings.Compare(a.HostName, b.HostName)
+	})
+	utils.WriteJsonResponse(w, results)
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package netdiag
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+)
+
+const probeTimeout = 5 * time.Second
+
+type LookupResult struct {
+	Host       string
+	Addresses  []string
+	CanonName  string
+	DurationMs int64
+	Error      string
+}
+
+type ProbeResult struct {
+	HostName   string
+	HostType   string
+	Address    string
+	Reachable  bool
+	ResolvedIp string
+	DnsMs      int64
+	ConnectMs  int64
+	TlsMs      int64
+	Error      string
+}
+
+// Lookup resolves a host name using the DNS resolver of the runtime's environment.
+// Resolution failures are reported in the result rather than as an error, since they are the thing being diagnosed.
+func Lookup(ctx context.Context, host string) (*LookupResult, error) {
+	if host == "" {
+		return nil, fmt.Errorf("a host name is required")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	result := &LookupResult{Host: host}
+	start := time.Now()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Addresses = addrs
+
+	if cname, err := net.DefaultResolver.LookupCNAME(ctx, host); err == nil {
+		result.CanonName = strings.TrimSuffix(cname, ".")
+	}
+
+	return result, nil
+}
+
+// Probe checks whether the runtime can reach a host declared in the manifest,
+// by resolving its address and opening a connection to it (with a TLS handshake, if the host uses TLS).
+// No request is sent to the host.
+func Probe(ctx context.Context, hostName string) (*ProbeResult, error) {
+	info, ok := manifestdata.GetManifest().Hosts[hostName]
+	if !ok {
+		return nil, fmt.Errorf("host %s not found", hostName)
+	}
+
+	result := &ProbeResult{
+		HostName: hostName,
+		HostType: info.HostType(),
+	}
+
+	target, err := getProbeTarget(ctx, info)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Address = net.JoinHostPort(target.host, target.port)
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	ips, err := net.DefaultResolver.LookupHost(ctx, target.host)
+	result.DnsMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = fmt.Sprintf("dns lookup failed: %v", err)
+		return result, nil
+	}
+	result.ResolvedIp = ips[0]
+
+	start = time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(ips[0], target.port))
+	result.ConnectMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = fmt.Sprintf("connection failed: %v", err)
+		return result, nil
+	}
+	defer conn.Close()
+
+	if target.tls {
+		start = time.Now()
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: target.host})
+		err := tlsConn.HandshakeContext(ctx)
+		result.TlsMs = time.Since(start).Milliseconds()
+		if err != nil {
+			result.Error = fmt.Sprintf("tls handshake failed: %v", err)
+			return result, nil
+		}
+	}
+
+	result.Reachable = true
+	return result, nil
+}
+
+// ProbeAll probes every host declared in the manifest.
+func ProbeAll(ctx context.Context) []*ProbeResult {
+	hosts := manifestdata.GetManifest().Hosts
+	results := make([]*ProbeResult, 0, len(hosts))
+	for name := range hosts {
+		if r, err := Probe(ctx, name); err == nil {
+			results = append(results, r)
+		}
+	}
+	return results
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package netdiag
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetProbeTarget(t *testing.T) {
+	ctx := context.Background()
+	secrets.Initialize(ctx)
+
+	plaintext := false
+	tests := []struct {
+		info     manifest.HostInfo
+		expected probeTarget
+	}{
+		{manifest.HTTPHostInfo{Endpoint: "https://api.example.com/v1"}, probeTarget{"api.example.com", "443", true}},
+		{manifest.HTTPHostInfo{BaseURL: "http://localhost:8080/"}, probeTarget{"localhost", "8080", false}},
+		{manifest.PostgresqlHostInfo{ConnStr: "postgresql://user@db.example.com:5433/data"}, probeTarget{"db.example.com", "5433", false}},
+		{manifest.DgraphHostInfo{GrpcTarget: "localhost:9080"}, probeTarget{"localhost", "9080", false}},
+		{manifest.DgraphHostInfo{GrpcTarget: "127.0.0.1:9080"}, probeTarget{"127.0.0.1", "9080", false}},
+		{manifest.DgraphHostInfo{GrpcTarget: "dgraph:9080", TLS: &plaintext}, probeTarget{"dgraph", "9080", false}},
+		{manifest.DgraphHostInfo{GrpcTarget: "dgraph.example.com:443"}, probeTarget{"dgraph.example.com", "443", true}},
+		{manifest.NatsHostInfo{Url: "nats://nats-1:4222, nats://nats-2:4222"}, probeTarget{"nats-1", "4222", false}},
+		{manifest.NatsHostInfo{Url: "wss://nats.example.com"}, probeTarget{"nats.example.com", "443", true}},
+	}
+
+	for _, tt := range tests {
+		target, err := getProbeTarget(ctx, tt.info)
+		require.Nil(t, err)
+		assert.Equal(t, tt.expected, *target)
+	}
+
+	_, err := getProbeTarget(ctx, manifest.HTTPHostInfo{Endpoint: "ftp://example.com"})
+	assert.NotNil(t, err)
+}
+
+func Test_Lookup(t *testing.T) {
+	result, err := Lookup(context.Background(), "localhost")
+	require.Nil(t, err)
+	assert.Empty(t, result.Error)
+	assert.NotEmpty(t, result.Addresses)
+
+	_, err = Lookup(context.Background(), "")
+	assert.NotNil(t, err)
+}
+
+func Test_Probe(t *testing.T) {
+	ctx := context.Background()
+	secrets.Initialize(ctx)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// reserve a port, then release it so nothing is listening there
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	closedUrl := "http://" + l.Addr().String()
+	l.Close()
+
+	manifestdata.SetManifest(&manifest.Manifest{
+		Hosts: map[string]manifest.HostInfo{
+			"up":   manifest.HTTPHostInfo{Name: "up", Endpoint: server.URL},
+			"down": manifest.HTTPHostInfo{Name: "down", Endpoint: closedUrl},
+		},
+	})
+	defer manifestdata.SetManifest(&manifest.Manifest{})
+
+	result, err := Probe(ctx, "up")
+	require.Nil(t, err)
+	assert.True(t, result.Reachable, result.Error)
+	assert.Equal(t, "127.0.0.1", result.ResolvedIp)
+
+	result, err = Probe(ctx, "down")
+	require.Nil(t, err)
+	assert.False(t, result.Reachable)
+	assert.Contains(t, result.Error, "connection failed")
+
+	_, err = Probe(ctx, "missing")
+	assert.NotNil(t, err)
+
+	assert.Len(t, ProbeAll(ctx), 2)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package netdiag
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/secrets"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type probeTarget struct {
+	host string
+	port string
+	tls  bool
+}
+
+func getProbeTarget(ctx context.Context, info manifest.HostInfo) (*probeTarget, error) {
+	switch h := info.(type) {
+	case manifest.HTTPHostInfo:
+		u := h.Endpoint
+		if u == "" {
+			u = h.BaseURL
+		}
+		if u == "" {
+			return nil, fmt.Errorf("host has no endpoint or base url")
+		}
+		return getUrlTarget(u, map[string]string{"http": "80", "https": "443"})
+
+	case manifest.PostgresqlHostInfo:
+		// The connection string may contain secrets, such as the user name and password.
+		connStr, err := secrets.ApplyHostSecretsToString(ctx, info, h.ConnStr)
+		if err != nil {
+			return nil, err
+		}
+		config, err := pgconn.ParseConfig(connStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connection string: %w", err)
+		}
+		return &probeTarget{
+			host: config.Host,
+			port: strconv.Itoa(int(config.Port)),
+			tls:  false, // postgres negotiates TLS within its own protocol
+		}, nil
+
+	case manifest.DgraphHostInfo:
+		host, port, err := net.SplitHostPort(h.GrpcTarget)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grpc target: %w", err)
+		}
+		return &probeTarget{host: host, port: port, tls: h.UseTLS()}, nil
+
+	case manifest.NatsHostInfo:
+		// Only the first of multiple servers is probed.
+		u, _, _ := strings.Cut(h.Url, ",")
+		return getUrlTarget(strings.TrimSpace(u), map[string]string{"nats": "4222", "tls": "4222", "ws": "80", "wss": "443"})
+
+	default:
+		return nil, fmt.Errorf("hosts of type %s cannot be probed", info.HostType())
+	}
+}
+
+func getUrlTarget(s string, defaultPorts map[string]string) (*probeTarget, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultPorts[u.Scheme]
+	}
+	if port == "" {
+		return nil, fmt.Errorf("unsupported url scheme: %s", u.Scheme)
+	}
+
+	return &probeTarget{
+		host: u.Hostname(),
+		port: port,
+		tls:  u.Scheme == "https" || u.Scheme == "wss" || u.Scheme == "tls",
+	}, nil
+}
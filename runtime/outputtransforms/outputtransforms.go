@@ -0,0 +1,165 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package outputtransforms applies the transforms declared in the manifest, which reshape the output of functions
+// with jq queries.  They apply to the JSON that the REST and event stream endpoints return, which isn't shaped
+// by the function's GraphQL type, so a transform can rename fields or change the structure of the output.
+package outputtransforms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/itchyny/gojq"
+)
+
+type outputTransform struct {
+	info manifest.TransformInfo
+	code *gojq.Code
+	err  error
+}
+
+var transformsByFunction map[string][]*outputTransform
+var transformsMutex sync.RWMutex
+
+func Initialize() {
+	manifestdata.RegisterManifestLoadedCallback(func(ctx context.Context) error {
+		loadTransforms(ctx, manifestdata.GetManifest().Transforms)
+		return nil
+	})
+}
+
+func loadTransforms(ctx context.Context, infos map[string]manifest.TransformInfo) {
+	// Apply transforms in a consistent order.
+	names := make([]string, 0, len(infos))
+	for name := range infos {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	byFunction := make(map[string][]*outputTransform)
+	for _, name := range names {
+		t := &outputTransform{info: infos[name]}
+		t.code, t.err = compile(t.info.Query)
+		if t.err != nil {
+			// An invalid transform fails the invocation, rather than returning output the operator meant to reshape.
+			logger.Error(ctx).Err(t.err).
+				Str("transform", name).
+				Bool("user_visible", true).
+				Msg("Invalid transform query. Invocations of the transformed functions will fail.")
+		}
+
+		for _, fn := range t.info.Functions {
+			byFunction[fn] = append(byFunction[fn], t)
+		}
+	}
+
+	transformsMutex.Lock()
+	defer transformsMutex.Unlock()
+	transformsByFunction = byFunction
+}
+
+func compile(query string) (*gojq.Code, error) {
+	q, err := gojq.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	return gojq.Compile(q)
+}
+
+// Apply applies the transforms declared for the function to its JSON output, in name order.
+// Transforms that are limited to certain clients only apply when the request is from one of those clients.
+func Apply(ctx context.Context, fnName string, output []byte) ([]byte, error) {
+	transformsMutex.RLock()
+	transforms := transformsByFunction[fnName]
+	transformsMutex.RUnlock()
+
+	if len(transforms) == 0 {
+		return output, nil
+	}
+
+	client, _ := ctx.Value(utils.ClientNameContextKey).(string)
+
+	var v any
+	parsed := false
+	for _, t := range transforms {
+		if len(t.info.Clients) > 0 && !slices.Contains(t.info.Clients, client) {
+			continue
+		}
+
+		if t.err != nil {
+			return nil, fmt.Errorf("transform %s is invalid", t.info.Name)
+		}
+
+		if !parsed {
+			if err := utils.JsonDeserialize(output, &v); err != nil {
+				return nil, err
+			}
+			v = convertNumbers(v)
+			parsed = true
+		}
+
+		var err error
+		if v, err = t.run(ctx, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if !parsed {
+		return output, nil
+	}
+	return utils.JsonSerialize(v)
+}
+
+func (t *outputTransform) run(ctx context.Context, v any) (any, error) {
+	iter := t.code.RunWithContext(ctx, v)
+
+	result, ok := iter.Next()
+	if !ok {
+		return nil, nil
+	}
+	if err, ok := result.(error); ok {
+		return nil, fmt.Errorf("error applying transform %s: %w", t.info.Name, err)
+	}
+
+	if _, ok := iter.Next(); ok {
+		return nil, fmt.Errorf("transform %s produced more than one result", t.info.Name)
+	}
+
+	return result, nil
+}
+
+// convertNumbers converts the numbers of parsed JSON to the types that jq queries operate on.
+func convertNumbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		for k, val := range t {
+			t[k] = convertNumbers(val)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = convertNumbers(val)
+		}
+	}
+	return v
+}
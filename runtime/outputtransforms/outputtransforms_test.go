@@ -0,0 +1,81 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package outputtransforms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Apply(t *testing.T) {
+	ctx := context.Background()
+	loadTransforms(ctx, map[string]manifest.TransformInfo{
+		"a_active": {Name: "a_active", Functions: []string{"getUsers"}, Query: "map(select(.active))"},
+		"b_sorted": {Name: "b_sorted", Functions: []string{"getUsers"}, Query: "sort_by(.name)"},
+		"mobile":   {Name: "mobile", Functions: []string{"getUser"}, Query: "{id, name}", Clients: []string{"mobile-app"}},
+		"invalid":  {Name: "invalid", Functions: []string{"broken"}, Query: "map("},
+		"multiple": {Name: "multiple", Functions: []string{"many"}, Query: ".[]"},
+	})
+	defer loadTransforms(ctx, nil)
+
+	users := []byte(`[{"id":9007199254740993,"name":"zed","active":true},{"id":2,"name":"amy","active":true},{"id":3,"name":"bob","active":false}]`)
+
+	// transforms are applied in name order, and large integers are preserved
+	result, err := Apply(ctx, "getUsers", users)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"id":2,"name":"amy","active":true},{"id":9007199254740993,"name":"zed","active":true}]`, string(result))
+	assert.Contains(t, string(result), "9007199254740993")
+
+	// functions without transforms are unaffected
+	result, err = Apply(ctx, "other", users)
+	require.NoError(t, err)
+	assert.Equal(t, users, result)
+
+	// client-specific transforms only apply to that client
+	user := []byte(`{"id":1,"name":"amy","active":true}`)
+	result, err = Apply(ctx, "getUser", user)
+	require.NoError(t, err)
+	assert.Equal(t, user, result)
+
+	mobileCtx := context.WithValue(ctx, utils.ClientNameContextKey, "mobile-app")
+	result, err = Apply(mobileCtx, "getUser", user)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"name":"amy"}`, string(result))
+
+	_, err = Apply(ctx, "broken", users)
+	assert.Error(t, err)
+
+	_, err = Apply(ctx, "many", users)
+	assert.Error(t, err)
+}
+
+func Test_Apply_ChangesShape(t *testing.T) {
+	ctx := context.Background()
+	loadTransforms(ctx, map[string]manifest.TransformInfo{
+		"summary": {
+			Name:      "summary",
+			Functions: []string{"getOrder"},
+			Query:     `{orderId: .id, customer: {name: .customerName}, total: (.items | map(.price) | add)}`,
+		},
+	})
+	defer loadTransforms(ctx, nil)
+
+	// fields can be renamed and restructured, since the output isn't shaped by the function's GraphQL type
+	order := []byte(`{"id":"o-1","customerName":"amy","items":[{"price":2},{"price":3}]}`)
+	result, err := Apply(ctx, "getOrder", order)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"orderId":"o-1","customer":{"name":"amy"},"total":5}`, string(result))
+}
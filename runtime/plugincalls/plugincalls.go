@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package plugincalls lets a function of one plugin call a function exported by another plugin hosted by the
+// same runtime, as the manifest allows, without the round trip of an HTTP request.
+package plugincalls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/hypermodeinc/modus/runtime/functions"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/pluginmanager"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+)
+
+// maxCallDepth limits how deeply plugin calls can be nested, so that plugins that call each other can't recurse forever.
+const maxCallDepth = 8
+
+type callDepthContextKey struct{}
+
+// CallFunction calls a function exported by another plugin, with its arguments given as a JSON object of named
+// values, and returns the function's result as JSON.  The call is served as any other call of the function,
+// in a module instance of its own.
+func CallFunction(ctx context.Context, pluginName, fnName, args string) (*string, error) {
+	caller, ok := plugins.GetPluginFromContext(ctx)
+	if !ok {
+		return nil, errors.New("the calling plugin was not found in the context")
+	}
+
+	if !isCallAllowed(caller.Name(), pluginName, fnName) {
+		return nil, fmt.Errorf("the manifest does not allow plugin %s to call %s.%s", caller.Name(), pluginName, fnName)
+	}
+
+	depth, _ := ctx.Value(callDepthContextKey{}).(int)
+	if depth >= maxCallDepth {
+		return nil, fmt.Errorf("plugin calls cannot be nested more than %d deep", maxCallDepth)
+	}
+
+	target := pluginmanager.GetRegisteredPlugin(pluginName)
+	if target == nil {
+		return nil, fmt.Errorf("plugin %s is not loaded", pluginName)
+	}
+
+	info, ok := functions.NewFunctionInfo(fnName, target, false)
+	if !ok || plugins.IsHook(fnName) {
+		return nil, fmt.Errorf("plugin %s has no function named %s", pluginName, fnName)
+	}
+
+	params := make(map[string]any)
+	if args != "" {
+		if err := utils.JsonDeserialize([]byte(args), &params); err != nil {
+			return nil, fmt.Errorf("the arguments of %s.%s must be a JSON object: %w", pluginName, fnName, err)
+		}
+	}
+
+	ctx = context.WithValue(ctx, callDepthContextKey{}, depth+1)
+	execInfo, err := wasmhost.GetWasmHost(ctx).CallFunction(ctx, info, params)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := utils.JsonSerialize(execInfo.Result())
+	if err != nil {
+		return nil, err
+	}
+
+	result := string(bytes)
+	return &result, nil
+}
+
+// isCallAllowed reports whether the manifest allows a plugin to call a function of another plugin.
+// Unlike host functions, a plugin may not call other plugins unless they are listed.
+func isCallAllowed(caller, pluginName, fnName string) bool {
+	name := pluginName + "." + fnName
+	for _, pattern := range manifestdata.GetManifest().Plugins[caller].Calls {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
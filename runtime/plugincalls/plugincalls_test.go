@@ -0,0 +1,64 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package plugincalls
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setPlugins(t *testing.T, infos map[string]manifest.PluginInfo) {
+	md := manifestdata.GetManifest()
+	prev := md.Plugins
+	md.Plugins = infos
+	t.Cleanup(func() { md.Plugins = prev })
+}
+
+func TestIsCallAllowed(t *testing.T) {
+	setPlugins(t, map[string]manifest.PluginInfo{
+		"orders": {Name: "orders", Calls: []string{"users.getUser", "billing.*"}},
+		"users":  {Name: "users"},
+	})
+
+	assert.True(t, isCallAllowed("orders", "users", "getUser"))
+	assert.False(t, isCallAllowed("orders", "users", "deleteUser"))
+	assert.True(t, isCallAllowed("orders", "billing", "getInvoice"))
+	assert.False(t, isCallAllowed("users", "orders", "getOrder"))
+	assert.False(t, isCallAllowed("other", "users", "getUser"))
+}
+
+func TestCallFunctionRejections(t *testing.T) {
+	setPlugins(t, map[string]manifest.PluginInfo{
+		"orders": {Name: "orders", Calls: []string{"users.*"}},
+	})
+
+	_, err := CallFunction(context.Background(), "users", "getUser", "{}")
+	assert.ErrorContains(t, err, "calling plugin was not found")
+
+	caller := &plugins.Plugin{Metadata: &metadata.Metadata{Plugin: "orders@1.0.0"}}
+	ctx := context.WithValue(context.Background(), utils.PluginContextKey, caller)
+
+	_, err = CallFunction(ctx, "billing", "getInvoice", "{}")
+	assert.EqualError(t, err, "the manifest does not allow plugin orders to call billing.getInvoice")
+
+	_, err = CallFunction(context.WithValue(ctx, callDepthContextKey{}, maxCallDepth), "users", "getUser", "{}")
+	assert.EqualError(t, err, "plugin calls cannot be nested more than 8 deep")
+
+	_, err = CallFunction(ctx, "users", "getUser", "{}")
+	assert.EqualError(t, err, "plugin users is not loaded")
+}
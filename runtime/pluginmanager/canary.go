@@ -0,0 +1,201 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+)
+
+var errInvalidCanary = errors.New("invalid canary")
+
+// canary sends a share of the calls to the functions of a plugin to a version other than the active one.
+// Calls can also be routed explicitly with the X-Modus-Canary request header.
+type canary struct {
+	version   *pluginVersion
+	percent   float64
+	startedAt time.Time
+}
+
+// canaries are read on every function call, so they have their own lock, rather than the activation mutex.
+// They are only changed while the activation mutex is also held.
+var canaries = make(map[string]*canary)
+var canariesMutex sync.RWMutex
+
+func init() {
+	wasmhost.RegisterPluginSelector(selectPluginVersion)
+}
+
+// selectPluginVersion chooses the version of a plugin that serves a function call, and reports whether the plugin
+// has a canary, so that the calls of each version are measured separately.
+func selectPluginVersion(ctx context.Context, plugin *plugins.Plugin) (*plugins.Plugin, bool) {
+	canariesMutex.RLock()
+	c, ok := canaries[plugin.Name()]
+	canariesMutex.RUnlock()
+	if !ok {
+		return plugin, false
+	}
+
+	switch ctx.Value(utils.CanaryContextKey) {
+	case "true":
+		return c.version.plugin, true
+	case "false":
+		return plugin, true
+	}
+
+	if rand.Float64()*100 < c.percent {
+		return c.version.plugin, true
+	}
+	return plugin, true
+}
+
+func isCanary(v *pluginVersion) bool {
+	canariesMutex.RLock()
+	defer canariesMutex.RUnlock()
+	c, ok := canaries[v.plugin.Name()]
+	return ok && c.version == v
+}
+
+// replaceCanary moves a canary from a version to a reload of the same content.  The caller must hold the activation mutex.
+func replaceCanary(old, v *pluginVersion) {
+	canariesMutex.Lock()
+	defer canariesMutex.Unlock()
+	if c, ok := canaries[old.plugin.Name()]; ok && c.version == old {
+		c.version = v
+	}
+}
+
+// removeCanary ends the canary of a plugin, if it has one.  The caller must hold the activation mutex.
+func removeCanary(name string) *canary {
+	canariesMutex.Lock()
+	defer canariesMutex.Unlock()
+	c := canaries[name]
+	delete(canaries, name)
+	return c
+}
+
+// endPromotedCanary ends the canary of a plugin once its version has become the active one.
+// The caller must hold the activation mutex.
+func endPromotedCanary(ctx context.Context, name string) {
+	canariesMutex.RLock()
+	c, ok := canaries[name]
+	canariesMutex.RUnlock()
+	if !ok || !isActive(c.version) {
+		return
+	}
+
+	removeCanary(name)
+	logger.Info(ctx).
+		Str("plugin", name).
+		Str("build_id", c.version.plugin.BuildId()).
+		Bool("user_visible", true).
+		Msg("Canary version of plugin was promoted.")
+}
+
+// startCanary sends the given percentage of the calls to the functions of a plugin to one of its kept versions,
+// which is named as it is for a rollback.  Without a version reference, the newest version other than the active
+// one is used, which is the version that was loaded while the plugin was pinned.  Starting the canary of a plugin
+// that already has one changes its version and percentage.
+func startCanary(ctx context.Context, name, ref string, percent float64) (CanaryInfo, error) {
+	if percent < 0 || percent > 100 {
+		return CanaryInfo{}, fmt.Errorf("%w: the percentage must be between 0 and 100", errInvalidCanary)
+	}
+
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+
+	h, ok := histories[name]
+	if !ok {
+		return CanaryInfo{}, fmt.Errorf("%w: %s", errPluginNotFound, name)
+	}
+
+	v, err := findVersion(h, ref)
+	if err != nil {
+		return CanaryInfo{}, err
+	}
+	if isActive(v) {
+		return CanaryInfo{}, fmt.Errorf("%w: the version is already active", errInvalidCanary)
+	}
+
+	c := &canary{version: v, percent: percent, startedAt: time.Now()}
+	canariesMutex.Lock()
+	if existing, ok := canaries[name]; ok && existing.version == v {
+		c.startedAt = existing.startedAt
+	}
+	canaries[name] = c
+	canariesMutex.Unlock()
+
+	logger.Warn(ctx).
+		Str("plugin", name).
+		Str("build_id", v.plugin.BuildId()).
+		Float64("percent", percent).
+		Bool("user_visible", true).
+		Msg("Started canary of plugin version.")
+	return getCanaryInfo(name, c), nil
+}
+
+// stopCanary ends the canary of a plugin, so that all calls go to the active version again.
+// To promote the canary instead, activate its version with a rollback, or by unpinning the plugin.
+func stopCanary(ctx context.Context, name string) (CanaryInfo, error) {
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+
+	c := removeCanary(name)
+	if c == nil {
+		return CanaryInfo{}, fmt.Errorf("%w: %s has no canary", errPluginNotFound, name)
+	}
+
+	logger.Info(ctx).
+		Str("plugin", name).
+		Str("build_id", c.version.plugin.BuildId()).
+		Bool("user_visible", true).
+		Msg("Stopped canary of plugin version.")
+	return getCanaryInfo(name, c), nil
+}
+
+// CanaryInfo describes the canary of a plugin, for the admin API.
+type CanaryInfo struct {
+	Plugin    string            `json:"plugin"`
+	Version   PluginVersionInfo `json:"version"`
+	Percent   float64           `json:"percent"`
+	StartedAt time.Time         `json:"startedAt"`
+}
+
+func getCanaryInfo(name string, c *canary) CanaryInfo {
+	return CanaryInfo{
+		Plugin:    name,
+		Version:   getVersionInfo(histories[name], c.version),
+		Percent:   c.percent,
+		StartedAt: c.startedAt,
+	}
+}
+
+// getCanaries returns the canary of each plugin that has one.
+func getCanaries() []CanaryInfo {
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+	canariesMutex.RLock()
+	defer canariesMutex.RUnlock()
+
+	result := make([]CanaryInfo, 0, len(canaries))
+	for name, c := range canaries {
+		result = append(result, getCanaryInfo(name, c))
+	}
+	return result
+}
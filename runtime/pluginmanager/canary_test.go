@@ -0,0 +1,169 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SelectPluginVersion_Split(t *testing.T) {
+	ctx := newTestContext(t)
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	loadTestVersion(ctx, v1)
+	loadTestVersion(ctx, v2)
+
+	// without a canary, all calls go to the active version, and aren't measured separately
+	p, split := selectPluginVersion(ctx, v2.plugin)
+	assert.Same(t, v2.plugin, p)
+	assert.False(t, split)
+
+	countCanaryCalls := func() int {
+		n := 0
+		for range 1000 {
+			p, split := selectPluginVersion(ctx, v2.plugin)
+			assert.True(t, split)
+			if p == v1.plugin {
+				n++
+			}
+		}
+		return n
+	}
+
+	_, err := startCanary(ctx, "app", "build-1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, countCanaryCalls())
+
+	_, err = startCanary(ctx, "app", "build-1", 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, countCanaryCalls())
+
+	_, err = startCanary(ctx, "app", "build-1", 25)
+	require.NoError(t, err)
+	assert.InDelta(t, 250, countCanaryCalls(), 100)
+}
+
+func Test_SelectPluginVersion_Header(t *testing.T) {
+	ctx := newTestContext(t)
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	loadTestVersion(ctx, v1)
+	loadTestVersion(ctx, v2)
+
+	_, err := startCanary(ctx, "app", "", 50)
+	require.NoError(t, err)
+
+	for range 100 {
+		p, split := selectPluginVersion(context.WithValue(ctx, utils.CanaryContextKey, "true"), v2.plugin)
+		assert.Same(t, v1.plugin, p)
+		assert.True(t, split)
+
+		p, split = selectPluginVersion(context.WithValue(ctx, utils.CanaryContextKey, "false"), v2.plugin)
+		assert.Same(t, v2.plugin, p)
+		assert.True(t, split)
+	}
+}
+
+func Test_StartCanary_Errors(t *testing.T) {
+	ctx := newTestContext(t)
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	loadTestVersion(ctx, v1)
+
+	_, err := startCanary(ctx, "app", "", 101)
+	assert.ErrorIs(t, err, errInvalidCanary)
+
+	_, err = startCanary(ctx, "other", "", 10)
+	assert.ErrorIs(t, err, errPluginNotFound)
+
+	_, err = startCanary(ctx, "app", "build-1", 10)
+	assert.ErrorIs(t, err, errInvalidCanary, "the active version can't be a canary")
+
+	_, err = startCanary(ctx, "app", "", 10)
+	assert.ErrorIs(t, err, errVersionNotFound)
+
+	_, err = stopCanary(ctx, "app")
+	assert.ErrorIs(t, err, errPluginNotFound)
+}
+
+func Test_StopCanary(t *testing.T) {
+	ctx := newTestContext(t)
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	loadTestVersion(ctx, v1)
+	loadTestVersion(ctx, v2)
+
+	started, err := startCanary(ctx, "app", "build-1", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "build-1", started.Version.BuildId)
+	assert.Equal(t, 100.0, started.Percent)
+	require.Len(t, getCanaries(), 1)
+
+	// changing the percentage keeps the start time
+	changed, err := startCanary(ctx, "app", "build-1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, started.StartedAt, changed.StartedAt)
+
+	stopped, err := stopCanary(ctx, "app")
+	require.NoError(t, err)
+	assert.Equal(t, "build-1", stopped.Version.BuildId)
+	assert.Empty(t, getCanaries())
+
+	p, split := selectPluginVersion(ctx, v2.plugin)
+	assert.Same(t, v2.plugin, p)
+	assert.False(t, split)
+}
+
+func Test_PromoteCanary(t *testing.T) {
+	ctx := newTestContext(t)
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	loadTestVersion(ctx, v1)
+
+	// a version loaded while the plugin is pinned is the default canary
+	_, err := rollbackPlugin(ctx, "app", "build-1")
+	require.NoError(t, err)
+	loadTestVersion(ctx, v2)
+	assert.True(t, isActive(v1))
+
+	info, err := startCanary(ctx, "app", "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "build-2", info.Version.BuildId)
+	assert.True(t, isCanary(v2))
+
+	// unpinning the plugin activates the canary's version, which ends the canary
+	_, err = unpinPlugin(ctx, "app")
+	require.NoError(t, err)
+	assert.True(t, isActive(v2))
+	assert.False(t, isCanary(v2))
+	assert.Empty(t, getCanaries())
+}
+
+func Test_Canary_ReloadedVersion(t *testing.T) {
+	ctx := newTestContext(t)
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	loadTestVersion(ctx, v1)
+	loadTestVersion(ctx, v2)
+
+	_, err := startCanary(ctx, "app", "build-1", 10)
+	require.NoError(t, err)
+
+	// activating the same content again, such as when a file is restored, promotes the canary
+	reloaded := newTestVersion(t, ctx, "app", "build-1", "v1")
+	loadTestVersion(ctx, reloaded)
+	assert.True(t, isActive(reloaded))
+	assert.Empty(t, getCanaries())
+}
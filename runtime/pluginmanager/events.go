@@ -11,6 +11,7 @@ package pluginmanager
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
@@ -18,6 +19,10 @@ import (
 
 type PluginLoadedCallback = func(ctx context.Context, md *metadata.Metadata) error
 
+// ErrPluginRejected can be wrapped by the error of a plugin loaded callback, to unload the new plugin,
+// and keep serving the plugin that it would have replaced, if any.
+var ErrPluginRejected = errors.New("plugin rejected")
+
 var pluginLoadedCallbacks []PluginLoadedCallback
 var eventsMutex = sync.RWMutex{}
 
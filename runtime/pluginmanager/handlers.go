@@ -0,0 +1,135 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// PluginsHandler returns each loaded plugin with its build metadata, source language, host functions,
+// and exported functions with their parameter and result types and invocation statistics.
+// The plugin query parameter limits the response to the named plugin.
+func PluginsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("plugin")
+	if name == "" {
+		utils.WriteJsonResponse(w, getPluginInfos())
+		return
+	}
+
+	plugin := globalPluginRegistry.GetByName(name)
+	if plugin == nil {
+		http.Error(w, errPluginNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJsonResponse(w, getPluginInfo(plugin))
+}
+
+// VersionsHandler returns the kept versions of each loaded plugin, newest first,
+// with the version that is active, and the version that the plugin is pinned to, if any.
+func VersionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.WriteJsonResponse(w, getPluginVersions())
+}
+
+// RollbackHandler activates a previous version of the plugin named by the plugin query parameter (POST),
+// and pins the plugin to it.  The version query parameter can name the version by its ID, build ID or hash.
+// Otherwise, the newest of the previous versions is activated.
+func RollbackHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	info, err := rollbackPlugin(pluginsCtx, query.Get("plugin"), query.Get("version"))
+	writeVersionResponse(w, info, err)
+}
+
+// UnpinHandler removes the pin of the plugin named by the plugin query parameter (POST),
+// and activates its newest version.
+func UnpinHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	info, err := unpinPlugin(pluginsCtx, r.URL.Query().Get("plugin"))
+	writeVersionResponse(w, info, err)
+}
+
+// CanaryHandler returns the canary of each plugin that has one (GET), or starts or changes the canary of the plugin
+// named by the plugin query parameter (POST).  The percent query parameter is the share of calls that go to the canary,
+// and the version query parameter names the version as it does for a rollback.  Regardless of the percentage,
+// requests with an X-Modus-Canary header of true or false are routed to or away from the canary.
+func CanaryHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		utils.WriteJsonResponse(w, getCanaries())
+	case http.MethodPost:
+		query := r.URL.Query()
+		percent, err := strconv.ParseFloat(query.Get("percent"), 64)
+		if err != nil {
+			http.Error(w, "Invalid percent", http.StatusBadRequest)
+			return
+		}
+		info, err := startCanary(pluginsCtx, query.Get("plugin"), query.Get("version"), percent)
+		writeCanaryResponse(w, info, err)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CanaryStopHandler ends the canary of the plugin named by the plugin query parameter (POST),
+// so that all calls go to its active version.
+func CanaryStopHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	info, err := stopCanary(pluginsCtx, r.URL.Query().Get("plugin"))
+	writeCanaryResponse(w, info, err)
+}
+
+func writeCanaryResponse(w http.ResponseWriter, info CanaryInfo, err error) {
+	switch {
+	case errors.Is(err, errInvalidCanary):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		writeVersionResponse(w, PluginVersionInfo{}, err)
+	default:
+		utils.WriteJsonResponse(w, info)
+	}
+}
+
+func writeVersionResponse(w http.ResponseWriter, info PluginVersionInfo, err error) {
+	switch {
+	case errors.Is(err, errPluginNotFound), errors.Is(err, errVersionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		utils.WriteJsonResponse(w, info)
+	}
+}
@@ -0,0 +1,271 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+)
+
+var errPluginNotFound = errors.New("plugin not found")
+var errVersionNotFound = errors.New("plugin version not found")
+
+// pluginVersion is a version of a plugin that has been loaded, and whose compiled module is kept,
+// so that it can be activated again without recompiling it.
+type pluginVersion struct {
+	plugin   *plugins.Plugin
+	hash     string
+	loadedAt time.Time
+}
+
+// pluginHistory holds the versions of a plugin, newest first.  When it is pinned to a version,
+// newer versions are kept, but not activated, until it is unpinned.
+type pluginHistory struct {
+	versions []*pluginVersion
+	pinned   *pluginVersion
+}
+
+var histories = make(map[string]*pluginHistory)
+
+// activationMutex serializes the changes of the active version of each plugin,
+// whether they come from storage or from the admin API.
+var activationMutex sync.Mutex
+
+func hashPlugin(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// pinnedVersion returns the version that a plugin is pinned to, if any.  The caller must hold the activation mutex.
+func pinnedVersion(name string) *pluginVersion {
+	if h, ok := histories[name]; ok {
+		return h.pinned
+	}
+	return nil
+}
+
+// addVersion records a newly loaded version of a plugin, and releases the compiled modules of the versions
+// that are beyond the configured history size.  The caller must hold the activation mutex.
+func addVersion(ctx context.Context, v *pluginVersion) {
+	name := v.plugin.Name()
+	h, ok := histories[name]
+	if !ok {
+		h = &pluginHistory{}
+		histories[name] = h
+	}
+
+	// The same content may be loaded again, such as when a file is restored.  Only its newest load is kept.
+	h.versions = slices.DeleteFunc(h.versions, func(old *pluginVersion) bool {
+		if old.hash != v.hash {
+			return false
+		}
+		if h.pinned == old {
+			h.pinned = v
+		}
+		replaceCanary(old, v)
+		releaseVersion(ctx, old)
+		return true
+	})
+	h.versions = slices.Insert(h.versions, 0, v)
+
+	// The oldest versions are released first.  The newest version, the active one, the pinned one,
+	// and that of a canary are always kept.
+	keep := 1 + max(config.PluginHistorySize, 0)
+	for len(h.versions) > keep {
+		i := len(h.versions) - 1
+		for i > 0 && (isActive(h.versions[i]) || h.pinned == h.versions[i] || isCanary(h.versions[i])) {
+			i--
+		}
+		if i == 0 {
+			break
+		}
+		releaseVersion(ctx, h.versions[i])
+		h.versions = slices.Delete(h.versions, i, i+1)
+	}
+
+	endPromotedCanary(ctx, name)
+}
+
+// removeHistory forgets the versions of a plugin that has been unloaded, releasing their compiled modules.
+// The caller must hold the activation mutex.
+func removeHistory(ctx context.Context, name string) {
+	if h, ok := histories[name]; ok {
+		for _, v := range h.versions {
+			releaseVersion(ctx, v)
+		}
+		delete(histories, name)
+		removeCanary(name)
+	}
+}
+
+func releaseVersion(ctx context.Context, v *pluginVersion) {
+	if isActive(v) {
+		return
+	}
+	go retirePlugin(ctx, v.plugin)
+}
+
+func isActive(v *pluginVersion) bool {
+	return globalPluginRegistry.GetByName(v.plugin.Name()) == v.plugin
+}
+
+// activateVersion makes a kept version of a plugin the active one, and restores the previously active version
+// if it can't be activated.  The caller must hold the activation mutex.
+func activateVersion(ctx context.Context, v *pluginVersion) error {
+	if isActive(v) {
+		return nil
+	}
+
+	previous := globalPluginRegistry.GetByName(v.plugin.Name())
+	globalPluginRegistry.AddOrUpdate(v.plugin)
+	if err := triggerPluginLoaded(ctx, v.plugin.Metadata); err != nil {
+		if previous != nil {
+			globalPluginRegistry.AddOrUpdate(previous)
+		} else {
+			globalPluginRegistry.Remove(v.plugin)
+		}
+		return err
+	}
+
+	registry := wasmhost.GetWasmHost(ctx).GetFunctionRegistry()
+	registry.RegisterAllFunctions(ctx, globalPluginRegistry.GetAll()...)
+	endPromotedCanary(ctx, v.plugin.Name())
+
+	logger.Info(ctx).
+		Str("plugin", v.plugin.Name()).
+		Str("build_id", v.plugin.BuildId()).
+		Str("hash", v.hash).
+		Msg("Activated plugin version.")
+	return nil
+}
+
+// findVersion returns the version of a plugin that a reference identifies, by its plugin ID, build ID, or a prefix
+// of the hash of its content.  Without a reference, it returns the newest version other than the active one.
+func findVersion(h *pluginHistory, ref string) (*pluginVersion, error) {
+	for _, v := range h.versions {
+		switch {
+		case ref == "":
+			if !isActive(v) {
+				return v, nil
+			}
+		case v.plugin.Id == ref || v.plugin.BuildId() == ref:
+			return v, nil
+		case len(ref) >= 7 && strings.HasPrefix(v.hash, strings.ToLower(ref)):
+			return v, nil
+		}
+	}
+	if ref == "" {
+		return nil, fmt.Errorf("%w: there is no previous version to roll back to", errVersionNotFound)
+	}
+	return nil, fmt.Errorf("%w: %s", errVersionNotFound, ref)
+}
+
+// rollbackPlugin activates a previous version of a plugin, and pins the plugin to it, so that it stays active
+// when new versions are loaded.  Without a version reference, it rolls back to the newest of the previous versions.
+func rollbackPlugin(ctx context.Context, name, ref string) (PluginVersionInfo, error) {
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+
+	h, ok := histories[name]
+	if !ok {
+		return PluginVersionInfo{}, fmt.Errorf("%w: %s", errPluginNotFound, name)
+	}
+
+	v, err := findVersion(h, ref)
+	if err != nil {
+		return PluginVersionInfo{}, err
+	}
+
+	if err := activateVersion(ctx, v); err != nil {
+		return PluginVersionInfo{}, err
+	}
+
+	h.pinned = v
+	logger.Warn(ctx).
+		Str("plugin", name).
+		Str("build_id", v.plugin.BuildId()).
+		Bool("user_visible", true).
+		Msg("Rolled back plugin.  It is pinned to this version until it is unpinned.")
+	return getVersionInfo(h, v), nil
+}
+
+// unpinPlugin removes the pin of a plugin, and activates its newest version.
+func unpinPlugin(ctx context.Context, name string) (PluginVersionInfo, error) {
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+
+	h, ok := histories[name]
+	if !ok || len(h.versions) == 0 {
+		return PluginVersionInfo{}, fmt.Errorf("%w: %s", errPluginNotFound, name)
+	}
+
+	h.pinned = nil
+	v := h.versions[0]
+	if err := activateVersion(ctx, v); err != nil {
+		return PluginVersionInfo{}, err
+	}
+	return getVersionInfo(h, v), nil
+}
+
+// PluginVersionInfo describes a kept version of a plugin, for the admin API.
+type PluginVersionInfo struct {
+	Id        string    `json:"id"`
+	BuildId   string    `json:"buildId"`
+	Version   string    `json:"version,omitempty"`
+	BuildTime string    `json:"buildTime"`
+	GitCommit string    `json:"gitCommit,omitempty"`
+	Hash      string    `json:"hash"`
+	LoadedAt  time.Time `json:"loadedAt"`
+	Active    bool      `json:"active"`
+	Pinned    bool      `json:"pinned"`
+}
+
+func getVersionInfo(h *pluginHistory, v *pluginVersion) PluginVersionInfo {
+	md := v.plugin.Metadata
+	return PluginVersionInfo{
+		Id:        v.plugin.Id,
+		BuildId:   md.BuildId,
+		Version:   md.Version(),
+		BuildTime: md.BuildTime,
+		GitCommit: md.GitCommit,
+		Hash:      v.hash,
+		LoadedAt:  v.loadedAt,
+		Active:    isActive(v),
+		Pinned:    h.pinned == v,
+	}
+}
+
+// getPluginVersions returns the kept versions of each plugin, newest first.
+func getPluginVersions() map[string][]PluginVersionInfo {
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+
+	result := make(map[string][]PluginVersionInfo, len(histories))
+	for name, h := range histories {
+		infos := make([]PluginVersionInfo, len(h.versions))
+		for i, v := range h.versions {
+			infos[i] = getVersionInfo(h, v)
+		}
+		result[name] = infos
+	}
+	return result
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// isRetired reports whether a plugin no longer accepts calls, because its version was released.
+func isRetired(p *plugins.Plugin) bool {
+	if p.BeginCall() {
+		p.EndCall()
+		return false
+	}
+	return true
+}
+
+func buildIds(name string) []string {
+	var ids []string
+	for _, v := range histories[name].versions {
+		ids = append(ids, v.plugin.BuildId())
+	}
+	return ids
+}
+
+func Test_AddVersion_Trims(t *testing.T) {
+	ctx := newTestContext(t)
+	config.PluginHistorySize = 2
+
+	var versions []*pluginVersion
+	for i := 1; i <= 5; i++ {
+		v := newTestVersion(t, ctx, "app", fmt.Sprintf("build-%d", i), fmt.Sprintf("v%d", i))
+		loadTestVersion(ctx, v)
+		versions = append(versions, v)
+	}
+
+	// the active version is kept, with the configured number of previous versions, newest first
+	assert.Equal(t, []string{"build-5", "build-4", "build-3"}, buildIds("app"))
+	assert.True(t, isActive(versions[4]))
+
+	for _, v := range versions[:2] {
+		assert.Eventually(t, func() bool { return isRetired(v.plugin) }, time.Second, 10*time.Millisecond)
+	}
+	for _, v := range versions[2:] {
+		assert.False(t, isRetired(v.plugin))
+	}
+}
+
+func Test_AddVersion_KeepsActiveAndPinned(t *testing.T) {
+	ctx := newTestContext(t)
+	config.PluginHistorySize = 0
+
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	loadTestVersion(ctx, v1)
+	loadTestVersion(ctx, v2)
+	assert.Equal(t, []string{"build-2"}, buildIds("app"))
+
+	// a pinned version is kept while newer versions are loaded, even beyond the history size
+	activationMutex.Lock()
+	histories["app"].pinned = v2
+	activationMutex.Unlock()
+
+	v3 := newTestVersion(t, ctx, "app", "build-3", "v3")
+	v4 := newTestVersion(t, ctx, "app", "build-4", "v4")
+	loadTestVersion(ctx, v3)
+	loadTestVersion(ctx, v4)
+
+	// only the newest of the versions loaded while pinned is kept, and the pinned version stays active
+	assert.Equal(t, []string{"build-4", "build-2"}, buildIds("app"))
+	assert.True(t, isActive(v2))
+	assert.False(t, isRetired(v2.plugin))
+	assert.Eventually(t, func() bool { return isRetired(v3.plugin) }, time.Second, 10*time.Millisecond)
+}
+
+func Test_AddVersion_SameContent(t *testing.T) {
+	ctx := newTestContext(t)
+
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	loadTestVersion(ctx, v1)
+	loadTestVersion(ctx, v2)
+
+	// loading the same content again keeps only its newest load
+	reloaded := newTestVersion(t, ctx, "app", "build-1b", "v1")
+	loadTestVersion(ctx, reloaded)
+	assert.Equal(t, []string{"build-1b", "build-2"}, buildIds("app"))
+	assert.True(t, isActive(reloaded))
+	assert.Eventually(t, func() bool { return isRetired(v1.plugin) }, time.Second, 10*time.Millisecond)
+
+	// reloading the pinned content moves the pin to the new load
+	_, err := rollbackPlugin(ctx, "app", "build-2")
+	require.NoError(t, err)
+	v2b := newTestVersion(t, ctx, "app", "build-2b", "v2")
+	loadTestVersion(ctx, v2b)
+	assert.Same(t, v2b, histories["app"].pinned)
+	assert.True(t, isActive(v2b))
+}
+
+func Test_FindVersion(t *testing.T) {
+	ctx := newTestContext(t)
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	v3 := newTestVersion(t, ctx, "app", "build-3", "v3")
+	loadTestVersion(ctx, v1)
+	loadTestVersion(ctx, v2)
+	loadTestVersion(ctx, v3)
+	h := histories["app"]
+
+	// without a reference, the newest version other than the active one
+	v, err := findVersion(h, "")
+	require.NoError(t, err)
+	assert.Same(t, v2, v)
+
+	v, err = findVersion(h, "build-1")
+	require.NoError(t, err)
+	assert.Same(t, v1, v)
+
+	v, err = findVersion(h, v1.plugin.Id)
+	require.NoError(t, err)
+	assert.Same(t, v1, v)
+
+	v, err = findVersion(h, v3.hash[:7])
+	require.NoError(t, err)
+	assert.Same(t, v3, v)
+
+	// hash prefixes must be long enough to be unambiguous
+	_, err = findVersion(h, v3.hash[:6])
+	assert.ErrorIs(t, err, errVersionNotFound)
+
+	_, err = findVersion(&pluginHistory{versions: []*pluginVersion{v3}}, "")
+	assert.ErrorIs(t, err, errVersionNotFound)
+}
+
+func Test_RollbackPlugin(t *testing.T) {
+	ctx := newTestContext(t)
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	loadTestVersion(ctx, v1)
+	loadTestVersion(ctx, v2)
+
+	info, err := rollbackPlugin(ctx, "app", "")
+	require.NoError(t, err)
+	assert.Equal(t, "build-1", info.BuildId)
+	assert.True(t, info.Active)
+	assert.True(t, info.Pinned)
+	assert.True(t, isActive(v1))
+
+	// a new version of a pinned plugin is kept, but not activated
+	v3 := newTestVersion(t, ctx, "app", "build-3", "v3")
+	loadTestVersion(ctx, v3)
+	assert.True(t, isActive(v1))
+	assert.Equal(t, []string{"build-3", "build-2", "build-1"}, buildIds("app"))
+
+	versions := getPluginVersions()["app"]
+	require.Len(t, versions, 3)
+	assert.False(t, versions[0].Active)
+	assert.True(t, versions[2].Active)
+	assert.True(t, versions[2].Pinned)
+
+	_, err = rollbackPlugin(ctx, "other", "")
+	assert.ErrorIs(t, err, errPluginNotFound)
+
+	_, err = rollbackPlugin(ctx, "app", "build-9")
+	assert.ErrorIs(t, err, errVersionNotFound)
+}
+
+func Test_UnpinPlugin(t *testing.T) {
+	ctx := newTestContext(t)
+	v1 := newTestVersion(t, ctx, "app", "build-1", "v1")
+	v2 := newTestVersion(t, ctx, "app", "build-2", "v2")
+	loadTestVersion(ctx, v1)
+	loadTestVersion(ctx, v2)
+
+	_, err := rollbackPlugin(ctx, "app", "build-1")
+	require.NoError(t, err)
+	v3 := newTestVersion(t, ctx, "app", "build-3", "v3")
+	loadTestVersion(ctx, v3)
+
+	// unpinning activates the newest version, including one loaded while pinned
+	info, err := unpinPlugin(ctx, "app")
+	require.NoError(t, err)
+	assert.Equal(t, "build-3", info.BuildId)
+	assert.False(t, info.Pinned)
+	assert.True(t, isActive(v3))
+	assert.Nil(t, histories["app"].pinned)
+
+	// new versions are activated again
+	v4 := newTestVersion(t, ctx, "app", "build-4", "v4")
+	loadTestVersion(ctx, v4)
+	assert.True(t, isActive(v4))
+
+	_, err = unpinPlugin(ctx, "other")
+	assert.ErrorIs(t, err, errPluginNotFound)
+}
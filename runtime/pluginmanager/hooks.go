@@ -0,0 +1,85 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+)
+
+// runInitHook calls the init hook of a newly loaded plugin, if it exports one.  When the hook fails or times out,
+// the plugin is rejected, unless the init failure policy is to ignore the failure.
+func runInitHook(ctx context.Context, plugin *plugins.Plugin) error {
+	found, duration, err := runHook(ctx, plugin, plugins.InitHookName)
+	switch {
+	case !found:
+		return nil
+	case err == nil:
+		logger.Info(ctx).
+			Str("plugin", plugin.Name()).
+			Str("build_id", plugin.BuildId()).
+			Dur("duration_ms", duration).
+			Msg("Initialized plugin.")
+		return nil
+	case config.InitFailurePolicy == "ignore":
+		logger.Warn(ctx).Err(err).
+			Str("plugin", plugin.Name()).
+			Str("build_id", plugin.BuildId()).
+			Bool("user_visible", true).
+			Msg("The plugin's init hook failed.  Loading it regardless.")
+		return nil
+	default:
+		return fmt.Errorf("%w: %w", ErrPluginRejected, err)
+	}
+}
+
+// runShutdownHook calls the shutdown hook of a plugin that is unloaded, replaced, or stopped with the runtime,
+// if it exports one.  A failure is logged, since the plugin is going away regardless.
+func runShutdownHook(ctx context.Context, plugin *plugins.Plugin) {
+	found, duration, err := runHook(ctx, plugin, plugins.ShutdownHookName)
+	if !found {
+		return
+	}
+	if err != nil {
+		logger.Warn(ctx).Err(err).
+			Str("plugin", plugin.Name()).
+			Str("build_id", plugin.BuildId()).
+			Bool("user_visible", true).
+			Msg("The plugin's shutdown hook failed.")
+		return
+	}
+	logger.Info(ctx).
+		Str("plugin", plugin.Name()).
+		Str("build_id", plugin.BuildId()).
+		Dur("duration_ms", duration).
+		Msg("Shut down plugin.")
+}
+
+func runHook(ctx context.Context, plugin *plugins.Plugin, name string) (bool, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), config.HookTimeout)
+	defer cancel()
+
+	start := time.Now()
+	found, err := wasmhost.GetWasmHost(ctx).CallHook(ctx, plugin, name)
+	return found, time.Since(start), err
+}
+
+// Shutdown calls the shutdown hook of each active plugin, when the runtime stops.
+func Shutdown(ctx context.Context) {
+	for _, plugin := range GetRegisteredPlugins() {
+		runShutdownHook(ctx, plugin)
+	}
+}
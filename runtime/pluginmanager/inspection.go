@@ -0,0 +1,99 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+)
+
+// PluginInfo describes a loaded plugin, for the admin API.
+type PluginInfo struct {
+	Name          string          `json:"name"`
+	Version       string          `json:"version,omitempty"`
+	Id            string          `json:"id"`
+	Language      string          `json:"language"`
+	SDK           string          `json:"sdk"`
+	AbiVersion    int             `json:"abiVersion"`
+	BuildId       string          `json:"buildId"`
+	BuildTime     string          `json:"buildTime"`
+	GitRepo       string          `json:"gitRepo,omitempty"`
+	GitCommit     string          `json:"gitCommit,omitempty"`
+	FileName      string          `json:"fileName"`
+	Functions     []*FunctionInfo `json:"functions,omitempty"`
+	HostFunctions []string        `json:"hostFunctions,omitempty"`
+}
+
+// FunctionInfo describes a function exported by a plugin, with its invocation statistics if it has been called.
+type FunctionInfo struct {
+	Name       string                  `json:"name"`
+	Parameters []*metadata.Parameter   `json:"parameters,omitempty"`
+	Results    []*metadata.Result      `json:"results,omitempty"`
+	Docs       string                  `json:"docs,omitempty"`
+	Directives []*metadata.Directive   `json:"directives,omitempty"`
+	Stats      *wasmhost.FunctionStats `json:"stats,omitempty"`
+}
+
+func getPluginInfo(plugin *plugins.Plugin) *PluginInfo {
+	md := plugin.Metadata
+	info := &PluginInfo{
+		Name:          plugin.Name(),
+		Version:       plugin.Version(),
+		Id:            plugin.Id,
+		Language:      plugin.Language.Name(),
+		SDK:           md.SDK,
+		AbiVersion:    md.GetAbiVersion(),
+		BuildId:       md.BuildId,
+		BuildTime:     md.BuildTime,
+		GitRepo:       md.GitRepo,
+		GitCommit:     md.GitCommit,
+		FileName:      plugin.FileName,
+		Functions:     make([]*FunctionInfo, 0, len(md.FnExports)),
+		HostFunctions: make([]string, 0, len(md.FnImports)),
+	}
+
+	for name, fn := range md.FnExports {
+		fnInfo := &FunctionInfo{
+			Name:       name,
+			Parameters: fn.Parameters,
+			Results:    fn.Results,
+			Docs:       fn.Docs,
+			Directives: fn.Directives,
+		}
+		if stats, ok := wasmhost.GetFunctionStats(info.Name, name); ok {
+			fnInfo.Stats = &stats
+		}
+		info.Functions = append(info.Functions, fnInfo)
+	}
+	slices.SortFunc(info.Functions, func(a, b *FunctionInfo) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	for name := range md.FnImports {
+		info.HostFunctions = append(info.HostFunctions, name)
+	}
+	slices.Sort(info.HostFunctions)
+
+	return info
+}
+
+// getPluginInfos returns the description of each loaded plugin, sorted by name.
+func getPluginInfos() []*PluginInfo {
+	registered := GetRegisteredPlugins()
+	result := make([]*PluginInfo, len(registered))
+	for i, plugin := range registered {
+		result[i] = getPluginInfo(plugin)
+	}
+	return result
+}
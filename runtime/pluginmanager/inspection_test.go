@@ -0,0 +1,90 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PluginsHandler(t *testing.T) {
+	ctx := newTestContext(t)
+
+	app := newTestVersion(t, ctx, "app", "build-1", "app")
+	md := app.plugin.Metadata
+	md.FnExports = make(metadata.FunctionMap)
+	md.FnExports.AddFunction("sayHello").
+		WithParameter("name", "string").
+		WithResult("string").
+		WithDocs("Says hello.").
+		WithDirective("auth", map[string]string{"claim": "role"})
+	md.FnExports.AddFunction("add").
+		WithParameter("a", "i32").
+		WithParameter("b", "i32").
+		WithResult("i32")
+	md.FnImports = make(metadata.FunctionMap)
+	md.FnImports.AddFunction("hypermode.log")
+	loadTestVersion(ctx, app)
+	loadTestVersion(ctx, newTestVersion(t, ctx, "other", "build-2", "other"))
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		PluginsHandler(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get("/admin/plugins")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var infos []*PluginInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &infos))
+	require.Len(t, infos, 2)
+	assert.Equal(t, "app", infos[0].Name)
+	assert.Equal(t, "other", infos[1].Name)
+
+	w = get("/admin/plugins?plugin=app")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var info PluginInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, "1.0.0", info.Version)
+	assert.Equal(t, app.plugin.Id, info.Id)
+	assert.Equal(t, "Go", info.Language)
+	assert.Equal(t, "build-1", info.BuildId)
+	assert.Equal(t, "app.wasm", info.FileName)
+	assert.Equal(t, []string{"hypermode.log"}, info.HostFunctions)
+
+	// functions are sorted by name, and have no stats until they are called
+	require.Len(t, info.Functions, 2)
+	assert.Equal(t, "add", info.Functions[0].Name)
+	assert.Len(t, info.Functions[0].Parameters, 2)
+	fn := info.Functions[1]
+	assert.Equal(t, "sayHello", fn.Name)
+	assert.Equal(t, "Says hello.", fn.Docs)
+	assert.Equal(t, "string", fn.Results[0].Type)
+	require.Len(t, fn.Directives, 1)
+	assert.Equal(t, "role", fn.Directives[0].Args["claim"])
+	assert.Nil(t, fn.Stats)
+
+	w = get("/admin/plugins?plugin=missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	PluginsHandler(w, httptest.NewRequest(http.MethodPost, "/admin/plugins", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
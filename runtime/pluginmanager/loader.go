@@ -11,12 +11,18 @@ package pluginmanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/hypermodeinc/modus/runtime/config"
 	"github.com/hypermodeinc/modus/runtime/db"
 	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/metrics"
 	"github.com/hypermodeinc/modus/runtime/plugins"
 	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/signing"
 	"github.com/hypermodeinc/modus/runtime/storage"
 	"github.com/hypermodeinc/modus/runtime/utils"
 	"github.com/hypermodeinc/modus/runtime/wasmhost"
@@ -53,6 +59,19 @@ func monitorPlugins(ctx context.Context) {
 		}
 	}
 	sm.Start(ctx)
+
+	// When signatures are required, a plugin is loaded again when its signature changes,
+	// so that it doesn't matter whether the plugin or its signature is uploaded first.
+	if signing.IsEnabled() {
+		loadSignedPlugin := func(fi storage.FileInfo) error {
+			return loadPluginFile(storage.FileInfo{Name: strings.TrimSuffix(fi.Name, signing.SignatureExtension)})
+		}
+		ssm := storage.NewStorageMonitor(".wasm" + signing.SignatureExtension)
+		ssm.Added = loadSignedPlugin
+		ssm.Modified = loadSignedPlugin
+		ssm.Changed = sm.Changed
+		ssm.Start(ctx)
+	}
 }
 
 func loadPlugin(ctx context.Context, filename string) error {
@@ -65,6 +84,12 @@ func loadPlugin(ctx context.Context, filename string) error {
 		return err
 	}
 
+	// Refuse plugins that aren't signed by a trusted key, when signatures are required.
+	if err := signing.VerifyPlugin(ctx, filename, bytes); err != nil {
+		metrics.UnverifiedPluginsNum.Inc()
+		return err
+	}
+
 	// Compile the plugin into a module
 	cm, err := wasmhost.GetWasmHost(ctx).CompileModule(ctx, bytes)
 	if err != nil {
@@ -82,17 +107,51 @@ func loadPlugin(ctx context.Context, filename string) error {
 		return err
 	}
 
+	// Refuse plugins that were built for an ABI that the runtime doesn't support.
+	if err := md.CheckAbiVersion(); err != nil {
+		var abiErr *metadata.AbiVersionError
+		if errors.As(err, &abiErr) {
+			logger.Error(ctx).
+				Bool("user_visible", true).
+				Str("sdk", md.SDK).
+				Int("abi_version", abiErr.Version).
+				Msg(abiErr.Remedy())
+		}
+		return err
+	}
+
 	// Make the plugin object.
 	plugin, err := plugins.NewPlugin(ctx, cm, filename, md)
 	if err != nil {
 		return err
 	}
 
+	if err := initPlugin(ctx, plugin); err != nil {
+		return err
+	}
+
 	// Write the plugin info to the database.
 	// Note, this may update the ID if a plugin with the same BuildID is in the db already.
 	db.WritePluginInfo(ctx, plugin)
 
-	// Register the plugin.
+	version := &pluginVersion{plugin: plugin, hash: hashPlugin(bytes), loadedAt: time.Now()}
+
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+
+	// A plugin that was rolled back stays on its pinned version.  Newer versions are kept, so they can be activated later.
+	if pinned := pinnedVersion(plugin.Name()); pinned != nil && pinned.hash != version.hash {
+		addVersion(ctx, version)
+		logger.Warn(ctx).
+			Str("plugin", plugin.Name()).
+			Str("build_id", plugin.BuildId()).
+			Bool("user_visible", true).
+			Msg("Loaded a new version of a pinned plugin.  It will not be activated until the plugin is unpinned.")
+		return nil
+	}
+
+	// Register the plugin, keeping the plugin it replaces in case the new one is rejected.
+	previous := globalPluginRegistry.GetByName(plugin.Name())
 	globalPluginRegistry.AddOrUpdate(plugin)
 
 	// Log the details of the loaded plugin.
@@ -100,6 +159,20 @@ func loadPlugin(ctx context.Context, filename string) error {
 
 	// Trigger the plugin loaded event.
 	err = triggerPluginLoaded(ctx, md)
+	if errors.Is(err, ErrPluginRejected) {
+		if previous != nil {
+			globalPluginRegistry.AddOrUpdate(previous)
+		} else {
+			globalPluginRegistry.Remove(plugin)
+		}
+		if closeErr := plugin.Close(ctx); closeErr != nil {
+			logger.Warn(ctx).Err(closeErr).Str("plugin", plugin.Name()).Msg("Failed to close rejected plugin.")
+		}
+		return err
+	}
+
+	// Keep the version, so that it can be rolled back to.
+	addVersion(ctx, version)
 
 	return err
 }
@@ -135,6 +208,7 @@ func logPluginLoaded(ctx context.Context, plugin *plugins.Plugin) {
 		name, version := md.SdkNameAndVersion()
 		evt.Str("sdk", name)
 		evt.Str("sdk_version", version)
+		evt.Int("abi_version", md.GetAbiVersion())
 	}
 
 	if md.GitRepo != "" {
@@ -162,6 +236,66 @@ func unloadPlugin(ctx context.Context, filename string) error {
 		Str("build_id", p.BuildId()).
 		Msg("Unloading plugin.")
 
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+
+	// Removing the history retires each of the plugin's versions, including the one that was active.
 	globalPluginRegistry.Remove(p)
-	return p.Module.Close(ctx)
+	removeHistory(ctx, p.Name())
+	return nil
+}
+
+// retirePlugin closes the compiled module of a plugin that has been replaced or unloaded, once the function calls
+// in progress have completed, or the drain timeout has passed, and then its shutdown hook has run.
+// New calls are served by the plugin's current version.
+func retirePlugin(ctx context.Context, plugin *plugins.Plugin) {
+	ctx, cancel := context.WithTimeout(ctx, config.DrainTimeout)
+	defer cancel()
+
+	remaining := plugin.Drain(ctx)
+	if remaining > 0 {
+		logger.Warn(ctx).
+			Str("plugin", plugin.Name()).
+			Str("build_id", plugin.BuildId()).
+			Int("calls", remaining).
+			Bool("user_visible", true).
+			Msg("Function calls were still in progress when a replaced plugin version was closed.")
+	}
+
+	runShutdownHook(ctx, plugin)
+
+	if err := plugin.Close(context.WithoutCancel(ctx)); err != nil {
+		logger.Warn(ctx).Err(err).Str("plugin", plugin.Name()).Msg("Failed to release the compiled module of a previous plugin version.")
+	}
+}
+
+// initPlugin readies a plugin before it serves any calls.  A plugin that can't be readied is rejected,
+// and closed so that its compiled module is released.
+func initPlugin(ctx context.Context, plugin *plugins.Plugin) error {
+	// Prepare the critical functions now, rather than on their first call.
+	err := plugin.PrepareFunctions(ctx, warmFunctions()...)
+
+	// Let the plugin initialize itself.
+	if err == nil {
+		err = runInitHook(ctx, plugin)
+	}
+
+	if err != nil {
+		if closeErr := plugin.Close(ctx); closeErr != nil {
+			logger.Warn(ctx).Err(closeErr).Str("plugin", plugin.Name()).Msg("Failed to close rejected plugin.")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func warmFunctions() []string {
+	var names []string
+	for _, name := range strings.Split(config.WarmFunctions, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
 }
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
+)
+
+// a Wasm module that exports a function "f", which takes no parameters and returns nothing
+var noopWasmModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x04, 0x01, 0x60, 0x00, 0x00, // type section: () -> ()
+	0x03, 0x02, 0x01, 0x00, // function section
+	0x07, 0x05, 0x01, 0x01, 'f', 0x00, 0x00, // export section
+	0x0a, 0x04, 0x01, 0x02, 0x00, 0x0b, // code section
+}
+
+// closeRecorder records whether its compiled module was released.
+type closeRecorder struct {
+	wazero.CompiledModule
+	closed bool
+}
+
+func (c *closeRecorder) Close(ctx context.Context) error {
+	c.closed = true
+	return c.CompiledModule.Close(ctx)
+}
+
+func newTestPlugin(t *testing.T, ctx context.Context, fn *metadata.Function) (*plugins.Plugin, *closeRecorder) {
+	cm, err := wasmhost.GetWasmHost(ctx).CompileModule(ctx, noopWasmModule)
+	require.NoError(t, err)
+	recorder := &closeRecorder{CompiledModule: cm}
+
+	md := &metadata.Metadata{
+		Plugin:    "test@1.0.0",
+		SDK:       "modus-sdk-go@0.13.0",
+		BuildId:   "build-1",
+		FnExports: metadata.FunctionMap{"f": fn},
+	}
+	plugin, err := plugins.NewPlugin(ctx, recorder, "test.wasm", md)
+	require.NoError(t, err)
+	return plugin, recorder
+}
+
+func Test_InitPlugin(t *testing.T) {
+	ctx := newTestContext(t)
+
+	warm := config.WarmFunctions
+	config.WarmFunctions = "f"
+	t.Cleanup(func() { config.WarmFunctions = warm })
+
+	plugin, recorder := newTestPlugin(t, ctx, metadata.NewFunction("f"))
+	require.NoError(t, initPlugin(ctx, plugin))
+	assert.False(t, recorder.closed)
+
+	// the parameter has a type that isn't defined in the metadata, so the function can't be prepared
+	plugin, recorder = newTestPlugin(t, ctx, metadata.NewFunction("f").WithParameter("x", "test.Unknown"))
+	assert.Error(t, initPlugin(ctx, plugin))
+	assert.True(t, recorder.closed, "the compiled module of a rejected plugin must be released")
+}
@@ -18,7 +18,12 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// pluginsCtx is the context that plugins are loaded with, which is also used to activate plugin versions
+// through the admin API, since activation outlives the request.
+var pluginsCtx context.Context
+
 func Initialize(ctx context.Context) {
+	pluginsCtx = ctx
 	configureLogger()
 	monitorPlugins(ctx)
 }
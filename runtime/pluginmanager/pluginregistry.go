@@ -30,6 +30,11 @@ func GetRegisteredPlugins() []*plugins.Plugin {
 	return globalPluginRegistry.GetAll()
 }
 
+// GetRegisteredPlugin returns the active version of the named plugin, or nil if no such plugin is loaded.
+func GetRegisteredPlugin(name string) *plugins.Plugin {
+	return globalPluginRegistry.GetByName(name)
+}
+
 type pluginRegistry struct {
 	idRevIndex map[*plugins.Plugin]string
 	idIndex    map[string]*plugins.Plugin
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package pluginmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+	"github.com/hypermodeinc/modus/runtime/utils"
+	"github.com/hypermodeinc/modus/runtime/wasmhost"
+
+	"github.com/stretchr/testify/require"
+)
+
+// the smallest valid Wasm module, which has no imports or exports
+var emptyWasmModule = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// newTestContext forgets all plugins, and returns a context with a Wasm host, as the plugin manager has when it runs.
+func newTestContext(t *testing.T) context.Context {
+	ctx := context.Background()
+	host := wasmhost.NewWasmHost(ctx)
+	ctx = context.WithValue(ctx, utils.WasmHostContextKey, host)
+
+	resetPlugins := func() {
+		activationMutex.Lock()
+		defer activationMutex.Unlock()
+		for _, p := range globalPluginRegistry.GetAll() {
+			globalPluginRegistry.Remove(p)
+		}
+		histories = make(map[string]*pluginHistory)
+		canariesMutex.Lock()
+		canaries = make(map[string]*canary)
+		canariesMutex.Unlock()
+	}
+
+	resetPlugins()
+	t.Cleanup(resetPlugins)
+
+	// the default number of previous versions that are kept
+	historySize := config.PluginHistorySize
+	config.PluginHistorySize = 3
+	t.Cleanup(func() { config.PluginHistorySize = historySize })
+
+	return ctx
+}
+
+// newTestVersion returns a version of a plugin whose content is identified by the given string.
+// It isn't active until it is added to the plugin registry.
+func newTestVersion(t *testing.T, ctx context.Context, name, buildId, content string) *pluginVersion {
+	host := wasmhost.GetWasmHost(ctx)
+	cm, err := host.CompileModule(ctx, emptyWasmModule)
+	require.NoError(t, err)
+
+	md := &metadata.Metadata{
+		Plugin:  name + "@1.0.0",
+		SDK:     "modus-sdk-go@0.13.0",
+		BuildId: buildId,
+	}
+	plugin, err := plugins.NewPlugin(ctx, cm, name+".wasm", md)
+	require.NoError(t, err)
+
+	return &pluginVersion{
+		plugin:   plugin,
+		hash:     hashPlugin([]byte(content)),
+		loadedAt: time.Now(),
+	}
+}
+
+// loadTestVersion adds a version to the history of its plugin, and activates it unless the plugin is pinned
+// to another version, as loading a plugin from storage does.
+func loadTestVersion(ctx context.Context, v *pluginVersion) {
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+
+	if pinned := pinnedVersion(v.plugin.Name()); pinned == nil || pinned.hash == v.hash {
+		globalPluginRegistry.AddOrUpdate(v.plugin)
+	}
+	addVersion(ctx, v)
+}
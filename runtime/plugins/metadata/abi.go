@@ -0,0 +1,56 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package metadata
+
+import "fmt"
+
+// The ABI is the interface between plugins and the runtime, including the host functions that plugins import,
+// and how values are passed to and from them.  SDKs record the ABI version that a plugin was built for in its
+// metadata, and the runtime supports the versions from MinAbiVersion to MaxAbiVersion.
+const (
+	MinAbiVersion = 1
+	MaxAbiVersion = 1
+)
+
+// GetAbiVersion returns the ABI version that the plugin was built for.
+// SDKs that predate ABI versioning built plugins for the first version.
+func (m *Metadata) GetAbiVersion() int {
+	if m.AbiVersion == 0 {
+		return 1
+	}
+	return m.AbiVersion
+}
+
+// CheckAbiVersion returns an AbiVersionError if the runtime doesn't support the ABI version of the plugin.
+func (m *Metadata) CheckAbiVersion() error {
+	if v := m.GetAbiVersion(); v < MinAbiVersion || v > MaxAbiVersion {
+		return &AbiVersionError{Plugin: m.Plugin, SDK: m.SDK, Version: v}
+	}
+	return nil
+}
+
+// AbiVersionError is returned for a plugin that was built for an ABI version that the runtime doesn't support.
+type AbiVersionError struct {
+	Plugin  string
+	SDK     string
+	Version int
+}
+
+func (e *AbiVersionError) Error() string {
+	return fmt.Sprintf("plugin %s was built with SDK %s, for ABI v%d, but the runtime supports ABI v%d to v%d", e.Plugin, e.SDK, e.Version, MinAbiVersion, MaxAbiVersion)
+}
+
+// Remedy describes what to do about the incompatibility, which depends on whether the plugin is too new or too old.
+func (e *AbiVersionError) Remedy() string {
+	if e.Version > MaxAbiVersion {
+		return "The plugin was built with a newer SDK than this runtime supports.  Please upgrade the Modus runtime."
+	}
+	return "The plugin was built with an older SDK than this runtime supports.  Please rebuild it with a newer version of the Modus SDK."
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package metadata
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAbiVersion(t *testing.T) {
+	// plugins built before ABI versioning use the first version
+	md := &Metadata{Plugin: "orders@1.0.0", SDK: "modus-sdk-go@0.12.0"}
+	assert.Equal(t, 1, md.GetAbiVersion())
+	assert.NoError(t, md.CheckAbiVersion())
+
+	md.AbiVersion = MaxAbiVersion
+	assert.NoError(t, md.CheckAbiVersion())
+
+	md.AbiVersion = MaxAbiVersion + 1
+	err := md.CheckAbiVersion()
+	assert.EqualError(t, err, "plugin orders@1.0.0 was built with SDK modus-sdk-go@0.12.0, for ABI v2, but the runtime supports ABI v1 to v1")
+
+	var abiErr *AbiVersionError
+	if assert.True(t, errors.As(err, &abiErr)) {
+		assert.Contains(t, abiErr.Remedy(), "upgrade the Modus runtime")
+	}
+}
@@ -56,6 +56,16 @@ func (f *Function) WithParameter(name string, typ string, dflt ...any) *Function
 	return f
 }
 
+func (f *Function) WithDocs(docs string) *Function {
+	f.Docs = docs
+	return f
+}
+
+func (f *Function) WithDirective(name string, args map[string]string) *Function {
+	f.Directives = append(f.Directives, &Directive{Name: name, Args: args})
+	return f
+}
+
 func (f *Function) WithResult(typ string) *Function {
 	r := &Result{Type: typ}
 	f.Results = append(f.Results, r)
@@ -76,12 +86,29 @@ func (t *TypeDefinition) WithId(id uint32) *TypeDefinition {
 	return t
 }
 
-func (t *TypeDefinition) WithField(name string, typ string) *TypeDefinition {
+func (t *TypeDefinition) WithField(name string, typ string, dflt ...any) *TypeDefinition {
 	f := &Field{Name: name, Type: typ}
+	if len(dflt) > 0 {
+		f.Default = &dflt[0]
+	}
 	t.Fields = append(t.Fields, f)
 	return t
 }
 
+func (t *TypeDefinition) WithDocs(docs string) *TypeDefinition {
+	t.Docs = docs
+	return t
+}
+
+func (t *TypeDefinition) WithFieldDocs(name string, docs string) *TypeDefinition {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			f.Docs = docs
+		}
+	}
+	return t
+}
+
 func (f *Function) String() string {
 	p := strings.Trim(fmt.Sprintf("%v", f.Parameters), "[]")
 	r := strings.Trim(fmt.Sprintf("%v", f.Results), "[]")
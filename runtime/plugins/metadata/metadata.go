@@ -14,6 +14,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode"
 
 	"github.com/hypermodeinc/modus/runtime/utils"
 
@@ -26,34 +27,39 @@ type TypeMap map[string]*TypeDefinition
 type FunctionMap map[string]*Function
 
 type Metadata struct {
-	Plugin    string      `json:"plugin"`
-	Module    string      `json:"module"`
-	SDK       string      `json:"sdk"`
-	BuildId   string      `json:"buildId"`
-	BuildTime string      `json:"buildTs"`
-	GitRepo   string      `json:"gitRepo,omitempty"`
-	GitCommit string      `json:"gitCommit,omitempty"`
-	FnExports FunctionMap `json:"fnExports,omitempty"`
-	FnImports FunctionMap `json:"fnImports,omitempty"`
-	Types     TypeMap     `json:"types,omitempty"`
+	Plugin     string      `json:"plugin"`
+	Module     string      `json:"module"`
+	SDK        string      `json:"sdk"`
+	AbiVersion int         `json:"abiVersion,omitempty"`
+	BuildId    string      `json:"buildId"`
+	BuildTime  string      `json:"buildTs"`
+	GitRepo    string      `json:"gitRepo,omitempty"`
+	GitCommit  string      `json:"gitCommit,omitempty"`
+	FnExports  FunctionMap `json:"fnExports,omitempty"`
+	FnImports  FunctionMap `json:"fnImports,omitempty"`
+	Types      TypeMap     `json:"types,omitempty"`
 }
 
 type Function struct {
 	Name       string       `json:"-"`
 	Parameters []*Parameter `json:"parameters,omitempty"`
 	Results    []*Result    `json:"results,omitempty"`
+	Docs       string       `json:"docs,omitempty"`
+	Directives []*Directive `json:"directives,omitempty"`
 }
 
 type TypeDefinition struct {
 	Name   string   `json:"-"`
 	Id     uint32   `json:"id,omitempty"`
 	Fields []*Field `json:"fields,omitempty"`
+	Docs   string   `json:"docs,omitempty"`
 }
 
 type Parameter struct {
 	Name    string `json:"name"`
 	Type    string `json:"type"`
 	Default *any   `json:"default,omitempty"`
+	Docs    string `json:"docs,omitempty"`
 }
 
 type Result struct {
@@ -61,9 +67,16 @@ type Result struct {
 	Type string `json:"type"`
 }
 
+type Directive struct {
+	Name string            `json:"name"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
 type Field struct {
-	Name string `json:"name"`
-	Type string `json:"type"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default *any   `json:"default,omitempty"`
+	Docs    string `json:"docs,omitempty"`
 }
 
 func (p *Parameter) UnmarshalJSON(data []byte) error {
@@ -77,6 +90,8 @@ func (p *Parameter) UnmarshalJSON(data []byte) error {
 			p.Name = value.String()
 		case "type":
 			p.Type = value.String()
+		case "docs":
+			p.Docs = value.String()
 		case "default":
 			val := value.Value()
 			if val == nil {
@@ -91,6 +106,32 @@ func (p *Parameter) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (f *Field) UnmarshalJSON(data []byte) error {
+
+	// As with parameters, a null default value is distinct from the absence of a default value.
+
+	gjson.ParseBytes(data).ForEach(func(key, value gjson.Result) bool {
+		switch key.String() {
+		case "name":
+			f.Name = value.String()
+		case "type":
+			f.Type = value.String()
+		case "docs":
+			f.Docs = value.String()
+		case "default":
+			val := value.Value()
+			if val == nil {
+				f.Default = new(any)
+			} else {
+				f.Default = &val
+			}
+		}
+		return true
+	})
+
+	return nil
+}
+
 func (m *Metadata) NameAndVersion() (name string, version string) {
 	return parseNameAndVersion(m.Plugin)
 }
@@ -100,6 +141,30 @@ func (m *Metadata) Name() string {
 	return name
 }
 
+// Namespace is the name of the plugin as a GraphQL identifier in camel case, such as myPlugin for my-plugin.
+// It qualifies the names of the plugin's functions when they are composed with those of other plugins.
+func (m *Metadata) Namespace() string {
+	var b strings.Builder
+	upper := false
+	for _, r := range m.Name() {
+		isLetter := unicode.IsLetter(r) && r < unicode.MaxASCII
+		isDigit := unicode.IsDigit(r) && r < unicode.MaxASCII
+		switch {
+		case isLetter && b.Len() == 0:
+			b.WriteRune(unicode.ToLower(r))
+		case isLetter && upper:
+			b.WriteRune(unicode.ToUpper(r))
+		case isLetter || isDigit && b.Len() > 0:
+			b.WriteRune(r)
+		default:
+			upper = b.Len() > 0
+			continue
+		}
+		upper = false
+	}
+	return b.String()
+}
+
 func (m *Metadata) Version() string {
 	_, version := m.NameAndVersion()
 	return version
@@ -122,9 +187,9 @@ func (m *Metadata) SdkVersion() string {
 func (m *Metadata) GetTypeDefinition(typ string) (*TypeDefinition, error) {
 	switch typ {
 	case "[]byte":
-		return &TypeDefinition{typ, 1, nil}, nil
+		return &TypeDefinition{Name: typ, Id: 1}, nil
 	case "string":
-		return &TypeDefinition{typ, 2, nil}, nil
+		return &TypeDefinition{Name: typ, Id: 2}, nil
 	}
 
 	def, ok := m.Types[typ]
@@ -143,6 +208,26 @@ func (m *Metadata) GetExportedFunctions() []*Function {
 	return fns
 }
 
+// GetDirective returns the function's directive with the given name, or nil if there isn't one.
+func (f *Function) GetDirective(name string) *Directive {
+	for _, d := range f.Directives {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+func (f *Function) GetDirectives(name string) []*Directive {
+	var results []*Directive
+	for _, d := range f.Directives {
+		if d.Name == name {
+			results = append(results, d)
+		}
+	}
+	return results
+}
+
 func parseNameAndVersion(s string) (name string, version string) {
 	i := strings.LastIndex(s, "@")
 	if i == -1 {
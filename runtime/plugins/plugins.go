@@ -12,6 +12,9 @@ package plugins
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"slices"
+	"sync"
 
 	"github.com/hypermodeinc/modus/runtime/langsupport"
 	"github.com/hypermodeinc/modus/runtime/languages"
@@ -22,13 +25,42 @@ import (
 	wasm "github.com/tetratelabs/wazero/api"
 )
 
+// Plugins can export lifecycle hooks, which take no parameters and return nothing.  The runtime calls the init hook
+// when the plugin is loaded, before it serves any calls, and the shutdown hook when it is unloaded or replaced,
+// after its calls have completed.  Hooks aren't served as functions.
+const (
+	InitHookName     = "modus_init"
+	ShutdownHookName = "modus_shutdown"
+)
+
+// IsHook reports whether the named function is a lifecycle hook.
+func IsHook(fnName string) bool {
+	return fnName == InitHookName || fnName == ShutdownHookName
+}
+
 type Plugin struct {
-	Id             string
-	Module         wazero.CompiledModule
-	Metadata       *metadata.Metadata
-	FileName       string
-	Language       langsupport.Language
-	ExecutionPlans map[string]langsupport.ExecutionPlan
+	Id       string
+	Module   wazero.CompiledModule
+	Metadata *metadata.Metadata
+	FileName string
+	Language langsupport.Language
+
+	// The execution plan of each function is prepared on its first call, or when the plugin is warmed up,
+	// rather than when the plugin is loaded, since large plugins can have many functions and types.
+	planner     langsupport.Planner
+	fnDefs      map[string]wasm.FunctionDefinition
+	customTypes map[string]reflect.Type
+	plans       map[string]langsupport.ExecutionPlan
+	plansMutex  sync.Mutex
+
+	// Calls in progress are tracked, so that the compiled module of a plugin that is replaced or unloaded
+	// is only closed once they complete.
+	calls      int
+	retiring   bool
+	idle       chan struct{}
+	callsMutex sync.Mutex
+	closeOnce  sync.Once
+	closeErr   error
 }
 
 func NewPlugin(ctx context.Context, cm wazero.CompiledModule, filename string, md *metadata.Metadata) (*Plugin, error) {
@@ -40,24 +72,16 @@ func NewPlugin(ctx context.Context, cm wazero.CompiledModule, filename string, m
 		return nil, err
 	}
 
-	planner := language.NewPlanner(md)
 	imports := cm.ImportedFunctions()
 	exports := cm.ExportedFunctions()
-	plans := make(map[string]langsupport.ExecutionPlan, len(imports)+len(exports))
+	fnDefs := make(map[string]wasm.FunctionDefinition, len(md.FnImports)+len(md.FnExports))
 
-	ctx = context.WithValue(ctx, utils.MetadataContextKey, md)
-
-	for fnName, fnMeta := range md.FnExports {
+	for fnName := range md.FnExports {
 		fnDef, ok := exports[fnName]
 		if !ok {
 			return nil, fmt.Errorf("no wasm function definition found for %s", fnName)
 		}
-
-		plan, err := planner.GetPlan(ctx, fnMeta, fnDef)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get execution plan for %s: %w", fnName, err)
-		}
-		plans[fnName] = plan
+		fnDefs[fnName] = fnDef
 	}
 
 	importsMap := make(map[string]wasm.FunctionDefinition, len(imports))
@@ -68,31 +92,144 @@ func NewPlugin(ctx context.Context, cm wazero.CompiledModule, filename string, m
 		}
 	}
 
-	for importName, fnMeta := range md.FnImports {
+	for importName := range md.FnImports {
 		fnDef, ok := importsMap[importName]
 		if !ok {
 			return nil, fmt.Errorf("no wasm function definition found for %s", importName)
 		}
-
-		plan, err := planner.GetPlan(ctx, fnMeta, fnDef)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get execution plan for %s: %w", importName, err)
-		}
-		plans[importName] = plan
+		fnDefs[importName] = fnDef
 	}
 
+	// Custom types are only provided by tests, which may add them after the plugin is made.
+	customTypes, _ := ctx.Value(utils.CustomTypesContextKey).(map[string]reflect.Type)
+
 	plugin := &Plugin{
-		Id:             utils.GenerateUUIDv7(),
-		Module:         cm,
-		Metadata:       md,
-		FileName:       filename,
-		Language:       language,
-		ExecutionPlans: plans,
+		Id:          utils.GenerateUUIDv7(),
+		Module:      cm,
+		Metadata:    md,
+		FileName:    filename,
+		Language:    language,
+		planner:     language.NewPlanner(md),
+		fnDefs:      fnDefs,
+		customTypes: customTypes,
+		plans:       make(map[string]langsupport.ExecutionPlan, len(fnDefs)),
+		idle:        make(chan struct{}),
 	}
 
 	return plugin, nil
 }
 
+// GetExecutionPlan returns the execution plan of an exported or imported function of the plugin,
+// preparing it if it hasn't been prepared yet.  Imported functions are named by module and function, such as "modus_models.invokeModel".
+func (p *Plugin) GetExecutionPlan(ctx context.Context, fnName string) (langsupport.ExecutionPlan, error) {
+	p.plansMutex.Lock()
+	defer p.plansMutex.Unlock()
+
+	if plan, ok := p.plans[fnName]; ok {
+		return plan, nil
+	}
+
+	fnDef, ok := p.fnDefs[fnName]
+	if !ok {
+		return nil, fmt.Errorf("no function named %s in plugin %s", fnName, p.Name())
+	}
+
+	fnMeta, ok := p.Metadata.FnExports[fnName]
+	if !ok {
+		fnMeta = p.Metadata.FnImports[fnName]
+	}
+
+	ctx = context.WithValue(ctx, utils.MetadataContextKey, p.Metadata)
+	if p.customTypes != nil {
+		ctx = context.WithValue(ctx, utils.CustomTypesContextKey, p.customTypes)
+	}
+
+	plan, err := p.planner.GetPlan(ctx, fnMeta, fnDef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution plan for %s: %w", fnName, err)
+	}
+
+	p.plans[fnName] = plan
+	return plan, nil
+}
+
+// PrepareFunctions prepares the execution plans of the named functions ahead of their first call.
+// Names of functions that the plugin doesn't export are ignored, and "*" prepares every function.
+func (p *Plugin) PrepareFunctions(ctx context.Context, names ...string) error {
+	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
+	defer span.Finish()
+
+	if slices.Contains(names, "*") {
+		names = utils.MapKeys(p.fnDefs)
+		slices.Sort(names)
+	}
+
+	for _, name := range names {
+		if _, ok := p.fnDefs[name]; !ok {
+			continue
+		}
+		if _, err := p.GetExecutionPlan(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BeginCall records the start of a function call, and reports false if the plugin is retiring, in which case
+// the call must not use the plugin.  Each successful BeginCall must be followed by a call to EndCall.
+func (p *Plugin) BeginCall() bool {
+	p.callsMutex.Lock()
+	defer p.callsMutex.Unlock()
+
+	if p.retiring {
+		return false
+	}
+	p.calls++
+	return true
+}
+
+// EndCall records the end of a function call.
+func (p *Plugin) EndCall() {
+	p.callsMutex.Lock()
+	defer p.callsMutex.Unlock()
+
+	p.calls--
+	if p.retiring && p.calls == 0 {
+		close(p.idle)
+	}
+}
+
+// Drain stops the plugin from accepting new calls, then waits until the calls in progress have completed,
+// or until the context is done.  It returns the number of calls still in progress.
+func (p *Plugin) Drain(ctx context.Context) int {
+	p.callsMutex.Lock()
+	if !p.retiring {
+		p.retiring = true
+		if p.calls == 0 {
+			close(p.idle)
+		}
+	}
+	p.callsMutex.Unlock()
+
+	select {
+	case <-p.idle:
+	case <-ctx.Done():
+	}
+
+	p.callsMutex.Lock()
+	defer p.callsMutex.Unlock()
+	return p.calls
+}
+
+// Close releases the plugin's compiled module.  It is safe to call more than once.
+func (p *Plugin) Close(ctx context.Context) error {
+	p.closeOnce.Do(func() {
+		p.closeErr = p.Module.Close(ctx)
+	})
+	return p.closeErr
+}
+
 func (p *Plugin) NameAndVersion() (name string, version string) {
 	return p.Metadata.NameAndVersion()
 }
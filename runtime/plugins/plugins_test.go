@@ -0,0 +1,67 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tetratelabs/wazero"
+)
+
+type testModule struct {
+	wazero.CompiledModule
+	closed int
+}
+
+func (m *testModule) Close(context.Context) error {
+	m.closed++
+	return nil
+}
+
+func Test_DrainWaitsForCalls(t *testing.T) {
+	module := &testModule{}
+	p := &Plugin{Module: module, idle: make(chan struct{})}
+
+	assert.True(t, p.BeginCall())
+
+	done := make(chan int)
+	go func() {
+		done <- p.Drain(context.Background())
+	}()
+
+	// once draining, the plugin refuses new calls, while the call in progress continues
+	assert.Eventually(t, func() bool { return !p.BeginCall() }, time.Second, time.Millisecond)
+
+	p.EndCall()
+	assert.Equal(t, 0, <-done)
+	assert.Equal(t, 0, p.Drain(context.Background()))
+
+	// closing more than once closes the module once
+	assert.NoError(t, p.Close(context.Background()))
+	assert.NoError(t, p.Close(context.Background()))
+	assert.Equal(t, 1, module.closed)
+}
+
+func Test_DrainTimesOut(t *testing.T) {
+	p := &Plugin{idle: make(chan struct{})}
+
+	assert.True(t, p.BeginCall())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, 1, p.Drain(ctx))
+
+	// the call that outlived the deadline can still end
+	p.EndCall()
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/storage"
+)
+
+const promptFileExtension = ".prompt"
+
+// placeholderRegex matches a variable in a template, such as "{{name}}" or "{{ name }}".
+// Other uses of braces, such as JSON examples in a prompt, are left as they are.
+var placeholderRegex = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_.-]*)\s*\}\}`)
+
+var files = make(map[string]string)
+var filesMutex sync.RWMutex
+
+// Initialize watches the ".prompt" files stored alongside the plugin, so that they can be changed
+// without rebuilding the plugin.
+func Initialize(ctx context.Context) {
+	loadFile := func(file storage.FileInfo) error {
+		data, err := storage.GetFileContents(ctx, file.Name)
+		if err != nil {
+			logger.Err(ctx, err).Str("filename", file.Name).Msg("Failed to load prompt file.")
+			return err
+		}
+
+		setFile(file.Name, string(data))
+		logger.Info(ctx).Str("filename", file.Name).Msg("Loaded prompt file.")
+		return nil
+	}
+
+	sm := storage.NewStorageMonitor(promptFileExtension)
+	sm.Added = loadFile
+	sm.Modified = loadFile
+	sm.Removed = func(file storage.FileInfo) error {
+		filesMutex.Lock()
+		defer filesMutex.Unlock()
+		delete(files, file.Name)
+		return nil
+	}
+	sm.Start(ctx)
+}
+
+func setFile(name, contents string) {
+	filesMutex.Lock()
+	defer filesMutex.Unlock()
+	files[name] = contents
+}
+
+// getTemplate returns the named template.  Prompts declared in the manifest take precedence.
+// Otherwise, the template is read from a prompt file of the same name, such as "summarize.prompt".
+func getTemplate(name string) (string, error) {
+	fileName := name + promptFileExtension
+	if info, ok := manifestdata.GetManifest().Prompts[name]; ok {
+		if info.File == "" {
+			return info.Template, nil
+		}
+		fileName = info.File
+	}
+
+	filesMutex.RLock()
+	defer filesMutex.RUnlock()
+	if template, ok := files[fileName]; ok {
+		return template, nil
+	}
+	return "", fmt.Errorf("prompt template %s was not found", name)
+}
+
+// Render returns the named prompt template, with each of its variables replaced by the given value.
+// It is an error for the template to use a variable that is not given, so that an incomplete prompt
+// is never sent to a model.  Variables that the template doesn't use are ignored.
+func Render(ctx context.Context, name string, variables map[string]string) (string, error) {
+	template, err := getTemplate(name)
+	if err != nil {
+		return "", err
+	}
+
+	return render(template, variables, name)
+}
+
+func render(template string, variables map[string]string, name string) (string, error) {
+	var missing []string
+	result := placeholderRegex.ReplaceAllStringFunc(template, func(match string) string {
+		key := placeholderRegex.FindStringSubmatch(match)[1]
+		value, ok := variables[key]
+		if !ok {
+			if !slices.Contains(missing, key) {
+				missing = append(missing, key)
+			}
+			return match
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("prompt template %s is missing variables: %s", name, strings.Join(missing, ", "))
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package prompts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	md := manifestdata.GetManifest()
+	prev := md.Prompts
+	md.Prompts = map[string]manifest.PromptInfo{
+		"summarize": {Name: "summarize", Template: "Summarize in {{ language }}:\n\n{{text}}"},
+		"classify":  {Name: "classify", File: "labels.prompt"},
+	}
+	t.Cleanup(func() { md.Prompts = prev })
+
+	setFile("labels.prompt", "Classify {{text}} as one of {{labels}}.")
+	setFile("greet.prompt", "Hello, {{name}}!")
+	t.Cleanup(func() {
+		delete(files, "labels.prompt")
+		delete(files, "greet.prompt")
+	})
+
+	ctx := context.Background()
+
+	result, err := Render(ctx, "summarize", map[string]string{"language": "French", "text": "Bonjour", "unused": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize in French:\n\nBonjour", result)
+
+	result, err = Render(ctx, "classify", map[string]string{"text": "a cat", "labels": "animal, plant"})
+	require.NoError(t, err)
+	assert.Equal(t, "Classify a cat as one of animal, plant.", result)
+
+	result, err = Render(ctx, "greet", map[string]string{"name": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, Ada!", result)
+
+	_, err = Render(ctx, "missing", nil)
+	assert.ErrorContains(t, err, "was not found")
+}
+
+func TestRenderMissingVariables(t *testing.T) {
+	_, err := render("{{a}} {{b}} {{a}} {{c}}", map[string]string{"b": "x"}, "test")
+	assert.EqualError(t, err, "prompt template test is missing variables: a, c")
+}
+
+func TestRenderLeavesOtherBraces(t *testing.T) {
+	result, err := render(`Respond with {"name": "{{name}}"} and {{ not a variable }}.`, map[string]string{"name": "Ada"}, "test")
+	require.NoError(t, err)
+	assert.Equal(t, `Respond with {"name": "Ada"} and {{ not a variable }}.`, result)
+}
+
+func TestRenderDoesNotExpandValues(t *testing.T) {
+	result, err := render("{{a}}", map[string]string{"a": "{{b}}"}, "test")
+	require.NoError(t, err)
+	assert.Equal(t, "{{b}}", result)
+}
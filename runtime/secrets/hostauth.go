@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package secrets
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/google"
+)
+
+const googleCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+const azureCognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+
+// azureTokenUrlFormat is the format of the Azure AD token endpoint for a tenant.  Tests replace it with a local server.
+var azureTokenUrlFormat = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+
+type cachedTokenSource struct {
+	key string
+	ts  oauth2.TokenSource
+}
+
+// Token sources are kept per host, so that access tokens are reused until they expire.
+var tokenSources = make(map[string]*cachedTokenSource)
+var tokenSourcesMutex sync.Mutex
+
+// applyHostAuth adds an access token to the request, if the host is configured to authenticate with one.
+func applyHostAuth(ctx context.Context, host *manifest.HTTPHostInfo, req *http.Request) error {
+	if host.Auth == nil {
+		return nil
+	}
+
+	ts, err := getTokenSource(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate to host %s: %w", host.Name, err)
+	}
+
+	token, err := ts.Token()
+	if err != nil {
+		return fmt.Errorf("failed to get access token for host %s: %w", host.Name, err)
+	}
+
+	token.SetAuthHeader(req)
+	return nil
+}
+
+func getTokenSource(ctx context.Context, host *manifest.HTTPHostInfo) (oauth2.TokenSource, error) {
+	var credentials, tenantId, clientId string
+	for _, v := range []struct {
+		s   string
+		out *string
+	}{
+		{host.Auth.Credentials, &credentials},
+		{host.Auth.TenantId, &tenantId},
+		{host.Auth.ClientId, &clientId},
+	} {
+		s, err := ApplyHostSecretsToString(ctx, host, v.s)
+		if err != nil {
+			return nil, err
+		}
+		*v.out = s
+	}
+
+	// The token source is replaced if the credentials or scopes change.
+	scopes := host.Auth.Scopes
+	sum := sha256.Sum256([]byte(host.Auth.Type + "|" + strings.Join(scopes, " ") + "|" + tenantId + "|" + clientId + "|" + credentials))
+	key := string(sum[:])
+
+	tokenSourcesMutex.Lock()
+	defer tokenSourcesMutex.Unlock()
+
+	if c, ok := tokenSources[host.Name]; ok && c.key == key {
+		return c.ts, nil
+	}
+
+	var ts oauth2.TokenSource
+	switch host.Auth.Type {
+	case manifest.HttpAuthGoogleServiceAccount:
+		if len(scopes) == 0 {
+			scopes = []string{googleCloudPlatformScope}
+		}
+		// Tokens are fetched in the background of any one request, so they shouldn't use the request's context.
+		creds, err := google.CredentialsFromJSON(context.Background(), []byte(credentials), scopes...)
+		if err != nil {
+			return nil, err
+		}
+		ts = creds.TokenSource
+	case manifest.HttpAuthAzureAD:
+		if tenantId == "" || clientId == "" {
+			return nil, fmt.Errorf("a tenant id and client id are required for Azure AD credentials")
+		}
+		if len(scopes) == 0 {
+			scopes = []string{azureCognitiveServicesScope}
+		}
+		cfg := &clientcredentials.Config{
+			ClientID:     clientId,
+			ClientSecret: credentials,
+			TokenURL:     fmt.Sprintf(azureTokenUrlFormat, url.PathEscape(tenantId)),
+			Scopes:       scopes,
+		}
+		ts = cfg.TokenSource(context.Background())
+	default:
+		return nil, fmt.Errorf("unsupported auth type: %s", host.Auth.Type)
+	}
+
+	tokenSources[host.Name] = &cachedTokenSource{key, ts}
+	return ts, nil
+}
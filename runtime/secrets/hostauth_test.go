@@ -0,0 +1,136 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hypermodeinc/modus/lib/manifest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyHostAuth_GoogleServiceAccount(t *testing.T) {
+	var tokenRequests atomic.Int32
+	tsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"test-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tsrv.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	creds, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "test@my-project.iam.gserviceaccount.com",
+		"private_key":  string(keyPem),
+		"token_uri":    tsrv.URL,
+	})
+	require.NoError(t, err)
+
+	provider = &localSecretsProvider{}
+	t.Setenv("MODUS_VERTEX_AI_SERVICE_ACCOUNT_KEY", string(creds))
+
+	host := &manifest.HTTPHostInfo{
+		Name:    "vertex-ai",
+		BaseURL: "https://us-central1-aiplatform.googleapis.com/",
+		Auth: &manifest.HttpAuthInfo{
+			Type:        manifest.HttpAuthGoogleServiceAccount,
+			Credentials: "{{SERVICE_ACCOUNT_KEY}}",
+		},
+	}
+
+	// the access token is fetched once and reused
+	for range 2 {
+		req, err := http.NewRequest(http.MethodPost, host.BaseURL, nil)
+		require.NoError(t, err)
+		require.NoError(t, ApplyHostSecretsToHttpRequest(context.Background(), host, req))
+		assert.Equal(t, "Bearer test-token", req.Header.Get("Authorization"))
+	}
+	assert.Equal(t, int32(1), tokenRequests.Load())
+}
+
+func TestApplyHostAuth_Errors(t *testing.T) {
+	provider = &localSecretsProvider{}
+	ctx := context.Background()
+
+	host := &manifest.HTTPHostInfo{
+		Name: "bad-auth",
+		Auth: &manifest.HttpAuthInfo{Type: "unknown", Credentials: "{}"},
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	assert.Error(t, applyHostAuth(ctx, host, req))
+
+	host.Auth = &manifest.HttpAuthInfo{Type: manifest.HttpAuthGoogleServiceAccount, Credentials: "not json"}
+	assert.Error(t, applyHostAuth(ctx, host, req))
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
+
+func TestApplyHostAuth_AzureAD(t *testing.T) {
+	tsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/my-tenant/token", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, azureCognitiveServicesScope, r.PostForm.Get("scope"))
+
+		id, secret, _ := r.BasicAuth()
+		if id == "" {
+			id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		assert.Equal(t, "my-client", id)
+		assert.Equal(t, "my-secret", secret)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"azure-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tsrv.Close()
+
+	defer func(format string) { azureTokenUrlFormat = format }(azureTokenUrlFormat)
+	azureTokenUrlFormat = tsrv.URL + "/%s/token"
+
+	provider = &localSecretsProvider{}
+	t.Setenv("MODUS_AZURE_OPENAI_CLIENT_SECRET", "my-secret")
+
+	host := &manifest.HTTPHostInfo{
+		Name:    "azure-openai",
+		BaseURL: "https://my-resource.openai.azure.com/",
+		Auth: &manifest.HttpAuthInfo{
+			Type:        manifest.HttpAuthAzureAD,
+			TenantId:    "my-tenant",
+			ClientId:    "my-client",
+			Credentials: "{{CLIENT_SECRET}}",
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, host.BaseURL, nil)
+	require.NoError(t, err)
+	require.NoError(t, ApplyHostSecretsToHttpRequest(context.Background(), host, req))
+	assert.Equal(t, "Bearer azure-token", req.Header.Get("Authorization"))
+
+	// the tenant and client ids are required
+	host = &manifest.HTTPHostInfo{
+		Name: "azure-incomplete",
+		Auth: &manifest.HttpAuthInfo{Type: manifest.HttpAuthAzureAD, Credentials: "secret"},
+	}
+	assert.Error(t, applyHostAuth(context.Background(), host, req))
+}
@@ -91,7 +91,8 @@ func ApplyHostSecretsToHttpRequest(ctx context.Context, host *manifest.HTTPHostI
 		req.Header.Add(k, applySecretsToString(ctx, secrets, v))
 	}
 
-	return nil
+	// apply an access token, if the host uses one
+	return applyHostAuth(ctx, host, req)
 }
 
 func ApplyAuthToLocalModelRequest(ctx context.Context, host manifest.HostInfo, req *http.Request) error {
@@ -14,15 +14,28 @@ import (
 
 	"github.com/hypermodeinc/modus/runtime/aws"
 	"github.com/hypermodeinc/modus/runtime/collections"
+	"github.com/hypermodeinc/modus/runtime/config"
 	"github.com/hypermodeinc/modus/runtime/db"
+	"github.com/hypermodeinc/modus/runtime/devloop"
 	"github.com/hypermodeinc/modus/runtime/dgraphclient"
 	"github.com/hypermodeinc/modus/runtime/graphql"
+	"github.com/hypermodeinc/modus/runtime/guards"
 	"github.com/hypermodeinc/modus/runtime/hostfunctions"
+	"github.com/hypermodeinc/modus/runtime/httpclient"
+	"github.com/hypermodeinc/modus/runtime/inputlimits"
+	"github.com/hypermodeinc/modus/runtime/jobqueue"
+	"github.com/hypermodeinc/modus/runtime/lifecycle"
 	"github.com/hypermodeinc/modus/runtime/logger"
 	"github.com/hypermodeinc/modus/runtime/manifestdata"
+	"github.com/hypermodeinc/modus/runtime/middleware"
+	"github.com/hypermodeinc/modus/runtime/natsclient"
+	"github.com/hypermodeinc/modus/runtime/outputtransforms"
 	"github.com/hypermodeinc/modus/runtime/pluginmanager"
+	"github.com/hypermodeinc/modus/runtime/prompts"
 	"github.com/hypermodeinc/modus/runtime/secrets"
+	"github.com/hypermodeinc/modus/runtime/signing"
 	"github.com/hypermodeinc/modus/runtime/sqlclient"
+	"github.com/hypermodeinc/modus/runtime/standby"
 	"github.com/hypermodeinc/modus/runtime/storage"
 	"github.com/hypermodeinc/modus/runtime/utils"
 	"github.com/hypermodeinc/modus/runtime/wasmhost"
@@ -46,16 +59,34 @@ func Start() context.Context {
 	// If you need to change the order or add new services, be sure to test thoroughly.
 	// Generally, new services should be added to the end of the list, unless there is a specific reason to do otherwise.
 
+	// The standby role must be known before any workers start,
+	// and the lifecycle must be tracked before any functions are loaded.
+	standby.Initialize(ctx)
+	lifecycle.Initialize()
+
+	// The clients of the manifest hosts register manifest loaded callbacks, so they must be initialized
+	// before the manifest file is monitored below, or they would miss the initial load.  NATS subscriptions
+	// look up their functions when each message arrives, so they don't need the plugins to be loaded first.
 	sqlclient.Initialize()
 	dgraphclient.Initialize()
+	natsclient.Initialize(ctx)
+	guards.Initialize()
+	outputtransforms.Initialize()
+	inputlimits.Initialize()
+	middleware.InitializeRateLimits()
+	httpclient.Initialize()
 	aws.Initialize(ctx)
 	secrets.Initialize(ctx)
 	storage.Initialize(ctx)
 	db.Initialize(ctx)
 	collections.Initialize(ctx)
+	devloop.Initialize(ctx)
 	manifestdata.MonitorManifestFile(ctx)
+	signing.Initialize(ctx)
 	pluginmanager.Initialize(ctx)
 	graphql.Initialize()
+	jobqueue.Initialize(ctx)
+	prompts.Initialize(ctx)
 
 	return ctx
 }
@@ -63,7 +94,13 @@ func Start() context.Context {
 // Stops any services that need to be stopped when the runtime stops.
 func Stop(ctx context.Context) {
 
-	// Stop the wasm host first
+	// Let function executions in progress complete, such as those of background jobs, then stop the wasm host first
+	drainCtx, cancel := context.WithTimeout(ctx, config.DrainTimeout)
+	if n := lifecycle.Drain(drainCtx); n > 0 {
+		logger.Warn(ctx).Int64("executions", n).Msg("Function executions were still in progress when the runtime stopped.")
+	}
+	cancel()
+	pluginmanager.Shutdown(ctx)
 	wasmhost.GetWasmHost(ctx).Close(ctx)
 
 	// Stop the rest of the background services.
@@ -71,9 +108,12 @@ func Stop(ctx context.Context) {
 	// If you need to change the order or add new services, be sure to test thoroughly.
 	// Unlike start, these should each block until they are fully stopped.
 
+	jobqueue.Shutdown(ctx)
 	collections.Shutdown(ctx)
 	sqlclient.ShutdownPGPools()
 	dgraphclient.ShutdownConns()
+	natsclient.ShutdownConns()
+	httpclient.ShutdownCacheConns()
 	logger.Close()
 	db.Stop(ctx)
 }
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package signing verifies the signatures of plugins before they are loaded, so that a runtime only runs plugins
+// that were signed by one of the keys it is configured with.
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/storage"
+)
+
+// SignatureExtension is appended to the name of a plugin file to get the name of the file of its signature,
+// such as my-plugin.wasm.sig.
+const SignatureExtension = ".sig"
+
+var ErrUnsigned = errors.New("the plugin is not signed")
+var ErrInvalidSignature = errors.New("the plugin's signature does not match any of the trusted keys")
+
+var keys []crypto.PublicKey
+
+// Initialize loads the public keys that plugins must be signed with, if any are configured.
+func Initialize(ctx context.Context) {
+	if config.PluginSigningKeys == "" {
+		return
+	}
+
+	for _, path := range strings.Split(config.PluginSigningKeys, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.Fatal(ctx).Err(err).Str("path", path).Msg("Failed to read plugin signing key.  Exiting.")
+		}
+		key, err := parsePublicKey(data)
+		if err != nil {
+			logger.Fatal(ctx).Err(err).Str("path", path).Msg("Invalid plugin signing key.  Exiting.")
+		}
+		keys = append(keys, key)
+	}
+
+	logger.Info(ctx).Int("keys", len(keys)).Msg("Plugins must be signed by a trusted key.")
+}
+
+// IsEnabled reports whether plugins must be signed.
+func IsEnabled() bool {
+	return len(keys) > 0
+}
+
+// VerifyPlugin checks the signature of a plugin's content, which is read from the file of the same name with
+// the signature extension.  It returns nil if signatures aren't required.
+func VerifyPlugin(ctx context.Context, filename string, content []byte) error {
+	if !IsEnabled() {
+		return nil
+	}
+
+	sig, err := storage.GetFileContents(ctx, filename+SignatureExtension)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnsigned, err)
+	}
+
+	if !verify(keys, content, decodeSignature(sig)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// parsePublicKey parses a PEM-encoded public key, such as one generated by cosign or openssl.
+// Ed25519 and ECDSA keys are supported.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the key is not PEM-encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// decodeSignature returns the bytes of a signature, which may be base64-encoded, as cosign writes it,
+// or raw, as openssl writes it.
+func decodeSignature(data []byte) []byte {
+	if sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data))); err == nil {
+		return sig
+	}
+	return data
+}
+
+// verify reports whether a signature of the content was made by any of the keys.  Ed25519 signatures are of the
+// content itself, and ECDSA signatures, such as those of cosign sign-blob, are of its SHA-256 digest.
+func verify(keys []crypto.PublicKey, content, sig []byte) bool {
+	var digest []byte
+	for _, key := range keys {
+		switch k := key.(type) {
+		case ed25519.PublicKey:
+			if ed25519.Verify(k, content, sig) {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			if digest == nil {
+				sum := sha256.Sum256(content)
+				digest = sum[:]
+			}
+			if ecdsa.VerifyASN1(k, digest, sig) {
+				return true
+			}
+		}
+	}
+	return false
+}
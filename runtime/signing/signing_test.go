@@ -0,0 +1,87 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePublicKey(t *testing.T, key crypto.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func Test_ParsePublicKey(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := parsePublicKey(encodePublicKey(t, edKey))
+	require.NoError(t, err)
+	assert.Equal(t, edKey, key)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	key, err = parsePublicKey(encodePublicKey(t, &ecKey.PublicKey))
+	require.NoError(t, err)
+	assert.True(t, ecKey.PublicKey.Equal(key))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = parsePublicKey(encodePublicKey(t, &rsaKey.PublicKey))
+	assert.Error(t, err)
+
+	_, err = parsePublicKey([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func Test_Verify_Ed25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	content := []byte("plugin content")
+	sig := ed25519.Sign(priv, content)
+
+	assert.True(t, verify([]crypto.PublicKey{otherPub, pub}, content, sig))
+	assert.False(t, verify([]crypto.PublicKey{otherPub}, content, sig))
+	assert.False(t, verify([]crypto.PublicKey{pub}, []byte("tampered content"), sig))
+}
+
+func Test_Verify_ECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	content := []byte("plugin content")
+	digest := sha256.Sum256(content)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	// Signatures written by cosign are base64-encoded.
+	encoded := []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+
+	keys := []crypto.PublicKey{&key.PublicKey}
+	assert.True(t, verify(keys, content, decodeSignature(encoded)))
+	assert.True(t, verify(keys, content, decodeSignature(sig)))
+	assert.False(t, verify(keys, []byte("tampered content"), decodeSignature(encoded)))
+}
@@ -22,14 +22,30 @@ import (
 
 // ShutdownPGPools shuts down all the PostgreSQL connection pools.
 func ShutdownPGPools() {
+	shutdownPGPools(func(string) bool { return true })
+}
+
+// shutdownPGPools shuts down the PostgreSQL connection pools of the hosts that are stale.
+func shutdownPGPools(stale func(hostName string) bool) {
 	dsr.Lock()
 	defer dsr.Unlock()
 
-	for _, ds := range dsr.pgCache {
-		ds.pool.Close()
+	for name, ds := range dsr.pgCache {
+		if stale(name) {
+			ds.pool.Close()
+			delete(dsr.pgCache, name)
+		}
 	}
+}
 
-	clear(dsr.pgCache)
+// GetPostgresPool returns the connection pool of the PostgreSQL host in the manifest, creating it if needed.
+// The pool is shared with the database host functions, and is closed when the host changes in the manifest.
+func GetPostgresPool(ctx context.Context, hostName string) (*pgxpool.Pool, error) {
+	ds, err := dsr.getPGPool(ctx, hostName)
+	if err != nil {
+		return nil, err
+	}
+	return ds.pool, nil
 }
 
 func (r *dsRegistry) getPGPool(ctx context.Context, dsname string) (*postgresqlDS, error) {
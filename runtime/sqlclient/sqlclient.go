@@ -19,8 +19,9 @@ import (
 )
 
 func Initialize() {
+	// Only the pools of hosts that changed or were removed are closed when the manifest is reloaded.
 	manifestdata.RegisterManifestLoadedCallback(func(ctx context.Context) error {
-		ShutdownPGPools()
+		shutdownPGPools(manifestdata.GetManifestDiff(ctx).IsHostStale)
 		return nil
 	})
 }
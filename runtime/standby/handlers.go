@@ -0,0 +1,41 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package standby
+
+import (
+	"net/http"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// HandleRequireActive rejects requests while the runtime is a standby,
+// so that a standby never serves requests alongside the active runtime.
+func HandleRequireActive(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsStandby() {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "This runtime is a standby, and is not serving requests.", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PromoteHandler promotes a standby runtime to active (POST).
+// Load balancers or operators can use it to fail over without waiting for health checks to fail.
+func PromoteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	promoted := Promote(r.Context(), "promoted through the admin API")
+	utils.WriteJsonResponse(w, map[string]any{"status": "active", "promoted": promoted})
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package standby implements the warm standby mode of a failover pair.
+//
+// A standby runtime loads plugins and keeps its collections synced from the database like any other runtime,
+// but it does not serve function requests or run queued jobs.  It monitors the health of the active runtime,
+// and promotes itself when the active runtime stops responding.  It can also be promoted explicitly,
+// by an external load balancer or an operator, through the admin API.
+package standby
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+)
+
+// checkInterval is how often the standby checks the health of the active runtime.
+const checkInterval = 2 * time.Second
+
+// checkTimeout is how long the standby waits for a health check response before counting it as a failure.
+const checkTimeout = time.Second
+
+var standby atomic.Bool
+
+var promotedCallbacks []func(ctx context.Context)
+var callbacksMutex sync.Mutex
+
+// Initialize puts the runtime in standby if it was started as the standby of another runtime,
+// and starts monitoring the health of that runtime.
+func Initialize(ctx context.Context) {
+	if config.StandbyOf == "" {
+		return
+	}
+
+	standby.Store(true)
+	logger.Info(ctx).
+		Str("active_url", config.StandbyOf).
+		Int("failover_threshold", config.FailoverThreshold).
+		Msg("Running as a warm standby.  Requests will not be served until this runtime is promoted.")
+
+	go monitor(ctx, config.StandbyOf, checkInterval, config.FailoverThreshold)
+}
+
+// IsStandby reports whether the runtime is currently a standby.
+func IsStandby() bool {
+	return standby.Load()
+}
+
+// RegisterPromotedCallback registers a function to call when the runtime is promoted from standby to active.
+func RegisterPromotedCallback(callback func(ctx context.Context)) {
+	callbacksMutex.Lock()
+	defer callbacksMutex.Unlock()
+	promotedCallbacks = append(promotedCallbacks, callback)
+}
+
+// Promote makes the runtime active, if it is a standby.  It returns false if the runtime was already active.
+func Promote(ctx context.Context, reason string) bool {
+	if !standby.CompareAndSwap(true, false) {
+		return false
+	}
+
+	logger.Warn(ctx).Str("reason", reason).Msg("Standby runtime promoted to active.")
+
+	callbacksMutex.Lock()
+	callbacks := promotedCallbacks
+	callbacksMutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback(ctx)
+	}
+
+	return true
+}
+
+// monitor checks the health endpoint of the active runtime at the given interval,
+// and promotes this runtime after the given number of consecutive failed checks.
+func monitor(ctx context.Context, activeUrl string, interval time.Duration, threshold int) {
+	healthUrl := strings.TrimSuffix(activeUrl, "/") + "/health"
+	client := &http.Client{Timeout: checkTimeout}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for IsStandby() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if checkHealth(ctx, client, healthUrl) {
+			if failures > 0 {
+				logger.Info(ctx).Str("active_url", activeUrl).Msg("Active runtime has recovered.")
+			}
+			failures = 0
+			continue
+		}
+
+		failures++
+		logger.Warn(ctx).
+			Str("active_url", activeUrl).
+			Int("failures", failures).
+			Msg("Active runtime health check failed.")
+
+		if failures >= threshold {
+			Promote(ctx, "active runtime failed health checks")
+			return
+		}
+	}
+}
+
+func checkHealth(ctx context.Context, client *http.Client, url string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
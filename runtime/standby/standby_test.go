@@ -0,0 +1,88 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package standby
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Monitor_PromotesAfterFailedChecks(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	tsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer tsrv.Close()
+
+	var promotions atomic.Int32
+	RegisterPromotedCallback(func(ctx context.Context) { promotions.Add(1) })
+	defer func() { promotedCallbacks = nil }()
+
+	standby.Store(true)
+	defer standby.Store(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		monitor(ctx, tsrv.URL+"/", 10*time.Millisecond, 3)
+		close(done)
+	}()
+
+	// the standby stays in standby while the active runtime is healthy
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, IsStandby())
+
+	healthy.Store(false)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("standby was not promoted")
+	}
+
+	assert.False(t, IsStandby())
+	assert.Equal(t, int32(1), promotions.Load())
+
+	// promoting an active runtime has no effect
+	assert.False(t, Promote(ctx, "test"))
+	assert.Equal(t, int32(1), promotions.Load())
+}
+
+func Test_HandleRequireActive(t *testing.T) {
+	handler := HandleRequireActive(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	standby.Store(true)
+	defer standby.Store(false)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = httptest.NewRecorder()
+	PromoteHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/promote", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"active","promoted":true}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package storage
+
+import (
+	"net/http"
+	"sync"
+)
+
+// refreshRequests are the channels of the storage monitors, which are signaled when files are known to have changed,
+// so that the monitors list the files right away, rather than on their next refresh interval.
+var refreshRequests []chan struct{}
+var refreshRequestsMutex sync.Mutex
+
+func subscribeToRefreshRequests() <-chan struct{} {
+	refreshRequestsMutex.Lock()
+	defer refreshRequestsMutex.Unlock()
+
+	ch := make(chan struct{}, 1)
+	refreshRequests = append(refreshRequests, ch)
+	return ch
+}
+
+// RequestRefresh makes every storage monitor check for changes to its files right away.
+// Requests that arrive while a check is already pending are combined with it.
+func RequestRefresh() {
+	refreshRequestsMutex.Lock()
+	defer refreshRequestsMutex.Unlock()
+
+	for _, ch := range refreshRequests {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// RefreshHandler is a webhook that makes the runtime pick up changes to its files right away,
+// such as when a deployment pipeline has uploaded a new plugin.
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	RequestRefresh()
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,437 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+
+	"github.com/tidwall/gjson"
+)
+
+// ociTitleAnnotation names the file of each layer of an OCI artifact, as set by tools such as oras push.
+const ociTitleAnnotation = "org.opencontainers.image.title"
+
+const ociManifestMediaTypes = "application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json"
+
+// ociReference identifies an artifact in an OCI registry, such as ghcr.io/my-org/my-app:v1 or
+// ghcr.io/my-org/my-app@sha256:....  When it has a digest, the artifact is pinned to that exact content.
+type ociReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+func parseOciReference(ref string) (ociReference, error) {
+	var r ociReference
+
+	if i := strings.Index(ref, "@"); i >= 0 {
+		r.digest = ref[i+1:]
+		ref = ref[:i]
+		if !strings.HasPrefix(r.digest, "sha256:") || len(r.digest) != len("sha256:")+64 {
+			return r, fmt.Errorf("unsupported digest %q", r.digest)
+		}
+	}
+
+	// The registry is the first part of the reference, when it looks like a host name.
+	if i := strings.Index(ref, "/"); i >= 0 && (strings.ContainsAny(ref[:i], ".:") || ref[:i] == "localhost") {
+		r.registry = ref[:i]
+		ref = ref[i+1:]
+	} else {
+		r.registry = "registry-1.docker.io"
+		if !strings.Contains(ref, "/") {
+			ref = "library/" + ref
+		}
+	}
+
+	if i := strings.LastIndex(ref, ":"); i >= 0 && !strings.Contains(ref[i:], "/") {
+		r.tag = ref[i+1:]
+		ref = ref[:i]
+	}
+	if r.tag == "" && r.digest == "" {
+		r.tag = "latest"
+	}
+
+	r.repository = ref
+	if r.repository == "" {
+		return r, errors.New("the reference has no repository")
+	}
+	return r, nil
+}
+
+func (r ociReference) String() string {
+	s := r.registry + "/" + r.repository
+	if r.tag != "" {
+		s += ":" + r.tag
+	}
+	if r.digest != "" {
+		s += "@" + r.digest
+	}
+	return s
+}
+
+func (r ociReference) baseUrl() string {
+	scheme := "https"
+	if host, _, _ := strings.Cut(r.registry, ":"); host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	return scheme + "://" + r.registry + "/v2/" + r.repository
+}
+
+// ociLayer is a file of an OCI artifact.
+type ociLayer struct {
+	digest string
+}
+
+// ociStorageProvider reads the files of an OCI artifact, such as one pushed with oras push, instead of a directory.
+// Unless the reference is pinned to a digest, the tag is resolved again on each refresh, so that pushing a new
+// version of the artifact to the same tag is picked up.
+type ociStorageProvider struct {
+	ref    ociReference
+	client *http.Client
+
+	mu       sync.Mutex
+	token    string
+	digest   string
+	layers   map[string]ociLayer
+	username string
+	password string
+}
+
+func (stg *ociStorageProvider) initialize(ctx context.Context) {
+	ref, err := parseOciReference(config.OciReference)
+	if err != nil {
+		logger.Fatal(ctx).Err(err).Str("reference", config.OciReference).Msg("Invalid OCI reference.  Exiting.")
+	}
+
+	stg.ref = ref
+	stg.client = &http.Client{}
+	stg.username, stg.password = getOciCredentials(ref.registry)
+
+	logger.Info(ctx).
+		Str("reference", ref.String()).
+		Bool("pinned", ref.digest != "").
+		Msg("Using OCI registry for storage.")
+}
+
+// getOciCredentials returns the credentials for a registry, from the MODUS_OCI_USERNAME and MODUS_OCI_PASSWORD
+// environment variables, or else from the Docker configuration file written by docker login.
+func getOciCredentials(registry string) (string, string) {
+	if username := os.Getenv("MODUS_OCI_USERNAME"); username != "" {
+		return username, os.Getenv("MODUS_OCI_PASSWORD")
+	}
+
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return "", ""
+	}
+
+	hosts := []string{registry, "https://" + registry}
+	if registry == "registry-1.docker.io" {
+		hosts = append(hosts, "https://index.docker.io/v1/", "docker.io")
+	}
+	for _, host := range hosts {
+		auth := gjson.GetBytes(data, "auths."+gjson.Escape(host)+".auth").String()
+		if auth == "" {
+			continue
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(auth); err == nil {
+			username, password, _ := strings.Cut(string(decoded), ":")
+			return username, password
+		}
+	}
+	return "", ""
+}
+
+func (stg *ociStorageProvider) listFiles(ctx context.Context, extension string) ([]FileInfo, error) {
+	layers, err := stg.getLayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in OCI artifact: %w", err)
+	}
+
+	var files = make([]FileInfo, 0, len(layers))
+	for name, layer := range layers {
+		if strings.HasSuffix(name, extension) {
+			files = append(files, FileInfo{
+				Name: name,
+				Hash: layer.digest,
+			})
+		}
+	}
+	return files, nil
+}
+
+func (stg *ociStorageProvider) getFileContents(ctx context.Context, name string) ([]byte, error) {
+	stg.mu.Lock()
+	layer, ok := stg.layers[name]
+	stg.mu.Unlock()
+	if !ok {
+		layers, err := stg.getLayers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file %s from OCI artifact: %w", name, err)
+		}
+		if layer, ok = layers[name]; !ok {
+			return nil, fmt.Errorf("file %s not found in OCI artifact %s", name, stg.ref)
+		}
+	}
+
+	content, err := stg.fetch(ctx, http.MethodGet, "/blobs/"+layer.digest, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file %s from OCI artifact: %w", name, err)
+	}
+	if err := verifyDigest(content.body, layer.digest); err != nil {
+		return nil, fmt.Errorf("file %s of OCI artifact: %w", name, err)
+	}
+	return content.body, nil
+}
+
+// getLayers returns the files of the artifact.  The manifest is only fetched again when the tag points to a new digest.
+func (stg *ociStorageProvider) getLayers(ctx context.Context) (map[string]ociLayer, error) {
+	stg.mu.Lock()
+	digest, layers := stg.digest, stg.layers
+	stg.mu.Unlock()
+
+	ref := stg.ref.digest
+	if ref == "" {
+		ref = stg.ref.tag
+
+		// A HEAD request resolves the tag without counting towards the pull limits of registries such as Docker Hub.
+		if head, err := stg.fetch(ctx, http.MethodHead, "/manifests/"+ref, ociManifestMediaTypes); err == nil {
+			if d := head.header.Get("Docker-Content-Digest"); d != "" && d == digest {
+				return layers, nil
+			}
+		}
+	} else if layers != nil {
+		return layers, nil
+	}
+
+	resp, err := stg.fetch(ctx, http.MethodGet, "/manifests/"+ref, ociManifestMediaTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify pinned content, which mustn't change.  The digest of a tag is recorded, so that its changes are seen.
+	sum := sha256.Sum256(resp.body)
+	newDigest := "sha256:" + hex.EncodeToString(sum[:])
+	if stg.ref.digest != "" && newDigest != stg.ref.digest {
+		return nil, fmt.Errorf("the manifest of %s does not match its digest", stg.ref)
+	}
+
+	layers, err = parseOciManifest(resp.body)
+	if err != nil {
+		return nil, err
+	}
+
+	stg.mu.Lock()
+	changed := stg.digest != newDigest
+	stg.digest, stg.layers = newDigest, layers
+	stg.mu.Unlock()
+
+	if changed {
+		logger.Info(ctx).
+			Str("reference", stg.ref.String()).
+			Str("digest", newDigest).
+			Msg("Resolved OCI artifact.")
+	}
+	return layers, nil
+}
+
+// parseOciManifest returns the files of an OCI image manifest, named by their title annotation.
+func parseOciManifest(body []byte) (map[string]ociLayer, error) {
+	var manifest struct {
+		Layers []struct {
+			Digest      string            `json:"digest"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid OCI manifest: %w", err)
+	}
+
+	layers := make(map[string]ociLayer, len(manifest.Layers))
+	for _, l := range manifest.Layers {
+		// Files are stored flat, as they are in a storage directory.
+		if name := filepath.Base(l.Annotations[ociTitleAnnotation]); name != "" && name != "." && name != "/" {
+			layers[name] = ociLayer{digest: l.Digest}
+		}
+	}
+	return layers, nil
+}
+
+func verifyDigest(content []byte, digest string) error {
+	algorithm, expected, _ := strings.Cut(digest, ":")
+	if algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest %q", digest)
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != expected {
+		return fmt.Errorf("the content does not match its digest %s", digest)
+	}
+	return nil
+}
+
+type ociResponse struct {
+	header http.Header
+	body   []byte
+}
+
+// fetch makes a request to the registry, and authenticates when the registry asks for it, either with basic
+// authentication or with a bearer token obtained from the registry's token service.
+func (stg *ociStorageProvider) fetch(ctx context.Context, method, path, accept string) (*ociResponse, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, stg.ref.baseUrl()+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		stg.mu.Lock()
+		token := stg.token
+		stg.mu.Unlock()
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else if stg.username != "" {
+			req.SetBasicAuth(stg.username, stg.password)
+		}
+
+		resp, err := stg.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			if err := stg.authenticate(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		return &ociResponse{resp.Header, body}, nil
+	}
+}
+
+func (stg *ociStorageProvider) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		// Basic authentication is already sent with each request when credentials are configured.
+		if stg.username == "" {
+			return errors.New("the registry requires credentials")
+		}
+		return errors.New("the registry refused the credentials")
+	}
+
+	p := parseAuthParams(params)
+	tokenUrl, err := url.Parse(p["realm"])
+	if err != nil || p["realm"] == "" {
+		return fmt.Errorf("invalid authentication challenge %q", challenge)
+	}
+	q := tokenUrl.Query()
+	if p["service"] != "" {
+		q.Set("service", p["service"])
+	}
+	scope := p["scope"]
+	if scope == "" {
+		scope = "repository:" + stg.ref.repository + ":pull"
+	}
+	q.Set("scope", scope)
+	tokenUrl.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenUrl.String(), nil)
+	if err != nil {
+		return err
+	}
+	if stg.username != "" {
+		req.SetBasicAuth(stg.username, stg.password)
+	}
+
+	resp, err := stg.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get a registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to get a registry token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get a registry token: %s", resp.Status)
+	}
+
+	token := gjson.GetBytes(body, "token").String()
+	if token == "" {
+		token = gjson.GetBytes(body, "access_token").String()
+	}
+	if token == "" {
+		return errors.New("the registry returned no token")
+	}
+
+	stg.mu.Lock()
+	stg.token = token
+	stg.mu.Unlock()
+	return nil
+}
+
+// parseAuthParams parses the parameters of a WWW-Authenticate challenge, such as realm="...",service="...".
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		key, rest, ok := strings.Cut(strings.TrimLeft(s, " ,"), "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+		s = rest
+	}
+	return params
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseOciReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		ref      string
+		expected ociReference
+	}{
+		{"ghcr.io/my-org/my-app:v1", ociReference{"ghcr.io", "my-org/my-app", "v1", ""}},
+		{"ghcr.io/my-org/my-app", ociReference{"ghcr.io", "my-org/my-app", "latest", ""}},
+		{"ghcr.io/my-org/my-app@" + digest, ociReference{"ghcr.io", "my-org/my-app", "", digest}},
+		{"ghcr.io/my-org/my-app:v1@" + digest, ociReference{"ghcr.io", "my-org/my-app", "v1", digest}},
+		{"localhost:5000/my-app:v1", ociReference{"localhost:5000", "my-app", "v1", ""}},
+		{"my-org/my-app:v1", ociReference{"registry-1.docker.io", "my-org/my-app", "v1", ""}},
+		{"my-app", ociReference{"registry-1.docker.io", "library/my-app", "latest", ""}},
+	}
+	for _, tt := range tests {
+		r, err := parseOciReference(tt.ref)
+		require.NoError(t, err, tt.ref)
+		assert.Equal(t, tt.expected, r, tt.ref)
+	}
+
+	_, err := parseOciReference("ghcr.io/my-org/my-app@sha256:abc")
+	assert.Error(t, err)
+}
+
+func Test_ParseAuthParams(t *testing.T) {
+	params := parseAuthParams(`realm="https://ghcr.io/token",service="ghcr.io",scope="repository:my-org/my-app:pull"`)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://ghcr.io/token",
+		"service": "ghcr.io",
+		"scope":   "repository:my-org/my-app:pull",
+	}, params)
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// newTestRegistry serves an artifact with the given files, and requires a bearer token from its token service.
+func newTestRegistry(t *testing.T, files map[string]string) (*httptest.Server, string) {
+	blobs := make(map[string]string)
+	var layers []string
+	for name, content := range files {
+		d := sha256Digest([]byte(content))
+		blobs[d] = content
+		layers = append(layers, fmt.Sprintf(`{"mediaType":"application/octet-stream","digest":%q,"size":%d,"annotations":{%q:%q}}`,
+			d, len(content), ociTitleAnnotation, name))
+	}
+	manifest := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[` + strings.Join(layers, ",") + `]}`
+	manifestDigest := sha256Digest([]byte(manifest))
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "repository:my-app:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token":"secret"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="repository:my-app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v2/my-app/manifests/v1" || r.URL.Path == "/v2/my-app/manifests/"+manifestDigest:
+			w.Header().Set("Docker-Content-Digest", manifestDigest)
+			_, _ = w.Write([]byte(manifest))
+		case strings.HasPrefix(r.URL.Path, "/v2/my-app/blobs/"):
+			content, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/my-app/blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, manifestDigest
+}
+
+func Test_OciStorageProvider(t *testing.T) {
+	server, manifestDigest := newTestRegistry(t, map[string]string{
+		"my-plugin.wasm": "wasm content",
+		"modus.json":     "{}",
+	})
+	registry := strings.TrimPrefix(server.URL, "http://")
+	ctx := context.Background()
+
+	for _, ref := range []string{registry + "/my-app:v1", registry + "/my-app@" + manifestDigest} {
+		r, err := parseOciReference(ref)
+		require.NoError(t, err)
+		stg := &ociStorageProvider{ref: r, client: server.Client()}
+
+		files, err := stg.listFiles(ctx, ".wasm")
+		require.NoError(t, err, ref)
+		require.Len(t, files, 1, ref)
+		assert.Equal(t, "my-plugin.wasm", files[0].Name)
+		assert.Equal(t, sha256Digest([]byte("wasm content")), files[0].Hash)
+
+		content, err := stg.getFileContents(ctx, "modus.json")
+		require.NoError(t, err, ref)
+		assert.Equal(t, "{}", string(content))
+
+		_, err = stg.getFileContents(ctx, "missing.wasm")
+		assert.Error(t, err, ref)
+	}
+
+	// Content that doesn't match a pinned digest is refused.
+	r, err := parseOciReference(registry + "/my-app@sha256:" + strings.Repeat("0", 64))
+	require.NoError(t, err)
+	stg := &ociStorageProvider{ref: r, client: server.Client()}
+	_, err = stg.listFiles(ctx, ".wasm")
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package storage
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/aws"
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/tidwall/gjson"
+)
+
+// s3Object is the bucket and key of an object that an S3 event notification refers to.
+type s3Object struct {
+	bucket string
+	key    string
+}
+
+// listenForS3Events receives the S3 event notifications of the configured SQS queue, and requests a refresh of the
+// storage monitors when files within the storage path change.  Polling continues on the refresh interval regardless,
+// so that changes are still picked up if notifications are delayed or lost.
+func listenForS3Events(ctx context.Context) {
+	client := sqs.NewFromConfig(aws.GetAwsConfig())
+	queueUrl := config.S3EventQueue
+
+	logger.Info(ctx).Str("queue", queueUrl).Msg("Listening for S3 event notifications.")
+
+	go func() {
+		var loggedError = false
+		for ctx.Err() == nil {
+			result, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+				QueueUrl:            &queueUrl,
+				MaxNumberOfMessages: 10,
+				WaitTimeSeconds:     20,
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// Don't stop listening.  We'll just try again after the refresh interval.
+				if !loggedError {
+					logger.Err(ctx, err).Str("queue", queueUrl).Msg("Failed to receive S3 event notifications.")
+					loggedError = true
+				}
+				select {
+				case <-time.After(config.RefreshInterval):
+				case <-ctx.Done():
+				}
+				continue
+			}
+			loggedError = false
+
+			if len(result.Messages) == 0 {
+				continue
+			}
+
+			changed := false
+			entries := make([]types.DeleteMessageBatchRequestEntry, len(result.Messages))
+			for i, msg := range result.Messages {
+				for _, obj := range s3EventObjects([]byte(*msg.Body)) {
+					if isStorageObject(obj) {
+						changed = true
+					}
+				}
+				entries[i] = types.DeleteMessageBatchRequestEntry{Id: msg.MessageId, ReceiptHandle: msg.ReceiptHandle}
+			}
+
+			if changed {
+				logger.Debug(ctx).Msg("Received S3 event notification of changed files.")
+				RequestRefresh()
+			}
+
+			if _, err := client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{QueueUrl: &queueUrl, Entries: entries}); err != nil && ctx.Err() == nil {
+				logger.Warn(ctx).Err(err).Str("queue", queueUrl).Msg("Failed to delete S3 event notifications.")
+			}
+		}
+	}()
+}
+
+// s3EventObjects returns the objects that an SQS message refers to.  The message can be an S3 event notification,
+// an EventBridge event for S3, or either of them delivered through an SNS topic.
+func s3EventObjects(body []byte) []s3Object {
+	if !gjson.ValidBytes(body) {
+		return nil
+	}
+	msg := gjson.ParseBytes(body)
+
+	// SNS wraps the notification as a string.
+	if msg.Get("Type").String() == "Notification" {
+		return s3EventObjects([]byte(msg.Get("Message").String()))
+	}
+
+	// EventBridge
+	if msg.Get("source").String() == "aws.s3" {
+		return []s3Object{{
+			bucket: msg.Get("detail.bucket.name").String(),
+			key:    msg.Get("detail.object.key").String(),
+		}}
+	}
+
+	// S3 event notification, in which keys are URL-encoded.
+	var objects []s3Object
+	for _, record := range msg.Get("Records").Array() {
+		if record.Get("eventSource").String() != "aws:s3" {
+			continue
+		}
+		key := record.Get("s3.object.key").String()
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		objects = append(objects, s3Object{
+			bucket: record.Get("s3.bucket.name").String(),
+			key:    key,
+		})
+	}
+	return objects
+}
+
+// isStorageObject reports whether an object is one of the files of the storage path, as opposed to other
+// objects of the same bucket.
+func isStorageObject(obj s3Object) bool {
+	return obj.bucket == config.S3Bucket && strings.HasPrefix(obj.key, config.S3Path)
+}
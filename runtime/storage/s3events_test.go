@@ -0,0 +1,75 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testS3Notification = `{"Records":[
+	{"eventSource":"aws:s3","eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"modus"},"object":{"key":"app/my+plugin.wasm"}}},
+	{"eventSource":"aws:s3","eventName":"ObjectRemoved:Delete","s3":{"bucket":{"name":"modus"},"object":{"key":"other/x.wasm"}}}
+]}`
+
+func Test_S3EventObjects(t *testing.T) {
+	assert.Equal(t, []s3Object{
+		{"modus", "app/my plugin.wasm"},
+		{"modus", "other/x.wasm"},
+	}, s3EventObjects([]byte(testS3Notification)))
+
+	// EventBridge
+	assert.Equal(t, []s3Object{{"modus", "app/modus.json"}}, s3EventObjects([]byte(`{
+		"source": "aws.s3",
+		"detail-type": "Object Created",
+		"detail": {"bucket": {"name": "modus"}, "object": {"key": "app/modus.json"}}
+	}`)))
+
+	// SNS
+	message, _ := json.Marshal(testS3Notification)
+	assert.Len(t, s3EventObjects([]byte(`{"Type":"Notification","Message":`+string(message)+`}`)), 2)
+
+	// The test event that S3 sends when notifications are configured refers to no object.
+	assert.Empty(t, s3EventObjects([]byte(`{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"modus"}`)))
+	assert.Empty(t, s3EventObjects([]byte(`not json`)))
+}
+
+func Test_IsStorageObject(t *testing.T) {
+	defer func(bucket, path string) { config.S3Bucket, config.S3Path = bucket, path }(config.S3Bucket, config.S3Path)
+	config.S3Bucket = "modus"
+	config.S3Path = "app/"
+
+	assert.True(t, isStorageObject(s3Object{"modus", "app/plugin.wasm"}))
+	assert.False(t, isStorageObject(s3Object{"modus", "other/plugin.wasm"}))
+	assert.False(t, isStorageObject(s3Object{"other", "app/plugin.wasm"}))
+}
+
+func Test_RequestRefresh(t *testing.T) {
+	refresh := subscribeToRefreshRequests()
+
+	// Requests are combined while one is pending.
+	RequestRefresh()
+	RequestRefresh()
+	select {
+	case <-refresh:
+	case <-time.After(time.Second):
+		t.Fatal("no refresh request")
+	}
+	select {
+	case <-refresh:
+		t.Fatal("unexpected second refresh request")
+	default:
+	}
+}
@@ -36,13 +36,20 @@ func Initialize(ctx context.Context) {
 	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
 
-	if config.UseAwsStorage {
+	if config.OciReference != "" {
+		provider = &ociStorageProvider{}
+	} else if config.UseAwsStorage {
 		provider = &awsStorageProvider{}
 	} else {
 		provider = &localStorageProvider{}
 	}
 
 	provider.initialize(ctx)
+
+	// S3 event notifications pick up changes to files within seconds, rather than on the next refresh interval.
+	if config.UseAwsStorage && config.S3EventQueue != "" {
+		listenForS3Events(ctx)
+	}
 }
 
 func ListFiles(ctx context.Context, extension string) ([]FileInfo, error) {
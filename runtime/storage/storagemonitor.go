@@ -43,6 +43,21 @@ func NewStorageMonitor(extension string) *StorageMonitor {
 }
 
 func (sm *StorageMonitor) Start(ctx context.Context) {
+	// In development, local files are watched so that changes are picked up as soon as they are written.
+	// Otherwise, or if watching fails, changes are picked up on the next refresh interval.
+	var changes <-chan struct{}
+	if w, ok := provider.(fileWatcher); ok && config.IsDevEnvironment() {
+		ch, err := w.watch(ctx, sm.extension)
+		if err != nil {
+			logger.Warn(ctx).Err(err).Msgf("Failed to watch for changes to %s files.  Polling for changes instead.", sm.extension)
+		} else {
+			changes = ch
+		}
+	}
+
+	// Changes can also be notified by S3 events, or by a webhook.
+	refresh := subscribeToRefreshRequests()
+
 	go func() {
 		ticker := time.NewTicker(config.RefreshInterval)
 		defer ticker.Stop()
@@ -112,6 +127,10 @@ func (sm *StorageMonitor) Start(ctx context.Context) {
 			select {
 			case <-ticker.C:
 				continue
+			case <-changes:
+				continue
+			case <-refresh:
+				continue
 			case <-ctx.Done():
 				return
 			}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+	"github.com/hypermodeinc/modus/runtime/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long to wait after a file system event before notifying of a change,
+// so that a build that writes several files, or writes one file in several steps, results in a single reload.
+const watchDebounce = 100 * time.Millisecond
+
+// A fileWatcher is a storage provider that can notify of changes as they happen, rather than waiting to be polled.
+type fileWatcher interface {
+	watch(ctx context.Context, extension string) (<-chan struct{}, error)
+}
+
+func (stg *localStorageProvider) watch(ctx context.Context, extension string) (<-chan struct{}, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.Add(config.StoragePath); err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer w.Close()
+
+		timer := time.NewTimer(watchDebounce)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if strings.HasSuffix(evt.Name, extension) && !evt.Has(fsnotify.Chmod) {
+					timer.Reset(watchDebounce)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				logger.Warn(ctx).Err(err).Msgf("Error watching for changes to %s files.", extension)
+			case <-timer.C:
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
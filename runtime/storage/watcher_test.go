@@ -0,0 +1,58 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LocalStorageWatch(t *testing.T) {
+	dir := t.TempDir()
+	defer func(path string) { config.StoragePath = path }(config.StoragePath)
+	config.StoragePath = dir
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stg := &localStorageProvider{}
+	changes, err := stg.watch(ctx, ".wasm")
+	require.NoError(t, err)
+
+	// files with other extensions are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644))
+	select {
+	case <-changes:
+		t.Fatal("unexpected change notification")
+	case <-time.After(3 * watchDebounce):
+	}
+
+	// several writes result in a single notification
+	path := filepath.Join(dir, "plugin.wasm")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(path, []byte("ab"), 0644))
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("no change notification")
+	}
+	select {
+	case <-changes:
+		t.Fatal("unexpected second change notification")
+	case <-time.After(3 * watchDebounce):
+	}
+}
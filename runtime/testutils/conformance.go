@@ -0,0 +1,117 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package testutils
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// ConformanceCase is a canonical function signature and set of arguments that every language adapter must handle
+// the same way.  The result of each case is compared to the golden output in testdata/conformance.json, as it would
+// be serialized to a client.  Functions that only check their input return nothing, so their golden output is null.
+type ConformanceCase struct {
+	Name string
+	Args []any
+}
+
+// ConformanceCases covers the behavior that is most likely to differ between languages,
+// such as 64-bit integer precision, null handling, and the order of map entries.
+var ConformanceCases = []ConformanceCase{
+	{"bool/input/true", []any{true}},
+	{"bool/input/false", []any{false}},
+	{"bool/output/true", nil},
+	{"bool/output/false", nil},
+	{"i64/input/min", []any{int64(math.MinInt64)}},
+	{"i64/input/max", []any{int64(math.MaxInt64)}},
+	{"i64/output/min", nil},
+	{"i64/output/max", nil},
+	{"u64/input/min", []any{uint64(0)}},
+	{"u64/input/max", []any{uint64(math.MaxUint64)}},
+	{"u64/output/min", nil},
+	{"u64/output/max", nil},
+	{"string/input", []any{"こんにちは、世界"}},
+	{"string/output", nil},
+	{"string/nullable/input/null", []any{nil}},
+	{"string/nullable/output/null", nil},
+	{"map/input", []any{map[string]any{"a": "1", "b": "2", "c": "3"}}},
+	{"map/output", nil},
+	{"map/iterate", []any{conformanceMap(100)}},
+	{"map/lookup", []any{conformanceMap(100), "key_047"}},
+	{"object/input/null_field", []any{map[string]any{"a": true, "b": 123, "c": nil}}},
+	{"object/input/missing_field", []any{map[string]any{"a": true, "b": 123}}},
+	{"object/output/null_field", nil},
+}
+
+//go:embed testdata/conformance.json
+var conformanceGolden []byte
+
+func conformanceMap(n int) map[string]string {
+	m := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		m[fmt.Sprintf("key_%03d", i)] = fmt.Sprintf("val_%03d", i)
+	}
+	return m
+}
+
+// RunConformanceSuite runs every conformance case against the adapter's test functions.
+// The functions map gives the name of the adapter's function that implements each case.
+// Every case must be implemented, so that all adapters are held to the same behavior.
+func (f *WasmTestFixture) RunConformanceSuite(t *testing.T, functions map[string]string) {
+	var golden map[string]json.RawMessage
+	if err := utils.JsonDeserialize(conformanceGolden, &golden); err != nil {
+		t.Fatalf("failed to read golden outputs: %v", err)
+	}
+
+	for _, c := range ConformanceCases {
+		t.Run(c.Name, func(t *testing.T) {
+			fnName, ok := functions[c.Name]
+			if !ok {
+				t.Fatalf("the adapter does not implement conformance case %s", c.Name)
+			}
+
+			expected, ok := golden[c.Name]
+			if !ok {
+				t.Fatalf("no golden output for conformance case %s", c.Name)
+			}
+
+			result, err := f.CallFunction(t, fnName, c.Args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := utils.JsonSerialize(result)
+			if err != nil {
+				t.Fatalf("failed to serialize result: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, expected); err != nil {
+				t.Fatalf("invalid golden output: %v", err)
+			}
+
+			if !bytes.Equal(buf.Bytes(), actual) {
+				t.Errorf("expected %s, got %s", buf.Bytes(), actual)
+			}
+		})
+	}
+
+	for name := range functions {
+		if _, ok := golden[name]; !ok {
+			t.Errorf("the adapter maps unknown conformance case %s", name)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package timezones
+
+import (
+	"fmt"
+	"time"
+
+	// Embed the IANA time zone database, so that lookups work even if the
+	// host doesn't have one installed (such as in a minimal container image).
+	_ "time/tzdata"
+)
+
+// maxTransitions limits the number of transitions returned for a single query,
+// to protect against unbounded results from very large time ranges.
+const maxTransitions = 1000
+
+type TimeZoneInfo struct {
+	Name         string `json:"name"`
+	Abbreviation string `json:"abbreviation"`
+	Offset       int32  `json:"offset"`
+	IsDST        bool   `json:"isDST"`
+}
+
+type TimeZoneTransition struct {
+	Time   time.Time     `json:"time"`
+	Before *TimeZoneInfo `json:"before"`
+	After  *TimeZoneInfo `json:"after"`
+}
+
+// GetTimeZones returns the names of all time zones known to the runtime.
+func GetTimeZones() []string {
+	return getZoneNames()
+}
+
+// GetTimeZoneInfo returns the offset and abbreviation in effect for the time zone at the given instant.
+func GetTimeZoneInfo(tz string, instant time.Time) (*TimeZoneInfo, error) {
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+
+	return getZoneInfo(instant.In(loc)), nil
+}
+
+// GetTimeZoneTransitions returns the offset transitions (such as DST changes) for the time zone
+// that occur after the start instant and before the end instant.
+func GetTimeZoneTransitions(tz string, start, end time.Time) ([]*TimeZoneTransition, error) {
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+
+	if end.Before(start) {
+		return nil, fmt.Errorf("end time %s is before start time %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+
+	transitions := make([]*TimeZoneTransition, 0)
+	t := start.In(loc)
+	for len(transitions) < maxTransitions {
+		_, next := t.ZoneBounds()
+		if next.IsZero() || !next.Before(end) {
+			break
+		}
+
+		before := getZoneInfo(next.Add(-time.Second))
+		after := getZoneInfo(next)
+		if before.Offset != after.Offset || before.Abbreviation != after.Abbreviation || before.IsDST != after.IsDST {
+			transitions = append(transitions, &TimeZoneTransition{
+				Time:   next.UTC(),
+				Before: before,
+				After:  after,
+			})
+		}
+
+		t = next
+	}
+
+	return transitions, nil
+}
+
+// ConvertTime interprets a local date and time string in the source time zone,
+// and returns the same instant formatted as RFC 3339 in the target time zone.
+// If the input string includes an offset, it is used instead of the source time zone.
+func ConvertTime(input, fromTz, toTz string) (string, error) {
+	from, err := loadLocation(fromTz)
+	if err != nil {
+		return "", err
+	}
+
+	to, err := loadLocation(toTz)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := parseLocalTime(input, from)
+	if err != nil {
+		return "", err
+	}
+
+	return t.In(to).Format(time.RFC3339Nano), nil
+}
+
+// FormatTime returns the instant formatted as RFC 3339 in the given time zone.
+func FormatTime(instant time.Time, tz string) (string, error) {
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return "", err
+	}
+
+	return instant.In(loc).Format(time.RFC3339Nano), nil
+}
+
+func loadLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return nil, fmt.Errorf("time zone name is required")
+	}
+
+	// Reject the special "Local" zone, since it refers to the host's zone, not the caller's.
+	if tz == "Local" {
+		return nil, fmt.Errorf("time zone %q is not supported", tz)
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tz)
+	}
+
+	return loc, nil
+}
+
+func getZoneInfo(t time.Time) *TimeZoneInfo {
+	abbr, offset := t.Zone()
+	return &TimeZoneInfo{
+		Name:         t.Location().String(),
+		Abbreviation: abbr,
+		Offset:       int32(offset),
+		IsDST:        t.IsDST(),
+	}
+}
+
+var localTimeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseLocalTime(s string, loc *time.Location) (time.Time, error) {
+	for _, format := range localTimeFormats {
+		if t, err := time.ParseInLocation(format, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("failed to parse date time string: %s", s)
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package timezones_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/runtime/timezones"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetTimeZoneInfo(t *testing.T) {
+	summer := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	info, err := timezones.GetTimeZoneInfo("America/New_York", summer)
+	require.NoError(t, err)
+	assert.Equal(t, "EDT", info.Abbreviation)
+	assert.Equal(t, int32(-4*60*60), info.Offset)
+	assert.True(t, info.IsDST)
+
+	winter := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	info, err = timezones.GetTimeZoneInfo("America/New_York", winter)
+	require.NoError(t, err)
+	assert.Equal(t, "EST", info.Abbreviation)
+	assert.Equal(t, int32(-5*60*60), info.Offset)
+	assert.False(t, info.IsDST)
+
+	_, err = timezones.GetTimeZoneInfo("Not/AZone", winter)
+	assert.Error(t, err)
+}
+
+func Test_GetTimeZoneTransitions(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	transitions, err := timezones.GetTimeZoneTransitions("Europe/London", start, end)
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+
+	assert.Equal(t, time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC), transitions[0].Time)
+	assert.Equal(t, "GMT", transitions[0].Before.Abbreviation)
+	assert.Equal(t, "BST", transitions[0].After.Abbreviation)
+
+	assert.Equal(t, time.Date(2024, 10, 27, 1, 0, 0, 0, time.UTC), transitions[1].Time)
+	assert.Equal(t, "BST", transitions[1].Before.Abbreviation)
+	assert.Equal(t, "GMT", transitions[1].After.Abbreviation)
+
+	transitions, err = timezones.GetTimeZoneTransitions("UTC", start, end)
+	require.NoError(t, err)
+	assert.Empty(t, transitions)
+}
+
+func Test_ConvertTime(t *testing.T) {
+	result, err := timezones.ConvertTime("2024-07-01T09:00", "America/Los_Angeles", "Asia/Tokyo")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-07-02T01:00:00+09:00", result)
+
+	result, err = timezones.ConvertTime("2024-07-01T09:00:00Z", "America/Los_Angeles", "Europe/Paris")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-07-01T11:00:00+02:00", result)
+}
+
+func Test_GetTimeZones(t *testing.T) {
+	zones := timezones.GetTimeZones()
+	assert.Contains(t, zones, "UTC")
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package timezones
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The Go standard library doesn't expose the list of zone names.  We start from the names of the
+// embedded zone database (see zonenames_generated.go), which are always available, and add any
+// others from the same sources that the time package uses, in the same order of precedence.
+var zoneSources = []string{
+	"/usr/share/zoneinfo/",
+	"/usr/share/lib/zoneinfo/",
+	"/usr/lib/locale/TZ/",
+	"/etc/zoneinfo/",
+}
+
+var getZoneNames = sync.OnceValue(func() []string {
+	var names []string
+	if zi := os.Getenv("ZONEINFO"); zi != "" {
+		names = readZoneSource(zi)
+	}
+	for _, src := range zoneSources {
+		if len(names) > 0 {
+			break
+		}
+		names = readZoneSource(src)
+	}
+
+	for _, name := range embeddedZoneNames {
+		if isZoneName(name) {
+			names = append(names, name)
+		}
+	}
+
+	// Always include UTC, and only include names that the time package can actually load.
+	names = append(names, "UTC")
+	slices.Sort(names)
+	names = slices.Compact(names)
+	return slices.DeleteFunc(names, func(name string) bool {
+		_, err := time.LoadLocation(name)
+		return err != nil
+	})
+})
+
+func readZoneSource(src string) []string {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil
+	}
+
+	if !info.IsDir() {
+		return readZoneZip(src)
+	}
+
+	var names []string
+	_ = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			// skip the alternate "posix" and "right" (leap second) trees
+			if rel == "posix" || rel == "right" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if isZoneName(rel) && isZoneFile(path) {
+			names = append(names, rel)
+		}
+		return nil
+	})
+	return names
+}
+
+func readZoneZip(src string) []string {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+
+	names := make([]string, 0, len(r.File))
+	for _, f := range r.File {
+		if isZoneName(f.Name) {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+func isZoneName(name string) bool {
+	if name == "" || name == "localtime" || name == "posixrules" || name == "Factory" {
+		return false
+	}
+
+	// zone names start with an uppercase letter (this excludes files like "zone.tab", "leapseconds", etc.)
+	if name[0] < 'A' || name[0] > 'Z' {
+		return false
+	}
+
+	return !strings.Contains(name, ".")
+}
+
+func isZoneFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err := f.Read(magic); err != nil {
+		return false
+	}
+	return bytes.Equal(magic, []byte("TZif"))
+}
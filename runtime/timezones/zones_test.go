@@ -0,0 +1,37 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package timezones
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EmbeddedZoneNames(t *testing.T) {
+	// The embedded names don't depend on the host having a zone database.
+	assert.Contains(t, embeddedZoneNames, "America/New_York")
+	assert.Contains(t, embeddedZoneNames, "Asia/Tokyo")
+	assert.Contains(t, embeddedZoneNames, "Europe/London")
+
+	// Every embedded name must be loadable from the embedded database alone.
+	t.Setenv("ZONEINFO", t.TempDir())
+	for _, name := range embeddedZoneNames {
+		if _, err := time.LoadLocation(name); err != nil {
+			t.Errorf("failed to load embedded zone %s: %v", name, err)
+		}
+	}
+
+	zones := getZoneNames()
+	assert.Contains(t, zones, "UTC")
+	assert.NotContains(t, zones, "Factory")
+	assert.NotContains(t, zones, "localtime")
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"archive/zip"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+func main() {
+
+	// This is the same zone database that the "time/tzdata" package embeds.
+	src := filepath.Join(runtime.GOROOT(), "lib", "time", "zoneinfo.zip")
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+
+	names := make([]string, 0, len(r.File))
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, "/") {
+			names = append(names, f.Name)
+		}
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	sb.WriteString(`//
+// ** THIS FILE IS AUTOGENERATED - DO NOT EDIT **
+//
+// The zone names are those of the IANA time zone database that is embedded
+// by the "time/tzdata" package, from the Go distribution's zoneinfo.zip file.
+//
+
+package timezones
+
+var embeddedZoneNames = []string{
+`)
+	for _, name := range names {
+		sb.WriteString("\t\"" + name + "\",\n")
+	}
+	sb.WriteString("}\n")
+
+	if err := os.WriteFile("./timezones/zonenames_generated.go", []byte(sb.String()), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,290 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package transforms provides common transformations over JSON data, executed on the host
+// so that guests don't have to walk large datasets themselves.
+package transforms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hypermodeinc/modus/runtime/utils"
+)
+
+// Flatten flattens nested objects into a single level, joining the keys of each path with the separator.
+// Array elements are addressed by their index.  If the data is an array, each element is flattened individually.
+func Flatten(dataJson, separator string) (string, error) {
+	data, err := parse(dataJson)
+	if err != nil {
+		return "", err
+	}
+
+	if separator == "" {
+		separator = "."
+	}
+
+	var result any
+	if arr, ok := data.([]any); ok {
+		items := make([]any, len(arr))
+		for i, item := range arr {
+			items[i] = flattenValue(item, separator)
+		}
+		result = items
+	} else {
+		result = flattenValue(data, separator)
+	}
+
+	return serialize(result)
+}
+
+func flattenValue(v any, separator string) any {
+	switch v.(type) {
+	case map[string]any, []any:
+		result := make(map[string]any)
+		flattenInto(result, "", v, separator)
+		return result
+	default:
+		return v
+	}
+}
+
+func flattenInto(result map[string]any, prefix string, v any, separator string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + separator + key
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) == 0 && prefix != "" {
+			result[prefix] = t
+		}
+		for k, val := range t {
+			flattenInto(result, join(k), val, separator)
+		}
+	case []any:
+		if len(t) == 0 && prefix != "" {
+			result[prefix] = t
+		}
+		for i, val := range t {
+			flattenInto(result, join(strconv.Itoa(i)), val, separator)
+		}
+	default:
+		result[prefix] = v
+	}
+}
+
+// GroupBy groups an array of objects by the value at the given key path.
+// The result is an object mapping each distinct key value to the items having that value.
+// Items that don't have the key are grouped under an empty string.
+func GroupBy(dataJson, key string) (string, error) {
+	items, err := parseArray(dataJson)
+	if err != nil {
+		return "", err
+	}
+
+	groups := make(map[string][]any)
+	for _, item := range items {
+		var k string
+		if v, ok := getPath(item, key); ok {
+			k = keyString(v)
+		}
+		groups[k] = append(groups[k], item)
+	}
+
+	return serialize(groups)
+}
+
+// Project keeps only the given fields of an object, or of each object in an array.
+// Fields may be nested key paths separated by dots, and keep their nesting in the result.
+// Fields that are not present are omitted.
+func Project(dataJson string, fields []string) (string, error) {
+	data, err := parse(dataJson)
+	if err != nil {
+		return "", err
+	}
+
+	var result any
+	if arr, ok := data.([]any); ok {
+		items := make([]any, len(arr))
+		for i, item := range arr {
+			items[i] = projectValue(item, fields)
+		}
+		result = items
+	} else {
+		result = projectValue(data, fields)
+	}
+
+	return serialize(result)
+}
+
+func projectValue(v any, fields []string) any {
+	if _, ok := v.(map[string]any); !ok {
+		return v
+	}
+
+	result := make(map[string]any, len(fields))
+	for _, field := range fields {
+		val, ok := getPath(v, field)
+		if !ok {
+			continue
+		}
+
+		parts := strings.Split(field, ".")
+		m := result
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := m[p].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				m[p] = next
+			}
+			m = next
+		}
+		m[parts[len(parts)-1]] = val
+	}
+	return result
+}
+
+// Join joins two arrays of objects where the value at leftKey equals the value at rightKey.
+// Each result item has the fields of the left item, plus any fields of the right item that the left item doesn't have.
+// The kind is either "inner", which omits left items without a match, or "left", which keeps them as-is.
+func Join(leftJson, rightJson, leftKey, rightKey, kind string) (string, error) {
+	var keepUnmatched bool
+	switch kind {
+	case "", "inner":
+	case "left":
+		keepUnmatched = true
+	default:
+		return "", fmt.Errorf("unsupported join kind: %s", kind)
+	}
+
+	left, err := parseArray(leftJson)
+	if err != nil {
+		return "", fmt.Errorf("invalid left data: %w", err)
+	}
+	right, err := parseArray(rightJson)
+	if err != nil {
+		return "", fmt.Errorf("invalid right data: %w", err)
+	}
+
+	index := make(map[string][]map[string]any, len(right))
+	for _, item := range right {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if v, ok := getPath(obj, rightKey); ok {
+			k := joinKey(v)
+			index[k] = append(index[k], obj)
+		}
+	}
+
+	result := make([]any, 0, len(left))
+	for _, item := range left {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		var matches []map[string]any
+		if v, ok := getPath(obj, leftKey); ok {
+			matches = index[joinKey(v)]
+		}
+
+		if len(matches) == 0 {
+			if keepUnmatched {
+				result = append(result, obj)
+			}
+			continue
+		}
+
+		for _, match := range matches {
+			merged := make(map[string]any, len(obj)+len(match))
+			for k, v := range match {
+				merged[k] = v
+			}
+			for k, v := range obj {
+				merged[k] = v
+			}
+			result = append(result, merged)
+		}
+	}
+
+	return serialize(result)
+}
+
+func getPath(v any, path string) (any, bool) {
+	for _, p := range strings.Split(path, ".") {
+		switch t := v.(type) {
+		case map[string]any:
+			val, ok := t[p]
+			if !ok {
+				return nil, false
+			}
+			v = val
+		case []any:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, false
+			}
+			v = t[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// keyString returns the string form of a value used as a grouping key.
+// Strings are used as-is, and other values use their JSON representation.
+func keyString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := utils.JsonSerialize(v)
+	return string(b)
+}
+
+// joinKey returns the JSON representation of a value, so that only values of the same type can match.
+func joinKey(v any) string {
+	b, _ := utils.JsonSerialize(v)
+	return string(b)
+}
+
+func parse(dataJson string) (any, error) {
+	var data any
+	if err := utils.JsonDeserialize([]byte(dataJson), &data); err != nil {
+		return nil, fmt.Errorf("invalid JSON data: %w", err)
+	}
+	return data, nil
+}
+
+func parseArray(dataJson string) ([]any, error) {
+	data, err := parse(dataJson)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := data.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON array")
+	}
+	return arr, nil
+}
+
+func serialize(v any) (string, error) {
+	b, err := utils.JsonSerialize(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Flatten(t *testing.T) {
+	result, err := Flatten(`{"a":{"b":1,"c":[true,{"d":"x"}]},"e":{}}`, "")
+	require.Nil(t, err)
+	assert.JSONEq(t, `{"a.b":1,"a.c.0":true,"a.c.1.d":"x","e":{}}`, result)
+
+	result, err = Flatten(`[{"a":{"b":1}},{"a":{"b":2}},3]`, "_")
+	require.Nil(t, err)
+	assert.JSONEq(t, `[{"a_b":1},{"a_b":2},3]`, result)
+
+	_, err = Flatten(`{`, "")
+	assert.NotNil(t, err)
+}
+
+func Test_GroupBy(t *testing.T) {
+	data := `[
+		{"name":"a","team":{"id":1}},
+		{"name":"b","team":{"id":2}},
+		{"name":"c","team":{"id":1}},
+		{"name":"d"}
+	]`
+
+	result, err := GroupBy(data, "team.id")
+	require.Nil(t, err)
+	assert.JSONEq(t, `{
+		"1":[{"name":"a","team":{"id":1}},{"name":"c","team":{"id":1}}],
+		"2":[{"name":"b","team":{"id":2}}],
+		"":[{"name":"d"}]
+	}`, result)
+
+	_, err = GroupBy(`{"a":1}`, "a")
+	assert.NotNil(t, err)
+}
+
+func Test_Project(t *testing.T) {
+	result, err := Project(`{"id":1,"name":"a","address":{"city":"x","zip":"y"}}`, []string{"id", "address.city", "missing"})
+	require.Nil(t, err)
+	assert.JSONEq(t, `{"id":1,"address":{"city":"x"}}`, result)
+
+	result, err = Project(`[{"id":1,"name":"a"},{"id":2,"name":"b"}]`, []string{"name"})
+	require.Nil(t, err)
+	assert.JSONEq(t, `[{"name":"a"},{"name":"b"}]`, result)
+}
+
+func Test_Join(t *testing.T) {
+	users := `[{"id":1,"name":"a"},{"id":2,"name":"b"},{"id":3,"name":"c"}]`
+	orders := `[{"userId":1,"total":10},{"userId":1,"total":20},{"userId":2,"total":5,"name":"ignored"}]`
+
+	result, err := Join(users, orders, "id", "userId", "inner")
+	require.Nil(t, err)
+	assert.JSONEq(t, `[
+		{"id":1,"name":"a","userId":1,"total":10},
+		{"id":1,"name":"a","userId":1,"total":20},
+		{"id":2,"name":"b","userId":2,"total":5}
+	]`, result)
+
+	result, err = Join(users, orders, "id", "userId", "left")
+	require.Nil(t, err)
+	assert.Contains(t, result, `{"id":3,"name":"c"}`)
+
+	// keys of different types don't match
+	result, err = Join(`[{"id":"1"}]`, orders, "id", "userId", "inner")
+	require.Nil(t, err)
+	assert.JSONEq(t, `[]`, result)
+
+	_, err = Join(users, orders, "id", "userId", "outer")
+	assert.NotNil(t, err)
+}
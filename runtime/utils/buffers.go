@@ -9,29 +9,93 @@
 
 package utils
 
-import "bytes"
+import (
+	"bytes"
+	"io"
+	"sync"
+)
 
+// OutputBuffers capture the output of a function execution.  Lines that the function writes to stdout and stderr,
+// and messages that it logs with host functions, are kept in one buffer, in the order they were written.
 type OutputBuffers interface {
-	StdOut() *bytes.Buffer
-	StdErr() *bytes.Buffer
+	StdOut() io.Writer
+	StdErr() io.Writer
+	Log(msg LogMessage)
+	Messages() []LogMessage
 }
 
 func NewOutputBuffers() OutputBuffers {
-	return &outputBuffers{
-		stdOut: &bytes.Buffer{},
-		stdErr: &bytes.Buffer{},
-	}
+	b := &outputBuffers{}
+	b.stdOut = &consoleWriter{buffers: b}
+	b.stdErr = &consoleWriter{buffers: b}
+	return b
 }
 
 type outputBuffers struct {
-	stdOut *bytes.Buffer
-	stdErr *bytes.Buffer
+	mu       sync.Mutex
+	messages []LogMessage
+	stdOut   *consoleWriter
+	stdErr   *consoleWriter
 }
 
-func (b *outputBuffers) StdOut() *bytes.Buffer {
+func (b *outputBuffers) StdOut() io.Writer {
 	return b.stdOut
 }
 
-func (b *outputBuffers) StdErr() *bytes.Buffer {
+func (b *outputBuffers) StdErr() io.Writer {
 	return b.stdErr
 }
+
+// Log adds a message after the lines that have been written so far.
+func (b *outputBuffers) Log(msg LogMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, msg)
+}
+
+// Messages returns the messages in the order they were written,
+// followed by any last lines of stdout and stderr that weren't terminated.
+func (b *outputBuffers) Messages() []LogMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	messages := make([]LogMessage, len(b.messages), len(b.messages)+2)
+	copy(messages, b.messages)
+	for _, w := range []*consoleWriter{b.stdOut, b.stdErr} {
+		if w.partial.Len() > 0 {
+			messages = append(messages, consoleMessage(w.partial.String()))
+		}
+	}
+	return messages
+}
+
+// consoleWriter adds each line that is written to it as a message of the output buffers.
+type consoleWriter struct {
+	buffers *outputBuffers
+	partial bytes.Buffer
+}
+
+func (w *consoleWriter) Write(p []byte) (int, error) {
+	w.buffers.mu.Lock()
+	defer w.buffers.mu.Unlock()
+
+	w.partial.Write(p)
+	for {
+		line, err := w.partial.ReadString('\n')
+		if err != nil {
+			// keep the incomplete line until the rest of it is written
+			w.partial.Reset()
+			w.partial.WriteString(line)
+			break
+		}
+		if line = line[:len(line)-1]; line != "" {
+			w.buffers.messages = append(w.buffers.messages, consoleMessage(line))
+		}
+	}
+	return len(p), nil
+}
+
+func consoleMessage(line string) LogMessage {
+	level, message := SplitConsoleOutputLine(line)
+	return LogMessage{Level: level, Message: message}
+}
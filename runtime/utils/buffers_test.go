@@ -0,0 +1,38 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputBuffers(t *testing.T) {
+	b := NewOutputBuffers()
+
+	fmt.Fprint(b.StdOut(), "Info: first\n\nsec")
+	b.Log(LogMessage{Level: "info", Message: "logged", Fields: map[string]string{"user": "amy"}})
+	fmt.Fprint(b.StdOut(), "ond\n")
+	fmt.Fprint(b.StdErr(), "Error: failed\n")
+	b.Log(LogMessage{Level: "debug", Message: "last"})
+	fmt.Fprint(b.StdOut(), "unterminated")
+
+	// lines are added when they end, and the console's level prefixes are parsed
+	assert.Equal(t, []LogMessage{
+		{Level: "info", Message: "first"},
+		{Level: "info", Message: "logged", Fields: map[string]string{"user": "amy"}},
+		{Message: "second"},
+		{Level: "error", Message: "failed"},
+		{Level: "debug", Message: "last"},
+		{Message: "unterminated"},
+	}, b.Messages())
+}
@@ -10,7 +10,9 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/spf13/cast"
 )
@@ -78,7 +80,7 @@ func Cast[T any](obj any) (T, error) {
 		}
 		result = any(v).(T)
 	case uint64:
-		v, e := cast.ToUint64E(obj)
+		v, e := toUint64E(obj)
 		if e != nil {
 			return result, e
 		}
@@ -113,3 +115,19 @@ func Cast[T any](obj any) (T, error) {
 
 	return result, nil
 }
+
+// toUint64E is like cast.ToUint64E, but also parses strings of values that are too large for an int64,
+// such as the string-encoded BigInt values of GraphQL requests.
+func toUint64E(obj any) (uint64, error) {
+	switch t := obj.(type) {
+	case string:
+		if v, err := strconv.ParseUint(t, 10, 64); err == nil {
+			return v, nil
+		}
+	case json.Number:
+		if v, err := strconv.ParseUint(string(t), 10, 64); err == nil {
+			return v, nil
+		}
+	}
+	return cast.ToUint64E(obj)
+}
@@ -9,36 +9,22 @@
 
 package utils
 
-import (
-	"bytes"
-	"strings"
-)
+import "strings"
 
 type LogMessage struct {
-	Level   string `json:"level,omitempty"`
-	Message string `json:"message"`
+	Level   string            `json:"level,omitempty"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+
+	// Extensions are the code, category and details of an error reported by a function,
+	// which are returned to the caller in the extensions of the GraphQL error.
+	Extensions map[string]any `json:"-"`
 }
 
 func (l LogMessage) IsError() bool {
 	return l.Level == "error" || l.Level == "fatal"
 }
 
-func TransformConsoleOutput(buffers OutputBuffers) []LogMessage {
-	return append(transformConsoleOutputLines(buffers.StdOut()), transformConsoleOutputLines(buffers.StdErr())...)
-}
-
-func transformConsoleOutputLines(buf *bytes.Buffer) []LogMessage {
-	lines := strings.Split(buf.String(), "\n")
-	messages := make([]LogMessage, 0, len(lines))
-	for _, line := range lines {
-		if line != "" {
-			level, message := SplitConsoleOutputLine(line)
-			messages = append(messages, LogMessage{level, message})
-		}
-	}
-	return messages
-}
-
 func SplitConsoleOutputLine(line string) (level string, message string) {
 	a := strings.SplitAfterN(line, ": ", 2)
 	if len(a) == 2 {
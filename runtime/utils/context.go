@@ -19,4 +19,13 @@ const WasmAdapterContextKey contextKey = "wasm_adapter"
 const FunctionNameContextKey contextKey = "function_name"
 const FunctionOutputContextKey contextKey = "function_output"
 const FunctionMessagesContextKey contextKey = "function_messages"
+const ModelUsageContextKey contextKey = "model_usage"
+const ModerationFlagsContextKey contextKey = "moderation_flags"
 const CustomTypesContextKey contextKey = "custom_types"
+const StreamWriterContextKey contextKey = "stream_writer"
+const ClientNameContextKey contextKey = "client_name"
+const CanaryContextKey contextKey = "canary"
+
+// StreamWriter sends a chunk of streamed output from a function to the client, as it is received.
+// When the client has requested a streaming response, it is available in the context under StreamWriterContextKey.
+type StreamWriter func(functionName, data string)
@@ -37,16 +37,33 @@ func sendHttp(req *http.Request) ([]byte, error) {
 	}
 
 	if response.StatusCode != http.StatusOK {
-		if len(body) == 0 {
-			return nil, fmt.Errorf("HTTP error: %s", response.Status)
-		} else {
-			return nil, fmt.Errorf("HTTP error: %s\n%s", response.Status, body)
+		return nil, &HttpStatusError{
+			StatusCode: response.StatusCode,
+			Status:     response.Status,
+			Header:     response.Header,
+			Body:       body,
 		}
 	}
 
 	return body, nil
 }
 
+// HttpStatusError is returned when the server responds with a status other than 200 OK.
+// The response headers are retained, so that callers can honor headers such as Retry-After.
+type HttpStatusError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+func (e *HttpStatusError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("HTTP error: %s", e.Status)
+	}
+	return fmt.Sprintf("HTTP error: %s\n%s", e.Status, e.Body)
+}
+
 type HttpResult[T any] struct {
 	Data      T
 	StartTime time.Time
@@ -123,3 +140,14 @@ func PostHttp[TResult any](ctx context.Context, url string, payload any, beforeS
 func WriteJsonContentHeader(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 }
+
+// WriteJsonResponse serializes the value as the JSON body of the response.
+func WriteJsonResponse(w http.ResponseWriter, v any) {
+	data, err := JsonSerialize(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	WriteJsonContentHeader(w)
+	_, _ = w.Write(data)
+}
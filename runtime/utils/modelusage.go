@@ -0,0 +1,76 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	"context"
+
+	"github.com/tidwall/gjson"
+)
+
+// ModelUsage describes a model invocation made by a function, and is returned to the caller in the GraphQL response extensions.
+// Token counts are zero if the model's provider doesn't report them.  FallbackFor is the name of the requested model,
+// when the invocation was made to one of its fallback models.
+type ModelUsage struct {
+	Model        string `json:"model"`
+	FallbackFor  string `json:"fallbackFor,omitempty"`
+	InputTokens  int    `json:"inputTokens"`
+	OutputTokens int    `json:"outputTokens"`
+	DurationMs   int64  `json:"durationMs"`
+	Status       string `json:"status"`
+}
+
+// AddModelUsage records a model invocation for the function execution in the context, if there is one.
+func AddModelUsage(ctx context.Context, usage ModelUsage) {
+	if list, ok := ctx.Value(ModelUsageContextKey).(*[]ModelUsage); ok {
+		*list = append(*list, usage)
+	}
+}
+
+// ModerationFlag describes a violation of a model's moderation policy, and is returned to the caller in the GraphQL
+// response extensions.  The stage is either "input" or "output", and the action is either "blocked" or "flagged".
+// The reasons are the categories of the moderation model, or the deny list terms or patterns that matched.
+type ModerationFlag struct {
+	Model   string   `json:"model"`
+	Stage   string   `json:"stage"`
+	Action  string   `json:"action"`
+	Reasons []string `json:"reasons"`
+}
+
+// AddModerationFlag records a moderation policy violation for the function execution in the context, if there is one.
+func AddModerationFlag(ctx context.Context, flag ModerationFlag) {
+	if list, ok := ctx.Value(ModerationFlagsContextKey).(*[]ModerationFlag); ok {
+		*list = append(*list, flag)
+	}
+}
+
+// GetTokenUsage returns the number of input and output tokens reported in the model's output.
+// Providers report usage in different shapes, so each of the known shapes is tried in turn.
+func GetTokenUsage(output string) (inputTokens, outputTokens int, ok bool) {
+	if !gjson.Valid(output) {
+		return 0, 0, false
+	}
+
+	results := gjson.GetMany(output,
+		"usage.prompt_tokens", "usage.completion_tokens", // OpenAI and compatible APIs
+		"usage.input_tokens", "usage.output_tokens", // Anthropic
+		"usageMetadata.promptTokenCount", "usageMetadata.candidatesTokenCount", // Gemini
+		"prompt_token_count", "generation_token_count", // Meta Llama on Bedrock
+		"inputTextTokenCount", "results.0.tokenCount", // Amazon Titan on Bedrock
+	)
+
+	for i := 0; i < len(results); i += 2 {
+		if results[i].Exists() || results[i+1].Exists() {
+			return int(results[i].Int()), int(results[i+1].Int()), true
+		}
+	}
+
+	return 0, 0, false
+}
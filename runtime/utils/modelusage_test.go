@@ -0,0 +1,40 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTokenUsage(t *testing.T) {
+	tests := []struct {
+		output string
+		input  int
+		out    int
+		ok     bool
+	}{
+		{`{"usage":{"prompt_tokens":12,"completion_tokens":34}}`, 12, 34, true},
+		{`{"usage":{"input_tokens":5,"output_tokens":6}}`, 5, 6, true},
+		{`{"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":8}}`, 7, 8, true},
+		{`{"generation":"hi","prompt_token_count":9,"generation_token_count":10}`, 9, 10, true},
+		{`{"inputTextTokenCount":3,"results":[{"tokenCount":4}]}`, 3, 4, true},
+		{`{"choices":[]}`, 0, 0, false},
+		{`not json`, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		input, output, ok := GetTokenUsage(tt.output)
+		assert.Equal(t, tt.input, input, tt.output)
+		assert.Equal(t, tt.out, output, tt.output)
+		assert.Equal(t, tt.ok, ok, tt.output)
+	}
+}
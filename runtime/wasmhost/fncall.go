@@ -14,11 +14,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/hypermodeinc/modus/runtime/functions"
+	"github.com/hypermodeinc/modus/runtime/inputlimits"
 	"github.com/hypermodeinc/modus/runtime/logger"
 	"github.com/hypermodeinc/modus/runtime/metrics"
+	"github.com/hypermodeinc/modus/runtime/plugins"
 	"github.com/hypermodeinc/modus/runtime/utils"
 
 	"github.com/rs/xid"
@@ -27,15 +30,17 @@ import (
 
 type ExecutionInfo interface {
 	ExecutionId() string
-	Buffers() utils.OutputBuffers
 	Messages() []utils.LogMessage
+	ModelUsage() []utils.ModelUsage
+	ModerationFlags() []utils.ModerationFlag
 	Result() any
 }
 
 type executionInfo struct {
 	executionId string
 	buffers     utils.OutputBuffers
-	messages    []utils.LogMessage
+	modelUsage  []utils.ModelUsage
+	moderation  []utils.ModerationFlag
 	result      any
 }
 
@@ -43,18 +48,56 @@ func (e *executionInfo) ExecutionId() string {
 	return e.executionId
 }
 
-func (e *executionInfo) Buffers() utils.OutputBuffers {
-	return e.buffers
+// Messages returns the messages that the function wrote to the console or logged, in the order they were written.
+func (e *executionInfo) Messages() []utils.LogMessage {
+	return e.buffers.Messages()
 }
 
-func (e *executionInfo) Messages() []utils.LogMessage {
-	return e.messages
+func (e *executionInfo) ModelUsage() []utils.ModelUsage {
+	return e.modelUsage
+}
+
+func (e *executionInfo) ModerationFlags() []utils.ModerationFlag {
+	return e.moderation
 }
 
 func (e *executionInfo) Result() any {
 	return e.result
 }
 
+var inFlightExecutions atomic.Int64
+
+// InFlightExecutions returns the number of function executions currently in progress.
+func InFlightExecutions() int64 {
+	return inFlightExecutions.Load()
+}
+
+// PluginSelector chooses the version of a plugin that serves a function call, such as a canary version,
+// and reports whether the plugin's calls are split between versions.
+type PluginSelector = func(ctx context.Context, plugin *plugins.Plugin) (*plugins.Plugin, bool)
+
+var pluginSelector PluginSelector
+
+func RegisterPluginSelector(selector PluginSelector) {
+	pluginSelector = selector
+}
+
+// selectFunctionVersion returns the function of the version of its plugin that serves the call.
+// If the selected version doesn't export the function, the call is served by the active version.
+func selectFunctionVersion(ctx context.Context, fnInfo functions.FunctionInfo) (functions.FunctionInfo, bool) {
+	if pluginSelector == nil || fnInfo.IsImport() {
+		return fnInfo, false
+	}
+
+	plugin, split := pluginSelector(ctx, fnInfo.Plugin())
+	if plugin != fnInfo.Plugin() {
+		if info, ok := functions.NewFunctionInfo(fnInfo.Name(), plugin, false); ok {
+			return info, split
+		}
+	}
+	return fnInfo, split
+}
+
 func CallFunction(ctx context.Context, fnName string, paramValues ...any) (ExecutionInfo, error) {
 	return GetWasmHost(ctx).CallFunctionByName(ctx, fnName, paramValues...)
 }
@@ -78,23 +121,58 @@ func (host *wasmHost) CallFunction(ctx context.Context, fnInfo functions.Functio
 	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
 
+	inFlightExecutions.Add(1)
+	metrics.FunctionExecutionsInFlightNum.Inc()
+	defer func() {
+		inFlightExecutions.Add(-1)
+		metrics.FunctionExecutionsInFlightNum.Dec()
+	}()
+
+	fnInfo, split := selectFunctionVersion(ctx, fnInfo)
+
+	// A call that resolved its function just before the plugin was replaced or unloaded is served by the
+	// plugin's current version, if any, since a retiring plugin doesn't accept new calls.
+	if !fnInfo.Plugin().BeginCall() {
+		current, err := host.GetFunctionInfo(fnInfo.Name())
+		if err != nil {
+			return nil, err
+		}
+		fnInfo, split = selectFunctionVersion(ctx, current)
+		if !fnInfo.Plugin().BeginCall() {
+			return nil, fmt.Errorf("plugin %s is being unloaded", fnInfo.Plugin().Name())
+		}
+	}
+	defer fnInfo.Plugin().EndCall()
+
 	execInfo := &executionInfo{
 		executionId: xid.New().String(),
 		buffers:     utils.NewOutputBuffers(),
-		messages:    []utils.LogMessage{},
 	}
 
 	fnName := fnInfo.Name()
 	plugin := fnInfo.Plugin()
-	plan := fnInfo.ExecutionPlan()
 
 	ctx = context.WithValue(ctx, utils.ExecutionIdContextKey, execInfo.executionId)
-	ctx = context.WithValue(ctx, utils.FunctionMessagesContextKey, &execInfo.messages)
+	ctx = context.WithValue(ctx, utils.FunctionMessagesContextKey, execInfo.buffers)
+	ctx = context.WithValue(ctx, utils.ModelUsageContextKey, &execInfo.modelUsage)
+	ctx = context.WithValue(ctx, utils.ModerationFlagsContextKey, &execInfo.moderation)
 	ctx = context.WithValue(ctx, utils.FunctionNameContextKey, fnName)
 	ctx = context.WithValue(ctx, utils.PluginContextKey, plugin)
 	ctx = context.WithValue(ctx, utils.MetadataContextKey, plugin.Metadata)
 	ctx = context.WithValue(ctx, utils.WasmHostContextKey, host)
 
+	// Reject oversized input before any memory is allocated for it.
+	if err := inputlimits.Check(parameters); err != nil {
+		return nil, err
+	}
+
+	// The execution plan is prepared on the function's first call, unless the plugin was warmed up.
+	plan, err := fnInfo.ExecutionPlan(ctx)
+	if err != nil {
+		logger.Err(ctx, err).Str("function", fnName).Msg("Error getting execution plan.")
+		return nil, err
+	}
+
 	// Each request will get its own instance of the plugin module, so that we can run
 	// multiple requests in parallel without risk of corrupting the module's memory.
 	// This also protects against security risk, as each request will have its own
@@ -181,6 +259,15 @@ func (host *wasmHost) CallFunction(ctx context.Context, fnInfo functions.Functio
 	d := float64(duration.Milliseconds())
 	metrics.FunctionExecutionDurationMilliseconds.WithLabelValues(fnName).Observe(d)
 	metrics.FunctionExecutionDurationMillisecondsSummary.WithLabelValues(fnName).Observe(d)
+	recordFunctionCall(plugin.Name(), fnName, start, duration, err)
+	if split {
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		metrics.PluginVersionExecutionsNum.WithLabelValues(plugin.Name(), plugin.BuildId(), status).Inc()
+		metrics.PluginVersionExecutionDurationMilliseconds.WithLabelValues(plugin.Name(), plugin.BuildId()).Observe(d)
+	}
 
 	execInfo.result = result
 	return execInfo, err
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package wasmhost
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hypermodeinc/modus/runtime/functions"
+	"github.com/hypermodeinc/modus/runtime/metrics"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/plugins/metadata"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The Go test plugin imports these host functions, which the tests of this package don't call.
+var testdataRegistrations = []func(WasmHost) error{
+	func(host WasmHost) error {
+		return host.RegisterHostFunction("hypermode", "log", func(level, message string) {})
+	},
+	func(host WasmHost) error {
+		return host.RegisterHostFunction("test", "add", func(a, b int) int { return a + b })
+	},
+	func(host WasmHost) error {
+		return host.RegisterHostFunction("test", "echo1", func(s string) string { return s })
+	},
+	func(host WasmHost) error {
+		return host.RegisterHostFunction("test", "echo2", func(s *string) string { return *s })
+	},
+	func(host WasmHost) error {
+		return host.RegisterHostFunction("test", "echo3", func(s string) *string { return &s })
+	},
+	func(host WasmHost) error {
+		return host.RegisterHostFunction("test", "echo4", func(s *string) *string { return s })
+	},
+	func(host WasmHost) error {
+		return host.RegisterHostFunction("test", "encodeStrings1", func(items *[]string) *string { return nil })
+	},
+	func(host WasmHost) error {
+		return host.RegisterHostFunction("test", "encodeStrings2", func(items *[]*string) *string { return nil })
+	},
+}
+
+// newTestPlugins returns an active version of the Go test plugin, and a canary version with the given build ID,
+// which doesn't export the named functions.
+func newTestPlugins(t *testing.T, canaryBuildId string, missingFunctions ...string) (*wasmHost, *plugins.Plugin, *plugins.Plugin) {
+	ctx := context.Background()
+	host := NewWasmHost(ctx, testdataRegistrations...).(*wasmHost)
+	t.Cleanup(func() { host.Close(ctx) })
+
+	content, err := os.ReadFile(filepath.Join("..", "languages", "golang", "testdata", "build", "testdata.wasm"))
+	require.NoError(t, err)
+
+	newPlugin := func(update func(md *metadata.Metadata)) *plugins.Plugin {
+		cm, err := host.CompileModule(ctx, content)
+		require.NoError(t, err)
+		md, err := metadata.GetMetadataFromCompiledModule(cm)
+		require.NoError(t, err)
+		update(md)
+		p, err := plugins.NewPlugin(ctx, cm, "testdata.wasm", md)
+		require.NoError(t, err)
+		return p
+	}
+
+	active := newPlugin(func(md *metadata.Metadata) {})
+	canary := newPlugin(func(md *metadata.Metadata) {
+		md.BuildId = canaryBuildId
+		md.FnExports = maps.Clone(md.FnExports)
+		for _, name := range missingFunctions {
+			delete(md.FnExports, name)
+		}
+	})
+
+	host.GetFunctionRegistry().RegisterAllFunctions(ctx, active)
+	return host, active, canary
+}
+
+// useCanary routes every call to the functions of the active plugin to the canary plugin.
+func useCanary(t *testing.T, active, canary *plugins.Plugin) {
+	RegisterPluginSelector(func(ctx context.Context, plugin *plugins.Plugin) (*plugins.Plugin, bool) {
+		if plugin == active {
+			return canary, true
+		}
+		return plugin, false
+	})
+	t.Cleanup(func() { RegisterPluginSelector(nil) })
+}
+
+func Test_SelectFunctionVersion(t *testing.T) {
+	_, active, canary := newTestPlugins(t, "canary-select", "testStringOutput")
+	ctx := context.Background()
+
+	fnInfo, ok := functions.NewFunctionInfo("testBoolOutput_true", active, false)
+	require.True(t, ok)
+
+	// without a selector, the active version serves the call
+	selected, split := selectFunctionVersion(ctx, fnInfo)
+	assert.Same(t, active, selected.Plugin())
+	assert.False(t, split)
+
+	useCanary(t, active, canary)
+	selected, split = selectFunctionVersion(ctx, fnInfo)
+	assert.Same(t, canary, selected.Plugin())
+	assert.Equal(t, "testBoolOutput_true", selected.Name())
+	assert.True(t, split)
+
+	// a function that the canary doesn't export is served by the active version
+	fnInfo, ok = functions.NewFunctionInfo("testStringOutput", active, false)
+	require.True(t, ok)
+	selected, split = selectFunctionVersion(ctx, fnInfo)
+	assert.Same(t, active, selected.Plugin())
+	assert.True(t, split)
+}
+
+func Test_CallFunction_CanaryMetrics(t *testing.T) {
+	host, active, canary := newTestPlugins(t, "canary-metrics", "testStringOutput")
+	ctx := context.Background()
+	name := active.Name()
+	useCanary(t, active, canary)
+
+	canaryCalls := metrics.PluginVersionExecutionsNum.WithLabelValues(name, "canary-metrics", "success")
+	activeCalls := metrics.PluginVersionExecutionsNum.WithLabelValues(name, active.BuildId(), "success")
+	canaryBefore, activeBefore := testutil.ToFloat64(canaryCalls), testutil.ToFloat64(activeCalls)
+
+	info, err := host.CallFunctionByName(ctx, "testBoolOutput_true")
+	require.NoError(t, err)
+	assert.Equal(t, true, info.Result())
+
+	info, err = host.CallFunctionByName(ctx, "testStringOutput")
+	require.NoError(t, err)
+	assert.NotEmpty(t, info.Result())
+
+	// each call is measured for the version that served it
+	assert.Equal(t, canaryBefore+1, testutil.ToFloat64(canaryCalls))
+	assert.Equal(t, activeBefore+1, testutil.ToFloat64(activeCalls))
+}
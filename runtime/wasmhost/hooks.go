@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package wasmhost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/plugins"
+	"github.com/hypermodeinc/modus/runtime/utils"
+
+	"github.com/rs/xid"
+)
+
+// CallHook calls a lifecycle hook of a plugin, such as modus_init, in a module instance of its own,
+// and reports whether the plugin exports the hook.  The hook can call host functions as any function can.
+func (host *wasmHost) CallHook(ctx context.Context, plugin *plugins.Plugin, name string) (bool, error) {
+	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
+	defer span.Finish()
+
+	fnDef, ok := plugin.Module.ExportedFunctions()[name]
+	if !ok {
+		return false, nil
+	}
+	if len(fnDef.ParamTypes()) > 0 || len(fnDef.ResultTypes()) > 0 {
+		return true, fmt.Errorf("the %s hook must not have parameters or results", name)
+	}
+
+	inFlightExecutions.Add(1)
+	defer inFlightExecutions.Add(-1)
+
+	execInfo := &executionInfo{
+		executionId: xid.New().String(),
+		buffers:     utils.NewOutputBuffers(),
+	}
+
+	ctx = context.WithValue(ctx, utils.ExecutionIdContextKey, execInfo.executionId)
+	ctx = context.WithValue(ctx, utils.FunctionMessagesContextKey, execInfo.buffers)
+	ctx = context.WithValue(ctx, utils.ModelUsageContextKey, &execInfo.modelUsage)
+	ctx = context.WithValue(ctx, utils.ModerationFlagsContextKey, &execInfo.moderation)
+	ctx = context.WithValue(ctx, utils.FunctionNameContextKey, name)
+	ctx = context.WithValue(ctx, utils.PluginContextKey, plugin)
+	ctx = context.WithValue(ctx, utils.MetadataContextKey, plugin.Metadata)
+	ctx = context.WithValue(ctx, utils.WasmHostContextKey, host)
+
+	mod, err := host.GetModuleInstance(ctx, plugin, execInfo.buffers)
+	if err != nil {
+		return true, err
+	}
+	defer mod.Close(ctx)
+
+	wa := plugin.Language.NewWasmAdapter(mod)
+	ctx = context.WithValue(ctx, utils.WasmAdapterContextKey, wa)
+
+	logger.Info(ctx).
+		Str("plugin", plugin.Name()).
+		Str("hook", name).
+		Bool("user_visible", true).
+		Msg("Calling lifecycle hook.")
+
+	if _, err := mod.ExportedFunction(name).Call(ctx); err != nil {
+		return true, fmt.Errorf("the %s hook of plugin %s failed: %w", name, plugin.Name(), err)
+	}
+
+	return true, nil
+}
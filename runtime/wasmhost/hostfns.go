@@ -13,12 +13,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
 	"reflect"
 	"runtime/debug"
 	"time"
 
 	"github.com/hypermodeinc/modus/runtime/langsupport"
 	"github.com/hypermodeinc/modus/runtime/logger"
+	"github.com/hypermodeinc/modus/runtime/manifestdata"
 	"github.com/hypermodeinc/modus/runtime/plugins"
 	"github.com/hypermodeinc/modus/runtime/utils"
 
@@ -222,10 +224,17 @@ func (host *wasmHost) newHostFunction(modName, funcName string, fn any, opts ...
 			return
 		}
 
+		// The manifest can restrict the host functions that each plugin may call.
+		if !isHostFunctionAllowed(plugin, hf.name) {
+			logger.Error(ctx).Str("host_function", fullName).Bool("user_visible", true).Msg("The manifest does not allow this plugin to call this host function.")
+			return
+		}
+
 		// Get the execution plan for the host function
-		plan, ok := plugin.ExecutionPlans[fullName]
-		if !ok {
-			logger.Error(ctx).Str("host_function", fullName).Msg("Execution plan not found.")
+		plan, err := plugin.GetExecutionPlan(ctx, fullName)
+		if err != nil {
+			logger.Err(ctx, err).Str("host_function", fullName).Msg("Error getting execution plan.")
+			return
 		}
 
 		// Get the Wasm adapter
@@ -312,6 +321,21 @@ func (host *wasmHost) newHostFunction(modName, funcName string, fn any, opts ...
 	return hf, nil
 }
 
+// isHostFunctionAllowed reports whether the manifest allows a plugin to call a host function.
+// Plugins that the manifest doesn't list host functions for may call any of them.
+func isHostFunctionAllowed(plugin *plugins.Plugin, name string) bool {
+	allowed := manifestdata.GetManifest().Plugins[plugin.Name()].HostFunctions
+	if allowed == nil {
+		return true
+	}
+	for _, pattern := range allowed {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (host *wasmHost) instantiateHostFunctions(ctx context.Context) error {
 	span, ctx := utils.NewSentrySpanForCurrentFunc(ctx)
 	defer span.Finish()
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package wasmhost
+
+import (
+	"sync"
+	"time"
+)
+
+// FunctionStats are the invocation statistics of a function since the runtime started.
+// They are kept by plugin name rather than by plugin version, so they carry over when a plugin is reloaded.
+type FunctionStats struct {
+	Calls          int64     `json:"calls"`
+	Errors         int64     `json:"errors"`
+	LastCalledAt   time.Time `json:"lastCalledAt"`
+	LastDurationMs int64     `json:"lastDurationMs"`
+	LastError      string    `json:"lastError,omitempty"`
+}
+
+type functionStatsKey struct {
+	plugin   string
+	function string
+}
+
+var functionStats = make(map[functionStatsKey]*FunctionStats)
+var functionStatsMutex sync.Mutex
+
+func recordFunctionCall(plugin, function string, start time.Time, duration time.Duration, err error) {
+	functionStatsMutex.Lock()
+	defer functionStatsMutex.Unlock()
+
+	key := functionStatsKey{plugin, function}
+	stats, ok := functionStats[key]
+	if !ok {
+		stats = &FunctionStats{}
+		functionStats[key] = stats
+	}
+
+	stats.Calls++
+	stats.LastCalledAt = start
+	stats.LastDurationMs = duration.Milliseconds()
+	if err != nil {
+		stats.Errors++
+		stats.LastError = err.Error()
+	}
+}
+
+// GetFunctionStats returns the invocation statistics of a function of a plugin, if it has been called.
+func GetFunctionStats(plugin, function string) (FunctionStats, bool) {
+	functionStatsMutex.Lock()
+	defer functionStatsMutex.Unlock()
+
+	if stats, ok := functionStats[functionStatsKey{plugin, function}]; ok {
+		return *stats, true
+	}
+	return FunctionStats{}, false
+}
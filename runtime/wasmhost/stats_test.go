@@ -0,0 +1,57 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package wasmhost
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RecordFunctionCall(t *testing.T) {
+	_, ok := GetFunctionStats("stats-plugin", "fn")
+	assert.False(t, ok)
+
+	first := time.Now()
+	recordFunctionCall("stats-plugin", "fn", first, 20*time.Millisecond, errors.New("failed"))
+	second := first.Add(time.Second)
+	recordFunctionCall("stats-plugin", "fn", second, 5*time.Millisecond, nil)
+
+	stats, ok := GetFunctionStats("stats-plugin", "fn")
+	require.True(t, ok)
+	assert.Equal(t, int64(2), stats.Calls)
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.Equal(t, second, stats.LastCalledAt)
+	assert.Equal(t, int64(5), stats.LastDurationMs)
+	assert.Equal(t, "failed", stats.LastError)
+
+	// the stats of each function are separate
+	_, ok = GetFunctionStats("stats-plugin", "other")
+	assert.False(t, ok)
+}
+
+func Test_CallFunction_RecordsStats(t *testing.T) {
+	host, active, _ := newTestPlugins(t, "stats")
+	before, _ := GetFunctionStats(active.Name(), "testStringOutput")
+
+	start := time.Now()
+	_, err := host.CallFunctionByName(context.Background(), "testStringOutput")
+	require.NoError(t, err)
+
+	stats, ok := GetFunctionStats(active.Name(), "testStringOutput")
+	require.True(t, ok)
+	assert.Equal(t, before.Calls+1, stats.Calls)
+	assert.Equal(t, before.Errors, stats.Errors)
+	assert.False(t, stats.LastCalledAt.Before(start))
+}
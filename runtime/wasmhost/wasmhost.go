@@ -31,6 +31,7 @@ type WasmHost interface {
 	RegisterHostFunction(modName, funcName string, fn any, opts ...HostFunctionOption) error
 	CallFunction(ctx context.Context, fnInfo functions.FunctionInfo, parameters map[string]any) (ExecutionInfo, error)
 	CallFunctionByName(ctx context.Context, fnName string, paramValues ...any) (ExecutionInfo, error)
+	CallHook(ctx context.Context, plugin *plugins.Plugin, name string) (bool, error)
 	Close(ctx context.Context)
 	CompileModule(ctx context.Context, bytes []byte) (wazero.CompiledModule, error)
 	GetFunctionInfo(fnName string) (functions.FunctionInfo, error)
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { cache } from "..";
+
+// The mocks keep the values in a map, and ignore their time to live.
+const values = new Map<string, string>();
+let lastTtlMs: i64 = 0;
+
+mockImport("hypermode.cacheGet", (key: string): string | null => {
+  return values.has(key) ? values.get(key) : null;
+});
+
+mockImport(
+  "hypermode.cacheSet",
+  (key: string, value: string, ttlMs: i64): void => {
+    values.set(key, value);
+    lastTtlMs = ttlMs;
+  },
+);
+
+mockImport("hypermode.cacheDelete", (key: string): bool => {
+  return values.delete(key);
+});
+
+it("can set, get and remove a value", () => {
+  expect(cache.get("greeting")).toBe(null);
+
+  cache.set("greeting", "hello", 120000);
+  expect(lastTtlMs).toBe(120000);
+  expect(cache.get("greeting")).toBe("hello");
+
+  expect(cache.remove("greeting")).toBe(true);
+  expect(cache.remove("greeting")).toBe(false);
+  expect(cache.get("greeting")).toBe(null);
+});
+
+run();
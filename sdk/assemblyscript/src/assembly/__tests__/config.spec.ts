@@ -0,0 +1,37 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { config } from "..";
+
+const values = new Map<string, string>();
+values.set("apiBaseUrl", "https://example.com/api");
+values.set("modus.environment", "dev");
+
+mockImport("hypermode.getConfigValue", (key: string): string | null => {
+  return values.has(key) ? values.get(key) : null;
+});
+
+mockImport("hypermode.getConfigKeys", (): string[] => {
+  return values.keys();
+});
+
+it("can get a configuration value", () => {
+  expect(config.get("apiBaseUrl")).toBe("https://example.com/api");
+  expect(config.get(config.Key.Environment)).toBe("dev");
+  expect(config.get("missing")).toBe(null);
+});
+
+it("can get the configuration keys", () => {
+  const keys = config.keys();
+  expect(keys.length).toBe(2);
+  expect(keys.includes("apiBaseUrl")).toBe(true);
+});
+
+run();
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { expressions } from "..";
+
+let lastData = "";
+let lastLibs: string[] = [];
+
+// The mock returns the data for the expression "data",
+// and fails for any other expression.
+mockImport(
+  "hypermode.evaluateExpression",
+  (expression: string, data: string, libs: string[]): string | null => {
+    lastData = data;
+    lastLibs = libs;
+    if (expression != "data") {
+      return null;
+    }
+    return data == "" ? "null" : data;
+  },
+);
+
+it("can evaluate an expression", () => {
+  const result = expressions.evaluate<i32[]>("data", "[1,2,3]", [
+    expressions.Lib.Math,
+  ]);
+  expect(result.length).toBe(3);
+  expect(lastData).toBe("[1,2,3]");
+  expect(lastLibs.length).toBe(1);
+  expect(lastLibs[0]).toBe("math");
+});
+
+it("can evaluate an expression without data", () => {
+  expect(expressions.evaluate<string | null>("data")).toBe(null);
+  expect(lastData).toBe("");
+  expect(lastLibs.length).toBe(0);
+});
+
+run();
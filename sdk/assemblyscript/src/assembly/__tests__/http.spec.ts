@@ -8,7 +8,13 @@
  */
 
 import { expect, it, log, mockImport, run } from "as-test";
-import { Request, Response } from "../http";
+import {
+  HttpError,
+  PagedResponse,
+  PaginationOptions,
+  Request,
+  Response,
+} from "../http";
 import { http } from "..";
 import { JSON } from "json-as";
 
@@ -24,9 +30,22 @@ mockImport("hypermode.log", (level: string, message: string): void => {
   }
 });
 
-// eslint-disable-next-line @typescript-eslint/no-unused-vars
 mockImport("hypermode.httpFetch", (req: Request): Response => {
   const res = instantiate<Response>();
+  if (req.url == "https://example.com/unavailable") {
+    const err = instantiate<HttpError>();
+    const e = changetype<usize>(err);
+    store<string>(e, "unavailable", offsetof<HttpError>("code"));
+    store<string>(e, "Service Unavailable", offsetof<HttpError>("message"));
+    store<u16>(e, 503, offsetof<HttpError>("status"));
+    store<bool>(e, true, offsetof<HttpError>("retryable"));
+    const r = changetype<usize>(res);
+    store<u16>(r, 503, offsetof<Response>("status"));
+    store<string>(r, "Service Unavailable", offsetof<Response>("statusText"));
+    store<HttpError>(r, err, offsetof<Response>("error"));
+    return res;
+  }
+
   const txt = '{"x":1,"y":2,"z":3}';
   const len = String.UTF8.byteLength(txt);
   store<ArrayBuffer>(
@@ -44,6 +63,21 @@ mockImport("hypermode.httpFetch", (req: Request): Response => {
   return res;
 });
 
+mockImport(
+  "hypermode.httpFetchAll",
+  (req: Request, opts: PaginationOptions): PagedResponse => {
+    const res = instantiate<PagedResponse>();
+    const txt = opts.strategy == "cursor" ? "[1,2,3]" : "[]";
+    store<ArrayBuffer>(
+      changetype<usize>(res),
+      String.UTF8.encode(txt),
+      offsetof<PagedResponse>("items"),
+    );
+    store<i32>(changetype<usize>(res), 2, offsetof<PagedResponse>("pages"));
+    return res;
+  },
+);
+
 it("can run a http request", () => {
   expect(http.fetch("").status).toBe(200);
 });
@@ -60,6 +94,34 @@ it("can receive the status", () => {
   expect(res.statusText).toBe("OK");
 });
 
+it("has no error on success", () => {
+  const res = http.fetch("");
+  expect(res.error).toBe(null);
+});
+
+it("can receive a structured error", () => {
+  const res = http.fetch("https://example.com/unavailable");
+  expect(res.ok).toBe(false);
+  expect(res.status).toBe(503);
+  expect(res.error!.code).toBe("unavailable");
+  expect(res.error!.retryable).toBe(true);
+  expect(res.error!.toString()).toBe(
+    "HTTP request failed with status 503 Service Unavailable (unavailable)",
+  );
+});
+
+it("can fetch all pages", () => {
+  const opts = new PaginationOptions();
+  opts.strategy = "cursor";
+  opts.cursorParam = "cursor";
+  opts.cursorPath = "next";
+  const res = http.fetchAll(new Request("https://example.com/items"), opts);
+  expect(res.pages).toBe(2);
+  expect(res.truncated).toBe(false);
+  expect(res.error).toBe(null);
+  expect(res.json<i32[]>().length).toBe(3);
+});
+
 run();
 
 
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { EnqueueOptions, JobRequest } from "../jobs";
+import { jobs } from "..";
+
+let lastRequest: JobRequest | null = null;
+
+mockImport("hypermode.enqueueJob", (req: JobRequest): string | null => {
+  lastRequest = req;
+  return "job-1";
+});
+
+it("can enqueue a job", () => {
+  const id = jobs.enqueue("sendEmail", "hello");
+  expect(id).toBe("job-1");
+  expect(lastRequest!.functionName).toBe("sendEmail");
+  expect(lastRequest!.payload).toBe("hello");
+  expect(lastRequest!.maxAttempts).toBe(0);
+});
+
+it("can enqueue a job with options", () => {
+  const options = new EnqueueOptions();
+  options.maxAttempts = 3;
+  options.delayMs = 2000;
+  jobs.enqueue("sendEmail", "hello", options);
+  expect(lastRequest!.maxAttempts).toBe(3);
+  expect(lastRequest!.delayMs).toBe(2000);
+});
+
+run();
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { log } from "..";
+
+let lastLevel = "";
+let lastMessage = "";
+let lastFields = new Map<string, string>();
+
+mockImport(
+  "hypermode.writeLog",
+  (level: string, message: string, fields: Map<string, string>): void => {
+    lastLevel = level;
+    lastMessage = message;
+    lastFields = fields;
+  },
+);
+
+it("can write a log message with fields", () => {
+  const fields = new Map<string, string>();
+  fields.set("sku", "a-1");
+  fields.set("count", "2");
+  log.write(log.Level.Warning, "Low stock.", fields);
+
+  expect(lastLevel).toBe("warning");
+  expect(lastMessage).toBe("Low stock.");
+  expect(lastFields.get("sku")).toBe("a-1");
+  expect(lastFields.get("count")).toBe("2");
+});
+
+it("can write a log message without fields", () => {
+  log.write(log.Level.Info, "Started.");
+
+  expect(lastLevel).toBe("info");
+  expect(lastMessage).toBe("Started.");
+  expect(lastFields.size).toBe(0);
+});
+
+run();
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { JSON } from "json-as";
+import { Model, ModelInfo, ModelStreamChunk } from "../models";
+import { models } from "..";
+
+const chunks = ['{"text":"Hello"}', '{"text":", World!"}'];
+let position = 0;
+let lastInput = "";
+let closed = 0;
+
+mockImport("hypermode.lookupModel", (modelName: string): ModelInfo => {
+  return new ModelInfo(modelName);
+});
+
+mockImport(
+  "hypermode.startModelStream",
+  (modelName: string, input: string): string | null => {
+    if (modelName == "missing") {
+      return null;
+    }
+    lastInput = input;
+    position = 0;
+    return "stream-1";
+  },
+);
+
+mockImport(
+  "hypermode.readModelStream",
+  (streamId: string): ModelStreamChunk | null => {
+    const chunk = new ModelStreamChunk();
+    if (position == chunks.length) {
+      chunk.done = true;
+    } else {
+      chunk.data = chunks[position++];
+    }
+    return chunk;
+  },
+);
+
+mockImport("hypermode.closeModelStream", (streamId: string): void => {
+  closed++;
+});
+
+it("can read a model stream to the end", () => {
+  const model = models.getModel<TestModel>("test");
+  const stream = model.stream(<TestInput>{ prompt: "Say Hello." });
+  expect(lastInput).toBe('{"prompt":"Say Hello."}');
+
+  const received: string[] = [];
+  let data = stream.next();
+  while (data !== null) {
+    received.push(data);
+    data = stream.next();
+  }
+  expect(received.join(",")).toBe(chunks.join(","));
+
+  // An ended stream isn't closed again.
+  stream.close();
+  expect(closed).toBe(0);
+});
+
+it("can close a model stream", () => {
+  const model = models.getModel<TestModel>("test");
+  const stream = model.stream(<TestInput>{ prompt: "Say Hello." });
+  expect(stream.next()).toBe(chunks[0]);
+
+  stream.close();
+  stream.close();
+  expect(closed).toBe(1);
+  expect(stream.next()).toBe(null);
+});
+
+class TestModel extends Model<TestInput, string> {}
+
+@json
+class TestInput {
+  prompt!: string;
+}
+
+run();
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { nats } from "..";
+
+let lastSubject = "";
+let lastTimeoutMs: i64 = 0;
+
+mockImport(
+  "hypermode.natsPublish",
+  (hostName: string, subject: string, data: string): string | null => {
+    lastSubject = subject;
+    return "success";
+  },
+);
+
+mockImport(
+  "hypermode.natsRequest",
+  (
+    hostName: string,
+    subject: string,
+    data: string,
+    timeoutMs: i64,
+  ): string | null => {
+    lastSubject = subject;
+    lastTimeoutMs = timeoutMs;
+    return "reply: " + data;
+  },
+);
+
+it("can publish a message", () => {
+  nats.publish("my-nats", "orders.created", '{"id":1}');
+  expect(lastSubject).toBe("orders.created");
+});
+
+it("can send a request", () => {
+  expect(nats.request("my-nats", "orders.get", "1", 2000)).toBe("reply: 1");
+  expect(lastSubject).toBe("orders.get");
+  expect(lastTimeoutMs).toBe(2000);
+});
+
+run();
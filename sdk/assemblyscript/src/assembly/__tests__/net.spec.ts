@@ -0,0 +1,64 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { LookupResult, ProbeResult } from "../net";
+import { net } from "..";
+
+// The mocks resolve only "localhost", and reach only the host named "up".
+mockImport("hypermode.netLookup", (host: string): LookupResult | null => {
+  if (host == "") {
+    return null;
+  }
+  const res = instantiate<LookupResult>();
+  const r = changetype<usize>(res);
+  store<string>(r, host, offsetof<LookupResult>("host"));
+  if (host == "localhost") {
+    store<string[]>(r, ["127.0.0.1"], offsetof<LookupResult>("addresses"));
+  } else {
+    store<string>(r, "no such host", offsetof<LookupResult>("error"));
+  }
+  return res;
+});
+
+mockImport("hypermode.netProbe", (hostName: string): ProbeResult | null => {
+  if (hostName != "up" && hostName != "down") {
+    return null;
+  }
+  const res = instantiate<ProbeResult>();
+  const r = changetype<usize>(res);
+  store<string>(r, hostName, offsetof<ProbeResult>("hostName"));
+  if (hostName == "up") {
+    store<bool>(r, true, offsetof<ProbeResult>("reachable"));
+  } else {
+    store<string>(r, "connection failed", offsetof<ProbeResult>("error"));
+  }
+  return res;
+});
+
+it("can resolve a host name", () => {
+  const result = net.lookup("localhost");
+  expect(result.addresses.length).toBe(1);
+  expect(result.addresses[0]).toBe("127.0.0.1");
+  expect(result.error).toBe("");
+});
+
+it("reports a host name that can't be resolved", () => {
+  expect(net.lookup("missing.example.com").error).toBe("no such host");
+});
+
+it("can probe a host", () => {
+  expect(net.probe("up").reachable).toBe(true);
+
+  const result = net.probe("down");
+  expect(result.reachable).toBe(false);
+  expect(result.error).toBe("connection failed");
+});
+
+run();
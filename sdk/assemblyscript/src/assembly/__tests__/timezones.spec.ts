@@ -0,0 +1,104 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { TimeZoneInfo, TimeZoneTransition } from "../timezones";
+import { timezones } from "..";
+
+// The mocks only know the "UTC" and "Asia/Tokyo" time zones, and fail for any other.
+let lastInstant: i64 = 0;
+
+mockImport("hypermode.getTimeZones", (): string[] => {
+  return ["Asia/Tokyo", "UTC"];
+});
+
+mockImport(
+  "hypermode.getTimeZoneInfo",
+  (tz: string, instant: Date): TimeZoneInfo | null => {
+    lastInstant = instant.getTime();
+    if (tz != "Asia/Tokyo") {
+      return null;
+    }
+    const info = new TimeZoneInfo();
+    info.name = tz;
+    info.abbreviation = "JST";
+    info.offset = 9 * 60 * 60;
+    return info;
+  },
+);
+
+mockImport(
+  "hypermode.getTimeZoneTransitions",
+  (tz: string, start: Date, end: Date): TimeZoneTransition[] | null => {
+    if (tz != "UTC" && tz != "Asia/Tokyo") {
+      return null;
+    }
+    return [];
+  },
+);
+
+mockImport(
+  "hypermode.convertTimeZone",
+  (input: string, fromTz: string, toTz: string): string | null => {
+    if (fromTz != "UTC" || toTz != "Asia/Tokyo") {
+      return null;
+    }
+    return "2024-07-01T18:00:00+09:00";
+  },
+);
+
+mockImport(
+  "hypermode.formatTimeInZone",
+  (instant: Date, tz: string): string | null => {
+    lastInstant = instant.getTime();
+    if (tz != "Asia/Tokyo") {
+      return null;
+    }
+    return "2024-07-01T21:00:00+09:00";
+  },
+);
+
+const instant = new Date(Date.UTC(2024, 6, 1, 12));
+
+it("can get the time zones", () => {
+  const zones = timezones.getTimeZones();
+  expect(zones.length).toBe(2);
+  expect(zones[0]).toBe("Asia/Tokyo");
+});
+
+it("can get the info of a time zone", () => {
+  const info = timezones.getTimeZoneInfo("Asia/Tokyo", instant);
+  expect(info.abbreviation).toBe("JST");
+  expect(info.offset).toBe(9 * 60 * 60);
+  expect(info.isDST).toBe(false);
+  expect(lastInstant).toBe(instant.getTime());
+});
+
+it("can get the transitions of a time zone", () => {
+  const end = new Date(Date.UTC(2025, 6, 1, 12));
+  const transitions = timezones.getTimeZoneTransitions("UTC", instant, end);
+  expect(transitions.length).toBe(0);
+});
+
+it("can convert a time between time zones", () => {
+  const result = timezones.convertTime(
+    "2024-07-01T09:00",
+    "UTC",
+    "Asia/Tokyo",
+  );
+  expect(result).toBe("2024-07-01T18:00:00+09:00");
+});
+
+it("can format a time in a time zone", () => {
+  const result = timezones.formatTime(instant, "Asia/Tokyo");
+  expect(result).toBe("2024-07-01T21:00:00+09:00");
+  expect(lastInstant).toBe(instant.getTime());
+});
+
+run();
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { expect, it, mockImport, run } from "as-test";
+import { transforms } from "..";
+
+let lastArgs: string[] = [];
+
+// The mocks return canned results.
+mockImport(
+  "hypermode.flattenData",
+  (data: string, separator: string): string | null => {
+    lastArgs = [data, separator];
+    return '{"a.b":1,"a.c":2}';
+  },
+);
+
+mockImport(
+  "hypermode.groupData",
+  (data: string, key: string): string | null => {
+    lastArgs = [data, key];
+    return '{"x":[{"k":"x","v":1},{"k":"x","v":3}],"y":[{"k":"y","v":2}]}';
+  },
+);
+
+mockImport(
+  "hypermode.projectData",
+  (data: string, fields: string[]): string | null => {
+    lastArgs = [data].concat(fields);
+    return '[{"k":"x"},{"k":"y"}]';
+  },
+);
+
+mockImport(
+  "hypermode.joinData",
+  (
+    left: string,
+    right: string,
+    leftKey: string,
+    rightKey: string,
+    kind: string,
+  ): string | null => {
+    lastArgs = [left, right, leftKey, rightKey, kind];
+    return '[{"k":"x","v":1,"name":"ex"}]';
+  },
+);
+
+it("can flatten data", () => {
+  const result = transforms.flatten<Map<string, i32>>('{"a":{"b":1,"c":2}}');
+  expect(result.get("a.c")).toBe(2);
+  expect(lastArgs[1]).toBe(".");
+});
+
+it("can group data", () => {
+  const result = transforms.groupBy<Map<string, Item[]>>("[]", "k");
+  expect(result.get("x").length).toBe(2);
+  expect(result.get("y")[0].v).toBe(2);
+  expect(lastArgs[1]).toBe("k");
+});
+
+it("can project data", () => {
+  const result = transforms.project<Item[]>("[]", ["k"]);
+  expect(result.length).toBe(2);
+  expect(result[1].k).toBe("y");
+  expect(lastArgs[1]).toBe("k");
+});
+
+it("can join data", () => {
+  const result = transforms.join<Item[]>("[]", "[]", "k", "id");
+  expect(result.length).toBe(1);
+  expect(result[0].name).toBe("ex");
+  expect(lastArgs[4]).toBe("inner");
+});
+
+run();
+
+
+@json
+class Item {
+  k: string = "";
+  v: i32 = 0;
+  name: string = "";
+}
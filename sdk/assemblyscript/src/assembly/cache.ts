@@ -0,0 +1,53 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// @ts-expect-error: decorator
+@external("hypermode", "cacheGet")
+declare function hostCacheGet(key: string): string | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "cacheSet")
+declare function hostCacheSet(key: string, value: string, ttlMs: i64): void;
+
+// @ts-expect-error: decorator
+@external("hypermode", "cacheDelete")
+declare function hostCacheDelete(key: string): bool;
+
+/**
+ * Gets the value stored in the cache for the key.
+ *
+ * The cache is held in memory by the Modus runtime, and is shared by the
+ * function calls of the plugin.  It is bounded, so values can be evicted
+ * before they expire.
+ * @param key - The key of the value.
+ * @returns The value, or null if there is none.
+ */
+export function get(key: string): string | null {
+  return hostCacheGet(key);
+}
+
+/**
+ * Stores a value in the cache for the key.
+ * @param key - The key of the value.
+ * @param value - The value to store.
+ * @param ttlMs - How long the value is kept, in milliseconds.
+ * The value never expires if it is zero.
+ */
+export function set(key: string, value: string, ttlMs: i64 = 0): void {
+  hostCacheSet(key, value, ttlMs);
+}
+
+/**
+ * Removes the value stored in the cache for the key.
+ * @param key - The key of the value.
+ * @returns True if there was a value to remove.
+ */
+export function remove(key: string): bool {
+  return hostCacheDelete(key);
+}
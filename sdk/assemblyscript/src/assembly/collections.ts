@@ -14,7 +14,13 @@ export type CollectionStatus = string;
 export namespace CollectionStatus {
   export const Success = "success";
   export const Error = "error";
+  export const PartialSuccess = "partial";
+  export const Conflict = "conflict";
 }
+// pass as one of the namespaces to search every namespace of a collection.
+// each search result says which namespace it came from.
+export const AllNamespaces = "*";
+
 abstract class CollectionResult {
   collection: string;
   status: CollectionStatus;
@@ -43,6 +49,89 @@ export class CollectionMutationResult extends CollectionResult {
     this.operation = operation;
   }
 }
+// the result of a batch mutation, where each item can fail without failing the others.
+// the keys are those of the items that succeeded, and the failures are those that didn't.
+export class CollectionBatchMutationResult extends CollectionResult {
+  operation: string;
+  keys: string[] = [];
+  failures: CollectionMutationFailure[] = [];
+
+  constructor(
+    collection: string,
+    status: CollectionStatus,
+    error: string,
+    operation: string,
+  ) {
+    super(collection, status, error);
+    this.operation = operation;
+  }
+}
+// the result of upsertIfVersion or removeIfVersion. the version is the new version of the item
+// on success, or its current version when the status is conflict. a version of 0 means that the
+// item doesn't exist.
+export class CollectionVersionedMutationResult extends CollectionResult {
+  operation: string;
+  key: string;
+  version: i64;
+
+  constructor(
+    collection: string,
+    status: CollectionStatus,
+    error: string,
+    operation: string,
+    key: string,
+    version: i64 = 0,
+  ) {
+    super(collection, status, error);
+    this.operation = operation;
+    this.key = key;
+    this.version = version;
+  }
+}
+// the current version of an item, which is 0 if the item doesn't exist.
+export class CollectionItemVersion {
+  key: string;
+  version: i64;
+
+  constructor(key: string, version: i64) {
+    this.key = key;
+    this.version = version;
+  }
+}
+export class CollectionMutationFailure {
+  key: string;
+  error: string;
+
+  constructor(key: string, error: string) {
+    this.key = key;
+    this.error = error;
+  }
+}
+// an item to upsert with upsertItems. a key is generated if the key is empty.
+// the metadata is a JSON object, or an empty string if the item has no metadata.
+// the item is removed automatically after ttl seconds. if the ttl is zero, the ttl
+// of the collection is used, if it has one.
+export class CollectionItem {
+  key: string;
+  text: string;
+  labels: string[];
+  metadata: string;
+  ttl: i64;
+
+  constructor(
+    key: string,
+    text: string,
+    labels: string[] = [],
+    metadata: string = "",
+    ttl: i64 = 0,
+  ) {
+    this.key = key;
+    this.text = text;
+    this.labels = labels;
+    this.metadata = metadata;
+    this.ttl = ttl;
+  }
+}
 export class SearchMethodMutationResult extends CollectionResult {
   operation: string;
   searchMethod: string;
@@ -59,6 +148,57 @@ export class SearchMethodMutationResult extends CollectionResult {
     this.searchMethod = searchMethod;
   }
 }
+// the result of exporting a collection to object storage, or importing it from there.
+// the count is the number of items in the namespaces that were exported or imported.
+export class CollectionSnapshotResult extends CollectionResult {
+  operation: string;
+  uri: string;
+  namespaces: string[] = [];
+  count: i32 = 0;
+
+  constructor(
+    collection: string,
+    status: CollectionStatus,
+    error: string,
+    operation: string,
+    uri: string,
+  ) {
+    super(collection, status, error);
+    this.operation = operation;
+    this.uri = uri;
+  }
+}
+// a page of the items of a collection, read with getItems. the cursor reads the next page,
+// and is empty when there are no more items.
+export class CollectionItemsResult extends CollectionResult {
+  namespace: string;
+  items: CollectionItemObject[] = [];
+  cursor: string = "";
+
+  constructor(
+    collection: string,
+    status: CollectionStatus,
+    error: string,
+    namespace: string,
+  ) {
+    super(collection, status, error);
+    this.namespace = namespace;
+  }
+}
+// an item of a collection. the metadata is a JSON object, or empty if the item has none.
+export class CollectionItemObject {
+  key: string;
+  text: string;
+  labels: string[];
+  metadata: string;
+
+  constructor(key: string, text: string, labels: string[], metadata: string) {
+    this.key = key;
+    this.text = text;
+    this.labels = labels;
+    this.metadata = metadata;
+  }
+}
 export class CollectionSearchResult extends CollectionResult {
   searchMethod: string;
   objects: CollectionSearchResultObject[];
@@ -121,6 +261,37 @@ export class CollectionClassificationResult extends CollectionResult {
   }
 }
 
+// the clusters of near-duplicate items of a namespace.
+export class CollectionDuplicatesResult extends CollectionResult {
+  namespace: string;
+  searchMethod: string;
+  clusters: CollectionDuplicateCluster[] = [];
+
+  constructor(
+    collection: string,
+    status: CollectionStatus,
+    error: string,
+    namespace: string,
+    searchMethod: string,
+  ) {
+    super(collection, status, error);
+    this.namespace = namespace;
+    this.searchMethod = searchMethod;
+  }
+}
+
+// a group of items that are near-duplicates of each other, directly or through other items of the group.
+// the score is the lowest similarity of the pairs of items that link the group.
+export class CollectionDuplicateCluster {
+  keys: string[];
+  score: f64;
+
+  constructor(keys: string[], score: f64) {
+    this.keys = keys;
+    this.score = score;
+  }
+}
+
 export class CollectionClassificationLabelObject {
   label: string;
   confidence: f64;
@@ -163,6 +334,35 @@ declare function hostDeleteFromCollection(
   key: string,
 ): CollectionMutationResult;
 
+// @ts-expect-error: decorator
+@external("hypermode", "upsertToCollectionIfVersion")
+declare function hostUpsertToCollectionIfVersion(
+  collection: string,
+  namespace: string,
+  key: string,
+  text: string,
+  labels: string[],
+  metadata: string,
+  expectedVersion: i64,
+): CollectionVersionedMutationResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "deleteFromCollectionIfVersion")
+declare function hostDeleteFromCollectionIfVersion(
+  collection: string,
+  namespace: string,
+  key: string,
+  expectedVersion: i64,
+): CollectionVersionedMutationResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "getVersionFromCollection")
+declare function hostGetVersionFromCollection(
+  collection: string,
+  namespace: string,
+  key: string,
+): CollectionItemVersion;
+
 // @ts-expect-error: decorator
 @external("hypermode", "searchCollection")
 declare function hostSearchCollection(
@@ -183,6 +383,15 @@ declare function hostNnClassifyCollection(
   text: string,
 ): CollectionClassificationResult;
 
+// @ts-expect-error: decorator
+@external("hypermode", "findNearDuplicates")
+declare function hostFindNearDuplicates(
+  collection: string,
+  namespace: string,
+  searchMethod: string,
+  threshold: f64,
+): CollectionDuplicatesResult;
+
 // @ts-expect-error: decorator
 @external("hypermode", "recomputeSearchMethod")
 declare function hostRecomputeSearchMethod(
@@ -220,6 +429,20 @@ declare function hostGetTextsFromCollection(
 @external("hypermode", "getNamespacesFromCollection")
 declare function hostGetNamespacesFromCollection(collection: string): string[];
 
+// @ts-expect-error: decorator
+@external("hypermode", "exportCollection")
+declare function hostExportCollection(
+  collection: string,
+  uri: string,
+): CollectionSnapshotResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "importCollection")
+declare function hostImportCollection(
+  collection: string,
+  uri: string,
+): CollectionSnapshotResult;
+
 // @ts-expect-error: decorator
 @external("hypermode", "getVector")
 declare function hostGetVector(
@@ -248,6 +471,118 @@ declare function hostSearchCollectionByVector(
   returnText: bool,
 ): CollectionSearchResult;
 
+// @ts-expect-error: decorator
+@external("hypermode", "upsertToCollectionWithMetadata")
+declare function hostUpsertToCollectionWithMetadata(
+  collection: string,
+  namespace: string,
+  keys: string[],
+  texts: string[],
+  labels: string[][],
+  metadata: string[],
+): CollectionMutationResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "searchCollectionWithFilter")
+declare function hostSearchCollectionWithFilter(
+  collection: string,
+  namespaces: string[],
+  searchMethod: string,
+  text: string,
+  limit: i32,
+  returnText: bool,
+  filter: string,
+): CollectionSearchResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "searchCollectionByVectorWithFilter")
+declare function hostSearchCollectionByVectorWithFilter(
+  collection: string,
+  namespaces: string[],
+  searchMethod: string,
+  vector: f32[],
+  limit: i32,
+  returnText: bool,
+  filter: string,
+): CollectionSearchResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "hybridSearchCollection")
+declare function hostHybridSearchCollection(
+  collection: string,
+  namespaces: string[],
+  searchMethod: string,
+  text: string,
+  limit: i32,
+  returnText: bool,
+  fusion: string,
+  vectorWeight: f64,
+  filter: string,
+): CollectionSearchResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "weightedSearchCollection")
+declare function hostWeightedSearchCollection(
+  collection: string,
+  namespaces: string[],
+  searchMethods: string[],
+  weights: f64[],
+  text: string,
+  limit: i32,
+  returnText: bool,
+  filter: string,
+): CollectionSearchResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "getMetadata")
+declare function hostGetMetadata(
+  collection: string,
+  namespace: string,
+  key: string,
+): string;
+
+// @ts-expect-error: decorator
+@external("hypermode", "upsertBatchToCollection")
+declare function hostUpsertBatchToCollection(
+  collection: string,
+  namespace: string,
+  keys: string[],
+  texts: string[],
+  labels: string[][],
+  metadata: string[],
+): CollectionBatchMutationResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "upsertBatchToCollectionWithTtl")
+declare function hostUpsertBatchToCollectionWithTtl(
+  collection: string,
+  namespace: string,
+  keys: string[],
+  texts: string[],
+  labels: string[][],
+  metadata: string[],
+  ttls: i64[],
+): CollectionBatchMutationResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "getItemsFromCollection")
+declare function hostGetItemsFromCollection(
+  collection: string,
+  namespace: string,
+  sortBy: string,
+  descending: bool,
+  cursor: string,
+  limit: i32,
+): CollectionItemsResult;
+
+// @ts-expect-error: decorator
+@external("hypermode", "deleteBatchFromCollection")
+declare function hostDeleteBatchFromCollection(
+  collection: string,
+  namespace: string,
+  keys: string[],
+): CollectionBatchMutationResult;
+
 // add batch upsert
 export function upsertBatch(
   collection: string,
@@ -353,10 +688,14 @@ export function upsert(
   return result;
 }
 
-// remove data from in-mem storage and indexes
-export function remove(
+// upsert texts along with metadata, which can be used to filter searches.
+// the metadata for each text is a JSON object, or an empty string if the text has no metadata.
+export function upsertBatchWithMetadata(
   collection: string,
-  key: string,
+  keys: string[] | null,
+  texts: string[],
+  metadata: string[],
+  labelsArr: string[][] = [],
   namespace: string = "",
 ): CollectionMutationResult {
   if (collection.length == 0) {
@@ -365,41 +704,374 @@ export function remove(
       collection,
       CollectionStatus.Error,
       "Collection is empty.",
-      "delete",
+      "upsert",
     );
   }
-  if (key.length == 0) {
-    console.error("Key is empty.");
+  if (texts.length == 0) {
+    console.error("Texts is empty.");
     return new CollectionMutationResult(
       collection,
       CollectionStatus.Error,
-      "Key is empty.",
-      "delete",
+      "Texts is empty.",
+      "upsert",
     );
   }
-  const result = hostDeleteFromCollection(collection, namespace, key);
+  if (metadata.length != 0 && metadata.length != texts.length) {
+    console.error("Metadata must have the same length as texts.");
+    return new CollectionMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Metadata must have the same length as texts.",
+      "upsert",
+    );
+  }
+  let keysArr: string[] = [];
+  if (keys != null) {
+    keysArr = keys;
+  }
+
+  const result = hostUpsertToCollectionWithMetadata(
+    collection,
+    namespace,
+    keysArr,
+    texts,
+    labelsArr,
+    metadata,
+  );
   if (utils.resultIsInvalid(result)) {
-    console.error("Error deleting from Text index.");
+    console.error("Error upserting to Text index.");
     return new CollectionMutationResult(
       collection,
       CollectionStatus.Error,
-      "Error deleting from Text index.",
-      "delete",
+      "Error upserting to Text index.",
+      "upsert",
     );
   }
   return result;
 }
 
-// fetch embedders for collection & search method, run text through it and
-// search Text index for similar Texts, return the result keys
-// open question: how do i return a more expansive result from string array
-export function search(
+// upsert many items at once, such as when ingesting data. embeddings are computed in batches,
+// and each item can fail without failing the others, in which case the status is partial,
+// and the failures say which items failed and why.
+export function upsertItems(
   collection: string,
-  searchMethod: string,
-  text: string,
-  limit: i32,
+  items: CollectionItem[],
+  namespace: string = "",
+): CollectionBatchMutationResult {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return new CollectionBatchMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Collection is empty.",
+      "upsert",
+    );
+  }
+  if (items.length == 0) {
+    console.error("Items is empty.");
+    return new CollectionBatchMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Items is empty.",
+      "upsert",
+    );
+  }
+
+  const keys = new Array<string>(items.length);
+  const texts = new Array<string>(items.length);
+  const labelsArr = new Array<string[]>(items.length);
+  const metadata = new Array<string>(items.length);
+  const ttls = new Array<i64>(items.length);
+  let hasTtl = false;
+  for (let i = 0; i < items.length; i++) {
+    keys[i] = items[i].key;
+    texts[i] = items[i].text;
+    labelsArr[i] = items[i].labels;
+    metadata[i] = items[i].metadata;
+    if (items[i].ttl < 0) {
+      console.error("TTL must not be negative.");
+      return new CollectionBatchMutationResult(
+        collection,
+        CollectionStatus.Error,
+        "TTL must not be negative.",
+        "upsert",
+      );
+    }
+    ttls[i] = items[i].ttl;
+    if (ttls[i] > 0) hasTtl = true;
+  }
+
+  const result = hasTtl
+    ? hostUpsertBatchToCollectionWithTtl(
+        collection,
+        namespace,
+        keys,
+        texts,
+        labelsArr,
+        metadata,
+        ttls,
+      )
+    : hostUpsertBatchToCollection(
+        collection,
+        namespace,
+        keys,
+        texts,
+        labelsArr,
+        metadata,
+      );
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error upserting to Text index.");
+    return new CollectionBatchMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Error upserting to Text index.",
+      "upsert",
+    );
+  }
+  return result;
+}
+
+// remove many items at once. keys that aren't in the collection are reported as failures.
+export function removeBatch(
+  collection: string,
+  keys: string[],
+  namespace: string = "",
+): CollectionBatchMutationResult {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return new CollectionBatchMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Collection is empty.",
+      "delete",
+    );
+  }
+  if (keys.length == 0) {
+    console.error("Keys is empty.");
+    return new CollectionBatchMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Keys is empty.",
+      "delete",
+    );
+  }
+  const result = hostDeleteBatchFromCollection(collection, namespace, keys);
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error deleting from Text index.");
+    return new CollectionBatchMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Error deleting from Text index.",
+      "delete",
+    );
+  }
+  return result;
+}
+
+// remove data from in-mem storage and indexes
+export function remove(
+  collection: string,
+  key: string,
+  namespace: string = "",
+): CollectionMutationResult {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return new CollectionMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Collection is empty.",
+      "delete",
+    );
+  }
+  if (key.length == 0) {
+    console.error("Key is empty.");
+    return new CollectionMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Key is empty.",
+      "delete",
+    );
+  }
+  const result = hostDeleteFromCollection(collection, namespace, key);
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error deleting from Text index.");
+    return new CollectionMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Error deleting from Text index.",
+      "delete",
+    );
+  }
+  return result;
+}
+
+// upsert a text only if the item has the expected version, which is read with getVersion,
+// so that concurrent writers of an item don't overwrite each other's changes.
+// an expected version of 0 only upserts a new item. when the item has another version,
+// the status of the result is conflict, and the item can be read again and retried.
+// the metadata is a JSON object, or an empty string if the text has no metadata.
+export function upsertIfVersion(
+  collection: string,
+  key: string,
+  text: string,
+  expectedVersion: i64,
+  labels: string[] = [],
+  metadata: string = "",
+  namespace: string = "",
+): CollectionVersionedMutationResult {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return new CollectionVersionedMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Collection is empty.",
+      "upsert",
+      key,
+    );
+  }
+  if (key.length == 0) {
+    console.error("Key is empty.");
+    return new CollectionVersionedMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Key is empty.",
+      "upsert",
+      key,
+    );
+  }
+  if (text.length == 0) {
+    console.error("Text is empty.");
+    return new CollectionVersionedMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Text is empty.",
+      "upsert",
+      key,
+    );
+  }
+  if (expectedVersion < 0) {
+    console.error("Expected version is negative.");
+    return new CollectionVersionedMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Expected version is negative.",
+      "upsert",
+      key,
+    );
+  }
+  const result = hostUpsertToCollectionIfVersion(
+    collection,
+    namespace,
+    key,
+    text,
+    labels,
+    metadata,
+    expectedVersion,
+  );
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error upserting to Text index.");
+    return new CollectionVersionedMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Error upserting to Text index.",
+      "upsert",
+      key,
+    );
+  }
+  return result;
+}
+
+// remove an item only if it has the expected version, which is read with getVersion.
+// when the item has another version, the status of the result is conflict.
+export function removeIfVersion(
+  collection: string,
+  key: string,
+  expectedVersion: i64,
+  namespace: string = "",
+): CollectionVersionedMutationResult {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return new CollectionVersionedMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Collection is empty.",
+      "delete",
+      key,
+    );
+  }
+  if (key.length == 0) {
+    console.error("Key is empty.");
+    return new CollectionVersionedMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Key is empty.",
+      "delete",
+      key,
+    );
+  }
+  if (expectedVersion < 0) {
+    console.error("Expected version is negative.");
+    return new CollectionVersionedMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Expected version is negative.",
+      "delete",
+      key,
+    );
+  }
+  const result = hostDeleteFromCollectionIfVersion(
+    collection,
+    namespace,
+    key,
+    expectedVersion,
+  );
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error deleting from Text index.");
+    return new CollectionVersionedMutationResult(
+      collection,
+      CollectionStatus.Error,
+      "Error deleting from Text index.",
+      "delete",
+      key,
+    );
+  }
+  return result;
+}
+
+// get the current version of an item, which is 0 if the item doesn't exist, or -1 on an error.
+// each upsert of an item increments its version.
+export function getVersion(
+  collection: string,
+  key: string,
+  namespace: string = "",
+): i64 {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return -1;
+  }
+  if (key.length == 0) {
+    console.error("Key is empty.");
+    return -1;
+  }
+  const result = hostGetVersionFromCollection(collection, namespace, key);
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error getting version from Text index.");
+    return -1;
+  }
+  return result.version;
+}
+
+// fetch embedders for collection & search method, run text through it and
+// search Text index for similar Texts, return the result keys
+// open question: how do i return a more expansive result from string array
+export function search(
+  collection: string,
+  searchMethod: string,
+  text: string,
+  limit: i32,
   returnText: bool = false,
   namespaces: string[] = [],
+  filter: string = "",
 ): CollectionSearchResult {
   if (text.length == 0) {
     return new CollectionSearchResult(
@@ -410,14 +1082,27 @@ export function search(
       [],
     );
   }
-  const result = hostSearchCollection(
-    collection,
-    namespaces,
-    searchMethod,
-    text,
-    limit,
-    returnText,
-  );
+  // only items whose metadata matches the filter expression are returned,
+  // such as: tenant = 'acme' AND lang IN ('en', 'fr') AND year >= 2020
+  const result =
+    filter.length > 0
+      ? hostSearchCollectionWithFilter(
+          collection,
+          namespaces,
+          searchMethod,
+          text,
+          limit,
+          returnText,
+          filter,
+        )
+      : hostSearchCollection(
+          collection,
+          namespaces,
+          searchMethod,
+          text,
+          limit,
+          returnText,
+        );
   if (utils.resultIsInvalid(result)) {
     console.error("Error searching Text index.");
     return new CollectionSearchResult(
@@ -438,6 +1123,7 @@ export function searchByVector(
   limit: i32,
   returnText: bool = false,
   namespaces: string[] = [],
+  filter: string = "",
 ): CollectionSearchResult {
   if (vector.length == 0) {
     return new CollectionSearchResult(
@@ -448,20 +1134,143 @@ export function searchByVector(
       [],
     );
   }
-  const result = hostSearchCollectionByVector(
+  const result =
+    filter.length > 0
+      ? hostSearchCollectionByVectorWithFilter(
+          collection,
+          namespaces,
+          searchMethod,
+          vector,
+          limit,
+          returnText,
+          filter,
+        )
+      : hostSearchCollectionByVector(
+          collection,
+          namespaces,
+          searchMethod,
+          vector,
+          limit,
+          returnText,
+        );
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error searching Text index by vector.");
+    return new CollectionSearchResult(
+      collection,
+      CollectionStatus.Error,
+      "Error searching Text index by vector.",
+      searchMethod,
+      [],
+    );
+  }
+  return result;
+}
+
+export type Fusion = string;
+// eslint-disable-next-line @typescript-eslint/no-namespace
+export namespace Fusion {
+  // combine the results with reciprocal rank fusion, using only their ranks
+  export const RRF = "rrf";
+  // combine the vector similarity and keyword scores of the results, by weight
+  export const Weighted = "weighted";
+}
+
+// search by both the vector similarity of the search method and the keywords in the text,
+// so that texts containing exact terms from the query are found even when their embeddings
+// aren't the most similar. the vector weight, from 0 to 1, is only used by weighted fusion.
+export function hybridSearch(
+  collection: string,
+  searchMethod: string,
+  text: string,
+  limit: i32,
+  returnText: bool = false,
+  namespaces: string[] = [],
+  fusion: Fusion = Fusion.RRF,
+  vectorWeight: f64 = 0.5,
+  filter: string = "",
+): CollectionSearchResult {
+  if (text.length == 0) {
+    return new CollectionSearchResult(
+      collection,
+      CollectionStatus.Error,
+      "Text is empty.",
+      searchMethod,
+      [],
+    );
+  }
+  const result = hostHybridSearchCollection(
     collection,
     namespaces,
     searchMethod,
-    vector,
+    text,
     limit,
     returnText,
+    fusion,
+    vectorWeight,
+    filter,
   );
   if (utils.resultIsInvalid(result)) {
-    console.error("Error searching Text index by vector.");
+    console.error("Error hybrid searching Text index.");
     return new CollectionSearchResult(
       collection,
       CollectionStatus.Error,
-      "Error searching Text index by vector.",
+      "Error hybrid searching Text index.",
+      searchMethod,
+      [],
+    );
+  }
+  return result;
+}
+
+// search with several search methods, such as one that embeds the title of the items
+// and one that embeds their body, and combine the results by the weight of each search method.
+// the score of each result is the weighted sum of its similarity in each search method,
+// with the weights scaled to add up to 1.
+export function weightedSearch(
+  collection: string,
+  searchMethods: string[],
+  weights: f64[],
+  text: string,
+  limit: i32,
+  returnText: bool = false,
+  namespaces: string[] = [],
+  filter: string = "",
+): CollectionSearchResult {
+  const searchMethod = searchMethods.join(",");
+  if (text.length == 0) {
+    return new CollectionSearchResult(
+      collection,
+      CollectionStatus.Error,
+      "Text is empty.",
+      searchMethod,
+      [],
+    );
+  }
+  if (searchMethods.length == 0 || searchMethods.length != weights.length) {
+    return new CollectionSearchResult(
+      collection,
+      CollectionStatus.Error,
+      "Each search method must have a weight.",
+      searchMethod,
+      [],
+    );
+  }
+  const result = hostWeightedSearchCollection(
+    collection,
+    namespaces,
+    searchMethods,
+    weights,
+    text,
+    limit,
+    returnText,
+    filter,
+  );
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error weighted searching Text index.");
+    return new CollectionSearchResult(
+      collection,
+      CollectionStatus.Error,
+      "Error weighted searching Text index.",
       searchMethod,
       [],
     );
@@ -507,6 +1316,43 @@ export function nnClassify(
   return result;
 }
 
+// group the items of a namespace whose vectors in the search method are at least as similar
+// as the threshold, such as 0.95 for the cosine similarity, to clean up a dataset.
+// only clusters of two or more items are returned, largest first.
+export function findNearDuplicates(
+  collection: string,
+  searchMethod: string,
+  threshold: f64,
+  namespace: string = "",
+): CollectionDuplicatesResult {
+  if (searchMethod.length == 0) {
+    return new CollectionDuplicatesResult(
+      collection,
+      CollectionStatus.Error,
+      "Search method is empty.",
+      namespace,
+      searchMethod,
+    );
+  }
+  const result = hostFindNearDuplicates(
+    collection,
+    namespace,
+    searchMethod,
+    threshold,
+  );
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error finding near-duplicates in Text index.");
+    return new CollectionDuplicatesResult(
+      collection,
+      CollectionStatus.Error,
+      "Error finding near-duplicates in Text index.",
+      namespace,
+      searchMethod,
+    );
+  }
+  return result;
+}
+
 export function recomputeSearchMethod(
   collection: string,
   searchMethod: string,
@@ -620,6 +1466,86 @@ export function getNamespaces(collection: string): string[] {
   return hostGetNamespacesFromCollection(collection);
 }
 
+// export every namespace of the collection, with its items, vectors and indexes,
+// to object storage at a uri such as s3://bucket/path/file or gs://bucket/path/file.
+export function exportCollection(
+  collection: string,
+  uri: string,
+): CollectionSnapshotResult {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return new CollectionSnapshotResult(
+      collection,
+      CollectionStatus.Error,
+      "Collection is empty.",
+      "export",
+      uri,
+    );
+  }
+  if (uri.length == 0) {
+    console.error("URI is empty.");
+    return new CollectionSnapshotResult(
+      collection,
+      CollectionStatus.Error,
+      "URI is empty.",
+      "export",
+      uri,
+    );
+  }
+  const result = hostExportCollection(collection, uri);
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error exporting collection.");
+    return new CollectionSnapshotResult(
+      collection,
+      CollectionStatus.Error,
+      "Error exporting collection.",
+      "export",
+      uri,
+    );
+  }
+  return result;
+}
+
+// upsert the items of an archive written by exportCollection into the collection. their vectors are used
+// for each search method that has the same embedder as when they were exported, and computed otherwise.
+export function importCollection(
+  collection: string,
+  uri: string,
+): CollectionSnapshotResult {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return new CollectionSnapshotResult(
+      collection,
+      CollectionStatus.Error,
+      "Collection is empty.",
+      "import",
+      uri,
+    );
+  }
+  if (uri.length == 0) {
+    console.error("URI is empty.");
+    return new CollectionSnapshotResult(
+      collection,
+      CollectionStatus.Error,
+      "URI is empty.",
+      "import",
+      uri,
+    );
+  }
+  const result = hostImportCollection(collection, uri);
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error importing collection.");
+    return new CollectionSnapshotResult(
+      collection,
+      CollectionStatus.Error,
+      "Error importing collection.",
+      "import",
+      uri,
+    );
+  }
+  return result;
+}
+
 export function getVector(
   collection: string,
   searchMethod: string,
@@ -656,3 +1582,69 @@ export function getLabels(
   }
   return hostGetLabels(collection, namespace, key);
 }
+
+// get the metadata upserted with the text for the key, as a JSON object
+export function getMetadata(
+  collection: string,
+  key: string,
+  namespace: string = "",
+): string {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return "{}";
+  }
+  if (key.length == 0) {
+    console.error("Key is empty.");
+    return "{}";
+  }
+  return hostGetMetadata(collection, namespace, key);
+}
+
+export type SortBy = string;
+// eslint-disable-next-line @typescript-eslint/no-namespace
+export namespace SortBy {
+  // sort the items by key
+  export const Key = "key";
+  // sort the items by when they were last upserted
+  export const Insertion = "insertion";
+}
+
+// read a page of the items of a collection. pass the cursor of the result to read the next
+// page, until the cursor is empty. pages don't skip or repeat items when items are upserted
+// or removed between reads. the limit is 100 when zero, and at most 1000.
+export function getItems(
+  collection: string,
+  namespace: string = "",
+  sortBy: SortBy = SortBy.Key,
+  descending: bool = false,
+  cursor: string = "",
+  limit: i32 = 0,
+): CollectionItemsResult {
+  if (collection.length == 0) {
+    console.error("Collection is empty.");
+    return new CollectionItemsResult(
+      collection,
+      CollectionStatus.Error,
+      "Collection is empty.",
+      namespace,
+    );
+  }
+  const result = hostGetItemsFromCollection(
+    collection,
+    namespace,
+    sortBy,
+    descending,
+    cursor,
+    limit,
+  );
+  if (utils.resultIsInvalid(result)) {
+    console.error("Error getting items from collection.");
+    return new CollectionItemsResult(
+      collection,
+      CollectionStatus.Error,
+      "Error getting items from collection.",
+      namespace,
+    );
+  }
+  return result;
+}
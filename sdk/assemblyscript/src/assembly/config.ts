@@ -0,0 +1,47 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// @ts-expect-error: decorator
+@external("hypermode", "getConfigValue")
+declare function hostGetConfigValue(key: string): string | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "getConfigKeys")
+declare function hostGetConfigKeys(): string[];
+
+/**
+ * Reserved keys for the deployment metadata provided by the runtime.
+ */
+export namespace Key {
+  export const Environment = "modus.environment";
+  export const Namespace = "modus.namespace";
+  export const Region = "modus.region";
+  export const RuntimeVersion = "modus.runtime.version";
+  export const PluginName = "modus.plugin.name";
+  export const PluginVersion = "modus.plugin.version";
+  export const PluginBuildId = "modus.plugin.buildId";
+}
+
+/**
+ * Gets the value of a configuration item of the app, such as a variable of
+ * the manifest, or deployment metadata such as `Key.Environment`.
+ * @param key - The key of the configuration item.
+ * @returns The value, or null if it is not defined.
+ */
+export function get(key: string): string | null {
+  return hostGetConfigValue(key);
+}
+
+/**
+ * Gets the keys of all configuration items available to the calling function.
+ * @returns The keys of the configuration items.
+ */
+export function keys(): string[] {
+  return hostGetConfigKeys();
+}
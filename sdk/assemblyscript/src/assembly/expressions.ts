@@ -0,0 +1,54 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { JSON } from "json-as";
+import * as utils from "./utils";
+
+// @ts-expect-error: decorator
+@external("hypermode", "evaluateExpression")
+declare function hostEvaluateExpression(
+  expression: string,
+  data: string,
+  libs: string[],
+): string | null;
+
+/**
+ * The optional function libraries that can be enabled for an expression.
+ */
+export namespace Lib {
+  export const Strings = "strings";
+  export const Math = "math";
+  export const Encoders = "encoders";
+  export const Lists = "lists";
+  export const Sets = "sets";
+}
+
+/**
+ * Evaluates a CEL expression (https://cel.dev) in the Modus runtime.
+ * @typeParam T - The type of the result.
+ * @param expression - The expression, which can access the data as `data`.
+ * @param data - The data, as a JSON string.  Use `JSON.stringify` to pass
+ * an object.  The data is null if it is empty.
+ * @param libs - The names of the optional function libraries that the
+ * expression uses, such as `Lib.Strings`.
+ * @returns The result of the expression.
+ */
+export function evaluate<T>(
+  expression: string,
+  data: string = "",
+  libs: string[] = [],
+): T {
+  const result = hostEvaluateExpression(expression, data, libs);
+  if (utils.resultIsInvalid(result)) {
+    throw new Error(
+      "Failed to evaluate the expression. Check the logs for more information.",
+    );
+  }
+  return JSON.parse<T>(result!);
+}
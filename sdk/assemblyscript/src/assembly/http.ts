@@ -15,6 +15,13 @@ const emptyArrayBuffer = new ArrayBuffer(0);
 @external("hypermode", "httpFetch")
 declare function fetchFromHost(request: Request): Response;
 
+// @ts-expect-error: decorator
+@external("hypermode", "httpFetchAll")
+declare function fetchAllFromHost(
+  request: Request,
+  options: PaginationOptions,
+): PagedResponse;
+
 /**
  * Performs an HTTP request and returns the response.
  * @param requestOrUrl - Either a `Request` instance or a URL string.
@@ -40,6 +47,30 @@ export function fetch<T>(
     throw new Error("HTTP fetch failed. Check the logs for more information.");
   }
 
+  // If no response was received, throw the error to the caller.
+  // Otherwise, the caller can inspect the status code and error on the response.
+  if (response.status == 0 && response.error) {
+    throw new Error(response.error!.toString());
+  }
+
+  return response;
+}
+
+/**
+ * Requests all pages of a paginated API, following the pagination strategy of the options,
+ * and returns the items collected from them.
+ * @param request - The request for the first page.
+ * @param options - The pagination options.
+ * @returns The items collected from all pages.
+ */
+export function fetchAll(
+  request: Request,
+  options: PaginationOptions,
+): PagedResponse {
+  const response = fetchAllFromHost(request, options);
+  if (!response) {
+    throw new Error("HTTP fetch failed. Check the logs for more information.");
+  }
   return response;
 }
 
@@ -214,6 +245,12 @@ export class Response {
    */
   readonly body: ArrayBuffer = emptyArrayBuffer;
 
+  /**
+   * The error, if the request could not be completed,
+   * or if the server responded with an error status code.
+   */
+  readonly error: HttpError | null = null;
+
   private constructor() {}
 
   /**
@@ -241,6 +278,145 @@ export class Response {
   }
 }
 
+/**
+ * Options for fetching all pages of a paginated API.
+ */
+export class PaginationOptions {
+  /**
+   * The pagination strategy, one of "cursor", "page", or "link".
+   */
+  strategy: string = "";
+
+  /**
+   * The path to the array of items in each response body (ex: "data.items").
+   * If empty, the response body itself must be an array.
+   */
+  itemsPath: string = "";
+
+  /**
+   * The query parameter used to send the cursor (cursor strategy only).
+   */
+  cursorParam: string = "";
+
+  /**
+   * The path to the next cursor in each response body (cursor strategy only).
+   */
+  cursorPath: string = "";
+
+  /**
+   * The query parameter used to send the page number (page strategy only).
+   */
+  pageParam: string = "";
+
+  /**
+   * The number of the first page (page strategy only). Defaults to 1.
+   */
+  startPage: i32 = 0;
+
+  /**
+   * The query parameter used to send the page size, if any.
+   */
+  pageSizeParam: string = "";
+
+  /**
+   * The number of items requested per page, if any.
+   */
+  pageSize: i32 = 0;
+
+  /**
+   * The maximum number of pages to request.
+   */
+  maxPages: i32 = 0;
+
+  /**
+   * The maximum number of items to return.
+   */
+  maxItems: i32 = 0;
+}
+
+/**
+ * Represents the items collected from all pages of a paginated API.
+ */
+export class PagedResponse {
+  /**
+   * The items collected from all pages, as a raw JSON array.
+   */
+  readonly items: ArrayBuffer = emptyArrayBuffer;
+
+  /**
+   * The number of pages that were requested.
+   */
+  readonly pages: i32 = 0;
+
+  /**
+   * Whether a limit was reached before the last page.
+   */
+  readonly truncated: bool = false;
+
+  /**
+   * The error, if a page request failed.
+   * The items of the previous pages are still returned.
+   */
+  readonly error: HttpError | null = null;
+
+  private constructor() {}
+
+  /**
+   * Deserializes the items as JSON, and returns an object of type `T`.
+   * @typeParam T - The type of object to return, typically an array.
+   * @returns An object of type `T` represented by the JSON items.
+   * @throws An error if the items cannot be deserialized into the specified type.
+   */
+  json<T>(): T {
+    return Content.from(this.items).json<T>();
+  }
+}
+
+/**
+ * Describes a failed HTTP request.
+ */
+export class HttpError {
+  /**
+   * A short identifier for the kind of error, such as "not_found", "rate_limited" or "timeout".
+   */
+  readonly code: string = "";
+
+  /**
+   * A description of the error.
+   */
+  readonly message: string = "";
+
+  /**
+   * The HTTP status code, or zero if no response was received.
+   */
+  readonly status: u16 = 0;
+
+  /**
+   * The subset of the response headers that are useful for handling the error,
+   * such as Retry-After and rate limit headers.
+   */
+  readonly headers: Headers | null = null;
+
+  /**
+   * The beginning of the response body, if any.
+   */
+  readonly bodySnippet: string = "";
+
+  /**
+   * Whether the request may succeed if it is retried.
+   */
+  readonly retryable: bool = false;
+
+  private constructor() {}
+
+  toString(): string {
+    if (this.status == 0) {
+      return `HTTP request failed (${this.code}): ${this.message}`;
+    }
+    return `HTTP request failed with status ${this.status} ${this.message} (${this.code})`;
+  }
+}
+
 /**
  * Represents an HTTP header.
  */
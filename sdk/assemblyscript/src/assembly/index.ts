@@ -30,3 +30,30 @@ export { vectors };
 
 import * as auth from "./auth";
 export { auth };
+
+import * as jobs from "./jobs";
+export { jobs };
+
+import * as log from "./log";
+export { log };
+
+import * as timezones from "./timezones";
+export { timezones };
+
+import * as cache from "./cache";
+export { cache };
+
+import * as expressions from "./expressions";
+export { expressions };
+
+import * as transforms from "./transforms";
+export { transforms };
+
+import * as config from "./config";
+export { config };
+
+import * as nats from "./nats";
+export { nats };
+
+import * as net from "./net";
+export { net };
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// @ts-expect-error: decorator
+@external("hypermode", "enqueueJob")
+declare function hostEnqueueJob(request: JobRequest): string | null;
+
+/**
+ * Describes a job to add to the queue.
+ */
+export class JobRequest {
+  constructor(
+    public readonly functionName: string,
+    public readonly payload: string,
+    public readonly maxAttempts: i32 = 0,
+    public readonly delayMs: i64 = 0,
+  ) {}
+}
+
+/**
+ * Provides options for enqueuing a job.
+ */
+export class EnqueueOptions {
+  /**
+   * How many times the job is attempted before it is moved to the dead-letter list.
+   * The runtime's default is used if it is zero.
+   */
+  maxAttempts: i32 = 0;
+
+  /**
+   * How long to wait, in milliseconds, before the job is first attempted.
+   */
+  delayMs: i64 = 0;
+}
+
+/**
+ * Adds a job to the queue, and returns the job's ID.
+ *
+ * The named function is invoked with the payload until it succeeds, or until the maximum
+ * number of attempts is reached.  The function must be exported by the plugin, and take
+ * either no parameters or a single string.
+ * @param functionName - The name of the function that handles the job.
+ * @param payload - The payload to pass to the function.
+ * @param options - The optional parameters for the job.
+ * @returns The ID of the job.
+ */
+export function enqueue(
+  functionName: string,
+  payload: string = "",
+  options: EnqueueOptions = new EnqueueOptions(),
+): string {
+  if (functionName.length == 0) {
+    throw new Error("A function name is required.");
+  }
+
+  const request = new JobRequest(
+    functionName,
+    payload,
+    options.maxAttempts,
+    options.delayMs,
+  );
+
+  const id = hostEnqueueJob(request);
+  if (id === null) {
+    throw new Error(`Failed to enqueue a job for function ${functionName}.`);
+  }
+
+  return id;
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// @ts-expect-error: decorator
+@external("hypermode", "writeLog")
+declare function hostWriteLog(
+  level: string,
+  message: string,
+  fields: Map<string, string>,
+): void;
+
+/**
+ * The levels of log messages.
+ */
+export namespace Level {
+  export const Debug = "debug";
+  export const Info = "info";
+  export const Warning = "warning";
+  export const Error = "error";
+}
+
+/**
+ * Logs a message at the given level, with fields that describe it.
+ *
+ * The fields are included as attributes in the Modus runtime's log output,
+ * and are returned to the caller with the message, in the extensions of the
+ * GraphQL response.  Messages at the error level are also returned to the
+ * caller as GraphQL errors.
+ * @param level - The level of the message, such as "info" or "warning".
+ * @param message - The message to log.
+ * @param fields - The fields that describe the message.
+ */
+export function write(
+  level: string,
+  message: string,
+  fields: Map<string, string> = new Map<string, string>(),
+): void {
+  hostWriteLog(level, message, fields);
+}
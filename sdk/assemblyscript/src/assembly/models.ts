@@ -23,6 +23,21 @@ declare function hostInvokeModel(
   input: string,
 ): string | null;
 
+// @ts-expect-error: decorator
+@external("hypermode", "startModelStream")
+declare function hostStartModelStream(
+  modelName: string,
+  input: string,
+): string | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "readModelStream")
+declare function hostReadModelStream(streamId: string): ModelStreamChunk | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "closeModelStream")
+declare function hostCloseModelStream(streamId: string): void;
+
 class ModusModelFactory implements ModelFactory {
   constructor() {
     // Note, we assign this to a static property on the base Model class so that it can be accessed
@@ -91,6 +106,80 @@ export abstract class Model<TInput = unknown, TOutput = unknown> {
 
     return JSON.parse<TOutput>(outputJson);
   }
+
+  /**
+   * Invokes the model with streaming enabled.
+   * The stream should be read to the end, or closed.
+   * @param input The input object to pass to the model.
+   * @returns A stream of the model's response.
+   */
+  stream(input: TInput): ModelStream {
+    const modelName = this.info.name;
+    const inputJson = JSON.stringify(input);
+    if (this.debug) {
+      console.debug(`Streaming ${modelName} model with input: ${inputJson}`);
+    }
+
+    const id = hostStartModelStream(modelName, inputJson);
+    if (id === null) {
+      throw new Error(`Failed to start a stream of ${modelName} model.`);
+    }
+
+    return new ModelStream(id);
+  }
+}
+
+/**
+ * A chunk of a model's streamed response, as returned by the host.
+ */
+export class ModelStreamChunk {
+  data: string = "";
+  done: bool = false;
+}
+
+/**
+ * A stream of a model's response, which is read one chunk at a time as the model
+ * generates it.  When the function is called by a GraphQL subscription, each chunk
+ * that is read is also sent to the client.
+ */
+export class ModelStream {
+  private done: bool = false;
+
+  constructor(public readonly id: string) {}
+
+  /**
+   * Waits for the next chunk of the model's response.
+   * @returns The data of the chunk, which is usually a JSON object in the format of
+   * the model's provider, or null when the stream has ended.
+   */
+  next(): string | null {
+    if (this.done) {
+      return null;
+    }
+
+    const chunk = hostReadModelStream(this.id);
+    if (chunk === null) {
+      this.done = true;
+      throw new Error(`Failed to read model stream ${this.id}.`);
+    }
+    if (chunk.done) {
+      this.done = true;
+      return null;
+    }
+
+    return chunk.data;
+  }
+
+  /**
+   * Stops the stream before it has been read to the end.
+   * It does nothing if the stream has already ended.
+   */
+  close(): void {
+    if (!this.done) {
+      this.done = true;
+      hostCloseModelStream(this.id);
+    }
+  }
 }
 
 const factory = new ModusModelFactory();
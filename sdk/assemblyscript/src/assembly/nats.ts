@@ -0,0 +1,63 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import * as utils from "./utils";
+
+// @ts-expect-error: decorator
+@external("hypermode", "natsPublish")
+declare function hostNatsPublish(
+  hostName: string,
+  subject: string,
+  data: string,
+): string | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "natsRequest")
+declare function hostNatsRequest(
+  hostName: string,
+  subject: string,
+  data: string,
+  timeoutMs: i64,
+): string | null;
+
+/**
+ * Publishes a message to the subject, on a NATS host defined in the manifest.
+ * @param hostName - The name of the NATS host.
+ * @param subject - The subject to publish to.
+ * @param data - The message data.
+ */
+export function publish(hostName: string, subject: string, data: string): void {
+  const response = hostNatsPublish(hostName, subject, data);
+  if (utils.resultIsInvalid(response)) {
+    throw new Error("Failed to publish the NATS message.");
+  }
+}
+
+/**
+ * Sends a request message to the subject, on a NATS host defined in the
+ * manifest, and returns the reply.
+ * @param hostName - The name of the NATS host.
+ * @param subject - The subject to send the request to.
+ * @param data - The request data.
+ * @param timeoutMs - How long to wait for a reply, in milliseconds.
+ * The default timeout of the runtime is used if it is zero.
+ * @returns The reply data.
+ */
+export function request(
+  hostName: string,
+  subject: string,
+  data: string,
+  timeoutMs: i64 = 0,
+): string {
+  const response = hostNatsRequest(hostName, subject, data, timeoutMs);
+  if (utils.resultIsInvalid(response)) {
+    throw new Error("Failed to send the NATS request.");
+  }
+  return response!;
+}
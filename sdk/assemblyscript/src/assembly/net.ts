@@ -0,0 +1,137 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import * as utils from "./utils";
+
+// @ts-expect-error: decorator
+@external("hypermode", "netLookup")
+declare function hostNetLookup(host: string): LookupResult | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "netProbe")
+declare function hostNetProbe(hostName: string): ProbeResult | null;
+
+/**
+ * Resolves a host name using the DNS resolver of the runtime's environment.
+ * A host name that can't be resolved is reported by the `error` field of the
+ * result, rather than thrown.
+ * @param host - The host name to resolve.
+ * @returns The result of the resolution.
+ */
+export function lookup(host: string): LookupResult {
+  const result = hostNetLookup(host);
+  if (utils.resultIsInvalid(result)) {
+    throw new Error(
+      "Failed to resolve the host name. Check the logs for more information.",
+    );
+  }
+  return result!;
+}
+
+/**
+ * Checks whether the runtime can reach a host defined in the manifest, by
+ * resolving its address and opening a connection to it.  No request is sent
+ * to the host.  A host that can't be reached is reported by the `error` field
+ * of the result, rather than thrown.
+ * @param hostName - The name of the host in the manifest.
+ * @returns The result of the probe.
+ */
+export function probe(hostName: string): ProbeResult {
+  const result = hostNetProbe(hostName);
+  if (utils.resultIsInvalid(result)) {
+    throw new Error(
+      "Failed to probe the host. Check the logs for more information.",
+    );
+  }
+  return result!;
+}
+
+/**
+ * The result of resolving a host name.
+ */
+export class LookupResult {
+  /**
+   * The host name that was resolved.
+   */
+  readonly host: string = "";
+
+  /**
+   * The IP addresses of the host.
+   */
+  readonly addresses: string[] = [];
+
+  /**
+   * The canonical name of the host, if known.
+   */
+  readonly canonName: string = "";
+
+  /**
+   * How long the resolution took, in milliseconds.
+   */
+  readonly durationMs: i64 = 0;
+
+  /**
+   * Why the host name could not be resolved, or empty if it was.
+   */
+  readonly error: string = "";
+
+  private constructor() {}
+}
+
+/**
+ * The result of probing a host defined in the manifest.
+ */
+export class ProbeResult {
+  /**
+   * The name of the host in the manifest.
+   */
+  readonly hostName: string = "";
+
+  /**
+   * The type of the host, such as "http" or "postgresql".
+   */
+  readonly hostType: string = "";
+
+  /**
+   * The host and port that was probed.
+   */
+  readonly address: string = "";
+
+  /**
+   * Whether a connection to the host was established.
+   */
+  readonly reachable: bool = false;
+
+  /**
+   * The IP address that the host name resolved to.
+   */
+  readonly resolvedIp: string = "";
+
+  /**
+   * How long resolving the host name took, in milliseconds.
+   */
+  readonly dnsMs: i64 = 0;
+
+  /**
+   * How long opening the connection took, in milliseconds.
+   */
+  readonly connectMs: i64 = 0;
+
+  /**
+   * How long the TLS handshake took, in milliseconds, if the host uses TLS.
+   */
+  readonly tlsMs: i64 = 0;
+
+  /**
+   * Why the host could not be reached, or empty if it was.
+   */
+  readonly error: string = "";
+
+  private constructor() {}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// @ts-expect-error: decorator
+@external("hypermode", "getTimeZones")
+declare function hostGetTimeZones(): string[];
+
+// @ts-expect-error: decorator
+@external("hypermode", "getTimeZoneInfo")
+declare function hostGetTimeZoneInfo(
+  tz: string,
+  instant: Date,
+): TimeZoneInfo | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "getTimeZoneTransitions")
+declare function hostGetTimeZoneTransitions(
+  tz: string,
+  start: Date,
+  end: Date,
+): TimeZoneTransition[] | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "convertTimeZone")
+declare function hostConvertTimeZone(
+  input: string,
+  fromTz: string,
+  toTz: string,
+): string | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "formatTimeInZone")
+declare function hostFormatTimeInZone(
+  instant: Date,
+  tz: string,
+): string | null;
+
+/**
+ * Describes the offset and abbreviation in effect for a time zone at an instant.
+ */
+export class TimeZoneInfo {
+  name!: string;
+  abbreviation!: string;
+
+  /**
+   * The offset from UTC, in seconds east of UTC.
+   */
+  offset: i32 = 0;
+
+  isDST: bool = false;
+}
+
+/**
+ * Describes a change of offset or abbreviation in a time zone, such as a DST change.
+ */
+export class TimeZoneTransition {
+  time!: Date;
+  before!: TimeZoneInfo;
+  after!: TimeZoneInfo;
+}
+
+/**
+ * Returns the names of all time zones known to the runtime, such as "America/New_York".
+ */
+export function getTimeZones(): string[] {
+  return hostGetTimeZones();
+}
+
+/**
+ * Returns the offset and abbreviation in effect for the time zone at the given instant.
+ * @param tz - The name of the time zone, such as "America/New_York".
+ * @param instant - The instant to get the info for.
+ */
+export function getTimeZoneInfo(tz: string, instant: Date): TimeZoneInfo {
+  const info = hostGetTimeZoneInfo(tz, instant);
+  if (info === null) {
+    throw new Error(`Failed to get info for time zone ${tz}.`);
+  }
+  return info;
+}
+
+/**
+ * Returns the transitions of the time zone that occur after the start instant
+ * and before the end instant.
+ * @param tz - The name of the time zone, such as "America/New_York".
+ * @param start - The start of the range.
+ * @param end - The end of the range.
+ */
+export function getTimeZoneTransitions(
+  tz: string,
+  start: Date,
+  end: Date,
+): TimeZoneTransition[] {
+  const transitions = hostGetTimeZoneTransitions(tz, start, end);
+  if (transitions === null) {
+    throw new Error(`Failed to get transitions for time zone ${tz}.`);
+  }
+  return transitions;
+}
+
+/**
+ * Interprets a local date and time string, such as "2024-07-01T09:00", in the source
+ * time zone, and returns the same instant formatted as RFC 3339 in the target time zone.
+ * If the input string includes an offset, it is used instead of the source time zone.
+ * @param input - The local date and time to convert.
+ * @param fromTz - The name of the source time zone.
+ * @param toTz - The name of the target time zone.
+ */
+export function convertTime(
+  input: string,
+  fromTz: string,
+  toTz: string,
+): string {
+  const result = hostConvertTimeZone(input, fromTz, toTz);
+  if (result === null) {
+    throw new Error(`Failed to convert time from ${fromTz} to ${toTz}.`);
+  }
+  return result;
+}
+
+/**
+ * Returns the instant formatted as RFC 3339 in the given time zone.
+ * @param instant - The instant to format.
+ * @param tz - The name of the time zone, such as "America/New_York".
+ */
+export function formatTime(instant: Date, tz: string): string {
+  const result = hostFormatTimeInZone(instant, tz);
+  if (result === null) {
+    throw new Error(`Failed to format time in time zone ${tz}.`);
+  }
+  return result;
+}
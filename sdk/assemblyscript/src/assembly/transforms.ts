@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { JSON } from "json-as";
+import * as utils from "./utils";
+
+// @ts-expect-error: decorator
+@external("hypermode", "flattenData")
+declare function hostFlattenData(
+  data: string,
+  separator: string,
+): string | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "groupData")
+declare function hostGroupData(data: string, key: string): string | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "projectData")
+declare function hostProjectData(data: string, fields: string[]): string | null;
+
+// @ts-expect-error: decorator
+@external("hypermode", "joinData")
+declare function hostJoinData(
+  left: string,
+  right: string,
+  leftKey: string,
+  rightKey: string,
+  kind: string,
+): string | null;
+
+/**
+ * The kinds of joins, for use with `join`.
+ */
+export namespace JoinKind {
+  /**
+   * Omits the left items that have no matching right item.
+   */
+  export const Inner = "inner";
+
+  /**
+   * Keeps the left items that have no matching right item as they are.
+   */
+  export const Left = "left";
+}
+
+/**
+ * Flattens the nested objects of the data into a single level, joining the
+ * keys of each path with the separator.  Array elements are addressed by
+ * their index.  If the data is an array, each element is flattened
+ * individually.
+ * @typeParam T - The type of the result.
+ * @param data - The data, as a JSON string.
+ * @param separator - The separator of the keys.
+ * @returns The flattened data.
+ */
+export function flatten<T>(data: string, separator: string = "."): T {
+  return parseResult<T>(hostFlattenData(data, separator), "flatten");
+}
+
+/**
+ * Groups an array of objects by the value at the key path.  Items that don't
+ * have the key are grouped under an empty string.
+ * @typeParam T - The type of the result, typically a `Map<string, U[]>`.
+ * @param data - The array of objects, as a JSON string.
+ * @param key - The key path of the value to group by.
+ * @returns An object mapping each distinct key value to its items.
+ */
+export function groupBy<T>(data: string, key: string): T {
+  return parseResult<T>(hostGroupData(data, key), "group");
+}
+
+/**
+ * Keeps only the given fields of an object, or of each object in an array.
+ * Fields may be nested key paths separated by dots, and keep their nesting in
+ * the result.
+ * @typeParam T - The type of the result.
+ * @param data - The data, as a JSON string.
+ * @param fields - The key paths of the fields to keep.
+ * @returns The projected data.
+ */
+export function project<T>(data: string, fields: string[]): T {
+  return parseResult<T>(hostProjectData(data, fields), "project");
+}
+
+/**
+ * Joins two arrays of objects where the value at leftKey equals the value at
+ * rightKey.  Each result item has the fields of the left item, plus any fields
+ * of the right item that the left item doesn't have.
+ * @typeParam T - The type of the result, typically an array.
+ * @param left - The left array of objects, as a JSON string.
+ * @param right - The right array of objects, as a JSON string.
+ * @param leftKey - The key path of the value to match in the left items.
+ * @param rightKey - The key path of the value to match in the right items.
+ * @param kind - The kind of join, such as `JoinKind.Inner`.
+ * @returns The joined items.
+ */
+export function join<T>(
+  left: string,
+  right: string,
+  leftKey: string,
+  rightKey: string,
+  kind: string = JoinKind.Inner,
+): T {
+  return parseResult<T>(
+    hostJoinData(left, right, leftKey, rightKey, kind),
+    "join",
+  );
+}
+
+function parseResult<T>(result: string | null, action: string): T {
+  if (utils.resultIsInvalid(result)) {
+    throw new Error(
+      `Failed to ${action} the data. Check the logs for more information.`,
+    );
+  }
+  return JSON.parse<T>(result!);
+}
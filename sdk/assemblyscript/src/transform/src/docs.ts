@@ -0,0 +1,126 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import { Node } from "assemblyscript/dist/assemblyscript.js";
+import { Directive } from "./types.js";
+
+export class DocComment {
+  constructor(
+    public text: string,
+    public params: Map<string, string>,
+    public directives: Directive[],
+  ) {}
+}
+
+// Decorators, modifiers and line comments can be between a doc comment
+// and the declaration that it documents.
+const trailingDecoratorRegex = /(^|\n)[ \t]*@[\w.]+(\([^)]*\))?[ \t]*$/;
+const trailingLineCommentRegex = /(^|\n)[ \t]*\/\/[^\n]*$/;
+const trailingModifierRegex =
+  /\b(export|declare|default|public|private|protected|readonly|static|abstract)$/;
+
+/**
+ * Gets the doc comment of a declaration, if it has one.
+ */
+export function getDocComment(node: Node | null): DocComment | undefined {
+  if (!node) return undefined;
+  const range = node.range;
+  return parseDocComment(range.source.text.slice(0, range.start));
+}
+
+/**
+ * Parses the JSDoc comment at the end of the source text that precedes
+ * a declaration.  The text of the comment documents the declaration, and its
+ * `@param` tags document the parameters of a function.  Its `@modus:` tags are
+ * Modus directives, such as `@modus:auth claim=role value="team admin"`.
+ * Other tags are ignored.
+ */
+export function parseDocComment(before: string): DocComment | undefined {
+  let text = before.trimEnd();
+  for (;;) {
+    const t = text
+      .replace(trailingDecoratorRegex, "")
+      .replace(trailingLineCommentRegex, "")
+      .replace(trailingModifierRegex, "")
+      .trimEnd();
+    if (t === text) break;
+    text = t;
+  }
+
+  if (!text.endsWith("*/")) return undefined;
+  const start = text.lastIndexOf("/**");
+  if (start < 0) return undefined;
+
+  const lines = text
+    .slice(start + 3, text.length - 2)
+    .split("\n")
+    .map((line) => line.replace(/^\s*\*? ?/, "").trimEnd());
+
+  const description: string[] = [];
+  const params = new Map<string, string>();
+  const directives: Directive[] = [];
+  let param: string | undefined;
+  let inTag = false;
+  for (const line of lines) {
+    const directive = /^@modus:(\S+)\s*(.*)$/.exec(line);
+    if (directive) {
+      inTag = true;
+      param = undefined;
+      directives.push(parseDirective(directive[1], directive[2]));
+      continue;
+    }
+
+    const tag = /^@(\w+)\s*(.*)$/.exec(line);
+    if (tag) {
+      inTag = true;
+      param = undefined;
+      if (tag[1] === "param") {
+        const m = /^(?:\{[^}]*\}\s*)?\[?([\w$]+)[^\s]*\s*(?:-\s*)?(.*)$/.exec(
+          tag[2],
+        );
+        if (m) {
+          param = m[1];
+          params.set(param, m[2]);
+        }
+      }
+    } else if (param !== undefined) {
+      params.set(param, (params.get(param) + "\n" + line.trim()).trim());
+    } else if (!inTag) {
+      description.push(line);
+    }
+  }
+
+  return new DocComment(description.join("\n").trim(), params, directives);
+}
+
+/**
+ * Parses the space-separated key=value arguments of a directive.
+ * Values that contain spaces are double-quoted, as in Go directives.
+ */
+function parseDirective(name: string, text: string): Directive {
+  const d: Directive = { name };
+  const args = text.match(/(?:[^\s"]+|"(?:[^"\\]|\\.)*")+/g);
+  if (!args) return d;
+
+  d.args = {};
+  for (const arg of args) {
+    const i = arg.indexOf("=");
+    const key = i < 0 ? arg : arg.slice(0, i);
+    let value = i < 0 ? "" : arg.slice(i + 1);
+    if (value.length >= 2 && value.startsWith('"') && value.endsWith('"')) {
+      try {
+        value = JSON.parse(value);
+      } catch {
+        // keep the value as it is written
+      }
+    }
+    d.args[key] = value;
+  }
+  return d;
+}
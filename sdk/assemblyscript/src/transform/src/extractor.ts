@@ -13,6 +13,7 @@ import {
   Class,
   ElementKind,
   Expression,
+  FieldDeclaration,
   FloatLiteralExpression,
   Function as Func,
   FunctionDeclaration,
@@ -23,6 +24,8 @@ import {
   Program,
   Property,
   StringLiteralExpression,
+  Token,
+  UnaryPrefixExpression,
 } from "assemblyscript/dist/assemblyscript.js";
 import {
   FunctionSignature,
@@ -32,6 +35,7 @@ import {
   TypeDefinition,
   typeMap,
 } from "./types.js";
+import { getDocComment } from "./docs.js";
 import ModusTransform from "./index.js";
 
 export class Extractor {
@@ -63,6 +67,7 @@ export class Extractor {
             c.type.toString(),
             c.id,
             this.getClassFields(c),
+            getDocComment(c.prototype.declaration)?.text,
           );
         })
         .map((t) => [t.name, t]),
@@ -157,6 +162,10 @@ export class Extractor {
       .map((f) => ({
         name: f.name,
         type: f.type.toString(),
+        default: getFieldDefault(
+          (f.declaration as FieldDeclaration).initializer,
+        ),
+        docs: getDocComment(f.declaration)?.text || undefined,
       }));
   }
 
@@ -203,6 +212,7 @@ export class Extractor {
   private convertToFunctionSignature(e: importExportInfo): FunctionSignature {
     const f = this.program.instancesByName.get(e.function) as Func;
     const d = f.declaration as FunctionDeclaration;
+    const docs = getDocComment(d);
     const params: Parameter[] = [];
     for (let i = 0; i < f.signature.parameterTypes.length; i++) {
       const param = d.signature.parameters[i];
@@ -213,12 +223,17 @@ export class Extractor {
         name,
         type: type.toString(),
         default: defaultValue,
+        docs: docs?.params.get(name) || undefined,
       });
     }
 
-    return new FunctionSignature(e.name, params, [
-      { type: f.signature.returnType.toString() },
-    ]);
+    return new FunctionSignature(
+      e.name,
+      params,
+      [{ type: f.signature.returnType.toString() }],
+      docs?.text || undefined,
+      docs?.directives,
+    );
   }
 }
 
@@ -302,6 +317,48 @@ export function getLiteral(node: Expression | null): JsonLiteral {
   return "";
 }
 
+// Returns the default value of a field, if it is initialized with a literal.
+// Other initializers can't be represented in the metadata, so the runtime
+// uses the empty value of the field's type when the field is omitted.
+export function getFieldDefault(node: Expression | null): JsonLiteral {
+  if (!node) return undefined;
+  switch (node.kind) {
+    case NodeKind.True:
+    case NodeKind.False:
+    case NodeKind.Null: {
+      return getLiteral(node);
+    }
+    case NodeKind.Literal: {
+      const _node = node as LiteralExpression;
+      switch (_node.literalKind) {
+        case LiteralKind.Integer:
+        case LiteralKind.Float:
+        case LiteralKind.String: {
+          return getLiteral(node);
+        }
+        case LiteralKind.Array: {
+          const out = (_node as ArrayLiteralExpression).elementExpressions.map(
+            (e) => getFieldDefault(e),
+          );
+          return out.includes(undefined) ? undefined : out;
+        }
+      }
+      return undefined;
+    }
+    case NodeKind.UnaryPrefix: {
+      const _node = node as UnaryPrefixExpression;
+      if (_node.operator === Token.Minus) {
+        const value = getFieldDefault(_node.operand);
+        if (typeof value === "number") {
+          return -value;
+        }
+      }
+      return undefined;
+    }
+  }
+  return undefined;
+}
+
 const nullableTypeRegex = /\s?\|\s?null$/;
 
 function isNullable(type: string) {
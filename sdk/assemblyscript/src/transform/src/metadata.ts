@@ -21,10 +21,15 @@ import writeLogo from "./logo.js";
 
 const METADATA_VERSION = 2;
 
+// The version of the interface between plugins built with this SDK and the runtime,
+// which the runtime checks when loading a plugin.
+const ABI_VERSION = 1;
+
 export class Metadata {
   public plugin: string;
   public module: string;
   public sdk: string;
+  public abiVersion: number = ABI_VERSION;
   public buildId: string;
   public buildTs: string;
   public gitRepo?: string;
@@ -25,6 +25,8 @@ export class FunctionSignature {
     public name: string,
     public parameters: Parameter[],
     public results: Result[],
+    public docs?: string,
+    public directives?: Directive[],
   ) {}
 
   toString() {
@@ -56,6 +58,16 @@ export class FunctionSignature {
       output["results"] = this.results;
     }
 
+    // omit empty docs
+    if (this.docs) {
+      output["docs"] = this.docs;
+    }
+
+    // omit empty directives
+    if (this.directives && this.directives.length > 0) {
+      output["directives"] = this.directives;
+    }
+
     return output;
   }
 }
@@ -65,6 +77,7 @@ export class TypeDefinition {
     public name: string,
     public id: number,
     public fields?: Field[],
+    public docs?: string,
   ) {}
 
   toString() {
@@ -83,6 +96,7 @@ export class TypeDefinition {
     return {
       id: this.id,
       fields: this.fields,
+      docs: this.docs || undefined,
     };
   }
 
@@ -103,11 +117,19 @@ export interface Parameter {
   name: string;
   type: string;
   default?: JsonLiteral;
+  docs?: string;
+}
+
+export interface Directive {
+  name: string;
+  args?: { [key: string]: string };
 }
 
 interface Field {
   name: string;
   type: string;
+  default?: JsonLiteral;
+  docs?: string;
 }
 
 export const typeMap = new Map<string, string>([
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import assert from "node:assert/strict";
+import { test } from "node:test";
+import { parseDocComment } from "../lib/docs.js";
+
+test("parses the text and parameters of a function's doc comment", () => {
+  const docs = parseDocComment(`
+/**
+ * Says hello to someone.
+ *
+ * It is polite.
+ * @param name The name of the person to greet.
+ * @param loud - Whether to shout
+ *   the greeting.
+ * @returns A greeting.
+ */
+export `);
+
+  assert.equal(docs.text, "Says hello to someone.\n\nIt is polite.");
+  assert.deepEqual(
+    [...docs.params],
+    [
+      ["name", "The name of the person to greet."],
+      ["loud", "Whether to shout\nthe greeting."],
+    ],
+  );
+});
+
+test("skips decorators and modifiers before the declaration", () => {
+  const docs = parseDocComment(`
+/** A person. */
+@json
+export `);
+  assert.equal(docs.text, "A person.");
+
+  const field = parseDocComment(`class Person {
+  /** The person's name. */
+  public readonly `);
+  assert.equal(field.text, "The person's name.");
+});
+
+test("ignores declarations without a doc comment", () => {
+  assert.equal(parseDocComment("const a = 1;\n\nexport "), undefined);
+  assert.equal(parseDocComment("/* not a doc comment */\n"), undefined);
+  assert.equal(parseDocComment(""), undefined);
+});
+
+test("parses the Modus directives of a function's doc comment", () => {
+  const docs = parseDocComment(`
+/**
+ * Deletes a team.
+ * @param id - The ID of the team.
+ * @modus:auth claim=role value="team admin"
+ * @modus:cache
+ * @modus:note text="say \\"hi\\"" other=a=b
+ */
+export `);
+
+  assert.equal(docs.text, "Deletes a team.");
+  assert.equal(docs.params.get("id"), "The ID of the team.");
+  assert.deepEqual(docs.directives, [
+    { name: "auth", args: { claim: "role", value: "team admin" } },
+    { name: "cache" },
+    { name: "note", args: { text: 'say "hi"', other: "a=b" } },
+  ]);
+});
@@ -0,0 +1,34 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package cache stores values in an in-memory cache of the Modus runtime, which is shared by the
+// function calls of a plugin.  The cache is bounded, so values can be evicted before they expire.
+package cache
+
+import "time"
+
+// Get returns the value stored in the cache for the key, and whether it was found.
+func Get(key string) (string, bool) {
+	value := hostCacheGet(&key)
+	if value == nil {
+		return "", false
+	}
+	return *value, true
+}
+
+// Set stores a value in the cache for the key.  The value expires after the given time to live,
+// or never expires if it is zero.
+func Set(key, value string, ttl time.Duration) {
+	hostCacheSet(&key, &value, ttl.Milliseconds())
+}
+
+// Delete removes the value stored in the cache for the key, and reports whether there was one.
+func Delete(key string) bool {
+	return hostCacheDelete(&key)
+}
@@ -0,0 +1,49 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/cache"
+)
+
+func TestCache(t *testing.T) {
+	if _, ok := cache.Get("greeting"); ok {
+		t.Error("Expected no value before it is set.")
+	}
+
+	cache.Set("greeting", "hello", 2*time.Minute)
+	values := cache.CacheSetCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a call to the host, but none was found.")
+	}
+	if ttl := values[2].(int64); ttl != 120000 {
+		t.Errorf("Expected a ttl of 120000 ms, but received: %d", ttl)
+	}
+
+	value, ok := cache.Get("greeting")
+	if !ok || value != "hello" {
+		t.Errorf("Expected value: hello, but received: %q (found: %t)", value, ok)
+	}
+
+	if !cache.Delete("greeting") {
+		t.Error("Expected the value to be deleted.")
+	}
+	if cache.Delete("greeting") {
+		t.Error("Expected no value to delete.")
+	}
+	if _, ok := cache.Get("greeting"); ok {
+		t.Error("Expected no value after it is deleted.")
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cache
+
+import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+
+var CacheGetCallStack = testutils.NewCallStack()
+var CacheSetCallStack = testutils.NewCallStack()
+var CacheDeleteCallStack = testutils.NewCallStack()
+
+// The mocks keep the values in a map, and ignore their time to live.
+var mockCache = make(map[string]string)
+
+func hostCacheGet(key *string) *string {
+	CacheGetCallStack.Push(key)
+
+	if value, ok := mockCache[*key]; ok {
+		return &value
+	}
+	return nil
+}
+
+func hostCacheSet(key, value *string, ttlMs int64) {
+	CacheSetCallStack.Push(key, value, ttlMs)
+
+	mockCache[*key] = *value
+}
+
+func hostCacheDelete(key *string) bool {
+	CacheDeleteCallStack.Push(key)
+
+	_, ok := mockCache[*key]
+	delete(mockCache, *key)
+	return ok
+}
@@ -0,0 +1,24 @@
+//go:build wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cache
+
+//go:noescape
+//go:wasmimport hypermode cacheGet
+func hostCacheGet(key *string) *string
+
+//go:noescape
+//go:wasmimport hypermode cacheSet
+func hostCacheSet(key, value *string, ttlMs int64)
+
+//go:noescape
+//go:wasmimport hypermode cacheDelete
+func hostCacheDelete(key *string) bool
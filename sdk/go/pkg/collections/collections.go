@@ -11,6 +11,11 @@ package collections
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
 )
 
 type CollectionStatus = string
@@ -18,6 +23,12 @@ type CollectionStatus = string
 const (
 	Success CollectionStatus = "success"
 	Error   CollectionStatus = "error"
+
+	// PartialSuccess is the status of a batch mutation where some of the items failed.
+	PartialSuccess CollectionStatus = "partial"
+
+	// Conflict is the status of a mutation that expected the item to have a different version than it has.
+	Conflict CollectionStatus = "conflict"
 )
 
 type CollectionMutationResult struct {
@@ -28,6 +39,51 @@ type CollectionMutationResult struct {
 	Keys       []string
 }
 
+// CollectionBatchMutationResult is the result of a batch mutation, where each item can fail independently.
+// The keys are those of the items that succeeded, and the failures are those that didn't.
+type CollectionBatchMutationResult struct {
+	Collection string
+	Status     string
+	Error      string
+	Operation  string
+	Keys       []string
+	Failures   []*CollectionMutationFailure
+}
+
+type CollectionMutationFailure struct {
+	Key   string
+	Error string
+}
+
+// CollectionVersionedMutationResult is the result of UpsertIfVersion or RemoveIfVersion.  The version is the new
+// version of the item on success, or its current version when the status is Conflict.  A version of 0 means that the
+// item doesn't exist.
+type CollectionVersionedMutationResult struct {
+	Collection string
+	Status     string
+	Error      string
+	Operation  string
+	Key        string
+	Version    int64
+}
+
+// CollectionItemVersion is the current version of an item, which is 0 if the item doesn't exist.
+type CollectionItemVersion struct {
+	Key     string
+	Version int64
+}
+
+// CollectionItem is an item to upsert with UpsertItems.  A key is generated if the key is empty.
+// The item is removed automatically once its TTL has passed, rounded up to whole seconds.  If the TTL is zero,
+// the TTL of the collection is used, if it has one.
+type CollectionItem struct {
+	Key      string
+	Text     string
+	Labels   []string
+	Metadata map[string]any
+	TTL      time.Duration
+}
+
 type SearchMethodMutationResult struct {
 	Collection   string
 	Status       string
@@ -36,6 +92,37 @@ type SearchMethodMutationResult struct {
 	SearchMethod string
 }
 
+// CollectionSnapshotResult is the result of exporting a collection to object storage, or importing it from there.
+// The count is the number of items in the namespaces that were exported or imported.
+type CollectionSnapshotResult struct {
+	Collection string
+	Status     string
+	Error      string
+	Operation  string
+	Uri        string
+	Namespaces []string
+	Count      int32
+}
+
+// CollectionItemsResult is a page of the items of a collection, read with GetItems.  The cursor reads the next page,
+// and is empty when there are no more items.
+type CollectionItemsResult struct {
+	Collection string
+	Namespace  string
+	Status     string
+	Items      []*CollectionItemObject
+	Cursor     string
+	Error      string
+}
+
+// CollectionItemObject is an item of a collection.  The metadata is a JSON object, or empty if the item has none.
+type CollectionItemObject struct {
+	Key      string
+	Text     string
+	Labels   []string
+	Metadata string
+}
+
 type CollectionSearchResult struct {
 	Collection   string
 	Status       string
@@ -62,6 +149,23 @@ type CollectionClassificationResult struct {
 	Cluster      []*CollectionClassificationResultObject
 }
 
+// CollectionDuplicatesResult holds the clusters of near-duplicate items of a namespace.
+type CollectionDuplicatesResult struct {
+	Collection   string
+	Namespace    string
+	SearchMethod string
+	Status       string
+	Clusters     []*CollectionDuplicateCluster
+	Error        string
+}
+
+// CollectionDuplicateCluster is a group of items that are near-duplicates of each other, directly or through other
+// items of the group.  The score is the lowest similarity of the pairs of items that link the group.
+type CollectionDuplicateCluster struct {
+	Keys  []string
+	Score float64
+}
+
 type CollectionClassificationLabelObject struct {
 	Label      string
 	Confidence float64
@@ -158,6 +262,169 @@ func Upsert(collection string, key *string, text string, labels []string, opts .
 	return result, nil
 }
 
+// UpsertBatchWithMetadata upserts texts along with metadata, which can be used to filter searches with WithFilter.
+// The metadata array can be nil, or have an entry for each text.  An entry can be nil if the text has no metadata.
+func UpsertBatchWithMetadata(collection string, keys []string, texts []string, labelsArr [][]string, metadataArr []map[string]any, opts ...NamespaceOption) (*CollectionMutationResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("Texts is empty")
+	}
+
+	if len(metadataArr) != 0 && len(metadataArr) != len(texts) {
+		return nil, fmt.Errorf("Metadata must have the same length as texts")
+	}
+
+	nsOpts := &NamespaceOptions{
+		namespace: "",
+	}
+
+	for _, opt := range opts {
+		opt(nsOpts)
+	}
+
+	if keys == nil {
+		keys = []string{}
+	}
+
+	if labelsArr == nil {
+		labelsArr = [][]string{}
+	}
+
+	metadata := make([]string, len(metadataArr))
+	for i, m := range metadataArr {
+		if m == nil {
+			continue
+		}
+		bytes, err := utils.JsonSerialize(m)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to serialize metadata: %w", err)
+		}
+		metadata[i] = string(bytes)
+	}
+
+	result := hostUpsertToCollectionWithMetadata(&collection, &nsOpts.namespace, &keys, &texts, &labelsArr, &metadata)
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to upsert")
+	}
+
+	return result, nil
+}
+
+// UpsertWithMetadata upserts a text along with metadata, which can be used to filter searches with WithFilter.
+func UpsertWithMetadata(collection string, key *string, text string, labels []string, metadata map[string]any, opts ...NamespaceOption) (*CollectionMutationResult, error) {
+	if text == "" {
+		return nil, fmt.Errorf("Text is required")
+	}
+
+	keyArr := []string{}
+
+	if key != nil {
+		keyArr = []string{*key}
+	}
+
+	labelsArr := [][]string{}
+
+	if labels != nil {
+		labelsArr = [][]string{labels}
+	}
+
+	return UpsertBatchWithMetadata(collection, keyArr, []string{text}, labelsArr, []map[string]any{metadata}, opts...)
+}
+
+// UpsertItems upserts many items at once, such as when ingesting data.  Embeddings are computed in batches,
+// and each item can fail on its own without failing the others, in which case the status of the result is
+// PartialSuccess, and its failures say which items failed and why.
+func UpsertItems(collection string, items []*CollectionItem, opts ...NamespaceOption) (*CollectionBatchMutationResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("Items is empty")
+	}
+
+	nsOpts := &NamespaceOptions{
+		namespace: "",
+	}
+
+	for _, opt := range opts {
+		opt(nsOpts)
+	}
+
+	keys := make([]string, len(items))
+	texts := make([]string, len(items))
+	labelsArr := make([][]string, len(items))
+	metadata := make([]string, len(items))
+	ttls := make([]int64, len(items))
+	hasTTL := false
+	for i, item := range items {
+		keys[i] = item.Key
+		texts[i] = item.Text
+		labelsArr[i] = item.Labels
+		if labelsArr[i] == nil {
+			labelsArr[i] = []string{}
+		}
+		if item.Metadata != nil {
+			bytes, err := utils.JsonSerialize(item.Metadata)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to serialize metadata: %w", err)
+			}
+			metadata[i] = string(bytes)
+		}
+		if item.TTL < 0 {
+			return nil, fmt.Errorf("TTL must not be negative")
+		}
+		if item.TTL > 0 {
+			ttls[i] = int64((item.TTL + time.Second - 1) / time.Second)
+			hasTTL = true
+		}
+	}
+
+	var result *CollectionBatchMutationResult
+	if hasTTL {
+		result = hostUpsertBatchToCollectionWithTtl(&collection, &nsOpts.namespace, &keys, &texts, &labelsArr, &metadata, &ttls)
+	} else {
+		result = hostUpsertBatchToCollection(&collection, &nsOpts.namespace, &keys, &texts, &labelsArr, &metadata)
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to upsert")
+	}
+
+	return result, nil
+}
+
+// RemoveBatch removes many items at once.  Keys that aren't in the collection are reported as failures of the result.
+func RemoveBatch(collection string, keys []string, opts ...NamespaceOption) (*CollectionBatchMutationResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("Keys is empty")
+	}
+
+	nsOpts := &NamespaceOptions{
+		namespace: "",
+	}
+
+	for _, opt := range opts {
+		opt(nsOpts)
+	}
+
+	result := hostDeleteBatchFromCollection(&collection, &nsOpts.namespace, &keys)
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to delete")
+	}
+
+	return result, nil
+}
+
 func Remove(collection, key string, opts ...NamespaceOption) (*CollectionMutationResult, error) {
 	if collection == "" {
 		return nil, fmt.Errorf("Collection name is required")
@@ -184,20 +451,150 @@ func Remove(collection, key string, opts ...NamespaceOption) (*CollectionMutatio
 	return result, nil
 }
 
+// UpsertIfVersion upserts a text only if the item has the expected version, which is read with GetVersion, so that
+// concurrent writers of an item don't overwrite each other's changes.  An expected version of 0 only upserts a new item.
+// When the item has another version, the status of the result is Conflict, and the item can be read again and retried.
+func UpsertIfVersion(collection, key, text string, labels []string, metadata map[string]any, expectedVersion int64, opts ...NamespaceOption) (*CollectionVersionedMutationResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if key == "" {
+		return nil, fmt.Errorf("Key is required")
+	}
+
+	if text == "" {
+		return nil, fmt.Errorf("Text is required")
+	}
+
+	if expectedVersion < 0 {
+		return nil, fmt.Errorf("Expected version must not be negative")
+	}
+
+	nsOpts := &NamespaceOptions{
+		namespace: "",
+	}
+
+	for _, opt := range opts {
+		opt(nsOpts)
+	}
+
+	if labels == nil {
+		labels = []string{}
+	}
+
+	metadataStr := ""
+	if metadata != nil {
+		bytes, err := utils.JsonSerialize(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to serialize metadata: %w", err)
+		}
+		metadataStr = string(bytes)
+	}
+
+	result := hostUpsertToCollectionIfVersion(&collection, &nsOpts.namespace, &key, &text, &labels, &metadataStr, expectedVersion)
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to upsert")
+	}
+
+	return result, nil
+}
+
+// RemoveIfVersion removes an item only if it has the expected version, which is read with GetVersion.
+// When the item has another version, the status of the result is Conflict.
+func RemoveIfVersion(collection, key string, expectedVersion int64, opts ...NamespaceOption) (*CollectionVersionedMutationResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if key == "" {
+		return nil, fmt.Errorf("Key is required")
+	}
+
+	if expectedVersion < 0 {
+		return nil, fmt.Errorf("Expected version must not be negative")
+	}
+
+	nsOpts := &NamespaceOptions{
+		namespace: "",
+	}
+
+	for _, opt := range opts {
+		opt(nsOpts)
+	}
+
+	result := hostDeleteFromCollectionIfVersion(&collection, &nsOpts.namespace, &key, expectedVersion)
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to delete")
+	}
+
+	return result, nil
+}
+
+// GetVersion returns the current version of an item, which is 0 if the item doesn't exist.  Each upsert of an item
+// increments its version.
+func GetVersion(collection, key string, opts ...NamespaceOption) (int64, error) {
+	if collection == "" {
+		return 0, fmt.Errorf("Collection name is required")
+	}
+
+	if key == "" {
+		return 0, fmt.Errorf("Key is required")
+	}
+
+	nsOpts := &NamespaceOptions{
+		namespace: "",
+	}
+
+	for _, opt := range opts {
+		opt(nsOpts)
+	}
+
+	result := hostGetVersionFromCollection(&collection, &nsOpts.namespace, &key)
+
+	if result == nil {
+		return 0, fmt.Errorf("Failed to get version for key")
+	}
+
+	return result.Version, nil
+}
+
 type SearchOption func(*SearchOptions)
 
 type SearchOptions struct {
-	namespaces []string
-	limit      int
-	returnText bool
+	namespaces   []string
+	limit        int
+	returnText   bool
+	filter       string
+	fusion       string
+	vectorWeight float64
 }
 
+const (
+	// FusionRRF combines the results of a hybrid search with reciprocal rank fusion, using only their ranks.
+	FusionRRF = "rrf"
+
+	// FusionWeighted combines the vector similarity and keyword scores of the results of a hybrid search, by weight.
+	FusionWeighted = "weighted"
+)
+
+// AllNamespaces is the wildcard that searches every namespace of a collection, when passed to WithNamespaces.
+const AllNamespaces = "*"
+
+// WithNamespaces searches the given namespaces, merging their results.  Each result says which namespace it came from.
 func WithNamespaces(namespaces []string) SearchOption {
 	return func(o *SearchOptions) {
 		o.namespaces = namespaces
 	}
 }
 
+// WithAllNamespaces searches every namespace of the collection, merging their results.
+func WithAllNamespaces() SearchOption {
+	return WithNamespaces([]string{AllNamespaces})
+}
+
 func WithLimit(limit int) SearchOption {
 	return func(o *SearchOptions) {
 		o.limit = limit
@@ -210,6 +607,33 @@ func WithReturnText(returnText bool) SearchOption {
 	}
 }
 
+// WithFilter only returns results whose metadata matches the filter expression, such as:
+//
+//	tenant = 'acme' AND lang IN ('en', 'fr') AND year >= 2020
+//
+// Comparisons use =, !=, <, <=, >, >=, IN and NOT IN, and can be combined with AND, OR, NOT and parentheses.
+func WithFilter(filter string) SearchOption {
+	return func(o *SearchOptions) {
+		o.filter = filter
+	}
+}
+
+// WithRRFFusion combines the results of a hybrid search with reciprocal rank fusion.  This is the default.
+func WithRRFFusion() SearchOption {
+	return func(o *SearchOptions) {
+		o.fusion = FusionRRF
+	}
+}
+
+// WithWeightedFusion combines the results of a hybrid search by weight.  The vector weight, from 0 to 1,
+// is how much the vector similarity counts, and the keyword score counts for the rest.
+func WithWeightedFusion(vectorWeight float64) SearchOption {
+	return func(o *SearchOptions) {
+		o.fusion = FusionWeighted
+		o.vectorWeight = vectorWeight
+	}
+}
+
 func Search(collection, searchMethod, text string, opts ...SearchOption) (*CollectionSearchResult, error) {
 	if collection == "" {
 		return nil, fmt.Errorf("Collection name is required")
@@ -233,7 +657,12 @@ func Search(collection, searchMethod, text string, opts ...SearchOption) (*Colle
 		opt(sOpts)
 	}
 
-	result := hostSearchCollection(&collection, &sOpts.namespaces, &searchMethod, &text, int32(sOpts.limit), sOpts.returnText)
+	var result *CollectionSearchResult
+	if sOpts.filter != "" {
+		result = hostSearchCollectionWithFilter(&collection, &sOpts.namespaces, &searchMethod, &text, int32(sOpts.limit), sOpts.returnText, &sOpts.filter)
+	} else {
+		result = hostSearchCollection(&collection, &sOpts.namespaces, &searchMethod, &text, int32(sOpts.limit), sOpts.returnText)
+	}
 
 	if result == nil {
 		return nil, fmt.Errorf("Failed to search")
@@ -271,7 +700,97 @@ func SearchByVector(collection, searchMethod string, vector []float32, opts ...S
 		opt(sOpts)
 	}
 
-	result := hostSearchCollectionByVector(&collection, &sOpts.namespaces, &searchMethod, &vector, int32(sOpts.limit), sOpts.returnText)
+	var result *CollectionSearchResult
+	if sOpts.filter != "" {
+		result = hostSearchCollectionByVectorWithFilter(&collection, &sOpts.namespaces, &searchMethod, &vector, int32(sOpts.limit), sOpts.returnText, &sOpts.filter)
+	} else {
+		result = hostSearchCollectionByVector(&collection, &sOpts.namespaces, &searchMethod, &vector, int32(sOpts.limit), sOpts.returnText)
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to search")
+	}
+
+	return result, nil
+}
+
+// HybridSearch searches the collection by both the vector similarity of the search method and the keywords in the text,
+// so that texts containing exact terms from the query are found even when their embeddings aren't the most similar.
+// The score of each result is its fused score.
+func HybridSearch(collection, searchMethod, text string, opts ...SearchOption) (*CollectionSearchResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if searchMethod == "" {
+		return nil, fmt.Errorf("Search method is required")
+	}
+
+	if text == "" {
+		return nil, fmt.Errorf("Text is required")
+	}
+
+	sOpts := &SearchOptions{
+		namespaces:   []string{},
+		limit:        10,
+		returnText:   false,
+		fusion:       FusionRRF,
+		vectorWeight: 0.5,
+	}
+
+	for _, opt := range opts {
+		opt(sOpts)
+	}
+
+	if sOpts.vectorWeight < 0 || sOpts.vectorWeight > 1 {
+		return nil, fmt.Errorf("Vector weight must be between 0 and 1")
+	}
+
+	result := hostHybridSearchCollection(&collection, &sOpts.namespaces, &searchMethod, &text, int32(sOpts.limit), sOpts.returnText, &sOpts.fusion, sOpts.vectorWeight, &sOpts.filter)
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to search")
+	}
+
+	return result, nil
+}
+
+// WeightedSearch searches the collection with several search methods, such as one that embeds the title of the items
+// and one that embeds their body, and combines the results by the weight of each search method.  The score of each
+// result is the weighted sum of its similarity in each search method.  The weights are scaled to add up to 1.
+func WeightedSearch(collection string, searchMethods map[string]float64, text string, opts ...SearchOption) (*CollectionSearchResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if len(searchMethods) == 0 {
+		return nil, fmt.Errorf("At least one search method is required")
+	}
+
+	if text == "" {
+		return nil, fmt.Errorf("Text is required")
+	}
+
+	sOpts := &SearchOptions{
+		namespaces: []string{},
+		limit:      10,
+		returnText: false,
+	}
+
+	for _, opt := range opts {
+		opt(sOpts)
+	}
+
+	names := slices.Sorted(maps.Keys(searchMethods))
+	weights := make([]float64, len(names))
+	for i, name := range names {
+		if searchMethods[name] < 0 {
+			return nil, fmt.Errorf("Weights must not be negative")
+		}
+		weights[i] = searchMethods[name]
+	}
+
+	result := hostWeightedSearchCollection(&collection, &sOpts.namespaces, &names, &weights, &text, int32(sOpts.limit), sOpts.returnText, &sOpts.filter)
 
 	if result == nil {
 		return nil, fmt.Errorf("Failed to search")
@@ -310,6 +829,35 @@ func NnClassify(collection, searchMethod, text string, opts ...NamespaceOption)
 	return result, nil
 }
 
+// FindNearDuplicates groups the items of a namespace whose vectors in the search method are at least as similar as
+// the threshold, such as 0.95 for the cosine similarity, to clean up a dataset.  Only clusters of two or more items
+// are returned, largest first.
+func FindNearDuplicates(collection, searchMethod string, threshold float64, opts ...NamespaceOption) (*CollectionDuplicatesResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if searchMethod == "" {
+		return nil, fmt.Errorf("Search method is required")
+	}
+
+	nsOpts := &NamespaceOptions{
+		namespace: "",
+	}
+
+	for _, opt := range opts {
+		opt(nsOpts)
+	}
+
+	result := hostFindNearDuplicates(&collection, &nsOpts.namespace, &searchMethod, threshold)
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to find near-duplicates")
+	}
+
+	return result, nil
+}
+
 func RecomputeSearchMethod(collection, searchMethod string, opts ...NamespaceOption) (*SearchMethodMutationResult, error) {
 	if collection == "" {
 		return nil, fmt.Errorf("Collection name is required")
@@ -432,6 +980,46 @@ func GetNamespaces(collection string) ([]string, error) {
 	return *result, nil
 }
 
+// Export writes an archive of every namespace of the collection, with its items, vectors and indexes,
+// to object storage at a URI such as s3://bucket/path/file or gs://bucket/path/file.
+func Export(collection, uri string) (*CollectionSnapshotResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if uri == "" {
+		return nil, fmt.Errorf("URI is required")
+	}
+
+	result := hostExportCollection(&collection, &uri)
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to export collection")
+	}
+
+	return result, nil
+}
+
+// Import upserts the items of an archive written by Export into the collection.  Their vectors are used
+// for each search method that has the same embedder as when they were exported, and computed otherwise.
+func Import(collection, uri string) (*CollectionSnapshotResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if uri == "" {
+		return nil, fmt.Errorf("URI is required")
+	}
+
+	result := hostImportCollection(&collection, &uri)
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to import collection")
+	}
+
+	return result, nil
+}
+
 func GetVector(collection, searchMethod, key string, opts ...NamespaceOption) ([]float32, error) {
 	if collection == "" {
 		return nil, fmt.Errorf("Collection name is required")
@@ -487,3 +1075,137 @@ func GetLabels(collection, key string, opts ...NamespaceOption) ([]string, error
 
 	return *result, nil
 }
+
+// GetMetadata returns the metadata that was upserted with the text for the key.
+func GetMetadata(collection, key string, opts ...NamespaceOption) (map[string]any, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	if key == "" {
+		return nil, fmt.Errorf("Key is required")
+	}
+
+	nsOpts := &NamespaceOptions{
+		namespace: "",
+	}
+
+	for _, opt := range opts {
+		opt(nsOpts)
+	}
+
+	result := hostGetMetadata(&collection, &nsOpts.namespace, &key)
+
+	if result == nil || *result == "" {
+		return map[string]any{}, nil
+	}
+
+	metadata := map[string]any{}
+	if err := utils.JsonDeserialize([]byte(*result), &metadata); err != nil {
+		return nil, fmt.Errorf("Failed to deserialize metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// The orders that GetItems can read items in.  The insertion time of an item is when it was last upserted.
+const (
+	SortByKey       = "key"
+	SortByInsertion = "insertion"
+)
+
+type ItemsOption func(*ItemsOptions)
+
+type ItemsOptions struct {
+	namespace  string
+	sortBy     string
+	descending bool
+	cursor     string
+	limit      int
+}
+
+// WithItemsNamespace reads the items of the given namespace, instead of the default namespace.
+func WithItemsNamespace(namespace string) ItemsOption {
+	return func(o *ItemsOptions) {
+		o.namespace = namespace
+	}
+}
+
+// WithSortBy sorts the items by SortByKey, which is the default, or by SortByInsertion.
+func WithSortBy(sortBy string) ItemsOption {
+	return func(o *ItemsOptions) {
+		o.sortBy = sortBy
+	}
+}
+
+// WithDescending sorts the items in descending order.
+func WithDescending() ItemsOption {
+	return func(o *ItemsOptions) {
+		o.descending = true
+	}
+}
+
+// WithCursor reads the page after the one that returned the cursor.  The other options must be the same as
+// those the cursor's page was read with.
+func WithCursor(cursor string) ItemsOption {
+	return func(o *ItemsOptions) {
+		o.cursor = cursor
+	}
+}
+
+// WithPageSize sets the number of items in a page, which is 100 by default, and at most 1000.
+func WithPageSize(size int) ItemsOption {
+	return func(o *ItemsOptions) {
+		o.limit = size
+	}
+}
+
+// GetItems reads a page of the items of a collection.  Pass the cursor of the result to WithCursor to read the next page.
+// Pages don't skip or repeat items when items are upserted or removed between reads.
+func GetItems(collection string, opts ...ItemsOption) (*CollectionItemsResult, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("Collection name is required")
+	}
+
+	itemsOpts := &ItemsOptions{
+		sortBy: SortByKey,
+	}
+
+	for _, opt := range opts {
+		opt(itemsOpts)
+	}
+
+	result := hostGetItemsFromCollection(&collection, &itemsOpts.namespace, &itemsOpts.sortBy, itemsOpts.descending, &itemsOpts.cursor, int32(itemsOpts.limit))
+
+	if result == nil {
+		return nil, fmt.Errorf("Failed to get items")
+	}
+
+	return result, nil
+}
+
+// ScrollItems calls fn with each item of a collection, reading a page at a time, until there are no more items
+// or fn returns false.  The cursor and page size options apply to the first page.
+func ScrollItems(collection string, fn func(item *CollectionItemObject) bool, opts ...ItemsOption) error {
+	pageOpts := opts
+	for {
+		result, err := GetItems(collection, pageOpts...)
+		if err != nil {
+			return err
+		}
+		if result.Status != Success {
+			return fmt.Errorf("Failed to get items: %s", result.Error)
+		}
+
+		for _, item := range result.Items {
+			if !fn(item) {
+				return nil
+			}
+		}
+
+		if result.Cursor == "" {
+			return nil
+		}
+		pageOpts = append(opts[:len(opts):len(opts)], WithCursor(result.Cursor))
+	}
+}
@@ -12,8 +12,10 @@
 package collections_test
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hypermodeinc/modus/sdk/go/pkg/collections"
 )
@@ -140,6 +142,93 @@ func TestHostRemoveFromCollection(t *testing.T) {
 	}
 }
 
+func TestHostUpsertIfVersion(t *testing.T) {
+	result, err := collections.UpsertIfVersion(collection, key, text, labels, map[string]any{"n": 1}, 2, collections.WithNamespace(namespace))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result == nil {
+		t.Fatal("Expected a result, but none was found.")
+	}
+	if result.Version != 3 {
+		t.Errorf("Expected version: 3, but received: %v", result.Version)
+	}
+
+	values := collections.UpsertIfVersionCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		if !reflect.DeepEqual(&collection, values[0]) {
+			t.Errorf("Expected collection: %v, but received: %v", &collection, values[0])
+		}
+		if !reflect.DeepEqual(&namespace, values[1]) {
+			t.Errorf("Expected namespace: %v, but received: %v", &namespace, values[1])
+		}
+		if !reflect.DeepEqual(&key, values[2]) {
+			t.Errorf("Expected key: %v, but received: %v", &key, values[2])
+		}
+		if !reflect.DeepEqual(&labels, values[4]) {
+			t.Errorf("Expected labels: %v, but received: %v", &labels, values[4])
+		}
+		metadata := `{"n":1}`
+		if !reflect.DeepEqual(&metadata, values[5]) {
+			t.Errorf("Expected metadata: %v, but received: %v", &metadata, values[5])
+		}
+		if !reflect.DeepEqual(int64(2), values[6]) {
+			t.Errorf("Expected version: %v, but received: %v", 2, values[6])
+		}
+	}
+
+	if _, err := collections.UpsertIfVersion(collection, "", text, nil, nil, 0); err == nil {
+		t.Error("Expected an error without a key.")
+	}
+	if _, err := collections.UpsertIfVersion(collection, key, text, nil, nil, -1); err == nil {
+		t.Error("Expected an error with a negative version.")
+	}
+}
+
+func TestHostRemoveIfVersion(t *testing.T) {
+	result, err := collections.RemoveIfVersion(collection, key, 2, collections.WithNamespace(namespace))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result == nil || result.Status != collections.Success {
+		t.Fatalf("Expected a successful result, but received: %v", result)
+	}
+
+	values := collections.DeleteIfVersionCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		if !reflect.DeepEqual(&namespace, values[1]) {
+			t.Errorf("Expected namespace: %v, but received: %v", &namespace, values[1])
+		}
+		if !reflect.DeepEqual(&key, values[2]) {
+			t.Errorf("Expected key: %v, but received: %v", &key, values[2])
+		}
+		if !reflect.DeepEqual(int64(2), values[3]) {
+			t.Errorf("Expected version: %v, but received: %v", 2, values[3])
+		}
+	}
+}
+
+func TestHostGetVersion(t *testing.T) {
+	version, err := collections.GetVersion(collection, key, collections.WithNamespace(namespace))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if version != 3 {
+		t.Errorf("Expected version: 3, but received: %v", version)
+	}
+
+	values := collections.GetVersionCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else if !reflect.DeepEqual(&key, values[2]) {
+		t.Errorf("Expected key: %v, but received: %v", &key, values[2])
+	}
+}
+
 func TestHostSearchCollection(t *testing.T) {
 	result, err := collections.Search(collection, searchMethod, text, collections.WithNamespaces([]string{namespace}), collections.WithLimit(1), collections.WithReturnText(true))
 	if err != nil {
@@ -216,6 +305,38 @@ func TestHostNnClassifyCollection(t *testing.T) {
 	}
 }
 
+func TestHostFindNearDuplicates(t *testing.T) {
+	result, err := collections.FindNearDuplicates(collection, searchMethod, 0.95, collections.WithNamespace(namespace))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result == nil {
+		t.Fatal("Expected a result, but none was found.")
+	}
+
+	values := collections.FindNearDuplicatesCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		if !reflect.DeepEqual(&collection, values[0]) {
+			t.Errorf("Expected collection: %v, but received: %v", &collection, values[0])
+		}
+		if !reflect.DeepEqual(&namespace, values[1]) {
+			t.Errorf("Expected namespace: %v, but received: %v", &namespace, values[1])
+		}
+		if !reflect.DeepEqual(&searchMethod, values[2]) {
+			t.Errorf("Expected searchMethod: %v, but received: %v", &searchMethod, values[2])
+		}
+		if !reflect.DeepEqual(0.95, values[3]) {
+			t.Errorf("Expected threshold: %v, but received: %v", 0.95, values[3])
+		}
+	}
+
+	if _, err := collections.FindNearDuplicates(collection, "", 0.95); err == nil {
+		t.Error("Expected an error without a search method.")
+	}
+}
+
 func TestHostRecomputeSearchMethod(t *testing.T) {
 	result, err := collections.RecomputeSearchMethod(collection, searchMethod, collections.WithNamespace(namespace))
 	if err != nil {
@@ -493,3 +614,362 @@ func TestHostSearchByVector(t *testing.T) {
 		}
 	}
 }
+
+func TestHostUpsertBatchWithMetadataToCollection(t *testing.T) {
+	metadataArr := []map[string]any{{"lang": "en", "year": 2024}}
+	result, err := collections.UpsertBatchWithMetadata(collection, keyArr, textArr, labelsArr, metadataArr, collections.WithNamespace(namespace))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result == nil {
+		t.Fatal("Expected a result, but none was found.")
+	}
+
+	values := collections.UpsertWithMetadataCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		if !reflect.DeepEqual(&collection, values[0]) {
+			t.Errorf("Expected collection: %v, but received: %v", &collection, values[0])
+		}
+		if !reflect.DeepEqual(&keyArr, values[2]) {
+			t.Errorf("Expected keys: %v, but received: %v", &keyArr, values[2])
+		}
+		expected := &[]string{`{"lang":"en","year":2024}`}
+		if !reflect.DeepEqual(expected, values[5]) {
+			t.Errorf("Expected metadata: %v, but received: %v", expected, values[5])
+		}
+	}
+}
+
+func TestHostSearchCollectionWithFilter(t *testing.T) {
+	filter := "lang = 'en' AND year >= 2020"
+	_, err := collections.Search(collection, searchMethod, text, collections.WithLimit(1), collections.WithFilter(filter))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	values := collections.SearchWithFilterCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else if !reflect.DeepEqual(&filter, values[6]) {
+		t.Errorf("Expected filter: %v, but received: %v", &filter, values[6])
+	}
+}
+
+func TestHostSearchByVectorWithFilter(t *testing.T) {
+	filter := "lang IN ('en', 'fr')"
+	_, err := collections.SearchByVector(collection, searchMethod, []float32{0.1, 0.2, 0.3}, collections.WithFilter(filter))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	values := collections.SearchByVectorWithFilterCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else if !reflect.DeepEqual(&filter, values[6]) {
+		t.Errorf("Expected filter: %v, but received: %v", &filter, values[6])
+	}
+}
+
+func TestHostGetMetadataFromCollection(t *testing.T) {
+	result, err := collections.GetMetadata(collection, key, collections.WithNamespace(namespace))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result["lang"] != "en" {
+		t.Errorf("Expected lang: en, but received: %v", result["lang"])
+	}
+	if fmt.Sprint(result["year"]) != "2024" {
+		t.Errorf("Expected year: 2024, but received: %v", result["year"])
+	}
+
+	values := collections.GetMetadataCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else if !reflect.DeepEqual(&key, values[2]) {
+		t.Errorf("Expected key: %v, but received: %v", &key, values[2])
+	}
+}
+
+func TestHostHybridSearchCollection(t *testing.T) {
+	filter := "lang = 'en'"
+	result, err := collections.HybridSearch(collection, searchMethod, text, collections.WithLimit(5), collections.WithWeightedFusion(0.7), collections.WithFilter(filter))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result == nil {
+		t.Fatal("Expected a result, but none was found.")
+	}
+
+	values := collections.HybridSearchCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		if !reflect.DeepEqual(&text, values[3]) {
+			t.Errorf("Expected text: %v, but received: %v", &text, values[3])
+		}
+		if !reflect.DeepEqual(int32(5), values[4]) {
+			t.Errorf("Expected limit: %v, but received: %v", int32(5), values[4])
+		}
+		fusion := collections.FusionWeighted
+		if !reflect.DeepEqual(&fusion, values[6]) {
+			t.Errorf("Expected fusion: %v, but received: %v", &fusion, values[6])
+		}
+		if !reflect.DeepEqual(0.7, values[7]) {
+			t.Errorf("Expected vector weight: %v, but received: %v", 0.7, values[7])
+		}
+		if !reflect.DeepEqual(&filter, values[8]) {
+			t.Errorf("Expected filter: %v, but received: %v", &filter, values[8])
+		}
+	}
+
+	if _, err := collections.HybridSearch(collection, searchMethod, text, collections.WithWeightedFusion(2)); err == nil {
+		t.Error("Expected an error for an invalid vector weight.")
+	}
+}
+
+func TestHostWeightedSearchCollection(t *testing.T) {
+	searchMethods := map[string]float64{"title": 0.3, "body": 0.7}
+	result, err := collections.WeightedSearch(collection, searchMethods, text, collections.WithLimit(5))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result == nil {
+		t.Fatal("Expected a result, but none was found.")
+	}
+
+	values := collections.WeightedSearchCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		names := []string{"body", "title"}
+		if !reflect.DeepEqual(&names, values[2]) {
+			t.Errorf("Expected search methods: %v, but received: %v", &names, values[2])
+		}
+		weights := []float64{0.7, 0.3}
+		if !reflect.DeepEqual(&weights, values[3]) {
+			t.Errorf("Expected weights: %v, but received: %v", &weights, values[3])
+		}
+		if !reflect.DeepEqual(&text, values[4]) {
+			t.Errorf("Expected text: %v, but received: %v", &text, values[4])
+		}
+		if !reflect.DeepEqual(int32(5), values[5]) {
+			t.Errorf("Expected limit: %v, but received: %v", int32(5), values[5])
+		}
+	}
+
+	if _, err := collections.WeightedSearch(collection, map[string]float64{"title": -1}, text); err == nil {
+		t.Error("Expected an error for a negative weight.")
+	}
+	if _, err := collections.WeightedSearch(collection, nil, text); err == nil {
+		t.Error("Expected an error without search methods.")
+	}
+}
+
+func TestHostUpsertItemsToCollection(t *testing.T) {
+	items := []*collections.CollectionItem{
+		{Key: "a", Text: "apple", Labels: []string{"fruit"}, Metadata: map[string]any{"color": "red"}},
+		{Text: "banana"},
+	}
+	result, err := collections.UpsertItems(collection, items, collections.WithNamespace(namespace))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result == nil || result.Status != collections.Success {
+		t.Fatalf("Expected a successful result, but received: %v", result)
+	}
+
+	values := collections.UpsertBatchCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		if !reflect.DeepEqual(&namespace, values[1]) {
+			t.Errorf("Expected namespace: %v, but received: %v", &namespace, values[1])
+		}
+		if expected := &[]string{"a", ""}; !reflect.DeepEqual(expected, values[2]) {
+			t.Errorf("Expected keys: %v, but received: %v", expected, values[2])
+		}
+		if expected := &[]string{"apple", "banana"}; !reflect.DeepEqual(expected, values[3]) {
+			t.Errorf("Expected texts: %v, but received: %v", expected, values[3])
+		}
+		if expected := &[][]string{{"fruit"}, {}}; !reflect.DeepEqual(expected, values[4]) {
+			t.Errorf("Expected labels: %v, but received: %v", expected, values[4])
+		}
+		if expected := &[]string{`{"color":"red"}`, ""}; !reflect.DeepEqual(expected, values[5]) {
+			t.Errorf("Expected metadata: %v, but received: %v", expected, values[5])
+		}
+	}
+}
+
+func TestHostUpsertItemsWithTTLToCollection(t *testing.T) {
+	items := []*collections.CollectionItem{
+		{Key: "a", Text: "apple", TTL: time.Hour},
+		{Key: "b", Text: "banana", TTL: 1500 * time.Millisecond},
+		{Key: "c", Text: "cherry"},
+	}
+	result, err := collections.UpsertItems(collection, items, collections.WithNamespace(namespace))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result == nil || result.Status != collections.Success {
+		t.Fatalf("Expected a successful result, but received: %v", result)
+	}
+
+	values := collections.UpsertBatchWithTtlCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else if expected := &[]int64{3600, 2, 0}; !reflect.DeepEqual(expected, values[6]) {
+		t.Errorf("Expected ttls: %v, but received: %v", expected, values[6])
+	}
+
+	_, err = collections.UpsertItems(collection, []*collections.CollectionItem{{Text: "apple", TTL: -time.Second}})
+	if err == nil {
+		t.Error("Expected an error for a negative TTL, but received none.")
+	}
+}
+
+func TestHostRemoveBatchFromCollection(t *testing.T) {
+	keys := []string{"missing", "a", "b"}
+	result, err := collections.RemoveBatch(collection, keys, collections.WithNamespace(namespace))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.Status != collections.PartialSuccess {
+		t.Errorf("Expected status: %v, but received: %v", collections.PartialSuccess, result.Status)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].Key != "missing" {
+		t.Errorf("Expected a failure for the missing key, but received: %v", result.Failures)
+	}
+
+	values := collections.DeleteBatchCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else if !reflect.DeepEqual(&keys, values[2]) {
+		t.Errorf("Expected keys: %v, but received: %v", &keys, values[2])
+	}
+}
+
+func TestHostSearchAllNamespaces(t *testing.T) {
+	_, err := collections.Search(collection, searchMethod, text, collections.WithAllNamespaces())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	values := collections.SearchCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else if expected := &[]string{collections.AllNamespaces}; !reflect.DeepEqual(expected, values[1]) {
+		t.Errorf("Expected namespaces: %v, but received: %v", expected, values[1])
+	}
+}
+
+func TestHostExportCollection(t *testing.T) {
+	uri := "s3://bucket/backups/collection.archive"
+	result, err := collections.Export(collection, uri)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.Status != collections.Success || result.Operation != "export" || result.Count != 3 {
+		t.Errorf("Expected a successful export, but received: %v", result)
+	}
+
+	values := collections.ExportCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		if !reflect.DeepEqual(&collection, values[0]) {
+			t.Errorf("Expected collection: %v, but received: %v", &collection, values[0])
+		}
+		if !reflect.DeepEqual(&uri, values[1]) {
+			t.Errorf("Expected uri: %v, but received: %v", &uri, values[1])
+		}
+	}
+
+	if _, err := collections.Export(collection, ""); err == nil {
+		t.Error("Expected an error for a missing URI.")
+	}
+}
+
+func TestHostImportCollection(t *testing.T) {
+	uri := "gs://bucket/collection.archive"
+	result, err := collections.Import(collection, uri)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if expected := []string{"namespace1", "namespace2"}; !reflect.DeepEqual(expected, result.Namespaces) {
+		t.Errorf("Expected namespaces: %v, but received: %v", expected, result.Namespaces)
+	}
+
+	values := collections.ImportCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else if !reflect.DeepEqual(&uri, values[1]) {
+		t.Errorf("Expected uri: %v, but received: %v", &uri, values[1])
+	}
+}
+
+func TestHostGetItemsFromCollection(t *testing.T) {
+	result, err := collections.GetItems(collection, collections.WithItemsNamespace(namespace), collections.WithSortBy(collections.SortByInsertion), collections.WithDescending(), collections.WithCursor("b"), collections.WithPageSize(2))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result == nil || result.Status != collections.Success {
+		t.Fatalf("Expected a successful result, but received: %v", result)
+	}
+
+	values := collections.GetItemsCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		if !reflect.DeepEqual(&namespace, values[1]) {
+			t.Errorf("Expected namespace: %v, but received: %v", &namespace, values[1])
+		}
+		if expected := collections.SortByInsertion; *values[2].(*string) != expected {
+			t.Errorf("Expected sort: %v, but received: %v", expected, *values[2].(*string))
+		}
+		if values[3] != true {
+			t.Errorf("Expected descending, but received: %v", values[3])
+		}
+		if expected := "b"; *values[4].(*string) != expected {
+			t.Errorf("Expected cursor: %v, but received: %v", expected, *values[4].(*string))
+		}
+		if values[5] != int32(2) {
+			t.Errorf("Expected limit: %v, but received: %v", 2, values[5])
+		}
+	}
+}
+
+func TestScrollItems(t *testing.T) {
+	collections.GetItemsCallStack.Items = nil
+
+	var keys []string
+	err := collections.ScrollItems(collection, func(item *collections.CollectionItemObject) bool {
+		keys = append(keys, item.Key)
+		return true
+	}, collections.WithPageSize(2))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if expected := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(expected, keys) {
+		t.Errorf("Expected keys: %v, but received: %v", expected, keys)
+	}
+	if calls := collections.GetItemsCallStack.Size(); calls != 3 {
+		t.Errorf("Expected 3 pages to be read, but %d were read", calls)
+	}
+	if cursor := *collections.GetItemsCallStack.Pop()[4].(*string); cursor != "d" {
+		t.Errorf("Expected the last page to be read after d, but it was read after: %v", cursor)
+	}
+
+	keys = nil
+	err = collections.ScrollItems(collection, func(item *collections.CollectionItemObject) bool {
+		keys = append(keys, item.Key)
+		return item.Key != "c"
+	}, collections.WithPageSize(2))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(expected, keys) {
+		t.Errorf("Expected scrolling to stop at c, but received: %v", keys)
+	}
+}
@@ -15,8 +15,12 @@ import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
 
 var UpsertCallStack = testutils.NewCallStack()
 var DeleteCallStack = testutils.NewCallStack()
+var UpsertIfVersionCallStack = testutils.NewCallStack()
+var DeleteIfVersionCallStack = testutils.NewCallStack()
+var GetVersionCallStack = testutils.NewCallStack()
 var SearchCallStack = testutils.NewCallStack()
 var NnClassifyCallStack = testutils.NewCallStack()
+var FindNearDuplicatesCallStack = testutils.NewCallStack()
 var RecomputeSearchMethodCallStack = testutils.NewCallStack()
 var ComputeDistanceCallStack = testutils.NewCallStack()
 var GetTextCallStack = testutils.NewCallStack()
@@ -25,6 +29,18 @@ var GetNamespacesCallStack = testutils.NewCallStack()
 var GetVectorCallStack = testutils.NewCallStack()
 var GetLabelsCallStack = testutils.NewCallStack()
 var SearchByVectorCallStack = testutils.NewCallStack()
+var UpsertWithMetadataCallStack = testutils.NewCallStack()
+var SearchWithFilterCallStack = testutils.NewCallStack()
+var SearchByVectorWithFilterCallStack = testutils.NewCallStack()
+var GetMetadataCallStack = testutils.NewCallStack()
+var HybridSearchCallStack = testutils.NewCallStack()
+var WeightedSearchCallStack = testutils.NewCallStack()
+var UpsertBatchCallStack = testutils.NewCallStack()
+var UpsertBatchWithTtlCallStack = testutils.NewCallStack()
+var GetItemsCallStack = testutils.NewCallStack()
+var DeleteBatchCallStack = testutils.NewCallStack()
+var ExportCallStack = testutils.NewCallStack()
+var ImportCallStack = testutils.NewCallStack()
 
 func hostUpsertToCollection(collection, namespace *string, keys, texts *[]string, labels *[][]string) *CollectionMutationResult {
 	UpsertCallStack.Push(collection, namespace, keys, texts, labels)
@@ -44,6 +60,38 @@ func hostDeleteFromCollection(collection, namespace, key *string) *CollectionMut
 	}
 }
 
+func hostUpsertToCollectionIfVersion(collection, namespace, key, text *string, labels *[]string, metadata *string, expectedVersion int64) *CollectionVersionedMutationResult {
+	UpsertIfVersionCallStack.Push(collection, namespace, key, text, labels, metadata, expectedVersion)
+
+	return &CollectionVersionedMutationResult{
+		Collection: *collection,
+		Status:     "success",
+		Operation:  "upsert",
+		Key:        *key,
+		Version:    expectedVersion + 1,
+	}
+}
+
+func hostDeleteFromCollectionIfVersion(collection, namespace, key *string, expectedVersion int64) *CollectionVersionedMutationResult {
+	DeleteIfVersionCallStack.Push(collection, namespace, key, expectedVersion)
+
+	return &CollectionVersionedMutationResult{
+		Collection: *collection,
+		Status:     "success",
+		Operation:  "delete",
+		Key:        *key,
+	}
+}
+
+func hostGetVersionFromCollection(collection, namespace, key *string) *CollectionItemVersion {
+	GetVersionCallStack.Push(collection, namespace, key)
+
+	return &CollectionItemVersion{
+		Key:     *key,
+		Version: 3,
+	}
+}
+
 func hostSearchCollection(collection *string, namespaces *[]string, searchMethod, text *string, limit int32, returnText bool) *CollectionSearchResult {
 	SearchCallStack.Push(collection, namespaces, searchMethod, text, limit, returnText)
 
@@ -62,6 +110,15 @@ func hostNnClassifyCollection(collection, namespace, searchMethod, text *string)
 	}
 }
 
+func hostFindNearDuplicates(collection, namespace, searchMethod *string, threshold float64) *CollectionDuplicatesResult {
+	FindNearDuplicatesCallStack.Push(collection, namespace, searchMethod, threshold)
+
+	return &CollectionDuplicatesResult{
+		Collection: *collection,
+		Status:     "success",
+	}
+}
+
 func hostRecomputeSearchMethod(collection, namespace, searchMethod *string) *SearchMethodMutationResult {
 	RecomputeSearchMethodCallStack.Push(collection, namespace, searchMethod)
 
@@ -130,3 +187,143 @@ func hostSearchCollectionByVector(collection *string, namespaces *[]string, sear
 		Status:     "success",
 	}
 }
+
+func hostUpsertToCollectionWithMetadata(collection, namespace *string, keys, texts *[]string, labels *[][]string, metadata *[]string) *CollectionMutationResult {
+	UpsertWithMetadataCallStack.Push(collection, namespace, keys, texts, labels, metadata)
+
+	return &CollectionMutationResult{
+		Collection: *collection,
+		Status:     "success",
+	}
+}
+
+func hostSearchCollectionWithFilter(collection *string, namespaces *[]string, searchMethod, text *string, limit int32, returnText bool, filter *string) *CollectionSearchResult {
+	SearchWithFilterCallStack.Push(collection, namespaces, searchMethod, text, limit, returnText, filter)
+
+	return &CollectionSearchResult{
+		Collection: *collection,
+		Status:     "success",
+	}
+}
+
+func hostSearchCollectionByVectorWithFilter(collection *string, namespaces *[]string, searchMethod *string, vector *[]float32, limit int32, returnText bool, filter *string) *CollectionSearchResult {
+	SearchByVectorWithFilterCallStack.Push(collection, namespaces, searchMethod, vector, limit, returnText, filter)
+
+	return &CollectionSearchResult{
+		Collection: *collection,
+		Status:     "success",
+	}
+}
+
+func hostGetMetadata(collection, namespace, key *string) *string {
+	GetMetadataCallStack.Push(collection, namespace, key)
+
+	ret := `{"lang":"en","year":2024}`
+
+	return &ret
+}
+
+func hostHybridSearchCollection(collection *string, namespaces *[]string, searchMethod, text *string, limit int32, returnText bool, fusion *string, vectorWeight float64, filter *string) *CollectionSearchResult {
+	HybridSearchCallStack.Push(collection, namespaces, searchMethod, text, limit, returnText, fusion, vectorWeight, filter)
+
+	return &CollectionSearchResult{
+		Collection: *collection,
+		Status:     "success",
+	}
+}
+
+func hostWeightedSearchCollection(collection *string, namespaces, searchMethods *[]string, weights *[]float64, text *string, limit int32, returnText bool, filter *string) *CollectionSearchResult {
+	WeightedSearchCallStack.Push(collection, namespaces, searchMethods, weights, text, limit, returnText, filter)
+
+	return &CollectionSearchResult{
+		Collection: *collection,
+		Status:     "success",
+	}
+}
+
+func hostUpsertBatchToCollection(collection, namespace *string, keys, texts *[]string, labels *[][]string, metadata *[]string) *CollectionBatchMutationResult {
+	UpsertBatchCallStack.Push(collection, namespace, keys, texts, labels, metadata)
+
+	return &CollectionBatchMutationResult{
+		Collection: *collection,
+		Operation:  "upsert",
+		Status:     "success",
+		Keys:       *keys,
+		Failures:   []*CollectionMutationFailure{},
+	}
+}
+
+func hostUpsertBatchToCollectionWithTtl(collection, namespace *string, keys, texts *[]string, labels *[][]string, metadata *[]string, ttls *[]int64) *CollectionBatchMutationResult {
+	UpsertBatchWithTtlCallStack.Push(collection, namespace, keys, texts, labels, metadata, ttls)
+
+	return &CollectionBatchMutationResult{
+		Collection: *collection,
+		Operation:  "upsert",
+		Status:     "success",
+		Keys:       *keys,
+		Failures:   []*CollectionMutationFailure{},
+	}
+}
+
+func hostDeleteBatchFromCollection(collection, namespace *string, keys *[]string) *CollectionBatchMutationResult {
+	DeleteBatchCallStack.Push(collection, namespace, keys)
+
+	return &CollectionBatchMutationResult{
+		Collection: *collection,
+		Operation:  "delete",
+		Status:     "partial",
+		Keys:       (*keys)[1:],
+		Failures:   []*CollectionMutationFailure{{Key: (*keys)[0], Error: "key not found"}},
+	}
+}
+
+func hostExportCollection(collection, uri *string) *CollectionSnapshotResult {
+	ExportCallStack.Push(collection, uri)
+
+	return &CollectionSnapshotResult{
+		Collection: *collection,
+		Status:     "success",
+		Operation:  "export",
+		Uri:        *uri,
+		Namespaces: []string{"namespace1", "namespace2"},
+		Count:      3,
+	}
+}
+
+func hostImportCollection(collection, uri *string) *CollectionSnapshotResult {
+	ImportCallStack.Push(collection, uri)
+
+	return &CollectionSnapshotResult{
+		Collection: *collection,
+		Status:     "success",
+		Operation:  "import",
+		Uri:        *uri,
+		Namespaces: []string{"namespace1", "namespace2"},
+		Count:      3,
+	}
+}
+
+var mockItems = []string{"a", "b", "c", "d", "e"}
+
+// hostGetItemsFromCollection pages through mockItems in key order, using the last key of a page as its cursor.
+func hostGetItemsFromCollection(collection, namespace, sortBy *string, descending bool, cursor *string, limit int32) *CollectionItemsResult {
+	GetItemsCallStack.Push(collection, namespace, sortBy, descending, cursor, limit)
+
+	result := &CollectionItemsResult{
+		Collection: *collection,
+		Namespace:  *namespace,
+		Status:     "success",
+		Items:      []*CollectionItemObject{},
+	}
+	for _, key := range mockItems {
+		if key <= *cursor {
+			continue
+		}
+		if len(result.Items) == int(limit) {
+			result.Cursor = result.Items[len(result.Items)-1].Key
+			break
+		}
+		result.Items = append(result.Items, &CollectionItemObject{Key: key, Text: "text " + key, Labels: []string{}})
+	}
+	return result
+}
@@ -42,6 +42,46 @@ func hostDeleteFromCollection(collection, namespace, key *string) *CollectionMut
 	return (*CollectionMutationResult)(response)
 }
 
+//go:noescape
+//go:wasmimport hypermode deleteFromCollectionIfVersion
+func _hostDeleteFromCollectionIfVersion(collection, namespace, key *string, expectedVersion int64) unsafe.Pointer
+
+//hypermode:import hypermode deleteFromCollectionIfVersion
+func hostDeleteFromCollectionIfVersion(collection, namespace, key *string, expectedVersion int64) *CollectionVersionedMutationResult {
+	response := _hostDeleteFromCollectionIfVersion(collection, namespace, key, expectedVersion)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionVersionedMutationResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode upsertToCollectionIfVersion
+func _hostUpsertToCollectionIfVersion(collection, namespace, key, text *string, labels unsafe.Pointer, metadata *string, expectedVersion int64) unsafe.Pointer
+
+//hypermode:import hypermode upsertToCollectionIfVersion
+func hostUpsertToCollectionIfVersion(collection, namespace, key, text *string, labels *[]string, metadata *string, expectedVersion int64) *CollectionVersionedMutationResult {
+	labelsPtr := unsafe.Pointer(labels)
+	response := _hostUpsertToCollectionIfVersion(collection, namespace, key, text, labelsPtr, metadata, expectedVersion)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionVersionedMutationResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode getVersionFromCollection
+func _hostGetVersionFromCollection(collection, namespace, key *string) unsafe.Pointer
+
+//hypermode:import hypermode getVersionFromCollection
+func hostGetVersionFromCollection(collection, namespace, key *string) *CollectionItemVersion {
+	response := _hostGetVersionFromCollection(collection, namespace, key)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionItemVersion)(response)
+}
+
 //go:noescape
 //go:wasmimport hypermode searchCollection
 func _hostSearchCollection(collection *string, namespaces unsafe.Pointer, searchMethod, text *string, limit int32, returnText bool) unsafe.Pointer
@@ -56,6 +96,19 @@ func hostSearchCollection(collection *string, namespaces *[]string, searchMethod
 	return (*CollectionSearchResult)(response)
 }
 
+//go:noescape
+//go:wasmimport hypermode findNearDuplicates
+func _hostFindNearDuplicates(collection, namespace, searchMethod *string, threshold float64) unsafe.Pointer
+
+//hypermode:import hypermode findNearDuplicates
+func hostFindNearDuplicates(collection, namespace, searchMethod *string, threshold float64) *CollectionDuplicatesResult {
+	response := _hostFindNearDuplicates(collection, namespace, searchMethod, threshold)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionDuplicatesResult)(response)
+}
+
 //go:noescape
 //go:wasmimport hypermode nnClassifyCollection
 func _hostNnClassifyCollection(collection, namespace, searchMethod, text *string) unsafe.Pointer
@@ -174,3 +227,180 @@ func hostSearchCollectionByVector(collection *string, namespaces *[]string, sear
 	}
 	return (*CollectionSearchResult)(response)
 }
+
+//go:noescape
+//go:wasmimport hypermode upsertToCollectionWithMetadata
+func _hostUpsertToCollectionWithMetadata(collection, namespace *string, keys, texts, labels, metadata unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode upsertToCollectionWithMetadata
+func hostUpsertToCollectionWithMetadata(collection, namespace *string, keys, texts *[]string, labels *[][]string, metadata *[]string) *CollectionMutationResult {
+	keysPointer := unsafe.Pointer(keys)
+	textsPointer := unsafe.Pointer(texts)
+	labelsPointer := unsafe.Pointer(labels)
+	metadataPointer := unsafe.Pointer(metadata)
+	response := _hostUpsertToCollectionWithMetadata(collection, namespace, keysPointer, textsPointer, labelsPointer, metadataPointer)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionMutationResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode searchCollectionWithFilter
+func _hostSearchCollectionWithFilter(collection *string, namespaces unsafe.Pointer, searchMethod, text *string, limit int32, returnText bool, filter *string) unsafe.Pointer
+
+//hypermode:import hypermode searchCollectionWithFilter
+func hostSearchCollectionWithFilter(collection *string, namespaces *[]string, searchMethod, text *string, limit int32, returnText bool, filter *string) *CollectionSearchResult {
+	namespacesPtr := unsafe.Pointer(namespaces)
+	response := _hostSearchCollectionWithFilter(collection, namespacesPtr, searchMethod, text, limit, returnText, filter)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionSearchResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode searchCollectionByVectorWithFilter
+func _hostSearchCollectionByVectorWithFilter(collection *string, namespaces unsafe.Pointer, searchMethod *string, vector unsafe.Pointer, limit int32, returnText bool, filter *string) unsafe.Pointer
+
+//hypermode:import hypermode searchCollectionByVectorWithFilter
+func hostSearchCollectionByVectorWithFilter(collection *string, namespaces *[]string, searchMethod *string, vector *[]float32, limit int32, returnText bool, filter *string) *CollectionSearchResult {
+	namespacesPtr := unsafe.Pointer(namespaces)
+	vectorPtr := unsafe.Pointer(vector)
+	response := _hostSearchCollectionByVectorWithFilter(collection, namespacesPtr, searchMethod, vectorPtr, limit, returnText, filter)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionSearchResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode getMetadata
+func _hostGetMetadata(collection, namespace, key *string) unsafe.Pointer
+
+//hypermode:import hypermode getMetadata
+func hostGetMetadata(collection, namespace, key *string) *string {
+	response := _hostGetMetadata(collection, namespace, key)
+	if response == nil {
+		return nil
+	}
+	return (*string)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode hybridSearchCollection
+func _hostHybridSearchCollection(collection *string, namespaces unsafe.Pointer, searchMethod, text *string, limit int32, returnText bool, fusion *string, vectorWeight float64, filter *string) unsafe.Pointer
+
+//hypermode:import hypermode hybridSearchCollection
+func hostHybridSearchCollection(collection *string, namespaces *[]string, searchMethod, text *string, limit int32, returnText bool, fusion *string, vectorWeight float64, filter *string) *CollectionSearchResult {
+	namespacesPtr := unsafe.Pointer(namespaces)
+	response := _hostHybridSearchCollection(collection, namespacesPtr, searchMethod, text, limit, returnText, fusion, vectorWeight, filter)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionSearchResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode weightedSearchCollection
+func _hostWeightedSearchCollection(collection *string, namespaces, searchMethods, weights unsafe.Pointer, text *string, limit int32, returnText bool, filter *string) unsafe.Pointer
+
+//hypermode:import hypermode weightedSearchCollection
+func hostWeightedSearchCollection(collection *string, namespaces, searchMethods *[]string, weights *[]float64, text *string, limit int32, returnText bool, filter *string) *CollectionSearchResult {
+	namespacesPtr := unsafe.Pointer(namespaces)
+	searchMethodsPtr := unsafe.Pointer(searchMethods)
+	weightsPtr := unsafe.Pointer(weights)
+	response := _hostWeightedSearchCollection(collection, namespacesPtr, searchMethodsPtr, weightsPtr, text, limit, returnText, filter)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionSearchResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode upsertBatchToCollection
+func _hostUpsertBatchToCollection(collection, namespace *string, keys, texts, labels, metadata unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode upsertBatchToCollection
+func hostUpsertBatchToCollection(collection, namespace *string, keys, texts *[]string, labels *[][]string, metadata *[]string) *CollectionBatchMutationResult {
+	keysPointer := unsafe.Pointer(keys)
+	textsPointer := unsafe.Pointer(texts)
+	labelsPointer := unsafe.Pointer(labels)
+	metadataPointer := unsafe.Pointer(metadata)
+	response := _hostUpsertBatchToCollection(collection, namespace, keysPointer, textsPointer, labelsPointer, metadataPointer)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionBatchMutationResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode upsertBatchToCollectionWithTtl
+func _hostUpsertBatchToCollectionWithTtl(collection, namespace *string, keys, texts, labels, metadata, ttls unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode upsertBatchToCollectionWithTtl
+func hostUpsertBatchToCollectionWithTtl(collection, namespace *string, keys, texts *[]string, labels *[][]string, metadata *[]string, ttls *[]int64) *CollectionBatchMutationResult {
+	keysPointer := unsafe.Pointer(keys)
+	textsPointer := unsafe.Pointer(texts)
+	labelsPointer := unsafe.Pointer(labels)
+	metadataPointer := unsafe.Pointer(metadata)
+	ttlsPointer := unsafe.Pointer(ttls)
+	response := _hostUpsertBatchToCollectionWithTtl(collection, namespace, keysPointer, textsPointer, labelsPointer, metadataPointer, ttlsPointer)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionBatchMutationResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode deleteBatchFromCollection
+func _hostDeleteBatchFromCollection(collection, namespace *string, keys unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode deleteBatchFromCollection
+func hostDeleteBatchFromCollection(collection, namespace *string, keys *[]string) *CollectionBatchMutationResult {
+	keysPointer := unsafe.Pointer(keys)
+	response := _hostDeleteBatchFromCollection(collection, namespace, keysPointer)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionBatchMutationResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode exportCollection
+func _hostExportCollection(collection, uri *string) unsafe.Pointer
+
+//hypermode:import hypermode exportCollection
+func hostExportCollection(collection, uri *string) *CollectionSnapshotResult {
+	response := _hostExportCollection(collection, uri)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionSnapshotResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode importCollection
+func _hostImportCollection(collection, uri *string) unsafe.Pointer
+
+//hypermode:import hypermode importCollection
+func hostImportCollection(collection, uri *string) *CollectionSnapshotResult {
+	response := _hostImportCollection(collection, uri)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionSnapshotResult)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode getItemsFromCollection
+func _hostGetItemsFromCollection(collection, namespace, sortBy *string, descending bool, cursor *string, limit int32) unsafe.Pointer
+
+//hypermode:import hypermode getItemsFromCollection
+func hostGetItemsFromCollection(collection, namespace, sortBy *string, descending bool, cursor *string, limit int32) *CollectionItemsResult {
+	response := _hostGetItemsFromCollection(collection, namespace, sortBy, descending, cursor, limit)
+	if response == nil {
+		return nil
+	}
+	return (*CollectionItemsResult)(response)
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package config reads the configuration of the Modus app, such as the variables of the manifest,
+// and deployment metadata provided by the runtime under keys with the "modus." prefix.
+package config
+
+// Reserved keys for the deployment metadata provided by the runtime.
+const (
+	KeyEnvironment    = "modus.environment"
+	KeyNamespace      = "modus.namespace"
+	KeyRegion         = "modus.region"
+	KeyRuntimeVersion = "modus.runtime.version"
+	KeyPluginName     = "modus.plugin.name"
+	KeyPluginVersion  = "modus.plugin.version"
+	KeyPluginBuildId  = "modus.plugin.buildId"
+)
+
+// Get returns the value of a configuration item, and whether it is defined.
+func Get(key string) (string, bool) {
+	value := hostGetConfigValue(&key)
+	if value == nil {
+		return "", false
+	}
+	return *value, true
+}
+
+// Keys returns the keys of all configuration items available to the calling function.
+func Keys() []string {
+	keys := hostGetConfigKeys()
+	if keys == nil {
+		return nil
+	}
+	return *keys
+}
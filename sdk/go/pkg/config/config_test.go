@@ -0,0 +1,54 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/config"
+)
+
+func TestGet(t *testing.T) {
+	value, ok := config.Get("apiBaseUrl")
+	if !ok || value != "https://example.com/api" {
+		t.Errorf("Expected value: https://example.com/api, but received: %q (found: %t)", value, ok)
+	}
+
+	value, ok = config.Get(config.KeyEnvironment)
+	if !ok || value != "dev" {
+		t.Errorf("Expected value: dev, but received: %q (found: %t)", value, ok)
+	}
+
+	values := config.GetConfigValueCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a call to the host, but none was found.")
+	}
+	if key := *values[0].(*string); key != "modus.environment" {
+		t.Errorf("Expected key: modus.environment, but received: %s", key)
+	}
+
+	if _, ok := config.Get("missing"); ok {
+		t.Error("Expected no value for a missing key.")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := config.Keys()
+	expected := []string{"apiBaseUrl", "modus.environment"}
+	if !reflect.DeepEqual(expected, keys) {
+		t.Errorf("Expected keys: %v, but received: %v", expected, keys)
+	}
+	if config.GetConfigKeysCallStack.Size() == 0 {
+		t.Error("Expected a call to the host, but none was found.")
+	}
+}
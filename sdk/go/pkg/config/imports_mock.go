@@ -0,0 +1,38 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config
+
+import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+
+var GetConfigValueCallStack = testutils.NewCallStack()
+var GetConfigKeysCallStack = testutils.NewCallStack()
+
+var mockConfig = map[string]string{
+	"apiBaseUrl":        "https://example.com/api",
+	"modus.environment": "dev",
+}
+
+func hostGetConfigValue(key *string) *string {
+	GetConfigValueCallStack.Push(key)
+
+	if value, ok := mockConfig[*key]; ok {
+		return &value
+	}
+	return nil
+}
+
+func hostGetConfigKeys() *[]string {
+	GetConfigKeysCallStack.Push()
+
+	keys := []string{"apiBaseUrl", "modus.environment"}
+	return &keys
+}
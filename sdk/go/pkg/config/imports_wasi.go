@@ -0,0 +1,31 @@
+//go:build wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config
+
+import "unsafe"
+
+//go:noescape
+//go:wasmimport hypermode getConfigValue
+func hostGetConfigValue(key *string) *string
+
+//go:noescape
+//go:wasmimport hypermode getConfigKeys
+func _hostGetConfigKeys() unsafe.Pointer
+
+//hypermode:import hypermode getConfigKeys
+func hostGetConfigKeys() *[]string {
+	response := _hostGetConfigKeys()
+	if response == nil {
+		return nil
+	}
+	return (*[]string)(response)
+}
@@ -9,7 +9,12 @@
 
 package console
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
 
 func Assert(condition bool, message string) {
 	if !condition {
@@ -56,3 +61,30 @@ func Error(message string) {
 func Errorf(format string, args ...any) {
 	Error(fmt.Sprintf(format, args...))
 }
+
+// DetailedError is implemented by errors that describe themselves to the caller of a function
+// with more than a message, such as graphql.Error.
+type DetailedError interface {
+	error
+	ErrorCode() string
+	ErrorCategory() string
+	ErrorDetails() map[string]any
+}
+
+// ReportError reports an error returned by a function to its caller.
+// Errors that implement DetailedError are reported with their code, category and details.
+func ReportError(err error) {
+	var de DetailedError
+	if !errors.As(err, &de) {
+		Error(err.Error())
+		return
+	}
+
+	details := ""
+	if d := de.ErrorDetails(); d != nil {
+		if b, e := utils.JsonSerialize(d); e == nil {
+			details = string(b)
+		}
+	}
+	reportError(err.Error(), de.ErrorCode(), de.ErrorCategory(), details)
+}
@@ -12,9 +12,12 @@
 package console_test
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/hypermodeinc/modus/sdk/go/pkg/console"
+	"github.com/hypermodeinc/modus/sdk/go/pkg/graphql"
 )
 
 func Test_Log(t *testing.T) {
@@ -124,3 +127,30 @@ func Test_Assert(t *testing.T) {
 		t.Errorf(`LogCalls[1] = %s; want "Assertion failed: Condition is false"`, values[1])
 	}
 }
+
+func Test_ReportError(t *testing.T) {
+	// Test case 1: a plain error is logged as an error message
+	console.ReportError(errors.New("Something went wrong."))
+	values := console.LogCallStack.Pop()
+	if len(values) != 2 {
+		t.Errorf("Expected 2 values, but got %d values", len(values))
+	}
+	if values[0] != "error" || values[1] != "Something went wrong." {
+		t.Errorf(`LogCalls = %v; want ["error" "Something went wrong."]`, values)
+	}
+
+	// Test case 2: a detailed error is reported with its code, category and details, even when wrapped
+	err := graphql.NewError("USER_NOT_FOUND", graphql.CategoryNotFound, "User not found.").
+		WithDetails(map[string]any{"id": "123"})
+	console.ReportError(fmt.Errorf("getUser: %w", err))
+	values = console.ReportErrorCallStack.Pop()
+	expected := []any{"getUser: User not found.", "USER_NOT_FOUND", "NOT_FOUND", `{"id":"123"}`}
+	if len(values) != len(expected) {
+		t.Fatalf("Expected %d values, but got %d values", len(expected), len(values))
+	}
+	for i := range expected {
+		if values[i] != expected[i] {
+			t.Errorf(`ReportErrorCalls[%d] = %v; want %v`, i, values[i], expected[i])
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 )
 
 var LogCallStack = testutils.NewCallStack()
+var ReportErrorCallStack = testutils.NewCallStack()
 
 func log(level, message string) {
 	LogCallStack.Push(level, message)
@@ -28,3 +29,8 @@ func log(level, message string) {
 		fmt.Printf("[%s] %s\n", level, message)
 	}
 }
+
+func reportError(message, code, category, details string) {
+	ReportErrorCallStack.Push(message, code, category, details)
+	fmt.Printf("[error] %s\n", message)
+}
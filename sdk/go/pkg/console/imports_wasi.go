@@ -18,3 +18,11 @@ func _log(level, message *string)
 func log(level, message string) {
 	_log(&level, &message)
 }
+
+//go:noescape
+//go:wasmimport hypermode reportError
+func _reportError(message, code, category, details *string)
+
+func reportError(message, code, category, details string) {
+	_reportError(&message, &code, &category, &details)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package expressions evaluates CEL expressions (https://cel.dev) in the Modus runtime.
+// The expressions can access the data passed to them as `data`.
+package expressions
+
+import (
+	"errors"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// The optional function libraries that can be enabled for an expression.
+const (
+	LibStrings  = "strings"
+	LibMath     = "math"
+	LibEncoders = "encoders"
+	LibLists    = "lists"
+	LibSets     = "sets"
+)
+
+// Evaluate evaluates a CEL expression against the data, and returns the result as type T.
+// The data is serialized as JSON, and must be nil or serializable.  Any optional function libraries
+// that the expression uses must be enabled by name, such as LibStrings.
+func Evaluate[T any](expression string, data any, libs ...string) (T, error) {
+	var result T
+
+	var dataJson string
+	if data != nil {
+		bytes, err := utils.JsonSerialize(data)
+		if err != nil {
+			return result, err
+		}
+		dataJson = string(bytes)
+	}
+
+	response := hostEvaluateExpression(&expression, &dataJson, &libs)
+	if response == nil {
+		return result, errors.New("Failed to evaluate the expression. Check the logs for more information.")
+	}
+
+	if err := utils.JsonDeserialize([]byte(*response), &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package expressions_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/expressions"
+)
+
+func TestEvaluate(t *testing.T) {
+	data := map[string]int{"a": 1, "b": 2}
+	result, err := expressions.Evaluate[map[string]int]("data", data, expressions.LibMath)
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if !reflect.DeepEqual(data, result) {
+		t.Errorf("Expected result: %v, but received: %v", data, result)
+	}
+
+	values := expressions.EvaluateExpressionCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a call to the host, but none was found.")
+	}
+	if json := *values[1].(*string); json != `{"a":1,"b":2}` {
+		t.Errorf("Expected data: {\"a\":1,\"b\":2}, but received: %s", json)
+	}
+	if libs := *values[2].(*[]string); !reflect.DeepEqual([]string{"math"}, libs) {
+		t.Errorf("Expected libs: [math], but received: %v", libs)
+	}
+}
+
+func TestEvaluateWithoutData(t *testing.T) {
+	result, err := expressions.Evaluate[*int]("data", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if result != nil {
+		t.Errorf("Expected a nil result, but received: %v", *result)
+	}
+
+	values := expressions.EvaluateExpressionCallStack.Pop()
+	if json := *values[1].(*string); json != "" {
+		t.Errorf("Expected no data, but received: %s", json)
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	if _, err := expressions.Evaluate[bool]("data.", nil); err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
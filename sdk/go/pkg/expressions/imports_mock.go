@@ -0,0 +1,30 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package expressions
+
+import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+
+var EvaluateExpressionCallStack = testutils.NewCallStack()
+
+// The mock returns the data for the expression "data", and fails for any other expression.
+func hostEvaluateExpression(expression, data *string, libs *[]string) *string {
+	EvaluateExpressionCallStack.Push(expression, data, libs)
+
+	if *expression != "data" {
+		return nil
+	}
+	if *data == "" {
+		result := "null"
+		return &result
+	}
+	return data
+}
@@ -0,0 +1,27 @@
+//go:build wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package expressions
+
+import "unsafe"
+
+//go:noescape
+//go:wasmimport hypermode evaluateExpression
+func _hostEvaluateExpression(expression, data *string, libs unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode evaluateExpression
+func hostEvaluateExpression(expression, data *string, libs *[]string) *string {
+	response := _hostEvaluateExpression(expression, data, unsafe.Pointer(libs))
+	if response == nil {
+		return nil
+	}
+	return (*string)(response)
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package graphql
+
+// ErrorCategory is the broad kind of an error, similar to the class of an HTTP status code.
+type ErrorCategory string
+
+const (
+	CategoryBadRequest      ErrorCategory = "BAD_REQUEST"
+	CategoryUnauthenticated ErrorCategory = "UNAUTHENTICATED"
+	CategoryForbidden       ErrorCategory = "FORBIDDEN"
+	CategoryNotFound        ErrorCategory = "NOT_FOUND"
+	CategoryConflict        ErrorCategory = "CONFLICT"
+	CategoryTooManyRequests ErrorCategory = "TOO_MANY_REQUESTS"
+	CategoryInternal        ErrorCategory = "INTERNAL"
+	CategoryUnavailable     ErrorCategory = "UNAVAILABLE"
+)
+
+// Error is an error that a function can return to describe the failure to its caller.
+// Its code, category and details are placed in the extensions of the GraphQL error, as they are given.
+// If the function's result is nullable, any other fields of the response are still returned.
+type Error struct {
+	Message  string
+	Code     string
+	Category ErrorCategory
+	Details  map[string]any
+}
+
+// NewError creates an error with the given code, category and message.
+func NewError(code string, category ErrorCategory, message string) *Error {
+	return &Error{Message: message, Code: code, Category: category}
+}
+
+// WithDetails sets the details of the error, and returns the error.
+func (e *Error) WithDetails(details map[string]any) *Error {
+	e.Details = details
+	return e
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) ErrorCode() string {
+	return e.Code
+}
+
+func (e *Error) ErrorCategory() string {
+	return string(e.Category)
+}
+
+func (e *Error) ErrorDetails() map[string]any {
+	return e.Details
+}
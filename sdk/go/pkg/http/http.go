@@ -38,5 +38,11 @@ func Fetch[T *Request | string](requestOrUrl T, options ...*RequestOptions) (*Re
 		return nil, errors.New(msg)
 	}
 
+	// If no response was received, return the error to the caller.
+	// Otherwise, the caller can inspect the status code and error on the response.
+	if response.Status == 0 && response.Error != nil {
+		return nil, response.Error
+	}
+
 	return response, nil
 }
@@ -124,3 +124,45 @@ func TestFetchTooManyOptionsParameters(t *testing.T) {
 	}()
 	_ = http.NewRequest("https://example.com", nil, nil)
 }
+
+func TestFetchAll(t *testing.T) {
+	request := http.NewRequest("https://example.com/items")
+	options := &http.PaginationOptions{
+		Strategy:    http.PaginationCursor,
+		ItemsPath:   "data",
+		CursorParam: "cursor",
+		CursorPath:  "next",
+	}
+	response, err := http.FetchAll(request, options)
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if response.Pages != 2 {
+		t.Errorf("Expected 2 pages, but received: %d", response.Pages)
+	}
+
+	var items []int
+	response.JSON(&items)
+	if !reflect.DeepEqual([]int{1, 2, 3}, items) {
+		t.Errorf("Expected items [1 2 3], but received: %v", items)
+	}
+
+	values := http.FetchAllCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a request, but none was found.")
+	} else {
+		if !reflect.DeepEqual(request, values[0]) {
+			t.Errorf("Expected request: %v, but received: %v", request, values[0])
+		}
+		if !reflect.DeepEqual(options, values[1]) {
+			t.Errorf("Expected options: %v, but received: %v", options, values[1])
+		}
+	}
+}
+
+func TestFetchAllWithInvalidUrl(t *testing.T) {
+	_, err := http.FetchAll(http.NewRequest("not a url"), &http.PaginationOptions{Strategy: http.PaginationLink})
+	if err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
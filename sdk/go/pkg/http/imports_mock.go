@@ -27,3 +27,14 @@ func fetch(request *Request) *Response {
 		Body: []byte("Hello, World!"),
 	}
 }
+
+var FetchAllCallStack = testutils.NewCallStack()
+
+func fetchAll(request *Request, options *PaginationOptions) *PagedResponse {
+	FetchAllCallStack.Push(request, options)
+
+	return &PagedResponse{
+		Items: []byte(`[1,2,3]`),
+		Pages: 2,
+	}
+}
@@ -25,3 +25,16 @@ func fetch(request *Request) *Response {
 	}
 	return (*Response)(response)
 }
+
+//go:noescape
+//go:wasmimport hypermode httpFetchAll
+func _fetchAll(request unsafe.Pointer, options unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode httpFetchAll
+func fetchAll(request *Request, options *PaginationOptions) *PagedResponse {
+	response := _fetchAll(unsafe.Pointer(request), unsafe.Pointer(options))
+	if response == nil {
+		return nil
+	}
+	return (*PagedResponse)(response)
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+)
+
+// Pagination strategies, for use with PaginationOptions.
+const (
+	PaginationCursor = "cursor"
+	PaginationPage   = "page"
+	PaginationLink   = "link"
+)
+
+type PaginationOptions struct {
+	// Strategy is one of PaginationCursor, PaginationPage, or PaginationLink.
+	Strategy string
+
+	// ItemsPath is the path to the array of items in each response body (ex: "data.items").
+	// If empty, the response body itself must be an array.
+	ItemsPath string
+
+	// CursorParam is the query parameter used to send the cursor (cursor strategy only).
+	CursorParam string
+
+	// CursorPath is the path to the next cursor in each response body (cursor strategy only).
+	CursorPath string
+
+	// PageParam is the query parameter used to send the page number (page strategy only).
+	PageParam string
+
+	// StartPage is the number of the first page (page strategy only). Defaults to 1.
+	StartPage int32
+
+	// PageSizeParam and PageSize optionally set the number of items requested per page.
+	PageSizeParam string
+	PageSize      int32
+
+	// MaxPages and MaxItems limit the total number of pages requested, and items returned.
+	MaxPages int32
+	MaxItems int32
+}
+
+type PagedResponse struct {
+	// Items is a JSON array of the items collected from all pages.
+	Items []byte
+
+	// Pages is the number of pages that were requested.
+	Pages int32
+
+	// Truncated is true when a limit was reached before the last page.
+	Truncated bool
+
+	// Error is set when a page request failed.  The items of the previous pages are still returned.
+	Error *Error
+}
+
+// FetchAll requests all pages of a paginated API, following the pagination strategy of the options,
+// and returns the items collected from them.
+func FetchAll(request *Request, options *PaginationOptions) (*PagedResponse, error) {
+	if _, err := url.ParseRequestURI(request.Url); err != nil {
+		return nil, errors.New("Invalid URL")
+	}
+
+	response := fetchAll(request, options)
+	if response == nil {
+		msg := "HTTP fetch failed. Check the logs for more information."
+		return nil, errors.New(msg)
+	}
+
+	return response, nil
+}
+
+func (r *PagedResponse) JSON(result any) {
+	if err := json.Unmarshal(r.Items, result); err != nil {
+		panic(err)
+	}
+}
@@ -11,6 +11,7 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
 )
 
 type Response struct {
@@ -18,6 +19,39 @@ type Response struct {
 	StatusText string
 	Headers    *Headers
 	Body       []byte
+
+	// Error is set when the request could not be completed,
+	// or when the server responded with an error status code.
+	Error *Error
+}
+
+// Error describes a failed HTTP request.
+type Error struct {
+	// Code is a short identifier for the kind of error, such as "not_found", "rate_limited" or "timeout".
+	Code string
+
+	// Message is a description of the error.
+	Message string
+
+	// Status is the HTTP status code, or zero if no response was received.
+	Status uint16
+
+	// Headers contains the subset of the response headers that are useful for handling the error,
+	// such as Retry-After and rate limit headers.
+	Headers *Headers
+
+	// BodySnippet contains the beginning of the response body, if any.
+	BodySnippet string
+
+	// Retryable indicates whether the request may succeed if it is retried.
+	Retryable bool
+}
+
+func (e *Error) Error() string {
+	if e.Status == 0 {
+		return fmt.Sprintf("HTTP request failed (%s): %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("HTTP request failed with status %d %s (%s)", e.Status, e.Message, e.Code)
 }
 
 func (r *Response) Ok() bool {
@@ -0,0 +1,28 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package jobs
+
+import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+
+var EnqueueCallStack = testutils.NewCallStack()
+
+// The mock fails for a function named "missing".
+func hostEnqueueJob(req *JobRequest) *string {
+	EnqueueCallStack.Push(req)
+
+	if req.FunctionName == "missing" {
+		return nil
+	}
+
+	id := "job-1"
+	return &id
+}
@@ -0,0 +1,23 @@
+//go:build wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package jobs
+
+import "unsafe"
+
+//go:noescape
+//go:wasmimport hypermode enqueueJob
+func _hostEnqueueJob(req unsafe.Pointer) *string
+
+//hypermode:import hypermode enqueueJob
+func hostEnqueueJob(req *JobRequest) *string {
+	return _hostEnqueueJob(unsafe.Pointer(req))
+}
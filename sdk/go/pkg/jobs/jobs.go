@@ -0,0 +1,67 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package jobs enqueues functions to run in the background, with retries.
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// JobRequest describes a job to add to the queue.
+type JobRequest struct {
+	FunctionName string
+	Payload      string
+	MaxAttempts  int32
+	DelayMs      int64
+}
+
+type EnqueueOption func(*JobRequest)
+
+// WithMaxAttempts sets how many times the job is attempted before it is moved to the dead-letter list.
+// The runtime's default is used if it is not set.
+func WithMaxAttempts(maxAttempts int) EnqueueOption {
+	return func(r *JobRequest) {
+		r.MaxAttempts = int32(maxAttempts)
+	}
+}
+
+// WithDelay sets how long to wait before the job is first attempted.
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(r *JobRequest) {
+		r.DelayMs = delay.Milliseconds()
+	}
+}
+
+// Enqueue adds a job to the queue, and returns the job's ID.
+//
+// The named function is invoked with the payload until it succeeds, or until the maximum number of attempts
+// is reached.  The function must be exported by the plugin, and take either no parameters or a single string.
+func Enqueue(functionName, payload string, opts ...EnqueueOption) (string, error) {
+	if functionName == "" {
+		return "", errors.New("a function name is required")
+	}
+
+	req := &JobRequest{
+		FunctionName: functionName,
+		Payload:      payload,
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	id := hostEnqueueJob(req)
+	if id == nil {
+		return "", fmt.Errorf("failed to enqueue a job for function %s", functionName)
+	}
+
+	return *id, nil
+}
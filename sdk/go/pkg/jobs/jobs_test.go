@@ -0,0 +1,54 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package jobs_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/jobs"
+)
+
+func TestEnqueue(t *testing.T) {
+	id, err := jobs.Enqueue("sendEmail", "hello", jobs.WithMaxAttempts(3), jobs.WithDelay(2*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if id != "job-1" {
+		t.Errorf("Expected job ID: job-1, but received: %s", id)
+	}
+
+	values := jobs.EnqueueCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a job request, but none was found.")
+	}
+
+	expected := &jobs.JobRequest{FunctionName: "sendEmail", Payload: "hello", MaxAttempts: 3, DelayMs: 2000}
+	if req := values[0].(*jobs.JobRequest); !reflect.DeepEqual(expected, req) {
+		t.Errorf("Expected job request: %v, but received: %v", expected, req)
+	}
+}
+
+func TestEnqueueError(t *testing.T) {
+	calls := jobs.EnqueueCallStack.Size()
+	if _, err := jobs.Enqueue("", "hello"); err == nil {
+		t.Error("Expected an error for a missing function name, but received none.")
+	}
+	if jobs.EnqueueCallStack.Size() != calls {
+		t.Error("Expected no call to the host.")
+	}
+
+	if _, err := jobs.Enqueue("missing", "hello"); err == nil {
+		t.Error("Expected an error, but received none.")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package log
+
+import "unsafe"
+
+//go:noescape
+//go:wasmimport hypermode writeLog
+func _hostWriteLog(level, message *string, fields unsafe.Pointer)
+
+//hypermode:import hypermode writeLog
+func hostWriteLog(level, message *string, fields *map[string]string) {
+	_hostWriteLog(level, message, unsafe.Pointer(fields))
+}
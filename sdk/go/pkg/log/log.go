@@ -0,0 +1,28 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package log writes structured log messages, with fields that describe them.
+//
+// The fields are included as attributes in the Modus runtime's log output, and are returned to the caller
+// with the message, in the extensions of the GraphQL response.
+package log
+
+// The levels of log messages.
+const (
+	LevelDebug   = "debug"
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// Write logs a message at the given level, with fields that describe it.
+// Messages at the error level are also returned to the caller as GraphQL errors.
+func Write(level, message string, fields map[string]string) {
+	hostWriteLog(&level, &message, &fields)
+}
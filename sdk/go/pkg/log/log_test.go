@@ -0,0 +1,38 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package log_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/log"
+)
+
+func TestWrite(t *testing.T) {
+	fields := map[string]string{"sku": "a-1", "count": "2"}
+	log.Write(log.LevelWarning, "Low stock.", fields)
+
+	values := log.WriteLogCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a log message, but none was found.")
+	}
+	if level := *values[0].(*string); level != "warning" {
+		t.Errorf("Expected level: warning, but received: %s", level)
+	}
+	if message := *values[1].(*string); message != "Low stock." {
+		t.Errorf("Expected message: Low stock., but received: %s", message)
+	}
+	if f := *values[2].(*map[string]string); !reflect.DeepEqual(f, fields) {
+		t.Errorf("Expected fields: %v, but received: %v", fields, f)
+	}
+}
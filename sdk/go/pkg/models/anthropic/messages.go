@@ -0,0 +1,316 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// The anthropic package provides objects that conform to the Anthropic Messages API.
+//
+// Models using these objects should declare "anthropic" as their provider in the manifest,
+// so that the runtime fills in the API version and other required request details.
+package anthropic
+
+import (
+	"strings"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/models"
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+	"github.com/tidwall/sjson"
+)
+
+// Provides input and output types that conform to the Anthropic Messages API,
+// as described in the [API Reference] docs.
+//
+// [API Reference]: https://docs.anthropic.com/en/api/messages
+type MessagesModel struct {
+	messagesModelBase
+}
+
+type messagesModelBase = models.ModelBase[MessagesModelInput, MessagesModelOutput]
+
+// The input object for the Anthropic Messages API.
+type MessagesModelInput struct {
+
+	// The model that will complete your prompt.
+	//
+	// Must be the exact string expected by the model provider.
+	// For example, "claude-3-5-sonnet-20240620".
+	Model string `json:"model"`
+
+	// The input messages.
+	//
+	// Note that there is no "system" role for input messages.
+	// Use the System field to provide a system prompt.
+	Messages []*Message `json:"messages"`
+
+	// The maximum number of tokens to generate before stopping.
+	//
+	// The default (0) is equivalent to 4096.
+	MaxTokens int `json:"max_tokens,omitempty"`
+
+	// An object describing metadata about the request.
+	Metadata *Metadata `json:"metadata,omitempty"`
+
+	// Custom text sequences that will cause the model to stop generating.
+	StopSequences []string `json:"stop_sequences,omitempty"`
+
+	// A system prompt, providing context and instructions to the model,
+	// such as specifying a particular goal or role.
+	System string `json:"system,omitempty"`
+
+	// A number between 0.0 and 1.0 that controls the randomness injected into the response.
+	//
+	// Use a temperature closer to 0.0 for analytical or multiple choice tasks,
+	// and closer to 1.0 for creative tasks.
+	//
+	// The default value is 1.0.
+	Temperature float64 `json:"temperature"`
+
+	// Definitions of tools that the model may use.
+	//
+	// See https://docs.anthropic.com/en/docs/tool-use
+	Tools []Tool `json:"tools,omitempty"`
+
+	// Controls how the model uses the provided tools.
+	//  - ToolChoiceAuto means the model decides whether to use tools.
+	//  - ToolChoiceAny means the model must use one of the tools.
+	//  - ToolChoiceTool(name) forces the model to use a specific tool.
+	//
+	// The default is ToolChoiceAuto when tools are present.
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	// Only sample from the top K options for each subsequent token.
+	//
+	// Recommended for advanced use cases only.  You usually only need to use Temperature.
+	TopK int `json:"top_k,omitempty"`
+
+	// Use nucleus sampling.
+	//
+	// Recommended for advanced use cases only.  You usually only need to use Temperature.
+	TopP float64 `json:"top_p,omitempty"`
+}
+
+// An object describing metadata about the request.
+type Metadata struct {
+
+	// An external identifier for the user who is associated with the request.
+	UserId string `json:"user_id,omitempty"`
+}
+
+// A message sent to or received from the model.
+type Message struct {
+
+	// The role of the author of this message, either "user" or "assistant".
+	Role string `json:"role"`
+
+	// The content blocks of the message.
+	Content []ContentBlock `json:"content"`
+}
+
+// Creates a new user message object with the given text.
+func NewUserMessage(text string) *Message {
+	return &Message{
+		Role:    "user",
+		Content: []ContentBlock{NewTextBlock(text)},
+	}
+}
+
+// Creates a new assistant message object with the given content blocks.
+//
+// To continue a conversation that includes tool use, pass the content of the model's output.
+func NewAssistantMessage(content ...ContentBlock) *Message {
+	return &Message{
+		Role:    "assistant",
+		Content: content,
+	}
+}
+
+// Creates a new user message object that returns the results of tool use to the model.
+func NewToolResultMessage(results ...ContentBlock) *Message {
+	return &Message{
+		Role:    "user",
+		Content: results,
+	}
+}
+
+// A block of content in a message.
+//
+// The Type field determines which other fields are used:
+//   - "text" blocks use Text.
+//   - "tool_use" blocks, generated by the model, use Id, Name and Input.
+//   - "tool_result" blocks, sent in response to tool use, use ToolUseId, Content and IsError.
+type ContentBlock struct {
+
+	// The type of the content block.
+	Type string `json:"type"`
+
+	// The text of a text block.
+	Text string `json:"text,omitempty"`
+
+	// The id of a tool use block.
+	Id string `json:"id,omitempty"`
+
+	// The name of the tool in a tool use block.
+	Name string `json:"name,omitempty"`
+
+	// The input to the tool in a tool use block, as generated by the model in JSON format.
+	//
+	// NOTE:
+	// The model may generate input that doesn't match the tool's input schema.
+	// Validate the input in your code before using it.
+	Input utils.RawJsonString `json:"input,omitempty"`
+
+	// The id of the tool use block that a tool result block responds to.
+	ToolUseId string `json:"tool_use_id,omitempty"`
+
+	// The content of a tool result block.
+	Content string `json:"content,omitempty"`
+
+	// Whether a tool result block reports an error.
+	IsError bool `json:"is_error,omitempty"`
+}
+
+// Creates a new text content block.
+func NewTextBlock(text string) ContentBlock {
+	return ContentBlock{Type: "text", Text: text}
+}
+
+// Creates a new tool result content block, in response to the tool use block with the given id.
+func NewToolResultBlock(toolUseId, content string, isError bool) ContentBlock {
+	return ContentBlock{
+		Type:      "tool_result",
+		ToolUseId: toolUseId,
+		Content:   content,
+		IsError:   isError,
+	}
+}
+
+// A tool that the model may use.
+type Tool struct {
+
+	// The name of the tool.
+	Name string `json:"name"`
+
+	// An optional, but strongly recommended, description of the tool.
+	Description string `json:"description,omitempty"`
+
+	// The JSON Schema for the tool's input.
+	//
+	// This defines the shape of the input that the tool accepts, and that the model will produce.
+	InputSchema utils.RawJsonString `json:"input_schema"`
+}
+
+// An object specifying how the model should use the provided tools.
+type ToolChoice struct {
+
+	// The type of tool choice.
+	Type string `json:"type"`
+
+	// The name of the tool to use, when the type is "tool".
+	Name string `json:"name,omitempty"`
+}
+
+var (
+	// Directs the model to decide whether to use tools.
+	ToolChoiceAuto = &ToolChoice{Type: "auto"}
+
+	// Directs the model to use one of the provided tools.
+	ToolChoiceAny = &ToolChoice{Type: "any"}
+
+	// Forces the model to use a specific tool.
+	ToolChoiceTool = func(name string) *ToolChoice {
+		return &ToolChoice{Type: "tool", Name: name}
+	}
+)
+
+// The output object for the Anthropic Messages API.
+type MessagesModelOutput struct {
+
+	// A unique identifier for the message.
+	Id string `json:"id"`
+
+	// The object type.  This will always be "message".
+	Type string `json:"type"`
+
+	// The role of the generated message.  This will always be "assistant".
+	Role string `json:"role"`
+
+	// The content generated by the model.
+	Content []ContentBlock `json:"content"`
+
+	// The model that handled the request.
+	Model string `json:"model"`
+
+	// The reason that the model stopped, such as "end_turn", "max_tokens", "stop_sequence" or "tool_use".
+	StopReason string `json:"stop_reason"`
+
+	// The custom stop sequence that was generated, if any.
+	StopSequence string `json:"stop_sequence"`
+
+	// The usage statistics for the request.
+	Usage Usage `json:"usage"`
+}
+
+// Returns the combined text of the text blocks in the output.
+func (o *MessagesModelOutput) Text() string {
+	var sb strings.Builder
+	for _, block := range o.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+	return sb.String()
+}
+
+// Returns the tool use blocks in the output.
+func (o *MessagesModelOutput) ToolUses() []ContentBlock {
+	var toolUses []ContentBlock
+	for _, block := range o.Content {
+		if block.Type == "tool_use" {
+			toolUses = append(toolUses, block)
+		}
+	}
+	return toolUses
+}
+
+// The usage statistics returned by the Anthropic API.
+type Usage struct {
+
+	// The number of input tokens used.
+	InputTokens int `json:"input_tokens"`
+
+	// The number of output tokens used.
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Creates an input object for the Anthropic Messages API.
+func (m *MessagesModel) CreateInput(messages ...*Message) (*MessagesModelInput, error) {
+	return &MessagesModelInput{
+		Model:       m.Info().FullName,
+		Messages:    messages,
+		MaxTokens:   4096,
+		Temperature: 1.0,
+	}, nil
+}
+
+func (mi *MessagesModelInput) MarshalJSON() ([]byte, error) {
+
+	type alias MessagesModelInput
+	b, err := utils.JsonSerialize(alias(*mi))
+	if err != nil {
+		return nil, err
+	}
+
+	// omit default temperature
+	if mi.Temperature == 1.0 {
+		b, err = sjson.DeleteBytes(b, "temperature")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// A request to transcribe audio to text, with a model that follows the OpenAI audio API, such as Whisper.
+type TranscriptionRequest struct {
+
+	// The bytes of the audio file, such as MP3, WAV or WebM.
+	Audio []byte `json:"audio"`
+
+	// The name of the audio file.  Some models use the extension to determine the audio format.
+	FileName string `json:"fileName,omitempty"`
+
+	// The language of the audio, as an ISO-639-1 code such as "en", if known.
+	Language string `json:"language,omitempty"`
+
+	// Text to guide the model's style, or to continue a previous segment of audio.
+	Prompt string `json:"prompt,omitempty"`
+
+	// The sampling temperature, between 0 and 1.
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// The text transcribed from audio.
+type TranscriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// A request to synthesize speech from text, with a model that follows the OpenAI audio API.
+type SpeechRequest struct {
+
+	// The text to speak.
+	Text string `json:"text"`
+
+	// The voice to use.  The supported voices depend on the model.  The default is "alloy".
+	Voice string `json:"voice,omitempty"`
+
+	// The audio format, which is one of "mp3", "opus", "aac", "flac", "wav" or "pcm".  The default is "mp3".
+	Format string `json:"format,omitempty"`
+
+	// The speed of the speech, between 0.25 and 4.  The default is 1.
+	Speed *float64 `json:"speed,omitempty"`
+
+	// A URL to upload the audio to with a PUT request, such as a presigned object storage URL, instead of
+	// returning the audio to the function.  The audio is streamed to the URL as the model produces it.
+	// The URL must belong to a host defined in the manifest.
+	OutputUrl string `json:"outputUrl,omitempty"`
+}
+
+// The speech synthesized from text.  Either the audio or the URL it was uploaded to is set.
+type SpeechResponse struct {
+
+	// The bytes of the audio.
+	Audio []byte `json:"audio,omitempty"`
+
+	// The URL the audio was uploaded to, if an output URL was given in the request.
+	Url string `json:"url,omitempty"`
+
+	// The MIME type of the audio, such as "audio/mpeg".
+	MimeType string `json:"mimeType"`
+
+	// The size of the audio, in bytes.
+	Size int64 `json:"size"`
+}
+
+// Transcribes audio to text with the named model.
+func TranscribeAudio(modelName string, request *TranscriptionRequest) (*TranscriptionResponse, error) {
+	inputJson, err := utils.JsonSerialize(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transcription request for %s: %w", modelName, err)
+	}
+
+	sInputJson := string(inputJson)
+	sOutputJson := transcribeAudio(&modelName, &sInputJson)
+	if sOutputJson == nil {
+		return nil, fmt.Errorf("failed to transcribe audio with model %s", modelName)
+	}
+
+	var response TranscriptionResponse
+	if err := utils.JsonDeserialize([]byte(*sOutputJson), &response); err != nil {
+		return nil, fmt.Errorf("failed to deserialize transcription response for %s: %w", modelName, err)
+	}
+
+	return &response, nil
+}
+
+// Synthesizes speech from text with the named model.
+func SynthesizeSpeech(modelName string, request *SpeechRequest) (*SpeechResponse, error) {
+	inputJson, err := utils.JsonSerialize(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize speech request for %s: %w", modelName, err)
+	}
+
+	sInputJson := string(inputJson)
+	sOutputJson := synthesizeSpeech(&modelName, &sInputJson)
+	if sOutputJson == nil {
+		return nil, fmt.Errorf("failed to synthesize speech with model %s", modelName)
+	}
+
+	var response SpeechResponse
+	if err := utils.JsonDeserialize([]byte(*sOutputJson), &response); err != nil {
+		return nil, fmt.Errorf("failed to deserialize speech response for %s: %w", modelName, err)
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,30 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import "fmt"
+
+// ComputeEmbeddings returns a vector embedding for each of the texts, in the same order, using the named model.
+//
+// The runtime sends the texts to the model in batches, according to the model's provider,
+// so this is much faster than invoking the model once for each text.
+// The model can be any embedding model defined in the manifest, except for Anthropic models, which don't provide embeddings.
+func ComputeEmbeddings(modelName string, texts ...string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	vectors := computeEmbeddings(&modelName, &texts)
+	if vectors == nil {
+		return nil, fmt.Errorf("failed to compute embeddings with model %s", modelName)
+	}
+
+	return *vectors, nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package gemini
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/models"
+)
+
+// Provides input and output types that conform to the Gemini embedContent API,
+// as described in the [API Reference] docs.
+//
+// The model's path in the manifest should use the embedContent method, such as "text-embedding-004:embedContent".
+//
+// [API Reference]: https://ai.google.dev/api/embeddings
+type EmbeddingsModel struct {
+	embeddingsModelBase
+}
+
+type embeddingsModelBase = models.ModelBase[EmbeddingsModelInput, EmbeddingsModelOutput]
+
+// The input object for the Gemini embedContent API.
+type EmbeddingsModelInput struct {
+
+	// The content to embed.  Only text parts are counted.
+	Content *Content `json:"content"`
+
+	// The type of task the embeddings will be used for, such as "RETRIEVAL_QUERY" or "RETRIEVAL_DOCUMENT".
+	TaskType TaskType `json:"taskType,omitempty"`
+
+	// The title of the text, when the task type is "RETRIEVAL_DOCUMENT".
+	Title string `json:"title,omitempty"`
+
+	// The reduced dimension of the output embedding.
+	//
+	// If set, excess values are truncated from the end of the embedding.
+	OutputDimensionality int `json:"outputDimensionality,omitempty"`
+}
+
+// The type of task that embeddings will be used for.
+type TaskType string
+
+const (
+	TaskTypeRetrievalQuery     TaskType = "RETRIEVAL_QUERY"
+	TaskTypeRetrievalDocument  TaskType = "RETRIEVAL_DOCUMENT"
+	TaskTypeSemanticSimilarity TaskType = "SEMANTIC_SIMILARITY"
+	TaskTypeClassification     TaskType = "CLASSIFICATION"
+	TaskTypeClustering         TaskType = "CLUSTERING"
+)
+
+// The output object for the Gemini embedContent API.
+type EmbeddingsModelOutput struct {
+
+	// The embedding generated from the input content.
+	Embedding Embedding `json:"embedding"`
+}
+
+// A list of floats representing an embedding.
+type Embedding struct {
+	Values []float32 `json:"values"`
+}
+
+// Creates an input object for the Gemini embedContent API.
+//
+// The text is combined into a single content object, which results in a single embedding.
+func (m *EmbeddingsModel) CreateInput(text ...string) (*EmbeddingsModelInput, error) {
+	if len(text) == 0 {
+		return nil, fmt.Errorf("at least one text string must be provided")
+	}
+
+	parts := make([]Part, len(text))
+	for i, t := range text {
+		parts[i] = NewTextPart(t)
+	}
+
+	return &EmbeddingsModelInput{
+		Content: &Content{Parts: parts},
+	}, nil
+}
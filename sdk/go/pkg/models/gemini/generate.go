@@ -0,0 +1,281 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// The gemini package provides objects that conform to the Google Gemini API,
+// as served by Google AI Studio or Vertex AI.
+//
+// Models using these objects should declare "gemini" as their provider in the manifest,
+// and include the API method in the model's path, such as "gemini-1.5-flash:generateContent".
+package gemini
+
+import (
+	"strings"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/models"
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// Provides input and output types that conform to the Gemini generateContent API,
+// as described in the [API Reference] docs.
+//
+// [API Reference]: https://ai.google.dev/api/generate-content
+type GenerateModel struct {
+	generateModelBase
+}
+
+type generateModelBase = models.ModelBase[GenerateModelInput, GenerateModelOutput]
+
+// The input object for the Gemini generateContent API.
+type GenerateModelInput struct {
+
+	// The content of the current conversation with the model.
+	//
+	// For single-turn queries, this is a single user content item.
+	// For multi-turn queries, this is the conversation history and the latest request.
+	Contents []*Content `json:"contents"`
+
+	// Developer set system instructions.  Currently text only.
+	SystemInstruction *Content `json:"systemInstruction,omitempty"`
+
+	// Configuration options for model generation and outputs.
+	GenerationConfig *GenerationConfig `json:"generationConfig,omitempty"`
+
+	// A list of unique settings for blocking unsafe content.
+	SafetySettings []SafetySetting `json:"safetySettings,omitempty"`
+
+	// The name of content cached to use as context, in the format "cachedContents/{id}".
+	CachedContent string `json:"cachedContent,omitempty"`
+}
+
+// The multi-part content of a message.
+type Content struct {
+
+	// The producer of the content, either "user" or "model".
+	//
+	// Omit the role for system instructions.
+	Role string `json:"role,omitempty"`
+
+	// The ordered parts that make up the content.
+	Parts []Part `json:"parts"`
+}
+
+// Creates a new user content object with the given parts.
+func NewUserContent(parts ...Part) *Content {
+	return &Content{Role: "user", Parts: parts}
+}
+
+// Creates a new user content object with the given text.
+func NewUserTextContent(text string) *Content {
+	return NewUserContent(NewTextPart(text))
+}
+
+// Creates a new model content object with the given parts.
+//
+// Use this to provide previous model responses when continuing a conversation.
+func NewModelContent(parts ...Part) *Content {
+	return &Content{Role: "model", Parts: parts}
+}
+
+// Creates a new model content object with the given text.
+func NewModelTextContent(text string) *Content {
+	return NewModelContent(NewTextPart(text))
+}
+
+// Creates a new system instruction content object with the given text.
+func NewSystemTextContent(text string) *Content {
+	return &Content{Parts: []Part{NewTextPart(text)}}
+}
+
+// A part of a content object.  Exactly one of the fields should be set.
+type Part struct {
+
+	// Inline text.
+	Text string `json:"text,omitempty"`
+
+	// Inline media bytes, such as an image.
+	InlineData *Blob `json:"inlineData,omitempty"`
+
+	// Media referenced by URI, such as a file uploaded to the Gemini API or to Cloud Storage.
+	FileData *FileData `json:"fileData,omitempty"`
+}
+
+// Raw media bytes sent directly in the request.
+type Blob struct {
+
+	// The IANA standard MIME type of the data, such as "image/png".
+	MimeType string `json:"mimeType"`
+
+	// The raw bytes of the data.  These are base64-encoded in the request.
+	Data []byte `json:"data"`
+}
+
+// A reference to media stored outside of the request.
+type FileData struct {
+
+	// The IANA standard MIME type of the data, such as "application/pdf".
+	MimeType string `json:"mimeType,omitempty"`
+
+	// The URI of the file.
+	FileUri string `json:"fileUri"`
+}
+
+// Creates a new text part.
+func NewTextPart(text string) Part {
+	return Part{Text: text}
+}
+
+// Creates a new part containing the given media bytes.
+func NewInlineDataPart(mimeType string, data []byte) Part {
+	return Part{InlineData: &Blob{MimeType: mimeType, Data: data}}
+}
+
+// Creates a new part that references the media at the given URI.
+func NewFileDataPart(mimeType, fileUri string) Part {
+	return Part{FileData: &FileData{MimeType: mimeType, FileUri: fileUri}}
+}
+
+// Configuration options for model generation and outputs.
+//
+// Fields left at their zero value use the model's default.
+type GenerationConfig struct {
+
+	// The number of generated responses to return.
+	CandidateCount int `json:"candidateCount,omitempty"`
+
+	// Character sequences that will stop output generation.
+	StopSequences []string `json:"stopSequences,omitempty"`
+
+	// The maximum number of tokens to include in a candidate.
+	MaxOutputTokens int `json:"maxOutputTokens,omitempty"`
+
+	// Controls the randomness of the output, from 0.0 to 2.0.
+	//
+	// This is a pointer so that a temperature of 0.0 can be distinguished from the default.
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// The maximum cumulative probability of tokens to consider when sampling.
+	TopP float64 `json:"topP,omitempty"`
+
+	// The maximum number of tokens to consider when sampling.
+	TopK int `json:"topK,omitempty"`
+
+	// The MIME type of the generated text, such as "text/plain" or "application/json".
+	ResponseMimeType string `json:"responseMimeType,omitempty"`
+
+	// The schema that generated JSON text must follow, when the response MIME type is "application/json".
+	ResponseSchema utils.RawJsonString `json:"responseSchema,omitempty"`
+}
+
+// A safety setting, affecting the safety-blocking behavior.
+type SafetySetting struct {
+	Category  HarmCategory       `json:"category"`
+	Threshold HarmBlockThreshold `json:"threshold"`
+}
+
+// The category of a safety rating.
+type HarmCategory string
+
+const (
+	HarmCategoryHateSpeech       HarmCategory = "HARM_CATEGORY_HATE_SPEECH"
+	HarmCategorySexuallyExplicit HarmCategory = "HARM_CATEGORY_SEXUALLY_EXPLICIT"
+	HarmCategoryHarassment       HarmCategory = "HARM_CATEGORY_HARASSMENT"
+	HarmCategoryDangerousContent HarmCategory = "HARM_CATEGORY_DANGEROUS_CONTENT"
+)
+
+// The threshold at which content is blocked.
+type HarmBlockThreshold string
+
+const (
+	BlockLowAndAbove    HarmBlockThreshold = "BLOCK_LOW_AND_ABOVE"
+	BlockMediumAndAbove HarmBlockThreshold = "BLOCK_MEDIUM_AND_ABOVE"
+	BlockOnlyHigh       HarmBlockThreshold = "BLOCK_ONLY_HIGH"
+	BlockNone           HarmBlockThreshold = "BLOCK_NONE"
+)
+
+// The output object for the Gemini generateContent API.
+type GenerateModelOutput struct {
+
+	// Candidate responses from the model.
+	Candidates []Candidate `json:"candidates"`
+
+	// Feedback about the prompt, if it was blocked.
+	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+
+	// Metadata on the request's token usage.
+	UsageMetadata UsageMetadata `json:"usageMetadata"`
+}
+
+// Returns the combined text of the parts of the first candidate, or an empty string if there are no candidates.
+func (o *GenerateModelOutput) Text() string {
+	if len(o.Candidates) == 0 || o.Candidates[0].Content == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, part := range o.Candidates[0].Content.Parts {
+		sb.WriteString(part.Text)
+	}
+	return sb.String()
+}
+
+// A response candidate generated from the model.
+type Candidate struct {
+
+	// The index of the candidate in the list of candidates.
+	Index int `json:"index"`
+
+	// The generated content.
+	Content *Content `json:"content"`
+
+	// The reason why the model stopped generating tokens, such as "STOP", "MAX_TOKENS" or "SAFETY".
+	FinishReason string `json:"finishReason"`
+
+	// Ratings for the safety of the candidate.
+	SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
+}
+
+// The safety rating for a piece of content.
+type SafetyRating struct {
+	Category    HarmCategory `json:"category"`
+	Probability string       `json:"probability"`
+	Blocked     bool         `json:"blocked,omitempty"`
+}
+
+// Feedback about the prompt, if it was blocked.
+type PromptFeedback struct {
+
+	// The reason the prompt was blocked, such as "SAFETY" or "OTHER".
+	BlockReason string `json:"blockReason"`
+
+	// Ratings for the safety of the prompt.
+	SafetyRatings []SafetyRating `json:"safetyRatings"`
+}
+
+// Metadata on the request's token usage.
+type UsageMetadata struct {
+
+	// The number of tokens in the prompt.
+	PromptTokenCount int `json:"promptTokenCount"`
+
+	// The total number of tokens across the generated candidates.
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+
+	// The total number of tokens for the request.
+	TotalTokenCount int `json:"totalTokenCount"`
+
+	// The number of tokens in the cached part of the prompt.
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+}
+
+// Creates an input object for the Gemini generateContent API.
+func (m *GenerateModel) CreateInput(contents ...*Content) (*GenerateModelInput, error) {
+	return &GenerateModelInput{
+		Contents: contents,
+	}, nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package huggingface
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/models"
+)
+
+// Provides input and output types that conform to the Hugging Face feature-extraction task,
+// as described in the [API Reference] docs.
+//
+// The model should be a sentence embedding model, which returns one vector per input text.
+//
+// [API Reference]: https://huggingface.co/docs/api-inference/tasks/feature-extraction
+type FeatureExtractionModel struct {
+	featureExtractionModelBase
+}
+
+type featureExtractionModelBase = models.ModelBase[FeatureExtractionModelInput, FeatureExtractionModelOutput]
+
+// The input object for the feature-extraction task.
+type FeatureExtractionModelInput struct {
+
+	// A list of one or more text strings to create vector embeddings for.
+	Inputs []string `json:"inputs"`
+
+	// Whether to normalize the embeddings.  Only supported by TGI servers.
+	Normalize *bool `json:"normalize,omitempty"`
+
+	// Whether to truncate inputs that are longer than the model's maximum length.
+	Truncate *bool `json:"truncate,omitempty"`
+}
+
+// The output of the feature-extraction task, which is a vector embedding for each input text.
+type FeatureExtractionModelOutput [][]float32
+
+// Creates an input object for the feature-extraction task.
+//
+// The content parameter is a list of one or more text strings to create vector embeddings for.
+func (m *FeatureExtractionModel) CreateInput(content ...string) (*FeatureExtractionModelInput, error) {
+	if len(content) == 0 {
+		return nil, fmt.Errorf("at least one text string must be provided")
+	}
+
+	return &FeatureExtractionModelInput{Inputs: content}, nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// The huggingface package provides objects that conform to the Hugging Face Inference API,
+// as served by the serverless API, Inference Endpoints, or a self-hosted Text Generation Inference (TGI) server.
+//
+// Models using these objects should declare "hugging-face" as their provider in the manifest.
+// Without a path, requests are routed to the model's source model id, such as "mistralai/Mistral-7B-Instruct-v0.3".
+// For a TGI server, the model's path should be "generate".
+package huggingface
+
+import (
+	"github.com/hypermodeinc/modus/sdk/go/pkg/models"
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// Provides input and output types that conform to the Hugging Face text-generation task,
+// as described in the [API Reference] docs.
+//
+// [API Reference]: https://huggingface.co/docs/api-inference/tasks/text-generation
+type TextGenerationModel struct {
+	textGenerationModelBase
+}
+
+type textGenerationModelBase = models.ModelBase[TextGenerationModelInput, TextGenerationModelOutput]
+
+// The input object for the text-generation task.
+type TextGenerationModelInput struct {
+
+	// The prompt to generate text from.
+	Inputs string `json:"inputs"`
+
+	// Optional parameters for the generation.
+	Parameters *TextGenerationParameters `json:"parameters,omitempty"`
+}
+
+// The parameters of the text-generation task.
+type TextGenerationParameters struct {
+
+	// The maximum number of tokens to generate.
+	MaxNewTokens int `json:"max_new_tokens,omitempty"`
+
+	// The sampling temperature.  Higher values produce more random output.
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// The cumulative probability of tokens to sample from.
+	TopP *float64 `json:"top_p,omitempty"`
+
+	// The number of highest probability tokens to sample from.
+	TopK int `json:"top_k,omitempty"`
+
+	// The penalty applied to repeated tokens.  1.0 means no penalty.
+	RepetitionPenalty *float64 `json:"repetition_penalty,omitempty"`
+
+	// Sequences that stop the generation when generated.
+	Stop []string `json:"stop,omitempty"`
+
+	// Whether to include the prompt in the generated text.
+	ReturnFullText *bool `json:"return_full_text,omitempty"`
+
+	// Whether to sample, rather than use greedy decoding.
+	DoSample bool `json:"do_sample,omitempty"`
+
+	// The seed for sampling, for reproducible results.
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// The output object for the text-generation task.
+type TextGenerationModelOutput struct {
+
+	// The generated text.
+	GeneratedText string `json:"generated_text"`
+}
+
+// Creates an input object for the text-generation task.
+func (m *TextGenerationModel) CreateInput(prompt string) (*TextGenerationModelInput, error) {
+	return &TextGenerationModelInput{Inputs: prompt}, nil
+}
+
+// UnmarshalJSON reads the output of either the serverless API, which is a list with a single result,
+// or of a TGI server, which is the result itself.
+func (o *TextGenerationModelOutput) UnmarshalJSON(data []byte) error {
+	type output TextGenerationModelOutput
+	if len(data) > 0 && data[0] == '[' {
+		var results []output
+		if err := utils.JsonDeserialize(data, &results); err != nil {
+			return err
+		}
+		if len(results) > 0 {
+			*o = TextGenerationModelOutput(results[0])
+		}
+		return nil
+	}
+	return utils.JsonDeserialize(data, (*output)(o))
+}
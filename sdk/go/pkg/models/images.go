@@ -0,0 +1,89 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// A request to generate images from a text prompt.
+//
+// The request is the same for all models.  The Modus runtime converts it to the API of the model's provider,
+// such as OpenAI, Stability AI, Amazon Titan Image Generator on Bedrock, or Imagen on Vertex AI.
+type ImageRequest struct {
+
+	// A description of the images to generate.
+	Prompt string `json:"prompt"`
+
+	// A description of what the images should not contain, for models that support it.
+	NegativePrompt string `json:"negativePrompt,omitempty"`
+
+	// The number of images to generate.  The default is one, and the maximum is ten.
+	Count int `json:"count,omitempty"`
+
+	// The size of the images, such as "1024x1024".  The supported sizes depend on the model.
+	Size string `json:"size,omitempty"`
+
+	// The image format, such as "png", "jpeg" or "webp", for models that support more than one.
+	Format string `json:"format,omitempty"`
+
+	// Either ImageResponseData, to return the bytes of each image, or ImageResponseUrl, to return
+	// a link to each image, for providers that store the images they generate.  The default is ImageResponseData.
+	ResponseType string `json:"responseType,omitempty"`
+}
+
+const (
+	ImageResponseData = "data"
+	ImageResponseUrl  = "url"
+)
+
+// The images generated for a request.
+type ImageResponse struct {
+	Images []*GeneratedImage `json:"images"`
+}
+
+// An image generated by a model.  Either the URL or the data is set.
+type GeneratedImage struct {
+
+	// A link to the image, which is usually only valid for a limited time.
+	Url string `json:"url,omitempty"`
+
+	// The bytes of the image.
+	Data []byte `json:"data,omitempty"`
+
+	// The MIME type of the image, such as "image/png".
+	MimeType string `json:"mimeType,omitempty"`
+
+	// The prompt the model actually used, if the model revised the prompt it was given.
+	RevisedPrompt string `json:"revisedPrompt,omitempty"`
+}
+
+// Generates images from a text prompt with the named model.
+func GenerateImages(modelName string, request *ImageRequest) (*ImageResponse, error) {
+	inputJson, err := utils.JsonSerialize(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize image request for %s: %w", modelName, err)
+	}
+
+	sInputJson := string(inputJson)
+	sOutputJson := generateImages(&modelName, &sInputJson)
+	if sOutputJson == nil {
+		return nil, fmt.Errorf("failed to generate images with model %s", modelName)
+	}
+
+	var response ImageResponse
+	if err := utils.JsonDeserialize([]byte(*sOutputJson), &response); err != nil {
+		return nil, fmt.Errorf("failed to deserialize image response for %s: %w", modelName, err)
+	}
+
+	return &response, nil
+}
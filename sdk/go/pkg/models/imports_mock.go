@@ -11,10 +11,25 @@
 
 package models
 
-import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+)
 
 var LookupModelCallStack = testutils.NewCallStack()
 var InvokeModelCallStack = testutils.NewCallStack()
+var InvokeModelWithToolsCallStack = testutils.NewCallStack()
+var ComputeEmbeddingsCallStack = testutils.NewCallStack()
+var GenerateImagesCallStack = testutils.NewCallStack()
+var RerankDocumentsCallStack = testutils.NewCallStack()
+var TranscribeAudioCallStack = testutils.NewCallStack()
+var SynthesizeSpeechCallStack = testutils.NewCallStack()
+var RenderPromptCallStack = testutils.NewCallStack()
+var StartModelStreamCallStack = testutils.NewCallStack()
+var ReadModelStreamCallStack = testutils.NewCallStack()
+var CloseModelStreamCallStack = testutils.NewCallStack()
 
 const MockResponseText = "Hello, World!"
 
@@ -33,3 +48,92 @@ func invokeModel(modelName *string, input *string) *string {
 	output := `{"response":"` + MockResponseText + `"}`
 	return &output
 }
+
+func invokeModelWithTools(modelName *string, request *string) *string {
+	InvokeModelWithToolsCallStack.Push(modelName, request)
+	if strings.Contains(*request, `"responseFormat"`) {
+		output := `{"content":"{\"name\":\"Paris\",\"population\":2100000}","finishReason":"stop"}`
+		return &output
+	}
+	output := `{"content":"","toolCalls":[{"id":"call_1","name":"getWeather","arguments":"{\"city\":\"Paris\"}"}],"finishReason":"tool_calls"}`
+	return &output
+}
+
+func computeEmbeddings(modelName *string, texts *[]string) *[][]float32 {
+	ComputeEmbeddingsCallStack.Push(modelName, texts)
+
+	vectors := make([][]float32, len(*texts))
+	for i, text := range *texts {
+		vectors[i] = []float32{float32(len(text)), float32(i)}
+	}
+	return &vectors
+}
+
+func generateImages(modelName *string, request *string) *string {
+	GenerateImagesCallStack.Push(modelName, request)
+	output := `{"images":[{"data":"iVBORw0KGgo=","mimeType":"image/png"}]}`
+	return &output
+}
+
+func rerankDocuments(modelName *string, request *string) *string {
+	RerankDocumentsCallStack.Push(modelName, request)
+	output := `{"results":[{"index":1,"score":0.9,"document":"b"},{"index":0,"score":0.1,"document":"a"}]}`
+	return &output
+}
+
+func transcribeAudio(modelName *string, request *string) *string {
+	TranscribeAudioCallStack.Push(modelName, request)
+	output := `{"text":"` + MockResponseText + `"}`
+	return &output
+}
+
+func synthesizeSpeech(modelName *string, request *string) *string {
+	SynthesizeSpeechCallStack.Push(modelName, request)
+	output := `{"audio":"SUQz","mimeType":"audio/mpeg","size":3}`
+	return &output
+}
+
+// The mock uses the name of the prompt as its template.
+func renderPrompt(name *string, variables *map[string]string) *string {
+	RenderPromptCallStack.Push(name, variables)
+	result := *name
+	for k, v := range *variables {
+		result = strings.ReplaceAll(result, "{{"+k+"}}", v)
+	}
+	return &result
+}
+
+// MockStreamChunks are the chunks of each mock model stream.
+var MockStreamChunks = []string{`{"text":"Hello"}`, `{"text":", World!"}`}
+
+var mockStreamPositions = map[string]int{}
+
+// The mock fails to start a stream for a model named "missing".
+func startModelStream(modelName *string, input *string) *string {
+	StartModelStreamCallStack.Push(modelName, input)
+	if *modelName == "missing" {
+		return nil
+	}
+	id := fmt.Sprintf("stream-%d", StartModelStreamCallStack.Size())
+	mockStreamPositions[id] = 0
+	return &id
+}
+
+func readModelStream(streamId *string) *ModelStreamChunk {
+	ReadModelStreamCallStack.Push(streamId)
+	pos, ok := mockStreamPositions[*streamId]
+	if !ok {
+		return nil
+	}
+	if pos == len(MockStreamChunks) {
+		delete(mockStreamPositions, *streamId)
+		return &ModelStreamChunk{Done: true}
+	}
+	mockStreamPositions[*streamId] = pos + 1
+	return &ModelStreamChunk{Data: MockStreamChunks[pos]}
+}
+
+func closeModelStream(streamId *string) {
+	CloseModelStreamCallStack.Push(streamId)
+	delete(mockStreamPositions, *streamId)
+}
@@ -29,3 +29,66 @@ func lookupModel(modelName *string) *ModelInfo {
 //go:noescape
 //go:wasmimport hypermode invokeModel
 func invokeModel(modelName *string, input *string) *string
+
+//go:noescape
+//go:wasmimport hypermode invokeModelWithTools
+func invokeModelWithTools(modelName *string, request *string) *string
+
+//go:noescape
+//go:wasmimport hypermode computeEmbeddings
+func _computeEmbeddings(modelName *string, texts unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode computeEmbeddings
+func computeEmbeddings(modelName *string, texts *[]string) *[][]float32 {
+	response := _computeEmbeddings(modelName, unsafe.Pointer(texts))
+	if response == nil {
+		return nil
+	}
+	return (*[][]float32)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode generateImages
+func generateImages(modelName *string, request *string) *string
+
+//go:noescape
+//go:wasmimport hypermode rerankDocuments
+func rerankDocuments(modelName *string, request *string) *string
+
+//go:noescape
+//go:wasmimport hypermode transcribeAudio
+func transcribeAudio(modelName *string, request *string) *string
+
+//go:noescape
+//go:wasmimport hypermode synthesizeSpeech
+func synthesizeSpeech(modelName *string, request *string) *string
+
+//go:noescape
+//go:wasmimport hypermode renderPrompt
+func _renderPrompt(name *string, variables unsafe.Pointer) *string
+
+//hypermode:import hypermode renderPrompt
+func renderPrompt(name *string, variables *map[string]string) *string {
+	return _renderPrompt(name, unsafe.Pointer(variables))
+}
+
+//go:noescape
+//go:wasmimport hypermode startModelStream
+func startModelStream(modelName *string, input *string) *string
+
+//go:noescape
+//go:wasmimport hypermode readModelStream
+func _readModelStream(streamId *string) unsafe.Pointer
+
+//hypermode:import hypermode readModelStream
+func readModelStream(streamId *string) *ModelStreamChunk {
+	chunk := _readModelStream(streamId)
+	if chunk == nil {
+		return nil
+	}
+	return (*ModelStreamChunk)(chunk)
+}
+
+//go:noescape
+//go:wasmimport hypermode closeModelStream
+func closeModelStream(streamId *string)
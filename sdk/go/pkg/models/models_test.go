@@ -113,3 +113,201 @@ func TestInvokeModel_bad_model_instance(t *testing.T) {
 		t.Errorf("Expected output to be nil, but received: %v", output)
 	}
 }
+
+func TestInvokeWithTools(t *testing.T) {
+	modelName := "test"
+	request := &models.ChatRequest{
+		Messages: []*models.ChatMessage{models.NewUserChatMessage("What's the weather in Paris?")},
+		Tools:    []*models.Tool{{Name: "getWeather", Parameters: `{"type":"object","properties":{"city":{"type":"string"}}}`}},
+	}
+
+	response, err := models.InvokeWithTools(modelName, request)
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expectedResponse := &models.ChatResponse{
+		ToolCalls:    []*models.ToolCall{{Id: "call_1", Name: "getWeather", Arguments: `{"city":"Paris"}`}},
+		FinishReason: "tool_calls",
+	}
+	if !reflect.DeepEqual(expectedResponse, response) {
+		t.Errorf("Expected response: %v, but received: %v", expectedResponse, response)
+	}
+
+	values := models.InvokeModelWithToolsCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a model name and request, but none was found.")
+	} else {
+		expectedRequest := `{"messages":[{"role":"user","content":"What's the weather in Paris?"}],"tools":[{"name":"getWeather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}]}`
+		if *values[1].(*string) != expectedRequest {
+			t.Errorf("Expected request: %s, but received: %s", expectedRequest, *values[1].(*string))
+		}
+	}
+}
+
+func TestComputeEmbeddings(t *testing.T) {
+	modelName := "test"
+	vectors, err := models.ComputeEmbeddings(modelName, "a", "abc")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expectedVectors := [][]float32{{1, 0}, {3, 1}}
+	if !reflect.DeepEqual(expectedVectors, vectors) {
+		t.Errorf("Expected vectors: %v, but received: %v", expectedVectors, vectors)
+	}
+
+	values := models.ComputeEmbeddingsCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a model name and texts, but none was found.")
+	} else if *values[0].(*string) != modelName {
+		t.Errorf("Expected model name: %s, but received: %s", modelName, *values[0].(*string))
+	}
+}
+
+type City struct {
+	Name       string   `json:"name"`
+	Population int      `json:"population"`
+	Landmarks  []string `json:"landmarks,omitempty"`
+}
+
+func TestNewResponseFormat(t *testing.T) {
+	format := models.NewResponseFormat[City]()
+	if format.Name != "City" {
+		t.Errorf("Expected name: City, but received: %s", format.Name)
+	}
+
+	expectedSchema := `{"properties":{"landmarks":{"items":{"type":"string"},"type":"array"},"name":{"type":"string"},"population":{"type":"integer"}},"required":["name","population"],"type":"object"}`
+	if string(format.Schema) != expectedSchema {
+		t.Errorf("Expected schema: %s, but received: %s", expectedSchema, format.Schema)
+	}
+}
+
+func TestInvokeStructured(t *testing.T) {
+	request := &models.ChatRequest{
+		Messages: []*models.ChatMessage{models.NewUserChatMessage("Describe Paris.")},
+	}
+
+	city, err := models.InvokeStructured[City]("test", request)
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expectedCity := &City{Name: "Paris", Population: 2100000}
+	if !reflect.DeepEqual(expectedCity, city) {
+		t.Errorf("Expected city: %v, but received: %v", expectedCity, city)
+	}
+
+	if request.ResponseFormat != nil {
+		t.Error("Expected the request not to be modified.")
+	}
+}
+
+func TestGenerateImages(t *testing.T) {
+	response, err := models.GenerateImages("test", &models.ImageRequest{Prompt: "a red fox", Size: "1024x1024"})
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expectedResponse := &models.ImageResponse{
+		Images: []*models.GeneratedImage{{Data: []byte("\x89PNG\r\n\x1a\n"), MimeType: "image/png"}},
+	}
+	if !reflect.DeepEqual(expectedResponse, response) {
+		t.Errorf("Expected response: %v, but received: %v", expectedResponse, response)
+	}
+
+	values := models.GenerateImagesCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a model name and request, but none was found.")
+	} else {
+		expectedRequest := `{"prompt":"a red fox","size":"1024x1024"}`
+		if *values[1].(*string) != expectedRequest {
+			t.Errorf("Expected request: %s, but received: %s", expectedRequest, *values[1].(*string))
+		}
+	}
+}
+
+func TestRerankDocuments(t *testing.T) {
+	response, err := models.RerankDocuments("test", &models.RerankRequest{Query: "q", Documents: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expectedResponse := &models.RerankResponse{
+		Results: []*models.RerankResult{{Index: 1, Score: 0.9, Document: "b"}, {Index: 0, Score: 0.1, Document: "a"}},
+	}
+	if !reflect.DeepEqual(expectedResponse, response) {
+		t.Errorf("Expected response: %v, but received: %v", expectedResponse, response)
+	}
+
+	values := models.RerankDocumentsCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a model name and request, but none was found.")
+	} else {
+		expectedRequest := `{"query":"q","documents":["a","b"]}`
+		if *values[1].(*string) != expectedRequest {
+			t.Errorf("Expected request: %s, but received: %s", expectedRequest, *values[1].(*string))
+		}
+	}
+}
+
+func TestTranscribeAudio(t *testing.T) {
+	response, err := models.TranscribeAudio("test", &models.TranscriptionRequest{Audio: []byte("ID3"), FileName: "speech.mp3"})
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	if response.Text != models.MockResponseText {
+		t.Errorf("Expected text: %s, but received: %s", models.MockResponseText, response.Text)
+	}
+
+	values := models.TranscribeAudioCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a model name and request, but none was found.")
+	} else {
+		expectedRequest := `{"audio":"SUQz","fileName":"speech.mp3"}`
+		if *values[1].(*string) != expectedRequest {
+			t.Errorf("Expected request: %s, but received: %s", expectedRequest, *values[1].(*string))
+		}
+	}
+}
+
+func TestSynthesizeSpeech(t *testing.T) {
+	response, err := models.SynthesizeSpeech("test", &models.SpeechRequest{Text: "Hello, World!", Voice: "nova"})
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expectedResponse := &models.SpeechResponse{Audio: []byte("ID3"), MimeType: "audio/mpeg", Size: 3}
+	if !reflect.DeepEqual(expectedResponse, response) {
+		t.Errorf("Expected response: %v, but received: %v", expectedResponse, response)
+	}
+
+	values := models.SynthesizeSpeechCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a model name and request, but none was found.")
+	} else {
+		expectedRequest := `{"text":"Hello, World!","voice":"nova"}`
+		if *values[1].(*string) != expectedRequest {
+			t.Errorf("Expected request: %s, but received: %s", expectedRequest, *values[1].(*string))
+		}
+	}
+}
+
+func TestRenderPrompt(t *testing.T) {
+	result, err := models.RenderPrompt("Hello, {{name}}!", map[string]string{"name": "World"})
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	if result != "Hello, World!" {
+		t.Errorf("Expected result: %s, but received: %s", "Hello, World!", result)
+	}
+
+	values := models.RenderPromptCallStack.Pop()
+	if values == nil {
+		t.Error("Expected a prompt name and variables, but none was found.")
+	} else if vars := *values[1].(*map[string]string); vars["name"] != "World" {
+		t.Errorf("Expected variable name: World, but received: %s", vars["name"])
+	}
+}
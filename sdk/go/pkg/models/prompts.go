@@ -0,0 +1,30 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import "fmt"
+
+// Renders the named prompt template with the given variables.
+//
+// Templates are declared in the "prompts" section of the manifest, or stored alongside the plugin
+// as ".prompt" files, so they can be changed without rebuilding the plugin.  Variables are written
+// in a template as {{name}}.  An error is returned if the template uses a variable that is not given.
+func RenderPrompt(name string, variables map[string]string) (string, error) {
+	if variables == nil {
+		variables = map[string]string{}
+	}
+
+	result := renderPrompt(&name, &variables)
+	if result == nil {
+		return "", fmt.Errorf("failed to render prompt %s", name)
+	}
+
+	return *result, nil
+}
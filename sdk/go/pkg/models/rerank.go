@@ -0,0 +1,71 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// A request to score candidate documents by their relevance to a query, such as the results of a collection search.
+//
+// The request is the same for all models.  The Modus runtime converts it to the API of the model's provider,
+// such as Cohere, Voyage AI, Jina AI, or a Hugging Face Text Embeddings Inference server.
+type RerankRequest struct {
+
+	// The query to score the documents against.
+	Query string `json:"query"`
+
+	// The candidate documents.  The maximum is 1000.
+	Documents []string `json:"documents"`
+
+	// The number of most relevant documents to return.  The default is to return all of them.
+	TopN int `json:"topN,omitempty"`
+}
+
+// The documents scored for a request, ordered from most to least relevant.
+type RerankResponse struct {
+	Results []*RerankResult `json:"results"`
+}
+
+// The relevance score of a document.
+type RerankResult struct {
+
+	// The position of the document in the request.
+	Index int `json:"index"`
+
+	// The relevance score.  The range of scores depends on the model, but higher is always more relevant.
+	Score float64 `json:"score"`
+
+	// The text of the document.
+	Document string `json:"document"`
+}
+
+// Reranks the documents by their relevance to the query with the named model.
+func RerankDocuments(modelName string, request *RerankRequest) (*RerankResponse, error) {
+	inputJson, err := utils.JsonSerialize(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize rerank request for %s: %w", modelName, err)
+	}
+
+	sInputJson := string(inputJson)
+	sOutputJson := rerankDocuments(&modelName, &sInputJson)
+	if sOutputJson == nil {
+		return nil, fmt.Errorf("failed to rerank documents with model %s", modelName)
+	}
+
+	var response RerankResponse
+	if err := utils.JsonDeserialize([]byte(*sOutputJson), &response); err != nil {
+		return nil, fmt.Errorf("failed to deserialize rerank response for %s: %w", modelName, err)
+	}
+
+	return &response, nil
+}
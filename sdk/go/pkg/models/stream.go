@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// A chunk of a model's streamed response, as returned by the host.
+type ModelStreamChunk struct {
+	Data string
+	Done bool
+}
+
+// A stream of a model's response, which is read one chunk at a time as the model generates it.
+//
+// When the function is called by a GraphQL subscription, each chunk that is read is also sent to the client.
+type ModelStream struct {
+	id   string
+	done bool
+}
+
+// Invokes the model with streaming enabled, and returns a stream of its response.
+// The stream should be read to the end, or closed.
+func (m ModelBase[TIn, TOut]) Stream(input *TIn) (*ModelStream, error) {
+	if m.info == nil {
+		return nil, fmt.Errorf("model info is not set (use GetModel to create a model instance)")
+	}
+
+	modelName := m.info.Name
+	inputJson, err := utils.JsonSerialize(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize model input for %s: %w", modelName, err)
+	}
+
+	sInputJson := string(inputJson)
+	id := startModelStream(&modelName, &sInputJson)
+	if id == nil {
+		return nil, fmt.Errorf("failed to start model stream for %s", modelName)
+	}
+
+	return &ModelStream{id: *id}, nil
+}
+
+// Waits for the next chunk of the model's response, and returns its data, which is usually a JSON object
+// in the format of the model's provider.  The second return value is false when the stream has ended.
+func (s *ModelStream) Next() (string, bool, error) {
+	if s.done {
+		return "", false, nil
+	}
+
+	chunk := readModelStream(&s.id)
+	if chunk == nil {
+		s.done = true
+		return "", false, fmt.Errorf("failed to read model stream %s", s.id)
+	}
+	if chunk.Done {
+		s.done = true
+		return "", false, nil
+	}
+
+	return chunk.Data, true, nil
+}
+
+// Stops the stream before it has been read to the end.  It does nothing if the stream has already ended.
+func (s *ModelStream) Close() {
+	if !s.done {
+		s.done = true
+		closeModelStream(&s.id)
+	}
+}
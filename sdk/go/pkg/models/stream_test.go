@@ -0,0 +1,98 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/models"
+)
+
+func TestStreamModel(t *testing.T) {
+	model, err := models.GetModel[TestModel]("test")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	stream, err := model.Stream(&TestModelInput{Prompt: "Say Hello."})
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	values := models.StartModelStreamCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected the model name and input, but none were found.")
+	}
+	if name := *values[0].(*string); name != "test" {
+		t.Errorf("Expected model name: test, but received: %s", name)
+	}
+	if input := *values[1].(*string); input != `{"prompt":"Say Hello."}` {
+		t.Errorf("Expected input: %s, but received: %s", `{"prompt":"Say Hello."}`, input)
+	}
+
+	var chunks []string
+	for {
+		data, ok, err := stream.Next()
+		if err != nil {
+			t.Fatalf("Expected no error, but received: %s", err)
+		}
+		if !ok {
+			break
+		}
+		chunks = append(chunks, data)
+	}
+	if !reflect.DeepEqual(chunks, models.MockStreamChunks) {
+		t.Errorf("Expected chunks: %v, but received: %v", models.MockStreamChunks, chunks)
+	}
+
+	// An ended stream is neither read nor closed again.
+	reads := models.ReadModelStreamCallStack.Size()
+	if _, ok, _ := stream.Next(); ok {
+		t.Error("Expected the stream to have ended")
+	}
+	stream.Close()
+	if n := models.ReadModelStreamCallStack.Size(); n != reads {
+		t.Errorf("Expected %d reads, but received: %d", reads, n)
+	}
+	if n := models.CloseModelStreamCallStack.Size(); n != 0 {
+		t.Errorf("Expected no calls to close the stream, but received: %d", n)
+	}
+}
+
+func TestStreamModel_Close(t *testing.T) {
+	model, _ := models.GetModel[TestModel]("test")
+	stream, err := model.Stream(&TestModelInput{Prompt: "Say Hello."})
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	if data, ok, err := stream.Next(); err != nil || !ok || data != models.MockStreamChunks[0] {
+		t.Errorf("Expected the first chunk, but received: %q, %v, %v", data, ok, err)
+	}
+
+	closes := models.CloseModelStreamCallStack.Size()
+	stream.Close()
+	if n := models.CloseModelStreamCallStack.Size(); n != closes+1 {
+		t.Errorf("Expected the stream to be closed once, but it was closed %d times", n-closes)
+	}
+	if _, ok, _ := stream.Next(); ok {
+		t.Error("Expected no more chunks after closing the stream")
+	}
+}
+
+func TestStreamModel_Error(t *testing.T) {
+	model, _ := models.GetModel[TestModel]("missing")
+	if _, err := model.Stream(&TestModelInput{Prompt: "Say Hello."}); err == nil {
+		t.Error("Expected an error, but received none")
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// Constrains the model's response to a JSON value that matches a schema.
+//
+// The Modus runtime requests structured output from models whose provider supports it,
+// and instructs other models to respond with JSON matching the schema.
+// The response is always validated against the schema.
+type ResponseFormat struct {
+
+	// A name for the schema, such as the name of the type it describes.
+	Name string `json:"name,omitempty"`
+
+	// The JSON Schema that the response must match.
+	Schema utils.RawJsonString `json:"schema"`
+
+	// The number of times the model is asked to correct a response that doesn't match the schema,
+	// before an error is returned.  The default is zero, and the maximum is five.
+	MaxRetries int `json:"maxRetries,omitempty"`
+}
+
+// Creates a response format with a JSON Schema generated from the type T, which is usually a struct.
+//
+// Struct fields are named by their json tags, and fields without "omitempty" are required.
+func NewResponseFormat[T any]() *ResponseFormat {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	schema := schemaFor(t, map[reflect.Type]bool{})
+	data, _ := utils.JsonSerialize(schema)
+	return &ResponseFormat{Name: t.Name(), Schema: utils.RawJsonString(data)}
+}
+
+// Sends a chat request to the named model, and returns the model's response as a value of type T.
+//
+// If the request doesn't include a response format, one is generated from the type T.
+// An error is returned if the model calls a tool instead of responding, or if the response doesn't match the schema.
+func InvokeStructured[T any](modelName string, request *ChatRequest) (*T, error) {
+	if request.ResponseFormat == nil {
+		r := *request
+		r.ResponseFormat = NewResponseFormat[T]()
+		request = &r
+	}
+
+	response, err := InvokeWithTools(modelName, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.ToolCalls) > 0 {
+		return nil, fmt.Errorf("model %s called tools instead of responding", modelName)
+	}
+
+	var result T
+	if err := utils.JsonDeserialize([]byte(response.Content), &result); err != nil {
+		return nil, fmt.Errorf("failed to deserialize structured response for %s: %w", modelName, err)
+	}
+
+	return &result, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string"} // base64 encoded bytes
+		}
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), visiting)}
+	case reflect.Struct:
+		// Recursive types are allowed to be any value where they recur.
+		if visiting[t] {
+			return map[string]any{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := make(map[string]any)
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+
+			properties[name] = schemaFor(f.Type, visiting)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required}
+	default:
+		return map[string]any{}
+	}
+}
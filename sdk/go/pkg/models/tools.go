@@ -0,0 +1,166 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package models
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// A chat request in which tools can be declared for the model to call.
+//
+// The request is the same for all models.  The Modus runtime converts it to the API of the model's provider,
+// such as OpenAI tools or Anthropic tool use, based on the provider declared in the manifest.
+type ChatRequest struct {
+
+	// The messages of the conversation so far.
+	Messages []*ChatMessage `json:"messages"`
+
+	// The tools that the model may call.
+	Tools []*Tool `json:"tools,omitempty"`
+
+	// Controls how the model uses the tools.
+	//  - ToolChoiceAuto means the model decides whether to call tools.
+	//  - ToolChoiceNone means the model must not call tools.
+	//  - ToolChoiceRequired means the model must call at least one tool.
+	//  - The name of a tool forces the model to call that tool.
+	//
+	// The default is ToolChoiceAuto when tools are present.
+	ToolChoice string `json:"toolChoice,omitempty"`
+
+	// The maximum number of tokens to generate.  If zero, the provider's default is used.
+	MaxTokens int `json:"maxTokens,omitempty"`
+
+	// The sampling temperature.  If nil, the provider's default is used.
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// Constrains the model's final response to JSON that matches a schema.  See InvokeStructured.
+	ResponseFormat *ResponseFormat `json:"responseFormat,omitempty"`
+}
+
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
+// A message in a chat request.
+type ChatMessage struct {
+
+	// The role of the author of the message, either "system", "user", "assistant" or "tool".
+	Role string `json:"role"`
+
+	// The text content of the message.
+	Content string `json:"content,omitempty"`
+
+	// The tool calls made by the model, in an assistant message.
+	ToolCalls []*ToolCall `json:"toolCalls,omitempty"`
+
+	// The id of the tool call that a tool message responds to.
+	ToolCallId string `json:"toolCallId,omitempty"`
+}
+
+// Creates a new system message with the given text.
+func NewSystemChatMessage(text string) *ChatMessage {
+	return &ChatMessage{Role: "system", Content: text}
+}
+
+// Creates a new user message with the given text.
+func NewUserChatMessage(text string) *ChatMessage {
+	return &ChatMessage{Role: "user", Content: text}
+}
+
+// Creates a new assistant message from the model's response, so that the conversation can be continued.
+func NewAssistantChatMessage(response *ChatResponse) *ChatMessage {
+	return &ChatMessage{Role: "assistant", Content: response.Content, ToolCalls: response.ToolCalls}
+}
+
+// Creates a new tool message with the result of the given tool call.
+func NewToolChatMessage(toolCallId, result string) *ChatMessage {
+	return &ChatMessage{Role: "tool", ToolCallId: toolCallId, Content: result}
+}
+
+// A tool that the model may call.
+type Tool struct {
+
+	// The name of the tool.
+	Name string `json:"name"`
+
+	// A description of what the tool does, which helps the model decide when to call it.
+	Description string `json:"description,omitempty"`
+
+	// The JSON Schema of the tool's arguments.  If omitted, the tool takes no arguments.
+	Parameters utils.RawJsonString `json:"parameters,omitempty"`
+}
+
+// A call to a tool, made by the model.
+type ToolCall struct {
+
+	// The id of the tool call, which is used to respond with the tool's result.
+	Id string `json:"id"`
+
+	// The name of the tool to call.
+	Name string `json:"name"`
+
+	// The arguments to the tool, as a JSON object generated by the model.
+	//
+	// NOTE:
+	// The model may generate arguments that don't match the tool's schema.
+	// Validate the arguments in your code before using them.
+	Arguments string `json:"arguments"`
+}
+
+// The response to a chat request.
+type ChatResponse struct {
+
+	// The text generated by the model.
+	Content string `json:"content"`
+
+	// The tools that the model called, if any.
+	ToolCalls []*ToolCall `json:"toolCalls,omitempty"`
+
+	// The reason the model stopped, such as "stop", "length" or "tool_calls".
+	FinishReason string `json:"finishReason"`
+
+	// The usage statistics for the request, if provided by the model.
+	Usage *ChatUsage `json:"usage,omitempty"`
+}
+
+// The usage statistics for a chat request.
+type ChatUsage struct {
+
+	// The number of input tokens used.
+	InputTokens int `json:"inputTokens"`
+
+	// The number of output tokens generated.
+	OutputTokens int `json:"outputTokens"`
+}
+
+// Sends a chat request that may declare tools to the named model, and returns the model's response.
+func InvokeWithTools(modelName string, request *ChatRequest) (*ChatResponse, error) {
+	inputJson, err := utils.JsonSerialize(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize chat request for %s: %w", modelName, err)
+	}
+
+	sInputJson := string(inputJson)
+	sOutputJson := invokeModelWithTools(&modelName, &sInputJson)
+	if sOutputJson == nil {
+		return nil, fmt.Errorf("failed to invoke model %s", modelName)
+	}
+
+	var response ChatResponse
+	if err := utils.JsonDeserialize([]byte(*sOutputJson), &response); err != nil {
+		return nil, fmt.Errorf("failed to deserialize chat response for %s: %w", modelName, err)
+	}
+
+	return &response, nil
+}
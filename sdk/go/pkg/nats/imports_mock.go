@@ -0,0 +1,39 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package nats
+
+import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+
+var NatsPublishCallStack = testutils.NewCallStack()
+var NatsRequestCallStack = testutils.NewCallStack()
+
+// The mocks fail for the host named "unknown", and reply to requests with the request data.
+
+func hostNatsPublish(hostName, subject, data *string) *string {
+	NatsPublishCallStack.Push(hostName, subject, data)
+
+	if *hostName == "unknown" {
+		return nil
+	}
+	result := "success"
+	return &result
+}
+
+func hostNatsRequest(hostName, subject, data *string, timeoutMs int64) *string {
+	NatsRequestCallStack.Push(hostName, subject, data, timeoutMs)
+
+	if *hostName == "unknown" {
+		return nil
+	}
+	result := "reply: " + *data
+	return &result
+}
@@ -0,0 +1,20 @@
+//go:build wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package nats
+
+//go:noescape
+//go:wasmimport hypermode natsPublish
+func hostNatsPublish(hostName, subject, data *string) *string
+
+//go:noescape
+//go:wasmimport hypermode natsRequest
+func hostNatsRequest(hostName, subject, data *string, timeoutMs int64) *string
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package nats publishes messages and sends requests to the NATS hosts defined in the manifest.
+package nats
+
+import (
+	"errors"
+	"time"
+)
+
+// Publish publishes a message to the subject, on the NATS host with the given name.
+func Publish(hostName, subject, data string) error {
+	response := hostNatsPublish(&hostName, &subject, &data)
+	if response == nil {
+		return errors.New("Failed to publish the NATS message.")
+	}
+
+	return nil
+}
+
+// Request sends a request message to the subject, on the NATS host with the given name, and returns
+// the reply.  The request fails if no reply is received within the timeout, or within the default
+// timeout of the runtime if it is zero.
+func Request(hostName, subject, data string, timeout time.Duration) (string, error) {
+	response := hostNatsRequest(&hostName, &subject, &data, timeout.Milliseconds())
+	if response == nil {
+		return "", errors.New("Failed to send the NATS request.")
+	}
+
+	return *response, nil
+}
@@ -0,0 +1,59 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package nats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/nats"
+)
+
+func TestPublish(t *testing.T) {
+	if err := nats.Publish("my-nats", "orders.created", `{"id":1}`); err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	values := nats.NatsPublishCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a call to the host, but none was found.")
+	}
+	if subject := *values[1].(*string); subject != "orders.created" {
+		t.Errorf("Expected subject: orders.created, but received: %s", subject)
+	}
+
+	if err := nats.Publish("unknown", "orders.created", `{"id":1}`); err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
+
+func TestRequest(t *testing.T) {
+	reply, err := nats.Request("my-nats", "orders.get", "1", 2*time.Second)
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if reply != "reply: 1" {
+		t.Errorf("Expected reply: reply: 1, but received: %s", reply)
+	}
+
+	values := nats.NatsRequestCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a call to the host, but none was found.")
+	}
+	if timeoutMs := values[3].(int64); timeoutMs != 2000 {
+		t.Errorf("Expected a timeout of 2000 ms, but received: %d", timeoutMs)
+	}
+
+	if _, err := nats.Request("unknown", "orders.get", "1", 0); err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
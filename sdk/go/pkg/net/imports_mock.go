@@ -0,0 +1,44 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package net
+
+import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+
+var NetLookupCallStack = testutils.NewCallStack()
+var NetProbeCallStack = testutils.NewCallStack()
+
+// The mocks resolve only "localhost", and reach only the host named "up".
+
+func hostNetLookup(host *string) *LookupResult {
+	NetLookupCallStack.Push(host)
+
+	if *host == "" {
+		return nil
+	}
+	if *host != "localhost" {
+		return &LookupResult{Host: *host, Error: "no such host"}
+	}
+	return &LookupResult{Host: *host, Addresses: []string{"127.0.0.1"}, DurationMs: 1}
+}
+
+func hostNetProbe(hostName *string) *ProbeResult {
+	NetProbeCallStack.Push(hostName)
+
+	switch *hostName {
+	case "up":
+		return &ProbeResult{HostName: *hostName, HostType: "http", Address: "localhost:8080", Reachable: true, ResolvedIp: "127.0.0.1"}
+	case "down":
+		return &ProbeResult{HostName: *hostName, HostType: "http", Address: "localhost:8081", ResolvedIp: "127.0.0.1", Error: "connection failed"}
+	default:
+		return nil
+	}
+}
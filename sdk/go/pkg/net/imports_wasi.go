@@ -0,0 +1,40 @@
+//go:build wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package net
+
+import "unsafe"
+
+//go:noescape
+//go:wasmimport hypermode netLookup
+func _hostNetLookup(host *string) unsafe.Pointer
+
+//hypermode:import hypermode netLookup
+func hostNetLookup(host *string) *LookupResult {
+	result := _hostNetLookup(host)
+	if result == nil {
+		return nil
+	}
+	return (*LookupResult)(result)
+}
+
+//go:noescape
+//go:wasmimport hypermode netProbe
+func _hostNetProbe(hostName *string) unsafe.Pointer
+
+//hypermode:import hypermode netProbe
+func hostNetProbe(hostName *string) *ProbeResult {
+	result := _hostNetProbe(hostName)
+	if result == nil {
+		return nil
+	}
+	return (*ProbeResult)(result)
+}
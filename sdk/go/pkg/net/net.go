@@ -0,0 +1,81 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package net diagnoses the network connectivity of the Modus runtime, such as whether it can resolve
+// a host name, or reach a host defined in the manifest.
+package net
+
+import "errors"
+
+// LookupResult is the result of resolving a host name.
+type LookupResult struct {
+	// Host is the host name that was resolved.
+	Host string
+
+	// Addresses are the IP addresses of the host.
+	Addresses []string
+
+	// CanonName is the canonical name of the host, if known.
+	CanonName string
+
+	// DurationMs is how long the resolution took, in milliseconds.
+	DurationMs int64
+
+	// Error describes why the host name could not be resolved, or is empty if it was.
+	Error string
+}
+
+// ProbeResult is the result of probing a host defined in the manifest.
+type ProbeResult struct {
+	// HostName is the name of the host in the manifest.
+	HostName string
+
+	// HostType is the type of the host, such as "http" or "postgresql".
+	HostType string
+
+	// Address is the host and port that was probed.
+	Address string
+
+	// Reachable is true if a connection to the host was established.
+	Reachable bool
+
+	// ResolvedIp is the IP address that the host name resolved to.
+	ResolvedIp string
+
+	// DnsMs, ConnectMs and TlsMs are how long each step of the probe took, in milliseconds.
+	DnsMs     int64
+	ConnectMs int64
+	TlsMs     int64
+
+	// Error describes why the host could not be reached, or is empty if it was.
+	Error string
+}
+
+// Lookup resolves a host name using the DNS resolver of the runtime's environment.
+// A host name that can't be resolved is reported by the Error field of the result, not as an error.
+func Lookup(host string) (*LookupResult, error) {
+	result := hostNetLookup(&host)
+	if result == nil {
+		return nil, errors.New("Failed to resolve the host name. Check the logs for more information.")
+	}
+
+	return result, nil
+}
+
+// Probe checks whether the runtime can reach a host defined in the manifest, by resolving its address
+// and opening a connection to it.  No request is sent to the host.  A host that can't be reached is
+// reported by the Error field of the result, not as an error.
+func Probe(hostName string) (*ProbeResult, error) {
+	result := hostNetProbe(&hostName)
+	if result == nil {
+		return nil, errors.New("Failed to probe the host. Check the logs for more information.")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,71 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package net_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/net"
+)
+
+func TestLookup(t *testing.T) {
+	result, err := net.Lookup("localhost")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if !reflect.DeepEqual([]string{"127.0.0.1"}, result.Addresses) {
+		t.Errorf("Expected addresses: [127.0.0.1], but received: %v", result.Addresses)
+	}
+
+	values := net.NetLookupCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a call to the host, but none was found.")
+	}
+	if host := *values[0].(*string); host != "localhost" {
+		t.Errorf("Expected host: localhost, but received: %s", host)
+	}
+
+	result, err = net.Lookup("missing.example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if result.Error == "" {
+		t.Error("Expected the result to have an error, but it has none.")
+	}
+
+	if _, err := net.Lookup(""); err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	result, err := net.Probe("up")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if !result.Reachable {
+		t.Errorf("Expected the host to be reachable, but received: %s", result.Error)
+	}
+
+	result, err = net.Probe("down")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if result.Reachable || result.Error != "connection failed" {
+		t.Errorf("Expected the host to be unreachable, but received: %+v", result)
+	}
+
+	if _, err := net.Probe("missing"); err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package plugins
+
+import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+
+var CallFunctionCallStack = testutils.NewCallStack()
+
+// The mock returns the arguments it was given as the result, unless the function is named "missing".
+func hostCallFunction(plugin, function, args *string) *string {
+	CallFunctionCallStack.Push(plugin, function, args)
+
+	if *function == "missing" {
+		return nil
+	}
+
+	return args
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package plugins calls the functions of other plugins that are hosted by the same runtime.
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// Calls a function exported by another plugin, with its arguments given by name, and returns its result.
+//
+// The manifest must allow the calling plugin to call the function, by listing it as "plugin.function"
+// in the "calls" of the plugin's section of the "plugins" manifest section.
+func CallFunction[T any](plugin, function string, args map[string]any) (T, error) {
+	var result T
+
+	if args == nil {
+		args = map[string]any{}
+	}
+
+	bytes, err := utils.JsonSerialize(args)
+	if err != nil {
+		return result, err
+	}
+
+	argsStr := string(bytes)
+	response := hostCallFunction(&plugin, &function, &argsStr)
+	if response == nil {
+		return result, fmt.Errorf("failed to call function %s of plugin %s", function, plugin)
+	}
+
+	if err := utils.JsonDeserialize([]byte(*response), &result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,58 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package plugins_test
+
+import (
+	"testing"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/plugins"
+)
+
+type user struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestCallFunction(t *testing.T) {
+	result, err := plugins.CallFunction[user]("users", "getUser", map[string]any{"id": "42", "name": "Ada"})
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expected := user{Id: "42", Name: "Ada"}
+	if result != expected {
+		t.Errorf("Expected result: %v, but received: %v", expected, result)
+	}
+
+	values := plugins.CallFunctionCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a plugin, function and arguments, but none was found.")
+	}
+	if plugin := *values[0].(*string); plugin != "users" {
+		t.Errorf("Expected plugin: users, but received: %s", plugin)
+	}
+	if function := *values[1].(*string); function != "getUser" {
+		t.Errorf("Expected function: getUser, but received: %s", function)
+	}
+}
+
+func TestCallFunctionError(t *testing.T) {
+	_, err := plugins.CallFunction[string]("users", "missing", nil)
+	if err == nil {
+		t.Fatal("Expected an error, but received none.")
+	}
+
+	values := plugins.CallFunctionCallStack.Pop()
+	if args := *values[2].(*string); args != "{}" {
+		t.Errorf("Expected arguments: {}, but received: %s", args)
+	}
+}
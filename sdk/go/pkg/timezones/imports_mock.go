@@ -0,0 +1,94 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package timezones
+
+import (
+	"time"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+)
+
+var GetTimeZonesCallStack = testutils.NewCallStack()
+var GetTimeZoneInfoCallStack = testutils.NewCallStack()
+var GetTimeZoneTransitionsCallStack = testutils.NewCallStack()
+var ConvertTimeZoneCallStack = testutils.NewCallStack()
+var FormatTimeInZoneCallStack = testutils.NewCallStack()
+
+// The mocks only know the "UTC" and "Asia/Tokyo" time zones, and fail for any other.
+var mockZones = map[string]*time.Location{
+	"UTC":        time.UTC,
+	"Asia/Tokyo": time.FixedZone("JST", 9*60*60),
+}
+
+func hostGetTimeZones() *[]string {
+	GetTimeZonesCallStack.Push()
+
+	names := []string{"Asia/Tokyo", "UTC"}
+	return &names
+}
+
+func hostGetTimeZoneInfo(tz *string, instant *time.Time) *TimeZoneInfo {
+	GetTimeZoneInfoCallStack.Push(tz, instant)
+
+	loc, ok := mockZones[*tz]
+	if !ok {
+		return nil
+	}
+
+	abbr, offset := instant.In(loc).Zone()
+	return &TimeZoneInfo{Name: *tz, Abbreviation: abbr, Offset: int32(offset)}
+}
+
+func hostGetTimeZoneTransitions(tz *string, start, end *time.Time) *[]*TimeZoneTransition {
+	GetTimeZoneTransitionsCallStack.Push(tz, start, end)
+
+	if _, ok := mockZones[*tz]; !ok {
+		return nil
+	}
+
+	// neither mock zone has any transitions
+	transitions := []*TimeZoneTransition{}
+	return &transitions
+}
+
+func hostConvertTimeZone(input, fromTz, toTz *string) *string {
+	ConvertTimeZoneCallStack.Push(input, fromTz, toTz)
+
+	from, ok := mockZones[*fromTz]
+	if !ok {
+		return nil
+	}
+	to, ok := mockZones[*toTz]
+	if !ok {
+		return nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02T15:04", *input, from)
+	if err != nil {
+		return nil
+	}
+
+	result := t.In(to).Format(time.RFC3339)
+	return &result
+}
+
+func hostFormatTimeInZone(instant *time.Time, tz *string) *string {
+	FormatTimeInZoneCallStack.Push(instant, tz)
+
+	loc, ok := mockZones[*tz]
+	if !ok {
+		return nil
+	}
+
+	result := instant.In(loc).Format(time.RFC3339)
+	return &result
+}
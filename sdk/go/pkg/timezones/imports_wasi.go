@@ -0,0 +1,69 @@
+//go:build wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package timezones
+
+import (
+	"time"
+	"unsafe"
+)
+
+//go:noescape
+//go:wasmimport hypermode getTimeZones
+func _hostGetTimeZones() unsafe.Pointer
+
+//hypermode:import hypermode getTimeZones
+func hostGetTimeZones() *[]string {
+	response := _hostGetTimeZones()
+	if response == nil {
+		return nil
+	}
+	return (*[]string)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode getTimeZoneInfo
+func _hostGetTimeZoneInfo(tz *string, instant unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode getTimeZoneInfo
+func hostGetTimeZoneInfo(tz *string, instant *time.Time) *TimeZoneInfo {
+	response := _hostGetTimeZoneInfo(tz, unsafe.Pointer(instant))
+	if response == nil {
+		return nil
+	}
+	return (*TimeZoneInfo)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode getTimeZoneTransitions
+func _hostGetTimeZoneTransitions(tz *string, start, end unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode getTimeZoneTransitions
+func hostGetTimeZoneTransitions(tz *string, start, end *time.Time) *[]*TimeZoneTransition {
+	response := _hostGetTimeZoneTransitions(tz, unsafe.Pointer(start), unsafe.Pointer(end))
+	if response == nil {
+		return nil
+	}
+	return (*[]*TimeZoneTransition)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode convertTimeZone
+func hostConvertTimeZone(input, fromTz, toTz *string) *string
+
+//go:noescape
+//go:wasmimport hypermode formatTimeInZone
+func _hostFormatTimeInZone(instant unsafe.Pointer, tz *string) *string
+
+//hypermode:import hypermode formatTimeInZone
+func hostFormatTimeInZone(instant *time.Time, tz *string) *string {
+	return _hostFormatTimeInZone(unsafe.Pointer(instant), tz)
+}
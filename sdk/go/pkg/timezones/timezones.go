@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package timezones looks up time zones, and converts and formats times in them,
+// using the IANA time zone database of the Modus runtime.
+package timezones
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeZoneInfo describes the offset and abbreviation in effect for a time zone at an instant.
+type TimeZoneInfo struct {
+	Name         string
+	Abbreviation string
+	Offset       int32 // seconds east of UTC
+	IsDST        bool
+}
+
+// TimeZoneTransition describes a change of offset or abbreviation in a time zone, such as a DST change.
+type TimeZoneTransition struct {
+	Time   time.Time
+	Before *TimeZoneInfo
+	After  *TimeZoneInfo
+}
+
+// GetTimeZones returns the names of all time zones known to the runtime, such as "America/New_York".
+func GetTimeZones() []string {
+	names := hostGetTimeZones()
+	if names == nil {
+		return nil
+	}
+	return *names
+}
+
+// GetTimeZoneInfo returns the offset and abbreviation in effect for the time zone at the given instant.
+func GetTimeZoneInfo(tz string, instant time.Time) (*TimeZoneInfo, error) {
+	info := hostGetTimeZoneInfo(&tz, &instant)
+	if info == nil {
+		return nil, fmt.Errorf("failed to get info for time zone %s", tz)
+	}
+	return info, nil
+}
+
+// GetTimeZoneTransitions returns the transitions of the time zone that occur after the start instant
+// and before the end instant.
+func GetTimeZoneTransitions(tz string, start, end time.Time) ([]*TimeZoneTransition, error) {
+	transitions := hostGetTimeZoneTransitions(&tz, &start, &end)
+	if transitions == nil {
+		return nil, fmt.Errorf("failed to get transitions for time zone %s", tz)
+	}
+	return *transitions, nil
+}
+
+// ConvertTime interprets a local date and time string, such as "2024-07-01T09:00", in the source time zone,
+// and returns the same instant formatted as RFC 3339 in the target time zone.
+// If the input string includes an offset, it is used instead of the source time zone.
+func ConvertTime(input, fromTz, toTz string) (string, error) {
+	result := hostConvertTimeZone(&input, &fromTz, &toTz)
+	if result == nil {
+		return "", fmt.Errorf("failed to convert time from %s to %s", fromTz, toTz)
+	}
+	return *result, nil
+}
+
+// FormatTime returns the instant formatted as RFC 3339 in the given time zone.
+func FormatTime(instant time.Time, tz string) (string, error) {
+	result := hostFormatTimeInZone(&instant, &tz)
+	if result == nil {
+		return "", fmt.Errorf("failed to format time in time zone %s", tz)
+	}
+	return *result, nil
+}
@@ -0,0 +1,112 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package timezones_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/timezones"
+)
+
+var testInstant = time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+func TestGetTimeZones(t *testing.T) {
+	zones := timezones.GetTimeZones()
+	expected := []string{"Asia/Tokyo", "UTC"}
+	if !reflect.DeepEqual(expected, zones) {
+		t.Errorf("Expected time zones: %v, but received: %v", expected, zones)
+	}
+
+	if timezones.GetTimeZonesCallStack.Size() == 0 {
+		t.Error("Expected a call to the host.")
+	}
+}
+
+func TestGetTimeZoneInfo(t *testing.T) {
+	info, err := timezones.GetTimeZoneInfo("Asia/Tokyo", testInstant)
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expected := &timezones.TimeZoneInfo{Name: "Asia/Tokyo", Abbreviation: "JST", Offset: 9 * 60 * 60}
+	if !reflect.DeepEqual(expected, info) {
+		t.Errorf("Expected info: %v, but received: %v", expected, info)
+	}
+
+	values := timezones.GetTimeZoneInfoCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a call to the host, but none was found.")
+	}
+	if instant := values[1].(*time.Time); !instant.Equal(testInstant) {
+		t.Errorf("Expected instant: %s, but received: %s", testInstant, instant)
+	}
+
+	if _, err := timezones.GetTimeZoneInfo("Not/AZone", testInstant); err == nil {
+		t.Error("Expected an error, but received none.")
+	}
+}
+
+func TestGetTimeZoneTransitions(t *testing.T) {
+	end := testInstant.AddDate(1, 0, 0)
+	transitions, err := timezones.GetTimeZoneTransitions("UTC", testInstant, end)
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if len(transitions) != 0 {
+		t.Errorf("Expected no transitions, but received: %v", transitions)
+	}
+
+	values := timezones.GetTimeZoneTransitionsCallStack.Pop()
+	if values == nil {
+		t.Fatal("Expected a call to the host, but none was found.")
+	}
+	if start := values[1].(*time.Time); !start.Equal(testInstant) {
+		t.Errorf("Expected start: %s, but received: %s", testInstant, start)
+	}
+	if e := values[2].(*time.Time); !e.Equal(end) {
+		t.Errorf("Expected end: %s, but received: %s", end, e)
+	}
+
+	if _, err := timezones.GetTimeZoneTransitions("Not/AZone", testInstant, end); err == nil {
+		t.Error("Expected an error, but received none.")
+	}
+}
+
+func TestConvertTime(t *testing.T) {
+	result, err := timezones.ConvertTime("2024-07-01T09:00", "UTC", "Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if expected := "2024-07-01T18:00:00+09:00"; result != expected {
+		t.Errorf("Expected: %s, but received: %s", expected, result)
+	}
+
+	if _, err := timezones.ConvertTime("2024-07-01T09:00", "UTC", "Not/AZone"); err == nil {
+		t.Error("Expected an error, but received none.")
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	result, err := timezones.FormatTime(testInstant, "Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+	if expected := "2024-07-01T21:00:00+09:00"; result != expected {
+		t.Errorf("Expected: %s, but received: %s", expected, result)
+	}
+
+	if _, err := timezones.FormatTime(testInstant, "Not/AZone"); err == nil {
+		t.Error("Expected an error, but received none.")
+	}
+}
@@ -0,0 +1,61 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package transforms
+
+import "github.com/hypermodeinc/modus/sdk/go/pkg/testutils"
+
+var FlattenDataCallStack = testutils.NewCallStack()
+var GroupDataCallStack = testutils.NewCallStack()
+var ProjectDataCallStack = testutils.NewCallStack()
+var JoinDataCallStack = testutils.NewCallStack()
+
+// The mocks return canned results, or fail when the separator, key, field or kind is "error".
+
+func hostFlattenData(data, separator *string) *string {
+	FlattenDataCallStack.Push(data, separator)
+
+	if *separator == "error" {
+		return nil
+	}
+	result := `{"a.b":1,"a.c":2}`
+	return &result
+}
+
+func hostGroupData(data, key *string) *string {
+	GroupDataCallStack.Push(data, key)
+
+	if *key == "error" {
+		return nil
+	}
+	result := `{"x":[{"k":"x","v":1},{"k":"x","v":3}],"y":[{"k":"y","v":2}]}`
+	return &result
+}
+
+func hostProjectData(data *string, fields *[]string) *string {
+	ProjectDataCallStack.Push(data, fields)
+
+	if len(*fields) > 0 && (*fields)[0] == "error" {
+		return nil
+	}
+	result := `[{"k":"x"},{"k":"y"}]`
+	return &result
+}
+
+func hostJoinData(left, right, leftKey, rightKey, kind *string) *string {
+	JoinDataCallStack.Push(left, right, leftKey, rightKey, kind)
+
+	if *kind == "error" {
+		return nil
+	}
+	result := `[{"k":"x","v":1,"name":"ex"}]`
+	return &result
+}
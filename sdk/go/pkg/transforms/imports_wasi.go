@@ -0,0 +1,39 @@
+//go:build wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package transforms
+
+import "unsafe"
+
+//go:noescape
+//go:wasmimport hypermode flattenData
+func hostFlattenData(data, separator *string) *string
+
+//go:noescape
+//go:wasmimport hypermode groupData
+func hostGroupData(data, key *string) *string
+
+//go:noescape
+//go:wasmimport hypermode projectData
+func _hostProjectData(data *string, fields unsafe.Pointer) unsafe.Pointer
+
+//hypermode:import hypermode projectData
+func hostProjectData(data *string, fields *[]string) *string {
+	response := _hostProjectData(data, unsafe.Pointer(fields))
+	if response == nil {
+		return nil
+	}
+	return (*string)(response)
+}
+
+//go:noescape
+//go:wasmimport hypermode joinData
+func hostJoinData(left, right, leftKey, rightKey, kind *string) *string
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package transforms provides common transformations over JSON data, executed by the Modus runtime
+// so that functions don't have to walk large datasets themselves.
+package transforms
+
+import (
+	"errors"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/utils"
+)
+
+// The kinds of joins, for use with Join.
+const (
+	// JoinInner omits the left items that have no matching right item.
+	JoinInner = "inner"
+
+	// JoinLeft keeps the left items that have no matching right item as they are.
+	JoinLeft = "left"
+)
+
+// Flatten flattens the nested objects of the data into a single level, joining the keys of each path
+// with the separator.  Array elements are addressed by their index.  If the data is an array, each
+// element is flattened individually.
+func Flatten[T any](data any, separator string) (T, error) {
+	var result T
+	dataJson, err := serialize(data)
+	if err != nil {
+		return result, err
+	}
+	return deserialize[T](hostFlattenData(&dataJson, &separator), "flatten")
+}
+
+// GroupBy groups the items by the value at the key path.  Items that don't have the key are grouped
+// under an empty string.
+func GroupBy[T any](items []T, key string) (map[string][]T, error) {
+	dataJson, err := serialize(items)
+	if err != nil {
+		return nil, err
+	}
+	return deserialize[map[string][]T](hostGroupData(&dataJson, &key), "group")
+}
+
+// Project keeps only the given fields of an object, or of each object in an array.  Fields may be
+// nested key paths separated by dots, and keep their nesting in the result.
+func Project[T any](data any, fields ...string) (T, error) {
+	var result T
+	dataJson, err := serialize(data)
+	if err != nil {
+		return result, err
+	}
+	return deserialize[T](hostProjectData(&dataJson, &fields), "project")
+}
+
+// Join joins two arrays of objects where the value at leftKey equals the value at rightKey.  Each result
+// item has the fields of the left item, plus any fields of the right item that the left item doesn't have.
+// The kind is either JoinInner or JoinLeft.
+func Join[T any](left, right any, leftKey, rightKey, kind string) ([]T, error) {
+	leftJson, err := serialize(left)
+	if err != nil {
+		return nil, err
+	}
+	rightJson, err := serialize(right)
+	if err != nil {
+		return nil, err
+	}
+	return deserialize[[]T](hostJoinData(&leftJson, &rightJson, &leftKey, &rightKey, &kind), "join")
+}
+
+func serialize(data any) (string, error) {
+	bytes, err := utils.JsonSerialize(data)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func deserialize[T any](response *string, action string) (T, error) {
+	var result T
+	if response == nil {
+		return result, errors.New("Failed to " + action + " the data. Check the logs for more information.")
+	}
+	if err := utils.JsonDeserialize([]byte(*response), &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
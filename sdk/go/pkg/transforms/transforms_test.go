@@ -0,0 +1,119 @@
+//go:build !wasip1
+
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package transforms_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hypermodeinc/modus/sdk/go/pkg/transforms"
+)
+
+type item struct {
+	K    string `json:"k"`
+	V    int    `json:"v,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+var items = []item{{K: "x", V: 1}, {K: "y", V: 2}, {K: "x", V: 3}}
+
+func TestFlatten(t *testing.T) {
+	data := map[string]any{"a": map[string]int{"b": 1, "c": 2}}
+	result, err := transforms.Flatten[map[string]int](data, ".")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expected := map[string]int{"a.b": 1, "a.c": 2}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected result: %v, but received: %v", expected, result)
+	}
+
+	values := transforms.FlattenDataCallStack.Pop()
+	if json := *values[0].(*string); json != `{"a":{"b":1,"c":2}}` {
+		t.Errorf("Expected data: {\"a\":{\"b\":1,\"c\":2}}, but received: %s", json)
+	}
+
+	if _, err := transforms.Flatten[map[string]int](data, "error"); err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	result, err := transforms.GroupBy(items, "k")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expected := map[string][]item{
+		"x": {{K: "x", V: 1}, {K: "x", V: 3}},
+		"y": {{K: "y", V: 2}},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected result: %v, but received: %v", expected, result)
+	}
+
+	values := transforms.GroupDataCallStack.Pop()
+	if key := *values[1].(*string); key != "k" {
+		t.Errorf("Expected key: k, but received: %s", key)
+	}
+
+	if _, err := transforms.GroupBy(items, "error"); err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
+
+func TestProject(t *testing.T) {
+	result, err := transforms.Project[[]item](items[:2], "k")
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expected := []item{{K: "x"}, {K: "y"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected result: %v, but received: %v", expected, result)
+	}
+
+	values := transforms.ProjectDataCallStack.Pop()
+	if fields := *values[1].(*[]string); !reflect.DeepEqual([]string{"k"}, fields) {
+		t.Errorf("Expected fields: [k], but received: %v", fields)
+	}
+
+	if _, err := transforms.Project[[]item](items, "error"); err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
+
+func TestJoin(t *testing.T) {
+	names := []map[string]string{{"id": "x", "name": "ex"}}
+	result, err := transforms.Join[item](items[:1], names, "k", "id", transforms.JoinInner)
+	if err != nil {
+		t.Fatalf("Expected no error, but received: %s", err)
+	}
+
+	expected := []item{{K: "x", V: 1, Name: "ex"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected result: %v, but received: %v", expected, result)
+	}
+
+	values := transforms.JoinDataCallStack.Pop()
+	if right := *values[1].(*string); right != `[{"id":"x","name":"ex"}]` {
+		t.Errorf("Expected right data: [{\"id\":\"x\",\"name\":\"ex\"}], but received: %s", right)
+	}
+	if kind := *values[4].(*string); kind != "inner" {
+		t.Errorf("Expected kind: inner, but received: %s", kind)
+	}
+
+	if _, err := transforms.Join[item](items, names, "k", "id", "error"); err == nil {
+		t.Error("Expected an error, but none was received.")
+	}
+}
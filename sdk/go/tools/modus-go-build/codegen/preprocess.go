@@ -319,7 +319,7 @@ func writeFuncWrappers(b *bytes.Buffer, pkg *packages.Package, imports map[strin
 			b.WriteByte('\n')
 
 			b.WriteString("\tif err != nil {\n")
-			b.WriteString("\t\tconsole.Error(err.Error())\n")
+			b.WriteString("\t\tconsole.ReportError(err)\n")
 			b.WriteString("\t}\n")
 
 			if numResults > 0 {
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package extractor
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hypermodeinc/modus/sdk/go/tools/modus-go-build/metadata"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// directivePrefix marks a comment line as a Modus directive, such as:
+//
+//	//modus:cache ttl=60
+//
+// Like other Go directives, there is no space after the slashes,
+// which also keeps the line out of the doc comment text.
+const directivePrefix = "//modus:"
+
+// docComments holds the documentation comments found in the source code,
+// keyed by fully-qualified function name, type name, or type name and field name.
+type docComments struct {
+	functions  map[string]string
+	parameters map[string]map[string]string
+	directives map[string][]*metadata.Directive
+	types      map[string]string
+	fields     map[string]map[string]string
+}
+
+func getDocComments(pkgs map[string]*packages.Package) *docComments {
+	docs := &docComments{
+		functions:  make(map[string]string),
+		parameters: make(map[string]map[string]string),
+		directives: make(map[string][]*metadata.Directive),
+		types:      make(map[string]string),
+		fields:     make(map[string]map[string]string),
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if d.Recv == nil {
+						text, params := getFunctionDocText(d.Doc, getParameterNames(d.Type))
+						if text != "" {
+							docs.functions[pkg.PkgPath+"."+d.Name.Name] = text
+						}
+						if len(params) > 0 {
+							docs.parameters[pkg.PkgPath+"."+d.Name.Name] = params
+						}
+						if directives := getDirectives(d.Doc); len(directives) > 0 {
+							docs.directives[pkg.PkgPath+"."+d.Name.Name] = directives
+						}
+					}
+				case *ast.GenDecl:
+					if d.Tok != token.TYPE {
+						continue
+					}
+					for _, spec := range d.Specs {
+						ts := spec.(*ast.TypeSpec)
+						typeName := pkg.PkgPath + "." + ts.Name.Name
+
+						// a lone type declaration has its doc comment on the declaration, not the spec
+						doc := ts.Doc
+						if doc == nil && len(d.Specs) == 1 {
+							doc = d.Doc
+						}
+						if text := getDocText(doc); text != "" {
+							docs.types[typeName] = text
+						}
+
+						if st, ok := ts.Type.(*ast.StructType); ok {
+							for _, f := range st.Fields.List {
+								doc := f.Doc
+								if doc == nil {
+									doc = f.Comment
+								}
+								text := getDocText(doc)
+								if text == "" {
+									continue
+								}
+								if docs.fields[typeName] == nil {
+									docs.fields[typeName] = make(map[string]string)
+								}
+								for _, n := range f.Names {
+									docs.fields[typeName][n.Name] = text
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return docs
+}
+
+func (d *docComments) forFunction(pkgPath, name string) string {
+	return d.functions[pkgPath+"."+name]
+}
+
+func (d *docComments) forParameter(pkgPath, fnName, paramName string) string {
+	return d.parameters[pkgPath+"."+fnName][paramName]
+}
+
+func (d *docComments) directivesForFunction(pkgPath, name string) []*metadata.Directive {
+	return d.directives[pkgPath+"."+name]
+}
+
+func (d *docComments) forType(name string) string {
+	return d.types[name]
+}
+
+func (d *docComments) forField(typeName, fieldName string) string {
+	return d.fields[typeName][fieldName]
+}
+
+func getDocText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+
+	// CommentGroup.Text already excludes directives such as //go:export
+	return strings.TrimSpace(cg.Text())
+}
+
+// getFunctionDocText splits the doc comment of a function into the text that documents the function,
+// and the documentation of its parameters.  A parameter is documented by a list item that starts
+// with its name, such as:
+//
+//	// Says hello to someone.
+//	//   - name: the name of the person to greet
+func getFunctionDocText(cg *ast.CommentGroup, paramNames []string) (string, map[string]string) {
+	text := getDocText(cg)
+	if text == "" || len(paramNames) == 0 {
+		return text, nil
+	}
+
+	var params map[string]string
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		item, isItem := strings.CutPrefix(strings.TrimSpace(line), "- ")
+		name, doc, found := strings.Cut(item, ":")
+		name = strings.TrimSpace(name)
+		if isItem && found && slices.Contains(paramNames, name) {
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[name] = strings.TrimSpace(doc)
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")), params
+}
+
+func getParameterNames(ft *ast.FuncType) []string {
+	var names []string
+	for _, f := range ft.Params.List {
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+	}
+	return names
+}
+
+// getDirectives parses the Modus directives in a comment group.
+// Each directive has a name, optionally followed by space-separated key=value arguments.
+// Values that contain spaces are double-quoted, such as value="team admin".
+func getDirectives(cg *ast.CommentGroup) []*metadata.Directive {
+	if cg == nil {
+		return nil
+	}
+
+	var directives []*metadata.Directive
+	for _, c := range cg.List {
+		text, found := strings.CutPrefix(c.Text, directivePrefix)
+		if !found {
+			continue
+		}
+
+		parts := splitDirective(text)
+		if len(parts) == 0 {
+			continue
+		}
+
+		d := &metadata.Directive{Name: parts[0]}
+		for _, arg := range parts[1:] {
+			if d.Args == nil {
+				d.Args = make(map[string]string)
+			}
+			k, v, _ := strings.Cut(arg, "=")
+			if unquoted, err := strconv.Unquote(v); err == nil {
+				v = unquoted
+			}
+			d.Args[k] = v
+		}
+		directives = append(directives, d)
+	}
+
+	return directives
+}
+
+// splitDirective splits the text of a directive at spaces, except for those within double quotes.
+func splitDirective(text string) []string {
+	var parts []string
+	var sb strings.Builder
+	inQuotes, escaped := false, false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuotes && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && unicode.IsSpace(r):
+			if sb.Len() > 0 {
+				parts = append(parts, sb.String())
+				sb.Reset()
+			}
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	if sb.Len() > 0 {
+		parts = append(parts, sb.String())
+	}
+	return parts
+}
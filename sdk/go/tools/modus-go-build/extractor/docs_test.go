@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 Hypermode Inc.
+ * Licensed under the terms of the Apache License, Version 2.0
+ * See the LICENSE file that accompanied this code for further details.
+ *
+ * SPDX-FileCopyrightText: 2024 Hypermode Inc. <hello@hypermode.com>
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package extractor
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const testSource = `package example
+
+// Says hello to someone.
+//
+//   - name: the name of the person to greet
+//   - other: not a parameter, so it stays in the text
+//
+//modus:cache ttl=60
+func SayHello(name string, loud bool) string {
+	return ""
+}
+
+// A person.
+type Person struct {
+	// The person's name.
+	Name string
+
+	Age int // The person's age, in years.
+}
+
+type (
+	// A place.
+	Place struct{}
+
+	Unknown struct{}
+)
+
+// Deletes a team.
+//
+//modus:auth claim=role value="team admin"
+//modus:cache
+//modus:note text="say \"hi\"" other=a=b
+func DeleteTeam(id string) {
+}
+
+// Methods aren't functions of the plugin.
+func (p Person) Greet() string {
+	return ""
+}
+`
+
+func parseTestDocs(t *testing.T, src string) *docComments {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "example.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse the test source: %s", err)
+	}
+
+	pkg := &packages.Package{PkgPath: "example", Syntax: []*ast.File{f}}
+	return getDocComments(map[string]*packages.Package{"example": pkg})
+}
+
+func TestGetDocComments(t *testing.T) {
+	docs := parseTestDocs(t, testSource)
+
+	expected := "Says hello to someone.\n\n  - other: not a parameter, so it stays in the text"
+	if text := docs.forFunction("example", "SayHello"); text != expected {
+		t.Errorf("Expected function docs %q, but received %q", expected, text)
+	}
+	if text := docs.forParameter("example", "SayHello", "name"); text != "the name of the person to greet" {
+		t.Errorf("Unexpected docs for parameter name: %q", text)
+	}
+	if text := docs.forParameter("example", "SayHello", "loud"); text != "" {
+		t.Errorf("Expected no docs for parameter loud, but received %q", text)
+	}
+
+	if text := docs.forType("example.Person"); text != "A person." {
+		t.Errorf("Unexpected docs for type Person: %q", text)
+	}
+	if text := docs.forField("example.Person", "Name"); text != "The person's name." {
+		t.Errorf("Unexpected docs for field Name: %q", text)
+	}
+	if text := docs.forField("example.Person", "Age"); text != "The person's age, in years." {
+		t.Errorf("Unexpected docs for field Age: %q", text)
+	}
+	if text := docs.forType("example.Place"); text != "A place." {
+		t.Errorf("Unexpected docs for type Place: %q", text)
+	}
+	if text := docs.forType("example.Unknown"); text != "" {
+		t.Errorf("Expected no docs for type Unknown, but received %q", text)
+	}
+	if text := docs.forFunction("example", "Greet"); text != "" {
+		t.Errorf("Expected no docs for method Greet, but received %q", text)
+	}
+}
+
+func TestGetDirectives(t *testing.T) {
+	docs := parseTestDocs(t, testSource)
+
+	directives := docs.directivesForFunction("example", "DeleteTeam")
+	if len(directives) != 3 {
+		t.Fatalf("Expected 3 directives, but received %d", len(directives))
+	}
+
+	expected := []struct {
+		name string
+		args map[string]string
+	}{
+		{"auth", map[string]string{"claim": "role", "value": "team admin"}},
+		{"cache", nil},
+		{"note", map[string]string{"text": `say "hi"`, "other": "a=b"}},
+	}
+	for i, e := range expected {
+		d := directives[i]
+		if d.Name != e.name {
+			t.Errorf("Expected directive %s, but received %s", e.name, d.Name)
+		}
+		if !reflect.DeepEqual(d.Args, e.args) {
+			t.Errorf("Expected arguments %v for directive %s, but received %v", e.args, e.name, d.Args)
+		}
+	}
+
+	if text := docs.forFunction("example", "DeleteTeam"); text != "Deletes a team." {
+		t.Errorf("Unexpected docs for function DeleteTeam: %q", text)
+	}
+
+	directives = docs.directivesForFunction("example", "SayHello")
+	if len(directives) != 1 || directives[0].Name != "cache" || directives[0].Args["ttl"] != "60" {
+		t.Errorf("Unexpected directives for function SayHello: %v", directives)
+	}
+}
@@ -12,6 +12,7 @@ package extractor
 import (
 	"go/types"
 	"sort"
+	"strings"
 
 	"github.com/hypermodeinc/modus/sdk/go/tools/modus-go-build/config"
 	"github.com/hypermodeinc/modus/sdk/go/tools/modus-go-build/metadata"
@@ -26,10 +27,18 @@ func CollectProgramInfo(config *config.Config, meta *metadata.Metadata, wasmFunc
 	}
 
 	requiredTypes := make(map[string]types.Type)
+	docs := getDocComments(pkgs)
 
 	for name, f := range getExportedFunctions(pkgs) {
 		if _, ok := wasmFunctions.Exports[name]; ok {
-			meta.FnExports[name] = transformFunc(name, f)
+			fn := transformFunc(name, f)
+			fnName := strings.TrimPrefix(f.Name(), "__hyp_")
+			fn.Docs = docs.forFunction(f.Pkg().Path(), fnName)
+			for _, p := range fn.Parameters {
+				p.Docs = docs.forParameter(f.Pkg().Path(), fnName, p.Name)
+			}
+			fn.Directives = docs.directivesForFunction(f.Pkg().Path(), fnName)
+			meta.FnExports[name] = fn
 			findRequiredTypes(f, requiredTypes)
 		}
 	}
@@ -57,6 +66,10 @@ func CollectProgramInfo(config *config.Config, meta *metadata.Metadata, wasmFunc
 		if s, ok := t.(*types.Struct); ok && !wellKnownTypes[name] {
 			t := transformStruct(name, s)
 			t.Id = id
+			t.Docs = docs.forType(name)
+			for i, f := range t.Fields {
+				f.Docs = docs.forField(name, s.Field(i).Name())
+			}
 			meta.Types[name] = t
 		} else {
 			meta.Types[name] = &metadata.TypeDefinition{
@@ -21,38 +21,52 @@ import (
 
 const MetadataVersion = 2
 
+// AbiVersion is the version of the interface between plugins built with this SDK and the runtime,
+// which the runtime checks when loading a plugin.
+const AbiVersion = 1
+
 type TypeMap map[string]*TypeDefinition
 type FunctionMap map[string]*Function
 
 type Metadata struct {
-	Plugin    string      `json:"plugin"`
-	Module    string      `json:"module"`
-	SDK       string      `json:"sdk"`
-	BuildId   string      `json:"buildId"`
-	BuildTime string      `json:"buildTs"`
-	GitRepo   string      `json:"gitRepo,omitempty"`
-	GitCommit string      `json:"gitCommit,omitempty"`
-	FnExports FunctionMap `json:"fnExports,omitempty"`
-	FnImports FunctionMap `json:"fnImports,omitempty"`
-	Types     TypeMap     `json:"types,omitempty"`
+	Plugin     string      `json:"plugin"`
+	Module     string      `json:"module"`
+	SDK        string      `json:"sdk"`
+	AbiVersion int         `json:"abiVersion"`
+	BuildId    string      `json:"buildId"`
+	BuildTime  string      `json:"buildTs"`
+	GitRepo    string      `json:"gitRepo,omitempty"`
+	GitCommit  string      `json:"gitCommit,omitempty"`
+	FnExports  FunctionMap `json:"fnExports,omitempty"`
+	FnImports  FunctionMap `json:"fnImports,omitempty"`
+	Types      TypeMap     `json:"types,omitempty"`
 }
 
 type Function struct {
 	Name       string       `json:"-"`
 	Parameters []*Parameter `json:"parameters,omitempty"`
 	Results    []*Result    `json:"results,omitempty"`
+	Docs       string       `json:"docs,omitempty"`
+	Directives []*Directive `json:"directives,omitempty"`
+}
+
+type Directive struct {
+	Name string            `json:"name"`
+	Args map[string]string `json:"args,omitempty"`
 }
 
 type TypeDefinition struct {
 	Id     uint32   `json:"id"`
 	Name   string   `json:"-"`
 	Fields []*Field `json:"fields,omitempty"`
+	Docs   string   `json:"docs,omitempty"`
 }
 
 type Parameter struct {
 	Name     string `json:"name"`
 	Type     string `json:"type"`
 	Default  *any   `json:"default,omitempty"`
+	Docs     string `json:"docs,omitempty"`
 	Optional bool   `json:"-"` // deprecated
 }
 
@@ -64,15 +78,17 @@ type Result struct {
 type Field struct {
 	Name string `json:"name"`
 	Type string `json:"type"`
+	Docs string `json:"docs,omitempty"`
 }
 
 func NewMetadata() *Metadata {
 	return &Metadata{
-		BuildId:   xid.New().String(),
-		BuildTime: utils.TimeNow(),
-		FnExports: make(FunctionMap),
-		FnImports: make(FunctionMap),
-		Types:     make(TypeMap),
+		AbiVersion: AbiVersion,
+		BuildId:    xid.New().String(),
+		BuildTime:  utils.TimeNow(),
+		FnExports:  make(FunctionMap),
+		FnImports:  make(FunctionMap),
+		Types:      make(TypeMap),
 	}
 }
 